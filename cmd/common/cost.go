@@ -0,0 +1,217 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime"
+)
+
+// ConfirmCostNever disables the confirmation of the expensive gadgets
+const ConfirmCostNever = "never"
+
+// costScope describes the nodes and the containers traced according to the
+// flags of the command. narrowed is true when only some pods or containers
+// are traced.
+func costScope(cmd *cobra.Command) (scope string, narrowed bool) {
+	value := func(name string) string {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			return ""
+		}
+		// The string slices are shown as [], like the empty ones
+		return strings.Trim(flag.Value.String(), "[]")
+	}
+
+	var parts []string
+	if node := value("node"); node != "" {
+		parts = append(parts, fmt.Sprintf("node %q", node))
+	} else if cmd.Flags().Lookup("node") != nil {
+		parts = append(parts, "all nodes")
+	}
+
+	if cmd.Flags().Lookup("namespace") != nil {
+		if value("all-namespaces") == "true" {
+			parts = append(parts, "all namespaces")
+		} else {
+			parts = append(parts, fmt.Sprintf("namespace %q", value("namespace")))
+		}
+	}
+
+	for _, f := range []struct {
+		name string
+		desc string
+	}{
+		{"podname", "pod"},
+		{"selector", "labels"},
+		{"namespace-selector", "namespace labels"},
+		{"containername", "container"},
+	} {
+		if v := value(f.name); v != "" {
+			parts = append(parts, fmt.Sprintf("%s %q", f.desc, v))
+			narrowed = true
+		}
+	}
+	if !narrowed {
+		parts = append(parts, "all containers")
+	}
+
+	return strings.Join(parts, ", "), narrowed
+}
+
+// effectiveCost returns the cost class of running the gadget, one class
+// lower when only some pods or containers are traced
+func effectiveCost(cost gadgets.Cost, narrowed bool) gadgets.CostClass {
+	if !narrowed {
+		return cost.EventCost
+	}
+	switch cost.EventCost {
+	case gadgets.CostHigh:
+		return gadgets.CostMedium
+	case gadgets.CostMedium:
+		return gadgets.CostLow
+	}
+	return cost.EventCost
+}
+
+// countTargets returns the number of nodes and containers the gadget would
+// trace, as resolved by the runtime, or why they are unknown. Only the
+// operators counting the containers are initialized, the others could already
+// connect to sinks, listen, etc.
+func countTargets(rt runtime.Runtime, runtimeGlobalParams *params.Params, gadgetCtx *gadgetcontext.GadgetContext, operatorsGlobalParamsCollection params.Collection) string {
+	counter, ok := rt.(runtime.TargetCounter)
+	if !ok {
+		return "unknown"
+	}
+
+	if err := rt.Init(runtimeGlobalParams); err != nil {
+		return fmt.Sprintf("unknown, initializing runtime: %v", err)
+	}
+	defer rt.Close()
+
+	var counters operators.Operators
+	for _, operator := range gadgetCtx.Operators() {
+		if _, ok := operators.Unwrap(operator).(operators.ContainerCounter); ok {
+			counters = append(counters, operator)
+		}
+	}
+	if err := counters.Init(operatorsGlobalParamsCollection); err != nil {
+		return fmt.Sprintf("unknown, %v", err)
+	}
+	defer counters.Close()
+	gadgetCtx.SetOperators(counters)
+
+	nodes, containers, err := counter.CountTargets(gadgetCtx)
+	if err != nil {
+		return fmt.Sprintf("unknown, %v", err)
+	}
+	return fmt.Sprintf("%s, %s", plural(nodes, "node"), plural(containers, "container"))
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// formatCost describes the cost of running the gadget. targets is the number
+// of nodes and containers it would trace, see countTargets, it's omitted when
+// empty.
+func formatCost(gadgetDesc gadgets.GadgetDesc, cmd *cobra.Command, targets string) string {
+	var out strings.Builder
+	scope, narrowed := costScope(cmd)
+
+	fmt.Fprintf(&out, "Gadget:  %s\n", profileGadgetName(gadgetDesc))
+	coster, ok := gadgetDesc.(gadgets.GadgetDescCost)
+	if !ok {
+		fmt.Fprintf(&out, "Cost:    unknown\n")
+		fmt.Fprintf(&out, "Scope:   %s\n", scope)
+		writeTargets(&out, targets)
+		return out.String()
+	}
+
+	cost := coster.Cost()
+	fmt.Fprintf(&out, "Probes:  %d, tracing %s\n", cost.Probes, cost.Events)
+	fmt.Fprintf(&out, "Cost:    %s per event", cost.EventCost)
+	if c := effectiveCost(cost, narrowed); c != cost.EventCost {
+		fmt.Fprintf(&out, ", %s for the selected containers", c)
+	}
+	fmt.Fprintf(&out, "\n")
+	if cost.BufferSize > 0 {
+		fmt.Fprintf(&out, "Buffers: %d KiB per CPU on each node\n", cost.BufferSize/1024)
+	}
+	fmt.Fprintf(&out, "Scope:   %s\n", scope)
+	writeTargets(&out, targets)
+	return out.String()
+}
+
+func writeTargets(out io.Writer, targets string) {
+	if targets != "" {
+		fmt.Fprintf(out, "Targets: %s\n", targets)
+	}
+}
+
+// confirmCost asks for a confirmation before running a gadget whose cost is
+// at least the threshold. It returns an error when the user doesn't confirm
+// or can't be asked.
+func confirmCost(gadgetDesc gadgets.GadgetDesc, cmd *cobra.Command, threshold string, in *os.File, out io.Writer) error {
+	if threshold == ConfirmCostNever {
+		return nil
+	}
+	thresholdLevel := gadgets.CostClass(threshold).Level()
+	if thresholdLevel == 0 {
+		return fmt.Errorf("invalid cost %q: expected %s, %s, %s or %s",
+			threshold, gadgets.CostLow, gadgets.CostMedium, gadgets.CostHigh, ConfirmCostNever)
+	}
+
+	coster, ok := gadgetDesc.(gadgets.GadgetDescCost)
+	if !ok {
+		return nil
+	}
+	_, narrowed := costScope(cmd)
+	cost := effectiveCost(coster.Cost(), narrowed)
+	if cost.Level() < thresholdLevel {
+		return nil
+	}
+
+	if !term.IsTerminal(int(in.Fd())) {
+		return fmt.Errorf("the cost of the gadget is %s, run it with --yes to confirm or with --dry-run to see the details", cost)
+	}
+
+	fmt.Fprintf(out, "%s\nThe cost of the gadget is %s. Run it? [y/N] ", formatCost(gadgetDesc, cmd, ""), cost)
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("reading answer: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return errors.New("aborted")
+}
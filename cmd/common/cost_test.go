@@ -0,0 +1,203 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime"
+)
+
+func TestCostScope(t *testing.T) {
+	t.Parallel()
+
+	newCmd := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{}
+		cmd.Flags().String("node", "", "")
+		cmd.Flags().String("namespace", "default", "")
+		cmd.Flags().Bool("all-namespaces", false, "")
+		cmd.Flags().String("podname", "", "")
+		cmd.Flags().StringSlice("selector", []string{}, "")
+		cmd.Flags().String("containername", "", "")
+		require.Nil(t, cmd.ParseFlags(args))
+		return cmd
+	}
+
+	scope, narrowed := costScope(newCmd("--all-namespaces"))
+	require.Equal(t, "all nodes, all namespaces, all containers", scope)
+	require.False(t, narrowed)
+
+	scope, narrowed = costScope(newCmd("--node", "minikube", "--podname", "nginx"))
+	require.Equal(t, `node "minikube", namespace "default", pod "nginx"`, scope)
+	require.True(t, narrowed)
+
+	// The flags of ig
+	scope, narrowed = costScope(&cobra.Command{})
+	require.Equal(t, "all containers", scope)
+	require.False(t, narrowed)
+
+	cost := gadgets.Cost{EventCost: gadgets.CostHigh}
+	require.Equal(t, gadgets.CostHigh, effectiveCost(cost, false))
+	require.Equal(t, gadgets.CostMedium, effectiveCost(cost, true))
+}
+
+type testCostGadget struct {
+	gadgets.GadgetDesc
+	cost gadgets.Cost
+}
+
+func (g *testCostGadget) Name() string {
+	return "test"
+}
+
+func (g *testCostGadget) Category() string {
+	return gadgets.CategoryTrace
+}
+
+func (g *testCostGadget) Cost() gadgets.Cost {
+	return g.cost
+}
+
+func TestConfirmCostNonInteractive(t *testing.T) {
+	t.Parallel()
+
+	// A pipe isn't a terminal, like the standard input of a script
+	in, w, err := os.Pipe()
+	require.Nil(t, err)
+	defer in.Close()
+	defer w.Close()
+
+	gadget := &testCostGadget{cost: gadgets.Cost{EventCost: gadgets.CostHigh}}
+
+	var out bytes.Buffer
+	err = confirmCost(gadget, &cobra.Command{}, string(gadgets.CostHigh), in, &out)
+	require.EqualError(t, err, "the cost of the gadget is high, run it with --yes to confirm or with --dry-run to see the details")
+	require.Empty(t, out.String())
+
+	// Narrowing the scope lowers the cost below the threshold
+	cmd := &cobra.Command{}
+	cmd.Flags().String("podname", "", "")
+	require.Nil(t, cmd.ParseFlags([]string{"--podname", "nginx"}))
+	require.Nil(t, confirmCost(gadget, cmd, string(gadgets.CostHigh), in, &out))
+
+	out.Reset()
+	require.Nil(t, confirmCost(gadget, &cobra.Command{}, ConfirmCostNever, in, &out))
+	require.Empty(t, out.String())
+
+	require.Error(t, confirmCost(gadget, &cobra.Command{}, "huge", in, &out))
+}
+
+type testTargetRuntime struct {
+	runtime.Runtime
+	initErr error
+	closed  bool
+}
+
+func (r *testTargetRuntime) Init(*params.Params) error {
+	return r.initErr
+}
+
+func (r *testTargetRuntime) Close() error {
+	r.closed = true
+	return nil
+}
+
+func (r *testTargetRuntime) CountTargets(gadgetCtx runtime.GadgetContext) (int, int, error) {
+	containers := 0
+	for _, operator := range gadgetCtx.Operators() {
+		n, err := operator.(operators.ContainerCounter).CountContainers(nil)
+		if err != nil {
+			return 0, 0, err
+		}
+		containers += n
+	}
+	return 1, containers, nil
+}
+
+type testCounterOperator struct {
+	operators.Operator
+	initialized bool
+}
+
+func (o *testCounterOperator) Name() string {
+	return "TestCounter"
+}
+
+func (o *testCounterOperator) Init(*params.Params) error {
+	o.initialized = true
+	return nil
+}
+
+func (o *testCounterOperator) Close() error {
+	return nil
+}
+
+func (o *testCounterOperator) CountContainers(*params.Params) (int, error) {
+	if !o.initialized {
+		return 0, errors.New("not initialized")
+	}
+	return 3, nil
+}
+
+// testOperator fails the test if it's initialized by the dry run
+type testOperator struct {
+	operators.Operator
+	t *testing.T
+}
+
+func (o *testOperator) Name() string {
+	return "Test"
+}
+
+func (o *testOperator) Init(*params.Params) error {
+	o.t.Error("operator not counting containers initialized")
+	return nil
+}
+
+func TestCountTargets(t *testing.T) {
+	t.Parallel()
+
+	gadget := &testCostGadget{cost: gadgets.Cost{EventCost: gadgets.CostHigh}}
+	newGadgetCtx := func(rt runtime.Runtime) *gadgetcontext.GadgetContext {
+		gadgetCtx := gadgetcontext.New(context.Background(), "", rt, nil, gadget, nil, nil, nil, nil, 0)
+		gadgetCtx.SetOperators(operators.Operators{&testOperator{t: t}, &testCounterOperator{}})
+		return gadgetCtx
+	}
+
+	rt := &testTargetRuntime{}
+	require.Equal(t, "1 node, 3 containers", countTargets(rt, nil, newGadgetCtx(rt), nil))
+	require.True(t, rt.closed)
+
+	rt = &testTargetRuntime{initErr: errors.New("must be run as root")}
+	require.Equal(t, "unknown, initializing runtime: must be run as root", countTargets(rt, nil, newGadgetCtx(rt), nil))
+
+	// The runtime doesn't know how to count them
+	require.Equal(t, "unknown", countTargets(struct{ runtime.Runtime }{}, nil, newGadgetCtx(nil), nil))
+
+	out := formatCost(gadget, &cobra.Command{}, "1 node, 3 containers")
+	require.Contains(t, out, "Targets: 1 node, 3 containers\n")
+	require.NotContains(t, formatCost(gadget, &cobra.Command{}, ""), "Targets:")
+}
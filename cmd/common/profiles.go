@@ -0,0 +1,185 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	k8syaml "sigs.k8s.io/yaml"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+)
+
+// ProfileValues are the values of a flag in a profile. It's a string or, for
+// the flags that can be given several times like --filter, a list of strings.
+type ProfileValues []string
+
+func (v *ProfileValues) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err == nil {
+		*v = ProfileValues{value}
+		return nil
+	}
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return errors.New("expected a string or a list of strings")
+	}
+	*v = values
+	return nil
+}
+
+// Profile is a named set of flags, e.g. the filters to ignore the noise of a
+// node, to run the gadgets the same way across a team
+type Profile struct {
+	Description string `json:"description,omitempty"`
+	// Flags are given to all the gadgets having them and ignored by the others
+	Flags map[string]ProfileValues `json:"flags,omitempty"`
+	// Gadgets are the flags of a single gadget, by category and name like
+	// "trace exec". They take precedence over Flags.
+	Gadgets map[string]map[string]ProfileValues `json:"gadgets,omitempty"`
+}
+
+// Config is the content of the configuration file
+type Config struct {
+	Profiles map[string]*Profile `json:"profiles,omitempty"`
+}
+
+// ProfileLoader returns the profiles of a source, e.g. a ConfigMap. The
+// profiles of the later loaders replace the ones with the same name.
+type ProfileLoader func() (map[string]*Profile, error)
+
+// ParseConfig parses a configuration in YAML or JSON
+func ParseConfig(data []byte) (*Config, error) {
+	config := &Config{}
+	if err := k8syaml.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func getConfigFilename() (string, error) {
+	homedir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home dir: %w", err)
+	}
+	return filepath.Join(homedir, ".ig", "config.yaml"), nil
+}
+
+// LoadConfigFileProfiles returns the profiles of ~/.ig/config.yaml, if it
+// exists
+func LoadConfigFileProfiles() (map[string]*Profile, error) {
+	configFile, err := getConfigFilename()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	config, err := ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing config file %q: %w", configFile, err)
+	}
+	return config.Profiles, nil
+}
+
+func loadProfile(name string, loaders []ProfileLoader) (*Profile, error) {
+	profiles := make(map[string]*Profile)
+	for _, loader := range loaders {
+		loaded, err := loader()
+		if err != nil {
+			return nil, fmt.Errorf("loading profiles: %w", err)
+		}
+		for k, v := range loaded {
+			profiles[k] = v
+		}
+	}
+
+	profile, ok := profiles[name]
+	if !ok || profile == nil {
+		names := make([]string, 0, len(profiles))
+		for k := range profiles {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("profile %q not found: no profiles defined", name)
+		}
+		return nil, fmt.Errorf("profile %q not found, available profiles: %s", name, strings.Join(names, ", "))
+	}
+	return profile, nil
+}
+
+// profileGadgetName returns the name of a gadget in Profile.Gadgets, as typed
+// on the command line
+func profileGadgetName(gadgetDesc gadgets.GadgetDesc) string {
+	if gadgetDesc.Category() == gadgets.CategoryNone {
+		return gadgetDesc.Name()
+	}
+	return gadgetDesc.Category() + " " + gadgetDesc.Name()
+}
+
+// apply sets the flags of the profile on the command of a gadget. The flags
+// given on the command line are kept as they are.
+func (p *Profile) apply(cmd *cobra.Command, gadget string) error {
+	set := func(name string, values ProfileValues) error {
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil {
+			return fmt.Errorf("unknown flag %q", name)
+		}
+		if flag.Changed {
+			return nil
+		}
+		// The first value replaces the default of the flags taking several
+		// values, the next ones are appended
+		for _, value := range values {
+			if err := cmd.Flags().Set(name, value); err != nil {
+				return fmt.Errorf("setting flag %q to %q: %w", name, value, err)
+			}
+		}
+		return nil
+	}
+
+	// Set the flags of the gadget first, so they aren't overridden by the
+	// general ones
+	for name, values := range p.Gadgets[gadget] {
+		if err := set(name, values); err != nil {
+			return fmt.Errorf("flags of %q: %w", gadget, err)
+		}
+	}
+	for name, values := range p.Flags {
+		if cmd.Flags().Lookup(name) == nil {
+			log.Debugf("flag %q of profile not supported by %q, ignoring", name, gadget)
+			continue
+		}
+		if err := set(name, values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
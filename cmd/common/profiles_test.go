@@ -0,0 +1,81 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+profiles:
+  noisy-node:
+    description: Ignore the system daemons
+    flags:
+      filter:
+      - comm:!kubelet
+      - comm:!containerd
+      timeout: "10"
+      unknown: "ignored"
+    gadgets:
+      trace exec:
+        timeout: "30"
+`
+
+func TestProfiles(t *testing.T) {
+	t.Parallel()
+
+	config, err := ParseConfig([]byte(testConfig))
+	require.Nil(t, err)
+
+	profile, err := loadProfile("noisy-node", []ProfileLoader{
+		func() (map[string]*Profile, error) { return config.Profiles, nil },
+	})
+	require.Nil(t, err)
+	require.Equal(t, ProfileValues{"comm:!kubelet", "comm:!containerd"}, profile.Flags["filter"])
+
+	_, err = loadProfile("other", []ProfileLoader{
+		func() (map[string]*Profile, error) { return config.Profiles, nil },
+	})
+	require.ErrorContains(t, err, "available profiles: noisy-node")
+
+	newCmd := func() (*cobra.Command, *[]string, *int) {
+		var filters []string
+		var timeout int
+		cmd := &cobra.Command{}
+		cmd.Flags().StringSliceVarP(&filters, "filter", "F", []string{}, "")
+		cmd.Flags().IntVarP(&timeout, "timeout", "t", 0, "")
+		return cmd, &filters, &timeout
+	}
+
+	cmd, filters, timeout := newCmd()
+	require.Nil(t, profile.apply(cmd, "trace open"))
+	require.Equal(t, []string{"comm:!kubelet", "comm:!containerd"}, *filters)
+	require.Equal(t, 10, *timeout)
+
+	// The flags of the gadget take precedence over the general ones
+	cmd, _, timeout = newCmd()
+	require.Nil(t, profile.apply(cmd, "trace exec"))
+	require.Equal(t, 30, *timeout)
+
+	// The flags of the command line take precedence over the profile
+	cmd, filters, timeout = newCmd()
+	require.Nil(t, cmd.ParseFlags([]string{"-F", "comm:bash", "-t", "5"}))
+	require.Nil(t, profile.apply(cmd, "trace exec"))
+	require.Equal(t, []string{"comm:bash"}, *filters)
+	require.Equal(t, 5, *timeout)
+}
@@ -16,19 +16,23 @@ package common
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 	k8syaml "sigs.k8s.io/yaml"
 
 	"github.com/inspektor-gadget/inspektor-gadget/cmd/common/frontends"
 	"github.com/inspektor-gadget/inspektor-gadget/cmd/common/frontends/console"
 	cols "github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/formatter/textcolumns"
 	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
 	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
@@ -43,10 +47,15 @@ const (
 	OutputModeJSON       = "json"
 	OutputModeJSONPretty = "jsonpretty"
 	OutputModeYAML       = "yaml"
+
+	// OutputModeJSONPrettyLine is prettified JSON that keeps one event per
+	// line, for the consumers reading the output line by line
+	OutputModeJSONPrettyLine = "json-pretty"
 )
 
-// AddCommandsFromRegistry adds all gadgets known by the registry as cobra commands as a subcommand to their categories
-func AddCommandsFromRegistry(rootCmd *cobra.Command, runtime runtime.Runtime, columnFilters []cols.ColumnFilter) {
+// AddCommandsFromRegistry adds all gadgets known by the registry as cobra commands as a subcommand to their categories.
+// The profiles selected with --profile are looked up in the given loaders, then in the configuration file.
+func AddCommandsFromRegistry(rootCmd *cobra.Command, runtime runtime.Runtime, columnFilters []cols.ColumnFilter, profileLoaders ...ProfileLoader) {
 	runtimeGlobalParams := runtime.GlobalParamDescs().ToParams()
 
 	// Build lookup
@@ -99,6 +108,7 @@ func AddCommandsFromRegistry(rootCmd *cobra.Command, runtime runtime.Runtime, co
 			runtimeGlobalParams,
 			operatorsGlobalParamsCollection,
 			gadgetInfo.OperatorParamsCollection.ToParams(),
+			append(profileLoaders, LoadConfigFileProfiles),
 		))
 	}
 }
@@ -130,9 +140,15 @@ func buildCommandFromGadget(
 	runtimeGlobalParams *params.Params,
 	operatorsGlobalParamsCollection params.Collection,
 	operatorsParamsCollection params.Collection,
+	profileLoaders []ProfileLoader,
 ) *cobra.Command {
 	var outputMode string
+	var profile string
+	var dryRun bool
+	var yes bool
+	var confirmCostThreshold string
 	var verbose bool
+	var raw bool
 	var filters []string
 	var timeout int
 
@@ -167,12 +183,44 @@ func buildCommandFromGadget(
 		Short:        gadgetDesc.Description(),
 		SilenceUsage: true, // do not print usage when there is an error
 		PreRunE: func(cmd *cobra.Command, args []string) error {
+			if profile != "" {
+				p, err := loadProfile(profile, profileLoaders)
+				if err != nil {
+					return err
+				}
+				if err := p.apply(cmd, profileGadgetName(gadgetDesc)); err != nil {
+					return fmt.Errorf("applying profile %q: %w", profile, err)
+				}
+			}
 			if verbose {
 				log.SetLevel(log.DebugLevel)
 			}
 			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
+			if dryRun {
+				gadgetCtx := gadgetcontext.New(
+					context.Background(),
+					"",
+					runtime,
+					runtimeParams,
+					gadgetDesc,
+					gadgetParams,
+					operatorsParamsCollection,
+					parser,
+					logger.DefaultLogger(),
+					0,
+				)
+				targets := countTargets(runtime, runtimeGlobalParams, gadgetCtx, operatorsGlobalParamsCollection)
+				fmt.Print(formatCost(gadgetDesc, cmd, targets))
+				return nil
+			}
+			if !yes {
+				if err := confirmCost(gadgetDesc, cmd, confirmCostThreshold, os.Stdin, os.Stderr); err != nil {
+					return err
+				}
+			}
+
 			err := runtime.Init(runtimeGlobalParams)
 			if err != nil {
 				return fmt.Errorf("initializing runtime: %w", err)
@@ -309,7 +357,7 @@ func buildCommandFromGadget(
 				}
 			}
 
-			formatter := parser.GetTextColumnsFormatter()
+			formatter := parser.GetTextColumnsFormatter(textcolumns.WithRaw(raw))
 
 			requestedStandardColumns := outputModeParams == ""
 			requestedColumns := strings.Split(outputModeParams, ",")
@@ -332,6 +380,21 @@ func buildCommandFromGadget(
 
 			valid, invalid := parser.VerifyColumnNames(requestedColumns)
 
+			// Enable the operators filling the requested or filtered columns
+			neededColumns := append([]string{}, valid...)
+			if outputModeName != OutputModeColumns {
+				neededColumns = nil
+				for _, attrs := range parser.GetColumnAttributes() {
+					neededColumns = append(neededColumns, attrs.Name)
+				}
+			}
+			for _, filter := range filters {
+				neededColumns = append(neededColumns, strings.SplitN(filter, ":", 2)[0])
+			}
+			if err := enableColumnParams(parser.GetColumnAttributes(), operatorsParamsCollection, neededColumns); err != nil {
+				return err
+			}
+
 			for _, c := range invalid {
 				log.Warnf("column %q not found", c)
 			}
@@ -376,8 +439,15 @@ func buildCommandFromGadget(
 				//  TODO: This can be optimized later on
 				formatter.SetEnableExtraLines(true)
 
+				// Highlight the warnings and alerts, unless the output is
+				// read by a program or the user opted out
+				if !raw && os.Getenv("NO_COLOR") == "" && term.IsTerminal(int(os.Stdout.Fd())) {
+					formatter.SetEnableColors(true)
+				}
+
 				parser.SetEventCallback(formatter.EventHandlerFunc())
-				if gadgetDesc.Type().IsPeriodic() {
+				// The raw output is for scripts, the screen isn't cleared and the header is only printed once
+				if gadgetDesc.Type().IsPeriodic() && !raw {
 					// In case of periodic outputting gadgets, this is done as full table output, and we need to
 					// clear the screen for every interval, that's why we add fe.Clear here
 					parser.SetEventCallback(formatter.EventHandlerFuncArray(
@@ -398,6 +468,8 @@ func buildCommandFromGadget(
 				parser.SetEventCallback(printEventAsJSONFn(fe))
 			case OutputModeJSONPretty:
 				parser.SetEventCallback(printEventAsJSONPrettyFn(fe))
+			case OutputModeJSONPrettyLine:
+				parser.SetEventCallback(printEventAsJSONPrettyLineFn(fe))
 			case OutputModeYAML:
 				parser.SetEventCallback(printEventAsYAMLFn(fe))
 			}
@@ -431,6 +503,35 @@ func buildCommandFromGadget(
 		"Print debug information",
 	)
 
+	cmd.PersistentFlags().StringVar(
+		&profile,
+		"profile",
+		"",
+		"Name of the profile whose flags are used, the flags given on the command line take precedence",
+	)
+
+	cmd.PersistentFlags().BoolVar(
+		&dryRun,
+		"dry-run",
+		false,
+		"Print the expected overhead of the gadget (probes, cost per event, buffers, traced containers) without running it",
+	)
+
+	cmd.PersistentFlags().BoolVarP(
+		&yes,
+		"yes", "y",
+		false,
+		"Run the gadget without asking for a confirmation when its cost is high",
+	)
+
+	cmd.PersistentFlags().StringVar(
+		&confirmCostThreshold,
+		"confirm-cost",
+		string(gadgets.CostHigh),
+		fmt.Sprintf("Ask for a confirmation before running the gadgets with at least this cost (%s, %s, %s or %s)",
+			gadgets.CostLow, gadgets.CostMedium, gadgets.CostHigh, ConfirmCostNever),
+	)
+
 	outputFormats.Append(gadgets.OutputFormats{
 		"json": {
 			Name:        "JSON",
@@ -489,6 +590,13 @@ func buildCommandFromGadget(
 
 		outputFormats.Append(gadgets.OutputFormats{OutputModeColumns: of})
 
+		cmd.PersistentFlags().BoolVar(
+			&raw,
+			"raw",
+			false,
+			"Print the columns as they are, for scripts: separated by tabs, without padding, truncation nor human readable units",
+		)
+
 		cmd.PersistentFlags().StringSliceVarP(
 			&filters,
 			"filter", "F",
@@ -503,6 +611,7 @@ func buildCommandFromGadget(
     columnName:<value      - matches, if the content of columnName is less than the value
     columnName:~value      - matches, if the content of columnName matches the regular expression 'value'
                              see [https://github.com/google/re2/wiki/Syntax] for more information on the syntax
+  The columns showing a symbolic name, like a TCP state or a syscall, can also be matched by its number (e.g. state:1)
 `,
 		)
 
@@ -513,6 +622,13 @@ func buildCommandFromGadget(
 				Transform:   nil,
 			},
 		})
+		outputFormats.Append(gadgets.OutputFormats{
+			OutputModeJSONPrettyLine: {
+				Name:        "JSON Prettified, one line per event",
+				Description: "The output of the gadget is returned as JSON with spaces between the fields, each event on a single line",
+				Transform:   nil,
+			},
+		})
 		outputFormats.Append(gadgets.OutputFormats{
 			OutputModeYAML: {
 				Name:        "YAML",
@@ -623,6 +739,40 @@ func printEventAsJSONPrettyFn(fe frontends.Frontend) func(ev any) {
 	}
 }
 
+func printEventAsJSONPrettyLineFn(fe frontends.Frontend) func(ev any) {
+	return func(ev any) {
+		d, err := json.Marshal(ev)
+		if err != nil {
+			fe.Logf(logger.WarnLevel, "marshaling %+v: %s", ev, err)
+			return
+		}
+		fe.Output(string(spaceJSON(d)))
+	}
+}
+
+// spaceJSON adds a space after the colons and the commas separating the
+// fields and the elements of a compact JSON document. The newlines in the
+// strings are escaped by the encoder, so the output stays on a single line.
+func spaceJSON(compact []byte) []byte {
+	out := make([]byte, 0, len(compact)+len(compact)/8)
+	inString := false
+	escaped := false
+	for _, c := range compact {
+		out = append(out, c)
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case !inString && (c == ':' || c == ','):
+			out = append(out, ' ')
+		}
+	}
+	return out
+}
+
 func printEventAsYAMLFn(fe frontends.Frontend) func(ev any) {
 	return func(ev any) {
 		d, err := k8syaml.Marshal(ev)
@@ -633,3 +783,36 @@ func printEventAsYAMLFn(fe frontends.Frontend) func(ev any) {
 		fe.Output("---\n" + string(d))
 	}
 }
+
+// enableColumnParams sets the bool operator params the given columns are
+// tagged with ("param:<key>"): the operators only fill these columns when
+// they're requested.
+func enableColumnParams(columns []cols.Attributes, operatorsParamsCollection params.Collection, columnNames []string) error {
+	needed := make(map[string]struct{}, len(columnNames))
+	for _, name := range columnNames {
+		needed[strings.ToLower(name)] = struct{}{}
+	}
+
+	for _, attrs := range columns {
+		if _, ok := needed[strings.ToLower(attrs.Name)]; !ok {
+			continue
+		}
+		for _, tag := range attrs.Tags {
+			if !strings.HasPrefix(tag, "param:") {
+				continue
+			}
+			key := strings.TrimPrefix(tag, "param:")
+			for _, operatorParams := range operatorsParamsCollection {
+				for _, p := range *operatorParams {
+					if p.TypeHint != params.TypeBool || strings.ToLower(p.Key) != key {
+						continue
+					}
+					if err := p.Set("true"); err != nil {
+						return fmt.Errorf("enabling %q for column %q: %w", p.Key, attrs.Name, err)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
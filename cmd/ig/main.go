@@ -30,8 +30,23 @@ import (
 	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/all-gadgets"
 	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/traceloop/tracer"
 
-	// Another blank import for the used operator
+	// Other blank imports for the used operators
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/alertmanager"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/azuremonitor"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/cloudlogging"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/cloudwatch"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/fluentforward"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/host"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/join"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/journald"
 	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/localmanager"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/nodemetadata"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/otel"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/s3"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/sbom"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/severity"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/systemd"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/vulnerabilities"
 )
 
 func main() {
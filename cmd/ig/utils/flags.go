@@ -40,6 +40,10 @@ type CommonFlags struct {
 	// The name of the container runtimes to be used separated by comma.
 	Runtimes string
 
+	// ContainerdNamespaces are the containerd namespaces whose containers
+	// are enriched besides the one of Kubernetes
+	ContainerdNamespaces []string
+
 	// RuntimeConfigs contains the list of the container runtimes to be used
 	// with their specific socket path.
 	RuntimeConfigs []*containerutils.RuntimeConfig
@@ -80,10 +84,14 @@ func AddCommonFlags(command *cobra.Command, commonFlags *CommonFlags) {
 				}
 			}
 
-			commonFlags.RuntimeConfigs = append(commonFlags.RuntimeConfigs, &containerutils.RuntimeConfig{
+			runtimeConfig := &containerutils.RuntimeConfig{
 				Name:       runtimeName,
 				SocketPath: socketPath,
-			})
+			}
+			if runtimeName == runtimeclient.ContainerdName {
+				runtimeConfig.Namespaces = commonFlags.ContainerdNamespaces
+			}
+			commonFlags.RuntimeConfigs = append(commonFlags.RuntimeConfigs, runtimeConfig)
 		}
 
 		// Output Mode
@@ -116,6 +124,13 @@ func AddCommonFlags(command *cobra.Command, commonFlags *CommonFlags) {
 			strings.Join(containerutils.AvailableRuntimes, ", ")),
 	)
 
+	command.PersistentFlags().StringSliceVar(
+		&commonFlags.ContainerdNamespaces,
+		"containerd-namespaces",
+		nil,
+		"Comma-separated containerd namespaces whose containers are also enriched besides the k8s.io one of Kubernetes (e.g. default,buildkit), or * for all of them",
+	)
+
 	command.PersistentFlags().IntVar(
 		&commonFlags.Timeout,
 		"timeout",
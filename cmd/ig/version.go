@@ -18,6 +18,8 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/fips"
 )
 
 // This variable is used by the "version" command and is set during build.
@@ -29,6 +31,9 @@ func newVersionCmd() *cobra.Command {
 		Short: "Show version",
 		Run: func(cmd *cobra.Command, args []string) {
 			fmt.Println(version)
+			if fips.Enabled() {
+				fmt.Println("FIPS mode: enabled")
+			}
 		},
 	}
 }
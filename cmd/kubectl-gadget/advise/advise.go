@@ -30,6 +30,7 @@ func NewAdviseCmd() *cobra.Command {
 
 	cmd.AddCommand(newNetworkPolicyCmd())
 	cmd.AddCommand(newSeccompProfileCmd())
+	cmd.AddCommand(newWorkloadProfileCmd())
 
 	return cmd
 }
@@ -0,0 +1,230 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advise
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/kubectl-gadget/utils"
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+)
+
+var workloadProfileStartCmd = &cobra.Command{
+	Use:          "start",
+	Short:        "Start to learn the system calls, capabilities and network peers",
+	RunE:         runWorkloadProfileStart,
+	SilenceUsage: true,
+}
+
+var workloadProfileStopCmd = &cobra.Command{
+	Use:          "stop <trace-id>",
+	Short:        "Stop learning and report the profiles",
+	RunE:         runWorkloadProfileStop,
+	SilenceUsage: true,
+}
+
+var workloadProfileListCmd = &cobra.Command{
+	Use:          "list",
+	Short:        "List existing workload-profile traces",
+	RunE:         runWorkloadProfileList,
+	SilenceUsage: true,
+}
+
+var (
+	workloadProfileOutputMode string
+	workloadProfileName       string
+)
+
+func newWorkloadProfileCmd() *cobra.Command {
+	workloadProfileCmd := &cobra.Command{
+		Use:          "workload-profile",
+		Short:        "Learn the profile of workloads to be enforced by admission controllers",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+	}
+	utils.AddCommonFlags(workloadProfileCmd, &params)
+
+	workloadProfileCmd.AddCommand(workloadProfileStartCmd)
+	workloadProfileStartCmd.PersistentFlags().StringVarP(&workloadProfileOutputMode,
+		"output-mode", "m",
+		"terminal",
+		"The trace output mode, possibles values are terminal and workload-profile.")
+	workloadProfileStartCmd.PersistentFlags().StringVar(&workloadProfileName,
+		"profile-name", "",
+		"Name of the workload profile to be created or updated when using --output-mode=workload-profile.\nNamespace can be specified by using namespace/profile-name.")
+
+	workloadProfileCmd.AddCommand(workloadProfileStopCmd)
+	workloadProfileCmd.AddCommand(workloadProfileListCmd)
+
+	return workloadProfileCmd
+}
+
+func workloadProfileOutputModeToTraceOutputMode(outputMode string) (gadgetv1alpha1.TraceOutputMode, error) {
+	switch outputMode {
+	case "terminal":
+		return gadgetv1alpha1.TraceOutputModeStatus, nil
+	case "workload-profile":
+		return gadgetv1alpha1.TraceOutputModeExternalResource, nil
+	default:
+		return "", fmt.Errorf("%q is not an accepted value for --output-mode, possible values are: terminal (default) and workload-profile", outputMode)
+	}
+}
+
+// runWorkloadProfileStart starts learning the profile of the workload of the
+// given pod.
+func runWorkloadProfileStart(cmd *cobra.Command, args []string) error {
+	if params.Podname == "" {
+		return commonutils.WrapInErrMissingArgs("--podname")
+	}
+
+	traceOutputMode, err := workloadProfileOutputModeToTraceOutputMode(workloadProfileOutputMode)
+	if err != nil {
+		return err
+	}
+
+	if traceOutputMode != gadgetv1alpha1.TraceOutputModeExternalResource && workloadProfileName != "" {
+		return errors.New("you can only use --profile-name with --output-mode workload-profile")
+	}
+
+	config := &utils.TraceConfig{
+		GadgetName:        "workload-profile",
+		Operation:         gadgetv1alpha1.OperationStart,
+		TraceOutputMode:   traceOutputMode,
+		TraceOutput:       workloadProfileName,
+		TraceInitialState: gadgetv1alpha1.TraceStateStarted,
+		CommonFlags:       &params,
+	}
+
+	traceID, err := utils.CreateTrace(config)
+	if err != nil {
+		return commonutils.WrapInErrRunGadget(err)
+	}
+
+	fmt.Printf("%s\n", traceID)
+
+	return nil
+}
+
+// getWorkloadProfilesName returns the name of the workload profiles written by
+// the trace whose ID is given as parameter: they have the trace's ID in their
+// labels.
+func getWorkloadProfilesName(traceID string) ([]string, error) {
+	traceClient, err := utils.GetTraceClient()
+	if err != nil {
+		return nil, err
+	}
+
+	profilesList, err := traceClient.GadgetV1alpha1().WorkloadProfiles("").List(
+		context.TODO(),
+		metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", utils.GlobalTraceID, traceID)},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("listing workload profiles: %w", err)
+	}
+
+	var profilesName []string
+	for _, profile := range profilesList.Items {
+		profilesName = append(profilesName, profile.Namespace+"/"+profile.Name)
+	}
+
+	return profilesName, nil
+}
+
+// runWorkloadProfileStop reports an already running trace which ID was given
+// as parameter.
+func runWorkloadProfileStop(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return commonutils.WrapInErrMissingArgs("<trace-id>")
+	}
+
+	traceID := args[0]
+
+	callback := func(traceOutputMode string, results []string) error {
+		if traceOutputMode == string(gadgetv1alpha1.TraceOutputModeExternalResource) {
+			profilesName, err := getWorkloadProfilesName(traceID)
+			if err != nil {
+				return err
+			}
+
+			profilePlural := ""
+			if len(profilesName) > 1 {
+				profilePlural = "s"
+			}
+
+			fmt.Printf("Successfully updated workload profile%s: %s\n", profilePlural, strings.Join(profilesName, ","))
+
+			return nil
+		}
+
+		for i, r := range results {
+			if r == "" {
+				continue
+			}
+			if i > 0 {
+				fmt.Println("---")
+			}
+			fmt.Printf("%v", r)
+		}
+
+		return nil
+	}
+
+	// Maybe there is no trace with the given ID.
+	// But it is better to try to delete something which does not exist than
+	// leaking a resource.
+	defer utils.DeleteTrace(traceID)
+
+	err := utils.SetTraceOperation(traceID, string(gadgetv1alpha1.OperationGenerate))
+	if err != nil {
+		return commonutils.WrapInErrGenGadgetOutput(err)
+	}
+
+	// We stop the trace so its Status.State become Stopped.
+	// Indeed, generate operation does not change value of Status.State.
+	err = utils.SetTraceOperation(traceID, string(gadgetv1alpha1.OperationStop))
+	if err != nil {
+		return commonutils.WrapInErrStopGadget(err)
+	}
+
+	err = utils.PrintTraceOutputFromStatus(traceID, string(gadgetv1alpha1.TraceStateStopped), callback)
+	if err != nil {
+		return commonutils.WrapInErrGetGadgetOutput(err)
+	}
+
+	return nil
+}
+
+// runWorkloadProfileList lists already running traces which config was given
+// as parameter.
+func runWorkloadProfileList(cmd *cobra.Command, args []string) error {
+	config := &utils.TraceConfig{
+		GadgetName:  "workload-profile",
+		CommonFlags: &params,
+	}
+
+	err := utils.PrintAllTraces(config)
+	if err != nil {
+		return commonutils.WrapInErrListGadgetTraces(err)
+	}
+
+	return nil
+}
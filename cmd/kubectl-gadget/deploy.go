@@ -73,12 +73,30 @@ var (
 	livenessProbe       bool
 	deployTimeout       time.Duration
 	fallbackPodInformer bool
+	pipelineProcess     bool
+	confine             bool
 	printOnly           bool
 	quiet               bool
 	debug               bool
 	wait                bool
 	runtimesConfig      commonutils.RuntimesSocketPathConfig
 	nodeSelector        string
+	auditWebhookAddress string
+	journald            bool
+	fluentForwardAddr   string
+	cloudWatchLogGroup  string
+	cloudWatchRegion    string
+	cloudLoggingProject string
+	azureEndpoint       string
+	azureRuleID         string
+	azureStream         string
+	s3Bucket            string
+	s3Endpoint          string
+	s3Region            string
+	s3Cluster           string
+	otelEndpoint        string
+	alertmanagerURL     string
+	saAnnotations       map[string]string
 )
 
 var supportedHooks = []string{"auto", "crio", "podinformer", "nri", "fanotify"}
@@ -111,6 +129,16 @@ func init() {
 		"fallback-podinformer", "",
 		true,
 		"use pod informer as a fallback for the main hook")
+	deployCmd.PersistentFlags().BoolVarP(
+		&pipelineProcess,
+		"pipeline-process", "",
+		false,
+		"parse, enrich and export the events in a separate process without privileges")
+	deployCmd.PersistentFlags().BoolVarP(
+		&confine,
+		"confine", "",
+		true,
+		"restrict the syscalls and the writable paths of the gadget pods with seccomp and Landlock")
 	deployCmd.PersistentFlags().BoolVarP(
 		&printOnly,
 		"print-only", "",
@@ -142,6 +170,86 @@ func init() {
 		"node-selector", "",
 		"",
 		"node labels selector for the Inspektor Gadget DaemonSet")
+	deployCmd.PersistentFlags().StringVarP(
+		&auditWebhookAddress,
+		"audit-webhook-address", "",
+		"",
+		"address the gadget pods listen on to receive the audit log webhook (e.g. :8443), empty to disable")
+	deployCmd.PersistentFlags().BoolVarP(
+		&journald,
+		"journald", "",
+		false,
+		"write the events of the gadgets to the journal of the nodes")
+	deployCmd.PersistentFlags().StringVarP(
+		&fluentForwardAddr,
+		"fluent-forward-address", "",
+		"",
+		"address of the Fluentd or Fluent Bit forward input the gadget pods send the events to (e.g. $(NODE_IP):24224), empty to disable")
+	deployCmd.PersistentFlags().StringVarP(
+		&cloudWatchLogGroup,
+		"cloudwatch-log-group", "",
+		"",
+		"CloudWatch Logs log group the gadget pods send the events to, empty to disable")
+	deployCmd.PersistentFlags().StringVarP(
+		&cloudWatchRegion,
+		"cloudwatch-region", "",
+		"",
+		"AWS region of the CloudWatch Logs log group")
+	deployCmd.PersistentFlags().StringVarP(
+		&cloudLoggingProject,
+		"cloud-logging-project", "",
+		"",
+		"Google Cloud project whose Cloud Logging the gadget pods send the events to, empty to disable")
+	deployCmd.PersistentFlags().StringVarP(
+		&azureEndpoint,
+		"azure-monitor-endpoint", "",
+		"",
+		"Azure Monitor logs ingestion endpoint the gadget pods send the events to, empty to disable")
+	deployCmd.PersistentFlags().StringVarP(
+		&azureRuleID,
+		"azure-monitor-rule-id", "",
+		"",
+		"immutable ID of the Azure Monitor data collection rule")
+	deployCmd.PersistentFlags().StringVarP(
+		&azureStream,
+		"azure-monitor-stream", "",
+		"",
+		"stream of the Azure Monitor data collection rule (default \"Custom-InspektorGadget\")")
+	deployCmd.PersistentFlags().StringVarP(
+		&s3Bucket,
+		"s3-bucket", "",
+		"",
+		"bucket the gadget pods upload the results of the profile gadgets to, empty to disable")
+	deployCmd.PersistentFlags().StringVarP(
+		&s3Endpoint,
+		"s3-endpoint", "",
+		"",
+		"URL of the S3-compatible object storage of the bucket (default Amazon S3)")
+	deployCmd.PersistentFlags().StringVarP(
+		&s3Region,
+		"s3-region", "",
+		"",
+		"region of the bucket")
+	deployCmd.PersistentFlags().StringVarP(
+		&s3Cluster,
+		"s3-cluster", "",
+		"",
+		"name of the cluster used in the keys of the uploaded objects (default \"default\")")
+	deployCmd.PersistentFlags().StringVarP(
+		&otelEndpoint,
+		"otel-endpoint", "",
+		"",
+		"OTLP/HTTP endpoint the gadget pods export the spans of the requests to, empty to disable")
+	deployCmd.PersistentFlags().StringVarP(
+		&alertmanagerURL,
+		"alertmanager-url", "",
+		"",
+		"URL of the Alertmanager the gadget pods send the alerts to (e.g. http://alertmanager.monitoring.svc:9093), empty to disable")
+	deployCmd.PersistentFlags().StringToStringVarP(
+		&saAnnotations,
+		"service-account-annotations", "",
+		nil,
+		"annotations of the service account of the gadget pods, e.g. to bind it to a cloud identity (eks.amazonaws.com/role-arn=..., iam.gke.io/gcp-service-account=..., azure.workload.identity/client-id=...)")
 	rootCmd.AddCommand(deployCmd)
 }
 
@@ -348,6 +456,13 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 
 	objects = append(objects, traceObjects...)
 
+	workloadProfileObjects, err := parseK8sYaml(resources.WorkloadProfilesCustomResource)
+	if err != nil {
+		return err
+	}
+
+	objects = append(objects, workloadProfileObjects...)
+
 	config, err := utils.KubernetesConfigFlags.ToRESTConfig()
 	if err != nil {
 		return fmt.Errorf("creating RESTConfig: %w", err)
@@ -415,6 +530,40 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 					gadgetContainer.Env[i].Value = hookMode
 				case "INSPEKTOR_GADGET_OPTION_FALLBACK_POD_INFORMER":
 					gadgetContainer.Env[i].Value = strconv.FormatBool(fallbackPodInformer)
+				case "INSPEKTOR_GADGET_OPTION_PIPELINE_PROCESS":
+					gadgetContainer.Env[i].Value = strconv.FormatBool(pipelineProcess)
+				case "INSPEKTOR_GADGET_OPTION_CONFINE":
+					gadgetContainer.Env[i].Value = strconv.FormatBool(confine)
+				case "INSPEKTOR_GADGET_AUDIT_WEBHOOK_ADDRESS":
+					gadgetContainer.Env[i].Value = auditWebhookAddress
+				case "INSPEKTOR_GADGET_JOURNALD":
+					gadgetContainer.Env[i].Value = strconv.FormatBool(journald)
+				case "INSPEKTOR_GADGET_FLUENT_FORWARD_ADDRESS":
+					gadgetContainer.Env[i].Value = fluentForwardAddr
+				case "INSPEKTOR_GADGET_CLOUDWATCH_LOG_GROUP":
+					gadgetContainer.Env[i].Value = cloudWatchLogGroup
+				case "INSPEKTOR_GADGET_CLOUDWATCH_REGION":
+					gadgetContainer.Env[i].Value = cloudWatchRegion
+				case "INSPEKTOR_GADGET_CLOUD_LOGGING_PROJECT":
+					gadgetContainer.Env[i].Value = cloudLoggingProject
+				case "INSPEKTOR_GADGET_AZURE_MONITOR_ENDPOINT":
+					gadgetContainer.Env[i].Value = azureEndpoint
+				case "INSPEKTOR_GADGET_AZURE_MONITOR_RULE_ID":
+					gadgetContainer.Env[i].Value = azureRuleID
+				case "INSPEKTOR_GADGET_AZURE_MONITOR_STREAM":
+					gadgetContainer.Env[i].Value = azureStream
+				case "INSPEKTOR_GADGET_S3_BUCKET":
+					gadgetContainer.Env[i].Value = s3Bucket
+				case "INSPEKTOR_GADGET_S3_ENDPOINT":
+					gadgetContainer.Env[i].Value = s3Endpoint
+				case "INSPEKTOR_GADGET_S3_REGION":
+					gadgetContainer.Env[i].Value = s3Region
+				case "INSPEKTOR_GADGET_S3_CLUSTER":
+					gadgetContainer.Env[i].Value = s3Cluster
+				case "INSPEKTOR_GADGET_OTEL_ENDPOINT":
+					gadgetContainer.Env[i].Value = otelEndpoint
+				case "INSPEKTOR_GADGET_ALERTMANAGER_URL":
+					gadgetContainer.Env[i].Value = alertmanagerURL
 				case utils.GadgetEnvironmentContainerdSocketpath:
 					gadgetContainer.Env[i].Value = runtimesConfig.Containerd
 				case utils.GadgetEnvironmentCRIOSocketpath:
@@ -426,6 +575,15 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 				}
 			}
 
+			if azureEndpoint != "" {
+				// Let the webhook of Azure AD Workload Identity give its
+				// token to the gadget pods
+				if daemonSet.Spec.Template.Labels == nil {
+					daemonSet.Spec.Template.Labels = map[string]string{}
+				}
+				daemonSet.Spec.Template.Labels["azure.workload.identity/use"] = "true"
+			}
+
 			if nodeSelector != "" {
 				affinity, err := createAffinity(k8sClient)
 				if err != nil {
@@ -440,6 +598,15 @@ func runDeploy(cmd *cobra.Command, args []string) error {
 			)
 		}
 
+		if serviceAccount, ok := object.(*v1.ServiceAccount); ok && len(saAnnotations) > 0 {
+			if serviceAccount.Annotations == nil {
+				serviceAccount.Annotations = map[string]string{}
+			}
+			for key, value := range saAnnotations {
+				serviceAccount.Annotations[key] = value
+			}
+		}
+
 		if printOnly {
 			bytes, err := yaml.Marshal(object)
 			if err != nil {
@@ -68,7 +68,8 @@ func main() {
 
 	// columnFilters for kubectl-gadget
 	columnFilters := []columns.ColumnFilter{columns.WithoutExceptTag("runtime", "kubernetes")}
-	common.AddCommandsFromRegistry(rootCmd, runtime, columnFilters)
+	// The profiles of the cluster can be overridden by the configuration file
+	common.AddCommandsFromRegistry(rootCmd, runtime, columnFilters, utils.LoadConfigMapProfiles)
 
 	// Advise category is still being handled by CRs for now
 	rootCmd.AddCommand(advise.NewAdviseCmd())
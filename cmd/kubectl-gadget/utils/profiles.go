@@ -0,0 +1,57 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/inspektor-gadget/inspektor-gadget/cmd/common"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/k8sutil"
+)
+
+const (
+	// ProfilesConfigMap is the ConfigMap of the gadget namespace sharing the
+	// profiles with all the users of the cluster
+	ProfilesConfigMap    = "gadget-profiles"
+	profilesConfigMapKey = "config.yaml"
+)
+
+// LoadConfigMapProfiles returns the profiles of the gadget-profiles ConfigMap,
+// if it exists. Its config.yaml key has the same format as the configuration
+// file.
+func LoadConfigMapProfiles() (map[string]*common.Profile, error) {
+	client, err := k8sutil.NewClientsetFromConfigFlags(KubernetesConfigFlags)
+	if err != nil {
+		return nil, fmt.Errorf("creating RESTConfig: %w", err)
+	}
+
+	cm, err := client.CoreV1().ConfigMaps(GadgetNamespace).Get(context.TODO(), ProfilesConfigMap, metav1.GetOptions{})
+	if err != nil {
+		if k8serrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getting ConfigMap %q: %w", ProfilesConfigMap, err)
+	}
+
+	config, err := common.ParseConfig([]byte(cm.Data[profilesConfigMapKey]))
+	if err != nil {
+		return nil, fmt.Errorf("parsing ConfigMap %q: %w", ProfilesConfigMap, err)
+	}
+	return config.Profiles, nil
+}
@@ -25,6 +25,7 @@ import (
 
 	commonutils "github.com/inspektor-gadget/inspektor-gadget/cmd/common/utils"
 	"github.com/inspektor-gadget/inspektor-gadget/cmd/kubectl-gadget/utils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/fips"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/k8sutil"
 )
 
@@ -42,6 +43,9 @@ var versionCmd = &cobra.Command{
 	Short: "Show version",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		fmt.Println("Client version:", version)
+		if fips.Enabled() {
+			fmt.Println("Client FIPS mode: enabled")
+		}
 
 		client, err := k8sutil.NewClientsetFromConfigFlags(utils.KubernetesConfigFlags)
 		if err != nil {
@@ -173,4 +173,6 @@ cd /
 rm -f /run/gadgettracermanager.socket
 rm -f /run/gadgetservice.socket
 exec /bin/gadgettracermanager -serve -hook-mode=$GADGET_TRACER_MANAGER_HOOK_MODE \
-    -controller -fallback-podinformer=$INSPEKTOR_GADGET_OPTION_FALLBACK_POD_INFORMER
+    -controller -fallback-podinformer=$INSPEKTOR_GADGET_OPTION_FALLBACK_POD_INFORMER \
+    -pipeline-process=${INSPEKTOR_GADGET_OPTION_PIPELINE_PROCESS:-false} \
+    -confine=${INSPEKTOR_GADGET_OPTION_CONFINE:-true}
@@ -41,9 +41,12 @@ import (
 	// The script gadget is designed only to work in k8s, hence it's not part of all-gadgets
 	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/script"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/confinement"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/fips"
 	gadgetservice "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-service"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgettracermanager"
 	pb "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgettracermanager/api"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/pipelineprocess"
 )
 
 var (
@@ -51,6 +54,9 @@ var (
 	serve                   bool
 	liveness                bool
 	fallbackPodInformer     bool
+	pipelineProcess         bool
+	pipelineProcessChild    bool
+	confine                 bool
 	dump                    string
 	hookMode                string
 	socketfile              string
@@ -88,6 +94,9 @@ func init() {
 
 	flag.BoolVar(&liveness, "liveness", false, "Execute as client and perform liveness probe")
 	flag.BoolVar(&fallbackPodInformer, "fallback-podinformer", true, "Use pod informer as a fallback for main hook")
+	flag.BoolVar(&pipelineProcess, "pipeline-process", false, "Parse, enrich and export the events in a separate process without privileges")
+	flag.BoolVar(&pipelineProcessChild, "pipeline-process-child", false, "Run as the pipeline process, used internally by -pipeline-process")
+	flag.BoolVar(&confine, "confine", true, "Restrict the syscalls and the writable paths of the server with seccomp and Landlock")
 }
 
 func main() {
@@ -99,6 +108,13 @@ func main() {
 		os.Exit(1)
 	}
 
+	if pipelineProcessChild {
+		if err := pipelineprocess.Serve(os.Getenv("NODE_NAME")); err != nil {
+			log.Fatalf("pipeline process: %v", err)
+		}
+		os.Exit(0)
+	}
+
 	labels := []*pb.Label{}
 	if label != "" {
 		pairs := strings.Split(label, ",")
@@ -255,6 +271,16 @@ func main() {
 			log.Fatalf("Environment variable NODE_NAME not set")
 		}
 
+		if confine {
+			if err := confinement.Apply(); err != nil {
+				log.Fatalf("failed to confine the Gadget Tracer Manager: %v", err)
+			}
+		}
+
+		if fips.Enabled() {
+			log.Infof("Using FIPS validated crypto")
+		}
+
 		lis, err := net.Listen("unix", socketfile)
 		if err != nil {
 			log.Fatalf("failed to listen: %v", err)
@@ -287,6 +313,12 @@ func main() {
 			go startController(node, tracerManager)
 		}
 
+		if pipelineProcess {
+			if err := pipelineprocess.Start(&tracerManager.ContainerCollection, "-pipeline-process-child"); err != nil {
+				log.Fatalf("failed to start the pipeline process: %v", err)
+			}
+		}
+
 		service := gadgetservice.NewService(log.StandardLogger())
 		go func() {
 			err := service.Run("unix", gadgetServiceSocketFile)
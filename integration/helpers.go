@@ -28,6 +28,17 @@ import (
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
 
+// normalizeGeneration clears the restart count and the generation of the
+// container of the events, which depend on the containers that ran before on
+// the node
+func normalizeGeneration(entry any) {
+	if setter, ok := entry.(interface {
+		SetGeneration(restartCount uint32, generation string)
+	}); ok {
+		setter.SetGeneration(0, "")
+	}
+}
+
 func parseMultiJSONOutput[T any](output string, normalize func(*T)) ([]*T, error) {
 	ret := []*T{}
 
@@ -40,6 +51,7 @@ func parseMultiJSONOutput[T any](output string, normalize func(*T)) ([]*T, error
 		// To be able to use reflect.DeepEqual and cmp.Diff, we need to
 		// "normalize" the output so that it only includes non-default values
 		// for the fields we are able to verify.
+		normalizeGeneration(&entry)
 		if normalize != nil {
 			normalize(&entry)
 		}
@@ -61,6 +73,7 @@ func parseJSONArrayOutput[T any](output string, normalize func(*T)) ([]*T, error
 		// To be able to use reflect.DeepEqual and cmp.Diff, we need to
 		// "normalize" the output so that it only includes non-default values
 		// for the fields we are able to verify.
+		normalizeGeneration(entry)
 		if normalize != nil {
 			normalize(entry)
 		}
@@ -0,0 +1,75 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	kmodTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/audit/kmod/types"
+)
+
+func TestAuditKmod(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-audit-kmod")
+
+	// The test pod doesn't have CAP_SYS_MODULE: the kernel refuses the
+	// module, an empty file, before parsing it
+	kmodCmd := &Command{
+		Name:         "StartKmodGadget",
+		Cmd:          fmt.Sprintf("ig audit kmod -o json --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &kmodTypes.Event{
+				Event:   BuildBaseEvent(ns),
+				Comm:    "insmod",
+				Syscall: columns.Enum{Name: "finit_module"},
+				Module:  "test",
+				File:    "/tmp/test.ko",
+				Ret:     -1,
+				Err:     "EPERM",
+			}
+
+			normalize := func(e *kmodTypes.Event) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Timestamp = 0
+				e.Pid = 0
+				e.Tid = 0
+				e.Syscall.Value = 0
+				e.MountNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		kmodCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		PodCommand("test-pod", "alpine", ns, `["/bin/sh", "-c"]`, "apk add kmod > /dev/null && touch /tmp/test.ko && while true; do insmod /tmp/test.ko; sleep 0.1; done"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	generateTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/generate/types"
+)
+
+func TestGenerate(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-generate")
+
+	// The events come from all the containers of the node in turn, generate
+	// enough of them for the test pod to get some
+	generateCmd := &Command{
+		Name: "Generate",
+		Cmd:  fmt.Sprintf("ig generate -o json --runtimes=%s --rate 100 --count 200 --fields app=shop,ok:bool --timeout 5", *containerRuntime),
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &generateTypes.Event{
+				Event:  BuildBaseEvent(ns),
+				Fields: map[string]any{"app": "shop"},
+			}
+
+			normalize := func(e *generateTypes.Event) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker && e.Pod == "test-pod" {
+					e.Container = "test-pod"
+				}
+
+				e.Timestamp = 0
+				e.MountNsID = 0
+				e.Seq = 0
+				// Only check the type of the random field
+				if _, ok := e.Fields["ok"].(bool); ok {
+					delete(e.Fields, "ok")
+				}
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodCommand(ns, "sleep inf"),
+		WaitUntilTestPodReadyCommand(ns),
+		generateCmd,
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
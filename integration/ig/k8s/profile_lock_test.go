@@ -0,0 +1,73 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	lockTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/lock/types"
+)
+
+// contendedPodArgs is a python program whose threads contend for the global
+// interpreter lock, built on futexes
+const contendedPodArgs = `"import threading\ndef spin():\n    while True:\n        pass\nfor i in range(4):\n    threading.Thread(target=spin).start()"`
+
+func TestProfileLock(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-profile-lock")
+
+	profileLockCmd := &Command{
+		Name: "ProfileLock",
+		Cmd:  fmt.Sprintf("ig profile lock -o json --yes --runtimes=%s --timeout 10", *containerRuntime),
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &lockTypes.Report{
+				CommonData: BuildCommonData(ns),
+				Comm:       "python3",
+				Type:       lockTypes.LockTypeFutex,
+			}
+
+			normalize := func(e *lockTypes.Report) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Node = ""
+				e.Pid = 0
+				e.KernelStack = nil
+				e.UserStack = nil
+				e.Address = ""
+				e.Count = 0
+				e.Total = 0
+				e.Max = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		PodCommand("test-pod", "python:3-alpine", ns, `["python3", "-c"]`, contendedPodArgs),
+		WaitUntilTestPodReadyCommand(ns),
+		profileLockCmd,
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	memleakTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/memleak/types"
+)
+
+// leakyPodArgs is a python program that allocates memory without freeing it
+const leakyPodArgs = `"import time\nx = []\nwhile True:\n    x.append(bytearray(100000))\n    time.sleep(0.1)"`
+
+func TestProfileMemleak(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-profile-memleak")
+
+	profileMemleakCmd := &Command{
+		Name: "ProfileMemleak",
+		Cmd:  fmt.Sprintf("ig profile memleak --user -o json --yes --runtimes=%s --interval 0 --timeout 10", *containerRuntime),
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &memleakTypes.Report{
+				CommonData: BuildCommonData(ns),
+				Comm:       "python3",
+				Kind:       memleakTypes.KindUser,
+			}
+
+			normalize := func(e *memleakTypes.Report) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Node = ""
+				e.Pid = 0
+				e.Allocations = 0
+				e.Bytes = 0
+				e.Stack = nil
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		PodCommand("test-pod", "python:3-alpine", ns, `["python3", "-c"]`, leakyPodArgs),
+		WaitUntilTestPodReadyCommand(ns),
+		profileMemleakCmd,
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
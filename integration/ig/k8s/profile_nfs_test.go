@@ -0,0 +1,141 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	nfsprofileTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/nfs/types"
+)
+
+// nfsServerPodCommand returns a Command that creates a pod serving an NFS
+// export, with the image used by the Kubernetes e2e tests
+func nfsServerPodCommand(ns string) *Command {
+	return &Command{
+		Name: "RunNfsServer",
+		Cmd: fmt.Sprintf(`kubectl apply -f - <<"EOF"
+apiVersion: v1
+kind: Pod
+metadata:
+  name: nfs-server
+  namespace: %s
+spec:
+  restartPolicy: Never
+  terminationGracePeriodSeconds: 0
+  containers:
+  - name: nfs-server
+    image: registry.k8s.io/e2e-test-images/volume/nfs:1.3
+    args: ["/exports"]
+    securityContext:
+      privileged: true
+EOF
+`, ns),
+		ExpectedString: "pod/nfs-server created\n",
+	}
+}
+
+// nfsClientPodCommand returns a Command that creates the test pod, writing
+// and reading a file of the NFS export served at the given address
+func nfsClientPodCommand(ns, server string) *Command {
+	return &Command{
+		Name: "RunNfsClient",
+		Cmd: fmt.Sprintf(`kubectl apply -f - <<"EOF"
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test-pod
+  namespace: %s
+spec:
+  restartPolicy: Never
+  terminationGracePeriodSeconds: 0
+  containers:
+  - name: test-pod
+    image: busybox
+    command: ["/bin/sh", "-c"]
+    args:
+    - while true; do echo foo > /mnt/test-file; cat /mnt/test-file > /dev/null; sleep 0.1; done
+    volumeMounts:
+    - name: nfs
+      mountPath: /mnt
+  volumes:
+  - name: nfs
+    nfs:
+      server: %s
+      path: /
+EOF
+`, ns, server),
+		ExpectedString: "pod/test-pod created\n",
+	}
+}
+
+func TestProfileNfs(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-profile-nfs")
+
+	commandsPreTest := []*Command{
+		CreateTestNamespaceCommand(ns),
+		nfsServerPodCommand(ns),
+		WaitUntilPodReadyCommand(ns, "nfs-server"),
+	}
+
+	RunTestSteps(commandsPreTest, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+	serverIP, err := GetTestPodIP(ns, "nfs-server")
+	if err != nil {
+		t.Fatalf("failed to get pod ip %s", err)
+	}
+
+	profileNfsCmd := &Command{
+		Name: "ProfileNfs",
+		Cmd:  fmt.Sprintf("ig profile nfs -o json --runtimes=%s --timeout 10", *containerRuntime),
+		ExpectedOutputFn: func(output string) error {
+			expectedEntries := []*nfsprofileTypes.Report{
+				{
+					CommonData: BuildCommonData(ns),
+					Operation:  nfsprofileTypes.OperationOpen,
+				},
+				{
+					CommonData: BuildCommonData(ns),
+					Operation:  nfsprofileTypes.OperationWrite,
+				},
+			}
+
+			normalize := func(e *nfsprofileTypes.Report) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Node = ""
+				e.Count = 0
+				e.Average = 0
+				e.Histogram = nil
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntries...)
+		},
+	}
+
+	commands := []*Command{
+		nfsClientPodCommand(ns, serverIP),
+		WaitUntilTestPodReadyCommand(ns),
+		profileNfsCmd,
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
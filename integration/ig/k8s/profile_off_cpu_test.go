@@ -0,0 +1,65 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	offcpuTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/off-cpu/types"
+)
+
+func TestProfileOffCpu(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-profile-off-cpu")
+
+	profileOffCpuCmd := &Command{
+		Name: "ProfileOffCpu",
+		Cmd:  fmt.Sprintf("ig profile off-cpu -K -o json --yes --runtimes=%s --timeout 10", *containerRuntime),
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &offcpuTypes.Report{
+				CommonData: BuildCommonData(ns),
+				Comm:       "sh",
+			}
+
+			normalize := func(e *offcpuTypes.Report) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Node = ""
+				e.Pid = 0
+				e.KernelStack = nil
+				e.UserStack = nil
+				e.Duration = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodRepeatCommand(ns, "sleep 1"),
+		WaitUntilTestPodReadyCommand(ns),
+		profileOffCpuCmd,
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
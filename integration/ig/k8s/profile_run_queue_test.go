@@ -0,0 +1,67 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	runqueueTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/run-queue/types"
+)
+
+func TestProfileRunQueue(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-profile-run-queue")
+
+	profileRunQueueCmd := &Command{
+		Name: "ProfileRunQueue",
+		Cmd:  fmt.Sprintf("ig profile run-queue -o json --yes --runtimes=%s --interval 0 --timeout 10", *containerRuntime),
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &runqueueTypes.Report{
+				CommonData: BuildCommonData(ns),
+			}
+
+			normalize := func(e *runqueueTypes.Report) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Node = ""
+				e.Max = 0
+				e.Pid = 0
+				e.Tid = 0
+				e.Comm = ""
+				e.Count = 0
+				e.Average = 0
+				e.Histogram = nil
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodRepeatCommand(ns, "sleep 0.1"),
+		WaitUntilTestPodReadyCommand(ns),
+		profileRunQueueCmd,
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	startupTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/startup/types"
+)
+
+func TestProfileStartup(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-profile-startup")
+
+	// The gadget only records the containers started while it's running:
+	// the pod is created by the same command, once the gadget started.
+	profileStartupCmd := &Command{
+		Name: "ProfileStartup",
+		Cmd: fmt.Sprintf(`ig profile startup -o json --runtimes=%s --timeout 15 &
+			sleep 3
+			kubectl run -n %s test-pod --image busybox --restart=Never -- sh -c 'cat /etc/app.conf; nc -w 1 127.0.0.1 9090; sleep inf' > /dev/null
+			wait`, *containerRuntime, ns),
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &startupTypes.Report{
+				CommonData: BuildCommonData(ns),
+				Timeline: []startupTypes.Entry{
+					{
+						Comm:      "cat",
+						Operation: startupTypes.OperationOpen,
+						Target:    "/etc/app.conf",
+						Error:     "ENOENT",
+					},
+					{
+						Comm:      "nc",
+						Operation: startupTypes.OperationConnect,
+						Target:    "127.0.0.1:9090",
+					},
+				},
+			}
+
+			normalize := func(e *startupTypes.Report) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Node = ""
+				e.Execs = 0
+				e.Opens = 0
+				e.Connects = 0
+				e.Dropped = 0
+
+				// Only keep the operations of the command of the pod, not the
+				// ones of the runtime and of the libraries
+				var timeline []startupTypes.Entry
+				for _, entry := range e.Timeline {
+					entry.Elapsed = 0
+					entry.Pid = 0
+					switch {
+					case entry.Comm == "cat" && entry.Target == "/etc/app.conf":
+					case entry.Comm == "nc" && entry.Operation == startupTypes.OperationConnect:
+						// The connection can fail right away or time out
+						entry.Error = ""
+					default:
+						continue
+					}
+					timeline = append(timeline, entry)
+				}
+				e.Timeline = timeline
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		profileStartupCmd,
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
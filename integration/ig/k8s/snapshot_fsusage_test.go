@@ -0,0 +1,74 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	fsusageTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/fsusage/types"
+)
+
+func TestSnapshotFsusage(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-snapshot-fsusage")
+
+	snapshotFsusageCmd := &Command{
+		Name:         "SnapshotFsusage",
+		Cmd:          fmt.Sprintf("ig snapshot fsusage -o json --runtimes=%s --top 1", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &fsusageTypes.Event{
+				Event: BuildBaseEvent(ns),
+				TopDirs: []fsusageTypes.DirUsage{
+					{Path: "/var/log"},
+				},
+			}
+
+			normalize := func(e *fsusageTypes.Event) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Node = ""
+				e.MountNsID = 0
+				e.UpperDir = ""
+				e.Usage = 0
+				e.Size = 0
+				e.Inodes = 0
+				for i := range e.TopDirs {
+					e.TopDirs[i].Usage = 0
+					e.TopDirs[i].Inodes = 0
+				}
+			}
+
+			return ExpectEntriesInArrayToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodCommand(ns, "mkdir -p /var/log/app; dd if=/dev/zero of=/var/log/app/test.log bs=1M count=10; sleep inf"),
+		WaitUntilTestPodReadyCommand(ns),
+		snapshotFsusageCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	numaTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/numa/types"
+)
+
+func TestSnapshotNuma(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-snapshot-numa")
+
+	snapshotNumaCmd := &Command{
+		Name:         "SnapshotNuma",
+		Cmd:          fmt.Sprintf("ig snapshot numa -o json --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &numaTypes.Event{
+				Event: BuildBaseEvent(ns),
+				Nodes: []numaTypes.NodeUsage{
+					{Node: 0, Local: true},
+				},
+			}
+
+			normalize := func(e *numaTypes.Event) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Node = ""
+				e.MountNsID = 0
+				e.Cpus = ""
+				e.Mems = ""
+				e.Memory = 0
+				e.Remote = 0
+				e.RemoteRatio = 0
+				// Only the first NUMA node is checked, the test nodes can have more
+				if len(e.Nodes) > 0 {
+					e.Nodes = []numaTypes.NodeUsage{
+						{Node: e.Nodes[0].Node, Local: e.Nodes[0].Local},
+					}
+				}
+			}
+
+			return ExpectEntriesInArrayToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodCommand(ns, "nc -l -p 9090"),
+		WaitUntilTestPodReadyCommand(ns),
+		snapshotNumaCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	pagecacheTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/page-cache/types"
+)
+
+func TestTopPageCache(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-top-page-cache")
+
+	topPageCacheCmd := &Command{
+		Name:         "TopPageCache",
+		Cmd:          fmt.Sprintf("ig top page-cache -o json -m 999 --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &pagecacheTypes.Stats{
+				CommonData: BuildCommonData(ns),
+				Filename:   "test-file",
+			}
+
+			normalize := func(e *pagecacheTypes.Stats) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Node = ""
+				e.MountNsID = 0
+				e.Hits = 0
+				e.Misses = 0
+				e.HitRatio = 0
+				e.Dirtied = 0
+				e.DirtyRatio = 0
+				e.Faults = 0
+				e.Cached = 0
+				e.Inode = 0
+			}
+
+			return ExpectEntriesInMultipleArrayToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		topPageCacheCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		BusyboxPodRepeatCommand(ns, "dd if=/dev/zero of=/tmp/test-file bs=4k count=16 2> /dev/null; cat /tmp/test-file > /dev/null"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	syscallTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/syscall/types"
+)
+
+func TestTopSyscall(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-top-syscall")
+
+	topSyscallCmd := &Command{
+		Name:         "TopSyscall",
+		Cmd:          fmt.Sprintf("ig top syscall -o json --yes -m 999 --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &syscallTypes.Stats{
+				CommonData: BuildCommonData(ns),
+				Syscall:    columns.Enum{Name: "write"},
+			}
+
+			normalize := func(e *syscallTypes.Stats) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Node = ""
+				e.MountNsID = 0
+				e.Syscall.Value = 0
+				e.Count = 0
+				e.Total = 0
+				e.Average = 0
+				e.P99 = 0
+				e.Max = 0
+			}
+
+			return ExpectEntriesInMultipleArrayToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		topSyscallCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		BusyboxPodRepeatCommand(ns, "echo foo > /tmp/test-file"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
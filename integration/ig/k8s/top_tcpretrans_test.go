@@ -0,0 +1,71 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	tcpretransTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/tcpretrans/types"
+)
+
+func TestTopTcpretrans(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-top-tcpretrans")
+
+	topTcpretransCmd := &Command{
+		Name:         "TopTcpretrans",
+		Cmd:          fmt.Sprintf("ig top tcpretrans -o json -m 999 --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &tcpretransTypes.Stats{
+				CommonData: BuildCommonData(ns),
+			}
+
+			normalize := func(e *tcpretransTypes.Stats) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Node = ""
+				e.MountNsID = 0
+				e.Connections = 0
+				e.Sent = 0
+				e.Retrans = 0
+				e.RetransRate = 0
+				e.AvgSrtt = 0
+				e.MaxSrtt = 0
+				e.SrttHistogram = nil
+				e.RetransRateHistogram = nil
+			}
+
+			return ExpectEntriesInMultipleArrayToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		topTcpretransCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		PodCommand("test-pod", "nginx", ns, "[sh, -c]", "nginx && while true; do curl 127.0.0.1; sleep 0.1; done"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
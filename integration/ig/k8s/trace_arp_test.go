@@ -0,0 +1,87 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	arpTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/arp/types"
+)
+
+func TestTraceArp(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-trace-arp")
+
+	commandsPreTest := []*Command{
+		CreateTestNamespaceCommand(ns),
+		PodCommand("nginx-pod", "nginx", ns, "", ""),
+		WaitUntilPodReadyCommand(ns, "nginx-pod"),
+	}
+
+	RunTestSteps(commandsPreTest, t)
+	nginxIP, err := GetTestPodIP(ns, "nginx-pod")
+	if err != nil {
+		t.Fatalf("failed to get pod ip %s", err)
+	}
+
+	traceArpCmd := &Command{
+		Name:         "TraceArp",
+		Cmd:          fmt.Sprintf("ig trace arp -o json --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			testPodIP, err := GetTestPodIP(ns, "test-pod")
+			if err != nil {
+				return fmt.Errorf("getting pod ip: %w", err)
+			}
+
+			// The reply isn't checked, the pods can be on different nodes
+			expectedEntry := &arpTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				Protocol:  arpTypes.ProtocolARP,
+				Operation: "REQUEST",
+				SenderIP:  testPodIP,
+				TargetIP:  nginxIP,
+				PktType:   "OUTGOING",
+			}
+
+			normalize := func(e *arpTypes.Event) {
+				e.Timestamp = 0
+				e.NetNsID = 0
+				e.SenderMAC = ""
+				e.TargetMAC = ""
+
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker && e.Pod == "test-pod" {
+					e.Container = "test-pod"
+				}
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		traceArpCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		BusyboxPodRepeatCommand(ns, fmt.Sprintf("arping -c 1 -I eth0 %s", nginxIP)),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -18,7 +18,10 @@ import (
 	"fmt"
 	"testing"
 
+	"golang.org/x/sys/unix"
+
 	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	capabilitiesTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
 )
 
@@ -28,7 +31,7 @@ func TestTraceCapabilities(t *testing.T) {
 
 	capabilitiesCmd := &Command{
 		Name:         "TraceCapabilities",
-		Cmd:          fmt.Sprintf("ig trace capabilities -o json --runtimes=%s", *containerRuntime),
+		Cmd:          fmt.Sprintf("ig trace capabilities -o json --yes --runtimes=%s", *containerRuntime),
 		StartAndStop: true,
 		ExpectedOutputFn: func(output string) error {
 			expectedEntry := &capabilitiesTypes.Event{
@@ -36,7 +39,7 @@ func TestTraceCapabilities(t *testing.T) {
 				Comm:          "nice",
 				CapName:       "SYS_NICE",
 				Cap:           23,
-				Syscall:       "setpriority",
+				Syscall:       columns.Enum{Name: "setpriority", Value: unix.SYS_SETPRIORITY},
 				Audit:         1,
 				Verdict:       "Deny",
 				CurrentUserNs: 1,
@@ -0,0 +1,92 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	containerlifecycleTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/container-lifecycle/types"
+)
+
+func TestTraceContainerLifecycle(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-trace-container-lifecycle")
+
+	// newExpectedEntry returns the event expected for the test pod
+	newExpectedEntry := func(eventType containerlifecycleTypes.EventType) *containerlifecycleTypes.Event {
+		return &containerlifecycleTypes.Event{
+			Event:     BuildBaseEvent(ns),
+			EventType: eventType,
+			Args:      []string{"/bin/sh", "-c", "sleep inf"},
+		}
+	}
+
+	normalize := func(e *containerlifecycleTypes.Event) {
+		// TODO: Handle it once we support getting K8s container name for docker
+		// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+		if *containerRuntime == ContainerRuntimeDocker {
+			e.Container = "test-pod"
+		}
+
+		e.Timestamp = 0
+		e.MountNsID = 0
+		e.ContainerID = ""
+		e.Runtime = ""
+		e.Image = ""
+		e.Pid = 0
+		e.NetNsID = 0
+		e.StartedAt = 0
+		e.Mounts = nil
+		// The security context depends on the container runtime and its
+		// configuration
+		e.SecurityContext = containerlifecycleTypes.SecurityContext{}
+	}
+
+	traceContainerLifecycleCmd := &Command{
+		Name:         "TraceContainerLifecycle",
+		Cmd:          fmt.Sprintf("ig trace container-lifecycle -o json --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			return ExpectEntriesToMatch(output, normalize,
+				newExpectedEntry(containerlifecycleTypes.EventTypeCreated),
+				newExpectedEntry(containerlifecycleTypes.EventTypeDeleted),
+			)
+		},
+	}
+
+	traceExistingContainersCmd := &Command{
+		Name: "TraceExistingContainers",
+		Cmd:  fmt.Sprintf("ig trace container-lifecycle --include-existing -o json --runtimes=%s --timeout 2", *containerRuntime),
+		ExpectedOutputFn: func(output string) error {
+			return ExpectEntriesToMatch(output, normalize,
+				newExpectedEntry(containerlifecycleTypes.EventTypeExisting),
+			)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		traceContainerLifecycleCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		BusyboxPodCommand(ns, "sleep inf"),
+		WaitUntilTestPodReadyCommand(ns),
+		traceExistingContainersCmd,
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	cputhrottleTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/cpu-throttle/types"
+)
+
+// throttledPodCommand returns a Command that creates the test pod with a CPU
+// limit of 200m, running more busy loops than the limit allows
+func throttledPodCommand(ns string) *Command {
+	return &Command{
+		Name: "RunThrottledPod",
+		Cmd: fmt.Sprintf(`kubectl apply -f - <<"EOF"
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test-pod
+  namespace: %s
+spec:
+  restartPolicy: Never
+  terminationGracePeriodSeconds: 0
+  containers:
+  - name: test-pod
+    image: busybox
+    command: ["/bin/sh", "-c"]
+    args:
+    - for i in 1 2 3 4; do while true; do :; done & done; wait
+    resources:
+      limits:
+        cpu: 200m
+EOF
+`, ns),
+		ExpectedString: "pod/test-pod created\n",
+	}
+}
+
+func TestTraceCpuThrottle(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-trace-cpu-throttle")
+
+	cpuThrottleCmd := &Command{
+		Name:         "StartCpuThrottleGadget",
+		Cmd:          fmt.Sprintf("ig trace cpu-throttle -o json --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &cputhrottleTypes.Event{
+				Event:  BuildBaseEvent(ns),
+				Quota:  20 * time.Millisecond,
+				Period: 100 * time.Millisecond,
+			}
+
+			normalize := func(e *cputhrottleTypes.Event) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Timestamp = 0
+				e.Periods = 0
+				e.Throttled = 0
+				e.Duration = 0
+				e.MountNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		cpuThrottleCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		throttledPodCommand(ns),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
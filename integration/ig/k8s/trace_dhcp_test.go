@@ -0,0 +1,73 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	dhcpTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dhcp/types"
+)
+
+func TestTraceDhcp(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-trace-dhcp")
+
+	traceDhcpCmd := &Command{
+		Name:         "TraceDhcp",
+		Cmd:          fmt.Sprintf("ig trace dhcp -o json --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			// There isn't any DHCP server on the pod network, only the
+			// discover messages of the client are checked
+			expectedEntry := &dhcpTypes.Event{
+				Event:       BuildBaseEvent(ns),
+				Protocol:    dhcpTypes.ProtocolDHCP,
+				MessageType: "DISCOVER",
+				SrcIP:       "0.0.0.0",
+				DstIP:       "255.255.255.255",
+				PktType:     "OUTGOING",
+			}
+
+			normalize := func(e *dhcpTypes.Event) {
+				e.Timestamp = 0
+				e.NetNsID = 0
+				e.TransactionID = ""
+				e.ClientMAC = ""
+				e.ClientID = ""
+
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker && e.Pod == "test-pod" {
+					e.Container = "test-pod"
+				}
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		traceDhcpCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		BusyboxPodRepeatCommand(ns, "udhcpc -n -q -t 1 -T 1 -i eth0 -s /bin/true"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -46,69 +46,75 @@ func TestTraceDns(t *testing.T) {
 		ExpectedOutputFn: func(output string) error {
 			expectedEntries := []*dnsTypes.Event{
 				{
-					Event:      BuildBaseEvent(ns),
-					Comm:       "nslookup",
-					Qr:         dnsTypes.DNSPktTypeQuery,
-					Nameserver: dnsServer,
-					PktType:    "OUTGOING",
-					DNSName:    "fake.test.com.",
-					QType:      "A",
+					Event:          BuildBaseEvent(ns),
+					Comm:           "nslookup",
+					Qr:             dnsTypes.DNSPktTypeQuery,
+					Nameserver:     dnsServer,
+					NameserverPort: 53,
+					PktType:        "OUTGOING",
+					DNSName:        "fake.test.com.",
+					QType:          "A",
 				},
 				{
-					Event:      BuildBaseEvent(ns),
-					Comm:       "nslookup",
-					Qr:         dnsTypes.DNSPktTypeResponse,
-					Nameserver: dnsServer,
-					PktType:    "HOST",
-					DNSName:    "fake.test.com.",
-					QType:      "A",
-					Rcode:      "NoError",
-					Latency:    1,
-					NumAnswers: 1,
-					Addresses:  []string{"127.0.0.1"},
+					Event:          BuildBaseEvent(ns),
+					Comm:           "nslookup",
+					Qr:             dnsTypes.DNSPktTypeResponse,
+					Nameserver:     dnsServer,
+					NameserverPort: 53,
+					PktType:        "HOST",
+					DNSName:        "fake.test.com.",
+					QType:          "A",
+					Rcode:          "NoError",
+					Latency:        1,
+					NumAnswers:     1,
+					Addresses:      []string{"127.0.0.1"},
 				},
 				{
-					Event:      BuildBaseEvent(ns),
-					Comm:       "nslookup",
-					Qr:         dnsTypes.DNSPktTypeQuery,
-					Nameserver: dnsServer,
-					PktType:    "OUTGOING",
-					DNSName:    "fake.test.com.",
-					QType:      "AAAA",
+					Event:          BuildBaseEvent(ns),
+					Comm:           "nslookup",
+					Qr:             dnsTypes.DNSPktTypeQuery,
+					Nameserver:     dnsServer,
+					NameserverPort: 53,
+					PktType:        "OUTGOING",
+					DNSName:        "fake.test.com.",
+					QType:          "AAAA",
 				},
 				{
-					Event:      BuildBaseEvent(ns),
-					Comm:       "nslookup",
-					Qr:         dnsTypes.DNSPktTypeResponse,
-					Nameserver: dnsServer,
-					PktType:    "HOST",
-					DNSName:    "fake.test.com.",
-					QType:      "AAAA",
-					Rcode:      "NoError",
-					Latency:    1,
-					NumAnswers: 1,
-					Addresses:  []string{"::1"},
+					Event:          BuildBaseEvent(ns),
+					Comm:           "nslookup",
+					Qr:             dnsTypes.DNSPktTypeResponse,
+					Nameserver:     dnsServer,
+					NameserverPort: 53,
+					PktType:        "HOST",
+					DNSName:        "fake.test.com.",
+					QType:          "AAAA",
+					Rcode:          "NoError",
+					Latency:        1,
+					NumAnswers:     1,
+					Addresses:      []string{"::1"},
 				},
 				{
-					Event:      BuildBaseEvent(ns),
-					Comm:       "nslookup",
-					Qr:         dnsTypes.DNSPktTypeQuery,
-					Nameserver: dnsServer,
-					PktType:    "OUTGOING",
-					DNSName:    "fake.test.com.",
-					QType:      "A",
+					Event:          BuildBaseEvent(ns),
+					Comm:           "nslookup",
+					Qr:             dnsTypes.DNSPktTypeQuery,
+					Nameserver:     dnsServer,
+					NameserverPort: 53,
+					PktType:        "OUTGOING",
+					DNSName:        "fake.test.com.",
+					QType:          "A",
 				},
 				{
-					Event:      BuildBaseEvent(ns),
-					Comm:       "nslookup",
-					Qr:         dnsTypes.DNSPktTypeResponse,
-					Nameserver: dnsServer,
-					PktType:    "HOST",
-					DNSName:    "nodomain.fake.test.com.",
-					QType:      "A",
-					Rcode:      "NXDomain",
-					Latency:    1,
-					NumAnswers: 0,
+					Event:          BuildBaseEvent(ns),
+					Comm:           "nslookup",
+					Qr:             dnsTypes.DNSPktTypeResponse,
+					Nameserver:     dnsServer,
+					NameserverPort: 53,
+					PktType:        "HOST",
+					DNSName:        "nodomain.fake.test.com.",
+					QType:          "A",
+					Rcode:          "NXDomain",
+					Latency:        1,
+					NumAnswers:     0,
 				},
 			}
 
@@ -124,6 +130,7 @@ func TestTraceDns(t *testing.T) {
 				e.NetNsID = 0
 				e.Pid = 0
 				e.Tid = 0
+				e.ClientPort = 0
 
 				// Latency should be > 0 only for DNS responses.
 				if e.Latency > 0 {
@@ -38,6 +38,7 @@ func TestTraceFsslower(t *testing.T) {
 				Event: BuildBaseEvent(ns),
 				Comm:  "cat",
 				File:  "foo",
+				Path:  "/foo",
 				Op:    "R",
 			}
 
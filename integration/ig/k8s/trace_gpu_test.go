@@ -0,0 +1,71 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	gpuTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/gpu/types"
+)
+
+func TestTraceGpu(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-trace-gpu")
+
+	// The test nodes don't have GPUs: the failed opens of the device nodes
+	// are reported too
+	gpuCmd := &Command{
+		Name:         "StartGpuGadget",
+		Cmd:          fmt.Sprintf("ig trace gpu -o json --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &gpuTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				Comm:      "cat",
+				Operation: gpuTypes.OperationOpen,
+				Device:    "/dev/nvidia0",
+				Ret:       -2,
+				Err:       "ENOENT",
+			}
+
+			normalize := func(e *gpuTypes.Event) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Timestamp = 0
+				e.Pid = 0
+				e.MountNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		gpuCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		BusyboxPodRepeatCommand(ns, "cat /dev/nvidia0"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
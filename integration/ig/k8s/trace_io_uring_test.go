@@ -0,0 +1,75 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	iouringTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/io-uring/types"
+)
+
+// ioUringPodArgs installs fio and reads a file with io_uring in a loop
+const ioUringPodArgs = "apk add fio > /dev/null && while true; do fio --name test --ioengine io_uring --rw randread --size 64k --filename /tmp/test-file > /dev/null; sleep 0.1; done"
+
+func TestTraceIoUring(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-trace-io-uring")
+
+	ioUringCmd := &Command{
+		Name:         "StartIoUringGadget",
+		Cmd:          fmt.Sprintf("ig trace io-uring -o json --yes --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &iouringTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				Comm:      "fio",
+				Operation: "read",
+				File:      "test-file",
+				Res:       4096,
+			}
+
+			normalize := func(e *iouringTypes.Event) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Timestamp = 0
+				e.Pid = 0
+				e.Tid = 0
+				e.Fd = 0
+				e.Latency = 0
+				e.UserData = 0
+				e.MountNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		ioUringCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		PodCommand("test-pod", "alpine", ns, `["/bin/sh", "-c"]`, ioUringPodArgs),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	lsmdenialTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/lsm-denial/types"
+)
+
+// readOnlyProfile is an AppArmor profile allowing to read and execute any
+// file, but not to write
+const readOnlyProfile = "ig-test-read-only"
+
+// loadReadOnlyProfileCommand returns a Command that loads readOnlyProfile on
+// the node
+func loadReadOnlyProfileCommand() *Command {
+	return &Command{
+		Name: "LoadAppArmorProfile",
+		Cmd: fmt.Sprintf(`apparmor_parser -r <<"EOF"
+#include <tunables/global>
+
+profile %s flags=(attach_disconnected) {
+  #include <abstractions/base>
+
+  /** rix,
+}
+EOF
+`, readOnlyProfile),
+	}
+}
+
+// unloadReadOnlyProfileCommand returns a Command that removes readOnlyProfile
+// from the node
+func unloadReadOnlyProfileCommand() *Command {
+	return &Command{
+		Name: "UnloadAppArmorProfile",
+		Cmd:  fmt.Sprintf("echo -n %s > /sys/kernel/security/apparmor/.remove", readOnlyProfile),
+	}
+}
+
+// readOnlyPodCommand returns a Command that creates the test pod, confined by
+// readOnlyProfile and writing a file in a loop
+func readOnlyPodCommand(ns string) *Command {
+	return &Command{
+		Name: "RunReadOnlyPod",
+		Cmd: fmt.Sprintf(`kubectl apply -f - <<"EOF"
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test-pod
+  namespace: %s
+  annotations:
+    container.apparmor.security.beta.kubernetes.io/test-pod: localhost/%s
+spec:
+  restartPolicy: Never
+  terminationGracePeriodSeconds: 0
+  containers:
+  - name: test-pod
+    image: busybox
+    command: ["/bin/sh", "-c"]
+    args:
+    - while true; do echo hello > /tmp/hello; sleep 0.1; done
+EOF
+`, ns, readOnlyProfile),
+		ExpectedString: "pod/test-pod created\n",
+	}
+}
+
+func TestTraceLsmDenial(t *testing.T) {
+	if err := loadReadOnlyProfileCommand().RunWithoutTest(); err != nil {
+		t.Skipf("Skip running trace lsm-denial gadget: loading AppArmor profile: %s", err)
+	}
+	t.Cleanup(func() {
+		RunTestSteps([]*Command{unloadReadOnlyProfileCommand()}, t)
+	})
+
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-trace-lsm-denial")
+
+	lsmDenialCmd := &Command{
+		Name:         "StartLsmDenialGadget",
+		Cmd:          fmt.Sprintf("ig trace lsm-denial -o json --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &lsmdenialTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				Comm:      "sh",
+				LSM:       lsmdenialTypes.LSMAppArmor,
+				Operation: "mknod",
+				Subject:   readOnlyProfile,
+				Target:    "/tmp/hello",
+			}
+
+			normalize := func(e *lsmdenialTypes.Event) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Timestamp = 0
+				e.Pid = 0
+				e.Tid = 0
+				e.Uid = 0
+				e.ContainerUid = 0
+				e.MountNsID = 0
+				e.Info = ""
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		lsmDenialCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		readOnlyPodCommand(ns),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	natTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/nat/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+func TestTraceNat(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-trace-nat")
+
+	commandsPreTest := []*Command{
+		CreateTestNamespaceCommand(ns),
+		PodCommand("nginx-pod", "nginx", ns, "", ""),
+		{
+			Name:           "CreateService",
+			Cmd:            fmt.Sprintf("kubectl expose -n %s pod nginx-pod --port 80", ns),
+			ExpectedRegexp: "service/nginx-pod exposed",
+		},
+		WaitUntilPodReadyCommand(ns, "nginx-pod"),
+	}
+
+	RunTestSteps(commandsPreTest, t)
+	nginxIP, err := GetTestPodIP(ns, "nginx-pod")
+	if err != nil {
+		t.Fatalf("failed to get pod ip %s", err)
+	}
+
+	traceNatCmd := &Command{
+		Name:         "TraceNat",
+		Cmd:          "ig trace nat -o json",
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			testPodIP, err := GetTestPodIP(ns, "test-pod")
+			if err != nil {
+				return fmt.Errorf("getting pod ip: %w", err)
+			}
+
+			// The connection to the service is translated to the nginx pod
+			// by kube-proxy, in the network namespace of the host
+			expectedEntry := &natTypes.Event{
+				Event: eventtypes.Event{
+					Type: eventtypes.NORMAL,
+				},
+				IPVersion:       4,
+				Protocol:        "TCP",
+				NAT:             "DNAT",
+				Saddr:           testPodIP,
+				Dport:           80,
+				TranslatedSaddr: testPodIP,
+				TranslatedDaddr: nginxIP,
+				TranslatedDport: 80,
+			}
+
+			normalize := func(e *natTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.NetNsID = 0
+				e.Sport = 0
+				e.TranslatedSport = 0
+				// The IP of the service
+				e.Daddr = ""
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		traceNatCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		BusyboxPodRepeatCommand(ns, "wget -q -O /dev/null nginx-pod:80"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	packetdropTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/packetdrop/types"
+)
+
+func TestTracePacketdrop(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-trace-packetdrop")
+
+	packetdropCmd := &Command{
+		Name:         "StartPacketdropGadget",
+		Cmd:          fmt.Sprintf("ig trace packetdrop -o json --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &packetdropTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				Comm:      "nc",
+				IPVersion: 4,
+				Protocol:  "UDP",
+				Saddr:     "127.0.0.1",
+				Daddr:     "127.0.0.1",
+				Dport:     9999,
+				Reason:    columns.Enum{Name: "NO_SOCKET"},
+			}
+
+			normalize := func(e *packetdropTypes.Event) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Timestamp = 0
+				e.Pid = 0
+				e.Sport = 0
+				e.MountNsID = 0
+				e.NetNsID = 0
+				// The value of the drop reasons depends on the kernel version
+				e.Reason.Value = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		packetdropCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		BusyboxPodRepeatCommand(ns, "echo hello | nc -u -w1 127.0.0.1 9999"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
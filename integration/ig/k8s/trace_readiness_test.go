@@ -0,0 +1,88 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	readinessTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/readiness/types"
+)
+
+func TestTraceReadiness(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-trace-readiness")
+
+	readinessCmd := &Command{
+		Name:         "StartReadinessGadget",
+		Cmd:          fmt.Sprintf("ig trace readiness -o json --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntries := []*readinessTypes.Event{
+				{
+					Event:     BuildBaseEvent(ns),
+					Comm:      "nginx",
+					Operation: "listen",
+					Port:      80,
+					// Don't check the exact value but check that it isn't empty
+					Elapsed: 1,
+				},
+				{
+					Event:     BuildBaseEvent(ns),
+					Comm:      "nginx",
+					Operation: "accept",
+					IPVersion: 4,
+					Addr:      "127.0.0.1",
+					Port:      80,
+					Elapsed:   1,
+				},
+			}
+
+			normalize := func(e *readinessTypes.Event) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Timestamp = 0
+				e.Pid = 0
+				e.MountNsID = 0
+				if e.Elapsed > 0 {
+					e.Elapsed = 1
+				}
+				// nginx listens on both IPv4 and IPv6, the first one is reported
+				if e.Operation == "listen" {
+					e.IPVersion = 0
+					e.Addr = ""
+				}
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntries...)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		readinessCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		PodCommand("test-pod", "nginx", ns, "[sh, -c]", "nginx && while true; do curl 127.0.0.1; sleep 0.1; done"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
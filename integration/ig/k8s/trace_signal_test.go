@@ -18,7 +18,10 @@ import (
 	"fmt"
 	"testing"
 
+	"golang.org/x/sys/unix"
+
 	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	signalTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/signal/types"
 )
 
@@ -34,7 +37,7 @@ func TestTraceSignal(t *testing.T) {
 			expectedEntry := &signalTypes.Event{
 				Event:  BuildBaseEvent(ns),
 				Comm:   "sh",
-				Signal: "SIGTERM",
+				Signal: columns.Enum{Name: "SIGTERM", Value: int64(unix.SIGTERM)},
 			}
 
 			normalize := func(e *signalTypes.Event) {
@@ -32,9 +32,10 @@ func TestTraceSni(t *testing.T) {
 		StartAndStop: true,
 		ExpectedOutputFn: func(output string) error {
 			expectedEntry := &sniTypes.Event{
-				Event: BuildBaseEvent(ns),
-				Comm:  "wget",
-				Name:  "kubernetes.default.svc.cluster.local",
+				Event:   BuildBaseEvent(ns),
+				Comm:    "wget",
+				Name:    "kubernetes.default.svc.cluster.local",
+				Outcome: sniTypes.OutcomeOK,
 			}
 
 			normalize := func(e *sniTypes.Event) {
@@ -48,6 +49,13 @@ func TestTraceSni(t *testing.T) {
 				e.NetNsID = 0
 				e.Pid = 0
 				e.Tid = 0
+				e.SrcIP = ""
+				e.DstIP = ""
+				e.SrcPort = 0
+				e.DstPort = 0
+				e.ALPN = nil
+				e.Versions = nil
+				e.Version = ""
 			}
 
 			return ExpectEntriesToMatch(output, normalize, expectedEntry)
@@ -0,0 +1,140 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	sqlTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/sql/types"
+)
+
+// postgresPodCommand returns a Command that creates a PostgreSQL server pod
+func postgresPodCommand(ns string) *Command {
+	return &Command{
+		Name: "RunPostgresPod",
+		Cmd: fmt.Sprintf(`kubectl apply -f - <<"EOF"
+apiVersion: v1
+kind: Pod
+metadata:
+  name: postgres
+  namespace: %s
+spec:
+  restartPolicy: Never
+  terminationGracePeriodSeconds: 0
+  containers:
+  - name: postgres
+    image: postgres:15
+    env:
+    - name: POSTGRES_PASSWORD
+      value: secret
+    readinessProbe:
+      exec:
+        command: ["pg_isready", "-U", "postgres"]
+EOF
+`, ns),
+		ExpectedString: "pod/postgres created\n",
+	}
+}
+
+// psqlPodCommand returns a Command that creates the test pod, sending a query
+// to the PostgreSQL server at the given address in a loop with psql
+func psqlPodCommand(ns, server string) *Command {
+	return &Command{
+		Name: "RunPsqlPod",
+		Cmd: fmt.Sprintf(`kubectl apply -f - <<"EOF"
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test-pod
+  namespace: %s
+spec:
+  restartPolicy: Never
+  terminationGracePeriodSeconds: 0
+  containers:
+  - name: test-pod
+    image: postgres:15
+    command: ["/bin/sh", "-c"]
+    args:
+    - while true; do psql -h %s -U postgres -c 'SELECT 1'; sleep 1; done
+    env:
+    - name: PGPASSWORD
+      value: secret
+EOF
+`, ns, server),
+		ExpectedString: "pod/test-pod created\n",
+	}
+}
+
+func TestTraceSql(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-trace-sql")
+
+	commandsPreTest := []*Command{
+		CreateTestNamespaceCommand(ns),
+		postgresPodCommand(ns),
+		WaitUntilPodReadyCommand(ns, "postgres"),
+	}
+
+	RunTestSteps(commandsPreTest, t)
+	postgresIP, err := GetTestPodIP(ns, "postgres")
+	if err != nil {
+		t.Fatalf("failed to get pod ip %s", err)
+	}
+
+	traceSqlCmd := &Command{
+		Name:         "TraceSql",
+		Cmd:          fmt.Sprintf("ig trace sql -o json --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &sqlTypes.Event{
+				Event:   BuildBaseEvent(ns),
+				Comm:    "psql",
+				Library: sqlTypes.LibPQ,
+				// The literal values are hidden by default
+				Query:  "SELECT ?",
+				Status: "PGRES_TUPLES_OK",
+				Rows:   1,
+			}
+
+			normalize := func(e *sqlTypes.Event) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Timestamp = 0
+				e.MountNsID = 0
+				e.Pid = 0
+				e.Tid = 0
+				e.Latency = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		traceSqlCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		psqlPodCommand(ns, postgresIP),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
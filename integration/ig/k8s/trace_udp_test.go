@@ -0,0 +1,73 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	udpTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/udp/types"
+)
+
+func TestTraceUdp(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-trace-udp")
+
+	udpCmd := &Command{
+		Name:         "StartUdpGadget",
+		Cmd:          fmt.Sprintf("ig trace udp -o json --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &udpTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				Operation: "send",
+				Comm:      "nc",
+				IPVersion: 4,
+				Daddr:     "127.0.0.1",
+				Dport:     9999,
+				Bytes:     6,
+			}
+
+			normalize := func(e *udpTypes.Event) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Timestamp = 0
+				e.Pid = 0
+				e.Saddr = ""
+				e.Sport = 0
+				e.MountNsID = 0
+				e.NetNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		udpCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		BusyboxPodRepeatCommand(ns, "echo hello | nc -u -w1 127.0.0.1 9999"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+	unixTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/unix/types"
+)
+
+// unixSocketPodArgs is a python program listening on a UNIX socket and
+// connecting to it in a loop
+const unixSocketPodArgs = `"import socket, threading, time\npath = '/tmp/test.sock'\nserver = socket.socket(socket.AF_UNIX, socket.SOCK_STREAM)\nserver.bind(path)\nserver.listen()\ndef serve():\n    while True:\n        server.accept()[0].close()\nthreading.Thread(target=serve, daemon=True).start()\nwhile True:\n    client = socket.socket(socket.AF_UNIX, socket.SOCK_STREAM)\n    client.connect(path)\n    client.close()\n    time.sleep(0.1)"`
+
+func TestTraceUnix(t *testing.T) {
+	t.Parallel()
+	ns := GenerateTestNamespaceName("test-trace-unix")
+
+	traceUnixCmd := &Command{
+		Name:         "TraceUnix",
+		Cmd:          fmt.Sprintf("ig trace unix -o json --runtimes=%s", *containerRuntime),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntries := []*unixTypes.Event{
+				{
+					Event:      BuildBaseEvent(ns),
+					Comm:       "python3",
+					Operation:  unixTypes.OperationConnect,
+					SocketType: "stream",
+					Path:       "/tmp/test.sock",
+					PeerComm:   "python3",
+				},
+				{
+					Event:      BuildBaseEvent(ns),
+					Comm:       "python3",
+					Operation:  unixTypes.OperationAccept,
+					SocketType: "stream",
+					Path:       "/tmp/test.sock",
+					PeerComm:   "python3",
+				},
+			}
+
+			normalize := func(e *unixTypes.Event) {
+				// TODO: Handle it once we support getting K8s container name for docker
+				// Issue: https://github.com/inspektor-gadget/inspektor-gadget/issues/737
+				if *containerRuntime == ContainerRuntimeDocker {
+					e.Container = "test-pod"
+				}
+
+				e.Timestamp = 0
+				e.MountNsID = 0
+				e.Pid = 0
+				e.Tid = 0
+				e.Uid = 0
+				e.ContainerUid = 0
+				e.PeerPid = 0
+				e.PeerUid = 0
+				e.PeerMountNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntries...)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		traceUnixCmd,
+		SleepForSecondsCommand(2), // wait to ensure ig has started
+		PodCommand("test-pod", "python:3-alpine", ns, `["python3", "-c"]`, unixSocketPodArgs),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
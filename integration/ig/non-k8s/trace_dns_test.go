@@ -41,12 +41,13 @@ func TestTraceDns(t *testing.T) {
 							Container: cn,
 						},
 					},
-					Qr:         dnsTypes.DNSPktTypeQuery,
-					Comm:       "nslookup",
-					Nameserver: "127.0.0.1",
-					PktType:    "OUTGOING",
-					DNSName:    "fake.test.com.",
-					QType:      "A",
+					Qr:             dnsTypes.DNSPktTypeQuery,
+					Comm:           "nslookup",
+					Nameserver:     "127.0.0.1",
+					NameserverPort: 53,
+					PktType:        "OUTGOING",
+					DNSName:        "fake.test.com.",
+					QType:          "A",
 				},
 				{
 					Event: eventtypes.Event{
@@ -55,16 +56,17 @@ func TestTraceDns(t *testing.T) {
 							Container: cn,
 						},
 					},
-					Qr:         dnsTypes.DNSPktTypeResponse,
-					Comm:       "nslookup",
-					Nameserver: "127.0.0.1",
-					PktType:    "HOST",
-					DNSName:    "fake.test.com.",
-					QType:      "A",
-					Rcode:      "NoError",
-					Latency:    1,
-					NumAnswers: 1,
-					Addresses:  []string{"127.0.0.1"},
+					Qr:             dnsTypes.DNSPktTypeResponse,
+					Comm:           "nslookup",
+					Nameserver:     "127.0.0.1",
+					NameserverPort: 53,
+					PktType:        "HOST",
+					DNSName:        "fake.test.com.",
+					QType:          "A",
+					Rcode:          "NoError",
+					Latency:        1,
+					NumAnswers:     1,
+					Addresses:      []string{"127.0.0.1"},
 				},
 				{
 					Event: eventtypes.Event{
@@ -73,12 +75,13 @@ func TestTraceDns(t *testing.T) {
 							Container: cn,
 						},
 					},
-					Qr:         dnsTypes.DNSPktTypeQuery,
-					Comm:       "nslookup",
-					Nameserver: "127.0.0.1",
-					PktType:    "OUTGOING",
-					DNSName:    "fake.test.com.",
-					QType:      "AAAA",
+					Qr:             dnsTypes.DNSPktTypeQuery,
+					Comm:           "nslookup",
+					Nameserver:     "127.0.0.1",
+					NameserverPort: 53,
+					PktType:        "OUTGOING",
+					DNSName:        "fake.test.com.",
+					QType:          "AAAA",
 				},
 				{
 					Event: eventtypes.Event{
@@ -87,16 +90,17 @@ func TestTraceDns(t *testing.T) {
 							Container: cn,
 						},
 					},
-					Qr:         dnsTypes.DNSPktTypeResponse,
-					Comm:       "nslookup",
-					Nameserver: "127.0.0.1",
-					PktType:    "HOST",
-					DNSName:    "fake.test.com.",
-					QType:      "AAAA",
-					Rcode:      "NoError",
-					Latency:    1,
-					NumAnswers: 1,
-					Addresses:  []string{"::1"},
+					Qr:             dnsTypes.DNSPktTypeResponse,
+					Comm:           "nslookup",
+					Nameserver:     "127.0.0.1",
+					NameserverPort: 53,
+					PktType:        "HOST",
+					DNSName:        "fake.test.com.",
+					QType:          "AAAA",
+					Rcode:          "NoError",
+					Latency:        1,
+					NumAnswers:     1,
+					Addresses:      []string{"::1"},
 				},
 			}
 
@@ -107,6 +111,7 @@ func TestTraceDns(t *testing.T) {
 				e.NetNsID = 0
 				e.Pid = 0
 				e.Tid = 0
+				e.ClientPort = 0
 
 				// Latency should be > 0 only for DNS responses.
 				if e.Latency > 0 {
@@ -0,0 +1,61 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestAdviseWorkloadProfile(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-advise-workload-profile")
+
+	t.Parallel()
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodRepeatCommand(ns, "echo foo"),
+		WaitUntilTestPodReadyCommand(ns),
+		{
+			Name: "RunAdviseWorkloadProfileGadget",
+			Cmd:  fmt.Sprintf("id=$($KUBECTL_GADGET advise workload-profile start -n %s -p test-pod); sleep 30; $KUBECTL_GADGET advise workload-profile stop $id", ns),
+			// The pod doesn't have any owner, so it's its own workload
+			ExpectedRegexp: fmt.Sprintf(`(?s)kind: WorkloadProfile.*name: pod-test-pod
+  namespace: %s
+spec:
+  containers:
+  - name: test-pod
+    syscalls:.*- write.*workload:
+    apiVersion: v1
+    kind: Pod
+    name: test-pod`, ns),
+		},
+		{
+			Name:           "RunAdviseWorkloadProfileGadgetToResource",
+			Cmd:            fmt.Sprintf("id=$($KUBECTL_GADGET advise workload-profile start -n %s -p test-pod -m workload-profile); sleep 30; $KUBECTL_GADGET advise workload-profile stop $id", ns),
+			ExpectedString: fmt.Sprintf("Successfully updated workload profile: %s/pod-test-pod\n", ns),
+		},
+		{
+			Name:           "CheckWorkloadProfileResource",
+			Cmd:            fmt.Sprintf("kubectl get workloadprofile -n %s pod-test-pod -o jsonpath='{.spec.workload.kind}/{.spec.workload.name}'", ns),
+			ExpectedString: "Pod/test-pod",
+		},
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
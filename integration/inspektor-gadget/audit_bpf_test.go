@@ -0,0 +1,76 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	auditbpfTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/audit/bpf/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+// bpfMapPodArgs is a python program creating an eBPF hash map named "test",
+// in a loop
+const bpfMapPodArgs = `"import ctypes, os, struct, time\nlibc = ctypes.CDLL(None, use_errno=True)\nnr = 321 if os.uname().machine == 'x86_64' else 280\nattr = struct.pack('7I16s', 1, 4, 4, 1, 0, 0, 0, b'test').ljust(128, b'\\0')\nwhile True:\n    fd = libc.syscall(nr, 0, attr, len(attr))\n    if fd >= 0:\n        os.close(fd)\n    time.sleep(0.1)"`
+
+func TestAuditBpf(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-audit-bpf")
+
+	t.Parallel()
+
+	auditBpfCmd := &Command{
+		Name:         "StartAuditBpfGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET audit bpf -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &auditbpfTypes.Event{
+				Event:      BuildBaseEvent(ns),
+				Comm:       "python3",
+				Cmd:        columns.Enum{Name: "BPF_MAP_CREATE", Value: 0},
+				Type:       columns.Enum{Name: "Hash", Value: 1},
+				Name:       "test",
+				KeySize:    4,
+				ValueSize:  4,
+				MaxEntries: 1,
+			}
+
+			normalize := func(e *auditbpfTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.Pid = 0
+				e.Tid = 0
+				// The map is only created if unprivileged eBPF is enabled on the node
+				e.Fd = 0
+				e.Err = ""
+				e.MountNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		auditBpfCmd,
+		PodCommand("test-pod", "python:3-alpine", ns, `["python3", "-c"]`, bpfMapPodArgs),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	auditdevicesTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/audit/devices/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestAuditDevices(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-audit-devices")
+
+	t.Parallel()
+
+	auditDevicesCmd := &Command{
+		Name:         "StartAuditDevicesGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET audit devices -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &auditdevicesTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				Comm:      "mknod",
+				Operation: auditdevicesTypes.OperationMknod,
+				Device:    "b 254:0",
+				Access:    "m",
+				Path:      "/tmp/vda",
+			}
+
+			normalize := func(e *auditdevicesTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.Pid = 0
+				e.MountNsID = 0
+				// Whether the container has the CAP_MKNOD capability depends
+				// on the container runtime
+				e.Ret = 0
+				e.Err = ""
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		auditDevicesCmd,
+		BusyboxPodRepeatCommand(ns, "rm -f /tmp/vda; mknod /tmp/vda b 254 0"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
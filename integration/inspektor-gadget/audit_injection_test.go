@@ -0,0 +1,69 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	auditinjectionTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/audit/injection/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+// memfdExecPodArgs is a python program executing a copy of busybox from a
+// memfd, in a loop
+const memfdExecPodArgs = `"import os, time\nwhile True:\n    fd = os.memfd_create('payload')\n    os.write(fd, open('/bin/busybox', 'rb').read())\n    if os.fork() == 0:\n        os.execv(f'/proc/self/fd/{fd}', ['true'])\n    os.wait()\n    os.close(fd)\n    time.sleep(0.1)"`
+
+func TestAuditInjection(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-audit-injection")
+
+	t.Parallel()
+
+	auditInjectionCmd := &Command{
+		Name:         "StartAuditInjectionGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET audit injection -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &auditinjectionTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				Operation: auditinjectionTypes.OperationMemfdExec,
+				File:      "memfd:payload",
+			}
+
+			normalize := func(e *auditinjectionTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.Pid = 0
+				e.Tid = 0
+				// The command is the name of the file descriptor
+				e.Comm = ""
+				e.MountNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		auditInjectionCmd,
+		PodCommand("test-pod", "python:3-alpine", ns, `["python3", "-c"]`, memfdExecPodArgs),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
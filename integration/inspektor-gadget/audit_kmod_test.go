@@ -0,0 +1,71 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	auditkmodTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/audit/kmod/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestAuditKmod(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-audit-kmod")
+
+	t.Parallel()
+
+	// The test pod doesn't have CAP_SYS_MODULE: the kernel refuses the
+	// module, an empty file, before parsing it
+	auditKmodCmd := &Command{
+		Name:         "StartAuditKmodGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET audit kmod -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &auditkmodTypes.Event{
+				Event:   BuildBaseEvent(ns),
+				Comm:    "insmod",
+				Syscall: columns.Enum{Name: "finit_module"},
+				Module:  "test",
+				File:    "/tmp/test.ko",
+				Ret:     -1,
+				Err:     "EPERM",
+			}
+
+			normalize := func(e *auditkmodTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.Pid = 0
+				e.Tid = 0
+				e.Syscall.Value = 0
+				e.MountNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		auditKmodCmd,
+		PodCommand("test-pod", "alpine", ns, `["/bin/sh", "-c"]`, "apk add kmod > /dev/null && touch /tmp/test.ko && while true; do insmod /tmp/test.ko; sleep 0.1; done"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -18,6 +18,9 @@ import (
 	"fmt"
 	"testing"
 
+	"golang.org/x/sys/unix"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	seccompauditTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/audit/seccomp/types"
 
 	. "github.com/inspektor-gadget/inspektor-gadget/integration"
@@ -91,7 +94,7 @@ EOF
 			ExpectedOutputFn: func(output string) error {
 				expectedEntry := &seccompauditTypes.Event{
 					Event:   BuildBaseEvent(ns),
-					Syscall: "unshare",
+					Syscall: columns.Enum{Name: "unshare", Value: unix.SYS_UNSHARE},
 					Code:    "kill_thread",
 					Comm:    "unshare",
 				}
@@ -0,0 +1,73 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	generateTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/generate/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestGenerate(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-generate")
+
+	t.Parallel()
+
+	generateCmd := &Command{
+		Name: "RunGenerateGadget",
+		Cmd:  fmt.Sprintf("$KUBECTL_GADGET generate -n %s -o json --count 2 --fields app=shop,ok:bool --timeout 5", ns),
+		ExpectedOutputFn: func(output string) error {
+			// The test pod is the only container selected, all the events
+			// come from it
+			expectedEntries := []*generateTypes.Event{
+				{
+					Event:  BuildBaseEvent(ns),
+					Seq:    1,
+					Fields: map[string]any{"app": "shop"},
+				},
+				{
+					Event:  BuildBaseEvent(ns),
+					Seq:    2,
+					Fields: map[string]any{"app": "shop"},
+				},
+			}
+
+			normalize := func(e *generateTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.MountNsID = 0
+				// Only check the type of the random field
+				if _, ok := e.Fields["ok"].(bool); ok {
+					delete(e.Fields, "ok")
+				}
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntries...)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodCommand(ns, "sleep inf"),
+		WaitUntilTestPodReadyCommand(ns),
+		generateCmd,
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
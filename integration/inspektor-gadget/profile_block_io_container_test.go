@@ -0,0 +1,60 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	profileblockioContainerTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/block-io-container/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestProfileBlockIOContainer(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-profile-block-io-container")
+
+	t.Parallel()
+
+	profileBlockIOContainerCmd := &Command{
+		Name: "RunProfileBlockIOContainerGadget",
+		Cmd:  fmt.Sprintf("$KUBECTL_GADGET profile block-io-container -n %s -o json --interval 0 --timeout 10", ns),
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &profileblockioContainerTypes.Report{
+				CommonData: BuildCommonData(ns),
+			}
+
+			normalize := func(e *profileblockioContainerTypes.Report) {
+				e.Node = ""
+				e.CgroupID = 0
+				e.Count = 0
+				e.Average = 0
+				e.Histogram = nil
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodRepeatCommand(ns, "dd if=/dev/zero of=/tmp/test-file bs=64k count=16 conv=fsync 2> /dev/null"),
+		WaitUntilTestPodReadyCommand(ns),
+		profileBlockIOContainerCmd,
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
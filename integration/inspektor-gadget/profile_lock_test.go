@@ -0,0 +1,69 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	profilelockTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/lock/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+// contendedPodArgs is a python program whose threads contend for the global
+// interpreter lock, built on futexes
+const contendedPodArgs = `"import threading\ndef spin():\n    while True:\n        pass\nfor i in range(4):\n    threading.Thread(target=spin).start()"`
+
+func TestProfileLock(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-profile-lock")
+
+	t.Parallel()
+
+	profileLockCmd := &Command{
+		Name: "RunProfileLockGadget",
+		Cmd:  fmt.Sprintf("$KUBECTL_GADGET profile lock -n %s -o json --yes --timeout 10", ns),
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &profilelockTypes.Report{
+				CommonData: BuildCommonData(ns),
+				Comm:       "python3",
+				Type:       profilelockTypes.LockTypeFutex,
+			}
+
+			normalize := func(e *profilelockTypes.Report) {
+				e.Node = ""
+				e.Pid = 0
+				e.KernelStack = nil
+				e.UserStack = nil
+				e.Address = ""
+				e.Count = 0
+				e.Total = 0
+				e.Max = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		PodCommand("test-pod", "python:3-alpine", ns, `["python3", "-c"]`, contendedPodArgs),
+		WaitUntilTestPodReadyCommand(ns),
+		profileLockCmd,
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,65 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	profilememleakTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/memleak/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+// leakyPodArgs is a python program that allocates memory without freeing it
+const leakyPodArgs = `"import time\nx = []\nwhile True:\n    x.append(bytearray(100000))\n    time.sleep(0.1)"`
+
+func TestProfileMemleak(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-profile-memleak")
+
+	t.Parallel()
+
+	profileMemleakCmd := &Command{
+		Name: "RunProfileMemleakGadget",
+		Cmd:  fmt.Sprintf("$KUBECTL_GADGET profile memleak --user -n %s -o json --yes --interval 0 --timeout 10", ns),
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &profilememleakTypes.Report{
+				CommonData: BuildCommonData(ns),
+				Comm:       "python3",
+				Kind:       profilememleakTypes.KindUser,
+			}
+
+			normalize := func(e *profilememleakTypes.Report) {
+				e.Node = ""
+				e.Pid = 0
+				e.Allocations = 0
+				e.Bytes = 0
+				e.Stack = nil
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		PodCommand("test-pod", "python:3-alpine", ns, `["python3", "-c"]`, leakyPodArgs),
+		WaitUntilTestPodReadyCommand(ns),
+		profileMemleakCmd,
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,63 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	profilerunqueueTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/run-queue/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestProfileRunQueue(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-profile-run-queue")
+
+	t.Parallel()
+
+	profileRunQueueCmd := &Command{
+		Name: "RunProfileRunQueueGadget",
+		Cmd:  fmt.Sprintf("$KUBECTL_GADGET profile run-queue -n %s -o json --yes --interval 0 --timeout 10", ns),
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &profilerunqueueTypes.Report{
+				CommonData: BuildCommonData(ns),
+			}
+
+			normalize := func(e *profilerunqueueTypes.Report) {
+				e.Node = ""
+				e.Max = 0
+				e.Pid = 0
+				e.Tid = 0
+				e.Comm = ""
+				e.Count = 0
+				e.Average = 0
+				e.Histogram = nil
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodRepeatCommand(ns, "sleep 0.1"),
+		WaitUntilTestPodReadyCommand(ns),
+		profileRunQueueCmd,
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
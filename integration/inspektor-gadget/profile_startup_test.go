@@ -0,0 +1,94 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	profilestartupTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/startup/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestProfileStartup(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-profile-startup")
+
+	t.Parallel()
+
+	// The gadget only records the containers started while it's running:
+	// the pod is created by the same command, once the gadget started.
+	profileStartupCmd := &Command{
+		Name: "StartProfileStartupGadget",
+		Cmd: fmt.Sprintf(`$KUBECTL_GADGET profile startup -n %s -o json --timeout 15 &
+			sleep 5
+			kubectl run -n %s test-pod --image busybox --restart=Never -- sh -c 'cat /etc/app.conf; nc -w 1 127.0.0.1 9090; sleep inf' > /dev/null
+			wait`, ns, ns),
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &profilestartupTypes.Report{
+				CommonData: BuildCommonData(ns),
+				Timeline: []profilestartupTypes.Entry{
+					{
+						Comm:      "cat",
+						Operation: profilestartupTypes.OperationOpen,
+						Target:    "/etc/app.conf",
+						Error:     "ENOENT",
+					},
+					{
+						Comm:      "nc",
+						Operation: profilestartupTypes.OperationConnect,
+						Target:    "127.0.0.1:9090",
+					},
+				},
+			}
+
+			normalize := func(e *profilestartupTypes.Report) {
+				e.Node = ""
+				e.Execs = 0
+				e.Opens = 0
+				e.Connects = 0
+				e.Dropped = 0
+
+				// Only keep the operations of the command of the pod, not the
+				// ones of the runtime and of the libraries
+				var timeline []profilestartupTypes.Entry
+				for _, entry := range e.Timeline {
+					entry.Elapsed = 0
+					entry.Pid = 0
+					switch {
+					case entry.Comm == "cat" && entry.Target == "/etc/app.conf":
+					case entry.Comm == "nc" && entry.Operation == profilestartupTypes.OperationConnect:
+						// The connection can fail right away or time out
+						entry.Error = ""
+					default:
+						continue
+					}
+					timeline = append(timeline, entry)
+				}
+				e.Timeline = timeline
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		profileStartupCmd,
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	snapshotfsusageTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/fsusage/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestSnapshotFsusage(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-snapshot-fsusage")
+
+	t.Parallel()
+
+	commandsPreTest := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodCommand(ns, "mkdir -p /var/log/app; dd if=/dev/zero of=/var/log/app/test.log bs=1M count=10; sleep inf"),
+		WaitUntilTestPodReadyCommand(ns),
+	}
+	RunTestSteps(commandsPreTest, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+
+	t.Cleanup(func() {
+		commandsPostTest := []*Command{
+			DeleteTestNamespaceCommand(ns),
+		}
+		RunTestSteps(commandsPostTest, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+	})
+
+	nodeName, err := GetPodNode(ns, "test-pod")
+	if err != nil {
+		t.Fatalf("getting test-pod node: %s", err)
+	}
+
+	commands := []*Command{
+		{
+			Name: "RunFsusageGadget",
+			Cmd:  fmt.Sprintf("$KUBECTL_GADGET snapshot fsusage -n %s -o json --node %s --top 1", ns, nodeName),
+			ExpectedOutputFn: func(output string) error {
+				expectedEntry := &snapshotfsusageTypes.Event{
+					Event: BuildBaseEvent(ns),
+					TopDirs: []snapshotfsusageTypes.DirUsage{
+						{Path: "/var/log"},
+					},
+				}
+				expectedEntry.Node = nodeName
+
+				normalize := func(e *snapshotfsusageTypes.Event) {
+					e.MountNsID = 0
+					e.UpperDir = ""
+					e.Usage = 0
+					e.Size = 0
+					e.Inodes = 0
+					for i := range e.TopDirs {
+						e.TopDirs[i].Usage = 0
+						e.TopDirs[i].Inodes = 0
+					}
+				}
+
+				return ExpectEntriesInArrayToMatch(output, normalize, expectedEntry)
+			},
+		},
+	}
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,111 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	snapshotinventoryTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/inventory/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+// bpfMapHolderPodArgs is a python program creating an eBPF hash map named
+// "test" and keeping its file descriptor open
+const bpfMapHolderPodArgs = `"import ctypes, os, struct, time\nlibc = ctypes.CDLL(None, use_errno=True)\nnr = 321 if os.uname().machine == 'x86_64' else 280\nattr = struct.pack('7I16s', 1, 4, 4, 1, 0, 0, 0, b'test').ljust(128, b'\\0')\nfd = libc.syscall(nr, 0, attr, len(attr))\nif fd < 0:\n    raise OSError(ctypes.get_errno(), 'creating map')\nwhile True:\n    time.sleep(60)"`
+
+// bpfMapHolderPodCommand returns a Command that creates the test pod holding a
+// BPF map. It's privileged as unprivileged eBPF is usually disabled.
+func bpfMapHolderPodCommand(ns string) *Command {
+	return &Command{
+		Name: "RunBpfMapHolderPod",
+		Cmd: fmt.Sprintf(`kubectl apply -f - <<"EOF"
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test-pod
+  namespace: %s
+spec:
+  restartPolicy: Never
+  terminationGracePeriodSeconds: 0
+  containers:
+  - name: test-pod
+    image: python:3-alpine
+    command: ["python3", "-c"]
+    args: [%s]
+    securityContext:
+      privileged: true
+EOF
+`, ns, bpfMapHolderPodArgs),
+		ExpectedString: "pod/test-pod created\n",
+	}
+}
+
+func TestSnapshotInventory(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-snapshot-inventory")
+
+	t.Parallel()
+
+	commandsPreTest := []*Command{
+		CreateTestNamespaceCommand(ns),
+		bpfMapHolderPodCommand(ns),
+		WaitUntilTestPodReadyCommand(ns),
+	}
+	RunTestSteps(commandsPreTest, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+
+	t.Cleanup(func() {
+		commandsPostTest := []*Command{
+			DeleteTestNamespaceCommand(ns),
+		}
+		RunTestSteps(commandsPostTest, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+	})
+
+	nodeName, err := GetPodNode(ns, "test-pod")
+	if err != nil {
+		t.Fatalf("getting test-pod node: %s", err)
+	}
+
+	commands := []*Command{
+		{
+			Name: "RunInventoryGadget",
+			Cmd:  fmt.Sprintf("$KUBECTL_GADGET snapshot inventory -n %s -o json --node %s", ns, nodeName),
+			ExpectedOutputFn: func(output string) error {
+				expectedEntry := &snapshotinventoryTypes.Event{
+					Event: BuildBaseEvent(ns),
+					Kind:  snapshotinventoryTypes.KindMap,
+					Type:  "Hash",
+					Name:  "test",
+					Processes: []snapshotinventoryTypes.Process{
+						{Comm: "python3"},
+					},
+				}
+				expectedEntry.Node = nodeName
+
+				normalize := func(e *snapshotinventoryTypes.Event) {
+					e.MountNsID = 0
+					e.ID = 0
+					e.Memory = 0
+					for i := range e.Processes {
+						e.Processes[i].Pid = 0
+					}
+				}
+
+				return ExpectEntriesInArrayToMatch(output, normalize, expectedEntry)
+			},
+		},
+	}
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
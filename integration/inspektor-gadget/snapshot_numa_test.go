@@ -0,0 +1,83 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	snapshotnumaTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/numa/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestSnapshotNuma(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-snapshot-numa")
+
+	t.Parallel()
+
+	commandsPreTest := []*Command{
+		CreateTestNamespaceCommand(ns),
+		BusyboxPodCommand(ns, "nc -l -p 9090"),
+		WaitUntilTestPodReadyCommand(ns),
+	}
+	RunTestSteps(commandsPreTest, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+
+	t.Cleanup(func() {
+		commandsPostTest := []*Command{
+			DeleteTestNamespaceCommand(ns),
+		}
+		RunTestSteps(commandsPostTest, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+	})
+
+	nodeName, err := GetPodNode(ns, "test-pod")
+	if err != nil {
+		t.Fatalf("getting test-pod node: %s", err)
+	}
+
+	commands := []*Command{
+		{
+			Name: "RunNumaGadget",
+			Cmd:  fmt.Sprintf("$KUBECTL_GADGET snapshot numa -n %s -o json --node %s", ns, nodeName),
+			ExpectedOutputFn: func(output string) error {
+				expectedEntry := &snapshotnumaTypes.Event{
+					Event: BuildBaseEvent(ns),
+					Nodes: []snapshotnumaTypes.NodeUsage{
+						{Node: 0, Local: true},
+					},
+				}
+				expectedEntry.Node = nodeName
+
+				normalize := func(e *snapshotnumaTypes.Event) {
+					e.MountNsID = 0
+					e.Cpus = ""
+					e.Mems = ""
+					e.Memory = 0
+					e.Remote = 0
+					e.RemoteRatio = 0
+					// Only the first NUMA node is checked, the test nodes can have more
+					if len(e.Nodes) > 0 {
+						e.Nodes = []snapshotnumaTypes.NodeUsage{
+							{Node: e.Nodes[0].Node, Local: e.Nodes[0].Local},
+						}
+					}
+				}
+
+				return ExpectEntriesInArrayToMatch(output, normalize, expectedEntry)
+			},
+		},
+	}
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -68,6 +68,7 @@ func TestSnapshotSocket(t *testing.T) {
 					RemoteAddress: "0.0.0.0",
 					RemotePort:    0,
 					Status:        "LISTEN",
+					Comm:          "nc",
 				}
 				expectedEntry.Node = nodeName
 
@@ -78,6 +79,7 @@ func TestSnapshotSocket(t *testing.T) {
 					e.Container = ""
 					e.InodeNumber = 0
 					e.NetNsID = 0
+					e.Pid = 0
 				}
 
 				return ExpectEntriesInArrayToMatch(output, normalize, expectedEntry)
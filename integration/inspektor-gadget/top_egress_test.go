@@ -0,0 +1,73 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	topegressTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/egress/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestTopEgress(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-top-egress")
+
+	t.Parallel()
+
+	topEgressCmd := &Command{
+		Name:         "StartTopEgressGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET top egress -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			// The test pod doesn't have any workload, its traffic is
+			// reported by pod. It's still in the baseline window.
+			expectedEntry := &topegressTypes.Stats{
+				CommonData:  BuildCommonData(ns),
+				Proto:       "tcp",
+				Port:        80,
+				Destination: "example.com",
+				Domain:      "example.com",
+				Status:      topegressTypes.StatusLearning,
+			}
+			expectedEntry.Container = ""
+
+			normalize := func(e *topegressTypes.Stats) {
+				e.Node = ""
+				e.NetNsID = 0
+				e.ASN = 0
+				e.ASName = ""
+				e.RemoteAddr = ""
+				e.Addresses = 0
+				e.Sent = 0
+				e.Received = 0
+			}
+
+			return ExpectEntriesInMultipleArrayToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		topEgressCmd,
+		// The destination has to be outside of the cluster
+		BusyboxPodRepeatCommand(ns, "wget -q -O /dev/null http://example.com"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
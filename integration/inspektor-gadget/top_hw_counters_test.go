@@ -0,0 +1,79 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	tophwcountersTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/hw-counters/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+// hwCountersAvailable returns whether the gadget can read the hardware
+// performance counters of the test nodes, they are usually not available on
+// virtual machines
+func hwCountersAvailable(cmd string) bool {
+	probe := &Command{
+		Name: "ProbeHwCounters",
+		Cmd:  fmt.Sprintf("%s 2>&1 | grep -q 'hardware performance counters not available'", cmd),
+	}
+	return probe.RunWithoutTest() != nil
+}
+
+func TestTopHwCounters(t *testing.T) {
+	if !hwCountersAvailable("$KUBECTL_GADGET top hw-counters -A --timeout 1") {
+		t.Skip("Skip running top hw-counters gadget: hardware performance counters not available on the test nodes")
+	}
+
+	ns := GenerateTestNamespaceName("test-top-hw-counters")
+
+	t.Parallel()
+
+	topHwCountersCmd := &Command{
+		Name:         "StartTopHwCountersGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET top hw-counters -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &tophwcountersTypes.Stats{
+				CommonData: BuildCommonData(ns),
+			}
+
+			normalize := func(e *tophwcountersTypes.Stats) {
+				e.Node = ""
+				e.MountNsID = 0
+				e.Cycles = 0
+				e.Instructions = 0
+				e.IPC = 0
+				e.CacheReferences = 0
+				e.CacheMisses = 0
+				e.MissRatio = 0
+			}
+
+			return ExpectEntriesInMultipleArrayToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		topHwCountersCmd,
+		BusyboxPodCommand(ns, "while true; do :; done"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	topnetworkTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/network/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestTopNetwork(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-top-network")
+
+	t.Parallel()
+
+	commandsPreTest := []*Command{
+		CreateTestNamespaceCommand(ns),
+		PodCommand("nginx-pod", "nginx", ns, "", ""),
+		WaitUntilPodReadyCommand(ns, "nginx-pod"),
+	}
+
+	RunTestSteps(commandsPreTest, t)
+	nginxIP, err := GetTestPodIP(ns, "nginx-pod")
+	if err != nil {
+		t.Fatalf("failed to get pod ip %s", err)
+	}
+
+	topNetworkCmd := &Command{
+		Name:         "StartTopNetworkGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET top network -n %s -o json --yes", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			// The ephemeral port of the client is aggregated
+			expectedEntry := &topnetworkTypes.Stats{
+				CommonData: BuildCommonData(ns),
+				IPVersion:  4,
+				Proto:      "tcp",
+				LocalPort:  0,
+				RemoteAddr: nginxIP,
+				RemotePort: 80,
+			}
+
+			normalize := func(e *topnetworkTypes.Stats) {
+				e.Node = ""
+				e.NetNsID = 0
+				e.Sent = 0
+				e.Received = 0
+				e.SentPackets = 0
+				e.ReceivedPackets = 0
+				e.Throughput = 0
+			}
+
+			return ExpectEntriesInMultipleArrayToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		topNetworkCmd,
+		BusyboxPodRepeatCommand(ns, fmt.Sprintf("wget -q -O /dev/null %s", nginxIP)),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
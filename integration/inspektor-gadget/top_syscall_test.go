@@ -0,0 +1,66 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	topsyscallTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/syscall/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestTopSyscall(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-top-syscall")
+
+	t.Parallel()
+
+	topSyscallCmd := &Command{
+		Name:         "StartTopSyscallGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET top syscall -n %s -o json --yes", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &topsyscallTypes.Stats{
+				CommonData: BuildCommonData(ns),
+				Syscall:    columns.Enum{Name: "write"},
+			}
+
+			normalize := func(e *topsyscallTypes.Stats) {
+				e.Node = ""
+				e.MountNsID = 0
+				e.Syscall.Value = 0
+				e.Count = 0
+				e.Total = 0
+				e.Average = 0
+				e.P99 = 0
+				e.Max = 0
+			}
+
+			return ExpectEntriesInMultipleArrayToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		topSyscallCmd,
+		BusyboxPodRepeatCommand(ns, "echo foo > /tmp/test-file"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	tracearpTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/arp/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestTraceArp(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-arp")
+
+	t.Parallel()
+
+	commandsPreTest := []*Command{
+		CreateTestNamespaceCommand(ns),
+		PodCommand("nginx-pod", "nginx", ns, "", ""),
+		WaitUntilPodReadyCommand(ns, "nginx-pod"),
+	}
+
+	RunTestSteps(commandsPreTest, t)
+	nginxIP, err := GetTestPodIP(ns, "nginx-pod")
+	if err != nil {
+		t.Fatalf("failed to get pod ip %s", err)
+	}
+
+	traceArpCmd := &Command{
+		Name:         "StartTraceArpGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace arp -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			testPodIP, err := GetTestPodIP(ns, "test-pod")
+			if err != nil {
+				return fmt.Errorf("getting pod ip: %w", err)
+			}
+
+			// The reply isn't checked, the pods can be on different nodes
+			expectedEntry := &tracearpTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				Protocol:  tracearpTypes.ProtocolARP,
+				Operation: "REQUEST",
+				SenderIP:  testPodIP,
+				TargetIP:  nginxIP,
+				PktType:   "OUTGOING",
+			}
+
+			normalize := func(e *tracearpTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.NetNsID = 0
+				e.SenderMAC = ""
+				e.TargetMAC = ""
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		traceArpCmd,
+		BusyboxPodRepeatCommand(ns, fmt.Sprintf("arping -c 1 -I eth0 %s", nginxIP)),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -18,6 +18,9 @@ import (
 	"fmt"
 	"testing"
 
+	"golang.org/x/sys/unix"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	tracecapabilitiesTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
 
 	. "github.com/inspektor-gadget/inspektor-gadget/integration"
@@ -34,7 +37,7 @@ func TestTraceCapabilities(t *testing.T) {
 
 	traceCapabilitiesCmd := &Command{
 		Name:         "StartTraceCapabilitiesGadget",
-		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace capabilities -n %s -o json", ns),
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace capabilities -n %s -o json --yes", ns),
 		StartAndStop: true,
 		ExpectedOutputFn: func(output string) error {
 			expectedEntry := &tracecapabilitiesTypes.Event{
@@ -42,7 +45,7 @@ func TestTraceCapabilities(t *testing.T) {
 				Comm:          "nice",
 				CapName:       "SYS_NICE",
 				Cap:           23,
-				Syscall:       "setpriority",
+				Syscall:       columns.Enum{Name: "setpriority", Value: unix.SYS_SETPRIORITY},
 				Audit:         1,
 				Verdict:       "Deny",
 				CurrentUserNs: 1,
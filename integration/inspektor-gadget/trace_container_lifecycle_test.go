@@ -0,0 +1,88 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	tracecontainerlifecycleTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/container-lifecycle/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestTraceContainerLifecycle(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-container-lifecycle")
+
+	t.Parallel()
+
+	// newExpectedEntry returns the event expected for the test pod
+	newExpectedEntry := func(eventType tracecontainerlifecycleTypes.EventType) *tracecontainerlifecycleTypes.Event {
+		return &tracecontainerlifecycleTypes.Event{
+			Event:     BuildBaseEvent(ns),
+			EventType: eventType,
+			Args:      []string{"/bin/sh", "-c", "sleep inf"},
+		}
+	}
+
+	normalize := func(e *tracecontainerlifecycleTypes.Event) {
+		e.Timestamp = 0
+		e.Node = ""
+		e.MountNsID = 0
+		e.ContainerID = ""
+		e.Runtime = ""
+		e.Image = ""
+		e.Pid = 0
+		e.NetNsID = 0
+		e.StartedAt = 0
+		e.Mounts = nil
+		// The security context depends on the container runtime and its
+		// configuration
+		e.SecurityContext = tracecontainerlifecycleTypes.SecurityContext{}
+	}
+
+	traceContainerLifecycleCmd := &Command{
+		Name:         "StartTraceContainerLifecycleGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace container-lifecycle -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			return ExpectEntriesToMatch(output, normalize,
+				newExpectedEntry(tracecontainerlifecycleTypes.EventTypeCreated),
+				newExpectedEntry(tracecontainerlifecycleTypes.EventTypeDeleted),
+			)
+		},
+	}
+
+	traceExistingContainersCmd := &Command{
+		Name: "RunTraceContainerLifecycleGadgetWithExisting",
+		Cmd:  fmt.Sprintf("$KUBECTL_GADGET trace container-lifecycle -n %s --include-existing -o json --timeout 2", ns),
+		ExpectedOutputFn: func(output string) error {
+			return ExpectEntriesToMatch(output, normalize,
+				newExpectedEntry(tracecontainerlifecycleTypes.EventTypeExisting),
+			)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		traceContainerLifecycleCmd,
+		BusyboxPodCommand(ns, "sleep inf"),
+		WaitUntilTestPodReadyCommand(ns),
+		traceExistingContainersCmd,
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -48,48 +48,52 @@ func TestTraceDns(t *testing.T) {
 		ExpectedOutputFn: func(output string) error {
 			expectedEntries := []*tracednsTypes.Event{
 				{
-					Event:      BuildBaseEvent(ns),
-					Comm:       "nslookup",
-					Qr:         tracednsTypes.DNSPktTypeQuery,
-					Nameserver: dnsServer,
-					PktType:    "OUTGOING",
-					DNSName:    "fake.test.com.",
-					QType:      "A",
+					Event:          BuildBaseEvent(ns),
+					Comm:           "nslookup",
+					Qr:             tracednsTypes.DNSPktTypeQuery,
+					Nameserver:     dnsServer,
+					NameserverPort: 53,
+					PktType:        "OUTGOING",
+					DNSName:        "fake.test.com.",
+					QType:          "A",
 				},
 				{
-					Event:      BuildBaseEvent(ns),
-					Comm:       "nslookup",
-					Qr:         tracednsTypes.DNSPktTypeResponse,
-					Nameserver: dnsServer,
-					PktType:    "HOST",
-					DNSName:    "fake.test.com.",
-					QType:      "A",
-					Rcode:      "NoError",
-					Latency:    1,
-					NumAnswers: 1,
-					Addresses:  []string{"127.0.0.1"},
+					Event:          BuildBaseEvent(ns),
+					Comm:           "nslookup",
+					Qr:             tracednsTypes.DNSPktTypeResponse,
+					Nameserver:     dnsServer,
+					NameserverPort: 53,
+					PktType:        "HOST",
+					DNSName:        "fake.test.com.",
+					QType:          "A",
+					Rcode:          "NoError",
+					Latency:        1,
+					NumAnswers:     1,
+					Addresses:      []string{"127.0.0.1"},
 				},
 				{
-					Event:      BuildBaseEvent(ns),
-					Comm:       "nslookup",
-					Qr:         tracednsTypes.DNSPktTypeQuery,
-					Nameserver: dnsServer,
-					PktType:    "OUTGOING",
-					DNSName:    "fake.test.com.",
-					QType:      "A",
+					Event:          BuildBaseEvent(ns),
+					Comm:           "nslookup",
+					Qr:             tracednsTypes.DNSPktTypeQuery,
+					Nameserver:     dnsServer,
+					NameserverPort: 53,
+					PktType:        "OUTGOING",
+					DNSName:        "fake.test.com.",
+					QType:          "A",
 				},
 				{
-					Event:      BuildBaseEvent(ns),
-					Comm:       "nslookup",
-					Qr:         tracednsTypes.DNSPktTypeResponse,
-					Nameserver: dnsServer,
-					PktType:    "HOST",
-					DNSName:    "fake.test.com.",
-					QType:      "AAAA",
-					Rcode:      "NoError",
-					Latency:    1,
-					NumAnswers: 1,
-					Addresses:  []string{"::1"},
+					Event:          BuildBaseEvent(ns),
+					Comm:           "nslookup",
+					Qr:             tracednsTypes.DNSPktTypeResponse,
+					Nameserver:     dnsServer,
+					NameserverPort: 53,
+					PktType:        "HOST",
+					DNSName:        "fake.test.com.",
+					QType:          "AAAA",
+					Rcode:          "NoError",
+					Latency:        1,
+					NumAnswers:     1,
+					Addresses:      []string{"::1"},
 				},
 			}
 
@@ -101,6 +105,7 @@ func TestTraceDns(t *testing.T) {
 				e.NetNsID = 0
 				e.Pid = 0
 				e.Tid = 0
+				e.ClientPort = 0
 
 				// Latency should be > 0 only for DNS responses.
 				if e.Latency > 0 {
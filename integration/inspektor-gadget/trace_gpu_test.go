@@ -0,0 +1,67 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	tracegpuTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/gpu/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestTraceGpu(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-gpu")
+
+	t.Parallel()
+
+	// The test nodes don't have GPUs: the failed opens of the device nodes
+	// are reported too
+	traceGpuCmd := &Command{
+		Name:         "StartTraceGpuGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace gpu -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &tracegpuTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				Comm:      "cat",
+				Operation: tracegpuTypes.OperationOpen,
+				Device:    "/dev/nvidia0",
+				Ret:       -2,
+				Err:       "ENOENT",
+			}
+
+			normalize := func(e *tracegpuTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.Pid = 0
+				e.MountNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		traceGpuCmd,
+		BusyboxPodRepeatCommand(ns, "cat /dev/nvidia0"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	tracegrpcTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/grpc/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+// grpcClientPodCommand returns a Command that creates the test pod, calling
+// the SayHello method of the grpcbin server at the given address in a loop.
+// A new connection is used for each call, so the gadget sees its preface.
+func grpcClientPodCommand(ns, server string) *Command {
+	return PodCommand("test-pod", "fullstorydev/grpcurl:v1.8.7-alpine", ns, `["/bin/sh", "-c"]`,
+		fmt.Sprintf("while true; do grpcurl -plaintext %s:9000 hello.HelloService/SayHello; sleep 1; done", server))
+}
+
+func TestTraceGrpc(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-grpc")
+
+	t.Parallel()
+
+	commandsPreTest := []*Command{
+		CreateTestNamespaceCommand(ns),
+		PodCommand("grpcbin", "moul/grpcbin", ns, "", ""),
+		WaitUntilPodReadyCommand(ns, "grpcbin"),
+	}
+
+	RunTestSteps(commandsPreTest, t)
+	grpcbinIP, err := GetTestPodIP(ns, "grpcbin")
+	if err != nil {
+		t.Fatalf("failed to get pod ip %s", err)
+	}
+
+	traceGrpcCmd := &Command{
+		Name:         "StartTraceGrpcGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace grpc -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			testPodIP, err := GetTestPodIP(ns, "test-pod")
+			if err != nil {
+				return fmt.Errorf("getting pod ip: %w", err)
+			}
+
+			expectedEntry := &tracegrpcTypes.Event{
+				Event:      BuildBaseEvent(ns),
+				Comm:       "grpcurl",
+				Role:       tracegrpcTypes.RoleClient,
+				ClientIP:   testPodIP,
+				ServerIP:   grpcbinIP,
+				ServerPort: 9000,
+				Authority:  fmt.Sprintf("%s:9000", grpcbinIP),
+				Service:    "hello.HelloService",
+				Method:     "SayHello",
+				Status:     "OK",
+				HTTPStatus: "200",
+			}
+
+			normalize := func(e *tracegrpcTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.MountNsID = 0
+				e.NetNsID = 0
+				e.Pid = 0
+				e.Tid = 0
+				e.ClientPort = 0
+				e.StreamID = 0
+				e.Latency = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		traceGrpcCmd,
+		grpcClientPodCommand(ns, grpcbinIP),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
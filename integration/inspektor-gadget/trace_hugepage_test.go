@@ -0,0 +1,70 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	tracehugepageTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/hugepage/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+// hugePagePodArgs is a python program that asks for huge pages for 16MB of
+// memory and writes to it, in a loop
+const hugePagePodArgs = `"import mmap, time\nwhile True:\n    m = mmap.mmap(-1, 16 << 20)\n    m.madvise(mmap.MADV_HUGEPAGE)\n    m.write(b'x' * (16 << 20))\n    m.close()\n    time.sleep(0.1)"`
+
+func TestTraceHugepage(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-hugepage")
+
+	t.Parallel()
+
+	// It requires the transparent huge pages to be enabled on the nodes, in
+	// the "always" or "madvise" mode
+	traceHugepageCmd := &Command{
+		Name:         "StartTraceHugepageGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace hugepage -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &tracehugepageTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				Comm:      "python3",
+				Operation: tracehugepageTypes.OperationFault,
+			}
+
+			normalize := func(e *tracehugepageTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.Pid = 0
+				e.Tid = 0
+				e.Latency = 0
+				e.MountNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		traceHugepageCmd,
+		PodCommand("test-pod", "python:3-alpine", ns, `["python3", "-c"]`, hugePagePodArgs),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	traceicmpTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/icmp/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestTraceIcmp(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-icmp")
+
+	t.Parallel()
+
+	commandsPreTest := []*Command{
+		CreateTestNamespaceCommand(ns),
+		PodCommand("nginx-pod", "nginx", ns, "", ""),
+		WaitUntilPodReadyCommand(ns, "nginx-pod"),
+	}
+
+	RunTestSteps(commandsPreTest, t)
+	nginxIP, err := GetTestPodIP(ns, "nginx-pod")
+	if err != nil {
+		t.Fatalf("failed to get pod ip %s", err)
+	}
+
+	traceIcmpCmd := &Command{
+		Name:         "StartTraceIcmpGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace icmp -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			testPodIP, err := GetTestPodIP(ns, "test-pod")
+			if err != nil {
+				return fmt.Errorf("getting pod ip: %w", err)
+			}
+
+			expectedEntries := []*traceicmpTypes.Event{
+				{
+					Event:     BuildBaseEvent(ns),
+					IPVersion: 4,
+					Saddr:     testPodIP,
+					Daddr:     nginxIP,
+					PktType:   "OUTGOING",
+					ICMPType:  traceicmpTypes.TypeEchoRequest,
+				},
+				{
+					Event:     BuildBaseEvent(ns),
+					IPVersion: 4,
+					Saddr:     nginxIP,
+					Daddr:     testPodIP,
+					PktType:   "HOST",
+					ICMPType:  traceicmpTypes.TypeEchoReply,
+					// Don't check the exact value but check that it isn't empty
+					RTT: 1,
+				},
+			}
+
+			normalize := func(e *traceicmpTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.NetNsID = 0
+				e.ID = 0
+				e.Seq = 0
+				if e.RTT > 0 {
+					e.RTT = 1
+				}
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntries...)
+		},
+	}
+
+	commands := []*Command{
+		traceIcmpCmd,
+		BusyboxPodRepeatCommand(ns, fmt.Sprintf("ping -c 1 %s", nginxIP)),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,144 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	tracekafkaTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/kafka/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+// kafkaBrokerPodCommand returns a Command that creates a pod running a Kafka
+// broker, advertising its IP address to the clients
+func kafkaBrokerPodCommand(ns string) *Command {
+	return &Command{
+		Name: "RunKafkaBrokerPod",
+		Cmd: fmt.Sprintf(`kubectl apply -f - <<"EOF"
+apiVersion: v1
+kind: Pod
+metadata:
+  name: kafka
+  namespace: %s
+spec:
+  restartPolicy: Never
+  terminationGracePeriodSeconds: 0
+  containers:
+  - name: kafka
+    image: bitnami/kafka:3.6
+    env:
+    - name: POD_IP
+      valueFrom:
+        fieldRef:
+          fieldPath: status.podIP
+    - name: KAFKA_CFG_NODE_ID
+      value: "0"
+    - name: KAFKA_CFG_PROCESS_ROLES
+      value: controller,broker
+    - name: KAFKA_CFG_CONTROLLER_QUORUM_VOTERS
+      value: 0@localhost:9093
+    - name: KAFKA_CFG_LISTENERS
+      value: PLAINTEXT://:9092,CONTROLLER://:9093
+    - name: KAFKA_CFG_ADVERTISED_LISTENERS
+      value: PLAINTEXT://$(POD_IP):9092
+    - name: KAFKA_CFG_CONTROLLER_LISTENER_NAMES
+      value: CONTROLLER
+    readinessProbe:
+      tcpSocket:
+        port: 9092
+EOF
+`, ns),
+		ExpectedString: "pod/kafka created\n",
+	}
+}
+
+// kafkaProducerPodCommand returns a Command that creates the test pod,
+// producing a message to the "test" topic of the broker at the given address
+// in a loop. The topic is created by the broker on the first request.
+func kafkaProducerPodCommand(ns, server string) *Command {
+	return PodCommand("test-pod", "bitnami/kafka:3.6", ns, `["/bin/sh", "-c"]`,
+		fmt.Sprintf("while true; do echo hello | kafka-console-producer.sh --bootstrap-server %s:9092 --topic test; sleep 1; done", server))
+}
+
+func TestTraceKafka(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-kafka")
+
+	t.Parallel()
+
+	commandsPreTest := []*Command{
+		CreateTestNamespaceCommand(ns),
+		kafkaBrokerPodCommand(ns),
+		WaitUntilPodReadyCommand(ns, "kafka"),
+	}
+
+	RunTestSteps(commandsPreTest, t)
+	kafkaIP, err := GetTestPodIP(ns, "kafka")
+	if err != nil {
+		t.Fatalf("failed to get pod ip %s", err)
+	}
+
+	traceKafkaCmd := &Command{
+		Name:         "StartTraceKafkaGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace kafka -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			testPodIP, err := GetTestPodIP(ns, "test-pod")
+			if err != nil {
+				return fmt.Errorf("getting pod ip: %w", err)
+			}
+
+			expectedEntry := &tracekafkaTypes.Event{
+				Event:      BuildBaseEvent(ns),
+				Comm:       "java",
+				Role:       tracekafkaTypes.RoleClient,
+				ClientIP:   testPodIP,
+				ServerIP:   kafkaIP,
+				ServerPort: 9092,
+				ClientID:   "console-producer",
+				API:        "produce",
+				Topic:      "test",
+				Partition:  0,
+				Error:      "NONE",
+			}
+
+			normalize := func(e *tracekafkaTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.MountNsID = 0
+				e.NetNsID = 0
+				e.Pid = 0
+				e.Tid = 0
+				e.ClientPort = 0
+				e.APIVersion = 0
+				e.Acks = 0
+				e.Size = 0
+				e.Latency = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		traceKafkaCmd,
+		kafkaProducerPodCommand(ns, kafkaIP),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,188 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	tracelsmdenialTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/lsm-denial/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+// readOnlyProfile is an AppArmor profile allowing to read and execute any
+// file, but not to write
+const readOnlyProfile = "ig-test-read-only"
+
+// profileLoaderPodCommand returns a Command that creates a privileged pod
+// loading readOnlyProfile on its node
+func profileLoaderPodCommand(ns string) *Command {
+	return &Command{
+		Name: "RunProfileLoaderPod",
+		Cmd: fmt.Sprintf(`kubectl apply -f - <<"EOF"
+apiVersion: v1
+kind: Pod
+metadata:
+  name: profile-loader
+  namespace: %s
+spec:
+  restartPolicy: Never
+  terminationGracePeriodSeconds: 0
+  hostPID: true
+  containers:
+  - name: profile-loader
+    image: busybox
+    command: ["/bin/sh", "-c"]
+    args:
+    - |
+      nsenter -t 1 -m -- apparmor_parser -r <<"PROFILE"
+      #include <tunables/global>
+
+      profile %s flags=(attach_disconnected) {
+        #include <abstractions/base>
+
+        /** rix,
+      }
+      PROFILE
+      sleep inf
+    securityContext:
+      privileged: true
+EOF
+`, ns, readOnlyProfile),
+		ExpectedString: "pod/profile-loader created\n",
+	}
+}
+
+// profileLoadedCommand returns a Command that fails if readOnlyProfile isn't
+// loaded on the node of the loader pod
+func profileLoadedCommand(ns string) *Command {
+	return &Command{
+		Name: "CheckProfileLoaded",
+		Cmd: fmt.Sprintf("kubectl exec -n %s profile-loader -- nsenter -t 1 -m -- grep -q '^%s ' /sys/kernel/security/apparmor/profiles",
+			ns, readOnlyProfile),
+	}
+}
+
+// unloadProfileCommand returns a Command that removes readOnlyProfile from
+// the node of the loader pod
+func unloadProfileCommand(ns string) *Command {
+	return &Command{
+		Name: "UnloadProfile",
+		Cmd: fmt.Sprintf("kubectl exec -n %s profile-loader -- nsenter -t 1 -m -- sh -c 'echo -n %s > /sys/kernel/security/apparmor/.remove'",
+			ns, readOnlyProfile),
+	}
+}
+
+// readOnlyPodCommand returns a Command that creates the test pod on the given
+// node, confined by readOnlyProfile and writing a file in a loop
+func readOnlyPodCommand(ns, node string) *Command {
+	return &Command{
+		Name: "RunReadOnlyPod",
+		Cmd: fmt.Sprintf(`kubectl apply -f - <<"EOF"
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test-pod
+  namespace: %s
+  annotations:
+    container.apparmor.security.beta.kubernetes.io/test-pod: localhost/%s
+spec:
+  restartPolicy: Never
+  terminationGracePeriodSeconds: 0
+  nodeName: %s
+  containers:
+  - name: test-pod
+    image: busybox
+    command: ["/bin/sh", "-c"]
+    args:
+    - while true; do echo hello > /tmp/hello; sleep 0.1; done
+EOF
+`, ns, readOnlyProfile, node),
+		ExpectedString: "pod/test-pod created\n",
+	}
+}
+
+func TestTraceLsmDenial(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-lsm-denial")
+
+	t.Parallel()
+
+	commandsPreTest := []*Command{
+		CreateTestNamespaceCommand(ns),
+		profileLoaderPodCommand(ns),
+		WaitUntilPodReadyCommand(ns, "profile-loader"),
+	}
+	RunTestSteps(commandsPreTest, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+
+	t.Cleanup(func() {
+		commandsPostTest := []*Command{
+			DeleteTestNamespaceCommand(ns),
+		}
+		RunTestSteps(commandsPostTest, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+	})
+
+	if err := profileLoadedCommand(ns).RunWithoutTest(); err != nil {
+		t.Skipf("Skip running trace lsm-denial gadget: AppArmor profile not loaded: %s", err)
+	}
+
+	// The cleanups run in reverse order: the profile is removed before the
+	// loader pod
+	t.Cleanup(func() {
+		RunTestSteps([]*Command{unloadProfileCommand(ns)}, t)
+	})
+
+	nodeName, err := GetPodNode(ns, "profile-loader")
+	if err != nil {
+		t.Fatalf("getting profile-loader node: %s", err)
+	}
+
+	traceLsmDenialCmd := &Command{
+		Name:         "StartTraceLsmDenialGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace lsm-denial -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &tracelsmdenialTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				Comm:      "sh",
+				LSM:       tracelsmdenialTypes.LSMAppArmor,
+				Operation: "mknod",
+				Subject:   readOnlyProfile,
+				Target:    "/tmp/hello",
+			}
+
+			normalize := func(e *tracelsmdenialTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.Pid = 0
+				e.Tid = 0
+				e.Uid = 0
+				e.ContainerUid = 0
+				e.MountNsID = 0
+				e.Info = ""
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		traceLsmDenialCmd,
+		readOnlyPodCommand(ns, nodeName),
+		WaitUntilTestPodReadyCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,94 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	tracenatTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/nat/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestTraceNat(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-nat")
+
+	t.Parallel()
+
+	// The translations are done in the network namespace of the host, the
+	// events aren't attached to a pod: the endpoints are checked instead
+	traceNatCmd := &Command{
+		Name:         "StartTraceNatGadget",
+		Cmd:          "$KUBECTL_GADGET trace nat -o json",
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &tracenatTypes.Event{
+				Event: eventtypes.Event{
+					Type: eventtypes.NORMAL,
+				},
+				IPVersion:              4,
+				Protocol:               "TCP",
+				NAT:                    "DNAT",
+				Dport:                  80,
+				TranslatedDport:        80,
+				SrcKind:                eventtypes.RemoteKindPod,
+				SrcNamespace:           ns,
+				SrcName:                "test-pod",
+				DstKind:                eventtypes.RemoteKindService,
+				DstNamespace:           ns,
+				DstName:                "nginx-pod",
+				TranslatedSrcKind:      eventtypes.RemoteKindPod,
+				TranslatedSrcNamespace: ns,
+				TranslatedSrcName:      "test-pod",
+				TranslatedDstKind:      eventtypes.RemoteKindPod,
+				TranslatedDstNamespace: ns,
+				TranslatedDstName:      "nginx-pod",
+			}
+
+			normalize := func(e *tracenatTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.NetNsID = 0
+				e.Saddr = ""
+				e.Sport = 0
+				e.Daddr = ""
+				e.TranslatedSaddr = ""
+				e.TranslatedSport = 0
+				e.TranslatedDaddr = ""
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		PodCommand("nginx-pod", "nginx", ns, "", ""),
+		{
+			Name:           "CreateService",
+			Cmd:            fmt.Sprintf("kubectl expose -n %s pod nginx-pod --port 80", ns),
+			ExpectedRegexp: "service/nginx-pod exposed",
+		},
+		WaitUntilPodReadyCommand(ns, "nginx-pod"),
+		traceNatCmd,
+		BusyboxPodRepeatCommand(ns, "wget -q -O /dev/null nginx-pod:80"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,116 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	tracenetfilterTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/netfilter/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+// firewallPodCommand returns a Command that creates the test pod, adding an
+// nftables rule dropping the datagrams to the port 9999 in its network
+// namespace and sending datagrams to it in a loop
+func firewallPodCommand(ns string) *Command {
+	return &Command{
+		Name: "RunFirewallPod",
+		Cmd: fmt.Sprintf(`kubectl apply -f - <<"EOF"
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test-pod
+  namespace: %s
+spec:
+  restartPolicy: Never
+  terminationGracePeriodSeconds: 0
+  containers:
+  - name: test-pod
+    image: alpine
+    command: ["/bin/sh", "-c"]
+    args:
+    - |
+      apk add nftables
+      nft add table inet fw
+      nft add chain inet fw out "{ type filter hook output priority 0; }"
+      nft add rule inet fw out udp dport 9999 drop
+      while true; do echo hello | nc -u -w1 127.0.0.1 9999; sleep 0.1; done
+    securityContext:
+      capabilities:
+        add: ["NET_ADMIN"]
+EOF
+`, ns),
+		ExpectedString: "pod/test-pod created\n",
+	}
+}
+
+func TestTraceNetfilter(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-netfilter")
+
+	t.Parallel()
+
+	traceNetfilterCmd := &Command{
+		Name:         "StartTraceNetfilterGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace netfilter -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &tracenetfilterTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				Comm:      "nc",
+				IPVersion: 4,
+				Protocol:  "UDP",
+				Saddr:     "127.0.0.1",
+				Daddr:     "127.0.0.1",
+				Dport:     9999,
+				Backend:   tracenetfilterTypes.BackendNftables,
+				Table:     "fw",
+				Chain:     "out",
+				Hook:      "output",
+				Action:    tracenetfilterTypes.ActionDrop,
+				Out:       "lo",
+			}
+
+			normalize := func(e *tracenetfilterTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.MountNsID = 0
+				e.NetNsID = 0
+				e.Pid = 0
+				e.Sport = 0
+				// Don't depend on how the loopback address is resolved
+				e.SrcKind = ""
+				e.SrcNamespace = ""
+				e.SrcName = ""
+				e.DstKind = ""
+				e.DstNamespace = ""
+				e.DstName = ""
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		traceNetfilterCmd,
+		firewallPodCommand(ns),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
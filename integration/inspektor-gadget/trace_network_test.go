@@ -43,7 +43,7 @@ func TestTraceNetwork(t *testing.T) {
 
 	traceNetworkCmd := &Command{
 		Name:         "StartTraceNetworkGadget",
-		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace network -n %s -o json", ns),
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace network -n %s -o json --yes", ns),
 		StartAndStop: true,
 		ExpectedOutputFn: func(output string) error {
 			testPodIP, err := GetTestPodIP(ns, "test-pod")
@@ -0,0 +1,62 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	tracepagefaultTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/page-fault/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestTracePageFault(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-page-fault")
+
+	t.Parallel()
+
+	tracePageFaultCmd := &Command{
+		Name:         "StartTracePageFaultGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace page-fault -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &tracepagefaultTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				Operation: tracepagefaultTypes.OperationStats,
+			}
+
+			normalize := func(e *tracepagefaultTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.Minor = 0
+				e.Major = 0
+				e.MountNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		tracePageFaultCmd,
+		BusyboxPodRepeatCommand(ns, "head -c 10M /dev/zero | tail"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	tracepressureTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/pressure/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+// contendedPodCommand returns a Command that creates the test pod with a CPU
+// limit of 200m, running more busy loops than the limit allows: its tasks are
+// stalled waiting for the CPU
+func contendedPodCommand(ns string) *Command {
+	return &Command{
+		Name: "RunContendedPod",
+		Cmd: fmt.Sprintf(`kubectl apply -f - <<"EOF"
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test-pod
+  namespace: %s
+spec:
+  restartPolicy: Never
+  terminationGracePeriodSeconds: 0
+  containers:
+  - name: test-pod
+    image: busybox
+    command: ["/bin/sh", "-c"]
+    args:
+    - for i in 1 2 3 4; do while true; do :; done & done; wait
+    resources:
+      limits:
+        cpu: 200m
+EOF
+`, ns),
+		ExpectedString: "pod/test-pod created\n",
+	}
+}
+
+func TestTracePressure(t *testing.T) {
+	if *k8sDistro == K8sDistroARO {
+		t.Skip("Skip running trace pressure gadget on ARO: the gadget requires cgroup v2")
+	}
+
+	ns := GenerateTestNamespaceName("test-pressure")
+
+	t.Parallel()
+
+	tracePressureCmd := &Command{
+		Name:         "StartTracePressureGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace pressure -n %s -o json --some-threshold 1", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &tracepressureTypes.Event{
+				Event:    BuildBaseEvent(ns),
+				Resource: tracepressureTypes.ResourceCPU,
+				State:    tracepressureTypes.StatePressure,
+			}
+
+			normalize := func(e *tracepressureTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.SomeAvg10 = 0
+				e.SomeAvg60 = 0
+				e.SomeAvg300 = 0
+				e.FullAvg10 = 0
+				e.FullAvg60 = 0
+				e.FullAvg300 = 0
+				e.MountNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		tracePressureCmd,
+		contendedPodCommand(ns),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
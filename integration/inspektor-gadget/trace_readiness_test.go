@@ -0,0 +1,84 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	tracereadinessTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/readiness/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestTraceReadiness(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-readiness")
+
+	t.Parallel()
+
+	traceReadinessCmd := &Command{
+		Name:         "StartTraceReadinessGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace readiness -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntries := []*tracereadinessTypes.Event{
+				{
+					Event:     BuildBaseEvent(ns),
+					Comm:      "nginx",
+					Operation: "listen",
+					Port:      80,
+					// Don't check the exact value but check that it isn't empty
+					Elapsed: 1,
+				},
+				{
+					Event:     BuildBaseEvent(ns),
+					Comm:      "nginx",
+					Operation: "accept",
+					IPVersion: 4,
+					Addr:      "127.0.0.1",
+					Port:      80,
+					Elapsed:   1,
+				},
+			}
+
+			normalize := func(e *tracereadinessTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.Pid = 0
+				e.MountNsID = 0
+				if e.Elapsed > 0 {
+					e.Elapsed = 1
+				}
+				// nginx listens on both IPv4 and IPv6, the first one is reported
+				if e.Operation == "listen" {
+					e.IPVersion = 0
+					e.Addr = ""
+				}
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntries...)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		traceReadinessCmd,
+		PodCommand("test-pod", "nginx", ns, "[sh, -c]", "nginx && while true; do curl 127.0.0.1; sleep 0.1; done"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
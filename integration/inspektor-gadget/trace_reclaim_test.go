@@ -0,0 +1,96 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	tracereclaimTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/reclaim/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+// memoryLimitedPodCommand returns a Command that creates the test pod with a
+// memory limit of 64MB, writing files larger than that: their page cache has
+// to be reclaimed to stay under the limit
+func memoryLimitedPodCommand(ns string) *Command {
+	return &Command{
+		Name: "RunMemoryLimitedPod",
+		Cmd: fmt.Sprintf(`kubectl apply -f - <<"EOF"
+apiVersion: v1
+kind: Pod
+metadata:
+  name: test-pod
+  namespace: %s
+spec:
+  restartPolicy: Never
+  terminationGracePeriodSeconds: 0
+  containers:
+  - name: test-pod
+    image: busybox
+    command: ["/bin/sh", "-c"]
+    args:
+    - while true; do dd if=/dev/zero of=/tmp/test-file bs=1M count=128 2> /dev/null; done
+    resources:
+      limits:
+        memory: 64Mi
+EOF
+`, ns),
+		ExpectedString: "pod/test-pod created\n",
+	}
+}
+
+func TestTraceReclaim(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-reclaim")
+
+	t.Parallel()
+
+	traceReclaimCmd := &Command{
+		Name:         "StartTraceReclaimGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace reclaim -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &tracereclaimTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				Comm:      "dd",
+				Operation: tracereclaimTypes.OperationMemcg,
+			}
+
+			normalize := func(e *tracereclaimTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.Pid = 0
+				e.Tid = 0
+				e.Latency = 0
+				e.Reclaimed = 0
+				e.SwapOut = 0
+				e.MountNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		traceReclaimCmd,
+		memoryLimitedPodCommand(ns),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
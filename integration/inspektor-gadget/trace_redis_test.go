@@ -0,0 +1,97 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	traceredisTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/redis/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+// redisClientPodCommand returns a Command that creates the test pod, setting
+// a key on the Redis server at the given address in a loop
+func redisClientPodCommand(ns, server string) *Command {
+	return PodCommand("test-pod", "redis:7", ns, `["/bin/sh", "-c"]`,
+		fmt.Sprintf("while true; do redis-cli -h %s SET user:1234:name alice; sleep 1; done", server))
+}
+
+func TestTraceRedis(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-redis")
+
+	t.Parallel()
+
+	commandsPreTest := []*Command{
+		CreateTestNamespaceCommand(ns),
+		PodCommand("redis", "redis:7", ns, "", ""),
+		WaitUntilPodReadyCommand(ns, "redis"),
+	}
+
+	RunTestSteps(commandsPreTest, t)
+	redisIP, err := GetTestPodIP(ns, "redis")
+	if err != nil {
+		t.Fatalf("failed to get pod ip %s", err)
+	}
+
+	traceRedisCmd := &Command{
+		Name:         "StartTraceRedisGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace redis -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			testPodIP, err := GetTestPodIP(ns, "test-pod")
+			if err != nil {
+				return fmt.Errorf("getting pod ip: %w", err)
+			}
+
+			expectedEntry := &traceredisTypes.Event{
+				Event:      BuildBaseEvent(ns),
+				Comm:       "redis-cli",
+				Role:       traceredisTypes.RoleClient,
+				ClientIP:   testPodIP,
+				ServerIP:   redisIP,
+				ServerPort: 6379,
+				Command:    "SET",
+				KeyPrefix:  "user:1234:name",
+				Args:       3,
+				Reply:      "status",
+			}
+
+			normalize := func(e *traceredisTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.MountNsID = 0
+				e.NetNsID = 0
+				e.Pid = 0
+				e.Tid = 0
+				e.ClientPort = 0
+				e.ReplySize = 0
+				e.Latency = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		traceRedisCmd,
+		redisClientPodCommand(ns, redisIP),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
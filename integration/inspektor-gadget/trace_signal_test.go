@@ -18,6 +18,9 @@ import (
 	"fmt"
 	"testing"
 
+	"golang.org/x/sys/unix"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	tracesignalTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/signal/types"
 
 	. "github.com/inspektor-gadget/inspektor-gadget/integration"
@@ -36,7 +39,7 @@ func TestTraceSignal(t *testing.T) {
 			expectedEntry := &tracesignalTypes.Event{
 				Event:  BuildBaseEvent(ns),
 				Comm:   "sh",
-				Signal: "SIGTERM",
+				Signal: columns.Enum{Name: "SIGTERM", Value: int64(unix.SIGTERM)},
 			}
 
 			normalize := func(e *tracesignalTypes.Event) {
@@ -34,9 +34,10 @@ func TestTraceSni(t *testing.T) {
 		StartAndStop: true,
 		ExpectedOutputFn: func(output string) error {
 			expectedEntry := &tracesniTypes.Event{
-				Event: BuildBaseEvent(ns),
-				Comm:  "wget",
-				Name:  "inspektor-gadget.io",
+				Event:   BuildBaseEvent(ns),
+				Comm:    "wget",
+				Name:    "inspektor-gadget.io",
+				Outcome: tracesniTypes.OutcomeOK,
 			}
 
 			normalize := func(e *tracesniTypes.Event) {
@@ -46,6 +47,13 @@ func TestTraceSni(t *testing.T) {
 				e.NetNsID = 0
 				e.Pid = 0
 				e.Tid = 0
+				e.SrcIP = ""
+				e.DstIP = ""
+				e.SrcPort = 0
+				e.DstPort = 0
+				e.ALPN = nil
+				e.Versions = nil
+				e.Version = ""
 			}
 
 			return ExpectAllToMatch(output, normalize, expectedEntry)
@@ -0,0 +1,69 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	traceudpTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/udp/types"
+
+	. "github.com/inspektor-gadget/inspektor-gadget/integration"
+)
+
+func TestTraceUdp(t *testing.T) {
+	ns := GenerateTestNamespaceName("test-udp")
+
+	t.Parallel()
+
+	traceUdpCmd := &Command{
+		Name:         "StartTraceUdpGadget",
+		Cmd:          fmt.Sprintf("$KUBECTL_GADGET trace udp -n %s -o json", ns),
+		StartAndStop: true,
+		ExpectedOutputFn: func(output string) error {
+			expectedEntry := &traceudpTypes.Event{
+				Event:     BuildBaseEvent(ns),
+				Operation: "send",
+				Comm:      "nc",
+				IPVersion: 4,
+				Daddr:     "127.0.0.1",
+				Dport:     9999,
+				Bytes:     6,
+			}
+
+			normalize := func(e *traceudpTypes.Event) {
+				e.Timestamp = 0
+				e.Node = ""
+				e.Pid = 0
+				e.Saddr = ""
+				e.Sport = 0
+				e.MountNsID = 0
+				e.NetNsID = 0
+			}
+
+			return ExpectEntriesToMatch(output, normalize, expectedEntry)
+		},
+	}
+
+	commands := []*Command{
+		CreateTestNamespaceCommand(ns),
+		traceUdpCmd,
+		BusyboxPodRepeatCommand(ns, "echo hello | nc -u -w1 127.0.0.1 9999"),
+		WaitUntilTestPodReadyCommand(ns),
+		DeleteTestNamespaceCommand(ns),
+	}
+
+	RunTestSteps(commands, t, WithCbBeforeCleanup(PrintLogsFn(ns)))
+}
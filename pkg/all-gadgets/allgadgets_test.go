@@ -0,0 +1,152 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package allgadgets
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/formatter/textcolumns"
+	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
+)
+
+const (
+	rawNumber = 100
+	rawString = "some\tvalue\n"
+)
+
+// fillEvent sets all the numbers and strings reachable without following pointers, so
+// that any human formatting applied to them shows up in the output
+func fillEvent(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fillEvent(v.Field(i))
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(rawNumber)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(rawNumber)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(rawNumber)
+	case reflect.String:
+		v.SetString(rawString)
+	}
+}
+
+// numericColumns returns the names of the columns that are backed by a numeric field of ev, including
+// the ones with an extractor formatting it
+func numericColumns(cols any, ev any) map[string]bool {
+	numeric := map[string]bool{}
+	iter := reflect.ValueOf(cols).MapRange()
+	for iter.Next() {
+		col := iter.Value()
+		if col.MethodByName("IsVirtual").Call(nil)[0].Bool() {
+			continue
+		}
+		switch col.MethodByName("GetRaw").Call([]reflect.Value{reflect.ValueOf(ev)})[0].Interface().(reflect.Value).Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64:
+			numeric[strings.ToUpper(iter.Key().String())] = true
+		}
+	}
+	return numeric
+}
+
+// TestRawOutput checks the --raw contract on the columns of all the registered gadgets: one
+// line per event, plain tab separated values without padding, truncation or units
+func TestRawOutput(t *testing.T) {
+	for _, gadgetDesc := range gadgetregistry.GetAll() {
+		gadgetDesc := gadgetDesc
+		parser := gadgetDesc.Parser()
+		if parser == nil {
+			continue
+		}
+
+		t.Run(fmt.Sprintf("%s/%s", gadgetDesc.Category(), gadgetDesc.Name()), func(t *testing.T) {
+			var names []string
+			for _, attributes := range parser.GetColumnAttributes() {
+				names = append(names, strings.ToUpper(attributes.Name))
+			}
+
+			formatter := parser.GetTextColumnsFormatter(textcolumns.WithRaw(true))
+			require.NoError(t, formatter.SetShowColumns(names))
+			require.Equal(t, strings.Join(names, "\t"), formatter.FormatHeader())
+
+			var lines []string
+			formatter.SetEventCallback(func(line string) {
+				lines = append(lines, line)
+			})
+
+			ev := gadgetDesc.EventPrototype()
+			fillEvent(reflect.ValueOf(ev).Elem())
+			numeric := numericColumns(parser.GetColumns(), ev)
+			reflect.ValueOf(formatter.EventHandlerFunc()).Call([]reflect.Value{reflect.ValueOf(ev)})
+			require.Len(t, lines, 1)
+			require.NotContains(t, lines[0], "\n")
+
+			fields := strings.Split(lines[0], "\t")
+			require.Len(t, fields, len(names))
+			for i, field := range fields {
+				require.Equal(t, strings.TrimSpace(field), field, "column %q is padded", names[i])
+				if numeric[names[i]] {
+					require.Equal(t, fmt.Sprint(rawNumber), field, "column %q isn't a plain number", names[i])
+				}
+			}
+		})
+	}
+}
+
+// TestEventEncoding checks that the events of all the registered gadgets can be sent to the
+// pipeline process, which encodes them with encoding/gob, without losing their exported fields
+func TestEventEncoding(t *testing.T) {
+	type message struct {
+		Event any
+	}
+
+	registered := map[reflect.Type]bool{}
+	for _, gadgetDesc := range gadgetregistry.GetAll() {
+		gadgetDesc := gadgetDesc
+		ev := gadgetDesc.EventPrototype()
+		if ev == nil {
+			continue
+		}
+		name := fmt.Sprintf("%s/%s", gadgetDesc.Category(), gadgetDesc.Name())
+
+		t.Run(name, func(t *testing.T) {
+			fillEvent(reflect.ValueOf(ev).Elem())
+			// Several gadgets can share the same events
+			if !registered[reflect.TypeOf(ev)] {
+				registered[reflect.TypeOf(ev)] = true
+				gob.RegisterName(name, ev)
+			}
+
+			var buf bytes.Buffer
+			require.NoError(t, gob.NewEncoder(&buf).Encode(&message{Event: ev}))
+			var decoded message
+			require.NoError(t, gob.NewDecoder(&buf).Decode(&decoded))
+			require.Equal(t, ev, decoded.Event)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkloadReference identifies the workload whose pods were observed
+type WorkloadReference struct {
+	// APIVersion of the workload
+	APIVersion string `json:"apiVersion,omitempty"`
+
+	// Kind of the workload: Deployment, StatefulSet, DaemonSet, Job,
+	// CronJob, ReplicationController or Pod
+	Kind string `json:"kind"`
+
+	// Name of the workload
+	Name string `json:"name"`
+}
+
+// ContainerProfile is the behaviour observed for a container of the workload
+type ContainerProfile struct {
+	// Name of the container in the pod spec
+	Name string `json:"name"`
+
+	// Syscalls is the sorted list of system calls made by the container
+	Syscalls []string `json:"syscalls,omitempty"`
+
+	// Capabilities is the sorted list of capabilities the container used,
+	// e.g. NET_BIND_SERVICE. Only the checks the kernel allowed are
+	// recorded.
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// NetworkPeerDirection is the direction of the traffic exchanged with a peer
+// +kubebuilder:validation:Enum=ingress;egress
+type NetworkPeerDirection string
+
+const (
+	NetworkPeerDirectionIngress NetworkPeerDirection = "ingress"
+	NetworkPeerDirectionEgress  NetworkPeerDirection = "egress"
+)
+
+// NetworkPeer is an endpoint the pods of the workload communicated with
+type NetworkPeer struct {
+	// Direction is ingress when the peer connected to the workload and
+	// egress when the workload connected to the peer
+	Direction NetworkPeerDirection `json:"direction"`
+
+	// Protocol is tcp or udp
+	Protocol string `json:"protocol"`
+
+	// Port is the port of the workload for ingress peers and the port of
+	// the peer for egress peers
+	Port uint16 `json:"port"`
+
+	// Kind is pod, svc or other
+	Kind string `json:"kind"`
+
+	// Namespace of the pod or service
+	Namespace string `json:"namespace,omitempty"`
+
+	// Name of the service. Pods are identified by their labels as their
+	// names change each time they are recreated.
+	Name string `json:"name,omitempty"`
+
+	// Labels of the pod or selector of the service
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Address is the IP address of the peer when it isn't a pod or a
+	// service
+	Address string `json:"address,omitempty"`
+}
+
+// WorkloadProfileSpec is the behaviour learned for a workload
+type WorkloadProfileSpec struct {
+	// Workload is the workload the profile was learned from
+	Workload WorkloadReference `json:"workload"`
+
+	// Containers is the profile of each container of the workload
+	Containers []ContainerProfile `json:"containers,omitempty"`
+
+	// NetworkPeers is the list of peers the pods of the workload
+	// communicated with
+	NetworkPeers []NetworkPeer `json:"networkPeers,omitempty"`
+}
+
+// +genclient
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:shortName=wp
+//+kubebuilder:printcolumn:name="Kind",type=string,JSONPath=`.spec.workload.kind`
+//+kubebuilder:printcolumn:name="Workload",type=string,JSONPath=`.spec.workload.name`
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// WorkloadProfile is the Schema for the workloadprofiles API
+type WorkloadProfile struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec WorkloadProfileSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// WorkloadProfileList contains a list of WorkloadProfile
+type WorkloadProfileList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WorkloadProfile `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WorkloadProfile{}, &WorkloadProfileList{})
+}
@@ -0,0 +1,304 @@
+// Copyright 2019-2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bpfconflicts detects the known patterns of interactions with the
+// other agents using eBPF on the host, like Cilium, Falco or Tetragon, that
+// make the gadgets fail or miss events.
+package bpfconflicts
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/bpfobjects"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+const (
+	KindTrampoline = "trampoline"
+	KindXDP        = "xdp"
+	KindLSM        = "lsm"
+	KindKprobe     = "kprobe"
+	KindFiles      = "files"
+)
+
+const (
+	// maxTrampolineLinks is the number of fentry, fexit, fmod_ret and LSM
+	// programs that can be attached to a kernel function
+	// (BPF_MAX_TRAMP_LINKS on x86-64)
+	maxTrampolineLinks = 38
+	// Warn when a function uses more than this number of slots
+	trampolineThreshold = 30
+	// Warn when the process uses more than this percentage of the file
+	// descriptors it's allowed to open
+	filesThresholdPercent = 80
+)
+
+// tracefs can be mounted at either path
+var tracefsPaths = []string{"/sys/kernel/tracing", "/sys/kernel/debug/tracing"}
+
+// The LSMs that can deny operations before the BPF LSM programs are called
+var macLSMs = map[string]bool{
+	"selinux":  true,
+	"apparmor": true,
+	"smack":    true,
+	"tomoyo":   true,
+	"landlock": true,
+}
+
+// Conflict is a pattern detected on the host that can make the gadgets fail
+// or miss events
+type Conflict struct {
+	Kind    string
+	Message string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: %s", c.Kind, c.Message)
+}
+
+// link is a BPF link held by other processes than the current one
+type link struct {
+	linkType string
+	attachTo string
+	owners   []string
+}
+
+// state is what the conflicts are detected from
+type state struct {
+	links []*link
+	lsms  []string
+	// kprobeMisses are the number of missed events of the probes created
+	// with tracefs, indexed by their name
+	kprobeMisses map[string]uint64
+	openFiles    uint64
+	maxFiles     uint64
+}
+
+// Detect returns the conflicts detected on the host. It reads the fdinfo of
+// all the processes, so it shouldn't be called in a hot path.
+func Detect() []Conflict {
+	s := &state{}
+
+	s.links = otherLinks()
+	if content, err := os.ReadFile(filepath.Join(host.HostRoot, "/sys/kernel/security/lsm")); err == nil {
+		s.lsms = strings.Split(strings.TrimSpace(string(content)), ",")
+	}
+	for _, path := range tracefsPaths {
+		file, err := os.Open(filepath.Join(path, "kprobe_profile"))
+		if err != nil {
+			continue
+		}
+		s.kprobeMisses, _ = parseKprobeProfile(file)
+		file.Close()
+		break
+	}
+	if fds, err := os.ReadDir("/proc/self/fd"); err == nil {
+		s.openFiles = uint64(len(fds))
+	}
+	var rlimit unix.Rlimit
+	if err := unix.Getrlimit(unix.RLIMIT_NOFILE, &rlimit); err == nil {
+		s.maxFiles = rlimit.Cur
+	}
+
+	return s.conflicts()
+}
+
+// otherLinks returns the BPF links held by the other processes
+func otherLinks() []*link {
+	self := uint32(os.Getpid())
+	funcName := bpfobjects.KernelFuncName()
+	links := make(map[uint32]*link)
+
+	bpfobjects.Walk(func(pid uint32, o *bpfobjects.Object) {
+		if o.Kind != bpfobjects.KindLink || pid == self {
+			return
+		}
+		l, ok := links[o.ID]
+		if !ok {
+			l = &link{
+				linkType: o.Fields["link_type"],
+				attachTo: o.AttachTo(funcName),
+			}
+			links[o.ID] = l
+		}
+		owner := fmt.Sprintf("%s (%d)", host.GetProcComm(int(pid)), pid)
+		if n := len(l.owners); n == 0 || l.owners[n-1] != owner {
+			l.owners = append(l.owners, owner)
+		}
+	})
+
+	ret := make([]*link, 0, len(links))
+	for _, l := range links {
+		ret = append(ret, l)
+	}
+	return ret
+}
+
+// parseKprobeProfile parses the kprobe_profile file of tracefs: each line is
+// the name of a probe, its number of hits and its number of missed events
+func parseKprobeProfile(r io.Reader) (map[string]uint64, error) {
+	misses := make(map[string]uint64)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		n, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil || n == 0 {
+			continue
+		}
+		misses[fields[0]] = n
+	}
+	return misses, scanner.Err()
+}
+
+// owners returns the sorted and deduplicated owners of the links
+func owners(links []*link) string {
+	set := make(map[string]struct{})
+	for _, l := range links {
+		for _, owner := range l.owners {
+			set[owner] = struct{}{}
+		}
+	}
+	ret := make([]string, 0, len(set))
+	for owner := range set {
+		ret = append(ret, owner)
+	}
+	sort.Strings(ret)
+	return strings.Join(ret, ", ")
+}
+
+func interfaceName(ifindex string) string {
+	index, err := strconv.Atoi(ifindex)
+	if err != nil {
+		return ifindex
+	}
+	iface, err := net.InterfaceByIndex(index)
+	if err != nil {
+		return ifindex
+	}
+	return iface.Name
+}
+
+func (s *state) conflicts() []Conflict {
+	var conflicts []Conflict
+
+	// The fentry, fexit, fmod_ret and LSM programs attached to the same
+	// function share a trampoline with a limited number of slots
+	trampolines := make(map[string][]*link)
+	// Only one XDP program can be attached to an interface
+	xdp := make(map[string][]*link)
+	var lsmLinks []*link
+	for _, l := range s.links {
+		prefix, target, ok := strings.Cut(l.attachTo, "/")
+		switch {
+		case l.linkType == "tracing" && ok && prefix != "tp_btf":
+			if prefix == "lsm" {
+				lsmLinks = append(lsmLinks, l)
+				if !strings.HasPrefix(target, "btf_id:") {
+					target = "bpf_lsm_" + target
+				}
+			}
+			trampolines[target] = append(trampolines[target], l)
+		case l.linkType == "xdp":
+			ifindex := strings.TrimPrefix(l.attachTo, "xdp ifindex:")
+			xdp[ifindex] = append(xdp[ifindex], l)
+		}
+	}
+
+	for target, links := range trampolines {
+		if len(links) < trampolineThreshold {
+			continue
+		}
+		conflicts = append(conflicts, Conflict{
+			Kind: KindTrampoline,
+			Message: fmt.Sprintf("%d of the %d programs that can be attached to %s are attached by %s: attaching more fentry, fexit or LSM programs to it fails with E2BIG",
+				len(links), maxTrampolineLinks, target, owners(links)),
+		})
+	}
+
+	for ifindex, links := range xdp {
+		conflicts = append(conflicts, Conflict{
+			Kind: KindXDP,
+			Message: fmt.Sprintf("an XDP program is attached to interface %s by %s: attaching another one to it fails with EBUSY",
+				interfaceName(ifindex), owners(links)),
+		})
+	}
+
+	if len(lsmLinks) > 0 {
+		var hooks []string
+		for _, l := range lsmLinks {
+			hooks = append(hooks, strings.TrimPrefix(l.attachTo, "lsm/"))
+		}
+		sort.Strings(hooks)
+		message := fmt.Sprintf("BPF LSM programs are attached to %s by %s: they can deny operations of the containers, reported with EPERM errors by the gadgets",
+			strings.Join(hooks, ", "), owners(lsmLinks))
+
+		// The LSMs are called in order and stop at the first denial
+		var before []string
+		for _, lsm := range s.lsms {
+			if lsm == "bpf" {
+				break
+			}
+			if macLSMs[lsm] {
+				before = append(before, lsm)
+			}
+		}
+		if len(before) > 0 {
+			message += fmt.Sprintf(". The operations denied by %s aren't seen by these programs as they are called before them", strings.Join(before, ", "))
+		}
+		conflicts = append(conflicts, Conflict{Kind: KindLSM, Message: message})
+	}
+
+	if len(s.kprobeMisses) > 0 {
+		var probes []string
+		for probe, misses := range s.kprobeMisses {
+			probes = append(probes, fmt.Sprintf("%s (%d)", probe, misses))
+		}
+		sort.Strings(probes)
+		conflicts = append(conflicts, Conflict{
+			Kind: KindKprobe,
+			Message: fmt.Sprintf("probes missed events, the return probes miss them when all their instances are in use: %s",
+				strings.Join(probes, ", ")),
+		})
+	}
+
+	if s.maxFiles > 0 && s.openFiles*100 >= s.maxFiles*filesThresholdPercent {
+		conflicts = append(conflicts, Conflict{
+			Kind: KindFiles,
+			Message: fmt.Sprintf("%d of the %d file descriptors allowed are open: each probe and perf buffer of the gadgets needs one, raise the limit with ulimit -n",
+				s.openFiles, s.maxFiles),
+		})
+	}
+
+	sort.Slice(conflicts, func(i, j int) bool {
+		if conflicts[i].Kind != conflicts[j].Kind {
+			return conflicts[i].Kind < conflicts[j].Kind
+		}
+		return conflicts[i].Message < conflicts[j].Message
+	})
+
+	return conflicts
+}
@@ -0,0 +1,89 @@
+// Copyright 2019-2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpfconflicts
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseKprobeProfile(t *testing.T) {
+	misses, err := parseKprobeProfile(strings.NewReader(
+		"  p_do_sys_openat2_0                                   1204               0\n" +
+			"  r_inet_csk_accept_0                                   837              12\n"))
+	if err != nil {
+		t.Fatalf("Could not parse kprobe_profile: %s", err)
+	}
+	expected := map[string]uint64{"r_inet_csk_accept_0": 12}
+	if !reflect.DeepEqual(misses, expected) {
+		t.Fatalf("Invalid misses %v. Expecting %v", misses, expected)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	s := &state{
+		lsms:      []string{"lockdown", "capability", "selinux", "bpf"},
+		openFiles: 100,
+		maxFiles:  1024,
+	}
+	if conflicts := s.conflicts(); len(conflicts) != 0 {
+		t.Fatalf("Unexpected conflicts %v", conflicts)
+	}
+
+	// Tetragon enforcing policies with BPF LSM programs
+	s.links = append(s.links,
+		&link{linkType: "tracing", attachTo: "lsm/file_open", owners: []string{"tetragon (1210)"}},
+		&link{linkType: "tracing", attachTo: "lsm/bprm_check_security", owners: []string{"tetragon (1210)"}},
+	)
+	// A lot of programs attached to the same function
+	for i := 0; i < trampolineThreshold; i++ {
+		s.links = append(s.links, &link{
+			linkType: "tracing",
+			attachTo: "fentry/tcp_connect",
+			owners:   []string{fmt.Sprintf("agent (%d)", 2000+i%2)},
+		})
+	}
+	s.links = append(s.links, &link{linkType: "xdp", attachTo: "xdp ifindex:4242", owners: []string{"cilium-agent (812)"}})
+	s.kprobeMisses = map[string]uint64{"r_inet_csk_accept_0": 12}
+	s.openFiles = 900
+
+	conflicts := s.conflicts()
+	kinds := []string{}
+	for _, conflict := range conflicts {
+		kinds = append(kinds, conflict.Kind)
+	}
+	expectedKinds := []string{KindFiles, KindKprobe, KindLSM, KindTrampoline, KindXDP}
+	if !reflect.DeepEqual(kinds, expectedKinds) {
+		t.Fatalf("Invalid conflicts %v. Expecting kinds %v", conflicts, expectedKinds)
+	}
+
+	lsm := conflicts[2].Message
+	for _, s := range []string{"bprm_check_security, file_open", "tetragon (1210)", "denied by selinux"} {
+		if !strings.Contains(lsm, s) {
+			t.Fatalf("LSM conflict %q doesn't mention %q", lsm, s)
+		}
+	}
+	trampoline := conflicts[3].Message
+	for _, s := range []string{"30 of the 38", "tcp_connect", "agent (2000), agent (2001)"} {
+		if !strings.Contains(trampoline, s) {
+			t.Fatalf("Trampoline conflict %q doesn't mention %q", trampoline, s)
+		}
+	}
+	if xdp := conflicts[4].Message; !strings.Contains(xdp, "interface 4242 by cilium-agent (812)") {
+		t.Fatalf("Invalid XDP conflict %q", xdp)
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright 2019-2022 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bpfobjects finds the BPF objects loaded on the host and the
+// processes holding file descriptors to them, from their fdinfo files.
+package bpfobjects
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/btf"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+const (
+	KindProgram = "prog"
+	KindMap     = "map"
+	KindLink    = "link"
+)
+
+// Object is a BPF object described by the fdinfo file of a file descriptor
+// referring to it
+type Object struct {
+	Kind   string
+	ID     uint32
+	Fields map[string]string
+}
+
+// ParseFdInfo parses the content of /proc/<pid>/fdinfo/<fd>. It returns nil
+// if the file descriptor doesn't refer to a BPF object.
+func ParseFdInfo(r io.Reader) (*Object, error) {
+	fields := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		fields[key] = strings.TrimSpace(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// The fdinfo of the links also contains the ID of their program
+	var kind, id string
+	switch {
+	case fields["link_id"] != "":
+		kind, id = KindLink, fields["link_id"]
+	case fields["prog_id"] != "":
+		kind, id = KindProgram, fields["prog_id"]
+	case fields["map_id"] != "":
+		kind, id = KindMap, fields["map_id"]
+	default:
+		return nil, nil
+	}
+
+	n, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s ID %q: %w", kind, id, err)
+	}
+
+	return &Object{Kind: kind, ID: uint32(n), Fields: fields}, nil
+}
+
+// ReadFdInfo parses the fdinfo file of the file descriptor fd of the process
+// pid, "self" for the current process
+func ReadFdInfo(pid string, fd string) (*Object, error) {
+	file, err := os.Open(filepath.Join(host.HostProcFs, pid, "fdinfo", fd))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ParseFdInfo(file)
+}
+
+// Walk calls fn for each file descriptor of the processes of the host that
+// refers to a BPF object
+func Walk(fn func(pid uint32, o *Object)) error {
+	processes, err := os.ReadDir(host.HostProcFs)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range processes {
+		pid, err := strconv.ParseUint(p.Name(), 10, 32)
+		if err != nil || !p.IsDir() {
+			continue
+		}
+		// Processes can exit during the walk
+		fds, err := os.ReadDir(filepath.Join(host.HostProcFs, p.Name(), "fdinfo"))
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			o, err := ReadFdInfo(p.Name(), fd.Name())
+			if err != nil || o == nil {
+				continue
+			}
+			fn(uint32(pid), o)
+		}
+	}
+
+	return nil
+}
+
+// Uint returns the value of a numeric field, 0 if it's not present
+func (o *Object) Uint(key string) uint64 {
+	n, _ := strconv.ParseUint(o.Fields[key], 10, 64)
+	return n
+}
+
+// Prefixes of the section names of the programs attached by tracing links,
+// they are followed by the name of the function
+var tracingAttachPrefixes = map[ebpf.AttachType]string{
+	ebpf.AttachTraceRawTp:   "tp_btf/",
+	ebpf.AttachTraceFEntry:  "fentry/",
+	ebpf.AttachTraceFExit:   "fexit/",
+	ebpf.AttachModifyReturn: "fmod_ret/",
+	ebpf.AttachLSMMac:       "lsm/",
+}
+
+// The BPF LSM programs are attached to the functions named after the hooks
+// with this prefix
+const bpfLSMPrefix = "bpf_lsm_"
+
+// AttachTo describes what a link attaches its program to. funcName resolves
+// the BTF IDs of the kernel functions, see KernelFuncName.
+func (o *Object) AttachTo(funcName func(btfID uint32) string) string {
+	attachType := ebpf.AttachType(o.Uint("attach_type"))
+
+	switch o.Fields["link_type"] {
+	case "tracing":
+		prefix, ok := tracingAttachPrefixes[attachType]
+		if !ok {
+			return attachType.String()
+		}
+		name := funcName(uint32(o.Uint("target_btf_id")))
+		if name == "" {
+			return fmt.Sprintf("%sbtf_id:%d", prefix, o.Uint("target_btf_id"))
+		}
+		if attachType == ebpf.AttachLSMMac {
+			name = strings.TrimPrefix(name, bpfLSMPrefix)
+		}
+		return prefix + name
+	case "cgroup":
+		// AttachCGroupInetIngress is the same value as AttachNone
+		name := attachType.String()
+		if attachType == ebpf.AttachCGroupInetIngress {
+			name = "CGroupInetIngress"
+		}
+		return fmt.Sprintf("%s cgroup_id:%d", name, o.Uint("cgroup_id"))
+	case "netns":
+		return fmt.Sprintf("%s netns:%d", attachType, o.Uint("netns_ino"))
+	case "xdp":
+		return fmt.Sprintf("xdp ifindex:%d", o.Uint("ifindex"))
+	}
+
+	return ""
+}
+
+// KernelFuncName returns a function resolving the names of the kernel
+// functions the tracing links are attached to
+func KernelFuncName() func(uint32) string {
+	spec, err := btf.LoadKernelSpec()
+	if err != nil {
+		return func(uint32) string { return "" }
+	}
+	return func(id uint32) string {
+		typ, err := spec.TypeByID(btf.TypeID(id))
+		if err != nil {
+			return ""
+		}
+		return typ.TypeName()
+	}
+}
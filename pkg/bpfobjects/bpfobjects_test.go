@@ -0,0 +1,78 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bpfobjects
+
+import (
+	"strings"
+	"testing"
+)
+
+func mustParseFdInfo(t *testing.T, content string) *Object {
+	o, err := ParseFdInfo(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("Could not parse fdinfo: %s", err)
+	}
+	return o
+}
+
+func TestParseFdInfo(t *testing.T) {
+	o := mustParseFdInfo(t, "pos:\t0\nflags:\t02000002\nmnt_id:\t15\nino:\t1057\nprog_type:\t8\nprog_jited:\t1\nprog_tag:\t4f2ff5d8a5e0b1c3\nmemlock:\t4096\nprog_id:\t42\n")
+	if o == nil || o.Kind != KindProgram || o.ID != 42 || o.Uint("memlock") != 4096 {
+		t.Fatalf("Invalid program %+v", o)
+	}
+
+	o = mustParseFdInfo(t, "pos:\t0\nflags:\t02000002\nmnt_id:\t15\nino:\t1057\nmap_type:\t1\nkey_size:\t4\nvalue_size:\t8\nmax_entries:\t1024\nmap_flags:\t0x0\nmemlock:\t16384\nmap_id:\t7\nfrozen:\t0\n")
+	if o == nil || o.Kind != KindMap || o.ID != 7 {
+		t.Fatalf("Invalid map %+v", o)
+	}
+
+	// The links also have the ID of their program
+	o = mustParseFdInfo(t, "pos:\t0\nflags:\t02000000\nmnt_id:\t15\nino:\t1057\nlink_type:\ttracing\nlink_id:\t3\nprog_id:\t42\nattach_type:\t27\ntarget_obj_id:\t1\ntarget_btf_id:\t12345\n")
+	if o == nil || o.Kind != KindLink || o.ID != 3 || o.Uint("prog_id") != 42 {
+		t.Fatalf("Invalid link %+v", o)
+	}
+
+	o = mustParseFdInfo(t, "pos:\t0\nflags:\t02000002\nmnt_id:\t26\nino:\t3746\n")
+	if o != nil {
+		t.Fatalf("File descriptor of a regular file was parsed as %+v", o)
+	}
+}
+
+func TestLinkAttachTo(t *testing.T) {
+	funcName := func(id uint32) string {
+		if id == 12345 {
+			return "bpf_lsm_file_open"
+		}
+		return ""
+	}
+
+	table := []struct {
+		fdinfo   string
+		expected string
+	}{
+		{"link_type:\ttracing\nlink_id:\t3\nprog_id:\t42\nattach_type:\t27\ntarget_obj_id:\t1\ntarget_btf_id:\t12345\n", "lsm/file_open"},
+		{"link_type:\ttracing\nlink_id:\t4\nprog_id:\t43\nattach_type:\t24\ntarget_obj_id:\t1\ntarget_btf_id:\t999\n", "fentry/btf_id:999"},
+		{"link_type:\tcgroup\nlink_id:\t5\nprog_id:\t44\ncgroup_id:\t1\nattach_type:\t1\n", "CGroupInetEgress cgroup_id:1"},
+		{"link_type:\tcgroup\nlink_id:\t8\nprog_id:\t47\ncgroup_id:\t1\nattach_type:\t0\n", "CGroupInetIngress cgroup_id:1"},
+		{"link_type:\txdp\nlink_id:\t6\nprog_id:\t45\nifindex:\t2\n", "xdp ifindex:2"},
+		{"link_type:\tperf_event\nlink_id:\t7\nprog_id:\t46\n", ""},
+	}
+	for _, entry := range table {
+		o := mustParseFdInfo(t, entry.fdinfo)
+		if attachTo := o.AttachTo(funcName); attachTo != entry.expected {
+			t.Fatalf("Invalid attach target %q for %q. Expecting %q", attachTo, entry.fdinfo, entry.expected)
+		}
+	}
+}
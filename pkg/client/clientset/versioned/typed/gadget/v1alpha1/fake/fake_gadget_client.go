@@ -30,6 +30,10 @@ func (c *FakeGadgetV1alpha1) Traces(namespace string) v1alpha1.TraceInterface {
 	return &FakeTraces{c, namespace}
 }
 
+func (c *FakeGadgetV1alpha1) WorkloadProfiles(namespace string) v1alpha1.WorkloadProfileInterface {
+	return &FakeWorkloadProfiles{c, namespace}
+}
+
 // RESTClient returns a RESTClient that is used to communicate
 // with API server by this client implementation.
 func (c *FakeGadgetV1alpha1) RESTClient() rest.Interface {
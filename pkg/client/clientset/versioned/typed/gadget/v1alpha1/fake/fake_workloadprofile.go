@@ -0,0 +1,128 @@
+// Copyright 2019-2021 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeWorkloadProfiles implements WorkloadProfileInterface
+type FakeWorkloadProfiles struct {
+	Fake *FakeGadgetV1alpha1
+	ns   string
+}
+
+var workloadprofilesResource = schema.GroupVersionResource{Group: "gadget", Version: "v1alpha1", Resource: "workloadprofiles"}
+
+var workloadprofilesKind = schema.GroupVersionKind{Group: "gadget", Version: "v1alpha1", Kind: "WorkloadProfile"}
+
+// Get takes name of the workloadProfile, and returns the corresponding workloadProfile object, and an error if there is any.
+func (c *FakeWorkloadProfiles) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.WorkloadProfile, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(workloadprofilesResource, c.ns, name), &v1alpha1.WorkloadProfile{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.WorkloadProfile), err
+}
+
+// List takes label and field selectors, and returns the list of WorkloadProfiles that match those selectors.
+func (c *FakeWorkloadProfiles) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.WorkloadProfileList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(workloadprofilesResource, workloadprofilesKind, c.ns, opts), &v1alpha1.WorkloadProfileList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.WorkloadProfileList{ListMeta: obj.(*v1alpha1.WorkloadProfileList).ListMeta}
+	for _, item := range obj.(*v1alpha1.WorkloadProfileList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested workloadProfiles.
+func (c *FakeWorkloadProfiles) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(workloadprofilesResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a workloadProfile and creates it.  Returns the server's representation of the workloadProfile, and an error, if there is any.
+func (c *FakeWorkloadProfiles) Create(ctx context.Context, workloadProfile *v1alpha1.WorkloadProfile, opts v1.CreateOptions) (result *v1alpha1.WorkloadProfile, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(workloadprofilesResource, c.ns, workloadProfile), &v1alpha1.WorkloadProfile{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.WorkloadProfile), err
+}
+
+// Update takes the representation of a workloadProfile and updates it. Returns the server's representation of the workloadProfile, and an error, if there is any.
+func (c *FakeWorkloadProfiles) Update(ctx context.Context, workloadProfile *v1alpha1.WorkloadProfile, opts v1.UpdateOptions) (result *v1alpha1.WorkloadProfile, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(workloadprofilesResource, c.ns, workloadProfile), &v1alpha1.WorkloadProfile{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.WorkloadProfile), err
+}
+
+// Delete takes name of the workloadProfile and deletes it. Returns an error if one occurs.
+func (c *FakeWorkloadProfiles) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteAction(workloadprofilesResource, c.ns, name), &v1alpha1.WorkloadProfile{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeWorkloadProfiles) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(workloadprofilesResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.WorkloadProfileList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched workloadProfile.
+func (c *FakeWorkloadProfiles) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.WorkloadProfile, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(workloadprofilesResource, c.ns, name, pt, data, subresources...), &v1alpha1.WorkloadProfile{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.WorkloadProfile), err
+}
@@ -25,6 +25,7 @@ import (
 type GadgetV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	TracesGetter
+	WorkloadProfilesGetter
 }
 
 // GadgetV1alpha1Client is used to interact with features provided by the gadget group.
@@ -36,6 +37,10 @@ func (c *GadgetV1alpha1Client) Traces(namespace string) TraceInterface {
 	return newTraces(c, namespace)
 }
 
+func (c *GadgetV1alpha1Client) WorkloadProfiles(namespace string) WorkloadProfileInterface {
+	return newWorkloadProfiles(c, namespace)
+}
+
 // NewForConfig creates a new GadgetV1alpha1Client for the given config.
 func NewForConfig(c *rest.Config) (*GadgetV1alpha1Client, error) {
 	config := *c
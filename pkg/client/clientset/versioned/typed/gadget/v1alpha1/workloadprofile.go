@@ -0,0 +1,176 @@
+// Copyright 2019-2021 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	"time"
+
+	v1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	scheme "github.com/inspektor-gadget/inspektor-gadget/pkg/client/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// WorkloadProfilesGetter has a method to return a WorkloadProfileInterface.
+// A group's client should implement this interface.
+type WorkloadProfilesGetter interface {
+	WorkloadProfiles(namespace string) WorkloadProfileInterface
+}
+
+// WorkloadProfileInterface has methods to work with WorkloadProfile resources.
+type WorkloadProfileInterface interface {
+	Create(ctx context.Context, workloadProfile *v1alpha1.WorkloadProfile, opts v1.CreateOptions) (*v1alpha1.WorkloadProfile, error)
+	Update(ctx context.Context, workloadProfile *v1alpha1.WorkloadProfile, opts v1.UpdateOptions) (*v1alpha1.WorkloadProfile, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha1.WorkloadProfile, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha1.WorkloadProfileList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.WorkloadProfile, err error)
+	WorkloadProfileExpansion
+}
+
+// workloadProfiles implements WorkloadProfileInterface
+type workloadProfiles struct {
+	client rest.Interface
+	ns     string
+}
+
+// newWorkloadProfiles returns a WorkloadProfiles
+func newWorkloadProfiles(c *GadgetV1alpha1Client, namespace string) *workloadProfiles {
+	return &workloadProfiles{
+		client: c.RESTClient(),
+		ns:     namespace,
+	}
+}
+
+// Get takes name of the workloadProfile, and returns the corresponding workloadProfile object, and an error if there is any.
+func (c *workloadProfiles) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.WorkloadProfile, err error) {
+	result = &v1alpha1.WorkloadProfile{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("workloadprofiles").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of WorkloadProfiles that match those selectors.
+func (c *workloadProfiles) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.WorkloadProfileList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha1.WorkloadProfileList{}
+	err = c.client.Get().
+		Namespace(c.ns).
+		Resource("workloadprofiles").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested workloadProfiles.
+func (c *workloadProfiles) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Namespace(c.ns).
+		Resource("workloadprofiles").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a workloadProfile and creates it.  Returns the server's representation of the workloadProfile, and an error, if there is any.
+func (c *workloadProfiles) Create(ctx context.Context, workloadProfile *v1alpha1.WorkloadProfile, opts v1.CreateOptions) (result *v1alpha1.WorkloadProfile, err error) {
+	result = &v1alpha1.WorkloadProfile{}
+	err = c.client.Post().
+		Namespace(c.ns).
+		Resource("workloadprofiles").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(workloadProfile).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a workloadProfile and updates it. Returns the server's representation of the workloadProfile, and an error, if there is any.
+func (c *workloadProfiles) Update(ctx context.Context, workloadProfile *v1alpha1.WorkloadProfile, opts v1.UpdateOptions) (result *v1alpha1.WorkloadProfile, err error) {
+	result = &v1alpha1.WorkloadProfile{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("workloadprofiles").
+		Name(workloadProfile.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(workloadProfile).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the workloadProfile and deletes it. Returns an error if one occurs.
+func (c *workloadProfiles) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("workloadprofiles").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *workloadProfiles) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Namespace(c.ns).
+		Resource("workloadprofiles").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched workloadProfile.
+func (c *workloadProfiles) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.WorkloadProfile, err error) {
+	result = &v1alpha1.WorkloadProfile{}
+	err = c.client.Patch(pt).
+		Namespace(c.ns).
+		Resource("workloadprofiles").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
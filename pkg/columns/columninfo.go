@@ -66,6 +66,7 @@ type Column[T any] struct {
 	kind          reflect.Kind // cached kind info from reflection
 	columnType    reflect.Type // cached type info from reflection
 	useTemplate   bool         // if a template has been set, this will be true
+	isEnum        bool         // if the field is an Enum, the column points to its name
 	template      string       // defines the template that will be used. Non-typed templates will be applied first.
 }
 
@@ -294,9 +295,15 @@ func (ci *Column[T]) getRawField(v reflect.Value) reflect.Value {
 		v = v.Elem()
 	}
 	if len(ci.subFieldIndex) > 0 {
-		return ci.getFieldRec(v, ci.subFieldIndex)
+		v = ci.getFieldRec(v, ci.subFieldIndex)
+	} else {
+		v = v.Field(ci.fieldIndex)
 	}
-	return v.Field(ci.fieldIndex)
+	if ci.isEnum && v.Kind() == reflect.Struct {
+		// Name is the first field of Enum
+		return v.Field(0)
+	}
+	return v
 }
 
 func (ci *Column[T]) getFieldRec(v reflect.Value, sub []subField) reflect.Value {
@@ -353,6 +360,17 @@ func (ci *Column[T]) IsVirtual() bool {
 	return ci.fieldIndex == virtualIndex
 }
 
+// IsEnum returns true, if the column is a field of type Enum. Its kind is reflect.String, the one of the name.
+func (ci *Column[T]) IsEnum() bool {
+	return ci.isEnum
+}
+
+// GetEnumValueOffset returns the offset of the numeric value of an Enum column, GetOffset returning the one of its
+// name
+func (ci *Column[T]) GetEnumValueOffset() uintptr {
+	return ci.offset + enumValueOffset
+}
+
 // HasCustomExtractor returns true, if the column has a user defined extractor set
 func (ci *Column[T]) HasCustomExtractor() bool {
 	return ci.Extractor != nil
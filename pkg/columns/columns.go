@@ -166,8 +166,15 @@ func (c *Columns[T]) iterateFields(t reflect.Type, sub []subField, offset uintpt
 
 		tag := f.Tag.Get("column")
 
+		isEnum := f.Type == enumType
+
+		// The columns of enums point to their name, which can't be done through a pointer
+		if f.Type.Kind() == reflect.Pointer && f.Type.Elem() == enumType {
+			return fmt.Errorf("unsupported pointer to Enum on field %q of %q", f.Name, t.Name())
+		}
+
 		// If this field is a pointer to a struct or a struct, try to embed it unless a "noembed" tag is set
-		if f.Type.Kind() == reflect.Struct || (f.Type.Kind() == reflect.Pointer && f.Type.Elem().Kind() == reflect.Struct) {
+		if !isEnum && (f.Type.Kind() == reflect.Struct || (f.Type.Kind() == reflect.Pointer && f.Type.Elem().Kind() == reflect.Struct)) {
 			if !strings.Contains(tag, ",noembed") {
 				err := c.iterateFields(f.Type, append(append([]subField{}, sub...), subField{i, isPtr}), offset+f.Offset)
 				if err != nil {
@@ -208,6 +215,13 @@ func (c *Columns[T]) iterateFields(t reflect.Type, sub []subField, offset uintpt
 		column.kind = f.Type.Kind()
 		column.columnType = f.Type
 
+		// enums are handled like their name
+		if isEnum {
+			column.isEnum = true
+			column.kind = reflect.String
+			column.columnType = stringType
+		}
+
 		// read information from tag
 		err := column.fromTag(tag)
 		if err != nil {
@@ -15,6 +15,7 @@
 package columns
 
 import (
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"testing"
@@ -355,6 +356,72 @@ func TestStringer(t *testing.T) {
 	}
 }
 
+func TestEnum(t *testing.T) {
+	type testStruct struct {
+		EnumField Enum `column:"enumField"`
+	}
+	cols := expectColumnsSuccess[testStruct](t)
+	col := expectColumn(t, cols, "enumField")
+
+	if !col.IsEnum() || col.Kind() != reflect.String {
+		t.Fatalf("expected enum column of kind string")
+	}
+
+	ts := &testStruct{EnumField: Enum{Name: "ESTABLISHED", Value: 1}}
+
+	val, ok := col.Get(ts).Interface().(string)
+	if !ok {
+		t.Fatalf("expected type string")
+	}
+	expected := "ESTABLISHED"
+	if val != expected {
+		t.Errorf("expected %q from enum, got %q", expected, val)
+	}
+	if GetField[int64](ts, col.GetEnumValueOffset()) != 1 {
+		t.Errorf("expected value 1 at the offset of the enum value")
+	}
+}
+
+func TestEnumPointer(t *testing.T) {
+	type testStruct struct {
+		EnumField *Enum `column:"enumField"`
+	}
+	expectColumnsFail[testStruct](t, "pointer to enum")
+}
+
+func TestEnumJSON(t *testing.T) {
+	type embedded struct {
+		Reason Enum `json:"reason" column:"reason"`
+	}
+	type testStruct struct {
+		embedded
+		State Enum `json:"state" column:"state"`
+	}
+	expectColumnsSuccess[testStruct](t)
+
+	ts := testStruct{
+		embedded: embedded{Reason: Enum{Name: "NO_SOCKET", Value: 3}},
+		State:    Enum{Name: "ESTABLISHED", Value: 1},
+	}
+
+	b, err := json.Marshal(&ts)
+	if err != nil {
+		t.Fatalf("marshalling: %v", err)
+	}
+	expected := `{"reason":{"name":"NO_SOCKET","value":3},"state":{"name":"ESTABLISHED","value":1}}`
+	if string(b) != expected {
+		t.Errorf("expected %s, got %s", expected, b)
+	}
+
+	var got testStruct
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("unmarshalling: %v", err)
+	}
+	if got != ts {
+		t.Errorf("expected %+v after round trip, got %+v", ts, got)
+	}
+}
+
 func TestVirtualColumns(t *testing.T) {
 	type testStruct struct {
 		StringField string `column:"stringField"`
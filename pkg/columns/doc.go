@@ -88,5 +88,16 @@ You can also just override the default extractor like so:
 	cols.SetExtractor("node", func(a *Event) string {
 		return "Foobar"
 	})
+
+# Enums
+
+Fields holding a value out of a known set, like the state of a TCP connection, can use the Enum type to keep both the
+value and its symbolic name:
+
+	type Event struct {
+		State columns.Enum `json:"state" column:"state"`
+	}
+
+The column shows the name and can be filtered by the name (`state:ESTABLISHED`) or by the value (`state:1`).
 */
 package columns
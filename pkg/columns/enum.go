@@ -0,0 +1,38 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package columns
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// Enum is a value out of a known set with a symbolic name, like the state of a TCP connection or the number of a
+// syscall. Columns of this type show the name, can be filtered by the name or the value, and both are kept when the
+// struct is marshalled to JSON.
+type Enum struct {
+	// Name must stay the first field, the columns of this type point to it
+	Name  string `json:"name"`
+	Value int64  `json:"value"`
+}
+
+func (e Enum) String() string {
+	return e.Name
+}
+
+var (
+	enumType        = reflect.TypeOf(Enum{})
+	enumValueOffset = unsafe.Offsetof(Enum{}.Value)
+)
@@ -79,6 +79,13 @@ func getValueFromFilterSpec[T any](fs *FilterSpec[T], column *columns.Column[T])
 		}
 		value = reflect.ValueOf(number).Convert(column.Type())
 	case reflect.String:
+		if column.IsEnum() {
+			// Enums can be matched by their value as well as by their name
+			if number, err := strconv.ParseInt(fs.value, 10, 64); err == nil {
+				value = reflect.ValueOf(number)
+				break
+			}
+		}
 		value = reflect.ValueOf(fs.value)
 	default:
 		return reflect.Value{}, fmt.Errorf("tried to match %q on unsupported column %q", fs.value, column.Name)
@@ -214,6 +221,9 @@ func (fs *FilterSpec[T]) getComparisonFunc() func(*T) bool {
 				return fs.regex.MatchString(columns.GetField[string](entry, fs.column.GetOffset())) != fs.negate
 			}
 		}
+		if _, ok := fs.refValue.(int64); ok && fs.column.IsEnum() {
+			return getComparisonFuncForComparisonType[int64, T](fs.comparisonType, fs.negate, fs.column.GetEnumValueOffset(), fs.refValue)
+		}
 		return getComparisonFuncForComparisonType[string, T](fs.comparisonType, fs.negate, offset, fs.refValue)
 	case reflect.Float32:
 		return getComparisonFuncForComparisonType[float32, T](fs.comparisonType, fs.negate, offset, fs.refValue)
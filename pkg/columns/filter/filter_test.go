@@ -22,22 +22,23 @@ import (
 
 func TestFilters(t *testing.T) {
 	type testData struct {
-		Int         int      `column:"int,align:right,width:6"`
-		Int8        int8     `column:"int8,align:right,width:6"`
-		Int16       int16    `column:"int16,align:right,width:6"`
-		Int32       int32    `column:"int32,align:right,width:6"`
-		Int64       int64    `column:"int64,align:right,width:6"`
-		Uint        uint     `column:"uint,align:right,width:6"`
-		Uint8       uint8    `column:"uint8,align:right,width:6"`
-		Uint16      uint16   `column:"uint16,align:right,width:6"`
-		Uint32      uint32   `column:"uint32,align:right,width:6"`
-		Uint64      uint64   `column:"uint64,align:right,width:6"`
-		String      string   `column:"string"`
-		Dummy       string   // This a dummy field that we can expose using a virtual field
-		Time        int64    `column:"time,align:right,width:24,group:sum"`
-		Float32     float32  `column:"float32"`
-		Float64     float64  `column:"float64"`
-		Unsupported struct{} `column:"unsupported"`
+		Int         int          `column:"int,align:right,width:6"`
+		Int8        int8         `column:"int8,align:right,width:6"`
+		Int16       int16        `column:"int16,align:right,width:6"`
+		Int32       int32        `column:"int32,align:right,width:6"`
+		Int64       int64        `column:"int64,align:right,width:6"`
+		Uint        uint         `column:"uint,align:right,width:6"`
+		Uint8       uint8        `column:"uint8,align:right,width:6"`
+		Uint16      uint16       `column:"uint16,align:right,width:6"`
+		Uint32      uint32       `column:"uint32,align:right,width:6"`
+		Uint64      uint64       `column:"uint64,align:right,width:6"`
+		String      string       `column:"string"`
+		Dummy       string       // This a dummy field that we can expose using a virtual field
+		Time        int64        `column:"time,align:right,width:24,group:sum"`
+		Float32     float32      `column:"float32"`
+		Float64     float64      `column:"float64"`
+		Unsupported struct{}     `column:"unsupported"`
+		Enum        columns.Enum `column:"enum"`
 	}
 
 	type filterTest struct {
@@ -62,6 +63,7 @@ func TestFilters(t *testing.T) {
 			Uint64:  7,
 			Float32: 7,
 			Float64: 7,
+			Enum:    columns.Enum{Name: "SEVEN", Value: 7},
 		},
 		{
 			String:  "Demo 123",
@@ -77,6 +79,7 @@ func TestFilters(t *testing.T) {
 			Uint64:  1,
 			Float32: 1,
 			Float64: 1,
+			Enum:    columns.Enum{Name: "ONE", Value: 1},
 		},
 		{
 			String:  "Demo 234",
@@ -92,6 +95,7 @@ func TestFilters(t *testing.T) {
 			Uint64:  2,
 			Float32: 2,
 			Float64: 2,
+			Enum:    columns.Enum{Name: "TWO", Value: 2},
 		},
 		{
 			String:  "Demo 234",
@@ -107,6 +111,7 @@ func TestFilters(t *testing.T) {
 			Uint64:  3,
 			Float32: 3,
 			Float64: 3,
+			Enum:    columns.Enum{Name: "THREE", Value: 3},
 		},
 		{
 			String:  "Foobar",
@@ -122,6 +127,7 @@ func TestFilters(t *testing.T) {
 			Uint64:  2,
 			Float32: 2,
 			Float64: 2,
+			Enum:    columns.Enum{Name: "TWO", Value: 2},
 		},
 		nil,
 	}
@@ -142,6 +148,12 @@ func TestFilters(t *testing.T) {
 		{filterString: "string:!~(?i)demo", expectedCount: 2, expectError: false, description: "negated case-insensitive regular expression search"},
 		{filterString: "string:~(?i)??//{demo", expectedCount: 0, expectError: true, description: "garbage regular expression search"},
 
+		{filterString: "enum:TWO", expectedCount: 2, expectError: false, description: "match on enum, by name"},
+		{filterString: "enum:2", expectedCount: 2, expectError: false, description: "match on enum, by value"},
+		{filterString: "enum:!2", expectedCount: 3, expectError: false, description: "match on enum, negated value"},
+		{filterString: "enum:>2", expectedCount: 2, expectError: false, description: "match on enum, gt value"},
+		{filterString: "enum:~^T", expectedCount: 3, expectError: false, description: "match on enum, regular expression on name"},
+
 		{filterString: "int:", expectedCount: 0, expectError: true, description: "match on int, empty string"},
 		{filterString: "int:1", expectedCount: 1, expectError: false, description: "match on int, exact match"},
 		{filterString: "int:~1", expectedCount: 0, expectError: true, description: "match on int, wrong comparison type"},
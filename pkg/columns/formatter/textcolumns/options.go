@@ -37,6 +37,7 @@ type Options struct {
 	DefaultColumns []string    // defines which columns to show by default; will be set to all visible columns if nil
 	HeaderStyle    HeaderStyle // defines how column headers are decorated (e.g. uppercase/lowercase)
 	RowDivider     string      // defines the (to be repeated) string that should be used below the header
+	Raw            bool        // if enabled, values are written as they are, see WithRaw
 }
 
 func DefaultOptions() *Options {
@@ -83,3 +84,16 @@ func WithRowDivider(divider string) Option {
 		opts.RowDivider = divider
 	}
 }
+
+// WithRaw sets whether the values should be written as they are, for scripts: without padding, truncation nor the
+// custom extractors of numeric fields (e.g. durations are written in nanoseconds), tabs and newlines escaped,
+// columns separated by tabs and no row divider.
+func WithRaw(raw bool) Option {
+	return func(opts *Options) {
+		opts.Raw = raw
+		if raw {
+			opts.AutoScale = false
+			opts.ColumnDivider = DividerTab
+		}
+	}
+}
@@ -27,7 +27,50 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/ellipsis"
 )
 
+var rawReplacer = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n")
+
+// formatRaw returns v as it is, with only the characters that would break the rows and columns escaped
+func formatRaw(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Int,
+		reflect.Int8,
+		reflect.Int16,
+		reflect.Int32,
+		reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint,
+		reflect.Uint8,
+		reflect.Uint16,
+		reflect.Uint32,
+		reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32,
+		reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	case reflect.String:
+		return rawReplacer.Replace(v.String())
+	default:
+		return rawReplacer.Replace(fmt.Sprintf("%v", v.Interface()))
+	}
+}
+
 func (tf *TextColumnsFormatter[T]) setFormatter(column *Column[T]) {
+	if tf.options.Raw {
+		// Numbers are written as such, even when the column formats them, e.g. as a duration
+		if column.col.HasCustomExtractor() && !column.col.IsVirtual() {
+			switch column.col.GetRaw(new(T)).Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+				reflect.Float32, reflect.Float64:
+				column.useRaw = true
+			}
+		}
+		column.formatter = func(v interface{}) string {
+			return formatRaw(reflect.ValueOf(v))
+		}
+		return
+	}
+
 	switch column.col.Kind() {
 	case reflect.Int,
 		reflect.Int8,
@@ -90,7 +133,12 @@ func (tf *TextColumnsFormatter[T]) FormatEntry(entry *T) string {
 		if i > 0 {
 			row.WriteString(tf.options.ColumnDivider)
 		}
-		field := col.col.GetRef(entryValue)
+		var field reflect.Value
+		if col.useRaw {
+			field = col.col.GetRaw(entry)
+		} else {
+			field = col.col.GetRef(entryValue)
+		}
 		row.WriteString(col.formatter(field.Interface()))
 	}
 	return row.String()
@@ -111,6 +159,10 @@ func (tf *TextColumnsFormatter[T]) FormatHeader() string {
 		case HeaderStyleLowercase:
 			name = strings.ToLower(name)
 		}
+		if tf.options.Raw {
+			row.WriteString(name)
+			continue
+		}
 		row.WriteString(tf.buildFixedString(name, column.calculatedWidth, ellipsis.End, column.col.Alignment))
 	}
 	return row.String()
@@ -118,7 +170,7 @@ func (tf *TextColumnsFormatter[T]) FormatHeader() string {
 
 // FormatRowDivider returns a string that repeats the defined RowDivider until the total length of a row is reached
 func (tf *TextColumnsFormatter[T]) FormatRowDivider() string {
-	if tf.options.RowDivider == DividerNone {
+	if tf.options.RowDivider == DividerNone || tf.options.Raw {
 		return ""
 	}
 	var row strings.Builder
@@ -153,7 +205,7 @@ func (tf *TextColumnsFormatter[T]) WriteTable(writer io.Writer, entries []*T) er
 	if err != nil {
 		return err
 	}
-	if tf.options.RowDivider != DividerNone {
+	if tf.options.RowDivider != DividerNone && !tf.options.Raw {
 		_, err = writer.Write([]byte(tf.FormatRowDivider()))
 		if err != nil {
 			return err
@@ -27,6 +27,7 @@ type Column[T any] struct {
 	calculatedWidth int
 	treatAsFixed    bool
 	formatter       ColumnFormatter
+	useRaw          bool // in raw mode, the value is taken without the custom extractor
 }
 
 type TextColumnsFormatter[T any] struct {
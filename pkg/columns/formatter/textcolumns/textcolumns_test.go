@@ -17,6 +17,7 @@ package textcolumns
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 
@@ -242,3 +243,27 @@ func TestTextColumnsFormatter_SetShownColumns(t *testing.T) {
 		})
 	}
 }
+
+func TestTextColumnsFormatter_Raw(t *testing.T) {
+	type rawStruct struct {
+		Name     string  `column:"name,width:4"`
+		Duration int64   `column:"duration,width:4"`
+		Ratio    float64 `column:"ratio,precision:1"`
+	}
+	cols := columns.MustCreateColumns[rawStruct]()
+	cols.MustSetExtractor("duration", func(e *rawStruct) string {
+		return time.Duration(e.Duration).String()
+	})
+
+	formatter := NewFormatter(cols.GetColumnMap(), WithRaw(true), WithRowDivider(DividerDash))
+	require.NoError(t, formatter.SetShowColumns([]string{"name", "duration", "ratio"}))
+
+	// The widths, the extractors of numeric fields and the precision of floats must not be applied
+	entry := &rawStruct{Name: "a long\tname\n", Duration: 1500000000, Ratio: 0.125}
+	assert.Equal(t, "a long\\tname\\n\t1500000000\t0.125", formatter.FormatEntry(entry))
+	assert.Equal(t, "NAME\tDURATION\tRATIO", formatter.FormatHeader())
+	assert.Equal(t, "", formatter.FormatRowDivider())
+
+	formatter.RecalculateWidths(8, true)
+	assert.Equal(t, "a long\\tname\\n\t1500000000\t0.125", formatter.FormatEntry(entry))
+}
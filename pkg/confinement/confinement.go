@@ -0,0 +1,82 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package confinement restricts what the gadget agent can do once it's
+// started: a seccomp profile limits the syscalls it can use to the ones it was
+// seen using with the advise seccomp-profile gadget, and Landlock rules limit
+// the directories it can write to.
+//
+// The gadget pods are privileged, their container runtime doesn't apply any
+// seccomp profile to them, so the agent applies them to itself.
+package confinement
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// confinedEnv is set in the environment of the agent once it's confined
+const confinedEnv = "INSPEKTOR_GADGET_CONFINED"
+
+// WritablePaths are the directories the agent can create, write and remove
+// files in: its sockets, the pinned eBPF objects, tracefs to create kprobes on
+// old kernels, and the temporary files of the bcc based gadgets. /dev/null is
+// the only device it writes to, e.g. for the output of the processes it starts.
+var WritablePaths = []string{
+	"/run",
+	"/tmp",
+	"/dev/null",
+	"/sys/fs/bpf",
+	"/sys/kernel/debug",
+	"/sys/kernel/tracing",
+}
+
+// Apply confines the process and re-executes it, the seccomp filters and the
+// Landlock rules apply to a thread and the processes it starts only. It only
+// returns in the confined process, or if it fails.
+func Apply() error {
+	if os.Getenv(confinedEnv) != "" {
+		log.Infof("Running confined, writable paths: %v", WritablePaths)
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("getting executable: %w", err)
+	}
+
+	// Confine the thread running execve()
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := restrictPaths(WritablePaths); err != nil {
+		if err != errLandlockUnsupported {
+			return fmt.Errorf("applying Landlock rules: %w", err)
+		}
+		log.Warnf("Landlock not supported by the kernel, the paths the agent can write to are not restricted")
+	}
+	if err := loadSeccompProfile(); err != nil {
+		return fmt.Errorf("loading seccomp profile: %w", err)
+	}
+
+	env := append(os.Environ(), confinedEnv+"=1")
+	if err := syscall.Exec(exe, os.Args, env); err != nil {
+		return fmt.Errorf("re-executing %q: %w", exe, err)
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package confinement
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/cgroups"
+)
+
+func TestSeccompProfile(t *testing.T) {
+	profile, err := parseSeccompProfile(seccompProfile)
+	require.NoError(t, err)
+	require.Equal(t, specs.ActErrno, profile.DefaultAction)
+
+	allowed := map[string]bool{}
+	for _, syscall := range profile.Syscalls {
+		_, err := seccompAction(syscall.Action)
+		require.NoError(t, err)
+		for _, name := range syscall.Names {
+			allowed[name] = syscall.Action == specs.ActAllow
+		}
+	}
+	for _, name := range []string{"bpf", "perf_event_open", "setns", "fanotify_init", "execve", "name_to_handle_at"} {
+		require.True(t, allowed[name], name)
+	}
+	for _, name := range []string{"init_module", "kexec_load", "mount", "ptrace", "reboot"} {
+		require.False(t, allowed[name], name)
+	}
+}
+
+// agentCodePaths are the code paths the agent runs once confined that don't
+// need privileges, they must work with the seccomp profile loaded
+var agentCodePaths = map[string]func() error{
+	// Cgroup enrichment of the containers, see
+	// containercollection.WithCgroupEnrichment()
+	"cgroup_id": func() error {
+		path := "/sys/fs/cgroup"
+		if _, pathV2, err := cgroups.GetCgroupPaths(os.Getpid()); err == nil && pathV2 != "" {
+			if path, err = cgroups.CgroupPathV2AddMountpoint(pathV2); err != nil {
+				return err
+			}
+		}
+		_, err := cgroups.GetCgroupID(path)
+		return err
+	},
+	"namespaces": func() error {
+		if _, err := containerutils.GetMntNs(os.Getpid()); err != nil {
+			return err
+		}
+		_, err := containerutils.GetNetNs(os.Getpid())
+		return err
+	},
+	"write_dev_null": func() error {
+		return os.WriteFile(os.DevNull, []byte("test"), 0)
+	},
+}
+
+func TestSeccompProfileCodePaths(t *testing.T) {
+	for name, codePath := range agentCodePaths {
+		codePath := codePath
+		t.Run(name, func(t *testing.T) {
+			// Check the code path works without the profile, e.g. the
+			// cgroup ID is only available with cgroup v2
+			if err := codePath(); err != nil {
+				t.Skipf("Code path not supported: %v", err)
+			}
+
+			errs := make(chan error, 2)
+			go func() {
+				// The thread is confined, it exits with the goroutine as
+				// it's never unlocked
+				runtime.LockOSThread()
+
+				if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+					errs <- err
+					return
+				}
+				if err := loadSeccompProfile(); err != nil {
+					errs <- err
+					return
+				}
+				errs <- nil
+				errs <- codePath()
+			}()
+
+			require.NoError(t, <-errs)
+			require.NoError(t, <-errs)
+		})
+	}
+}
+
+func TestRestrictPaths(t *testing.T) {
+	writable := t.TempDir()
+	readOnly := t.TempDir()
+	writableFile := filepath.Join(readOnly, "writable")
+	require.NoError(t, os.WriteFile(writableFile, nil, 0o600))
+
+	errs := make(chan error, 4)
+	go func() {
+		// The thread is confined, it exits with the goroutine as it's
+		// never unlocked
+		runtime.LockOSThread()
+
+		if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+			errs <- err
+			return
+		}
+		if err := restrictPaths([]string{writable, writableFile, "/does/not/exist"}); err != nil {
+			errs <- err
+			return
+		}
+		errs <- nil
+		errs <- os.WriteFile(filepath.Join(writable, "file"), nil, 0o600)
+		errs <- os.WriteFile(writableFile, []byte("test"), 0o600)
+		errs <- os.WriteFile(filepath.Join(readOnly, "file"), nil, 0o600)
+	}()
+
+	err := <-errs
+	if err == errLandlockUnsupported {
+		t.Skip("Landlock not supported by the kernel")
+	}
+	require.NoError(t, err)
+	require.NoError(t, <-errs)
+	require.NoError(t, <-errs)
+	require.ErrorIs(t, <-errs, os.ErrPermission)
+
+	// Other threads aren't confined
+	require.NoError(t, os.WriteFile(filepath.Join(readOnly, "file"), nil, 0o600))
+}
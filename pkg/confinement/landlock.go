@@ -0,0 +1,119 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package confinement
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"unsafe"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+var errLandlockUnsupported = errors.New("landlock not supported")
+
+const (
+	// Access rights modifying the file system, available since the first
+	// version of the Landlock ABI
+	landlockWriteAccessV1 = unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM
+
+	// Access rights that can be granted on a file that isn't a directory
+	landlockFileAccess = unix.LANDLOCK_ACCESS_FS_EXECUTE |
+		unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_READ_FILE |
+		unix.LANDLOCK_ACCESS_FS_TRUNCATE
+)
+
+// landlockWriteAccess returns the access rights modifying the file system
+// known by the given version of the Landlock ABI
+func landlockWriteAccess(abi int) uint64 {
+	access := uint64(landlockWriteAccessV1)
+	if abi >= 2 {
+		access |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		access |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	return access
+}
+
+// restrictPaths forbids the calling thread to modify the file system outside
+// of paths, which can be directories or single files like device nodes. Paths
+// that don't exist are ignored.
+func restrictPaths(paths []string) error {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		if errno == unix.ENOSYS || errno == unix.EOPNOTSUPP {
+			return errLandlockUnsupported
+		}
+		return fmt.Errorf("getting Landlock ABI version: %w", errno)
+	}
+	access := landlockWriteAccess(int(abi))
+
+	attr := unix.LandlockRulesetAttr{Access_fs: access}
+	rulesetFd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET,
+		uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("creating Landlock ruleset: %w", errno)
+	}
+	defer unix.Close(int(rulesetFd))
+
+	for _, path := range paths {
+		fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				log.Debugf("Landlock: ignoring %q: %v", path, err)
+				continue
+			}
+			return fmt.Errorf("opening %q: %w", path, err)
+		}
+		var stat unix.Stat_t
+		if err := unix.Fstat(fd, &stat); err != nil {
+			unix.Close(fd)
+			return fmt.Errorf("getting file status of %q: %w", path, err)
+		}
+		allowed := access
+		if stat.Mode&unix.S_IFMT != unix.S_IFDIR {
+			allowed &= landlockFileAccess
+		}
+		rule := unix.LandlockPathBeneathAttr{
+			Allowed_access: allowed,
+			Parent_fd:      int32(fd),
+		}
+		_, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, rulesetFd,
+			unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule)), 0, 0, 0)
+		unix.Close(fd)
+		if errno != 0 {
+			return fmt.Errorf("adding Landlock rule for %q: %w", path, errno)
+		}
+	}
+
+	_, _, errno = unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, rulesetFd, 0, 0)
+	if errno != 0 {
+		return fmt.Errorf("restricting thread: %w", errno)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package confinement
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	libseccomp "github.com/seccomp/libseccomp-golang"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// seccompProfile was generated by running the advise seccomp-profile gadget
+// on the gadget pods while running all the gadgets, and then completed with
+// the syscalls used by the hook modes and the bcc based gadgets. Syscalls not
+// in it fail with EPERM.
+//
+//go:embed seccomp-profile.json
+var seccompProfile []byte
+
+func parseSeccompProfile(profile []byte) (*specs.LinuxSeccomp, error) {
+	var seccomp specs.LinuxSeccomp
+	if err := json.Unmarshal(profile, &seccomp); err != nil {
+		return nil, err
+	}
+	return &seccomp, nil
+}
+
+func seccompAction(action specs.LinuxSeccompAction) (libseccomp.ScmpAction, error) {
+	switch action {
+	case specs.ActAllow:
+		return libseccomp.ActAllow, nil
+	case specs.ActErrno:
+		return libseccomp.ActErrno.SetReturnCode(int16(unix.EPERM)), nil
+	case specs.ActLog:
+		return libseccomp.ActLog, nil
+	default:
+		return libseccomp.ActInvalid, fmt.Errorf("unsupported action %q", action)
+	}
+}
+
+// loadSeccompProfile applies the seccomp profile to the calling thread
+func loadSeccompProfile() error {
+	profile, err := parseSeccompProfile(seccompProfile)
+	if err != nil {
+		return fmt.Errorf("parsing profile: %w", err)
+	}
+
+	defaultAction, err := seccompAction(profile.DefaultAction)
+	if err != nil {
+		return err
+	}
+	filter, err := libseccomp.NewFilter(defaultAction)
+	if err != nil {
+		return fmt.Errorf("creating filter: %w", err)
+	}
+	defer filter.Release()
+
+	// The agent has CAP_SYS_ADMIN, it can load the filter without
+	// no_new_privs
+	if err := filter.SetNoNewPrivsBit(false); err != nil {
+		return fmt.Errorf("unsetting no_new_privs: %w", err)
+	}
+
+	for _, syscall := range profile.Syscalls {
+		action, err := seccompAction(syscall.Action)
+		if err != nil {
+			return err
+		}
+		for _, name := range syscall.Names {
+			call, err := libseccomp.GetSyscallFromName(name)
+			if err != nil {
+				// Syscalls only existing on other architectures
+				log.Debugf("seccomp: ignoring %q: %v", name, err)
+				continue
+			}
+			if err := filter.AddRule(call, action); err != nil {
+				return fmt.Errorf("adding rule for %q: %w", name, err)
+			}
+		}
+	}
+
+	return filter.Load()
+}
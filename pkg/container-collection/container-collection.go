@@ -25,6 +25,7 @@ import (
 	"sync"
 	"time"
 
+	lru "github.com/hashicorp/golang-lru/v2"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
 
@@ -55,6 +56,13 @@ type ContainerCollection struct {
 	// the container is meant to be dropped.
 	containerEnrichers []func(container *Container) (ok bool)
 
+	// enrichers are the custom enrichers registered with WithEnrichers()
+	enrichers []Enricher
+
+	// restarts are the last runs of the containers, by restartKey()
+	restarts   *lru.Cache[string, *lastRun]
+	restartsMu sync.Mutex
+
 	// initialContainers is used during the initialization process to
 	// gather initial containers and then call the enrichers
 	initialContainers []*Container
@@ -84,6 +92,9 @@ func (cc *ContainerCollection) Initialize(options ...ContainerCollectionOption)
 		panic("Initialize already called")
 	}
 
+	// lru.New() only fails with a non-positive size
+	cc.restarts, _ = lru.New[string, *lastRun](restartsCacheSize)
+
 	// Call functional options. This might fetch initial containers.
 	for _, o := range options {
 		err := o(cc)
@@ -105,6 +116,7 @@ initialContainersLoop:
 			}
 		}
 
+		cc.setGeneration(container)
 		cc.containers.Store(container.ID, container)
 		if cc.pubsub != nil {
 			cc.pubsub.Publish(EventTypeAddContainer, container)
@@ -140,6 +152,8 @@ func (cc *ContainerCollection) RemoveContainer(id string) {
 	if cc.pubsub != nil {
 		cc.pubsub.Publish(EventTypeRemoveContainer, container)
 	}
+	cc.containerRemoved(container)
+	cc.containerStopped(container)
 
 	// Save the container in the cache as enrichers might need the container some time after it
 	// has been removed.
@@ -168,10 +182,15 @@ func (cc *ContainerCollection) AddContainer(container *Container) {
 		}
 	}
 
+	cc.setGeneration(container)
 	_, loaded := cc.containers.LoadOrStore(container.ID, container)
 	if loaded {
 		return
 	}
+	if container.Sandbox != "" {
+		log.Infof("container %q runs in a %s sandbox: the gadgets only see the processes of the sandbox on the host",
+			container.Name, container.Sandbox)
+	}
 	if cc.pubsub != nil {
 		cc.pubsub.Publish(EventTypeAddContainer, container)
 	}
@@ -213,6 +232,16 @@ func (cc *ContainerCollection) LookupContainerByMntns(mntnsid uint64) *Container
 	return lookupContainerByMntns(&cc.containers, mntnsid)
 }
 
+// lookupContainerByMntnsWithCache also looks for the containers removed
+// recently, whose last events can still be enriched, see WithTracerCollection()
+func (cc *ContainerCollection) lookupContainerByMntnsWithCache(mntnsid uint64) *Container {
+	container := lookupContainerByMntns(&cc.containers, mntnsid)
+	if container == nil && cc.cachedContainers != nil {
+		container = lookupContainerByMntns(cc.cachedContainers, mntnsid)
+	}
+	return container
+}
+
 // LookupContainersByNetns returns a slice of containers that run in a given
 // network namespace. Or an empty slice if there are no containers running in
 // that network namespace.
@@ -351,16 +380,20 @@ func (cc *ContainerCollection) EnrichNode(event *eventtypes.CommonData) {
 func (cc *ContainerCollection) EnrichByMntNs(event *eventtypes.CommonData, mountnsid uint64) {
 	event.Node = cc.nodeName
 
-	container := lookupContainerByMntns(&cc.containers, mountnsid)
-	if container == nil && cc.cachedContainers != nil {
-		container = lookupContainerByMntns(cc.cachedContainers, mountnsid)
-	}
-
+	container := cc.lookupContainerByMntnsWithCache(mountnsid)
 	if container != nil {
 		event.Container = container.Name
 		event.Pod = container.Podname
 		event.Namespace = container.Namespace
+		event.Sandbox = container.Sandbox
+		event.WorkloadKind = container.WorkloadKind
+		event.Workload = container.WorkloadName
+		event.ImageRegistry = container.ImageRegistry
+		event.ImageDigest = container.ImageDigest
+		event.RestartCount = container.RestartCount
+		event.Generation = container.Generation
 	}
+	cc.enrichEvent(event, container)
 }
 
 func (cc *ContainerCollection) EnrichByNetNs(event *eventtypes.CommonData, netnsid uint64) {
@@ -368,23 +401,36 @@ func (cc *ContainerCollection) EnrichByNetNs(event *eventtypes.CommonData, netns
 
 	containers := cc.LookupContainersByNetns(netnsid)
 	if len(containers) == 0 || containers[0].HostNetwork {
+		cc.enrichEvent(event, nil)
 		return
 	}
 	if len(containers) == 1 {
 		event.Container = containers[0].Name
 		event.Pod = containers[0].Podname
 		event.Namespace = containers[0].Namespace
+		event.Sandbox = containers[0].Sandbox
+		event.WorkloadKind = containers[0].WorkloadKind
+		event.Workload = containers[0].WorkloadName
+		event.ImageRegistry = containers[0].ImageRegistry
+		event.ImageDigest = containers[0].ImageDigest
+		event.RestartCount = containers[0].RestartCount
+		event.Generation = containers[0].Generation
+		cc.enrichEvent(event, containers[0])
 		return
 	}
 	if containers[0].Podname != "" && containers[0].Namespace != "" {
 		// Kubernetes containers within the same pod.
 		event.Pod = containers[0].Podname
 		event.Namespace = containers[0].Namespace
+		event.Sandbox = containers[0].Sandbox
+		event.WorkloadKind = containers[0].WorkloadKind
+		event.Workload = containers[0].WorkloadName
 	}
 	// else {
 	// 	TODO: Non-Kubernetes containers sharing the same network namespace.
 	// 	What should we do here?
 	// }
+	cc.enrichEvent(event, nil)
 }
 
 // Subscribe returns the list of existing containers and registers a callback
@@ -86,9 +86,10 @@ func TestWithTracerCollection(t *testing.T) {
 		for i := 0; i < nContainers; i++ {
 			ev := types.CommonData{}
 			expected := types.CommonData{
-				Namespace: containers[i].Namespace,
-				Pod:       containers[i].Podname,
-				Container: containers[i].Name,
+				Namespace:  containers[i].Namespace,
+				Pod:        containers[i].Podname,
+				Container:  containers[i].Name,
+				Generation: fmt.Sprintf("id%d-0", i),
 			}
 
 			cc.EnrichByMntNs(&ev, containers[i].Mntns)
@@ -119,3 +120,142 @@ func TestWithTracerCollection(t *testing.T) {
 
 	require.Equal(t, expected, ev, "events should be equal")
 }
+
+type mntnsEvent struct {
+	types.Event
+	types.WithMountNsID
+}
+
+func TestLateEnrichment(t *testing.T) {
+	t.Parallel()
+
+	cc := ContainerCollection{}
+	late := cc.NewLateEnrichment()
+
+	// Unknown mount namespace: held once
+	ev := &mntnsEvent{WithMountNsID: types.WithMountNsID{MountNsID: 4026532001}}
+	require.True(t, late.Defer(ev, 0))
+	require.False(t, late.Defer(ev, 0))
+
+	// Still unknown after being held, the next events aren't held anymore
+	other := &mntnsEvent{WithMountNsID: types.WithMountNsID{MountNsID: 4026532001}}
+	require.False(t, late.Defer(other, 0))
+
+	// Container added while its event was held
+	ev = &mntnsEvent{WithMountNsID: types.WithMountNsID{MountNsID: 4026532002}}
+	require.True(t, late.Defer(ev, 0))
+	cc.containers.Store("id", &Container{ID: "id", Name: "name", Mntns: 4026532002})
+	require.False(t, late.Defer(ev, 0))
+	cc.EnrichEventByMntNs(ev)
+	require.Equal(t, "name", ev.Container)
+
+	// Known container
+	require.False(t, late.Defer(&mntnsEvent{WithMountNsID: types.WithMountNsID{MountNsID: 4026532002}}, 0))
+
+	// Given back before its delay expired, e.g. it couldn't be held: the
+	// next events are still held
+	ev = &mntnsEvent{WithMountNsID: types.WithMountNsID{MountNsID: 4026532003}}
+	require.True(t, late.Defer(ev, time.Hour))
+	require.False(t, late.Defer(ev, time.Hour))
+	other = &mntnsEvent{WithMountNsID: types.WithMountNsID{MountNsID: 4026532003}}
+	require.True(t, late.Defer(other, time.Hour))
+}
+
+func TestEnrichers(t *testing.T) {
+	t.Parallel()
+
+	tenants := map[uint64]string{}
+	enricher := &EnricherFuncs{
+		EnrichContainerFunc: func(container *Container) bool {
+			if container.Name == "dropped" {
+				return false
+			}
+			tenants[container.Mntns] = "tenant-" + container.Name
+			return true
+		},
+		ContainerRemovedFunc: func(container *Container) {
+			delete(tenants, container.Mntns)
+		},
+		EnrichEventFunc: func(event any, container *Container) {
+			ev := event.(*types.CommonData)
+			if container == nil {
+				ev.Container = "unknown"
+				return
+			}
+			ev.Container = tenants[container.Mntns]
+		},
+	}
+
+	cc := ContainerCollection{}
+	require.NoError(t, cc.Initialize(WithEnrichers(enricher)))
+
+	cc.AddContainer(&Container{ID: "id1", Name: "name1", Mntns: 4026532001})
+	cc.AddContainer(&Container{ID: "id2", Name: "dropped", Mntns: 4026532002})
+	require.Equal(t, 1, cc.ContainerLen())
+	require.Equal(t, map[uint64]string{4026532001: "tenant-name1"}, tenants)
+
+	// The custom enrichers run after the container information is added
+	ev := types.CommonData{}
+	cc.EnrichByMntNs(&ev, 4026532001)
+	require.Equal(t, "tenant-name1", ev.Container)
+
+	ev = types.CommonData{}
+	cc.EnrichByMntNs(&ev, 4026532002)
+	require.Equal(t, "unknown", ev.Container)
+
+	cc.RemoveContainer("id1")
+	require.Empty(t, tenants)
+}
+
+func TestRestarts(t *testing.T) {
+	cc := ContainerCollection{}
+	require.NoError(t, cc.Initialize())
+
+	generation := func(id string) (uint32, string) {
+		c := cc.GetContainer(id)
+		require.NotNil(t, c)
+		return c.RestartCount, c.Generation
+	}
+
+	// Kubernetes: the restarts create new containers in the same pod
+	cc.AddContainer(&Container{ID: "0123456789abcdef", Namespace: "ns", Podname: "pod", Name: "name", Mntns: 4026532001})
+	count, gen := generation("0123456789abcdef")
+	require.Equal(t, uint32(0), count)
+	require.Equal(t, "0123456789ab-0", gen)
+
+	// Adding the same run again isn't a restart
+	cc.AddContainer(&Container{ID: "0123456789abcdef", Namespace: "ns", Podname: "pod", Name: "name", Mntns: 4026532001})
+	count, _ = generation("0123456789abcdef")
+	require.Equal(t, uint32(0), count)
+
+	cc.RemoveContainer("0123456789abcdef")
+	cc.AddContainer(&Container{ID: "fedcba9876543210", Namespace: "ns", Podname: "pod", Name: "name", Mntns: 4026532002})
+	count, gen = generation("fedcba9876543210")
+	require.Equal(t, uint32(1), count)
+	require.Equal(t, "fedcba987654-1", gen)
+
+	ev := types.CommonData{}
+	cc.EnrichByMntNs(&ev, 4026532002)
+	require.Equal(t, uint32(1), ev.RestartCount)
+	require.Equal(t, "fedcba987654-1", ev.Generation)
+
+	// The restart count given by the runtime is kept when it's higher
+	cc.RemoveContainer("fedcba9876543210")
+	cc.AddContainer(&Container{ID: "id3", Namespace: "ns", Podname: "pod", Name: "name", RestartCount: 5})
+	count, gen = generation("id3")
+	require.Equal(t, uint32(5), count)
+	require.Equal(t, "id3-5", gen)
+
+	// Docker: the restarts keep the ID
+	cc.AddContainer(&Container{ID: "docker", Name: "name"})
+	cc.RemoveContainer("docker")
+	cc.AddContainer(&Container{ID: "docker", Name: "name"})
+	count, gen = generation("docker")
+	require.Equal(t, uint32(1), count)
+	require.Equal(t, "docker-1", gen)
+
+	// Another container with the same name isn't a restart
+	cc.AddContainer(&Container{ID: "other", Name: "name"})
+	count, _ = generation("other")
+	require.Equal(t, uint32(0), count)
+}
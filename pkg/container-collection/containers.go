@@ -24,6 +24,7 @@ import (
 	"k8s.io/client-go/rest"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
 )
 
 // Container represents a container with its metadata.
@@ -31,16 +32,52 @@ type Container struct {
 	// Container Runtime
 	Runtime string `json:"runtime,omitempty" column:"runtime,minWidth:5,maxWidth:10" columnTags:"runtime"`
 
+	// RuntimeNamespace is the namespace of the runtime the container belongs
+	// to, like the containerd namespace of the containers not created by
+	// Kubernetes
+	RuntimeNamespace string `json:"runtimeNamespace,omitempty" column:"runtimenamespace,width:16,hide" columnTags:"runtime"`
+
+	// Sandbox is the kind of sandbox the container runs in, "kata" or
+	// "gvisor", empty when it runs on the kernel of the host
+	Sandbox string `json:"sandbox,omitempty" column:"sandbox,width:8,hide" columnTags:"runtime"`
+
 	// ID is the container id, typically a 64 hexadecimal string
 	ID string `json:"id,omitempty" column:"id,width:13,maxWidth:64" columnTags:"runtime"`
 
+	// Image is the reference of the image the container was created from
+	Image string `json:"image,omitempty" column:"image,width:30,hide" columnTags:"runtime"`
+
+	// ImageDigest is the digest of the manifest of the image as resolved
+	// from the registry, e.g. "sha256:...", empty when it's unknown like for
+	// images built locally
+	ImageDigest string `json:"imageDigest,omitempty" column:"imagedigest,width:20,hide" columnTags:"runtime"`
+
+	// ImageRegistry is the registry the image comes from, "docker.io" for
+	// Docker Hub
+	ImageRegistry string `json:"imageRegistry,omitempty" column:"registry,width:20,hide" columnTags:"runtime"`
+
 	// Pid is the process id of the container
 	Pid uint32 `json:"pid,omitempty" column:"pid,template:pid,hide"`
 
+	// RestartCount is the number of times the container was restarted, as
+	// told by the runtime or by Kubernetes, or as seen by the collection
+	RestartCount uint32 `json:"restartCount,omitempty" column:"restarts,width:8,hide"`
+
+	// Generation identifies the run of the container: the short ID followed
+	// by the restart count, as Docker restarts the containers with the same
+	// ID. The events of the different runs of a container have the same pod
+	// and name but different generations.
+	Generation string `json:"generation,omitempty" column:"generation,width:16,hide"`
+
 	// Container's configuration is the config.json from the OCI runtime
 	// spec
 	OciConfig *ocispec.Spec `json:"ociConfig,omitempty"`
 
+	// RuntimeAnnotations are the annotations of the runtime spec of the
+	// container when the OCI config isn't known, e.g. the
+	// io.kubernetes.cri-o.* ones given by CRI-O
+	RuntimeAnnotations map[string]string `json:"runtimeAnnotations,omitempty"`
+
 	// Bundle is the directory containing the config.json from the OCI
 	// runtime spec
 	// See https://github.com/opencontainers/runtime-spec/blob/main/bundle.md
@@ -57,6 +94,10 @@ type Container struct {
 	CgroupV1 string `json:"cgroupV1,omitempty"`
 	CgroupV2 string `json:"cgroupV2,omitempty"`
 
+	// UidMap maps the UIDs of the user namespace of the container to the
+	// ones of the host, nil when it runs in the user namespace of the host
+	UidMap containerutils.IDMap `json:"uidMap,omitempty"`
+
 	// Kubernetes metadata
 	Namespace string            `json:"namespace,omitempty"`
 	Podname   string            `json:"podname,omitempty"`
@@ -64,6 +105,16 @@ type Container struct {
 	Labels    map[string]string `json:"labels,omitempty"`
 	PodUID    string            `json:"podUID,omitempty"`
 
+	// NamespaceLabels are the labels of the Kubernetes namespace of the pod
+	NamespaceLabels map[string]string `json:"namespaceLabels,omitempty"`
+
+	// Workload is the resource managing the pod, found by following the
+	// owner references of the pod up to the highest one with an expected
+	// kind, e.g. a Deployment rather than its ReplicaSet. It's the pod itself
+	// when it isn't managed by any.
+	WorkloadKind string `json:"workloadKind,omitempty"`
+	WorkloadName string `json:"workloadName,omitempty"`
+
 	ownerReference *metav1.OwnerReference
 
 	// We keep an open file descriptor of the containers mount namespace to be sure the kernel
@@ -75,10 +126,15 @@ type Container struct {
 }
 
 type ContainerSelector struct {
-	Namespace string
-	Podname   string
-	Labels    map[string]string
-	Name      string
+	Namespace       string
+	Podname         string
+	Labels          map[string]string
+	NamespaceLabels map[string]string
+	Name            string
+
+	// RuntimeAnnotations select the containers by the annotations of their
+	// runtime spec
+	RuntimeAnnotations map[string]string
 }
 
 // GetOwnerReference returns the owner reference information of the
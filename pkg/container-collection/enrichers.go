@@ -0,0 +1,86 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containercollection
+
+// Enricher allows the applications embedding the container collection to add
+// their own metadata to the containers and to the events, e.g. the tenant a
+// cgroup belongs to. Enrichers are registered with WithEnrichers().
+type Enricher interface {
+	// EnrichContainer is called when a container is added, after the
+	// enrichers of the container collection and before the subscribers are
+	// notified. Returning false drops the container.
+	EnrichContainer(container *Container) (ok bool)
+
+	// ContainerRemoved is called when a container is removed, after the
+	// subscribers have been notified.
+	ContainerRemoved(container *Container)
+
+	// EnrichEvent is called for every event enriched by the container
+	// collection, after the container information has been added. container
+	// is nil when the event doesn't come from a single known container.
+	EnrichEvent(event any, container *Container)
+}
+
+// EnricherFuncs implements Enricher with the functions given, the ones left
+// nil are skipped.
+type EnricherFuncs struct {
+	EnrichContainerFunc  func(container *Container) bool
+	ContainerRemovedFunc func(container *Container)
+	EnrichEventFunc      func(event any, container *Container)
+}
+
+func (e *EnricherFuncs) EnrichContainer(container *Container) bool {
+	if e.EnrichContainerFunc == nil {
+		return true
+	}
+	return e.EnrichContainerFunc(container)
+}
+
+func (e *EnricherFuncs) ContainerRemoved(container *Container) {
+	if e.ContainerRemovedFunc != nil {
+		e.ContainerRemovedFunc(container)
+	}
+}
+
+func (e *EnricherFuncs) EnrichEvent(event any, container *Container) {
+	if e.EnrichEventFunc != nil {
+		e.EnrichEventFunc(event, container)
+	}
+}
+
+// WithEnrichers registers custom enrichers. They are called in the order
+// given, with the containers already enriched by the options given before
+// this one.
+func WithEnrichers(enrichers ...Enricher) ContainerCollectionOption {
+	return func(cc *ContainerCollection) error {
+		for _, enricher := range enrichers {
+			cc.containerEnrichers = append(cc.containerEnrichers, enricher.EnrichContainer)
+			cc.enrichers = append(cc.enrichers, enricher)
+		}
+		return nil
+	}
+}
+
+func (cc *ContainerCollection) containerRemoved(container *Container) {
+	for _, enricher := range cc.enrichers {
+		enricher.ContainerRemoved(container)
+	}
+}
+
+func (cc *ContainerCollection) enrichEvent(event any, container *Container) {
+	for _, enricher := range cc.enrichers {
+		enricher.EnrichEvent(event, container)
+	}
+}
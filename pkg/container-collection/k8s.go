@@ -140,6 +140,21 @@ func (k *K8sClient) PodToContainers(pod *v1.Pod) []Container {
 			Name:      s.Name,
 			Labels:    labels,
 			Pid:       uint32(pid),
+			Sandbox:   containerData.Sandbox,
+			Image:     s.Image,
+
+			RestartCount: uint32(s.RestartCount),
+
+			RuntimeAnnotations: containerData.Annotations,
+			// The image ID of the status is the reference pinned to the
+			// digest the image was pulled with
+			ImageDigest:   runtimeclient.DigestFromImageRef(s.ImageID),
+			ImageRegistry: runtimeclient.ImageRegistry(s.Image),
+		}
+		// Runtimes not telling the sandbox: use the RuntimeClass, usually
+		// named after the runtime handler
+		if containerDef.Sandbox == "" && pod.Spec.RuntimeClassName != nil {
+			containerDef.Sandbox = runtimeclient.SandboxFromRuntime(*pod.Spec.RuntimeClassName)
 		}
 		containers = append(containers, containerDef)
 	}
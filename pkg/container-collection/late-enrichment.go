@@ -0,0 +1,96 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containercollection
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+)
+
+// maxUnresolvedMntns is the number of mount namespaces remembered as not
+// being the ones of containers
+const maxUnresolvedMntns = 1024
+
+// LateEnrichment tells which events to hold until the container they come
+// from is added to the collection. Containers living for a few milliseconds
+// can emit all their events before the runtime hooks and the enrichers are
+// done with them, and the events would miss the container information.
+type LateEnrichment struct {
+	cc        *ContainerCollection
+	hostMntns uint64
+
+	mu sync.Mutex
+	// deferred are the events being held, with the time their delay expires
+	deferred map[any]time.Time
+	// unresolved are the mount namespaces whose events were held without
+	// their container showing up, e.g. of services of the host with their
+	// own mount namespace. Their events aren't held anymore.
+	unresolved map[uint64]struct{}
+}
+
+func (cc *ContainerCollection) NewLateEnrichment() *LateEnrichment {
+	hostMntns, err := containerutils.GetMntNs(1)
+	if err != nil {
+		log.Warnf("late enrichment: getting host mount namespace: %s", err)
+	}
+	return &LateEnrichment{
+		cc:         cc,
+		hostMntns:  hostMntns,
+		deferred:   make(map[any]time.Time),
+		unresolved: make(map[uint64]struct{}),
+	}
+}
+
+// Defer tells whether the event has to be held for delay and enriched again
+// later: it comes from a mount namespace which isn't the one of the host nor of
+// a known container. It's false when the event was already held once.
+func (l *LateEnrichment) Defer(event operators.ContainerInfoFromMountNSID, delay time.Duration) bool {
+	mntns := event.GetMountNSID()
+	if mntns == 0 || mntns == l.hostMntns {
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if release, held := l.deferred[event]; held {
+		delete(l.deferred, event)
+		// The event is given back before its delay expired when it couldn't
+		// be held, e.g. the queue of the parser is full, or when the gadget
+		// stops: the container may still show up
+		expired := !time.Now().Before(release)
+		if expired && l.cc.lookupContainerByMntnsWithCache(mntns) == nil {
+			if len(l.unresolved) >= maxUnresolvedMntns {
+				l.unresolved = make(map[uint64]struct{})
+			}
+			l.unresolved[mntns] = struct{}{}
+		}
+		return false
+	}
+	if _, ok := l.unresolved[mntns]; ok {
+		return false
+	}
+	if l.cc.lookupContainerByMntnsWithCache(mntns) != nil {
+		return false
+	}
+
+	l.deferred[event] = time.Now().Add(delay)
+	return true
+}
@@ -37,6 +37,45 @@ func ContainerSelectorMatches(s *ContainerSelector, c *Container) bool {
 			return false
 		}
 	}
+	for sk, sv := range s.NamespaceLabels {
+		if cv, ok := c.NamespaceLabels[sk]; !ok || cv != sv {
+			return false
+		}
+	}
+	if len(s.RuntimeAnnotations) > 0 {
+		annotations := runtimeAnnotations(c)
+		for sk, sv := range s.RuntimeAnnotations {
+			if cv, ok := annotations[sk]; !ok || cv != sv {
+				return false
+			}
+		}
+	}
 
 	return true
 }
+
+// SelectedLabels returns the given labels of the pod of the container as
+// comma-separated key=value pairs. The labels not set on the pod are taken
+// from its namespace.
+func SelectedLabels(c *Container, keys []string) string {
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value, ok := c.Labels[key]
+		if !ok {
+			value, ok = c.NamespaceLabels[key]
+		}
+		if ok {
+			pairs = append(pairs, key+"="+value)
+		}
+	}
+	return strings.Join(pairs, ",")
+}
+
+// runtimeAnnotations returns the annotations of the runtime spec of the
+// container, from its OCI config when it's known
+func runtimeAnnotations(c *Container) map[string]string {
+	if c.OciConfig != nil {
+		return c.OciConfig.Annotations
+	}
+	return c.RuntimeAnnotations
+}
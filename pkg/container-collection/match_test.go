@@ -19,6 +19,7 @@ import (
 	"reflect"
 	"testing"
 
+	ocispec "github.com/opencontainers/runtime-spec/specs-go"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -98,6 +99,41 @@ func TestSelector(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "Namespace labels match",
+			match:       true,
+			selector: &ContainerSelector{
+				NamespaceLabels: map[string]string{
+					"env": "prod",
+				},
+			},
+			container: &Container{
+				Namespace: "this-namespace",
+				Podname:   "this-pod",
+				Name:      "this-container",
+				NamespaceLabels: map[string]string{
+					"env":  "prod",
+					"team": "web",
+				},
+			},
+		},
+		{
+			description: "Namespace label doesn't match the pod label",
+			match:       false,
+			selector: &ContainerSelector{
+				NamespaceLabels: map[string]string{
+					"env": "prod",
+				},
+			},
+			container: &Container{
+				Namespace: "this-namespace",
+				Podname:   "this-pod",
+				Name:      "this-container",
+				Labels: map[string]string{
+					"env": "prod",
+				},
+			},
+		},
 		{
 			description: "Several namespaces without match",
 			match:       false,
@@ -124,6 +160,67 @@ func TestSelector(t *testing.T) {
 				Name:      "this-container",
 			},
 		},
+		{
+			description: "Runtime annotations match",
+			match:       true,
+			selector: &ContainerSelector{
+				RuntimeAnnotations: map[string]string{
+					"io.kubernetes.cri-o.TTY": "true",
+				},
+			},
+			container: &Container{
+				Name: "this-container",
+				RuntimeAnnotations: map[string]string{
+					"io.kubernetes.cri-o.TTY":      "true",
+					"io.kubernetes.cri-o.Stdin":    "false",
+					"io.kubernetes.container.hash": "4b2b8c1f",
+				},
+			},
+		},
+		{
+			description: "Runtime annotations from the OCI config",
+			match:       true,
+			selector: &ContainerSelector{
+				RuntimeAnnotations: map[string]string{
+					"io.kubernetes.cri-o.TTY": "true",
+				},
+			},
+			container: &Container{
+				Name: "this-container",
+				OciConfig: &ocispec.Spec{
+					Annotations: map[string]string{
+						"io.kubernetes.cri-o.TTY": "true",
+					},
+				},
+			},
+		},
+		{
+			description: "Runtime annotation doesn't match",
+			match:       false,
+			selector: &ContainerSelector{
+				RuntimeAnnotations: map[string]string{
+					"io.kubernetes.cri-o.TTY": "true",
+				},
+			},
+			container: &Container{
+				Name: "this-container",
+				RuntimeAnnotations: map[string]string{
+					"io.kubernetes.cri-o.TTY": "false",
+				},
+			},
+		},
+		{
+			description: "Runtime annotations unknown",
+			match:       false,
+			selector: &ContainerSelector{
+				RuntimeAnnotations: map[string]string{
+					"io.kubernetes.cri-o.TTY": "true",
+				},
+			},
+			container: &Container{
+				Name: "this-container",
+			},
+		},
 	}
 
 	for i, entry := range table {
@@ -135,6 +232,24 @@ func TestSelector(t *testing.T) {
 	}
 }
 
+func TestSelectedLabels(t *testing.T) {
+	container := &Container{
+		Labels: map[string]string{
+			"app": "nginx",
+			"env": "dev",
+		},
+		NamespaceLabels: map[string]string{
+			"env":  "prod",
+			"team": "web",
+		},
+	}
+
+	labels := SelectedLabels(container, []string{"app", "env", "team", "unknown"})
+	if expected := "app=nginx,env=dev,team=web"; labels != expected {
+		t.Fatalf("Error while selecting labels: got %q, expected %q", labels, expected)
+	}
+}
+
 func TestContainerResolver(t *testing.T) {
 	opts := []ContainerCollectionOption{}
 
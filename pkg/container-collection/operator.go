@@ -21,10 +21,16 @@ import (
 func (cc *ContainerCollection) EnrichEventByMntNs(event operators.ContainerInfoFromMountNSID) {
 	event.SetNode(cc.nodeName)
 
-	container := cc.LookupContainerByMntns(event.GetMountNSID())
+	container := cc.lookupContainerByMntnsWithCache(event.GetMountNSID())
+	setContainerUid(event, container)
 	if container != nil {
 		event.SetContainerInfo(container.Podname, container.Namespace, container.Name)
+		setSandbox(event, container.Sandbox)
+		setWorkload(event, container)
+		setImageInfo(event, container)
+		setGeneration(event, container)
 	}
+	cc.enrichEvent(event, container)
 }
 
 func (cc *ContainerCollection) EnrichEventByNetNs(event operators.ContainerInfoFromNetNSID) {
@@ -32,20 +38,103 @@ func (cc *ContainerCollection) EnrichEventByNetNs(event operators.ContainerInfoF
 
 	containers := cc.LookupContainersByNetns(event.GetNetNSID())
 	if len(containers) == 0 || containers[0].HostNetwork {
+		cc.enrichEvent(event, nil)
 		return
 	}
 	if len(containers) == 1 {
 		event.SetContainerInfo(containers[0].Podname, containers[0].Namespace, containers[0].Name)
+		setSandbox(event, containers[0].Sandbox)
+		setWorkload(event, containers[0])
+		setImageInfo(event, containers[0])
+		setGeneration(event, containers[0])
+		cc.enrichEvent(event, containers[0])
 		return
 	}
 	if containers[0].Podname != "" && containers[0].Namespace != "" {
 		// Kubernetes containers within the same pod.
 		event.SetContainerInfo(containers[0].Podname, containers[0].Namespace, "")
+		setSandbox(event, containers[0].Sandbox)
+		setWorkload(event, containers[0])
 	}
 	// else {
 	// 	TODO: Non-Kubernetes containers sharing the same network namespace.
 	// 	What should we do here?
 	// }
+	cc.enrichEvent(event, nil)
+}
+
+// EnrichContainerInfo adds the container information to the events that can
+// tell the mount or the network namespace they come from, and the given labels
+// of the pod to the ones that can tell them, see the KubeManager operator
+func (cc *ContainerCollection) EnrichContainerInfo(ev any, labels []string) {
+	if event, ok := ev.(operators.ContainerInfoFromMountNSID); ok {
+		cc.EnrichEventByMntNs(event)
+	}
+	if event, ok := ev.(operators.ContainerInfoFromNetNSID); ok {
+		cc.EnrichEventByNetNs(event)
+	}
+
+	setter, ok := ev.(operators.LabelsSetter)
+	if len(labels) == 0 || !ok {
+		return
+	}
+	var container *Container
+	if event, ok := ev.(operators.ContainerInfoFromMountNSID); ok {
+		container = cc.LookupContainerByMntns(event.GetMountNSID())
+	}
+	if event, ok := ev.(operators.ContainerInfoFromNetNSID); ok && container == nil {
+		// The containers sharing a network namespace are in the same pod
+		if containers := cc.LookupContainersByNetns(event.GetNetNSID()); len(containers) > 0 && !containers[0].HostNetwork {
+			container = containers[0]
+		}
+	}
+	if container != nil {
+		setter.SetLabels(SelectedLabels(container, labels))
+	}
+}
+
+// setSandbox marks the events of sandboxed containers, when the event can tell
+// it
+func setSandbox(event any, sandbox string) {
+	if setter, ok := event.(operators.SandboxSetter); ok && sandbox != "" {
+		setter.SetSandbox(sandbox)
+	}
+}
+
+// setWorkload adds the workload managing the pod of the container, when the
+// event can tell it
+func setWorkload(event any, container *Container) {
+	if setter, ok := event.(operators.WorkloadSetter); ok && container.WorkloadName != "" {
+		setter.SetWorkload(container.WorkloadKind, container.WorkloadName)
+	}
+}
+
+// setImageInfo adds the registry and the digest of the image of the container,
+// when the event can tell them
+func setImageInfo(event any, container *Container) {
+	if setter, ok := event.(operators.ImageInfoSetter); ok && container.ImageRegistry != "" {
+		setter.SetImageInfo(container.ImageRegistry, container.ImageDigest)
+	}
+}
 
-	return
+// setGeneration adds the restart count and the generation of the container,
+// when the event can tell them
+func setGeneration(event any, container *Container) {
+	if setter, ok := event.(operators.GenerationSetter); ok && container.Generation != "" {
+		setter.SetGeneration(container.RestartCount, container.Generation)
+	}
+}
+
+// setContainerUid gives the UID of the event as seen in the container, the
+// same as the one of the host unless the container runs in a user namespace
+func setContainerUid(event any, container *Container) {
+	setter, ok := event.(operators.ContainerUidSetter)
+	if !ok {
+		return
+	}
+	if container == nil {
+		setter.SetContainerUid(setter.GetUid())
+		return
+	}
+	setter.SetContainerUid(container.UidMap.ToContainer(setter.GetUid()))
 }
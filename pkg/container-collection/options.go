@@ -20,12 +20,14 @@ import (
 	"fmt"
 	"math"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/sys/unix"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -42,10 +44,19 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
 )
 
+// restartCountAnnotation is the restart count given by the kubelet to the
+// runtime when creating a container
+const restartCountAnnotation = "io.kubernetes.container.restartCount"
+
 func enrichContainerWithContainerData(containerData *runtimeclient.ContainerData, container *Container) {
 	// Runtime
 	container.ID = containerData.ID
 	container.Runtime = containerData.Runtime
+	container.RuntimeNamespace = containerData.RuntimeNamespace
+	container.RestartCount = containerData.RestartCount
+	container.Image = containerData.Image
+	container.ImageDigest = containerData.ImageDigest
+	container.ImageRegistry = runtimeclient.ImageRegistry(containerData.Image)
 
 	// Kubernetes
 	container.Namespace = containerData.PodNamespace
@@ -82,6 +93,14 @@ func containerRuntimeEnricher(
 		return true
 	}
 
+	// Listing the containers doesn't always give the digest of the image,
+	// e.g. containerd only gives the ID of the local image there.
+	if containerData.ImageDigest == "" {
+		if details, err := runtimeClient.GetContainerDetails(container.ID); err == nil {
+			containerData.ImageDigest = details.ImageDigest
+		}
+	}
+
 	enrichContainerWithContainerData(containerData, container)
 
 	return true
@@ -129,21 +148,13 @@ func WithContainerRuntimeEnrichment(runtime *containerutils.RuntimeConfig) Conta
 			return err
 		}
 
-		switch runtime.Name {
-		case runtimeclient.PodmanName:
-			// Podman only supports runtime enrichment for initial containers otherwise it will deadlock.
-			// As a consequence, we need to ensure that new podman containers will be enriched with all
-			// the information via other enrichers e.g. see RuncNotifier.futureContainers implementation
-			// to see how container name is enriched.
-		default:
-			// Add the enricher for future containers even if enriching the current
-			// containers fails. We do it because the runtime could be temporarily
-			// unavailable and once it is up, we will start receiving the
-			// notifications for its containers thus we will be able to enrich them.
-			cc.containerEnrichers = append(cc.containerEnrichers, func(container *Container) bool {
-				return containerRuntimeEnricher(runtime.Name, runtimeClient, container)
-			})
-		}
+		// Add the enricher for future containers even if enriching the current
+		// containers fails. We do it because the runtime could be temporarily
+		// unavailable and once it is up, we will start receiving the
+		// notifications for its containers thus we will be able to enrich them.
+		cc.containerEnrichers = append(cc.containerEnrichers, func(container *Container) bool {
+			return containerRuntimeEnricher(runtime.Name, runtimeClient, container)
+		})
 
 		cc.cleanUpFuncs = append(cc.cleanUpFuncs, func() {
 			if err := runtimeClient.Close(); err != nil {
@@ -181,6 +192,8 @@ func WithContainerRuntimeEnrichment(runtime *containerutils.RuntimeConfig) Conta
 
 			var c Container
 			c.Pid = uint32(pid)
+			c.Sandbox = containerDetails.Sandbox
+			c.RuntimeAnnotations = containerDetails.Annotations
 			enrichContainerWithContainerData(&containerDetails.ContainerData, &c)
 			cc.initialContainers = append(cc.initialContainers, &c)
 		}
@@ -525,6 +538,110 @@ func WithKubernetesEnrichment(nodeName string, kubeconfig *rest.Config) Containe
 	}
 }
 
+// WithNamespaceLabelsEnrichment adds the labels of the Kubernetes namespace of
+// the containers. The namespaces are cached with an informer. It must be used
+// after the options giving the namespace of the containers.
+//
+// ContainerCollection.Initialize(WithNamespaceLabelsEnrichment(nil))
+func WithNamespaceLabelsEnrichment(kubeconfig *rest.Config) ContainerCollectionOption {
+	return func(cc *ContainerCollection) error {
+		if kubeconfig == nil {
+			var err error
+			kubeconfig, err = rest.InClusterConfig()
+			if err != nil {
+				return fmt.Errorf("getting Kubernetes config: %w", err)
+			}
+		}
+		clientset, err := kubernetes.NewForConfig(kubeconfig)
+		if err != nil {
+			return fmt.Errorf("getting Kubernetes client: %w", err)
+		}
+
+		namespaceListWatcher := cache.NewListWatchFromClient(clientset.CoreV1().RESTClient(), "namespaces", "", fields.Everything())
+		store, controller := cache.NewInformer(namespaceListWatcher, &v1.Namespace{}, 0, cache.ResourceEventHandlerFuncs{})
+
+		stop := make(chan struct{})
+		go controller.Run(stop)
+		cc.cleanUpFuncs = append(cc.cleanUpFuncs, func() {
+			close(stop)
+		})
+
+		// Wait for the namespaces of the initial containers
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if !cache.WaitForCacheSync(ctx.Done(), controller.HasSynced) {
+			return errors.New("timeout waiting for namespaces to be synced")
+		}
+
+		cc.containerEnrichers = append(cc.containerEnrichers, func(container *Container) bool {
+			if container.Namespace == "" {
+				return true
+			}
+
+			obj, exists, err := store.GetByKey(container.Namespace)
+			if err != nil || !exists {
+				log.Debugf("namespace labels enricher: namespace %q not found", container.Namespace)
+				return true
+			}
+
+			namespace := obj.(*v1.Namespace)
+			container.NamespaceLabels = make(map[string]string, len(namespace.Labels))
+			for k, v := range namespace.Labels {
+				container.NamespaceLabels[k] = v
+			}
+			return true
+		})
+		return nil
+	}
+}
+
+// WithWorkloadEnrichment adds the workload managing the pod of the
+// containers, like a Deployment or a CronJob. It must be used after the
+// options giving the pod of the containers.
+//
+// ContainerCollection.Initialize(WithWorkloadEnrichment(nil))
+func WithWorkloadEnrichment(kubeconfig *rest.Config) ContainerCollectionOption {
+	return func(cc *ContainerCollection) error {
+		if kubeconfig == nil {
+			var err error
+			kubeconfig, err = rest.InClusterConfig()
+			if err != nil {
+				return fmt.Errorf("getting Kubernetes config: %w", err)
+			}
+		}
+		dynamicClient, err := dynamic.NewForConfig(kubeconfig)
+		if err != nil {
+			return fmt.Errorf("getting dynamic Kubernetes client: %w", err)
+		}
+
+		cc.containerEnrichers = append(cc.containerEnrichers, func(container *Container) bool {
+			workloadEnrichment(dynamicClient, container)
+			return true
+		})
+		return nil
+	}
+}
+
+func workloadEnrichment(dynamicClient dynamic.Interface, container *Container) {
+	if container.Podname == "" || container.WorkloadName != "" {
+		return
+	}
+
+	if err := ownerReferenceEnrichment(dynamicClient, container, nil); err != nil {
+		log.Warnf("workload enricher: cannot get owner of pod %s/%s: %s",
+			container.Namespace, container.Podname, err)
+		return
+	}
+
+	if container.ownerReference != nil {
+		container.WorkloadKind = container.ownerReference.Kind
+		container.WorkloadName = container.ownerReference.Name
+	} else {
+		container.WorkloadKind = "Pod"
+		container.WorkloadName = container.Podname
+	}
+}
+
 // WithRuncFanotify uses fanotify to detect when containers are created and add
 // them in the ContainerCollection.
 //
@@ -625,6 +742,13 @@ func WithLinuxNamespaceEnrichment() ContainerCollectionOption {
 			}
 			container.Netns = netns
 			container.HostNetwork = netns == netnsHost
+
+			// Kept to give the UIDs of the events as seen in the container
+			uidMap, err := containerutils.GetUidMap(pid)
+			if err != nil {
+				log.Warnf("namespace enricher: failed to get uid map of container %s: %s", container.ID, err)
+			}
+			container.UidMap = uidMap
 			return true
 		})
 		return nil
@@ -670,6 +794,14 @@ func WithOCIConfigEnrichment() ContainerCollectionOption {
 			if podUID := resolver.PodUID(container.OciConfig.Annotations); podUID != "" {
 				container.PodUID = podUID
 			}
+			if image := resolver.ContainerImage(container.OciConfig.Annotations); image != "" {
+				container.Image = image
+				container.ImageRegistry = runtimeclient.ImageRegistry(image)
+			}
+			// Only CRI-O passes the annotations of the kubelet
+			if restartCount, err := strconv.ParseUint(container.OciConfig.Annotations[restartCountAnnotation], 10, 32); err == nil {
+				container.RestartCount = uint32(restartCount)
+			}
 
 			return true
 		})
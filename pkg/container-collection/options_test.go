@@ -18,6 +18,9 @@ import (
 	"testing"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 )
 
 func TestGetExpectedOwnerReference(t *testing.T) {
@@ -268,3 +271,48 @@ func TestGetExpectedOwnerReference(t *testing.T) {
 		}
 	}
 }
+
+func newObject(apiVersion, kind, name string, owner *metav1.OwnerReference) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	if owner != nil {
+		obj.SetOwnerReferences([]metav1.OwnerReference{*owner})
+	}
+	return obj
+}
+
+func TestWorkloadEnrichment(t *testing.T) {
+	controller := true
+	dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newObject("v1", "Pod", "nginx-7c5ddbdf54-x2lbq", &metav1.OwnerReference{
+			APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "nginx-7c5ddbdf54", Controller: &controller,
+		}),
+		newObject("apps/v1", "ReplicaSet", "nginx-7c5ddbdf54", &metav1.OwnerReference{
+			APIVersion: "apps/v1", Kind: "Deployment", Name: "nginx", Controller: &controller,
+		}),
+		newObject("apps/v1", "Deployment", "nginx", nil),
+		newObject("v1", "Pod", "standalone", nil),
+	)
+
+	table := []struct {
+		podname      string
+		expectedKind string
+		expectedName string
+	}{
+		{"nginx-7c5ddbdf54-x2lbq", "Deployment", "nginx"},
+		{"standalone", "Pod", "standalone"},
+		// Pods that can't be found aren't enriched
+		{"unknown", "", ""},
+	}
+	for _, entry := range table {
+		container := &Container{Namespace: "default", Podname: entry.podname}
+		workloadEnrichment(dynamicClient, container)
+		if container.WorkloadKind != entry.expectedKind || container.WorkloadName != entry.expectedName {
+			t.Fatalf("Failed test for pod %q: result %s/%s expected %s/%s", entry.podname,
+				container.WorkloadKind, container.WorkloadName, entry.expectedKind, entry.expectedName)
+		}
+	}
+}
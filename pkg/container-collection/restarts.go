@@ -0,0 +1,73 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containercollection
+
+import (
+	"fmt"
+)
+
+// restartsCacheSize bounds the number of containers whose last run is
+// remembered, the containers of deleted pods are evicted eventually
+const restartsCacheSize = 4096
+
+// lastRun is the last run of a container seen by the collection
+type lastRun struct {
+	id           string
+	restartCount uint32
+	removed      bool
+}
+
+// restartKey identifies a container across its restarts: the pod and the name
+// for Kubernetes, whose restarts create new containers, and the ID otherwise,
+// as Docker restarts the containers with the same ID
+func restartKey(container *Container) string {
+	if container.Podname != "" {
+		return container.Namespace + "/" + container.Podname + "/" + container.Name
+	}
+	return container.ID
+}
+
+// setGeneration sets the restart count and the generation of a container
+// being added. The restart count given by the runtime or by Kubernetes is
+// kept unless the collection already saw more restarts of the container, e.g.
+// when the runtime doesn't tell it.
+func (cc *ContainerCollection) setGeneration(container *Container) {
+	cc.restartsMu.Lock()
+	defer cc.restartsMu.Unlock()
+
+	key := restartKey(container)
+	last, ok := cc.restarts.Get(key)
+	switch {
+	case ok && !last.removed && last.id == container.ID:
+		// The same run added again, e.g. by another source of containers
+		container.RestartCount = last.restartCount
+	case ok && last.restartCount+1 > container.RestartCount:
+		container.RestartCount = last.restartCount + 1
+	}
+	cc.restarts.Add(key, &lastRun{id: container.ID, restartCount: container.RestartCount})
+
+	container.Generation = fmt.Sprintf("%.12s-%d", container.ID, container.RestartCount)
+}
+
+// containerStopped marks the run of a container as done, the next container
+// with the same restart key is a restart
+func (cc *ContainerCollection) containerStopped(container *Container) {
+	cc.restartsMu.Lock()
+	defer cc.restartsMu.Unlock()
+
+	if last, ok := cc.restarts.Peek(restartKey(container)); ok && last.id == container.ID {
+		last.removed = true
+	}
+}
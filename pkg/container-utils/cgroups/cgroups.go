@@ -17,6 +17,7 @@ package cgroups
 import (
 	"bufio"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -93,3 +94,70 @@ func GetCgroupPaths(pid int) (string, string, error) {
 
 	return cgroupPathV1, cgroupPathV2, nil
 }
+
+// GetSystemdUnitCgroupPath returns the cgroup2 path of a systemd unit,
+// including the "/sys/fs/cgroup/{unified,}" prefix. As systemctl does, a unit
+// name without a type suffix is considered to be a service.
+func GetSystemdUnitCgroupPath(unit string) (string, error) {
+	root, err := CgroupPathV2AddMountpoint("/")
+	if err != nil {
+		return "", err
+	}
+	return findSystemdUnitCgroup(root, unit)
+}
+
+// systemdUnitSuffixes are the types of the systemd units that can have
+// processes in their cgroup
+var systemdUnitSuffixes = []string{".service", ".scope", ".socket", ".mount", ".swap"}
+
+// SystemdUnitFromCgroupPath returns the name of the systemd unit a process
+// whose cgroup is path runs in, or an empty string if the cgroup is not the
+// one of a unit. If systemd runs in a unit (e.g. a container, or the manager
+// of a user), the innermost unit is returned.
+func SystemdUnitFromCgroupPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i := len(parts) - 1; i >= 0; i-- {
+		for _, suffix := range systemdUnitSuffixes {
+			if strings.HasSuffix(parts[i], suffix) {
+				return parts[i]
+			}
+		}
+	}
+	return ""
+}
+
+func findSystemdUnitCgroup(root, unit string) (string, error) {
+	if unit == "" || strings.Contains(unit, "/") {
+		return "", fmt.Errorf("invalid systemd unit name %q", unit)
+	}
+	if !strings.Contains(unit, ".") {
+		unit += ".service"
+	}
+
+	// Units nested in other units (e.g. a systemd running inside a
+	// container) can have the same name, prefer the one closest to the root.
+	found := ""
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if !d.IsDir() || d.Name() != unit {
+			return nil
+		}
+		if found == "" || strings.Count(path, "/") < strings.Count(found, "/") {
+			found = path
+		}
+		return filepath.SkipDir
+	})
+	if err != nil {
+		return "", fmt.Errorf("looking up cgroup of systemd unit %q: %w", unit, err)
+	}
+	if found == "" {
+		return "", fmt.Errorf("cgroup of systemd unit %q not found in %q", unit, root)
+	}
+
+	return found, nil
+}
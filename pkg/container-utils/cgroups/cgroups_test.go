@@ -0,0 +1,87 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cgroups
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindSystemdUnitCgroup(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{
+		"system.slice/nginx.service",
+		"system.slice/docker-1234.scope/system.slice/nginx.service",
+		"system.slice/docker.socket",
+		"user.slice/user-1000.slice/user@1000.service/app.slice",
+	} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		unit     string
+		expected string
+	}{
+		{unit: "nginx.service", expected: "system.slice/nginx.service"},
+		{unit: "nginx", expected: "system.slice/nginx.service"},
+		{unit: "docker.socket", expected: "system.slice/docker.socket"},
+		{unit: "user@1000.service", expected: "user.slice/user-1000.slice/user@1000.service"},
+		{unit: "missing.service"},
+		{unit: "system.slice/nginx.service"},
+		{unit: ""},
+	}
+
+	for _, test := range tests {
+		path, err := findSystemdUnitCgroup(root, test.unit)
+		if test.expected == "" {
+			if err == nil {
+				t.Errorf("unit %q: expected error, got path %q", test.unit, path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unit %q: unexpected error: %s", test.unit, err)
+			continue
+		}
+		if expected := filepath.Join(root, test.expected); path != expected {
+			t.Errorf("unit %q: expected %q, got %q", test.unit, expected, path)
+		}
+	}
+}
+
+func TestSystemdUnitFromCgroupPath(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "/system.slice/kubelet.service", expected: "kubelet.service"},
+		{path: "/system.slice/containerd.service/kubepods-burstable-pod1234.slice:cri-containerd:5678", expected: "containerd.service"},
+		{path: "/system.slice/docker-1234.scope/system.slice/nginx.service", expected: "nginx.service"},
+		{path: "/user.slice/user-1000.slice/user@1000.service/app.slice/app-foo.scope", expected: "app-foo.scope"},
+		{path: "/user.slice/user-1000.slice/session-2.scope", expected: "session-2.scope"},
+		{path: "/kubepods.slice/kubepods-besteffort.slice", expected: ""},
+		{path: "/", expected: ""},
+		{path: "", expected: ""},
+	}
+
+	for _, test := range tests {
+		if unit := SystemdUnitFromCgroupPath(test.path); unit != test.expected {
+			t.Errorf("path %q: expected %q, got %q", test.path, test.expected, unit)
+		}
+	}
+}
@@ -15,24 +15,41 @@
 package containerd
 
 import (
+	"fmt"
 	"time"
 
+	log "github.com/sirupsen/logrus"
+
 	criclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/cri"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/rootless"
 	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
 )
 
 const (
 	DefaultTimeout = 2 * time.Second
+
+	// Rootless containerd listens in the mount namespace of RootlessKit only
+	rootlessStateDir   = "containerd-rootless"
+	rootlessSocketPath = "/run/containerd/containerd.sock"
 )
 
+// ContainerdClient gets the containers of Kubernetes through CRI, and the ones
+// of the other containerd namespaces selected from their bundle, as CRI only
+// gives the ones of its own namespace
 type ContainerdClient struct {
 	criclient.CRIClient
+
+	namespaces []string
 }
 
-func NewContainerdClient(socketPath string) (runtimeclient.ContainerRuntimeClient, error) {
+// NewContainerdClient creates a client for the containers of the CRI namespace
+// and of the other namespaces given, AllNamespaces for all of them
+func NewContainerdClient(socketPath string, namespaces []string) (runtimeclient.ContainerRuntimeClient, error) {
 	if socketPath == "" {
 		socketPath = runtimeclient.ContainerdDefaultSocketPath
 	}
+	socketPath = rootless.SocketPath(socketPath, runtimeclient.ContainerdDefaultSocketPath,
+		rootless.InRootlessKit(rootlessStateDir, rootlessSocketPath))
 
 	criClient, err := criclient.NewCRIClient(runtimeclient.ContainerdName, socketPath, DefaultTimeout)
 	if err != nil {
@@ -40,6 +57,75 @@ func NewContainerdClient(socketPath string) (runtimeclient.ContainerRuntimeClien
 	}
 
 	return &ContainerdClient{
-		CRIClient: criClient,
+		CRIClient:  criClient,
+		namespaces: namespaces,
 	}, nil
 }
+
+func (c *ContainerdClient) GetContainers() ([]*runtimeclient.ContainerData, error) {
+	containers, err := c.CRIClient.GetContainers()
+	if len(c.namespaces) == 0 {
+		return containers, err
+	}
+	if err != nil {
+		// containerd can run without its CRI plugin, e.g. the one of Docker
+		log.Debugf("containerd: listing the containers of CRI: %s", err)
+	}
+
+	namespaces, err := taskNamespaces(c.namespaces)
+	if err != nil {
+		return nil, fmt.Errorf("listing containerd namespaces: %w", err)
+	}
+	tasks, err := listTasks(namespaces)
+	if err != nil {
+		return nil, fmt.Errorf("listing containers of containerd namespaces %v: %w", namespaces, err)
+	}
+	return append(containers, tasks...), nil
+}
+
+// findTask returns the container of the namespaces selected outside of CRI,
+// or nil if it isn't one of them
+func (c *ContainerdClient) findTask(containerID string) *runtimeclient.ContainerDetailsData {
+	if len(c.namespaces) == 0 {
+		return nil
+	}
+	namespaces, err := taskNamespaces(c.namespaces)
+	if err != nil {
+		return nil
+	}
+	for _, ns := range namespaces {
+		if task, err := getTask(ns, containerID); err == nil {
+			return task
+		}
+	}
+	return nil
+}
+
+func (c *ContainerdClient) GetContainer(containerID string) (*runtimeclient.ContainerData, error) {
+	containerData, err := c.CRIClient.GetContainer(containerID)
+	if err == nil {
+		return containerData, nil
+	}
+	if task := c.findTask(containerID); task != nil {
+		return &task.ContainerData, nil
+	}
+	return nil, err
+}
+
+func (c *ContainerdClient) GetContainerDetails(containerID string) (*runtimeclient.ContainerDetailsData, error) {
+	containerDetailsData, err := c.CRIClient.GetContainerDetails(containerID)
+	if err != nil {
+		if task := c.findTask(containerID); task != nil {
+			return task, nil
+		}
+		return nil, err
+	}
+
+	// Rootless containerd can run in its own PID namespace
+	containerDetailsData.Pid, err = rootless.HostPid(c.SocketPath, containerDetailsData.Pid)
+	if err != nil {
+		return nil, fmt.Errorf("translating pid of container %s: %w", containerID, err)
+	}
+
+	return containerDetailsData, nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containerd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	ocispec "github.com/opencontainers/runtime-spec/specs-go"
+
+	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+const (
+	// CRINamespace is the containerd namespace of the containers created
+	// through CRI, i.e. by Kubernetes
+	CRINamespace = "k8s.io"
+
+	// AllNamespaces selects all the containerd namespaces but the one of
+	// Docker, whose containers are the ones of the docker runtime
+	AllNamespaces = "*"
+	mobyNamespace = "moby"
+
+	// nameAnnotation is the name given to the containers by nerdctl
+	nameAnnotation = "nerdctl/name"
+)
+
+// taskDir is where the runc shim of containerd keeps the bundle of the
+// containers, by namespace and container ID
+var taskDir = "/run/containerd/io.containerd.runtime.v2.task"
+
+// taskNamespaces returns the namespaces with containers among the ones
+// selected, without the one of CRI which is handled by the CRI client
+func taskNamespaces(selected []string) ([]string, error) {
+	var namespaces []string
+	for _, ns := range selected {
+		if ns == AllNamespaces {
+			entries, err := os.ReadDir(filepath.Join(host.HostRoot, taskDir))
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil, nil
+				}
+				return nil, err
+			}
+			var all []string
+			for _, entry := range entries {
+				if entry.IsDir() && entry.Name() != CRINamespace && entry.Name() != mobyNamespace {
+					all = append(all, entry.Name())
+				}
+			}
+			return all, nil
+		}
+		if ns != CRINamespace {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	return namespaces, nil
+}
+
+// getTask returns the container of the namespace from its bundle, which
+// exists as long as the container is running
+func getTask(namespace, id string) (*runtimeclient.ContainerDetailsData, error) {
+	bundle := filepath.Join(host.HostRoot, taskDir, namespace, id)
+
+	pidBytes, err := os.ReadFile(filepath.Join(bundle, "init.pid"))
+	if err != nil {
+		return nil, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(pidBytes)))
+	if err != nil {
+		return nil, fmt.Errorf("parsing pid of container %s: %w", id, err)
+	}
+
+	name := id
+	configBytes, err := os.ReadFile(filepath.Join(bundle, "config.json"))
+	if err == nil {
+		config := &ocispec.Spec{}
+		if err := json.Unmarshal(configBytes, config); err == nil && config.Annotations[nameAnnotation] != "" {
+			name = config.Annotations[nameAnnotation]
+		}
+	}
+
+	return &runtimeclient.ContainerDetailsData{
+		ContainerData: runtimeclient.ContainerData{
+			ID:               id,
+			Name:             name,
+			State:            runtimeclient.StateRunning,
+			Runtime:          runtimeclient.ContainerdName,
+			RuntimeNamespace: namespace,
+		},
+		Pid: pid,
+	}, nil
+}
+
+// listTasks returns the running containers of the namespaces
+func listTasks(namespaces []string) ([]*runtimeclient.ContainerData, error) {
+	var containers []*runtimeclient.ContainerData
+	for _, ns := range namespaces {
+		entries, err := os.ReadDir(filepath.Join(host.HostRoot, taskDir, ns))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			task, err := getTask(ns, entry.Name())
+			if err != nil {
+				// Not running
+				continue
+			}
+			containers = append(containers, &task.ContainerData)
+		}
+	}
+	return containers, nil
+}
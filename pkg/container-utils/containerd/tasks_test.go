@@ -0,0 +1,73 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containerd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
+)
+
+func addTask(t *testing.T, namespace, id, pid, config string) {
+	t.Helper()
+	bundle := filepath.Join(taskDir, namespace, id)
+	require.NoError(t, os.MkdirAll(bundle, 0o755))
+	if pid != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(bundle, "init.pid"), []byte(pid), 0o644))
+	}
+	if config != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(bundle, "config.json"), []byte(config), 0o644))
+	}
+}
+
+func TestTasks(t *testing.T) {
+	oldTaskDir := taskDir
+	taskDir = t.TempDir()
+	t.Cleanup(func() { taskDir = oldTaskDir })
+
+	addTask(t, "k8s.io", "cri", "40", "")
+	addTask(t, "moby", "docker", "41", "")
+	addTask(t, "default", "nerdctl", "42", `{"annotations": {"nerdctl/name": "web"}}`)
+	addTask(t, "buildkit", "worker", "43", `{}`)
+	// Created but not started yet
+	addTask(t, "buildkit", "created", "", `{}`)
+
+	namespaces, err := taskNamespaces([]string{"k8s.io", "default"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"default"}, namespaces)
+
+	namespaces, err = taskNamespaces([]string{AllNamespaces})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"default", "buildkit"}, namespaces)
+
+	containers, err := listTasks(namespaces)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []*runtimeclient.ContainerData{
+		{ID: "nerdctl", Name: "web", State: runtimeclient.StateRunning, Runtime: runtimeclient.ContainerdName, RuntimeNamespace: "default"},
+		{ID: "worker", Name: "worker", State: runtimeclient.StateRunning, Runtime: runtimeclient.ContainerdName, RuntimeNamespace: "buildkit"},
+	}, containers)
+
+	task, err := getTask("default", "nerdctl")
+	require.NoError(t, err)
+	require.Equal(t, 42, task.Pid)
+
+	c := &ContainerdClient{namespaces: []string{"buildkit"}}
+	require.NotNil(t, c.findTask("worker"))
+	require.Nil(t, c.findTask("nerdctl"))
+}
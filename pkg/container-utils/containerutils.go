@@ -44,6 +44,11 @@ var AvailableRuntimes = []string{
 type RuntimeConfig struct {
 	Name       string
 	SocketPath string
+
+	// Namespaces are the containerd namespaces whose containers are
+	// enriched besides the one of Kubernetes, containerd.AllNamespaces for
+	// all of them
+	Namespaces []string
 }
 
 func NewContainerRuntimeClient(runtime *RuntimeConfig) (runtimeclient.ContainerRuntimeClient, error) {
@@ -59,7 +64,7 @@ func NewContainerRuntimeClient(runtime *RuntimeConfig) (runtimeclient.ContainerR
 		if envsp := os.Getenv("INSPEKTOR_GADGET_CONTAINERD_SOCKETPATH"); envsp != "" && socketPath == "" {
 			socketPath = envsp
 		}
-		return containerd.NewContainerdClient(socketPath)
+		return containerd.NewContainerdClient(socketPath, runtime.Namespaces)
 	case runtimeclient.CrioName:
 		socketPath := runtime.SocketPath
 		if envsp := os.Getenv("INSPEKTOR_GADGET_CRIO_SOCKETPATH"); envsp != "" && socketPath == "" {
@@ -34,6 +34,10 @@ import (
 	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
 )
 
+// crioRuntimeHandlerAnnotation is the runtime handler CRI-O runs the container
+// with, from the RuntimeClass of the pod
+const crioRuntimeHandlerAnnotation = "io.kubernetes.cri-o.RuntimeHandler"
+
 // CRIClient implements the ContainerRuntimeClient interface using the CRI
 // plugin interface to communicate with the different container runtimes.
 type CRIClient struct {
@@ -197,10 +201,15 @@ func parseContainerDetailsData(runtimeName string, containerStatus *runtime.Cont
 	// Create container details structure to be filled.
 	containerDetailsData := &runtimeclient.ContainerDetailsData{
 		ContainerData: runtimeclient.ContainerData{
-			ID:      containerStatus.Id,
-			Name:    strings.TrimPrefix(containerStatus.GetMetadata().Name, "/"),
-			State:   containerStatusStateToRuntimeClientState(containerStatus.GetState()),
-			Runtime: runtimeName,
+			ID:           containerStatus.Id,
+			Name:         strings.TrimPrefix(containerStatus.GetMetadata().Name, "/"),
+			State:        containerStatusStateToRuntimeClientState(containerStatus.GetState()),
+			Runtime:      runtimeName,
+			RestartCount: containerStatus.GetMetadata().GetAttempt(),
+			Image:        containerStatus.GetImage().GetImage(),
+			// The image reference of the status is pinned to the digest the
+			// image was pulled with
+			ImageDigest: runtimeclient.DigestFromImageRef(containerStatus.GetImageRef()),
 		},
 	}
 
@@ -227,7 +236,8 @@ func parseExtraInfo(extraInfo map[string]string,
 ) error {
 	// Define the info content (only required fields).
 	type RuntimeSpecContent struct {
-		Mounts []struct {
+		Annotations map[string]string `json:"annotations,omitempty"`
+		Mounts      []struct {
 			Destination string `json:"destination"`
 			Source      string `json:"source,omitempty"`
 		} `json:"mounts,omitempty"`
@@ -237,6 +247,7 @@ func parseExtraInfo(extraInfo map[string]string,
 	}
 	type InfoContent struct {
 		Pid         int                `json:"pid"`
+		RuntimeType string             `json:"runtimeType"`
 		RuntimeSpec RuntimeSpecContent `json:"runtimeSpec"`
 	}
 
@@ -258,6 +269,10 @@ func parseExtraInfo(extraInfo map[string]string,
 		// Set the PID value.
 		pid = infoContent.Pid
 
+		// containerd gives the runtime of the container, e.g.
+		// io.containerd.kata.v2.
+		containerDetailsData.Sandbox = runtimeclient.SandboxFromRuntime(infoContent.RuntimeType)
+
 		// Set the runtime spec pointer, to be copied below.
 		runtimeSpec = &infoContent.RuntimeSpec
 
@@ -299,6 +314,11 @@ func parseExtraInfo(extraInfo map[string]string,
 		if runtimeSpec.Linux != nil {
 			containerDetailsData.CgroupsPath = runtimeSpec.Linux.CgroupsPath
 		}
+		containerDetailsData.Annotations = runtimeSpec.Annotations
+		// CRI-O gives the runtime handler of the container in an annotation
+		if handler, ok := runtimeSpec.Annotations[crioRuntimeHandlerAnnotation]; ok && containerDetailsData.Sandbox == "" {
+			containerDetailsData.Sandbox = runtimeclient.SandboxFromRuntime(handler)
+		}
 		if len(runtimeSpec.Mounts) > 0 {
 			containerDetailsData.Mounts = make([]runtimeclient.ContainerMountData, len(runtimeSpec.Mounts))
 			for i, specMount := range runtimeSpec.Mounts {
@@ -332,10 +352,14 @@ func containerStatusStateToRuntimeClientState(containerStatusState runtime.Conta
 
 func CRIContainerToContainerData(runtimeName string, container *runtime.Container) *runtimeclient.ContainerData {
 	containerData := &runtimeclient.ContainerData{
-		ID:      container.Id,
-		Name:    strings.TrimPrefix(container.GetMetadata().Name, "/"),
-		State:   containerStatusStateToRuntimeClientState(container.GetState()),
-		Runtime: runtimeName,
+		ID:           container.Id,
+		Name:         strings.TrimPrefix(container.GetMetadata().Name, "/"),
+		State:        containerStatusStateToRuntimeClientState(container.GetState()),
+		Runtime:      runtimeName,
+		RestartCount: container.GetMetadata().GetAttempt(),
+		Image:        container.GetImage().GetImage(),
+		// Some runtimes only give the ID of the local image here
+		ImageDigest: runtimeclient.DigestFromImageRef(container.GetImageRef()),
 	}
 
 	// Fill K8S information.
@@ -174,6 +174,37 @@ func TestParseExtraInfo(t *testing.T) {
 				},
 			},
 		},
+		// Sandboxed runtimes
+		{
+			description: "New format: containerd runtime type",
+			info: map[string]string{
+				"info": `{"pid":1234,"runtimeType":"io.containerd.kata.v2"}`,
+			},
+			expected: &runtimeclient.ContainerDetailsData{Pid: 1234, Sandbox: runtimeclient.SandboxKata},
+		},
+		{
+			description: "New format: CRI-O runtime handler",
+			info: map[string]string{
+				"info": `{
+					"pid": 1234,
+					"runtimeSpec": {
+						"annotations": { "io.kubernetes.cri-o.RuntimeHandler": "runsc" }
+					}
+				}`,
+			},
+			expected: &runtimeclient.ContainerDetailsData{
+				Pid:         1234,
+				Sandbox:     runtimeclient.SandboxGVisor,
+				Annotations: map[string]string{"io.kubernetes.cri-o.RuntimeHandler": "runsc"},
+			},
+		},
+		{
+			description: "New format: not sandboxed",
+			info: map[string]string{
+				"info": `{"pid":1234,"runtimeType":"io.containerd.runc.v2"}`,
+			},
+			expected: &runtimeclient.ContainerDetailsData{Pid: 1234},
+		},
 	}
 
 	// Iterate on all tests.
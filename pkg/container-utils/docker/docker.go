@@ -27,11 +27,15 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/cgroups"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/rootless"
 	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
 )
 
 const (
 	DefaultTimeout = 2 * time.Second
+
+	// Rootless Docker listens in the runtime directory of the user
+	rootlessSocketPath = "docker.sock"
 )
 
 // DockerClient implements the ContainerRuntimeClient interface but using the
@@ -48,6 +52,8 @@ func NewDockerClient(socketPath string) (runtimeclient.ContainerRuntimeClient, e
 	if socketPath == "" {
 		socketPath = runtimeclient.DockerDefaultSocketPath
 	}
+	socketPath = rootless.SocketPath(socketPath, runtimeclient.DockerDefaultSocketPath,
+		rootless.InRuntimeDir(rootlessSocketPath))
 
 	cli, err := client.NewClientWithOpts(
 		client.WithAPIVersionNegotiation(),
@@ -134,6 +140,32 @@ func (c *DockerClient) GetContainer(containerID string) (*runtimeclient.Containe
 	return DockerContainerToContainerData(&containers[0]), nil
 }
 
+// imageDigest returns the digest the image with the given ID was pulled with
+// from the repository of the given reference. It's empty for the images built
+// locally, which don't have any.
+func (c *DockerClient) imageDigest(imageID, ref string) string {
+	image, _, err := c.client.ImageInspectWithRaw(context.Background(), imageID)
+	if err != nil {
+		log.Debugf("DockerClient: inspecting image %q: %s", imageID, err)
+		return ""
+	}
+
+	repo, _, _ := strings.Cut(ref, "@")
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+	for _, repoDigest := range image.RepoDigests {
+		if strings.HasPrefix(repoDigest, repo+"@") {
+			return runtimeclient.DigestFromImageRef(repoDigest)
+		}
+	}
+	// Image pulled by ID or tagged after the pull: any repository will do
+	if len(image.RepoDigests) > 0 {
+		return runtimeclient.DigestFromImageRef(image.RepoDigests[0])
+	}
+	return ""
+}
+
 func (c *DockerClient) GetContainerDetails(containerID string) (*runtimeclient.ContainerDetailsData, error) {
 	containerID, err := runtimeclient.ParseContainerID(runtimeclient.DockerName, containerID)
 	if err != nil {
@@ -164,9 +196,11 @@ func (c *DockerClient) GetContainerDetails(containerID string) (*runtimeclient.C
 			Name:    strings.TrimPrefix(containerJSON.Name, "/"),
 			State:   containerStatusStateToRuntimeClientState(containerJSON.State.Status),
 			Runtime: runtimeclient.DockerName,
+			Image:   containerJSON.Config.Image,
 		},
 		Pid:         containerJSON.State.Pid,
 		CgroupsPath: string(containerJSON.HostConfig.Cgroup),
+		Sandbox:     runtimeclient.SandboxFromRuntime(containerJSON.HostConfig.Runtime),
 	}
 	if len(containerJSON.Mounts) > 0 {
 		containerDetailsData.Mounts = make([]runtimeclient.ContainerMountData, len(containerJSON.Mounts))
@@ -178,9 +212,17 @@ func (c *DockerClient) GetContainerDetails(containerID string) (*runtimeclient.C
 		}
 	}
 
+	// Rootless Docker can run in its own PID namespace
+	containerDetailsData.Pid, err = rootless.HostPid(c.socketPath, containerDetailsData.Pid)
+	if err != nil {
+		return nil, fmt.Errorf("translating pid of container %s: %w", containerID, err)
+	}
+
 	// Fill K8S information.
 	runtimeclient.EnrichWithK8sMetadata(&containerDetailsData.ContainerData, containerJSON.Config.Labels)
 
+	containerDetailsData.ImageDigest = c.imageDigest(containerJSON.Image, containerJSON.Config.Image)
+
 	// Try to get cgroups information from /proc/<pid>/cgroup as a fallback.
 	// However, don't fail if such a file is not available, as it would prevent the
 	// whole feature to work on systems without this file.
@@ -236,6 +278,7 @@ func DockerContainerToContainerData(container *dockertypes.Container) *runtimecl
 		Name:    strings.TrimPrefix(container.Names[0], "/"),
 		State:   containerStatusStateToRuntimeClientState(container.State),
 		Runtime: runtimeclient.DockerName,
+		Image:   container.Image,
 	}
 
 	// Fill K8S information.
@@ -0,0 +1,200 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kata runs commands inside the guest of the Kata Containers sandboxes,
+// through the debug console of their agent, like kata-runtime exec does. The
+// debug console must be enabled in the configuration of Kata Containers, with
+// debug_console_enabled = true in the [agent.kata] section.
+package kata
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+const (
+	// The annotations of the runtime spec of the containers giving the ID of
+	// their pod sandbox, which is also the ID of the Kata sandbox
+	containerdSandboxIDAnnotation = "io.kubernetes.cri.sandbox-id"
+	crioSandboxIDAnnotation       = "io.kubernetes.cri-o.SandboxID"
+
+	// debugConsolePort is the default vsock port of the debug console of the
+	// agent, debug_console_vport in the configuration
+	debugConsolePort = 1026
+
+	timeout = 5 * time.Second
+)
+
+// shimMonitorSockets are the sockets of the management API of the shim of a
+// sandbox, by sandbox ID, for the Go runtime and for runtime-rs
+var shimMonitorSockets = []string{
+	"/run/vc/sbs/%s/shim-monitor.sock",
+	"/run/kata/%s/shim-monitor.sock",
+}
+
+// SandboxID returns the ID of the sandbox of a container from the annotations
+// of its runtime spec, empty if they don't tell it
+func SandboxID(annotations map[string]string) string {
+	if id := annotations[containerdSandboxIDAnnotation]; id != "" {
+		return id
+	}
+	return annotations[crioSandboxIDAnnotation]
+}
+
+// Exec runs the command with the shell of the debug console of the sandbox and
+// returns the output of the console, which starts with the echo of the command
+// and has "\r\n" line endings. Closing it hangs up the console, which stops the
+// command.
+func Exec(sandboxID string, command string) (io.ReadCloser, error) {
+	url, err := agentURL(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+	console, err := dialDebugConsole(url)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to the debug console of sandbox %q: %w", sandboxID, err)
+	}
+	// exec, so the console is closed once the command exits
+	if _, err := fmt.Fprintf(console, "exec %s\n", command); err != nil {
+		console.Close()
+		return nil, fmt.Errorf("running command in sandbox %q: %w", sandboxID, err)
+	}
+	return console, nil
+}
+
+// agentURL asks the shim of the sandbox how to reach its agent, e.g.
+// "vsock://3:1024"
+func agentURL(sandboxID string) (string, error) {
+	var socket string
+	for _, pattern := range shimMonitorSockets {
+		path := filepath.Join(host.HostRoot, fmt.Sprintf(pattern, sandboxID))
+		if _, err := os.Stat(path); err == nil {
+			socket = path
+			break
+		}
+	}
+	if socket == "" {
+		return "", fmt.Errorf("shim of sandbox %q not found", sandboxID)
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	resp, err := client.Get("http://shim/agent-url")
+	if err != nil {
+		return "", fmt.Errorf("getting the agent URL of sandbox %q: %w", sandboxID, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("getting the agent URL of sandbox %q: %w", sandboxID, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("getting the agent URL of sandbox %q: %s: %s", sandboxID, resp.Status, body)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// dialDebugConsole connects to the debug console of the agent reachable at
+// url, with the port of the agent replaced by the one of the console
+func dialDebugConsole(url string) (io.ReadWriteCloser, error) {
+	scheme, address, ok := strings.Cut(url, "://")
+	if !ok {
+		return nil, fmt.Errorf("invalid agent URL %q", url)
+	}
+	// The address is the CID or the path, then the port of the agent
+	if i := strings.LastIndex(address, ":"); i >= 0 {
+		address = address[:i]
+	}
+
+	switch scheme {
+	case "vsock":
+		cid, err := strconv.ParseUint(address, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid agent URL %q: %w", url, err)
+		}
+		return dialVsock(uint32(cid), debugConsolePort)
+	case "hvsock":
+		return dialHybridVsock(filepath.Join(host.HostRoot, address), debugConsolePort)
+	default:
+		return nil, fmt.Errorf("unsupported agent URL %q", url)
+	}
+}
+
+func dialVsock(cid, port uint32) (io.ReadWriteCloser, error) {
+	fd, err := unix.Socket(unix.AF_VSOCK, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("creating vsock socket: %w", err)
+	}
+	if err := unix.Connect(fd, &unix.SockaddrVM{CID: cid, Port: port}); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("connecting to vsock %d:%d: %w", cid, port, err)
+	}
+	return os.NewFile(uintptr(fd), fmt.Sprintf("vsock:%d:%d", cid, port)), nil
+}
+
+// dialHybridVsock connects to a port of the guest through the unix socket of
+// the hypervisors emulating vsock, like Cloud Hypervisor and Firecracker
+func dialHybridVsock(path string, port uint32) (io.ReadWriteCloser, error) {
+	conn, err := net.DialTimeout("unix", path, timeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := fmt.Fprintf(conn, "CONNECT %d\n", port); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	reply, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(reply, "OK") {
+		conn.Close()
+		return nil, fmt.Errorf("connecting to port %d: %s", port, strings.TrimSpace(reply))
+	}
+	conn.SetDeadline(time.Time{})
+	return &bufferedConn{Reader: reader, Conn: conn}, nil
+}
+
+// bufferedConn reads what was buffered while connecting before the connection
+type bufferedConn struct {
+	*bufio.Reader
+	net.Conn
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.Reader.Read(p)
+}
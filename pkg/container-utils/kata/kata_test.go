@@ -0,0 +1,145 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kata
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+func TestSandboxID(t *testing.T) {
+	table := []struct {
+		description string
+		annotations map[string]string
+		expected    string
+	}{
+		{
+			description: "containerd",
+			annotations: map[string]string{containerdSandboxIDAnnotation: "abc"},
+			expected:    "abc",
+		},
+		{
+			description: "cri-o",
+			annotations: map[string]string{crioSandboxIDAnnotation: "def"},
+			expected:    "def",
+		},
+		{
+			description: "none",
+			annotations: map[string]string{"io.kubernetes.cri.container-name": "name"},
+			expected:    "",
+		},
+	}
+
+	for _, entry := range table {
+		require.Equal(t, entry.expected, SandboxID(entry.annotations), entry.description)
+	}
+}
+
+// fakeSandbox serves the shim monitor API of a sandbox and a debug console
+// behind a hybrid vsock socket, in a temporary host root
+func fakeSandbox(t *testing.T, sandboxID string, run func(command string, w io.Writer)) {
+	root := t.TempDir()
+	oldRoot := host.HostRoot
+	host.HostRoot = root
+	t.Cleanup(func() {
+		host.HostRoot = oldRoot
+	})
+
+	dir := filepath.Join(root, "run/vc/sbs", sandboxID)
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+
+	monitor, err := net.Listen("unix", filepath.Join(dir, "shim-monitor.sock"))
+	require.NoError(t, err)
+	t.Cleanup(func() { monitor.Close() })
+	mux := http.NewServeMux()
+	mux.HandleFunc("/agent-url", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "hvsock:///run/vc/%s/kata.hvsock:1024\n", sandboxID)
+	})
+	go http.Serve(monitor, mux)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "run/vc", sandboxID), 0o755))
+	hvsock, err := net.Listen("unix", filepath.Join(root, "run/vc", sandboxID, "kata.hvsock"))
+	require.NoError(t, err)
+	t.Cleanup(func() { hvsock.Close() })
+	go func() {
+		for {
+			conn, err := hvsock.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line != fmt.Sprintf("CONNECT %d\n", debugConsolePort) {
+					fmt.Fprintf(conn, "FAILURE\n")
+					return
+				}
+				fmt.Fprintf(conn, "OK 1073741824\n")
+
+				// The shell of the console echoes the command
+				command, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				fmt.Fprintf(conn, "%s\r\n", command[:len(command)-1])
+				run(command, conn)
+			}()
+		}
+	}()
+}
+
+func TestExec(t *testing.T) {
+	fakeSandbox(t, "sandbox", func(command string, w io.Writer) {
+		if command == "exec echo hello\n" {
+			fmt.Fprintf(w, "hello\r\n")
+		}
+	})
+
+	output, err := Exec("sandbox", "echo hello")
+	require.NoError(t, err)
+	defer output.Close()
+
+	data, err := io.ReadAll(output)
+	require.NoError(t, err)
+	require.Equal(t, "exec echo hello\r\nhello\r\n", string(data))
+}
+
+func TestExecUnknownSandbox(t *testing.T) {
+	fakeSandbox(t, "sandbox", func(string, io.Writer) {})
+
+	_, err := Exec("other", "echo hello")
+	require.Error(t, err)
+}
+
+func TestDialDebugConsole(t *testing.T) {
+	for _, url := range []string{"", "vsock", "vsock://abc:1024", "tcp://127.0.0.1:1024"} {
+		_, err := dialDebugConsole(url)
+		require.Error(t, err, url)
+	}
+}
@@ -26,6 +26,9 @@ const (
 	containerdPodUIDAnnotation        = "io.kubernetes.cri.sandbox-uid"
 	containerdContainerNameAnnotation = "io.kubernetes.cri.container-name"
 	containerdContainerTypeAnnotation = "io.kubernetes.cri.container-type"
+	// Image name annotation added in containerd v1.6.0 via
+	// https://github.com/containerd/containerd/pull/6041
+	containerdImageNameAnnotation = "io.kubernetes.cri.image-name"
 )
 
 type containerdResolver struct{}
@@ -50,6 +53,10 @@ func (containerdResolver) PodNamespace(annotations map[string]string) string {
 	return annotations[containerdPodNamespaceAnnotation]
 }
 
+func (containerdResolver) ContainerImage(annotations map[string]string) string {
+	return annotations[containerdImageNameAnnotation]
+}
+
 func (containerdResolver) Runtime() string {
 	return "containerd"
 }
@@ -24,6 +24,7 @@ const (
 	crioPodUIDAnnotation           = "io.kubernetes.pod.uid"
 	crioContainerNameAnnotation    = "io.kubernetes.container.name"
 	crioContainerTypeAnnotation    = "io.kubernetes.cri-o.ContainerType"
+	crioImageNameAnnotation        = "io.kubernetes.cri-o.ImageName"
 )
 
 type crioResolver struct{}
@@ -48,6 +49,10 @@ func (crioResolver) PodNamespace(annotations map[string]string) string {
 	return annotations[crioPodNamespaceAnnotation]
 }
 
+func (crioResolver) ContainerImage(annotations map[string]string) string {
+	return annotations[crioImageNameAnnotation]
+}
+
 func (crioResolver) Runtime() string {
 	return "cri-o"
 }
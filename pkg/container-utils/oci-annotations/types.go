@@ -32,6 +32,9 @@ type Resolver interface {
 	PodUID(annotations map[string]string) string
 	// PodNamespace returns the namespace of the pod to which container belongs
 	PodNamespace(annotations map[string]string) string
+	// ContainerImage returns the image the container was created from, as
+	// given by the user
+	ContainerImage(annotations map[string]string) string
 	// Runtime returns runtime in which the container is running
 	Runtime() string
 }
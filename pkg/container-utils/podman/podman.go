@@ -21,51 +21,192 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"sync"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/rootless"
 	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
 )
 
 const (
 	defaultConnectionTimeout = 2 * time.Second
+	eventsRetryInterval      = 5 * time.Second
 	containerListAllURL      = "http://d/v4.0.0/libpod/containers/json?all=true"
 	containerInspectURL      = "http://d/v4.0.0/libpod/containers/%s/json"
+	containerEventsURL       = "http://d/v4.0.0/libpod/events?stream=true&filters="
+
+	// Rootless Podman listens in the runtime directory of the user
+	rootlessSocketPath = "podman/podman.sock"
 )
 
+// PodmanClient keeps the list of containers up to date with the libpod events
+// instead of asking Podman for each new container: Podman holds the lock of
+// the container while the OCI runtime creates it, the requests about it would
+// block until the container is created.
 type PodmanClient struct {
-	client http.Client
+	client       http.Client
+	eventsClient http.Client
+
+	mu         sync.Mutex
+	containers map[string]*runtimeclient.ContainerData
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 func NewPodmanClient(socketPath string) runtimeclient.ContainerRuntimeClient {
 	if socketPath == "" {
 		socketPath = runtimeclient.PodmanDefaultSocketPath
 	}
+	socketPath = rootless.SocketPath(socketPath, runtimeclient.PodmanDefaultSocketPath,
+		rootless.InRuntimeDir(rootlessSocketPath))
 
-	return &PodmanClient{
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (conn net.Conn, err error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &PodmanClient{
 		client: http.Client{
-			Transport: &http.Transport{
-				DialContext: func(ctx context.Context, _, _ string) (conn net.Conn, err error) {
-					return net.Dial("unix", socketPath)
-				},
-			},
-			Timeout: defaultConnectionTimeout,
+			Transport: transport,
+			Timeout:   defaultConnectionTimeout,
+		},
+		// The events are streamed for as long as the client is open
+		eventsClient: http.Client{
+			Transport: transport,
 		},
+		cancel: cancel,
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.watchEvents(ctx)
+	}()
+
+	return p
+}
+
+type containerEvent struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+}
+
+// watchEvents follows the libpod events until ctx is canceled, reconnecting
+// when Podman restarts: the Podman service is often socket-activated and exits
+// when idle.
+func (p *PodmanClient) watchEvents(ctx context.Context) {
+	for {
+		err := p.followEvents(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Debugf("PodmanClient: following events: %s", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventsRetryInterval):
+		}
+	}
+}
+
+func (p *PodmanClient) followEvents(ctx context.Context) error {
+	f, err := json.Marshal(map[string][]string{"type": {"container"}})
+	if err != nil {
+		return fmt.Errorf("setting up filters: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, containerEventsURL+url.QueryEscape(string(f)), nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := p.eventsClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("getting events: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("getting events via rest api: %s", resp.Status)
+	}
+
+	// The containers are listed once the events are followed to not miss the
+	// ones created in between
+	containers, err := p.listContainers()
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.containers = make(map[string]*runtimeclient.ContainerData, len(containers))
+	for _, c := range containers {
+		p.containers[c.ID] = c
+	}
+	p.mu.Unlock()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var event containerEvent
+		if err := decoder.Decode(&event); err != nil {
+			return fmt.Errorf("decoding event: %w", err)
+		}
+		p.handleEvent(&event)
 	}
 }
 
-func (p *PodmanClient) listContainers(containerID string) ([]*runtimeclient.ContainerData, error) {
-	var filters string
-	if containerID != "" {
-		f, err := json.Marshal(map[string][]string{"id": {containerID}})
-		if err != nil {
-			return nil, fmt.Errorf("setting up filters: %w", err)
+func (p *PodmanClient) handleEvent(event *containerEvent) {
+	if event.Type != "container" || event.Actor.ID == "" {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if event.Action == "remove" {
+		delete(p.containers, event.Actor.ID)
+		return
+	}
+
+	var state string
+	switch event.Action {
+	case "create":
+		state = runtimeclient.StateCreated
+	case "init", "start", "restart":
+		state = runtimeclient.StateRunning
+	case "died", "stop":
+		state = runtimeclient.StateExited
+	default:
+		return
+	}
+
+	c, ok := p.containers[event.Actor.ID]
+	if !ok {
+		c = &runtimeclient.ContainerData{
+			ID:      event.Actor.ID,
+			Runtime: runtimeclient.PodmanName,
 		}
-		filters = "&filters=" + url.QueryEscape(string(f))
+		p.containers[c.ID] = c
+	}
+	if name := event.Actor.Attributes["name"]; name != "" {
+		c.Name = name
+	}
+	if image := event.Actor.Attributes["image"]; image != "" {
+		c.Image = image
 	}
+	c.State = state
+}
 
-	resp, err := p.client.Get(containerListAllURL + filters)
+func (p *PodmanClient) listContainers() ([]*runtimeclient.ContainerData, error) {
+	resp, err := p.client.Get(containerListAllURL)
 	if err != nil {
 		return nil, fmt.Errorf("listing containers: %w", err)
 	}
@@ -79,6 +220,7 @@ func (p *PodmanClient) listContainers(containerID string) ([]*runtimeclient.Cont
 		ID    string   `json:"Id"`
 		Names []string `json:"Names"`
 		State string   `json:"State"`
+		Image string   `json:"Image"`
 	}
 	if err = json.NewDecoder(resp.Body).Decode(&containers); err != nil {
 		return nil, fmt.Errorf("decoding containers: %w", err)
@@ -91,28 +233,28 @@ func (p *PodmanClient) listContainers(containerID string) ([]*runtimeclient.Cont
 			Name:    c.Names[0],
 			State:   containerStatusStateToRuntimeClientState(c.State),
 			Runtime: runtimeclient.PodmanName,
+			Image:   c.Image,
 		}
 	}
 	return ret, nil
 }
 
 func (p *PodmanClient) GetContainers() ([]*runtimeclient.ContainerData, error) {
-	return p.listContainers("")
+	return p.listContainers()
 }
 
+// GetContainer returns the container from the ones known by the events, it
+// doesn't block while the container is being created
 func (p *PodmanClient) GetContainer(containerID string) (*runtimeclient.ContainerData, error) {
-	containers, err := p.listContainers(containerID)
-	if err != nil {
-		return nil, err
-	}
-	if len(containers) == 0 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	c, ok := p.containers[containerID]
+	if !ok {
 		return nil, fmt.Errorf("container %q not found", containerID)
 	}
-	if len(containers) > 1 {
-		log.Warnf("PodmanClient: multiple containers (%d) with ID %q. Taking the first one: %+v",
-			len(containers), containerID, containers)
-	}
-	return containers[0], nil
+	ret := *c
+	return &ret, nil
 }
 
 func (p *PodmanClient) GetContainerDetails(containerID string) (*runtimeclient.ContainerDetailsData, error) {
@@ -127,9 +269,11 @@ func (p *PodmanClient) GetContainerDetails(containerID string) (*runtimeclient.C
 	}
 
 	var container struct {
-		ID    string `json:"Id"`
-		Name  string `json:"Name"`
-		State struct {
+		ID          string `json:"Id"`
+		Name        string `json:"Name"`
+		ImageName   string `json:"ImageName"`
+		ImageDigest string `json:"ImageDigest"`
+		State       struct {
 			Status     string `json:"Status"`
 			Pid        int    `json:"Pid"`
 			CgroupPath string `json:"CgroupPath"`
@@ -142,10 +286,12 @@ func (p *PodmanClient) GetContainerDetails(containerID string) (*runtimeclient.C
 
 	return &runtimeclient.ContainerDetailsData{
 		ContainerData: runtimeclient.ContainerData{
-			ID:      container.ID,
-			Name:    container.Name,
-			State:   containerStatusStateToRuntimeClientState(container.State.Status),
-			Runtime: runtimeclient.PodmanName,
+			ID:          container.ID,
+			Name:        container.Name,
+			State:       containerStatusStateToRuntimeClientState(container.State.Status),
+			Runtime:     runtimeclient.PodmanName,
+			Image:       container.ImageName,
+			ImageDigest: container.ImageDigest,
 		},
 		Pid:         container.State.Pid,
 		CgroupsPath: container.State.CgroupPath,
@@ -153,6 +299,8 @@ func (p *PodmanClient) GetContainerDetails(containerID string) (*runtimeclient.C
 }
 
 func (p *PodmanClient) Close() error {
+	p.cancel()
+	p.wg.Wait()
 	return nil
 }
 
@@ -0,0 +1,98 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podman
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
+)
+
+// fakePodman serves the list of containers and streams the events sent to it
+func fakePodman(t *testing.T, events chan string) string {
+	socketPath := filepath.Join(t.TempDir(), "podman.sock")
+	l, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v4.0.0/libpod/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"Id":"initial","Names":["initial"],"State":"running"}]`)
+	})
+	mux.HandleFunc("/v4.0.0/libpod/events", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case e := <-events:
+				fmt.Fprintln(w, e)
+				w.(http.Flusher).Flush()
+			}
+		}
+	})
+
+	server := &http.Server{Handler: mux}
+	go server.Serve(l)
+	t.Cleanup(func() { server.Close() })
+
+	return socketPath
+}
+
+func event(action, id, name string) string {
+	return fmt.Sprintf(`{"Type":"container","Action":%q,"Actor":{"ID":%q,"Attributes":{"name":%q}}}`,
+		action, id, name)
+}
+
+func TestPodmanClientEvents(t *testing.T) {
+	events := make(chan string)
+	client := NewPodmanClient(fakePodman(t, events))
+	defer client.Close()
+
+	require.Eventually(t, func() bool {
+		_, err := client.GetContainer("initial")
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond, "initial container not listed")
+
+	events <- event("create", "new", "test")
+	events <- event("init", "new", "test")
+	require.Eventually(t, func() bool {
+		c, err := client.GetContainer("new")
+		return err == nil && c.State == runtimeclient.StateRunning
+	}, 5*time.Second, 10*time.Millisecond, "new container not running")
+
+	c, err := client.GetContainer("new")
+	require.NoError(t, err)
+	require.Equal(t, &runtimeclient.ContainerData{
+		ID:      "new",
+		Name:    "test",
+		State:   runtimeclient.StateRunning,
+		Runtime: runtimeclient.PodmanName,
+	}, c)
+
+	events <- event("died", "new", "test")
+	events <- event("remove", "new", "test")
+	require.Eventually(t, func() bool {
+		_, err := client.GetContainer("new")
+		return err != nil
+	}, 5*time.Second, 10*time.Millisecond, "removed container still known")
+}
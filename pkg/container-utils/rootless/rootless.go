@@ -0,0 +1,230 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rootless finds the container runtimes running as a user instead of
+// root, like rootless Docker and containerd, and translates the PIDs they give
+// when they run in their own PID namespace.
+//
+// The rootless runtimes are started by RootlessKit, which creates a user
+// namespace and a mount namespace for them, and optionally a PID namespace.
+// Their sockets are in the runtime directory of the user, /run/user/$UID, or
+// only in the mount namespace of RootlessKit.
+package rootless
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+const runtimeDir = "/run/user/%s"
+
+// SocketFunc returns the socket of the rootless runtime of a user
+type SocketFunc func(uid string) (string, error)
+
+// InRuntimeDir returns the socket at path in the runtime directory of the
+// user, like rootless Docker and Podman use
+func InRuntimeDir(path string) SocketFunc {
+	return func(uid string) (string, error) {
+		return filepath.Join(fmt.Sprintf(runtimeDir, uid), path), nil
+	}
+}
+
+// InRootlessKit returns the socket at path in the mount namespace of the
+// RootlessKit whose state directory is stateDir, in the runtime directory of
+// the user, like rootless containerd uses
+func InRootlessKit(stateDir, path string) SocketFunc {
+	return func(uid string) (string, error) {
+		childPidFile := filepath.Join(fmt.Sprintf(runtimeDir, uid), stateDir, "child_pid")
+		childPid, err := os.ReadFile(childPidFile)
+		if err != nil {
+			return "", err
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(childPid)))
+		if err != nil {
+			return "", fmt.Errorf("parsing %q: %w", childPidFile, err)
+		}
+		return filepath.Join(host.HostProcFs, fmt.Sprint(pid), "root", path), nil
+	}
+}
+
+// SocketPath returns socketPath, unless it's the default socket of the rootful
+// runtime and it doesn't exist: then, it returns the socket of the rootless
+// runtime of the user running ig with sudo, if it exists
+func SocketPath(socketPath, defaultSocketPath string, rootless SocketFunc) string {
+	if socketPath != defaultSocketPath {
+		return socketPath
+	}
+	if _, err := os.Stat(socketPath); !errors.Is(err, os.ErrNotExist) {
+		return socketPath
+	}
+
+	uid := os.Getenv("SUDO_UID")
+	if uid == "" {
+		return socketPath
+	}
+	rootlessSocketPath, err := rootless(uid)
+	if err != nil {
+		return socketPath
+	}
+	if _, err := os.Stat(rootlessSocketPath); err != nil {
+		return socketPath
+	}
+	return rootlessSocketPath
+}
+
+// HostPid translates the pid given by the runtime listening on socketPath to
+// the PID namespace of ig. It's the same pid unless the runtime runs in its
+// own PID namespace.
+func HostPid(socketPath string, pid int) (int, error) {
+	runtimePid, err := peerPid(socketPath)
+	if err != nil {
+		return 0, err
+	}
+
+	nsPids, err := readNSpid(runtimePid)
+	if err != nil {
+		return 0, err
+	}
+	// The runtime is in the PID namespace of ig
+	if len(nsPids) == 1 {
+		return pid, nil
+	}
+
+	runtimeNs, err := pidNsInode(filepath.Join(host.HostProcFs, fmt.Sprint(runtimePid), "ns", "pid"), 0)
+	if err != nil {
+		return 0, err
+	}
+
+	// Look for the process whose pid is the given one at the level of the PID
+	// namespace of the runtime, and which is in this namespace or in one
+	// nested in it
+	level := len(nsPids) - 1
+	entries, err := os.ReadDir(host.HostProcFs)
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range entries {
+		candidate, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		candidatePids, err := readNSpid(candidate)
+		if err != nil || len(candidatePids) <= level || candidatePids[level] != pid {
+			continue
+		}
+		nsPath := filepath.Join(host.HostProcFs, entry.Name(), "ns", "pid")
+		ns, err := pidNsInode(nsPath, len(candidatePids)-1-level)
+		if err != nil || ns != runtimeNs {
+			continue
+		}
+		return candidate, nil
+	}
+
+	return 0, fmt.Errorf("pid %d of the PID namespace of the runtime not found", pid)
+}
+
+// peerPid returns the pid of the process listening on socketPath
+func peerPid(socketPath string) (int, error) {
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	rawConn, err := conn.(*net.UnixConn).SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	var cred *unix.Ucred
+	var credErr error
+	err = rawConn.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if credErr != nil {
+		return 0, fmt.Errorf("getting peer credentials of %q: %w", socketPath, credErr)
+	}
+	if cred.Pid == 0 {
+		return 0, fmt.Errorf("process listening on %q not visible", socketPath)
+	}
+	return int(cred.Pid), nil
+}
+
+// readNSpid returns the pids of a process in the PID namespaces it's in, from
+// the one of ig to its own
+func readNSpid(pid int) ([]int, error) {
+	f, err := os.Open(filepath.Join(host.HostProcFs, fmt.Sprint(pid), "status"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "NSpid:") {
+			continue
+		}
+		var pids []int
+		for _, field := range strings.Fields(strings.TrimPrefix(line, "NSpid:")) {
+			nsPid, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("parsing NSpid of %d: %w", pid, err)
+			}
+			pids = append(pids, nsPid)
+		}
+		return pids, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// Kernels before 4.1 don't have NSpid, the PID namespaces can't be told
+	return []int{pid}, nil
+}
+
+// pidNsInode returns the inode of the PID namespace at nsPath, or of its
+// ancestor up levels above it
+func pidNsInode(nsPath string, up int) (uint64, error) {
+	fd, err := unix.Open(nsPath, unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < up; i++ {
+		parent, err := unix.IoctlRetInt(fd, unix.NS_GET_PARENT)
+		unix.Close(fd)
+		if err != nil {
+			return 0, fmt.Errorf("getting parent of PID namespace %q: %w", nsPath, err)
+		}
+		fd = parent
+	}
+	defer unix.Close(fd)
+
+	var stat unix.Stat_t
+	if err := unix.Fstat(fd, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Ino, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rootless
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const helperSocketEnv = "ROOTLESS_TEST_SOCKET"
+
+func TestSocketPath(t *testing.T) {
+	dir := t.TempDir()
+	defaultSocketPath := filepath.Join(dir, "default.sock")
+	userSocketPath := filepath.Join(dir, "1000.sock")
+	userSocket := func(uid string) (string, error) {
+		return filepath.Join(dir, uid+".sock"), nil
+	}
+	t.Setenv("SUDO_UID", "1000")
+
+	// Neither socket exists
+	require.Equal(t, defaultSocketPath, SocketPath(defaultSocketPath, defaultSocketPath, userSocket))
+
+	require.NoError(t, os.WriteFile(userSocketPath, nil, 0o600))
+	require.Equal(t, userSocketPath, SocketPath(defaultSocketPath, defaultSocketPath, userSocket))
+
+	// Sockets given by the user are kept
+	require.Equal(t, "/other.sock", SocketPath("/other.sock", defaultSocketPath, userSocket))
+
+	require.NoError(t, os.WriteFile(defaultSocketPath, nil, 0o600))
+	require.Equal(t, defaultSocketPath, SocketPath(defaultSocketPath, defaultSocketPath, userSocket))
+}
+
+func TestHostPidSameNamespace(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "runtime.sock")
+	l, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	defer l.Close()
+
+	pid, err := HostPid(socketPath, 1234)
+	require.NoError(t, err)
+	require.Equal(t, 1234, pid)
+}
+
+// TestHelperRuntime listens on a socket as the runtime would, it's run in a
+// new PID namespace by TestHostPidNestedNamespace
+func TestHelperRuntime(t *testing.T) {
+	socketPath := os.Getenv(helperSocketEnv)
+	if socketPath == "" {
+		t.Skip("only run by TestHostPidNestedNamespace")
+	}
+	l, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	defer l.Close()
+	time.Sleep(time.Minute)
+}
+
+func TestHostPidNestedNamespace(t *testing.T) {
+	if os.Getuid() != 0 {
+		t.Skip("creating a PID namespace needs root")
+	}
+
+	socketPath := filepath.Join(t.TempDir(), "runtime.sock")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperRuntime$")
+	cmd.Env = append(os.Environ(), helperSocketEnv+"="+socketPath)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Cloneflags: syscall.CLONE_NEWPID}
+	require.NoError(t, cmd.Start())
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(socketPath)
+		return err == nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The runtime is pid 1 of its namespace
+	pid, err := HostPid(socketPath, 1)
+	require.NoError(t, err)
+	require.Equal(t, cmd.Process.Pid, pid)
+
+	_, err = HostPid(socketPath, 4242)
+	require.Error(t, err)
+}
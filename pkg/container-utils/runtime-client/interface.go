@@ -64,6 +64,23 @@ type ContainerData struct {
 
 	// Namespace of the pod running the container.
 	PodNamespace string
+
+	// RuntimeNamespace is the namespace of the runtime the container belongs
+	// to, e.g. the containerd namespace, empty for the default one.
+	RuntimeNamespace string
+
+	// RestartCount is the number of times the container was restarted, e.g.
+	// the attempt of the CRI metadata, zero when the runtime doesn't tell it.
+	RestartCount uint32
+
+	// Image is the reference of the image the container was created from,
+	// as given by the user (e.g. docker.io/library/nginx:latest).
+	Image string
+
+	// ImageDigest is the digest of the manifest of the image as resolved
+	// from the registry (e.g. sha256:...), empty when the runtime doesn't
+	// tell it.
+	ImageDigest string
 }
 
 // ContainerDetailsData contains container extra information returned from the
@@ -81,6 +98,14 @@ type ContainerDetailsData struct {
 
 	// List of mounts in the container.
 	Mounts []ContainerMountData
+
+	// Sandbox is the kind of sandbox the container runs in, SandboxKata or
+	// SandboxGVisor, empty when it runs on the kernel of the host.
+	Sandbox string
+
+	// Annotations of the runtime spec of the container, e.g. the
+	// io.kubernetes.cri-o.* ones set by CRI-O.
+	Annotations map[string]string
 }
 
 // ContainerMountData contains mount information in ContainerData.
@@ -106,6 +131,16 @@ const (
 	StateUnknown = "unknown"
 )
 
+const (
+	// The container runs in a virtual machine of Kata Containers: the
+	// processes seen on the host are the ones of the hypervisor.
+	SandboxKata = "kata"
+
+	// The container runs on the kernel of gVisor, in user space: the
+	// processes seen on the host are the ones of the sandbox.
+	SandboxGVisor = "gvisor"
+)
+
 const (
 	containerLabelK8sPodName      = "io.kubernetes.pod.name"
 	containerLabelK8sPodNamespace = "io.kubernetes.pod.namespace"
@@ -157,3 +192,35 @@ func EnrichWithK8sMetadata(container *ContainerData, labels map[string]string) {
 		container.PodUID = podUID
 	}
 }
+
+// DigestFromImageRef returns the digest of an image reference pinned to a
+// digest, like "docker.io/library/nginx@sha256:...", or empty if the
+// reference doesn't have one, e.g. when it's the ID of a local image
+func DigestFromImageRef(ref string) string {
+	_, digest, found := strings.Cut(ref, "@")
+	if !found {
+		return ""
+	}
+	return digest
+}
+
+// ImageRegistry returns the registry host of the given image reference, with
+// the same rules as Docker: the first component of the name is the registry
+// only if it looks like a host, otherwise the image comes from Docker Hub,
+// given as "docker.io". It's empty for the IDs of local images.
+func ImageRegistry(image string) string {
+	if image == "" || strings.HasPrefix(image, "sha256:") {
+		return ""
+	}
+
+	name, _, _ := strings.Cut(image, "@")
+	host, _, found := strings.Cut(name, "/")
+	if !found || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		return "docker.io"
+	}
+	switch host {
+	case "index.docker.io", "registry-1.docker.io":
+		return "docker.io"
+	}
+	return host
+}
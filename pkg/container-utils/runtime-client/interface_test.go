@@ -0,0 +1,51 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtimeclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageRegistry(t *testing.T) {
+	table := map[string]string{
+		"":                                    "",
+		"nginx":                               "docker.io",
+		"nginx:1.25":                          "docker.io",
+		"library/nginx@sha256:0123":           "docker.io",
+		"docker.io/library/nginx:1.25":        "docker.io",
+		"index.docker.io/library/nginx":       "docker.io",
+		"ghcr.io/inspektor-gadget/ig:latest":  "ghcr.io",
+		"localhost/app":                       "localhost",
+		"registry.local:5000/team/app:v1":     "registry.local:5000",
+		"sha256:4f0b7ad1b5d3a6a8c8a8b1b43c1e": "",
+	}
+	for image, expected := range table {
+		require.Equal(t, expected, ImageRegistry(image), image)
+	}
+}
+
+func TestDigestFromImageRef(t *testing.T) {
+	table := map[string]string{
+		"docker.io/library/nginx@sha256:0123":           "sha256:0123",
+		"docker-pullable://nginx@sha256:0123":           "sha256:0123",
+		"docker.io/library/nginx:1.25":                  "",
+		"sha256:4f0b7ad1b5d3a6a8c8a8b1b43c1e2b8e7d1c0f": "",
+	}
+	for ref, expected := range table {
+		require.Equal(t, expected, DigestFromImageRef(ref), ref)
+	}
+}
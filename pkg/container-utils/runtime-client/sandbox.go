@@ -0,0 +1,109 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtimeclient
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const sandboxRuntimesEnv = "INSPEKTOR_GADGET_SANDBOX_RUNTIMES"
+
+// sandboxRuntimes are the OCI runtimes, containerd runtime types, runtime
+// handlers and RuntimeClasses of the sandboxes, as installed by default:
+//   - Kata Containers: by kata-deploy, with one RuntimeClass and handler per
+//     hypervisor, by Docker, by AKS Pod Sandboxing and by OpenShift sandboxed
+//     containers
+//   - gVisor: by runsc install for Docker and containerd, and by GKE Sandbox
+//
+// Other names, e.g. of RuntimeClasses created by hand, can be added with the
+// INSPEKTOR_GADGET_SANDBOX_RUNTIMES environment variable as a comma-separated
+// list of name=sandbox, e.g. INSPEKTOR_GADGET_SANDBOX_RUNTIMES=my-vms=kata.
+var sandboxRuntimes = map[string]string{
+	"kata":                         SandboxKata,
+	"kata-runtime":                 SandboxKata,
+	"kata-remote":                  SandboxKata,
+	"kata-mshv-vm-isolation":       SandboxKata,
+	"io.containerd.kata.v2":        SandboxKata,
+	"io.containerd.kata-remote.v2": SandboxKata,
+	"runsc":                        SandboxGVisor,
+	"gvisor":                       SandboxGVisor,
+	"io.containerd.runsc.v1":       SandboxGVisor,
+	"io.containerd.runsc.v2":       SandboxGVisor,
+}
+
+// The hypervisors of kata-deploy, each with a RuntimeClass and a handler
+// named kata-<hypervisor> and a runtime type io.containerd.kata-<hypervisor>.v2
+var kataHypervisors = []string{
+	"qemu",
+	"qemu-tdx",
+	"qemu-sev",
+	"qemu-snp",
+	"qemu-nvidia-gpu",
+	"clh",
+	"fc",
+	"dragonball",
+	"stratovirt",
+}
+
+func init() {
+	for _, hypervisor := range kataHypervisors {
+		sandboxRuntimes["kata-"+hypervisor] = SandboxKata
+		sandboxRuntimes["io.containerd.kata-"+hypervisor+".v2"] = SandboxKata
+	}
+
+	if err := configureSandboxRuntimes(os.Getenv(sandboxRuntimesEnv)); err != nil {
+		log.Warnf("sandbox detection: %s", err)
+	}
+}
+
+// configureSandboxRuntimes adds the runtimes given as a "name=sandbox"
+// comma-separated list to the known ones
+func configureSandboxRuntimes(value string) error {
+	runtimes := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, sandbox, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		sandbox = strings.TrimSpace(sandbox)
+		if !ok || name == "" {
+			return fmt.Errorf("parsing %s: invalid entry %q, expected name=sandbox", sandboxRuntimesEnv, entry)
+		}
+		if sandbox != SandboxKata && sandbox != SandboxGVisor {
+			return fmt.Errorf("parsing %s: invalid sandbox %q in %q, expected %q or %q",
+				sandboxRuntimesEnv, sandbox, entry, SandboxKata, SandboxGVisor)
+		}
+		runtimes[name] = sandbox
+	}
+
+	for name, sandbox := range runtimes {
+		sandboxRuntimes[name] = sandbox
+	}
+	return nil
+}
+
+// SandboxFromRuntime returns the sandbox of the containers run by the given
+// OCI runtime, runtime handler or RuntimeClass, e.g. "io.containerd.kata.v2"
+// or "runsc", or empty if they aren't sandboxed. Only the known names are
+// matched, see sandboxRuntimes.
+func SandboxFromRuntime(runtime string) string {
+	return sandboxRuntimes[runtime]
+}
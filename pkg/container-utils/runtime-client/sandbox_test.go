@@ -0,0 +1,63 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtimeclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandboxFromRuntime(t *testing.T) {
+	table := map[string]string{
+		"":                                "",
+		"runc":                            "",
+		"io.containerd.runc.v2":           "",
+		"crun":                            "",
+		"kata":                            SandboxKata,
+		"kata-qemu":                       SandboxKata,
+		"kata-clh":                        SandboxKata,
+		"kata-mshv-vm-isolation":          SandboxKata,
+		"io.containerd.kata.v2":           SandboxKata,
+		"io.containerd.kata-fc.v2":        SandboxKata,
+		"runsc":                           SandboxGVisor,
+		"gvisor":                          SandboxGVisor,
+		"io.containerd.runsc.v1":          SandboxGVisor,
+		"kata-unknown-hypervisor":         "",
+		"not-kata":                        "",
+		"my-runsc-wrapper":                "",
+		"io.containerd.kata.v2.unrelated": "",
+	}
+
+	for runtime, expected := range table {
+		require.Equal(t, expected, SandboxFromRuntime(runtime), runtime)
+	}
+}
+
+func TestConfigureSandboxRuntimes(t *testing.T) {
+	require.NoError(t, configureSandboxRuntimes(" my-vms = kata ,sandboxed=gvisor,"))
+	t.Cleanup(func() {
+		delete(sandboxRuntimes, "my-vms")
+		delete(sandboxRuntimes, "sandboxed")
+	})
+	require.Equal(t, SandboxKata, SandboxFromRuntime("my-vms"))
+	require.Equal(t, SandboxGVisor, SandboxFromRuntime("sandboxed"))
+	require.Equal(t, SandboxKata, SandboxFromRuntime("kata"))
+
+	for _, value := range []string{"my-vms", "=kata", "my-vms=firecracker", "other=kata,my-vms"} {
+		require.Error(t, configureSandboxRuntimes(value), value)
+	}
+	require.Empty(t, SandboxFromRuntime("other"))
+}
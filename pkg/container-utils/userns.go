@@ -0,0 +1,82 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containerutils
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+// overflowID is the ID given to the IDs of the host not mapped in a user
+// namespace, see /proc/sys/kernel/overflowuid
+const overflowID = 65534
+
+// IDMapping is a range of IDs of a user namespace mapped to a range of IDs
+// of the host, a line of /proc/<pid>/uid_map
+type IDMapping struct {
+	ContainerID uint32 `json:"containerID"`
+	HostID      uint32 `json:"hostID"`
+	Size        uint32 `json:"size"`
+}
+
+// IDMap maps the IDs of a user namespace to the ones of the host. It's nil
+// for the user namespace of the host.
+type IDMap []IDMapping
+
+// GetUidMap returns the mapping of the UIDs of the user namespace of the
+// given process, nil when it runs in the user namespace of the host
+func GetUidMap(pid int) (IDMap, error) {
+	data, err := os.ReadFile(filepath.Join(host.HostProcFs, fmt.Sprint(pid), "uid_map"))
+	if err != nil {
+		return nil, err
+	}
+	return parseIDMap(string(data))
+}
+
+func parseIDMap(data string) (IDMap, error) {
+	var idMap IDMap
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		var mapping IDMapping
+		if _, err := fmt.Sscan(line, &mapping.ContainerID, &mapping.HostID, &mapping.Size); err != nil {
+			return nil, fmt.Errorf("parsing ID mapping %q: %w", line, err)
+		}
+		idMap = append(idMap, mapping)
+	}
+
+	// The whole range of IDs mapped to itself: the user namespace of the host
+	if len(idMap) == 1 && idMap[0].ContainerID == 0 && idMap[0].HostID == 0 && idMap[0].Size == math.MaxUint32 {
+		return nil, nil
+	}
+	return idMap, nil
+}
+
+// ToContainer returns the ID in the user namespace of the given ID of the
+// host, the overflow ID if it isn't mapped there
+func (m IDMap) ToContainer(hostID uint32) uint32 {
+	if m == nil {
+		return hostID
+	}
+	for _, mapping := range m {
+		if hostID >= mapping.HostID && uint64(hostID) < uint64(mapping.HostID)+uint64(mapping.Size) {
+			return mapping.ContainerID + (hostID - mapping.HostID)
+		}
+	}
+	return overflowID
+}
@@ -0,0 +1,41 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containerutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIDMap(t *testing.T) {
+	hostMap, err := parseIDMap("         0          0 4294967295\n")
+	require.NoError(t, err)
+	require.Nil(t, hostMap)
+	require.Equal(t, uint32(1000), hostMap.ToContainer(1000))
+
+	idMap, err := parseIDMap("         0     100000      65536\n     65536    1000      1\n")
+	require.NoError(t, err)
+	require.Len(t, idMap, 2)
+	require.Equal(t, uint32(0), idMap.ToContainer(100000))
+	require.Equal(t, uint32(1000), idMap.ToContainer(101000))
+	require.Equal(t, uint32(65536), idMap.ToContainer(1000))
+	// Not mapped in the user namespace, like root of the host
+	require.Equal(t, uint32(65534), idMap.ToContainer(0))
+	require.Equal(t, uint32(65534), idMap.ToContainer(165536))
+
+	_, err = parseIDMap("0 100000\n")
+	require.Error(t, err)
+}
@@ -52,6 +52,10 @@ type TraceReconciler struct {
 	// TraceFactories contains the trace factories keyed by the gadget name
 	TraceFactories map[string]gadgets.TraceFactory
 	TracerManager  *gadgettracermanager.GadgetTracerManager
+
+	// tracerFilters are the filters the tracers were registered or last
+	// updated with, keyed by tracer ID
+	tracerFilters map[string]*gadgetv1alpha1.ContainerFilter
 }
 
 func updateTraceStatus(ctx context.Context, cli client.Client,
@@ -137,6 +141,7 @@ func (r *TraceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 					// Print error message but don't try again later
 					log.Errorf("Failed to delete tracer BPF map: %s", err)
 				}
+				delete(r.tracerFilters, gadgets.TraceNameFromNamespacedName(req.NamespacedName))
 			}
 
 			// Remove our finalizer
@@ -185,16 +190,27 @@ func (r *TraceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		return ctrl.Result{}, err
 	}
 
-	// Register tracer
+	// Register tracer. If it's already registered and the filter of the trace
+	// changed, update the containers of the tracer BPF map, which doesn't
+	// require to restart the running gadget.
 	if r.TracerManager != nil {
-		err = r.TracerManager.AddTracer(
-			gadgets.TraceNameFromNamespacedName(req.NamespacedName),
-			*gadgets.ContainerSelectorFromContainerFilter(trace.Spec.Filter),
-		)
-		if err != nil && !errors.Is(err, os.ErrExist) {
+		tracerID := gadgets.TraceNameFromNamespacedName(req.NamespacedName)
+		containerSelector := *gadgets.ContainerSelectorFromContainerFilter(trace.Spec.Filter)
+		err = r.TracerManager.AddTracer(tracerID, containerSelector)
+		if errors.Is(err, os.ErrExist) {
+			err = nil
+			if filter, ok := r.tracerFilters[tracerID]; !ok || !apiequality.Semantic.DeepEqual(filter, trace.Spec.Filter) {
+				err = r.TracerManager.UpdateTracer(tracerID, containerSelector)
+			}
+		}
+		if err != nil {
 			log.Errorf("Failed to add tracer BPF map: %s", err)
 			return ctrl.Result{}, err
 		}
+		if r.tracerFilters == nil {
+			r.tracerFilters = make(map[string]*gadgetv1alpha1.ContainerFilter)
+		}
+		r.tracerFilters[tracerID] = trace.Spec.Filter.DeepCopy()
 	}
 
 	// Lookup annotations
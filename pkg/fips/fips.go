@@ -0,0 +1,24 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fips tells whether the binary was built to only use FIPS 140-2
+// validated cryptography.
+//
+// The binaries are built in this mode with GOEXPERIMENT=boringcrypto, see
+// "make FIPS=true". The crypto packages of the Go standard library then use
+// the BoringCrypto module, and importing this package restricts all the TLS
+// connections, to the Kubernetes API server, the gadget pods, the collectors
+// the sinks send the events to and the registries, to the FIPS approved
+// versions, cipher suites, curves and signature algorithms.
+package fips
@@ -17,6 +17,7 @@ package gadgetcollection
 import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets"
 	seccomp "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/advise/seccomp"
+	workloadprofile "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/advise/workloadprofile"
 	auditseccomp "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/audit/seccomp"
 	biolatency "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/profile/block-io"
 	profile "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/profile/cpu"
@@ -68,5 +69,6 @@ func TraceFactories() map[string]gadgets.TraceFactory {
 		"tcptop":            tcptop.NewFactory(),
 		"tcptracer":         tcptracer.NewFactory(),
 		"traceloop":         traceloop.NewFactory(),
+		"workload-profile":  workloadprofile.NewFactory(),
 	}
 }
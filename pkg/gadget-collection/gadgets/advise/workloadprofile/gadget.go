@@ -0,0 +1,632 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workloadprofile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	k8syaml "sigs.k8s.io/yaml"
+
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection/networktracer"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace"
+	networkgraph "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-collection/gadgets/trace/network"
+	seccomptracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/advise/seccomp/tracer"
+	capabilitiestracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/tracer"
+	capabilitiesTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
+	netTracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/tracer"
+	netTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/types"
+	standardcapabilities "github.com/inspektor-gadget/inspektor-gadget/pkg/standardgadgets/trace/capabilities"
+)
+
+type Trace struct {
+	helpers gadgets.GadgetHelpers
+	client  client.Client
+
+	started bool
+	done    chan bool
+	wg      sync.WaitGroup
+
+	syscallTracer      *seccomptracer.Tracer
+	capabilitiesTracer trace.Tracer
+	networkTracer      *netTracer.Tracer
+	conn               *networktracer.ConnectionToContainerCollection
+	enricher           *networkgraph.Enricher
+
+	mu sync.Mutex
+	// capabilities used by each mount namespace
+	capabilities map[uint64]map[string]struct{}
+	// peers of each pod, indexed by namespace/podname
+	peers map[string]map[string]gadgetv1alpha1.NetworkPeer
+
+	// profileGenerated is used to know if there was a profile generated
+	// at container termination so that the Generate() operation does not
+	// have to notify that it did not find a pod that matches the filter.
+	profileGenerated bool
+}
+
+type TraceFactory struct {
+	gadgets.BaseFactory
+}
+
+func NewFactory() gadgets.TraceFactory {
+	return &TraceFactory{
+		BaseFactory: gadgets.BaseFactory{DeleteTrace: deleteTrace},
+	}
+}
+
+func (f *TraceFactory) Description() string {
+	return `The workload-profile gadget learns the behaviour of the pods of a workload:
+the system calls and the capabilities used by each container, and the network
+peers the pods communicate with. The profiles are written in WorkloadProfile
+custom resources that admission controllers and policy engines like Kyverno or
+OPA Gatekeeper can query to enforce what was observed.
+
+The profiles can be generated in two ways:
+1. on demand with the gadget.kinvolk.io/operation=generate annotation. In this
+   case, the Trace.Spec.Filter should specify the namespace and pod name to the
+   exclusion of other fields. The on-demand generation supports the outputMode
+   Status and ExternalResource.
+2. automatically when containers matching the Trace.Spec.Filter terminate. In
+   this case, all filters are supported. The at-termination generation only
+   supports the outputMode ExternalResource.
+
+All the pods of a workload share the same WorkloadProfile, created in the
+namespace of the pods and named after the workload, e.g. deployment-nginx. The
+name can be set with Trace.Spec.Output. When the profile already exists, what
+was learned is added to it, so the pods running on different nodes contribute
+to the same profile.
+
+WorkloadProfiles will have the following annotations:
+
+* workloadprofile.gadget.kinvolk.io/trace: the namespaced name of the Trace
+  custom resource that last updated this WorkloadProfile
+* workloadprofile.gadget.kinvolk.io/node: the node where this WorkloadProfile
+  was last updated
+
+WorkloadProfiles will have the same labels as the Trace custom resource that
+generated them.
+`
+}
+
+func (f *TraceFactory) OutputModesSupported() map[gadgetv1alpha1.TraceOutputMode]struct{} {
+	return map[gadgetv1alpha1.TraceOutputMode]struct{}{
+		gadgetv1alpha1.TraceOutputModeStatus:           {},
+		gadgetv1alpha1.TraceOutputModeExternalResource: {},
+	}
+}
+
+func deleteTrace(name string, t interface{}) {
+	trace := t.(*Trace)
+	if trace.started {
+		trace.helpers.Unsubscribe(genPubSubKey(name))
+		trace.stop()
+	}
+}
+
+func (f *TraceFactory) Operations() map[gadgetv1alpha1.Operation]gadgets.TraceOperation {
+	n := func() interface{} {
+		return &Trace{
+			client:  f.Client,
+			helpers: f.Helpers,
+		}
+	}
+	return map[gadgetv1alpha1.Operation]gadgets.TraceOperation{
+		gadgetv1alpha1.OperationStart: {
+			Doc: "Start learning the workload profiles",
+			Operation: func(name string, trace *gadgetv1alpha1.Trace) {
+				f.LookupOrCreate(name, n).(*Trace).Start(trace)
+			},
+			Order: 1,
+		},
+		gadgetv1alpha1.OperationGenerate: {
+			Doc: `Generate the profile of the workload of the pod specified in
+Trace.Spec.Filter. The namespace and pod name should be specified at the
+exclusion of other fields.`,
+			Operation: func(name string, trace *gadgetv1alpha1.Trace) {
+				f.LookupOrCreate(name, n).(*Trace).Generate(trace)
+			},
+			Order: 2,
+		},
+		gadgetv1alpha1.OperationStop: {
+			Doc: "Stop learning the workload profiles",
+			Operation: func(name string, trace *gadgetv1alpha1.Trace) {
+				f.LookupOrCreate(name, n).(*Trace).Stop(trace)
+			},
+			Order: 3,
+		},
+	}
+}
+
+type pubSubKey string
+
+func genPubSubKey(name string) pubSubKey {
+	return pubSubKey(fmt.Sprintf("gadget/workload-profile/%s", name))
+}
+
+func podKey(namespace, podname string) string {
+	return namespace + "/" + podname
+}
+
+func (t *Trace) Start(trace *gadgetv1alpha1.Trace) {
+	trace.Status.Output = ""
+	if t.started {
+		trace.Status.State = gadgetv1alpha1.TraceStateStarted
+		t.profileGenerated = false
+		return
+	}
+
+	traceName := gadgets.TraceName(trace.ObjectMeta.Namespace, trace.ObjectMeta.Name)
+
+	t.capabilities = make(map[uint64]map[string]struct{})
+	t.peers = make(map[string]map[string]gadgetv1alpha1.NetworkPeer)
+
+	var err error
+	t.syscallTracer, err = seccomptracer.NewTracer()
+	if err != nil {
+		trace.Status.OperationError = fmt.Sprintf("Failed to start seccomp tracer: %s", err)
+		return
+	}
+
+	mountNsMap, err := t.helpers.TracerMountNsMap(traceName)
+	if err != nil {
+		trace.Status.OperationError = fmt.Sprintf("failed to find tracer's mount ns map: %s", err)
+		t.stop()
+		return
+	}
+	capabilitiesConfig := &capabilitiestracer.Config{
+		MountnsMap: mountNsMap,
+		AuditOnly:  true,
+		Unique:     true,
+	}
+	coreTracer, err := capabilitiestracer.NewTracer(capabilitiesConfig, t.helpers, t.capabilityChecked)
+	if err == nil {
+		t.capabilitiesTracer = coreTracer
+	} else {
+		// fallback to standard tracer
+		log.Infof("Gadget %s: falling back to standard capabilities tracer. CO-RE tracer failed: %s",
+			trace.Spec.Gadget, err)
+
+		standardTracer, err := standardcapabilities.NewTracer(capabilitiesConfig, t.capabilityChecked)
+		if err != nil {
+			trace.Status.OperationError = fmt.Sprintf("failed to create capabilities tracer: %s", err)
+			t.stop()
+			return
+		}
+		t.capabilitiesTracer = standardTracer
+	}
+
+	t.enricher, err = networkgraph.NewEnricher()
+	if err != nil {
+		trace.Status.OperationError = fmt.Sprintf("Failed to start network enricher: %s", err)
+		t.stop()
+		return
+	}
+	t.networkTracer, err = netTracer.NewTracer(t.helpers)
+	if err != nil {
+		trace.Status.OperationError = fmt.Sprintf("Failed to start network tracer: %s", err)
+		t.stop()
+		return
+	}
+
+	// The events are retrieved with Pop(), this callback only gets the
+	// errors attaching and detaching containers.
+	eventCallback := func(container *containercollection.Container, event *netTypes.Event) {
+		log.Warnf("Trace %s: network tracer on %s/%s: %s",
+			traceName, container.Namespace, container.Podname, event.Message)
+	}
+	config := &networktracer.ConnectToContainerCollectionConfig[netTypes.Event]{
+		Tracer:        t.networkTracer,
+		Resolver:      t.helpers,
+		Selector:      *gadgets.ContainerSelectorFromContainerFilter(trace.Spec.Filter),
+		EventCallback: eventCallback,
+		Base:          netTypes.Base,
+	}
+	t.conn, err = networktracer.ConnectToContainerCollection(config)
+	if err != nil {
+		trace.Status.OperationError = fmt.Sprintf("Failed to start network tracer: %s", err)
+		t.stop()
+		return
+	}
+
+	// 'trace' is owned by the controller and could be modified
+	// outside of the gadget control. Make a copy for the callback.
+	traceCopy := trace.DeepCopy()
+
+	// Subscribe to container creation and termination events. Termination
+	// is used to update the WorkloadProfile when a container terminates.
+	// Creation is used to fetch the owner reference of the containers to
+	// be sure this field is set when the container terminates.
+	containers := t.helpers.Subscribe(
+		genPubSubKey(trace.ObjectMeta.Namespace+"/"+trace.ObjectMeta.Name),
+		*gadgets.ContainerSelectorFromContainerFilter(trace.Spec.Filter),
+		func(event containercollection.PubSubEvent) {
+			switch event.Type {
+			case containercollection.EventTypeAddContainer:
+				getContainerOwnerReference(event.Container)
+			case containercollection.EventTypeRemoveContainer:
+				t.containerTerminated(traceCopy, event)
+			}
+		},
+	)
+	for _, container := range containers {
+		getContainerOwnerReference(container)
+	}
+
+	t.done = make(chan bool)
+	t.wg.Add(1)
+	go t.run()
+
+	t.started = true
+	t.profileGenerated = false
+
+	trace.Status.State = gadgetv1alpha1.TraceStateStarted
+}
+
+func getContainerOwnerReference(c *containercollection.Container) *metav1.OwnerReference {
+	ownerRef, err := c.GetOwnerReference()
+	if err != nil {
+		log.Warnf("Failed to get owner reference of %s/%s/%s: %s",
+			c.Namespace, c.Podname, c.Name, err)
+	}
+	return ownerRef
+}
+
+func (t *Trace) capabilityChecked(event *capabilitiesTypes.Event) {
+	if event.Verdict != "Allow" || event.CapName == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	caps, ok := t.capabilities[event.MountNsID]
+	if !ok {
+		caps = make(map[string]struct{})
+		t.capabilities[event.MountNsID] = caps
+	}
+	caps[strings.TrimPrefix(event.CapName, "CAP_")] = struct{}{}
+}
+
+func (t *Trace) run() {
+	defer t.wg.Done()
+	ticker := time.NewTicker(time.Second)
+	for {
+		select {
+		case <-t.done:
+			ticker.Stop()
+			return
+		case <-ticker.C:
+			t.updatePeers()
+		}
+	}
+}
+
+func (t *Trace) updatePeers() {
+	events, err := t.networkTracer.Pop()
+	if err != nil {
+		log.Errorf("Failed to read network BPF map: %s", err)
+		return
+	}
+	t.enricher.Enrich(events)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, event := range events {
+		peer, ok := peerFromEvent(event)
+		if !ok {
+			continue
+		}
+		key := podKey(event.Namespace, event.Pod)
+		peers, ok := t.peers[key]
+		if !ok {
+			peers = make(map[string]gadgetv1alpha1.NetworkPeer)
+			t.peers[key] = peers
+		}
+		peers[peerKey(&peer)] = peer
+	}
+}
+
+// podProfile returns the profile learned for the given containers of a pod.
+func (t *Trace) podProfile(
+	namespace, podname string,
+	mntnsByContainer map[string]uint64,
+	ownerReference *metav1.OwnerReference,
+) *gadgetv1alpha1.WorkloadProfileSpec {
+	spec := &gadgetv1alpha1.WorkloadProfileSpec{
+		Workload: workloadReference(ownerReference, podname),
+	}
+
+	for name, mntns := range mntnsByContainer {
+		c := gadgetv1alpha1.ContainerProfile{Name: name}
+
+		syscalls, err := t.syscallTracer.Peek(mntns)
+		if err != nil {
+			log.Debugf("peeking syscalls for mntns %d: %s", mntns, err)
+		}
+		c.Syscalls = syscalls
+
+		t.mu.Lock()
+		for capName := range t.capabilities[mntns] {
+			c.Capabilities = append(c.Capabilities, capName)
+		}
+		t.mu.Unlock()
+
+		mergeProfiles(spec, &gadgetv1alpha1.WorkloadProfileSpec{
+			Containers: []gadgetv1alpha1.ContainerProfile{c},
+		})
+	}
+
+	t.mu.Lock()
+	peers := &gadgetv1alpha1.WorkloadProfileSpec{}
+	for _, peer := range t.peers[podKey(namespace, podname)] {
+		peers.NetworkPeers = append(peers.NetworkPeers, peer)
+	}
+	t.mu.Unlock()
+	mergeProfiles(spec, peers)
+
+	return spec
+}
+
+// profileNsName returns the namespace and the name of the WorkloadProfile. If
+// the Trace.Spec.Output doesn't specify them, the WorkloadProfile is created
+// in the namespace of the pod and named after its workload.
+func profileNsName(traceOutputName, podNamespace string, workload gadgetv1alpha1.WorkloadReference) (string, string) {
+	if traceOutputName != "" {
+		parts := strings.SplitN(traceOutputName, "/", 2)
+		if len(parts) == 2 {
+			return parts[0], parts[1]
+		}
+		return podNamespace, traceOutputName
+	}
+	return podNamespace, profileName(workload)
+}
+
+// newWorkloadProfile returns a WorkloadProfile ready to be created.
+func newWorkloadProfile(
+	trace *gadgetv1alpha1.Trace,
+	podNamespace string,
+	spec *gadgetv1alpha1.WorkloadProfileSpec,
+) *gadgetv1alpha1.WorkloadProfile {
+	namespace, name := profileNsName(trace.Spec.Output, podNamespace, spec.Workload)
+
+	r := &gadgetv1alpha1.WorkloadProfile{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: gadgetv1alpha1.SchemeGroupVersion.String(),
+			Kind:       "WorkloadProfile",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   namespace,
+			Annotations: map[string]string{},
+			Labels:      map[string]string{},
+		},
+		Spec: *spec,
+	}
+	workloadProfileAddLabelsAndAnnotations(r, trace)
+
+	return r
+}
+
+func workloadProfileAddLabelsAndAnnotations(r *gadgetv1alpha1.WorkloadProfile, trace *gadgetv1alpha1.Trace) {
+	if r.ObjectMeta.Annotations == nil {
+		r.ObjectMeta.Annotations = map[string]string{}
+	}
+	if r.ObjectMeta.Labels == nil {
+		r.ObjectMeta.Labels = map[string]string{}
+	}
+
+	traceName := fmt.Sprintf("%s/%s", trace.ObjectMeta.Namespace, trace.ObjectMeta.Name)
+	r.ObjectMeta.Annotations["workloadprofile.gadget.kinvolk.io/trace"] = traceName
+	r.ObjectMeta.Annotations["workloadprofile.gadget.kinvolk.io/node"] = trace.Spec.Node
+
+	// Copy labels from the trace into the WorkloadProfile. This will allow
+	// the CLI to add a label on the trace and gather its output
+	for key, value := range trace.ObjectMeta.Labels {
+		r.ObjectMeta.Labels[key] = value
+	}
+}
+
+// writeWorkloadProfile creates the WorkloadProfile or adds what was learned to
+// the existing one. The gadget runs on all the nodes, so several instances
+// could be updating the same WorkloadProfile at the same time.
+func (t *Trace) writeWorkloadProfile(r *gadgetv1alpha1.WorkloadProfile, trace *gadgetv1alpha1.Trace) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		existing := &gadgetv1alpha1.WorkloadProfile{}
+		err := t.client.Get(context.TODO(), client.ObjectKeyFromObject(r), existing)
+		if apierrors.IsNotFound(err) {
+			err = t.client.Create(context.TODO(), r)
+			if apierrors.IsAlreadyExists(err) {
+				// Created in the meantime by another node, retry
+				// to update it instead.
+				return apierrors.NewConflict(gadgetv1alpha1.SchemeGroupVersion.WithResource("workloadprofiles").GroupResource(), r.Name, err)
+			}
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		mergeProfiles(&existing.Spec, &r.Spec)
+		workloadProfileAddLabelsAndAnnotations(existing, trace)
+		return t.client.Update(context.TODO(), existing)
+	})
+}
+
+// containerTerminated is a callback called every time a container is
+// terminated on the node. It is used to update the WorkloadProfile when a
+// container terminates.
+func (t *Trace) containerTerminated(trace *gadgetv1alpha1.Trace, event containercollection.PubSubEvent) {
+	if event.Container.Mntns == 0 {
+		log.Errorf("Container has unknown mntns")
+		return
+	}
+
+	traceName := fmt.Sprintf("%s/%s", trace.ObjectMeta.Namespace, trace.ObjectMeta.Name)
+	namespacedName := podKey(event.Container.Namespace, event.Container.Podname)
+
+	if trace.Spec.OutputMode == gadgetv1alpha1.TraceOutputModeExternalResource {
+		// This field was fetched when the container was created
+		ownerReference := getContainerOwnerReference(event.Container)
+
+		spec := t.podProfile(event.Container.Namespace, event.Container.Podname,
+			map[string]uint64{event.Container.Name: event.Container.Mntns}, ownerReference)
+		r := newWorkloadProfile(trace, event.Container.Namespace, spec)
+
+		log.Infof("Trace %s: updating WorkloadProfile %s/%s for pod %s",
+			traceName, r.Namespace, r.Name, namespacedName)
+		if err := t.writeWorkloadProfile(r, trace); err != nil {
+			log.Errorf("Failed to update WorkloadProfile for pod %s: %s", namespacedName, err)
+		} else {
+			t.profileGenerated = true
+		}
+	}
+
+	// The container has terminated. Cleanup what was learned about it.
+	t.syscallTracer.Delete(event.Container.Mntns)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.capabilities, event.Container.Mntns)
+	if len(t.helpers.LookupMntnsByPod(event.Container.Namespace, event.Container.Podname)) == 0 {
+		delete(t.peers, namespacedName)
+	}
+}
+
+func (t *Trace) Generate(trace *gadgetv1alpha1.Trace) {
+	if !t.started {
+		trace.Status.OperationError = "Not started"
+		return
+	}
+	if trace.Spec.Filter == nil || trace.Spec.Filter.Namespace == "" || trace.Spec.Filter.Podname == "" {
+		trace.Status.OperationError = "Missing pod"
+		return
+	}
+	if len(trace.Spec.Filter.Labels) != 0 {
+		trace.Status.OperationError = "Workload profile gadget does not support filtering by labels"
+		return
+	}
+
+	mntnsByContainer := t.helpers.LookupMntnsByPod(
+		trace.Spec.Filter.Namespace,
+		trace.Spec.Filter.Podname,
+	)
+	if trace.Spec.Filter.ContainerName != "" {
+		mntns, ok := mntnsByContainer[trace.Spec.Filter.ContainerName]
+		if ok {
+			mntnsByContainer = map[string]uint64{trace.Spec.Filter.ContainerName: mntns}
+		} else {
+			mntnsByContainer = nil
+		}
+	}
+	if len(mntnsByContainer) == 0 {
+		// Notify this only if the profile was not already generated at container termination
+		if !t.profileGenerated {
+			trace.Status.OperationWarning = fmt.Sprintf("Pod %s/%s not found",
+				trace.Spec.Filter.Namespace,
+				trace.Spec.Filter.Podname,
+			)
+		}
+		return
+	}
+
+	var ownerReference *metav1.OwnerReference
+	for _, mntns := range mntnsByContainer {
+		ownerReference = t.helpers.LookupOwnerReferenceByMntns(mntns)
+		break
+	}
+
+	spec := t.podProfile(trace.Spec.Filter.Namespace, trace.Spec.Filter.Podname,
+		mntnsByContainer, ownerReference)
+	r := newWorkloadProfile(trace, trace.Spec.Filter.Namespace, spec)
+
+	switch trace.Spec.OutputMode {
+	case gadgetv1alpha1.TraceOutputModeStatus:
+		output, err := k8syaml.Marshal(r)
+		if err != nil {
+			trace.Status.OperationError = fmt.Sprintf("Failed to marshal WorkloadProfile: %s", err)
+			return
+		}
+
+		trace.Status.Output = string(output)
+	case gadgetv1alpha1.TraceOutputModeExternalResource:
+		if err := t.writeWorkloadProfile(r, trace); err != nil {
+			trace.Status.OperationError = fmt.Sprintf("Failed to update resource: %s", err)
+			return
+		}
+	default:
+		trace.Status.OperationError = fmt.Sprintf("OutputMode not supported: %s", trace.Spec.OutputMode)
+	}
+}
+
+func (t *Trace) Stop(trace *gadgetv1alpha1.Trace) {
+	if !t.started {
+		trace.Status.OperationError = "Not started"
+		return
+	}
+
+	t.helpers.Unsubscribe(genPubSubKey(trace.ObjectMeta.Namespace + "/" + trace.ObjectMeta.Name))
+	t.stop()
+
+	trace.Status.State = gadgetv1alpha1.TraceStateStopped
+}
+
+// stop releases the tracers. It's also used to clean up when Start() fails
+// half way.
+func (t *Trace) stop() {
+	if t.conn != nil {
+		t.conn.Close()
+		t.conn = nil
+	}
+
+	if t.done != nil {
+		// tell run() to stop using t.networkTracer
+		t.done <- true
+		// wait for run() to end before closing t.networkTracer and t.enricher
+		t.wg.Wait()
+		t.done = nil
+	}
+
+	if t.networkTracer != nil {
+		t.networkTracer.Close()
+		t.networkTracer = nil
+	}
+	if t.enricher != nil {
+		t.enricher.Close()
+		t.enricher = nil
+	}
+	if t.capabilitiesTracer != nil {
+		t.capabilitiesTracer.Stop()
+		t.capabilitiesTracer = nil
+	}
+	if t.syscallTracer != nil {
+		t.syscallTracer.Close()
+		t.syscallTracer = nil
+	}
+
+	t.started = false
+}
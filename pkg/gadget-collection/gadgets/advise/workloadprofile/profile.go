@@ -0,0 +1,187 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workloadprofile
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	netTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// Labels that change each time a workload is rolled out. Keeping them would
+// make the peers learned before and after a rollout look different.
+var labelsToIgnore = map[string]struct{}{
+	"controller-revision-hash": {},
+	"pod-template-generation":  {},
+	"pod-template-hash":        {},
+}
+
+// workloadReference returns the workload owning a pod: its highest owner
+// reference, or the pod itself when it doesn't have any.
+func workloadReference(ownerReference *metav1.OwnerReference, podName string) gadgetv1alpha1.WorkloadReference {
+	if ownerReference == nil {
+		return gadgetv1alpha1.WorkloadReference{
+			APIVersion: "v1",
+			Kind:       "Pod",
+			Name:       podName,
+		}
+	}
+	return gadgetv1alpha1.WorkloadReference{
+		APIVersion: ownerReference.APIVersion,
+		Kind:       ownerReference.Kind,
+		Name:       ownerReference.Name,
+	}
+}
+
+// profileName returns the name of the WorkloadProfile of a workload, e.g.
+// deployment-nginx. All the pods of a workload share the same profile, so
+// they are enforced the same way.
+func profileName(workload gadgetv1alpha1.WorkloadReference) string {
+	return fmt.Sprintf("%s-%s", strings.ToLower(workload.Kind), workload.Name)
+}
+
+// mergeStrings returns the sorted union of a and b without duplicates.
+func mergeStrings(a, b []string) []string {
+	set := make(map[string]struct{}, len(a)+len(b))
+	for _, s := range a {
+		set[s] = struct{}{}
+	}
+	for _, s := range b {
+		set[s] = struct{}{}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+
+	ret := make([]string, 0, len(set))
+	for s := range set {
+		ret = append(ret, s)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// peerKey identifies a peer. Services are identified by their name and pods by
+// their labels, as their names change each time they are recreated.
+func peerKey(p *gadgetv1alpha1.NetworkPeer) string {
+	key := fmt.Sprintf("%s/%s/%d/%s/%s/%s/%s",
+		p.Direction, p.Protocol, p.Port, p.Kind, p.Namespace, p.Name, p.Address)
+	if p.Kind != string(eventtypes.RemoteKindPod) {
+		return key
+	}
+
+	labels := make([]string, 0, len(p.Labels))
+	for k, v := range p.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	return key + "/" + strings.Join(labels, ",")
+}
+
+// peerFromEvent converts an event of the network tracer into a peer. It
+// returns false for the events that don't come from a pod or whose direction
+// is unknown.
+func peerFromEvent(event *netTypes.Event) (gadgetv1alpha1.NetworkPeer, bool) {
+	if event.Pod == "" {
+		return gadgetv1alpha1.NetworkPeer{}, false
+	}
+
+	peer := gadgetv1alpha1.NetworkPeer{
+		Protocol: event.Proto,
+		Port:     event.Port,
+		Kind:     string(event.RemoteKind),
+	}
+
+	switch event.PktType {
+	case "HOST":
+		peer.Direction = gadgetv1alpha1.NetworkPeerDirectionIngress
+	case "OUTGOING":
+		peer.Direction = gadgetv1alpha1.NetworkPeerDirectionEgress
+	default:
+		return gadgetv1alpha1.NetworkPeer{}, false
+	}
+
+	switch event.RemoteKind {
+	case eventtypes.RemoteKindPod:
+		peer.Namespace = event.RemoteNamespace
+		for k, v := range event.RemoteLabels {
+			if _, ok := labelsToIgnore[k]; ok {
+				continue
+			}
+			if peer.Labels == nil {
+				peer.Labels = make(map[string]string)
+			}
+			peer.Labels[k] = v
+		}
+	case eventtypes.RemoteKindService:
+		peer.Namespace = event.RemoteNamespace
+		peer.Name = event.RemoteName
+		peer.Labels = event.RemoteLabels
+	default:
+		peer.Address = event.RemoteAddr
+	}
+
+	return peer, true
+}
+
+// mergeProfiles merges the containers and peers of src into dst. The
+// syscalls, capabilities and peers of dst are never removed: a profile only
+// grows while the workload is observed.
+func mergeProfiles(dst, src *gadgetv1alpha1.WorkloadProfileSpec) {
+	if dst.Workload.Name == "" {
+		dst.Workload = src.Workload
+	}
+
+	for _, c := range src.Containers {
+		found := false
+		for i := range dst.Containers {
+			if dst.Containers[i].Name != c.Name {
+				continue
+			}
+			dst.Containers[i].Syscalls = mergeStrings(dst.Containers[i].Syscalls, c.Syscalls)
+			dst.Containers[i].Capabilities = mergeStrings(dst.Containers[i].Capabilities, c.Capabilities)
+			found = true
+			break
+		}
+		if !found {
+			dst.Containers = append(dst.Containers, *c.DeepCopy())
+		}
+	}
+	sort.Slice(dst.Containers, func(i, j int) bool {
+		return dst.Containers[i].Name < dst.Containers[j].Name
+	})
+
+	known := make(map[string]struct{}, len(dst.NetworkPeers))
+	for i := range dst.NetworkPeers {
+		known[peerKey(&dst.NetworkPeers[i])] = struct{}{}
+	}
+	for _, p := range src.NetworkPeers {
+		key := peerKey(&p)
+		if _, ok := known[key]; ok {
+			continue
+		}
+		known[key] = struct{}{}
+		dst.NetworkPeers = append(dst.NetworkPeers, *p.DeepCopy())
+	}
+	sort.Slice(dst.NetworkPeers, func(i, j int) bool {
+		return peerKey(&dst.NetworkPeers[i]) < peerKey(&dst.NetworkPeers[j])
+	})
+}
@@ -0,0 +1,186 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package workloadprofile
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	gadgetv1alpha1 "github.com/inspektor-gadget/inspektor-gadget/pkg/apis/gadget/v1alpha1"
+	netTypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+func TestProfileName(t *testing.T) {
+	ownerReference := &metav1.OwnerReference{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Name:       "nginx",
+	}
+	workload := workloadReference(ownerReference, "nginx-6799fc88d8-2tk7s")
+	if name := profileName(workload); name != "deployment-nginx" {
+		t.Fatalf("Invalid profile name %q for %+v. Expecting %q", name, workload, "deployment-nginx")
+	}
+
+	// Pods without owner have their own profile
+	workload = workloadReference(nil, "mypod")
+	expected := gadgetv1alpha1.WorkloadReference{APIVersion: "v1", Kind: "Pod", Name: "mypod"}
+	if workload != expected {
+		t.Fatalf("Invalid workload %+v. Expecting %+v", workload, expected)
+	}
+	if name := profileName(workload); name != "pod-mypod" {
+		t.Fatalf("Invalid profile name %q for %+v. Expecting %q", name, workload, "pod-mypod")
+	}
+}
+
+func TestProfileNsName(t *testing.T) {
+	workload := gadgetv1alpha1.WorkloadReference{Kind: "StatefulSet", Name: "redis"}
+
+	table := []struct {
+		output            string
+		expectedNamespace string
+		expectedName      string
+	}{
+		{"", "default", "statefulset-redis"},
+		{"myprofile", "default", "myprofile"},
+		{"profiles/myprofile", "profiles", "myprofile"},
+	}
+	for _, entry := range table {
+		namespace, name := profileNsName(entry.output, "default", workload)
+		if namespace != entry.expectedNamespace || name != entry.expectedName {
+			t.Fatalf("Invalid profile %s/%s for output %q. Expecting %s/%s",
+				namespace, name, entry.output, entry.expectedNamespace, entry.expectedName)
+		}
+	}
+}
+
+func TestPeerFromEvent(t *testing.T) {
+	event := &netTypes.Event{
+		Event: eventtypes.Event{
+			CommonData: eventtypes.CommonData{Namespace: "default", Pod: "nginx-6799fc88d8-2tk7s"},
+		},
+		PktType:         "OUTGOING",
+		Proto:           "tcp",
+		Port:            6379,
+		RemoteKind:      eventtypes.RemoteKindPod,
+		RemoteAddr:      "10.244.0.12",
+		RemoteName:      "redis-0",
+		RemoteNamespace: "db",
+		RemoteLabels:    map[string]string{"app": "redis", "controller-revision-hash": "redis-7d5b5f8c6d"},
+	}
+	peer, ok := peerFromEvent(event)
+	if !ok {
+		t.Fatalf("Event %+v was ignored", event)
+	}
+	expected := gadgetv1alpha1.NetworkPeer{
+		Direction: gadgetv1alpha1.NetworkPeerDirectionEgress,
+		Protocol:  "tcp",
+		Port:      6379,
+		Kind:      "pod",
+		Namespace: "db",
+		Labels:    map[string]string{"app": "redis"},
+	}
+	if !reflect.DeepEqual(peer, expected) {
+		t.Fatalf("Invalid peer %+v. Expecting %+v", peer, expected)
+	}
+
+	event = &netTypes.Event{
+		Event: eventtypes.Event{
+			CommonData: eventtypes.CommonData{Namespace: "default", Pod: "nginx-6799fc88d8-2tk7s"},
+		},
+		PktType:    "HOST",
+		Proto:      "tcp",
+		Port:       80,
+		RemoteKind: eventtypes.RemoteKindOther,
+		RemoteAddr: "192.168.1.10",
+	}
+	peer, ok = peerFromEvent(event)
+	if !ok {
+		t.Fatalf("Event %+v was ignored", event)
+	}
+	expected = gadgetv1alpha1.NetworkPeer{
+		Direction: gadgetv1alpha1.NetworkPeerDirectionIngress,
+		Protocol:  "tcp",
+		Port:      80,
+		Kind:      "other",
+		Address:   "192.168.1.10",
+	}
+	if !reflect.DeepEqual(peer, expected) {
+		t.Fatalf("Invalid peer %+v. Expecting %+v", peer, expected)
+	}
+
+	// Events on the host network namespace are ignored
+	event.Pod = ""
+	if _, ok := peerFromEvent(event); ok {
+		t.Fatalf("Event %+v on the host was not ignored", event)
+	}
+}
+
+func TestMergeProfiles(t *testing.T) {
+	redis := gadgetv1alpha1.NetworkPeer{
+		Direction: gadgetv1alpha1.NetworkPeerDirectionEgress,
+		Protocol:  "tcp",
+		Port:      6379,
+		Kind:      "pod",
+		Namespace: "db",
+		Labels:    map[string]string{"app": "redis"},
+	}
+	dns := gadgetv1alpha1.NetworkPeer{
+		Direction: gadgetv1alpha1.NetworkPeerDirectionEgress,
+		Protocol:  "udp",
+		Port:      53,
+		Kind:      "svc",
+		Namespace: "kube-system",
+		Name:      "kube-dns",
+	}
+
+	dst := &gadgetv1alpha1.WorkloadProfileSpec{
+		Workload: gadgetv1alpha1.WorkloadReference{Kind: "Deployment", Name: "nginx"},
+		Containers: []gadgetv1alpha1.ContainerProfile{
+			{Name: "nginx", Syscalls: []string{"read", "write"}, Capabilities: []string{"NET_BIND_SERVICE"}},
+		},
+		NetworkPeers: []gadgetv1alpha1.NetworkPeer{redis},
+	}
+	// Learned on another node
+	src := &gadgetv1alpha1.WorkloadProfileSpec{
+		Workload: gadgetv1alpha1.WorkloadReference{Kind: "Deployment", Name: "nginx"},
+		Containers: []gadgetv1alpha1.ContainerProfile{
+			{Name: "sidecar", Syscalls: []string{"read"}},
+			{Name: "nginx", Syscalls: []string{"accept4", "read"}, Capabilities: []string{"CHOWN"}},
+		},
+		NetworkPeers: []gadgetv1alpha1.NetworkPeer{dns, redis},
+	}
+	mergeProfiles(dst, src)
+
+	expected := &gadgetv1alpha1.WorkloadProfileSpec{
+		Workload: gadgetv1alpha1.WorkloadReference{Kind: "Deployment", Name: "nginx"},
+		Containers: []gadgetv1alpha1.ContainerProfile{
+			{Name: "nginx", Syscalls: []string{"accept4", "read", "write"}, Capabilities: []string{"CHOWN", "NET_BIND_SERVICE"}},
+			{Name: "sidecar", Syscalls: []string{"read"}},
+		},
+		NetworkPeers: []gadgetv1alpha1.NetworkPeer{redis, dns},
+	}
+	if !reflect.DeepEqual(dst, expected) {
+		t.Fatalf("Invalid merged profile %+v. Expecting %+v", dst, expected)
+	}
+
+	// Merging again doesn't change anything
+	mergeProfiles(dst, src)
+	if !reflect.DeepEqual(dst, expected) {
+		t.Fatalf("Merging twice changed the profile to %+v. Expecting %+v", dst, expected)
+	}
+}
@@ -20,6 +20,8 @@ package gadgetcontext
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"time"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
@@ -47,6 +49,9 @@ type GadgetContext struct {
 	result                   []byte
 	resultError              error
 	timeout                  time.Duration
+
+	paramsUpdaterLock sync.Mutex
+	paramsUpdater     func(map[string]string) error
 }
 
 func New(
@@ -111,6 +116,12 @@ func (c *GadgetContext) Operators() operators.Operators {
 	return c.operators
 }
 
+// SetOperators replaces the operators run with the gadget, by default all the
+// ones that can operate on it, e.g. to run it without the sinks
+func (c *GadgetContext) SetOperators(ops operators.Operators) {
+	c.operators = ops
+}
+
 func (c *GadgetContext) Logger() logger.Logger {
 	return c.logger
 }
@@ -127,6 +138,27 @@ func (c *GadgetContext) Timeout() time.Duration {
 	return c.timeout
 }
 
+// SetParamsUpdater is called by the runtime with the function changing the
+// params of the running gadget, or nil once the gadget is done
+func (c *GadgetContext) SetParamsUpdater(updater func(map[string]string) error) {
+	c.paramsUpdaterLock.Lock()
+	defer c.paramsUpdaterLock.Unlock()
+	c.paramsUpdater = updater
+}
+
+// UpdateParams changes the params of the running gadget and its operators
+// without restarting it. The params use the same keys as the ones sent to the
+// gadget service: the ones of the operators are prefixed with "operator." and
+// the name of the operator.
+func (c *GadgetContext) UpdateParams(params map[string]string) error {
+	c.paramsUpdaterLock.Lock()
+	defer c.paramsUpdaterLock.Unlock()
+	if c.paramsUpdater == nil {
+		return errors.New("gadget is not running")
+	}
+	return c.paramsUpdater(params)
+}
+
 func WithTimeoutOrCancel(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
 	if timeout == 0 {
 		return context.WithCancel(ctx)
@@ -19,6 +19,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"strconv"
 	"sync"
 	"time"
 
@@ -35,8 +36,24 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime/local"
 
 	// TODO: Move!
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/alertmanager"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/azuremonitor"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/cloudlogging"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/cloudwatch"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/fluentforward"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/host"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/join"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/journald"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/kubeaudit"
 	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/kubeipresolver"
 	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/kubemanager"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/nodemetadata"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/otel"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/s3"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/sbom"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/severity"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/systemd"
+	_ "github.com/inspektor-gadget/inspektor-gadget/pkg/operators/vulnerabilities"
 )
 
 type Config struct {
@@ -86,6 +103,16 @@ func (s *Service) RunGadget(runGadget pb.GadgetManager_RunGadgetServer) error {
 		return fmt.Errorf("expected first control message to be gadget request")
 	}
 
+	// Give the time of the node as soon as possible, the client estimates the
+	// offset of the clocks from the time it took to get it
+	err = runGadget.Send(&pb.GadgetEvent{
+		Type:    pb.EventTypeGadgetClock,
+		Payload: []byte(strconv.FormatInt(time.Now().UnixNano(), 10)),
+	})
+	if err != nil {
+		return err
+	}
+
 	// Create a new logger that logs to gRPC and falls back to the standard logger when it failed to send the message
 	logger := logger.NewFromGenericLogger(&Logger{
 		send:           runGadget.Send,
@@ -223,6 +250,13 @@ func (s *Service) RunGadget(runGadget pb.GadgetManager_RunGadgetServer) error {
 			case *pb.GadgetControlRequest_StopRequest:
 				gadgetCtx.Cancel()
 				return
+			case *pb.GadgetControlRequest_UpdateRequest:
+				err := gadgetCtx.UpdateParams(msg.GetUpdateRequest().Params)
+				if err != nil {
+					logger.Errorf("updating params: %v", err)
+					continue
+				}
+				logger.Infof("params updated")
 			default:
 				logger.Warn("unexpected request")
 			}
@@ -51,6 +51,14 @@ func (g *GadgetDesc) EventPrototype() any {
 	return nil
 }
 
+func (g *GadgetDesc) Cost() gadgets.Cost {
+	return gadgets.Cost{
+		Probes:    1,
+		Events:    "every syscall",
+		EventCost: gadgets.CostHigh,
+	}
+}
+
 func init() {
 	gadgetregistry.Register(&GadgetDesc{})
 }
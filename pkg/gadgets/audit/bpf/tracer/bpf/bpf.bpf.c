@@ -0,0 +1,88 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "bpf.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event *unusedevent __attribute__((unused));
+
+// The loads in progress, indexed by thread. The event is filled in when the
+// syscall starts and sent when it returns.
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__type(value, struct event);
+} loads SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, sizeof(__u32));
+} events SEC(".maps");
+
+SEC("tracepoint/syscalls/sys_enter_bpf")
+int ig_bpf_e(struct trace_event_raw_sys_enter *ctx)
+{
+	__u64 pid_tgid = bpf_get_current_pid_tgid();
+	__u64 mntns_id = gadget_get_mntns_id();
+	union bpf_attr *attr = (union bpf_attr *)ctx->args[1];
+	struct event event = {};
+	__u32 cmd = ctx->args[0];
+
+	if (cmd != BPF_PROG_LOAD && cmd != BPF_MAP_CREATE)
+		return 0;
+
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return 0;
+
+	event.mntns_id = mntns_id;
+	event.pid = pid_tgid >> 32;
+	event.tid = (__u32)pid_tgid;
+	event.uid = (__u32)bpf_get_current_uid_gid();
+	event.cmd = cmd;
+	bpf_get_current_comm(&event.task, sizeof(event.task));
+
+	// The attributes are read from the memory of the process, the reads
+	// can fail if it's invalid: the syscall then fails with EFAULT.
+	if (cmd == BPF_PROG_LOAD) {
+		bpf_probe_read_user(&event.type, sizeof(event.type), &attr->prog_type);
+		bpf_probe_read_user(&event.insn_cnt, sizeof(event.insn_cnt), &attr->insn_cnt);
+		bpf_probe_read_user(&event.name, sizeof(event.name), &attr->prog_name);
+	} else {
+		bpf_probe_read_user(&event.type, sizeof(event.type), &attr->map_type);
+		bpf_probe_read_user(&event.key_size, sizeof(event.key_size), &attr->key_size);
+		bpf_probe_read_user(&event.value_size, sizeof(event.value_size), &attr->value_size);
+		bpf_probe_read_user(&event.max_entries, sizeof(event.max_entries), &attr->max_entries);
+		bpf_probe_read_user(&event.name, sizeof(event.name), &attr->map_name);
+	}
+
+	bpf_map_update_elem(&loads, &event.tid, &event, BPF_ANY);
+	return 0;
+}
+
+SEC("tracepoint/syscalls/sys_exit_bpf")
+int ig_bpf_x(struct trace_event_raw_sys_exit *ctx)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	struct event *event;
+
+	event = bpf_map_lookup_elem(&loads, &tid);
+	if (!event)
+		return 0;
+
+	event->timestamp = bpf_ktime_get_boot_ns();
+	event->ret = ctx->ret;
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
+
+	bpf_map_delete_elem(&loads, &tid);
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
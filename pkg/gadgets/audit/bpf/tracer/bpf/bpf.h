@@ -0,0 +1,29 @@
+/* SPDX-License-Identifier: (LGPL-2.1 OR BSD-2-Clause) */
+#ifndef GADGET_AUDIT_BPF_H
+#define GADGET_AUDIT_BPF_H
+
+#define TASK_COMM_LEN	16
+#define BPF_OBJ_NAME_LEN	16
+
+struct event {
+	__u64 mntns_id;
+	__u64 timestamp;
+	__u32 pid;
+	__u32 tid;
+	__u32 uid;
+	// File descriptor of the program or map on success, -errno on failure
+	__s32 ret;
+	// BPF_PROG_LOAD or BPF_MAP_CREATE
+	__u32 cmd;
+	// Type of the program or the map
+	__u32 type;
+	// Number of instructions of the program
+	__u32 insn_cnt;
+	__u32 key_size;
+	__u32 value_size;
+	__u32 max_entries;
+	__u8 task[TASK_COMM_LEN];
+	__u8 name[BPF_OBJ_NAME_LEN];
+};
+
+#endif /* GADGET_AUDIT_BPF_H */
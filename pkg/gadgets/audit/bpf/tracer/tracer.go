@@ -0,0 +1,227 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+	"golang.org/x/sys/unix"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/audit/bpf/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -type event bpf ./bpf/bpf.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
+
+type Config struct {
+	MountnsMap *ebpf.Map
+}
+
+type Tracer struct {
+	config        *Config
+	enricher      gadgets.DataEnricherByMntNs
+	eventCallback func(*types.Event)
+
+	objs   bpfObjects
+	links  []link.Link
+	reader *perf.Reader
+}
+
+func NewTracer(config *Config, enricher gadgets.DataEnricherByMntNs,
+	eventCallback func(*types.Event),
+) (*Tracer, error) {
+	t := &Tracer{
+		config:        config,
+		enricher:      enricher,
+		eventCallback: eventCallback,
+	}
+
+	if err := t.install(); err != nil {
+		t.close()
+		return nil, err
+	}
+
+	go t.run()
+
+	return t, nil
+}
+
+// Stop stops the tracer
+// TODO: Remove after refactoring
+func (t *Tracer) Stop() {
+	t.close()
+}
+
+func (t *Tracer) close() {
+	for i, l := range t.links {
+		t.links[i] = gadgets.CloseLink(l)
+	}
+
+	if t.reader != nil {
+		t.reader.Close()
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadBpf()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	enter, err := link.Tracepoint("syscalls", "sys_enter_bpf", t.objs.IgBpfE, nil)
+	if err != nil {
+		return fmt.Errorf("attaching tracepoint sys_enter_bpf: %w", err)
+	}
+	t.links = append(t.links, enter)
+
+	exit, err := link.Tracepoint("syscalls", "sys_exit_bpf", t.objs.IgBpfX, nil)
+	if err != nil {
+		return fmt.Errorf("attaching tracepoint sys_exit_bpf: %w", err)
+	}
+	t.links = append(t.links, exit)
+
+	t.reader, err = perf.NewReader(t.objs.bpfMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	if err != nil {
+		return fmt.Errorf("creating perf ring buffer: %w", err)
+	}
+
+	return nil
+}
+
+var cmds = map[uint32]columns.Enum{
+	unix.BPF_PROG_LOAD:  {Name: "BPF_PROG_LOAD", Value: unix.BPF_PROG_LOAD},
+	unix.BPF_MAP_CREATE: {Name: "BPF_MAP_CREATE", Value: unix.BPF_MAP_CREATE},
+}
+
+// objectType returns the name of the type of a program or a map, e.g. Kprobe
+// or Hash, as named by the ebpf library
+func objectType(cmd uint32, typ uint32) columns.Enum {
+	if cmd == unix.BPF_PROG_LOAD {
+		return columns.Enum{Name: ebpf.ProgramType(typ).String(), Value: int64(typ)}
+	}
+	return columns.Enum{Name: ebpf.MapType(typ).String(), Value: int64(typ)}
+}
+
+func (t *Tracer) run() {
+	for {
+		record, err := t.reader.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				// nothing to do, we're done
+				return
+			}
+
+			msg := fmt.Sprintf("Error reading perf ring buffer: %s", err)
+			t.eventCallback(types.Base(eventtypes.Err(msg)))
+			return
+		}
+
+		if record.LostSamples > 0 {
+			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
+			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			continue
+		}
+
+		bpfEvent := (*bpfEvent)(unsafe.Pointer(&record.RawSample[0]))
+
+		event := types.Event{
+			Event: eventtypes.Event{
+				Type:      eventtypes.NORMAL,
+				Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+			},
+			WithMountNsID: eventtypes.WithMountNsID{MountNsID: bpfEvent.MntnsId},
+			Pid:           bpfEvent.Pid,
+			Tid:           bpfEvent.Tid,
+			Uid:           bpfEvent.Uid,
+			Comm:          gadgets.FromCString(bpfEvent.Task[:]),
+			Cmd:           cmds[bpfEvent.Cmd],
+			Type:          objectType(bpfEvent.Cmd, bpfEvent.Type),
+			Name:          gadgets.FromCString(bpfEvent.Name[:]),
+			KeySize:       bpfEvent.KeySize,
+			ValueSize:     bpfEvent.ValueSize,
+			MaxEntries:    bpfEvent.MaxEntries,
+		}
+
+		// Each instruction is 8 bytes
+		if bpfEvent.Cmd == unix.BPF_PROG_LOAD {
+			event.Size = bpfEvent.InsnCnt * 8
+		}
+
+		if bpfEvent.Ret >= 0 {
+			event.Fd = bpfEvent.Ret
+		}
+
+		if bpfEvent.Ret < 0 {
+			event.Err = unix.ErrnoName(syscall.Errno(-bpfEvent.Ret))
+		}
+
+		if t.enricher != nil {
+			t.enricher.EnrichByMntNs(&event.CommonData, event.MountNsID)
+		}
+
+		t.eventCallback(&event)
+	}
+}
+
+// --- Registry changes
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	go t.run()
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+	}
+	return tracer, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Pid  uint32 `json:"pid,omitempty" column:"pid,template:pid"`
+	Tid  uint32 `json:"tid,omitempty" column:"tid,template:pid,hide"`
+	Uid  uint32 `json:"uid" column:"uid,template:uid,hide"`
+	Comm string `json:"comm,omitempty" column:"comm,template:comm"`
+	// Cmd is BPF_PROG_LOAD or BPF_MAP_CREATE
+	Cmd columns.Enum `json:"cmd" column:"cmd,width:14"`
+	// Type is the type of the program or the map, e.g. Kprobe or Hash
+	Type columns.Enum `json:"type" column:"type,width:16"`
+	Name string       `json:"name,omitempty" column:"name,width:16"`
+	// Size is the size of the instructions of the program, in bytes
+	Size       uint32 `json:"size,omitempty" column:"size,width:8,align:right"`
+	KeySize    uint32 `json:"keySize,omitempty" column:"keysize,width:7,align:right,hide"`
+	ValueSize  uint32 `json:"valueSize,omitempty" column:"valuesize,width:9,align:right,hide"`
+	MaxEntries uint32 `json:"maxEntries,omitempty" column:"maxentries,width:10,align:right,hide"`
+	// Fd is the file descriptor of the program or the map on success
+	Fd  int32  `json:"fd,omitempty" column:"fd,width:3,fixed,hide"`
+	Err string `json:"err,omitempty" column:"err,width:8"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	return columns.MustCreateColumns[Event]()
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
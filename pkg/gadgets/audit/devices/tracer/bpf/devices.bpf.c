@@ -0,0 +1,338 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "devices.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+
+#define CAP_SYS_ADMIN	21
+
+#define S_IFMT	00170000
+#define S_IFBLK	0060000
+#define S_IFCHR	0020000
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event *unusedevent __attribute__((unused));
+
+// Arguments of the syscalls and functions, the pointers are stored as __u64
+// so bpf2go can generate the Go types of the maps
+struct args_t {
+	__u64 path;
+	__u32 major;
+	__u32 minor;
+	__u32 access;
+	__u32 cmd;
+	int fd;
+	enum dev_kind dev_type;
+	// Whether CAP_SYS_ADMIN was checked during the ioctl
+	bool sysadmin;
+};
+
+// One map by kind of operation: the device cgroup is checked during mknod and
+// the opens of block devices.
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__type(value, struct args_t);
+} mknod_start SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__type(value, struct args_t);
+} devcg_start SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__type(value, struct args_t);
+} open_start SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__type(value, struct args_t);
+} ioctl_start SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, __u32);
+	__type(value, struct event);
+} tmp_event SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, sizeof(__u32));
+} events SEC(".maps");
+
+static __always_inline __u32 current_tid(void)
+{
+	return (__u32)bpf_get_current_pid_tgid();
+}
+
+static __always_inline bool should_discard(void)
+{
+	return gadget_should_discard_mntns_id(gadget_get_mntns_id());
+}
+
+static __always_inline void store_args(void *map, struct args_t *args)
+{
+	__u32 tid = current_tid();
+
+	bpf_map_update_elem(map, &tid, args, BPF_ANY);
+}
+
+static __always_inline struct args_t *pop_args(void *map, struct args_t *out)
+{
+	__u32 tid = current_tid();
+	struct args_t *args;
+
+	args = bpf_map_lookup_elem(map, &tid);
+	if (!args)
+		return NULL;
+
+	__builtin_memcpy(out, args, sizeof(*out));
+	bpf_map_delete_elem(map, &tid);
+	return out;
+}
+
+static __always_inline void emit(void *ctx, struct args_t *args, enum event_type type,
+				 int ret, bool user_path)
+{
+	__u32 zero = 0;
+	struct event *event;
+
+	event = bpf_map_lookup_elem(&tmp_event, &zero);
+	if (!event)
+		return;
+
+	event->timestamp = bpf_ktime_get_boot_ns();
+	event->mntns_id = gadget_get_mntns_id();
+	event->pid = bpf_get_current_pid_tgid() >> 32;
+	event->uid = (__u32)bpf_get_current_uid_gid();
+	event->ret = ret;
+	event->major = args->major;
+	event->minor = args->minor;
+	event->access = args->access;
+	event->cmd = args->cmd;
+	event->dev_type = args->dev_type;
+	event->type = type;
+	bpf_get_current_comm(&event->comm, sizeof(event->comm));
+
+	event->path[0] = '\0';
+	if (args->path) {
+		if (user_path)
+			bpf_probe_read_user_str(&event->path, sizeof(event->path), (void *)args->path);
+		else
+			bpf_probe_read_kernel_str(&event->path, sizeof(event->path), (void *)args->path);
+	}
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
+}
+
+/* mknod(): only the creation of device nodes is reported */
+
+static __always_inline int mknod_entry(const char *path, __u32 mode, __u32 dev)
+{
+	struct args_t args = {};
+
+	if (should_discard())
+		return 0;
+
+	switch (mode & S_IFMT) {
+	case S_IFBLK:
+		args.dev_type = DEV_KIND_BLOCK;
+		break;
+	case S_IFCHR:
+		args.dev_type = DEV_KIND_CHAR;
+		break;
+	default:
+		return 0;
+	}
+
+	args.path = (__u64)path;
+	args.access = mode;
+	// new_decode_dev(), the device number is encoded as for userspace
+	args.major = (dev & 0xfff00) >> 8;
+	args.minor = (dev & 0xff) | ((dev >> 12) & 0xfff00);
+	store_args(&mknod_start, &args);
+	return 0;
+}
+
+static __always_inline int mknod_exit(struct trace_event_raw_sys_exit *ctx)
+{
+	struct args_t args;
+
+	if (!pop_args(&mknod_start, &args))
+		return 0;
+
+	emit(ctx, &args, DEVICES_EVENT_TYPE_MKNOD, (int)ctx->ret, true);
+	return 0;
+}
+
+SEC("tracepoint/syscalls/sys_enter_mknod")
+int ig_dev_mknod_e(struct trace_event_raw_sys_enter *ctx)
+{
+	return mknod_entry((const char *)ctx->args[0], (__u32)ctx->args[1], (__u32)ctx->args[2]);
+}
+
+SEC("tracepoint/syscalls/sys_exit_mknod")
+int ig_dev_mknod_x(struct trace_event_raw_sys_exit *ctx)
+{
+	return mknod_exit(ctx);
+}
+
+SEC("tracepoint/syscalls/sys_enter_mknodat")
+int ig_dev_mknodat_e(struct trace_event_raw_sys_enter *ctx)
+{
+	return mknod_entry((const char *)ctx->args[1], (__u32)ctx->args[2], (__u32)ctx->args[3]);
+}
+
+SEC("tracepoint/syscalls/sys_exit_mknodat")
+int ig_dev_mknodat_x(struct trace_event_raw_sys_exit *ctx)
+{
+	return mknod_exit(ctx);
+}
+
+/* Device cgroup: only the denials are reported */
+
+SEC("kprobe/devcgroup_check_permission")
+int BPF_KPROBE(ig_dev_devcg_e, short type, u32 major, u32 minor, short access)
+{
+	struct args_t args = {};
+
+	if (should_discard())
+		return 0;
+
+	args.dev_type = type;
+	args.major = major;
+	args.minor = minor;
+	args.access = access;
+	store_args(&devcg_start, &args);
+	return 0;
+}
+
+SEC("kretprobe/devcgroup_check_permission")
+int BPF_KRETPROBE(ig_dev_devcg_x, int ret)
+{
+	struct args_t args;
+
+	if (!pop_args(&devcg_start, &args))
+		return 0;
+
+	if (ret == 0)
+		return 0;
+
+	emit(ctx, &args, DEVICES_EVENT_TYPE_CGROUP_DENIED, ret, false);
+	return 0;
+}
+
+/* Opens of block devices */
+
+SEC("kprobe/blkdev_open")
+int BPF_KPROBE(ig_dev_blkopen_e, struct inode *inode, struct file *file)
+{
+	struct args_t args = {};
+	dev_t rdev;
+
+	if (should_discard())
+		return 0;
+
+	rdev = BPF_CORE_READ(inode, i_rdev);
+	// MAJOR() and MINOR(), the device number is encoded as in the kernel
+	args.major = rdev >> 20;
+	args.minor = rdev & ((1U << 20) - 1);
+	args.access = BPF_CORE_READ(file, f_flags);
+	args.dev_type = DEV_KIND_BLOCK;
+	args.path = (__u64)BPF_CORE_READ(file, f_path.dentry, d_name.name);
+	store_args(&open_start, &args);
+	return 0;
+}
+
+SEC("kretprobe/blkdev_open")
+int BPF_KRETPROBE(ig_dev_blkopen_x, int ret)
+{
+	struct args_t args;
+
+	if (!pop_args(&open_start, &args))
+		return 0;
+
+	emit(ctx, &args, DEVICES_EVENT_TYPE_BLOCK_OPEN, ret, false);
+	return 0;
+}
+
+/* ioctls that check CAP_SYS_ADMIN */
+
+SEC("tracepoint/syscalls/sys_enter_ioctl")
+int ig_dev_ioctl_e(struct trace_event_raw_sys_enter *ctx)
+{
+	struct args_t args = {};
+
+	if (should_discard())
+		return 0;
+
+	args.fd = (int)ctx->args[0];
+	args.cmd = (__u32)ctx->args[1];
+	store_args(&ioctl_start, &args);
+	return 0;
+}
+
+SEC("kprobe/cap_capable")
+int BPF_KPROBE(ig_dev_cap_e, const struct cred *cred, struct user_namespace *targ_ns, int cap)
+{
+	__u32 tid = current_tid();
+	struct args_t *args;
+
+	if (cap != CAP_SYS_ADMIN)
+		return 0;
+
+	args = bpf_map_lookup_elem(&ioctl_start, &tid);
+	if (args)
+		args->sysadmin = true;
+	return 0;
+}
+
+static __always_inline __u64 get_file_name(int fd)
+{
+	struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+	struct fdtable *fdt = BPF_CORE_READ(task, files, fdt);
+	struct file **fds;
+	struct file *file;
+
+	if (fd < 0 || fd >= BPF_CORE_READ(fdt, max_fds))
+		return 0;
+
+	fds = BPF_CORE_READ(fdt, fd);
+	if (bpf_probe_read_kernel(&file, sizeof(file), &fds[fd]) || !file)
+		return 0;
+
+	return (__u64)BPF_CORE_READ(file, f_path.dentry, d_name.name);
+}
+
+SEC("tracepoint/syscalls/sys_exit_ioctl")
+int ig_dev_ioctl_x(struct trace_event_raw_sys_exit *ctx)
+{
+	struct args_t args;
+
+	if (!pop_args(&ioctl_start, &args))
+		return 0;
+
+	if (!args.sysadmin)
+		return 0;
+
+	args.path = get_file_name(args.fd);
+	emit(ctx, &args, DEVICES_EVENT_TYPE_SYSADMIN_IOCTL, (int)ctx->ret, false);
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
@@ -0,0 +1,41 @@
+/* SPDX-License-Identifier: (LGPL-2.1 OR BSD-2-Clause) */
+#ifndef GADGET_AUDIT_DEVICES_H
+#define GADGET_AUDIT_DEVICES_H
+
+#define TASK_COMM_LEN	16
+#define PATH_MAX	256
+
+enum event_type {
+	DEVICES_EVENT_TYPE_MKNOD,
+	DEVICES_EVENT_TYPE_CGROUP_DENIED,
+	DEVICES_EVENT_TYPE_BLOCK_OPEN,
+	DEVICES_EVENT_TYPE_SYSADMIN_IOCTL,
+};
+
+// Device types, as defined by the device cgroup
+enum dev_kind {
+	DEV_KIND_NONE,
+	DEV_KIND_BLOCK,
+	DEV_KIND_CHAR,
+};
+
+struct event {
+	__u64 timestamp;
+	__u64 mntns_id;
+	__u32 pid;
+	__u32 uid;
+	int ret;
+	__u32 major;
+	__u32 minor;
+	// Access requested: DEVCG_ACC_* for the device cgroup, open flags for
+	// block devices, mode for mknod
+	__u32 access;
+	// Command of the ioctl
+	__u32 cmd;
+	enum dev_kind dev_type;
+	enum event_type type;
+	__u8 comm[TASK_COMM_LEN];
+	__u8 path[PATH_MAX];
+};
+
+#endif /* GADGET_AUDIT_DEVICES_H */
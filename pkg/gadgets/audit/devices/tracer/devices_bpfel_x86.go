@@ -0,0 +1,213 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type devicesArgsT struct {
+	Path     uint64
+	Major    uint32
+	Minor    uint32
+	Access   uint32
+	Cmd      uint32
+	Fd       int32
+	DevType  devicesDevKind
+	Sysadmin bool
+	_        [7]byte
+}
+
+type devicesDevKind uint32
+
+const (
+	devicesDevKindDEV_KIND_NONE  devicesDevKind = 0
+	devicesDevKindDEV_KIND_BLOCK devicesDevKind = 1
+	devicesDevKindDEV_KIND_CHAR  devicesDevKind = 2
+)
+
+type devicesEvent struct {
+	Timestamp uint64
+	MntnsId   uint64
+	Pid       uint32
+	Uid       uint32
+	Ret       int32
+	Major     uint32
+	Minor     uint32
+	Access    uint32
+	Cmd       uint32
+	DevType   devicesDevKind
+	Type      devicesEventType
+	Comm      [16]uint8
+	Path      [256]uint8
+	_         [4]byte
+}
+
+type devicesEventType uint32
+
+const (
+	devicesEventTypeDEVICES_EVENT_TYPE_MKNOD          devicesEventType = 0
+	devicesEventTypeDEVICES_EVENT_TYPE_CGROUP_DENIED  devicesEventType = 1
+	devicesEventTypeDEVICES_EVENT_TYPE_BLOCK_OPEN     devicesEventType = 2
+	devicesEventTypeDEVICES_EVENT_TYPE_SYSADMIN_IOCTL devicesEventType = 3
+)
+
+// loadDevices returns the embedded CollectionSpec for devices.
+func loadDevices() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_DevicesBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load devices: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadDevicesObjects loads devices and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*devicesObjects
+//	*devicesPrograms
+//	*devicesMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadDevicesObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadDevices()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// devicesSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type devicesSpecs struct {
+	devicesProgramSpecs
+	devicesMapSpecs
+}
+
+// devicesSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type devicesProgramSpecs struct {
+	IgDevBlkopenE *ebpf.ProgramSpec `ebpf:"ig_dev_blkopen_e"`
+	IgDevBlkopenX *ebpf.ProgramSpec `ebpf:"ig_dev_blkopen_x"`
+	IgDevCapE     *ebpf.ProgramSpec `ebpf:"ig_dev_cap_e"`
+	IgDevDevcgE   *ebpf.ProgramSpec `ebpf:"ig_dev_devcg_e"`
+	IgDevDevcgX   *ebpf.ProgramSpec `ebpf:"ig_dev_devcg_x"`
+	IgDevIoctlE   *ebpf.ProgramSpec `ebpf:"ig_dev_ioctl_e"`
+	IgDevIoctlX   *ebpf.ProgramSpec `ebpf:"ig_dev_ioctl_x"`
+	IgDevMknodE   *ebpf.ProgramSpec `ebpf:"ig_dev_mknod_e"`
+	IgDevMknodX   *ebpf.ProgramSpec `ebpf:"ig_dev_mknod_x"`
+	IgDevMknodatE *ebpf.ProgramSpec `ebpf:"ig_dev_mknodat_e"`
+	IgDevMknodatX *ebpf.ProgramSpec `ebpf:"ig_dev_mknodat_x"`
+}
+
+// devicesMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type devicesMapSpecs struct {
+	DevcgStart           *ebpf.MapSpec `ebpf:"devcg_start"`
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	IoctlStart           *ebpf.MapSpec `ebpf:"ioctl_start"`
+	MknodStart           *ebpf.MapSpec `ebpf:"mknod_start"`
+	OpenStart            *ebpf.MapSpec `ebpf:"open_start"`
+	TmpEvent             *ebpf.MapSpec `ebpf:"tmp_event"`
+}
+
+// devicesObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadDevicesObjects or ebpf.CollectionSpec.LoadAndAssign.
+type devicesObjects struct {
+	devicesPrograms
+	devicesMaps
+}
+
+func (o *devicesObjects) Close() error {
+	return _DevicesClose(
+		&o.devicesPrograms,
+		&o.devicesMaps,
+	)
+}
+
+// devicesMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadDevicesObjects or ebpf.CollectionSpec.LoadAndAssign.
+type devicesMaps struct {
+	DevcgStart           *ebpf.Map `ebpf:"devcg_start"`
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	IoctlStart           *ebpf.Map `ebpf:"ioctl_start"`
+	MknodStart           *ebpf.Map `ebpf:"mknod_start"`
+	OpenStart            *ebpf.Map `ebpf:"open_start"`
+	TmpEvent             *ebpf.Map `ebpf:"tmp_event"`
+}
+
+func (m *devicesMaps) Close() error {
+	return _DevicesClose(
+		m.DevcgStart,
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.IoctlStart,
+		m.MknodStart,
+		m.OpenStart,
+		m.TmpEvent,
+	)
+}
+
+// devicesPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadDevicesObjects or ebpf.CollectionSpec.LoadAndAssign.
+type devicesPrograms struct {
+	IgDevBlkopenE *ebpf.Program `ebpf:"ig_dev_blkopen_e"`
+	IgDevBlkopenX *ebpf.Program `ebpf:"ig_dev_blkopen_x"`
+	IgDevCapE     *ebpf.Program `ebpf:"ig_dev_cap_e"`
+	IgDevDevcgE   *ebpf.Program `ebpf:"ig_dev_devcg_e"`
+	IgDevDevcgX   *ebpf.Program `ebpf:"ig_dev_devcg_x"`
+	IgDevIoctlE   *ebpf.Program `ebpf:"ig_dev_ioctl_e"`
+	IgDevIoctlX   *ebpf.Program `ebpf:"ig_dev_ioctl_x"`
+	IgDevMknodE   *ebpf.Program `ebpf:"ig_dev_mknod_e"`
+	IgDevMknodX   *ebpf.Program `ebpf:"ig_dev_mknod_x"`
+	IgDevMknodatE *ebpf.Program `ebpf:"ig_dev_mknodat_e"`
+	IgDevMknodatX *ebpf.Program `ebpf:"ig_dev_mknodat_x"`
+}
+
+func (p *devicesPrograms) Close() error {
+	return _DevicesClose(
+		p.IgDevBlkopenE,
+		p.IgDevBlkopenX,
+		p.IgDevCapE,
+		p.IgDevDevcgE,
+		p.IgDevDevcgX,
+		p.IgDevIoctlE,
+		p.IgDevIoctlX,
+		p.IgDevMknodE,
+		p.IgDevMknodX,
+		p.IgDevMknodatE,
+		p.IgDevMknodatX,
+	)
+}
+
+func _DevicesClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed devices_bpfel_x86.o
+var _DevicesBytes []byte
@@ -0,0 +1,301 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+	"golang.org/x/sys/unix"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/audit/devices/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -type event -type event_type -type dev_kind devices ./bpf/devices.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
+
+type Config struct {
+	MountnsMap *ebpf.Map
+}
+
+type Tracer struct {
+	config        *Config
+	enricher      gadgets.DataEnricherByMntNs
+	eventCallback func(*types.Event)
+
+	objs   devicesObjects
+	links  []link.Link
+	reader *perf.Reader
+}
+
+func NewTracer(config *Config, enricher gadgets.DataEnricherByMntNs,
+	eventCallback func(*types.Event),
+) (*Tracer, error) {
+	t := &Tracer{
+		config:        config,
+		enricher:      enricher,
+		eventCallback: eventCallback,
+	}
+
+	if err := t.install(); err != nil {
+		t.close()
+		return nil, err
+	}
+
+	go t.run()
+
+	return t, nil
+}
+
+// Stop stops the tracer
+// TODO: Remove after refactoring
+func (t *Tracer) Stop() {
+	t.close()
+}
+
+func (t *Tracer) close() {
+	for i, l := range t.links {
+		t.links[i] = gadgets.CloseLink(l)
+	}
+
+	if t.reader != nil {
+		t.reader.Close()
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadDevices()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	tracepoints := []struct {
+		name string
+		prog *ebpf.Program
+	}{
+		{"sys_enter_mknodat", t.objs.IgDevMknodatE},
+		{"sys_exit_mknodat", t.objs.IgDevMknodatX},
+		{"sys_enter_ioctl", t.objs.IgDevIoctlE},
+		{"sys_exit_ioctl", t.objs.IgDevIoctlX},
+	}
+
+	// arm64 does not defined a mknod() syscall, only mknodat().
+	if runtime.GOARCH != "arm64" {
+		tracepoints = append(tracepoints, []struct {
+			name string
+			prog *ebpf.Program
+		}{
+			{"sys_enter_mknod", t.objs.IgDevMknodE},
+			{"sys_exit_mknod", t.objs.IgDevMknodX},
+		}...)
+	}
+
+	for _, tp := range tracepoints {
+		l, err := link.Tracepoint("syscalls", tp.name, tp.prog, nil)
+		if err != nil {
+			return fmt.Errorf("attaching tracepoint %s: %w", tp.name, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	kprobes := []struct {
+		symbol string
+		entry  *ebpf.Program
+		exit   *ebpf.Program
+	}{
+		{"devcgroup_check_permission", t.objs.IgDevDevcgE, t.objs.IgDevDevcgX},
+		{"blkdev_open", t.objs.IgDevBlkopenE, t.objs.IgDevBlkopenX},
+		{"cap_capable", t.objs.IgDevCapE, nil},
+	}
+
+	for _, kp := range kprobes {
+		l, err := link.Kprobe(kp.symbol, kp.entry, nil)
+		if err != nil {
+			return fmt.Errorf("attaching kprobe %s: %w", kp.symbol, err)
+		}
+		t.links = append(t.links, l)
+
+		if kp.exit == nil {
+			continue
+		}
+
+		l, err = link.Kretprobe(kp.symbol, kp.exit, nil)
+		if err != nil {
+			return fmt.Errorf("attaching kretprobe %s: %w", kp.symbol, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	t.reader, err = perf.NewReader(t.objs.devicesMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	if err != nil {
+		return fmt.Errorf("creating perf ring buffer: %w", err)
+	}
+
+	return nil
+}
+
+var operations = map[devicesEventType]string{
+	devicesEventTypeDEVICES_EVENT_TYPE_MKNOD:          types.OperationMknod,
+	devicesEventTypeDEVICES_EVENT_TYPE_CGROUP_DENIED:  types.OperationCgroupDenied,
+	devicesEventTypeDEVICES_EVENT_TYPE_BLOCK_OPEN:     types.OperationBlockOpen,
+	devicesEventTypeDEVICES_EVENT_TYPE_SYSADMIN_IOCTL: types.OperationSysAdminIoctl,
+}
+
+var deviceTypes = map[devicesDevKind]string{
+	devicesDevKindDEV_KIND_BLOCK: "b",
+	devicesDevKindDEV_KIND_CHAR:  "c",
+}
+
+// Access flags of the device cgroup
+// https://github.com/torvalds/linux/blob/v6.2/include/linux/device_cgroup.h#L7-L9
+const (
+	devcgAccMknod = 1
+	devcgAccRead  = 2
+	devcgAccWrite = 4
+)
+
+func accessString(bpfEvent *devicesEvent) string {
+	switch bpfEvent.Type {
+	case devicesEventTypeDEVICES_EVENT_TYPE_MKNOD:
+		return "m"
+	case devicesEventTypeDEVICES_EVENT_TYPE_CGROUP_DENIED:
+		var sb strings.Builder
+		if bpfEvent.Access&devcgAccMknod != 0 {
+			sb.WriteString("m")
+		}
+		if bpfEvent.Access&devcgAccRead != 0 {
+			sb.WriteString("r")
+		}
+		if bpfEvent.Access&devcgAccWrite != 0 {
+			sb.WriteString("w")
+		}
+		return sb.String()
+	case devicesEventTypeDEVICES_EVENT_TYPE_BLOCK_OPEN:
+		switch bpfEvent.Access & unix.O_ACCMODE {
+		case unix.O_RDONLY:
+			return "r"
+		case unix.O_WRONLY:
+			return "w"
+		case unix.O_RDWR:
+			return "rw"
+		}
+	}
+	return ""
+}
+
+func (t *Tracer) run() {
+	for {
+		record, err := t.reader.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				// nothing to do, we're done
+				return
+			}
+
+			msg := fmt.Sprintf("Error reading perf ring buffer: %s", err)
+			t.eventCallback(types.Base(eventtypes.Err(msg)))
+			return
+		}
+
+		if record.LostSamples > 0 {
+			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
+			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			continue
+		}
+
+		bpfEvent := (*devicesEvent)(unsafe.Pointer(&record.RawSample[0]))
+
+		event := types.Event{
+			Event: eventtypes.Event{
+				Type:      eventtypes.NORMAL,
+				Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+			},
+			WithMountNsID: eventtypes.WithMountNsID{MountNsID: bpfEvent.MntnsId},
+			Pid:           bpfEvent.Pid,
+			Uid:           bpfEvent.Uid,
+			Comm:          gadgets.FromCString(bpfEvent.Comm[:]),
+			Operation:     operations[bpfEvent.Type],
+			Access:        accessString(bpfEvent),
+			Path:          gadgets.FromCString(bpfEvent.Path[:]),
+			Cmd:           bpfEvent.Cmd,
+			Ret:           int(bpfEvent.Ret),
+		}
+
+		if devType, ok := deviceTypes[bpfEvent.DevType]; ok {
+			event.Device = fmt.Sprintf("%s %d:%d", devType, bpfEvent.Major, bpfEvent.Minor)
+		}
+
+		if bpfEvent.Ret < 0 {
+			event.Err = unix.ErrnoName(syscall.Errno(-bpfEvent.Ret))
+		}
+
+		if t.enricher != nil {
+			t.enricher.EnrichByMntNs(&event.CommonData, event.MountNsID)
+		}
+
+		t.eventCallback(&event)
+	}
+}
+
+// --- Registry changes
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	go t.run()
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+	}
+	return tracer, nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2022-2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperationMknod         = "mknod"
+	OperationCgroupDenied  = "cgroup-denied"
+	OperationBlockOpen     = "block-open"
+	OperationSysAdminIoctl = "sysadmin-ioctl"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Pid       uint32 `json:"pid,omitempty" column:"pid,template:pid"`
+	Uid       uint32 `json:"uid" column:"uid,template:uid,hide"`
+	Comm      string `json:"comm,omitempty" column:"comm,template:comm"`
+	Operation string `json:"operation,omitempty" column:"op,width:14,fixed"`
+	// Device is the type and the number of the device, e.g. "b 8:0"
+	Device string `json:"device,omitempty" column:"device,width:10"`
+	// Access is the access requested to the device: a combination of
+	// "m" (mknod), "r" (read) and "w" (write)
+	Access string `json:"access,omitempty" column:"access,width:6,fixed"`
+	// Path is the path of the created node, or the name of the opened
+	// device or of the file of the ioctl
+	Path string `json:"path,omitempty" column:"path,width:24"`
+	Cmd  uint32 `json:"cmd,omitempty" column:"cmd,width:10,fixed,hide"`
+	Ret  int    `json:"ret,omitempty" column:"ret,width:3,fixed,hide"`
+	Err  string `json:"err,omitempty" column:"err,width:8"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	cols.MustSetExtractor("cmd", func(event *Event) string {
+		if event.Operation != OperationSysAdminIoctl {
+			return ""
+		}
+		return fmt.Sprintf("0x%08x", event.Cmd)
+	})
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
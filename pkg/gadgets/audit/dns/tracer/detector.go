@@ -0,0 +1,148 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"sync"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/audit/dns/types"
+	dnstypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+)
+
+const (
+	queryCacheSize = 1024
+
+	// queryTimeout is how long a query is considered outstanding when looking
+	// for a query with another ID, resolvers give up after a few seconds
+	queryTimeout = 10 * time.Second
+)
+
+// queryKey is a unique identifier for a DNS query: the network namespace
+// where the packet was observed and the ID of the DNS header.
+type queryKey struct {
+	netns uint64
+	id    string
+}
+
+type query struct {
+	timestamp      int64
+	name           string
+	qtype          string
+	nameserver     string
+	nameserverPort uint16
+	clientPort     uint16
+}
+
+// detector matches the responses with the queries observed before them and
+// reports the ones that could be spoofed. It uses an LRU cache to bound
+// memory usage. All operations are thread-safe.
+type detector struct {
+	mu      sync.Mutex
+	queries *lru.Cache[queryKey, *query]
+}
+
+func newDetector() (*detector, error) {
+	queries, err := lru.New[queryKey, *query](queryCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return &detector{queries: queries}, nil
+}
+
+// process records the queries and checks the responses, it returns the event
+// to report or nil if the packet looks legitimate. Like for the latency, only
+// the queries leaving and the responses arriving to the network namespace are
+// considered, to skip the packets forwarded between containers.
+func (d *detector) process(ev *dnstypes.Event) *types.Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	key := queryKey{ev.NetNsID, ev.ID}
+
+	if ev.Qr == dnstypes.DNSPktTypeQuery && ev.PktType == "OUTGOING" {
+		d.queries.Add(key, &query{
+			timestamp:      int64(ev.Timestamp),
+			name:           ev.DNSName,
+			qtype:          ev.QType,
+			nameserver:     ev.Nameserver,
+			nameserverPort: ev.NameserverPort,
+			clientPort:     ev.ClientPort,
+		})
+		return nil
+	}
+	if ev.Qr != dnstypes.DNSPktTypeResponse || ev.PktType != "HOST" {
+		return nil
+	}
+
+	if q, ok := d.queries.Peek(key); ok {
+		var reason types.Reason
+		switch {
+		case ev.Nameserver != q.nameserver:
+			reason = types.ReasonUnexpectedSource
+		case ev.NameserverPort != q.nameserverPort || ev.ClientPort != q.clientPort:
+			reason = types.ReasonPortMismatch
+		default:
+			d.queries.Remove(key)
+			return nil
+		}
+		// Keep the query: the legitimate response may still come
+		return newEvent(ev, reason, ev.ID, q)
+	}
+
+	// Responses for unknown queries are usually the ones of queries sent
+	// before the gadget started or evicted from the cache. It's only
+	// suspicious when a query for the same name is waiting for its
+	// response, as when an attacker tries to guess its ID.
+	for _, k := range d.queries.Keys() {
+		if k.netns != ev.NetNsID {
+			continue
+		}
+		q, ok := d.queries.Peek(k)
+		if !ok || q.name != ev.DNSName || q.qtype != ev.QType {
+			continue
+		}
+		if time.Duration(int64(ev.Timestamp)-q.timestamp) > queryTimeout {
+			continue
+		}
+		return newEvent(ev, types.ReasonIDMismatch, k.id, q)
+	}
+
+	return nil
+}
+
+func newEvent(ev *dnstypes.Event, reason types.Reason, queryID string, q *query) *types.Event {
+	return &types.Event{
+		Event:          ev.Event,
+		WithMountNsID:  ev.WithMountNsID,
+		WithNetNsID:    ev.WithNetNsID,
+		Pid:            ev.Pid,
+		Tid:            ev.Tid,
+		Comm:           ev.Comm,
+		Reason:         reason,
+		DNSName:        ev.DNSName,
+		QType:          ev.QType,
+		ID:             ev.ID,
+		Source:         ev.Nameserver,
+		SourcePort:     ev.NameserverPort,
+		Port:           ev.ClientPort,
+		QueryID:        queryID,
+		Nameserver:     q.nameserver,
+		NameserverPort: q.nameserverPort,
+		QueryPort:      q.clientPort,
+	}
+}
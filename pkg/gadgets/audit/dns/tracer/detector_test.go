@@ -0,0 +1,174 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/audit/dns/types"
+	dnstypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+func dnsPacket(qr dnstypes.DNSPktType, netns uint64, id string, ts time.Duration, nameserver string, nameserverPort, clientPort uint16) *dnstypes.Event {
+	pktType := "OUTGOING"
+	if qr == dnstypes.DNSPktTypeResponse {
+		pktType = "HOST"
+	}
+	return &dnstypes.Event{
+		Event: eventtypes.Event{
+			Type:      eventtypes.NORMAL,
+			Timestamp: eventtypes.Time(ts),
+		},
+		WithNetNsID:    eventtypes.WithNetNsID{NetNsID: netns},
+		ID:             id,
+		Qr:             qr,
+		Nameserver:     nameserver,
+		NameserverPort: nameserverPort,
+		ClientPort:     clientPort,
+		PktType:        pktType,
+		QType:          "A",
+		DNSName:        "example.com.",
+	}
+}
+
+func queryPacket(netns uint64, id string, ts time.Duration) *dnstypes.Event {
+	return dnsPacket(dnstypes.DNSPktTypeQuery, netns, id, ts, "10.96.0.10", 53, 40000)
+}
+
+func responsePacket(netns uint64, id string, ts time.Duration, nameserver string, nameserverPort, clientPort uint16) *dnstypes.Event {
+	return dnsPacket(dnstypes.DNSPktTypeResponse, netns, id, ts, nameserver, nameserverPort, clientPort)
+}
+
+func TestDetector(t *testing.T) {
+	type testCase struct {
+		packets []*dnstypes.Event
+		// expected are the reasons reported for the packets, in order
+		expected []types.Reason
+	}
+
+	testCases := map[string]testCase{
+		"legitimate_response": {
+			packets: []*dnstypes.Event{
+				queryPacket(1, "0001", 0),
+				responsePacket(1, "0001", time.Millisecond, "10.96.0.10", 53, 40000),
+			},
+			expected: []types.Reason{"", ""},
+		},
+		"unsolicited_response": {
+			packets: []*dnstypes.Event{
+				responsePacket(1, "0001", time.Millisecond, "10.96.0.10", 53, 40000),
+			},
+			expected: []types.Reason{""},
+		},
+		"unexpected_source": {
+			packets: []*dnstypes.Event{
+				queryPacket(1, "0001", 0),
+				responsePacket(1, "0001", time.Millisecond, "192.0.2.1", 53, 40000),
+				responsePacket(1, "0001", 2*time.Millisecond, "10.96.0.10", 53, 40000),
+			},
+			expected: []types.Reason{"", types.ReasonUnexpectedSource, ""},
+		},
+		"source_port_mismatch": {
+			packets: []*dnstypes.Event{
+				queryPacket(1, "0001", 0),
+				responsePacket(1, "0001", time.Millisecond, "10.96.0.10", 5353, 40000),
+			},
+			expected: []types.Reason{"", types.ReasonPortMismatch},
+		},
+		"destination_port_mismatch": {
+			packets: []*dnstypes.Event{
+				queryPacket(1, "0001", 0),
+				responsePacket(1, "0001", time.Millisecond, "10.96.0.10", 53, 40001),
+			},
+			expected: []types.Reason{"", types.ReasonPortMismatch},
+		},
+		"id_mismatch": {
+			packets: []*dnstypes.Event{
+				queryPacket(1, "0001", 0),
+				responsePacket(1, "0002", time.Millisecond, "10.96.0.10", 53, 40000),
+				responsePacket(1, "0001", 2*time.Millisecond, "10.96.0.10", 53, 40000),
+				responsePacket(1, "0003", 3*time.Millisecond, "10.96.0.10", 53, 40000),
+			},
+			expected: []types.Reason{"", types.ReasonIDMismatch, "", ""},
+		},
+		"id_mismatch_after_timeout": {
+			packets: []*dnstypes.Event{
+				queryPacket(1, "0001", 0),
+				responsePacket(1, "0002", queryTimeout+time.Second, "10.96.0.10", 53, 40000),
+			},
+			expected: []types.Reason{"", ""},
+		},
+		"other_netns": {
+			packets: []*dnstypes.Event{
+				queryPacket(1, "0001", 0),
+				responsePacket(2, "0001", time.Millisecond, "192.0.2.1", 53, 40000),
+				responsePacket(2, "0002", time.Millisecond, "10.96.0.10", 53, 40000),
+			},
+			expected: []types.Reason{"", "", ""},
+		},
+	}
+
+	for name, test := range testCases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			d, err := newDetector()
+			if err != nil {
+				t.Fatalf("Could not initialize detector: %s", err)
+			}
+			for i, packet := range test.packets {
+				var reason types.Reason
+				if event := d.process(packet); event != nil {
+					reason = event.Reason
+				}
+				if reason != test.expected[i] {
+					t.Fatalf("Packet %d: expected reason %q but got %q", i, test.expected[i], reason)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectorEvent(t *testing.T) {
+	d, err := newDetector()
+	if err != nil {
+		t.Fatalf("Could not initialize detector: %s", err)
+	}
+	d.process(queryPacket(1, "0001", 0))
+	event := d.process(responsePacket(1, "0002", time.Millisecond, "192.0.2.1", 5353, 40001))
+	if event == nil {
+		t.Fatalf("Expected an event")
+	}
+
+	expected := types.Event{
+		Reason:         types.ReasonIDMismatch,
+		DNSName:        "example.com.",
+		QType:          "A",
+		ID:             "0002",
+		Source:         "192.0.2.1",
+		SourcePort:     5353,
+		Port:           40001,
+		QueryID:        "0001",
+		Nameserver:     "10.96.0.10",
+		NameserverPort: 53,
+		QueryPort:      40000,
+	}
+	event.Event = eventtypes.Event{}
+	event.WithNetNsID = eventtypes.WithNetNsID{}
+	if *event != expected {
+		t.Fatalf("Expected event %+v but got %+v", expected, *event)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/audit/dns/types"
+	dnstracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/tracer"
+	dnstypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// Tracer reuses the tracer of the trace dns gadget, with its parsing and its
+// socket enricher, and only forwards the responses found by the detector
+type Tracer struct {
+	*dnstracer.Tracer
+
+	detector      *detector
+	eventCallback func(*types.Event)
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return &Tracer{
+		Tracer: &dnstracer.Tracer{},
+	}, nil
+}
+
+func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
+	detector, err := newDetector()
+	if err != nil {
+		return err
+	}
+	t.detector = detector
+
+	if err := t.Tracer.Init(gadgetCtx); err != nil {
+		return err
+	}
+	t.Tracer.SetEventHandler(t.handleDNSEvent)
+	return nil
+}
+
+func (t *Tracer) handleDNSEvent(ev *dnstypes.Event) {
+	if ev.Type != eventtypes.NORMAL {
+		t.eventCallback(types.Base(ev.Event))
+		return
+	}
+	if event := t.detector.process(ev); event != nil {
+		t.eventCallback(event)
+	}
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
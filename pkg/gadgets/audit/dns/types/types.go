@@ -0,0 +1,82 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/environment"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type Reason string
+
+const (
+	// ReasonUnexpectedSource is a response to an outstanding query coming
+	// from another address than the nameserver queried
+	ReasonUnexpectedSource Reason = "unexpected-source"
+
+	// ReasonPortMismatch is a response to an outstanding query coming from
+	// the nameserver queried but with other ports than the query
+	ReasonPortMismatch Reason = "port-mismatch"
+
+	// ReasonIDMismatch is a response whose ID doesn't match any query while
+	// a query for the same name is outstanding with another ID
+	ReasonIDMismatch Reason = "id-mismatch"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+	eventtypes.WithNetNsID
+
+	Pid  uint32 `json:"pid,omitempty" column:"pid,template:pid"`
+	Tid  uint32 `json:"tid,omitempty" column:"tid,template:pid,hide"`
+	Comm string `json:"comm,omitempty" column:"comm,template:comm"`
+
+	Reason  Reason `json:"reason,omitempty" column:"reason,width:17"`
+	DNSName string `json:"name,omitempty" column:"name,width:30"`
+	QType   string `json:"qtype,omitempty" column:"qtype,minWidth:5,maxWidth:10"`
+
+	// ID, Source, SourcePort and Port are the ones of the response
+	ID         string `json:"id,omitempty" column:"id,width:4,fixed"`
+	Source     string `json:"source,omitempty" column:"source,template:ipaddr"`
+	SourcePort uint16 `json:"sourcePort,omitempty" column:"sourceport,template:ipport,hide"`
+	Port       uint16 `json:"port,omitempty" column:"port,template:ipport,hide"`
+
+	// QueryID, Nameserver, NameserverPort and QueryPort are the ones of the
+	// outstanding query
+	QueryID        string `json:"queryId,omitempty" column:"queryid,width:7,fixed"`
+	Nameserver     string `json:"nameserver,omitempty" column:"nameserver,template:ipaddr"`
+	NameserverPort uint16 `json:"nameserverPort,omitempty" column:"nameserverport,template:ipport,hide"`
+	QueryPort      uint16 `json:"queryPort,omitempty" column:"queryport,template:ipport,hide"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	// Hide container column for kubernetes environment
+	if environment.Environment == environment.Kubernetes {
+		col, _ := cols.GetColumn("container")
+		col.Visible = false
+	}
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
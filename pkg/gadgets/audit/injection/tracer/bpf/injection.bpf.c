@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "injection.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+
+#define PTRACE_ATTACH	16
+#define PTRACE_SEIZE	0x4206
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event *unusedevent __attribute__((unused));
+
+// The syscalls in progress, indexed by thread. The event is filled in while
+// the syscall goes and sent when it returns.
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__type(value, struct event);
+} starts SEC(".maps");
+
+// The event is too big for the stack
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, __u32);
+	__type(value, struct event);
+} tmp_event SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, sizeof(__u32));
+} events SEC(".maps");
+
+static __always_inline struct event *new_event(enum injection_op op)
+{
+	__u64 pid_tgid = bpf_get_current_pid_tgid();
+	__u64 mntns_id = gadget_get_mntns_id();
+	struct event *event;
+	__u32 zero = 0;
+
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return NULL;
+
+	event = bpf_map_lookup_elem(&tmp_event, &zero);
+	if (!event)
+		return NULL;
+
+	__builtin_memset(event, 0, sizeof(*event));
+	event->mntns_id = mntns_id;
+	event->pid = pid_tgid >> 32;
+	event->tid = (__u32)pid_tgid;
+	event->uid = (__u32)bpf_get_current_uid_gid();
+	event->op = op;
+	bpf_get_current_comm(&event->task, sizeof(event->task));
+	return event;
+}
+
+static __always_inline int syscall_entry(enum injection_op op)
+{
+	struct event *event;
+
+	event = new_event(op);
+	if (!event)
+		return 0;
+
+	bpf_map_update_elem(&starts, &event->tid, event, BPF_ANY);
+	return 0;
+}
+
+static __always_inline int syscall_exit(struct trace_event_raw_sys_exit *ctx)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	struct event *event;
+
+	event = bpf_map_lookup_elem(&starts, &tid);
+	if (!event)
+		return 0;
+
+	event->timestamp = bpf_ktime_get_boot_ns();
+	event->ret = ctx->ret;
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
+
+	bpf_map_delete_elem(&starts, &tid);
+	return 0;
+}
+
+// Called with the target once the kernel found it, before checking the
+// permissions
+static __always_inline int set_target(struct task_struct *target)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	struct event *event;
+
+	event = bpf_map_lookup_elem(&starts, &tid);
+	if (!event)
+		return 0;
+
+	event->target_pid = BPF_CORE_READ(target, tgid);
+	event->target_mntns_id = BPF_CORE_READ(target, nsproxy, mnt_ns, ns.inum);
+	bpf_probe_read_kernel_str(&event->target_task, sizeof(event->target_task), target->comm);
+	return 0;
+}
+
+SEC("tracepoint/syscalls/sys_enter_ptrace")
+int ig_inj_ptrace_e(struct trace_event_raw_sys_enter *ctx)
+{
+	switch (ctx->args[0]) {
+	case PTRACE_ATTACH:
+		return syscall_entry(INJECTION_OP_PTRACE_ATTACH);
+	case PTRACE_SEIZE:
+		return syscall_entry(INJECTION_OP_PTRACE_SEIZE);
+	}
+	return 0;
+}
+
+SEC("tracepoint/syscalls/sys_exit_ptrace")
+int ig_inj_ptrace_x(struct trace_event_raw_sys_exit *ctx)
+{
+	return syscall_exit(ctx);
+}
+
+SEC("kprobe/ptrace_attach")
+int BPF_KPROBE(ig_inj_attach, struct task_struct *task)
+{
+	return set_target(task);
+}
+
+SEC("tracepoint/syscalls/sys_enter_process_vm_writev")
+int ig_inj_writev_e(struct trace_event_raw_sys_enter *ctx)
+{
+	return syscall_entry(INJECTION_OP_VM_WRITEV);
+}
+
+SEC("tracepoint/syscalls/sys_exit_process_vm_writev")
+int ig_inj_writev_x(struct trace_event_raw_sys_exit *ctx)
+{
+	return syscall_exit(ctx);
+}
+
+// Checks whether the caller can access the memory of the target, called by
+// process_vm_writev() but also the other syscalls that aren't traced
+SEC("kprobe/mm_access")
+int BPF_KPROBE(ig_inj_mm_access, struct task_struct *task)
+{
+	return set_target(task);
+}
+
+// Reports the programs executed from a memfd, i.e. that only exist in memory
+SEC("tracepoint/sched/sched_process_exec")
+int ig_inj_exec(struct trace_event_raw_sched_process_exec *ctx)
+{
+	struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+	const char prefix[] = "memfd:";
+	const unsigned char *name;
+	struct event *event;
+	char buf[sizeof(prefix) - 1];
+
+	name = BPF_CORE_READ(task, mm, exe_file, f_path.dentry, d_name.name);
+	if (bpf_probe_read_kernel(buf, sizeof(buf), name))
+		return 0;
+	for (int i = 0; i < sizeof(buf); i++) {
+		if (buf[i] != prefix[i])
+			return 0;
+	}
+
+	event = new_event(INJECTION_OP_MEMFD_EXEC);
+	if (!event)
+		return 0;
+
+	event->timestamp = bpf_ktime_get_boot_ns();
+	bpf_probe_read_kernel_str(&event->file, sizeof(event->file), name);
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
@@ -0,0 +1,37 @@
+/* SPDX-License-Identifier: (LGPL-2.1 OR BSD-2-Clause) */
+#ifndef GADGET_AUDIT_INJECTION_H
+#define GADGET_AUDIT_INJECTION_H
+
+#define TASK_COMM_LEN	16
+#define NAME_MAX	255
+
+enum injection_op {
+	INJECTION_OP_PTRACE_ATTACH,
+	INJECTION_OP_PTRACE_SEIZE,
+	INJECTION_OP_VM_WRITEV,
+	INJECTION_OP_MEMFD_EXEC,
+};
+
+struct event {
+	__u64 mntns_id;
+	__u64 timestamp;
+	// Mount namespace of the target, to know whether it's in the same
+	// container
+	__u64 target_mntns_id;
+	__u32 pid;
+	__u32 tid;
+	__u32 uid;
+	// Result of the syscall: 0 or the number of bytes written on success,
+	// -errno on failure
+	__s64 ret;
+	// PID of the target in the namespace of the node, 0 if the kernel
+	// failed before looking it up
+	__u32 target_pid;
+	enum injection_op op;
+	__u8 task[TASK_COMM_LEN];
+	__u8 target_task[TASK_COMM_LEN];
+	// Name of the file of the memfd, e.g. "memfd:payload"
+	__u8 file[NAME_MAX + 1];
+};
+
+#endif /* GADGET_AUDIT_INJECTION_H */
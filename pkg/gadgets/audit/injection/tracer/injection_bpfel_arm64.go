@@ -0,0 +1,171 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build arm64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type injectionEvent struct {
+	MntnsId       uint64
+	Timestamp     uint64
+	TargetMntnsId uint64
+	Pid           uint32
+	Tid           uint32
+	Uid           uint32
+	_             [4]byte
+	Ret           int64
+	TargetPid     uint32
+	Op            injectionInjectionOp
+	Task          [16]uint8
+	TargetTask    [16]uint8
+	File          [256]uint8
+}
+
+type injectionInjectionOp uint32
+
+const (
+	injectionInjectionOpINJECTION_OP_PTRACE_ATTACH injectionInjectionOp = 0
+	injectionInjectionOpINJECTION_OP_PTRACE_SEIZE  injectionInjectionOp = 1
+	injectionInjectionOpINJECTION_OP_VM_WRITEV     injectionInjectionOp = 2
+	injectionInjectionOpINJECTION_OP_MEMFD_EXEC    injectionInjectionOp = 3
+)
+
+// loadInjection returns the embedded CollectionSpec for injection.
+func loadInjection() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_InjectionBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load injection: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadInjectionObjects loads injection and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*injectionObjects
+//	*injectionPrograms
+//	*injectionMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadInjectionObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadInjection()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// injectionSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type injectionSpecs struct {
+	injectionProgramSpecs
+	injectionMapSpecs
+}
+
+// injectionSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type injectionProgramSpecs struct {
+	IgInjAttach   *ebpf.ProgramSpec `ebpf:"ig_inj_attach"`
+	IgInjExec     *ebpf.ProgramSpec `ebpf:"ig_inj_exec"`
+	IgInjMmAccess *ebpf.ProgramSpec `ebpf:"ig_inj_mm_access"`
+	IgInjPtraceE  *ebpf.ProgramSpec `ebpf:"ig_inj_ptrace_e"`
+	IgInjPtraceX  *ebpf.ProgramSpec `ebpf:"ig_inj_ptrace_x"`
+	IgInjWritevE  *ebpf.ProgramSpec `ebpf:"ig_inj_writev_e"`
+	IgInjWritevX  *ebpf.ProgramSpec `ebpf:"ig_inj_writev_x"`
+}
+
+// injectionMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type injectionMapSpecs struct {
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Starts               *ebpf.MapSpec `ebpf:"starts"`
+	TmpEvent             *ebpf.MapSpec `ebpf:"tmp_event"`
+}
+
+// injectionObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadInjectionObjects or ebpf.CollectionSpec.LoadAndAssign.
+type injectionObjects struct {
+	injectionPrograms
+	injectionMaps
+}
+
+func (o *injectionObjects) Close() error {
+	return _InjectionClose(
+		&o.injectionPrograms,
+		&o.injectionMaps,
+	)
+}
+
+// injectionMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadInjectionObjects or ebpf.CollectionSpec.LoadAndAssign.
+type injectionMaps struct {
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Starts               *ebpf.Map `ebpf:"starts"`
+	TmpEvent             *ebpf.Map `ebpf:"tmp_event"`
+}
+
+func (m *injectionMaps) Close() error {
+	return _InjectionClose(
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.Starts,
+		m.TmpEvent,
+	)
+}
+
+// injectionPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadInjectionObjects or ebpf.CollectionSpec.LoadAndAssign.
+type injectionPrograms struct {
+	IgInjAttach   *ebpf.Program `ebpf:"ig_inj_attach"`
+	IgInjExec     *ebpf.Program `ebpf:"ig_inj_exec"`
+	IgInjMmAccess *ebpf.Program `ebpf:"ig_inj_mm_access"`
+	IgInjPtraceE  *ebpf.Program `ebpf:"ig_inj_ptrace_e"`
+	IgInjPtraceX  *ebpf.Program `ebpf:"ig_inj_ptrace_x"`
+	IgInjWritevE  *ebpf.Program `ebpf:"ig_inj_writev_e"`
+	IgInjWritevX  *ebpf.Program `ebpf:"ig_inj_writev_x"`
+}
+
+func (p *injectionPrograms) Close() error {
+	return _InjectionClose(
+		p.IgInjAttach,
+		p.IgInjExec,
+		p.IgInjMmAccess,
+		p.IgInjPtraceE,
+		p.IgInjPtraceX,
+		p.IgInjWritevE,
+		p.IgInjWritevX,
+	)
+}
+
+func _InjectionClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed injection_bpfel_arm64.o
+var _InjectionBytes []byte
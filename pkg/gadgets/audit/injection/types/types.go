@@ -0,0 +1,59 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type Operation string
+
+const (
+	OperationPtraceAttach Operation = "ptrace-attach"
+	OperationPtraceSeize  Operation = "ptrace-seize"
+	OperationVMWritev     Operation = "vm-writev"
+	OperationMemfdExec    Operation = "memfd-exec"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Pid       uint32    `json:"pid,omitempty" column:"pid,template:pid"`
+	Tid       uint32    `json:"tid,omitempty" column:"tid,template:pid,hide"`
+	Uid       uint32    `json:"uid" column:"uid,template:uid,hide"`
+	Comm      string    `json:"comm,omitempty" column:"comm,template:comm"`
+	Operation Operation `json:"operation,omitempty" column:"op,width:14"`
+	// TargetPid is the process being attached to or written, it's 0 if the
+	// kernel failed before finding it
+	TargetPid       uint32 `json:"targetpid,omitempty" column:"targetpid,template:pid"`
+	TargetComm      string `json:"targetcomm,omitempty" column:"targetcomm,template:comm"`
+	TargetMountNsID uint64 `json:"targetmountnsid,omitempty" column:"targetmntns,template:ns"`
+	// File is the name of the memfd executed, e.g. "memfd:payload"
+	File string `json:"file,omitempty" column:"file,width:24"`
+	Ret  int64  `json:"ret,omitempty" column:"ret,width:8,hide"`
+	Err  string `json:"err,omitempty" column:"err,width:8"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	return columns.MustCreateColumns[Event]()
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
@@ -0,0 +1,211 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "kmod.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+#define MAX_PATH_DEPTH	32
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event *unusedevent __attribute__((unused));
+
+// Layout of the module:module_load tracepoint. The type of vmlinux.h isn't
+// used as it doesn't exist in the kernels built without module support.
+struct module_load_args {
+	struct trace_entry ent;
+	unsigned int taints;
+	__u32 data_loc_name;
+};
+
+// The loads in progress, indexed by thread. The event is filled in while
+// the syscall goes and sent when it returns.
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__type(value, struct event);
+} loads SEC(".maps");
+
+// The event is too big for the stack
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, __u32);
+	__type(value, struct event);
+} tmp_event SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, sizeof(__u32));
+} events SEC(".maps");
+
+static __always_inline struct event *new_event(enum kmod_syscall syscall, const char *uargs)
+{
+	__u64 pid_tgid = bpf_get_current_pid_tgid();
+	__u64 mntns_id = gadget_get_mntns_id();
+	struct event *event;
+	__u32 zero = 0;
+
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return NULL;
+
+	event = bpf_map_lookup_elem(&tmp_event, &zero);
+	if (!event)
+		return NULL;
+
+	__builtin_memset(event, 0, sizeof(*event));
+	event->mntns_id = mntns_id;
+	event->pid = pid_tgid >> 32;
+	event->tid = (__u32)pid_tgid;
+	event->uid = (__u32)bpf_get_current_uid_gid();
+	event->syscall = syscall;
+	bpf_get_current_comm(&event->task, sizeof(event->task));
+	bpf_probe_read_user_str(&event->params, sizeof(event->params), uargs);
+	return event;
+}
+
+static __always_inline struct file *get_file(int fd)
+{
+	struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+	struct fdtable *fdt = BPF_CORE_READ(task, files, fdt);
+	struct file **fds;
+	struct file *file;
+
+	if (fd < 0 || fd >= BPF_CORE_READ(fdt, max_fds))
+		return NULL;
+
+	fds = BPF_CORE_READ(fdt, fd);
+	if (bpf_probe_read_kernel(&file, sizeof(file), &fds[fd]))
+		return NULL;
+
+	return file;
+}
+
+// Walk the dentries from the file to the root, crossing the mount points,
+// and write the name of each of them to buf. The components are written from
+// the file to the root, each one terminated by a NUL, the caller has to
+// reverse them. The path is truncated if it's deeper than MAX_PATH_DEPTH or
+// longer than PATH_MAX_LEN.
+static __always_inline __u32 get_path(struct file *file, __u8 *buf)
+{
+	struct dentry *dentry = BPF_CORE_READ(file, f_path.dentry);
+	struct vfsmount *vfsmnt = BPF_CORE_READ(file, f_path.mnt);
+	struct mount *mnt = container_of(vfsmnt, struct mount, mnt);
+	struct dentry *mnt_root, *parent;
+	struct mount *mnt_parent;
+	const unsigned char *name;
+	__u32 off = 0;
+	int len;
+
+	#pragma unroll
+	for (int i = 0; i < MAX_PATH_DEPTH; i++) {
+		mnt_root = BPF_CORE_READ(mnt, mnt.mnt_root);
+		if (dentry == mnt_root) {
+			mnt_parent = BPF_CORE_READ(mnt, mnt_parent);
+			// root of the mount namespace
+			if (mnt_parent == mnt)
+				break;
+			dentry = BPF_CORE_READ(mnt, mnt_mountpoint);
+			mnt = mnt_parent;
+			continue;
+		}
+
+		parent = BPF_CORE_READ(dentry, d_parent);
+		if (dentry == parent || off >= PATH_MAX_LEN)
+			break;
+
+		name = BPF_CORE_READ(dentry, d_name.name);
+		len = bpf_probe_read_kernel_str(buf + (off & (PATH_MAX_LEN - 1)),
+						NAME_MAX + 1, name);
+		if (len <= 0)
+			break;
+		off += len;
+		dentry = parent;
+	}
+
+	return off;
+}
+
+SEC("tracepoint/syscalls/sys_enter_init_module")
+int ig_kmod_init_e(struct trace_event_raw_sys_enter *ctx)
+{
+	struct event *event;
+
+	event = new_event(KMOD_SYSCALL_INIT_MODULE, (const char *)ctx->args[2]);
+	if (!event)
+		return 0;
+
+	bpf_map_update_elem(&loads, &event->tid, event, BPF_ANY);
+	return 0;
+}
+
+SEC("tracepoint/syscalls/sys_enter_finit_module")
+int ig_kmod_finit_e(struct trace_event_raw_sys_enter *ctx)
+{
+	struct event *event;
+	struct file *file;
+
+	event = new_event(KMOD_SYSCALL_FINIT_MODULE, (const char *)ctx->args[1]);
+	if (!event)
+		return 0;
+
+	file = get_file((int)ctx->args[0]);
+	if (file)
+		event->file_len = get_path(file, event->file);
+
+	bpf_map_update_elem(&loads, &event->tid, event, BPF_ANY);
+	return 0;
+}
+
+// Called once the module is parsed, before its init function and only if the
+// kernel got that far
+SEC("tracepoint/module/module_load")
+int ig_kmod_load(struct module_load_args *ctx)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	struct event *event;
+
+	event = bpf_map_lookup_elem(&loads, &tid);
+	if (!event)
+		return 0;
+
+	bpf_probe_read_kernel_str(event->name, sizeof(event->name),
+				  (void *)ctx + (ctx->data_loc_name & 0xffff));
+	return 0;
+}
+
+static __always_inline int load_exit(struct trace_event_raw_sys_exit *ctx)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	struct event *event;
+
+	event = bpf_map_lookup_elem(&loads, &tid);
+	if (!event)
+		return 0;
+
+	event->timestamp = bpf_ktime_get_boot_ns();
+	event->ret = ctx->ret;
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
+
+	bpf_map_delete_elem(&loads, &tid);
+	return 0;
+}
+
+SEC("tracepoint/syscalls/sys_exit_init_module")
+int ig_kmod_init_x(struct trace_event_raw_sys_exit *ctx)
+{
+	return load_exit(ctx);
+}
+
+SEC("tracepoint/syscalls/sys_exit_finit_module")
+int ig_kmod_finit_x(struct trace_event_raw_sys_exit *ctx)
+{
+	return load_exit(ctx);
+}
+
+char LICENSE[] SEC("license") = "GPL";
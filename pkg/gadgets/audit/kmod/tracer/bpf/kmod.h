@@ -0,0 +1,38 @@
+/* SPDX-License-Identifier: (LGPL-2.1 OR BSD-2-Clause) */
+#ifndef GADGET_AUDIT_KMOD_H
+#define GADGET_AUDIT_KMOD_H
+
+#define TASK_COMM_LEN	16
+// MODULE_NAME_LEN of the kernel, the size of the name of struct module
+#define MODULE_NAME_LEN	56
+#define NAME_MAX	255
+#define PATH_MAX_LEN	512
+// Room for a last component after PATH_MAX_LEN, see get_path()
+#define FILE_MAX_LEN	(PATH_MAX_LEN + NAME_MAX + 1)
+#define PARAMS_MAX_LEN	128
+
+enum kmod_syscall {
+	KMOD_SYSCALL_INIT_MODULE,
+	KMOD_SYSCALL_FINIT_MODULE,
+};
+
+struct event {
+	__u64 mntns_id;
+	__u64 timestamp;
+	__u32 pid;
+	__u32 tid;
+	__u32 uid;
+	int ret;
+	// Length of file, 0 for init_module()
+	__u32 file_len;
+	enum kmod_syscall syscall;
+	__u8 task[TASK_COMM_LEN];
+	// Name of the module, empty if the kernel failed before parsing it
+	__u8 name[MODULE_NAME_LEN];
+	// Parameters passed to the module, e.g. "debug=1"
+	__u8 params[PARAMS_MAX_LEN];
+	// Components of the path of the file of finit_module(), see get_path()
+	__u8 file[FILE_MAX_LEN];
+};
+
+#endif /* GADGET_AUDIT_KMOD_H */
@@ -0,0 +1,162 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type kmodEvent struct {
+	MntnsId   uint64
+	Timestamp uint64
+	Pid       uint32
+	Tid       uint32
+	Uid       uint32
+	Ret       int32
+	FileLen   uint32
+	Syscall   kmodKmodSyscall
+	Task      [16]uint8
+	Name      [56]uint8
+	Params    [128]uint8
+	File      [768]uint8
+}
+
+type kmodKmodSyscall uint32
+
+const (
+	kmodKmodSyscallKMOD_SYSCALL_INIT_MODULE  kmodKmodSyscall = 0
+	kmodKmodSyscallKMOD_SYSCALL_FINIT_MODULE kmodKmodSyscall = 1
+)
+
+// loadKmod returns the embedded CollectionSpec for kmod.
+func loadKmod() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_KmodBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load kmod: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadKmodObjects loads kmod and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*kmodObjects
+//	*kmodPrograms
+//	*kmodMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadKmodObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadKmod()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// kmodSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type kmodSpecs struct {
+	kmodProgramSpecs
+	kmodMapSpecs
+}
+
+// kmodSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type kmodProgramSpecs struct {
+	IgKmodFinitE *ebpf.ProgramSpec `ebpf:"ig_kmod_finit_e"`
+	IgKmodFinitX *ebpf.ProgramSpec `ebpf:"ig_kmod_finit_x"`
+	IgKmodInitE  *ebpf.ProgramSpec `ebpf:"ig_kmod_init_e"`
+	IgKmodInitX  *ebpf.ProgramSpec `ebpf:"ig_kmod_init_x"`
+	IgKmodLoad   *ebpf.ProgramSpec `ebpf:"ig_kmod_load"`
+}
+
+// kmodMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type kmodMapSpecs struct {
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Loads                *ebpf.MapSpec `ebpf:"loads"`
+	TmpEvent             *ebpf.MapSpec `ebpf:"tmp_event"`
+}
+
+// kmodObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadKmodObjects or ebpf.CollectionSpec.LoadAndAssign.
+type kmodObjects struct {
+	kmodPrograms
+	kmodMaps
+}
+
+func (o *kmodObjects) Close() error {
+	return _KmodClose(
+		&o.kmodPrograms,
+		&o.kmodMaps,
+	)
+}
+
+// kmodMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadKmodObjects or ebpf.CollectionSpec.LoadAndAssign.
+type kmodMaps struct {
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Loads                *ebpf.Map `ebpf:"loads"`
+	TmpEvent             *ebpf.Map `ebpf:"tmp_event"`
+}
+
+func (m *kmodMaps) Close() error {
+	return _KmodClose(
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.Loads,
+		m.TmpEvent,
+	)
+}
+
+// kmodPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadKmodObjects or ebpf.CollectionSpec.LoadAndAssign.
+type kmodPrograms struct {
+	IgKmodFinitE *ebpf.Program `ebpf:"ig_kmod_finit_e"`
+	IgKmodFinitX *ebpf.Program `ebpf:"ig_kmod_finit_x"`
+	IgKmodInitE  *ebpf.Program `ebpf:"ig_kmod_init_e"`
+	IgKmodInitX  *ebpf.Program `ebpf:"ig_kmod_init_x"`
+	IgKmodLoad   *ebpf.Program `ebpf:"ig_kmod_load"`
+}
+
+func (p *kmodPrograms) Close() error {
+	return _KmodClose(
+		p.IgKmodFinitE,
+		p.IgKmodFinitX,
+		p.IgKmodInitE,
+		p.IgKmodInitX,
+		p.IgKmodLoad,
+	)
+}
+
+func _KmodClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed kmod_bpfel_x86.o
+var _KmodBytes []byte
@@ -0,0 +1,231 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+	"golang.org/x/sys/unix"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/audit/kmod/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -type event -type kmod_syscall kmod ./bpf/kmod.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
+
+type Config struct {
+	MountnsMap *ebpf.Map
+}
+
+type Tracer struct {
+	config        *Config
+	enricher      gadgets.DataEnricherByMntNs
+	eventCallback func(*types.Event)
+
+	objs   kmodObjects
+	links  []link.Link
+	reader *perf.Reader
+}
+
+func NewTracer(config *Config, enricher gadgets.DataEnricherByMntNs,
+	eventCallback func(*types.Event),
+) (*Tracer, error) {
+	t := &Tracer{
+		config:        config,
+		enricher:      enricher,
+		eventCallback: eventCallback,
+	}
+
+	if err := t.install(); err != nil {
+		t.close()
+		return nil, err
+	}
+
+	go t.run()
+
+	return t, nil
+}
+
+// Stop stops the tracer
+// TODO: Remove after refactoring
+func (t *Tracer) Stop() {
+	t.close()
+}
+
+func (t *Tracer) close() {
+	for i, l := range t.links {
+		t.links[i] = gadgets.CloseLink(l)
+	}
+
+	if t.reader != nil {
+		t.reader.Close()
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadKmod()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	tracepoints := []struct {
+		group string
+		name  string
+		prog  *ebpf.Program
+	}{
+		{"syscalls", "sys_enter_init_module", t.objs.IgKmodInitE},
+		{"syscalls", "sys_exit_init_module", t.objs.IgKmodInitX},
+		{"syscalls", "sys_enter_finit_module", t.objs.IgKmodFinitE},
+		{"syscalls", "sys_exit_finit_module", t.objs.IgKmodFinitX},
+		{"module", "module_load", t.objs.IgKmodLoad},
+	}
+
+	for _, tp := range tracepoints {
+		l, err := link.Tracepoint(tp.group, tp.name, tp.prog, nil)
+		if err != nil {
+			return fmt.Errorf("attaching tracepoint %s: %w", tp.name, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	t.reader, err = perf.NewReader(t.objs.kmodMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	if err != nil {
+		return fmt.Errorf("creating perf ring buffer: %w", err)
+	}
+
+	return nil
+}
+
+var syscalls = map[kmodKmodSyscall]columns.Enum{
+	kmodKmodSyscallKMOD_SYSCALL_INIT_MODULE:  {Name: "init_module", Value: unix.SYS_INIT_MODULE},
+	kmodKmodSyscallKMOD_SYSCALL_FINIT_MODULE: {Name: "finit_module", Value: unix.SYS_FINIT_MODULE},
+}
+
+// moduleNameFromFile guesses the name of a module from its file, e.g.
+// "nf_tables" for nf-tables.ko.xz, when the kernel failed before parsing it
+func moduleNameFromFile(file string) string {
+	name, _, _ := strings.Cut(filepath.Base(file), ".")
+	return strings.ReplaceAll(name, "-", "_")
+}
+
+func (t *Tracer) run() {
+	for {
+		record, err := t.reader.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				// nothing to do, we're done
+				return
+			}
+
+			msg := fmt.Sprintf("Error reading perf ring buffer: %s", err)
+			t.eventCallback(types.Base(eventtypes.Err(msg)))
+			return
+		}
+
+		if record.LostSamples > 0 {
+			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
+			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			continue
+		}
+
+		bpfEvent := (*kmodEvent)(unsafe.Pointer(&record.RawSample[0]))
+
+		event := types.Event{
+			Event: eventtypes.Event{
+				Type:      eventtypes.NORMAL,
+				Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+			},
+			WithMountNsID: eventtypes.WithMountNsID{MountNsID: bpfEvent.MntnsId},
+			Pid:           bpfEvent.Pid,
+			Tid:           bpfEvent.Tid,
+			Uid:           bpfEvent.Uid,
+			Comm:          gadgets.FromCString(bpfEvent.Task[:]),
+			Syscall:       syscalls[bpfEvent.Syscall],
+			Module:        gadgets.FromCString(bpfEvent.Name[:]),
+			Params:        gadgets.FromCString(bpfEvent.Params[:]),
+			Ret:           int(bpfEvent.Ret),
+		}
+
+		if bpfEvent.FileLen > 0 && int(bpfEvent.FileLen) <= len(bpfEvent.File) {
+			event.File = gadgets.PathFromComponents(bpfEvent.File[:bpfEvent.FileLen])
+			if event.Module == "" {
+				event.Module = moduleNameFromFile(event.File)
+			}
+		}
+
+		if bpfEvent.Ret < 0 {
+			event.Err = unix.ErrnoName(syscall.Errno(-bpfEvent.Ret))
+		}
+
+		if t.enricher != nil {
+			t.enricher.EnrichByMntNs(&event.CommonData, event.MountNsID)
+		}
+
+		t.eventCallback(&event)
+	}
+}
+
+// --- Registry changes
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	go t.run()
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+	}
+	return tracer, nil
+}
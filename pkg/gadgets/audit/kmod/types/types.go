@@ -0,0 +1,49 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Pid     uint32       `json:"pid,omitempty" column:"pid,template:pid"`
+	Tid     uint32       `json:"tid,omitempty" column:"tid,template:pid,hide"`
+	Uid     uint32       `json:"uid" column:"uid,template:uid,hide"`
+	Comm    string       `json:"comm,omitempty" column:"comm,template:comm"`
+	Syscall columns.Enum `json:"syscall" column:"syscall,template:syscall"`
+	// Module is the name of the module, guessed from its file if the kernel
+	// failed before parsing it
+	Module string `json:"module,omitempty" column:"module,width:20"`
+	// File is the file of the module, only known with finit_module()
+	File   string `json:"file,omitempty" column:"file,width:40"`
+	Params string `json:"params,omitempty" column:"params,width:24,hide"`
+	Ret    int    `json:"ret,omitempty" column:"ret,width:3,fixed,hide"`
+	Err    string `json:"err,omitempty" column:"err,width:8"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	return columns.MustCreateColumns[Event]()
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/cilium/ebpf/link"
 	"github.com/cilium/ebpf/perf"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/audit/seccomp/types"
@@ -124,7 +125,7 @@ func (t *Tracer) run() {
 			},
 			Pid:           uint32(eventC.Pid),
 			WithMountNsID: eventtypes.WithMountNsID{MountNsID: eventC.MntnsId},
-			Syscall:       syscallToName(int(eventC.Syscall)),
+			Syscall:       columns.Enum{Name: syscallToName(int(eventC.Syscall)), Value: int64(eventC.Syscall)},
 			Code:          codeToName(uint(eventC.Code)),
 			Comm:          gadgets.FromCString(eventC.Comm[:]),
 		}
@@ -23,10 +23,10 @@ type Event struct {
 	eventtypes.Event
 	eventtypes.WithMountNsID
 
-	Pid     uint32 `json:"pid,omitempty" column:"pid,template:pid"`
-	Comm    string `json:"comm,omitempty" column:"comm,template:comm"`
-	Syscall string `json:"syscall,omitempty" column:"syscall,template:syscall"`
-	Code    string `json:"code,omitempty" column:"code,width:12,fixed"`
+	Pid     uint32       `json:"pid,omitempty" column:"pid,template:pid"`
+	Comm    string       `json:"comm,omitempty" column:"comm,template:comm"`
+	Syscall columns.Enum `json:"syscall" column:"syscall,template:syscall"`
+	Code    string       `json:"code,omitempty" column:"code,width:12,fixed"`
 }
 
 func GetColumns() *columns.Columns[Event] {
@@ -0,0 +1,23 @@
+/* SPDX-License-Identifier: (GPL-2.0 WITH Linux-syscall-note) OR Apache-2.0 */
+
+#ifndef CGROUP_FILTER_H
+#define CGROUP_FILTER_H
+
+#include <bpf/bpf_helpers.h>
+
+const volatile bool gadget_filter_by_cgroup = false;
+
+struct {
+	__uint(type, BPF_MAP_TYPE_CGROUP_ARRAY);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, sizeof(__u32));
+	__uint(max_entries, 1);
+} gadget_cgroup_filter_map SEC(".maps");
+
+// gadget_should_discard_cgroup returns true if the current task is not part of
+// the cgroup stored in gadget_cgroup_filter_map, or of one of its descendants.
+static __always_inline bool gadget_should_discard_cgroup() {
+	return gadget_filter_by_cgroup && bpf_current_task_under_cgroup(&gadget_cgroup_filter_map, 0) != 1;
+}
+
+#endif
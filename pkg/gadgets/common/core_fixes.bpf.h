@@ -166,4 +166,40 @@ static __always_inline bool has_kmem_cache_free()
 	return false;
 }
 
+/**
+ * commit 11e9734bcb6a("mm/slab_common: unify NUMA and UMA version of
+ * tracepoints") removes the kmalloc_node and kmem_cache_alloc_node
+ * tracepoints and replaces the `trace_event_raw_kmem_alloc` and
+ * `trace_event_raw_kmem_alloc_node` classes with `trace_event_raw_kmalloc` and
+ * `trace_event_raw_kmem_cache_alloc`.
+ * see:
+ *     https://github.com/torvalds/linux/commit/11e9734bcb6a
+ */
+struct trace_event_raw_kmem_alloc___x {
+	const void *ptr;
+	size_t bytes_alloc;
+} __attribute__((preserve_access_index));
+
+struct trace_event_raw_kmalloc___x {
+	const void *ptr;
+	size_t bytes_alloc;
+} __attribute__((preserve_access_index));
+
+struct trace_event_raw_kmem_cache_alloc___x {
+	const void *ptr;
+	size_t bytes_alloc;
+} __attribute__((preserve_access_index));
+
+struct trace_event_raw_kmem_alloc_node___x {
+	const void *ptr;
+	size_t bytes_alloc;
+} __attribute__((preserve_access_index));
+
+static __always_inline bool has_kmem_alloc()
+{
+	if (bpf_core_type_exists(struct trace_event_raw_kmem_alloc___x))
+		return true;
+	return false;
+}
+
 #endif /* __CORE_FIXES_BPF_H */
@@ -0,0 +1,76 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gadgets
+
+import (
+	"os"
+)
+
+// CostClass is the overhead a gadget adds to each of the kernel events it
+// traces
+type CostClass string
+
+const (
+	// CostLow is for rare events, like the executions or the mounts
+	CostLow CostClass = "low"
+	// CostMedium is for frequent events, like the file opens or the TCP
+	// connections
+	CostMedium CostClass = "medium"
+	// CostHigh is for the events happening all the time, like the syscalls,
+	// the packets or the capability checks
+	CostHigh CostClass = "high"
+)
+
+var costLevels = map[CostClass]int{
+	CostLow:    1,
+	CostMedium: 2,
+	CostHigh:   3,
+}
+
+// Level returns the order of the class, 0 if it's unknown
+func (c CostClass) Level() int {
+	return costLevels[c]
+}
+
+// Cost describes the overhead of running a gadget on a node
+type Cost struct {
+	// Probes is the number of kprobes, tracepoints, perf events, etc. the
+	// gadget attaches to
+	Probes int
+	// Events describes what the probes trace, e.g. "every syscall"
+	Events string
+	// EventCost is the overhead of the probes on each event
+	EventCost CostClass
+	// BufferSize is the memory of the buffers used to send the events to
+	// user space, per CPU
+	BufferSize uint64
+}
+
+// PerfBufferPages is the number of pages of the perf buffers created by the
+// gadgets, per CPU
+const PerfBufferPages = 64
+
+// PerfBufferSize returns the size of the perf buffers created by the gadgets,
+// per CPU
+func PerfBufferSize() uint64 {
+	return uint64(PerfBufferPages * os.Getpagesize())
+}
+
+// GadgetDescCost can be implemented by the gadgets to describe their overhead.
+// It's shown before running them and used to ask for a confirmation when it's
+// high.
+type GadgetDescCost interface {
+	Cost() Cost
+}
@@ -0,0 +1,89 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/generate/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
+)
+
+const (
+	ParamRate   = "rate"
+	ParamCount  = "count"
+	ParamFields = "fields"
+)
+
+type GadgetDesc struct{}
+
+func (g *GadgetDesc) Name() string {
+	return "generate"
+}
+
+func (g *GadgetDesc) Category() string {
+	return gadgets.CategoryNone
+}
+
+func (g *GadgetDesc) Type() gadgets.GadgetType {
+	return gadgets.TypeTrace
+}
+
+func (g *GadgetDesc) Description() string {
+	return "Generate synthetic events to test the sinks, filters and alerting rules without real activity"
+}
+
+func (g *GadgetDesc) ParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:          ParamRate,
+			Title:        "Rate",
+			DefaultValue: "1",
+			Description:  "Number of events generated per second",
+			TypeHint:     params.TypeUint32,
+			Validator:    params.ValidateUintRange(1, maxRate),
+		},
+		{
+			Key:          ParamCount,
+			Title:        "Count",
+			DefaultValue: "0",
+			Description:  "Number of events to generate before stopping, 0 to generate them until the gadget is stopped",
+			TypeHint:     params.TypeUint64,
+		},
+		{
+			Key:   ParamFields,
+			Title: "Fields",
+			Description: "Comma-separated fields of the events: name=value for a constant, or name:type with type one of " +
+				"string, int, bool, ip or enum(a|b|...) for a random value",
+			Validator: func(value string) error {
+				_, err := parseFields(value)
+				return err
+			},
+		},
+	}
+}
+
+func (g *GadgetDesc) Parser() parser.Parser {
+	return parser.NewParser[types.Event](types.GetColumns())
+}
+
+func (g *GadgetDesc) EventPrototype() any {
+	return &types.Event{}
+}
+
+func init() {
+	gadgetregistry.Register(&GadgetDesc{})
+}
@@ -0,0 +1,238 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/generate/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	maxRate = 100000
+
+	// Above 100 events per second, the events are generated in batches not
+	// to wake up more often than that
+	minInterval = 10 * time.Millisecond
+)
+
+// field is a field of the generated events, with a constant value or a
+// random one of a given type
+type field struct {
+	name     string
+	constant string
+	kind     string
+	values   []string
+}
+
+// parseFields parses the schema of the events: comma-separated name=value or
+// name:type
+func parseFields(value string) ([]field, error) {
+	var fields []field
+	if value == "" {
+		return fields, nil
+	}
+
+	names := make(map[string]struct{})
+	for _, spec := range strings.Split(value, ",") {
+		var f field
+		if name, constant, ok := strings.Cut(spec, "="); ok {
+			f = field{name: name, constant: constant}
+		} else if name, kind, ok := strings.Cut(spec, ":"); ok {
+			f = field{name: name, kind: kind}
+			switch {
+			case kind == "string", kind == "int", kind == "bool", kind == "ip":
+			case strings.HasPrefix(kind, "enum(") && strings.HasSuffix(kind, ")"):
+				f.kind = "enum"
+				f.values = strings.Split(strings.TrimSuffix(strings.TrimPrefix(kind, "enum("), ")"), "|")
+			default:
+				return nil, fmt.Errorf("unknown type %q of field %q", kind, name)
+			}
+		} else {
+			return nil, fmt.Errorf("field %q should be name=value or name:type", spec)
+		}
+
+		if f.name == "" {
+			return nil, fmt.Errorf("field %q has no name", spec)
+		}
+		if _, ok := names[f.name]; ok {
+			return nil, fmt.Errorf("field %q given twice", f.name)
+		}
+		names[f.name] = struct{}{}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// generate returns a value of the field. The random values are taken among a
+// few ones, to be aggregated and filtered on.
+func (f *field) generate(r *rand.Rand) any {
+	switch f.kind {
+	case "":
+		return f.constant
+	case "string":
+		return fmt.Sprintf("value-%d", r.Intn(10))
+	case "int":
+		return r.Intn(1000)
+	case "bool":
+		return r.Intn(2) == 1
+	case "ip":
+		return fmt.Sprintf("10.0.%d.%d", r.Intn(4), 1+r.Intn(254))
+	case "enum":
+		return f.values[r.Intn(len(f.values))]
+	}
+	return nil
+}
+
+type Config struct {
+	Rate   uint32
+	Count  uint64
+	Fields []field
+}
+
+type Tracer struct {
+	config        *Config
+	eventCallback func(*types.Event)
+	rand          *rand.Rand
+	seq           uint64
+
+	// containers are the mount namespaces of the containers selected by
+	// the filters, the events come from them in turn
+	mu         sync.Mutex
+	containers map[string]uint64
+	next       int
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return &Tracer{
+		config:     &Config{},
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+		containers: make(map[string]uint64),
+	}, nil
+}
+
+func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.Rate = params.Get(ParamRate).AsUint32()
+	t.config.Count = params.Get(ParamCount).AsUint64()
+
+	fields, err := parseFields(params.Get(ParamFields).AsString())
+	if err != nil {
+		return err
+	}
+	t.config.Fields = fields
+	return nil
+}
+
+func (t *Tracer) Close() {}
+
+func (t *Tracer) AttachContainer(c *containercollection.Container) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.containers[c.ID] = c.Mntns
+	return nil
+}
+
+func (t *Tracer) DetachContainer(c *containercollection.Container) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.containers, c.ID)
+	return nil
+}
+
+// nextMntns returns the mount namespace of the next container the events
+// come from, 0 for the host
+func (t *Tracer) nextMntns() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.containers) == 0 {
+		return 0
+	}
+	ids := make([]string, 0, len(t.containers))
+	for id := range t.containers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	t.next = (t.next + 1) % len(ids)
+	return t.containers[ids[t.next]]
+}
+
+func (t *Tracer) newEvent() *types.Event {
+	t.seq++
+	event := &types.Event{
+		Event: eventtypes.Event{
+			Type:      eventtypes.NORMAL,
+			Timestamp: eventtypes.Time(time.Now().UnixNano()),
+		},
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: t.nextMntns()},
+		Seq:           t.seq,
+	}
+	if len(t.config.Fields) > 0 {
+		event.Fields = make(map[string]any, len(t.config.Fields))
+		for i := range t.config.Fields {
+			f := &t.config.Fields[i]
+			event.Fields[f.name] = f.generate(t.rand)
+		}
+	}
+	return event
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	ctx, cancel := gadgetcontext.WithTimeoutOrCancel(gadgetCtx.Context(), gadgetCtx.Timeout())
+	defer cancel()
+
+	interval := time.Second / time.Duration(t.config.Rate)
+	if interval < minInterval {
+		interval = minInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	for {
+		// The first event is generated right away
+		due := uint64(time.Since(start).Seconds()*float64(t.config.Rate)) + 1
+		for t.seq < due {
+			if t.config.Count != 0 && t.seq >= t.config.Count {
+				return nil
+			}
+			t.eventCallback(t.newEvent())
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
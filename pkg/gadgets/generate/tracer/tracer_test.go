@@ -0,0 +1,79 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/generate/types"
+)
+
+func TestParseFields(t *testing.T) {
+	fields, err := parseFields("env=prod,user:string,status:enum(ok|failed),latency:int")
+	require.NoError(t, err)
+	require.Equal(t, []field{
+		{name: "env", constant: "prod"},
+		{name: "user", kind: "string"},
+		{name: "status", kind: "enum", values: []string{"ok", "failed"}},
+		{name: "latency", kind: "int"},
+	}, fields)
+
+	for _, invalid := range []string{"user", "user:float", ":int", "user:int,user:string"} {
+		_, err := parseFields(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestRun(t *testing.T) {
+	desc := &GadgetDesc{}
+	gadgetParams := desc.ParamDescs().ToParams()
+	require.NoError(t, gadgetParams.Set(ParamRate, "1000"))
+	require.NoError(t, gadgetParams.Set(ParamCount, "20"))
+	require.NoError(t, gadgetParams.Set(ParamFields, "env=prod,status:enum(ok|failed)"))
+
+	gadgetCtx := gadgetcontext.New(context.Background(), "", nil, nil, desc, gadgetParams,
+		nil, nil, log.StandardLogger(), 5*time.Second)
+
+	gadget, err := desc.NewInstance()
+	require.NoError(t, err)
+	tracer := gadget.(*Tracer)
+	require.NoError(t, tracer.Init(gadgetCtx))
+	tracer.AttachContainer(&containercollection.Container{ID: "a", Mntns: 1})
+	tracer.AttachContainer(&containercollection.Container{ID: "b", Mntns: 2})
+
+	var events []*types.Event
+	tracer.SetEventHandler(func(ev *types.Event) {
+		events = append(events, ev)
+	})
+	require.NoError(t, tracer.Run(gadgetCtx))
+
+	require.Len(t, events, 20)
+	mntns := make(map[uint64]int)
+	for i, event := range events {
+		require.Equal(t, uint64(i+1), event.Seq)
+		require.Equal(t, "prod", event.Fields["env"])
+		require.Contains(t, []any{"ok", "failed"}, event.Fields["status"])
+		mntns[event.MountNsID]++
+	}
+	// The events come from the containers in turn
+	require.Equal(t, map[uint64]int{1: 10, 2: 10}, mntns)
+}
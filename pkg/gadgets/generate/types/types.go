@@ -0,0 +1,66 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// Event is a synthetic event, its fields are given by the schema of the
+// gadget. It comes from one of the containers selected by the filters, taken
+// in turn, or from the host if none is selected.
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Seq    uint64         `json:"seq" column:"seq,minWidth:6"`
+	Fields map[string]any `json:"fields,omitempty"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	cols.MustAddColumn(columns.Attributes{
+		Name:    "fields",
+		Width:   50,
+		Visible: true,
+		Order:   1000,
+	}, func(e *Event) string {
+		keys := make([]string, 0, len(e.Fields))
+		for key := range e.Fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		pairs := make([]string, len(keys))
+		for i, key := range keys {
+			pairs[i] = fmt.Sprintf("%s=%v", key, e.Fields[key])
+		}
+		return strings.Join(pairs, " ")
+	})
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"fmt"
 	"net"
 	"net/netip"
+	"os"
+	"strings"
 	"sync"
 	"time"
 	"unsafe"
@@ -40,8 +42,6 @@ const (
 	// The Trace custom resource is preferably in the "gadget" namespace
 	TraceDefaultNamespace = "gadget"
 
-	PerfBufferPages = 64
-
 	// bpf_ktime_get_boot_ns()'s func id as defined in Linux API
 	// https://github.com/torvalds/linux/blob/v6.2-rc1/include/uapi/linux/bpf.h#L5614
 	BpfKtimeGetBootNsFuncID = 125
@@ -53,6 +53,14 @@ const (
 	// Name of the map that stores the mount namespace inode id to filter on.
 	// Keep in syn with name used in pkg/gadgets/common/mntns_filter.h.
 	MntNsFilterMapName = "gadget_mntns_filter_map"
+
+	// Constant used to enable filtering by cgroup in eBPF.
+	// Keep in sync with variable defined in pkg/gadgets/common/cgroup_filter.h.
+	FilterByCgroupName = "gadget_filter_by_cgroup"
+
+	// Name of the map that stores the cgroup to filter on.
+	// Keep in sync with name used in pkg/gadgets/common/cgroup_filter.h.
+	CgroupFilterMapName = "gadget_cgroup_filter_map"
 )
 
 // CloseLink closes l if it's not nil and returns nil
@@ -106,6 +114,25 @@ func FromCStringN(in []byte, length int) string {
 	return string(in[:l])
 }
 
+// PathFromComponents builds a path from its components as written by the
+// get_path() helpers of the eBPF programs: from the file to the root, each one
+// terminated by a NUL.
+func PathFromComponents(buf []byte) string {
+	components := strings.Split(strings.TrimSuffix(string(buf), "\x00"), "\x00")
+	var sb strings.Builder
+	for i := len(components) - 1; i >= 0; i-- {
+		if components[i] == "" {
+			continue
+		}
+		sb.WriteString("/")
+		sb.WriteString(components[i])
+	}
+	if sb.Len() == 0 {
+		return "/"
+	}
+	return sb.String()
+}
+
 func Htonl(hl uint32) uint32 {
 	var nl [4]byte
 	binary.BigEndian.PutUint32(nl[:], hl)
@@ -288,3 +315,21 @@ func LoadeBPFSpec(
 
 	return nil
 }
+
+// SetCgroupFilter stores the cgroup found at cgroupPath in cgroupMap, the
+// BPF_MAP_TYPE_CGROUP_ARRAY map defined in pkg/gadgets/common/cgroup_filter.h.
+// The eBPF program must have been loaded with FilterByCgroupName set to true.
+func SetCgroupFilter(cgroupMap *ebpf.Map, cgroupPath string) error {
+	f, err := os.Open(cgroupPath)
+	if err != nil {
+		return fmt.Errorf("opening cgroup %q: %w", cgroupPath, err)
+	}
+	// The kernel keeps its own reference to the cgroup once it's in the map.
+	defer f.Close()
+
+	if err := cgroupMap.Put(uint32(0), uint32(f.Fd())); err != nil {
+		return fmt.Errorf("updating cgroup filter map: %w", err)
+	}
+
+	return nil
+}
@@ -76,6 +76,13 @@ type GadgetDescSkipParams interface {
 	SkipParams() []params.ValueHint
 }
 
+// GadgetDescSeverityRules / SeverityRules() returns the rules classifying the
+// events of the gadget by default, e.g. "warn:status:!OK". The rules given by
+// the user are evaluated before them, see the Severity operator.
+type GadgetDescSeverityRules interface {
+	SeverityRules() []string
+}
+
 type OutputFormats map[string]OutputFormat
 
 // OutputFormat can hold alternative output formats for a gadget. Whenever
@@ -115,6 +122,27 @@ type EventEnricherSetter interface {
 	SetEventEnricher(func(ev any) error)
 }
 
+// SampleParser parses the raw samples of the eBPF programs of a gadget in user
+// space, like the network packets, into its events
+type SampleParser interface {
+	// ParseSample returns the events completed by a raw sample. netns is
+	// the network namespace the sample comes from, for network gadgets.
+	ParseSample(rawSample []byte, netns uint64) ([]any, error)
+
+	// DropNetns drops the state kept to parse the samples of a network
+	// namespace that isn't traced anymore
+	DropNetns(netns uint64)
+}
+
+// SampleParserSetter is implemented by the gadgets whose raw samples can be
+// parsed by another process than the one reading them, see
+// pkg/pipelineprocess. That process creates and initializes an instance of
+// the gadget, without running it, to use it as the SampleParser given here.
+type SampleParserSetter interface {
+	SampleParser
+	SetSampleParser(parser SampleParser)
+}
+
 // RunGadget is an interface that will be implemented by gadgets that are run in
 // the background and emit events as soon as they occur.
 type RunGadget interface {
@@ -154,6 +182,16 @@ type InitCloseGadget interface {
 	Close()
 }
 
+// ParamsUpdater is an optional interface that can be implemented by gadgets
+// whose params can be changed while they are running, without reloading their
+// eBPF programs and losing the state kept in the kernel. UpdateParams is called
+// with all the params of the gadget, including the changed ones, and must
+// return an error without changing anything if one of the changes isn't
+// supported.
+type ParamsUpdater interface {
+	UpdateParams(*params.Params) error
+}
+
 type Gadget any
 
 // GadgetInstantiate is the same interface as Gadget but adds one call to instantiate an actual
@@ -82,7 +82,9 @@ func newAttachment(
 
 	var opts ebpf.CollectionOptions
 
-	if socketEnricher != nil {
+	// Programs that can't be enriched with the process don't use the
+	// sockets map
+	if _, ok := spec.Maps[SocketsMapName]; ok && socketEnricher != nil {
 		u32netns := uint32(netns)
 		consts := map[string]interface{}{
 			"current_netns": u32netns,
@@ -127,6 +129,7 @@ func newAttachment(
 type Tracer[Event any] struct {
 	socketEnricher *socketenricher.SocketEnricher
 	spec           *ebpf.CollectionSpec
+	prepared       bool
 
 	// key: network namespace inode number
 	// value: Tracelet
@@ -136,8 +139,13 @@ type Tracer[Event any] struct {
 	bpfPerfMapName  string
 	bpfSocketAttach int
 
-	baseEvent    func(ev types.Event) *Event
-	processEvent func(rawSample []byte, netns uint64) (*Event, error)
+	baseEvent     func(ev types.Event) *Event
+	processEvents func(rawSample []byte, netns uint64) ([]*Event, error)
+	dropNetns     func(netns uint64)
+
+	// sampleParser parses the samples instead of processEvents when they are
+	// parsed by another process, see SetSampleParser
+	sampleParser gadgets.SampleParser
 
 	eventHandler func(ev *Event)
 }
@@ -150,35 +158,67 @@ func NewTracer[Event any](
 	baseEvent func(ev types.Event) *Event,
 	processEvent func(rawSample []byte, netns uint64) (*Event, error),
 ) (*Tracer[Event], error) {
-	gadgets.FixBpfKtimeGetBootNs(spec.Programs)
-
-	var socketEnricher *socketenricher.SocketEnricher
-	var err error
-
-	// Only create socket enricher if this is used by the tracer
-	for _, m := range spec.Maps {
-		if m.Name == SocketsMapName {
-			socketEnricher, err = socketenricher.NewSocketEnricher()
-			if err != nil {
-				// Non fatal: support kernels without BTF
-				log.Errorf("creating socket enricher: %s", err)
-			}
-			break
+	processEvents := func(rawSample []byte, netns uint64) ([]*Event, error) {
+		event, err := processEvent(rawSample, netns)
+		if err != nil || event == nil {
+			return nil, err
 		}
+		return []*Event{event}, nil
 	}
+	return NewStreamTracer(spec, bpfProgName, bpfPerfMapName, bpfSocketAttach, baseEvent, processEvents, nil)
+}
 
+// NewStreamTracer creates a tracer whose samples can complete several events,
+// like the ones of the protocols over TCP, decoded from several packets.
+// dropNetns, if not nil, is called when a network namespace isn't traced
+// anymore, to drop the state kept to decode its packets.
+func NewStreamTracer[Event any](
+	spec *ebpf.CollectionSpec,
+	bpfProgName string,
+	bpfPerfMapName string,
+	bpfSocketAttach int,
+	baseEvent func(ev types.Event) *Event,
+	processEvents func(rawSample []byte, netns uint64) ([]*Event, error),
+	dropNetns func(netns uint64),
+) (*Tracer[Event], error) {
 	return &Tracer[Event]{
-		socketEnricher:  socketEnricher,
 		spec:            spec,
 		attachments:     make(map[uint64]*attachment),
 		bpfProgName:     bpfProgName,
 		bpfPerfMapName:  bpfPerfMapName,
 		bpfSocketAttach: bpfSocketAttach,
 		baseEvent:       baseEvent,
-		processEvent:    processEvent,
+		processEvents:   processEvents,
+		dropNetns:       dropNetns,
 	}, nil
 }
 
+// prepare fixes the programs for the running kernel and creates the socket
+// enricher. It's done on the first attachment: the samples can be parsed
+// without privileges, by a tracer that is never attached.
+func (t *Tracer[Event]) prepare() {
+	if t.prepared {
+		return
+	}
+	t.prepared = true
+
+	gadgets.FixBpfKtimeGetBootNs(t.spec.Programs)
+
+	// Only create socket enricher if this is used by the tracer
+	for _, m := range t.spec.Maps {
+		if m.Name == SocketsMapName {
+			socketEnricher, err := socketenricher.NewSocketEnricher()
+			if err != nil {
+				// Non fatal: support kernels without BTF
+				log.Errorf("creating socket enricher: %s", err)
+				break
+			}
+			t.socketEnricher = socketEnricher
+			break
+		}
+	}
+}
+
 func (t *Tracer[Event]) Attach(pid uint32, eventCallback func(*Event)) error {
 	netns, err := containerutils.GetNetNs(int(pid))
 	if err != nil {
@@ -189,6 +229,7 @@ func (t *Tracer[Event]) Attach(pid uint32, eventCallback func(*Event)) error {
 		return nil
 	}
 
+	t.prepare()
 	a, err := newAttachment(pid, netns, t.socketEnricher, t.spec,
 		t.bpfProgName, t.bpfPerfMapName, t.bpfSocketAttach)
 	if err != nil {
@@ -196,11 +237,38 @@ func (t *Tracer[Event]) Attach(pid uint32, eventCallback func(*Event)) error {
 	}
 	t.attachments[netns] = a
 
-	go t.listen(netns, a.perfRd, t.baseEvent, t.processEvent, eventCallback)
+	go t.listen(netns, a.perfRd, t.baseEvent, t.sampleParser, eventCallback)
 
 	return nil
 }
 
+// ParseSample returns the events completed by a raw sample of the network
+// namespace netns, see gadgets.SampleParser
+func (t *Tracer[Event]) ParseSample(rawSample []byte, netns uint64) ([]any, error) {
+	events, err := t.processEvents(rawSample, netns)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]any, 0, len(events))
+	for _, event := range events {
+		out = append(out, event)
+	}
+	return out, nil
+}
+
+// DropNetns drops the state kept to parse the samples of a network namespace
+func (t *Tracer[Event]) DropNetns(netns uint64) {
+	if t.dropNetns != nil {
+		t.dropNetns(netns)
+	}
+}
+
+// SetSampleParser makes the tracer give the samples of the network namespaces
+// it attaches to after this call to parser, instead of parsing them
+func (t *Tracer[Event]) SetSampleParser(parser gadgets.SampleParser) {
+	t.sampleParser = parser
+}
+
 func (t *Tracer[Event]) SetEventHandler(handler any) {
 	nh, ok := handler.(func(ev *Event))
 	if !ok {
@@ -221,9 +289,13 @@ func (t *Tracer[Event]) listen(
 	netns uint64,
 	rd *perf.Reader,
 	baseEvent func(ev types.Event) *Event,
-	processEvent func(rawSample []byte, netns uint64) (*Event, error),
+	sampleParser gadgets.SampleParser,
 	eventCallback func(*Event),
 ) {
+	if sampleParser == nil {
+		sampleParser = t
+	}
+
 	for {
 		record, err := rd.Read()
 		if err != nil {
@@ -242,15 +314,20 @@ func (t *Tracer[Event]) listen(
 			continue
 		}
 
-		event, err := processEvent(record.RawSample, netns)
+		events, err := sampleParser.ParseSample(record.RawSample, netns)
 		if err != nil {
 			eventCallback(baseEvent(types.Err(err.Error())))
 			continue
 		}
-		if event == nil {
-			continue
+		for _, ev := range events {
+			event, ok := ev.(*Event)
+			if !ok {
+				msg := fmt.Sprintf("unexpected event type %T in netns %d", ev, netns)
+				eventCallback(baseEvent(types.Err(msg)))
+				continue
+			}
+			eventCallback(event)
 		}
-		eventCallback(event)
 	}
 }
 
@@ -259,6 +336,12 @@ func (t *Tracer[Event]) releaseAttachment(netns uint64, a *attachment) {
 	unix.Close(a.sockFd)
 	a.collection.Close()
 	delete(t.attachments, netns)
+
+	if t.sampleParser != nil {
+		t.sampleParser.DropNetns(netns)
+	} else {
+		t.DropNetns(netns)
+	}
 }
 
 func (t *Tracer[Event]) Detach(pid uint32) error {
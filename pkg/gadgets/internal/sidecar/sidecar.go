@@ -0,0 +1,139 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sidecar detects the traffic handled by service mesh sidecar proxies.
+// Meshes transparently redirect the traffic of the pod to the proxy with
+// iptables, so the same connection is seen once between the application and
+// the proxy and once between the proxy and the remote endpoint.
+//
+// The defaults below are the ones of Istio and Linkerd. Meshes configured
+// differently, e.g. with another proxy.uid for Linkerd, can be described with
+// the INSPEKTOR_GADGET_SIDECAR_PORTS and INSPEKTOR_GADGET_SIDECAR_UIDS
+// environment variables, e.g. INSPEKTOR_GADGET_SIDECAR_UIDS=2103=linkerd.
+// Their entries are added to the defaults.
+package sidecar
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	Envoy   = "envoy"
+	Linkerd = "linkerd"
+
+	portsEnv = "INSPEKTOR_GADGET_SIDECAR_PORTS"
+	uidsEnv  = "INSPEKTOR_GADGET_SIDECAR_UIDS"
+)
+
+// redirectPorts are the ports the iptables rules installed by the meshes
+// redirect the outbound and inbound traffic to:
+//   - Istio: 15001 (outbound) and 15006 (inbound), see
+//     https://istio.io/latest/docs/ops/deployment/application-requirements/#ports-used-by-istio
+//   - Linkerd: 4140 (outbound) and 4143 (inbound), the proxy.ports.outbound
+//     and proxy.ports.inbound values of its Helm chart
+var redirectPorts = map[uint16]string{
+	15001: Envoy,
+	15006: Envoy,
+	4140:  Linkerd,
+	4143:  Linkerd,
+}
+
+// proxyUIDs are the default users the proxies run as. The iptables rules
+// exclude them from the redirection: 1337 for istio-proxy, see the link above,
+// and 2102 for Linkerd, the proxy.uid value of its Helm chart.
+var proxyUIDs = map[uint32]string{
+	1337: Envoy,
+	2102: Linkerd,
+}
+
+var proxyComms = map[string]string{
+	"envoy":          Envoy,
+	"linkerd2-proxy": Linkerd,
+}
+
+func init() {
+	if err := configure(os.Getenv(portsEnv), os.Getenv(uidsEnv)); err != nil {
+		log.Warnf("sidecar detection: %s", err)
+	}
+}
+
+// configure adds the ports and UIDs given as "id=sidecar" comma-separated
+// lists to the known ones
+func configure(ports, uids string) error {
+	parsedPorts, err := parseIDs(ports, 16)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", portsEnv, err)
+	}
+	parsedUIDs, err := parseIDs(uids, 32)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", uidsEnv, err)
+	}
+
+	for port, s := range parsedPorts {
+		redirectPorts[uint16(port)] = s
+	}
+	for uid, s := range parsedUIDs {
+		proxyUIDs[uint32(uid)] = s
+	}
+	return nil
+}
+
+func parseIDs(value string, bitSize int) (map[uint64]string, error) {
+	ids := map[uint64]string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		idStr, s, ok := strings.Cut(entry, "=")
+		s = strings.TrimSpace(s)
+		if !ok || s == "" {
+			return nil, fmt.Errorf("invalid entry %q, expected id=sidecar", entry)
+		}
+		id, err := strconv.ParseUint(strings.TrimSpace(idStr), 10, bitSize)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entry %q: %w", entry, err)
+		}
+		ids[id] = s
+	}
+	return ids, nil
+}
+
+// FromPorts returns the sidecar the traffic was redirected to, if any of the
+// given ports is a redirection port, or an empty string otherwise.
+func FromPorts(ports ...uint16) string {
+	for _, port := range ports {
+		if s, ok := redirectPorts[port]; ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// FromProcess returns the sidecar the given process belongs to, or an empty
+// string if it isn't a known sidecar proxy.
+func FromProcess(comm string, uid uint32) string {
+	if s, ok := proxyComms[comm]; ok {
+		return s
+	}
+	if s, ok := proxyUIDs[uid]; ok {
+		return s
+	}
+	return ""
+}
@@ -0,0 +1,160 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sidecar
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromPorts(t *testing.T) {
+	table := []struct {
+		description string
+		ports       []uint16
+		expected    string
+	}{
+		{"istio_outbound", []uint16{43210, 15001}, Envoy},
+		{"istio_inbound", []uint16{15006, 43210}, Envoy},
+		{"linkerd_outbound", []uint16{43210, 4140}, Linkerd},
+		{"linkerd_inbound", []uint16{4143, 43210}, Linkerd},
+		{"application", []uint16{43210, 8080}, ""},
+		{"istio_admin_port", []uint16{15000}, ""},
+		{"no_ports", nil, ""},
+	}
+
+	for _, entry := range table {
+		require.Equal(t, entry.expected, FromPorts(entry.ports...), entry.description)
+	}
+}
+
+func TestFromProcess(t *testing.T) {
+	table := []struct {
+		description string
+		comm        string
+		uid         uint32
+		expected    string
+	}{
+		{"envoy_comm", "envoy", 0, Envoy},
+		{"envoy_uid", "pilot-agent", 1337, Envoy},
+		{"linkerd_comm", "linkerd2-proxy", 0, Linkerd},
+		{"linkerd_uid", "proxy", 2102, Linkerd},
+		{"application", "nginx", 101, ""},
+		{"root", "curl", 0, ""},
+	}
+
+	for _, entry := range table {
+		require.Equal(t, entry.expected, FromProcess(entry.comm, entry.uid), entry.description)
+	}
+}
+
+func TestParseIDs(t *testing.T) {
+	t.Parallel()
+
+	table := []struct {
+		description string
+		value       string
+		bitSize     int
+		expected    map[uint64]string
+		expectedErr bool
+	}{
+		{
+			description: "empty",
+			value:       "",
+			bitSize:     16,
+			expected:    map[uint64]string{},
+		},
+		{
+			description: "entries",
+			value:       "15001=envoy, 4140 = linkerd,",
+			bitSize:     16,
+			expected:    map[uint64]string{15001: Envoy, 4140: Linkerd},
+		},
+		{
+			description: "uid",
+			value:       "100000=envoy",
+			bitSize:     32,
+			expected:    map[uint64]string{100000: Envoy},
+		},
+		{
+			description: "port_out_of_range",
+			value:       "100000=envoy",
+			bitSize:     16,
+			expectedErr: true,
+		},
+		{
+			description: "missing_sidecar",
+			value:       "15001=",
+			bitSize:     16,
+			expectedErr: true,
+		},
+		{
+			description: "missing_separator",
+			value:       "15001",
+			bitSize:     16,
+			expectedErr: true,
+		},
+		{
+			description: "not_a_number",
+			value:       "http=envoy",
+			bitSize:     16,
+			expectedErr: true,
+		},
+	}
+
+	for _, entry := range table {
+		entry := entry
+		t.Run(entry.description, func(t *testing.T) {
+			t.Parallel()
+
+			ids, err := parseIDs(entry.value, entry.bitSize)
+			if entry.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, entry.expected, ids)
+		})
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	// Restore the defaults for the other tests
+	ports := map[uint16]string{}
+	for k, v := range redirectPorts {
+		ports[k] = v
+	}
+	uids := map[uint32]string{}
+	for k, v := range proxyUIDs {
+		uids[k] = v
+	}
+	t.Cleanup(func() {
+		redirectPorts = ports
+		proxyUIDs = uids
+	})
+
+	require.NoError(t, configure("15021=envoy,4191=linkerd", "2103=linkerd"))
+	require.Equal(t, Envoy, FromPorts(15021))
+	require.Equal(t, Linkerd, FromPorts(4191))
+	require.Equal(t, Linkerd, FromProcess("proxy", 2103))
+
+	// The defaults are kept
+	require.Equal(t, Envoy, FromPorts(15001))
+	require.Equal(t, Linkerd, FromProcess("proxy", 2102))
+
+	// Nothing is added if any of the entries is invalid
+	require.Error(t, configure("15022=envoy", "invalid"))
+	require.Equal(t, "", FromPorts(15022))
+}
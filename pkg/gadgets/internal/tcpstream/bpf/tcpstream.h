@@ -0,0 +1,46 @@
+#ifndef GADGET_TCPSTREAM_H
+#define GADGET_TCPSTREAM_H
+
+#define TASK_COMM_LEN	16
+
+// Maximum number of ports of the servers
+#define MAX_PORTS	16
+
+// Size of the biggest packet sent to userspace. Perf samples can't be bigger
+// than 64KiB, the end of bigger (GSO) packets is lost.
+#define MAX_PACKET_SIZE	65000
+
+#define FLAG_FIN	(1 << 0)
+#define FLAG_RST	(1 << 1)
+
+// A connection to a server, seen from the client
+struct conn_t {
+	__u8 client[16];
+	__u8 server[16];
+	__u16 client_port;
+	__u16 server_port;
+};
+
+// The event is followed by the first bytes of the packet, starting at the
+// ethernet header
+struct event_t {
+	__u64 timestamp;
+	__u64 mount_ns_id;
+	__u32 pid;
+	__u32 tid;
+	__u8 task[TASK_COMM_LEN];
+
+	struct conn_t conn;
+	__u32 af; // AF_INET or AF_INET6
+
+	// TCP sequence number of the first byte of the payload
+	__u32 seq;
+	// Offset and length of the TCP payload in the packet
+	__u32 payload_offset;
+	__u32 payload_len;
+	__u8 from_client;
+	__u8 flags;
+	__u8 pkt_type;
+};
+
+#endif
@@ -0,0 +1,190 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tcpstream is the base of the gadgets decoding the protocol of the
+// TCP connections to some ports, like trace kafka or trace redis: the packets
+// from and to the ports are sent to userspace by a socket filter, and the
+// payload of each connection is given in order to the protocol decoder of the
+// gadget.
+package tcpstream
+
+import (
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// ConnKey identifies a connection, from the point of view of the client
+type ConnKey struct {
+	Netns      uint64
+	ClientIP   string
+	ServerIP   string
+	ClientPort uint16
+	ServerPort uint16
+}
+
+// Process is the process owning the socket that sent or received a segment
+type Process struct {
+	MountNsID uint64
+	Pid       uint32
+	Tid       uint32
+	Comm      string
+}
+
+// Segment is the TCP payload of a packet of a connection
+type Segment struct {
+	Key        ConnKey
+	FromClient bool
+	// Outgoing tells whether the packet was sent by the traced container
+	Outgoing bool
+	Seq      uint32
+	// Payload is the part of the payload that was captured, Length the
+	// length of the whole payload
+	Payload   []byte
+	Length    uint32
+	FIN       bool
+	RST       bool
+	Timestamp eventtypes.Time
+	BootTime  uint64
+	Process   Process
+}
+
+// Decoder decodes the protocol of a connection
+type Decoder[Event any] interface {
+	// Sync tells whether the direction of the connection the segment was
+	// sent in can be decoded from the beginning of its payload on
+	Sync(seg *Segment) bool
+	// Decode decodes the payload of a segment, it follows the payload of
+	// the previous segment sent in the same direction. missing is the
+	// number of bytes at the end of the payload that weren't captured.
+	// It returns the events completed by the segment, the connection
+	// isn't traced anymore once it returns an error.
+	Decode(seg *Segment, payload []byte, missing uint32) ([]*Event, error)
+}
+
+// Protocol creates the decoders of the connections
+type Protocol[Event any] interface {
+	// NewDecoder returns the decoder of a connection seen for the first
+	// time, or nil if it can't be traced from this segment on
+	NewDecoder(seg *Segment) Decoder[Event]
+	// DropNetns forgets what was learnt about a network namespace that
+	// isn't traced anymore
+	DropNetns(netns uint64)
+}
+
+// halfConn is one direction of a connection
+type halfConn struct {
+	synced  bool
+	nextSeq uint32
+}
+
+type conn[Event any] struct {
+	// 0: from the client, 1: from the server
+	halves  [2]halfConn
+	decoder Decoder[Event]
+	// broken is set when data was lost or couldn't be decoded: the
+	// connection isn't traced anymore
+	broken bool
+}
+
+// Conns tracks the connections and gives the payload of their segments in
+// order to their decoder
+type Conns[Event any] struct {
+	protocol Protocol[Event]
+	conns    map[ConnKey]*conn[Event]
+}
+
+func NewConns[Event any](protocol Protocol[Event]) *Conns[Event] {
+	return &Conns[Event]{
+		protocol: protocol,
+		conns:    make(map[ConnKey]*conn[Event]),
+	}
+}
+
+// Process adds a segment to its connection and returns the events completed
+// by it
+func (c *Conns[Event]) Process(seg *Segment) []*Event {
+	cn, ok := c.conns[seg.Key]
+	if !ok {
+		// The connections established before the gadget started are
+		// traced from the first segment the decoder accepts
+		decoder := c.protocol.NewDecoder(seg)
+		if decoder == nil {
+			return nil
+		}
+		cn = &conn[Event]{decoder: decoder}
+		c.conns[seg.Key] = cn
+	}
+
+	var events []*Event
+	if !cn.broken {
+		events = cn.process(seg)
+	}
+
+	if seg.FIN || seg.RST {
+		delete(c.conns, seg.Key)
+	}
+	return events
+}
+
+// DropNetns forgets the connections of a network namespace that isn't traced
+// anymore
+func (c *Conns[Event]) DropNetns(netns uint64) {
+	for key := range c.conns {
+		if key.Netns == netns {
+			delete(c.conns, key)
+		}
+	}
+	c.protocol.DropNetns(netns)
+}
+
+func (c *conn[Event]) process(seg *Segment) []*Event {
+	dir := 0
+	if !seg.FromClient {
+		dir = 1
+	}
+	h := &c.halves[dir]
+
+	payload := seg.Payload
+	length := seg.Length
+	if !h.synced {
+		if !c.decoder.Sync(seg) {
+			return nil
+		}
+		h.synced = true
+		h.nextSeq = seg.Seq
+	} else {
+		// Retransmissions and packets captured twice on the loopback
+		// interface
+		diff := int32(h.nextSeq - seg.Seq)
+		if diff < 0 {
+			c.broken = true
+			return nil
+		}
+		if uint32(diff) >= length {
+			return nil
+		}
+		length -= uint32(diff)
+		if diff >= int32(len(payload)) {
+			payload = nil
+		} else {
+			payload = payload[diff:]
+		}
+	}
+	h.nextSeq += length
+
+	events, err := c.decoder.Decode(seg, payload, length-uint32(len(payload)))
+	if err != nil {
+		c.broken = true
+	}
+	return events
+}
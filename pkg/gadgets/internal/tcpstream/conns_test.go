@@ -0,0 +1,144 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcpstream_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/tcpstream"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/tcpstream/testutils"
+)
+
+// chunk is the payload given to the decoder
+type chunk struct {
+	fromClient bool
+	payload    string
+	missing    uint32
+}
+
+// echoProtocol reports every payload given to the decoders as an event.
+// The connections start with a "hello" from the client, a payload starting
+// with "bad" can't be decoded.
+type echoProtocol struct {
+	dropped []uint64
+}
+
+type echoDecoder struct{}
+
+func (p *echoProtocol) NewDecoder(seg *tcpstream.Segment) tcpstream.Decoder[chunk] {
+	if !seg.FromClient || string(seg.Payload) != "hello" {
+		return nil
+	}
+	return &echoDecoder{}
+}
+
+func (p *echoProtocol) DropNetns(netns uint64) {
+	p.dropped = append(p.dropped, netns)
+}
+
+func (d *echoDecoder) Sync(seg *tcpstream.Segment) bool {
+	return len(seg.Payload) > 0
+}
+
+func (d *echoDecoder) Decode(seg *tcpstream.Segment, payload []byte, missing uint32) ([]*chunk, error) {
+	if len(payload) >= 3 && string(payload[:3]) == "bad" {
+		return nil, errors.New("bad payload")
+	}
+	return []*chunk{{fromClient: seg.FromClient, payload: string(payload), missing: missing}}, nil
+}
+
+func expectChunk(t *testing.T, events []*chunk, fromClient bool, payload string, missing uint32) {
+	t.Helper()
+	testutils.ExpectEvents(t, events, 1)
+	if events[0].fromClient != fromClient || events[0].payload != payload || events[0].missing != missing {
+		t.Fatalf("Invalid chunk %+v", events[0])
+	}
+}
+
+func TestConnsSync(t *testing.T) {
+	protocol := &echoProtocol{}
+	c := tcpstream.NewConns[chunk](protocol)
+	client, server := testutils.NewPeers(80, "client")
+
+	// Not traced until the client says hello
+	testutils.ExpectEvents(t, c.Process(server.Segment([]byte("welcome"), 1000)), 0)
+	testutils.ExpectEvents(t, c.Process(client.Segment([]byte("hi"), 1000)), 0)
+	expectChunk(t, c.Process(client.Segment([]byte("hello"), 2000)), true, "hello", 0)
+
+	// Each direction starts with the first segment with a payload
+	testutils.ExpectEvents(t, c.Process(server.Segment(nil, 3000)), 0)
+	expectChunk(t, c.Process(server.Segment([]byte("world"), 4000)), false, "world", 0)
+
+	c.DropNetns(1)
+	if len(protocol.dropped) != 1 || protocol.dropped[0] != 1 {
+		t.Fatalf("Network namespace not dropped: %v", protocol.dropped)
+	}
+	testutils.ExpectEvents(t, c.Process(client.Segment([]byte("again"), 5000)), 0)
+}
+
+func TestConnsRetransmission(t *testing.T) {
+	c := tcpstream.NewConns[chunk](&echoProtocol{})
+	client, _ := testutils.NewPeers(80, "client")
+
+	expectChunk(t, c.Process(client.Segment([]byte("hello"), 1000)), true, "hello", 0)
+
+	// Segments captured twice are ignored, the new part of overlapping
+	// ones is decoded
+	seg := client.Segment([]byte("foo"), 2000)
+	expectChunk(t, c.Process(seg), true, "foo", 0)
+	testutils.ExpectEvents(t, c.Process(seg), 0)
+	seg = client.Segment([]byte("bar"), 3000)
+	seg.Seq -= 3
+	seg.Payload = []byte("foobar")
+	seg.Length = 6
+	expectChunk(t, c.Process(seg), true, "bar", 0)
+
+	// The end of the payload wasn't captured
+	seg = client.Segment([]byte("truncated"), 4000)
+	seg.Payload = seg.Payload[:5]
+	expectChunk(t, c.Process(seg), true, "trunc", 4)
+}
+
+func TestConnsBroken(t *testing.T) {
+	for name, seg := range map[string]func(client *testutils.Peer) *tcpstream.Segment{
+		"lost_segment": func(client *testutils.Peer) *tcpstream.Segment {
+			client.Segment([]byte("lost"), 2000)
+			return client.Segment([]byte("next"), 3000)
+		},
+		"decoding_error": func(client *testutils.Peer) *tcpstream.Segment {
+			return client.Segment([]byte("bad"), 2000)
+		},
+	} {
+		seg := seg
+		t.Run(name, func(t *testing.T) {
+			c := tcpstream.NewConns[chunk](&echoProtocol{})
+			client, server := testutils.NewPeers(80, "client")
+
+			expectChunk(t, c.Process(client.Segment([]byte("hello"), 1000)), true, "hello", 0)
+			testutils.ExpectEvents(t, c.Process(seg(client)), 0)
+
+			// The connection isn't traced anymore
+			testutils.ExpectEvents(t, c.Process(client.Segment([]byte("more"), 4000)), 0)
+			testutils.ExpectEvents(t, c.Process(server.Segment([]byte("reply"), 5000)), 0)
+
+			// Until it's closed
+			end := server.Segment(nil, 6000)
+			end.RST = true
+			testutils.ExpectEvents(t, c.Process(end), 0)
+			expectChunk(t, c.Process(client.Segment([]byte("hello"), 7000)), true, "hello", 0)
+		})
+	}
+}
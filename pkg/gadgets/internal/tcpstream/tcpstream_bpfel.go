@@ -0,0 +1,163 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64 || amd64p32 || arm || arm64 || loong64 || mips64le || mips64p32le || mipsle || ppc64le || riscv64
+
+package tcpstream
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type tcpstreamEventT struct {
+	Timestamp uint64
+	MountNsId uint64
+	Pid       uint32
+	Tid       uint32
+	Task      [16]uint8
+	Conn      struct {
+		Client     [16]uint8
+		Server     [16]uint8
+		ClientPort uint16
+		ServerPort uint16
+	}
+	Af            uint32
+	Seq           uint32
+	PayloadOffset uint32
+	PayloadLen    uint32
+	FromClient    uint8
+	Flags         uint8
+	PktType       uint8
+	_             [1]byte
+}
+
+type tcpstreamSocketsKey struct {
+	Netns  uint32
+	Family uint16
+	Proto  uint16
+	Port   uint16
+	_      [2]byte
+}
+
+type tcpstreamSocketsValue struct {
+	Mntns             uint64
+	PidTgid           uint64
+	Task              [16]int8
+	Sock              uint64
+	DeletionTimestamp uint64
+}
+
+// loadTcpstream returns the embedded CollectionSpec for tcpstream.
+func loadTcpstream() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_TcpstreamBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load tcpstream: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadTcpstreamObjects loads tcpstream and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*tcpstreamObjects
+//	*tcpstreamPrograms
+//	*tcpstreamMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadTcpstreamObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadTcpstream()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// tcpstreamSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type tcpstreamSpecs struct {
+	tcpstreamProgramSpecs
+	tcpstreamMapSpecs
+}
+
+// tcpstreamSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type tcpstreamProgramSpecs struct {
+	IgTcpStream *ebpf.ProgramSpec `ebpf:"ig_tcp_stream"`
+}
+
+// tcpstreamMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type tcpstreamMapSpecs struct {
+	Events  *ebpf.MapSpec `ebpf:"events"`
+	Ports   *ebpf.MapSpec `ebpf:"ports"`
+	Sockets *ebpf.MapSpec `ebpf:"sockets"`
+}
+
+// tcpstreamObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadTcpstreamObjects or ebpf.CollectionSpec.LoadAndAssign.
+type tcpstreamObjects struct {
+	tcpstreamPrograms
+	tcpstreamMaps
+}
+
+func (o *tcpstreamObjects) Close() error {
+	return _TcpstreamClose(
+		&o.tcpstreamPrograms,
+		&o.tcpstreamMaps,
+	)
+}
+
+// tcpstreamMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadTcpstreamObjects or ebpf.CollectionSpec.LoadAndAssign.
+type tcpstreamMaps struct {
+	Events  *ebpf.Map `ebpf:"events"`
+	Ports   *ebpf.Map `ebpf:"ports"`
+	Sockets *ebpf.Map `ebpf:"sockets"`
+}
+
+func (m *tcpstreamMaps) Close() error {
+	return _TcpstreamClose(
+		m.Events,
+		m.Ports,
+		m.Sockets,
+	)
+}
+
+// tcpstreamPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadTcpstreamObjects or ebpf.CollectionSpec.LoadAndAssign.
+type tcpstreamPrograms struct {
+	IgTcpStream *ebpf.Program `ebpf:"ig_tcp_stream"`
+}
+
+func (p *tcpstreamPrograms) Close() error {
+	return _TcpstreamClose(
+		p.IgTcpStream,
+	)
+}
+
+func _TcpstreamClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed tcpstream_bpfel.o
+var _TcpstreamBytes []byte
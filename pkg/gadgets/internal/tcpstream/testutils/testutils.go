@@ -0,0 +1,70 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package testutils generates the segments of a connection to test the
+// protocol decoders of the gadgets based on tcpstream.
+package testutils
+
+import (
+	"testing"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/tcpstream"
+)
+
+// Peer generates the segments sent by one side of a connection
+type Peer struct {
+	FromClient bool
+	Seq        uint32
+	Key        tcpstream.ConnKey
+	Process    tcpstream.Process
+}
+
+// NewPeers returns the client and the server of a connection to the given
+// port, traced in the network namespace of the process of the client
+func NewPeers(serverPort uint16, comm string) (*Peer, *Peer) {
+	key := tcpstream.ConnKey{
+		Netns:      1,
+		ClientIP:   "10.0.0.2",
+		ServerIP:   "10.0.0.3",
+		ClientPort: 45678,
+		ServerPort: serverPort,
+	}
+	process := tcpstream.Process{Pid: 1234, Comm: comm}
+	client := &Peer{FromClient: true, Seq: 1000, Key: key, Process: process}
+	server := &Peer{Seq: 5000, Key: key, Process: process}
+	return client, server
+}
+
+// Segment returns the next segment sent by the peer
+func (p *Peer) Segment(payload []byte, bootTime uint64) *tcpstream.Segment {
+	seg := &tcpstream.Segment{
+		Key:        p.Key,
+		FromClient: p.FromClient,
+		Outgoing:   p.FromClient,
+		Seq:        p.Seq,
+		Payload:    payload,
+		Length:     uint32(len(payload)),
+		BootTime:   bootTime,
+		Process:    p.Process,
+	}
+	p.Seq += uint32(len(payload))
+	return seg
+}
+
+func ExpectEvents[Event any](t *testing.T, events []*Event, n int) {
+	t.Helper()
+	if len(events) != n {
+		t.Fatalf("Got %d events, expecting %d: %+v", len(events), n, events)
+	}
+}
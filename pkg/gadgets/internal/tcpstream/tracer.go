@@ -0,0 +1,164 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tcpstream
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/networktracer"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate bash -c "source ./clangosflags.sh; go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -cc clang -type event_t tcpstream ./bpf/tcpstream.c -- $CLANG_OS_FLAGS -I./bpf/ -I../socketenricher/bpf"
+
+const (
+	BPFProgName     = "ig_tcp_stream"
+	BPFPerfMapName  = "events"
+	BPFSocketAttach = 50
+)
+
+// Flags of the events, from tcpstream.h
+const (
+	flagFIN = 1 << 0
+	flagRST = 1 << 1
+)
+
+// MAX_PORTS from tcpstream.h
+const maxPorts = 16
+
+// PACKET_OUTGOING from include/uapi/linux/if_packet.h
+const packetOutgoing = 4
+
+// Tracer decodes the connections from and to some ports of the traced
+// containers with the decoders of a protocol
+type Tracer[Event any] struct {
+	*networktracer.Tracer[Event]
+
+	// The events are decoded from several packets, a packet can complete
+	// several events
+	mu    sync.Mutex
+	conns *Conns[Event]
+}
+
+// NewTracer creates a tracer decoding the connections to the given ports
+func NewTracer[Event any](
+	ports []uint16,
+	protocol Protocol[Event],
+	baseEvent func(ev eventtypes.Event) *Event,
+) (*Tracer[Event], error) {
+	if len(ports) > maxPorts {
+		return nil, fmt.Errorf("too many ports: %d, the maximum is %d", len(ports), maxPorts)
+	}
+
+	spec, err := loadTcpstream()
+	if err != nil {
+		return nil, fmt.Errorf("loading asset: %w", err)
+	}
+	m := spec.Maps["ports"]
+	for _, port := range ports {
+		m.Contents = append(m.Contents, ebpf.MapKV{Key: port, Value: uint8(1)})
+	}
+
+	t := &Tracer[Event]{
+		conns: NewConns(protocol),
+	}
+
+	networkTracer, err := networktracer.NewStreamTracer(
+		spec,
+		BPFProgName,
+		BPFPerfMapName,
+		BPFSocketAttach,
+		baseEvent,
+		t.parseEvent,
+		t.dropNetns,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("creating network tracer: %w", err)
+	}
+	t.Tracer = networkTracer
+	return t, nil
+}
+
+func (t *Tracer[Event]) parseEvent(sample []byte, netns uint64) ([]*Event, error) {
+	bpfEvent := (*tcpstreamEventT)(unsafe.Pointer(&sample[0]))
+	eventSize := int(unsafe.Sizeof(*bpfEvent))
+	if len(sample) < eventSize {
+		return nil, errors.New("invalid sample size")
+	}
+
+	// The packet follows the event, it can be truncated
+	packet := sample[eventSize:]
+	start := int(bpfEvent.PayloadOffset)
+	if start > len(packet) {
+		start = len(packet)
+	}
+	end := start + int(bpfEvent.PayloadLen)
+	if end > len(packet) {
+		end = len(packet)
+	}
+
+	seg := &Segment{
+		Key:        ConnKey{Netns: netns},
+		FromClient: bpfEvent.FromClient != 0,
+		Outgoing:   bpfEvent.PktType == packetOutgoing,
+		Seq:        bpfEvent.Seq,
+		Payload:    packet[start:end],
+		Length:     bpfEvent.PayloadLen,
+		FIN:        bpfEvent.Flags&flagFIN != 0,
+		RST:        bpfEvent.Flags&flagRST != 0,
+		Timestamp:  gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+		BootTime:   bpfEvent.Timestamp,
+		Process: Process{
+			MountNsID: bpfEvent.MountNsId,
+			Pid:       bpfEvent.Pid,
+			Tid:       bpfEvent.Tid,
+			Comm:      gadgets.FromCString(bpfEvent.Task[:]),
+		},
+	}
+
+	switch bpfEvent.Af {
+	case syscall.AF_INET:
+		seg.Key.ClientIP = gadgets.IPStringFromBytes(bpfEvent.Conn.Client, 4)
+		seg.Key.ServerIP = gadgets.IPStringFromBytes(bpfEvent.Conn.Server, 4)
+	case syscall.AF_INET6:
+		seg.Key.ClientIP = gadgets.IPStringFromBytes(bpfEvent.Conn.Client, 6)
+		seg.Key.ServerIP = gadgets.IPStringFromBytes(bpfEvent.Conn.Server, 6)
+	default:
+		return nil, fmt.Errorf("unknown address family %d", bpfEvent.Af)
+	}
+	seg.Key.ClientPort = bpfEvent.Conn.ClientPort
+	seg.Key.ServerPort = bpfEvent.Conn.ServerPort
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.conns.Process(seg), nil
+}
+
+// dropNetns drops the connections of a network namespace that isn't traced
+// anymore
+func (t *Tracer[Event]) dropNetns(netns uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.conns.DropNetns(netns)
+}
@@ -0,0 +1,140 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build arm64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type biocontainerHist struct {
+	Latency uint64
+	Cnt     uint64
+	Slots   [27]uint32
+	_       [4]byte
+}
+
+type biocontainerStartT struct {
+	Ts       uint64
+	CgroupId uint64
+}
+
+// loadBiocontainer returns the embedded CollectionSpec for biocontainer.
+func loadBiocontainer() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_BiocontainerBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load biocontainer: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadBiocontainerObjects loads biocontainer and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*biocontainerObjects
+//	*biocontainerPrograms
+//	*biocontainerMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadBiocontainerObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadBiocontainer()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// biocontainerSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type biocontainerSpecs struct {
+	biocontainerProgramSpecs
+	biocontainerMapSpecs
+}
+
+// biocontainerSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type biocontainerProgramSpecs struct {
+	IgBiocontDone *ebpf.ProgramSpec `ebpf:"ig_biocont_done"`
+	IgBiocontIns  *ebpf.ProgramSpec `ebpf:"ig_biocont_ins"`
+	IgBiocontIss  *ebpf.ProgramSpec `ebpf:"ig_biocont_iss"`
+}
+
+// biocontainerMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type biocontainerMapSpecs struct {
+	Hists *ebpf.MapSpec `ebpf:"hists"`
+	Start *ebpf.MapSpec `ebpf:"start"`
+}
+
+// biocontainerObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadBiocontainerObjects or ebpf.CollectionSpec.LoadAndAssign.
+type biocontainerObjects struct {
+	biocontainerPrograms
+	biocontainerMaps
+}
+
+func (o *biocontainerObjects) Close() error {
+	return _BiocontainerClose(
+		&o.biocontainerPrograms,
+		&o.biocontainerMaps,
+	)
+}
+
+// biocontainerMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadBiocontainerObjects or ebpf.CollectionSpec.LoadAndAssign.
+type biocontainerMaps struct {
+	Hists *ebpf.Map `ebpf:"hists"`
+	Start *ebpf.Map `ebpf:"start"`
+}
+
+func (m *biocontainerMaps) Close() error {
+	return _BiocontainerClose(
+		m.Hists,
+		m.Start,
+	)
+}
+
+// biocontainerPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadBiocontainerObjects or ebpf.CollectionSpec.LoadAndAssign.
+type biocontainerPrograms struct {
+	IgBiocontDone *ebpf.Program `ebpf:"ig_biocont_done"`
+	IgBiocontIns  *ebpf.Program `ebpf:"ig_biocont_ins"`
+	IgBiocontIss  *ebpf.Program `ebpf:"ig_biocont_iss"`
+}
+
+func (p *biocontainerPrograms) Close() error {
+	return _BiocontainerClose(
+		p.IgBiocontDone,
+		p.IgBiocontIns,
+		p.IgBiocontIss,
+	)
+}
+
+func _BiocontainerClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed biocontainer_bpfel_arm64.o
+var _BiocontainerBytes []byte
@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: GPL-2.0
+// Copyright (c) 2020 Wenbo Zhang
+// Copyright (c) 2023 The Inspektor Gadget authors
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "biocontainer.h"
+#include "bits.bpf.h"
+
+#define MAX_ENTRIES	10240
+
+const volatile bool targ_queued = false;
+const volatile bool targ_ms = false;
+
+extern int LINUX_KERNEL_VERSION __kconfig;
+
+struct start_t {
+	__u64 ts;
+	__u64 cgroup_id;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, struct request *);
+	__type(value, struct start_t);
+} start SEC(".maps");
+
+static struct hist initial_hist;
+
+// Histograms indexed by the id of the cgroup the I/O is charged to
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u64);
+	__type(value, struct hist);
+} hists SEC(".maps");
+
+// get_cgroup_id returns the id of the cgroup the first bio of the request is
+// associated with: the cgroup that submitted it, or the one that dirtied the
+// pages in the case of writeback.
+static __always_inline __u64 get_cgroup_id(struct request *rq)
+{
+	struct bio *bio = BPF_CORE_READ(rq, bio);
+
+	if (!bio || !bpf_core_field_exists(bio->bi_blkg))
+		return 0;
+
+	return BPF_CORE_READ(bio, bi_blkg, blkcg, css.cgroup, kn, id);
+}
+
+static __always_inline
+int trace_rq_start(struct request *rq, int issue)
+{
+	struct start_t data = {};
+
+	if (issue && targ_queued && BPF_CORE_READ(rq, q, elevator))
+		return 0;
+
+	data.cgroup_id = get_cgroup_id(rq);
+	if (!data.cgroup_id)
+		return 0;
+
+	data.ts = bpf_ktime_get_ns();
+	bpf_map_update_elem(&start, &rq, &data, 0);
+	return 0;
+}
+
+SEC("raw_tp/block_rq_insert")
+int ig_biocont_ins(u64 *ctx)
+{
+	/**
+	 * commit a54895fa (v5.11-rc1) changed tracepoint argument list
+	 * from TP_PROTO(struct request_queue *q, struct request *rq)
+	 * to TP_PROTO(struct request *rq)
+	 */
+	if (LINUX_KERNEL_VERSION < KERNEL_VERSION(5, 11, 0))
+		return trace_rq_start((void *)ctx[1], false);
+	else
+		return trace_rq_start((void *)ctx[0], false);
+}
+
+SEC("raw_tp/block_rq_issue")
+int ig_biocont_iss(u64 *ctx)
+{
+	/**
+	 * commit a54895fa (v5.11-rc1) changed tracepoint argument list
+	 * from TP_PROTO(struct request_queue *q, struct request *rq)
+	 * to TP_PROTO(struct request *rq)
+	 */
+	if (LINUX_KERNEL_VERSION < KERNEL_VERSION(5, 11, 0))
+		return trace_rq_start((void *)ctx[1], true);
+	else
+		return trace_rq_start((void *)ctx[0], true);
+}
+
+SEC("raw_tp/block_rq_complete")
+int BPF_PROG(ig_biocont_done, struct request *rq, int error,
+	unsigned int nr_bytes)
+{
+	u64 slot, ts = bpf_ktime_get_ns();
+	struct start_t *startp;
+	struct hist *histp;
+	s64 delta;
+
+	startp = bpf_map_lookup_elem(&start, &rq);
+	if (!startp)
+		return 0;
+	delta = (s64)(ts - startp->ts);
+	if (delta < 0)
+		goto cleanup;
+
+	histp = bpf_map_lookup_elem(&hists, &startp->cgroup_id);
+	if (!histp) {
+		bpf_map_update_elem(&hists, &startp->cgroup_id, &initial_hist, BPF_NOEXIST);
+		histp = bpf_map_lookup_elem(&hists, &startp->cgroup_id);
+		if (!histp)
+			goto cleanup;
+	}
+
+	if (targ_ms)
+		delta /= 1000000U;
+	else
+		delta /= 1000U;
+	slot = log2l(delta);
+	if (slot >= MAX_SLOTS)
+		slot = MAX_SLOTS - 1;
+	__sync_fetch_and_add(&histp->slots[slot], 1);
+	__sync_fetch_and_add(&histp->latency, delta);
+	__sync_fetch_and_add(&histp->cnt, 1);
+
+cleanup:
+	bpf_map_delete_elem(&start, &rq);
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
@@ -0,0 +1,314 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+
+	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/cgroups"
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/block-io-container/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/histogram"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -type hist biocontainer ./bpf/biocontainer.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
+
+type Config struct {
+	MountnsMap      *ebpf.Map
+	Interval        time.Duration
+	UseMilliseconds bool
+	Queued          bool
+}
+
+type Tracer struct {
+	config        *Config
+	enricherFunc  func(ev any) error
+	eventCallback func(*types.Report)
+
+	objs  biocontainerObjects
+	links []link.Link
+
+	// mntns indexed by cgroup id, 0 if no process of the cgroup was found
+	cgroups map[uint64]uint64
+}
+
+func (t *Tracer) close() {
+	for i, l := range t.links {
+		t.links[i] = gadgets.CloseLink(l)
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadBiocontainer()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	consts := map[string]interface{}{
+		"targ_ms":     t.config.UseMilliseconds,
+		"targ_queued": t.config.Queued,
+	}
+
+	if err := spec.RewriteConstants(consts); err != nil {
+		return fmt.Errorf("rewriting constants: %w", err)
+	}
+
+	if err := spec.LoadAndAssign(&t.objs, nil); err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	tracepoints := []struct {
+		name string
+		prog *ebpf.Program
+	}{
+		{"block_rq_insert", t.objs.IgBiocontIns},
+		{"block_rq_issue", t.objs.IgBiocontIss},
+		{"block_rq_complete", t.objs.IgBiocontDone},
+	}
+
+	for _, tp := range tracepoints {
+		l, err := link.AttachRawTracepoint(link.RawTracepointOptions{Name: tp.name, Program: tp.prog})
+		if err != nil {
+			return fmt.Errorf("attaching tracepoint for %s: %w", tp.name, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	return nil
+}
+
+// resolveCgroups finds the mount namespaces of the given cgroups by looking
+// for a process in each of them. The I/Os are charged to cgroups, not to mount
+// namespaces, and the histograms need the latter to be enriched.
+func (t *Tracer) resolveCgroups(cgroupIDs []uint64) {
+	unknown := make(map[uint64]struct{})
+	for _, id := range cgroupIDs {
+		if _, ok := t.cgroups[id]; !ok {
+			unknown[id] = struct{}{}
+		}
+	}
+	if len(unknown) == 0 {
+		return
+	}
+
+	entries, err := os.ReadDir(host.HostProcFs)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+
+		_, pathV2, err := cgroups.GetCgroupPaths(pid)
+		if err != nil || pathV2 == "" {
+			continue
+		}
+		path, err := cgroups.CgroupPathV2AddMountpoint(pathV2)
+		if err != nil {
+			continue
+		}
+		id, err := cgroups.GetCgroupID(path)
+		if err != nil {
+			continue
+		}
+		if _, ok := unknown[id]; !ok {
+			continue
+		}
+
+		mntns, err := containerutils.GetMntNs(pid)
+		if err != nil {
+			continue
+		}
+		t.cgroups[id] = mntns
+		delete(unknown, id)
+		if len(unknown) == 0 {
+			return
+		}
+	}
+
+	// Don't look for them again, cgroup ids aren't reused
+	for id := range unknown {
+		t.cgroups[id] = 0
+	}
+}
+
+// isFiltered returns true if the mount namespace isn't one of the containers
+// selected by the user
+func (t *Tracer) isFiltered(mntnsID uint64) bool {
+	if t.config.MountnsMap == nil {
+		return false
+	}
+
+	var val uint32
+	return mntnsID == 0 || t.config.MountnsMap.Lookup(mntnsID, &val) != nil
+}
+
+// collectReports returns the histograms gathered since the last call and
+// resets them
+func (t *Tracer) collectReports() ([]*types.Report, error) {
+	histsMap := t.objs.Hists
+
+	unit := histogram.UnitMicroseconds
+	if t.config.UseMilliseconds {
+		unit = histogram.UnitMilliseconds
+	}
+
+	hists := make(map[uint64]biocontainerHist)
+
+	var key uint64
+	err := histsMap.NextKey(nil, unsafe.Pointer(&key))
+	for err == nil {
+		hist := biocontainerHist{}
+		if err := histsMap.Lookup(key, unsafe.Pointer(&hist)); err != nil {
+			return nil, fmt.Errorf("getting histogram for cgroup %d: %w", key, err)
+		}
+		hists[key] = hist
+
+		prev := key
+		err = histsMap.NextKey(unsafe.Pointer(&prev), unsafe.Pointer(&key))
+	}
+	if !errors.Is(err, ebpf.ErrKeyNotExist) {
+		return nil, fmt.Errorf("getting next histogram key: %w", err)
+	}
+
+	cgroupIDs := make([]uint64, 0, len(hists))
+	for id := range hists {
+		if err := histsMap.Delete(id); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+			return nil, fmt.Errorf("resetting histogram for cgroup %d: %w", id, err)
+		}
+		cgroupIDs = append(cgroupIDs, id)
+	}
+	t.resolveCgroups(cgroupIDs)
+
+	reports := make([]*types.Report, 0, len(hists))
+	for id, hist := range hists {
+		mntnsID := t.cgroups[id]
+		if t.isFiltered(mntnsID) {
+			continue
+		}
+
+		report := types.NewReport(unit, hist.Slots[:])
+		report.CgroupID = id
+		report.MntnsID = mntnsID
+		report.Count = hist.Cnt
+		if hist.Cnt > 0 {
+			report.Average = float64(hist.Latency) / float64(hist.Cnt)
+		}
+		reports = append(reports, report)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].CgroupID < reports[j].CgroupID
+	})
+
+	return reports, nil
+}
+
+func (t *Tracer) emitReports() error {
+	reports, err := t.collectReports()
+	if err != nil {
+		return fmt.Errorf("collecting reports: %w", err)
+	}
+
+	for _, report := range reports {
+		if t.enricherFunc != nil && report.MntnsID != 0 {
+			t.enricherFunc(report)
+		}
+		t.eventCallback(report)
+	}
+
+	return nil
+}
+
+// --- Registry changes
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.Interval = time.Duration(params.Get(gadgets.ParamInterval).AsUint32()) * time.Second
+	t.config.UseMilliseconds = params.Get(ParamMilliseconds).AsBool()
+	t.config.Queued = params.Get(ParamQueued).AsBool()
+
+	t.cgroups = make(map[uint64]uint64)
+
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	ctx, cancel := gadgetcontext.WithTimeoutOrCancel(gadgetCtx.Context(), gadgetCtx.Timeout())
+	defer cancel()
+
+	// A nil channel blocks forever: without interval, the histograms are only
+	// shown when the gadget stops
+	var tick <-chan time.Time
+	if t.config.Interval > 0 {
+		ticker := time.NewTicker(t.config.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return t.emitReports()
+		case <-tick:
+			if err := t.emitReports(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Report))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (t *Tracer) SetEventEnricher(enricher func(ev any) error) {
+	t.enricherFunc = enricher
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+	}
+	return tracer, nil
+}
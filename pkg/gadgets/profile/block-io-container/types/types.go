@@ -0,0 +1,63 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"strings"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/histogram"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// Report is the block I/O latency distribution of a container during an
+// interval
+type Report struct {
+	eventtypes.CommonData
+
+	// CgroupID is the id of the cgroup the I/Os were charged to
+	CgroupID uint64 `json:"cgroupID" column:"cgroupid,width:10,hide"`
+	Count    uint64 `json:"count" column:"count,width:8"`
+	// Average is the average latency, in the unit of the histogram
+	Average float64 `json:"average" column:"avg,width:10"`
+
+	Histogram *histogram.Histogram `json:"histogram,omitempty"`
+
+	MntnsID uint64 `json:"-"`
+}
+
+func NewReport(unit histogram.Unit, slots []uint32) *Report {
+	return &Report{
+		Histogram: &histogram.Histogram{
+			Unit:      unit,
+			Intervals: histogram.NewIntervalsFromExp2Slots(slots),
+		},
+	}
+}
+
+func GetColumns() *columns.Columns[Report] {
+	return columns.MustCreateColumns[Report]()
+}
+
+func (r *Report) GetMountNSID() uint64 {
+	return r.MntnsID
+}
+
+func (r *Report) ExtraLines() []string {
+	if r.Histogram == nil {
+		return nil
+	}
+	return strings.Split(strings.TrimRight(r.Histogram.String(), "\n"), "\n")
+}
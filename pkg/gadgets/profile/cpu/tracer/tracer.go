@@ -223,7 +223,7 @@ func (t *Tracer) collectResult() ([]byte, error) {
 		return keysCounts[i].value != keysCounts[j].value
 	})
 
-	kAllSyms, err := kallsyms.NewKAllSyms()
+	kAllSyms, err := kallsyms.Shared()
 	if err != nil {
 		return nil, err
 	}
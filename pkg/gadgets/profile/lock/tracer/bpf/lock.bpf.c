@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: GPL-2.0
+// Copyright (c) 2023 The Inspektor Gadget authors
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "lock.h"
+#include "maps.bpf.h"
+#include "mntns_filter.h"
+
+#define PF_KTHREAD		0x00200000	/* I am a kernel thread */
+#define MAX_STACK_DEPTH		127
+
+#define FUTEX_WAIT		0
+#define FUTEX_LOCK_PI		6
+#define FUTEX_WAIT_BITSET	9
+#define FUTEX_WAIT_REQUEUE_PI	11
+#define FUTEX_LOCK_PI2		13
+#define FUTEX_PRIVATE_FLAG	128
+#define FUTEX_CLOCK_REALTIME	256
+#define FUTEX_CMD_MASK		~(FUTEX_PRIVATE_FLAG | FUTEX_CLOCK_REALTIME)
+
+const volatile bool kernel_stacks_only = false;
+const volatile bool user_stacks_only = false;
+const volatile __u64 min_wait_ns = 1;
+
+struct internal_key {
+	__u64 start_ts;
+	struct key_t key;
+};
+
+/*
+ * A thread waiting on a futex can contend on a kernel lock in the syscall,
+ * the waits on futexes and kernel locks are tracked separately.
+ */
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__type(key, __u32);
+	__type(value, struct internal_key);
+	__uint(max_entries, MAX_ENTRIES);
+} futex_start SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__type(key, __u32);
+	__type(value, struct internal_key);
+	__uint(max_entries, MAX_ENTRIES);
+} lock_start SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_STACK_TRACE);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, MAX_STACK_DEPTH * sizeof(__u64));
+	__uint(max_entries, MAX_ENTRIES);
+} stackmap SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__type(key, struct key_t);
+	__type(value, struct value_t);
+	__uint(max_entries, MAX_ENTRIES);
+} info SEC(".maps");
+
+static __always_inline int wait_begin(void *ctx, void *start, __u64 addr,
+				      __u32 flags, bool futex)
+{
+	__u64 pid_tgid = bpf_get_current_pid_tgid();
+	struct task_struct *task;
+	struct internal_key i_key;
+	__u32 tid = (__u32)pid_tgid;
+	__u64 mntns_id;
+
+	mntns_id = gadget_get_mntns_id();
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return 0;
+
+	__builtin_memset(&i_key, 0, sizeof(i_key));
+	i_key.key.mntns_id = mntns_id;
+	i_key.key.addr = addr;
+	i_key.key.pid = pid_tgid >> 32;
+	i_key.key.flags = flags;
+	i_key.key.futex = futex;
+	bpf_get_current_comm(&i_key.key.name, sizeof(i_key.key.name));
+
+	/* The kernel stack of a futex wait is always the one of the syscall */
+	if (user_stacks_only || futex)
+		i_key.key.kern_stack_id = -1;
+	else
+		i_key.key.kern_stack_id = bpf_get_stackid(ctx, &stackmap, 0);
+
+	task = (struct task_struct *)bpf_get_current_task();
+	if (kernel_stacks_only || BPF_CORE_READ(task, flags) & PF_KTHREAD)
+		i_key.key.user_stack_id = -1;
+	else
+		i_key.key.user_stack_id = bpf_get_stackid(ctx, &stackmap,
+							  BPF_F_USER_STACK);
+
+	i_key.start_ts = bpf_ktime_get_ns();
+
+	/*
+	 * The contention on a kernel lock can be reported several times before
+	 * it ends, e.g. when a mutex spins before sleeping, keep the first one.
+	 */
+	bpf_map_update_elem(start, &tid, &i_key, BPF_NOEXIST);
+	return 0;
+}
+
+static __always_inline int wait_end(void *start)
+{
+	static const struct value_t zero;
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	struct internal_key *i_keyp;
+	struct value_t *valp;
+	__s64 delta;
+
+	i_keyp = bpf_map_lookup_elem(start, &tid);
+	if (!i_keyp)
+		return 0;
+
+	delta = (__s64)(bpf_ktime_get_ns() - i_keyp->start_ts);
+	if (delta < 0 || delta < min_wait_ns)
+		goto cleanup;
+
+	valp = bpf_map_lookup_or_try_init(&info, &i_keyp->key, &zero);
+	if (!valp)
+		goto cleanup;
+
+	__sync_fetch_and_add(&valp->total_ns, delta);
+	__sync_fetch_and_add(&valp->count, 1);
+	if (delta > valp->max_ns)
+		valp->max_ns = delta;
+
+cleanup:
+	bpf_map_delete_elem(start, &tid);
+	return 0;
+}
+
+SEC("tracepoint/syscalls/sys_enter_futex")
+int ig_lock_futex_e(struct trace_event_raw_sys_enter *ctx)
+{
+	int cmd = (int)ctx->args[1] & FUTEX_CMD_MASK;
+
+	/* Only the operations waiting for the futex are traced */
+	switch (cmd) {
+	case FUTEX_WAIT:
+	case FUTEX_LOCK_PI:
+	case FUTEX_WAIT_BITSET:
+	case FUTEX_WAIT_REQUEUE_PI:
+	case FUTEX_LOCK_PI2:
+		break;
+	default:
+		return 0;
+	}
+
+	return wait_begin(ctx, &futex_start, ctx->args[0], 0, true);
+}
+
+SEC("tracepoint/syscalls/sys_exit_futex")
+int ig_lock_futex_x(struct trace_event_raw_sys_exit *ctx)
+{
+	return wait_end(&futex_start);
+}
+
+SEC("raw_tp/contention_begin")
+int BPF_PROG(ig_lock_cont_b, void *lock, unsigned int flags)
+{
+	return wait_begin(ctx, &lock_start, (__u64)lock, flags, false);
+}
+
+SEC("raw_tp/contention_end")
+int BPF_PROG(ig_lock_cont_e, void *lock, int ret)
+{
+	return wait_end(&lock_start);
+}
+
+char LICENSE[] SEC("license") = "GPL";
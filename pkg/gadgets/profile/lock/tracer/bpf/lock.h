@@ -0,0 +1,28 @@
+/* SPDX-License-Identifier: GPL-2.0 */
+#ifndef __LOCK_H
+#define __LOCK_H
+
+#define TASK_COMM_LEN		16
+#define MAX_ENTRIES		10240
+
+struct key_t {
+	__u64 mntns_id;
+	/* Address of the futex or of the kernel lock */
+	__u64 addr;
+	__u32 pid;
+	/* Flags of the contention_begin tracepoint, for kernel locks */
+	__u32 flags;
+	int user_stack_id;
+	int kern_stack_id;
+	__u8 name[TASK_COMM_LEN];
+	__u8 futex;
+	__u8 pad[7];
+};
+
+struct value_t {
+	__u64 total_ns;
+	__u64 max_ns;
+	__u64 count;
+};
+
+#endif /* __LOCK_H */
@@ -0,0 +1,163 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type lockInternalKey struct {
+	StartTs uint64
+	Key     lockKeyT
+}
+
+type lockKeyT struct {
+	MntnsId     uint64
+	Addr        uint64
+	Pid         uint32
+	Flags       uint32
+	UserStackId int32
+	KernStackId int32
+	Name        [16]uint8
+	Futex       uint8
+	Pad         [7]uint8
+}
+
+type lockValueT struct {
+	TotalNs uint64
+	MaxNs   uint64
+	Count   uint64
+}
+
+// loadLock returns the embedded CollectionSpec for lock.
+func loadLock() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_LockBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load lock: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadLockObjects loads lock and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*lockObjects
+//	*lockPrograms
+//	*lockMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadLockObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadLock()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// lockSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type lockSpecs struct {
+	lockProgramSpecs
+	lockMapSpecs
+}
+
+// lockSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type lockProgramSpecs struct {
+	IgLockContB  *ebpf.ProgramSpec `ebpf:"ig_lock_cont_b"`
+	IgLockContE  *ebpf.ProgramSpec `ebpf:"ig_lock_cont_e"`
+	IgLockFutexE *ebpf.ProgramSpec `ebpf:"ig_lock_futex_e"`
+	IgLockFutexX *ebpf.ProgramSpec `ebpf:"ig_lock_futex_x"`
+}
+
+// lockMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type lockMapSpecs struct {
+	FutexStart           *ebpf.MapSpec `ebpf:"futex_start"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Info                 *ebpf.MapSpec `ebpf:"info"`
+	LockStart            *ebpf.MapSpec `ebpf:"lock_start"`
+	Stackmap             *ebpf.MapSpec `ebpf:"stackmap"`
+}
+
+// lockObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadLockObjects or ebpf.CollectionSpec.LoadAndAssign.
+type lockObjects struct {
+	lockPrograms
+	lockMaps
+}
+
+func (o *lockObjects) Close() error {
+	return _LockClose(
+		&o.lockPrograms,
+		&o.lockMaps,
+	)
+}
+
+// lockMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadLockObjects or ebpf.CollectionSpec.LoadAndAssign.
+type lockMaps struct {
+	FutexStart           *ebpf.Map `ebpf:"futex_start"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Info                 *ebpf.Map `ebpf:"info"`
+	LockStart            *ebpf.Map `ebpf:"lock_start"`
+	Stackmap             *ebpf.Map `ebpf:"stackmap"`
+}
+
+func (m *lockMaps) Close() error {
+	return _LockClose(
+		m.FutexStart,
+		m.GadgetMntnsFilterMap,
+		m.Info,
+		m.LockStart,
+		m.Stackmap,
+	)
+}
+
+// lockPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadLockObjects or ebpf.CollectionSpec.LoadAndAssign.
+type lockPrograms struct {
+	IgLockContB  *ebpf.Program `ebpf:"ig_lock_cont_b"`
+	IgLockContE  *ebpf.Program `ebpf:"ig_lock_cont_e"`
+	IgLockFutexE *ebpf.Program `ebpf:"ig_lock_futex_e"`
+	IgLockFutexX *ebpf.Program `ebpf:"ig_lock_futex_x"`
+}
+
+func (p *lockPrograms) Close() error {
+	return _LockClose(
+		p.IgLockContB,
+		p.IgLockContE,
+		p.IgLockFutexE,
+		p.IgLockFutexX,
+	)
+}
+
+func _LockClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed lock_bpfel_x86.o
+var _LockBytes []byte
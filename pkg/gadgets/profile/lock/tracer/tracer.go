@@ -0,0 +1,277 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/lock/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/kallsyms"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -type key_t -type value_t lock ./bpf/lock.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
+
+const perfMaxStackDepth = 127
+
+type Config struct {
+	MountnsMap      *ebpf.Map
+	UserStackOnly   bool
+	KernelStackOnly bool
+	MinWait         time.Duration
+}
+
+type Tracer struct {
+	config        *Config
+	enricherFunc  func(ev any) error
+	eventCallback func(*types.Report)
+
+	objs  lockObjects
+	links []link.Link
+}
+
+func (t *Tracer) close() {
+	for i, l := range t.links {
+		t.links[i] = gadgets.CloseLink(l)
+	}
+	t.objs.Close()
+}
+
+func (t *Tracer) install(logger logger.Logger) error {
+	spec, err := loadLock()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	consts := map[string]interface{}{
+		"kernel_stacks_only": t.config.KernelStackOnly,
+		"user_stacks_only":   t.config.UserStackOnly,
+		"min_wait_ns":        uint64(t.config.MinWait.Nanoseconds()),
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, consts, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	futexTracepoints := []struct {
+		name string
+		prog *ebpf.Program
+	}{
+		{"sys_enter_futex", t.objs.IgLockFutexE},
+		{"sys_exit_futex", t.objs.IgLockFutexX},
+	}
+	for _, tp := range futexTracepoints {
+		l, err := link.Tracepoint("syscalls", tp.name, tp.prog, nil)
+		if err != nil {
+			return fmt.Errorf("attaching tracepoint %s: %w", tp.name, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	// The contention tracepoints are only available since Linux 5.19, only
+	// the futexes are traced on older kernels
+	lockTracepoints := []struct {
+		name string
+		prog *ebpf.Program
+	}{
+		{"contention_begin", t.objs.IgLockContB},
+		{"contention_end", t.objs.IgLockContE},
+	}
+	for _, tp := range lockTracepoints {
+		l, err := link.AttachRawTracepoint(link.RawTracepointOptions{
+			Name:    tp.name,
+			Program: tp.prog,
+		})
+		if errors.Is(err, os.ErrNotExist) {
+			logger.Warnf("kernel locks can't be traced: tracepoint %s not available", tp.name)
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("attaching tracepoint %s: %w", tp.name, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	return nil
+}
+
+// Flags of the contention_begin tracepoint
+const (
+	lcbFSpin   = 1 << 0
+	lcbFRead   = 1 << 1
+	lcbFWrite  = 1 << 2
+	lcbFRt     = 1 << 3
+	lcbFPercpu = 1 << 4
+	lcbFMutex  = 1 << 5
+)
+
+// kernelLockType returns the type of a kernel lock from the flags of the
+// contention_begin tracepoint, as perf lock contention does
+func kernelLockType(flags uint32) string {
+	switch {
+	case flags&lcbFMutex != 0:
+		return types.LockTypeMutex
+	case flags&lcbFRt != 0:
+		return types.LockTypeRtMutex
+	case flags&lcbFPercpu != 0:
+		return types.LockTypePcpuSem
+	case flags&lcbFSpin != 0 && flags&lcbFRead != 0:
+		return types.LockTypeRwlockR
+	case flags&lcbFSpin != 0 && flags&lcbFWrite != 0:
+		return types.LockTypeRwlockW
+	case flags&lcbFSpin != 0:
+		return types.LockTypeSpinlock
+	case flags&lcbFRead != 0:
+		return types.LockTypeRwsemR
+	case flags&lcbFWrite != 0:
+		return types.LockTypeRwsemW
+	default:
+		return types.LockTypeOther
+	}
+}
+
+func (t *Tracer) getStack(stackID int32, kAllSyms *kallsyms.KAllSyms) []string {
+	if stackID < 0 {
+		return nil
+	}
+
+	ips := [perfMaxStackDepth]uint64{}
+	if err := t.objs.Stackmap.Lookup(uint32(stackID), unsafe.Pointer(&ips)); err != nil {
+		return nil
+	}
+
+	symbols := []string{}
+	for _, ip := range ips {
+		if ip == 0 {
+			break
+		}
+
+		// We will not support getting userland symbols.
+		if kAllSyms == nil {
+			symbols = append(symbols, "[unknown]")
+		} else {
+			symbols = append(symbols, kAllSyms.LookupByInstructionPointer(ip))
+		}
+	}
+
+	return symbols
+}
+
+func (t *Tracer) collectReports() ([]*types.Report, error) {
+	kAllSyms, err := kallsyms.Shared()
+	if err != nil {
+		return nil, fmt.Errorf("reading kallsyms: %w", err)
+	}
+
+	reports := []*types.Report{}
+
+	var key lockKeyT
+	var value lockValueT
+	entries := t.objs.Info.Iterate()
+	for entries.Next(&key, &value) {
+		lockType := types.LockTypeFutex
+		if key.Futex == 0 {
+			lockType = kernelLockType(key.Flags)
+		}
+
+		reports = append(reports, &types.Report{
+			Comm:        gadgets.FromCString(key.Name[:]),
+			Pid:         key.Pid,
+			Type:        lockType,
+			Address:     fmt.Sprintf("0x%x", key.Addr),
+			Count:       value.Count,
+			Total:       time.Duration(value.TotalNs),
+			Max:         time.Duration(value.MaxNs),
+			UserStack:   t.getStack(key.UserStackId, nil),
+			KernelStack: t.getStack(key.KernStackId, kAllSyms),
+			MntnsID:     key.MntnsId,
+		})
+	}
+	if err := entries.Err(); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+		return nil, fmt.Errorf("iterating wait times: %w", err)
+	}
+
+	// The longest waits are shown last, close to the prompt
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Total < reports[j].Total
+	})
+
+	return reports, nil
+}
+
+// --- Registry changes
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.UserStackOnly = params.Get(ParamUserStack).AsBool()
+	t.config.KernelStackOnly = params.Get(ParamKernelStack).AsBool()
+	t.config.MinWait = params.Get(ParamMinWait).AsDuration()
+
+	defer t.close()
+	if err := t.install(gadgetCtx.Logger()); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	reports, err := t.collectReports()
+	if err != nil {
+		return fmt.Errorf("collecting reports: %w", err)
+	}
+
+	for _, report := range reports {
+		if t.enricherFunc != nil {
+			t.enricherFunc(report)
+		}
+		t.eventCallback(report)
+	}
+
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Report))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (t *Tracer) SetEventEnricher(enricher func(ev any) error) {
+	t.enricherFunc = enricher
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+	}
+	return tracer, nil
+}
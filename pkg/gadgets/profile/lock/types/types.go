@@ -0,0 +1,116 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	LockTypeFutex    = "futex"
+	LockTypeMutex    = "mutex"
+	LockTypeRtMutex  = "rtmutex"
+	LockTypePcpuSem  = "pcpu-sem"
+	LockTypeSpinlock = "spinlock"
+	LockTypeRwlockR  = "rwlock:R"
+	LockTypeRwlockW  = "rwlock:W"
+	LockTypeRwsemR   = "rwsem:R"
+	LockTypeRwsemW   = "rwsem:W"
+	LockTypeOther    = "other"
+)
+
+// Report is the time the threads of a process spent waiting for the same lock
+// in the same stack
+type Report struct {
+	eventtypes.CommonData
+
+	Comm        string        `json:"comm,omitempty" column:"comm,template:comm"`
+	Pid         uint32        `json:"pid,omitempty" column:"pid,template:pid"`
+	Type        string        `json:"type,omitempty" column:"type,width:9,fixed"`
+	Address     string        `json:"address,omitempty" column:"addr,width:18,hide"`
+	Count       uint64        `json:"count,omitempty" column:"count,width:8,align:right"`
+	Total       time.Duration `json:"total,omitempty" column:"total,width:14,align:right"`
+	Max         time.Duration `json:"max,omitempty" column:"max,width:14,align:right"`
+	UserStack   []string      `json:"userStack,omitempty"`
+	KernelStack []string      `json:"kernelStack,omitempty"`
+
+	MntnsID uint64 `json:"-"`
+}
+
+func GetColumns() *columns.Columns[Report] {
+	cols := columns.MustCreateColumns[Report]()
+
+	cols.MustSetExtractor("total", func(r *Report) string {
+		return r.Total.String()
+	})
+	cols.MustSetExtractor("max", func(r *Report) string {
+		return r.Max.String()
+	})
+
+	return cols
+}
+
+func (r *Report) GetMountNSID() uint64 {
+	return r.MntnsID
+}
+
+func (r *Report) ExtraLines() []string {
+	var out []string
+	for i := len(r.KernelStack) - 1; i >= 0; i-- {
+		out = append(out, "\t"+r.KernelStack[i])
+	}
+	for i := len(r.UserStack) - 1; i >= 0; i-- {
+		out = append(out, "\t"+r.UserStack[i])
+	}
+	return out
+}
+
+// Folded returns the report in the folded format used to generate flame
+// graphs: the container, the command, the type of lock and the frames from
+// the outermost one, separated by semicolons, followed by the waited time in
+// microseconds
+func (r *Report) Folded() string {
+	frames := []string{}
+	if container := r.containerName(); container != "" {
+		frames = append(frames, container)
+	}
+	frames = append(frames, r.Comm, r.Type)
+	for i := len(r.UserStack) - 1; i >= 0; i-- {
+		frames = append(frames, r.UserStack[i])
+	}
+	if len(r.UserStack) > 0 && len(r.KernelStack) > 0 {
+		frames = append(frames, "-")
+	}
+	for i := len(r.KernelStack) - 1; i >= 0; i-- {
+		frames = append(frames, r.KernelStack[i])
+	}
+
+	return fmt.Sprintf("%s %d", strings.Join(frames, ";"), r.Total.Microseconds())
+}
+
+func (r *Report) containerName() string {
+	parts := []string{}
+	for _, part := range []string{r.Namespace, r.Pod, r.Container} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
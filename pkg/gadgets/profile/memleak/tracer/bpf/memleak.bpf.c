@@ -0,0 +1,246 @@
+// SPDX-License-Identifier: GPL-2.0
+// Copyright (c) 2023 The Inspektor Gadget authors
+//
+// Based on memleak(8) from BCC.
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "memleak.h"
+#include "core_fixes.bpf.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+#define MAX_ALLOCS	262144
+
+const volatile __u64 min_size = 0;
+
+/* size requested by the threads currently in a libc allocation function */
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__type(value, __u64);
+} sizes SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ALLOCS);
+	__type(key, struct alloc_key);
+	__type(value, struct alloc_info);
+} allocs SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_STACK_TRACE);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__uint(value_size, MAX_STACK_DEPTH * sizeof(__u64));
+} stack_traces SEC(".maps");
+
+static __always_inline int gen_alloc(void *ctx, __u64 addr, __u64 size,
+				     bool user)
+{
+	struct alloc_key key = {};
+	struct alloc_info info = {};
+	__u64 pid_tgid = bpf_get_current_pid_tgid();
+	__u64 mntns_id;
+
+	if (!addr || size < min_size)
+		return 0;
+
+	mntns_id = gadget_get_mntns_id();
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return 0;
+
+	key.addr = addr;
+	if (user)
+		key.pid = pid_tgid >> 32;
+
+	info.size = size;
+	info.timestamp_ns = bpf_ktime_get_ns();
+	info.mntns_id = mntns_id;
+	info.pid = pid_tgid >> 32;
+	info.user = user;
+	info.stack_id = bpf_get_stackid(ctx, &stack_traces,
+					user ? BPF_F_USER_STACK : 0);
+	bpf_get_current_comm(&info.comm, sizeof(info.comm));
+
+	bpf_map_update_elem(&allocs, &key, &info, BPF_ANY);
+	return 0;
+}
+
+static __always_inline int gen_free(__u64 addr, bool user)
+{
+	struct alloc_key key = {};
+
+	key.addr = addr;
+	if (user)
+		key.pid = bpf_get_current_pid_tgid() >> 32;
+
+	bpf_map_delete_elem(&allocs, &key);
+	return 0;
+}
+
+static __always_inline int gen_alloc_enter(__u64 size)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+
+	bpf_map_update_elem(&sizes, &tid, &size, BPF_ANY);
+	return 0;
+}
+
+static __always_inline int gen_alloc_exit(void *ctx, __u64 addr)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	__u64 *size;
+
+	size = bpf_map_lookup_elem(&sizes, &tid);
+	if (!size)
+		return 0;
+
+	gen_alloc(ctx, addr, *size, true);
+	bpf_map_delete_elem(&sizes, &tid);
+	return 0;
+}
+
+SEC("uprobe/malloc")
+int BPF_KPROBE(ig_memleak_malloc_e, size_t size)
+{
+	return gen_alloc_enter(size);
+}
+
+SEC("uretprobe/malloc")
+int BPF_KRETPROBE(ig_memleak_malloc_x, void *ret)
+{
+	return gen_alloc_exit(ctx, (__u64)ret);
+}
+
+SEC("uprobe/calloc")
+int BPF_KPROBE(ig_memleak_calloc_e, size_t nmemb, size_t size)
+{
+	return gen_alloc_enter(nmemb * size);
+}
+
+SEC("uretprobe/calloc")
+int BPF_KRETPROBE(ig_memleak_calloc_x, void *ret)
+{
+	return gen_alloc_exit(ctx, (__u64)ret);
+}
+
+SEC("uprobe/realloc")
+int BPF_KPROBE(ig_memleak_realloc_e, void *ptr, size_t size)
+{
+	gen_free((__u64)ptr, true);
+	return gen_alloc_enter(size);
+}
+
+SEC("uretprobe/realloc")
+int BPF_KRETPROBE(ig_memleak_realloc_x, void *ret)
+{
+	return gen_alloc_exit(ctx, (__u64)ret);
+}
+
+SEC("uprobe/free")
+int BPF_KPROBE(ig_memleak_free, void *ptr)
+{
+	return gen_free((__u64)ptr, true);
+}
+
+SEC("tracepoint/kmem/kmalloc")
+int ig_memleak_kmalloc(void *ctx)
+{
+	const void *ptr;
+	size_t bytes_alloc;
+
+	if (has_kmem_alloc()) {
+		struct trace_event_raw_kmem_alloc___x *args = ctx;
+		ptr = BPF_CORE_READ(args, ptr);
+		bytes_alloc = BPF_CORE_READ(args, bytes_alloc);
+	} else {
+		struct trace_event_raw_kmalloc___x *args = ctx;
+		ptr = BPF_CORE_READ(args, ptr);
+		bytes_alloc = BPF_CORE_READ(args, bytes_alloc);
+	}
+
+	return gen_alloc(ctx, (__u64)ptr, bytes_alloc, false);
+}
+
+/* Only exists before the kmalloc and kmalloc_node tracepoints were unified */
+SEC("tracepoint/kmem/kmalloc_node")
+int ig_memleak_kmalloc_node(void *ctx)
+{
+	struct trace_event_raw_kmem_alloc_node___x *args = ctx;
+
+	if (!has_kmem_alloc())
+		return 0;
+
+	return gen_alloc(ctx, (__u64)BPF_CORE_READ(args, ptr),
+			 BPF_CORE_READ(args, bytes_alloc), false);
+}
+
+SEC("tracepoint/kmem/kfree")
+int ig_memleak_kfree(void *ctx)
+{
+	const void *ptr;
+
+	if (has_kfree()) {
+		struct trace_event_raw_kfree___x *args = ctx;
+		ptr = BPF_CORE_READ(args, ptr);
+	} else {
+		struct trace_event_raw_kmem_free___x *args = ctx;
+		ptr = BPF_CORE_READ(args, ptr);
+	}
+
+	return gen_free((__u64)ptr, false);
+}
+
+SEC("tracepoint/kmem/kmem_cache_alloc")
+int ig_memleak_cache_alloc(void *ctx)
+{
+	const void *ptr;
+	size_t bytes_alloc;
+
+	if (has_kmem_alloc()) {
+		struct trace_event_raw_kmem_alloc___x *args = ctx;
+		ptr = BPF_CORE_READ(args, ptr);
+		bytes_alloc = BPF_CORE_READ(args, bytes_alloc);
+	} else {
+		struct trace_event_raw_kmem_cache_alloc___x *args = ctx;
+		ptr = BPF_CORE_READ(args, ptr);
+		bytes_alloc = BPF_CORE_READ(args, bytes_alloc);
+	}
+
+	return gen_alloc(ctx, (__u64)ptr, bytes_alloc, false);
+}
+
+/* Only exists before the kmem_cache_alloc and kmem_cache_alloc_node
+ * tracepoints were unified */
+SEC("tracepoint/kmem/kmem_cache_alloc_node")
+int ig_memleak_cache_alloc_node(void *ctx)
+{
+	struct trace_event_raw_kmem_alloc_node___x *args = ctx;
+
+	if (!has_kmem_alloc())
+		return 0;
+
+	return gen_alloc(ctx, (__u64)BPF_CORE_READ(args, ptr),
+			 BPF_CORE_READ(args, bytes_alloc), false);
+}
+
+SEC("tracepoint/kmem/kmem_cache_free")
+int ig_memleak_cache_free(void *ctx)
+{
+	const void *ptr;
+
+	if (has_kmem_cache_free()) {
+		struct trace_event_raw_kmem_cache_free___x *args = ctx;
+		ptr = BPF_CORE_READ(args, ptr);
+	} else {
+		struct trace_event_raw_kmem_free___x *args = ctx;
+		ptr = BPF_CORE_READ(args, ptr);
+	}
+
+	return gen_free((__u64)ptr, false);
+}
+
+char LICENSE[] SEC("license") = "GPL";
@@ -0,0 +1,25 @@
+/* SPDX-License-Identifier: (LGPL-2.1 OR BSD-2-Clause) */
+#ifndef __MEMLEAK_H
+#define __MEMLEAK_H
+
+#define TASK_COMM_LEN		16
+#define MAX_STACK_DEPTH		127
+
+/* pid is 0 for kernel allocations, user-space addresses are per process */
+struct alloc_key {
+	__u64 addr;
+	__u32 pid;
+	__u32 pad;
+};
+
+struct alloc_info {
+	__u64 size;
+	__u64 timestamp_ns;
+	__u64 mntns_id;
+	__s32 stack_id;
+	__u32 pid;
+	__u8 user;
+	__u8 comm[TASK_COMM_LEN];
+};
+
+#endif /* __MEMLEAK_H */
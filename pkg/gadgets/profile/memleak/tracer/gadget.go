@@ -0,0 +1,105 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/memleak/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
+)
+
+const (
+	ParamTop     = "top"
+	ParamOlder   = "older"
+	ParamMinSize = "min-size"
+	ParamUser    = "user"
+)
+
+type GadgetDesc struct{}
+
+func (g *GadgetDesc) Name() string {
+	return "memleak"
+}
+
+func (g *GadgetDesc) Category() string {
+	return gadgets.CategoryProfile
+}
+
+func (g *GadgetDesc) Type() gadgets.GadgetType {
+	return gadgets.TypeProfile
+}
+
+func (g *GadgetDesc) Description() string {
+	return "Periodically report the outstanding memory allocations of each container with their stack traces"
+}
+
+func (g *GadgetDesc) ParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:          gadgets.ParamInterval,
+			Title:        "Interval",
+			DefaultValue: "5",
+			Description:  "Interval (in Seconds) at which the outstanding allocations are reported, 0 to report them only when the gadget stops",
+			TypeHint:     params.TypeUint32,
+		},
+		{
+			Key:          ParamTop,
+			Alias:        "T",
+			DefaultValue: "10",
+			Description:  "Number of stacks with the most outstanding bytes to report for each container, 0 to report all of them",
+			TypeHint:     params.TypeUint32,
+		},
+		{
+			Key:          ParamOlder,
+			DefaultValue: "500ms",
+			Description:  "Only report the allocations older than this age",
+			TypeHint:     params.TypeDuration,
+		},
+		{
+			Key:          ParamMinSize,
+			DefaultValue: "0",
+			Description:  "Ignore the allocations smaller than this size (in Bytes)",
+			TypeHint:     params.TypeUint64,
+		},
+		{
+			Key:          ParamUser,
+			DefaultValue: "false",
+			Description:  "Also track the malloc(), calloc(), realloc() and free() calls to the libc of the containers",
+			TypeHint:     params.TypeBool,
+		},
+	}
+}
+
+func (g *GadgetDesc) Parser() parser.Parser {
+	return parser.NewParser[types.Report](types.GetColumns())
+}
+
+func (g *GadgetDesc) EventPrototype() any {
+	return &types.Report{}
+}
+
+func (g *GadgetDesc) Cost() gadgets.Cost {
+	return gadgets.Cost{
+		Probes:    6,
+		Events:    "every kernel allocation and free, and the libc ones with --user",
+		EventCost: gadgets.CostHigh,
+	}
+}
+
+func init() {
+	gadgetregistry.Register(&GadgetDesc{})
+}
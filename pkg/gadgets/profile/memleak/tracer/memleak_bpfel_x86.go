@@ -0,0 +1,181 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type memleakAllocInfo struct {
+	Size        uint64
+	TimestampNs uint64
+	MntnsId     uint64
+	StackId     int32
+	Pid         uint32
+	User        uint8
+	Comm        [16]uint8
+	_           [7]byte
+}
+
+type memleakAllocKey struct {
+	Addr uint64
+	Pid  uint32
+	Pad  uint32
+}
+
+// loadMemleak returns the embedded CollectionSpec for memleak.
+func loadMemleak() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_MemleakBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load memleak: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadMemleakObjects loads memleak and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*memleakObjects
+//	*memleakPrograms
+//	*memleakMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadMemleakObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadMemleak()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// memleakSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type memleakSpecs struct {
+	memleakProgramSpecs
+	memleakMapSpecs
+}
+
+// memleakSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type memleakProgramSpecs struct {
+	IgMemleakCacheAlloc     *ebpf.ProgramSpec `ebpf:"ig_memleak_cache_alloc"`
+	IgMemleakCacheAllocNode *ebpf.ProgramSpec `ebpf:"ig_memleak_cache_alloc_node"`
+	IgMemleakCacheFree      *ebpf.ProgramSpec `ebpf:"ig_memleak_cache_free"`
+	IgMemleakCallocE        *ebpf.ProgramSpec `ebpf:"ig_memleak_calloc_e"`
+	IgMemleakCallocX        *ebpf.ProgramSpec `ebpf:"ig_memleak_calloc_x"`
+	IgMemleakFree           *ebpf.ProgramSpec `ebpf:"ig_memleak_free"`
+	IgMemleakKfree          *ebpf.ProgramSpec `ebpf:"ig_memleak_kfree"`
+	IgMemleakKmalloc        *ebpf.ProgramSpec `ebpf:"ig_memleak_kmalloc"`
+	IgMemleakKmallocNode    *ebpf.ProgramSpec `ebpf:"ig_memleak_kmalloc_node"`
+	IgMemleakMallocE        *ebpf.ProgramSpec `ebpf:"ig_memleak_malloc_e"`
+	IgMemleakMallocX        *ebpf.ProgramSpec `ebpf:"ig_memleak_malloc_x"`
+	IgMemleakReallocE       *ebpf.ProgramSpec `ebpf:"ig_memleak_realloc_e"`
+	IgMemleakReallocX       *ebpf.ProgramSpec `ebpf:"ig_memleak_realloc_x"`
+}
+
+// memleakMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type memleakMapSpecs struct {
+	Allocs               *ebpf.MapSpec `ebpf:"allocs"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Sizes                *ebpf.MapSpec `ebpf:"sizes"`
+	StackTraces          *ebpf.MapSpec `ebpf:"stack_traces"`
+}
+
+// memleakObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadMemleakObjects or ebpf.CollectionSpec.LoadAndAssign.
+type memleakObjects struct {
+	memleakPrograms
+	memleakMaps
+}
+
+func (o *memleakObjects) Close() error {
+	return _MemleakClose(
+		&o.memleakPrograms,
+		&o.memleakMaps,
+	)
+}
+
+// memleakMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadMemleakObjects or ebpf.CollectionSpec.LoadAndAssign.
+type memleakMaps struct {
+	Allocs               *ebpf.Map `ebpf:"allocs"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Sizes                *ebpf.Map `ebpf:"sizes"`
+	StackTraces          *ebpf.Map `ebpf:"stack_traces"`
+}
+
+func (m *memleakMaps) Close() error {
+	return _MemleakClose(
+		m.Allocs,
+		m.GadgetMntnsFilterMap,
+		m.Sizes,
+		m.StackTraces,
+	)
+}
+
+// memleakPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadMemleakObjects or ebpf.CollectionSpec.LoadAndAssign.
+type memleakPrograms struct {
+	IgMemleakCacheAlloc     *ebpf.Program `ebpf:"ig_memleak_cache_alloc"`
+	IgMemleakCacheAllocNode *ebpf.Program `ebpf:"ig_memleak_cache_alloc_node"`
+	IgMemleakCacheFree      *ebpf.Program `ebpf:"ig_memleak_cache_free"`
+	IgMemleakCallocE        *ebpf.Program `ebpf:"ig_memleak_calloc_e"`
+	IgMemleakCallocX        *ebpf.Program `ebpf:"ig_memleak_calloc_x"`
+	IgMemleakFree           *ebpf.Program `ebpf:"ig_memleak_free"`
+	IgMemleakKfree          *ebpf.Program `ebpf:"ig_memleak_kfree"`
+	IgMemleakKmalloc        *ebpf.Program `ebpf:"ig_memleak_kmalloc"`
+	IgMemleakKmallocNode    *ebpf.Program `ebpf:"ig_memleak_kmalloc_node"`
+	IgMemleakMallocE        *ebpf.Program `ebpf:"ig_memleak_malloc_e"`
+	IgMemleakMallocX        *ebpf.Program `ebpf:"ig_memleak_malloc_x"`
+	IgMemleakReallocE       *ebpf.Program `ebpf:"ig_memleak_realloc_e"`
+	IgMemleakReallocX       *ebpf.Program `ebpf:"ig_memleak_realloc_x"`
+}
+
+func (p *memleakPrograms) Close() error {
+	return _MemleakClose(
+		p.IgMemleakCacheAlloc,
+		p.IgMemleakCacheAllocNode,
+		p.IgMemleakCacheFree,
+		p.IgMemleakCallocE,
+		p.IgMemleakCallocX,
+		p.IgMemleakFree,
+		p.IgMemleakKfree,
+		p.IgMemleakKmalloc,
+		p.IgMemleakKmallocNode,
+		p.IgMemleakMallocE,
+		p.IgMemleakMallocX,
+		p.IgMemleakReallocE,
+		p.IgMemleakReallocX,
+	)
+}
+
+func _MemleakClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed memleak_bpfel_x86.o
+var _MemleakBytes []byte
@@ -0,0 +1,577 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"golang.org/x/sys/unix"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/memleak/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/kallsyms"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -type alloc_key -type alloc_info memleak ./bpf/memleak.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
+
+const maxStackDepth = 127
+
+type Config struct {
+	MountnsMap *ebpf.Map
+	Interval   time.Duration
+	Top        int
+	MinAge     time.Duration
+	MinSize    uint64
+	User       bool
+}
+
+// libcProbes are the uprobes attached to a libc, shared by all the containers
+// using it
+type libcProbes struct {
+	links []link.Link
+	refs  int
+}
+
+// fileID identifies a file regardless of the mount namespace it's seen from
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+type Tracer struct {
+	config        *Config
+	enricherFunc  func(ev any) error
+	eventCallback func(*types.Report)
+
+	objs     memleakObjects
+	links    []link.Link
+	kAllSyms *kallsyms.KAllSyms
+
+	mu        sync.Mutex
+	installed bool
+	// libc used by each container, nil if the uprobes aren't attached
+	containers map[*containercollection.Container]*fileID
+	libcs      map[fileID]*libcProbes
+}
+
+func (t *Tracer) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.installed = false
+	for id, probes := range t.libcs {
+		for _, l := range probes.links {
+			gadgets.CloseLink(l)
+		}
+		delete(t.libcs, id)
+	}
+	for container := range t.containers {
+		t.containers[container] = nil
+	}
+
+	for i, l := range t.links {
+		t.links[i] = gadgets.CloseLink(l)
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadMemleak()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	consts := map[string]interface{}{
+		"min_size": t.config.MinSize,
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, consts, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	tracepoints := []struct {
+		name string
+		prog *ebpf.Program
+	}{
+		{"kmalloc", t.objs.IgMemleakKmalloc},
+		{"kmalloc_node", t.objs.IgMemleakKmallocNode},
+		{"kfree", t.objs.IgMemleakKfree},
+		{"kmem_cache_alloc", t.objs.IgMemleakCacheAlloc},
+		{"kmem_cache_alloc_node", t.objs.IgMemleakCacheAllocNode},
+		{"kmem_cache_free", t.objs.IgMemleakCacheFree},
+	}
+
+	for _, tp := range tracepoints {
+		l, err := link.Tracepoint("kmem", tp.name, tp.prog, nil)
+		if err != nil {
+			// The _node variants were merged into the other ones in Linux 6.1
+			if errors.Is(err, os.ErrNotExist) && strings.HasSuffix(tp.name, "_node") {
+				continue
+			}
+			return fmt.Errorf("attaching tracepoint kmem:%s: %w", tp.name, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	t.kAllSyms, err = kallsyms.Shared()
+	if err != nil {
+		return fmt.Errorf("reading kallsyms: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.installed = true
+	if t.config.User {
+		for container := range t.containers {
+			if err := t.attachLibc(container); err != nil {
+				return fmt.Errorf("tracing libc of container %q: %w", container.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// libcPaths are the usual locations of the glibc and musl libraries, relative
+// to the root of the container
+var libcPaths = []string{
+	"lib/*-linux-gnu/libc.so.6",
+	"usr/lib/*-linux-gnu/libc.so.6",
+	"lib64/libc.so.6",
+	"usr/lib64/libc.so.6",
+	"lib/libc.so.6",
+	"usr/lib/libc.so.6",
+	"lib/ld-musl-*.so.1",
+}
+
+// findLibc returns the path, from the host, of the libc used by the given
+// process. The libc mapped by the process is preferred, the usual locations
+// are used when the process didn't load it yet, e.g. it's still the runtime
+// setting up the container.
+func findLibc(pid uint32) (string, error) {
+	root := filepath.Join(host.HostProcFs, fmt.Sprint(pid))
+
+	file, err := os.Open(filepath.Join(root, "maps"))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// address perms offset dev inode pathname
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+
+		path := fields[5]
+		name := filepath.Base(path)
+		// With musl, the libc is the dynamic loader
+		if strings.HasPrefix(name, "libc.so") || strings.HasPrefix(name, "libc-") ||
+			strings.HasPrefix(name, "ld-musl-") {
+			return filepath.Join(root, "root", path), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	for _, pattern := range libcPaths {
+		matches, _ := filepath.Glob(filepath.Join(root, "root", pattern))
+		if len(matches) > 0 {
+			return matches[0], nil
+		}
+	}
+
+	return "", errors.New("libc not found")
+}
+
+// attachLibc attaches the uprobes to the libc of the container, unless they
+// are already attached because another container uses the same file. It must
+// be called with t.mu held.
+func (t *Tracer) attachLibc(container *containercollection.Container) error {
+	path, err := findLibc(container.Pid)
+	if err != nil {
+		return err
+	}
+
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return fmt.Errorf("stat %s: %w", path, err)
+	}
+	id := fileID{dev: st.Dev, ino: st.Ino}
+
+	if probes, ok := t.libcs[id]; ok {
+		probes.refs++
+		t.containers[container] = &id
+		return nil
+	}
+
+	ex, err := link.OpenExecutable(path)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", path, err)
+	}
+
+	uprobes := []struct {
+		symbol string
+		prog   *ebpf.Program
+		ret    bool
+	}{
+		{"malloc", t.objs.IgMemleakMallocE, false},
+		{"malloc", t.objs.IgMemleakMallocX, true},
+		{"calloc", t.objs.IgMemleakCallocE, false},
+		{"calloc", t.objs.IgMemleakCallocX, true},
+		{"realloc", t.objs.IgMemleakReallocE, false},
+		{"realloc", t.objs.IgMemleakReallocX, true},
+		{"free", t.objs.IgMemleakFree, false},
+	}
+
+	probes := &libcProbes{refs: 1}
+	for _, up := range uprobes {
+		var l link.Link
+		if up.ret {
+			l, err = ex.Uretprobe(up.symbol, up.prog, nil)
+		} else {
+			l, err = ex.Uprobe(up.symbol, up.prog, nil)
+		}
+		if err != nil {
+			for _, l := range probes.links {
+				gadgets.CloseLink(l)
+			}
+			return fmt.Errorf("attaching uprobe to %s in %s: %w", up.symbol, path, err)
+		}
+		probes.links = append(probes.links, l)
+	}
+
+	t.libcs[id] = probes
+	t.containers[container] = &id
+	return nil
+}
+
+// detachLibc releases the uprobes used by the container. It must be called
+// with t.mu held.
+func (t *Tracer) detachLibc(container *containercollection.Container) {
+	id := t.containers[container]
+	if id == nil {
+		return
+	}
+	t.containers[container] = nil
+
+	probes, ok := t.libcs[*id]
+	if !ok {
+		return
+	}
+	probes.refs--
+	if probes.refs > 0 {
+		return
+	}
+	for _, l := range probes.links {
+		gadgets.CloseLink(l)
+	}
+	delete(t.libcs, *id)
+}
+
+// isFiltered returns true if the mount namespace isn't one of the containers
+// selected by the user
+func (t *Tracer) isFiltered(mntnsID uint64) bool {
+	if t.config.MountnsMap == nil {
+		return false
+	}
+
+	var val uint32
+	return t.config.MountnsMap.Lookup(mntnsID, &val) != nil
+}
+
+func processExists(pid uint32) bool {
+	_, err := os.Stat(filepath.Join(host.HostProcFs, fmt.Sprint(pid)))
+	return err == nil
+}
+
+type stackKey struct {
+	mntnsID uint64
+	stackID int32
+	user    bool
+}
+
+type outstanding struct {
+	info  memleakAllocInfo
+	count uint64
+	bytes uint64
+}
+
+func (t *Tracer) getStack(stackID int32, user bool) []string {
+	if stackID < 0 {
+		return []string{"[missing stack]"}
+	}
+
+	ips := [maxStackDepth]uint64{}
+	if err := t.objs.StackTraces.Lookup(uint32(stackID), unsafe.Pointer(&ips)); err != nil {
+		return []string{"[missing stack]"}
+	}
+
+	// Kernel modules could have been loaded since the last report
+	if !user {
+		if kAllSyms, err := kallsyms.Shared(); err == nil {
+			t.kAllSyms = kAllSyms
+		}
+	}
+
+	stack := []string{}
+	for _, ip := range ips {
+		if ip == 0 {
+			break
+		}
+
+		// User-space addresses aren't symbolized
+		if user {
+			stack = append(stack, fmt.Sprintf("%#016x", ip))
+		} else {
+			stack = append(stack, t.kAllSyms.LookupByInstructionPointer(ip))
+		}
+	}
+
+	return stack
+}
+
+// collectReports returns the allocations older than the minimum age that are
+// still outstanding, grouped by container and stack
+func (t *Tracer) collectReports() ([]*types.Report, error) {
+	allocsMap := t.objs.Allocs
+
+	// bpf_ktime_get_ns() uses the monotonic clock
+	var now unix.Timespec
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &now); err != nil {
+		return nil, fmt.Errorf("getting monotonic time: %w", err)
+	}
+	minTimestamp := uint64(0)
+	if age := uint64(t.config.MinAge.Nanoseconds()); uint64(now.Nano()) > age {
+		minTimestamp = uint64(now.Nano()) - age
+	}
+
+	stacks := make(map[stackKey]*outstanding)
+	// The allocations of processes that exited won't ever be freed
+	var exited []memleakAllocKey
+	alive := make(map[uint32]bool)
+
+	var key memleakAllocKey
+	var info memleakAllocInfo
+	entries := allocsMap.Iterate()
+	for entries.Next(&key, &info) {
+		if info.TimestampNs > minTimestamp {
+			continue
+		}
+
+		user := info.User != 0
+		if user {
+			exists, ok := alive[info.Pid]
+			if !ok {
+				exists = processExists(info.Pid)
+				alive[info.Pid] = exists
+			}
+			if !exists {
+				exited = append(exited, key)
+				continue
+			}
+		}
+
+		if t.isFiltered(info.MntnsId) {
+			continue
+		}
+
+		sk := stackKey{mntnsID: info.MntnsId, stackID: info.StackId, user: user}
+		o, ok := stacks[sk]
+		if !ok {
+			o = &outstanding{}
+			stacks[sk] = o
+		}
+		o.count++
+		o.bytes += info.Size
+		if info.TimestampNs >= o.info.TimestampNs {
+			o.info = info
+		}
+	}
+	if err := entries.Err(); err != nil {
+		return nil, fmt.Errorf("iterating allocations: %w", err)
+	}
+
+	for _, key := range exited {
+		if err := allocsMap.Delete(key); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+			return nil, fmt.Errorf("deleting allocation of exited process: %w", err)
+		}
+	}
+
+	byMntns := make(map[uint64][]stackKey)
+	for sk := range stacks {
+		byMntns[sk.mntnsID] = append(byMntns[sk.mntnsID], sk)
+	}
+
+	mntnsIDs := make([]uint64, 0, len(byMntns))
+	for id := range byMntns {
+		mntnsIDs = append(mntnsIDs, id)
+	}
+	sort.Slice(mntnsIDs, func(i, j int) bool { return mntnsIDs[i] < mntnsIDs[j] })
+
+	reports := []*types.Report{}
+	for _, id := range mntnsIDs {
+		keys := byMntns[id]
+		sort.Slice(keys, func(i, j int) bool {
+			return stacks[keys[i]].bytes > stacks[keys[j]].bytes
+		})
+		if t.config.Top > 0 && len(keys) > t.config.Top {
+			keys = keys[:t.config.Top]
+		}
+
+		for _, sk := range keys {
+			o := stacks[sk]
+			kind := types.KindKernel
+			if sk.user {
+				kind = types.KindUser
+			}
+
+			reports = append(reports, &types.Report{
+				Pid:         o.info.Pid,
+				Comm:        gadgets.FromCString(o.info.Comm[:]),
+				Kind:        kind,
+				Allocations: o.count,
+				Bytes:       o.bytes,
+				Stack:       t.getStack(sk.stackID, sk.user),
+				MntnsID:     id,
+			})
+		}
+	}
+
+	return reports, nil
+}
+
+func (t *Tracer) emitReports() error {
+	reports, err := t.collectReports()
+	if err != nil {
+		return fmt.Errorf("collecting reports: %w", err)
+	}
+
+	for _, report := range reports {
+		if t.enricherFunc != nil {
+			t.enricherFunc(report)
+		}
+		t.eventCallback(report)
+	}
+
+	return nil
+}
+
+// --- Registry changes
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.Interval = time.Duration(params.Get(gadgets.ParamInterval).AsUint32()) * time.Second
+	t.config.Top = int(params.Get(ParamTop).AsUint32())
+	t.config.MinAge = params.Get(ParamOlder).AsDuration()
+	t.config.MinSize = params.Get(ParamMinSize).AsUint64()
+	t.config.User = params.Get(ParamUser).AsBool()
+
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	ctx, cancel := gadgetcontext.WithTimeoutOrCancel(gadgetCtx.Context(), gadgetCtx.Timeout())
+	defer cancel()
+
+	// A nil channel blocks forever: without interval, the allocations are
+	// only reported when the gadget stops
+	var tick <-chan time.Time
+	if t.config.Interval > 0 {
+		ticker := time.NewTicker(t.config.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return t.emitReports()
+		case <-tick:
+			if err := t.emitReports(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// AttachContainer keeps track of the containers to attach the libc uprobes
+// to. The kernel allocations are filtered with the mount namespace map.
+func (t *Tracer) AttachContainer(container *containercollection.Container) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.containers[container] = nil
+	if !t.installed || !t.config.User {
+		return nil
+	}
+	return t.attachLibc(container)
+}
+
+func (t *Tracer) DetachContainer(container *containercollection.Container) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.detachLibc(container)
+	delete(t.containers, container)
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Report))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (t *Tracer) SetEventEnricher(enricher func(ev any) error) {
+	t.enricherFunc = enricher
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config:     &Config{},
+		containers: make(map[*containercollection.Container]*fileID),
+		libcs:      make(map[fileID]*libcProbes),
+	}
+	return tracer, nil
+}
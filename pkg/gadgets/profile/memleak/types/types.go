@@ -0,0 +1,59 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	KindKernel = "kernel"
+	KindUser   = "user"
+)
+
+// Report is a set of allocations of a container, done from the same stack,
+// that weren't freed yet
+type Report struct {
+	eventtypes.CommonData
+
+	// Pid and Comm are the ones of the most recent allocation
+	Pid  uint32 `json:"pid" column:"pid,template:pid"`
+	Comm string `json:"comm" column:"comm,template:comm"`
+	Kind string `json:"kind" column:"kind,width:6"`
+
+	Allocations uint64 `json:"allocations" column:"allocs,width:8"`
+	Bytes       uint64 `json:"bytes" column:"bytes,width:12"`
+
+	Stack []string `json:"stack,omitempty"`
+
+	MntnsID uint64 `json:"-"`
+}
+
+func GetColumns() *columns.Columns[Report] {
+	return columns.MustCreateColumns[Report]()
+}
+
+func (r *Report) GetMountNSID() uint64 {
+	return r.MntnsID
+}
+
+func (r *Report) ExtraLines() []string {
+	out := make([]string, 0, len(r.Stack))
+	for _, s := range r.Stack {
+		out = append(out, "\t"+s)
+	}
+	return out
+}
@@ -0,0 +1,216 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "nfs.h"
+#include "bits.bpf.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+
+const volatile bool targ_ms = false;
+
+struct start_key {
+	__u32 tid;
+	__u32 op;
+};
+
+struct start_t {
+	__u64 ts;
+	__u64 mntns_id;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, struct start_key);
+	__type(value, struct start_t);
+} starts SEC(".maps");
+
+// RPC tasks are started by the process doing the operation but most of them
+// complete in the rpciod workqueue, they are tracked by their address. Their
+// fields aren't read: struct rpc_task is defined by the sunrpc module and isn't
+// available for CO-RE relocations when it's built as a module.
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u64);
+	__type(value, struct start_t);
+} rpc_starts SEC(".maps");
+
+static struct hist zero;
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, struct hist_key);
+	__type(value, struct hist);
+} hists SEC(".maps");
+
+static __always_inline void update_hist(__u64 mntns_id, enum nfs_op op, __u64 delta)
+{
+	struct hist_key key = {};
+	struct hist *histp;
+	__u64 slot;
+
+	key.mntns_id = mntns_id;
+	key.op = op;
+
+	histp = bpf_map_lookup_elem(&hists, &key);
+	if (!histp) {
+		bpf_map_update_elem(&hists, &key, &zero, BPF_NOEXIST);
+		histp = bpf_map_lookup_elem(&hists, &key);
+		if (!histp)
+			return;
+	}
+
+	if (targ_ms)
+		delta /= 1000000U;
+	else
+		delta /= 1000U;
+	slot = log2l(delta);
+	if (slot >= MAX_SLOTS)
+		slot = MAX_SLOTS - 1;
+	__sync_fetch_and_add(&histp->slots[slot], 1);
+	__sync_fetch_and_add(&histp->latency, delta);
+	__sync_fetch_and_add(&histp->cnt, 1);
+}
+
+static __always_inline int probe_entry(enum nfs_op op)
+{
+	struct start_key key = {};
+	struct start_t start = {};
+	__u64 mntns_id;
+
+	mntns_id = gadget_get_mntns_id();
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return 0;
+
+	key.tid = (__u32)bpf_get_current_pid_tgid();
+	key.op = op;
+	start.ts = bpf_ktime_get_ns();
+	start.mntns_id = mntns_id;
+	bpf_map_update_elem(&starts, &key, &start, BPF_ANY);
+	return 0;
+}
+
+static __always_inline int probe_exit(enum nfs_op op)
+{
+	struct start_key key = {};
+	struct start_t *startp;
+	__s64 delta;
+
+	key.tid = (__u32)bpf_get_current_pid_tgid();
+	key.op = op;
+
+	startp = bpf_map_lookup_elem(&starts, &key);
+	if (!startp)
+		return 0;
+
+	delta = (__s64)(bpf_ktime_get_ns() - startp->ts);
+	if (delta >= 0)
+		update_hist(startp->mntns_id, op, delta);
+
+	bpf_map_delete_elem(&starts, &key);
+	return 0;
+}
+
+SEC("kprobe/nfs_file_read")
+int ig_nfs_read_e(struct pt_regs *ctx)
+{
+	return probe_entry(NFS_OP_READ);
+}
+
+SEC("kretprobe/nfs_file_read")
+int ig_nfs_read_x(struct pt_regs *ctx)
+{
+	return probe_exit(NFS_OP_READ);
+}
+
+SEC("kprobe/nfs_file_write")
+int ig_nfs_write_e(struct pt_regs *ctx)
+{
+	return probe_entry(NFS_OP_WRITE);
+}
+
+SEC("kretprobe/nfs_file_write")
+int ig_nfs_write_x(struct pt_regs *ctx)
+{
+	return probe_exit(NFS_OP_WRITE);
+}
+
+SEC("kprobe/nfs_file_open")
+int ig_nfs_open_e(struct pt_regs *ctx)
+{
+	return probe_entry(NFS_OP_OPEN);
+}
+
+SEC("kretprobe/nfs_file_open")
+int ig_nfs_open_x(struct pt_regs *ctx)
+{
+	return probe_exit(NFS_OP_OPEN);
+}
+
+SEC("kprobe/nfs_file_fsync")
+int ig_nfs_fsync_e(struct pt_regs *ctx)
+{
+	return probe_entry(NFS_OP_FSYNC);
+}
+
+SEC("kretprobe/nfs_file_fsync")
+int ig_nfs_fsync_x(struct pt_regs *ctx)
+{
+	return probe_exit(NFS_OP_FSYNC);
+}
+
+SEC("kprobe/nfs_getattr")
+int ig_nfs_getattr_e(struct pt_regs *ctx)
+{
+	return probe_entry(NFS_OP_GETATTR);
+}
+
+SEC("kretprobe/nfs_getattr")
+int ig_nfs_getattr_x(struct pt_regs *ctx)
+{
+	return probe_exit(NFS_OP_GETATTR);
+}
+
+SEC("raw_tp/rpc_task_begin")
+int BPF_PROG(ig_nfs_rpc_begin, const struct rpc_task *task)
+{
+	struct start_t start = {};
+	__u64 key = (__u64)task;
+	__u64 mntns_id;
+
+	mntns_id = gadget_get_mntns_id();
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return 0;
+
+	start.ts = bpf_ktime_get_ns();
+	start.mntns_id = mntns_id;
+	bpf_map_update_elem(&rpc_starts, &key, &start, BPF_ANY);
+	return 0;
+}
+
+SEC("raw_tp/rpc_task_end")
+int BPF_PROG(ig_nfs_rpc_end, const struct rpc_task *task)
+{
+	__u64 key = (__u64)task;
+	struct start_t *startp;
+	__s64 delta;
+
+	startp = bpf_map_lookup_elem(&rpc_starts, &key);
+	if (!startp)
+		return 0;
+
+	delta = (__s64)(bpf_ktime_get_ns() - startp->ts);
+	if (delta >= 0)
+		update_hist(startp->mntns_id, NFS_OP_RPC, delta);
+
+	bpf_map_delete_elem(&rpc_starts, &key);
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
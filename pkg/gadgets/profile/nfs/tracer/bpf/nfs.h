@@ -0,0 +1,28 @@
+/* SPDX-License-Identifier: (LGPL-2.1 OR BSD-2-Clause) */
+#ifndef __NFS_H
+#define __NFS_H
+
+#define MAX_SLOTS	27
+
+enum nfs_op {
+	NFS_OP_READ,
+	NFS_OP_WRITE,
+	NFS_OP_OPEN,
+	NFS_OP_FSYNC,
+	NFS_OP_GETATTR,
+	NFS_OP_RPC,
+};
+
+struct hist_key {
+	__u64 mntns_id;
+	enum nfs_op op;
+	__u32 pad;
+};
+
+struct hist {
+	__u64 latency;
+	__u64 cnt;
+	__u32 slots[MAX_SLOTS];
+};
+
+#endif /* __NFS_H */
@@ -0,0 +1,195 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build arm64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type nfsHist struct {
+	Latency uint64
+	Cnt     uint64
+	Slots   [27]uint32
+	_       [4]byte
+}
+
+type nfsHistKey struct {
+	MntnsId uint64
+	Op      nfsNfsOp
+	Pad     uint32
+}
+
+type nfsNfsOp uint32
+
+const (
+	nfsNfsOpNFS_OP_READ    nfsNfsOp = 0
+	nfsNfsOpNFS_OP_WRITE   nfsNfsOp = 1
+	nfsNfsOpNFS_OP_OPEN    nfsNfsOp = 2
+	nfsNfsOpNFS_OP_FSYNC   nfsNfsOp = 3
+	nfsNfsOpNFS_OP_GETATTR nfsNfsOp = 4
+	nfsNfsOpNFS_OP_RPC     nfsNfsOp = 5
+)
+
+type nfsStartKey struct {
+	Tid uint32
+	Op  uint32
+}
+
+type nfsStartT struct {
+	Ts      uint64
+	MntnsId uint64
+}
+
+// loadNfs returns the embedded CollectionSpec for nfs.
+func loadNfs() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_NfsBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load nfs: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadNfsObjects loads nfs and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*nfsObjects
+//	*nfsPrograms
+//	*nfsMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadNfsObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadNfs()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// nfsSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type nfsSpecs struct {
+	nfsProgramSpecs
+	nfsMapSpecs
+}
+
+// nfsSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type nfsProgramSpecs struct {
+	IgNfsFsyncE   *ebpf.ProgramSpec `ebpf:"ig_nfs_fsync_e"`
+	IgNfsFsyncX   *ebpf.ProgramSpec `ebpf:"ig_nfs_fsync_x"`
+	IgNfsGetattrE *ebpf.ProgramSpec `ebpf:"ig_nfs_getattr_e"`
+	IgNfsGetattrX *ebpf.ProgramSpec `ebpf:"ig_nfs_getattr_x"`
+	IgNfsOpenE    *ebpf.ProgramSpec `ebpf:"ig_nfs_open_e"`
+	IgNfsOpenX    *ebpf.ProgramSpec `ebpf:"ig_nfs_open_x"`
+	IgNfsReadE    *ebpf.ProgramSpec `ebpf:"ig_nfs_read_e"`
+	IgNfsReadX    *ebpf.ProgramSpec `ebpf:"ig_nfs_read_x"`
+	IgNfsRpcBegin *ebpf.ProgramSpec `ebpf:"ig_nfs_rpc_begin"`
+	IgNfsRpcEnd   *ebpf.ProgramSpec `ebpf:"ig_nfs_rpc_end"`
+	IgNfsWriteE   *ebpf.ProgramSpec `ebpf:"ig_nfs_write_e"`
+	IgNfsWriteX   *ebpf.ProgramSpec `ebpf:"ig_nfs_write_x"`
+}
+
+// nfsMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type nfsMapSpecs struct {
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Hists                *ebpf.MapSpec `ebpf:"hists"`
+	RpcStarts            *ebpf.MapSpec `ebpf:"rpc_starts"`
+	Starts               *ebpf.MapSpec `ebpf:"starts"`
+}
+
+// nfsObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadNfsObjects or ebpf.CollectionSpec.LoadAndAssign.
+type nfsObjects struct {
+	nfsPrograms
+	nfsMaps
+}
+
+func (o *nfsObjects) Close() error {
+	return _NfsClose(
+		&o.nfsPrograms,
+		&o.nfsMaps,
+	)
+}
+
+// nfsMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadNfsObjects or ebpf.CollectionSpec.LoadAndAssign.
+type nfsMaps struct {
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Hists                *ebpf.Map `ebpf:"hists"`
+	RpcStarts            *ebpf.Map `ebpf:"rpc_starts"`
+	Starts               *ebpf.Map `ebpf:"starts"`
+}
+
+func (m *nfsMaps) Close() error {
+	return _NfsClose(
+		m.GadgetMntnsFilterMap,
+		m.Hists,
+		m.RpcStarts,
+		m.Starts,
+	)
+}
+
+// nfsPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadNfsObjects or ebpf.CollectionSpec.LoadAndAssign.
+type nfsPrograms struct {
+	IgNfsFsyncE   *ebpf.Program `ebpf:"ig_nfs_fsync_e"`
+	IgNfsFsyncX   *ebpf.Program `ebpf:"ig_nfs_fsync_x"`
+	IgNfsGetattrE *ebpf.Program `ebpf:"ig_nfs_getattr_e"`
+	IgNfsGetattrX *ebpf.Program `ebpf:"ig_nfs_getattr_x"`
+	IgNfsOpenE    *ebpf.Program `ebpf:"ig_nfs_open_e"`
+	IgNfsOpenX    *ebpf.Program `ebpf:"ig_nfs_open_x"`
+	IgNfsReadE    *ebpf.Program `ebpf:"ig_nfs_read_e"`
+	IgNfsReadX    *ebpf.Program `ebpf:"ig_nfs_read_x"`
+	IgNfsRpcBegin *ebpf.Program `ebpf:"ig_nfs_rpc_begin"`
+	IgNfsRpcEnd   *ebpf.Program `ebpf:"ig_nfs_rpc_end"`
+	IgNfsWriteE   *ebpf.Program `ebpf:"ig_nfs_write_e"`
+	IgNfsWriteX   *ebpf.Program `ebpf:"ig_nfs_write_x"`
+}
+
+func (p *nfsPrograms) Close() error {
+	return _NfsClose(
+		p.IgNfsFsyncE,
+		p.IgNfsFsyncX,
+		p.IgNfsGetattrE,
+		p.IgNfsGetattrX,
+		p.IgNfsOpenE,
+		p.IgNfsOpenX,
+		p.IgNfsReadE,
+		p.IgNfsReadX,
+		p.IgNfsRpcBegin,
+		p.IgNfsRpcEnd,
+		p.IgNfsWriteE,
+		p.IgNfsWriteX,
+	)
+}
+
+func _NfsClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed nfs_bpfel_arm64.o
+var _NfsBytes []byte
@@ -0,0 +1,221 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/nfs/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/histogram"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -type hist -type hist_key -type nfs_op nfs ./bpf/nfs.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
+
+type Config struct {
+	MountnsMap      *ebpf.Map
+	UseMilliseconds bool
+}
+
+type Tracer struct {
+	config        *Config
+	enricherFunc  func(ev any) error
+	eventCallback func(*types.Report)
+
+	objs  nfsObjects
+	links []link.Link
+}
+
+var operations = map[nfsNfsOp]string{
+	nfsNfsOpNFS_OP_READ:    types.OperationRead,
+	nfsNfsOpNFS_OP_WRITE:   types.OperationWrite,
+	nfsNfsOpNFS_OP_OPEN:    types.OperationOpen,
+	nfsNfsOpNFS_OP_FSYNC:   types.OperationFsync,
+	nfsNfsOpNFS_OP_GETATTR: types.OperationGetattr,
+	nfsNfsOpNFS_OP_RPC:     types.OperationRPC,
+}
+
+func (t *Tracer) close() {
+	for i, l := range t.links {
+		t.links[i] = gadgets.CloseLink(l)
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadNfs()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	consts := map[string]interface{}{
+		"targ_ms": t.config.UseMilliseconds,
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, consts, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	// The functions and the tracepoints are provided by the nfs and sunrpc
+	// modules, attaching fails if they aren't loaded.
+	kprobes := []struct {
+		symbol string
+		entry  *ebpf.Program
+		exit   *ebpf.Program
+	}{
+		{"nfs_file_read", t.objs.IgNfsReadE, t.objs.IgNfsReadX},
+		{"nfs_file_write", t.objs.IgNfsWriteE, t.objs.IgNfsWriteX},
+		{"nfs_file_open", t.objs.IgNfsOpenE, t.objs.IgNfsOpenX},
+		{"nfs_file_fsync", t.objs.IgNfsFsyncE, t.objs.IgNfsFsyncX},
+		{"nfs_getattr", t.objs.IgNfsGetattrE, t.objs.IgNfsGetattrX},
+	}
+
+	for _, kp := range kprobes {
+		l, err := link.Kprobe(kp.symbol, kp.entry, nil)
+		if err != nil {
+			return fmt.Errorf("attaching kprobe %s: %w", kp.symbol, err)
+		}
+		t.links = append(t.links, l)
+
+		l, err = link.Kretprobe(kp.symbol, kp.exit, nil)
+		if err != nil {
+			return fmt.Errorf("attaching kretprobe %s: %w", kp.symbol, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	tracepoints := []struct {
+		name string
+		prog *ebpf.Program
+	}{
+		{"rpc_task_begin", t.objs.IgNfsRpcBegin},
+		{"rpc_task_end", t.objs.IgNfsRpcEnd},
+	}
+
+	for _, tp := range tracepoints {
+		l, err := link.AttachRawTracepoint(link.RawTracepointOptions{Name: tp.name, Program: tp.prog})
+		if err != nil {
+			return fmt.Errorf("attaching tracepoint %s: %w", tp.name, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	return nil
+}
+
+func (t *Tracer) collectReports() ([]*types.Report, error) {
+	histsMap := t.objs.Hists
+
+	unit := histogram.UnitMicroseconds
+	if t.config.UseMilliseconds {
+		unit = histogram.UnitMilliseconds
+	}
+
+	reports := []*types.Report{}
+
+	key := nfsHistKey{}
+	err := histsMap.NextKey(nil, unsafe.Pointer(&key))
+	for err == nil {
+		hist := nfsHist{}
+		if err := histsMap.Lookup(key, unsafe.Pointer(&hist)); err != nil {
+			return nil, fmt.Errorf("getting histogram for mount namespace %d: %w", key.MntnsId, err)
+		}
+
+		report := types.NewReport(unit, hist.Slots[:])
+		report.MntnsID = key.MntnsId
+		report.Operation = operations[key.Op]
+		report.Count = hist.Cnt
+		if hist.Cnt > 0 {
+			report.Average = float64(hist.Latency) / float64(hist.Cnt)
+		}
+		reports = append(reports, report)
+
+		prev := key
+		err = histsMap.NextKey(unsafe.Pointer(&prev), unsafe.Pointer(&key))
+	}
+	if !errors.Is(err, ebpf.ErrKeyNotExist) {
+		return nil, fmt.Errorf("getting next histogram key: %w", err)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].MntnsID != reports[j].MntnsID {
+			return reports[i].MntnsID < reports[j].MntnsID
+		}
+		return reports[i].Operation < reports[j].Operation
+	})
+
+	return reports, nil
+}
+
+// --- Registry changes
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	t.config.UseMilliseconds = gadgetCtx.GadgetParams().Get(ParamMilliseconds).AsBool()
+
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	reports, err := t.collectReports()
+	if err != nil {
+		return fmt.Errorf("collecting reports: %w", err)
+	}
+
+	for _, report := range reports {
+		// Containers could be gone already, they won't be enriched
+		if t.enricherFunc != nil {
+			t.enricherFunc(report)
+		}
+		t.eventCallback(report)
+	}
+
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Report))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (t *Tracer) SetEventEnricher(enricher func(ev any) error) {
+	t.enricherFunc = enricher
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+	}
+	return tracer, nil
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: GPL-2.0
+// Copyright (c) 2021 Wenbo Zhang
+// Copyright (c) 2023 The Inspektor Gadget authors
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "offcpu.h"
+#include "maps.bpf.h"
+#include "mntns_filter.h"
+
+#define PF_KTHREAD		0x00200000	/* I am a kernel thread */
+#define MAX_STACK_DEPTH		127
+
+const volatile bool kernel_stacks_only = false;
+const volatile bool user_stacks_only = false;
+const volatile __u64 min_block_ns = 1;
+
+struct internal_key {
+	__u64 start_ts;
+	struct key_t key;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__type(key, __u32);
+	__type(value, struct internal_key);
+	__uint(max_entries, MAX_ENTRIES);
+} start SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_STACK_TRACE);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, MAX_STACK_DEPTH * sizeof(__u64));
+	__uint(max_entries, MAX_ENTRIES);
+} stackmap SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__type(key, struct key_t);
+	__type(value, __u64);
+	__uint(max_entries, MAX_ENTRIES);
+} info SEC(".maps");
+
+/* The blocked time is accounted when the task is scheduled again */
+SEC("raw_tp/sched_switch")
+int BPF_PROG(ig_offcpu_switch, bool preempt, struct task_struct *prev,
+	     struct task_struct *next)
+{
+	struct internal_key *i_keyp, i_key = {};
+	static const __u64 zero;
+	__u64 *valp;
+	__s64 delta;
+	__u64 mntns_id;
+	__u32 tid;
+
+	/* prev is still the current task, its stacks are the ones it blocks in */
+	tid = BPF_CORE_READ(prev, pid);
+	mntns_id = gadget_get_mntns_id();
+	if (tid != 0 && !gadget_should_discard_mntns_id(mntns_id)) {
+		i_key.key.mntns_id = mntns_id;
+		i_key.key.pid = BPF_CORE_READ(prev, tgid);
+		i_key.start_ts = bpf_ktime_get_ns();
+		bpf_get_current_comm(&i_key.key.name, sizeof(i_key.key.name));
+
+		if (user_stacks_only)
+			i_key.key.kern_stack_id = -1;
+		else
+			i_key.key.kern_stack_id = bpf_get_stackid(ctx, &stackmap, 0);
+
+		if (kernel_stacks_only || BPF_CORE_READ(prev, flags) & PF_KTHREAD)
+			i_key.key.user_stack_id = -1;
+		else
+			i_key.key.user_stack_id = bpf_get_stackid(ctx, &stackmap,
+								  BPF_F_USER_STACK);
+
+		bpf_map_update_elem(&start, &tid, &i_key, BPF_ANY);
+	}
+
+	tid = BPF_CORE_READ(next, pid);
+	i_keyp = bpf_map_lookup_elem(&start, &tid);
+	if (!i_keyp)
+		return 0;
+
+	delta = (__s64)(bpf_ktime_get_ns() - i_keyp->start_ts);
+	if (delta < 0 || delta < min_block_ns)
+		goto cleanup;
+
+	valp = bpf_map_lookup_or_try_init(&info, &i_keyp->key, &zero);
+	if (valp)
+		__sync_fetch_and_add(valp, delta);
+
+cleanup:
+	bpf_map_delete_elem(&start, &tid);
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
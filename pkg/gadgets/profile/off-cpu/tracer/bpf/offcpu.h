@@ -0,0 +1,16 @@
+/* SPDX-License-Identifier: (LGPL-2.1 OR BSD-2-Clause) */
+#ifndef __OFFCPU_H
+#define __OFFCPU_H
+
+#define TASK_COMM_LEN		16
+#define MAX_ENTRIES		10240
+
+struct key_t {
+	__u64 mntns_id;
+	__u32 pid;
+	int user_stack_id;
+	int kern_stack_id;
+	__u8 name[TASK_COMM_LEN];
+};
+
+#endif /* __OFFCPU_H */
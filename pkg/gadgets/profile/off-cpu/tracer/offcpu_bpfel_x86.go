@@ -0,0 +1,142 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type offcpuInternalKey struct {
+	StartTs uint64
+	Key     offcpuKeyT
+}
+
+type offcpuKeyT struct {
+	MntnsId     uint64
+	Pid         uint32
+	UserStackId int32
+	KernStackId int32
+	Name        [16]uint8
+	_           [4]byte
+}
+
+// loadOffcpu returns the embedded CollectionSpec for offcpu.
+func loadOffcpu() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_OffcpuBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load offcpu: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadOffcpuObjects loads offcpu and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*offcpuObjects
+//	*offcpuPrograms
+//	*offcpuMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadOffcpuObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadOffcpu()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// offcpuSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type offcpuSpecs struct {
+	offcpuProgramSpecs
+	offcpuMapSpecs
+}
+
+// offcpuSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type offcpuProgramSpecs struct {
+	IgOffcpuSwitch *ebpf.ProgramSpec `ebpf:"ig_offcpu_switch"`
+}
+
+// offcpuMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type offcpuMapSpecs struct {
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Info                 *ebpf.MapSpec `ebpf:"info"`
+	Stackmap             *ebpf.MapSpec `ebpf:"stackmap"`
+	Start                *ebpf.MapSpec `ebpf:"start"`
+}
+
+// offcpuObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadOffcpuObjects or ebpf.CollectionSpec.LoadAndAssign.
+type offcpuObjects struct {
+	offcpuPrograms
+	offcpuMaps
+}
+
+func (o *offcpuObjects) Close() error {
+	return _OffcpuClose(
+		&o.offcpuPrograms,
+		&o.offcpuMaps,
+	)
+}
+
+// offcpuMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadOffcpuObjects or ebpf.CollectionSpec.LoadAndAssign.
+type offcpuMaps struct {
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Info                 *ebpf.Map `ebpf:"info"`
+	Stackmap             *ebpf.Map `ebpf:"stackmap"`
+	Start                *ebpf.Map `ebpf:"start"`
+}
+
+func (m *offcpuMaps) Close() error {
+	return _OffcpuClose(
+		m.GadgetMntnsFilterMap,
+		m.Info,
+		m.Stackmap,
+		m.Start,
+	)
+}
+
+// offcpuPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadOffcpuObjects or ebpf.CollectionSpec.LoadAndAssign.
+type offcpuPrograms struct {
+	IgOffcpuSwitch *ebpf.Program `ebpf:"ig_offcpu_switch"`
+}
+
+func (p *offcpuPrograms) Close() error {
+	return _OffcpuClose(
+		p.IgOffcpuSwitch,
+	)
+}
+
+func _OffcpuClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed offcpu_bpfel_x86.o
+var _OffcpuBytes []byte
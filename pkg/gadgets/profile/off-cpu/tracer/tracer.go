@@ -0,0 +1,198 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/off-cpu/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/kallsyms"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -type key_t offcpu ./bpf/offcpu.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
+
+const perfMaxStackDepth = 127
+
+type Config struct {
+	MountnsMap      *ebpf.Map
+	UserStackOnly   bool
+	KernelStackOnly bool
+	MinBlock        time.Duration
+}
+
+type Tracer struct {
+	config        *Config
+	enricherFunc  func(ev any) error
+	eventCallback func(*types.Report)
+
+	objs offcpuObjects
+	link link.Link
+}
+
+func (t *Tracer) close() {
+	t.link = gadgets.CloseLink(t.link)
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadOffcpu()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	consts := map[string]interface{}{
+		"kernel_stacks_only": t.config.KernelStackOnly,
+		"user_stacks_only":   t.config.UserStackOnly,
+		"min_block_ns":       uint64(t.config.MinBlock.Nanoseconds()),
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, consts, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	t.link, err = link.AttachRawTracepoint(link.RawTracepointOptions{
+		Name:    "sched_switch",
+		Program: t.objs.IgOffcpuSwitch,
+	})
+	if err != nil {
+		return fmt.Errorf("attaching tracepoint: %w", err)
+	}
+
+	return nil
+}
+
+func (t *Tracer) getStack(stackID int32, kAllSyms *kallsyms.KAllSyms) []string {
+	if stackID < 0 {
+		return nil
+	}
+
+	ips := [perfMaxStackDepth]uint64{}
+	if err := t.objs.Stackmap.Lookup(uint32(stackID), unsafe.Pointer(&ips)); err != nil {
+		return nil
+	}
+
+	symbols := []string{}
+	for _, ip := range ips {
+		if ip == 0 {
+			break
+		}
+
+		// We will not support getting userland symbols.
+		if kAllSyms == nil {
+			symbols = append(symbols, "[unknown]")
+		} else {
+			symbols = append(symbols, kAllSyms.LookupByInstructionPointer(ip))
+		}
+	}
+
+	return symbols
+}
+
+func (t *Tracer) collectReports() ([]*types.Report, error) {
+	kAllSyms, err := kallsyms.Shared()
+	if err != nil {
+		return nil, fmt.Errorf("reading kallsyms: %w", err)
+	}
+
+	reports := []*types.Report{}
+
+	var key offcpuKeyT
+	var value uint64
+	entries := t.objs.Info.Iterate()
+	for entries.Next(&key, &value) {
+		reports = append(reports, &types.Report{
+			Comm:        gadgets.FromCString(key.Name[:]),
+			Pid:         key.Pid,
+			UserStack:   t.getStack(key.UserStackId, nil),
+			KernelStack: t.getStack(key.KernStackId, kAllSyms),
+			Duration:    time.Duration(value),
+			MntnsID:     key.MntnsId,
+		})
+	}
+	if err := entries.Err(); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+		return nil, fmt.Errorf("iterating blocked times: %w", err)
+	}
+
+	// The longest blocked times are shown last, close to the prompt
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Duration < reports[j].Duration
+	})
+
+	return reports, nil
+}
+
+// --- Registry changes
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.UserStackOnly = params.Get(ParamUserStack).AsBool()
+	t.config.KernelStackOnly = params.Get(ParamKernelStack).AsBool()
+	t.config.MinBlock = params.Get(ParamMinBlock).AsDuration()
+
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	reports, err := t.collectReports()
+	if err != nil {
+		return fmt.Errorf("collecting reports: %w", err)
+	}
+
+	for _, report := range reports {
+		if t.enricherFunc != nil {
+			t.enricherFunc(report)
+		}
+		t.eventCallback(report)
+	}
+
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Report))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (t *Tracer) SetEventEnricher(enricher func(ev any) error) {
+	t.enricherFunc = enricher
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+	}
+	return tracer, nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// Report is the time the threads of a process spent blocked in the same stack
+type Report struct {
+	eventtypes.CommonData
+
+	Comm        string        `json:"comm,omitempty" column:"comm,template:comm"`
+	Pid         uint32        `json:"pid,omitempty" column:"pid,template:pid"`
+	UserStack   []string      `json:"userStack,omitempty"`
+	KernelStack []string      `json:"kernelStack,omitempty"`
+	Duration    time.Duration `json:"duration,omitempty" column:"duration,minWidth:12"`
+
+	MntnsID uint64 `json:"-"`
+}
+
+func GetColumns() *columns.Columns[Report] {
+	return columns.MustCreateColumns[Report]()
+}
+
+func (r *Report) GetMountNSID() uint64 {
+	return r.MntnsID
+}
+
+func (r *Report) ExtraLines() []string {
+	var out []string
+	for i := len(r.KernelStack) - 1; i >= 0; i-- {
+		out = append(out, "\t"+r.KernelStack[i])
+	}
+	for i := len(r.UserStack) - 1; i >= 0; i-- {
+		out = append(out, "\t"+r.UserStack[i])
+	}
+	return out
+}
+
+// Folded returns the report in the folded format used to generate flame
+// graphs: the container, the command and the frames from the outermost one,
+// separated by semicolons, followed by the blocked time in microseconds
+func (r *Report) Folded() string {
+	frames := []string{}
+	if container := r.containerName(); container != "" {
+		frames = append(frames, container)
+	}
+	frames = append(frames, r.Comm)
+	for i := len(r.UserStack) - 1; i >= 0; i-- {
+		frames = append(frames, r.UserStack[i])
+	}
+	if len(r.UserStack) > 0 && len(r.KernelStack) > 0 {
+		frames = append(frames, "-")
+	}
+	for i := len(r.KernelStack) - 1; i >= 0; i-- {
+		frames = append(frames, r.KernelStack[i])
+	}
+
+	return fmt.Sprintf("%s %d", strings.Join(frames, ";"), r.Duration.Microseconds())
+}
+
+func (r *Report) containerName() string {
+	parts := []string{}
+	for _, part := range []string{r.Namespace, r.Pod, r.Container} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "/")
+}
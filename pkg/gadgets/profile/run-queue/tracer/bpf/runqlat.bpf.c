@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: GPL-2.0
+// Copyright (c) 2020 Wenbo Zhang
+// Copyright (c) 2023 The Inspektor Gadget authors
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "runqlat.h"
+#include "bits.bpf.h"
+#include "core_fixes.bpf.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+#define TASK_RUNNING	0
+
+const volatile bool targ_ms = false;
+
+struct start_t {
+	__u64 ts;
+	__u64 mntns_id;
+};
+
+/* Time the tasks were made runnable at, indexed by thread id */
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__type(value, struct start_t);
+} start SEC(".maps");
+
+static struct hist zero;
+
+/* Histograms indexed by the mount namespace of the tasks */
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u64);
+	__type(value, struct hist);
+} hists SEC(".maps");
+
+static __always_inline int trace_enqueue(struct task_struct *p)
+{
+	struct start_t data = {};
+	__u32 tid = BPF_CORE_READ(p, pid);
+
+	if (!tid)
+		return 0;
+
+	data.mntns_id = BPF_CORE_READ(p, nsproxy, mnt_ns, ns.inum);
+	if (gadget_should_discard_mntns_id(data.mntns_id))
+		return 0;
+
+	data.ts = bpf_ktime_get_ns();
+	bpf_map_update_elem(&start, &tid, &data, BPF_ANY);
+	return 0;
+}
+
+SEC("raw_tp/sched_wakeup")
+int BPF_PROG(ig_runqlat_wakeup, struct task_struct *p)
+{
+	return trace_enqueue(p);
+}
+
+SEC("raw_tp/sched_wakeup_new")
+int BPF_PROG(ig_runqlat_wakeup_new, struct task_struct *p)
+{
+	return trace_enqueue(p);
+}
+
+SEC("raw_tp/sched_switch")
+int BPF_PROG(ig_runqlat_switch, bool preempt, struct task_struct *prev,
+	     struct task_struct *next)
+{
+	struct start_t *startp;
+	struct hist *histp;
+	__u64 slot;
+	__s64 delta;
+	__u32 tid;
+
+	/* A preempted task is still runnable and waits to run again */
+	if (get_task_state(prev) == TASK_RUNNING)
+		trace_enqueue(prev);
+
+	tid = BPF_CORE_READ(next, pid);
+	startp = bpf_map_lookup_elem(&start, &tid);
+	if (!startp)
+		return 0;
+
+	delta = (__s64)(bpf_ktime_get_ns() - startp->ts);
+	if (delta < 0)
+		goto cleanup;
+
+	histp = bpf_map_lookup_elem(&hists, &startp->mntns_id);
+	if (!histp) {
+		bpf_map_update_elem(&hists, &startp->mntns_id, &zero, BPF_NOEXIST);
+		histp = bpf_map_lookup_elem(&hists, &startp->mntns_id);
+		if (!histp)
+			goto cleanup;
+	}
+
+	if (targ_ms)
+		delta /= 1000000U;
+	else
+		delta /= 1000U;
+
+	/*
+	 * The worst offender isn't updated atomically, it may be mixed up with
+	 * another task of the container waiting as long on another CPU.
+	 */
+	if (delta >= histp->max) {
+		histp->max = delta;
+		histp->max_pid = BPF_CORE_READ(next, tgid);
+		histp->max_tid = tid;
+		BPF_CORE_READ_STR_INTO(&histp->max_comm, next, comm);
+	}
+
+	slot = log2l(delta);
+	if (slot >= MAX_SLOTS)
+		slot = MAX_SLOTS - 1;
+	__sync_fetch_and_add(&histp->slots[slot], 1);
+	__sync_fetch_and_add(&histp->latency, delta);
+	__sync_fetch_and_add(&histp->cnt, 1);
+
+cleanup:
+	bpf_map_delete_elem(&start, &tid);
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
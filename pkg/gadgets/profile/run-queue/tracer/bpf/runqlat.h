@@ -0,0 +1,19 @@
+/* SPDX-License-Identifier: (LGPL-2.1 OR BSD-2-Clause) */
+#ifndef __RUNQLAT_H
+#define __RUNQLAT_H
+
+#define TASK_COMM_LEN	16
+#define MAX_SLOTS	26
+
+struct hist {
+	__u64 latency;
+	__u64 cnt;
+	/* The longest wait of the interval and the task that suffered it */
+	__u64 max;
+	__u32 max_pid;
+	__u32 max_tid;
+	__u8 max_comm[TASK_COMM_LEN];
+	__u32 slots[MAX_SLOTS];
+};
+
+#endif /* __RUNQLAT_H */
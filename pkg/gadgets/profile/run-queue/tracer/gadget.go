@@ -0,0 +1,84 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/run-queue/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
+)
+
+const (
+	ParamMilliseconds = "milliseconds"
+)
+
+type GadgetDesc struct{}
+
+func (g *GadgetDesc) Name() string {
+	return "run-queue"
+}
+
+func (g *GadgetDesc) Category() string {
+	return gadgets.CategoryProfile
+}
+
+func (g *GadgetDesc) Type() gadgets.GadgetType {
+	return gadgets.TypeProfile
+}
+
+func (g *GadgetDesc) Description() string {
+	return "Analyze the run queue latency of each container through latency distributions"
+}
+
+func (g *GadgetDesc) ParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:          gadgets.ParamInterval,
+			Title:        "Interval",
+			DefaultValue: "10",
+			Description:  "Interval (in Seconds) at which the histograms are shown and reset, 0 to show them only when the gadget stops",
+			TypeHint:     params.TypeUint32,
+		},
+		{
+			Key:          ParamMilliseconds,
+			Alias:        "m",
+			DefaultValue: "false",
+			Description:  "Show histograms in milliseconds instead of microseconds",
+			TypeHint:     params.TypeBool,
+		},
+	}
+}
+
+func (g *GadgetDesc) Parser() parser.Parser {
+	return parser.NewParser[types.Report](types.GetColumns())
+}
+
+func (g *GadgetDesc) EventPrototype() any {
+	return &types.Report{}
+}
+
+func (g *GadgetDesc) Cost() gadgets.Cost {
+	return gadgets.Cost{
+		Probes:    3,
+		Events:    "every wakeup and context switch",
+		EventCost: gadgets.CostHigh,
+	}
+}
+
+func init() {
+	gadgetregistry.Register(&GadgetDesc{})
+}
@@ -0,0 +1,146 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build arm64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type runqlatHist struct {
+	Latency uint64
+	Cnt     uint64
+	Max     uint64
+	MaxPid  uint32
+	MaxTid  uint32
+	MaxComm [16]uint8
+	Slots   [26]uint32
+}
+
+type runqlatStartT struct {
+	Ts      uint64
+	MntnsId uint64
+}
+
+// loadRunqlat returns the embedded CollectionSpec for runqlat.
+func loadRunqlat() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_RunqlatBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load runqlat: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadRunqlatObjects loads runqlat and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*runqlatObjects
+//	*runqlatPrograms
+//	*runqlatMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadRunqlatObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadRunqlat()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// runqlatSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type runqlatSpecs struct {
+	runqlatProgramSpecs
+	runqlatMapSpecs
+}
+
+// runqlatSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type runqlatProgramSpecs struct {
+	IgRunqlatSwitch    *ebpf.ProgramSpec `ebpf:"ig_runqlat_switch"`
+	IgRunqlatWakeup    *ebpf.ProgramSpec `ebpf:"ig_runqlat_wakeup"`
+	IgRunqlatWakeupNew *ebpf.ProgramSpec `ebpf:"ig_runqlat_wakeup_new"`
+}
+
+// runqlatMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type runqlatMapSpecs struct {
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Hists                *ebpf.MapSpec `ebpf:"hists"`
+	Start                *ebpf.MapSpec `ebpf:"start"`
+}
+
+// runqlatObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadRunqlatObjects or ebpf.CollectionSpec.LoadAndAssign.
+type runqlatObjects struct {
+	runqlatPrograms
+	runqlatMaps
+}
+
+func (o *runqlatObjects) Close() error {
+	return _RunqlatClose(
+		&o.runqlatPrograms,
+		&o.runqlatMaps,
+	)
+}
+
+// runqlatMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadRunqlatObjects or ebpf.CollectionSpec.LoadAndAssign.
+type runqlatMaps struct {
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Hists                *ebpf.Map `ebpf:"hists"`
+	Start                *ebpf.Map `ebpf:"start"`
+}
+
+func (m *runqlatMaps) Close() error {
+	return _RunqlatClose(
+		m.GadgetMntnsFilterMap,
+		m.Hists,
+		m.Start,
+	)
+}
+
+// runqlatPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadRunqlatObjects or ebpf.CollectionSpec.LoadAndAssign.
+type runqlatPrograms struct {
+	IgRunqlatSwitch    *ebpf.Program `ebpf:"ig_runqlat_switch"`
+	IgRunqlatWakeup    *ebpf.Program `ebpf:"ig_runqlat_wakeup"`
+	IgRunqlatWakeupNew *ebpf.Program `ebpf:"ig_runqlat_wakeup_new"`
+}
+
+func (p *runqlatPrograms) Close() error {
+	return _RunqlatClose(
+		p.IgRunqlatSwitch,
+		p.IgRunqlatWakeup,
+		p.IgRunqlatWakeupNew,
+	)
+}
+
+func _RunqlatClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed runqlat_bpfel_arm64.o
+var _RunqlatBytes []byte
@@ -0,0 +1,221 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/run-queue/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/histogram"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -type hist runqlat ./bpf/runqlat.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
+
+type Config struct {
+	MountnsMap      *ebpf.Map
+	Interval        time.Duration
+	UseMilliseconds bool
+}
+
+type Tracer struct {
+	config        *Config
+	enricherFunc  func(ev any) error
+	eventCallback func(*types.Report)
+
+	objs  runqlatObjects
+	links []link.Link
+}
+
+func (t *Tracer) close() {
+	for i, l := range t.links {
+		t.links[i] = gadgets.CloseLink(l)
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadRunqlat()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	consts := map[string]interface{}{
+		"targ_ms": t.config.UseMilliseconds,
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, consts, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	tracepoints := []struct {
+		name string
+		prog *ebpf.Program
+	}{
+		{"sched_wakeup", t.objs.IgRunqlatWakeup},
+		{"sched_wakeup_new", t.objs.IgRunqlatWakeupNew},
+		{"sched_switch", t.objs.IgRunqlatSwitch},
+	}
+
+	for _, tp := range tracepoints {
+		l, err := link.AttachRawTracepoint(link.RawTracepointOptions{Name: tp.name, Program: tp.prog})
+		if err != nil {
+			return fmt.Errorf("attaching tracepoint for %s: %w", tp.name, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	return nil
+}
+
+// collectReports returns the histograms gathered since the last call and
+// resets them
+func (t *Tracer) collectReports() ([]*types.Report, error) {
+	histsMap := t.objs.Hists
+
+	unit := histogram.UnitMicroseconds
+	if t.config.UseMilliseconds {
+		unit = histogram.UnitMilliseconds
+	}
+
+	hists := make(map[uint64]runqlatHist)
+
+	var key uint64
+	err := histsMap.NextKey(nil, unsafe.Pointer(&key))
+	for err == nil {
+		hist := runqlatHist{}
+		if err := histsMap.Lookup(key, unsafe.Pointer(&hist)); err != nil {
+			return nil, fmt.Errorf("getting histogram for mount namespace %d: %w", key, err)
+		}
+		hists[key] = hist
+
+		prev := key
+		err = histsMap.NextKey(unsafe.Pointer(&prev), unsafe.Pointer(&key))
+	}
+	if !errors.Is(err, ebpf.ErrKeyNotExist) {
+		return nil, fmt.Errorf("getting next histogram key: %w", err)
+	}
+
+	reports := make([]*types.Report, 0, len(hists))
+	for id, hist := range hists {
+		if err := histsMap.Delete(id); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+			return nil, fmt.Errorf("resetting histogram for mount namespace %d: %w", id, err)
+		}
+
+		report := types.NewReport(unit, hist.Slots[:])
+		report.MntnsID = id
+		report.Count = hist.Cnt
+		if hist.Cnt > 0 {
+			report.Average = float64(hist.Latency) / float64(hist.Cnt)
+		}
+		report.Max = hist.Max
+		report.Pid = hist.MaxPid
+		report.Tid = hist.MaxTid
+		report.Comm = gadgets.FromCString(hist.MaxComm[:])
+		reports = append(reports, report)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].MntnsID < reports[j].MntnsID
+	})
+
+	return reports, nil
+}
+
+func (t *Tracer) emitReports() error {
+	reports, err := t.collectReports()
+	if err != nil {
+		return fmt.Errorf("collecting reports: %w", err)
+	}
+
+	for _, report := range reports {
+		if t.enricherFunc != nil && report.MntnsID != 0 {
+			t.enricherFunc(report)
+		}
+		t.eventCallback(report)
+	}
+
+	return nil
+}
+
+// --- Registry changes
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.Interval = time.Duration(params.Get(gadgets.ParamInterval).AsUint32()) * time.Second
+	t.config.UseMilliseconds = params.Get(ParamMilliseconds).AsBool()
+
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	ctx, cancel := gadgetcontext.WithTimeoutOrCancel(gadgetCtx.Context(), gadgetCtx.Timeout())
+	defer cancel()
+
+	// A nil channel blocks forever: without interval, the histograms are only
+	// shown when the gadget stops
+	var tick <-chan time.Time
+	if t.config.Interval > 0 {
+		ticker := time.NewTicker(t.config.Interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return t.emitReports()
+		case <-tick:
+			if err := t.emitReports(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Report))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (t *Tracer) SetEventEnricher(enricher func(ev any) error) {
+	t.enricherFunc = enricher
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+	}
+	return tracer, nil
+}
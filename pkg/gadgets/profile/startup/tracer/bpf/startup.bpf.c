@@ -0,0 +1,232 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include <bpf/bpf_endian.h>
+#include "startup.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+
+#define AF_INET		2
+#define AF_INET6	10
+
+// Only the events happening during this time after the start of the container
+// are recorded
+const volatile __u64 window_ns = 0;
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event *unusedevent __attribute__((unused));
+
+struct args_t {
+	// Pointer to the path or the socket address
+	__u64 arg;
+	enum event_type type;
+};
+
+// Arguments of the open and connect calls in progress, indexed by thread
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__type(value, struct args_t);
+} start SEC(".maps");
+
+// The stack is limited, so use a map to build the event
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, __u32);
+	__type(value, struct event);
+} tmp_event SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, sizeof(__u32));
+} events SEC(".maps");
+
+// elapsed_since_start returns the time elapsed since the init process of the
+// pid namespace of the current task, the main process of the container,
+// started. It returns 0 if it's out of the window.
+static __always_inline __u64 elapsed_since_start(__u64 now)
+{
+	struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+	struct task_struct *reaper;
+	__u64 start;
+
+	reaper = BPF_CORE_READ(task, nsproxy, pid_ns_for_children, child_reaper);
+	if (!reaper)
+		return 0;
+
+	start = BPF_CORE_READ(reaper, start_boottime);
+	if (!start || start > now || now - start > window_ns)
+		return 0;
+
+	return now - start;
+}
+
+static __always_inline struct event *
+new_event(enum event_type type, __u64 mntns_id)
+{
+	__u64 now = bpf_ktime_get_boot_ns();
+	struct event *event;
+	__u64 elapsed;
+	__u32 zero = 0;
+
+	elapsed = elapsed_since_start(now);
+	if (!elapsed)
+		return NULL;
+
+	event = bpf_map_lookup_elem(&tmp_event, &zero);
+	if (!event)
+		return NULL;
+
+	__builtin_memset(event, 0, sizeof(*event));
+
+	event->type = type;
+	event->mntns_id = mntns_id;
+	event->timestamp = now;
+	event->elapsed = elapsed;
+	event->pid = bpf_get_current_pid_tgid() >> 32;
+	bpf_get_current_comm(&event->task, sizeof(event->task));
+
+	return event;
+}
+
+static __always_inline int probe_entry(const void *arg, enum event_type type)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	struct args_t args = {
+		.arg = (__u64)arg,
+		.type = type,
+	};
+
+	if (gadget_should_discard_mntns_id(gadget_get_mntns_id()))
+		return 0;
+
+	if (!elapsed_since_start(bpf_ktime_get_boot_ns()))
+		return 0;
+
+	bpf_map_update_elem(&start, &tid, &args, BPF_ANY);
+	return 0;
+}
+
+static __always_inline void read_sockaddr(struct event *event, const void *uaddr)
+{
+	struct sockaddr_in6 addr6;
+	struct sockaddr_in addr;
+	sa_family_t family;
+
+	if (bpf_probe_read_user(&family, sizeof(family), uaddr))
+		return;
+
+	switch (family) {
+	case AF_INET:
+		if (bpf_probe_read_user(&addr, sizeof(addr), uaddr))
+			return;
+		event->ver = 4;
+		event->port = bpf_ntohs(addr.sin_port);
+		__builtin_memcpy(event->addr, &addr.sin_addr, 4);
+		break;
+	case AF_INET6:
+		if (bpf_probe_read_user(&addr6, sizeof(addr6), uaddr))
+			return;
+		event->ver = 6;
+		event->port = bpf_ntohs(addr6.sin6_port);
+		__builtin_memcpy(event->addr, &addr6.sin6_addr, 16);
+		break;
+	}
+}
+
+static __always_inline int probe_exit(void *ctx, int ret)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	struct args_t *args;
+	struct event *event;
+
+	args = bpf_map_lookup_elem(&start, &tid);
+	if (!args)
+		return 0;
+
+	event = new_event(args->type, gadget_get_mntns_id());
+	if (!event)
+		goto cleanup;
+
+	event->ret = ret;
+	if (args->type == STARTUP_EVENT_TYPE_OPEN) {
+		bpf_probe_read_user_str(&event->path, sizeof(event->path), (const void *)args->arg);
+	} else {
+		read_sockaddr(event, (const void *)args->arg);
+		// Unix sockets and other families aren't reported
+		if (!event->ver)
+			goto cleanup;
+	}
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
+
+cleanup:
+	bpf_map_delete_elem(&start, &tid);
+	return 0;
+}
+
+SEC("tracepoint/sched/sched_process_exec")
+int ig_startup_exec(struct trace_event_raw_sched_process_exec *ctx)
+{
+	__u64 mntns_id = gadget_get_mntns_id();
+	unsigned int fname_off;
+	struct event *event;
+
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return 0;
+
+	event = new_event(STARTUP_EVENT_TYPE_EXEC, mntns_id);
+	if (!event)
+		return 0;
+
+	fname_off = ctx->__data_loc_filename & 0xFFFF;
+	bpf_probe_read_kernel_str(&event->path, sizeof(event->path), (void *)ctx + fname_off);
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
+	return 0;
+}
+
+SEC("tracepoint/syscalls/sys_enter_open")
+int ig_startup_open_e(struct trace_event_raw_sys_enter *ctx)
+{
+	return probe_entry((const void *)ctx->args[0], STARTUP_EVENT_TYPE_OPEN);
+}
+
+SEC("tracepoint/syscalls/sys_enter_openat")
+int ig_startup_openat_e(struct trace_event_raw_sys_enter *ctx)
+{
+	return probe_entry((const void *)ctx->args[1], STARTUP_EVENT_TYPE_OPEN);
+}
+
+SEC("tracepoint/syscalls/sys_enter_connect")
+int ig_startup_connect_e(struct trace_event_raw_sys_enter *ctx)
+{
+	return probe_entry((const void *)ctx->args[1], STARTUP_EVENT_TYPE_CONNECT);
+}
+
+SEC("tracepoint/syscalls/sys_exit_open")
+int ig_startup_open_x(struct trace_event_raw_sys_exit *ctx)
+{
+	return probe_exit(ctx, (int)ctx->ret);
+}
+
+SEC("tracepoint/syscalls/sys_exit_openat")
+int ig_startup_openat_x(struct trace_event_raw_sys_exit *ctx)
+{
+	return probe_exit(ctx, (int)ctx->ret);
+}
+
+SEC("tracepoint/syscalls/sys_exit_connect")
+int ig_startup_connect_x(struct trace_event_raw_sys_exit *ctx)
+{
+	return probe_exit(ctx, (int)ctx->ret);
+}
+
+char LICENSE[] SEC("license") = "GPL";
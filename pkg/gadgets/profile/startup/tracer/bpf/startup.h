@@ -0,0 +1,29 @@
+/* SPDX-License-Identifier: GPL-2.0 */
+#ifndef GADGET_STARTUP_H
+#define GADGET_STARTUP_H
+
+#define TASK_COMM_LEN	16
+#define PATH_MAX	256
+
+enum event_type : u8 {
+	STARTUP_EVENT_TYPE_EXEC,
+	STARTUP_EVENT_TYPE_OPEN,
+	STARTUP_EVENT_TYPE_CONNECT,
+};
+
+struct event {
+	__u64 mntns_id;
+	__u64 timestamp;
+	// Time elapsed since the init process of the container started
+	__u64 elapsed;
+	__u32 pid;
+	int ret;
+	__u8 addr[16];
+	__u16 port;
+	__u8 ver;
+	enum event_type type;
+	__u8 task[TASK_COMM_LEN];
+	__u8 path[PATH_MAX];
+};
+
+#endif /* GADGET_STARTUP_H */
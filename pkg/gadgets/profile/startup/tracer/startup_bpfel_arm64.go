@@ -0,0 +1,175 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build arm64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type startupArgsT struct {
+	Arg  uint64
+	Type startupEventType
+	_    [7]byte
+}
+
+type startupEvent struct {
+	MntnsId   uint64
+	Timestamp uint64
+	Elapsed   uint64
+	Pid       uint32
+	Ret       int32
+	Addr      [16]uint8
+	Port      uint16
+	Ver       uint8
+	Type      startupEventType
+	Task      [16]uint8
+	Path      [256]uint8
+	_         [4]byte
+}
+
+type startupEventType uint8
+
+const (
+	startupEventTypeSTARTUP_EVENT_TYPE_EXEC    startupEventType = 0
+	startupEventTypeSTARTUP_EVENT_TYPE_OPEN    startupEventType = 1
+	startupEventTypeSTARTUP_EVENT_TYPE_CONNECT startupEventType = 2
+)
+
+// loadStartup returns the embedded CollectionSpec for startup.
+func loadStartup() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_StartupBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load startup: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadStartupObjects loads startup and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*startupObjects
+//	*startupPrograms
+//	*startupMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadStartupObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadStartup()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// startupSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type startupSpecs struct {
+	startupProgramSpecs
+	startupMapSpecs
+}
+
+// startupSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type startupProgramSpecs struct {
+	IgStartupConnectE *ebpf.ProgramSpec `ebpf:"ig_startup_connect_e"`
+	IgStartupConnectX *ebpf.ProgramSpec `ebpf:"ig_startup_connect_x"`
+	IgStartupExec     *ebpf.ProgramSpec `ebpf:"ig_startup_exec"`
+	IgStartupOpenE    *ebpf.ProgramSpec `ebpf:"ig_startup_open_e"`
+	IgStartupOpenX    *ebpf.ProgramSpec `ebpf:"ig_startup_open_x"`
+	IgStartupOpenatE  *ebpf.ProgramSpec `ebpf:"ig_startup_openat_e"`
+	IgStartupOpenatX  *ebpf.ProgramSpec `ebpf:"ig_startup_openat_x"`
+}
+
+// startupMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type startupMapSpecs struct {
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Start                *ebpf.MapSpec `ebpf:"start"`
+	TmpEvent             *ebpf.MapSpec `ebpf:"tmp_event"`
+}
+
+// startupObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadStartupObjects or ebpf.CollectionSpec.LoadAndAssign.
+type startupObjects struct {
+	startupPrograms
+	startupMaps
+}
+
+func (o *startupObjects) Close() error {
+	return _StartupClose(
+		&o.startupPrograms,
+		&o.startupMaps,
+	)
+}
+
+// startupMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadStartupObjects or ebpf.CollectionSpec.LoadAndAssign.
+type startupMaps struct {
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Start                *ebpf.Map `ebpf:"start"`
+	TmpEvent             *ebpf.Map `ebpf:"tmp_event"`
+}
+
+func (m *startupMaps) Close() error {
+	return _StartupClose(
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.Start,
+		m.TmpEvent,
+	)
+}
+
+// startupPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadStartupObjects or ebpf.CollectionSpec.LoadAndAssign.
+type startupPrograms struct {
+	IgStartupConnectE *ebpf.Program `ebpf:"ig_startup_connect_e"`
+	IgStartupConnectX *ebpf.Program `ebpf:"ig_startup_connect_x"`
+	IgStartupExec     *ebpf.Program `ebpf:"ig_startup_exec"`
+	IgStartupOpenE    *ebpf.Program `ebpf:"ig_startup_open_e"`
+	IgStartupOpenX    *ebpf.Program `ebpf:"ig_startup_open_x"`
+	IgStartupOpenatE  *ebpf.Program `ebpf:"ig_startup_openat_e"`
+	IgStartupOpenatX  *ebpf.Program `ebpf:"ig_startup_openat_x"`
+}
+
+func (p *startupPrograms) Close() error {
+	return _StartupClose(
+		p.IgStartupConnectE,
+		p.IgStartupConnectX,
+		p.IgStartupExec,
+		p.IgStartupOpenE,
+		p.IgStartupOpenX,
+		p.IgStartupOpenatE,
+		p.IgStartupOpenatX,
+	)
+}
+
+func _StartupClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed startup_bpfel_arm64.o
+var _StartupBytes []byte
@@ -0,0 +1,275 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/profile/startup/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -type event -type event_type startup ./bpf/startup.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
+
+type Config struct {
+	MountnsMap *ebpf.Map
+	Window     time.Duration
+	MaxEvents  int
+}
+
+type tracepoint struct {
+	group string
+	name  string
+	prog  *ebpf.Program
+}
+
+type Tracer struct {
+	config        *Config
+	enricherFunc  func(ev any) error
+	eventCallback func(*types.Report)
+
+	objs   startupObjects
+	links  []link.Link
+	reader *perf.Reader
+
+	// reports indexed by mount namespace, only accessed by run()
+	reports map[uint64]*types.Report
+	done    chan struct{}
+}
+
+func (t *Tracer) close() {
+	for i, l := range t.links {
+		t.links[i] = gadgets.CloseLink(l)
+	}
+
+	if t.reader != nil {
+		t.reader.Close()
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadStartup()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	consts := map[string]interface{}{
+		"window_ns": uint64(t.config.Window.Nanoseconds()),
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, consts, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	tracepoints := []tracepoint{
+		{"sched", "sched_process_exec", t.objs.IgStartupExec},
+		{"syscalls", "sys_enter_openat", t.objs.IgStartupOpenatE},
+		{"syscalls", "sys_exit_openat", t.objs.IgStartupOpenatX},
+		{"syscalls", "sys_enter_connect", t.objs.IgStartupConnectE},
+		{"syscalls", "sys_exit_connect", t.objs.IgStartupConnectX},
+	}
+
+	// arm64 does not defined an open() syscall, only openat().
+	if runtime.GOARCH != "arm64" {
+		tracepoints = append(tracepoints,
+			tracepoint{"syscalls", "sys_enter_open", t.objs.IgStartupOpenE},
+			tracepoint{"syscalls", "sys_exit_open", t.objs.IgStartupOpenX},
+		)
+	}
+
+	for _, tp := range tracepoints {
+		l, err := link.Tracepoint(tp.group, tp.name, tp.prog, nil)
+		if err != nil {
+			return fmt.Errorf("attaching tracepoint %s/%s: %w", tp.group, tp.name, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	t.reader, err = perf.NewReader(t.objs.startupMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	if err != nil {
+		return fmt.Errorf("creating perf ring buffer: %w", err)
+	}
+
+	return nil
+}
+
+var operations = map[startupEventType]string{
+	startupEventTypeSTARTUP_EVENT_TYPE_EXEC:    types.OperationExec,
+	startupEventTypeSTARTUP_EVENT_TYPE_OPEN:    types.OperationOpen,
+	startupEventTypeSTARTUP_EVENT_TYPE_CONNECT: types.OperationConnect,
+}
+
+func (t *Tracer) report(mntnsID uint64) *types.Report {
+	report, ok := t.reports[mntnsID]
+	if ok {
+		return report
+	}
+
+	report = &types.Report{MntnsID: mntnsID}
+	// Enrich the report as soon as the container is seen, it could be gone
+	// when the gadget finishes.
+	if t.enricherFunc != nil {
+		t.enricherFunc(report)
+	}
+	t.reports[mntnsID] = report
+	return report
+}
+
+func entryFromEvent(bpfEvent *startupEvent) types.Entry {
+	entry := types.Entry{
+		Elapsed:   time.Duration(bpfEvent.Elapsed),
+		Pid:       bpfEvent.Pid,
+		Comm:      gadgets.FromCString(bpfEvent.Task[:]),
+		Operation: operations[bpfEvent.Type],
+	}
+
+	switch bpfEvent.Type {
+	case startupEventTypeSTARTUP_EVENT_TYPE_CONNECT:
+		addr := gadgets.IPStringFromBytes(bpfEvent.Addr, int(bpfEvent.Ver))
+		entry.Target = net.JoinHostPort(addr, strconv.Itoa(int(bpfEvent.Port)))
+	default:
+		entry.Target = gadgets.FromCString(bpfEvent.Path[:])
+	}
+
+	if bpfEvent.Ret < 0 {
+		entry.Error = unix.ErrnoName(syscall.Errno(-bpfEvent.Ret))
+	}
+
+	return entry
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+
+	for {
+		record, err := t.reader.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				// nothing to do, we're done
+				return
+			}
+
+			log.Errorf("Error reading perf ring buffer: %s", err)
+			return
+		}
+
+		if record.LostSamples > 0 {
+			log.Warnf("lost %d samples", record.LostSamples)
+			continue
+		}
+
+		bpfEvent := (*startupEvent)(unsafe.Pointer(&record.RawSample[0]))
+
+		report := t.report(bpfEvent.MntnsId)
+		switch bpfEvent.Type {
+		case startupEventTypeSTARTUP_EVENT_TYPE_EXEC:
+			report.Execs++
+		case startupEventTypeSTARTUP_EVENT_TYPE_OPEN:
+			report.Opens++
+		case startupEventTypeSTARTUP_EVENT_TYPE_CONNECT:
+			report.Connects++
+		}
+
+		if len(report.Timeline) >= t.config.MaxEvents {
+			report.Dropped++
+			continue
+		}
+		report.Timeline = append(report.Timeline, entryFromEvent(bpfEvent))
+	}
+}
+
+// --- Registry changes
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.Window = params.Get(ParamWindow).AsDuration()
+	t.config.MaxEvents = int(params.Get(ParamMaxEvents).AsUint32())
+
+	t.reports = make(map[uint64]*types.Report)
+	t.done = make(chan struct{})
+
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	go t.run()
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	// Stop reading events before emitting the reports
+	t.reader.Close()
+	<-t.done
+
+	reports := make([]*types.Report, 0, len(t.reports))
+	for _, report := range t.reports {
+		// Events from different CPUs aren't read in order
+		sort.SliceStable(report.Timeline, func(i, j int) bool {
+			return report.Timeline[i].Elapsed < report.Timeline[j].Elapsed
+		})
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].MntnsID < reports[j].MntnsID
+	})
+
+	for _, report := range reports {
+		t.eventCallback(report)
+	}
+
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Report))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (t *Tracer) SetEventEnricher(enricher func(ev any) error) {
+	t.enricherFunc = enricher
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+	}
+	return tracer, nil
+}
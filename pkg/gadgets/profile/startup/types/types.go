@@ -0,0 +1,80 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperationExec    = "exec"
+	OperationOpen    = "open"
+	OperationConnect = "connect"
+)
+
+// Entry is an operation done by a process of the container while it was
+// starting
+type Entry struct {
+	// Elapsed is the time since the main process of the container started
+	Elapsed   time.Duration `json:"elapsed"`
+	Pid       uint32        `json:"pid"`
+	Comm      string        `json:"comm"`
+	Operation string        `json:"operation"`
+	// Target is the executed or opened file, or the address connected to
+	Target string `json:"target"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Report struct {
+	eventtypes.CommonData
+
+	Execs    int `json:"execs" column:"execs,width:6"`
+	Opens    int `json:"opens" column:"opens,width:6"`
+	Connects int `json:"connects" column:"connects,width:8"`
+
+	Timeline []Entry `json:"timeline,omitempty"`
+	// Dropped is the number of entries that didn't fit in the timeline
+	Dropped int `json:"dropped,omitempty"`
+
+	MntnsID uint64 `json:"-"`
+}
+
+func GetColumns() *columns.Columns[Report] {
+	return columns.MustCreateColumns[Report]()
+}
+
+func (r *Report) GetMountNSID() uint64 {
+	return r.MntnsID
+}
+
+func (r *Report) ExtraLines() []string {
+	out := make([]string, 0, len(r.Timeline)+1)
+	for _, e := range r.Timeline {
+		line := fmt.Sprintf("\t+%-12s %-7s %-7d %-16s %s", e.Elapsed.Round(time.Microsecond),
+			e.Operation, e.Pid, e.Comm, e.Target)
+		if e.Error != "" {
+			line += " (" + e.Error + ")"
+		}
+		out = append(out, line)
+	}
+	if r.Dropped > 0 {
+		out = append(out, fmt.Sprintf("\t... %d more", r.Dropped))
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/fsusage/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
+)
+
+const (
+	ParamDepth = "depth"
+	ParamTop   = "top"
+)
+
+type GadgetDesc struct{}
+
+func (g *GadgetDesc) Name() string {
+	return "fsusage"
+}
+
+func (g *GadgetDesc) Category() string {
+	return gadgets.CategorySnapshot
+}
+
+func (g *GadgetDesc) Type() gadgets.GadgetType {
+	return gadgets.TypeOneShot
+}
+
+func (g *GadgetDesc) Description() string {
+	return "Gather the disk and inode usage of the writable layer of the containers"
+}
+
+func (g *GadgetDesc) ParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:          ParamDepth,
+			DefaultValue: "2",
+			Description:  "Depth of the directories the usage is summarized by",
+			TypeHint:     params.TypeUint32,
+		},
+		{
+			Key:          ParamTop,
+			Alias:        "T",
+			DefaultValue: "5",
+			Description:  "Number of directories with the most usage to show for each container, 0 to not show them",
+			TypeHint:     params.TypeUint32,
+		},
+	}
+}
+
+func (g *GadgetDesc) Parser() parser.Parser {
+	return parser.NewParser[types.Event](types.GetColumns())
+}
+
+func (g *GadgetDesc) EventPrototype() any {
+	return &types.Event{}
+}
+
+func (g *GadgetDesc) SortByDefault() []string {
+	return types.SortByDefault
+}
+
+func init() {
+	gadgetregistry.Register(&GadgetDesc{})
+}
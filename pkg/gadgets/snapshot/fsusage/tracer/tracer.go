@@ -0,0 +1,237 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/sys/unix"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/fsusage/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+type Config struct {
+	Depth int
+	Top   int
+}
+
+type Tracer struct {
+	config *Config
+	// containers indexed by mount namespace
+	containers   map[uint64]*containercollection.Container
+	eventHandler func(ev []*types.Event)
+}
+
+// getUpperDir returns the path, from the host, of the upper directory of the
+// overlay mounted as root filesystem of the given process
+func getUpperDir(pid uint32) (string, error) {
+	file, err := os.Open(filepath.Join(host.HostProcFs, fmt.Sprint(pid), "mountinfo"))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	upperDir := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// id parent major:minor root mountpoint options [optional...] - fstype source superoptions
+		mount, fsInfo, ok := strings.Cut(scanner.Text(), " - ")
+		if !ok {
+			continue
+		}
+		mountFields := strings.Fields(mount)
+		fsFields := strings.Fields(fsInfo)
+		if len(mountFields) < 5 || len(fsFields) < 3 {
+			continue
+		}
+		if mountFields[4] != "/" || fsFields[0] != "overlay" {
+			continue
+		}
+
+		// The last mount on / is the one visible by the process
+		for _, opt := range strings.Split(fsFields[2], ",") {
+			if strings.HasPrefix(opt, "upperdir=") {
+				upperDir = strings.TrimPrefix(opt, "upperdir=")
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if upperDir == "" {
+		return "", errors.New("root filesystem isn't an overlay with a writable layer")
+	}
+
+	return filepath.Join(host.HostRoot, upperDir), nil
+}
+
+// dirKey returns the directory, limited to the given depth, the usage of the
+// path is summarized by. Files are summarized by their parent directory.
+func dirKey(rel string, isDir bool, depth int) string {
+	if !isDir {
+		rel = filepath.Dir(rel)
+	}
+	if rel == "." {
+		return "/"
+	}
+
+	components := strings.Split(rel, string(filepath.Separator))
+	if len(components) > depth {
+		components = components[:depth]
+	}
+	return "/" + strings.Join(components, "/")
+}
+
+// walkUpperDir returns the usage of the upper directory and of its
+// directories, down to the configured depth
+func (t *Tracer) walkUpperDir(upperDir string) (*types.Event, error) {
+	event := &types.Event{
+		Event: eventtypes.Event{
+			Type: eventtypes.NORMAL,
+		},
+		UpperDir: upperDir,
+	}
+
+	dirs := make(map[string]*types.DirUsage)
+	// Files with several hard links are only counted once
+	seen := make(map[uint64]struct{})
+
+	err := filepath.WalkDir(upperDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == upperDir {
+				return err
+			}
+			// The containers keep writing while the walk is in progress
+			return nil
+		}
+
+		var st unix.Stat_t
+		if err := unix.Lstat(path, &st); err != nil {
+			return nil
+		}
+
+		if st.Nlink > 1 && !d.IsDir() {
+			if _, ok := seen[st.Ino]; ok {
+				return nil
+			}
+			seen[st.Ino] = struct{}{}
+		}
+
+		// st_blocks is in 512-byte units regardless of the block size
+		usage := uint64(st.Blocks) * 512
+
+		event.Inodes++
+		event.Usage += usage
+		event.Size += uint64(st.Size)
+
+		rel, err := filepath.Rel(upperDir, path)
+		if err != nil || rel == "." || t.config.Top == 0 {
+			return nil
+		}
+
+		key := dirKey(rel, d.IsDir(), t.config.Depth)
+		dir, ok := dirs[key]
+		if !ok {
+			dir = &types.DirUsage{Path: key}
+			dirs[key] = dir
+		}
+		dir.Inodes++
+		dir.Usage += usage
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, dir := range dirs {
+		event.TopDirs = append(event.TopDirs, *dir)
+	}
+	sort.Slice(event.TopDirs, func(i, j int) bool {
+		if event.TopDirs[i].Usage != event.TopDirs[j].Usage {
+			return event.TopDirs[i].Usage > event.TopDirs[j].Usage
+		}
+		return event.TopDirs[i].Path < event.TopDirs[j].Path
+	})
+	if len(event.TopDirs) > t.config.Top {
+		event.TopDirs = event.TopDirs[:t.config.Top]
+	}
+
+	return event, nil
+}
+
+// ---
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return &Tracer{
+		config:     &Config{},
+		containers: make(map[uint64]*containercollection.Container),
+	}, nil
+}
+
+func (t *Tracer) AttachContainer(container *containercollection.Container) error {
+	t.containers[container.Mntns] = container
+	return nil
+}
+
+func (t *Tracer) DetachContainer(container *containercollection.Container) error {
+	return nil
+}
+
+func (t *Tracer) SetEventHandlerArray(handler any) {
+	nh, ok := handler.(func(ev []*types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventHandler = nh
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.Depth = int(params.Get(ParamDepth).AsUint32())
+	t.config.Top = int(params.Get(ParamTop).AsUint32())
+
+	events := []*types.Event{}
+	for mntns, container := range t.containers {
+		upperDir, err := getUpperDir(container.Pid)
+		if err != nil {
+			gadgetCtx.Logger().Warnf("getting writable layer of container %q: %s", container.Name, err)
+			continue
+		}
+
+		event, err := t.walkUpperDir(upperDir)
+		if err != nil {
+			gadgetCtx.Logger().Warnf("walking writable layer of container %q: %s", container.Name, err)
+			continue
+		}
+		event.MountNsID = mntns
+		events = append(events, event)
+	}
+
+	t.eventHandler(events)
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+var SortByDefault = []string{"-usage", "-inodes"}
+
+// DirUsage is the disk usage of a directory of the writable layer
+type DirUsage struct {
+	Path   string `json:"path"`
+	Usage  uint64 `json:"usage"`
+	Inodes uint64 `json:"inodes"`
+}
+
+// Event is the disk usage of the writable layer of a container
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	UpperDir string `json:"upperDir,omitempty" column:"upperdir,width:40,hide"`
+	// Usage is the space allocated on disk, in bytes, like du does
+	Usage uint64 `json:"usage" column:"usage,width:12"`
+	// Size is the apparent size of the files, in bytes
+	Size   uint64 `json:"size" column:"size,width:12,hide"`
+	Inodes uint64 `json:"inodes" column:"inodes,width:8"`
+
+	TopDirs []DirUsage `json:"topDirs,omitempty"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	return columns.MustCreateColumns[Event]()
+}
+
+func (e *Event) ExtraLines() []string {
+	out := make([]string, 0, len(e.TopDirs))
+	for _, d := range e.TopDirs {
+		out = append(out, fmt.Sprintf("\t%-12d %-8d %s", d.Usage, d.Inodes, d.Path))
+	}
+	return out
+}
@@ -0,0 +1,69 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/inventory/types"
+)
+
+// parseLSMs parses /sys/kernel/security/lsm: the comma-separated list of the
+// LSMs in the order they are called
+func parseLSMs(content string) []*types.Event {
+	var events []*types.Event
+	for i, name := range strings.Split(strings.TrimSpace(content), ",") {
+		if name == "" {
+			continue
+		}
+		events = append(events, &types.Event{
+			Kind: types.KindLSM,
+			ID:   uint32(i + 1),
+			Name: name,
+		})
+	}
+	return events
+}
+
+// parseModules parses /proc/modules: each line is the name, size, reference
+// count, dependent modules, state and address of a kernel module
+func parseModules(r io.Reader) ([]*types.Event, error) {
+	var events []*types.Event
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+
+		size, _ := strconv.ParseUint(fields[1], 10, 64)
+		event := &types.Event{
+			Kind:   types.KindModule,
+			Name:   fields[0],
+			Type:   fields[4],
+			Memory: size,
+		}
+		for _, dep := range strings.Split(fields[3], ",") {
+			if dep != "" && dep != "-" {
+				event.UsedBy = append(event.UsedBy, dep)
+			}
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/inventory/types"
+)
+
+func TestParseLSMs(t *testing.T) {
+	events := parseLSMs("lockdown,capability,landlock,yama,apparmor,bpf\n")
+	if len(events) != 6 {
+		t.Fatalf("Invalid number of LSMs %d. Expecting 6", len(events))
+	}
+	expected := &types.Event{Kind: types.KindLSM, ID: 6, Name: "bpf"}
+	if !reflect.DeepEqual(events[5], expected) {
+		t.Fatalf("Invalid LSM %+v. Expecting %+v", events[5], expected)
+	}
+}
+
+func TestParseModules(t *testing.T) {
+	events, err := parseModules(strings.NewReader(
+		"nf_conntrack 172032 4 xt_conntrack,nf_nat,xt_MASQUERADE, Live 0xffffffffc0a52000\n" +
+			"falco 909312 2 - Live 0xffffffffc0b20000 (OE)\n"))
+	if err != nil {
+		t.Fatalf("Could not parse modules: %s", err)
+	}
+	expected := []*types.Event{
+		{
+			Kind:   types.KindModule,
+			Type:   "Live",
+			Name:   "nf_conntrack",
+			Memory: 172032,
+			UsedBy: []string{"xt_conntrack", "nf_nat", "xt_MASQUERADE"},
+		},
+		{
+			Kind:   types.KindModule,
+			Type:   "Live",
+			Name:   "falco",
+			Memory: 909312,
+		},
+	}
+	if !reflect.DeepEqual(events, expected) {
+		t.Fatalf("Invalid modules %+v. Expecting %+v", events, expected)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/cilium/ebpf"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/bpfobjects"
+	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/inventory/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+type objectKey struct {
+	kind string
+	id   uint32
+}
+
+type Tracer struct {
+	objects      map[objectKey]*types.Event
+	eventHandler func(ev []*types.Event)
+}
+
+func (t *Tracer) object(kind string, id uint32) *types.Event {
+	key := objectKey{kind: kind, id: id}
+	event, ok := t.objects[key]
+	if !ok {
+		event = &types.Event{
+			Event: eventtypes.Event{
+				Type: eventtypes.NORMAL,
+			},
+			Kind: kind,
+			ID:   id,
+		}
+		t.objects[key] = event
+	}
+	return event
+}
+
+// scanProcesses finds the processes holding file descriptors to BPF objects.
+// The links are only known this way.
+func (t *Tracer) scanProcesses(funcName func(uint32) string) error {
+	return bpfobjects.Walk(func(pid uint32, o *bpfobjects.Object) {
+		event := t.object(o.Kind, o.ID)
+		if o.Kind == types.KindLink && event.Type == "" {
+			event.Type = o.Fields["link_type"]
+			event.ProgramID = uint32(o.Uint("prog_id"))
+			event.AttachTo = o.AttachTo(funcName)
+		}
+
+		// A process can hold several file descriptors to the same object
+		if n := len(event.Processes); n > 0 && event.Processes[n-1].Pid == pid {
+			return
+		}
+		comm := host.GetProcComm(int(pid))
+		event.Processes = append(event.Processes, types.Process{Pid: pid, Comm: comm})
+	})
+}
+
+// memlock returns the memory locked by the BPF object whose file descriptor
+// is fd
+func memlock(fd int) uint64 {
+	o, err := bpfobjects.ReadFdInfo("self", fmt.Sprint(fd))
+	if err != nil || o == nil {
+		return 0
+	}
+	return o.Uint("memlock")
+}
+
+// listPrograms gets the details of all the programs loaded, including the
+// ones no process holds a file descriptor to, e.g. the pinned ones
+func (t *Tracer) listPrograms() error {
+	for id := ebpf.ProgramID(0); ; {
+		next, err := ebpf.ProgramGetNextID(id)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("getting next program ID: %w", err)
+		}
+		id = next
+
+		prog, err := ebpf.NewProgramFromID(id)
+		if err != nil {
+			// The program was unloaded in the meantime
+			continue
+		}
+		info, err := prog.Info()
+		if err != nil {
+			prog.Close()
+			continue
+		}
+
+		event := t.object(types.KindProgram, uint32(id))
+		event.Type = info.Type.String()
+		event.Name = info.Name
+		event.Tag = info.Tag
+		if mapIDs, ok := info.MapIDs(); ok {
+			for _, mapID := range mapIDs {
+				event.MapIDs = append(event.MapIDs, uint32(mapID))
+			}
+		}
+		event.Memory = memlock(prog.FD())
+		prog.Close()
+	}
+}
+
+// listMaps gets the details of all the maps created
+func (t *Tracer) listMaps() error {
+	for id := ebpf.MapID(0); ; {
+		next, err := ebpf.MapGetNextID(id)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("getting next map ID: %w", err)
+		}
+		id = next
+
+		m, err := ebpf.NewMapFromID(id)
+		if err != nil {
+			continue
+		}
+		info, err := m.Info()
+		if err != nil {
+			m.Close()
+			continue
+		}
+
+		event := t.object(types.KindMap, uint32(id))
+		event.Type = info.Type.String()
+		event.Name = info.Name
+		event.Memory = memlock(m.FD())
+		m.Close()
+	}
+}
+
+func listLSMs() ([]*types.Event, error) {
+	content, err := os.ReadFile(filepath.Join(host.HostRoot, "/sys/kernel/security/lsm"))
+	if err != nil {
+		return nil, err
+	}
+	return parseLSMs(string(content)), nil
+}
+
+func listModules() ([]*types.Event, error) {
+	file, err := os.Open(filepath.Join(host.HostProcFs, "modules"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseModules(file)
+}
+
+// ---
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return &Tracer{}, nil
+}
+
+func (t *Tracer) SetEventHandlerArray(handler any) {
+	nh, ok := handler.(func(ev []*types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventHandler = nh
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	t.objects = make(map[objectKey]*types.Event)
+
+	if err := t.scanProcesses(bpfobjects.KernelFuncName()); err != nil {
+		return fmt.Errorf("scanning processes: %w", err)
+	}
+	if err := t.listPrograms(); err != nil {
+		return fmt.Errorf("listing BPF programs: %w", err)
+	}
+	if err := t.listMaps(); err != nil {
+		return fmt.Errorf("listing BPF maps: %w", err)
+	}
+
+	events := make([]*types.Event, 0, len(t.objects))
+	for _, event := range t.objects {
+		sort.Slice(event.Processes, func(i, j int) bool {
+			return event.Processes[i].Pid < event.Processes[j].Pid
+		})
+		// The object belongs to the container of its first process
+		if len(event.Processes) > 0 {
+			mntns, err := containerutils.GetMntNs(int(event.Processes[0].Pid))
+			if err == nil {
+				event.MountNsID = mntns
+			}
+		}
+		events = append(events, event)
+	}
+
+	lsms, err := listLSMs()
+	if err != nil {
+		gadgetCtx.Logger().Warnf("listing LSMs: %s", err)
+	}
+	modules, err := listModules()
+	if err != nil {
+		gadgetCtx.Logger().Warnf("listing kernel modules: %s", err)
+	}
+	for _, event := range append(lsms, modules...) {
+		event.Event = eventtypes.Event{Type: eventtypes.NORMAL}
+		events = append(events, event)
+	}
+
+	t.eventHandler(events)
+	return nil
+}
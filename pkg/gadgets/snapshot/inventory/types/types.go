@@ -0,0 +1,113 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/go-units"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns/ellipsis"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+var SortByDefault = []string{"node", "kind", "id", "name"}
+
+const (
+	KindProgram = "prog"
+	KindMap     = "map"
+	KindLink    = "link"
+	KindLSM     = "lsm"
+	KindModule  = "module"
+)
+
+type Process struct {
+	Pid  uint32 `json:"pid"`
+	Comm string `json:"comm"`
+}
+
+// Event is an object loaded in the kernel of a node: a BPF program, map or
+// link, a Linux security module or a kernel module
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Kind string `json:"kind" column:"kind,width:6,fixed"`
+	// ID is the ID of the BPF objects and the position of the LSMs in the
+	// stack, the order they are called in
+	ID   uint32 `json:"id,omitempty" column:"id,width:6"`
+	Type string `json:"type,omitempty" column:"type,minWidth:8,maxWidth:16"`
+	Name string `json:"name,omitempty" column:"name,minWidth:12,maxWidth:32"`
+	// AttachTo is what a BPF link attaches its program to, e.g. the LSM
+	// hook or the kernel function
+	AttachTo string `json:"attachTo,omitempty" column:"attach,minWidth:12,maxWidth:32"`
+	// ProgramID is the program attached by a BPF link
+	ProgramID uint32 `json:"progID,omitempty" column:"progid,width:6,hide"`
+	// MapIDs are the maps used by a BPF program
+	MapIDs []uint32 `json:"mapIDs,omitempty" column:"maps,width:16,hide"`
+	Tag    string   `json:"tag,omitempty" column:"tag,width:16,fixed,hide"`
+	// Memory is the memory locked by the BPF programs and maps and the size
+	// of the kernel modules, in bytes
+	Memory uint64 `json:"memory,omitempty" column:"memory,width:10,align:right"`
+	// UsedBy are the kernel modules depending on a kernel module
+	UsedBy []string `json:"usedBy,omitempty" column:"usedby,width:16,hide"`
+
+	// Processes are the processes holding a file descriptor to the BPF
+	// object. The object belongs to the container of the first one.
+	Processes []Process `json:"processes,omitempty"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	cols.MustAddColumn(columns.Attributes{
+		Name:         "pid",
+		Width:        16,
+		EllipsisType: ellipsis.End,
+		Visible:      true,
+		Order:        999,
+	}, func(ev *Event) string {
+		pids := []string{}
+		for _, p := range ev.Processes {
+			pids = append(pids, fmt.Sprint(p.Pid))
+		}
+		return strings.Join(pids, ",")
+	})
+
+	cols.MustAddColumn(columns.Attributes{
+		Name:         "comm",
+		Width:        16,
+		EllipsisType: ellipsis.End,
+		Visible:      true,
+		Order:        1000,
+	}, func(ev *Event) string {
+		comms := []string{}
+		for _, p := range ev.Processes {
+			comms = append(comms, p.Comm)
+		}
+		return strings.Join(comms, ",")
+	})
+
+	cols.MustSetExtractor("memory", func(ev *Event) string {
+		if ev.Memory == 0 {
+			return ""
+		}
+		return units.BytesSize(float64(ev.Memory))
+	})
+
+	return cols
+}
@@ -0,0 +1,384 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/numa/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+const (
+	cgroupRoot = "/sys/fs/cgroup"
+	nodeRoot   = "/sys/devices/system/node"
+)
+
+// numaNode is a NUMA node of the host with its counters from numastat
+type numaNode struct {
+	id       int
+	cpus     []int
+	counters map[string]uint64
+}
+
+type Tracer struct {
+	// containers indexed by mount namespace
+	containers   map[uint64]*containercollection.Container
+	eventHandler func(ev []*types.Event)
+}
+
+// parseList parses a list of CPUs or nodes in the format of cpuset, e.g.
+// "0-3,8,10-11"
+func parseList(list string) ([]int, error) {
+	ids := []int{}
+	list = strings.TrimSpace(list)
+	if list == "" {
+		return ids, nil
+	}
+	for _, r := range strings.Split(list, ",") {
+		first, last, isRange := strings.Cut(r, "-")
+		start, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("parsing %q: %w", list, err)
+		}
+		end := start
+		if isRange {
+			end, err = strconv.Atoi(last)
+			if err != nil {
+				return nil, fmt.Errorf("parsing %q: %w", list, err)
+			}
+		}
+		for id := start; id <= end; id++ {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+// formatList formats sorted ids in the format of cpuset
+func formatList(ids []int) string {
+	var parts []string
+	for i := 0; i < len(ids); {
+		j := i
+		for j+1 < len(ids) && ids[j+1] == ids[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(ids[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", ids[i], ids[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+// readNodes returns the NUMA nodes of the host
+func readNodes() ([]numaNode, error) {
+	dirs, err := filepath.Glob(filepath.Join(nodeRoot, "node[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := []numaNode{}
+	for _, dir := range dirs {
+		id, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "node"))
+		if err != nil {
+			continue
+		}
+
+		cpulist, err := os.ReadFile(filepath.Join(dir, "cpulist"))
+		if err != nil {
+			return nil, err
+		}
+		cpus, err := parseList(string(cpulist))
+		if err != nil {
+			return nil, err
+		}
+
+		numastat, err := os.ReadFile(filepath.Join(dir, "numastat"))
+		if err != nil {
+			return nil, err
+		}
+		counters := make(map[string]uint64)
+		for _, line := range strings.Split(string(numastat), "\n") {
+			name, value, ok := strings.Cut(line, " ")
+			if !ok {
+				continue
+			}
+			counters[name], _ = strconv.ParseUint(value, 10, 64)
+		}
+
+		nodes = append(nodes, numaNode{id: id, cpus: cpus, counters: counters})
+	}
+	if len(nodes) == 0 {
+		return nil, errors.New("no NUMA node found")
+	}
+
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].id < nodes[j].id })
+	return nodes, nil
+}
+
+// getMemoryCgroup returns the directory of the cgroup of the given process in
+// the hierarchy of the memory controller, and whether it's a cgroup v1 one
+func getMemoryCgroup(pid uint32) (string, bool, error) {
+	file, err := os.Open(filepath.Join(host.HostProcFs, fmt.Sprint(pid), "cgroup"))
+	if err != nil {
+		return "", false, err
+	}
+	defer file.Close()
+
+	pathV2 := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[0] == "0" && fields[1] == "" {
+			pathV2 = fields[2]
+			continue
+		}
+		if fields[1] == "memory" {
+			dir := filepath.Join(cgroupRoot, "memory", fields[2])
+			if _, err := os.Stat(filepath.Join(dir, "memory.numa_stat")); err == nil {
+				return dir, true, nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", false, err
+	}
+
+	if pathV2 != "" {
+		dir := filepath.Join(cgroupRoot, pathV2)
+		if _, err := os.Stat(filepath.Join(dir, "memory.numa_stat")); err == nil {
+			return dir, false, nil
+		}
+	}
+
+	return "", false, errors.New("memory controller not found")
+}
+
+// readNumaStat returns the anonymous and file memory, in bytes, of the cgroup
+// on each NUMA node
+func readNumaStat(dir string, v1 bool) (map[int]uint64, map[int]uint64, error) {
+	content, err := os.ReadFile(filepath.Join(dir, "memory.numa_stat"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// cgroup v1 reports pages, with "=" after the name, and the memory of the
+	// children in the hierarchical_ lines. cgroup v2 reports bytes, always
+	// including the children.
+	anonKey, fileKey, unit := "anon", "file", uint64(1)
+	if v1 {
+		anonKey, fileKey = "hierarchical_anon", "hierarchical_file"
+		unit = uint64(os.Getpagesize())
+	}
+
+	anon := make(map[int]uint64)
+	file := make(map[int]uint64)
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		// "name=total" on cgroup v1, "name" on cgroup v2
+		name, _, _ := strings.Cut(fields[0], "=")
+
+		var stat map[int]uint64
+		switch name {
+		case anonKey:
+			stat = anon
+		case fileKey:
+			stat = file
+		default:
+			continue
+		}
+
+		for _, field := range fields[1:] {
+			node, value, ok := strings.Cut(field, "=")
+			if !ok || !strings.HasPrefix(node, "N") {
+				continue
+			}
+			id, err := strconv.Atoi(node[1:])
+			if err != nil {
+				continue
+			}
+			bytes, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			stat[id] = bytes * unit
+		}
+	}
+
+	return anon, file, nil
+}
+
+// readAllowed returns the CPUs and the NUMA nodes the process is allowed to
+// use
+func readAllowed(pid uint32) (string, string, error) {
+	file, err := os.Open(filepath.Join(host.HostProcFs, fmt.Sprint(pid), "status"))
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+
+	cpus, mems := "", ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		switch name {
+		case "Cpus_allowed_list":
+			cpus = strings.TrimSpace(value)
+		case "Mems_allowed_list":
+			mems = strings.TrimSpace(value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", err
+	}
+
+	return cpus, mems, nil
+}
+
+func (t *Tracer) getPlacement(container *containercollection.Container, nodes []numaNode) (*types.Event, error) {
+	cpus, mems, err := readAllowed(container.Pid)
+	if err != nil {
+		return nil, fmt.Errorf("reading allowed CPUs: %w", err)
+	}
+	allowedCPUs, err := parseList(cpus)
+	if err != nil {
+		return nil, err
+	}
+	allowed := make(map[int]struct{}, len(allowedCPUs))
+	for _, cpu := range allowedCPUs {
+		allowed[cpu] = struct{}{}
+	}
+
+	dir, v1, err := getMemoryCgroup(container.Pid)
+	if err != nil {
+		return nil, err
+	}
+	anon, file, err := readNumaStat(dir, v1)
+	if err != nil {
+		return nil, fmt.Errorf("reading NUMA statistics: %w", err)
+	}
+
+	event := &types.Event{
+		Event: eventtypes.Event{
+			Type: eventtypes.NORMAL,
+		},
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: container.Mntns},
+		Cpus:          cpus,
+		Mems:          mems,
+	}
+
+	for _, node := range nodes {
+		localCPUs := []int{}
+		for _, cpu := range node.cpus {
+			if _, ok := allowed[cpu]; ok {
+				localCPUs = append(localCPUs, cpu)
+			}
+		}
+
+		usage := types.NodeUsage{
+			Node:        node.id,
+			Cpus:        formatList(localCPUs),
+			Anon:        anon[node.id],
+			File:        file[node.id],
+			Local:       len(localCPUs) > 0,
+			NumaHit:     node.counters["numa_hit"],
+			NumaMiss:    node.counters["numa_miss"],
+			NumaForeign: node.counters["numa_foreign"],
+			LocalNode:   node.counters["local_node"],
+			OtherNode:   node.counters["other_node"],
+		}
+		event.Nodes = append(event.Nodes, usage)
+
+		event.Memory += usage.Anon + usage.File
+		if !usage.Local {
+			event.Remote += usage.Anon + usage.File
+		}
+	}
+	if event.Memory > 0 {
+		event.RemoteRatio = 100 * float64(event.Remote) / float64(event.Memory)
+	}
+
+	return event, nil
+}
+
+// ---
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return &Tracer{
+		containers: make(map[uint64]*containercollection.Container),
+	}, nil
+}
+
+func (t *Tracer) AttachContainer(container *containercollection.Container) error {
+	t.containers[container.Mntns] = container
+	return nil
+}
+
+func (t *Tracer) DetachContainer(container *containercollection.Container) error {
+	return nil
+}
+
+func (t *Tracer) SetEventHandlerArray(handler any) {
+	nh, ok := handler.(func(ev []*types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventHandler = nh
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	nodes, err := readNodes()
+	if err != nil {
+		return fmt.Errorf("reading NUMA nodes: %w", err)
+	}
+
+	events := []*types.Event{}
+	for _, container := range t.containers {
+		event, err := t.getPlacement(container, nodes)
+		if err != nil {
+			gadgetCtx.Logger().Warnf("getting NUMA placement of container %q: %s", container.Name, err)
+			continue
+		}
+		events = append(events, event)
+	}
+
+	t.eventHandler(events)
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+var SortByDefault = []string{"-remote", "-memory"}
+
+// NodeUsage is the memory of a container on a NUMA node
+type NodeUsage struct {
+	Node int `json:"node"`
+	// Cpus are the CPUs of the node the container can run on
+	Cpus string `json:"cpus"`
+	Anon uint64 `json:"anon"`
+	File uint64 `json:"file"`
+	// Local is whether the container can run on a CPU of the node
+	Local bool `json:"local"`
+
+	// Counters of the node, for all the processes running on the host
+	NumaHit     uint64 `json:"numaHit"`
+	NumaMiss    uint64 `json:"numaMiss"`
+	NumaForeign uint64 `json:"numaForeign"`
+	LocalNode   uint64 `json:"localNode"`
+	OtherNode   uint64 `json:"otherNode"`
+}
+
+// Event is the placement of the memory of a container on the NUMA nodes
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	// Cpus and Mems are the CPUs and the NUMA nodes the container is allowed
+	// to use, from its cpuset
+	Cpus string `json:"cpus,omitempty" column:"cpus,width:16"`
+	Mems string `json:"mems,omitempty" column:"mems,width:8"`
+	// Memory is the memory of the container on all the nodes, in bytes
+	Memory uint64 `json:"memory" column:"memory,width:12"`
+	// Remote is the memory on the nodes the container can't run on, in bytes
+	Remote      uint64  `json:"remote" column:"remote,width:12"`
+	RemoteRatio float64 `json:"remoteRatio" column:"remoteratio,width:11,precision:1"`
+
+	Nodes []NodeUsage `json:"nodes,omitempty"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	return columns.MustCreateColumns[Event]()
+}
+
+func (e *Event) ExtraLines() []string {
+	out := make([]string, 0, len(e.Nodes))
+	for _, n := range e.Nodes {
+		cpus := n.Cpus
+		if cpus == "" {
+			cpus = "-"
+		}
+		out = append(out, fmt.Sprintf("\tN%-3d cpus:%-12s anon:%-12d file:%-12d numa_miss:%-12d other_node:%d",
+			n.Node, cpus, n.Anon, n.File, n.NumaMiss, n.OtherNode))
+	}
+	return out
+}
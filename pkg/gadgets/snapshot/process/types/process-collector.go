@@ -31,6 +31,8 @@ const (
 type Event struct {
 	eventtypes.Event
 	eventtypes.WithMountNsID
+	eventtypes.WithSbomPackage
+	eventtypes.WithVulnerabilities
 
 	Command   string `json:"comm" column:"comm,template:comm"`
 	Pid       int    `json:"pid" column:"pid,template:pid"`
@@ -44,6 +46,10 @@ func GetColumns() *columns.Columns[Event] {
 	return columns.MustCreateColumns[Event]()
 }
 
+func (e *Event) GetPid() uint32 {
+	return uint32(e.Pid)
+}
+
 type processTree struct {
 	process  *Event
 	children []*processTree
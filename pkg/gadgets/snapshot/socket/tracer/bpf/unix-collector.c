@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: GPL-2.0 WITH Linux-syscall-note
+
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+
+/*
+ * Inspired by the BPF selftests in the Linux tree:
+ * https://github.com/torvalds/linux/blob/v5.17/tools/testing/selftests/bpf/progs/bpf_iter_unix.c
+ */
+
+/*
+ * This BPF program uses the GPL-restricted function bpf_seq_printf().
+ */
+
+#include <vmlinux/vmlinux.h>
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_tracing.h>
+#include <bpf/bpf_endian.h>
+#include "socket-common.h"
+
+char _license[] SEC("license") = "GPL";
+
+static const char proto[] = "UNIX";
+
+SEC("iter/unix")
+int ig_snap_unix(struct bpf_iter__unix *ctx)
+{
+	struct seq_file *seq = ctx->meta->seq;
+	struct unix_sock *unix_sk = ctx->unix_sk;
+	struct sock *sk = (struct sock *)unix_sk;
+	unsigned long peer_ino = 0;
+	__u64 i, len;
+
+	if (unix_sk == (void *)0)
+		return 0;
+
+	if (unix_sk->peer)
+		peer_ino = sock_i_ino(unix_sk->peer);
+
+	/*
+	 * Notice that client side program is expecting socket information exactly
+	 * in this format:
+	 *
+	 * protocol: "UNIX"
+	 * type: Hexadecimal of the socket type (SOCK_STREAM, SOCK_DGRAM...)
+	 * state: Hexadecimal, same values as TCP
+	 * ino and peer ino: unsigned long.
+	 * path: The rest of the line, abstract sockets start with "@".
+	 */
+	BPF_SEQ_PRINTF(seq, "%s %02X %02X %lu %lu ", proto, sk->sk_type,
+		sk->sk_state, sock_i_ino(sk), peer_ino);
+
+	if (unix_sk->addr) {
+		if (unix_sk->addr->name->sun_path[0]) {
+			BPF_SEQ_PRINTF(seq, "%s", unix_sk->addr->name->sun_path);
+		} else {
+			/*
+			 * The name of the abstract UNIX domain socket starts with '\0'
+			 * and can contain '\0'. The null bytes are escaped as done in
+			 * unix_seq_show().
+			 */
+			len = unix_sk->addr->len - sizeof(short);
+
+			BPF_SEQ_PRINTF(seq, "@");
+
+			for (i = 1; i < len; i++) {
+				/* unix_validate_addr() tests this upper bound. */
+				if (i >= sizeof(struct sockaddr_un))
+					break;
+
+				BPF_SEQ_PRINTF(seq, "%c",
+					unix_sk->addr->name->sun_path[i] ?: '@');
+			}
+		}
+	}
+
+	BPF_SEQ_PRINTF(seq, "\n");
+
+	return 0;
+}
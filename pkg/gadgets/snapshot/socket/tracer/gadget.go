@@ -45,7 +45,7 @@ func (g *GadgetDesc) Type() gadgets.GadgetType {
 }
 
 func (g *GadgetDesc) Description() string {
-	return "Gather information about TCP and UDP sockets"
+	return "Gather information about TCP, UDP and UNIX sockets"
 }
 
 func (g *GadgetDesc) ParamDescs() params.ParamDescs {
@@ -75,7 +75,7 @@ func (g *GadgetDesc) EventPrototype() any {
 func (g *GadgetDesc) SortByDefault() []string {
 	return []string{
 		"node", "namespace", "pod", "protocol", "status", "localAddr",
-		"remoteAddr", "localPort", "remotePort", "inode",
+		"remoteAddr", "localPort", "remotePort", "path", "inode",
 	}
 }
 
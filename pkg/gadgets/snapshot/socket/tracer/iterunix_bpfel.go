@@ -0,0 +1,115 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64 || amd64p32 || arm || arm64 || loong64 || mips64le || mips64p32le || mipsle || ppc64le || riscv64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+// loadIterUNIX returns the embedded CollectionSpec for iterUNIX.
+func loadIterUNIX() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_IterUNIXBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load iterUNIX: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadIterUNIXObjects loads iterUNIX and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*iterUNIXObjects
+//	*iterUNIXPrograms
+//	*iterUNIXMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadIterUNIXObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadIterUNIX()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// iterUNIXSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type iterUNIXSpecs struct {
+	iterUNIXProgramSpecs
+	iterUNIXMapSpecs
+}
+
+// iterUNIXSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type iterUNIXProgramSpecs struct {
+	IgSnapUnix *ebpf.ProgramSpec `ebpf:"ig_snap_unix"`
+}
+
+// iterUNIXMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type iterUNIXMapSpecs struct {
+}
+
+// iterUNIXObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadIterUNIXObjects or ebpf.CollectionSpec.LoadAndAssign.
+type iterUNIXObjects struct {
+	iterUNIXPrograms
+	iterUNIXMaps
+}
+
+func (o *iterUNIXObjects) Close() error {
+	return _IterUNIXClose(
+		&o.iterUNIXPrograms,
+		&o.iterUNIXMaps,
+	)
+}
+
+// iterUNIXMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadIterUNIXObjects or ebpf.CollectionSpec.LoadAndAssign.
+type iterUNIXMaps struct {
+}
+
+func (m *iterUNIXMaps) Close() error {
+	return _IterUNIXClose()
+}
+
+// iterUNIXPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadIterUNIXObjects or ebpf.CollectionSpec.LoadAndAssign.
+type iterUNIXPrograms struct {
+	IgSnapUnix *ebpf.Program `ebpf:"ig_snap_unix"`
+}
+
+func (p *iterUNIXPrograms) Close() error {
+	return _IterUNIXClose(
+		p.IgSnapUnix,
+	)
+}
+
+func _IterUNIXClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed iterunix_bpfel.o
+var _IterUNIXBytes []byte
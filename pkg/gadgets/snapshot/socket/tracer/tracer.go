@@ -21,8 +21,13 @@ import (
 	"encoding/binary"
 	"fmt"
 	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/cilium/ebpf/link"
+	log "github.com/sirupsen/logrus"
 
 	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
 	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
@@ -30,10 +35,12 @@ import (
 	socketcollectortypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/snapshot/socket/types"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/netnsenter"
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
 )
 
 //go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -cc clang iterTCPv4 ./bpf/tcp4-collector.c -- -I../../../../${TARGET} -Werror -O2 -g -c -x c
 //go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -cc clang iterUDPv4 ./bpf/udp4-collector.c -- -I../../../../${TARGET} -Werror -O2 -g -c -x c
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -cc clang iterUNIX ./bpf/unix-collector.c -- -I../../../../${TARGET} -Werror -O2 -g -c -x c
 
 type Tracer struct {
 	iters map[socketcollectortypes.Proto]*link.Iter
@@ -45,6 +52,16 @@ type Tracer struct {
 	visitedNamespaces map[uint64]uint32
 	protocols         socketcollectortypes.Proto
 	eventHandler      func([]*socketcollectortypes.Event)
+
+	// owners is a map where the key is the inode number of a socket and the
+	// value the process owning it. It's filled the first time the sockets
+	// are collected.
+	owners map[uint64]socketOwner
+}
+
+type socketOwner struct {
+	pid  uint32
+	comm string
 }
 
 func parseIPv4(ipU32 uint32) string {
@@ -84,9 +101,76 @@ func parseStatus(proto string, statusUint uint8) (string, error) {
 		}
 	}
 
+	// Transform TCP status into something closer to ss for UNIX sockets
+	if proto == "UNIX" {
+		switch status {
+		case "ESTABLISHED", "LISTEN":
+		case "CLOSE":
+			status = "UNCONNECTED"
+		default:
+			return "", fmt.Errorf("unexpected %s status %s", proto, status)
+		}
+	}
+
 	return status, nil
 }
 
+// Values of SOCK_STREAM, SOCK_DGRAM and SOCK_SEQPACKET
+var unixSocketTypes = map[uint8]string{
+	1: "stream",
+	2: "dgram",
+	5: "seqpacket",
+}
+
+// getSocketOwners returns the processes owning the sockets, found by reading
+// their file descriptors like ss does
+func getSocketOwners() map[uint64]socketOwner {
+	owners := make(map[uint64]socketOwner)
+
+	items, err := os.ReadDir(host.HostProcFs)
+	if err != nil {
+		log.Warnf("reading processes: %v", err)
+		return owners
+	}
+
+	for _, item := range items {
+		pid64, err := strconv.ParseUint(item.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+		pid := uint32(pid64)
+
+		fdPath := filepath.Join(host.HostProcFs, item.Name(), "fd")
+		fds, err := os.ReadDir(fdPath)
+		if err != nil {
+			continue
+		}
+
+		var comm string
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdPath, fd.Name()))
+			if err != nil || !strings.HasPrefix(target, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(target[len("socket:["):], "]"), 10, 64)
+			if err != nil {
+				continue
+			}
+			if owner, ok := owners[inode]; ok && owner.pid < pid {
+				continue
+			}
+
+			if comm == "" {
+				data, _ := os.ReadFile(filepath.Join(host.HostProcFs, item.Name(), "comm"))
+				comm = strings.TrimSuffix(string(data), "\n")
+			}
+			owners[inode] = socketOwner{pid: pid, comm: comm}
+		}
+	}
+
+	return owners
+}
+
 func getTCPIter() (*link.Iter, error) {
 	objs := iterTCPv4Objects{}
 	if err := loadIterTCPv4Objects(&objs, nil); err != nil {
@@ -121,8 +205,61 @@ func getUDPIter() (*link.Iter, error) {
 	return it, nil
 }
 
+func getUNIXIter() (*link.Iter, error) {
+	objs := iterUNIXObjects{}
+	if err := loadIterUNIXObjects(&objs, nil); err != nil {
+		return nil, fmt.Errorf("loading UNIX BPF objects: %w", err)
+	}
+	defer objs.Close()
+
+	it, err := link.AttachIter(link.IterOptions{
+		Program: objs.IgSnapUnix,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("attaching UNIX BPF iterator: %w", err)
+	}
+
+	return it, nil
+}
+
+// parseUNIXSocket parses a line printed by the UNIX iterator, see
+// bpf/unix-collector.c
+func parseUNIXSocket(line string) (*socketcollectortypes.Event, error) {
+	fields := strings.SplitN(line, " ", 6)
+	if len(fields) != 6 {
+		return nil, fmt.Errorf("parsing UNIX socket information: %q", line)
+	}
+
+	var socketType, hexStatus uint8
+	var inodeNumber, peerInodeNumber uint64
+	len, err := fmt.Sscanf(strings.Join(fields[1:5], " "), "%02X %02X %d %d",
+		&socketType, &hexStatus, &inodeNumber, &peerInodeNumber)
+	if err != nil || len != 4 {
+		return nil, fmt.Errorf("parsing UNIX socket information: %w", err)
+	}
+
+	status, err := parseStatus("UNIX", hexStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	return &socketcollectortypes.Event{
+		Protocol:        "UNIX",
+		Status:          status,
+		InodeNumber:     inodeNumber,
+		Path:            fields[5],
+		SocketType:      unixSocketTypes[socketType],
+		PeerInodeNumber: peerInodeNumber,
+	}, nil
+}
+
 // RunCollector is currently exported so it can be called from Collect()
 func (t *Tracer) RunCollector(pid uint32, podname, namespace, node string) ([]*socketcollectortypes.Event, error) {
+	// The processes are read from the host, outside of the network namespace
+	if t.owners == nil {
+		t.owners = getSocketOwners()
+	}
+
 	sockets := []*socketcollectortypes.Event{}
 	err := netnsenter.NetnsEnter(int(pid), func() error {
 		for iterKey, it := range t.iters {
@@ -138,23 +275,42 @@ func (t *Tracer) RunCollector(pid uint32, podname, namespace, node string) ([]*s
 
 			scanner := bufio.NewScanner(reader)
 			for scanner.Scan() {
-				var status, proto string
-				var destp, srcp uint16
-				var dest, src uint32
-				var hexStatus uint8
-				var inodeNumber uint64
-
-				// Format from socket_bpf_seq_print() in bpf/socket_common.h
-				// IP addresses and ports are in host-byte order
-				len, err := fmt.Sscanf(scanner.Text(), "%s %08X %04X %08X %04X %02X %d",
-					&proto, &src, &srcp, &dest, &destp, &hexStatus, &inodeNumber)
-				if err != nil || len != 7 {
-					return fmt.Errorf("parsing sockets information: %w", err)
-				}
-
-				status, err = parseStatus(proto, hexStatus)
-				if err != nil {
-					return err
+				var socket *socketcollectortypes.Event
+
+				if iterKey == socketcollectortypes.UNIX {
+					socket, err = parseUNIXSocket(scanner.Text())
+					if err != nil {
+						return err
+					}
+				} else {
+					var status, proto string
+					var destp, srcp uint16
+					var dest, src uint32
+					var hexStatus uint8
+					var inodeNumber uint64
+
+					// Format from socket_bpf_seq_print() in bpf/socket_common.h
+					// IP addresses and ports are in host-byte order
+					len, err := fmt.Sscanf(scanner.Text(), "%s %08X %04X %08X %04X %02X %d",
+						&proto, &src, &srcp, &dest, &destp, &hexStatus, &inodeNumber)
+					if err != nil || len != 7 {
+						return fmt.Errorf("parsing sockets information: %w", err)
+					}
+
+					status, err = parseStatus(proto, hexStatus)
+					if err != nil {
+						return err
+					}
+
+					socket = &socketcollectortypes.Event{
+						Protocol:      proto,
+						LocalAddress:  parseIPv4(src),
+						LocalPort:     srcp,
+						RemoteAddress: parseIPv4(dest),
+						RemotePort:    destp,
+						Status:        status,
+						InodeNumber:   inodeNumber,
+					}
 				}
 
 				// TODO: Receive the netns from caller
@@ -163,26 +319,24 @@ func (t *Tracer) RunCollector(pid uint32, podname, namespace, node string) ([]*s
 					return fmt.Errorf("getting netns for pid %d: %w", pid, err)
 				}
 
-				sockets = append(sockets, &socketcollectortypes.Event{
-					Event: eventtypes.Event{
-						Type: eventtypes.NORMAL,
-						// TODO: This can be removed as events will be enriched
-						//  by the eventHandler
-						CommonData: eventtypes.CommonData{
-							Node:      node,
-							Namespace: namespace,
-							Pod:       podname,
-						},
+				socket.Event = eventtypes.Event{
+					Type: eventtypes.NORMAL,
+					// TODO: This can be removed as events will be enriched
+					//  by the eventHandler
+					CommonData: eventtypes.CommonData{
+						Node:      node,
+						Namespace: namespace,
+						Pod:       podname,
 					},
-					Protocol:      proto,
-					LocalAddress:  parseIPv4(src),
-					LocalPort:     srcp,
-					RemoteAddress: parseIPv4(dest),
-					RemotePort:    destp,
-					Status:        status,
-					InodeNumber:   inodeNumber,
-					WithNetNsID:   eventtypes.WithNetNsID{NetNsID: netns},
-				})
+				}
+				socket.WithNetNsID = eventtypes.WithNetNsID{NetNsID: netns}
+
+				if owner, ok := t.owners[socket.InodeNumber]; ok {
+					socket.Pid = owner.pid
+					socket.Comm = owner.comm
+				}
+
+				sockets = append(sockets, socket)
 			}
 
 			if err := scanner.Err(); err != nil {
@@ -270,6 +424,20 @@ func (t *Tracer) openIters() error {
 		t.iters[socketcollectortypes.UDP] = it
 	}
 
+	if t.protocols == socketcollectortypes.UNIX || t.protocols == socketcollectortypes.ALL {
+		it, err = getUNIXIter()
+		if err != nil {
+			// The UNIX iterator is only available since Linux 5.17, don't
+			// fail when all protocols were requested
+			if t.protocols == socketcollectortypes.UNIX {
+				return err
+			}
+			log.Warnf("UNIX sockets can't be collected: %v", err)
+		} else {
+			t.iters[socketcollectortypes.UNIX] = it
+		}
+	}
+
 	return nil
 }
 
@@ -30,12 +30,14 @@ const (
 	ALL
 	TCP
 	UDP
+	UNIX
 )
 
 var ProtocolsMap = map[string]Proto{
-	"all": ALL,
-	"tcp": TCP,
-	"udp": UDP,
+	"all":  ALL,
+	"tcp":  TCP,
+	"udp":  UDP,
+	"unix": UNIX,
 }
 
 type Event struct {
@@ -49,6 +51,16 @@ type Event struct {
 	RemotePort    uint16 `json:"remotePort" column:"remotePort,template:ipport,hide"`
 	Status        string `json:"status" column:"status,order:1002,maxWidth:12"`
 	InodeNumber   uint64 `json:"inodeNumber" column:"inode,order:1003,hide"`
+
+	// Path, SocketType and PeerInodeNumber are only set for UNIX sockets
+	Path            string `json:"path,omitempty" column:"path,hide"`
+	SocketType      string `json:"socketType,omitempty" column:"type,order:1004,maxWidth:9,hide"`
+	PeerInodeNumber uint64 `json:"peerInodeNumber,omitempty" column:"peerInode,order:1005,hide"`
+
+	// Pid and Comm are the process owning the socket, the one with the
+	// lowest pid when several processes share it
+	Pid  uint32 `json:"pid,omitempty" column:"pid,template:pid,order:1006"`
+	Comm string `json:"comm,omitempty" column:"comm,template:comm,order:1007"`
 }
 
 func GetColumns() *columns.Columns[Event] {
@@ -67,6 +79,12 @@ func GetColumns() *columns.Columns[Event] {
 		Visible:  true,
 		Order:    1000,
 	}, func(e *Event) string {
+		if e.Protocol == "UNIX" {
+			if e.Path == "" {
+				return "*"
+			}
+			return e.Path
+		}
 		return fmt.Sprintf("%s:%d", e.LocalAddress, e.LocalPort)
 	})
 
@@ -77,6 +95,9 @@ func GetColumns() *columns.Columns[Event] {
 		Visible:  true,
 		Order:    1001,
 	}, func(e *Event) string {
+		if e.Protocol == "UNIX" {
+			return "*"
+		}
 		return fmt.Sprintf("%s:%d", e.RemoteAddress, e.RemotePort)
 	})
 
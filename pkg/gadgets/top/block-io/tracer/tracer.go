@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 	"unsafe"
 
@@ -31,6 +32,7 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/block-io/types"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/kallsyms"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
 
@@ -54,6 +56,10 @@ type Tracer struct {
 	eventCallback    func(*top.Event[types.Stats])
 	done             chan bool
 	colMap           columns.ColumnMap[types.Stats]
+
+	// mu protects the params of config changed while running
+	mu              sync.Mutex
+	intervalChanged chan struct{}
 }
 
 func NewTracer(config *Config, enricher gadgets.DataEnricherByMntNs,
@@ -109,7 +115,7 @@ func (t *Tracer) install() error {
 		return fmt.Errorf("loading ebpf spec: %w", err)
 	}
 
-	kernelSymbols, err := kallsyms.NewKAllSyms()
+	kernelSymbols, err := kallsyms.Shared()
 	if err != nil {
 		return fmt.Errorf("loading kernel symbols: %w", err)
 	}
@@ -234,9 +240,15 @@ func (t *Tracer) run(ctx context.Context) error {
 			return nil
 		case <-ctx.Done():
 			return nil
+		case <-t.intervalChanged:
+			t.mu.Lock()
+			ticker.Reset(t.config.Interval)
+			t.mu.Unlock()
 		case <-ticker.C:
+			t.mu.Lock()
 			stats, err := t.nextStats()
 			if err != nil {
+				t.mu.Unlock()
 				return fmt.Errorf("getting next stats: %w", err)
 			}
 
@@ -244,6 +256,7 @@ func (t *Tracer) run(ctx context.Context) error {
 			if n > t.config.MaxRows {
 				n = t.config.MaxRows
 			}
+			t.mu.Unlock()
 			t.eventCallback(&top.Event[types.Stats]{Stats: stats[:n]})
 
 			// Count down only if user requested a finite number of iterations
@@ -297,12 +310,39 @@ func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
 			Interval: 1 * time.Second,
 			SortBy:   nil,
 		},
-		done: make(chan bool),
+		done:            make(chan bool),
+		intervalChanged: make(chan struct{}, 1),
 	}
 	return tracer, nil
 }
 
+// UpdateParams changes the interval, the maximum number of rows and the sort
+// of the running gadget without reloading its eBPF programs
+func (t *Tracer) UpdateParams(params *params.Params) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	interval, err := top.UpdatedInterval(params, t.config.Interval, t.config.Iterations)
+	if err != nil {
+		return err
+	}
+
+	t.config.MaxRows = params.Get(gadgets.ParamMaxRows).AsInt()
+	t.config.SortBy = params.Get(gadgets.ParamSortBy).AsStringSlice()
+	if interval != t.config.Interval {
+		t.config.Interval = interval
+		select {
+		case t.intervalChanged <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
 func (t *Tracer) init(gadgetCtx gadgets.GadgetContext) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	params := gadgetCtx.GadgetParams()
 	t.config.MaxRows = params.Get(gadgets.ParamMaxRows).AsInt()
 	t.config.SortBy = params.Get(gadgets.ParamSortBy).AsStringSlice()
@@ -0,0 +1,141 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// asnRange is a range of addresses announced by an autonomous system
+type asnRange struct {
+	start netip.Addr
+	end   netip.Addr
+	asn   uint32
+	name  string
+}
+
+// asnDB maps the addresses to their autonomous system. The ranges are sorted
+// and don't overlap, the lookups are binary searches.
+type asnDB struct {
+	ranges []asnRange
+}
+
+// loadASNDB reads a database in the TSV format of iptoasn.com, compressed with
+// gzip or not: the first and the last address of the range, the AS number, the
+// country code and the description of the AS, e.g.
+// "1.0.0.0	1.0.0.255	13335	US	CLOUDFLARENET"
+func loadASNDB(path string) (*asnDB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening ASN database: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("decompressing ASN database: %w", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	db, err := parseASNDB(r)
+	if err != nil {
+		return nil, fmt.Errorf("parsing ASN database %q: %w", path, err)
+	}
+	return db, nil
+}
+
+func parseASNDB(r io.Reader) (*asnDB, error) {
+	db := &asnDB{}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Split(scanner.Text(), "\t")
+		if len(fields) == 1 && fields[0] == "" {
+			continue
+		}
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("line %d: expected 5 fields, got %d", line, len(fields))
+		}
+
+		start, err := netip.ParseAddr(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		end, err := netip.ParseAddr(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if start.Is4() != end.Is4() || end.Less(start) {
+			return nil, fmt.Errorf("line %d: invalid range %s-%s", line, start, end)
+		}
+		asn, err := strconv.ParseUint(fields[2], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid AS number: %w", line, err)
+		}
+
+		// AS 0 is used for the ranges that aren't routed
+		if asn == 0 {
+			continue
+		}
+		db.ranges = append(db.ranges, asnRange{
+			start: start,
+			end:   end,
+			asn:   uint32(asn),
+			name:  fields[4],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return db.ranges[i].start.Less(db.ranges[j].start)
+	})
+	return db, nil
+}
+
+// lookup returns the number and the name of the autonomous system announcing
+// addr, 0 if it isn't known
+func (db *asnDB) lookup(addr netip.Addr) (uint32, string) {
+	if db == nil {
+		return 0, ""
+	}
+
+	// Find the last range starting before addr, the IPv4 addresses are
+	// sorted before the IPv6 ones
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return addr.Less(db.ranges[i].start)
+	}) - 1
+	if i < 0 {
+		return 0, ""
+	}
+	r := db.ranges[i]
+	if r.start.Is4() != addr.Is4() || r.end.Less(addr) {
+		return 0, ""
+	}
+	return r.asn, r.name
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+const testASNDB = `1.0.0.0	1.0.0.255	13335	US	CLOUDFLARENET
+1.0.1.0	1.0.3.255	0	None	Not routed
+8.8.8.0	8.8.8.255	15169	US	GOOGLE
+2001:4860::	2001:4860:ffff:ffff:ffff:ffff:ffff:ffff	15169	US	GOOGLE
+`
+
+func TestASNDB(t *testing.T) {
+	db, err := parseASNDB(strings.NewReader(testASNDB))
+	if err != nil {
+		t.Fatalf("Could not parse database: %s", err)
+	}
+
+	type testCase struct {
+		addr         string
+		expectedASN  uint32
+		expectedName string
+	}
+
+	testCases := []testCase{
+		{"1.0.0.1", 13335, "CLOUDFLARENET"},
+		{"1.0.0.255", 13335, "CLOUDFLARENET"},
+		{"1.0.2.1", 0, ""},
+		{"8.8.8.8", 15169, "GOOGLE"},
+		{"8.8.9.1", 0, ""},
+		{"0.0.0.1", 0, ""},
+		{"2001:4860:4860::8888", 15169, "GOOGLE"},
+		{"2001:4861::1", 0, ""},
+		{"::1", 0, ""},
+	}
+
+	for _, test := range testCases {
+		asn, name := db.lookup(netip.MustParseAddr(test.addr))
+		if asn != test.expectedASN || name != test.expectedName {
+			t.Errorf("%s: expected AS%d %q but got AS%d %q", test.addr, test.expectedASN, test.expectedName, asn, name)
+		}
+	}
+}
+
+func TestASNDBInvalid(t *testing.T) {
+	for _, db := range []string{
+		"1.0.0.0\t1.0.0.255\t13335\tUS\n",
+		"1.0.0.0\t1.0.0.255\tAS13335\tUS\tCLOUDFLARENET\n",
+		"1.0.0.255\t1.0.0.0\t13335\tUS\tCLOUDFLARENET\n",
+		"1.0.0.0\t2001::\t13335\tUS\tCLOUDFLARENET\n",
+	} {
+		if _, err := parseASNDB(strings.NewReader(db)); err == nil {
+			t.Errorf("Expected an error parsing %q", db)
+		}
+	}
+}
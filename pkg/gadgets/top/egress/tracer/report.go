@@ -0,0 +1,242 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/egress/types"
+	nettypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/network/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const nameCacheSize = 16384
+
+// sharedAddressSpace is used by carrier-grade NATs and by the overlay networks
+// of some clusters, it isn't reachable from the internet
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// workload is what the traffic is aggregated by: the workload managing the
+// pod, or the pod when there isn't any, or the container outside Kubernetes
+type workload struct {
+	namespace string
+	kind      string
+	name      string
+	pod       string
+	container string
+}
+
+func workloadOf(container *containercollection.Container) workload {
+	switch {
+	case container.WorkloadName != "":
+		return workload{namespace: container.Namespace, kind: container.WorkloadKind, name: container.WorkloadName}
+	case container.Podname != "":
+		return workload{namespace: container.Namespace, pod: container.Podname}
+	default:
+		return workload{container: container.Name}
+	}
+}
+
+// nameKey identifies an address resolved in a network namespace
+type nameKey struct {
+	netns uint64
+	addr  netip.Addr
+}
+
+// destinationKey is a destination of a workload. The destination is an
+// address, a domain name or an AS number written as AS<number>: the baseline
+// has all of them, so that a destination is known when any of them was
+// contacted.
+type destinationKey struct {
+	workload    workload
+	proto       string
+	port        uint16
+	destination string
+}
+
+type row struct {
+	stats *types.Stats
+	// bytes sent and received by address, to find the top one
+	addrs map[netip.Addr]uint64
+}
+
+// reporter aggregates the traffic of the containers by workload and external
+// destination, and compares the destinations with the ones contacted during
+// the baseline window. The names are added by the DNS tracer while the
+// traffic is reported, the rest is only used by the reporting loop.
+type reporter struct {
+	names *lru.Cache[nameKey, string] // This is thread-safe.
+	asns  *asnDB
+
+	baselineEnd time.Time
+	baseline    map[destinationKey]struct{}
+}
+
+func newReporter(start time.Time, baseline time.Duration, asns *asnDB) (*reporter, error) {
+	names, err := lru.New[nameKey, string](nameCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return &reporter{
+		names:       names,
+		asns:        asns,
+		baselineEnd: start.Add(baseline),
+		baseline:    make(map[destinationKey]struct{}),
+	}, nil
+}
+
+// resolved records the addresses of a DNS response received in netns, the
+// last name resolved to an address wins
+func (r *reporter) resolved(netns uint64, name string, addrs []string) {
+	name = strings.TrimSuffix(name, ".")
+	for _, a := range addrs {
+		addr, err := netip.ParseAddr(a)
+		if err != nil {
+			continue
+		}
+		r.names.Add(nameKey{netns, addr.Unmap()}, name)
+	}
+}
+
+// isExternal tells whether addr is reachable from the internet, the traffic
+// inside the cluster and the private networks isn't reported
+func isExternal(addr netip.Addr) bool {
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// status classifies a destination, adding it to the baseline during the
+// baseline window. The AS is only compared for the addresses without name,
+// not to hide new domains hosted by a known cloud provider.
+func (r *reporter) status(now time.Time, key destinationKey, addr netip.Addr, domain string, asn uint32) types.Status {
+	destinations := []string{addr.String()}
+	if domain != "" {
+		destinations = append(destinations, domain)
+	} else if asn != 0 {
+		destinations = append(destinations, fmt.Sprintf("AS%d", asn))
+	}
+
+	if now.Before(r.baselineEnd) {
+		for _, destination := range destinations {
+			key.destination = destination
+			r.baseline[key] = struct{}{}
+		}
+		return types.StatusLearning
+	}
+
+	for _, destination := range destinations {
+		key.destination = destination
+		if _, ok := r.baseline[key]; ok {
+			return types.StatusKnown
+		}
+	}
+	return types.StatusNew
+}
+
+// statusRank orders the statuses of the addresses aggregated in the same
+// stats, the most interesting one is reported
+var statusRank = map[types.Status]int{
+	types.StatusKnown:    1,
+	types.StatusLearning: 2,
+	types.StatusNew:      3,
+}
+
+// report aggregates the traffic of the interval. Only the traffic of the
+// connections to a remote service, i.e. the ones whose local port is the
+// ephemeral one, is reported. lookup returns the workload of a network
+// namespace.
+func (r *reporter) report(now time.Time, stats []*nettypes.Stats, lookup func(netns uint64) (workload, bool)) []*types.Stats {
+	rows := make(map[destinationKey]*row)
+
+	for _, s := range stats {
+		if s.LocalPort != 0 || s.RemotePort == 0 {
+			continue
+		}
+		w, ok := lookup(s.NetNsID)
+		if !ok {
+			continue
+		}
+		addr, err := netip.ParseAddr(s.RemoteAddr)
+		if err != nil {
+			continue
+		}
+		addr = addr.Unmap()
+		if !isExternal(addr) {
+			continue
+		}
+
+		domain, _ := r.names.Get(nameKey{s.NetNsID, addr})
+		asn, asName := r.asns.lookup(addr)
+		destination := domain
+		if destination == "" {
+			if asn != 0 {
+				destination = fmt.Sprintf("AS%d", asn)
+			} else {
+				destination = addr.String()
+			}
+		}
+
+		key := destinationKey{w, s.Proto, s.RemotePort, destination}
+		rw, ok := rows[key]
+		if !ok {
+			rw = &row{
+				stats: &types.Stats{
+					CommonData: eventtypes.CommonData{
+						Namespace:    w.namespace,
+						Pod:          w.pod,
+						Container:    w.container,
+						WorkloadKind: w.kind,
+						Workload:     w.name,
+					},
+					Proto:       s.Proto,
+					Port:        s.RemotePort,
+					Destination: destination,
+					Domain:      domain,
+					ASN:         asn,
+					ASName:      asName,
+				},
+				addrs: make(map[netip.Addr]uint64),
+			}
+			rows[key] = rw
+		}
+
+		status := r.status(now, destinationKey{w, s.Proto, s.RemotePort, ""}, addr, domain, asn)
+		if statusRank[status] > statusRank[rw.stats.Status] {
+			rw.stats.Status = status
+		}
+		rw.stats.Sent += s.Sent
+		rw.stats.Received += s.Received
+		rw.addrs[addr] += s.Sent + s.Received
+	}
+
+	ret := make([]*types.Stats, 0, len(rows))
+	for _, rw := range rows {
+		var top uint64
+		for addr, bytes := range rw.addrs {
+			if bytes > top || rw.stats.RemoteAddr == "" {
+				top = bytes
+				rw.stats.RemoteAddr = addr.String()
+			}
+		}
+		rw.stats.Addresses = uint32(len(rw.addrs))
+		ret = append(ret, rw.stats)
+	}
+	return ret
+}
@@ -0,0 +1,237 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/egress/types"
+	nettypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/network/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+var testWorkloads = map[uint64]workload{
+	1: {namespace: "default", kind: "Deployment", name: "web"},
+	2: {namespace: "default", kind: "Deployment", name: "web"},
+	3: {namespace: "default", pod: "debug"},
+}
+
+func testLookup(netns uint64) (workload, bool) {
+	w, ok := testWorkloads[netns]
+	return w, ok
+}
+
+func traffic(netns uint64, remoteAddr string, remotePort uint16, sent uint64) *nettypes.Stats {
+	return &nettypes.Stats{
+		WithNetNsID: eventtypes.WithNetNsID{NetNsID: netns},
+		Proto:       "tcp",
+		RemoteAddr:  remoteAddr,
+		RemotePort:  remotePort,
+		Sent:        sent,
+		Received:    2 * sent,
+	}
+}
+
+func newTestReporter(t *testing.T, start time.Time) *reporter {
+	db, err := parseASNDB(strings.NewReader(testASNDB))
+	if err != nil {
+		t.Fatalf("Could not parse database: %s", err)
+	}
+	r, err := newReporter(start, time.Minute, db)
+	if err != nil {
+		t.Fatalf("Could not initialize reporter: %s", err)
+	}
+	return r
+}
+
+func sortedStats(stats []*types.Stats) []types.Stats {
+	ret := make([]types.Stats, 0, len(stats))
+	for _, s := range stats {
+		ret = append(ret, *s)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Workload != ret[j].Workload {
+			return ret[i].Workload < ret[j].Workload
+		}
+		return ret[i].Destination < ret[j].Destination
+	})
+	return ret
+}
+
+func TestReportAggregation(t *testing.T) {
+	start := time.Now()
+	r := newTestReporter(t, start)
+	r.resolved(1, "example.com.", []string{"93.184.216.34"})
+	r.resolved(2, "example.com.", []string{"93.184.216.34"})
+
+	stats := r.report(start, []*nettypes.Stats{
+		// Two pods of the same deployment
+		traffic(1, "93.184.216.34", 443, 100),
+		traffic(2, "93.184.216.34", 443, 50),
+		// Not resolved
+		traffic(2, "93.184.216.35", 443, 10),
+		// Two addresses of the same AS without name
+		traffic(1, "8.8.8.8", 53, 10),
+		traffic(2, "::ffff:8.8.8.9", 53, 5),
+		traffic(3, "8.8.8.8", 53, 1),
+		// Not reported: private addresses, connections to the container,
+		// unknown network namespaces
+		traffic(1, "10.96.0.10", 53, 1),
+		traffic(1, "100.64.0.1", 53, 1),
+		{WithNetNsID: eventtypes.WithNetNsID{NetNsID: 1}, Proto: "tcp", LocalPort: 80, RemoteAddr: "1.0.0.1", Sent: 1},
+		traffic(4, "1.0.0.1", 443, 1),
+	}, testLookup)
+
+	web := eventtypes.CommonData{Namespace: "default", WorkloadKind: "Deployment", Workload: "web"}
+	expected := []types.Stats{
+		{
+			CommonData:  eventtypes.CommonData{Namespace: "default", Pod: "debug"},
+			Proto:       "tcp",
+			Port:        53,
+			Destination: "AS15169",
+			ASN:         15169,
+			ASName:      "GOOGLE",
+			RemoteAddr:  "8.8.8.8",
+			Addresses:   1,
+			Status:      types.StatusLearning,
+			Sent:        1,
+			Received:    2,
+		},
+		{
+			CommonData:  web,
+			Proto:       "tcp",
+			Port:        443,
+			Destination: "93.184.216.35",
+			RemoteAddr:  "93.184.216.35",
+			Addresses:   1,
+			Status:      types.StatusLearning,
+			Sent:        10,
+			Received:    20,
+		},
+		{
+			CommonData:  web,
+			Proto:       "tcp",
+			Port:        53,
+			Destination: "AS15169",
+			ASN:         15169,
+			ASName:      "GOOGLE",
+			RemoteAddr:  "8.8.8.8",
+			Addresses:   2,
+			Status:      types.StatusLearning,
+			Sent:        15,
+			Received:    30,
+		},
+		{
+			CommonData:  web,
+			Proto:       "tcp",
+			Port:        443,
+			Destination: "example.com",
+			Domain:      "example.com",
+			RemoteAddr:  "93.184.216.34",
+			Addresses:   1,
+			Status:      types.StatusLearning,
+			Sent:        150,
+			Received:    300,
+		},
+	}
+
+	got := sortedStats(stats)
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d stats but got %d: %+v", len(expected), len(got), got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Stats %d: expected %+v but got %+v", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestReportBaseline(t *testing.T) {
+	start := time.Now()
+	after := start.Add(2 * time.Minute)
+
+	type testCase struct {
+		baseline []*nettypes.Stats
+		traffic  *nettypes.Stats
+		expected types.Status
+	}
+
+	testCases := map[string]testCase{
+		"same_address": {
+			baseline: []*nettypes.Stats{traffic(1, "198.51.100.1", 443, 1)},
+			traffic:  traffic(1, "198.51.100.1", 443, 1),
+			expected: types.StatusKnown,
+		},
+		"other_pod_of_the_workload": {
+			baseline: []*nettypes.Stats{traffic(1, "198.51.100.1", 443, 1)},
+			traffic:  traffic(2, "198.51.100.1", 443, 1),
+			expected: types.StatusKnown,
+		},
+		"other_workload": {
+			baseline: []*nettypes.Stats{traffic(1, "198.51.100.1", 443, 1)},
+			traffic:  traffic(3, "198.51.100.1", 443, 1),
+			expected: types.StatusNew,
+		},
+		"other_port": {
+			baseline: []*nettypes.Stats{traffic(1, "198.51.100.1", 443, 1)},
+			traffic:  traffic(1, "198.51.100.1", 8443, 1),
+			expected: types.StatusNew,
+		},
+		"same_as": {
+			baseline: []*nettypes.Stats{traffic(1, "8.8.8.8", 443, 1)},
+			traffic:  traffic(1, "8.8.8.9", 443, 1),
+			expected: types.StatusKnown,
+		},
+		"same_domain": {
+			baseline: []*nettypes.Stats{traffic(1, "198.51.100.1", 443, 1)},
+			traffic:  traffic(1, "198.51.100.2", 443, 1),
+			expected: types.StatusKnown,
+		},
+		"new_domain_of_a_known_as": {
+			baseline: []*nettypes.Stats{traffic(1, "8.8.8.8", 443, 1)},
+			traffic:  traffic(1, "8.8.8.10", 443, 1),
+			expected: types.StatusNew,
+		},
+		"no_baseline": {
+			traffic:  traffic(1, "198.51.100.1", 443, 1),
+			expected: types.StatusNew,
+		},
+	}
+
+	for name, test := range testCases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			r := newTestReporter(t, start)
+			r.resolved(1, "api.example.com.", []string{"198.51.100.1", "198.51.100.2"})
+			r.resolved(1, "evil.example.net.", []string{"8.8.8.10"})
+
+			for _, s := range r.report(start, test.baseline, testLookup) {
+				if s.Status != types.StatusLearning {
+					t.Fatalf("Expected %q during the baseline window but got %q", types.StatusLearning, s.Status)
+				}
+			}
+			stats := r.report(after, []*nettypes.Stats{test.traffic}, testLookup)
+			if len(stats) != 1 {
+				t.Fatalf("Expected 1 stats but got %d", len(stats))
+			}
+			if stats[0].Status != test.expected {
+				t.Fatalf("Expected %q but got %q", test.expected, stats[0].Status)
+			}
+		})
+	}
+}
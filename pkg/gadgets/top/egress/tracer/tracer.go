@@ -0,0 +1,272 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/egress/types"
+	nettracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/network/tracer"
+	dnstracer "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/tracer"
+	dnstypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type Config struct {
+	MaxRows    int
+	Interval   time.Duration
+	Iterations int
+	SortBy     []string
+	Baseline   time.Duration
+	ASNDB      string
+}
+
+// netnsUsers is the workload of a network namespace and the containers using
+// it
+type netnsUsers struct {
+	workload workload
+	users    map[string]struct{}
+}
+
+// Tracer reuses the tracer of the top network gadget to count the traffic and
+// the one of the trace dns gadget to know the domain names resolved by the
+// workloads
+type Tracer struct {
+	*nettracer.Tracer
+
+	config    *Config
+	dnsTracer *dnstracer.Tracer
+	reporter  *reporter
+
+	mu sync.Mutex
+	// key: network namespace inode number
+	netns map[uint64]*netnsUsers
+
+	eventCallback func(*top.Event[types.Stats])
+	colMap        columns.ColumnMap[types.Stats]
+}
+
+func (t *Tracer) lookup(netns uint64) (workload, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n, ok := t.netns[netns]
+	if !ok {
+		return workload{}, false
+	}
+	return n.workload, true
+}
+
+func (t *Tracer) handleDNSEvent(ev *dnstypes.Event) {
+	if ev.Type != eventtypes.NORMAL || ev.Qr != dnstypes.DNSPktTypeResponse || len(ev.Addresses) == 0 {
+		return
+	}
+	t.reporter.resolved(ev.NetNsID, ev.DNSName, ev.Addresses)
+}
+
+func (t *Tracer) nextStats() ([]*types.Stats, error) {
+	netStats, err := t.Tracer.NextStats()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := t.reporter.report(time.Now(), netStats, t.lookup)
+	top.SortStats(stats, t.config.SortBy, &t.colMap)
+
+	return stats, nil
+}
+
+func (t *Tracer) run(ctx context.Context) error {
+	// Don't use a context with a timeout but a counter to avoid having to deal
+	// with two timers: one for the timeout and another for the ticker.
+	count := t.config.Iterations
+	ticker := time.NewTicker(t.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			stats, err := t.nextStats()
+			if err != nil {
+				return fmt.Errorf("getting next stats: %w", err)
+			}
+
+			n := len(stats)
+			if n > t.config.MaxRows {
+				n = t.config.MaxRows
+			}
+			t.eventCallback(&top.Event[types.Stats]{Stats: stats[:n]})
+
+			// Count down only if user requested a finite number of iterations
+			// through a timeout.
+			if t.config.Iterations > 0 {
+				count--
+				if count == 0 {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// --- Registry changes
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+		netns:  make(map[uint64]*netnsUsers),
+	}
+	return tracer, nil
+}
+
+func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.MaxRows = params.Get(gadgets.ParamMaxRows).AsInt()
+	t.config.SortBy = params.Get(gadgets.ParamSortBy).AsStringSlice()
+	t.config.Interval = time.Second * time.Duration(params.Get(gadgets.ParamInterval).AsInt())
+	t.config.Baseline = params.Get(ParamBaseline).AsDuration()
+	t.config.ASNDB = params.Get(ParamASNDB).AsString()
+
+	var err error
+	if t.config.Iterations, err = top.ComputeIterations(t.config.Interval, gadgetCtx.Timeout()); err != nil {
+		return err
+	}
+	if t.config.Baseline < 0 {
+		return fmt.Errorf("the baseline window can't be negative")
+	}
+
+	statCols, err := columns.NewColumns[types.Stats]()
+	if err != nil {
+		return err
+	}
+	t.colMap = statCols.GetColumnMap()
+
+	var asns *asnDB
+	if t.config.ASNDB != "" {
+		if asns, err = loadASNDB(t.config.ASNDB); err != nil {
+			return err
+		}
+	}
+	if t.reporter, err = newReporter(time.Now(), t.config.Baseline, asns); err != nil {
+		return err
+	}
+
+	netTracer, err := (&nettracer.GadgetDesc{}).NewInstance()
+	if err != nil {
+		return err
+	}
+	t.Tracer = netTracer.(*nettracer.Tracer)
+	if err := t.Tracer.Init(gadgetCtx); err != nil {
+		return err
+	}
+
+	if t.dnsTracer, err = dnstracer.NewTracer(); err != nil {
+		return err
+	}
+	t.dnsTracer.SetEventHandler(t.handleDNSEvent)
+
+	return nil
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	return t.run(gadgetCtx.Context())
+}
+
+func (t *Tracer) Close() {
+	if t.dnsTracer != nil {
+		t.dnsTracer.Close()
+	}
+	if t.Tracer != nil {
+		t.Tracer.Close()
+	}
+}
+
+func (t *Tracer) SetEventHandlerArray(handler any) {
+	nh, ok := handler.(func(ev []*types.Stats))
+	if !ok {
+		panic("event handler invalid")
+	}
+
+	t.eventCallback = func(ev *top.Event[types.Stats]) {
+		if ev.Error != "" {
+			return
+		}
+		nh(ev.Stats)
+	}
+}
+
+// AttachContainer attaches both tracers to the network namespace of the
+// container. The pods using the network of the host are skipped, their
+// traffic would be the one of the whole node.
+func (t *Tracer) AttachContainer(container *containercollection.Container) error {
+	if container.HostNetwork {
+		return nil
+	}
+
+	if err := t.Tracer.AttachContainer(container); err != nil {
+		return err
+	}
+	if err := t.dnsTracer.AttachContainer(container); err != nil {
+		t.Tracer.DetachContainer(container)
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n, ok := t.netns[container.Netns]
+	if !ok {
+		n = &netnsUsers{
+			workload: workloadOf(container),
+			users:    make(map[string]struct{}),
+		}
+		t.netns[container.Netns] = n
+	}
+	n.users[container.ID] = struct{}{}
+
+	return nil
+}
+
+func (t *Tracer) DetachContainer(container *containercollection.Container) error {
+	if container.HostNetwork {
+		return nil
+	}
+
+	t.mu.Lock()
+	if n, ok := t.netns[container.Netns]; ok {
+		delete(n.users, container.ID)
+		if len(n.users) == 0 {
+			delete(t.netns, container.Netns)
+		}
+	}
+	t.mu.Unlock()
+
+	dnsErr := t.dnsTracer.DetachContainer(container)
+	if err := t.Tracer.DetachContainer(container); err != nil {
+		return err
+	}
+	return dnsErr
+}
@@ -0,0 +1,99 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/docker/go-units"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/environment"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// The status names sort in the order of interest: new, learning, known
+var SortByDefault = []string{"-status", "-sent", "-recv"}
+
+// Status tells whether a destination was contacted by the workload during the
+// baseline window
+type Status string
+
+const (
+	// StatusLearning is the status of the destinations during the baseline
+	// window, they are added to the baseline
+	StatusLearning Status = "learning"
+	// StatusKnown is the status of the destinations contacted during the
+	// baseline window
+	StatusKnown Status = "known"
+	// StatusNew is the status of the destinations never contacted during the
+	// baseline window
+	StatusNew Status = "new"
+)
+
+// Stats represents the traffic of a workload to an external destination
+// during the interval. The traffic of all the pods of the workload on the node
+// is aggregated: the pod is only set for the pods without workload, and the
+// network namespace is left empty for the node to be the only metadata added
+// to the stats.
+type Stats struct {
+	eventtypes.CommonData
+	eventtypes.WithNetNsID
+
+	Proto string `json:"proto,omitempty" column:"proto,maxWidth:5"`
+	Port  uint16 `json:"port" column:"port,template:ipport"`
+
+	// Destination is the domain name resolved by the workload, or the
+	// autonomous system, or the address of the destination
+	Destination string `json:"destination,omitempty" column:"destination,minWidth:24,maxWidth:50"`
+	Domain      string `json:"domain,omitempty" column:"domain,minWidth:24,maxWidth:50,hide"`
+	ASN         uint32 `json:"asn,omitempty" column:"asn,minWidth:6,maxWidth:10,hide"`
+	ASName      string `json:"asName,omitempty" column:"asname,minWidth:16,maxWidth:30"`
+	// RemoteAddr is the address that received the most traffic, Addresses
+	// the number of addresses aggregated
+	RemoteAddr string `json:"remoteAddr,omitempty" column:"remoteaddr,template:ipaddr,hide"`
+	Addresses  uint32 `json:"addresses,omitempty" column:"addrs,width:5,hide"`
+
+	Status   Status `json:"status,omitempty" column:"status,width:8,fixed,order:1001"`
+	Sent     uint64 `json:"sent" column:"sent,order:1002"`
+	Received uint64 `json:"received" column:"recv,order:1003"`
+}
+
+func GetColumns() *columns.Columns[Stats] {
+	cols := columns.MustCreateColumns[Stats]()
+
+	// The stats are aggregated by workload, not by container
+	if environment.Environment == environment.Kubernetes {
+		col, _ := cols.GetColumn("container")
+		col.Visible = false
+		col, _ = cols.GetColumn("workload")
+		col.Visible = true
+	}
+
+	cols.MustSetExtractor("asn", func(stats *Stats) (ret string) {
+		if stats.ASN == 0 {
+			return ""
+		}
+		return fmt.Sprintf("AS%d", stats.ASN)
+	})
+	cols.MustSetExtractor("sent", func(stats *Stats) (ret string) {
+		return fmt.Sprint(units.BytesSize(float64(stats.Sent)))
+	})
+	cols.MustSetExtractor("recv", func(stats *Stats) (ret string) {
+		return fmt.Sprint(units.BytesSize(float64(stats.Received)))
+	})
+
+	return cols
+}
@@ -0,0 +1,217 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/cgroups"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+const (
+	counterCycles = iota
+	counterInstructions
+	counterCacheReferences
+	counterCacheMisses
+	numCounters
+)
+
+// counterConfigs are the generic hardware events of the counters, the kernel
+// maps them to the events of the PMU of the CPU
+var counterConfigs = [numCounters]struct {
+	name   string
+	config uint64
+}{
+	counterCycles:          {"cycles", unix.PERF_COUNT_HW_CPU_CYCLES},
+	counterInstructions:    {"instructions", unix.PERF_COUNT_HW_INSTRUCTIONS},
+	counterCacheReferences: {"cache references", unix.PERF_COUNT_HW_CACHE_REFERENCES},
+	counterCacheMisses:     {"cache misses", unix.PERF_COUNT_HW_CACHE_MISSES},
+}
+
+// reading is the value of a counter read with PERF_FORMAT_TOTAL_TIME_ENABLED
+// and PERF_FORMAT_TOTAL_TIME_RUNNING
+type reading struct {
+	value   uint64
+	enabled uint64
+	running uint64
+}
+
+// scaledDelta returns the count since the last reading, estimated from the
+// time the counter ran when it was multiplexed with other events
+func (r reading) scaledDelta(last reading) uint64 {
+	if r.value < last.value || r.running <= last.running {
+		return 0
+	}
+	value := r.value - last.value
+	enabled := r.enabled - last.enabled
+	running := r.running - last.running
+	if enabled == running {
+		return value
+	}
+	return uint64(float64(value) * float64(enabled) / float64(running))
+}
+
+// counters are the counters of a cgroup, with a file descriptor per CPU for
+// each counter as cgroup events can't count on all the CPUs at once
+type counters struct {
+	fds  [numCounters][]int
+	last [numCounters]reading
+}
+
+func newPerfAttr(config uint64) *unix.PerfEventAttr {
+	return &unix.PerfEventAttr{
+		Type:        unix.PERF_TYPE_HARDWARE,
+		Config:      config,
+		Size:        uint32(unsafe.Sizeof(unix.PerfEventAttr{})),
+		Read_format: unix.PERF_FORMAT_TOTAL_TIME_ENABLED | unix.PERF_FORMAT_TOTAL_TIME_RUNNING,
+	}
+}
+
+// checkCounters checks that the counters are supported by the CPU, they
+// aren't on most virtual machines
+func checkCounters() error {
+	for _, c := range counterConfigs {
+		fd, err := unix.PerfEventOpen(newPerfAttr(c.config), 0, -1, -1, unix.PERF_FLAG_FD_CLOEXEC)
+		if err != nil {
+			return fmt.Errorf("opening %s counter: %w", c.name, err)
+		}
+		unix.Close(fd)
+	}
+	return nil
+}
+
+func openCounters(dir string) (*counters, error) {
+	cgroupFd, err := unix.Open(dir, unix.O_RDONLY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("opening cgroup: %w", err)
+	}
+	defer unix.Close(cgroupFd)
+
+	c := &counters{}
+	for i, cfg := range counterConfigs {
+		for cpu := 0; cpu < runtime.NumCPU(); cpu++ {
+			fd, err := unix.PerfEventOpen(newPerfAttr(cfg.config), cgroupFd, cpu, -1,
+				unix.PERF_FLAG_PID_CGROUP|unix.PERF_FLAG_FD_CLOEXEC)
+			if err != nil {
+				c.close()
+				return nil, fmt.Errorf("opening %s counter: %w", cfg.name, err)
+			}
+			c.fds[i] = append(c.fds[i], fd)
+		}
+	}
+
+	// The counts that happened before the gadget started aren't reported
+	if c.last, err = c.read(); err != nil {
+		c.close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// read returns the sum of the counters of all the CPUs
+func (c *counters) read() ([numCounters]reading, error) {
+	var readings [numCounters]reading
+	for i, fds := range c.fds {
+		for _, fd := range fds {
+			var r reading
+			buf := (*[unsafe.Sizeof(r)]byte)(unsafe.Pointer(&r))[:]
+			if _, err := unix.Read(fd, buf); err != nil {
+				return readings, fmt.Errorf("reading %s counter: %w", counterConfigs[i].name, err)
+			}
+			readings[i].value += r.value
+			readings[i].enabled += r.enabled
+			readings[i].running += r.running
+		}
+	}
+	return readings, nil
+}
+
+// next returns the counts since the last call
+func (c *counters) next() ([numCounters]uint64, error) {
+	var deltas [numCounters]uint64
+
+	readings, err := c.read()
+	if err != nil {
+		return deltas, err
+	}
+	for i := range readings {
+		deltas[i] = readings[i].scaledDelta(c.last[i])
+	}
+	c.last = readings
+
+	return deltas, nil
+}
+
+func (c *counters) close() {
+	for i, fds := range c.fds {
+		for _, fd := range fds {
+			unix.Close(fd)
+		}
+		c.fds[i] = nil
+	}
+}
+
+// getPerfEventCgroup returns the directory of the cgroup of the given process
+// in the hierarchy of the perf_event controller. It's always enabled with
+// cgroup v2, unless it's mounted with cgroup v1.
+func getPerfEventCgroup(pid uint32) (string, error) {
+	file, err := os.Open(filepath.Join(host.HostProcFs, fmt.Sprint(pid), "cgroup"))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	pathV2 := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[0] == "0" && fields[1] == "" {
+			pathV2 = fields[2]
+			continue
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			if controller != "perf_event" {
+				continue
+			}
+			return filepath.Join(cgroupRoot, fields[1], fields[2]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if pathV2 != "" {
+		return cgroups.CgroupPathV2AddMountpoint(pathV2)
+	}
+
+	return "", errors.New("perf_event controller not found")
+}
@@ -0,0 +1,221 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/hw-counters/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type Config struct {
+	MaxRows    int
+	Interval   time.Duration
+	Iterations int
+	SortBy     []string
+}
+
+type container struct {
+	name     string
+	counters *counters
+}
+
+type Tracer struct {
+	config        *Config
+	eventCallback func(*top.Event[types.Stats])
+	colMap        columns.ColumnMap[types.Stats]
+
+	mu sync.Mutex
+	// containers indexed by mount namespace
+	containers map[uint64]*container
+}
+
+func (t *Tracer) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for mntns, c := range t.containers {
+		c.counters.close()
+		delete(t.containers, mntns)
+	}
+}
+
+func (t *Tracer) nextStats(gadgetCtx gadgets.GadgetContext) []*types.Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := []*types.Stats{}
+	for mntns, c := range t.containers {
+		counts, err := c.counters.next()
+		if err != nil {
+			// The counters stay readable after the cgroup is removed, it
+			// shouldn't happen
+			gadgetCtx.Logger().Debugf("reading counters of container %q: %s", c.name, err)
+			continue
+		}
+		// Don't report the containers that didn't run
+		if counts[counterCycles] == 0 {
+			continue
+		}
+
+		stat := types.Stats{
+			WithMountNsID:   eventtypes.WithMountNsID{MountNsID: mntns},
+			Cycles:          counts[counterCycles],
+			Instructions:    counts[counterInstructions],
+			CacheReferences: counts[counterCacheReferences],
+			CacheMisses:     counts[counterCacheMisses],
+		}
+		stat.IPC = float64(stat.Instructions) / float64(stat.Cycles)
+		if stat.CacheReferences > 0 {
+			stat.MissRatio = 100 * float64(stat.CacheMisses) / float64(stat.CacheReferences)
+		}
+
+		stats = append(stats, &stat)
+	}
+
+	top.SortStats(stats, t.config.SortBy, &t.colMap)
+
+	return stats
+}
+
+func (t *Tracer) run(ctx context.Context, gadgetCtx gadgets.GadgetContext) error {
+	// Don't use a context with a timeout but a counter to avoid having to deal
+	// with two timers: one for the timeout and another for the ticker.
+	count := t.config.Iterations
+	ticker := time.NewTicker(t.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			stats := t.nextStats(gadgetCtx)
+
+			n := len(stats)
+			if n > t.config.MaxRows {
+				n = t.config.MaxRows
+			}
+			t.eventCallback(&top.Event[types.Stats]{Stats: stats[:n]})
+
+			// Count down only if user requested a finite number of iterations
+			// through a timeout.
+			if t.config.Iterations > 0 {
+				count--
+				if count == 0 {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	if err := t.init(gadgetCtx); err != nil {
+		return fmt.Errorf("initializing tracer: %w", err)
+	}
+
+	defer t.close()
+	if err := checkCounters(); err != nil {
+		return fmt.Errorf("hardware performance counters not available: %w", err)
+	}
+
+	return t.run(gadgetCtx.Context(), gadgetCtx)
+}
+
+func (t *Tracer) SetEventHandlerArray(handler any) {
+	nh, ok := handler.(func(ev []*types.Stats))
+	if !ok {
+		panic("event handler invalid")
+	}
+
+	t.eventCallback = func(ev *top.Event[types.Stats]) {
+		if ev.Error != "" {
+			return
+		}
+		nh(ev.Stats)
+	}
+}
+
+func (t *Tracer) AttachContainer(c *containercollection.Container) error {
+	dir, err := getPerfEventCgroup(c.Pid)
+	if err != nil {
+		return fmt.Errorf("getting perf_event cgroup: %w", err)
+	}
+
+	counters, err := openCounters(dir)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if old, ok := t.containers[c.Mntns]; ok {
+		old.counters.close()
+	}
+	t.containers[c.Mntns] = &container{
+		name:     c.Name,
+		counters: counters,
+	}
+	return nil
+}
+
+func (t *Tracer) DetachContainer(c *containercollection.Container) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if old, ok := t.containers[c.Mntns]; ok {
+		old.counters.close()
+		delete(t.containers, c.Mntns)
+	}
+	return nil
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config:     &Config{},
+		containers: make(map[uint64]*container),
+	}
+	return tracer, nil
+}
+
+func (t *Tracer) init(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.MaxRows = params.Get(gadgets.ParamMaxRows).AsInt()
+	t.config.SortBy = params.Get(gadgets.ParamSortBy).AsStringSlice()
+	t.config.Interval = time.Second * time.Duration(params.Get(gadgets.ParamInterval).AsInt())
+
+	var err error
+	if t.config.Iterations, err = top.ComputeIterations(t.config.Interval, gadgetCtx.Timeout()); err != nil {
+		return err
+	}
+
+	statCols, err := columns.NewColumns[types.Stats]()
+	if err != nil {
+		return err
+	}
+	t.colMap = statCols.GetColumnMap()
+
+	return nil
+}
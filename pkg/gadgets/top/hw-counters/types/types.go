@@ -0,0 +1,45 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+var SortByDefault = []string{"-cycles", "-instructions"}
+
+// Stats represents the hardware performance counters of a container during an
+// interval. The counts are estimated from the time the counters ran when the
+// PMU had to multiplex them.
+type Stats struct {
+	eventtypes.CommonData
+	eventtypes.WithMountNsID
+
+	Cycles       uint64 `json:"cycles" column:"cycles,width:14,align:right"`
+	Instructions uint64 `json:"instructions" column:"instructions,width:14,align:right"`
+	// IPC is the number of instructions per cycle
+	IPC float64 `json:"ipc" column:"ipc,width:6,precision:2,align:right"`
+	// CacheReferences and CacheMisses are the accesses to the last level
+	// cache on most CPUs
+	CacheReferences uint64 `json:"cacheReferences" column:"cacherefs,width:12,align:right"`
+	CacheMisses     uint64 `json:"cacheMisses" column:"cachemisses,width:12,align:right"`
+	// MissRatio is the percentage of the cache references that missed
+	MissRatio float64 `json:"missRatio" column:"missratio,width:9,precision:1,align:right"`
+}
+
+func GetColumns() *columns.Columns[Stats] {
+	return columns.MustCreateColumns[Stats]()
+}
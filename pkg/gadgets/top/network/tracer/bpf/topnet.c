@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+
+#include <linux/bpf.h>
+#include <linux/if_ether.h>
+#include <linux/if_packet.h>
+#include <linux/ip.h>
+#include <linux/ipv6.h>
+#include <linux/in.h>
+#include <linux/tcp.h>
+#include <linux/udp.h>
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_endian.h>
+
+#include "topnet.h"
+#include "topnetmap.h"
+
+// The loopback interface has the same index in all the network namespaces
+#define LOOPBACK_IFINDEX	1
+
+const volatile __u64 container_netns = 0;
+
+SEC("socket1")
+int ig_top_net(struct __sk_buff *skb)
+{
+	struct traffic_key key = {};
+	struct traffic_value *value;
+	__u16 sport, dport;
+	__u8 proto;
+	int l4_off;
+
+	// Skip multicast, broadcast, forwarding...
+	if (skb->pkt_type != PACKET_HOST && skb->pkt_type != PACKET_OUTGOING)
+		return 0;
+
+	// The traffic inside the network namespace doesn't use the network
+	if (skb->ifindex == LOOPBACK_IFINDEX)
+		return 0;
+
+	struct ethhdr ethh;
+	if (bpf_skb_load_bytes(skb, 0, &ethh, sizeof ethh))
+		return 0;
+
+	switch (bpf_ntohs(ethh.h_proto)) {
+	case ETH_P_IP: {
+		struct iphdr iph;
+		if (bpf_skb_load_bytes(skb, ETH_HLEN, &iph, sizeof iph))
+			return 0;
+
+		key.ipversion = 4;
+		if (skb->pkt_type == PACKET_HOST)
+			__builtin_memcpy(key.remote_addr, &iph.saddr, sizeof(iph.saddr));
+		else
+			__builtin_memcpy(key.remote_addr, &iph.daddr, sizeof(iph.daddr));
+		proto = iph.protocol;
+		// The IHL field is the size of the header in 32-bit words
+		l4_off = ETH_HLEN + iph.ihl * 4;
+		break;
+	}
+	case ETH_P_IPV6: {
+		struct ipv6hdr ip6h;
+		if (bpf_skb_load_bytes(skb, ETH_HLEN, &ip6h, sizeof ip6h))
+			return 0;
+
+		key.ipversion = 6;
+		if (skb->pkt_type == PACKET_HOST)
+			__builtin_memcpy(key.remote_addr, ip6h.saddr.in6_u.u6_addr8, sizeof(key.remote_addr));
+		else
+			__builtin_memcpy(key.remote_addr, ip6h.daddr.in6_u.u6_addr8, sizeof(key.remote_addr));
+		// Packets with extension headers are counted without ports
+		proto = ip6h.nexthdr;
+		l4_off = ETH_HLEN + sizeof(ip6h);
+		break;
+	}
+	default:
+		return 0;
+	}
+
+	key.netns = container_netns;
+	key.proto = proto;
+
+	// The source and destination ports are at the same offset in the TCP and
+	// UDP headers
+	if (proto == IPPROTO_TCP || proto == IPPROTO_UDP) {
+		struct udphdr udph;
+		if (bpf_skb_load_bytes(skb, l4_off, &udph, sizeof udph))
+			return 0;
+
+		sport = bpf_ntohs(udph.source);
+		dport = bpf_ntohs(udph.dest);
+		if (skb->pkt_type == PACKET_HOST) {
+			key.local_port = dport;
+			key.remote_port = sport;
+		} else {
+			key.local_port = sport;
+			key.remote_port = dport;
+		}
+
+		if (key.local_port < key.remote_port)
+			key.remote_port = 0;
+		else if (key.remote_port < key.local_port)
+			key.local_port = 0;
+	}
+
+	value = bpf_map_lookup_elem(&traffic, &key);
+	if (!value) {
+		struct traffic_value zero = {};
+
+		bpf_map_update_elem(&traffic, &key, &zero, BPF_NOEXIST);
+		value = bpf_map_lookup_elem(&traffic, &key);
+		if (!value)
+			return 0;
+	}
+
+	// Count the size of the IP packet
+	if (skb->pkt_type == PACKET_HOST) {
+		__sync_fetch_and_add(&value->received_bytes, skb->len - ETH_HLEN);
+		__sync_fetch_and_add(&value->received_packets, 1);
+	} else {
+		__sync_fetch_and_add(&value->sent_bytes, skb->len - ETH_HLEN);
+		__sync_fetch_and_add(&value->sent_packets, 1);
+	}
+
+	return 0;
+}
+
+char _license[] SEC("license") = "GPL";
@@ -0,0 +1,29 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+
+#ifndef GADGET_TOP_NETWORK_H
+#define GADGET_TOP_NETWORK_H
+
+#define MAX_ENTRIES	10240
+
+// The traffic is aggregated by remote endpoint and port. The ephemeral port of
+// a connection, assumed to be the highest one, is set to 0 to aggregate all
+// the connections to the same service.
+struct traffic_key {
+	__u64 netns;
+	__u8 remote_addr[16];
+	__u16 local_port;
+	__u16 remote_port;
+	__u8 proto;
+	__u8 ipversion;
+	__u8 pad[2];
+};
+
+struct traffic_value {
+	__u64 sent_bytes;
+	__u64 received_bytes;
+	__u64 sent_packets;
+	__u64 received_packets;
+};
+
+#endif
@@ -0,0 +1,21 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+
+#ifndef GADGET_TOP_NETWORK_MAP_H
+#define GADGET_TOP_NETWORK_MAP_H
+
+#include <linux/bpf.h>
+#include <bpf/bpf_helpers.h>
+
+#include "topnet.h"
+
+// Shared by the programs attached to the network namespaces of all the
+// containers
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, struct traffic_key);
+	__type(value, struct traffic_value);
+} traffic SEC(".maps");
+
+#endif
@@ -0,0 +1,136 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64 || amd64p32 || arm || arm64 || loong64 || mips64le || mips64p32le || mipsle || ppc64le || riscv64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type topnetTrafficKey struct {
+	Netns      uint64
+	RemoteAddr [16]uint8
+	LocalPort  uint16
+	RemotePort uint16
+	Proto      uint8
+	Ipversion  uint8
+	Pad        [2]uint8
+}
+
+type topnetTrafficValue struct {
+	SentBytes       uint64
+	ReceivedBytes   uint64
+	SentPackets     uint64
+	ReceivedPackets uint64
+}
+
+// loadTopnet returns the embedded CollectionSpec for topnet.
+func loadTopnet() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_TopnetBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load topnet: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadTopnetObjects loads topnet and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*topnetObjects
+//	*topnetPrograms
+//	*topnetMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadTopnetObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadTopnet()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// topnetSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type topnetSpecs struct {
+	topnetProgramSpecs
+	topnetMapSpecs
+}
+
+// topnetSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type topnetProgramSpecs struct {
+	IgTopNet *ebpf.ProgramSpec `ebpf:"ig_top_net"`
+}
+
+// topnetMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type topnetMapSpecs struct {
+	Traffic *ebpf.MapSpec `ebpf:"traffic"`
+}
+
+// topnetObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadTopnetObjects or ebpf.CollectionSpec.LoadAndAssign.
+type topnetObjects struct {
+	topnetPrograms
+	topnetMaps
+}
+
+func (o *topnetObjects) Close() error {
+	return _TopnetClose(
+		&o.topnetPrograms,
+		&o.topnetMaps,
+	)
+}
+
+// topnetMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadTopnetObjects or ebpf.CollectionSpec.LoadAndAssign.
+type topnetMaps struct {
+	Traffic *ebpf.Map `ebpf:"traffic"`
+}
+
+func (m *topnetMaps) Close() error {
+	return _TopnetClose(
+		m.Traffic,
+	)
+}
+
+// topnetPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadTopnetObjects or ebpf.CollectionSpec.LoadAndAssign.
+type topnetPrograms struct {
+	IgTopNet *ebpf.Program `ebpf:"ig_top_net"`
+}
+
+func (p *topnetPrograms) Close() error {
+	return _TopnetClose(
+		p.IgTopNet,
+	)
+}
+
+func _TopnetClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed topnet_bpfel.o
+var _TopnetBytes []byte
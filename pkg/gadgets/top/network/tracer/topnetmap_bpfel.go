@@ -0,0 +1,132 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64 || amd64p32 || arm || arm64 || loong64 || mips64le || mips64p32le || mipsle || ppc64le || riscv64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type topnetmapTrafficKey struct {
+	Netns      uint64
+	RemoteAddr [16]uint8
+	LocalPort  uint16
+	RemotePort uint16
+	Proto      uint8
+	Ipversion  uint8
+	Pad        [2]uint8
+}
+
+type topnetmapTrafficValue struct {
+	SentBytes       uint64
+	ReceivedBytes   uint64
+	SentPackets     uint64
+	ReceivedPackets uint64
+}
+
+// loadTopnetmap returns the embedded CollectionSpec for topnetmap.
+func loadTopnetmap() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_TopnetmapBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load topnetmap: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadTopnetmapObjects loads topnetmap and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*topnetmapObjects
+//	*topnetmapPrograms
+//	*topnetmapMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadTopnetmapObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadTopnetmap()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// topnetmapSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type topnetmapSpecs struct {
+	topnetmapProgramSpecs
+	topnetmapMapSpecs
+}
+
+// topnetmapSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type topnetmapProgramSpecs struct {
+}
+
+// topnetmapMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type topnetmapMapSpecs struct {
+	Traffic *ebpf.MapSpec `ebpf:"traffic"`
+}
+
+// topnetmapObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadTopnetmapObjects or ebpf.CollectionSpec.LoadAndAssign.
+type topnetmapObjects struct {
+	topnetmapPrograms
+	topnetmapMaps
+}
+
+func (o *topnetmapObjects) Close() error {
+	return _TopnetmapClose(
+		&o.topnetmapPrograms,
+		&o.topnetmapMaps,
+	)
+}
+
+// topnetmapMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadTopnetmapObjects or ebpf.CollectionSpec.LoadAndAssign.
+type topnetmapMaps struct {
+	Traffic *ebpf.Map `ebpf:"traffic"`
+}
+
+func (m *topnetmapMaps) Close() error {
+	return _TopnetmapClose(
+		m.Traffic,
+	)
+}
+
+// topnetmapPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadTopnetmapObjects or ebpf.CollectionSpec.LoadAndAssign.
+type topnetmapPrograms struct {
+}
+
+func (p *topnetmapPrograms) Close() error {
+	return _TopnetmapClose()
+}
+
+func _TopnetmapClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed topnetmap_bpfel.o
+var _TopnetmapBytes []byte
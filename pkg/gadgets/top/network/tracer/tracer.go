@@ -0,0 +1,328 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"golang.org/x/sys/unix"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/network/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/rawsock"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate bash -c "source ./clangosflags.sh; go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -cc clang topnetmap ./bpf/topnetmap.c -- $CLANG_OS_FLAGS -I./bpf/"
+
+//go:generate bash -c "source ./clangosflags.sh; go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -cc clang topnet ./bpf/topnet.c -- $CLANG_OS_FLAGS -I./bpf/"
+
+const (
+	BPFSocketAttach = 50
+)
+
+type Config struct {
+	MaxRows    int
+	Interval   time.Duration
+	Iterations int
+	SortBy     []string
+}
+
+type attachment struct {
+	objs   topnetObjects
+	sockFd int
+
+	// users keeps track of the users' pid that have called Attach(): the
+	// containers of a pod share the network namespace, so the program is
+	// only attached once.
+	users map[uint32]struct{}
+}
+
+type Tracer struct {
+	config *Config
+	// mapObjs contains the map shared by the per-netns programs
+	mapObjs topnetmapObjects
+
+	mu sync.Mutex
+	// key: network namespace inode number
+	attachments map[uint64]*attachment
+
+	eventCallback func(*top.Event[types.Stats])
+	colMap        columns.ColumnMap[types.Stats]
+}
+
+func (t *Tracer) Attach(pid uint32) (err error) {
+	netns, err := containerutils.GetNetNs(int(pid))
+	if err != nil {
+		return fmt.Errorf("getting network namespace of pid %d: %w", pid, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if a, ok := t.attachments[netns]; ok {
+		a.users[pid] = struct{}{}
+		return nil
+	}
+
+	a := &attachment{
+		users:  map[uint32]struct{}{pid: {}},
+		sockFd: -1,
+	}
+	defer func() {
+		if err != nil {
+			// bpf2go objects can safely be closed even when not initialized
+			a.objs.Close()
+			if a.sockFd != -1 {
+				unix.Close(a.sockFd)
+			}
+		}
+	}()
+
+	spec, err := loadTopnet()
+	if err != nil {
+		return fmt.Errorf("loading asset: %w", err)
+	}
+
+	consts := map[string]interface{}{
+		"container_netns": netns,
+	}
+	if err := spec.RewriteConstants(consts); err != nil {
+		return fmt.Errorf("rewriting constants: %w", err)
+	}
+
+	opts := &ebpf.CollectionOptions{
+		MapReplacements: map[string]*ebpf.Map{
+			"traffic": t.mapObjs.Traffic,
+		},
+	}
+	if err := spec.LoadAndAssign(&a.objs, opts); err != nil {
+		return fmt.Errorf("creating BPF collection: %w", err)
+	}
+
+	if a.sockFd, err = rawsock.OpenRawSock(pid); err != nil {
+		return fmt.Errorf("opening raw socket: %w", err)
+	}
+
+	if err := syscall.SetsockoptInt(a.sockFd, syscall.SOL_SOCKET, BPFSocketAttach, a.objs.IgTopNet.FD()); err != nil {
+		return fmt.Errorf("attaching BPF program: %w", err)
+	}
+
+	t.attachments[netns] = a
+
+	return nil
+}
+
+func (t *Tracer) releaseAttachment(netns uint64, a *attachment) {
+	unix.Close(a.sockFd)
+	a.objs.Close()
+	delete(t.attachments, netns)
+}
+
+func (t *Tracer) Detach(pid uint32) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for netns, a := range t.attachments {
+		if _, ok := a.users[pid]; ok {
+			delete(a.users, pid)
+			if len(a.users) == 0 {
+				t.releaseAttachment(netns, a)
+			}
+			return nil
+		}
+	}
+
+	return fmt.Errorf("pid %d is not attached", pid)
+}
+
+func protoString(proto uint8) string {
+	switch proto {
+	case syscall.IPPROTO_TCP:
+		return "tcp"
+	case syscall.IPPROTO_UDP:
+		return "udp"
+	case syscall.IPPROTO_ICMP:
+		return "icmp"
+	case syscall.IPPROTO_ICMPV6:
+		return "icmp6"
+	default:
+		return fmt.Sprint(proto)
+	}
+}
+
+// NextStats returns the traffic counted since the previous call, unsorted, and
+// resets the counters
+func (t *Tracer) NextStats() ([]*types.Stats, error) {
+	stats := []*types.Stats{}
+
+	var keys []topnetmapTrafficKey
+	var key topnetmapTrafficKey
+	var value topnetmapTrafficValue
+	entries := t.mapObjs.Traffic.Iterate()
+	for entries.Next(&key, &value) {
+		keys = append(keys, key)
+
+		stat := types.Stats{
+			WithNetNsID:     eventtypes.WithNetNsID{NetNsID: key.Netns},
+			IPVersion:       key.Ipversion,
+			Proto:           protoString(key.Proto),
+			LocalPort:       key.LocalPort,
+			RemoteAddr:      gadgets.IPStringFromBytes(key.RemoteAddr, int(key.Ipversion)),
+			RemotePort:      key.RemotePort,
+			Sent:            value.SentBytes,
+			Received:        value.ReceivedBytes,
+			SentPackets:     value.SentPackets,
+			ReceivedPackets: value.ReceivedPackets,
+			Throughput:      uint64(float64(value.SentBytes+value.ReceivedBytes) / t.config.Interval.Seconds()),
+		}
+
+		stats = append(stats, &stat)
+	}
+	if err := entries.Err(); err != nil {
+		return nil, fmt.Errorf("iterating traffic: %w", err)
+	}
+
+	for _, key := range keys {
+		if err := t.mapObjs.Traffic.Delete(key); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+			return nil, fmt.Errorf("deleting traffic: %w", err)
+		}
+	}
+
+	return stats, nil
+}
+
+func (t *Tracer) run(ctx context.Context) error {
+	// Don't use a context with a timeout but a counter to avoid having to deal
+	// with two timers: one for the timeout and another for the ticker.
+	count := t.config.Iterations
+	ticker := time.NewTicker(t.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			stats, err := t.NextStats()
+			if err != nil {
+				return fmt.Errorf("getting next stats: %w", err)
+			}
+			top.SortStats(stats, t.config.SortBy, &t.colMap)
+
+			n := len(stats)
+			if n > t.config.MaxRows {
+				n = t.config.MaxRows
+			}
+			t.eventCallback(&top.Event[types.Stats]{Stats: stats[:n]})
+
+			// Count down only if user requested a finite number of iterations
+			// through a timeout.
+			if t.config.Iterations > 0 {
+				count--
+				if count == 0 {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// --- Registry changes
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config:      &Config{},
+		attachments: make(map[uint64]*attachment),
+	}
+	return tracer, nil
+}
+
+func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.MaxRows = params.Get(gadgets.ParamMaxRows).AsInt()
+	t.config.SortBy = params.Get(gadgets.ParamSortBy).AsStringSlice()
+	t.config.Interval = time.Second * time.Duration(params.Get(gadgets.ParamInterval).AsInt())
+
+	var err error
+	if t.config.Iterations, err = top.ComputeIterations(t.config.Interval, gadgetCtx.Timeout()); err != nil {
+		return err
+	}
+
+	statCols, err := columns.NewColumns[types.Stats]()
+	if err != nil {
+		return err
+	}
+	t.colMap = statCols.GetColumnMap()
+
+	// The map has to exist before the containers are attached
+	spec, err := loadTopnetmap()
+	if err != nil {
+		return fmt.Errorf("loading asset: %w", err)
+	}
+	if err := spec.LoadAndAssign(&t.mapObjs, nil); err != nil {
+		return fmt.Errorf("loading ebpf map: %w", err)
+	}
+
+	return nil
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	return t.run(gadgetCtx.Context())
+}
+
+func (t *Tracer) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for netns, a := range t.attachments {
+		t.releaseAttachment(netns, a)
+	}
+	t.mapObjs.Close()
+}
+
+func (t *Tracer) SetEventHandlerArray(handler any) {
+	nh, ok := handler.(func(ev []*types.Stats))
+	if !ok {
+		panic("event handler invalid")
+	}
+
+	t.eventCallback = func(ev *top.Event[types.Stats]) {
+		if ev.Error != "" {
+			return
+		}
+		nh(ev.Stats)
+	}
+}
+
+func (t *Tracer) AttachContainer(container *containercollection.Container) error {
+	return t.Attach(container.Pid)
+}
+
+func (t *Tracer) DetachContainer(container *containercollection.Container) error {
+	return t.Detach(container.Pid)
+}
@@ -0,0 +1,90 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/docker/go-units"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+var SortByDefault = []string{"-throughput", "-sentpkts", "-recvpkts"}
+
+// Stats represents the traffic of a container with a remote endpoint. The
+// ephemeral port of the connections, either the local or the remote one, is
+// 0: the traffic of all the connections to the same service is aggregated.
+type Stats struct {
+	eventtypes.CommonData
+	eventtypes.WithNetNsID
+
+	IPVersion  uint8  `json:"ipversion,omitempty" column:"ip,template:ipversion"`
+	Proto      string `json:"proto,omitempty" column:"proto,maxWidth:5"`
+	LocalPort  uint16 `json:"localPort" column:"lport,template:ipport"`
+	RemoteAddr string `json:"remoteAddr,omitempty" column:"remoteaddr,template:ipaddr,hide"`
+	RemotePort uint16 `json:"remotePort" column:"rport,template:ipport,hide"`
+
+	Sent            uint64 `json:"sent" column:"sent,order:1002"`
+	Received        uint64 `json:"received" column:"recv,order:1003"`
+	SentPackets     uint64 `json:"sentPackets" column:"sentpkts,order:1004"`
+	ReceivedPackets uint64 `json:"receivedPackets" column:"recvpkts,order:1005"`
+	// Throughput is the number of bytes sent and received per second during
+	// the interval
+	Throughput uint64 `json:"throughput" column:"throughput,order:1006"`
+}
+
+func port(p uint16) string {
+	if p == 0 {
+		return "*"
+	}
+	return fmt.Sprint(p)
+}
+
+func GetColumns() *columns.Columns[Stats] {
+	cols := columns.MustCreateColumns[Stats]()
+
+	cols.MustSetExtractor("lport", func(stats *Stats) (ret string) {
+		return port(stats.LocalPort)
+	})
+	cols.MustSetExtractor("rport", func(stats *Stats) (ret string) {
+		return port(stats.RemotePort)
+	})
+	cols.MustSetExtractor("sent", func(stats *Stats) (ret string) {
+		return fmt.Sprint(units.BytesSize(float64(stats.Sent)))
+	})
+	cols.MustSetExtractor("recv", func(stats *Stats) (ret string) {
+		return fmt.Sprint(units.BytesSize(float64(stats.Received)))
+	})
+	cols.MustSetExtractor("throughput", func(stats *Stats) (ret string) {
+		return fmt.Sprintf("%s/s", units.BytesSize(float64(stats.Throughput)))
+	})
+
+	cols.MustAddColumn(columns.Attributes{
+		Name:     "remote",
+		MinWidth: 21, // 15(ipv4) + 1(:) + 5(port)
+		MaxWidth: 51, // 45(ipv4 mapped ipv6) + 1(:) + 5(port)
+		Visible:  true,
+		Order:    1000,
+	}, func(s *Stats) string {
+		if s.IPVersion == 6 {
+			return fmt.Sprintf("[%s]:%s", s.RemoteAddr, port(s.RemotePort))
+		}
+		return fmt.Sprintf("%s:%s", s.RemoteAddr, port(s.RemotePort))
+	})
+
+	return cols
+}
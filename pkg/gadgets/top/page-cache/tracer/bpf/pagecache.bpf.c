@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: GPL-2.0
+// Copyright (c) 2023 The Inspektor Gadget authors
+//
+// Based on cachestat(8) from BCC by Brendan Gregg and Allan McAleavy.
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "pagecache.h"
+#include "stat.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+/* Low bits of page->mapping that flag anonymous and movable pages */
+#define PAGE_MAPPING_FLAGS	0x3
+
+static struct file_stats zero_value = {};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, struct file_key);
+	__type(value, struct file_stats);
+} stats SEC(".maps");
+
+static __always_inline void get_filename(struct inode *inode, __u8 *buf,
+					 size_t size)
+{
+	struct hlist_node *first;
+	struct dentry *dentry;
+	struct qstr dname;
+
+	first = BPF_CORE_READ(inode, i_dentry.first);
+	if (!first)
+		return;
+
+	dentry = container_of(first, struct dentry, d_u.d_alias);
+	dname = BPF_CORE_READ(dentry, d_name);
+	bpf_probe_read_kernel_str(buf, size, dname.name);
+}
+
+static __always_inline int update_stats(struct address_space *mapping,
+					enum pagecache_op op)
+{
+	struct file_key key = {};
+	struct file_stats *statsp;
+	struct inode *inode;
+	__u64 mntns_id;
+	int mode;
+
+	if (!mapping || ((__u64)mapping & PAGE_MAPPING_FLAGS))
+		return 0;
+
+	mntns_id = gadget_get_mntns_id();
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return 0;
+
+	inode = BPF_CORE_READ(mapping, host);
+	if (!inode)
+		return 0;
+
+	/* Skip the caches of block devices and of the kernel internals */
+	mode = BPF_CORE_READ(inode, i_mode);
+	if (!S_ISREG(mode))
+		return 0;
+
+	key.mntns_id = mntns_id;
+	key.ino = BPF_CORE_READ(inode, i_ino);
+	key.dev = BPF_CORE_READ(inode, i_sb, s_dev);
+
+	statsp = bpf_map_lookup_elem(&stats, &key);
+	if (!statsp) {
+		bpf_map_update_elem(&stats, &key, &zero_value, BPF_NOEXIST);
+		statsp = bpf_map_lookup_elem(&stats, &key);
+		if (!statsp)
+			return 0;
+		get_filename(inode, statsp->filename, sizeof(statsp->filename));
+	}
+
+	switch (op) {
+	case PAGECACHE_ACCESS:
+		__sync_fetch_and_add(&statsp->accesses, 1);
+		break;
+	case PAGECACHE_ADD:
+		__sync_fetch_and_add(&statsp->additions, 1);
+		break;
+	case PAGECACHE_DIRTY:
+		__sync_fetch_and_add(&statsp->dirtied, 1);
+		break;
+	case PAGECACHE_FAULT:
+		__sync_fetch_and_add(&statsp->faults, 1);
+		break;
+	}
+	statsp->nrpages = BPF_CORE_READ(mapping, nrpages);
+
+	return 0;
+}
+
+/*
+ * struct folio overlays struct page, so the mapping of both can be read
+ * through a struct page.
+ */
+
+/* folio_mark_accessed(struct folio *) or mark_page_accessed(struct page *) */
+SEC("kprobe/folio_mark_accessed")
+int BPF_KPROBE(ig_pc_access, struct page *page)
+{
+	return update_stats(BPF_CORE_READ(page, mapping), PAGECACHE_ACCESS);
+}
+
+SEC("kprobe/filemap_add_folio")
+int BPF_KPROBE(ig_pc_add_folio, struct address_space *mapping)
+{
+	return update_stats(mapping, PAGECACHE_ADD);
+}
+
+/* Before Linux 5.18 */
+SEC("kprobe/add_to_page_cache_lru")
+int BPF_KPROBE(ig_pc_add_page, struct page *page, struct address_space *mapping)
+{
+	return update_stats(mapping, PAGECACHE_ADD);
+}
+
+/* writeback_dirty_folio or writeback_dirty_page tracepoints */
+SEC("raw_tp/writeback_dirty_folio")
+int BPF_PROG(ig_pc_dirty, struct page *page, struct address_space *mapping)
+{
+	return update_stats(mapping, PAGECACHE_DIRTY);
+}
+
+SEC("kprobe/filemap_fault")
+int BPF_KPROBE(ig_pc_fault, struct vm_fault *vmf)
+{
+	return update_stats(BPF_CORE_READ(vmf, vma, vm_file, f_mapping),
+			    PAGECACHE_FAULT);
+}
+
+char LICENSE[] SEC("license") = "GPL";
@@ -0,0 +1,35 @@
+/* SPDX-License-Identifier: (LGPL-2.1 OR BSD-2-Clause) */
+#ifndef __PAGECACHE_H
+#define __PAGECACHE_H
+
+#define NAME_MAX	255
+
+enum pagecache_op {
+	PAGECACHE_ACCESS,
+	PAGECACHE_ADD,
+	PAGECACHE_DIRTY,
+	PAGECACHE_FAULT,
+};
+
+struct file_key {
+	__u64 mntns_id;
+	__u64 ino;
+	__u32 dev;
+	__u32 pad;
+};
+
+struct file_stats {
+	/* Pages of the cache accessed by the read and write paths */
+	__u64 accesses;
+	/* Pages added to the cache, i.e. misses or new data written */
+	__u64 additions;
+	/* Pages dirtied, i.e. written */
+	__u64 dirtied;
+	/* Page faults on memory-mapped pages of the file */
+	__u64 faults;
+	/* Pages of the file in the cache when it was last accessed */
+	__u64 nrpages;
+	__u8 filename[NAME_MAX + 1];
+};
+
+#endif /* __PAGECACHE_H */
@@ -0,0 +1,150 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type pagecacheFileKey struct {
+	MntnsId uint64
+	Ino     uint64
+	Dev     uint32
+	Pad     uint32
+}
+
+type pagecacheFileStats struct {
+	Accesses  uint64
+	Additions uint64
+	Dirtied   uint64
+	Faults    uint64
+	Nrpages   uint64
+	Filename  [256]uint8
+}
+
+// loadPagecache returns the embedded CollectionSpec for pagecache.
+func loadPagecache() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_PagecacheBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load pagecache: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadPagecacheObjects loads pagecache and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*pagecacheObjects
+//	*pagecachePrograms
+//	*pagecacheMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadPagecacheObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadPagecache()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// pagecacheSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type pagecacheSpecs struct {
+	pagecacheProgramSpecs
+	pagecacheMapSpecs
+}
+
+// pagecacheSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type pagecacheProgramSpecs struct {
+	IgPcAccess   *ebpf.ProgramSpec `ebpf:"ig_pc_access"`
+	IgPcAddFolio *ebpf.ProgramSpec `ebpf:"ig_pc_add_folio"`
+	IgPcAddPage  *ebpf.ProgramSpec `ebpf:"ig_pc_add_page"`
+	IgPcDirty    *ebpf.ProgramSpec `ebpf:"ig_pc_dirty"`
+	IgPcFault    *ebpf.ProgramSpec `ebpf:"ig_pc_fault"`
+}
+
+// pagecacheMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type pagecacheMapSpecs struct {
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Stats                *ebpf.MapSpec `ebpf:"stats"`
+}
+
+// pagecacheObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadPagecacheObjects or ebpf.CollectionSpec.LoadAndAssign.
+type pagecacheObjects struct {
+	pagecachePrograms
+	pagecacheMaps
+}
+
+func (o *pagecacheObjects) Close() error {
+	return _PagecacheClose(
+		&o.pagecachePrograms,
+		&o.pagecacheMaps,
+	)
+}
+
+// pagecacheMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadPagecacheObjects or ebpf.CollectionSpec.LoadAndAssign.
+type pagecacheMaps struct {
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Stats                *ebpf.Map `ebpf:"stats"`
+}
+
+func (m *pagecacheMaps) Close() error {
+	return _PagecacheClose(
+		m.GadgetMntnsFilterMap,
+		m.Stats,
+	)
+}
+
+// pagecachePrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadPagecacheObjects or ebpf.CollectionSpec.LoadAndAssign.
+type pagecachePrograms struct {
+	IgPcAccess   *ebpf.Program `ebpf:"ig_pc_access"`
+	IgPcAddFolio *ebpf.Program `ebpf:"ig_pc_add_folio"`
+	IgPcAddPage  *ebpf.Program `ebpf:"ig_pc_add_page"`
+	IgPcDirty    *ebpf.Program `ebpf:"ig_pc_dirty"`
+	IgPcFault    *ebpf.Program `ebpf:"ig_pc_fault"`
+}
+
+func (p *pagecachePrograms) Close() error {
+	return _PagecacheClose(
+		p.IgPcAccess,
+		p.IgPcAddFolio,
+		p.IgPcAddPage,
+		p.IgPcDirty,
+		p.IgPcFault,
+	)
+}
+
+func _PagecacheClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed pagecache_bpfel_x86.o
+var _PagecacheBytes []byte
@@ -0,0 +1,296 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/page-cache/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -type file_key -type file_stats -cc clang pagecache ./bpf/pagecache.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
+
+type Config struct {
+	MountnsMap *ebpf.Map
+	MaxRows    int
+	Interval   time.Duration
+	Iterations int
+	SortBy     []string
+	// PerContainer aggregates the statistics of all the files of each
+	// container
+	PerContainer bool
+}
+
+type Tracer struct {
+	config        *Config
+	objs          pagecacheObjects
+	links         []link.Link
+	enricher      gadgets.DataEnricherByMntNs
+	eventCallback func(*top.Event[types.Stats])
+	colMap        columns.ColumnMap[types.Stats]
+}
+
+func (t *Tracer) close() {
+	for i, l := range t.links {
+		t.links[i] = gadgets.CloseLink(l)
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadPagecache()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	type probe struct {
+		symbol string
+		prog   *ebpf.Program
+	}
+
+	// The functions were converted to folios over time, the first one found
+	// in each list is used
+	kprobes := [][]probe{
+		{{"folio_mark_accessed", t.objs.IgPcAccess}, {"mark_page_accessed", t.objs.IgPcAccess}},
+		{{"filemap_add_folio", t.objs.IgPcAddFolio}, {"add_to_page_cache_lru", t.objs.IgPcAddPage}},
+		{{"filemap_fault", t.objs.IgPcFault}},
+	}
+
+	for _, candidates := range kprobes {
+		var l link.Link
+		for _, kp := range candidates {
+			l, err = link.Kprobe(kp.symbol, kp.prog, nil)
+			if err == nil || !errors.Is(err, os.ErrNotExist) {
+				break
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("attaching kprobe %s: %w", candidates[0].symbol, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	// Both tracepoints have the page or folio and the mapping as arguments
+	for _, name := range []string{"writeback_dirty_folio", "writeback_dirty_page"} {
+		var l link.Link
+		l, err = link.AttachRawTracepoint(link.RawTracepointOptions{Name: name, Program: t.objs.IgPcDirty})
+		if err == nil {
+			t.links = append(t.links, l)
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("attaching tracepoint writeback_dirty_folio: %w", err)
+	}
+
+	return nil
+}
+
+func (t *Tracer) nextStats() ([]*types.Stats, error) {
+	pageSize := uint64(os.Getpagesize())
+
+	// With PerContainer, the files of each container are aggregated under a
+	// key with only the mount namespace set
+	aggregated := make(map[pagecacheFileKey]*pagecacheFileStats)
+	var keys []pagecacheFileKey
+	var key pagecacheFileKey
+	var fileStats pagecacheFileStats
+	entries := t.objs.Stats.Iterate()
+	for entries.Next(&key, &fileStats) {
+		keys = append(keys, key)
+
+		aggKey := key
+		if t.config.PerContainer {
+			aggKey = pagecacheFileKey{MntnsId: key.MntnsId}
+		}
+		agg, ok := aggregated[aggKey]
+		if !ok {
+			agg = &pagecacheFileStats{Filename: fileStats.Filename}
+			aggregated[aggKey] = agg
+		}
+		agg.Accesses += fileStats.Accesses
+		agg.Additions += fileStats.Additions
+		agg.Dirtied += fileStats.Dirtied
+		agg.Faults += fileStats.Faults
+		agg.Nrpages += fileStats.Nrpages
+	}
+	if err := entries.Err(); err != nil {
+		return nil, fmt.Errorf("iterating stats: %w", err)
+	}
+
+	for _, key := range keys {
+		if err := t.objs.Stats.Delete(key); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+			return nil, fmt.Errorf("deleting stats: %w", err)
+		}
+	}
+
+	stats := make([]*types.Stats, 0, len(aggregated))
+	for key, fileStats := range aggregated {
+		// Same computation as cachestat: the writes also access and add
+		// pages, they are subtracted using the dirtied pages. The pages added
+		// to the cache are misses, the other accesses are hits.
+		var total, misses, hits uint64
+		if fileStats.Accesses > fileStats.Dirtied {
+			total = fileStats.Accesses - fileStats.Dirtied
+		}
+		if fileStats.Additions > fileStats.Dirtied {
+			misses = fileStats.Additions - fileStats.Dirtied
+		}
+		if total > misses {
+			hits = total - misses
+		}
+
+		stat := types.Stats{
+			Hits:          hits,
+			Misses:        misses,
+			Dirtied:       fileStats.Dirtied,
+			Faults:        fileStats.Faults,
+			Cached:        fileStats.Nrpages * pageSize,
+			WithMountNsID: eventtypes.WithMountNsID{MountNsID: key.MntnsId},
+		}
+		if !t.config.PerContainer {
+			stat.Inode = key.Ino
+			stat.Filename = gadgets.FromCString(fileStats.Filename[:])
+		}
+		if hits+misses > 0 {
+			stat.HitRatio = 100 * float64(hits) / float64(hits+misses)
+		}
+		if fileStats.Accesses > 0 {
+			stat.DirtyRatio = 100 * float64(fileStats.Dirtied) / float64(fileStats.Accesses)
+		}
+
+		if t.enricher != nil {
+			t.enricher.EnrichByMntNs(&stat.CommonData, stat.MountNsID)
+		}
+
+		stats = append(stats, &stat)
+	}
+
+	top.SortStats(stats, t.config.SortBy, &t.colMap)
+
+	return stats, nil
+}
+
+func (t *Tracer) run(ctx context.Context) error {
+	// Don't use a context with a timeout but a counter to avoid having to deal
+	// with two timers: one for the timeout and another for the ticker.
+	count := t.config.Iterations
+	ticker := time.NewTicker(t.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			stats, err := t.nextStats()
+			if err != nil {
+				return fmt.Errorf("getting next stats: %w", err)
+			}
+
+			n := len(stats)
+			if n > t.config.MaxRows {
+				n = t.config.MaxRows
+			}
+			t.eventCallback(&top.Event[types.Stats]{Stats: stats[:n]})
+
+			// Count down only if user requested a finite number of iterations
+			// through a timeout.
+			if t.config.Iterations > 0 {
+				count--
+				if count == 0 {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	if err := t.init(gadgetCtx); err != nil {
+		return fmt.Errorf("initializing tracer: %w", err)
+	}
+
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	return t.run(gadgetCtx.Context())
+}
+
+func (t *Tracer) SetEventHandlerArray(handler any) {
+	nh, ok := handler.(func(ev []*types.Stats))
+	if !ok {
+		panic("event handler invalid")
+	}
+
+	t.eventCallback = func(ev *top.Event[types.Stats]) {
+		if ev.Error != "" {
+			return
+		}
+		nh(ev.Stats)
+	}
+}
+
+func (t *Tracer) SetMountNsMap(mntnsMap *ebpf.Map) {
+	t.config.MountnsMap = mntnsMap
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+	}
+	return tracer, nil
+}
+
+func (t *Tracer) init(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.MaxRows = params.Get(gadgets.ParamMaxRows).AsInt()
+	t.config.SortBy = params.Get(gadgets.ParamSortBy).AsStringSlice()
+	t.config.Interval = time.Second * time.Duration(params.Get(gadgets.ParamInterval).AsInt())
+	t.config.PerContainer = params.Get(ParamPerContainer).AsBool()
+
+	var err error
+	if t.config.Iterations, err = top.ComputeIterations(t.config.Interval, gadgetCtx.Timeout()); err != nil {
+		return err
+	}
+
+	statCols, err := columns.NewColumns[types.Stats]()
+	if err != nil {
+		return err
+	}
+	t.colMap = statCols.GetColumnMap()
+
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/docker/go-units"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+var SortByDefault = []string{"-cached", "-misses", "-hits"}
+
+// Stats represents the page cache activity of a container on a single file,
+// or on all its files when they are aggregated per container
+type Stats struct {
+	eventtypes.CommonData
+	eventtypes.WithMountNsID
+
+	Hits   uint64 `json:"hits" column:"hits"`
+	Misses uint64 `json:"misses" column:"misses"`
+	// HitRatio is the percentage of the accesses found in the cache
+	HitRatio float64 `json:"hitRatio" column:"ratio,precision:1"`
+	Dirtied  uint64  `json:"dirtied" column:"dirtied"`
+	// DirtyRatio is the percentage of the accesses that were writes
+	DirtyRatio float64 `json:"dirtyRatio" column:"dirtyratio,width:10,precision:1"`
+	Faults     uint64  `json:"faults" column:"faults,hide"`
+	// Cached is the size of the pages of the file in the cache, from all the
+	// containers, when the file was last accessed
+	Cached   uint64 `json:"cached" column:"cached"`
+	Inode    uint64 `json:"inode" column:"inode,hide"`
+	Filename string `json:"filename,omitempty" column:"file"`
+}
+
+func GetColumns() *columns.Columns[Stats] {
+	cols := columns.MustCreateColumns[Stats]()
+
+	cols.MustSetExtractor("cached", func(stats *Stats) (ret string) {
+		return fmt.Sprint(units.BytesSize(float64(stats.Cached)))
+	})
+
+	return cols
+}
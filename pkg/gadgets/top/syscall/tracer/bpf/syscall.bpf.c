@@ -0,0 +1,96 @@
+// SPDX-License-Identifier: GPL-2.0
+// Copyright (c) 2023 The Inspektor Gadget authors
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "syscall.h"
+#include "bits.bpf.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+
+struct start_t {
+	__u64 ts;
+	__u64 mntns_id;
+	__u32 nr;
+};
+
+/* Syscalls in progress, indexed by thread id */
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__type(value, struct start_t);
+} start SEC(".maps");
+
+static struct syscall_stats zero_value = {};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, struct syscall_key);
+	__type(value, struct syscall_stats);
+} stats SEC(".maps");
+
+SEC("raw_tp/sys_enter")
+int BPF_PROG(ig_sys_enter, struct pt_regs *regs, long id)
+{
+	struct start_t data = {};
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+
+	data.mntns_id = gadget_get_mntns_id();
+	if (gadget_should_discard_mntns_id(data.mntns_id))
+		return 0;
+
+	data.nr = id;
+	data.ts = bpf_ktime_get_ns();
+	bpf_map_update_elem(&start, &tid, &data, BPF_ANY);
+	return 0;
+}
+
+SEC("raw_tp/sys_exit")
+int BPF_PROG(ig_sys_exit, struct pt_regs *regs, long ret)
+{
+	struct syscall_key key = {};
+	struct syscall_stats *statsp;
+	struct start_t *startp;
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	__u64 slot;
+	__s64 delta;
+
+	startp = bpf_map_lookup_elem(&start, &tid);
+	if (!startp)
+		return 0;
+
+	delta = (__s64)(bpf_ktime_get_ns() - startp->ts);
+	if (delta < 0)
+		goto cleanup;
+
+	key.mntns_id = startp->mntns_id;
+	key.nr = startp->nr;
+
+	statsp = bpf_map_lookup_elem(&stats, &key);
+	if (!statsp) {
+		bpf_map_update_elem(&stats, &key, &zero_value, BPF_NOEXIST);
+		statsp = bpf_map_lookup_elem(&stats, &key);
+		if (!statsp)
+			goto cleanup;
+	}
+
+	slot = log2l(delta);
+	if (slot >= MAX_SLOTS)
+		slot = MAX_SLOTS - 1;
+	__sync_fetch_and_add(&statsp->slots[slot], 1);
+	__sync_fetch_and_add(&statsp->total, delta);
+	__sync_fetch_and_add(&statsp->count, 1);
+	/* Not atomic, a concurrent longer syscall may be missed */
+	if (delta > statsp->max)
+		statsp->max = delta;
+
+cleanup:
+	bpf_map_delete_elem(&start, &tid);
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
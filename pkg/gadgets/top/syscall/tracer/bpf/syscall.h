@@ -0,0 +1,23 @@
+/* SPDX-License-Identifier: (LGPL-2.1 OR BSD-2-Clause) */
+#ifndef __SYSCALL_H
+#define __SYSCALL_H
+
+/* Latencies up to 2^36ns, about a minute */
+#define MAX_SLOTS	36
+
+struct syscall_key {
+	__u64 mntns_id;
+	__u32 nr;
+	__u32 pad;
+};
+
+struct syscall_stats {
+	__u64 count;
+	/* Latencies in nanoseconds */
+	__u64 total;
+	__u64 max;
+	/* Log2 histogram of the latencies, used to compute the percentiles */
+	__u32 slots[MAX_SLOTS];
+};
+
+#endif /* __SYSCALL_H */
@@ -0,0 +1,148 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type syscallStartT struct {
+	Ts      uint64
+	MntnsId uint64
+	Nr      uint32
+	_       [4]byte
+}
+
+type syscallSyscallKey struct {
+	MntnsId uint64
+	Nr      uint32
+	Pad     uint32
+}
+
+type syscallSyscallStats struct {
+	Count uint64
+	Total uint64
+	Max   uint64
+	Slots [36]uint32
+}
+
+// loadSyscall returns the embedded CollectionSpec for syscall.
+func loadSyscall() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_SyscallBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load syscall: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadSyscallObjects loads syscall and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*syscallObjects
+//	*syscallPrograms
+//	*syscallMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadSyscallObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadSyscall()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// syscallSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type syscallSpecs struct {
+	syscallProgramSpecs
+	syscallMapSpecs
+}
+
+// syscallSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type syscallProgramSpecs struct {
+	IgSysEnter *ebpf.ProgramSpec `ebpf:"ig_sys_enter"`
+	IgSysExit  *ebpf.ProgramSpec `ebpf:"ig_sys_exit"`
+}
+
+// syscallMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type syscallMapSpecs struct {
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Start                *ebpf.MapSpec `ebpf:"start"`
+	Stats                *ebpf.MapSpec `ebpf:"stats"`
+}
+
+// syscallObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadSyscallObjects or ebpf.CollectionSpec.LoadAndAssign.
+type syscallObjects struct {
+	syscallPrograms
+	syscallMaps
+}
+
+func (o *syscallObjects) Close() error {
+	return _SyscallClose(
+		&o.syscallPrograms,
+		&o.syscallMaps,
+	)
+}
+
+// syscallMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadSyscallObjects or ebpf.CollectionSpec.LoadAndAssign.
+type syscallMaps struct {
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Start                *ebpf.Map `ebpf:"start"`
+	Stats                *ebpf.Map `ebpf:"stats"`
+}
+
+func (m *syscallMaps) Close() error {
+	return _SyscallClose(
+		m.GadgetMntnsFilterMap,
+		m.Start,
+		m.Stats,
+	)
+}
+
+// syscallPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadSyscallObjects or ebpf.CollectionSpec.LoadAndAssign.
+type syscallPrograms struct {
+	IgSysEnter *ebpf.Program `ebpf:"ig_sys_enter"`
+	IgSysExit  *ebpf.Program `ebpf:"ig_sys_exit"`
+}
+
+func (p *syscallPrograms) Close() error {
+	return _SyscallClose(
+		p.IgSysEnter,
+		p.IgSysExit,
+	)
+}
+
+func _SyscallClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed syscall_bpfel_x86.o
+var _SyscallBytes []byte
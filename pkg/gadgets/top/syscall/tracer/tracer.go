@@ -0,0 +1,267 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	libseccomp "github.com/seccomp/libseccomp-golang"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/syscall/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -type syscall_key -type syscall_stats -cc clang syscall ./bpf/syscall.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
+
+type Config struct {
+	MountnsMap *ebpf.Map
+	MaxRows    int
+	Interval   time.Duration
+	Iterations int
+	SortBy     []string
+}
+
+type Tracer struct {
+	config        *Config
+	objs          syscallObjects
+	links         []link.Link
+	enricher      gadgets.DataEnricherByMntNs
+	eventCallback func(*top.Event[types.Stats])
+	colMap        columns.ColumnMap[types.Stats]
+}
+
+func (t *Tracer) close() {
+	for i, l := range t.links {
+		t.links[i] = gadgets.CloseLink(l)
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadSyscall()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	tracepoints := []struct {
+		name string
+		prog *ebpf.Program
+	}{
+		{"sys_enter", t.objs.IgSysEnter},
+		{"sys_exit", t.objs.IgSysExit},
+	}
+
+	for _, tp := range tracepoints {
+		l, err := link.AttachRawTracepoint(link.RawTracepointOptions{Name: tp.name, Program: tp.prog})
+		if err != nil {
+			return fmt.Errorf("attaching tracepoint %s: %w", tp.name, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	return nil
+}
+
+func syscallName(nr uint32) string {
+	name, err := libseccomp.ScmpSyscall(nr).GetName()
+	if err != nil {
+		return fmt.Sprintf("syscall_%d", nr)
+	}
+	return name
+}
+
+// percentile returns the latency below which p percent of the calls fall,
+// interpolated linearly inside the slot of the log2 histogram it falls in
+func percentile(slots []uint32, count uint64, p float64) uint64 {
+	if count == 0 {
+		return 0
+	}
+
+	rank := p / 100 * float64(count)
+	var cumul float64
+	for i, n := range slots {
+		if n == 0 {
+			continue
+		}
+		if cumul+float64(n) >= rank {
+			// Slot i holds the latencies in [2^i, 2^(i+1)), except the first
+			// one that also holds 0
+			low := float64(uint64(1) << i)
+			if i == 0 {
+				low = 0
+			}
+			high := float64(uint64(1) << (i + 1))
+			return uint64(low + (high-low)*(rank-cumul)/float64(n))
+		}
+		cumul += float64(n)
+	}
+
+	return uint64(1) << len(slots)
+}
+
+func (t *Tracer) nextStats() ([]*types.Stats, error) {
+	stats := []*types.Stats{}
+
+	var keys []syscallSyscallKey
+	var key syscallSyscallKey
+	var syscallStats syscallSyscallStats
+	entries := t.objs.Stats.Iterate()
+	for entries.Next(&key, &syscallStats) {
+		keys = append(keys, key)
+
+		stat := types.Stats{
+			Syscall:       columns.Enum{Name: syscallName(key.Nr), Value: int64(key.Nr)},
+			Count:         syscallStats.Count,
+			Total:         syscallStats.Total,
+			P99:           percentile(syscallStats.Slots[:], syscallStats.Count, 99),
+			Max:           syscallStats.Max,
+			WithMountNsID: eventtypes.WithMountNsID{MountNsID: key.MntnsId},
+		}
+		if syscallStats.Count > 0 {
+			stat.Average = syscallStats.Total / syscallStats.Count
+		}
+		// The histogram can't be more precise than the longest call
+		if stat.P99 > stat.Max {
+			stat.P99 = stat.Max
+		}
+
+		if t.enricher != nil {
+			t.enricher.EnrichByMntNs(&stat.CommonData, stat.MountNsID)
+		}
+
+		stats = append(stats, &stat)
+	}
+	if err := entries.Err(); err != nil {
+		return nil, fmt.Errorf("iterating stats: %w", err)
+	}
+
+	for _, key := range keys {
+		if err := t.objs.Stats.Delete(key); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+			return nil, fmt.Errorf("deleting stats: %w", err)
+		}
+	}
+
+	top.SortStats(stats, t.config.SortBy, &t.colMap)
+
+	return stats, nil
+}
+
+func (t *Tracer) run(ctx context.Context) error {
+	// Don't use a context with a timeout but a counter to avoid having to deal
+	// with two timers: one for the timeout and another for the ticker.
+	count := t.config.Iterations
+	ticker := time.NewTicker(t.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			stats, err := t.nextStats()
+			if err != nil {
+				return fmt.Errorf("getting next stats: %w", err)
+			}
+
+			n := len(stats)
+			if n > t.config.MaxRows {
+				n = t.config.MaxRows
+			}
+			t.eventCallback(&top.Event[types.Stats]{Stats: stats[:n]})
+
+			// Count down only if user requested a finite number of iterations
+			// through a timeout.
+			if t.config.Iterations > 0 {
+				count--
+				if count == 0 {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	if err := t.init(gadgetCtx); err != nil {
+		return fmt.Errorf("initializing tracer: %w", err)
+	}
+
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	return t.run(gadgetCtx.Context())
+}
+
+func (t *Tracer) SetEventHandlerArray(handler any) {
+	nh, ok := handler.(func(ev []*types.Stats))
+	if !ok {
+		panic("event handler invalid")
+	}
+
+	t.eventCallback = func(ev *top.Event[types.Stats]) {
+		if ev.Error != "" {
+			return
+		}
+		nh(ev.Stats)
+	}
+}
+
+func (t *Tracer) SetMountNsMap(mntnsMap *ebpf.Map) {
+	t.config.MountnsMap = mntnsMap
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+	}
+	return tracer, nil
+}
+
+func (t *Tracer) init(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.MaxRows = params.Get(gadgets.ParamMaxRows).AsInt()
+	t.config.SortBy = params.Get(gadgets.ParamSortBy).AsStringSlice()
+	t.config.Interval = time.Second * time.Duration(params.Get(gadgets.ParamInterval).AsInt())
+
+	var err error
+	if t.config.Iterations, err = top.ComputeIterations(t.config.Interval, gadgetCtx.Timeout()); err != nil {
+		return err
+	}
+
+	statCols, err := columns.NewColumns[types.Stats]()
+	if err != nil {
+		return err
+	}
+	t.colMap = statCols.GetColumnMap()
+
+	return nil
+}
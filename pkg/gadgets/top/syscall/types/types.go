@@ -0,0 +1,60 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+var SortByDefault = []string{"-total", "-count"}
+
+// Stats represents the latency of a syscall done by a container, the
+// durations are in nanoseconds
+type Stats struct {
+	eventtypes.CommonData
+	eventtypes.WithMountNsID
+
+	Syscall columns.Enum `json:"syscall" column:"syscall,width:18"`
+	Count   uint64       `json:"count" column:"count,width:8"`
+	// Total is the time spent in the syscall by all the calls
+	Total   uint64 `json:"total" column:"total,width:12,align:right"`
+	Average uint64 `json:"average" column:"avg,width:12,align:right"`
+	// P99 is approximated from a log2 histogram of the latencies
+	P99 uint64 `json:"p99" column:"p99,width:12,align:right"`
+	Max uint64 `json:"max" column:"max,width:12,align:right"`
+}
+
+func GetColumns() *columns.Columns[Stats] {
+	cols := columns.MustCreateColumns[Stats]()
+
+	cols.MustSetExtractor("total", func(stats *Stats) (ret string) {
+		return fmt.Sprint(time.Duration(stats.Total))
+	})
+	cols.MustSetExtractor("avg", func(stats *Stats) (ret string) {
+		return fmt.Sprint(time.Duration(stats.Average))
+	})
+	cols.MustSetExtractor("p99", func(stats *Stats) (ret string) {
+		return fmt.Sprint(time.Duration(stats.P99))
+	})
+	cols.MustSetExtractor("max", func(stats *Stats) (ret string) {
+		return fmt.Sprint(time.Duration(stats.Max))
+	})
+
+	return cols
+}
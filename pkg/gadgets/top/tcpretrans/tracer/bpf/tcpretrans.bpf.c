@@ -0,0 +1,86 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "tcpretrans.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+
+/* Taken from kernel include/linux/socket.h. */
+#define AF_INET		2
+#define AF_INET6	10
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u64);
+	__type(value, struct conn_t);
+} conns SEC(".maps");
+
+static __always_inline void read_sock(struct conn_t *conn, struct sock *sk)
+{
+	struct tcp_sock *tp = (struct tcp_sock *)sk;
+
+	// srtt_us is stored left shifted by 3 in the kernel
+	conn->srtt_us = BPF_CORE_READ(tp, srtt_us) >> 3;
+	conn->segs_out = BPF_CORE_READ(tp, data_segs_out);
+}
+
+// The connections are registered when they send, to know their container
+SEC("kprobe/tcp_sendmsg")
+int BPF_KPROBE(ig_toptcpr_send, struct sock *sk)
+{
+	__u64 key = (__u64)sk;
+	struct conn_t *connp;
+	struct conn_t conn = {};
+	__u16 family;
+	__u64 mntns_id;
+
+	connp = bpf_map_lookup_elem(&conns, &key);
+	if (connp) {
+		read_sock(connp, sk);
+		return 0;
+	}
+
+	family = BPF_CORE_READ(sk, __sk_common.skc_family);
+	if (family != AF_INET && family != AF_INET6)
+		return 0;
+
+	mntns_id = gadget_get_mntns_id();
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return 0;
+
+	conn.mntns_id = mntns_id;
+	read_sock(&conn, sk);
+	conn.base_segs_out = conn.segs_out;
+
+	bpf_map_update_elem(&conns, &key, &conn, BPF_NOEXIST);
+
+	return 0;
+}
+
+// The retransmissions are counted here rather than read from total_retrans,
+// that is only increased after the tracepoint. The raw tracepoint is used as
+// the layout of the tracepoint changed between kernel versions.
+SEC("raw_tp/tcp_retransmit_skb")
+int BPF_PROG(ig_toptcpr_retrans, struct sock *sk)
+{
+	__u64 key = (__u64)sk;
+	struct conn_t *connp;
+
+	// It runs from the timers, the connections not registered yet are
+	// ignored as their container isn't known
+	connp = bpf_map_lookup_elem(&conns, &key);
+	if (!connp)
+		return 0;
+
+	read_sock(connp, sk);
+	__sync_fetch_and_add(&connp->retrans, 1);
+
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
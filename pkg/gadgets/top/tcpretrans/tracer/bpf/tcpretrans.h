@@ -0,0 +1,17 @@
+/* SPDX-License-Identifier: (LGPL-2.1 OR BSD-2-Clause) */
+#ifndef GADGET_TOP_TCPRETRANS_H
+#define GADGET_TOP_TCPRETRANS_H
+
+// conn_t is indexed by the address of the struct sock of the connection
+struct conn_t {
+	__u64 mntns_id;
+	// srtt_us is the smoothed RTT in microseconds
+	__u32 srtt_us;
+	__u32 segs_out;
+	__u32 retrans;
+	// base_segs_out is the number of segments sent by the connection before
+	// it was first seen
+	__u32 base_segs_out;
+};
+
+#endif /* GADGET_TOP_TCPRETRANS_H */
@@ -0,0 +1,133 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type tcpretransConnT struct {
+	MntnsId     uint64
+	SrttUs      uint32
+	SegsOut     uint32
+	Retrans     uint32
+	BaseSegsOut uint32
+}
+
+// loadTcpretrans returns the embedded CollectionSpec for tcpretrans.
+func loadTcpretrans() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_TcpretransBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load tcpretrans: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadTcpretransObjects loads tcpretrans and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*tcpretransObjects
+//	*tcpretransPrograms
+//	*tcpretransMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadTcpretransObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadTcpretrans()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// tcpretransSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type tcpretransSpecs struct {
+	tcpretransProgramSpecs
+	tcpretransMapSpecs
+}
+
+// tcpretransSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type tcpretransProgramSpecs struct {
+	IgToptcprRetrans *ebpf.ProgramSpec `ebpf:"ig_toptcpr_retrans"`
+	IgToptcprSend    *ebpf.ProgramSpec `ebpf:"ig_toptcpr_send"`
+}
+
+// tcpretransMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type tcpretransMapSpecs struct {
+	Conns                *ebpf.MapSpec `ebpf:"conns"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+}
+
+// tcpretransObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadTcpretransObjects or ebpf.CollectionSpec.LoadAndAssign.
+type tcpretransObjects struct {
+	tcpretransPrograms
+	tcpretransMaps
+}
+
+func (o *tcpretransObjects) Close() error {
+	return _TcpretransClose(
+		&o.tcpretransPrograms,
+		&o.tcpretransMaps,
+	)
+}
+
+// tcpretransMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadTcpretransObjects or ebpf.CollectionSpec.LoadAndAssign.
+type tcpretransMaps struct {
+	Conns                *ebpf.Map `ebpf:"conns"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+}
+
+func (m *tcpretransMaps) Close() error {
+	return _TcpretransClose(
+		m.Conns,
+		m.GadgetMntnsFilterMap,
+	)
+}
+
+// tcpretransPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadTcpretransObjects or ebpf.CollectionSpec.LoadAndAssign.
+type tcpretransPrograms struct {
+	IgToptcprRetrans *ebpf.Program `ebpf:"ig_toptcpr_retrans"`
+	IgToptcprSend    *ebpf.Program `ebpf:"ig_toptcpr_send"`
+}
+
+func (p *tcpretransPrograms) Close() error {
+	return _TcpretransClose(
+		p.IgToptcprRetrans,
+		p.IgToptcprSend,
+	)
+}
+
+func _TcpretransClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed tcpretrans_bpfel_x86.o
+var _TcpretransBytes []byte
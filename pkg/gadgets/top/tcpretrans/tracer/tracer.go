@@ -0,0 +1,294 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/bits"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/top/tcpretrans/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/histogram"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -type conn_t tcpretrans ./bpf/tcpretrans.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
+
+// maxSlots is the number of slots of the histograms, up to 2^27µs (more than
+// two minutes) for the RTT
+const maxSlots = 28
+
+type Config struct {
+	MountnsMap *ebpf.Map
+	MaxRows    int
+	Interval   time.Duration
+	Iterations int
+	SortBy     []string
+}
+
+// counts are the counters of a connection at the end of the last interval
+type counts struct {
+	segsOut uint32
+	retrans uint32
+}
+
+type container struct {
+	stats        *types.Stats
+	sumSrtt      uint64
+	srttSlots    [maxSlots]uint32
+	retransSlots [maxSlots]uint32
+}
+
+type Tracer struct {
+	config        *Config
+	objs          tcpretransObjects
+	sendLink      link.Link
+	retransLink   link.Link
+	eventCallback func(*top.Event[types.Stats])
+	colMap        columns.ColumnMap[types.Stats]
+
+	// prev are the counts of the connections indexed by their socket
+	prev map[uint64]counts
+}
+
+func (t *Tracer) close() {
+	t.sendLink = gadgets.CloseLink(t.sendLink)
+	t.retransLink = gadgets.CloseLink(t.retransLink)
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadTcpretrans()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	t.sendLink, err = link.Kprobe("tcp_sendmsg", t.objs.IgToptcprSend, nil)
+	if err != nil {
+		return fmt.Errorf("attaching kprobe: %w", err)
+	}
+
+	t.retransLink, err = link.AttachRawTracepoint(link.RawTracepointOptions{
+		Name:    "tcp_retransmit_skb",
+		Program: t.objs.IgToptcprRetrans,
+	})
+	if err != nil {
+		return fmt.Errorf("attaching tracepoint: %w", err)
+	}
+
+	return nil
+}
+
+// slot returns the slot of a value in an exp-2 histogram
+func slot(value uint64) int {
+	s := 0
+	if value > 0 {
+		s = bits.Len64(value) - 1
+	}
+	if s >= maxSlots {
+		s = maxSlots - 1
+	}
+	return s
+}
+
+func (t *Tracer) nextStats() ([]*types.Stats, error) {
+	containers := make(map[uint64]*container)
+	idle := []uint64{}
+
+	var key uint64
+	var conn tcpretransConnT
+	entries := t.objs.Conns.Iterate()
+	for entries.Next(&key, &conn) {
+		prev, ok := t.prev[key]
+		if !ok {
+			prev = counts{segsOut: conn.BaseSegsOut}
+		}
+		sent := conn.SegsOut - prev.segsOut
+		retrans := conn.Retrans - prev.retrans
+
+		// Forget the connections that didn't send during the interval, they
+		// are registered again with their new counters when they send again
+		if sent == 0 && retrans == 0 {
+			idle = append(idle, key)
+			continue
+		}
+		t.prev[key] = counts{segsOut: conn.SegsOut, retrans: conn.Retrans}
+
+		c, ok := containers[conn.MntnsId]
+		if !ok {
+			c = &container{
+				stats: &types.Stats{
+					WithMountNsID: eventtypes.WithMountNsID{MountNsID: conn.MntnsId},
+				},
+			}
+			containers[conn.MntnsId] = c
+		}
+
+		c.stats.Connections++
+		c.stats.Sent += uint64(sent)
+		c.stats.Retrans += uint64(retrans)
+		c.sumSrtt += uint64(conn.SrttUs)
+		if uint64(conn.SrttUs) > c.stats.MaxSrtt {
+			c.stats.MaxSrtt = uint64(conn.SrttUs)
+		}
+		c.srttSlots[slot(uint64(conn.SrttUs))]++
+
+		permille := uint64(1000)
+		if sent > 0 {
+			permille = 1000 * uint64(retrans) / uint64(sent)
+		}
+		c.retransSlots[slot(permille)]++
+	}
+	if err := entries.Err(); err != nil {
+		return nil, fmt.Errorf("iterating connections: %w", err)
+	}
+
+	for _, key := range idle {
+		if err := t.objs.Conns.Delete(key); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+			return nil, fmt.Errorf("deleting connection: %w", err)
+		}
+		delete(t.prev, key)
+	}
+
+	stats := make([]*types.Stats, 0, len(containers))
+	for _, c := range containers {
+		c.stats.AvgSrtt = c.sumSrtt / uint64(c.stats.Connections)
+		if c.stats.Sent > 0 {
+			c.stats.RetransRate = 100 * float64(c.stats.Retrans) / float64(c.stats.Sent)
+		}
+		c.stats.SrttHistogram = &histogram.Histogram{
+			Unit:      histogram.UnitMicroseconds,
+			Intervals: histogram.NewIntervalsFromExp2Slots(c.srttSlots[:]),
+		}
+		c.stats.RetransRateHistogram = &histogram.Histogram{
+			Unit:      histogram.UnitPermille,
+			Intervals: histogram.NewIntervalsFromExp2Slots(c.retransSlots[:]),
+		}
+		stats = append(stats, c.stats)
+	}
+
+	top.SortStats(stats, t.config.SortBy, &t.colMap)
+
+	return stats, nil
+}
+
+func (t *Tracer) run(ctx context.Context) error {
+	// Don't use a context with a timeout but a counter to avoid having to deal
+	// with two timers: one for the timeout and another for the ticker.
+	count := t.config.Iterations
+	ticker := time.NewTicker(t.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			stats, err := t.nextStats()
+			if err != nil {
+				return fmt.Errorf("getting next stats: %w", err)
+			}
+
+			n := len(stats)
+			if n > t.config.MaxRows {
+				n = t.config.MaxRows
+			}
+			t.eventCallback(&top.Event[types.Stats]{Stats: stats[:n]})
+
+			// Count down only if user requested a finite number of iterations
+			// through a timeout.
+			if t.config.Iterations > 0 {
+				count--
+				if count == 0 {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	if err := t.init(gadgetCtx); err != nil {
+		return fmt.Errorf("initializing tracer: %w", err)
+	}
+
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	return t.run(gadgetCtx.Context())
+}
+
+func (t *Tracer) SetEventHandlerArray(handler any) {
+	nh, ok := handler.(func(ev []*types.Stats))
+	if !ok {
+		panic("event handler invalid")
+	}
+
+	t.eventCallback = func(ev *top.Event[types.Stats]) {
+		if ev.Error != "" {
+			return
+		}
+		nh(ev.Stats)
+	}
+}
+
+func (t *Tracer) SetMountNsMap(mntnsMap *ebpf.Map) {
+	t.config.MountnsMap = mntnsMap
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+		prev:   make(map[uint64]counts),
+	}
+	return tracer, nil
+}
+
+func (t *Tracer) init(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.MaxRows = params.Get(gadgets.ParamMaxRows).AsInt()
+	t.config.SortBy = params.Get(gadgets.ParamSortBy).AsStringSlice()
+	t.config.Interval = time.Second * time.Duration(params.Get(gadgets.ParamInterval).AsInt())
+
+	var err error
+	if t.config.Iterations, err = top.ComputeIterations(t.config.Interval, gadgetCtx.Timeout()); err != nil {
+		return err
+	}
+
+	statCols, err := columns.NewColumns[types.Stats]()
+	if err != nil {
+		return err
+	}
+	t.colMap = statCols.GetColumnMap()
+
+	return nil
+}
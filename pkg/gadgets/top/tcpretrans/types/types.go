@@ -0,0 +1,65 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/histogram"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+var SortByDefault = []string{"-retrans", "-sent"}
+
+// Stats represents the TCP connections of a container that sent segments
+// during an interval
+type Stats struct {
+	eventtypes.CommonData
+	eventtypes.WithMountNsID
+
+	Connections uint32 `json:"connections" column:"conns,width:6,align:right"`
+	// Sent is the number of data segments sent, including the
+	// retransmissions
+	Sent    uint64 `json:"sent" column:"sent,width:10,align:right"`
+	Retrans uint64 `json:"retrans" column:"retrans,width:8,align:right"`
+	// RetransRate is the percentage of the sent segments that were
+	// retransmissions
+	RetransRate float64 `json:"retransRate" column:"retransrate,width:11,precision:2,align:right"`
+	// AvgSrtt and MaxSrtt are the average and maximum of the smoothed RTT
+	// of the connections, in microseconds
+	AvgSrtt uint64 `json:"avgSrtt" column:"avgsrtt,width:10,align:right"`
+	MaxSrtt uint64 `json:"maxSrtt" column:"maxsrtt,width:10,align:right"`
+
+	// SrttHistogram is the distribution of the smoothed RTT of the
+	// connections, in microseconds
+	SrttHistogram *histogram.Histogram `json:"srttHistogram,omitempty"`
+	// RetransRateHistogram is the distribution of the retransmission rate of
+	// the connections, in per mille
+	RetransRateHistogram *histogram.Histogram `json:"retransRateHistogram,omitempty"`
+}
+
+func GetColumns() *columns.Columns[Stats] {
+	cols := columns.MustCreateColumns[Stats]()
+
+	cols.MustSetExtractor("avgsrtt", func(stats *Stats) string {
+		return (time.Duration(stats.AvgSrtt) * time.Microsecond).String()
+	})
+	cols.MustSetExtractor("maxsrtt", func(stats *Stats) string {
+		return (time.Duration(stats.MaxSrtt) * time.Microsecond).String()
+	})
+
+	return cols
+}
@@ -15,11 +15,14 @@
 package top
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	columnssort "github.com/inspektor-gadget/inspektor-gadget/pkg/columns/sort"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
 )
 
 const (
@@ -54,3 +57,17 @@ func ComputeIterations(interval, timeout time.Duration) (int, error) {
 	}
 	return int(timeout / interval), nil
 }
+
+// UpdatedInterval returns the interval set in the params of a running gadget.
+// The interval can't be changed when the gadget runs for a given number of
+// iterations, as the timeout was computed with the former interval.
+func UpdatedInterval(params *params.Params, interval time.Duration, iterations int) (time.Duration, error) {
+	updated := time.Second * time.Duration(params.Get(gadgets.ParamInterval).AsInt())
+	if updated <= 0 {
+		return 0, errors.New("the interval must be greater than 0")
+	}
+	if updated != interval && iterations > 0 {
+		return 0, errors.New("the interval can't be changed when running with a timeout")
+	}
+	return updated, nil
+}
@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+
+#include <linux/bpf.h>
+#include <linux/if_arp.h>
+#include <linux/if_ether.h>
+#include <linux/if_packet.h>
+#include <linux/ipv6.h>
+#include <linux/in.h>
+#include <linux/icmpv6.h>
+#include <sys/socket.h>
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_endian.h>
+
+#include "arp.h"
+
+// Taken from include/net/ndisc.h
+#define NDISC_ROUTER_SOLICITATION	133
+#define NDISC_ROUTER_ADVERTISEMENT	134
+#define NDISC_NEIGHBOUR_SOLICITATION	135
+#define NDISC_NEIGHBOUR_ADVERTISEMENT	136
+#define NDISC_REDIRECT			137
+
+#define ND_OPT_SOURCE_LL_ADDR	1
+#define ND_OPT_TARGET_LL_ADDR	2
+
+// Options are only looked for in the first bytes of the messages, the
+// link-layer address options are usually the first ones.
+#define MAX_ND_OPTS 4
+
+// The socket enricher only tracks TCP and UDP sockets, so the events aren't
+// enriched with the process.
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event_t *unusedevent __attribute__((unused));
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+} events SEC(".maps");
+
+static __always_inline int parse_arp(struct __sk_buff *skb, struct event_t *event)
+{
+	struct arp_eth_ipv4 arp;
+	if (bpf_skb_load_bytes(skb, ETH_HLEN, &arp, sizeof arp))
+		return -1;
+
+	// Only IPv4 over ethernet is supported
+	if (bpf_ntohs(arp.ar_hrd) != ARPHRD_ETHER ||
+	    bpf_ntohs(arp.ar_pro) != ETH_P_IP ||
+	    arp.ar_hln != ETH_ALEN || arp.ar_pln != 4)
+		return -1;
+
+	event->op = bpf_ntohs(arp.ar_op);
+	if (event->op != ARPOP_REQUEST && event->op != ARPOP_REPLY)
+		return -1;
+
+	event->af = AF_INET;
+	event->sender_ip_v4 = arp.ar_sip;
+	event->target_ip_v4 = arp.ar_tip;
+	__builtin_memcpy(event->sender_mac, arp.ar_sha, ETH_ALEN);
+	__builtin_memcpy(event->target_mac, arp.ar_tha, ETH_ALEN);
+
+	return 0;
+}
+
+static __always_inline int parse_ndp(struct __sk_buff *skb, struct ethhdr *ethh,
+				     struct event_t *event)
+{
+	struct ipv6hdr ip6h;
+	if (bpf_skb_load_bytes(skb, ETH_HLEN, &ip6h, sizeof ip6h))
+		return -1;
+	// Messages following extension headers aren't traced
+	if (ip6h.nexthdr != IPPROTO_ICMPV6)
+		return -1;
+
+	int off = ETH_HLEN + sizeof(ip6h);
+	struct nd_hdr ndh;
+	if (bpf_skb_load_bytes(skb, off, &ndh, sizeof ndh))
+		return -1;
+
+	// Size of the fixed part of the message, the options follow it
+	int size = sizeof(ndh);
+	switch (ndh.type) {
+	case NDISC_ROUTER_SOLICITATION:
+		break;
+	case NDISC_ROUTER_ADVERTISEMENT:
+		// Reachable time and retransmission timer
+		size += 8;
+		break;
+	case NDISC_NEIGHBOUR_SOLICITATION:
+	case NDISC_NEIGHBOUR_ADVERTISEMENT:
+		if (bpf_skb_load_bytes(skb, off + sizeof(ndh), event->target_ip_v6, sizeof(event->target_ip_v6)))
+			return -1;
+		size += 16;
+		break;
+	case NDISC_REDIRECT:
+		// Target and destination addresses
+		if (bpf_skb_load_bytes(skb, off + sizeof(ndh), event->target_ip_v6, sizeof(event->target_ip_v6)))
+			return -1;
+		size += 32;
+		break;
+	default:
+		return -1;
+	}
+
+	event->af = AF_INET6;
+	event->op = ndh.type;
+	if (ndh.type == NDISC_NEIGHBOUR_ADVERTISEMENT)
+		event->flags = ndh.flags;
+	__builtin_memcpy(event->sender_ip_v6, ip6h.saddr.in6_u.u6_addr8, sizeof(event->sender_ip_v6));
+	__builtin_memcpy(event->sender_mac, ethh->h_source, ETH_ALEN);
+
+	off += size;
+	#pragma unroll
+	for (int i = 0; i < MAX_ND_OPTS; i++) {
+		struct nd_opt_hdr opt;
+		if (bpf_skb_load_bytes(skb, off, &opt, sizeof opt))
+			break;
+		if (opt.len == 0)
+			break;
+
+		switch (opt.type) {
+		case ND_OPT_SOURCE_LL_ADDR:
+			bpf_skb_load_bytes(skb, off + sizeof(opt), event->sender_mac, ETH_ALEN);
+			break;
+		case ND_OPT_TARGET_LL_ADDR:
+			bpf_skb_load_bytes(skb, off + sizeof(opt), event->target_mac, ETH_ALEN);
+			break;
+		}
+		off += opt.len * 8;
+	}
+
+	return 0;
+}
+
+SEC("socket1")
+int ig_trace_arp(struct __sk_buff *skb)
+{
+	struct event_t event = {0,};
+	int ret;
+
+	struct ethhdr ethh;
+	if (bpf_skb_load_bytes(skb, 0, &ethh, sizeof ethh))
+		return 0;
+
+	switch (bpf_ntohs(ethh.h_proto)) {
+	case ETH_P_ARP:
+		ret = parse_arp(skb, &event);
+		break;
+	case ETH_P_IPV6:
+		ret = parse_ndp(skb, &ethh, &event);
+		break;
+	default:
+		return 0;
+	}
+	if (ret)
+		return 0;
+
+	event.timestamp = bpf_ktime_get_boot_ns();
+	event.pkt_type = skb->pkt_type;
+
+	bpf_perf_event_output(skb, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+
+	return 0;
+}
+
+char _license[] SEC("license") = "GPL";
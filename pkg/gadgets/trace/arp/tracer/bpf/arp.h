@@ -0,0 +1,58 @@
+#ifndef GADGET_ARP_H
+#define GADGET_ARP_H
+
+struct event_t {
+	__u64 timestamp;
+
+	union {
+		__u8 sender_ip_v6[16];
+		__u32 sender_ip_v4;
+	};
+	union {
+		__u8 target_ip_v6[16];
+		__u32 target_ip_v4;
+	};
+	__u32 af; // AF_INET for ARP, AF_INET6 for NDP
+
+	// ARP: source hardware address, NDP: source link-layer address option
+	// or the source of the ethernet frame if the option isn't present
+	__u8 sender_mac[ETH_ALEN];
+	// ARP: target hardware address, NDP: target link-layer address option
+	__u8 target_mac[ETH_ALEN];
+	// ARP: operation, NDP: ICMPv6 type
+	__u16 op;
+	// Router, solicited and override flags of the neighbor advertisements
+	__u8 flags;
+	__u8 pkt_type;
+};
+
+// ARP message for IPv4 over ethernet. struct arphdr in linux/if_arp.h doesn't
+// contain the addresses as their size depends on the protocol.
+struct arp_eth_ipv4 {
+	__u16 ar_hrd;
+	__u16 ar_pro;
+	__u8 ar_hln;
+	__u8 ar_pln;
+	__u16 ar_op;
+	__u8 ar_sha[ETH_ALEN];
+	__u32 ar_sip;
+	__u8 ar_tha[ETH_ALEN];
+	__u32 ar_tip;
+} __attribute__((packed));
+
+// Common part of the neighbor discovery messages
+struct nd_hdr {
+	__u8 type;
+	__u8 code;
+	__u16 checksum;
+	__u8 flags;
+	__u8 reserved[3];
+};
+
+struct nd_opt_hdr {
+	__u8 type;
+	// Length of the option in units of 8 bytes
+	__u8 len;
+};
+
+#endif
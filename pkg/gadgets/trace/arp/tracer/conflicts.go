@@ -0,0 +1,59 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	lru "github.com/hashicorp/golang-lru/v2"
+)
+
+const conflictCacheSize int = 4096
+
+// neighborKey identifies an IP address in a network namespace.
+type neighborKey struct {
+	netns uint64
+	ip    string
+}
+
+// conflictDetector remembers the MAC address that last claimed each IP
+// address to report when another MAC address claims it. It uses an LRU cache
+// to bound memory usage.
+// All operations are thread-safe.
+type conflictDetector struct {
+	neighbors *lru.Cache[neighborKey, string] // This is thread-safe.
+}
+
+func newConflictDetector() (*conflictDetector, error) {
+	neighbors, err := lru.New[neighborKey, string](conflictCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return &conflictDetector{neighbors}, nil
+}
+
+// claim records that mac claimed ip in netns. It returns the MAC address that
+// claimed it before if it's a different one, an empty string otherwise.
+func (d *conflictDetector) claim(netns uint64, ip string, mac string) string {
+	key := neighborKey{netns, ip}
+	prev, ok := d.neighbors.Get(key)
+
+	// The last claim wins, so a MAC address that changed legitimately is only
+	// reported once.
+	d.neighbors.Add(key, mac)
+
+	if !ok || prev == mac {
+		return ""
+	}
+	return prev
+}
@@ -0,0 +1,71 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"testing"
+)
+
+func mustCreateConflictDetector(t *testing.T) *conflictDetector {
+	d, err := newConflictDetector()
+	if err != nil {
+		t.Fatalf("Could not initialize conflict detector: %s", err)
+	}
+	return d
+}
+
+func assertConflict(t *testing.T, actual string, expected string) {
+	if actual != expected {
+		t.Fatalf("Expected conflict with %q but got %q", expected, actual)
+	}
+}
+
+func TestConflictDetectorSameMAC(t *testing.T) {
+	netns := uint64(1)
+	d := mustCreateConflictDetector(t)
+
+	assertConflict(t, d.claim(netns, "10.244.0.12", "aa:aa:aa:aa:aa:aa"), "")
+	assertConflict(t, d.claim(netns, "10.244.0.12", "aa:aa:aa:aa:aa:aa"), "")
+}
+
+func TestConflictDetectorDifferentMAC(t *testing.T) {
+	netns := uint64(1)
+	d := mustCreateConflictDetector(t)
+
+	assertConflict(t, d.claim(netns, "10.244.0.12", "aa:aa:aa:aa:aa:aa"), "")
+	assertConflict(t, d.claim(netns, "10.244.0.12", "bb:bb:bb:bb:bb:bb"), "aa:aa:aa:aa:aa:aa")
+
+	// The last claim wins
+	assertConflict(t, d.claim(netns, "10.244.0.12", "bb:bb:bb:bb:bb:bb"), "")
+	assertConflict(t, d.claim(netns, "10.244.0.12", "aa:aa:aa:aa:aa:aa"), "bb:bb:bb:bb:bb:bb")
+}
+
+func TestConflictDetectorDifferentNetNs(t *testing.T) {
+	firstNetns, secondNetns := uint64(1), uint64(2)
+	d := mustCreateConflictDetector(t)
+
+	// The same address can be used in different network namespaces
+	assertConflict(t, d.claim(firstNetns, "10.244.0.12", "aa:aa:aa:aa:aa:aa"), "")
+	assertConflict(t, d.claim(secondNetns, "10.244.0.12", "bb:bb:bb:bb:bb:bb"), "")
+}
+
+func TestConflictDetectorDifferentIP(t *testing.T) {
+	netns := uint64(1)
+	d := mustCreateConflictDetector(t)
+
+	// A MAC address can have several IP addresses
+	assertConflict(t, d.claim(netns, "10.244.0.12", "aa:aa:aa:aa:aa:aa"), "")
+	assertConflict(t, d.claim(netns, "fe80::a8aa:aaff:feaa:aaaa", "aa:aa:aa:aa:aa:aa"), "")
+}
@@ -0,0 +1,241 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"unsafe"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/networktracer"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/arp/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate bash -c "source ./clangosflags.sh; go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -cc clang -type event_t arp ./bpf/arp.c -- $CLANG_OS_FLAGS -I./bpf/"
+
+const (
+	BPFProgName     = "ig_trace_arp"
+	BPFPerfMapName  = "events"
+	BPFSocketAttach = 50
+)
+
+type Tracer struct {
+	*networktracer.Tracer[types.Event]
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewTracer() (*Tracer, error) {
+	t := &Tracer{}
+
+	if err := t.install(); err != nil {
+		t.Close()
+		return nil, fmt.Errorf("installing tracer: %w", err)
+	}
+
+	return t, nil
+}
+
+// pkt_type definitions:
+// https://github.com/torvalds/linux/blob/v5.14-rc7/include/uapi/linux/if_packet.h#L26
+var pktTypeNames = []string{
+	"HOST",
+	"BROADCAST",
+	"MULTICAST",
+	"OTHERHOST",
+	"OUTGOING",
+	"LOOPBACK",
+	"USER",
+	"KERNEL",
+}
+
+// https://www.iana.org/assignments/arp-parameters/arp-parameters.xhtml
+var arpOpNames = map[uint16]string{
+	1: "REQUEST",
+	2: "REPLY",
+}
+
+// https://www.rfc-editor.org/rfc/rfc4861#section-4
+const ndpNeighborAdvertisement = 136
+
+var ndpTypeNames = map[uint16]string{
+	133:                      "ROUTER_SOLICIT",
+	134:                      "ROUTER_ADVERT",
+	135:                      "NEIGH_SOLICIT",
+	ndpNeighborAdvertisement: "NEIGH_ADVERT",
+	137:                      "REDIRECT",
+}
+
+// Solicited flag of the neighbor advertisements
+const ndpFlagSolicited = 0x40
+
+// macString returns the MAC address as a string, or an empty string if it's
+// unset, as in the ARP requests.
+func macString(mac [6]uint8) string {
+	if mac == [6]uint8{} {
+		return ""
+	}
+	return net.HardwareAddr(mac[:]).String()
+}
+
+func parseARPEvent(sample []byte, netns uint64) (*types.Event, error) {
+	bpfEvent := (*arpEventT)(unsafe.Pointer(&sample[0]))
+	if len(sample) < int(unsafe.Sizeof(*bpfEvent)) {
+		return nil, errors.New("invalid sample size")
+	}
+
+	event := types.Event{
+		Event: eventtypes.Event{
+			Type:      eventtypes.NORMAL,
+			Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+		},
+		WithNetNsID: eventtypes.WithNetNsID{NetNsID: netns},
+
+		SenderMAC: macString(bpfEvent.SenderMac),
+		TargetMAC: macString(bpfEvent.TargetMac),
+	}
+
+	switch bpfEvent.Af {
+	case syscall.AF_INET:
+		event.Protocol = types.ProtocolARP
+		event.Operation = arpOpNames[bpfEvent.Op]
+		event.SenderIP = gadgets.IPStringFromBytes(bpfEvent.SenderIpV6, 4)
+		event.TargetIP = gadgets.IPStringFromBytes(bpfEvent.TargetIpV6, 4)
+		event.Gratuitous = event.SenderIP == event.TargetIP
+	case syscall.AF_INET6:
+		event.Protocol = types.ProtocolNDP
+		event.Operation = ndpTypeNames[bpfEvent.Op]
+		event.SenderIP = gadgets.IPStringFromBytes(bpfEvent.SenderIpV6, 6)
+		// Only the neighbor messages and the redirects have a target
+		if bpfEvent.TargetIpV6 != [16]uint8{} {
+			event.TargetIP = gadgets.IPStringFromBytes(bpfEvent.TargetIpV6, 6)
+		}
+		event.Gratuitous = bpfEvent.Op == ndpNeighborAdvertisement &&
+			bpfEvent.Flags&ndpFlagSolicited == 0
+	}
+
+	event.PktType = "UNKNOWN"
+	if pktTypeUint := uint(bpfEvent.PktType); pktTypeUint < uint(len(pktTypeNames)) {
+		event.PktType = pktTypeNames[pktTypeUint]
+	}
+
+	return &event, nil
+}
+
+// claimedAddress returns the IP address the sender of the message says it
+// owns and the MAC address it says it's reachable at.
+func claimedAddress(event *types.Event) (string, string) {
+	switch event.Protocol {
+	case types.ProtocolARP:
+		// Probes from the duplicate address detection don't claim anything
+		if event.SenderIP == "0.0.0.0" {
+			return "", ""
+		}
+		return event.SenderIP, event.SenderMAC
+	case types.ProtocolNDP:
+		if event.Operation == ndpTypeNames[ndpNeighborAdvertisement] {
+			// The target link-layer address can be omitted in the solicited
+			// advertisements
+			if event.TargetMAC == "" {
+				return event.TargetIP, event.SenderMAC
+			}
+			return event.TargetIP, event.TargetMAC
+		}
+		if event.SenderIP == "::" {
+			return "", ""
+		}
+		return event.SenderIP, event.SenderMAC
+	}
+	return "", ""
+}
+
+// --- Registry changes
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return &Tracer{}, nil
+}
+
+func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
+	if err := t.install(); err != nil {
+		t.Close()
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	t.ctx, t.cancel = gadgetcontext.WithTimeoutOrCancel(gadgetCtx.Context(), gadgetCtx.Timeout())
+	return nil
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadArp()
+	if err != nil {
+		return fmt.Errorf("loading asset: %w", err)
+	}
+
+	detector, err := newConflictDetector()
+	if err != nil {
+		return err
+	}
+
+	parseAndDetectConflicts := func(rawSample []byte, netns uint64) (*types.Event, error) {
+		event, err := parseARPEvent(rawSample, netns)
+		if err != nil {
+			return nil, err
+		}
+
+		if ip, mac := claimedAddress(event); ip != "" && mac != "" {
+			event.ConflictMAC = detector.claim(netns, ip, mac)
+		}
+
+		return event, nil
+	}
+
+	networkTracer, err := networktracer.NewTracer(
+		spec,
+		BPFProgName,
+		BPFPerfMapName,
+		BPFSocketAttach,
+		types.Base,
+		parseAndDetectConflicts,
+	)
+	if err != nil {
+		return fmt.Errorf("creating network tracer: %w", err)
+	}
+	t.Tracer = networkTracer
+	return nil
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	<-t.ctx.Done()
+	return nil
+}
+
+func (t *Tracer) Close() {
+	if t.cancel != nil {
+		t.cancel()
+	}
+
+	if t.Tracer != nil {
+		t.Tracer.Close()
+	}
+}
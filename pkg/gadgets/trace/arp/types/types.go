@@ -0,0 +1,64 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/environment"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	ProtocolARP = "ARP"
+	ProtocolNDP = "NDP"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithNetNsID
+
+	Protocol  string `json:"protocol,omitempty" column:"proto,width:5,fixed"`
+	Operation string `json:"operation,omitempty" column:"op,minWidth:7,maxWidth:14"`
+	SenderIP  string `json:"senderIP,omitempty" column:"senderip,template:ipaddr"`
+	SenderMAC string `json:"senderMAC,omitempty" column:"sendermac,width:17,fixed"`
+	TargetIP  string `json:"targetIP,omitempty" column:"targetip,template:ipaddr"`
+	TargetMAC string `json:"targetMAC,omitempty" column:"targetmac,width:17,fixed,hide"`
+	PktType   string `json:"pktType,omitempty" column:"pkttype,minWidth:4,maxWidth:9,hide"`
+
+	// Gratuitous is set for the ARP messages whose sender and target IP
+	// addresses are the same and for the unsolicited neighbor advertisements
+	Gratuitous bool `json:"gratuitous,omitempty" column:"gratuitous,width:10,fixed,hide"`
+	// ConflictMAC is the MAC address that claimed the same IP address in the
+	// same network namespace before this message
+	ConflictMAC string `json:"conflictMAC,omitempty" column:"conflict,width:17,fixed"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	// Hide container column for kubernetes environment
+	if environment.Environment == environment.Kubernetes {
+		col, _ := cols.GetColumn("container")
+		col.Visible = false
+	}
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
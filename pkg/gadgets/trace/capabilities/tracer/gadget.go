@@ -72,6 +72,15 @@ func (g *GadgetDesc) EventPrototype() any {
 	return &types.Event{}
 }
 
+func (g *GadgetDesc) Cost() gadgets.Cost {
+	return gadgets.Cost{
+		Probes:     4,
+		Events:     "every capability check and syscall",
+		EventCost:  gadgets.CostHigh,
+		BufferSize: gadgets.PerfBufferSize(),
+	}
+}
+
 func init() {
 	gadgetregistry.Register(&GadgetDesc{})
 }
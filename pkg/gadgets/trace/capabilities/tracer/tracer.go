@@ -28,6 +28,7 @@ import (
 	libseccomp "github.com/seccomp/libseccomp-golang"
 	"github.com/syndtr/gocapability/capability"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
@@ -261,7 +262,7 @@ func (t *Tracer) run() {
 			Audit:         int(bpfEvent.Audit),
 			InsetID:       insetID,
 			Comm:          gadgets.FromCString(bpfEvent.Task[:]),
-			Syscall:       syscall,
+			Syscall:       columns.Enum{Name: syscall, Value: int64(bpfEvent.Syscall)},
 			CapName:       capabilityName,
 			Verdict:       verdict,
 			Caps:          bpfEvent.CapEffective,
@@ -31,6 +31,7 @@ import (
 	"golang.org/x/sys/unix"
 
 	utilstest "github.com/inspektor-gadget/inspektor-gadget/internal/test"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/tracer"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/capabilities/types"
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
@@ -91,7 +92,7 @@ func TestCapabilitiesTracer(t *testing.T) {
 					Pid:           uint32(info.Pid),
 					Uid:           uint32(info.Uid),
 					Comm:          info.Comm,
-					Syscall:       "fchownat",
+					Syscall:       columns.Enum{Name: "fchownat", Value: unix.SYS_FCHOWNAT},
 					CapName:       "CHOWN",
 					Cap:           0,
 					Audit:         1,
@@ -130,7 +131,7 @@ func TestCapabilitiesTracer(t *testing.T) {
 					Pid:           uint32(info.Pid),
 					Uid:           uint32(info.Uid),
 					Comm:          info.Comm,
-					Syscall:       "fchownat",
+					Syscall:       columns.Enum{Name: "fchownat", Value: unix.SYS_FCHOWNAT},
 					CapName:       "CHOWN",
 					Cap:           0,
 					Audit:         1,
@@ -160,7 +161,7 @@ func TestCapabilitiesTracer(t *testing.T) {
 					Pid:           uint32(info.Pid),
 					Uid:           uint32(info.Uid),
 					Comm:          info.Comm,
-					Syscall:       "bind",
+					Syscall:       columns.Enum{Name: "bind", Value: unix.SYS_BIND},
 					CapName:       "NET_BIND_SERVICE",
 					Cap:           10,
 					Audit:         1,
@@ -196,7 +197,7 @@ func TestCapabilitiesTracer(t *testing.T) {
 					Pid:           uint32(info.Pid),
 					Uid:           uint32(info.Uid),
 					Comm:          info.Comm,
-					Syscall:       "fchownat",
+					Syscall:       columns.Enum{Name: "fchownat", Value: unix.SYS_FCHOWNAT},
 					CapName:       "CHOWN",
 					Cap:           0,
 					Audit:         1,
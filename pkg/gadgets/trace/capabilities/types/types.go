@@ -35,20 +35,21 @@ const (
 type Event struct {
 	eventtypes.Event
 	eventtypes.WithMountNsID
+	eventtypes.WithContainerUid
 
-	Pid           uint32   `json:"pid,omitempty" column:"pid,template:pid"`
-	Comm          string   `json:"comm,omitempty" column:"comm,template:comm"`
-	Syscall       string   `json:"syscall,omitempty" column:"syscall,template:syscall"`
-	Uid           uint32   `json:"uid,omitempty" column:"uid,minWidth:6"`
-	Cap           int      `json:"cap,omitempty" column:"cap,width:3,fixed"`
-	CapName       string   `json:"capName,omitempty" column:"capName,width:18,fixed"`
-	Audit         int      `json:"audit,omitempty" column:"audit,minWidth:5"`
-	Verdict       string   `json:"verdict,omitempty" column:"verdict,width:7,fixed"`
-	InsetID       *bool    `json:"insetid,omitempty" column:"insetid,width:7,fixed,hide"`
-	TargetUserNs  uint64   `json:"targetuserns,omitempty" column:"targetuserns,template:ns"`
-	CurrentUserNs uint64   `json:"currentuserns,omitempty" column:"currentuserns,template:ns"`
-	Caps          uint64   `json:"caps,omitempty" column:"caps,hide"`
-	CapsNames     []string `json:"capsNames,omitempty" column:"capsnames,hide"`
+	Pid           uint32       `json:"pid,omitempty" column:"pid,template:pid"`
+	Comm          string       `json:"comm,omitempty" column:"comm,template:comm"`
+	Syscall       columns.Enum `json:"syscall" column:"syscall,template:syscall"`
+	Uid           uint32       `json:"uid,omitempty" column:"uid,minWidth:6"`
+	Cap           int          `json:"cap,omitempty" column:"cap,width:3,fixed"`
+	CapName       string       `json:"capName,omitempty" column:"capName,width:18,fixed"`
+	Audit         int          `json:"audit,omitempty" column:"audit,minWidth:5"`
+	Verdict       string       `json:"verdict,omitempty" column:"verdict,width:7,fixed"`
+	InsetID       *bool        `json:"insetid,omitempty" column:"insetid,width:7,fixed,hide"`
+	TargetUserNs  uint64       `json:"targetuserns,omitempty" column:"targetuserns,template:ns"`
+	CurrentUserNs uint64       `json:"currentuserns,omitempty" column:"currentuserns,template:ns"`
+	Caps          uint64       `json:"caps,omitempty" column:"caps,hide"`
+	CapsNames     []string     `json:"capsNames,omitempty" column:"capsnames,hide"`
 }
 
 func GetColumns() *columns.Columns[Event] {
@@ -72,6 +73,10 @@ func GetColumns() *columns.Columns[Event] {
 	return cols
 }
 
+func (e *Event) GetUid() uint32 {
+	return e.Uid
+}
+
 func Base(ev eventtypes.Event) *Event {
 	return &Event{
 		Event: ev,
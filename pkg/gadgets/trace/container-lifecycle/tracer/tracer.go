@@ -0,0 +1,363 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ocispec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/syndtr/gocapability/capability"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	ociannotations "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/oci-annotations"
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/container-lifecycle/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+// userHZ is the unit of the times of /proc/$pid/stat, it's 100 on all the
+// architectures supported
+const userHZ = 100
+
+// configPaths are the locations of the config.json of the containers, for
+// the ones whose OCI configuration wasn't given by the container collection
+var configPaths = []string{
+	// containerd, all the namespaces
+	"/run/containerd/io.containerd.runtime.v2.task/*/%s/config.json",
+	// CRI-O and Podman
+	"/run/containers/storage/overlay-containers/%s/userdata/config.json",
+	"/var/lib/containers/storage/overlay-containers/%s/userdata/config.json",
+	// Docker
+	"/run/docker/containerd/daemon/io.containerd.runtime.v2.task/moby/%s/config.json",
+}
+
+type Config struct {
+	IncludeExisting bool
+}
+
+type container struct {
+	// start time of the container process in clock ticks since boot, to
+	// tell whether it's still running once it's detached
+	startTicks uint64
+	event      types.Event
+}
+
+type Tracer struct {
+	config        *Config
+	eventCallback func(*types.Event)
+	// the containers started before are the existing ones
+	startTime time.Time
+
+	mu         sync.Mutex
+	containers map[string]*container
+	// set once Run() returned, when the containers are detached because the
+	// gadget stops
+	stopped bool
+}
+
+// readStat returns the state and the start time of a process from
+// /proc/$pid/stat
+func readStat(pid uint32) (byte, uint64, error) {
+	buf, err := os.ReadFile(filepath.Join(host.HostProcFs, fmt.Sprint(pid), "stat"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// The command can contain spaces and parentheses, the fields are after
+	// the last parenthesis: the state is the third field and the start time
+	// the 22nd one
+	i := strings.LastIndexByte(string(buf), ')')
+	if i < 0 {
+		return 0, 0, errors.New("invalid stat file")
+	}
+	fields := strings.Fields(string(buf[i+1:]))
+	if len(fields) < 20 {
+		return 0, 0, errors.New("invalid stat file")
+	}
+	startTicks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("parsing start time: %w", err)
+	}
+	return fields[0][0], startTicks, nil
+}
+
+// isRunning tells whether the process started at the given time is still
+// running
+func isRunning(pid uint32, startTicks uint64) bool {
+	state, ticks, err := readStat(pid)
+	if err != nil {
+		return false
+	}
+	return ticks == startTicks && state != 'Z' && state != 'X'
+}
+
+// loadOCIConfig returns the OCI configuration of a container, nil if it
+// can't be found
+func loadOCIConfig(c *containercollection.Container) *ocispec.Spec {
+	if c.OciConfig != nil {
+		return c.OciConfig
+	}
+
+	var paths []string
+	if c.Bundle != "" {
+		paths = append(paths, filepath.Join(host.HostRoot, c.Bundle, "config.json"))
+	}
+	if c.ID != "" {
+		for _, pattern := range configPaths {
+			matches, _ := filepath.Glob(filepath.Join(host.HostRoot, fmt.Sprintf(pattern, c.ID)))
+			paths = append(paths, matches...)
+		}
+	}
+
+	for _, path := range paths {
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		spec := &ocispec.Spec{}
+		if err := json.Unmarshal(buf, spec); err != nil {
+			continue
+		}
+		return spec
+	}
+	return nil
+}
+
+// capNames returns the names of the given capabilities without the "CAP_"
+// prefix, as the trace capabilities gadget does, and whether they are all
+// the ones supported by the kernel
+func capNames(caps []string) ([]string, bool) {
+	names := make([]string, 0, len(caps))
+	set := make(map[string]struct{}, len(caps))
+	for _, c := range caps {
+		name := strings.ToLower(strings.TrimPrefix(c, "CAP_"))
+		names = append(names, name)
+		set[name] = struct{}{}
+	}
+
+	for _, c := range capability.List() {
+		if c > capability.CAP_LAST_CAP {
+			continue
+		}
+		if _, ok := set[c.String()]; !ok {
+			return names, false
+		}
+	}
+	return names, true
+}
+
+// isBindMount tells whether a mount of the OCI configuration is a bind mount,
+// they are the volumes and host paths given to the container
+func isBindMount(m ocispec.Mount) bool {
+	if m.Type == "bind" {
+		return true
+	}
+	for _, option := range m.Options {
+		if option == "bind" || option == "rbind" {
+			return true
+		}
+	}
+	return false
+}
+
+// fillFromSpec fills the fields of the event coming from the OCI
+// configuration of the container
+func fillFromSpec(event *types.Event, spec *ocispec.Spec) {
+	if resolver, err := ociannotations.NewResolverFromAnnotations(spec.Annotations); err == nil {
+		event.Image = resolver.ContainerImage(spec.Annotations)
+	}
+
+	for _, m := range spec.Mounts {
+		if !isBindMount(m) {
+			continue
+		}
+		mount := m.Source + ":" + m.Destination
+		for _, option := range m.Options {
+			if option == "ro" {
+				mount += ":ro"
+				break
+			}
+		}
+		event.Mounts = append(event.Mounts, mount)
+	}
+
+	sc := &event.SecurityContext
+	if spec.Root != nil {
+		sc.ReadOnlyRootfs = spec.Root.Readonly
+	}
+
+	if p := spec.Process; p != nil {
+		event.Args = p.Args
+		sc.Uid = p.User.UID
+		sc.Gid = p.User.GID
+		sc.NoNewPrivileges = p.NoNewPrivileges
+		sc.AppArmor = p.ApparmorProfile
+		sc.SELinux = p.SelinuxLabel
+		if p.Capabilities != nil {
+			sc.Capabilities, sc.Privileged = capNames(p.Capabilities.Effective)
+		}
+	}
+
+	if l := spec.Linux; l != nil {
+		sc.Seccomp = "unconfined"
+		if l.Seccomp != nil {
+			sc.Seccomp = "filtered"
+		}
+
+		// The namespaces shared with the host aren't created
+		namespaces := make(map[ocispec.LinuxNamespaceType]bool)
+		for _, ns := range l.Namespaces {
+			namespaces[ns.Type] = true
+		}
+		sc.HostNetwork = !namespaces[ocispec.NetworkNamespace]
+		sc.HostPID = !namespaces[ocispec.PIDNamespace]
+		sc.HostIPC = !namespaces[ocispec.IPCNamespace]
+	}
+}
+
+func (t *Tracer) newEvent(c *containercollection.Container, startTicks uint64) types.Event {
+	event := types.Event{
+		Event: eventtypes.Event{
+			Type: eventtypes.NORMAL,
+			CommonData: eventtypes.CommonData{
+				Namespace: c.Namespace,
+				Pod:       c.Podname,
+				Container: c.Name,
+			},
+		},
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: c.Mntns},
+		ContainerID:   c.ID,
+		Runtime:       c.Runtime,
+		Pid:           c.Pid,
+		NetNsID:       c.Netns,
+	}
+	if startTicks != 0 {
+		event.StartedAt = gadgets.WallTimeFromBootTime(startTicks * uint64(time.Second) / userHZ)
+	}
+
+	if spec := loadOCIConfig(c); spec != nil {
+		fillFromSpec(&event, spec)
+	}
+	if c.HostNetwork {
+		event.HostNetwork = true
+	}
+	return event
+}
+
+func (t *Tracer) emit(event types.Event, eventType types.EventType) {
+	event.Timestamp = eventtypes.Time(time.Now().UnixNano())
+	event.EventType = eventType
+	t.eventCallback(&event)
+}
+
+// ---
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return &Tracer{
+		config:     &Config{},
+		containers: make(map[string]*container),
+	}, nil
+}
+
+func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
+	// The existing containers are attached before Run() is called
+	t.config.IncludeExisting = gadgetCtx.GadgetParams().Get(ParamIncludeExisting).AsBool()
+	t.startTime = time.Now()
+	return nil
+}
+
+func (t *Tracer) Close() {}
+
+func (t *Tracer) AttachContainer(c *containercollection.Container) error {
+	_, startTicks, err := readStat(c.Pid)
+	if err != nil {
+		return fmt.Errorf("reading process status: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.containers[c.ID]; ok {
+		return nil
+	}
+	ct := &container{
+		startTicks: startTicks,
+		event:      t.newEvent(c, startTicks),
+	}
+	t.containers[c.ID] = ct
+
+	// The containers are also attached when the filters of the gadget
+	// change, so the ones that started before it are reported as existing
+	eventType := types.EventTypeCreated
+	if time.Unix(0, int64(ct.event.StartedAt)).Before(t.startTime) {
+		if !t.config.IncludeExisting {
+			return nil
+		}
+		eventType = types.EventTypeExisting
+	}
+	t.emit(ct.event, eventType)
+	return nil
+}
+
+func (t *Tracer) DetachContainer(c *containercollection.Container) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ct, ok := t.containers[c.ID]
+	if !ok {
+		return nil
+	}
+	delete(t.containers, c.ID)
+
+	// The containers are also detached when the gadget stops or they don't
+	// match its filters anymore
+	if t.stopped || isRunning(c.Pid, ct.startTicks) {
+		return nil
+	}
+	t.emit(ct.event, types.EventTypeDeleted)
+	return nil
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	ctx, cancel := gadgetcontext.WithTimeoutOrCancel(gadgetCtx.Context(), gadgetCtx.Timeout())
+	defer cancel()
+
+	<-ctx.Done()
+
+	t.mu.Lock()
+	t.stopped = true
+	t.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	ocispec "github.com/opencontainers/runtime-spec/specs-go"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/container-lifecycle/types"
+)
+
+func TestFillFromSpec(t *testing.T) {
+	spec := &ocispec.Spec{
+		Annotations: map[string]string{
+			"io.kubernetes.cri.container-type": "container",
+			"io.kubernetes.cri.image-name":     "docker.io/library/nginx:1.25",
+		},
+		Root: &ocispec.Root{Path: "rootfs", Readonly: true},
+		Process: &ocispec.Process{
+			Args:            []string{"nginx", "-g", "daemon off;"},
+			User:            ocispec.User{UID: 101, GID: 101},
+			NoNewPrivileges: true,
+			ApparmorProfile: "cri-containerd.apparmor.d",
+			Capabilities: &ocispec.LinuxCapabilities{
+				Effective: []string{"CAP_CHOWN", "CAP_NET_BIND_SERVICE"},
+			},
+		},
+		Mounts: []ocispec.Mount{
+			{Destination: "/proc", Type: "proc", Source: "proc"},
+			{Destination: "/etc/hosts", Type: "bind", Source: "/var/lib/kubelet/pods/1234/etc-hosts", Options: []string{"rbind", "rprivate", "rw"}},
+			{Destination: "/data", Source: "/mnt/data", Options: []string{"rbind", "ro"}},
+		},
+		Linux: &ocispec.Linux{
+			Namespaces: []ocispec.LinuxNamespace{
+				{Type: ocispec.PIDNamespace},
+				{Type: ocispec.MountNamespace},
+				{Type: ocispec.NetworkNamespace, Path: "/var/run/netns/cni-1234"},
+			},
+			Seccomp: &ocispec.LinuxSeccomp{DefaultAction: ocispec.ActErrno},
+		},
+	}
+
+	event := &types.Event{}
+	fillFromSpec(event, spec)
+
+	expected := &types.Event{
+		Image:  "docker.io/library/nginx:1.25",
+		Args:   []string{"nginx", "-g", "daemon off;"},
+		Mounts: []string{"/var/lib/kubelet/pods/1234/etc-hosts:/etc/hosts", "/mnt/data:/data:ro"},
+		SecurityContext: types.SecurityContext{
+			Uid:             101,
+			Gid:             101,
+			Capabilities:    []string{"chown", "net_bind_service"},
+			Seccomp:         "filtered",
+			AppArmor:        "cri-containerd.apparmor.d",
+			NoNewPrivileges: true,
+			ReadOnlyRootfs:  true,
+			HostIPC:         true,
+		},
+	}
+	if !reflect.DeepEqual(event, expected) {
+		t.Fatalf("Got %+v, expecting %+v", event, expected)
+	}
+}
+
+func TestIsRunning(t *testing.T) {
+	pid := uint32(os.Getpid())
+	_, startTicks, err := readStat(pid)
+	if err != nil {
+		t.Fatalf("Reading stat: %s", err)
+	}
+	if !isRunning(pid, startTicks) {
+		t.Fatalf("Process %d not running", pid)
+	}
+	// A new process reusing the pid
+	if isRunning(pid, startTicks+1) {
+		t.Fatalf("Process %d with another start time running", pid)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"strings"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type EventType string
+
+const (
+	// EventTypeCreated is reported when a container starts while the gadget
+	// is running
+	EventTypeCreated EventType = "created"
+	// EventTypeExisting is reported for the containers already running
+	// when the gadget starts, if requested
+	EventTypeExisting EventType = "existing"
+	// EventTypeDeleted is reported when the container process terminates
+	EventTypeDeleted EventType = "deleted"
+)
+
+// Event is a change of the lifecycle of a container. The image, mounts and
+// security context come from the OCI configuration of the container, they
+// are empty if it isn't available.
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	EventType   EventType       `json:"event" column:"event,width:8"`
+	ContainerID string          `json:"containerID" column:"id,width:13,maxWidth:64"`
+	Runtime     string          `json:"runtime,omitempty" column:"runtime,minWidth:5,maxWidth:10"`
+	Image       string          `json:"image,omitempty" column:"image,width:30"`
+	Pid         uint32          `json:"pid" column:"pid,template:pid"`
+	NetNsID     uint64          `json:"netnsid,omitempty" column:"netns,template:ns,hide"`
+	StartedAt   eventtypes.Time `json:"startedAt,omitempty" column:"startedAt,template:timestamp,stringer,hide"`
+	Args        []string        `json:"args,omitempty" column:"args,width:40,hide"`
+	Mounts      []string        `json:"mounts,omitempty" column:"mounts,width:40,hide"`
+
+	SecurityContext
+}
+
+// SecurityContext is the security configuration of the container process
+type SecurityContext struct {
+	Uid uint32 `json:"uid" column:"uid,template:uid"`
+	Gid uint32 `json:"gid" column:"gid,template:gid,hide"`
+
+	// Privileged is set when the container has all the capabilities
+	Privileged bool `json:"privileged,omitempty" column:"privileged,width:10,fixed"`
+	// Capabilities are the effective capabilities, without the "cap_"
+	// prefix
+	Capabilities    []string `json:"capabilities,omitempty" column:"capabilities,width:30,hide"`
+	Seccomp         string   `json:"seccomp,omitempty" column:"seccomp,width:10,hide"`
+	AppArmor        string   `json:"apparmor,omitempty" column:"apparmor,width:20,hide"`
+	SELinux         string   `json:"selinux,omitempty" column:"selinux,width:20,hide"`
+	NoNewPrivileges bool     `json:"noNewPrivileges,omitempty" column:"noNewPrivs,width:10,fixed,hide"`
+	ReadOnlyRootfs  bool     `json:"readOnlyRootfs,omitempty" column:"readOnlyRootfs,width:14,fixed,hide"`
+	HostNetwork     bool     `json:"hostNetwork,omitempty" column:"hostNetwork,width:11,fixed,hide"`
+	HostPID         bool     `json:"hostPID,omitempty" column:"hostPID,width:7,fixed,hide"`
+	HostIPC         bool     `json:"hostIPC,omitempty" column:"hostIPC,width:7,fixed,hide"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	cols.MustSetExtractor("args", func(event *Event) string {
+		return strings.Join(event.Args, " ")
+	})
+	cols.MustSetExtractor("mounts", func(event *Event) string {
+		return strings.Join(event.Mounts, ",")
+	})
+	cols.MustSetExtractor("capabilities", func(event *Event) string {
+		return strings.Join(event.Capabilities, ",")
+	})
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
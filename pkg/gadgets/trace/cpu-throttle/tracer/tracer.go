@@ -0,0 +1,320 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/cpu-throttle/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+type Config struct {
+	Interval time.Duration
+}
+
+// cpuStats are the statistics of the CFS bandwidth controller of a cgroup
+type cpuStats struct {
+	periods       uint64
+	throttled     uint64
+	throttledTime time.Duration
+	quota         time.Duration
+	period        time.Duration
+}
+
+type cgroup struct {
+	name string
+	dir  string
+	v1   bool
+	last cpuStats
+}
+
+type Tracer struct {
+	config        *Config
+	eventCallback func(*types.Event)
+
+	mu sync.Mutex
+	// cgroups of the containers indexed by mount namespace
+	cgroups map[uint64]*cgroup
+}
+
+// getCPUCgroup returns the directory of the cgroup of the given process in
+// the hierarchy of the cpu controller, and whether it's a cgroup v1 one
+func getCPUCgroup(pid uint32) (string, bool, error) {
+	file, err := os.Open(filepath.Join(host.HostProcFs, fmt.Sprint(pid), "cgroup"))
+	if err != nil {
+		return "", false, err
+	}
+	defer file.Close()
+
+	pathV2 := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[0] == "0" && fields[1] == "" {
+			pathV2 = fields[2]
+			continue
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			if controller != "cpu" {
+				continue
+			}
+			// The hierarchy is mounted at "cpu,cpuacct" with a "cpu" symlink
+			// on most distributions
+			for _, mount := range []string{fields[1], "cpu"} {
+				dir := filepath.Join(cgroupRoot, mount, fields[2])
+				if _, err := os.Stat(filepath.Join(dir, "cpu.cfs_quota_us")); err == nil {
+					return dir, true, nil
+				}
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", false, err
+	}
+
+	if pathV2 != "" {
+		dir := filepath.Join(cgroupRoot, pathV2)
+		if _, err := os.Stat(filepath.Join(dir, "cpu.max")); err == nil {
+			return dir, false, nil
+		}
+	}
+
+	return "", false, errors.New("cpu controller not found")
+}
+
+func readUint(path string) (uint64, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+}
+
+// readQuota returns the quota and the period of the cgroup, the quota is 0
+// without limit
+func readQuota(dir string, v1 bool) (time.Duration, time.Duration, error) {
+	if v1 {
+		content, err := os.ReadFile(filepath.Join(dir, "cpu.cfs_quota_us"))
+		if err != nil {
+			return 0, 0, err
+		}
+		// -1 without limit
+		quota, err := strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+		if err != nil {
+			return 0, 0, err
+		}
+		if quota < 0 {
+			quota = 0
+		}
+		period, err := readUint(filepath.Join(dir, "cpu.cfs_period_us"))
+		if err != nil {
+			return 0, 0, err
+		}
+		return time.Duration(quota) * time.Microsecond, time.Duration(period) * time.Microsecond, nil
+	}
+
+	// "$MAX $PERIOD", $MAX being "max" without limit
+	content, err := os.ReadFile(filepath.Join(dir, "cpu.max"))
+	if err != nil {
+		return 0, 0, err
+	}
+	fields := strings.Fields(string(content))
+	if len(fields) != 2 {
+		return 0, 0, fmt.Errorf("unexpected cpu.max content %q", content)
+	}
+	var quota uint64
+	if fields[0] != "max" {
+		if quota, err = strconv.ParseUint(fields[0], 10, 64); err != nil {
+			return 0, 0, err
+		}
+	}
+	period, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return time.Duration(quota) * time.Microsecond, time.Duration(period) * time.Microsecond, nil
+}
+
+func readCPUStats(dir string, v1 bool) (cpuStats, error) {
+	stats := cpuStats{}
+
+	file, err := os.Open(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return stats, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "nr_periods":
+			stats.periods = n
+		case "nr_throttled":
+			stats.throttled = n
+		case "throttled_time":
+			// cgroup v1, in nanoseconds
+			stats.throttledTime = time.Duration(n)
+		case "throttled_usec":
+			stats.throttledTime = time.Duration(n) * time.Microsecond
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return stats, err
+	}
+
+	stats.quota, stats.period, err = readQuota(dir, v1)
+	if err != nil {
+		return stats, fmt.Errorf("reading quota: %w", err)
+	}
+
+	return stats, nil
+}
+
+// check emits an event for each container throttled since the last check
+func (t *Tracer) check(gadgetCtx gadgets.GadgetContext) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for mntns, cg := range t.cgroups {
+		stats, err := readCPUStats(cg.dir, cg.v1)
+		if err != nil {
+			// The container is likely gone, don't try again
+			gadgetCtx.Logger().Debugf("reading CPU statistics of container %q: %s", cg.name, err)
+			delete(t.cgroups, mntns)
+			continue
+		}
+
+		last := cg.last
+		cg.last = stats
+
+		// The counters are reset if the cgroup is recreated
+		if stats.throttled <= last.throttled || stats.periods < last.periods ||
+			stats.throttledTime < last.throttledTime {
+			continue
+		}
+
+		t.eventCallback(&types.Event{
+			Event: eventtypes.Event{
+				Type:      eventtypes.NORMAL,
+				Timestamp: eventtypes.Time(time.Now().UnixNano()),
+			},
+			WithMountNsID: eventtypes.WithMountNsID{MountNsID: mntns},
+			Periods:       stats.periods - last.periods,
+			Throttled:     stats.throttled - last.throttled,
+			Duration:      stats.throttledTime - last.throttledTime,
+			Quota:         stats.quota,
+			Period:        stats.period,
+		})
+	}
+}
+
+// ---
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return &Tracer{
+		config:  &Config{},
+		cgroups: make(map[uint64]*cgroup),
+	}, nil
+}
+
+func (t *Tracer) AttachContainer(container *containercollection.Container) error {
+	dir, v1, err := getCPUCgroup(container.Pid)
+	if err != nil {
+		return fmt.Errorf("getting CPU cgroup: %w", err)
+	}
+
+	// The throttling that happened before the gadget started isn't reported
+	stats, err := readCPUStats(dir, v1)
+	if err != nil {
+		return fmt.Errorf("reading CPU statistics: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.cgroups[container.Mntns] = &cgroup{
+		name: container.Name,
+		dir:  dir,
+		v1:   v1,
+		last: stats,
+	}
+	return nil
+}
+
+func (t *Tracer) DetachContainer(container *containercollection.Container) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.cgroups, container.Mntns)
+	return nil
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.Interval = time.Duration(params.Get(gadgets.ParamInterval).AsUint32()) * time.Second
+	if t.config.Interval == 0 {
+		return errors.New("interval must be greater than 0")
+	}
+
+	ctx, cancel := gadgetcontext.WithTimeoutOrCancel(gadgetCtx.Context(), gadgetCtx.Timeout())
+	defer cancel()
+
+	ticker := time.NewTicker(t.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			t.check(gadgetCtx)
+		}
+	}
+}
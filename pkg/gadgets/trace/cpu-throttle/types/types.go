@@ -0,0 +1,80 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// Event is the CPU throttling of a container by the CFS bandwidth controller
+// during an interval
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	// Periods is the number of enforcement periods elapsed in which the
+	// container was runnable, and Throttled the number of them in which it
+	// used its whole quota
+	Periods   uint64 `json:"periods" column:"periods,width:8"`
+	Throttled uint64 `json:"throttled" column:"throttled,width:9"`
+	// Duration is the time the container was throttled, summed over the CPUs
+	Duration time.Duration `json:"duration" column:"duration,width:12"`
+
+	// Quota is the CPU time the container can use each Period, 0 if it
+	// isn't limited
+	Quota  time.Duration `json:"quota,omitempty" column:"quota,width:8"`
+	Period time.Duration `json:"period,omitempty" column:"period,width:8"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	cols.MustSetExtractor("duration", func(event *Event) string {
+		return event.Duration.String()
+	})
+	cols.MustSetExtractor("quota", func(event *Event) string {
+		if event.Quota == 0 {
+			return "max"
+		}
+		return event.Quota.String()
+	})
+	cols.MustSetExtractor("period", func(event *Event) string {
+		return event.Period.String()
+	})
+
+	cols.MustAddColumn(columns.Attributes{
+		Name:    "cpus",
+		Width:   6,
+		Visible: true,
+		Order:   1000,
+	}, func(event *Event) string {
+		if event.Quota == 0 || event.Period == 0 {
+			return "max"
+		}
+		return fmt.Sprintf("%.2f", float64(event.Quota)/float64(event.Period))
+	})
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
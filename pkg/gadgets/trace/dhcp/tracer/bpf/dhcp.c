@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+
+#include <linux/bpf.h>
+#include <linux/if_ether.h>
+#include <linux/if_packet.h>
+#include <linux/ip.h>
+#include <linux/ipv6.h>
+#include <linux/in.h>
+#include <linux/udp.h>
+#include <sys/socket.h>
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_endian.h>
+
+#include "dhcp.h"
+
+// Taken from include/net/ip.h
+#define IP_MF		0x2000
+#define IP_OFFSET	0x1FFF
+
+// The DHCP clients usually use raw sockets before they get an address, so the
+// events aren't enriched with the process.
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event_t *unusedevent __attribute__((unused));
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+} events SEC(".maps");
+
+static __always_inline int is_dhcp_port(__u16 port)
+{
+	return port == DHCP_SERVER_PORT || port == DHCP_CLIENT_PORT;
+}
+
+static __always_inline int is_dhcp6_port(__u16 port)
+{
+	return port == DHCP6_CLIENT_PORT || port == DHCP6_SERVER_PORT;
+}
+
+static __always_inline int parse_ipv4(struct __sk_buff *skb, struct event_t *event)
+{
+	struct iphdr iph;
+	if (bpf_skb_load_bytes(skb, ETH_HLEN, &iph, sizeof iph))
+		return -1;
+	if (iph.protocol != IPPROTO_UDP)
+		return -1;
+	// Fragments aren't reassembled
+	if (iph.frag_off & bpf_htons(IP_MF | IP_OFFSET))
+		return -1;
+
+	event->af = AF_INET;
+	event->saddr_v4 = iph.saddr;
+	event->daddr_v4 = iph.daddr;
+
+	return ETH_HLEN + iph.ihl * 4;
+}
+
+static __always_inline int parse_ipv6(struct __sk_buff *skb, struct event_t *event)
+{
+	struct ipv6hdr ip6h;
+	if (bpf_skb_load_bytes(skb, ETH_HLEN, &ip6h, sizeof ip6h))
+		return -1;
+	// Messages following extension headers aren't traced
+	if (ip6h.nexthdr != IPPROTO_UDP)
+		return -1;
+
+	event->af = AF_INET6;
+	__builtin_memcpy(event->saddr_v6, ip6h.saddr.in6_u.u6_addr8, sizeof(event->saddr_v6));
+	__builtin_memcpy(event->daddr_v6, ip6h.daddr.in6_u.u6_addr8, sizeof(event->daddr_v6));
+
+	return ETH_HLEN + sizeof(ip6h);
+}
+
+SEC("socket1")
+int ig_trace_dhcp(struct __sk_buff *skb)
+{
+	struct event_t event = {0,};
+	int off;
+
+	struct ethhdr ethh;
+	if (bpf_skb_load_bytes(skb, 0, &ethh, sizeof ethh))
+		return 0;
+
+	switch (bpf_ntohs(ethh.h_proto)) {
+	case ETH_P_IP:
+		off = parse_ipv4(skb, &event);
+		break;
+	case ETH_P_IPV6:
+		off = parse_ipv6(skb, &event);
+		break;
+	default:
+		return 0;
+	}
+	if (off < 0)
+		return 0;
+
+	struct udphdr udph;
+	if (bpf_skb_load_bytes(skb, off, &udph, sizeof udph))
+		return 0;
+
+	event.sport = bpf_ntohs(udph.source);
+	event.dport = bpf_ntohs(udph.dest);
+	if (event.af == AF_INET) {
+		if (!is_dhcp_port(event.sport) || !is_dhcp_port(event.dport))
+			return 0;
+	} else {
+		if (!is_dhcp6_port(event.sport) || !is_dhcp6_port(event.dport))
+			return 0;
+	}
+
+	event.payload_offset = off + sizeof(udph);
+	event.payload_len = bpf_ntohs(udph.len) - sizeof(udph);
+	event.timestamp = bpf_ktime_get_boot_ns();
+	event.pkt_type = skb->pkt_type;
+
+	// Append the packet to the event, the DHCP message is decoded in
+	// userspace
+	__u64 len = skb->len;
+	if (len > MAX_PACKET_SIZE)
+		len = MAX_PACKET_SIZE;
+
+	bpf_perf_event_output(skb, &events, (len << 32) | BPF_F_CURRENT_CPU, &event, sizeof(event));
+
+	return 0;
+}
+
+char _license[] SEC("license") = "GPL";
@@ -0,0 +1,37 @@
+#ifndef GADGET_DHCP_H
+#define GADGET_DHCP_H
+
+// Ports used by the clients and the servers (or relay agents)
+#define DHCP_SERVER_PORT	67
+#define DHCP_CLIENT_PORT	68
+#define DHCP6_CLIENT_PORT	546
+#define DHCP6_SERVER_PORT	547
+
+// Size of the biggest packet sent to userspace, it's enough for the DHCP
+// messages sent over an ethernet link with the standard MTU
+#define MAX_PACKET_SIZE	1514
+
+// The event is followed by the first bytes of the packet, starting at the
+// ethernet header
+struct event_t {
+	__u64 timestamp;
+
+	union {
+		__u8 saddr_v6[16];
+		__u32 saddr_v4;
+	};
+	union {
+		__u8 daddr_v6[16];
+		__u32 daddr_v4;
+	};
+	__u32 af; // AF_INET or AF_INET6
+
+	__u16 sport;
+	__u16 dport;
+	// Offset and length of the DHCP message in the packet
+	__u16 payload_offset;
+	__u16 payload_len;
+	__u8 pkt_type;
+};
+
+#endif
@@ -0,0 +1,390 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dhcp/types"
+)
+
+// DHCP message format and options:
+// https://www.rfc-editor.org/rfc/rfc2131#section-2
+// https://www.rfc-editor.org/rfc/rfc2132
+const (
+	dhcpHeaderSize   = 236
+	dhcpMagicCookie  = 0x63825363
+	dhcpHTypeEther   = 1
+	dhcpOptPad       = 0
+	dhcpOptEnd       = 255
+	dhcpOptMask      = 1
+	dhcpOptRouter    = 3
+	dhcpOptDNS       = 6
+	dhcpOptHostname  = 12
+	dhcpOptRequested = 50
+	dhcpOptLease     = 51
+	dhcpOptOverload  = 52
+	dhcpOptMsgType   = 53
+	dhcpOptServerID  = 54
+	dhcpOptRenewal   = 58
+	dhcpOptRebinding = 59
+	dhcpOptClientID  = 61
+)
+
+var dhcpMsgTypeNames = map[uint8]string{
+	1: "DISCOVER",
+	2: "OFFER",
+	3: "REQUEST",
+	4: "DECLINE",
+	5: "ACK",
+	6: "NAK",
+	7: "RELEASE",
+	8: "INFORM",
+}
+
+// DHCPv6 message format and options:
+// https://www.rfc-editor.org/rfc/rfc8415#section-8
+// https://www.rfc-editor.org/rfc/rfc8415#section-21
+const (
+	dhcp6HeaderSize      = 4
+	dhcp6RelayHeaderSize = 34
+	dhcp6RelayForw       = 12
+	dhcp6RelayRepl       = 13
+	dhcp6OptClientID     = 1
+	dhcp6OptIANA         = 3
+	dhcp6OptIAAddr       = 5
+	dhcp6OptRelayMsg     = 9
+	dhcp6OptDNS          = 23
+	dhcp6OptClientFQDN   = 39
+	dhcp6DUIDLLT         = 1
+	dhcp6DUIDLL          = 3
+	// Relay agents can be chained, don't follow too many of them
+	dhcp6MaxRelays = 4
+)
+
+var dhcp6MsgTypeNames = map[uint8]string{
+	1:              "SOLICIT",
+	2:              "ADVERTISE",
+	3:              "REQUEST",
+	4:              "CONFIRM",
+	5:              "RENEW",
+	6:              "REBIND",
+	7:              "REPLY",
+	8:              "RELEASE",
+	9:              "DECLINE",
+	10:             "RECONFIGURE",
+	11:             "INFO_REQUEST",
+	dhcp6RelayForw: "RELAY_FORW",
+	dhcp6RelayRepl: "RELAY_REPL",
+}
+
+// Messages sent by the DHCPv6 servers
+var dhcp6ServerMsgTypes = map[uint8]bool{
+	2:  true,
+	7:  true,
+	10: true,
+}
+
+var errTruncated = errors.New("truncated message")
+
+func ipString(b []byte) string {
+	return net.IP(b).String()
+}
+
+// ipList returns the IP addresses of size bytes contained in b.
+func ipList(b []byte, size int) []string {
+	var ips []string
+	for ; len(b) >= size; b = b[size:] {
+		ips = append(ips, ipString(b[:size]))
+	}
+	return ips
+}
+
+// walkOptions calls fn for each option of a DHCP message. It returns the
+// value of the option overload if present.
+func walkOptions(b []byte, fn func(code uint8, value []byte)) (uint8, error) {
+	var overload uint8
+	for len(b) > 0 {
+		code := b[0]
+		if code == dhcpOptEnd {
+			break
+		}
+		if code == dhcpOptPad {
+			b = b[1:]
+			continue
+		}
+		if len(b) < 2 || len(b) < 2+int(b[1]) {
+			return 0, errTruncated
+		}
+		value := b[2 : 2+int(b[1])]
+		if code == dhcpOptOverload && len(value) == 1 {
+			overload = value[0]
+		}
+		fn(code, value)
+		b = b[2+len(value):]
+	}
+	return overload, nil
+}
+
+// parseDHCPv4 decodes the DHCP message in payload and fills the event with it.
+func parseDHCPv4(payload []byte, event *types.Event) error {
+	if len(payload) < dhcpHeaderSize+4 {
+		return errTruncated
+	}
+	if binary.BigEndian.Uint32(payload[dhcpHeaderSize:]) != dhcpMagicCookie {
+		return errors.New("invalid magic cookie")
+	}
+
+	event.Protocol = types.ProtocolDHCP
+	event.TransactionID = fmt.Sprintf("%08x", binary.BigEndian.Uint32(payload[4:8]))
+
+	// Client address to be assigned
+	if yiaddr := payload[16:20]; !net.IP(yiaddr).Equal(net.IPv4zero) {
+		event.IP = ipString(yiaddr)
+	}
+	if giaddr := payload[24:28]; !net.IP(giaddr).Equal(net.IPv4zero) {
+		event.Relay = ipString(giaddr)
+	}
+	if htype, hlen := payload[1], payload[2]; htype == dhcpHTypeEther && hlen == 6 {
+		event.ClientMAC = net.HardwareAddr(payload[28:34]).String()
+	}
+
+	var msgType uint8
+	parseOption := func(code uint8, value []byte) {
+		switch code {
+		case dhcpOptMsgType:
+			if len(value) == 1 {
+				msgType = value[0]
+			}
+		case dhcpOptMask:
+			if len(value) == 4 {
+				event.SubnetMask = ipString(value)
+			}
+		case dhcpOptRouter:
+			event.Routers = append(event.Routers, ipList(value, 4)...)
+		case dhcpOptDNS:
+			event.DNSServers = append(event.DNSServers, ipList(value, 4)...)
+		case dhcpOptHostname:
+			event.Hostname = string(value)
+		case dhcpOptRequested:
+			if len(value) == 4 {
+				event.RequestedIP = ipString(value)
+			}
+		case dhcpOptLease:
+			if len(value) == 4 {
+				event.LeaseTime = binary.BigEndian.Uint32(value)
+			}
+		case dhcpOptServerID:
+			if len(value) == 4 {
+				event.Server = ipString(value)
+			}
+		case dhcpOptRenewal:
+			if len(value) == 4 {
+				event.RenewalTime = binary.BigEndian.Uint32(value)
+			}
+		case dhcpOptRebinding:
+			if len(value) == 4 {
+				event.RebindingTime = binary.BigEndian.Uint32(value)
+			}
+		case dhcpOptClientID:
+			event.ClientID = hex.EncodeToString(value)
+		}
+	}
+
+	overload, err := walkOptions(payload[dhcpHeaderSize+4:], parseOption)
+	if err != nil {
+		return err
+	}
+	// Options can also be stored in the file and sname fields
+	if overload&1 != 0 {
+		if _, err := walkOptions(payload[108:236], parseOption); err != nil {
+			return err
+		}
+	}
+	if overload&2 != 0 {
+		if _, err := walkOptions(payload[44:108], parseOption); err != nil {
+			return err
+		}
+	}
+
+	name, ok := dhcpMsgTypeNames[msgType]
+	if !ok {
+		// BOOTP messages don't have a message type
+		return fmt.Errorf("unknown message type %d", msgType)
+	}
+	event.MessageType = name
+
+	return nil
+}
+
+// walkOptions6 calls fn for each option of a DHCPv6 message.
+func walkOptions6(b []byte, fn func(code uint16, value []byte)) error {
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return errTruncated
+		}
+		code := binary.BigEndian.Uint16(b[0:2])
+		size := int(binary.BigEndian.Uint16(b[2:4]))
+		if len(b) < 4+size {
+			return errTruncated
+		}
+		fn(code, b[4:4+size])
+		b = b[4+size:]
+	}
+	return nil
+}
+
+// duidMAC returns the link-layer address of the DUIDs based on it.
+// https://www.rfc-editor.org/rfc/rfc8415#section-11
+func duidMAC(duid []byte) string {
+	if len(duid) < 4 || binary.BigEndian.Uint16(duid[2:4]) != dhcpHTypeEther {
+		return ""
+	}
+	var lladdr []byte
+	switch binary.BigEndian.Uint16(duid[0:2]) {
+	case dhcp6DUIDLLT:
+		// The link-layer address follows the time
+		if len(duid) >= 8 {
+			lladdr = duid[8:]
+		}
+	case dhcp6DUIDLL:
+		lladdr = duid[4:]
+	}
+	if len(lladdr) != 6 {
+		return ""
+	}
+	return net.HardwareAddr(lladdr).String()
+}
+
+// fqdnName decodes the domain name of the client FQDN option: it's encoded
+// like in DNS messages, without compression.
+// https://www.rfc-editor.org/rfc/rfc4704#section-4
+func fqdnName(value []byte) string {
+	if len(value) < 1 {
+		return ""
+	}
+	var labels []string
+	for b := value[1:]; len(b) > 0 && b[0] != 0; {
+		size := int(b[0])
+		if len(b) < 1+size {
+			break
+		}
+		labels = append(labels, string(b[1:1+size]))
+		b = b[1+size:]
+	}
+	return strings.Join(labels, ".")
+}
+
+// parseIANA decodes the identity association for non-temporary addresses
+// option: the client is given the first address it contains.
+func parseIANA(value []byte, event *types.Event) error {
+	// IAID, T1 and T2
+	if len(value) < 12 {
+		return errTruncated
+	}
+	event.RenewalTime = binary.BigEndian.Uint32(value[4:8])
+	event.RebindingTime = binary.BigEndian.Uint32(value[8:12])
+
+	return walkOptions6(value[12:], func(code uint16, value []byte) {
+		// Address, preferred and valid lifetimes
+		if code != dhcp6OptIAAddr || len(value) < 24 || event.IP != "" {
+			return
+		}
+		event.IP = ipString(value[0:16])
+		event.LeaseTime = binary.BigEndian.Uint32(value[20:24])
+	})
+}
+
+// parseDHCPv6 decodes the DHCPv6 message in payload and fills the event with
+// it. The messages of the relay agents are decoded up to the message they
+// encapsulate.
+func parseDHCPv6(payload []byte, event *types.Event) error {
+	event.Protocol = types.ProtocolDHCPv6
+
+	for i := 0; ; i++ {
+		if len(payload) < 1 {
+			return errTruncated
+		}
+		msgType := payload[0]
+		if msgType != dhcp6RelayForw && msgType != dhcp6RelayRepl {
+			break
+		}
+		if i == dhcp6MaxRelays {
+			return errors.New("too many relay agents")
+		}
+		if len(payload) < dhcp6RelayHeaderSize {
+			return errTruncated
+		}
+		// Link address of the relay agent the closest to the client
+		event.Relay = ipString(payload[2:18])
+
+		var relayed []byte
+		err := walkOptions6(payload[dhcp6RelayHeaderSize:], func(code uint16, value []byte) {
+			if code == dhcp6OptRelayMsg {
+				relayed = value
+			}
+		})
+		if err != nil {
+			return err
+		}
+		if relayed == nil {
+			return errors.New("relay message without relayed message")
+		}
+		payload = relayed
+	}
+
+	if len(payload) < dhcp6HeaderSize {
+		return errTruncated
+	}
+	msgType := payload[0]
+	name, ok := dhcp6MsgTypeNames[msgType]
+	if !ok {
+		return fmt.Errorf("unknown message type %d", msgType)
+	}
+	event.MessageType = name
+	event.TransactionID = fmt.Sprintf("%06x", uint32(payload[1])<<16|uint32(payload[2])<<8|uint32(payload[3]))
+
+	// The server identifier option contains a DUID, report the address of
+	// the server instead. It isn't known for the relayed messages.
+	if dhcp6ServerMsgTypes[msgType] && event.Relay == "" {
+		event.Server = event.SrcIP
+	}
+
+	var ianaErr error
+	err := walkOptions6(payload[dhcp6HeaderSize:], func(code uint16, value []byte) {
+		switch code {
+		case dhcp6OptClientID:
+			event.ClientID = hex.EncodeToString(value)
+			event.ClientMAC = duidMAC(value)
+		case dhcp6OptIANA:
+			if event.IP == "" && ianaErr == nil {
+				ianaErr = parseIANA(value, event)
+			}
+		case dhcp6OptDNS:
+			event.DNSServers = append(event.DNSServers, ipList(value, 16)...)
+		case dhcp6OptClientFQDN:
+			event.Hostname = fqdnName(value)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return ianaErr
+}
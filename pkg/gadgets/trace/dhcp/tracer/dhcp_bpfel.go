@@ -0,0 +1,132 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64 || amd64p32 || arm || arm64 || loong64 || mips64le || mips64p32le || mipsle || ppc64le || riscv64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type dhcpEventT struct {
+	Timestamp     uint64
+	SaddrV6       [16]uint8
+	DaddrV6       [16]uint8
+	Af            uint32
+	Sport         uint16
+	Dport         uint16
+	PayloadOffset uint16
+	PayloadLen    uint16
+	PktType       uint8
+	_             [3]byte
+}
+
+// loadDhcp returns the embedded CollectionSpec for dhcp.
+func loadDhcp() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_DhcpBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load dhcp: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadDhcpObjects loads dhcp and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*dhcpObjects
+//	*dhcpPrograms
+//	*dhcpMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadDhcpObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadDhcp()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// dhcpSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type dhcpSpecs struct {
+	dhcpProgramSpecs
+	dhcpMapSpecs
+}
+
+// dhcpSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type dhcpProgramSpecs struct {
+	IgTraceDhcp *ebpf.ProgramSpec `ebpf:"ig_trace_dhcp"`
+}
+
+// dhcpMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type dhcpMapSpecs struct {
+	Events *ebpf.MapSpec `ebpf:"events"`
+}
+
+// dhcpObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadDhcpObjects or ebpf.CollectionSpec.LoadAndAssign.
+type dhcpObjects struct {
+	dhcpPrograms
+	dhcpMaps
+}
+
+func (o *dhcpObjects) Close() error {
+	return _DhcpClose(
+		&o.dhcpPrograms,
+		&o.dhcpMaps,
+	)
+}
+
+// dhcpMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadDhcpObjects or ebpf.CollectionSpec.LoadAndAssign.
+type dhcpMaps struct {
+	Events *ebpf.Map `ebpf:"events"`
+}
+
+func (m *dhcpMaps) Close() error {
+	return _DhcpClose(
+		m.Events,
+	)
+}
+
+// dhcpPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadDhcpObjects or ebpf.CollectionSpec.LoadAndAssign.
+type dhcpPrograms struct {
+	IgTraceDhcp *ebpf.Program `ebpf:"ig_trace_dhcp"`
+}
+
+func (p *dhcpPrograms) Close() error {
+	return _DhcpClose(
+		p.IgTraceDhcp,
+	)
+}
+
+func _DhcpClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed dhcp_bpfel.o
+var _DhcpBytes []byte
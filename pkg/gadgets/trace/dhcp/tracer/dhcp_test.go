@@ -0,0 +1,162 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dhcp/types"
+)
+
+func dhcpOption(code uint8, value ...byte) []byte {
+	return append([]byte{code, uint8(len(value))}, value...)
+}
+
+func dhcp6Option(code uint16, value ...byte) []byte {
+	b := binary.BigEndian.AppendUint16(nil, code)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(value)))
+	return append(b, value...)
+}
+
+func concat(parts ...[]byte) []byte {
+	var b []byte
+	for _, part := range parts {
+		b = append(b, part...)
+	}
+	return b
+}
+
+func TestParseDHCPv4(t *testing.T) {
+	header := make([]byte, dhcpHeaderSize)
+	header[0] = 2 // BOOTREPLY
+	header[1] = dhcpHTypeEther
+	header[2] = 6
+	copy(header[4:8], []byte{0x3a, 0x5b, 0x7c, 0x9d})
+	copy(header[16:20], []byte{192, 168, 1, 42})
+	copy(header[28:34], []byte{0x52, 0x54, 0x00, 0x12, 0x34, 0x56})
+
+	payload := concat(
+		header,
+		binary.BigEndian.AppendUint32(nil, dhcpMagicCookie),
+		dhcpOption(dhcpOptMsgType, 5),
+		dhcpOption(dhcpOptServerID, 192, 168, 1, 1),
+		dhcpOption(dhcpOptLease, 0, 0, 0x0e, 0x10),
+		dhcpOption(dhcpOptRenewal, 0, 0, 0x07, 0x08),
+		[]byte{dhcpOptPad},
+		dhcpOption(dhcpOptMask, 255, 255, 255, 0),
+		dhcpOption(dhcpOptRouter, 192, 168, 1, 1),
+		dhcpOption(dhcpOptDNS, 8, 8, 8, 8, 1, 1, 1, 1),
+		dhcpOption(dhcpOptHostname, []byte("vm1")...),
+		[]byte{dhcpOptEnd, 0, 0, 0},
+	)
+
+	event := &types.Event{}
+	if err := parseDHCPv4(payload, event); err != nil {
+		t.Fatalf("Could not decode message: %s", err)
+	}
+	expected := &types.Event{
+		Protocol:      types.ProtocolDHCP,
+		MessageType:   "ACK",
+		TransactionID: "3a5b7c9d",
+		ClientMAC:     "52:54:00:12:34:56",
+		Hostname:      "vm1",
+		IP:            "192.168.1.42",
+		Server:        "192.168.1.1",
+		SubnetMask:    "255.255.255.0",
+		Routers:       []string{"192.168.1.1"},
+		DNSServers:    []string{"8.8.8.8", "1.1.1.1"},
+		LeaseTime:     3600,
+		RenewalTime:   1800,
+	}
+	if !reflect.DeepEqual(event, expected) {
+		t.Fatalf("Invalid event %+v. Expecting %+v", event, expected)
+	}
+
+	// Truncated options are reported
+	if err := parseDHCPv4(payload[:len(payload)-10], &types.Event{}); err == nil {
+		t.Fatalf("Truncated message was decoded")
+	}
+
+	// BOOTP messages aren't DHCP messages
+	if err := parseDHCPv4(concat(header, binary.BigEndian.AppendUint32(nil, dhcpMagicCookie)), &types.Event{}); err == nil {
+		t.Fatalf("Message without message type was decoded")
+	}
+}
+
+func TestParseDHCPv6(t *testing.T) {
+	clientDUID := []byte{0, dhcp6DUIDLL, 0, dhcpHTypeEther, 0x52, 0x54, 0x00, 0x12, 0x34, 0x56}
+	iaAddr := concat(
+		[]byte{0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x42},
+		[]byte{0, 0, 0x0e, 0x10}, // preferred lifetime
+		[]byte{0, 0, 0x1c, 0x20}, // valid lifetime
+	)
+	reply := concat(
+		[]byte{7, 0x12, 0x34, 0x56},
+		dhcp6Option(dhcp6OptClientID, clientDUID...),
+		dhcp6Option(dhcp6OptIANA, concat(
+			[]byte{0, 0, 0, 1},       // IAID
+			[]byte{0, 0, 0x07, 0x08}, // T1
+			[]byte{0, 0, 0x0b, 0x40}, // T2
+			dhcp6Option(dhcp6OptIAAddr, iaAddr...),
+		)...),
+		dhcp6Option(dhcp6OptDNS, []byte{0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x01}...),
+		dhcp6Option(dhcp6OptClientFQDN, concat([]byte{0}, []byte{3}, []byte("vm1"), []byte{3}, []byte("lan"), []byte{0})...),
+	)
+
+	event := &types.Event{SrcIP: "fe80::1"}
+	if err := parseDHCPv6(reply, event); err != nil {
+		t.Fatalf("Could not decode message: %s", err)
+	}
+	expected := &types.Event{
+		SrcIP:         "fe80::1",
+		Protocol:      types.ProtocolDHCPv6,
+		MessageType:   "REPLY",
+		TransactionID: "123456",
+		ClientMAC:     "52:54:00:12:34:56",
+		ClientID:      "00030001525400123456",
+		Hostname:      "vm1.lan",
+		IP:            "2001:db8::42",
+		Server:        "fe80::1",
+		DNSServers:    []string{"2001:db8::1"},
+		LeaseTime:     7200,
+		RenewalTime:   1800,
+		RebindingTime: 2880,
+	}
+	if !reflect.DeepEqual(event, expected) {
+		t.Fatalf("Invalid event %+v. Expecting %+v", event, expected)
+	}
+
+	// Relayed messages are decoded up to the client's message
+	relayed := concat(
+		[]byte{dhcp6RelayRepl, 0},
+		[]byte{0x20, 0x01, 0x0d, 0xb8, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x02}, // link address
+		[]byte{0xfe, 0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x03},       // peer address
+		dhcp6Option(dhcp6OptRelayMsg, reply...),
+	)
+	event = &types.Event{SrcIP: "2001:db8::1"}
+	if err := parseDHCPv6(relayed, event); err != nil {
+		t.Fatalf("Could not decode relayed message: %s", err)
+	}
+	if event.MessageType != "REPLY" || event.Relay != "2001:db8::2" || event.Server != "" || event.IP != "2001:db8::42" {
+		t.Fatalf("Invalid relayed event %+v", event)
+	}
+
+	// Truncated options are reported
+	if err := parseDHCPv6(reply[:len(reply)-3], &types.Event{}); err == nil {
+		t.Fatalf("Truncated message was decoded")
+	}
+}
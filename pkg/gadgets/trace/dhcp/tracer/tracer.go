@@ -0,0 +1,171 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/networktracer"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dhcp/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate bash -c "source ./clangosflags.sh; go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -cc clang -type event_t dhcp ./bpf/dhcp.c -- $CLANG_OS_FLAGS -I./bpf/"
+
+const (
+	BPFProgName     = "ig_trace_dhcp"
+	BPFPerfMapName  = "events"
+	BPFSocketAttach = 50
+)
+
+type Tracer struct {
+	*networktracer.Tracer[types.Event]
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewTracer() (*Tracer, error) {
+	t := &Tracer{}
+
+	if err := t.install(); err != nil {
+		t.Close()
+		return nil, fmt.Errorf("installing tracer: %w", err)
+	}
+
+	return t, nil
+}
+
+// pkt_type definitions:
+// https://github.com/torvalds/linux/blob/v5.14-rc7/include/uapi/linux/if_packet.h#L26
+var pktTypeNames = []string{
+	"HOST",
+	"BROADCAST",
+	"MULTICAST",
+	"OTHERHOST",
+	"OUTGOING",
+	"LOOPBACK",
+	"USER",
+	"KERNEL",
+}
+
+func parseDHCPEvent(sample []byte, netns uint64) (*types.Event, error) {
+	bpfEvent := (*dhcpEventT)(unsafe.Pointer(&sample[0]))
+	eventSize := int(unsafe.Sizeof(*bpfEvent))
+	if len(sample) < eventSize {
+		return nil, errors.New("invalid sample size")
+	}
+
+	// The packet follows the event
+	packet := sample[eventSize:]
+	start := int(bpfEvent.PayloadOffset)
+	end := start + int(bpfEvent.PayloadLen)
+	if end > len(packet) {
+		return nil, errors.New("truncated packet")
+	}
+	payload := packet[start:end]
+
+	event := types.Event{
+		Event: eventtypes.Event{
+			Type:      eventtypes.NORMAL,
+			Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+		},
+		WithNetNsID: eventtypes.WithNetNsID{NetNsID: netns},
+	}
+
+	event.PktType = "UNKNOWN"
+	if pktTypeUint := uint(bpfEvent.PktType); pktTypeUint < uint(len(pktTypeNames)) {
+		event.PktType = pktTypeNames[pktTypeUint]
+	}
+
+	var err error
+	switch bpfEvent.Af {
+	case syscall.AF_INET:
+		event.SrcIP = gadgets.IPStringFromBytes(bpfEvent.SaddrV6, 4)
+		event.DstIP = gadgets.IPStringFromBytes(bpfEvent.DaddrV6, 4)
+		err = parseDHCPv4(payload, &event)
+	case syscall.AF_INET6:
+		event.SrcIP = gadgets.IPStringFromBytes(bpfEvent.SaddrV6, 6)
+		event.DstIP = gadgets.IPStringFromBytes(bpfEvent.DaddrV6, 6)
+		err = parseDHCPv6(payload, &event)
+	default:
+		err = fmt.Errorf("unknown address family %d", bpfEvent.Af)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s message: %w", event.Protocol, err)
+	}
+
+	return &event, nil
+}
+
+// --- Registry changes
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return &Tracer{}, nil
+}
+
+func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
+	if err := t.install(); err != nil {
+		t.Close()
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	t.ctx, t.cancel = gadgetcontext.WithTimeoutOrCancel(gadgetCtx.Context(), gadgetCtx.Timeout())
+	return nil
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadDhcp()
+	if err != nil {
+		return fmt.Errorf("loading asset: %w", err)
+	}
+
+	networkTracer, err := networktracer.NewTracer(
+		spec,
+		BPFProgName,
+		BPFPerfMapName,
+		BPFSocketAttach,
+		types.Base,
+		parseDHCPEvent,
+	)
+	if err != nil {
+		return fmt.Errorf("creating network tracer: %w", err)
+	}
+	t.Tracer = networkTracer
+	return nil
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	<-t.ctx.Done()
+	return nil
+}
+
+func (t *Tracer) Close() {
+	if t.cancel != nil {
+		t.cancel()
+	}
+
+	if t.Tracer != nil {
+		t.Tracer.Close()
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/environment"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	ProtocolDHCP   = "DHCP"
+	ProtocolDHCPv6 = "DHCPv6"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithNetNsID
+
+	Protocol      string `json:"protocol,omitempty" column:"proto,width:6,fixed"`
+	MessageType   string `json:"messageType,omitempty" column:"msgtype,minWidth:7,maxWidth:12"`
+	TransactionID string `json:"transactionID,omitempty" column:"xid,width:8,fixed"`
+	SrcIP         string `json:"srcIP,omitempty" column:"srcip,template:ipaddr,hide"`
+	DstIP         string `json:"dstIP,omitempty" column:"dstip,template:ipaddr,hide"`
+	PktType       string `json:"pktType,omitempty" column:"pkttype,minWidth:4,maxWidth:9,hide"`
+
+	// ClientMAC is the hardware address of the client. For DHCPv6, it's
+	// only known when the DUID of the client is based on it.
+	ClientMAC string `json:"clientMAC,omitempty" column:"clientmac,width:17,fixed"`
+	// ClientID is the client identifier option for DHCP and the DUID of the
+	// client for DHCPv6, in hexadecimal
+	ClientID string `json:"clientID,omitempty" column:"clientid,minWidth:12,maxWidth:40,hide"`
+	Hostname string `json:"hostname,omitempty" column:"hostname,minWidth:8,maxWidth:32,hide"`
+
+	// IP is the address offered or assigned to the client
+	IP          string `json:"ip,omitempty" column:"ip,template:ipaddr"`
+	RequestedIP string `json:"requestedIP,omitempty" column:"requestedip,template:ipaddr,hide"`
+	// Server is the server identifier option for DHCP and the address of
+	// the server for DHCPv6
+	Server string `json:"server,omitempty" column:"server,template:ipaddr"`
+	// Relay is the address of the relay agent, if any
+	Relay string `json:"relay,omitempty" column:"relay,template:ipaddr,hide"`
+
+	SubnetMask string   `json:"subnetMask,omitempty" column:"mask,template:ipaddr,hide"`
+	Routers    []string `json:"routers,omitempty" column:"routers,hide"`
+	DNSServers []string `json:"dnsServers,omitempty" column:"dns,hide"`
+
+	// Lease, renewal (T1) and rebinding (T2) times, in seconds. For DHCPv6,
+	// the lease time is the valid lifetime of the address.
+	LeaseTime     uint32 `json:"leaseTime,omitempty" column:"lease,minWidth:5,maxWidth:10"`
+	RenewalTime   uint32 `json:"renewalTime,omitempty" column:"renewal,minWidth:5,maxWidth:10,hide"`
+	RebindingTime uint32 `json:"rebindingTime,omitempty" column:"rebinding,minWidth:5,maxWidth:10,hide"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	// Hide container column for kubernetes environment
+	if environment.Environment == environment.Kubernetes {
+		col, _ := cols.GetColumn("container")
+		col.Visible = false
+	}
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
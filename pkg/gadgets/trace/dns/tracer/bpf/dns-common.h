@@ -28,6 +28,8 @@ struct event_t {
 		__u32 daddr_v4;
 	};
 	__u32 af; // AF_INET or AF_INET6
+	__u16 sport;
+	__u16 dport;
 
 	__u16 id;
 	unsigned short qtype;
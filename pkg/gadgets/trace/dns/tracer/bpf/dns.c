@@ -168,6 +168,8 @@ output_dns_event(struct __sk_buff *skb, union dnsflags flags, __u32 name_len, __
 	// network endianness because inet_ntop() requires it.
 	event->daddr_v4 = bpf_htonl(event->daddr_v4);
 	event->saddr_v4 = bpf_htonl(event->saddr_v4);
+	event->sport = load_half(skb, ETH_HLEN + sizeof(struct iphdr) + offsetof(struct udphdr, source));
+	event->dport = load_half(skb, ETH_HLEN + sizeof(struct iphdr) + offsetof(struct udphdr, dest));
 
 	event->qr = flags.qr;
 
@@ -21,6 +21,8 @@ type dnsEventT struct {
 	SaddrV6     [16]uint8
 	DaddrV6     [16]uint8
 	Af          uint32
+	Sport       uint16
+	Dport       uint16
 	Id          uint16
 	Qtype       uint16
 	Qr          uint8
@@ -30,7 +32,7 @@ type dnsEventT struct {
 	Ancount     uint16
 	Anaddrcount uint16
 	Anaddr      [8][16]uint8
-	_           [2]byte
+	_           [6]byte
 }
 
 type dnsSocketsKey struct {
@@ -216,6 +216,8 @@ func bpfEventToDNSEvent(bpfEvent *dnsEventT, netns uint64) (*types.Event, error)
 
 	if bpfEvent.Qr == 1 {
 		event.Qr = types.DNSPktTypeResponse
+		event.NameserverPort = bpfEvent.Sport
+		event.ClientPort = bpfEvent.Dport
 		if bpfEvent.Af == syscall.AF_INET {
 			event.Nameserver = gadgets.IPStringFromBytes(bpfEvent.SaddrV6, 4)
 		} else if bpfEvent.Af == syscall.AF_INET6 {
@@ -223,6 +225,8 @@ func bpfEventToDNSEvent(bpfEvent *dnsEventT, netns uint64) (*types.Event, error)
 		}
 	} else {
 		event.Qr = types.DNSPktTypeQuery
+		event.NameserverPort = bpfEvent.Dport
+		event.ClientPort = bpfEvent.Sport
 		if bpfEvent.Af == syscall.AF_INET {
 			event.Nameserver = gadgets.IPStringFromBytes(bpfEvent.DaddrV6, 4)
 		} else if bpfEvent.Af == syscall.AF_INET6 {
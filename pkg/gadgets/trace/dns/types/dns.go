@@ -39,16 +39,20 @@ type Event struct {
 	Tid  uint32 `json:"tid,omitempty" column:"tid,template:pid"`
 	Comm string `json:"comm,omitempty" column:"comm,template:comm"`
 
-	ID         string        `json:"id,omitempty" column:"id,width:4,fixed,hide"`
-	Qr         DNSPktType    `json:"qr,omitempty" column:"qr,width:2,fixed"`
-	Nameserver string        `json:"nameserver,omitempty" column:"nameserver,template:ipaddr,hide"`
-	PktType    string        `json:"pktType,omitempty" column:"type,minWidth:7,maxWidth:9"`
-	QType      string        `json:"qtype,omitempty" column:"qtype,minWidth:5,maxWidth:10"`
-	DNSName    string        `json:"name,omitempty" column:"name,width:30"`
-	Rcode      string        `json:"rcode,omitempty" column:"rcode,minWidth:8"`
-	Latency    time.Duration `json:"latency,omitempty" column:"latency,hide"`
-	NumAnswers int           `json:"numAnswers,omitempty" column:"numAnswers,width:8,maxWidth:8" columnDesc:"Number of addresses contained in the response."`
-	Addresses  []string      `json:"addresses,omitempty" column:"addresses,width:32,hide" columnDesc:"Addresses in the response. Maximum 8 are reported. Only available if the response is compressed."`
+	ID         string     `json:"id,omitempty" column:"id,width:4,fixed,hide"`
+	Qr         DNSPktType `json:"qr,omitempty" column:"qr,width:2,fixed"`
+	Nameserver string     `json:"nameserver,omitempty" column:"nameserver,template:ipaddr,hide"`
+	// NameserverPort and ClientPort are the UDP ports of the nameserver and
+	// of the socket sending the query, whatever the direction of the packet
+	NameserverPort uint16        `json:"nameserverPort,omitempty" column:"nameserverport,template:ipport,hide"`
+	ClientPort     uint16        `json:"clientPort,omitempty" column:"clientport,template:ipport,hide"`
+	PktType        string        `json:"pktType,omitempty" column:"type,minWidth:7,maxWidth:9"`
+	QType          string        `json:"qtype,omitempty" column:"qtype,minWidth:5,maxWidth:10"`
+	DNSName        string        `json:"name,omitempty" column:"name,width:30"`
+	Rcode          string        `json:"rcode,omitempty" column:"rcode,minWidth:8"`
+	Latency        time.Duration `json:"latency,omitempty" column:"latency,hide"`
+	NumAnswers     int           `json:"numAnswers,omitempty" column:"numAnswers,width:8,maxWidth:8" columnDesc:"Number of addresses contained in the response."`
+	Addresses      []string      `json:"addresses,omitempty" column:"addresses,width:32,hide" columnDesc:"Addresses in the response. Maximum 8 are reported. Only available if the response is compressed."`
 }
 
 func GetColumns() *columns.Columns[Event] {
@@ -78,6 +82,34 @@ func GetColumns() *columns.Columns[Event] {
 	return cols
 }
 
+// GetSpan returns the span from a query to its response, nil for the queries
+// and the responses without latency
+func (e *Event) GetSpan() *eventtypes.Span {
+	if e.Qr != DNSPktTypeResponse || e.Latency <= 0 {
+		return nil
+	}
+
+	end := time.Unix(0, int64(e.Timestamp))
+	span := &eventtypes.Span{
+		Name:  "DNS " + e.QType,
+		Kind:  eventtypes.SpanKindClient,
+		Start: end.Add(-e.Latency),
+		End:   end,
+		Attributes: map[string]any{
+			"dns.question.name": e.DNSName,
+			"dns.question.type": e.QType,
+			"dns.response_code": e.Rcode,
+			"server.address":    e.Nameserver,
+			"process.pid":       e.Pid,
+			"process.command":   e.Comm,
+		},
+	}
+	if e.Rcode != "NoError" {
+		span.Error = e.Rcode
+	}
+	return span
+}
+
 func Base(ev eventtypes.Event) *Event {
 	return &Event{
 		Event: ev,
@@ -7,10 +7,15 @@
 #endif /* __TARGET_ARCH_arm64 */
 #include "execsnoop.h"
 #include "mntns_filter.h"
+#include "cgroup_filter.h"
 
 const volatile bool ignore_failed = true;
 const volatile uid_t targ_uid = INVALID_UID;
 const volatile int max_args = DEFAULT_MAXARGS;
+const volatile bool capture_cwd = false;
+const volatile bool capture_env = false;
+
+#define MAX_PATH_DEPTH 32
 
 static const struct event empty_event = {};
 
@@ -61,6 +66,9 @@ int ig_execve_e(struct trace_event_raw_sys_enter* ctx)
 	if (gadget_should_discard_mntns_id(mntns_id))
 		return 0;
 
+	if (gadget_should_discard_cgroup())
+		return 0;
+
 	id = bpf_get_current_pid_tgid();
 	pid = (pid_t)id;
 	tgid = id >> 32;
@@ -120,6 +128,70 @@ int ig_execve_e(struct trace_event_raw_sys_enter* ctx)
 	return 0;
 }
 
+// Walk the dentries from the cwd of the task to the root, crossing the mount
+// points, and write the name of each of them to buf. The components are
+// written from the cwd to the root, each one terminated by a NUL, the caller
+// has to reverse them. The path is truncated if it's deeper than
+// MAX_PATH_DEPTH or longer than PATH_MAX_LEN.
+static __always_inline __u32 get_path(struct task_struct *task, __u8 *buf)
+{
+	struct dentry *dentry = BPF_CORE_READ(task, fs, pwd.dentry);
+	struct vfsmount *vfsmnt = BPF_CORE_READ(task, fs, pwd.mnt);
+	struct mount *mnt = container_of(vfsmnt, struct mount, mnt);
+	struct dentry *mnt_root, *parent;
+	struct mount *mnt_parent;
+	const unsigned char *name;
+	__u32 off = 0;
+	int len;
+
+	#pragma unroll
+	for (int i = 0; i < MAX_PATH_DEPTH; i++) {
+		mnt_root = BPF_CORE_READ(mnt, mnt.mnt_root);
+		if (dentry == mnt_root) {
+			mnt_parent = BPF_CORE_READ(mnt, mnt_parent);
+			// root of the mount namespace
+			if (mnt_parent == mnt)
+				break;
+			dentry = BPF_CORE_READ(mnt, mnt_mountpoint);
+			mnt = mnt_parent;
+			continue;
+		}
+
+		parent = BPF_CORE_READ(dentry, d_parent);
+		if (dentry == parent || off >= PATH_MAX_LEN)
+			break;
+
+		name = BPF_CORE_READ(dentry, d_name.name);
+		len = bpf_probe_read_kernel_str(buf + (off & (PATH_MAX_LEN - 1)),
+						NAME_MAX + 1, name);
+		if (len <= 0)
+			break;
+		off += len;
+		dentry = parent;
+	}
+
+	return off;
+}
+
+// Copy the environment of the task, which is the one of the new program once
+// execve() returned, to buf. It's truncated if it's longer than ENV_MAX_LEN.
+static __always_inline __u32 get_env(struct task_struct *task, __u8 *buf)
+{
+	unsigned long start = BPF_CORE_READ(task, mm, env_start);
+	unsigned long end = BPF_CORE_READ(task, mm, env_end);
+	unsigned long len;
+
+	if (end <= start)
+		return 0;
+	len = end - start;
+	if (len > ENV_MAX_LEN)
+		len = ENV_MAX_LEN;
+
+	if (bpf_probe_read_user(buf, len, (const void *)start))
+		return 0;
+	return len;
+}
+
 #ifdef __TARGET_ARCH_arm64
 SEC("kretprobe/do_execveat_common.isra.0")
 int BPF_KRETPROBE(ig_execveat_x)
@@ -132,6 +204,8 @@ int ig_execve_x(struct trace_event_raw_sys_exit* ctx)
 	pid_t pid;
 	int ret;
 	struct event *event;
+	struct task_struct *task;
+	__u64 off, cwd_len;
 	u32 uid = (u32)bpf_get_current_uid_gid();
 
 	if (valid_uid(targ_uid) && targ_uid != uid)
@@ -151,6 +225,23 @@ int ig_execve_x(struct trace_event_raw_sys_exit* ctx)
 
 	event->retval = ret;
 	bpf_get_current_comm(&event->comm, sizeof(event->comm));
+
+	event->cwd_len = 0;
+	event->env_size = 0;
+	task = (struct task_struct*)bpf_get_current_task();
+	off = event->args_size;
+	if (off > FULL_MAX_ARGS_ARR)
+		goto cleanup;
+	if (capture_cwd) {
+		cwd_len = get_path(task, &event->args[off]);
+		event->cwd_len = cwd_len;
+		off += cwd_len;
+	}
+	if (off > FULL_MAX_ARGS_ARR + CWD_MAX_LEN)
+		goto cleanup;
+	if (capture_env)
+		event->env_size = get_env(task, &event->args[off]);
+
 	size_t len = EVENT_SIZE(event);
 	if (len <= sizeof(*event))
 		bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, len);
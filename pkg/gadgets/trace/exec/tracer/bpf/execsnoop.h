@@ -9,8 +9,13 @@
 #define FULL_MAX_ARGS_ARR (TOTAL_MAX_ARGS * ARGSIZE)
 #define INVALID_UID ((uid_t)-1)
 #define BASE_EVENT_SIZE (size_t)(&((struct event*)0)->args)
-#define EVENT_SIZE(e) (BASE_EVENT_SIZE + e->args_size)
+#define EVENT_SIZE(e) (BASE_EVENT_SIZE + e->args_size + e->cwd_len + e->env_size)
 #define LAST_ARG (FULL_MAX_ARGS_ARR - ARGSIZE)
+#define NAME_MAX 255
+/* must be a power of two, see get_path() */
+#define PATH_MAX_LEN 512
+#define CWD_MAX_LEN (PATH_MAX_LEN + NAME_MAX + 1)
+#define ENV_MAX_LEN 4096
 
 struct event {
 	__u64 mntns_id;
@@ -21,8 +26,16 @@ struct event {
 	int retval;
 	int args_count;
 	unsigned int args_size;
+	unsigned int cwd_len;
+	unsigned int env_size;
 	__u8 comm[TASK_COMM_LEN];
-	__u8 args[FULL_MAX_ARGS_ARR];
+	/*
+	 * args_size bytes of arguments, followed by cwd_len bytes of the
+	 * components of the cwd, from the cwd to the root, separated by NUL, and
+	 * by env_size bytes of environment, in the same format as
+	 * /proc/$PID/environ.
+	 */
+	__u8 args[FULL_MAX_ARGS_ARR + CWD_MAX_LEN + ENV_MAX_LEN];
 };
 
 #endif /* __EXECSNOOP_H */
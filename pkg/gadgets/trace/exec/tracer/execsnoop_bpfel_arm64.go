@@ -21,8 +21,10 @@ type execsnoopEvent struct {
 	Retval    int32
 	ArgsCount int32
 	ArgsSize  uint32
+	CwdLen    uint32
+	EnvSize   uint32
 	Comm      [16]uint8
-	Args      [7680]uint8
+	Args      [12544]uint8
 }
 
 // loadExecsnoop returns the embedded CollectionSpec for execsnoop.
@@ -74,9 +76,10 @@ type execsnoopProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type execsnoopMapSpecs struct {
-	Events               *ebpf.MapSpec `ebpf:"events"`
-	Execs                *ebpf.MapSpec `ebpf:"execs"`
-	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Events                *ebpf.MapSpec `ebpf:"events"`
+	Execs                 *ebpf.MapSpec `ebpf:"execs"`
+	GadgetCgroupFilterMap *ebpf.MapSpec `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
 }
 
 // execsnoopObjects contains all objects after they have been loaded into the kernel.
@@ -98,15 +101,17 @@ func (o *execsnoopObjects) Close() error {
 //
 // It can be passed to loadExecsnoopObjects or ebpf.CollectionSpec.LoadAndAssign.
 type execsnoopMaps struct {
-	Events               *ebpf.Map `ebpf:"events"`
-	Execs                *ebpf.Map `ebpf:"execs"`
-	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Events                *ebpf.Map `ebpf:"events"`
+	Execs                 *ebpf.Map `ebpf:"execs"`
+	GadgetCgroupFilterMap *ebpf.Map `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
 }
 
 func (m *execsnoopMaps) Close() error {
 	return _ExecsnoopClose(
 		m.Events,
 		m.Execs,
+		m.GadgetCgroupFilterMap,
 		m.GadgetMntnsFilterMap,
 	)
 }
@@ -22,6 +22,11 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
 )
 
+const (
+	ParamCwd = "cwd"
+	ParamEnv = "env"
+)
+
 type GadgetDesc struct{}
 
 func (g *GadgetDesc) Name() string {
@@ -41,7 +46,20 @@ func (g *GadgetDesc) Description() string {
 }
 
 func (g *GadgetDesc) ParamDescs() params.ParamDescs {
-	return nil
+	return params.ParamDescs{
+		{
+			Key:          ParamCwd,
+			Title:        "Current Working Directory",
+			Description:  "Capture the current working directory of the processes",
+			DefaultValue: "false",
+			TypeHint:     params.TypeBool,
+		},
+		{
+			Key:         ParamEnv,
+			Title:       "Environment Variables",
+			Description: "Comma-separated list of environment variables to capture (e.g. PATH,LD_PRELOAD)",
+		},
+	}
 }
 
 func (g *GadgetDesc) Parser() parser.Parser {
@@ -52,6 +70,15 @@ func (g *GadgetDesc) EventPrototype() any {
 	return &types.Event{}
 }
 
+func (g *GadgetDesc) Cost() gadgets.Cost {
+	return gadgets.Cost{
+		Probes:     2,
+		Events:     "every execve()",
+		EventCost:  gadgets.CostLow,
+		BufferSize: gadgets.PerfBufferSize(),
+	}
+}
+
 func init() {
 	gadgetregistry.Register(&GadgetDesc{})
 }
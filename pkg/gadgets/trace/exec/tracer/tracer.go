@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"unsafe"
 
 	"github.com/cilium/ebpf"
@@ -36,6 +37,15 @@ import (
 
 type Config struct {
 	MountnsMap *ebpf.Map
+
+	// CgroupPath, if set, restricts the events to processes running in that
+	// cgroup or in one of its descendants.
+	CgroupPath string
+
+	// CaptureCwd captures the current working directory of the processes
+	CaptureCwd bool
+	// Env is the list of environment variables to capture, none if empty
+	Env []string
 }
 
 type Tracer struct {
@@ -91,10 +101,22 @@ func (t *Tracer) install() error {
 		return fmt.Errorf("loading ebpf program: %w", err)
 	}
 
-	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+	consts := map[string]interface{}{
+		gadgets.FilterByCgroupName: t.config.CgroupPath != "",
+		"capture_cwd":              t.config.CaptureCwd,
+		"capture_env":              len(t.config.Env) > 0,
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, consts, &t.objs); err != nil {
 		return fmt.Errorf("loading ebpf spec: %w", err)
 	}
 
+	if t.config.CgroupPath != "" {
+		if err := gadgets.SetCgroupFilter(t.objs.GadgetCgroupFilterMap, t.config.CgroupPath); err != nil {
+			return err
+		}
+	}
+
 	t.enterLink, t.exitLink, err = loadExecsnoopLinks(t.objs)
 	if err != nil {
 		return err
@@ -110,6 +132,11 @@ func (t *Tracer) install() error {
 }
 
 func (t *Tracer) run() {
+	allowedEnv := make(map[string]struct{}, len(t.config.Env))
+	for _, name := range t.config.Env {
+		allowedEnv[name] = struct{}{}
+	}
+
 	for {
 		record, err := t.reader.Read()
 		if err != nil {
@@ -158,6 +185,15 @@ func (t *Tracer) run() {
 			}
 		}
 
+		off := int(bpfEvent.ArgsSize)
+		if bpfEvent.CwdLen > 0 {
+			event.Cwd = gadgets.PathFromComponents(bpfEvent.Args[off : off+int(bpfEvent.CwdLen)])
+			off += int(bpfEvent.CwdLen)
+		}
+		if bpfEvent.EnvSize > 0 {
+			event.Env = filterEnv(bpfEvent.Args[off:off+int(bpfEvent.EnvSize)], allowedEnv)
+		}
+
 		if t.enricher != nil {
 			t.enricher.EnrichByMntNs(&event.CommonData, event.MountNsID)
 		}
@@ -166,9 +202,32 @@ func (t *Tracer) run() {
 	}
 }
 
+// filterEnv returns the variables of the environment, as NAME=value, whose
+// name is allowed. The last variable is ignored if it was truncated.
+func filterEnv(buf []byte, allowed map[string]struct{}) []string {
+	truncated := buf[len(buf)-1] != 0
+	vars := strings.Split(strings.TrimSuffix(string(buf), "\x00"), "\x00")
+	if truncated {
+		vars = vars[:len(vars)-1]
+	}
+
+	var env []string
+	for _, v := range vars {
+		name, _, _ := strings.Cut(v, "=")
+		if _, ok := allowed[name]; ok {
+			env = append(env, v)
+		}
+	}
+	return env
+}
+
 // --- Registry changes
 
 func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.CaptureCwd = params.Get(ParamCwd).AsBool()
+	t.config.Env = params.Get(ParamEnv).AsStringSlice()
+
 	defer t.close()
 	if err := t.install(); err != nil {
 		return fmt.Errorf("installing tracer: %w", err)
@@ -184,6 +243,10 @@ func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
 	t.config.MountnsMap = mountnsMap
 }
 
+func (t *Tracer) SetCgroupFilter(cgroupPath string) {
+	t.config.CgroupPath = cgroupPath
+}
+
 func (t *Tracer) SetEventHandler(handler any) {
 	nh, ok := handler.(func(ev *types.Event))
 	if !ok {
@@ -19,6 +19,7 @@ package tracer_test
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"sort"
 	"testing"
@@ -27,6 +28,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 
 	utilstest "github.com/inspektor-gadget/inspektor-gadget/internal/test"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/cgroups"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/tracer"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
@@ -127,6 +129,75 @@ func TestExecTracer(t *testing.T) {
 				}
 			}),
 		},
+		"captures_events_with_matching_cgroup": {
+			getTracerConfig: func(info *utilstest.RunnerInfo) *tracer.Config {
+				// All processes are descendants of the root cgroup
+				cgroupPath, err := cgroups.CgroupPathV2AddMountpoint("/")
+				if err != nil {
+					t.Fatalf("Getting cgroup2 mountpoint: %s", err)
+				}
+
+				return &tracer.Config{
+					MountnsMap: utilstest.CreateMntNsFilterMap(t, info.MountNsID),
+					CgroupPath: cgroupPath,
+				}
+			},
+			generateEvent: generateEvent,
+			validateEvent: utilstest.ExpectOneEvent(func(info *utilstest.RunnerInfo, catPid int) *types.Event {
+				return &types.Event{
+					Event: eventtypes.Event{
+						Type: eventtypes.NORMAL,
+					},
+					Pid:           uint32(catPid),
+					Ppid:          uint32(info.Pid),
+					Uid:           uint32(info.Uid),
+					WithMountNsID: eventtypes.WithMountNsID{MountNsID: info.MountNsID},
+					Retval:        0,
+					Comm:          "cat",
+					Args:          []string{"/bin/cat", "/dev/null"},
+				}
+			}),
+		},
+		"captures_no_events_with_no_matching_cgroup": {
+			getTracerConfig: func(info *utilstest.RunnerInfo) *tracer.Config {
+				return &tracer.Config{
+					MountnsMap: utilstest.CreateMntNsFilterMap(t, info.MountNsID),
+					CgroupPath: createCgroup(t),
+				}
+			},
+			generateEvent: generateEvent,
+			validateEvent: utilstest.ExpectNoEvent[types.Event, int],
+		},
+		"captures_cwd_and_allowed_env": {
+			getTracerConfig: func(info *utilstest.RunnerInfo) *tracer.Config {
+				return &tracer.Config{
+					MountnsMap: utilstest.CreateMntNsFilterMap(t, info.MountNsID),
+					CaptureCwd: true,
+					Env:        []string{"IG_TEST_ENV"},
+				}
+			},
+			generateEvent: func() (int, error) {
+				cmd := exec.Command("/bin/cat", "/dev/null")
+				cmd.Dir = "/dev"
+				cmd.Env = []string{"IG_TEST_OTHER=bar", "IG_TEST_ENV=foo"}
+				if err := cmd.Run(); err != nil {
+					return 0, fmt.Errorf("running command: %w", err)
+				}
+
+				return cmd.Process.Pid, nil
+			},
+			validateEvent: func(t *testing.T, info *utilstest.RunnerInfo, _ int, events []types.Event) {
+				if len(events) != 1 {
+					t.Fatalf("One event expected")
+				}
+
+				utilstest.Equal(t, "/dev", events[0].Cwd,
+					"Event has bad cwd")
+				if diff := cmp.Diff(events[0].Env, []string{"IG_TEST_ENV=foo"}); diff != "" {
+					t.Fatalf("Event has bad env, diff: \n%s", diff)
+				}
+			},
+		},
 		"event_has_UID_of_user_generating_event": {
 			getTracerConfig: func(info *utilstest.RunnerInfo) *tracer.Config {
 				return &tracer.Config{
@@ -299,3 +370,21 @@ func generateEvent() (int, error) {
 
 	return cmd.Process.Pid, nil
 }
+
+// createCgroup creates an empty cgroup that is removed when the test finishes.
+func createCgroup(t *testing.T) string {
+	t.Helper()
+
+	root, err := cgroups.CgroupPathV2AddMountpoint("/")
+	if err != nil {
+		t.Fatalf("Getting cgroup2 mountpoint: %s", err)
+	}
+
+	path, err := os.MkdirTemp(root, "ig-test-exec-")
+	if err != nil {
+		t.Fatalf("Creating cgroup: %s", err)
+	}
+	t.Cleanup(func() { os.Remove(path) })
+
+	return path
+}
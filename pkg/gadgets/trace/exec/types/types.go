@@ -24,6 +24,11 @@ import (
 type Event struct {
 	eventtypes.Event
 	eventtypes.WithMountNsID
+	eventtypes.WithContainerUid
+	eventtypes.WithKubeAudit
+	eventtypes.WithSystemdUnit
+	eventtypes.WithSbomPackage
+	eventtypes.WithVulnerabilities
 
 	Pid    uint32   `json:"pid,omitempty" column:"pid,template:pid"`
 	Ppid   uint32   `json:"ppid,omitempty" column:"ppid,template:pid"`
@@ -31,6 +36,11 @@ type Event struct {
 	Retval int      `json:"ret,omitempty" column:"ret,width:3,fixed"`
 	Args   []string `json:"args,omitempty" column:"args,width:40"`
 	Uid    uint32   `json:"uid,omitempty" column:"uid,minWidth:10,hide"`
+
+	// Cwd and Env are only captured when asked, Env has the allowed
+	// environment variables as NAME=value
+	Cwd string   `json:"cwd,omitempty" column:"cwd,width:32,hide"`
+	Env []string `json:"env,omitempty" column:"env,width:40,hide"`
 }
 
 func GetColumns() *columns.Columns[Event] {
@@ -39,10 +49,25 @@ func GetColumns() *columns.Columns[Event] {
 	execColumns.MustSetExtractor("args", func(event *Event) (ret string) {
 		return strings.Join(event.Args, " ")
 	})
+	execColumns.MustSetExtractor("env", func(event *Event) (ret string) {
+		return strings.Join(event.Env, " ")
+	})
 
 	return execColumns
 }
 
+func (e *Event) GetPid() uint32 {
+	return e.Pid
+}
+
+func (e *Event) GetArgs() []string {
+	return e.Args
+}
+
+func (e *Event) GetUid() uint32 {
+	return e.Uid
+}
+
 func Base(ev eventtypes.Event) *Event {
 	return &Event{
 		Event: ev,
@@ -8,6 +8,7 @@
 #include "mntns_filter.h"
 
 #define MAX_ENTRIES	8192
+#define MAX_PATH_DEPTH	32
 
 const volatile pid_t target_pid = 0;
 const volatile __u64 min_lat_ns = 0;
@@ -29,6 +30,13 @@ struct {
 	__type(value, struct data);
 } starts SEC(".maps");
 
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, int);
+	__type(value, struct event);
+} heap SEC(".maps");
+
 struct {
 	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
 	__uint(key_size, sizeof(__u32));
@@ -64,6 +72,51 @@ static int probe_entry(struct file *fp, loff_t start, loff_t end)
 	return 0;
 }
 
+// Walk the dentries from the file to the root, crossing the mount points, and
+// write the name of each of them to buf. The components are written from the
+// file to the root, each one terminated by a NUL, the caller has to reverse
+// them. The path is truncated if it's deeper than MAX_PATH_DEPTH or longer than
+// PATH_MAX_LEN.
+static __always_inline __u32 get_path(struct file *fp, __u8 *buf)
+{
+	struct dentry *dentry = BPF_CORE_READ(fp, f_path.dentry);
+	struct vfsmount *vfsmnt = BPF_CORE_READ(fp, f_path.mnt);
+	struct mount *mnt = container_of(vfsmnt, struct mount, mnt);
+	struct dentry *mnt_root, *parent;
+	struct mount *mnt_parent;
+	const unsigned char *name;
+	__u32 off = 0;
+	int len;
+
+	#pragma unroll
+	for (int i = 0; i < MAX_PATH_DEPTH; i++) {
+		mnt_root = BPF_CORE_READ(mnt, mnt.mnt_root);
+		if (dentry == mnt_root) {
+			mnt_parent = BPF_CORE_READ(mnt, mnt_parent);
+			// root of the mount namespace
+			if (mnt_parent == mnt)
+				break;
+			dentry = BPF_CORE_READ(mnt, mnt_mountpoint);
+			mnt = mnt_parent;
+			continue;
+		}
+
+		parent = BPF_CORE_READ(dentry, d_parent);
+		if (dentry == parent || off >= PATH_MAX_LEN)
+			break;
+
+		name = BPF_CORE_READ(dentry, d_name.name);
+		len = bpf_probe_read_kernel_str(buf + (off & (PATH_MAX_LEN - 1)),
+						NAME_MAX + 1, name);
+		if (len <= 0)
+			break;
+		off += len;
+		dentry = parent;
+	}
+
+	return off;
+}
+
 static int probe_exit(void *ctx, enum fs_file_op op, ssize_t size)
 {
 	__u64 pid_tgid = bpf_get_current_pid_tgid();
@@ -72,9 +125,10 @@ static int probe_exit(void *ctx, enum fs_file_op op, ssize_t size)
 	__u64 end_ns, delta_ns;
 	const __u8 *file_name;
 	struct data *datap;
-	struct event event = {};
+	struct event *eventp;
 	struct dentry *dentry;
 	struct file *fp;
+	int zero = 0;
 	u64 mntns_id;
 
 	//if (target_pid && target_pid != pid)
@@ -91,23 +145,28 @@ static int probe_exit(void *ctx, enum fs_file_op op, ssize_t size)
 	if (delta_ns <= min_lat_ns)
 		return 0;
 
-	event.delta_us = delta_ns / 1000;
-	event.end_ns = end_ns;
-	event.offset = datap->start;
+	eventp = bpf_map_lookup_elem(&heap, &zero);
+	if (!eventp)
+		return 0;
+
+	eventp->delta_us = delta_ns / 1000;
+	eventp->end_ns = end_ns;
+	eventp->offset = datap->start;
 	if (op != F_FSYNC)
-		event.size = size;
+		eventp->size = size;
 	else
-		event.size = datap->end - datap->start;
-	event.pid = pid;
-	event.op = op;
-	event.mntns_id = gadget_get_mntns_id();
-	event.timestamp = bpf_ktime_get_boot_ns();
+		eventp->size = datap->end - datap->start;
+	eventp->pid = pid;
+	eventp->op = op;
+	eventp->mntns_id = gadget_get_mntns_id();
+	eventp->timestamp = bpf_ktime_get_boot_ns();
 	fp = datap->fp;
 	dentry = BPF_CORE_READ(fp, f_path.dentry);
 	file_name = BPF_CORE_READ(dentry, d_name.name);
-	bpf_probe_read_kernel_str(&event.file, sizeof(event.file), file_name);
-	bpf_get_current_comm(&event.task, sizeof(event.task));
-	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+	bpf_probe_read_kernel_str(&eventp->file, sizeof(eventp->file), file_name);
+	eventp->path_len = get_path(fp, eventp->path);
+	bpf_get_current_comm(&eventp->task, sizeof(eventp->task));
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, eventp, sizeof(*eventp));
 	return 0;
 }
 
@@ -4,6 +4,9 @@
 
 #define FILE_NAME_LEN	32
 #define TASK_COMM_LEN	16
+#define NAME_MAX	255
+/* must be a power of two, see get_path() */
+#define PATH_MAX_LEN	512
 
 enum fs_file_op {
 	F_READ,
@@ -24,6 +27,9 @@ struct event {
 	enum fs_file_op op;
 	__u8 file[FILE_NAME_LEN];
 	__u8 task[TASK_COMM_LEN];
+	__u32 path_len;
+	/* components of the path, from the file to the root, separated by NUL */
+	__u8 path[PATH_MAX_LEN + NAME_MAX + 1];
 };
 
 #endif /* __FSSLOWER_H */
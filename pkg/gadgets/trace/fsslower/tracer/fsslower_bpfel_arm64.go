@@ -23,6 +23,9 @@ type fsslowerEvent struct {
 	Op        uint32
 	File      [32]uint8
 	Task      [16]uint8
+	PathLen   uint32
+	Path      [768]uint8
+	_         [4]byte
 }
 
 // loadFsslower returns the embedded CollectionSpec for fsslower.
@@ -82,6 +85,7 @@ type fsslowerProgramSpecs struct {
 type fsslowerMapSpecs struct {
 	Events               *ebpf.MapSpec `ebpf:"events"`
 	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Heap                 *ebpf.MapSpec `ebpf:"heap"`
 	Starts               *ebpf.MapSpec `ebpf:"starts"`
 }
 
@@ -106,6 +110,7 @@ func (o *fsslowerObjects) Close() error {
 type fsslowerMaps struct {
 	Events               *ebpf.Map `ebpf:"events"`
 	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Heap                 *ebpf.Map `ebpf:"heap"`
 	Starts               *ebpf.Map `ebpf:"starts"`
 }
 
@@ -113,6 +118,7 @@ func (m *fsslowerMaps) Close() error {
 	return _FsslowerClose(
 		m.Events,
 		m.GadgetMntnsFilterMap,
+		m.Heap,
 		m.Starts,
 	)
 }
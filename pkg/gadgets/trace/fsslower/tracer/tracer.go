@@ -247,6 +247,7 @@ func (t *Tracer) run() {
 			Offset:        bpfEvent.Offset,
 			Latency:       bpfEvent.DeltaUs,
 			File:          gadgets.FromCString(bpfEvent.File[:]),
+			Path:          gadgets.PathFromComponents(bpfEvent.Path[:bpfEvent.PathLen]),
 		}
 
 		if t.enricher != nil {
@@ -33,7 +33,8 @@ type Event struct {
 	Bytes   uint64 `json:"bytes,omitempty" column:"bytes,width:10,align:right"`
 	Offset  int64  `json:"offset,omitempty" column:"offset,width:10,align:right"`
 	Latency uint64 `json:"latency,omitempty" column:"lat,width:10,align:right"`
-	File    string `json:"file,omitempty" column:"file,width:24,maxWidth:32"`
+	File    string `json:"file,omitempty" column:"file,width:24,maxWidth:32,hide"`
+	Path    string `json:"path,omitempty" column:"path,width:40,ellipsis:start"`
 }
 
 func GetColumns() *columns.Columns[Event] {
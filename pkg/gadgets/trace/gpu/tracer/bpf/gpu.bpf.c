@@ -0,0 +1,292 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include "gpu.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+
+// Report every ioctl instead of the first one of each command on each device
+const volatile bool all_ioctls = false;
+
+struct args_t {
+	__u64 fname;
+	__u64 file;
+	__u32 cmd;
+	enum event_type type;
+};
+
+struct device_t {
+	__u8 name[DEVICE_LEN];
+	// Device number of the file, to detect when the struct file of a closed
+	// device is reused
+	__u32 rdev;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__type(value, struct args_t);
+} start SEC(".maps");
+
+// Files opened on GPU and accelerator devices, indexed by their struct file
+// address. They are shared by the processes that inherit or duplicate the file
+// descriptors.
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u64);
+	__type(value, struct device_t);
+} devices SEC(".maps");
+
+struct ioctl_key {
+	__u64 file;
+	__u32 cmd;
+	__u32 pad;
+};
+
+// ioctl commands already reported on each file
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, struct ioctl_key);
+	__type(value, __u8);
+} seen SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, __u32);
+	__type(value, struct event);
+} tmp_event SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, sizeof(__u32));
+} events SEC(".maps");
+
+// Device nodes of the NVIDIA, DRM (Intel, AMD, ...), AMD compute and accel
+// (NPU, ...) drivers
+const char prefix_nvidia[] = "/dev/nvidia";
+const char prefix_dri[] = "/dev/dri/";
+const char prefix_kfd[] = "/dev/kfd";
+const char prefix_accel[] = "/dev/accel/";
+
+static __always_inline bool has_prefix(const __u8 *path, const char *prefix, int len)
+{
+	for (int i = 0; i < len; i++) {
+		if (path[i] != prefix[i])
+			return false;
+	}
+	return true;
+}
+
+static __always_inline bool is_gpu_device(const __u8 *path)
+{
+	// The prefixes' sizes include the NUL terminator
+	return has_prefix(path, prefix_nvidia, sizeof(prefix_nvidia) - 1) ||
+	       has_prefix(path, prefix_dri, sizeof(prefix_dri) - 1) ||
+	       has_prefix(path, prefix_kfd, sizeof(prefix_kfd) - 1) ||
+	       has_prefix(path, prefix_accel, sizeof(prefix_accel) - 1);
+}
+
+static __always_inline struct file *get_file(int fd)
+{
+	struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+	struct fdtable *fdt = BPF_CORE_READ(task, files, fdt);
+	struct file **fds;
+	struct file *file;
+
+	if (fd < 0 || fd >= BPF_CORE_READ(fdt, max_fds))
+		return NULL;
+
+	fds = BPF_CORE_READ(fdt, fd);
+	if (bpf_probe_read_kernel(&file, sizeof(file), &fds[fd]))
+		return NULL;
+
+	return file;
+}
+
+static __always_inline __u32 get_rdev(struct file *file)
+{
+	return BPF_CORE_READ(file, f_inode, i_rdev);
+}
+
+static __always_inline int probe_entry(const char *fname, __u64 file, __u32 cmd,
+				       enum event_type type)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	struct args_t args = {};
+	u64 mntns_id;
+
+	mntns_id = gadget_get_mntns_id();
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return 0;
+
+	args.fname = (__u64)fname;
+	args.file = file;
+	args.cmd = cmd;
+	args.type = type;
+	bpf_map_update_elem(&start, &tid, &args, BPF_ANY);
+	return 0;
+}
+
+static __always_inline struct event *new_event(struct args_t *args, int ret)
+{
+	__u64 uid_gid = bpf_get_current_uid_gid();
+	__u32 zero = 0;
+	struct event *event;
+
+	event = bpf_map_lookup_elem(&tmp_event, &zero);
+	if (!event)
+		return NULL;
+
+	event->timestamp = bpf_ktime_get_boot_ns();
+	event->mntns_id = gadget_get_mntns_id();
+	event->pid = bpf_get_current_pid_tgid() >> 32;
+	event->uid = (__u32)uid_gid;
+	event->gid = (__u32)(uid_gid >> 32);
+	event->ret = ret;
+	event->cmd = 0;
+	event->type = args->type;
+	bpf_get_current_comm(&event->comm, sizeof(event->comm));
+
+	return event;
+}
+
+static __always_inline int open_exit(void *ctx, struct args_t *args, int ret)
+{
+	struct device_t device = {};
+	struct event *event;
+	struct file *file;
+	__u64 key;
+
+	event = new_event(args, ret);
+	if (!event)
+		return 0;
+
+	bpf_probe_read_user_str(&event->device, sizeof(event->device), (const char *)args->fname);
+	if (!is_gpu_device(event->device))
+		return 0;
+
+	if (ret >= 0) {
+		file = get_file(ret);
+		if (file) {
+			key = (__u64)file;
+			__builtin_memcpy(device.name, event->device, sizeof(device.name));
+			device.rdev = get_rdev(file);
+			bpf_map_update_elem(&devices, &key, &device, BPF_ANY);
+		}
+	}
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
+	return 0;
+}
+
+static __always_inline int ioctl_exit(void *ctx, struct args_t *args, int ret)
+{
+	struct ioctl_key key = {};
+	struct device_t *device;
+	struct event *event;
+	__u8 one = 1;
+
+	// The file is resolved on entry: the fd could be closed meanwhile
+	key.file = args->file;
+	key.cmd = args->cmd;
+
+	device = bpf_map_lookup_elem(&devices, &key.file);
+	if (!device)
+		return 0;
+
+	if (!all_ioctls && bpf_map_update_elem(&seen, &key, &one, BPF_NOEXIST))
+		return 0;
+
+	event = new_event(args, ret);
+	if (!event)
+		return 0;
+
+	__builtin_memcpy(event->device, device->name, sizeof(event->device));
+	event->cmd = key.cmd;
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
+	return 0;
+}
+
+static __always_inline int probe_exit(void *ctx, int ret)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	struct args_t *args;
+
+	args = bpf_map_lookup_elem(&start, &tid);
+	if (!args)
+		return 0;
+
+	switch (args->type) {
+	case GPU_EVENT_TYPE_OPEN:
+		open_exit(ctx, args, ret);
+		break;
+	case GPU_EVENT_TYPE_IOCTL:
+		ioctl_exit(ctx, args, ret);
+		break;
+	}
+
+	bpf_map_delete_elem(&start, &tid);
+	return 0;
+}
+
+SEC("tracepoint/syscalls/sys_enter_open")
+int ig_gpu_open_e(struct trace_event_raw_sys_enter *ctx)
+{
+	return probe_entry((const char *)ctx->args[0], 0, 0, GPU_EVENT_TYPE_OPEN);
+}
+
+SEC("tracepoint/syscalls/sys_exit_open")
+int ig_gpu_open_x(struct trace_event_raw_sys_exit *ctx)
+{
+	return probe_exit(ctx, (int)ctx->ret);
+}
+
+SEC("tracepoint/syscalls/sys_enter_openat")
+int ig_gpu_openat_e(struct trace_event_raw_sys_enter *ctx)
+{
+	return probe_entry((const char *)ctx->args[1], 0, 0, GPU_EVENT_TYPE_OPEN);
+}
+
+SEC("tracepoint/syscalls/sys_exit_openat")
+int ig_gpu_openat_x(struct trace_event_raw_sys_exit *ctx)
+{
+	return probe_exit(ctx, (int)ctx->ret);
+}
+
+SEC("tracepoint/syscalls/sys_enter_ioctl")
+int ig_gpu_ioctl_e(struct trace_event_raw_sys_enter *ctx)
+{
+	struct file *file = get_file((int)ctx->args[0]);
+	struct device_t *device;
+	__u64 key = (__u64)file;
+
+	// Only keep track of the ioctls on GPU devices, they are very frequent
+	if (!file)
+		return 0;
+	device = bpf_map_lookup_elem(&devices, &key);
+	if (!device)
+		return 0;
+	if (device->rdev != get_rdev(file)) {
+		bpf_map_delete_elem(&devices, &key);
+		return 0;
+	}
+
+	return probe_entry(NULL, key, (__u32)ctx->args[1], GPU_EVENT_TYPE_IOCTL);
+}
+
+SEC("tracepoint/syscalls/sys_exit_ioctl")
+int ig_gpu_ioctl_x(struct trace_event_raw_sys_exit *ctx)
+{
+	return probe_exit(ctx, (int)ctx->ret);
+}
+
+char LICENSE[] SEC("license") = "GPL";
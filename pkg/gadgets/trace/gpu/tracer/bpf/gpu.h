@@ -0,0 +1,26 @@
+/* SPDX-License-Identifier: (LGPL-2.1 OR BSD-2-Clause) */
+#ifndef GADGET_GPU_H
+#define GADGET_GPU_H
+
+#define TASK_COMM_LEN	16
+#define DEVICE_LEN	32
+
+enum event_type {
+	GPU_EVENT_TYPE_OPEN,
+	GPU_EVENT_TYPE_IOCTL,
+};
+
+struct event {
+	__u64 timestamp;
+	__u64 mntns_id;
+	__u32 pid;
+	__u32 uid;
+	__u32 gid;
+	int ret;
+	__u32 cmd;
+	enum event_type type;
+	__u8 comm[TASK_COMM_LEN];
+	__u8 device[DEVICE_LEN];
+};
+
+#endif /* GADGET_GPU_H */
@@ -0,0 +1,187 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type gpuArgsT struct {
+	Fname uint64
+	File  uint64
+	Cmd   uint32
+	Type  gpuEventType
+}
+
+type gpuDeviceT struct {
+	Name [32]uint8
+	Rdev uint32
+}
+
+type gpuEvent struct {
+	Timestamp uint64
+	MntnsId   uint64
+	Pid       uint32
+	Uid       uint32
+	Gid       uint32
+	Ret       int32
+	Cmd       uint32
+	Type      gpuEventType
+	Comm      [16]uint8
+	Device    [32]uint8
+}
+
+type gpuEventType uint32
+
+const (
+	gpuEventTypeGPU_EVENT_TYPE_OPEN  gpuEventType = 0
+	gpuEventTypeGPU_EVENT_TYPE_IOCTL gpuEventType = 1
+)
+
+type gpuIoctlKey struct {
+	File uint64
+	Cmd  uint32
+	Pad  uint32
+}
+
+// loadGpu returns the embedded CollectionSpec for gpu.
+func loadGpu() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_GpuBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load gpu: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadGpuObjects loads gpu and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*gpuObjects
+//	*gpuPrograms
+//	*gpuMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadGpuObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadGpu()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// gpuSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type gpuSpecs struct {
+	gpuProgramSpecs
+	gpuMapSpecs
+}
+
+// gpuSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type gpuProgramSpecs struct {
+	IgGpuIoctlE  *ebpf.ProgramSpec `ebpf:"ig_gpu_ioctl_e"`
+	IgGpuIoctlX  *ebpf.ProgramSpec `ebpf:"ig_gpu_ioctl_x"`
+	IgGpuOpenE   *ebpf.ProgramSpec `ebpf:"ig_gpu_open_e"`
+	IgGpuOpenX   *ebpf.ProgramSpec `ebpf:"ig_gpu_open_x"`
+	IgGpuOpenatE *ebpf.ProgramSpec `ebpf:"ig_gpu_openat_e"`
+	IgGpuOpenatX *ebpf.ProgramSpec `ebpf:"ig_gpu_openat_x"`
+}
+
+// gpuMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type gpuMapSpecs struct {
+	Devices              *ebpf.MapSpec `ebpf:"devices"`
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Seen                 *ebpf.MapSpec `ebpf:"seen"`
+	Start                *ebpf.MapSpec `ebpf:"start"`
+	TmpEvent             *ebpf.MapSpec `ebpf:"tmp_event"`
+}
+
+// gpuObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadGpuObjects or ebpf.CollectionSpec.LoadAndAssign.
+type gpuObjects struct {
+	gpuPrograms
+	gpuMaps
+}
+
+func (o *gpuObjects) Close() error {
+	return _GpuClose(
+		&o.gpuPrograms,
+		&o.gpuMaps,
+	)
+}
+
+// gpuMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadGpuObjects or ebpf.CollectionSpec.LoadAndAssign.
+type gpuMaps struct {
+	Devices              *ebpf.Map `ebpf:"devices"`
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Seen                 *ebpf.Map `ebpf:"seen"`
+	Start                *ebpf.Map `ebpf:"start"`
+	TmpEvent             *ebpf.Map `ebpf:"tmp_event"`
+}
+
+func (m *gpuMaps) Close() error {
+	return _GpuClose(
+		m.Devices,
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.Seen,
+		m.Start,
+		m.TmpEvent,
+	)
+}
+
+// gpuPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadGpuObjects or ebpf.CollectionSpec.LoadAndAssign.
+type gpuPrograms struct {
+	IgGpuIoctlE  *ebpf.Program `ebpf:"ig_gpu_ioctl_e"`
+	IgGpuIoctlX  *ebpf.Program `ebpf:"ig_gpu_ioctl_x"`
+	IgGpuOpenE   *ebpf.Program `ebpf:"ig_gpu_open_e"`
+	IgGpuOpenX   *ebpf.Program `ebpf:"ig_gpu_open_x"`
+	IgGpuOpenatE *ebpf.Program `ebpf:"ig_gpu_openat_e"`
+	IgGpuOpenatX *ebpf.Program `ebpf:"ig_gpu_openat_x"`
+}
+
+func (p *gpuPrograms) Close() error {
+	return _GpuClose(
+		p.IgGpuIoctlE,
+		p.IgGpuIoctlX,
+		p.IgGpuOpenE,
+		p.IgGpuOpenX,
+		p.IgGpuOpenatE,
+		p.IgGpuOpenatX,
+	)
+}
+
+func _GpuClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed gpu_bpfel_x86.o
+var _GpuBytes []byte
@@ -0,0 +1,62 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperationOpen  = "open"
+	OperationIoctl = "ioctl"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Pid       uint32 `json:"pid,omitempty" column:"pid,minWidth:7"`
+	Uid       uint32 `json:"uid" column:"uid,minWidth:6,hide"`
+	Gid       uint32 `json:"gid" column:"gid,minWidth:6,hide"`
+	Comm      string `json:"comm,omitempty" column:"comm,maxWidth:16"`
+	Operation string `json:"operation,omitempty" column:"op,width:5,fixed"`
+	Device    string `json:"device,omitempty" column:"device,minWidth:16,width:20"`
+	// Cmd is the command of the ioctl
+	Cmd uint32 `json:"cmd,omitempty" column:"cmd,width:10,fixed"`
+	Ret int    `json:"ret,omitempty" column:"ret,width:3,fixed,hide"`
+	Err string `json:"err,omitempty" column:"err,width:8"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	cols.MustSetExtractor("cmd", func(event *Event) string {
+		if event.Operation != OperationIoctl {
+			return ""
+		}
+		return fmt.Sprintf("0x%08x", event.Cmd)
+	})
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
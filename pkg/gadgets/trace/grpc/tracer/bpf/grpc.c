@@ -0,0 +1,196 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+
+#include <linux/bpf.h>
+#include <linux/if_ether.h>
+#include <linux/ip.h>
+#include <linux/ipv6.h>
+#include <linux/in.h>
+#include <linux/tcp.h>
+#include <sys/socket.h>
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_endian.h>
+
+#define GADGET_TYPE_NETWORKING
+#include <sockets-map.h>
+
+#include "grpc.h"
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event_t *unusedevent __attribute__((unused));
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+} events SEC(".maps");
+
+// HTTP/2 connections whose preface was seen. Only they are traced: the state
+// of the header compression of the other connections is unknown.
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 10240);
+	__type(key, struct conn_t);
+	__type(value, __u8);
+} conns SEC(".maps");
+
+// Returns the offset of the TCP header or -1 if the packet isn't a TCP one.
+// The addresses of the packet are written to src and dst.
+static __always_inline int parse_ipv4(struct __sk_buff *skb, struct event_t *event,
+				      __u8 *src, __u8 *dst, __u32 *ip_payload_len)
+{
+	struct iphdr iph;
+	if (bpf_skb_load_bytes(skb, ETH_HLEN, &iph, sizeof iph))
+		return -1;
+	if (iph.protocol != IPPROTO_TCP)
+		return -1;
+
+	event->af = AF_INET;
+	__builtin_memcpy(src, &iph.saddr, sizeof(iph.saddr));
+	__builtin_memcpy(dst, &iph.daddr, sizeof(iph.daddr));
+
+	// The total length is 0 for packets bigger than 64KiB (BIG TCP)
+	__u16 tot_len = bpf_ntohs(iph.tot_len);
+	if (tot_len != 0)
+		*ip_payload_len = tot_len - iph.ihl * 4;
+
+	return ETH_HLEN + iph.ihl * 4;
+}
+
+static __always_inline int parse_ipv6(struct __sk_buff *skb, struct event_t *event,
+				      __u8 *src, __u8 *dst, __u32 *ip_payload_len)
+{
+	struct ipv6hdr ip6h;
+	if (bpf_skb_load_bytes(skb, ETH_HLEN, &ip6h, sizeof ip6h))
+		return -1;
+	// Packets with extension headers aren't traced
+	if (ip6h.nexthdr != IPPROTO_TCP)
+		return -1;
+
+	event->af = AF_INET6;
+	__builtin_memcpy(src, ip6h.saddr.in6_u.u6_addr8, 16);
+	__builtin_memcpy(dst, ip6h.daddr.in6_u.u6_addr8, 16);
+
+	__u16 payload_len = bpf_ntohs(ip6h.payload_len);
+	if (payload_len != 0)
+		*ip_payload_len = payload_len;
+
+	return ETH_HLEN + sizeof(ip6h);
+}
+
+static __always_inline int is_preface(struct __sk_buff *skb, int off)
+{
+	char preface[HTTP2_PREFACE_LEN] = HTTP2_PREFACE;
+	char buf[HTTP2_PREFACE_LEN];
+
+	if (bpf_skb_load_bytes(skb, off, buf, sizeof(buf)))
+		return 0;
+
+#pragma unroll
+	for (int i = 0; i < HTTP2_PREFACE_LEN; i++) {
+		if (buf[i] != preface[i])
+			return 0;
+	}
+	return 1;
+}
+
+SEC("socket1")
+int ig_trace_grpc(struct __sk_buff *skb)
+{
+	struct event_t event = {0,};
+	__u8 src[16] = {}, dst[16] = {};
+	__u32 ip_payload_len = 0;
+	int off;
+
+	struct ethhdr ethh;
+	if (bpf_skb_load_bytes(skb, 0, &ethh, sizeof ethh))
+		return 0;
+
+	switch (bpf_ntohs(ethh.h_proto)) {
+	case ETH_P_IP:
+		off = parse_ipv4(skb, &event, src, dst, &ip_payload_len);
+		break;
+	case ETH_P_IPV6:
+		off = parse_ipv6(skb, &event, src, dst, &ip_payload_len);
+		break;
+	default:
+		return 0;
+	}
+	if (off < 0)
+		return 0;
+
+	struct tcphdr tcph;
+	if (bpf_skb_load_bytes(skb, off, &tcph, sizeof tcph))
+		return 0;
+
+	__u32 tcp_header_len = tcph.doff * 4;
+	event.payload_offset = off + tcp_header_len;
+	if (ip_payload_len != 0)
+		event.payload_len = ip_payload_len - tcp_header_len;
+	else if (skb->len > event.payload_offset)
+		event.payload_len = skb->len - event.payload_offset;
+
+	if (tcph.fin)
+		event.flags |= FLAG_FIN;
+	if (tcph.rst)
+		event.flags |= FLAG_RST;
+	if (event.payload_len == 0 && event.flags == 0)
+		return 0;
+
+	// Is it a packet sent by the client or by the server?
+	__builtin_memcpy(event.conn.client, src, sizeof(event.conn.client));
+	__builtin_memcpy(event.conn.server, dst, sizeof(event.conn.server));
+	event.conn.client_port = bpf_ntohs(tcph.source);
+	event.conn.server_port = bpf_ntohs(tcph.dest);
+	event.from_client = 1;
+
+	if (!bpf_map_lookup_elem(&conns, &event.conn)) {
+		__builtin_memcpy(event.conn.client, dst, sizeof(event.conn.client));
+		__builtin_memcpy(event.conn.server, src, sizeof(event.conn.server));
+		event.conn.client_port = bpf_ntohs(tcph.dest);
+		event.conn.server_port = bpf_ntohs(tcph.source);
+		event.from_client = 0;
+
+		if (!bpf_map_lookup_elem(&conns, &event.conn)) {
+			// A new connection starts with the preface of the client
+			if (!is_preface(skb, event.payload_offset))
+				return 0;
+
+			__builtin_memcpy(event.conn.client, src, sizeof(event.conn.client));
+			__builtin_memcpy(event.conn.server, dst, sizeof(event.conn.server));
+			event.conn.client_port = bpf_ntohs(tcph.source);
+			event.conn.server_port = bpf_ntohs(tcph.dest);
+			event.from_client = 1;
+
+			__u8 zero = 0;
+			bpf_map_update_elem(&conns, &event.conn, &zero, BPF_ANY);
+		}
+	}
+
+	if (event.flags)
+		bpf_map_delete_elem(&conns, &event.conn);
+
+	event.seq = bpf_ntohl(tcph.seq);
+	event.pkt_type = skb->pkt_type;
+	event.timestamp = bpf_ktime_get_boot_ns();
+
+	// Enrich event with process metadata
+	struct sockets_value *skb_val = gadget_socket_lookup(skb);
+	if (skb_val != NULL) {
+		event.mount_ns_id = skb_val->mntns;
+		event.pid = skb_val->pid_tgid >> 32;
+		event.tid = (__u32)skb_val->pid_tgid;
+		__builtin_memcpy(&event.task, skb_val->task, sizeof(event.task));
+	}
+
+	// Append the packet to the event, the HTTP/2 frames are decoded in
+	// userspace
+	__u64 len = skb->len;
+	if (len > MAX_PACKET_SIZE)
+		len = MAX_PACKET_SIZE;
+
+	bpf_perf_event_output(skb, &events, (len << 32) | BPF_F_CURRENT_CPU, &event, sizeof(event));
+
+	return 0;
+}
+
+char _license[] SEC("license") = "GPL";
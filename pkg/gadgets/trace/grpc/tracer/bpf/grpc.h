@@ -0,0 +1,47 @@
+#ifndef GADGET_GRPC_H
+#define GADGET_GRPC_H
+
+#define TASK_COMM_LEN	16
+
+// The connection preface sent by HTTP/2 clients
+#define HTTP2_PREFACE		"PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+#define HTTP2_PREFACE_LEN	24
+
+// Size of the biggest packet sent to userspace. Perf samples can't be bigger
+// than 64KiB, the frames of bigger (GSO) packets are partially lost.
+#define MAX_PACKET_SIZE	65000
+
+#define FLAG_FIN	(1 << 0)
+#define FLAG_RST	(1 << 1)
+
+// A HTTP/2 connection, seen from the client
+struct conn_t {
+	__u8 client[16];
+	__u8 server[16];
+	__u16 client_port;
+	__u16 server_port;
+};
+
+// The event is followed by the first bytes of the packet, starting at the
+// ethernet header
+struct event_t {
+	__u64 timestamp;
+	__u64 mount_ns_id;
+	__u32 pid;
+	__u32 tid;
+	__u8 task[TASK_COMM_LEN];
+
+	struct conn_t conn;
+	__u32 af; // AF_INET or AF_INET6
+
+	// TCP sequence number of the first byte of the payload
+	__u32 seq;
+	// Offset and length of the TCP payload in the packet
+	__u32 payload_offset;
+	__u32 payload_len;
+	__u8 from_client;
+	__u8 flags;
+	__u8 pkt_type;
+};
+
+#endif
@@ -0,0 +1,510 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2/hpack"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/grpc/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// HTTP/2 framing: https://www.rfc-editor.org/rfc/rfc9113#section-4
+const (
+	http2Preface      = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+	frameHeaderLen    = 9
+	frameData         = 0x0
+	frameHeaders      = 0x1
+	frameRSTStream    = 0x3
+	frameContinuation = 0x9
+
+	flagEndStream  = 0x1
+	flagEndHeaders = 0x4
+	flagPadded     = 0x8
+	flagPriority   = 0x20
+)
+
+const (
+	// Frames bigger than that aren't buffered, the connection isn't traced
+	// anymore
+	maxFrameLen = 1 << 20
+	// Maximum number of calls waiting for their response on a connection
+	maxPendingCalls = 1024
+	// The dynamic table size is negotiated with SETTINGS frames, don't
+	// limit it
+	maxDynamicTableSize = 1 << 24
+)
+
+// https://grpc.github.io/grpc/core/md_doc_statuscodes.html
+var grpcStatusNames = []string{
+	"OK",
+	"CANCELLED",
+	"UNKNOWN",
+	"INVALID_ARGUMENT",
+	"DEADLINE_EXCEEDED",
+	"NOT_FOUND",
+	"ALREADY_EXISTS",
+	"PERMISSION_DENIED",
+	"RESOURCE_EXHAUSTED",
+	"FAILED_PRECONDITION",
+	"ABORTED",
+	"OUT_OF_RANGE",
+	"UNIMPLEMENTED",
+	"INTERNAL",
+	"UNAVAILABLE",
+	"DATA_LOSS",
+	"UNAUTHENTICATED",
+}
+
+const (
+	grpcStatusCancelled   = 1
+	grpcStatusUnknown     = 2
+	grpcStatusInternal    = 13
+	grpcStatusUnavailable = 14
+)
+
+func grpcStatusName(code uint32) string {
+	if code < uint32(len(grpcStatusNames)) {
+		return grpcStatusNames[code]
+	}
+	return strconv.FormatUint(uint64(code), 10)
+}
+
+// grpcStatusFromHTTP maps the HTTP status of responses without grpc-status,
+// e.g. sent by proxies, as gRPC clients do:
+// https://github.com/grpc/grpc/blob/master/doc/http-grpc-status-mapping.md
+func grpcStatusFromHTTP(status string) uint32 {
+	switch status {
+	case "400":
+		return grpcStatusInternal
+	case "401":
+		return 16
+	case "403":
+		return 7
+	case "404":
+		return 12
+	case "429", "502", "503", "504":
+		return grpcStatusUnavailable
+	default:
+		return grpcStatusUnknown
+	}
+}
+
+// connKey identifies a connection, from the point of view of the client
+type connKey struct {
+	netns      uint64
+	clientIP   string
+	serverIP   string
+	clientPort uint16
+	serverPort uint16
+}
+
+// process is the process owning the socket that sent or received a segment
+type process struct {
+	mountNsID uint64
+	pid       uint32
+	tid       uint32
+	comm      string
+}
+
+// segment is the TCP payload of a packet of a HTTP/2 connection
+type segment struct {
+	key        connKey
+	fromClient bool
+	// outgoing tells whether the packet was sent by the traced container
+	outgoing  bool
+	seq       uint32
+	payload   []byte
+	length    uint32
+	fin       bool
+	rst       bool
+	timestamp eventtypes.Time
+	bootTime  uint64
+	process   process
+}
+
+// halfConn is one direction of a connection
+type halfConn struct {
+	synced  bool
+	nextSeq uint32
+	buf     []byte
+	// Bytes of the current DATA frame that weren't received yet
+	skip uint32
+
+	decoder *hpack.Decoder
+	// Header block split over HEADERS and CONTINUATION frames
+	headerBlock     []byte
+	headerStreamID  uint32
+	headerEndStream bool
+}
+
+type call struct {
+	timestamp eventtypes.Time
+	bootTime  uint64
+	process   process
+	role      string
+
+	authority string
+	path      string
+
+	httpStatus string
+	grpcStatus string
+	message    string
+}
+
+type conn struct {
+	key connKey
+	// 0: from the client, 1: from the server
+	halves [2]halfConn
+	// broken is set when frames were lost: the state of the header
+	// compression is unknown and the connection isn't traced anymore
+	broken bool
+	calls  map[uint32]*call
+}
+
+func newConn(key connKey) *conn {
+	c := &conn{
+		key:   key,
+		calls: make(map[uint32]*call),
+	}
+	for i := range c.halves {
+		c.halves[i].decoder = hpack.NewDecoder(4096, nil)
+		c.halves[i].decoder.SetAllowedMaxDynamicTableSize(maxDynamicTableSize)
+	}
+	return c
+}
+
+// http2Parser decodes the gRPC calls of the HTTP/2 connections
+type http2Parser struct {
+	conns map[connKey]*conn
+}
+
+func newHTTP2Parser() *http2Parser {
+	return &http2Parser{
+		conns: make(map[connKey]*conn),
+	}
+}
+
+// process adds a segment to its connection and returns the calls that were
+// completed by it.
+func (p *http2Parser) process(seg *segment) []*types.Event {
+	c, ok := p.conns[seg.key]
+	if !ok {
+		// Connections are traced from their preface on
+		if !seg.fromClient || !strings.HasPrefix(string(seg.payload), http2Preface) {
+			return nil
+		}
+		c = newConn(seg.key)
+		p.conns[seg.key] = c
+	}
+
+	var events []*types.Event
+	if !c.broken {
+		events = c.process(seg)
+	}
+
+	if seg.fin || seg.rst {
+		delete(p.conns, seg.key)
+	}
+	return events
+}
+
+// dropNetns forgets the connections of a network namespace that isn't traced
+// anymore.
+func (p *http2Parser) dropNetns(netns uint64) {
+	for key := range p.conns {
+		if key.netns == netns {
+			delete(p.conns, key)
+		}
+	}
+}
+
+func (c *conn) process(seg *segment) []*types.Event {
+	dir := 0
+	if !seg.fromClient {
+		dir = 1
+	}
+	h := &c.halves[dir]
+
+	payload := seg.payload
+	length := seg.length
+	if !h.synced {
+		if len(payload) == 0 {
+			return nil
+		}
+		h.synced = true
+		h.nextSeq = seg.seq
+		if seg.fromClient {
+			payload = payload[len(http2Preface):]
+			length -= uint32(len(http2Preface))
+			h.nextSeq += uint32(len(http2Preface))
+		}
+	} else {
+		// Retransmissions and packets captured twice on the loopback
+		// interface
+		diff := int32(h.nextSeq - seg.seq)
+		if diff < 0 {
+			c.broken = true
+			return nil
+		}
+		if uint32(diff) >= length {
+			return nil
+		}
+		length -= uint32(diff)
+		if diff >= int32(len(payload)) {
+			payload = nil
+		} else {
+			payload = payload[diff:]
+		}
+	}
+	h.nextSeq += length
+
+	var events []*types.Event
+	if err := c.consume(h, seg, payload, &events); err != nil {
+		c.broken = true
+		return events
+	}
+
+	// The end of the packet wasn't captured: it's fine as long as it's
+	// the content of a DATA frame
+	if missing := length - uint32(len(payload)); missing > 0 {
+		if len(h.buf) > 0 || h.skip < missing {
+			c.broken = true
+			return events
+		}
+		h.skip -= missing
+	}
+
+	return events
+}
+
+// consume parses the frames of the payload, the last incomplete frame is kept
+// for the next segment.
+func (c *conn) consume(h *halfConn, seg *segment, payload []byte, events *[]*types.Event) error {
+	if h.skip > 0 {
+		n := uint32(len(payload))
+		if n > h.skip {
+			n = h.skip
+		}
+		h.skip -= n
+		payload = payload[n:]
+	}
+	if len(payload) == 0 {
+		return nil
+	}
+
+	buf := append(h.buf, payload...)
+	for len(buf) >= frameHeaderLen {
+		frameLen := uint32(buf[0])<<16 | uint32(buf[1])<<8 | uint32(buf[2])
+		frameType := buf[3]
+		flags := buf[4]
+		streamID := binary.BigEndian.Uint32(buf[5:9]) & 0x7fffffff
+
+		// The content of DATA frames isn't needed
+		if frameType == frameData && uint32(len(buf)-frameHeaderLen) < frameLen {
+			h.skip = frameLen - uint32(len(buf)-frameHeaderLen)
+			buf = nil
+			break
+		}
+		if frameType != frameData && frameLen > maxFrameLen {
+			return fmt.Errorf("frame too big: %d", frameLen)
+		}
+		if uint32(len(buf)-frameHeaderLen) < frameLen {
+			break
+		}
+
+		frame := buf[frameHeaderLen : frameHeaderLen+frameLen]
+		buf = buf[frameHeaderLen+frameLen:]
+
+		if err := c.frame(h, seg, frameType, flags, streamID, frame, events); err != nil {
+			return err
+		}
+	}
+
+	// Don't keep a reference to the whole payload
+	h.buf = append([]byte(nil), buf...)
+	return nil
+}
+
+func (c *conn) frame(h *halfConn, seg *segment, frameType, flags uint8, streamID uint32, frame []byte, events *[]*types.Event) error {
+	switch frameType {
+	case frameHeaders:
+		if flags&flagPadded != 0 {
+			if len(frame) < 1 || int(frame[0]) > len(frame)-1 {
+				return fmt.Errorf("invalid padding")
+			}
+			frame = frame[1 : len(frame)-int(frame[0])]
+		}
+		if flags&flagPriority != 0 {
+			if len(frame) < 5 {
+				return fmt.Errorf("invalid priority")
+			}
+			frame = frame[5:]
+		}
+		h.headerBlock = append(h.headerBlock[:0], frame...)
+		h.headerStreamID = streamID
+		h.headerEndStream = flags&flagEndStream != 0
+		if flags&flagEndHeaders == 0 {
+			return nil
+		}
+	case frameContinuation:
+		if streamID != h.headerStreamID {
+			return fmt.Errorf("unexpected CONTINUATION frame")
+		}
+		h.headerBlock = append(h.headerBlock, frame...)
+		if flags&flagEndHeaders == 0 {
+			return nil
+		}
+	case frameRSTStream:
+		cl, ok := c.calls[streamID]
+		if !ok {
+			return nil
+		}
+		cl.grpcStatus = strconv.Itoa(grpcStatusCancelled)
+		if len(frame) >= 4 {
+			cl.message = fmt.Sprintf("stream reset with error code %d", binary.BigEndian.Uint32(frame))
+		}
+		*events = append(*events, c.complete(streamID, cl, seg))
+		return nil
+	default:
+		return nil
+	}
+
+	// The header block is complete, it must be decoded even if it's not
+	// useful to keep the dynamic table up to date
+	fields, err := h.decoder.DecodeFull(h.headerBlock)
+	h.headerBlock = h.headerBlock[:0]
+	if err != nil {
+		return fmt.Errorf("decoding headers: %w", err)
+	}
+
+	if seg.fromClient {
+		c.request(h.headerStreamID, fields, seg)
+		return nil
+	}
+	if event := c.response(h.headerStreamID, h.headerEndStream, fields, seg); event != nil {
+		*events = append(*events, event)
+	}
+	return nil
+}
+
+func (c *conn) request(streamID uint32, fields []hpack.HeaderField, seg *segment) {
+	cl := &call{
+		timestamp: seg.timestamp,
+		bootTime:  seg.bootTime,
+		process:   seg.process,
+		role:      types.RoleServer,
+	}
+	if seg.outgoing {
+		cl.role = types.RoleClient
+	}
+
+	isGRPC := false
+	for _, f := range fields {
+		switch f.Name {
+		case ":path":
+			cl.path = f.Value
+		case ":authority":
+			cl.authority = f.Value
+		case "content-type":
+			isGRPC = strings.HasPrefix(f.Value, "application/grpc")
+		}
+	}
+	if !isGRPC || len(c.calls) >= maxPendingCalls {
+		return
+	}
+	c.calls[streamID] = cl
+}
+
+func (c *conn) response(streamID uint32, endStream bool, fields []hpack.HeaderField, seg *segment) *types.Event {
+	cl, ok := c.calls[streamID]
+	if !ok {
+		return nil
+	}
+
+	for _, f := range fields {
+		switch f.Name {
+		case ":status":
+			cl.httpStatus = f.Value
+		case "grpc-status":
+			cl.grpcStatus = f.Value
+		case "grpc-message":
+			cl.message = f.Value
+			// The message is percent-encoded
+			if m, err := url.PathUnescape(f.Value); err == nil {
+				cl.message = m
+			}
+		}
+	}
+
+	// Trailers end the call
+	if !endStream {
+		return nil
+	}
+	return c.complete(streamID, cl, seg)
+}
+
+func (c *conn) complete(streamID uint32, cl *call, seg *segment) *types.Event {
+	delete(c.calls, streamID)
+
+	event := &types.Event{
+		Event: eventtypes.Event{
+			Type:      eventtypes.NORMAL,
+			Timestamp: cl.timestamp,
+		},
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: cl.process.mountNsID},
+		WithNetNsID:   eventtypes.WithNetNsID{NetNsID: c.key.netns},
+		Pid:           cl.process.pid,
+		Tid:           cl.process.tid,
+		Comm:          cl.process.comm,
+		Role:          cl.role,
+		ClientIP:      c.key.clientIP,
+		ServerIP:      c.key.serverIP,
+		ClientPort:    c.key.clientPort,
+		ServerPort:    c.key.serverPort,
+		StreamID:      streamID,
+		Authority:     cl.authority,
+		Message:       cl.message,
+		HTTPStatus:    cl.httpStatus,
+	}
+	if seg.bootTime > cl.bootTime {
+		event.Latency = time.Duration(seg.bootTime - cl.bootTime)
+	}
+
+	// The path is /<package>.<service>/<method>
+	path := strings.TrimPrefix(cl.path, "/")
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		event.Service = path[:i]
+		event.Method = path[i+1:]
+	} else {
+		event.Method = path
+	}
+
+	if code, err := strconv.ParseUint(cl.grpcStatus, 10, 32); err == nil {
+		event.StatusCode = uint32(code)
+	} else {
+		event.StatusCode = grpcStatusFromHTTP(cl.httpStatus)
+	}
+	event.Status = grpcStatusName(event.StatusCode)
+
+	return event
+}
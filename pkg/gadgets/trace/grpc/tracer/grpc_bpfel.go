@@ -0,0 +1,165 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64 || amd64p32 || arm || arm64 || loong64 || mips64le || mips64p32le || mipsle || ppc64le || riscv64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type grpcConnT struct {
+	Client     [16]uint8
+	Server     [16]uint8
+	ClientPort uint16
+	ServerPort uint16
+}
+
+type grpcEventT struct {
+	Timestamp     uint64
+	MountNsId     uint64
+	Pid           uint32
+	Tid           uint32
+	Task          [16]uint8
+	Conn          grpcConnT
+	Af            uint32
+	Seq           uint32
+	PayloadOffset uint32
+	PayloadLen    uint32
+	FromClient    uint8
+	Flags         uint8
+	PktType       uint8
+	_             [1]byte
+}
+
+type grpcSocketsKey struct {
+	Netns  uint32
+	Family uint16
+	Proto  uint16
+	Port   uint16
+	_      [2]byte
+}
+
+type grpcSocketsValue struct {
+	Mntns             uint64
+	PidTgid           uint64
+	Task              [16]int8
+	Sock              uint64
+	DeletionTimestamp uint64
+}
+
+// loadGrpc returns the embedded CollectionSpec for grpc.
+func loadGrpc() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_GrpcBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load grpc: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadGrpcObjects loads grpc and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*grpcObjects
+//	*grpcPrograms
+//	*grpcMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadGrpcObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadGrpc()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// grpcSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type grpcSpecs struct {
+	grpcProgramSpecs
+	grpcMapSpecs
+}
+
+// grpcSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type grpcProgramSpecs struct {
+	IgTraceGrpc *ebpf.ProgramSpec `ebpf:"ig_trace_grpc"`
+}
+
+// grpcMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type grpcMapSpecs struct {
+	Conns   *ebpf.MapSpec `ebpf:"conns"`
+	Events  *ebpf.MapSpec `ebpf:"events"`
+	Sockets *ebpf.MapSpec `ebpf:"sockets"`
+}
+
+// grpcObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadGrpcObjects or ebpf.CollectionSpec.LoadAndAssign.
+type grpcObjects struct {
+	grpcPrograms
+	grpcMaps
+}
+
+func (o *grpcObjects) Close() error {
+	return _GrpcClose(
+		&o.grpcPrograms,
+		&o.grpcMaps,
+	)
+}
+
+// grpcMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadGrpcObjects or ebpf.CollectionSpec.LoadAndAssign.
+type grpcMaps struct {
+	Conns   *ebpf.Map `ebpf:"conns"`
+	Events  *ebpf.Map `ebpf:"events"`
+	Sockets *ebpf.Map `ebpf:"sockets"`
+}
+
+func (m *grpcMaps) Close() error {
+	return _GrpcClose(
+		m.Conns,
+		m.Events,
+		m.Sockets,
+	)
+}
+
+// grpcPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadGrpcObjects or ebpf.CollectionSpec.LoadAndAssign.
+type grpcPrograms struct {
+	IgTraceGrpc *ebpf.Program `ebpf:"ig_trace_grpc"`
+}
+
+func (p *grpcPrograms) Close() error {
+	return _GrpcClose(
+		p.IgTraceGrpc,
+	)
+}
+
+func _GrpcClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed grpc_bpfel.o
+var _GrpcBytes []byte
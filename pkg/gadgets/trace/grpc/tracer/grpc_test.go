@@ -0,0 +1,217 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/grpc/types"
+)
+
+// peer writes the frames sent by one side of a connection
+type peer struct {
+	buf     bytes.Buffer
+	framer  *http2.Framer
+	headers bytes.Buffer
+	encoder *hpack.Encoder
+	seq     uint32
+}
+
+func newPeer(seq uint32) *peer {
+	p := &peer{seq: seq}
+	p.framer = http2.NewFramer(&p.buf, nil)
+	p.encoder = hpack.NewEncoder(&p.headers)
+	return p
+}
+
+func (p *peer) writeHeaders(t *testing.T, streamID uint32, endStream bool, fields ...string) {
+	p.headers.Reset()
+	for i := 0; i < len(fields); i += 2 {
+		p.encoder.WriteField(hpack.HeaderField{Name: fields[i], Value: fields[i+1]})
+	}
+	err := p.framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: p.headers.Bytes(),
+		EndStream:     endStream,
+		EndHeaders:    true,
+	})
+	if err != nil {
+		t.Fatalf("writing headers: %s", err)
+	}
+}
+
+func (p *peer) writeData(t *testing.T, streamID uint32, len int) {
+	if err := p.framer.WriteData(streamID, false, make([]byte, len)); err != nil {
+		t.Fatalf("writing data: %s", err)
+	}
+}
+
+// segment returns the frames written since the last call
+func (p *peer) segment(fromClient bool, bootTime uint64) *segment {
+	payload := append([]byte(nil), p.buf.Bytes()...)
+	p.buf.Reset()
+	seg := &segment{
+		key: connKey{
+			netns:      1,
+			clientIP:   "10.0.0.2",
+			serverIP:   "10.0.0.3",
+			clientPort: 45678,
+			serverPort: 50051,
+		},
+		fromClient: fromClient,
+		outgoing:   fromClient,
+		seq:        p.seq,
+		payload:    payload,
+		length:     uint32(len(payload)),
+		bootTime:   bootTime,
+		process:    process{pid: 1234, comm: "client"},
+	}
+	p.seq += uint32(len(payload))
+	return seg
+}
+
+func requestFields(method string) []string {
+	return []string{
+		":method", "POST",
+		":scheme", "http",
+		":path", "/helloworld.Greeter/" + method,
+		":authority", "greeter:50051",
+		"content-type", "application/grpc",
+		"te", "trailers",
+	}
+}
+
+func expectEvents(t *testing.T, events []*types.Event, n int) {
+	t.Helper()
+	if len(events) != n {
+		t.Fatalf("Got %d events, expecting %d: %+v", len(events), n, events)
+	}
+}
+
+func TestParseGRPC(t *testing.T) {
+	p := newHTTP2Parser()
+	client := newPeer(1000)
+	server := newPeer(5000)
+
+	client.buf.WriteString(http2Preface)
+	client.framer.WriteSettings()
+	client.writeHeaders(t, 1, false, requestFields("SayHello")...)
+	client.writeData(t, 1, 20)
+	expectEvents(t, p.process(client.segment(true, 1000)), 0)
+
+	// The response is split over several packets
+	server.framer.WriteSettings()
+	server.writeHeaders(t, 1, false, ":status", "200", "content-type", "application/grpc")
+	server.writeData(t, 1, 3000)
+	seg := server.segment(false, 2000)
+	first := *seg
+	first.payload = seg.payload[:1000]
+	first.length = 1000
+	second := *seg
+	second.seq += 1000
+	second.payload = seg.payload[1000:]
+	second.length -= 1000
+	expectEvents(t, p.process(&first), 0)
+	// Retransmission
+	expectEvents(t, p.process(&first), 0)
+	expectEvents(t, p.process(&second), 0)
+
+	server.writeHeaders(t, 1, true, "grpc-status", "0")
+	events := p.process(server.segment(false, 3000))
+	expectEvents(t, events, 1)
+
+	event := events[0]
+	if event.Service != "helloworld.Greeter" || event.Method != "SayHello" || event.Authority != "greeter:50051" {
+		t.Fatalf("Invalid call %+v", event)
+	}
+	if event.Status != "OK" || event.StatusCode != 0 || event.HTTPStatus != "200" {
+		t.Fatalf("Invalid status %+v", event)
+	}
+	if event.Latency != 2000*time.Nanosecond || event.Role != types.RoleClient || event.Pid != 1234 {
+		t.Fatalf("Invalid event %+v", event)
+	}
+
+	// The second request uses the dynamic table of the header compression.
+	// The response only has trailers.
+	client.writeHeaders(t, 3, false, requestFields("SayHelloAgain")...)
+	expectEvents(t, p.process(client.segment(true, 4000)), 0)
+	server.writeHeaders(t, 3, true, ":status", "200", "grpc-status", "5", "grpc-message", "no%20such%20name")
+	events = p.process(server.segment(false, 4500))
+	expectEvents(t, events, 1)
+	event = events[0]
+	if event.Method != "SayHelloAgain" || event.Status != "NOT_FOUND" || event.Message != "no such name" {
+		t.Fatalf("Invalid call %+v", event)
+	}
+
+	// Responses of proxies without grpc-status
+	client.writeHeaders(t, 5, true, requestFields("SayHello")...)
+	expectEvents(t, p.process(client.segment(true, 5000)), 0)
+	server.writeHeaders(t, 5, true, ":status", "503")
+	events = p.process(server.segment(false, 5500))
+	expectEvents(t, events, 1)
+	if events[0].Status != "UNAVAILABLE" {
+		t.Fatalf("Invalid status %+v", events[0])
+	}
+
+	// Cancelled calls
+	client.writeHeaders(t, 7, false, requestFields("SayHello")...)
+	client.framer.WriteRSTStream(7, http2.ErrCodeCancel)
+	events = p.process(client.segment(true, 6000))
+	expectEvents(t, events, 1)
+	if events[0].Status != "CANCELLED" {
+		t.Fatalf("Invalid status %+v", events[0])
+	}
+}
+
+func TestParseGRPCLostSegment(t *testing.T) {
+	p := newHTTP2Parser()
+	client := newPeer(1000)
+	server := newPeer(5000)
+
+	client.buf.WriteString(http2Preface)
+	client.writeHeaders(t, 1, true, requestFields("SayHello")...)
+	expectEvents(t, p.process(client.segment(true, 1000)), 0)
+
+	// The end of the DATA frame wasn't captured
+	server.writeHeaders(t, 1, false, ":status", "200", "content-type", "application/grpc")
+	server.writeData(t, 1, 3000)
+	seg := server.segment(false, 2000)
+	seg.payload = seg.payload[:1000]
+	expectEvents(t, p.process(seg), 0)
+
+	server.writeHeaders(t, 1, true, "grpc-status", "0")
+	expectEvents(t, p.process(server.segment(false, 3000)), 1)
+
+	// A segment was lost: the connection isn't traced anymore
+	client.writeHeaders(t, 3, true, requestFields("SayHello")...)
+	client.segment(true, 4000)
+	client.writeHeaders(t, 5, true, requestFields("SayHello")...)
+	expectEvents(t, p.process(client.segment(true, 4000)), 0)
+	server.writeHeaders(t, 5, true, ":status", "200", "grpc-status", "0")
+	expectEvents(t, p.process(server.segment(false, 5000)), 0)
+
+	// Connections whose preface wasn't seen aren't traced
+	p = newHTTP2Parser()
+	server.writeHeaders(t, 5, true, ":status", "200", "grpc-status", "0")
+	expectEvents(t, p.process(server.segment(false, 5000)), 0)
+	if len(p.conns) != 0 {
+		t.Fatalf("Unexpected connections %+v", p.conns)
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+	"unsafe"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/networktracer"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/grpc/types"
+)
+
+//go:generate bash -c "source ./clangosflags.sh; go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -cc clang -type event_t grpc ./bpf/grpc.c -- $CLANG_OS_FLAGS -I./bpf/ -I../../../internal/socketenricher/bpf"
+
+const (
+	BPFProgName     = "ig_trace_grpc"
+	BPFPerfMapName  = "events"
+	BPFSocketAttach = 50
+)
+
+// Flags of the events, from grpc.h
+const (
+	flagFIN = 1 << 0
+	flagRST = 1 << 1
+)
+
+// PACKET_OUTGOING from include/uapi/linux/if_packet.h
+const packetOutgoing = 4
+
+type Tracer struct {
+	*networktracer.Tracer[types.Event]
+
+	// The calls are decoded from the frames of several packets, a packet
+	// can complete several calls
+	mu     sync.Mutex
+	parser *http2Parser
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func newTracer() *Tracer {
+	return &Tracer{
+		parser: newHTTP2Parser(),
+	}
+}
+
+func NewTracer() (*Tracer, error) {
+	t := newTracer()
+
+	if err := t.install(); err != nil {
+		t.Close()
+		return nil, fmt.Errorf("installing tracer: %w", err)
+	}
+
+	return t, nil
+}
+
+func (t *Tracer) parseGRPCEvent(sample []byte, netns uint64) ([]*types.Event, error) {
+	bpfEvent := (*grpcEventT)(unsafe.Pointer(&sample[0]))
+	eventSize := int(unsafe.Sizeof(*bpfEvent))
+	if len(sample) < eventSize {
+		return nil, errors.New("invalid sample size")
+	}
+
+	// The packet follows the event, it can be truncated
+	packet := sample[eventSize:]
+	start := int(bpfEvent.PayloadOffset)
+	if start > len(packet) {
+		start = len(packet)
+	}
+	end := start + int(bpfEvent.PayloadLen)
+	if end > len(packet) {
+		end = len(packet)
+	}
+
+	seg := &segment{
+		key:        connKey{netns: netns},
+		fromClient: bpfEvent.FromClient != 0,
+		outgoing:   bpfEvent.PktType == packetOutgoing,
+		seq:        bpfEvent.Seq,
+		payload:    packet[start:end],
+		length:     bpfEvent.PayloadLen,
+		fin:        bpfEvent.Flags&flagFIN != 0,
+		rst:        bpfEvent.Flags&flagRST != 0,
+		timestamp:  gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+		bootTime:   bpfEvent.Timestamp,
+		process: process{
+			mountNsID: bpfEvent.MountNsId,
+			pid:       bpfEvent.Pid,
+			tid:       bpfEvent.Tid,
+			comm:      gadgets.FromCString(bpfEvent.Task[:]),
+		},
+	}
+
+	switch bpfEvent.Af {
+	case syscall.AF_INET:
+		seg.key.clientIP = gadgets.IPStringFromBytes(bpfEvent.Conn.Client, 4)
+		seg.key.serverIP = gadgets.IPStringFromBytes(bpfEvent.Conn.Server, 4)
+	case syscall.AF_INET6:
+		seg.key.clientIP = gadgets.IPStringFromBytes(bpfEvent.Conn.Client, 6)
+		seg.key.serverIP = gadgets.IPStringFromBytes(bpfEvent.Conn.Server, 6)
+	default:
+		return nil, fmt.Errorf("unknown address family %d", bpfEvent.Af)
+	}
+	seg.key.clientPort = bpfEvent.Conn.ClientPort
+	seg.key.serverPort = bpfEvent.Conn.ServerPort
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.parser.process(seg), nil
+}
+
+// dropNetns drops the connections of a network namespace that isn't traced
+// anymore
+func (t *Tracer) dropNetns(netns uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.parser.dropNetns(netns)
+}
+
+// --- Registry changes
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return newTracer(), nil
+}
+
+func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
+	if err := t.install(); err != nil {
+		t.Close()
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	t.ctx, t.cancel = gadgetcontext.WithTimeoutOrCancel(gadgetCtx.Context(), gadgetCtx.Timeout())
+	return nil
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadGrpc()
+	if err != nil {
+		return fmt.Errorf("loading asset: %w", err)
+	}
+
+	networkTracer, err := networktracer.NewStreamTracer(
+		spec,
+		BPFProgName,
+		BPFPerfMapName,
+		BPFSocketAttach,
+		types.Base,
+		t.parseGRPCEvent,
+		t.dropNetns,
+	)
+	if err != nil {
+		return fmt.Errorf("creating network tracer: %w", err)
+	}
+	t.Tracer = networkTracer
+	return nil
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	<-t.ctx.Done()
+	return nil
+}
+
+func (t *Tracer) Close() {
+	if t.cancel != nil {
+		t.cancel()
+	}
+
+	if t.Tracer != nil {
+		t.Tracer.Close()
+	}
+}
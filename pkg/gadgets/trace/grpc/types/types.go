@@ -0,0 +1,81 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/environment"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	RoleClient = "client"
+	RoleServer = "server"
+)
+
+// Event is a gRPC call, reported once the server answered to it
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+	eventtypes.WithNetNsID
+
+	Pid  uint32 `json:"pid,omitempty" column:"pid,template:pid"`
+	Tid  uint32 `json:"tid,omitempty" column:"tid,template:pid,hide"`
+	Comm string `json:"comm,omitempty" column:"comm,template:comm"`
+
+	// Role tells whether the traced container is the client or the server
+	// of the call
+	Role string `json:"role,omitempty" column:"role,width:6,fixed"`
+
+	ClientIP   string `json:"clientIP,omitempty" column:"clientip,template:ipaddr,hide"`
+	ServerIP   string `json:"serverIP,omitempty" column:"serverip,template:ipaddr,hide"`
+	ClientPort uint16 `json:"clientPort,omitempty" column:"clientport,template:ipport,hide"`
+	ServerPort uint16 `json:"serverPort,omitempty" column:"serverport,template:ipport,hide"`
+
+	StreamID  uint32 `json:"streamID,omitempty" column:"stream,minWidth:6,hide"`
+	Authority string `json:"authority,omitempty" column:"authority,minWidth:16,maxWidth:40,hide"`
+	Service   string `json:"service,omitempty" column:"service,minWidth:16,maxWidth:50"`
+	Method    string `json:"method,omitempty" column:"method,minWidth:12,maxWidth:40"`
+
+	// Status is the name of the gRPC status code, e.g. OK or UNAVAILABLE
+	Status     string `json:"status,omitempty" column:"status,minWidth:2,maxWidth:19"`
+	StatusCode uint32 `json:"statusCode" column:"code,width:4,hide"`
+	Message    string `json:"message,omitempty" column:"message,minWidth:16,maxWidth:60,hide"`
+	HTTPStatus string `json:"httpStatus,omitempty" column:"httpstatus,width:4,hide"`
+
+	// Latency is the time between the request headers and the end of the
+	// response
+	Latency time.Duration `json:"latency,omitempty" column:"latency,minWidth:10,align:right"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	// Hide container column for kubernetes environment
+	if environment.Environment == environment.Kubernetes {
+		col, _ := cols.GetColumn("container")
+		col.Visible = false
+	}
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
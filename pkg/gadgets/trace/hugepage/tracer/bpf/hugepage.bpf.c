@@ -0,0 +1,139 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "hugepage.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+#define VM_FAULT_FALLBACK	0x000800
+
+const volatile __u64 min_latency_ns = 0;
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event *unusedevent __attribute__((unused));
+
+struct start_key {
+	__u32 tid;
+	enum hugepage_op op;
+};
+
+struct start_t {
+	__u64 ts;
+	__u32 order;
+};
+
+// A compaction can happen while handling a fault, the operations in progress
+// are indexed by thread and operation
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, struct start_key);
+	__type(value, struct start_t);
+} starts SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, sizeof(__u32));
+} events SEC(".maps");
+
+static __always_inline int trace_entry(enum hugepage_op op, __u32 order)
+{
+	struct start_key key = {};
+	struct start_t start = {};
+
+	if (gadget_should_discard_mntns_id(gadget_get_mntns_id()))
+		return 0;
+
+	key.tid = (__u32)bpf_get_current_pid_tgid();
+	key.op = op;
+	start.ts = bpf_ktime_get_ns();
+	start.order = order;
+	bpf_map_update_elem(&starts, &key, &start, BPF_ANY);
+	return 0;
+}
+
+static __always_inline int trace_exit(struct pt_regs *ctx, enum hugepage_op op,
+				      enum hugepage_op result, __s32 ret)
+{
+	__u64 pid_tgid = bpf_get_current_pid_tgid();
+	struct start_key key = {};
+	struct event event = {};
+	struct start_t *start;
+	__u64 ts = bpf_ktime_get_ns();
+
+	key.tid = (__u32)pid_tgid;
+	key.op = op;
+	start = bpf_map_lookup_elem(&starts, &key);
+	if (!start)
+		return 0;
+
+	event.latency = ts - start->ts;
+	if (event.latency < min_latency_ns)
+		goto cleanup;
+
+	event.mntns_id = gadget_get_mntns_id();
+	event.timestamp = bpf_ktime_get_boot_ns();
+	event.pid = pid_tgid >> 32;
+	event.tid = (__u32)pid_tgid;
+	event.order = start->order;
+	event.ret = ret;
+	event.op = result;
+	bpf_get_current_comm(&event.task, sizeof(event.task));
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+
+cleanup:
+	bpf_map_delete_elem(&starts, &key);
+	return 0;
+}
+
+SEC("kprobe/do_huge_pmd_anonymous_page")
+int BPF_KPROBE(ig_hp_fault_e)
+{
+	return trace_entry(HUGEPAGE_OP_FAULT, 0);
+}
+
+SEC("kretprobe/do_huge_pmd_anonymous_page")
+int BPF_KRETPROBE(ig_hp_fault_x, unsigned int ret)
+{
+	enum hugepage_op result = HUGEPAGE_OP_FAULT;
+
+	if (ret & VM_FAULT_FALLBACK)
+		result = HUGEPAGE_OP_FALLBACK;
+
+	return trace_exit(ctx, HUGEPAGE_OP_FAULT, result, 0);
+}
+
+// Attached to the function splitting huge pages of the running kernel, its
+// name changed over time
+SEC("kprobe/split_huge_page")
+int BPF_KPROBE(ig_hp_split_e)
+{
+	return trace_entry(HUGEPAGE_OP_SPLIT, 0);
+}
+
+SEC("kretprobe/split_huge_page")
+int BPF_KRETPROBE(ig_hp_split_x, int ret)
+{
+	return trace_exit(ctx, HUGEPAGE_OP_SPLIT, HUGEPAGE_OP_SPLIT, ret);
+}
+
+// Direct compaction done by the allocator when there isn't any free block of
+// the requested order
+SEC("kprobe/try_to_compact_pages")
+int BPF_KPROBE(ig_hp_compact_e, gfp_t gfp_mask, unsigned int order)
+{
+	return trace_entry(HUGEPAGE_OP_COMPACT, order);
+}
+
+SEC("kretprobe/try_to_compact_pages")
+int BPF_KRETPROBE(ig_hp_compact_x, int ret)
+{
+	return trace_exit(ctx, HUGEPAGE_OP_COMPACT, HUGEPAGE_OP_COMPACT, ret);
+}
+
+char LICENSE[] SEC("license") = "GPL";
@@ -0,0 +1,28 @@
+/* SPDX-License-Identifier: GPL-2.0 */
+#ifndef GADGET_HUGEPAGE_H
+#define GADGET_HUGEPAGE_H
+
+#define TASK_COMM_LEN	16
+
+enum hugepage_op : u8 {
+	HUGEPAGE_OP_FAULT,
+	HUGEPAGE_OP_FALLBACK,
+	HUGEPAGE_OP_SPLIT,
+	HUGEPAGE_OP_COMPACT,
+};
+
+struct event {
+	__u64 mntns_id;
+	__u64 timestamp;
+	__u64 latency;
+	__u32 pid;
+	__u32 tid;
+	/* Order of the allocation that stalled in compaction */
+	__u32 order;
+	/* Result of the split or of the compaction */
+	__s32 ret;
+	enum hugepage_op op;
+	__u8 task[TASK_COMM_LEN];
+};
+
+#endif /* GADGET_HUGEPAGE_H */
@@ -0,0 +1,174 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build arm64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type hugepageEvent struct {
+	MntnsId   uint64
+	Timestamp uint64
+	Latency   uint64
+	Pid       uint32
+	Tid       uint32
+	Order     uint32
+	Ret       int32
+	Op        hugepageHugepageOp
+	Task      [16]uint8
+	_         [7]byte
+}
+
+type hugepageHugepageOp uint8
+
+const (
+	hugepageHugepageOpHUGEPAGE_OP_FAULT    hugepageHugepageOp = 0
+	hugepageHugepageOpHUGEPAGE_OP_FALLBACK hugepageHugepageOp = 1
+	hugepageHugepageOpHUGEPAGE_OP_SPLIT    hugepageHugepageOp = 2
+	hugepageHugepageOpHUGEPAGE_OP_COMPACT  hugepageHugepageOp = 3
+)
+
+type hugepageStartKey struct {
+	Tid uint32
+	Op  hugepageHugepageOp
+	_   [3]byte
+}
+
+type hugepageStartT struct {
+	Ts    uint64
+	Order uint32
+	_     [4]byte
+}
+
+// loadHugepage returns the embedded CollectionSpec for hugepage.
+func loadHugepage() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_HugepageBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load hugepage: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadHugepageObjects loads hugepage and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*hugepageObjects
+//	*hugepagePrograms
+//	*hugepageMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadHugepageObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadHugepage()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// hugepageSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type hugepageSpecs struct {
+	hugepageProgramSpecs
+	hugepageMapSpecs
+}
+
+// hugepageSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type hugepageProgramSpecs struct {
+	IgHpCompactE *ebpf.ProgramSpec `ebpf:"ig_hp_compact_e"`
+	IgHpCompactX *ebpf.ProgramSpec `ebpf:"ig_hp_compact_x"`
+	IgHpFaultE   *ebpf.ProgramSpec `ebpf:"ig_hp_fault_e"`
+	IgHpFaultX   *ebpf.ProgramSpec `ebpf:"ig_hp_fault_x"`
+	IgHpSplitE   *ebpf.ProgramSpec `ebpf:"ig_hp_split_e"`
+	IgHpSplitX   *ebpf.ProgramSpec `ebpf:"ig_hp_split_x"`
+}
+
+// hugepageMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type hugepageMapSpecs struct {
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Starts               *ebpf.MapSpec `ebpf:"starts"`
+}
+
+// hugepageObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadHugepageObjects or ebpf.CollectionSpec.LoadAndAssign.
+type hugepageObjects struct {
+	hugepagePrograms
+	hugepageMaps
+}
+
+func (o *hugepageObjects) Close() error {
+	return _HugepageClose(
+		&o.hugepagePrograms,
+		&o.hugepageMaps,
+	)
+}
+
+// hugepageMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadHugepageObjects or ebpf.CollectionSpec.LoadAndAssign.
+type hugepageMaps struct {
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Starts               *ebpf.Map `ebpf:"starts"`
+}
+
+func (m *hugepageMaps) Close() error {
+	return _HugepageClose(
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.Starts,
+	)
+}
+
+// hugepagePrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadHugepageObjects or ebpf.CollectionSpec.LoadAndAssign.
+type hugepagePrograms struct {
+	IgHpCompactE *ebpf.Program `ebpf:"ig_hp_compact_e"`
+	IgHpCompactX *ebpf.Program `ebpf:"ig_hp_compact_x"`
+	IgHpFaultE   *ebpf.Program `ebpf:"ig_hp_fault_e"`
+	IgHpFaultX   *ebpf.Program `ebpf:"ig_hp_fault_x"`
+	IgHpSplitE   *ebpf.Program `ebpf:"ig_hp_split_e"`
+	IgHpSplitX   *ebpf.Program `ebpf:"ig_hp_split_x"`
+}
+
+func (p *hugepagePrograms) Close() error {
+	return _HugepageClose(
+		p.IgHpCompactE,
+		p.IgHpCompactX,
+		p.IgHpFaultE,
+		p.IgHpFaultX,
+		p.IgHpSplitE,
+		p.IgHpSplitX,
+	)
+}
+
+func _HugepageClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed hugepage_bpfel_arm64.o
+var _HugepageBytes []byte
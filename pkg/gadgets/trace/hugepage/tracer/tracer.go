@@ -0,0 +1,236 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/hugepage/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -type event -type hugepage_op hugepage ./bpf/hugepage.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
+
+type Config struct {
+	MountnsMap *ebpf.Map
+	MinLatency time.Duration
+}
+
+type Tracer struct {
+	config        *Config
+	enricher      gadgets.DataEnricherByMntNs
+	eventCallback func(*types.Event)
+
+	objs   hugepageObjects
+	links  []link.Link
+	reader *perf.Reader
+}
+
+func NewTracer(config *Config, enricher gadgets.DataEnricherByMntNs,
+	eventCallback func(*types.Event),
+) (*Tracer, error) {
+	t := &Tracer{
+		config:        config,
+		enricher:      enricher,
+		eventCallback: eventCallback,
+	}
+
+	if err := t.install(); err != nil {
+		t.close()
+		return nil, err
+	}
+
+	go t.run()
+
+	return t, nil
+}
+
+// Stop stops the tracer
+// TODO: Remove after refactoring
+func (t *Tracer) Stop() {
+	t.close()
+}
+
+func (t *Tracer) close() {
+	for i, l := range t.links {
+		t.links[i] = gadgets.CloseLink(l)
+	}
+
+	if t.reader != nil {
+		t.reader.Close()
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadHugepage()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	consts := map[string]interface{}{
+		"min_latency_ns": uint64(t.config.MinLatency.Nanoseconds()),
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, consts, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	type probe struct {
+		symbol string
+		entry  *ebpf.Program
+		exit   *ebpf.Program
+	}
+
+	// The function splitting huge pages was renamed over time, the first
+	// one found in each list is used
+	probes := [][]probe{
+		{{"do_huge_pmd_anonymous_page", t.objs.IgHpFaultE, t.objs.IgHpFaultX}},
+		{
+			{"__folio_split", t.objs.IgHpSplitE, t.objs.IgHpSplitX},
+			{"split_huge_page_to_list_to_order", t.objs.IgHpSplitE, t.objs.IgHpSplitX},
+			{"split_huge_page_to_list", t.objs.IgHpSplitE, t.objs.IgHpSplitX},
+		},
+		{{"try_to_compact_pages", t.objs.IgHpCompactE, t.objs.IgHpCompactX}},
+	}
+
+	for _, candidates := range probes {
+		var l link.Link
+		var p probe
+		for _, p = range candidates {
+			l, err = link.Kprobe(p.symbol, p.entry, nil)
+			if err == nil || !errors.Is(err, os.ErrNotExist) {
+				break
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("attaching kprobe %s: %w", candidates[0].symbol, err)
+		}
+		t.links = append(t.links, l)
+
+		l, err = link.Kretprobe(p.symbol, p.exit, nil)
+		if err != nil {
+			return fmt.Errorf("attaching kretprobe %s: %w", p.symbol, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	t.reader, err = perf.NewReader(t.objs.hugepageMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	if err != nil {
+		return fmt.Errorf("creating perf ring buffer: %w", err)
+	}
+
+	return nil
+}
+
+var operations = map[hugepageHugepageOp]string{
+	hugepageHugepageOpHUGEPAGE_OP_FAULT:    types.OperationFault,
+	hugepageHugepageOpHUGEPAGE_OP_FALLBACK: types.OperationFallback,
+	hugepageHugepageOpHUGEPAGE_OP_SPLIT:    types.OperationSplit,
+	hugepageHugepageOpHUGEPAGE_OP_COMPACT:  types.OperationCompact,
+}
+
+func (t *Tracer) run() {
+	for {
+		record, err := t.reader.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				// nothing to do, we're done
+				return
+			}
+
+			msg := fmt.Sprintf("Error reading perf ring buffer: %s", err)
+			t.eventCallback(types.Base(eventtypes.Err(msg)))
+			return
+		}
+
+		if record.LostSamples > 0 {
+			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
+			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			continue
+		}
+
+		bpfEvent := (*hugepageEvent)(unsafe.Pointer(&record.RawSample[0]))
+
+		event := types.Event{
+			Event: eventtypes.Event{
+				Type:      eventtypes.NORMAL,
+				Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+			},
+			WithMountNsID: eventtypes.WithMountNsID{MountNsID: bpfEvent.MntnsId},
+			Pid:           bpfEvent.Pid,
+			Tid:           bpfEvent.Tid,
+			Comm:          gadgets.FromCString(bpfEvent.Task[:]),
+			Operation:     operations[bpfEvent.Op],
+			Latency:       time.Duration(bpfEvent.Latency),
+			Order:         bpfEvent.Order,
+			Ret:           bpfEvent.Ret,
+		}
+
+		if t.enricher != nil {
+			t.enricher.EnrichByMntNs(&event.CommonData, event.MountNsID)
+		}
+
+		t.eventCallback(&event)
+	}
+}
+
+// --- Registry changes
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	t.config.MinLatency = gadgetCtx.GadgetParams().Get(ParamMinLatency).AsDuration()
+
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	go t.run()
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+	}
+	return tracer, nil
+}
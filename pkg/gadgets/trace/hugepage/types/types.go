@@ -0,0 +1,60 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperationFault    = "fault"
+	OperationFallback = "fallback"
+	OperationSplit    = "split"
+	OperationCompact  = "compact"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Pid       uint32        `json:"pid,omitempty" column:"pid,template:pid"`
+	Tid       uint32        `json:"tid,omitempty" column:"tid,template:pid,hide"`
+	Comm      string        `json:"comm,omitempty" column:"comm,template:comm"`
+	Operation string        `json:"operation,omitempty" column:"op,width:8,fixed" columnDesc:"fault for a huge page allocated on a page fault, fallback for a fault that fell back to small pages, split for a huge page split, compact for a direct compaction stall"`
+	Latency   time.Duration `json:"latency,omitempty" column:"latency,minWidth:10,align:right"`
+	// Order is the order of the allocation that stalled, for compactions
+	Order uint32 `json:"order,omitempty" column:"order,width:5,hide"`
+	// Ret is the value returned by the kernel for splits and compactions
+	Ret int32 `json:"ret,omitempty" column:"ret,width:4,hide"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	cols.MustSetExtractor("latency", func(event *Event) string {
+		return event.Latency.String()
+	})
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
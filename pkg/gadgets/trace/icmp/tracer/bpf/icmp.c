@@ -0,0 +1,199 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+
+#include <linux/bpf.h>
+#include <linux/if_ether.h>
+#include <linux/if_packet.h>
+#include <linux/ip.h>
+#include <linux/ipv6.h>
+#include <linux/in.h>
+#include <linux/icmp.h>
+#include <linux/icmpv6.h>
+#include <sys/socket.h>
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_endian.h>
+
+#include "icmp.h"
+
+#define ICMP_KIND_OTHER		0
+#define ICMP_KIND_ECHO_REQUEST	1
+#define ICMP_KIND_ECHO_REPLY	2
+#define ICMP_KIND_ERROR		3
+
+// The socket enricher only tracks TCP and UDP sockets, so the events aren't
+// enriched with the process.
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event_t *unusedevent __attribute__((unused));
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+} events SEC(".maps");
+
+// Timestamps of the echo requests sent by the containers, used to compute the
+// RTT when the reply arrives
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, MAX_PENDING_ECHOS);
+	__type(key, struct echo_key);
+	__type(value, __u64);
+} echo_requests SEC(".maps");
+
+static __always_inline int icmp_kind(__u32 af, __u8 type)
+{
+	if (af == AF_INET) {
+		switch (type) {
+		case ICMP_ECHO:
+			return ICMP_KIND_ECHO_REQUEST;
+		case ICMP_ECHOREPLY:
+			return ICMP_KIND_ECHO_REPLY;
+		case ICMP_DEST_UNREACH:
+		case ICMP_TIME_EXCEEDED:
+			return ICMP_KIND_ERROR;
+		}
+	} else {
+		switch (type) {
+		case ICMPV6_ECHO_REQUEST:
+			return ICMP_KIND_ECHO_REQUEST;
+		case ICMPV6_ECHO_REPLY:
+			return ICMP_KIND_ECHO_REPLY;
+		case ICMPV6_DEST_UNREACH:
+		case ICMPV6_TIME_EXCEED:
+			return ICMP_KIND_ERROR;
+		}
+	}
+
+	return ICMP_KIND_OTHER;
+}
+
+static __always_inline void
+fill_echo_key(struct echo_key *key, struct event_t *event, int request)
+{
+	key->af = event->af;
+	key->id = event->id;
+	key->seq = event->seq;
+
+	// The requester sends the request and receives the reply
+	if (request) {
+		__builtin_memcpy(key->requester, event->saddr_v6, sizeof(key->requester));
+		__builtin_memcpy(key->responder, event->daddr_v6, sizeof(key->responder));
+	} else {
+		__builtin_memcpy(key->requester, event->daddr_v6, sizeof(key->requester));
+		__builtin_memcpy(key->responder, event->saddr_v6, sizeof(key->responder));
+	}
+}
+
+// Errors carry the IP header of the packet that caused them right after the
+// ICMP header
+static __always_inline void
+load_orig_packet(struct __sk_buff *skb, int off, struct event_t *event)
+{
+	if (event->af == AF_INET) {
+		struct iphdr iph;
+		if (bpf_skb_load_bytes(skb, off, &iph, sizeof(iph)))
+			return;
+		event->orig_daddr_v4 = iph.daddr;
+		event->orig_proto = iph.protocol;
+	} else {
+		struct ipv6hdr ip6h;
+		if (bpf_skb_load_bytes(skb, off, &ip6h, sizeof(ip6h)))
+			return;
+		__builtin_memcpy(event->orig_daddr_v6, ip6h.daddr.in6_u.u6_addr8, sizeof(event->orig_daddr_v6));
+		event->orig_proto = ip6h.nexthdr;
+	}
+}
+
+SEC("socket1")
+int ig_trace_icmp(struct __sk_buff *skb)
+{
+	struct event_t event = {0,};
+	int icmp_off;
+
+	struct ethhdr ethh;
+	if (bpf_skb_load_bytes(skb, 0, &ethh, sizeof ethh))
+		return 0;
+
+	switch (bpf_ntohs(ethh.h_proto)) {
+	case ETH_P_IP: {
+		struct iphdr iph;
+		if (bpf_skb_load_bytes(skb, ETH_HLEN, &iph, sizeof iph))
+			return 0;
+		if (iph.protocol != IPPROTO_ICMP)
+			return 0;
+
+		event.af = AF_INET;
+		event.saddr_v4 = iph.saddr;
+		event.daddr_v4 = iph.daddr;
+		// The IHL field is the size of the header in 32-bit words
+		icmp_off = ETH_HLEN + iph.ihl * 4;
+		break;
+	}
+	case ETH_P_IPV6: {
+		struct ipv6hdr ip6h;
+		if (bpf_skb_load_bytes(skb, ETH_HLEN, &ip6h, sizeof ip6h))
+			return 0;
+		// ICMPv6 messages following extension headers aren't traced
+		if (ip6h.nexthdr != IPPROTO_ICMPV6)
+			return 0;
+
+		event.af = AF_INET6;
+		__builtin_memcpy(event.saddr_v6, ip6h.saddr.in6_u.u6_addr8, sizeof(event.saddr_v6));
+		__builtin_memcpy(event.daddr_v6, ip6h.daddr.in6_u.u6_addr8, sizeof(event.daddr_v6));
+		icmp_off = ETH_HLEN + sizeof(ip6h);
+		break;
+	}
+	default:
+		return 0;
+	}
+
+	struct icmp_hdr icmph;
+	if (bpf_skb_load_bytes(skb, icmp_off, &icmph, sizeof icmph))
+		return 0;
+
+	int kind = icmp_kind(event.af, icmph.type);
+	if (kind == ICMP_KIND_OTHER)
+		return 0;
+
+	event.timestamp = bpf_ktime_get_boot_ns();
+	event.type = icmph.type;
+	event.code = icmph.code;
+	event.pkt_type = skb->pkt_type;
+
+	struct echo_key key = {0,};
+	switch (kind) {
+	case ICMP_KIND_ECHO_REQUEST:
+		event.id = bpf_ntohs(icmph.id);
+		event.seq = bpf_ntohs(icmph.seq);
+
+		// Only measure the requests sent by the container, not the ones
+		// forwarded to it or going through the host netns.
+		if (event.pkt_type == PACKET_OUTGOING) {
+			fill_echo_key(&key, &event, 1);
+			bpf_map_update_elem(&echo_requests, &key, &event.timestamp, BPF_ANY);
+		}
+		break;
+	case ICMP_KIND_ECHO_REPLY:
+		event.id = bpf_ntohs(icmph.id);
+		event.seq = bpf_ntohs(icmph.seq);
+
+		if (event.pkt_type == PACKET_HOST) {
+			fill_echo_key(&key, &event, 0);
+			__u64 *ts = bpf_map_lookup_elem(&echo_requests, &key);
+			if (ts) {
+				event.rtt = event.timestamp - *ts;
+				bpf_map_delete_elem(&echo_requests, &key);
+			}
+		}
+		break;
+	case ICMP_KIND_ERROR:
+		load_orig_packet(skb, icmp_off + sizeof(icmph), &event);
+		break;
+	}
+
+	bpf_perf_event_output(skb, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+
+	return 0;
+}
+
+char _license[] SEC("license") = "GPL";
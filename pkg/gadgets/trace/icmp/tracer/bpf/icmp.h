@@ -0,0 +1,55 @@
+#ifndef GADGET_ICMP_H
+#define GADGET_ICMP_H
+
+// Maximum number of echo requests waiting for their reply. Older ones are
+// evicted, their replies won't have the RTT set.
+#define MAX_PENDING_ECHOS 10240
+
+struct event_t {
+	__u64 timestamp;
+	// Time between the echo request and its reply, only set for replies
+	__u64 rtt;
+
+	union {
+		__u8 saddr_v6[16];
+		__u32 saddr_v4;
+	};
+	union {
+		__u8 daddr_v6[16];
+		__u32 daddr_v4;
+	};
+	// Destination of the packet that caused an error message
+	union {
+		__u8 orig_daddr_v6[16];
+		__u32 orig_daddr_v4;
+	};
+	__u32 af; // AF_INET or AF_INET6
+
+	__u16 id;
+	__u16 seq;
+	__u8 type;
+	__u8 code;
+	__u8 pkt_type;
+	// Protocol of the packet that caused an error message
+	__u8 orig_proto;
+};
+
+// Common part of the ICMP and ICMPv6 headers, the id and seq fields are only
+// meaningful for echo messages
+struct icmp_hdr {
+	__u8 type;
+	__u8 code;
+	__u16 checksum;
+	__u16 id;
+	__u16 seq;
+};
+
+struct echo_key {
+	__u8 requester[16];
+	__u8 responder[16];
+	__u32 af;
+	__u16 id;
+	__u16 seq;
+};
+
+#endif
@@ -0,0 +1,146 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64 || amd64p32 || arm || arm64 || loong64 || mips64le || mips64p32le || mipsle || ppc64le || riscv64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type icmpEchoKey struct {
+	Requester [16]uint8
+	Responder [16]uint8
+	Af        uint32
+	Id        uint16
+	Seq       uint16
+}
+
+type icmpEventT struct {
+	Timestamp   uint64
+	Rtt         uint64
+	SaddrV6     [16]uint8
+	DaddrV6     [16]uint8
+	OrigDaddrV6 [16]uint8
+	Af          uint32
+	Id          uint16
+	Seq         uint16
+	Type        uint8
+	Code        uint8
+	PktType     uint8
+	OrigProto   uint8
+	_           [4]byte
+}
+
+// loadIcmp returns the embedded CollectionSpec for icmp.
+func loadIcmp() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_IcmpBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load icmp: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadIcmpObjects loads icmp and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*icmpObjects
+//	*icmpPrograms
+//	*icmpMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadIcmpObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadIcmp()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// icmpSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type icmpSpecs struct {
+	icmpProgramSpecs
+	icmpMapSpecs
+}
+
+// icmpSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type icmpProgramSpecs struct {
+	IgTraceIcmp *ebpf.ProgramSpec `ebpf:"ig_trace_icmp"`
+}
+
+// icmpMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type icmpMapSpecs struct {
+	EchoRequests *ebpf.MapSpec `ebpf:"echo_requests"`
+	Events       *ebpf.MapSpec `ebpf:"events"`
+}
+
+// icmpObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadIcmpObjects or ebpf.CollectionSpec.LoadAndAssign.
+type icmpObjects struct {
+	icmpPrograms
+	icmpMaps
+}
+
+func (o *icmpObjects) Close() error {
+	return _IcmpClose(
+		&o.icmpPrograms,
+		&o.icmpMaps,
+	)
+}
+
+// icmpMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadIcmpObjects or ebpf.CollectionSpec.LoadAndAssign.
+type icmpMaps struct {
+	EchoRequests *ebpf.Map `ebpf:"echo_requests"`
+	Events       *ebpf.Map `ebpf:"events"`
+}
+
+func (m *icmpMaps) Close() error {
+	return _IcmpClose(
+		m.EchoRequests,
+		m.Events,
+	)
+}
+
+// icmpPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadIcmpObjects or ebpf.CollectionSpec.LoadAndAssign.
+type icmpPrograms struct {
+	IgTraceIcmp *ebpf.Program `ebpf:"ig_trace_icmp"`
+}
+
+func (p *icmpPrograms) Close() error {
+	return _IcmpClose(
+		p.IgTraceIcmp,
+	)
+}
+
+func _IcmpClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed icmp_bpfel.o
+var _IcmpBytes []byte
@@ -0,0 +1,250 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/networktracer"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/icmp/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate bash -c "source ./clangosflags.sh; go run github.com/cilium/ebpf/cmd/bpf2go -target bpfel -cc clang -type event_t icmp ./bpf/icmp.c -- $CLANG_OS_FLAGS -I./bpf/"
+
+const (
+	BPFProgName     = "ig_trace_icmp"
+	BPFPerfMapName  = "events"
+	BPFSocketAttach = 50
+)
+
+type Tracer struct {
+	*networktracer.Tracer[types.Event]
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewTracer() (*Tracer, error) {
+	t := &Tracer{}
+
+	if err := t.install(); err != nil {
+		t.Close()
+		return nil, fmt.Errorf("installing tracer: %w", err)
+	}
+
+	return t, nil
+}
+
+// pkt_type definitions:
+// https://github.com/torvalds/linux/blob/v5.14-rc7/include/uapi/linux/if_packet.h#L26
+var pktTypeNames = []string{
+	"HOST",
+	"BROADCAST",
+	"MULTICAST",
+	"OTHERHOST",
+	"OUTGOING",
+	"LOOPBACK",
+	"USER",
+	"KERNEL",
+}
+
+// https://www.iana.org/assignments/icmp-parameters/icmp-parameters.xhtml
+var icmpTypeNames = map[uint8]string{
+	0:  types.TypeEchoReply,
+	3:  types.TypeDestUnreachable,
+	8:  types.TypeEchoRequest,
+	11: types.TypeTimeExceeded,
+}
+
+var icmpUnreachableCodeNames = map[uint8]string{
+	0:  "NET_UNREACH",
+	1:  "HOST_UNREACH",
+	2:  "PROT_UNREACH",
+	3:  "PORT_UNREACH",
+	4:  "FRAG_NEEDED",
+	5:  "SR_FAILED",
+	6:  "NET_UNKNOWN",
+	7:  "HOST_UNKNOWN",
+	9:  "NET_ANO",
+	10: "HOST_ANO",
+	13: "PKT_FILTERED",
+}
+
+var icmpTimeExceededCodeNames = map[uint8]string{
+	0: "TTL",
+	1: "FRAG_REASM",
+}
+
+// https://www.iana.org/assignments/icmpv6-parameters/icmpv6-parameters.xhtml
+var icmpv6TypeNames = map[uint8]string{
+	1:   types.TypeDestUnreachable,
+	3:   types.TypeTimeExceeded,
+	128: types.TypeEchoRequest,
+	129: types.TypeEchoReply,
+}
+
+var icmpv6UnreachableCodeNames = map[uint8]string{
+	0: "NO_ROUTE",
+	1: "ADM_PROHIBITED",
+	2: "NOT_NEIGHBOUR",
+	3: "ADDR_UNREACH",
+	4: "PORT_UNREACH",
+	5: "POLICY_FAIL",
+	6: "REJECT_ROUTE",
+}
+
+var icmpv6TimeExceededCodeNames = map[uint8]string{
+	0: "HOPLIMIT",
+	1: "FRAG_REASM",
+}
+
+var ipProtocolNames = map[uint8]string{
+	syscall.IPPROTO_ICMP:   "ICMP",
+	syscall.IPPROTO_TCP:    "TCP",
+	syscall.IPPROTO_UDP:    "UDP",
+	syscall.IPPROTO_ICMPV6: "ICMPv6",
+}
+
+func codeName(af uint32, icmpType string, code uint8) string {
+	var names map[uint8]string
+	switch icmpType {
+	case types.TypeDestUnreachable:
+		names = icmpUnreachableCodeNames
+		if af == syscall.AF_INET6 {
+			names = icmpv6UnreachableCodeNames
+		}
+	case types.TypeTimeExceeded:
+		names = icmpTimeExceededCodeNames
+		if af == syscall.AF_INET6 {
+			names = icmpv6TimeExceededCodeNames
+		}
+	default:
+		// Echo messages don't have codes
+		return ""
+	}
+
+	if name, ok := names[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("CODE_%d", code)
+}
+
+func parseICMPEvent(sample []byte, netns uint64) (*types.Event, error) {
+	bpfEvent := (*icmpEventT)(unsafe.Pointer(&sample[0]))
+	if len(sample) < int(unsafe.Sizeof(*bpfEvent)) {
+		return nil, errors.New("invalid sample size")
+	}
+
+	event := types.Event{
+		Event: eventtypes.Event{
+			Type:      eventtypes.NORMAL,
+			Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+		},
+		WithNetNsID: eventtypes.WithNetNsID{NetNsID: netns},
+
+		ID:  bpfEvent.Id,
+		Seq: bpfEvent.Seq,
+		RTT: time.Duration(bpfEvent.Rtt),
+	}
+
+	typeNames := icmpTypeNames
+	switch bpfEvent.Af {
+	case syscall.AF_INET:
+		event.IPVersion = 4
+	case syscall.AF_INET6:
+		event.IPVersion = 6
+		typeNames = icmpv6TypeNames
+	}
+
+	event.Saddr = gadgets.IPStringFromBytes(bpfEvent.SaddrV6, event.IPVersion)
+	event.Daddr = gadgets.IPStringFromBytes(bpfEvent.DaddrV6, event.IPVersion)
+
+	event.ICMPType = typeNames[bpfEvent.Type]
+	event.Code = codeName(bpfEvent.Af, event.ICMPType, bpfEvent.Code)
+
+	if event.ICMPType == types.TypeDestUnreachable || event.ICMPType == types.TypeTimeExceeded {
+		event.OrigDaddr = gadgets.IPStringFromBytes(bpfEvent.OrigDaddrV6, event.IPVersion)
+		event.OrigProtocol = ipProtocolNames[bpfEvent.OrigProto]
+	}
+
+	event.PktType = "UNKNOWN"
+	if pktTypeUint := uint(bpfEvent.PktType); pktTypeUint < uint(len(pktTypeNames)) {
+		event.PktType = pktTypeNames[pktTypeUint]
+	}
+
+	return &event, nil
+}
+
+// --- Registry changes
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return &Tracer{}, nil
+}
+
+func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
+	if err := t.install(); err != nil {
+		t.Close()
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	t.ctx, t.cancel = gadgetcontext.WithTimeoutOrCancel(gadgetCtx.Context(), gadgetCtx.Timeout())
+	return nil
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadIcmp()
+	if err != nil {
+		return fmt.Errorf("loading asset: %w", err)
+	}
+
+	networkTracer, err := networktracer.NewTracer(
+		spec,
+		BPFProgName,
+		BPFPerfMapName,
+		BPFSocketAttach,
+		types.Base,
+		parseICMPEvent,
+	)
+	if err != nil {
+		return fmt.Errorf("creating network tracer: %w", err)
+	}
+	t.Tracer = networkTracer
+	return nil
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	<-t.ctx.Done()
+	return nil
+}
+
+func (t *Tracer) Close() {
+	if t.cancel != nil {
+		t.cancel()
+	}
+
+	if t.Tracer != nil {
+		t.Tracer.Close()
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/environment"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	TypeEchoRequest     = "ECHO_REQUEST"
+	TypeEchoReply       = "ECHO_REPLY"
+	TypeDestUnreachable = "DEST_UNREACH"
+	TypeTimeExceeded    = "TIME_EXCEEDED"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithNetNsID
+
+	IPVersion int    `json:"ipversion,omitempty" column:"ip,template:ipversion"`
+	Saddr     string `json:"saddr,omitempty" column:"saddr,template:ipaddr"`
+	Daddr     string `json:"daddr,omitempty" column:"daddr,template:ipaddr"`
+	PktType   string `json:"pktType,omitempty" column:"pkttype,minWidth:4,maxWidth:9,hide"`
+
+	ICMPType string        `json:"icmpType,omitempty" column:"type,minWidth:10,maxWidth:13"`
+	Code     string        `json:"code,omitempty" column:"code,minWidth:4,maxWidth:14"`
+	ID       uint16        `json:"id,omitempty" column:"id,width:5,fixed,hide"`
+	Seq      uint16        `json:"seq,omitempty" column:"seq,width:5,fixed"`
+	RTT      time.Duration `json:"rtt,omitempty" column:"rtt,minWidth:8"`
+
+	// Destination and protocol of the packet that caused an unreachable or
+	// time exceeded message
+	OrigDaddr    string `json:"origDaddr,omitempty" column:"origdaddr,template:ipaddr,hide"`
+	OrigProtocol string `json:"origProto,omitempty" column:"origproto,maxWidth:6,hide"`
+}
+
+func isEcho(event *Event) bool {
+	return event.ICMPType == TypeEchoRequest || event.ICMPType == TypeEchoReply
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	// Hide container column for kubernetes environment
+	if environment.Environment == environment.Kubernetes {
+		col, _ := cols.GetColumn("container")
+		col.Visible = false
+	}
+
+	// The id and sequence number are only meaningful for echo messages
+	cols.MustSetExtractor("id", func(event *Event) string {
+		if !isEcho(event) {
+			return ""
+		}
+		return strconv.FormatUint(uint64(event.ID), 10)
+	})
+	cols.MustSetExtractor("seq", func(event *Event) string {
+		if !isEcho(event) {
+			return ""
+		}
+		return strconv.FormatUint(uint64(event.Seq), 10)
+	})
+
+	cols.MustSetExtractor("rtt", func(event *Event) string {
+		// RTT is reported only for replies to requests sent by the
+		// container, and only if the request wasn't evicted meanwhile.
+		if event.RTT > 0 {
+			return event.RTT.String()
+		}
+		return ""
+	})
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "iouring.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+#define IORING_CQE_F_MORE	(1U << 1)
+
+const volatile __u64 min_latency_ns = 0;
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event *unusedevent __attribute__((unused));
+
+// The layouts of the tracepoints, they didn't change since the request was
+// added to them in Linux 5.19. io_uring_submit_sqe was renamed to
+// io_uring_submit_req in Linux 6.0, only their common fields are used.
+struct submit_args {
+	struct trace_entry ent;
+	void *ctx;
+	void *req;
+	__u64 user_data;
+	__u8 opcode;
+};
+
+struct file_get_args {
+	struct trace_entry ent;
+	void *ctx;
+	void *req;
+	__u64 user_data;
+	int fd;
+};
+
+struct complete_args {
+	struct trace_entry ent;
+	void *ctx;
+	void *req;
+	__u64 user_data;
+	int res;
+	unsigned int cflags;
+};
+
+struct req_t {
+	__u64 ts;
+	__u64 mntns_id;
+	__u32 pid;
+	__u32 tid;
+	__s32 fd;
+	__u8 opcode;
+	__u8 task[TASK_COMM_LEN];
+};
+
+// The requests in flight, indexed by their address. A request completed
+// without a completion queue entry, with IOSQE_CQE_SKIP_SUCCESS, is never
+// removed, hence the LRU.
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u64);
+	__type(value, struct req_t);
+} reqs SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, sizeof(__u32));
+} events SEC(".maps");
+
+// The requests are submitted by the task owning the ring, or by its SQPOLL
+// thread that shares its namespaces
+static __always_inline int trace_submit(struct submit_args *ctx)
+{
+	__u64 pid_tgid = bpf_get_current_pid_tgid();
+	__u64 key = (__u64)ctx->req;
+	struct req_t req = {};
+	__u64 mntns_id;
+
+	mntns_id = gadget_get_mntns_id();
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return 0;
+
+	req.ts = bpf_ktime_get_ns();
+	req.mntns_id = mntns_id;
+	req.pid = pid_tgid >> 32;
+	req.tid = (__u32)pid_tgid;
+	req.fd = -1;
+	req.opcode = ctx->opcode;
+	bpf_get_current_comm(&req.task, sizeof(req.task));
+	bpf_map_update_elem(&reqs, &key, &req, BPF_ANY);
+	return 0;
+}
+
+SEC("tracepoint/io_uring/io_uring_submit_req")
+int ig_iouring_submit_req(struct submit_args *ctx)
+{
+	return trace_submit(ctx);
+}
+
+SEC("tracepoint/io_uring/io_uring_submit_sqe")
+int ig_iouring_submit_sqe(struct submit_args *ctx)
+{
+	return trace_submit(ctx);
+}
+
+// Called when the file of a request is looked up in the file table, not for
+// the fixed files
+SEC("tracepoint/io_uring/io_uring_file_get")
+int ig_iouring_file_get(struct file_get_args *ctx)
+{
+	__u64 key = (__u64)ctx->req;
+	struct req_t *req;
+
+	req = bpf_map_lookup_elem(&reqs, &key);
+	if (!req)
+		return 0;
+
+	req->fd = ctx->fd;
+	return 0;
+}
+
+SEC("tracepoint/io_uring/io_uring_complete")
+int ig_iouring_complete(struct complete_args *ctx)
+{
+	__u64 key = (__u64)ctx->req;
+	struct event event = {};
+	struct req_t *req;
+	struct file *file;
+	const unsigned char *name;
+
+	req = bpf_map_lookup_elem(&reqs, &key);
+	if (!req)
+		return 0;
+
+	event.latency = bpf_ktime_get_ns() - req->ts;
+	if (event.latency < min_latency_ns)
+		goto cleanup;
+
+	event.mntns_id = req->mntns_id;
+	event.timestamp = bpf_ktime_get_boot_ns();
+	event.user_data = ctx->user_data;
+	event.pid = req->pid;
+	event.tid = req->tid;
+	event.res = ctx->res;
+	event.fd = req->fd;
+	event.opcode = req->opcode;
+	__builtin_memcpy(event.task, req->task, sizeof(event.task));
+
+	// The file is only known to be a file, and not another member of the
+	// union, if it was looked up for this request
+	if (req->fd >= 0) {
+		// file is the first member of struct io_kiocb in all the versions
+		bpf_probe_read_kernel(&file, sizeof(file), ctx->req);
+		name = BPF_CORE_READ(file, f_path.dentry, d_name.name);
+		bpf_probe_read_kernel_str(&event.file, sizeof(event.file), name);
+	}
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+
+cleanup:
+	// Multishot requests stay in flight until their last completion
+	if (!(ctx->cflags & IORING_CQE_F_MORE))
+		bpf_map_delete_elem(&reqs, &key);
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
@@ -0,0 +1,25 @@
+/* SPDX-License-Identifier: GPL-2.0 */
+#ifndef GADGET_IOURING_H
+#define GADGET_IOURING_H
+
+#define TASK_COMM_LEN	16
+#define FILE_NAME_LEN	32
+
+struct event {
+	__u64 mntns_id;
+	__u64 timestamp;
+	/* Time between the submission and the completion */
+	__u64 latency;
+	__u64 user_data;
+	__u32 pid;
+	__u32 tid;
+	/* Result of the operation, as in the completion queue entry */
+	__s32 res;
+	/* File descriptor of the operation, -1 if it has none or a fixed one */
+	__s32 fd;
+	__u8 opcode;
+	__u8 task[TASK_COMM_LEN];
+	__u8 file[FILE_NAME_LEN];
+};
+
+#endif /* GADGET_IOURING_H */
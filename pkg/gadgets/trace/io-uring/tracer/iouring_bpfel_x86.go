@@ -0,0 +1,160 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type iouringEvent struct {
+	MntnsId   uint64
+	Timestamp uint64
+	Latency   uint64
+	UserData  uint64
+	Pid       uint32
+	Tid       uint32
+	Res       int32
+	Fd        int32
+	Opcode    uint8
+	Task      [16]uint8
+	File      [32]uint8
+	_         [7]byte
+}
+
+type iouringReqT struct {
+	Ts      uint64
+	MntnsId uint64
+	Pid     uint32
+	Tid     uint32
+	Fd      int32
+	Opcode  uint8
+	Task    [16]uint8
+	_       [3]byte
+}
+
+// loadIouring returns the embedded CollectionSpec for iouring.
+func loadIouring() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_IouringBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load iouring: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadIouringObjects loads iouring and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*iouringObjects
+//	*iouringPrograms
+//	*iouringMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadIouringObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadIouring()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// iouringSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type iouringSpecs struct {
+	iouringProgramSpecs
+	iouringMapSpecs
+}
+
+// iouringSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type iouringProgramSpecs struct {
+	IgIouringComplete  *ebpf.ProgramSpec `ebpf:"ig_iouring_complete"`
+	IgIouringFileGet   *ebpf.ProgramSpec `ebpf:"ig_iouring_file_get"`
+	IgIouringSubmitReq *ebpf.ProgramSpec `ebpf:"ig_iouring_submit_req"`
+	IgIouringSubmitSqe *ebpf.ProgramSpec `ebpf:"ig_iouring_submit_sqe"`
+}
+
+// iouringMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type iouringMapSpecs struct {
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Reqs                 *ebpf.MapSpec `ebpf:"reqs"`
+}
+
+// iouringObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadIouringObjects or ebpf.CollectionSpec.LoadAndAssign.
+type iouringObjects struct {
+	iouringPrograms
+	iouringMaps
+}
+
+func (o *iouringObjects) Close() error {
+	return _IouringClose(
+		&o.iouringPrograms,
+		&o.iouringMaps,
+	)
+}
+
+// iouringMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadIouringObjects or ebpf.CollectionSpec.LoadAndAssign.
+type iouringMaps struct {
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Reqs                 *ebpf.Map `ebpf:"reqs"`
+}
+
+func (m *iouringMaps) Close() error {
+	return _IouringClose(
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.Reqs,
+	)
+}
+
+// iouringPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadIouringObjects or ebpf.CollectionSpec.LoadAndAssign.
+type iouringPrograms struct {
+	IgIouringComplete  *ebpf.Program `ebpf:"ig_iouring_complete"`
+	IgIouringFileGet   *ebpf.Program `ebpf:"ig_iouring_file_get"`
+	IgIouringSubmitReq *ebpf.Program `ebpf:"ig_iouring_submit_req"`
+	IgIouringSubmitSqe *ebpf.Program `ebpf:"ig_iouring_submit_sqe"`
+}
+
+func (p *iouringPrograms) Close() error {
+	return _IouringClose(
+		p.IgIouringComplete,
+		p.IgIouringFileGet,
+		p.IgIouringSubmitReq,
+		p.IgIouringSubmitSqe,
+	)
+}
+
+func _IouringClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed iouring_bpfel_x86.o
+var _IouringBytes []byte
@@ -0,0 +1,245 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/io-uring/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -type event iouring ./bpf/iouring.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
+
+type Config struct {
+	MountnsMap *ebpf.Map
+	MinLatency time.Duration
+}
+
+type Tracer struct {
+	config        *Config
+	enricher      gadgets.DataEnricherByMntNs
+	eventCallback func(*types.Event)
+
+	objs   iouringObjects
+	links  []link.Link
+	reader *perf.Reader
+}
+
+func NewTracer(config *Config, enricher gadgets.DataEnricherByMntNs,
+	eventCallback func(*types.Event),
+) (*Tracer, error) {
+	t := &Tracer{
+		config:        config,
+		enricher:      enricher,
+		eventCallback: eventCallback,
+	}
+
+	if err := t.install(); err != nil {
+		t.close()
+		return nil, err
+	}
+
+	go t.run()
+
+	return t, nil
+}
+
+// Stop stops the tracer
+// TODO: Remove after refactoring
+func (t *Tracer) Stop() {
+	t.close()
+}
+
+func (t *Tracer) close() {
+	for i, l := range t.links {
+		t.links[i] = gadgets.CloseLink(l)
+	}
+
+	if t.reader != nil {
+		t.reader.Close()
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadIouring()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	consts := map[string]interface{}{
+		"min_latency_ns": uint64(t.config.MinLatency.Nanoseconds()),
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, consts, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	type tracepoint struct {
+		name string
+		prog *ebpf.Program
+	}
+
+	// io_uring_submit_sqe was renamed to io_uring_submit_req, the first one
+	// found in each list is used
+	tracepoints := [][]tracepoint{
+		{
+			{"io_uring_submit_req", t.objs.IgIouringSubmitReq},
+			{"io_uring_submit_sqe", t.objs.IgIouringSubmitSqe},
+		},
+		{{"io_uring_file_get", t.objs.IgIouringFileGet}},
+		{{"io_uring_complete", t.objs.IgIouringComplete}},
+	}
+
+	for _, candidates := range tracepoints {
+		var l link.Link
+		for _, tp := range candidates {
+			l, err = link.Tracepoint("io_uring", tp.name, tp.prog, nil)
+			if err == nil || !errors.Is(err, os.ErrNotExist) {
+				break
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("attaching tracepoint %s: %w", candidates[0].name, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	t.reader, err = perf.NewReader(t.objs.iouringMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	if err != nil {
+		return fmt.Errorf("creating perf ring buffer: %w", err)
+	}
+
+	return nil
+}
+
+// opcodes are the names of the io_uring operations, indexed by their
+// IORING_OP_ value
+var opcodes = []string{
+	"nop", "readv", "writev", "fsync", "read_fixed", "write_fixed",
+	"poll_add", "poll_remove", "sync_file_range", "sendmsg", "recvmsg",
+	"timeout", "timeout_remove", "accept", "async_cancel", "link_timeout",
+	"connect", "fallocate", "openat", "close", "files_update", "statx",
+	"read", "write", "fadvise", "madvise", "send", "recv", "openat2",
+	"epoll_ctl", "splice", "provide_buffers", "remove_buffers", "tee",
+	"shutdown", "renameat", "unlinkat", "mkdirat", "symlinkat", "linkat",
+	"msg_ring", "fsetxattr", "setxattr", "fgetxattr", "getxattr", "socket",
+	"uring_cmd", "send_zc", "sendmsg_zc", "read_multishot", "waitid",
+	"futex_wait", "futex_wake", "futex_waitv", "fixed_fd_install",
+	"ftruncate", "bind", "listen",
+}
+
+func opcodeName(opcode uint8) string {
+	if int(opcode) < len(opcodes) {
+		return opcodes[opcode]
+	}
+	return fmt.Sprintf("op%d", opcode)
+}
+
+func (t *Tracer) run() {
+	for {
+		record, err := t.reader.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				// nothing to do, we're done
+				return
+			}
+
+			msg := fmt.Sprintf("Error reading perf ring buffer: %s", err)
+			t.eventCallback(types.Base(eventtypes.Err(msg)))
+			return
+		}
+
+		if record.LostSamples > 0 {
+			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
+			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			continue
+		}
+
+		bpfEvent := (*iouringEvent)(unsafe.Pointer(&record.RawSample[0]))
+
+		event := types.Event{
+			Event: eventtypes.Event{
+				Type:      eventtypes.NORMAL,
+				Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+			},
+			WithMountNsID: eventtypes.WithMountNsID{MountNsID: bpfEvent.MntnsId},
+			Pid:           bpfEvent.Pid,
+			Tid:           bpfEvent.Tid,
+			Comm:          gadgets.FromCString(bpfEvent.Task[:]),
+			Operation:     opcodeName(bpfEvent.Opcode),
+			Fd:            bpfEvent.Fd,
+			File:          gadgets.FromCString(bpfEvent.File[:]),
+			Res:           bpfEvent.Res,
+			Latency:       time.Duration(bpfEvent.Latency),
+			UserData:      bpfEvent.UserData,
+		}
+
+		if t.enricher != nil {
+			t.enricher.EnrichByMntNs(&event.CommonData, event.MountNsID)
+		}
+
+		t.eventCallback(&event)
+	}
+}
+
+// --- Registry changes
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	t.config.MinLatency = gadgetCtx.GadgetParams().Get(ParamMinLatency).AsDuration()
+
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	go t.run()
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config: &Config{},
+	}
+	return tracer, nil
+}
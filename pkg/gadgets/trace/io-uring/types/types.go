@@ -0,0 +1,66 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// Event is an io_uring operation, reported on its completion
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Pid       uint32        `json:"pid,omitempty" column:"pid,template:pid"`
+	Tid       uint32        `json:"tid,omitempty" column:"tid,template:pid,hide"`
+	Comm      string        `json:"comm,omitempty" column:"comm,template:comm"`
+	Operation string        `json:"operation,omitempty" column:"op,width:14"`
+	Fd        int32         `json:"fd" column:"fd,width:4,align:right"`
+	File      string        `json:"file,omitempty" column:"file,width:24,maxWidth:32"`
+	Res       int32         `json:"res" column:"res,width:6,align:right" columnDesc:"result of the operation, a negative errno on failure"`
+	Latency   time.Duration `json:"latency,omitempty" column:"latency,minWidth:10,align:right"`
+	// UserData is the value set by the application to identify the
+	// operation
+	UserData uint64 `json:"userData,omitempty" column:"userdata,width:18,hide"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	cols.MustSetExtractor("fd", func(event *Event) string {
+		if event.Fd < 0 {
+			return ""
+		}
+		return fmt.Sprint(event.Fd)
+	})
+	cols.MustSetExtractor("latency", func(event *Event) string {
+		return event.Latency.String()
+	})
+	cols.MustSetExtractor("userdata", func(event *Event) string {
+		return fmt.Sprintf("0x%x", event.UserData)
+	})
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
@@ -0,0 +1,1271 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/tcpstream"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/kafka/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	// Messages bigger than that are invalid, it's the default maximum
+	// size of the requests of the brokers (socket.request.max.bytes)
+	maxMessageSize = 100 << 20
+	// Maximum number of bytes buffered while waiting for the end of the
+	// fields being decoded
+	maxBuffered = 64 << 10
+	// Maximum number of requests waiting for their response on a
+	// connection
+	maxPendingRequests = 1024
+	// Maximum number of elements of an array
+	maxArrayLen = 1 << 20
+	// Maximum number of partitions kept per message, the other ones aren't
+	// reported
+	maxPartitions = 1024
+	// Maximum number of topic names kept per network namespace
+	maxTopicNames = 10000
+)
+
+// API keys: https://kafka.apache.org/protocol#protocol_api_keys
+const (
+	apiProduce  = 0
+	apiFetch    = 1
+	apiMetadata = 3
+)
+
+// First versions of the APIs using the flexible encoding, see KIP-482
+var flexibleVersions = map[int16]int16{
+	apiProduce:  9,
+	apiFetch:    12,
+	apiMetadata: 9,
+}
+
+var apiNames = map[int16]string{
+	apiProduce: "produce",
+	apiFetch:   "fetch",
+}
+
+// Names of the error codes of the produce and fetch responses:
+// https://kafka.apache.org/protocol#protocol_error_codes
+var errorNames = map[int16]string{
+	-1:  "UNKNOWN_SERVER_ERROR",
+	0:   "NONE",
+	1:   "OFFSET_OUT_OF_RANGE",
+	2:   "CORRUPT_MESSAGE",
+	3:   "UNKNOWN_TOPIC_OR_PARTITION",
+	4:   "INVALID_FETCH_SIZE",
+	5:   "LEADER_NOT_AVAILABLE",
+	6:   "NOT_LEADER_OR_FOLLOWER",
+	7:   "REQUEST_TIMED_OUT",
+	8:   "BROKER_NOT_AVAILABLE",
+	9:   "REPLICA_NOT_AVAILABLE",
+	10:  "MESSAGE_TOO_LARGE",
+	13:  "NETWORK_EXCEPTION",
+	19:  "NOT_ENOUGH_REPLICAS",
+	20:  "NOT_ENOUGH_REPLICAS_AFTER_APPEND",
+	29:  "TOPIC_AUTHORIZATION_FAILED",
+	32:  "INVALID_TIMESTAMP",
+	35:  "UNSUPPORTED_VERSION",
+	45:  "OUT_OF_ORDER_SEQUENCE_NUMBER",
+	46:  "DUPLICATE_SEQUENCE_NUMBER",
+	47:  "INVALID_PRODUCER_EPOCH",
+	74:  "FENCED_LEADER_EPOCH",
+	75:  "UNKNOWN_LEADER_EPOCH",
+	100: "UNKNOWN_TOPIC_ID",
+}
+
+var (
+	// errShort is returned when the fields being decoded weren't all
+	// received yet
+	errShort           = errors.New("short buffer")
+	errInvalid         = errors.New("invalid Kafka message")
+	errTooManyRequests = errors.New("too many requests waiting for their response")
+)
+
+func errorName(code int16) string {
+	if name, ok := errorNames[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("ERROR_%d", code)
+}
+
+func isFlexible(apiKey, version int16) bool {
+	v, ok := flexibleVersions[apiKey]
+	return ok && version >= v
+}
+
+// partition is a partition of a topic in a produce request or in the
+// response to a request
+type partition struct {
+	topic string
+	// topicID identifies the topic in the recent versions of the APIs,
+	// instead of its name
+	topicID   uuid.UUID
+	index     int32
+	size      int64
+	errorCode int16
+}
+
+// message is a request or a response
+type message struct {
+	apiKey        int16
+	version       int16
+	correlationID int32
+	clientID      string
+	acks          int16
+	partitions    []partition
+	// names of the topics of the metadata responses
+	topicNames map[uuid.UUID]string
+
+	// segment the message started in
+	timestamp eventtypes.Time
+	bootTime  uint64
+	process   tcpstream.Process
+	outgoing  bool
+	// boot time of the segment the message ended in
+	endBootTime uint64
+}
+
+// bodyReader reads the fields of a message from the bytes buffered
+type bodyReader struct {
+	buf      []byte
+	off      int
+	flexible bool
+}
+
+func (r *bodyReader) bytes(n int64) ([]byte, error) {
+	if n < 0 || n > maxBuffered {
+		return nil, errInvalid
+	}
+	if int64(len(r.buf)-r.off) < n {
+		return nil, errShort
+	}
+	b := r.buf[r.off : r.off+int(n)]
+	r.off += int(n)
+	return b, nil
+}
+
+func (r *bodyReader) int8() (int8, error) {
+	b, err := r.bytes(1)
+	if err != nil {
+		return 0, err
+	}
+	return int8(b[0]), nil
+}
+
+func (r *bodyReader) int16() (int16, error) {
+	b, err := r.bytes(2)
+	if err != nil {
+		return 0, err
+	}
+	return int16(binary.BigEndian.Uint16(b)), nil
+}
+
+func (r *bodyReader) int32() (int32, error) {
+	b, err := r.bytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(b)), nil
+}
+
+func (r *bodyReader) int64() (int64, error) {
+	b, err := r.bytes(8)
+	if err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(b)), nil
+}
+
+func (r *bodyReader) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf[r.off:])
+	if n == 0 {
+		return 0, errShort
+	}
+	if n < 0 {
+		return 0, errInvalid
+	}
+	r.off += n
+	return v, nil
+}
+
+func (r *bodyReader) uuid() (uuid.UUID, error) {
+	var id uuid.UUID
+	b, err := r.bytes(int64(len(id)))
+	if err != nil {
+		return id, err
+	}
+	copy(id[:], b)
+	return id, nil
+}
+
+// length reads the length of a string, of an array or of bytes, it's -1 when
+// they are null. With the flexible versions, they are encoded as varints
+// (compact), otherwise as int16 for the strings and int32 for the rest.
+func (r *bodyReader) length(wide bool) (int64, error) {
+	if r.flexible {
+		v, err := r.uvarint()
+		if err != nil {
+			return 0, err
+		}
+		if v > maxMessageSize {
+			return 0, errInvalid
+		}
+		return int64(v) - 1, nil
+	}
+	if wide {
+		v, err := r.int32()
+		return int64(v), err
+	}
+	v, err := r.int16()
+	return int64(v), err
+}
+
+func (r *bodyReader) string() (string, error) {
+	n, err := r.length(false)
+	if err != nil || n < 0 {
+		return "", err
+	}
+	b, err := r.bytes(n)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// array returns the number of elements of an array, 0 if it's null
+func (r *bodyReader) array() (int64, error) {
+	n, err := r.length(true)
+	if err != nil {
+		return 0, err
+	}
+	if n > maxArrayLen {
+		return 0, errInvalid
+	}
+	if n < 0 {
+		return 0, nil
+	}
+	return n, nil
+}
+
+// skipInt32Array skips an array of int32, e.g. broker ids
+func (r *bodyReader) skipInt32Array() error {
+	n, err := r.array()
+	if err != nil {
+		return err
+	}
+	_, err = r.bytes(4 * n)
+	return err
+}
+
+// taggedFields skips the tagged fields of the flexible versions
+func (r *bodyReader) taggedFields() error {
+	if !r.flexible {
+		return nil
+	}
+	n, err := r.uvarint()
+	if err != nil {
+		return err
+	}
+	if n > maxArrayLen {
+		return errInvalid
+	}
+	for i := uint64(0); i < n; i++ {
+		if _, err := r.uvarint(); err != nil {
+			return err
+		}
+		size, err := r.uvarint()
+		if err != nil {
+			return err
+		}
+		if size > maxBuffered {
+			return errInvalid
+		}
+		if _, err := r.bytes(int64(size)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// step decodes the next fields of a message. It returns errShort, without
+// changing the state of the decoder, if they weren't all received yet.
+type step func(r *bodyReader) error
+
+// decoder decodes a message received in several segments. Only the fields
+// needed are buffered, the records are skipped.
+type decoder struct {
+	msg      *message
+	flexible bool
+	// requests waiting for their response, when decoding a response
+	pending map[int32]*message
+	req     *message
+
+	buf []byte
+	// number of bytes to skip before decoding the next fields
+	skip int64
+	// next is nil once the fields needed were decoded
+	next step
+
+	// elements left in the arrays being decoded
+	topicsLeft  int64
+	partsLeft   int64
+	brokersLeft int64
+	topic       string
+	topicID     uuid.UUID
+}
+
+func newRequestDecoder(msg *message) *decoder {
+	d := &decoder{msg: msg}
+	d.next = d.requestHeader
+	return d
+}
+
+func newResponseDecoder(msg *message, pending map[int32]*message) *decoder {
+	d := &decoder{msg: msg, pending: pending}
+	d.next = d.responseHeader
+	return d
+}
+
+// feed decodes the bytes of the message received
+func (d *decoder) feed(data []byte) error {
+	if d.next == nil {
+		return nil
+	}
+	if d.skip > 0 {
+		n := int64(len(data))
+		if n > d.skip {
+			n = d.skip
+		}
+		data = data[n:]
+		d.skip -= n
+	}
+	d.buf = append(d.buf, data...)
+
+	for d.next != nil && d.skip == 0 {
+		r := &bodyReader{buf: d.buf, flexible: d.flexible}
+		err := d.next(r)
+		if errors.Is(err, errShort) {
+			if len(d.buf) > maxBuffered {
+				return errInvalid
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		d.buf = d.buf[r.off:]
+
+		if d.skip > 0 {
+			n := int64(len(d.buf))
+			if n > d.skip {
+				n = d.skip
+			}
+			d.buf = d.buf[n:]
+			d.skip -= n
+		}
+	}
+	if d.next == nil {
+		d.buf = nil
+	}
+	return nil
+}
+
+// lost skips n bytes that weren't captured, it's only possible while
+// skipping the records or after the fields needed were decoded
+func (d *decoder) lost(n int64) bool {
+	if d.next == nil {
+		return true
+	}
+	if len(d.buf) > 0 || d.skip < n {
+		return false
+	}
+	d.skip -= n
+	return d.feed(nil) == nil
+}
+
+func (d *decoder) addPartition(p partition) {
+	if len(d.msg.partitions) < maxPartitions {
+		d.msg.partitions = append(d.msg.partitions, p)
+	}
+}
+
+// topicName reads the name of a topic or, in the recent versions, its ID
+func (d *decoder) topicName(r *bodyReader, idVersion int16) (string, uuid.UUID, error) {
+	if d.msg.version >= idVersion {
+		id, err := r.uuid()
+		return "", id, err
+	}
+	name, err := r.string()
+	return name, uuid.UUID{}, err
+}
+
+func (d *decoder) requestHeader(r *bodyReader) error {
+	apiKey, err := r.int16()
+	if err != nil {
+		return err
+	}
+	version, err := r.int16()
+	if err != nil {
+		return err
+	}
+	correlationID, err := r.int32()
+	if err != nil {
+		return err
+	}
+	// The client ID isn't a compact string in the flexible versions
+	clientID, err := r.string()
+	if err != nil {
+		return err
+	}
+	flexible := isFlexible(apiKey, version)
+	if flexible {
+		r.flexible = true
+		if err := r.taggedFields(); err != nil {
+			return err
+		}
+	}
+
+	d.msg.apiKey = apiKey
+	d.msg.version = version
+	d.msg.correlationID = correlationID
+	d.msg.clientID = clientID
+	d.flexible = flexible
+	d.next = nil
+	// The partitions of the fetch requests are the ones of their
+	// responses
+	if apiKey == apiProduce {
+		d.next = d.produceRequest
+	}
+	return nil
+}
+
+func (d *decoder) responseHeader(r *bodyReader) error {
+	correlationID, err := r.int32()
+	if err != nil {
+		return err
+	}
+	req := d.pending[correlationID]
+	flexible := req != nil && isFlexible(req.apiKey, req.version)
+	if flexible {
+		r.flexible = true
+		if err := r.taggedFields(); err != nil {
+			return err
+		}
+	}
+
+	d.msg.correlationID = correlationID
+	d.next = nil
+	if req == nil {
+		return nil
+	}
+	d.req = req
+	d.msg.apiKey = req.apiKey
+	d.msg.version = req.version
+	d.flexible = flexible
+	switch req.apiKey {
+	case apiProduce:
+		d.next = d.produceResponse
+	case apiFetch:
+		d.next = d.fetchResponse
+	case apiMetadata:
+		// The IDs of the topics were added in version 10
+		if req.version >= 10 {
+			d.next = d.metadataResponse
+		}
+	}
+	return nil
+}
+
+// Produce request: https://kafka.apache.org/protocol#The_Messages_Produce
+
+func (d *decoder) produceRequest(r *bodyReader) error {
+	if d.msg.version >= 3 {
+		// transactional_id
+		if _, err := r.string(); err != nil {
+			return err
+		}
+	}
+	acks, err := r.int16()
+	if err != nil {
+		return err
+	}
+	// timeout_ms
+	if _, err := r.int32(); err != nil {
+		return err
+	}
+	n, err := r.array()
+	if err != nil {
+		return err
+	}
+
+	d.msg.acks = acks
+	d.topicsLeft = n
+	d.next = nil
+	if n > 0 {
+		d.next = d.produceTopic
+	}
+	return nil
+}
+
+func (d *decoder) produceTopic(r *bodyReader) error {
+	name, id, err := d.topicName(r, 13)
+	if err != nil {
+		return err
+	}
+	n, err := r.array()
+	if err != nil {
+		return err
+	}
+
+	d.topic, d.topicID = name, id
+	d.partsLeft = n
+	d.next = d.producePartition
+	if n == 0 {
+		d.next = d.produceTopicEnd
+	}
+	return nil
+}
+
+func (d *decoder) producePartition(r *bodyReader) error {
+	index, err := r.int32()
+	if err != nil {
+		return err
+	}
+	size, err := r.length(true)
+	if err != nil {
+		return err
+	}
+	if size > maxMessageSize {
+		return errInvalid
+	}
+	if size < 0 {
+		size = 0
+	}
+
+	d.addPartition(partition{topic: d.topic, topicID: d.topicID, index: index, size: size})
+	d.skip = size
+	d.next = d.producePartitionEnd
+	return nil
+}
+
+func (d *decoder) producePartitionEnd(r *bodyReader) error {
+	if err := r.taggedFields(); err != nil {
+		return err
+	}
+	d.partsLeft--
+	d.next = d.producePartition
+	if d.partsLeft <= 0 {
+		d.next = d.produceTopicEnd
+	}
+	return nil
+}
+
+func (d *decoder) produceTopicEnd(r *bodyReader) error {
+	if err := r.taggedFields(); err != nil {
+		return err
+	}
+	d.topicsLeft--
+	d.next = d.produceTopic
+	if d.topicsLeft <= 0 {
+		d.next = nil
+	}
+	return nil
+}
+
+// Produce response, the error codes of the partitions are reported with
+// the ones of the request
+
+func (d *decoder) produceResponse(r *bodyReader) error {
+	n, err := r.array()
+	if err != nil {
+		return err
+	}
+	d.topicsLeft = n
+	d.next = nil
+	if n > 0 {
+		d.next = d.produceRespTopic
+	}
+	return nil
+}
+
+func (d *decoder) produceRespTopic(r *bodyReader) error {
+	name, id, err := d.topicName(r, 13)
+	if err != nil {
+		return err
+	}
+	n, err := r.array()
+	if err != nil {
+		return err
+	}
+
+	d.topic, d.topicID = name, id
+	d.partsLeft = n
+	d.next = d.produceRespPartition
+	if n == 0 {
+		d.next = d.produceRespTopicEnd
+	}
+	return nil
+}
+
+func (d *decoder) produceRespPartition(r *bodyReader) error {
+	index, err := r.int32()
+	if err != nil {
+		return err
+	}
+	errorCode, err := r.int16()
+	if err != nil {
+		return err
+	}
+	// base_offset
+	if _, err := r.int64(); err != nil {
+		return err
+	}
+	if d.msg.version >= 2 {
+		// log_append_time_ms
+		if _, err := r.int64(); err != nil {
+			return err
+		}
+	}
+	if d.msg.version >= 5 {
+		// log_start_offset
+		if _, err := r.int64(); err != nil {
+			return err
+		}
+	}
+	if d.msg.version >= 8 {
+		// record_errors
+		n, err := r.array()
+		if err != nil {
+			return err
+		}
+		for i := int64(0); i < n; i++ {
+			if _, err := r.int32(); err != nil {
+				return err
+			}
+			if _, err := r.string(); err != nil {
+				return err
+			}
+			if err := r.taggedFields(); err != nil {
+				return err
+			}
+		}
+		// error_message
+		if _, err := r.string(); err != nil {
+			return err
+		}
+	}
+	if err := r.taggedFields(); err != nil {
+		return err
+	}
+
+	d.addPartition(partition{topic: d.topic, topicID: d.topicID, index: index, errorCode: errorCode})
+	d.partsLeft--
+	if d.partsLeft <= 0 {
+		d.next = d.produceRespTopicEnd
+	}
+	return nil
+}
+
+func (d *decoder) produceRespTopicEnd(r *bodyReader) error {
+	if err := r.taggedFields(); err != nil {
+		return err
+	}
+	d.topicsLeft--
+	d.next = d.produceRespTopic
+	if d.topicsLeft <= 0 {
+		d.next = nil
+	}
+	return nil
+}
+
+// Fetch response: https://kafka.apache.org/protocol#The_Messages_Fetch
+
+func (d *decoder) fetchResponse(r *bodyReader) error {
+	if d.msg.version >= 1 {
+		// throttle_time_ms
+		if _, err := r.int32(); err != nil {
+			return err
+		}
+	}
+	if d.msg.version >= 7 {
+		// error_code and session_id
+		if _, err := r.int16(); err != nil {
+			return err
+		}
+		if _, err := r.int32(); err != nil {
+			return err
+		}
+	}
+	n, err := r.array()
+	if err != nil {
+		return err
+	}
+
+	d.topicsLeft = n
+	d.next = nil
+	if n > 0 {
+		d.next = d.fetchTopic
+	}
+	return nil
+}
+
+func (d *decoder) fetchTopic(r *bodyReader) error {
+	name, id, err := d.topicName(r, 13)
+	if err != nil {
+		return err
+	}
+	n, err := r.array()
+	if err != nil {
+		return err
+	}
+
+	d.topic, d.topicID = name, id
+	d.partsLeft = n
+	d.next = d.fetchPartition
+	if n == 0 {
+		d.next = d.fetchTopicEnd
+	}
+	return nil
+}
+
+func (d *decoder) fetchPartition(r *bodyReader) error {
+	index, err := r.int32()
+	if err != nil {
+		return err
+	}
+	errorCode, err := r.int16()
+	if err != nil {
+		return err
+	}
+	// high_watermark
+	if _, err := r.int64(); err != nil {
+		return err
+	}
+	if d.msg.version >= 4 {
+		// last_stable_offset
+		if _, err := r.int64(); err != nil {
+			return err
+		}
+	}
+	if d.msg.version >= 5 {
+		// log_start_offset
+		if _, err := r.int64(); err != nil {
+			return err
+		}
+	}
+	if d.msg.version >= 4 {
+		// aborted_transactions: producer_id and first_offset
+		n, err := r.array()
+		if err != nil {
+			return err
+		}
+		for i := int64(0); i < n; i++ {
+			if _, err := r.bytes(16); err != nil {
+				return err
+			}
+			if err := r.taggedFields(); err != nil {
+				return err
+			}
+		}
+	}
+	if d.msg.version >= 11 {
+		// preferred_read_replica
+		if _, err := r.int32(); err != nil {
+			return err
+		}
+	}
+	size, err := r.length(true)
+	if err != nil {
+		return err
+	}
+	if size > maxMessageSize {
+		return errInvalid
+	}
+	if size < 0 {
+		size = 0
+	}
+
+	d.addPartition(partition{topic: d.topic, topicID: d.topicID, index: index, size: size, errorCode: errorCode})
+	d.skip = size
+	d.next = d.fetchPartitionEnd
+	return nil
+}
+
+func (d *decoder) fetchPartitionEnd(r *bodyReader) error {
+	if err := r.taggedFields(); err != nil {
+		return err
+	}
+	d.partsLeft--
+	d.next = d.fetchPartition
+	if d.partsLeft <= 0 {
+		d.next = d.fetchTopicEnd
+	}
+	return nil
+}
+
+func (d *decoder) fetchTopicEnd(r *bodyReader) error {
+	if err := r.taggedFields(); err != nil {
+		return err
+	}
+	d.topicsLeft--
+	d.next = d.fetchTopic
+	if d.topicsLeft <= 0 {
+		d.next = nil
+	}
+	return nil
+}
+
+// Metadata response (version 10 and later), the names of the topics are
+// used for the requests identifying them by ID:
+// https://kafka.apache.org/protocol#The_Messages_Metadata
+
+func (d *decoder) metadataResponse(r *bodyReader) error {
+	// throttle_time_ms
+	if _, err := r.int32(); err != nil {
+		return err
+	}
+	n, err := r.array()
+	if err != nil {
+		return err
+	}
+
+	d.msg.topicNames = make(map[uuid.UUID]string)
+	d.brokersLeft = n
+	d.next = d.metadataBroker
+	if n == 0 {
+		d.next = d.metadataCluster
+	}
+	return nil
+}
+
+func (d *decoder) metadataBroker(r *bodyReader) error {
+	// node_id, host, port and rack
+	if _, err := r.int32(); err != nil {
+		return err
+	}
+	if _, err := r.string(); err != nil {
+		return err
+	}
+	if _, err := r.int32(); err != nil {
+		return err
+	}
+	if _, err := r.string(); err != nil {
+		return err
+	}
+	if err := r.taggedFields(); err != nil {
+		return err
+	}
+
+	d.brokersLeft--
+	if d.brokersLeft <= 0 {
+		d.next = d.metadataCluster
+	}
+	return nil
+}
+
+func (d *decoder) metadataCluster(r *bodyReader) error {
+	// cluster_id and controller_id
+	if _, err := r.string(); err != nil {
+		return err
+	}
+	if _, err := r.int32(); err != nil {
+		return err
+	}
+	n, err := r.array()
+	if err != nil {
+		return err
+	}
+
+	d.topicsLeft = n
+	d.next = nil
+	if n > 0 {
+		d.next = d.metadataTopic
+	}
+	return nil
+}
+
+func (d *decoder) metadataTopic(r *bodyReader) error {
+	// error_code
+	if _, err := r.int16(); err != nil {
+		return err
+	}
+	name, err := r.string()
+	if err != nil {
+		return err
+	}
+	id, err := r.uuid()
+	if err != nil {
+		return err
+	}
+	// is_internal
+	if _, err := r.int8(); err != nil {
+		return err
+	}
+	n, err := r.array()
+	if err != nil {
+		return err
+	}
+
+	if name != "" && id != (uuid.UUID{}) && len(d.msg.topicNames) < maxTopicNames {
+		d.msg.topicNames[id] = name
+	}
+	d.partsLeft = n
+	d.next = d.metadataPartition
+	if n == 0 {
+		d.next = d.metadataTopicEnd
+	}
+	return nil
+}
+
+func (d *decoder) metadataPartition(r *bodyReader) error {
+	// error_code, partition_index, leader_id and leader_epoch
+	if _, err := r.bytes(14); err != nil {
+		return err
+	}
+	// replica_nodes, isr_nodes and offline_replicas
+	for i := 0; i < 3; i++ {
+		if err := r.skipInt32Array(); err != nil {
+			return err
+		}
+	}
+	if err := r.taggedFields(); err != nil {
+		return err
+	}
+
+	d.partsLeft--
+	if d.partsLeft <= 0 {
+		d.next = d.metadataTopicEnd
+	}
+	return nil
+}
+
+func (d *decoder) metadataTopicEnd(r *bodyReader) error {
+	// topic_authorized_operations
+	if _, err := r.int32(); err != nil {
+		return err
+	}
+	if err := r.taggedFields(); err != nil {
+		return err
+	}
+
+	d.topicsLeft--
+	d.next = d.metadataTopic
+	if d.topicsLeft <= 0 {
+		d.next = nil
+	}
+	return nil
+}
+
+// halfConn is one direction of a connection
+type halfConn struct {
+	// Beginning of the size of the next message
+	size []byte
+	// Bytes of the current message that weren't received yet
+	left int64
+	dec  *decoder
+}
+
+// end returns the message that was completely received
+func (h *halfConn) end(seg *tcpstream.Segment) (*message, error) {
+	d := h.dec
+	h.dec = nil
+	if d.next != nil {
+		return nil, errInvalid
+	}
+	d.msg.endBootTime = seg.BootTime
+	return d.msg, nil
+}
+
+// lost skips n bytes that weren't captured. It returns the message that was
+// completed by them, if any.
+func (h *halfConn) lost(seg *tcpstream.Segment, n int64) (*message, error) {
+	if h.dec == nil || len(h.size) > 0 || h.left < n || !h.dec.lost(n) {
+		return nil, errInvalid
+	}
+	h.left -= n
+	if h.left > 0 {
+		return nil, nil
+	}
+	return h.end(seg)
+}
+
+// conn decodes the messages of a connection
+type conn struct {
+	protocol *kafkaProtocol
+	key      tcpstream.ConnKey
+	// 0: from the client, 1: from the server
+	halves [2]halfConn
+	// requests waiting for their response, by correlation ID
+	pending map[int32]*message
+}
+
+// feed splits the payload of a segment into messages and returns the ones
+// it completed. Each message starts with its size.
+func (c *conn) feed(h *halfConn, seg *tcpstream.Segment, payload []byte) ([]*message, error) {
+	var msgs []*message
+	for len(payload) > 0 {
+		if h.left == 0 {
+			n := 4 - len(h.size)
+			if n > len(payload) {
+				n = len(payload)
+			}
+			h.size = append(h.size, payload[:n]...)
+			payload = payload[n:]
+			if len(h.size) < 4 {
+				break
+			}
+			size := int64(int32(binary.BigEndian.Uint32(h.size)))
+			h.size = h.size[:0]
+			if size < 4 || size > maxMessageSize {
+				return msgs, errInvalid
+			}
+			h.left = size
+
+			msg := &message{
+				timestamp: seg.Timestamp,
+				bootTime:  seg.BootTime,
+				process:   seg.Process,
+				outgoing:  seg.Outgoing,
+			}
+			if seg.FromClient {
+				h.dec = newRequestDecoder(msg)
+			} else {
+				h.dec = newResponseDecoder(msg, c.pending)
+			}
+			continue
+		}
+
+		n := int64(len(payload))
+		if n > h.left {
+			n = h.left
+		}
+		if err := h.dec.feed(payload[:n]); err != nil {
+			return msgs, err
+		}
+		payload = payload[n:]
+		h.left -= n
+		if h.left == 0 {
+			msg, err := h.end(seg)
+			if err != nil {
+				return msgs, err
+			}
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs, nil
+}
+
+// kafkaProtocol decodes the produce and fetch requests of the connections
+type kafkaProtocol struct {
+	// names of the topics by ID, learnt from the metadata responses, for
+	// each network namespace
+	topicNames map[uint64]map[uuid.UUID]string
+}
+
+func newKafkaProtocol() *kafkaProtocol {
+	return &kafkaProtocol{
+		topicNames: make(map[uint64]map[uuid.UUID]string),
+	}
+}
+
+// looksLikeRequest tells whether a segment starts with the header of a
+// request
+func looksLikeRequest(payload []byte) bool {
+	if len(payload) < 12 {
+		return false
+	}
+	size := int32(binary.BigEndian.Uint32(payload))
+	apiKey := int16(binary.BigEndian.Uint16(payload[4:]))
+	version := int16(binary.BigEndian.Uint16(payload[6:]))
+	return size >= 10 && size <= maxMessageSize && apiKey >= 0 && apiKey < 100 && version >= 0 && version < 100
+}
+
+// NewDecoder traces the connections from the first request that starts at
+// the beginning of a segment
+func (p *kafkaProtocol) NewDecoder(seg *tcpstream.Segment) tcpstream.Decoder[types.Event] {
+	if !seg.FromClient || !looksLikeRequest(seg.Payload) {
+		return nil
+	}
+	return &conn{
+		protocol: p,
+		key:      seg.Key,
+		pending:  make(map[int32]*message),
+	}
+}
+
+func (p *kafkaProtocol) DropNetns(netns uint64) {
+	delete(p.topicNames, netns)
+}
+
+// Sync reads the responses from the first one to a request that was seen
+func (c *conn) Sync(seg *tcpstream.Segment) bool {
+	if seg.FromClient {
+		return looksLikeRequest(seg.Payload)
+	}
+	if len(seg.Payload) < 8 {
+		return false
+	}
+	_, ok := c.pending[int32(binary.BigEndian.Uint32(seg.Payload[4:]))]
+	return ok
+}
+
+func (c *conn) Decode(seg *tcpstream.Segment, payload []byte, missing uint32) ([]*types.Event, error) {
+	h := &c.halves[0]
+	if !seg.FromClient {
+		h = &c.halves[1]
+	}
+
+	msgs, err := c.feed(h, seg, payload)
+	if err == nil && missing > 0 {
+		// The end of the packet wasn't captured: it's fine as long as
+		// it's the content of the records
+		var msg *message
+		msg, err = h.lost(seg, int64(missing))
+		if msg != nil {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	var events []*types.Event
+	for _, msg := range msgs {
+		if !seg.FromClient {
+			events = append(events, c.response(msg)...)
+			continue
+		}
+		reqEvents, err := c.request(msg)
+		events = append(events, reqEvents...)
+		if err != nil {
+			return events, err
+		}
+	}
+	return events, err
+}
+
+func (c *conn) request(req *message) ([]*types.Event, error) {
+	switch req.apiKey {
+	case apiProduce:
+		// There is no response when no acknowledgment is required
+		if req.acks == 0 {
+			var events []*types.Event
+			for _, part := range req.partitions {
+				events = append(events, c.newEvent(req, part, req.bootTime))
+			}
+			return events, nil
+		}
+	case apiFetch, apiMetadata:
+	default:
+		return nil, nil
+	}
+
+	if len(c.pending) >= maxPendingRequests {
+		return nil, errTooManyRequests
+	}
+	c.pending[req.correlationID] = req
+	return nil, nil
+}
+
+func (c *conn) response(resp *message) []*types.Event {
+	req, ok := c.pending[resp.correlationID]
+	if !ok {
+		return nil
+	}
+	delete(c.pending, resp.correlationID)
+
+	var events []*types.Event
+	switch req.apiKey {
+	case apiProduce:
+		for _, part := range req.partitions {
+			for _, r := range resp.partitions {
+				if r.topic == part.topic && r.topicID == part.topicID && r.index == part.index {
+					part.errorCode = r.errorCode
+					break
+				}
+			}
+			events = append(events, c.newEvent(req, part, resp.endBootTime))
+		}
+	case apiFetch:
+		for _, part := range resp.partitions {
+			events = append(events, c.newEvent(req, part, resp.endBootTime))
+		}
+	case apiMetadata:
+		if len(resp.topicNames) == 0 {
+			break
+		}
+		topicNames := c.protocol.topicNames
+		names, ok := topicNames[c.key.Netns]
+		if !ok {
+			names = make(map[uuid.UUID]string)
+			topicNames[c.key.Netns] = names
+		}
+		for id, name := range resp.topicNames {
+			if _, ok := names[id]; !ok && len(names) >= maxTopicNames {
+				continue
+			}
+			names[id] = name
+		}
+	}
+	return events
+}
+
+func (c *conn) newEvent(req *message, part partition, endBootTime uint64) *types.Event {
+	event := &types.Event{
+		Event: eventtypes.Event{
+			Type:      eventtypes.NORMAL,
+			Timestamp: req.timestamp,
+		},
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: req.process.MountNsID},
+		WithNetNsID:   eventtypes.WithNetNsID{NetNsID: c.key.Netns},
+		Pid:           req.process.Pid,
+		Tid:           req.process.Tid,
+		Comm:          req.process.Comm,
+		Role:          types.RoleServer,
+		ClientIP:      c.key.ClientIP,
+		ServerIP:      c.key.ServerIP,
+		ClientPort:    c.key.ClientPort,
+		ServerPort:    c.key.ServerPort,
+		ClientID:      req.clientID,
+		API:           apiNames[req.apiKey],
+		APIVersion:    req.version,
+		Topic:         part.topic,
+		Partition:     part.index,
+		Size:          uint64(part.size),
+		Error:         errorName(part.errorCode),
+	}
+	if req.outgoing {
+		event.Role = types.RoleClient
+	}
+	if req.apiKey == apiProduce {
+		event.Acks = req.acks
+	}
+	if endBootTime > req.bootTime {
+		event.Latency = time.Duration(endBootTime - req.bootTime)
+	}
+	if event.Topic == "" && part.topicID != (uuid.UUID{}) {
+		event.Topic = part.topicID.String()
+		if name, ok := c.protocol.topicNames[c.key.Netns][part.topicID]; ok {
+			event.Topic = name
+		}
+	}
+	return event
+}
@@ -0,0 +1,242 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/tcpstream"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/tcpstream/testutils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/kafka/types"
+)
+
+// builder encodes the fields of a message
+type builder struct {
+	bytes.Buffer
+	flexible bool
+}
+
+func (b *builder) int8(v int8) *builder {
+	b.WriteByte(byte(v))
+	return b
+}
+
+func (b *builder) int16(v int16) *builder {
+	binary.Write(b, binary.BigEndian, v)
+	return b
+}
+
+func (b *builder) int32(v int32) *builder {
+	binary.Write(b, binary.BigEndian, v)
+	return b
+}
+
+func (b *builder) int64(v int64) *builder {
+	binary.Write(b, binary.BigEndian, v)
+	return b
+}
+
+func (b *builder) length(n int, wide bool) *builder {
+	if b.flexible {
+		b.Write(binary.AppendUvarint(nil, uint64(n+1)))
+	} else if wide {
+		b.int32(int32(n))
+	} else {
+		b.int16(int16(n))
+	}
+	return b
+}
+
+func (b *builder) str(s string) *builder {
+	b.length(len(s), false)
+	b.WriteString(s)
+	return b
+}
+
+func (b *builder) array(n int) *builder {
+	return b.length(n, true)
+}
+
+func (b *builder) records(size int) *builder {
+	b.length(size, true)
+	b.Write(make([]byte, size))
+	return b
+}
+
+func (b *builder) tags() *builder {
+	if b.flexible {
+		b.WriteByte(0)
+	}
+	return b
+}
+
+// requestHeader starts a request, the client ID isn't a compact string
+func requestHeader(apiKey, version int16, correlationID int32, flexible bool) *builder {
+	b := &builder{}
+	b.int16(apiKey).int16(version).int32(correlationID).str("my-client")
+	b.flexible = flexible
+	return b.tags()
+}
+
+func responseHeader(correlationID int32, flexible bool) *builder {
+	b := &builder{flexible: flexible}
+	return b.int32(correlationID).tags()
+}
+
+// message prefixes the fields with their size
+func (b *builder) message() []byte {
+	return append(binary.BigEndian.AppendUint32(nil, uint32(b.Len())), b.Bytes()...)
+}
+
+func expectPartition(t *testing.T, event *types.Event, api, topic string, partition int32, size uint64, errorName string) {
+	t.Helper()
+	if event.API != api || event.Topic != topic || event.Partition != partition || event.Size != size || event.Error != errorName {
+		t.Fatalf("Invalid event %+v", event)
+	}
+}
+
+func TestParseProduce(t *testing.T) {
+	p := tcpstream.NewConns[types.Event](newKafkaProtocol())
+	client, server := testutils.NewPeers(9092, "producer")
+
+	// Flexible version, split in several segments
+	req := requestHeader(apiProduce, 9, 7, true)
+	req.str("").int16(-1).int32(30000).array(2)
+	req.str("orders").array(2).int32(0).records(300).tags().int32(3).records(100).tags().tags()
+	req.str("payments").array(1).int32(1).records(50).tags().tags()
+	req.tags()
+	data := req.message()
+	testutils.ExpectEvents(t, p.Process(client.Segment(data[:20], 1000)), 0)
+	testutils.ExpectEvents(t, p.Process(client.Segment(data[20:200], 1100)), 0)
+	testutils.ExpectEvents(t, p.Process(client.Segment(data[200:], 1200)), 0)
+
+	resp := responseHeader(7, true)
+	resp.array(2)
+	resp.str("orders").array(2)
+	resp.int32(0).int16(0).int64(42).int64(-1).int64(0).array(0).str("").tags()
+	resp.int32(3).int16(6).int64(-1).int64(-1).int64(-1).array(0).str("").tags()
+	resp.tags()
+	resp.str("payments").array(1)
+	resp.int32(1).int16(0).int64(12).int64(-1).int64(0).array(0).str("").tags()
+	resp.tags()
+	resp.int32(0).tags()
+	events := p.Process(server.Segment(resp.message(), 4000))
+	testutils.ExpectEvents(t, events, 3)
+	expectPartition(t, events[0], "produce", "orders", 0, 300, "NONE")
+	expectPartition(t, events[1], "produce", "orders", 3, 100, "NOT_LEADER_OR_FOLLOWER")
+	expectPartition(t, events[2], "produce", "payments", 1, 50, "NONE")
+	if events[0].ClientID != "my-client" || events[0].Acks != -1 || events[0].Latency != 3000*time.Nanosecond {
+		t.Fatalf("Invalid event %+v", events[0])
+	}
+	if events[0].Role != types.RoleClient || events[0].Pid != 1234 || events[0].APIVersion != 9 {
+		t.Fatalf("Invalid event %+v", events[0])
+	}
+
+	// Non-flexible version without acknowledgment, followed by the end of
+	// the records that wasn't captured
+	req = requestHeader(apiProduce, 7, 8, false)
+	req.str("").int16(0).int32(30000).array(1)
+	req.str("logs").array(1).int32(2).records(3000)
+	seg := client.Segment(req.message(), 5000)
+	seg.Payload = seg.Payload[:100]
+	events = p.Process(seg)
+	testutils.ExpectEvents(t, events, 1)
+	expectPartition(t, events[0], "produce", "logs", 2, 3000, "NONE")
+	if events[0].Acks != 0 || events[0].Latency != 0 {
+		t.Fatalf("Invalid event %+v", events[0])
+	}
+
+	// Other requests aren't reported
+	req = requestHeader(18, 3, 9, false)
+	req.str("librdkafka").str("2.3.0")
+	testutils.ExpectEvents(t, p.Process(client.Segment(req.message(), 6000)), 0)
+	testutils.ExpectEvents(t, p.Process(server.Segment(responseHeader(9, false).int16(0).message(), 7000)), 0)
+}
+
+func TestParseFetch(t *testing.T) {
+	p := tcpstream.NewConns[types.Event](newKafkaProtocol())
+	client, server := testutils.NewPeers(9092, "producer")
+
+	// The response of a fetch request sent before the gadget started
+	// is ignored
+	testutils.ExpectEvents(t, p.Process(server.Segment(responseHeader(41, false).int32(0).message(), 500)), 0)
+
+	req := requestHeader(apiFetch, 11, 42, false)
+	req.int32(-1).int32(500).int32(1).int32(1 << 20).int8(0)
+	testutils.ExpectEvents(t, p.Process(client.Segment(req.message(), 1000)), 0)
+
+	resp := responseHeader(42, false)
+	resp.int32(0).int16(0).int32(123).array(1)
+	resp.str("orders").array(2)
+	resp.int32(0).int16(0).int64(100).int64(100).int64(0).array(1).int64(7).int64(50).int32(-1).records(20000)
+	resp.int32(1).int16(1).int64(-1).int64(-1).int64(-1).array(-1).int32(-1).records(0)
+	data := resp.message()
+
+	// The middle of the records wasn't captured
+	seg := server.Segment(data[:1000], 2000)
+	seg.Length = 10000
+	server.Seq = seg.Seq + 10000
+	testutils.ExpectEvents(t, p.Process(seg), 0)
+	events := p.Process(server.Segment(data[10000:], 3000))
+	testutils.ExpectEvents(t, events, 2)
+	expectPartition(t, events[0], "fetch", "orders", 0, 20000, "NONE")
+	expectPartition(t, events[1], "fetch", "orders", 1, 0, "OFFSET_OUT_OF_RANGE")
+	if events[0].Latency != 2000*time.Nanosecond {
+		t.Fatalf("Invalid event %+v", events[0])
+	}
+}
+
+func TestParseTopicID(t *testing.T) {
+	p := tcpstream.NewConns[types.Event](newKafkaProtocol())
+	client, server := testutils.NewPeers(9092, "producer")
+	id := uuid.MustParse("5f2b6a0c-8d43-4c84-9f0e-2f3a1b6c7d8e")
+	other := uuid.MustParse("0b7e1c2d-3a4f-4e5d-8a9b-1c2d3e4f5a6b")
+
+	req := requestHeader(apiMetadata, 12, 1, true)
+	req.array(-1).int8(1).int8(0).tags()
+	testutils.ExpectEvents(t, p.Process(client.Segment(req.message(), 1000)), 0)
+
+	resp := responseHeader(1, true)
+	resp.int32(0).array(1).int32(1).str("broker-1").int32(9092).str("").tags()
+	resp.str("cluster").int32(1).array(1)
+	resp.int16(0).str("orders").Write(id[:])
+	resp.int8(0).array(1).int16(0).int32(0).int32(1).int32(0).array(1).int32(1).array(1).int32(1).array(0).tags()
+	resp.int32(0).tags()
+	resp.tags()
+	testutils.ExpectEvents(t, p.Process(server.Segment(resp.message(), 2000)), 0)
+
+	req = requestHeader(apiFetch, 13, 2, true)
+	req.int32(-1).int32(500).int32(1).int32(1 << 20).int8(0).int32(0).int32(0).array(0).array(0).str("").tags()
+	testutils.ExpectEvents(t, p.Process(client.Segment(req.message(), 3000)), 0)
+
+	resp = responseHeader(2, true)
+	resp.int32(0).int16(0).int32(0).array(2)
+	resp.Write(id[:])
+	resp.array(1).int32(0).int16(0).int64(1).int64(1).int64(0).array(0).int32(-1).records(10).tags()
+	resp.tags()
+	resp.Write(other[:])
+	resp.array(1).int32(0).int16(100).int64(-1).int64(-1).int64(-1).array(0).int32(-1).records(0).tags()
+	resp.tags()
+	resp.tags()
+	events := p.Process(server.Segment(resp.message(), 4000))
+	testutils.ExpectEvents(t, events, 2)
+	expectPartition(t, events[0], "fetch", "orders", 0, 10, "NONE")
+	expectPartition(t, events[1], "fetch", other.String(), 0, 0, "UNKNOWN_TOPIC_ID")
+}
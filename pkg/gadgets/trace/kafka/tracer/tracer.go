@@ -0,0 +1,104 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"context"
+	"fmt"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/tcpstream"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/kafka/types"
+)
+
+// Default port of the Kafka brokers
+const defaultPort = 9092
+
+type Config struct {
+	// Ports of the Kafka brokers, 9092 if empty
+	Ports []uint16
+}
+
+type Tracer struct {
+	*tcpstream.Tracer[types.Event]
+	config *Config
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func NewTracer(config *Config) (*Tracer, error) {
+	t := &Tracer{config: config}
+
+	if err := t.install(); err != nil {
+		t.Close()
+		return nil, fmt.Errorf("installing tracer: %w", err)
+	}
+
+	return t, nil
+}
+
+// --- Registry changes
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return &Tracer{
+		config: &Config{},
+	}, nil
+}
+
+func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.Ports = params.Get(ParamPorts).AsUint16Slice()
+
+	if err := t.install(); err != nil {
+		t.Close()
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	t.ctx, t.cancel = gadgetcontext.WithTimeoutOrCancel(gadgetCtx.Context(), gadgetCtx.Timeout())
+	return nil
+}
+
+func (t *Tracer) install() error {
+	ports := t.config.Ports
+	if len(ports) == 0 {
+		ports = []uint16{defaultPort}
+	}
+
+	tracer, err := tcpstream.NewTracer[types.Event](ports, newKafkaProtocol(), types.Base)
+	if err != nil {
+		return err
+	}
+	t.Tracer = tracer
+	return nil
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	<-t.ctx.Done()
+	return nil
+}
+
+func (t *Tracer) Close() {
+	if t.cancel != nil {
+		t.cancel()
+	}
+
+	if t.Tracer != nil {
+		t.Tracer.Close()
+	}
+}
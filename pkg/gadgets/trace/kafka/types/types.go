@@ -0,0 +1,86 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/environment"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	RoleClient = "client"
+	RoleServer = "server"
+)
+
+// Event is a partition of a produce request or of a fetch response,
+// reported once the broker replied
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+	eventtypes.WithNetNsID
+
+	Pid  uint32 `json:"pid,omitempty" column:"pid,template:pid"`
+	Tid  uint32 `json:"tid,omitempty" column:"tid,template:pid,hide"`
+	Comm string `json:"comm,omitempty" column:"comm,template:comm"`
+
+	// Role tells whether the traced container is the client or the broker
+	Role string `json:"role,omitempty" column:"role,width:6,fixed"`
+
+	ClientIP   string `json:"clientIP,omitempty" column:"clientip,template:ipaddr,hide"`
+	ServerIP   string `json:"serverIP,omitempty" column:"serverip,template:ipaddr,hide"`
+	ClientPort uint16 `json:"clientPort,omitempty" column:"clientport,template:ipport,hide"`
+	ServerPort uint16 `json:"serverPort,omitempty" column:"serverport,template:ipport,hide"`
+
+	// ClientID is the client.id configured in the Kafka client
+	ClientID   string `json:"clientID,omitempty" column:"clientid,minWidth:10,maxWidth:30"`
+	API        string `json:"api,omitempty" column:"api,width:7,fixed"`
+	APIVersion int16  `json:"apiVersion" column:"version,width:7,hide"`
+
+	// Topic is the name of the topic, or its ID when the name isn't known
+	Topic     string `json:"topic,omitempty" column:"topic,minWidth:16,maxWidth:40"`
+	Partition int32  `json:"partition" column:"partition,width:9"`
+	// Acks is the number of acknowledgments required by the produce
+	// requests: 0, 1 or -1 (all)
+	Acks int16 `json:"acks,omitempty" column:"acks,width:4,hide"`
+	// Size is the number of bytes of the record batches produced or
+	// fetched
+	Size uint64 `json:"size" column:"size,minWidth:8"`
+	// Error is the error code of the partition, NONE on success
+	Error string `json:"error,omitempty" column:"error,minWidth:8,maxWidth:30"`
+
+	// Latency is the time between the request and the end of its response
+	Latency time.Duration `json:"latency,omitempty" column:"latency,minWidth:10,align:right"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	// Hide container column for kubernetes environment
+	if environment.Environment == environment.Kubernetes {
+		col, _ := cols.GetColumn("container")
+		col.Visible = false
+	}
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
@@ -0,0 +1,167 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "lsmdenial.h"
+#include "mntns_filter.h"
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event *unusedevent __attribute__((unused));
+
+// Layout of the avc:selinux_audited tracepoint, it didn't change since it was
+// added in Linux 5.10
+struct selinux_audited_args {
+	struct trace_entry ent;
+	__u32 requested;
+	__u32 denied;
+	__u32 audited;
+	int result;
+	// __data_loc fields: offset of the string from the start of the record
+	// in the lower 16 bits, its length in the upper ones
+	__u32 scontext;
+	__u32 tcontext;
+	__u32 tclass;
+};
+
+#define AUDIT_APPARMOR_DENIED	2
+#define AUDIT_APPARMOR_AUTO	7
+#define APPARMOR_COMPLAIN	1
+
+// The AppArmor types aren't in the vmlinux.h of all the architectures, only
+// the fields used are declared and relocated
+struct apparmor_audit_data___x {
+	int error;
+	const char *op;
+	const char *name;
+	const char *info;
+} __attribute__((preserve_access_index));
+
+struct common_audit_data___x {
+	struct apparmor_audit_data___x *apparmor_audit_data;
+} __attribute__((preserve_access_index));
+
+// Since Linux 6.7, aa_audit() is given the audit data of AppArmor, which
+// embeds the common one, instead of the common audit data
+struct apparmor_audit_data___new {
+	struct common_audit_data___x common;
+} __attribute__((preserve_access_index));
+
+struct aa_policy___x {
+	char *hname;
+} __attribute__((preserve_access_index));
+
+struct aa_profile___x {
+	struct aa_policy___x base;
+	long mode;
+} __attribute__((preserve_access_index));
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, int);
+	__type(value, struct event);
+} heap SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, sizeof(__u32));
+} events SEC(".maps");
+
+// new_event returns an event with the process fields, NULL if the process
+// isn't traced
+static __always_inline struct event *new_event(enum lsm lsm)
+{
+	__u64 pid_tgid = bpf_get_current_pid_tgid();
+	struct event *event;
+	__u64 mntns_id;
+	int zero = 0;
+
+	mntns_id = gadget_get_mntns_id();
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return NULL;
+
+	event = bpf_map_lookup_elem(&heap, &zero);
+	if (!event)
+		return NULL;
+
+	__builtin_memset(event, 0, sizeof(*event));
+	event->mntns_id = mntns_id;
+	event->timestamp = bpf_ktime_get_boot_ns();
+	event->pid = pid_tgid >> 32;
+	event->tid = (__u32)pid_tgid;
+	event->uid = (__u32)bpf_get_current_uid_gid();
+	event->lsm = lsm;
+	bpf_get_current_comm(&event->task, sizeof(event->task));
+	return event;
+}
+
+static __always_inline void read_data_loc(void *ctx, __u32 loc, __u8 *buf, __u32 size)
+{
+	bpf_probe_read_kernel_str(buf, size, ctx + (loc & 0xffff));
+}
+
+// Called for the decisions audited by SELinux, including the allowed ones
+// with an auditallow rule
+SEC("tracepoint/avc/selinux_audited")
+int ig_lsm_selinux(struct selinux_audited_args *ctx)
+{
+	struct event *event;
+
+	if (!ctx->denied)
+		return 0;
+
+	event = new_event(LSM_SELINUX);
+	if (!event)
+		return 0;
+
+	event->denied = ctx->denied;
+	// The denial isn't enforced in permissive mode
+	event->permissive = ctx->result == 0;
+	read_data_loc(ctx, ctx->tclass, event->op, sizeof(event->op));
+	read_data_loc(ctx, ctx->scontext, event->subject, sizeof(event->subject));
+	read_data_loc(ctx, ctx->tcontext, event->target, sizeof(event->target));
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
+	return 0;
+}
+
+SEC("kprobe/aa_audit")
+int BPF_KPROBE(ig_lsm_apparmor, int type, struct aa_profile___x *profile, void *data)
+{
+	struct apparmor_audit_data___x *ad;
+	struct event *event;
+	int error;
+
+	// The kernel is built without AppArmor
+	if (!bpf_core_type_exists(struct apparmor_audit_data___x))
+		return 0;
+
+	if (bpf_core_field_exists(((struct apparmor_audit_data___new *)0)->common))
+		ad = data;
+	else
+		ad = BPF_CORE_READ((struct common_audit_data___x *)data, apparmor_audit_data);
+
+	// The type is resolved from the error by aa_audit() for AUTO
+	error = BPF_CORE_READ(ad, error);
+	if (type != AUDIT_APPARMOR_DENIED && !(type == AUDIT_APPARMOR_AUTO && error))
+		return 0;
+
+	event = new_event(LSM_APPARMOR);
+	if (!event)
+		return 0;
+
+	event->permissive = BPF_CORE_READ(profile, mode) == APPARMOR_COMPLAIN;
+	bpf_probe_read_kernel_str(event->op, sizeof(event->op), BPF_CORE_READ(ad, op));
+	bpf_probe_read_kernel_str(event->subject, sizeof(event->subject),
+				  BPF_CORE_READ(profile, base.hname));
+	bpf_probe_read_kernel_str(event->target, sizeof(event->target), BPF_CORE_READ(ad, name));
+	bpf_probe_read_kernel_str(event->info, sizeof(event->info), BPF_CORE_READ(ad, info));
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
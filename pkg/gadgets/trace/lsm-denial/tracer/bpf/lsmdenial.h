@@ -0,0 +1,37 @@
+/* SPDX-License-Identifier: GPL-2.0 */
+#ifndef GADGET_LSMDENIAL_H
+#define GADGET_LSMDENIAL_H
+
+#define TASK_COMM_LEN	16
+#define OP_LEN		32
+#define CONTEXT_LEN	256
+#define INFO_LEN	64
+
+enum lsm : u8 {
+	LSM_SELINUX,
+	LSM_APPARMOR,
+};
+
+struct event {
+	__u64 mntns_id;
+	__u64 timestamp;
+	__u32 pid;
+	__u32 tid;
+	__u32 uid;
+	/* Denied permissions of the class, for SELinux */
+	__u32 denied;
+	enum lsm lsm;
+	/* The access was logged but allowed, in permissive or complain mode */
+	bool permissive;
+	__u8 task[TASK_COMM_LEN];
+	/* Class of the object for SELinux, operation for AppArmor */
+	__u8 op[OP_LEN];
+	/* Context of the process for SELinux, profile for AppArmor */
+	__u8 subject[CONTEXT_LEN];
+	/* Context of the object for SELinux, name of the object for AppArmor */
+	__u8 target[CONTEXT_LEN];
+	/* Reason of the denial, for AppArmor */
+	__u8 info[INFO_LEN];
+};
+
+#endif /* GADGET_LSMDENIAL_H */
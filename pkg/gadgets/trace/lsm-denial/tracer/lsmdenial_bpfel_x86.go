@@ -0,0 +1,152 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type lsmdenialEvent struct {
+	MntnsId    uint64
+	Timestamp  uint64
+	Pid        uint32
+	Tid        uint32
+	Uid        uint32
+	Denied     uint32
+	Lsm        lsmdenialLsm
+	Permissive bool
+	Task       [16]uint8
+	Op         [32]uint8
+	Subject    [256]uint8
+	Target     [256]uint8
+	Info       [64]uint8
+	_          [6]byte
+}
+
+type lsmdenialLsm uint8
+
+const (
+	lsmdenialLsmLSM_SELINUX  lsmdenialLsm = 0
+	lsmdenialLsmLSM_APPARMOR lsmdenialLsm = 1
+)
+
+// loadLsmdenial returns the embedded CollectionSpec for lsmdenial.
+func loadLsmdenial() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_LsmdenialBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load lsmdenial: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadLsmdenialObjects loads lsmdenial and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*lsmdenialObjects
+//	*lsmdenialPrograms
+//	*lsmdenialMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadLsmdenialObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadLsmdenial()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// lsmdenialSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type lsmdenialSpecs struct {
+	lsmdenialProgramSpecs
+	lsmdenialMapSpecs
+}
+
+// lsmdenialSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type lsmdenialProgramSpecs struct {
+	IgLsmApparmor *ebpf.ProgramSpec `ebpf:"ig_lsm_apparmor"`
+	IgLsmSelinux  *ebpf.ProgramSpec `ebpf:"ig_lsm_selinux"`
+}
+
+// lsmdenialMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type lsmdenialMapSpecs struct {
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Heap                 *ebpf.MapSpec `ebpf:"heap"`
+}
+
+// lsmdenialObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadLsmdenialObjects or ebpf.CollectionSpec.LoadAndAssign.
+type lsmdenialObjects struct {
+	lsmdenialPrograms
+	lsmdenialMaps
+}
+
+func (o *lsmdenialObjects) Close() error {
+	return _LsmdenialClose(
+		&o.lsmdenialPrograms,
+		&o.lsmdenialMaps,
+	)
+}
+
+// lsmdenialMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadLsmdenialObjects or ebpf.CollectionSpec.LoadAndAssign.
+type lsmdenialMaps struct {
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Heap                 *ebpf.Map `ebpf:"heap"`
+}
+
+func (m *lsmdenialMaps) Close() error {
+	return _LsmdenialClose(
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.Heap,
+	)
+}
+
+// lsmdenialPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadLsmdenialObjects or ebpf.CollectionSpec.LoadAndAssign.
+type lsmdenialPrograms struct {
+	IgLsmApparmor *ebpf.Program `ebpf:"ig_lsm_apparmor"`
+	IgLsmSelinux  *ebpf.Program `ebpf:"ig_lsm_selinux"`
+}
+
+func (p *lsmdenialPrograms) Close() error {
+	return _LsmdenialClose(
+		p.IgLsmApparmor,
+		p.IgLsmSelinux,
+	)
+}
+
+func _LsmdenialClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed lsmdenial_bpfel_x86.o
+var _LsmdenialBytes []byte
@@ -0,0 +1,91 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+// selinuxPermissions decodes the permissions of the SELinux classes, whose
+// values depend on the loaded policy, from selinuxfs
+type selinuxPermissions struct {
+	// classes are the names of the permissions of the classes, indexed by
+	// their bit, nil if they couldn't be read
+	classes map[string]map[uint32]string
+}
+
+func newSELinuxPermissions() *selinuxPermissions {
+	return &selinuxPermissions{
+		classes: make(map[string]map[uint32]string),
+	}
+}
+
+// readClass reads the permissions of a class: each one is a file of
+// /sys/fs/selinux/class/<class>/perms/ containing the index of its bit,
+// starting at 1
+func readClass(class string) (map[uint32]string, error) {
+	dir := filepath.Join(host.HostRoot, "/sys/fs/selinux/class", class, "perms")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	perms := make(map[uint32]string, len(entries))
+	for _, entry := range entries {
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		index, err := strconv.ParseUint(strings.TrimSpace(string(content)), 10, 32)
+		if err != nil || index == 0 || index > 32 {
+			continue
+		}
+		perms[1<<(index-1)] = entry.Name()
+	}
+	return perms, nil
+}
+
+// decode returns the names of the permissions of a class, a permission is
+// written in hexadecimal if its name isn't known
+func (s *selinuxPermissions) decode(class string, mask uint32) []string {
+	perms, ok := s.classes[class]
+	if !ok {
+		// The class isn't read again when the policy changes, the
+		// permissions of a class are unlikely to change
+		perms, _ = readClass(class)
+		s.classes[class] = perms
+	}
+
+	names := []string{}
+	for bit := uint32(1); bit != 0 && bit <= mask; bit <<= 1 {
+		if mask&bit == 0 {
+			continue
+		}
+		if name, ok := perms[bit]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, fmt.Sprintf("0x%x", bit))
+		}
+	}
+	return names
+}
@@ -0,0 +1,70 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"strings"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	LSMSELinux  = "selinux"
+	LSMAppArmor = "apparmor"
+)
+
+// Event is an access denied by SELinux or AppArmor, as logged in the audit
+// log of the node
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+	eventtypes.WithContainerUid
+
+	Pid  uint32 `json:"pid,omitempty" column:"pid,template:pid"`
+	Tid  uint32 `json:"tid,omitempty" column:"tid,template:pid,hide"`
+	Uid  uint32 `json:"uid" column:"uid,template:uid,hide"`
+	Comm string `json:"comm,omitempty" column:"comm,template:comm"`
+
+	LSM         string   `json:"lsm,omitempty" column:"lsm,width:8,fixed"`
+	Operation   string   `json:"operation,omitempty" column:"op,width:16" columnDesc:"class of the object for SELinux, operation for AppArmor"`
+	Permissions []string `json:"permissions,omitempty" column:"perms,width:16" columnDesc:"denied permissions, for SELinux"`
+	Subject     string   `json:"subject,omitempty" column:"subject,width:32" columnDesc:"context of the process for SELinux, profile for AppArmor"`
+	Target      string   `json:"target,omitempty" column:"target,width:32" columnDesc:"context of the object for SELinux, name of the object for AppArmor"`
+	Info        string   `json:"info,omitempty" column:"info,width:24,hide" columnDesc:"reason of the denial, for AppArmor"`
+	// Permissive is set when the access was logged but allowed, in the
+	// permissive mode of SELinux or the complain mode of AppArmor
+	Permissive bool `json:"permissive,omitempty" column:"permissive,width:10,hide"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	cols.MustSetExtractor("perms", func(event *Event) string {
+		return strings.Join(event.Permissions, ",")
+	})
+
+	return cols
+}
+
+func (e *Event) GetUid() uint32 {
+	return e.Uid
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
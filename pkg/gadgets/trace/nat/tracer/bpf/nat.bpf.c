@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+
+#include <vmlinux/vmlinux.h>
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+
+#include "nat.h"
+
+/* Define here, because there are conflicts with include files */
+#define NFCT_PTRMASK	~7UL
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event *unusedevent __attribute__((unused));
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, sizeof(__u32));
+} events SEC(".maps");
+
+/*
+ * __nf_conntrack_confirm() is called once per connection, when its first
+ * packet leaves the last netfilter hook. At that point, both the DNAT
+ * (prerouting/output) and the SNAT (postrouting/input) bindings are set up,
+ * so the reply tuple already contains the translated addresses.
+ */
+SEC("kprobe/__nf_conntrack_confirm")
+int BPF_KPROBE(ig_nat_confirm, struct sk_buff *skb)
+{
+	struct nf_conntrack_tuple orig, reply;
+	struct event event = {};
+	struct nf_conn *ct;
+	unsigned long nfct;
+	unsigned long status;
+
+	nfct = BPF_CORE_READ(skb, _nfct);
+	ct = (struct nf_conn *)(nfct & NFCT_PTRMASK);
+	if (ct == NULL)
+		return 0;
+
+	status = BPF_CORE_READ(ct, status);
+	if (!(status & (IPS_SRC_NAT | IPS_DST_NAT)))
+		return 0;
+
+	orig = BPF_CORE_READ(ct, tuplehash[IP_CT_DIR_ORIGINAL].tuple);
+	reply = BPF_CORE_READ(ct, tuplehash[IP_CT_DIR_REPLY].tuple);
+
+	event.timestamp = bpf_ktime_get_boot_ns();
+	event.af = orig.src.l3num;
+	event.proto = orig.dst.protonum;
+	event.status = status;
+	event.netns = BPF_CORE_READ(ct, ct_net.net, ns.inum);
+
+	__builtin_memcpy(event.orig_saddr, &orig.src.u3, sizeof(event.orig_saddr));
+	__builtin_memcpy(event.orig_daddr, &orig.dst.u3, sizeof(event.orig_daddr));
+	__builtin_memcpy(event.reply_saddr, &reply.src.u3, sizeof(event.reply_saddr));
+	__builtin_memcpy(event.reply_daddr, &reply.dst.u3, sizeof(event.reply_daddr));
+	event.orig_sport = orig.src.u.all;
+	event.orig_dport = orig.dst.u.all;
+	event.reply_sport = reply.src.u.all;
+	event.reply_dport = reply.dst.u.all;
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
@@ -0,0 +1,24 @@
+// SPDX-License-Identifier: GPL-2.0
+
+#ifndef __NAT_H
+#define __NAT_H
+
+struct event {
+	// Original tuple, as sent by the initiator of the connection
+	__u8 orig_saddr[16];
+	__u8 orig_daddr[16];
+	// Reply tuple, as expected from the responder after translation
+	__u8 reply_saddr[16];
+	__u8 reply_daddr[16];
+	__u64 timestamp;
+	__u32 netns;
+	__u32 af; // AF_INET or AF_INET6
+	__u32 status;
+	__u16 orig_sport;
+	__u16 orig_dport;
+	__u16 reply_sport;
+	__u16 reply_dport;
+	__u8 proto;
+};
+
+#endif /* __NAT_H */
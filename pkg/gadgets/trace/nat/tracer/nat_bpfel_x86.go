@@ -0,0 +1,136 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type natEvent struct {
+	OrigSaddr  [16]uint8
+	OrigDaddr  [16]uint8
+	ReplySaddr [16]uint8
+	ReplyDaddr [16]uint8
+	Timestamp  uint64
+	Netns      uint32
+	Af         uint32
+	Status     uint32
+	OrigSport  uint16
+	OrigDport  uint16
+	ReplySport uint16
+	ReplyDport uint16
+	Proto      uint8
+	_          [3]byte
+}
+
+// loadNat returns the embedded CollectionSpec for nat.
+func loadNat() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_NatBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load nat: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadNatObjects loads nat and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*natObjects
+//	*natPrograms
+//	*natMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadNatObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadNat()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// natSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type natSpecs struct {
+	natProgramSpecs
+	natMapSpecs
+}
+
+// natSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type natProgramSpecs struct {
+	IgNatConfirm *ebpf.ProgramSpec `ebpf:"ig_nat_confirm"`
+}
+
+// natMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type natMapSpecs struct {
+	Events *ebpf.MapSpec `ebpf:"events"`
+}
+
+// natObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadNatObjects or ebpf.CollectionSpec.LoadAndAssign.
+type natObjects struct {
+	natPrograms
+	natMaps
+}
+
+func (o *natObjects) Close() error {
+	return _NatClose(
+		&o.natPrograms,
+		&o.natMaps,
+	)
+}
+
+// natMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadNatObjects or ebpf.CollectionSpec.LoadAndAssign.
+type natMaps struct {
+	Events *ebpf.Map `ebpf:"events"`
+}
+
+func (m *natMaps) Close() error {
+	return _NatClose(
+		m.Events,
+	)
+}
+
+// natPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadNatObjects or ebpf.CollectionSpec.LoadAndAssign.
+type natPrograms struct {
+	IgNatConfirm *ebpf.Program `ebpf:"ig_nat_confirm"`
+}
+
+func (p *natPrograms) Close() error {
+	return _NatClose(
+		p.IgNatConfirm,
+	)
+}
+
+func _NatClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed nat_bpfel_x86.o
+var _NatBytes []byte
@@ -0,0 +1,189 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"unsafe"
+
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/nat/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -no-global-types -type event nat ./bpf/nat.bpf.c -- -I./bpf/ -I../../../../${TARGET}
+
+// Values of enum ip_conntrack_status, see include/uapi/linux/netfilter/nf_conntrack_common.h
+const (
+	ipsSrcNat = 1 << 4
+	ipsDstNat = 1 << 5
+)
+
+type Tracer struct {
+	eventCallback func(*types.Event)
+
+	objs        natObjects
+	confirmLink link.Link
+	reader      *perf.Reader
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return &Tracer{}, nil
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	go t.run()
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	return nil
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (t *Tracer) close() {
+	t.confirmLink = gadgets.CloseLink(t.confirmLink)
+
+	if t.reader != nil {
+		t.reader.Close()
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadNat()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	gadgets.FixBpfKtimeGetBootNs(spec.Programs)
+
+	if err := spec.LoadAndAssign(&t.objs, nil); err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	// __nf_conntrack_confirm is only available once the nf_conntrack module
+	// is loaded, which is the case on any node running kube-proxy.
+	t.confirmLink, err = link.Kprobe("__nf_conntrack_confirm", t.objs.IgNatConfirm, nil)
+	if err != nil {
+		return fmt.Errorf("attaching kprobe (is nf_conntrack loaded?): %w", err)
+	}
+
+	reader, err := perf.NewReader(t.objs.natMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	if err != nil {
+		return fmt.Errorf("creating perf ring buffer: %w", err)
+	}
+	t.reader = reader
+
+	return nil
+}
+
+var ipProtocol = map[uint8]string{
+	1:   "ICMP",
+	6:   "TCP",
+	17:  "UDP",
+	58:  "ICMPv6",
+	132: "SCTP",
+}
+
+func protocolToString(protocol uint8) string {
+	protocolString, ok := ipProtocol[protocol]
+	if !ok {
+		protocolString = fmt.Sprintf("%d", protocol)
+	}
+
+	return protocolString
+}
+
+func natToString(status uint32) string {
+	switch {
+	case status&ipsSrcNat != 0 && status&ipsDstNat != 0:
+		return "SNAT+DNAT"
+	case status&ipsSrcNat != 0:
+		return "SNAT"
+	case status&ipsDstNat != 0:
+		return "DNAT"
+	default:
+		return ""
+	}
+}
+
+func (t *Tracer) run() {
+	for {
+		record, err := t.reader.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				// nothing to do, we're done
+				return
+			}
+
+			msg := fmt.Sprintf("reading perf ring buffer: %s", err)
+			t.eventCallback(types.Base(eventtypes.Err(msg)))
+			return
+		}
+
+		if record.LostSamples > 0 {
+			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
+			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			continue
+		}
+
+		bpfEvent := (*natEvent)(unsafe.Pointer(&record.RawSample[0]))
+
+		ipversion := gadgets.IPVerFromAF(bpfEvent.Af)
+
+		// The reply tuple goes from the responder to the initiator, so the
+		// translated source is its destination and vice versa.
+		event := types.Event{
+			Event: eventtypes.Event{
+				Type:      eventtypes.NORMAL,
+				Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+			},
+			WithNetNsID:     eventtypes.WithNetNsID{NetNsID: uint64(bpfEvent.Netns)},
+			IPVersion:       ipversion,
+			Protocol:        protocolToString(bpfEvent.Proto),
+			NAT:             natToString(bpfEvent.Status),
+			Saddr:           gadgets.IPStringFromBytes(bpfEvent.OrigSaddr, ipversion),
+			Sport:           gadgets.Htons(bpfEvent.OrigSport),
+			Daddr:           gadgets.IPStringFromBytes(bpfEvent.OrigDaddr, ipversion),
+			Dport:           gadgets.Htons(bpfEvent.OrigDport),
+			TranslatedSaddr: gadgets.IPStringFromBytes(bpfEvent.ReplyDaddr, ipversion),
+			TranslatedSport: gadgets.Htons(bpfEvent.ReplyDport),
+			TranslatedDaddr: gadgets.IPStringFromBytes(bpfEvent.ReplySaddr, ipversion),
+			TranslatedDport: gadgets.Htons(bpfEvent.ReplySport),
+		}
+
+		t.eventCallback(&event)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithNetNsID
+
+	IPVersion int    `json:"ipversion,omitempty" column:"ip,template:ipversion,order:1000"`
+	Protocol  string `json:"proto,omitempty" column:"proto,maxWidth:6,order:1001"`
+	NAT       string `json:"nat,omitempty" column:"nat,minWidth:4,maxWidth:9,order:1002"`
+
+	// Original tuple, as sent by the initiator of the connection
+	Saddr string `json:"saddr,omitempty" column:"saddr,template:ipaddr,hide,order:2001"`
+	Sport uint16 `json:"sport,omitempty" column:"sport,template:ipport,hide,order:2002"`
+	Daddr string `json:"daddr,omitempty" column:"daddr,template:ipaddr,hide,order:3001"`
+	Dport uint16 `json:"dport,omitempty" column:"dport,template:ipport,hide,order:3002"`
+
+	// Tuple after the translation
+	TranslatedSaddr string `json:"translatedSaddr,omitempty" column:"tsaddr,template:ipaddr,hide,order:4001"`
+	TranslatedSport uint16 `json:"translatedSport,omitempty" column:"tsport,template:ipport,hide,order:4002"`
+	TranslatedDaddr string `json:"translatedDaddr,omitempty" column:"tdaddr,template:ipaddr,hide,order:5001"`
+	TranslatedDport uint16 `json:"translatedDport,omitempty" column:"tdport,template:ipport,hide,order:5002"`
+
+	/* Original source IP resolved by kubeipresolver  */
+	SrcKind      eventtypes.RemoteKind `json:"srcKind,omitempty" column:"srcKind,maxWidth:5,hide,order:2100"`
+	SrcNamespace string                `json:"srcNamespace,omitempty" column:"srcns,hide,order:2101"`
+	SrcName      string                `json:"srcName,omitempty" column:"srcname,hide,order:2102"`
+
+	/* Original destination IP resolved by kubeipresolver  */
+	DstKind      eventtypes.RemoteKind `json:"dstKind,omitempty" column:"dstKind,maxWidth:5,hide,order:3100"`
+	DstNamespace string                `json:"dstNamespace,omitempty" column:"dstns,hide,order:3101"`
+	DstName      string                `json:"dstName,omitempty" column:"dstname,hide,order:3102"`
+
+	/* Translated source IP resolved by kubeipresolver  */
+	TranslatedSrcKind      eventtypes.RemoteKind `json:"translatedSrcKind,omitempty" column:"tsrcKind,maxWidth:5,hide,order:4100"`
+	TranslatedSrcNamespace string                `json:"translatedSrcNamespace,omitempty" column:"tsrcns,hide,order:4101"`
+	TranslatedSrcName      string                `json:"translatedSrcName,omitempty" column:"tsrcname,hide,order:4102"`
+
+	/* Translated destination IP resolved by kubeipresolver  */
+	TranslatedDstKind      eventtypes.RemoteKind `json:"translatedDstKind,omitempty" column:"tdstKind,maxWidth:5,hide,order:5100"`
+	TranslatedDstNamespace string                `json:"translatedDstNamespace,omitempty" column:"tdstns,hide,order:5101"`
+	TranslatedDstName      string                `json:"translatedDstName,omitempty" column:"tdstname,hide,order:5102"`
+}
+
+func (e *Event) SetLocalPodDetails(owner, hostIP, podIP string, labels map[string]string) {
+	// Unused
+}
+
+func (e *Event) GetRemoteIPs() []string {
+	return []string{e.Saddr, e.Daddr, e.TranslatedSaddr, e.TranslatedDaddr}
+}
+
+func (e *Event) SetEndpointsDetails(endpoints []eventtypes.EndpointDetails) {
+	if len(endpoints) != 4 {
+		return
+	}
+	e.SrcName = endpoints[0].Name
+	e.SrcNamespace = endpoints[0].Namespace
+	e.SrcKind = endpoints[0].Kind
+
+	e.DstName = endpoints[1].Name
+	e.DstNamespace = endpoints[1].Namespace
+	e.DstKind = endpoints[1].Kind
+
+	e.TranslatedSrcName = endpoints[2].Name
+	e.TranslatedSrcNamespace = endpoints[2].Namespace
+	e.TranslatedSrcKind = endpoints[2].Kind
+
+	e.TranslatedDstName = endpoints[3].Name
+	e.TranslatedDstNamespace = endpoints[3].Namespace
+	e.TranslatedDstKind = endpoints[3].Kind
+}
+
+func endpoint(kind eventtypes.RemoteKind, namespace, name, addr string, port uint16) string {
+	ret := addr
+	switch kind {
+	case eventtypes.RemoteKindPod:
+		ret = "p/" + namespace + "/" + name
+	case eventtypes.RemoteKindService:
+		ret = "s/" + namespace + "/" + name
+	case eventtypes.RemoteKindOther:
+		ret = "o/" + addr
+	}
+	// Protocols without ports, like ICMP
+	if port == 0 {
+		return ret
+	}
+	return ret + ":" + fmt.Sprint(port)
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	// Virtual columns for the original and translated endpoints
+	virtualColumns := []struct {
+		name  string
+		order int
+		fn    func(e *Event) string
+	}{
+		{"src", 2000, func(e *Event) string {
+			return endpoint(e.SrcKind, e.SrcNamespace, e.SrcName, e.Saddr, e.Sport)
+		}},
+		{"dst", 3000, func(e *Event) string {
+			return endpoint(e.DstKind, e.DstNamespace, e.DstName, e.Daddr, e.Dport)
+		}},
+		{"tsrc", 4000, func(e *Event) string {
+			return endpoint(e.TranslatedSrcKind, e.TranslatedSrcNamespace, e.TranslatedSrcName, e.TranslatedSaddr, e.TranslatedSport)
+		}},
+		{"tdst", 5000, func(e *Event) string {
+			return endpoint(e.TranslatedDstKind, e.TranslatedDstNamespace, e.TranslatedDstName, e.TranslatedDaddr, e.TranslatedDport)
+		}},
+	}
+	for _, vc := range virtualColumns {
+		err := cols.AddColumn(columns.Attributes{
+			Name:    vc.name,
+			Visible: true,
+			Width:   30,
+			Order:   vc.order,
+		}, vc.fn)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
@@ -0,0 +1,297 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+
+#include <vmlinux/vmlinux.h>
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include <bpf/bpf_endian.h>
+
+#define GADGET_TYPE_TRACING
+#include <sockets-map.h>
+
+#include "netfilter.h"
+
+#define NF_DROP		0
+#define NF_VERDICT_MASK	0x000000ff
+
+// Tables can be traversed from within a table, e.g. when a rule sends a TCP
+// reset: the reset goes through the output hooks before the rule returns
+#define MAX_DEPTH	8
+
+// nf_tables and x_tables are often modules, their types aren't in the BTF of
+// the kernel: the offsets of the fields used are looked up by the tracer in
+// the BTF of the modules
+const volatile __u32 nft_pktinfo_state_off = 0;
+const volatile __u32 nft_chain_table_off = 0;
+const volatile __u32 nft_chain_name_off = 0;
+const volatile __u32 nft_table_name_off = 0;
+const volatile __u32 xt_table_name_off = 0;
+// Recent kernels give the table first to ipt_do_table() and ip6t_do_table()
+const volatile bool xt_table_first = false;
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event *unusedevent __attribute__((unused));
+
+// A table being traversed
+struct frame {
+	struct sk_buff *skb;
+	struct nf_hook_state *state;
+	// struct nft_chain for nftables, struct xt_table for iptables
+	void *table;
+	enum nf_backend backend;
+	// The packet was rejected by a rule of the table
+	bool rejected;
+};
+
+// The tables being traversed on each CPU, the innermost last
+struct frames {
+	__u32 depth;
+	struct frame frames[MAX_DEPTH];
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, int);
+	__type(value, struct frames);
+} stacks SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, sizeof(__u32));
+} events SEC(".maps");
+
+// Source and destination ports are the first fields of both struct tcphdr
+// and struct udphdr.
+struct ports {
+	__be16 source;
+	__be16 dest;
+};
+
+static __always_inline void *read_ptr(void *base, __u32 off)
+{
+	void *ptr = NULL;
+
+	bpf_probe_read_kernel(&ptr, sizeof(ptr), base + off);
+	return ptr;
+}
+
+static __always_inline void push(enum nf_backend backend, struct sk_buff *skb,
+				 struct nf_hook_state *state, void *table)
+{
+	struct frames *stack;
+	struct frame *frame;
+	int zero = 0;
+	__u32 depth;
+
+	stack = bpf_map_lookup_elem(&stacks, &zero);
+	if (!stack)
+		return;
+
+	// The depth is still counted when the stack is full to keep the entries
+	// and the returns balanced
+	depth = stack->depth++;
+	if (depth >= MAX_DEPTH)
+		return;
+
+	frame = &stack->frames[depth & (MAX_DEPTH - 1)];
+	frame->skb = skb;
+	frame->state = state;
+	frame->table = table;
+	frame->backend = backend;
+	frame->rejected = false;
+}
+
+SEC("kprobe/nft_do_chain")
+int BPF_KPROBE(ig_nf_nft_e, void *pkt, void *chain)
+{
+	// The skb is the first field of struct nft_pktinfo
+	struct sk_buff *skb = read_ptr(pkt, 0);
+	struct nf_hook_state *state = read_ptr(pkt, nft_pktinfo_state_off);
+
+	push(NF_BACKEND_NFTABLES, skb, state, chain);
+	return 0;
+}
+
+static __always_inline void push_xt(struct pt_regs *ctx, enum nf_backend backend)
+{
+	if (xt_table_first)
+		push(backend, (void *)PT_REGS_PARM2(ctx), (void *)PT_REGS_PARM3(ctx),
+		     (void *)PT_REGS_PARM1(ctx));
+	else
+		push(backend, (void *)PT_REGS_PARM1(ctx), (void *)PT_REGS_PARM2(ctx),
+		     (void *)PT_REGS_PARM3(ctx));
+}
+
+SEC("kprobe/ipt_do_table")
+int ig_nf_ipt_e(struct pt_regs *ctx)
+{
+	push_xt(ctx, NF_BACKEND_IPTABLES);
+	return 0;
+}
+
+SEC("kprobe/ip6t_do_table")
+int ig_nf_ip6t_e(struct pt_regs *ctx)
+{
+	push_xt(ctx, NF_BACKEND_IP6TABLES);
+	return 0;
+}
+
+// Called by the REJECT target of iptables and the reject expression of
+// nftables, the table returns NF_DROP afterwards
+SEC("kprobe/nf_send_reset")
+int ig_nf_reject(struct pt_regs *ctx)
+{
+	struct frames *stack;
+	int zero = 0;
+	__u32 depth;
+
+	stack = bpf_map_lookup_elem(&stacks, &zero);
+	if (!stack)
+		return 0;
+
+	depth = stack->depth;
+	if (depth == 0 || depth > MAX_DEPTH)
+		return 0;
+
+	stack->frames[(depth - 1) & (MAX_DEPTH - 1)].rejected = true;
+	return 0;
+}
+
+static __always_inline int fill_packet(struct event *event, struct sk_buff *skb, __u8 pf)
+{
+	unsigned char *head = BPF_CORE_READ(skb, head);
+	__u16 nhoff = BPF_CORE_READ(skb, network_header);
+	__u16 thoff;
+
+	switch (pf) {
+	case NFPROTO_IPV4: {
+		struct iphdr iph;
+
+		if (bpf_probe_read_kernel(&iph, sizeof(iph), head + nhoff))
+			return -1;
+
+		event->af = AF_INET;
+		event->proto = iph.protocol;
+		event->saddr_v4 = iph.saddr;
+		event->daddr_v4 = iph.daddr;
+		thoff = nhoff + iph.ihl * 4;
+		break;
+	}
+	case NFPROTO_IPV6: {
+		struct ipv6hdr ip6h;
+
+		if (bpf_probe_read_kernel(&ip6h, sizeof(ip6h), head + nhoff))
+			return -1;
+
+		event->af = AF_INET6;
+		event->proto = ip6h.nexthdr;
+		__builtin_memcpy(event->saddr, &ip6h.saddr, sizeof(event->saddr));
+		__builtin_memcpy(event->daddr, &ip6h.daddr, sizeof(event->daddr));
+		// Extension headers are not followed
+		thoff = nhoff + sizeof(ip6h);
+		break;
+	}
+	default:
+		// ARP and bridge tables aren't supported
+		return -1;
+	}
+
+	if (event->proto == IPPROTO_TCP || event->proto == IPPROTO_UDP) {
+		struct ports ports;
+
+		if (bpf_probe_read_kernel(&ports, sizeof(ports), head + thoff) == 0) {
+			event->sport = ports.source;
+			event->dport = ports.dest;
+		}
+	}
+
+	return 0;
+}
+
+static __always_inline void fill_table(struct event *event, struct frame *frame)
+{
+	if (frame->backend == NF_BACKEND_NFTABLES) {
+		void *table = read_ptr(frame->table, nft_chain_table_off);
+
+		bpf_probe_read_kernel_str(event->chain, sizeof(event->chain),
+					  read_ptr(frame->table, nft_chain_name_off));
+		bpf_probe_read_kernel_str(event->table, sizeof(event->table),
+					  read_ptr(table, nft_table_name_off));
+	} else {
+		bpf_probe_read_kernel_str(event->table, sizeof(event->table),
+					  frame->table + xt_table_name_off);
+	}
+}
+
+// The input device is only set on the receive path, the output one on the
+// transmit path
+static __always_inline void fill_dev(__u8 *name, struct net_device *dev)
+{
+	if (dev)
+		bpf_core_read_str(name, IFNAMSIZ, &dev->name);
+}
+
+// Attached to the return of nft_do_chain(), ipt_do_table() and
+// ip6t_do_table(): the verdict of the table is only known there
+SEC("kretprobe/nft_do_chain")
+int BPF_KRETPROBE(ig_nf_table_x, unsigned int verdict)
+{
+	struct event event = {};
+	struct nf_hook_state *state;
+	struct frames *stack;
+	struct frame *frame;
+	struct sk_buff *skb;
+	struct sock *sk;
+	int zero = 0;
+	__u32 depth;
+
+	stack = bpf_map_lookup_elem(&stacks, &zero);
+	if (!stack || stack->depth == 0)
+		return 0;
+
+	depth = --stack->depth;
+	if (depth >= MAX_DEPTH)
+		return 0;
+
+	if ((verdict & NF_VERDICT_MASK) != NF_DROP)
+		return 0;
+
+	frame = &stack->frames[depth & (MAX_DEPTH - 1)];
+	skb = frame->skb;
+	state = frame->state;
+
+	if (fill_packet(&event, skb, BPF_CORE_READ(state, pf)))
+		return 0;
+
+	fill_table(&event, frame);
+	event.backend = frame->backend;
+	event.action = frame->rejected ? NF_ACTION_REJECT : NF_ACTION_DROP;
+	event.hook = BPF_CORE_READ(state, hook);
+	event.netns = BPF_CORE_READ(state, net, ns.inum);
+	fill_dev(event.in, BPF_CORE_READ(state, in));
+	fill_dev(event.out, BPF_CORE_READ(state, out));
+
+	// Packets don't belong to the task running when they are dropped,
+	// use the process owning the socket when there is one.
+	sk = BPF_CORE_READ(skb, sk);
+	if (sk != NULL) {
+		struct sockets_value *skb_val = gadget_socket_lookup(sk, event.netns);
+		if (skb_val != NULL) {
+			event.mntns_id = skb_val->mntns;
+			event.pid = skb_val->pid_tgid >> 32;
+			event.tid = (__u32)skb_val->pid_tgid;
+			__builtin_memcpy(&event.task, skb_val->task, sizeof(event.task));
+		}
+	}
+
+	event.timestamp = bpf_ktime_get_boot_ns();
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
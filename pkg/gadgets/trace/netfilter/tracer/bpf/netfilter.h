@@ -0,0 +1,53 @@
+/* SPDX-License-Identifier: GPL-2.0 */
+#ifndef GADGET_TRACE_NETFILTER_H
+#define GADGET_TRACE_NETFILTER_H
+
+#define TASK_COMM_LEN	16
+#define IFNAMSIZ	16
+// Longer table and chain names are truncated
+#define NAME_LEN	32
+
+enum nf_backend : u8 {
+	NF_BACKEND_NFTABLES,
+	NF_BACKEND_IPTABLES,
+	NF_BACKEND_IP6TABLES,
+};
+
+enum nf_action : u8 {
+	NF_ACTION_DROP,
+	NF_ACTION_REJECT,
+};
+
+struct event {
+	union {
+		__u8 saddr[16];
+		unsigned __int128 saddr_v6;
+		__u32 saddr_v4;
+	};
+	union {
+		__u8 daddr[16];
+		unsigned __int128 daddr_v6;
+		__u32 daddr_v4;
+	};
+	__u64 timestamp;
+	__u64 mntns_id;
+	__u32 pid;
+	__u32 tid;
+	__u32 netns;
+	__u32 af; // AF_INET or AF_INET6
+	__u16 dport;
+	__u16 sport;
+	__u8 proto;
+	// NF_INET_PRE_ROUTING, NF_INET_LOCAL_IN...
+	__u8 hook;
+	enum nf_backend backend;
+	enum nf_action action;
+	__u8 task[TASK_COMM_LEN];
+	__u8 table[NAME_LEN];
+	// Base chain for nftables, empty for iptables: the chain is the hook
+	__u8 chain[NAME_LEN];
+	__u8 in[IFNAMSIZ];
+	__u8 out[IFNAMSIZ];
+};
+
+#endif /* GADGET_TRACE_NETFILTER_H */
@@ -0,0 +1,175 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type netfilterEvent struct {
+	Saddr     [16]uint8
+	Daddr     [16]uint8
+	Timestamp uint64
+	MntnsId   uint64
+	Pid       uint32
+	Tid       uint32
+	Netns     uint32
+	Af        uint32
+	Dport     uint16
+	Sport     uint16
+	Proto     uint8
+	Hook      uint8
+	Backend   netfilterNfBackend
+	Action    netfilterNfAction
+	Task      [16]uint8
+	Table     [32]uint8
+	Chain     [32]uint8
+	In        [16]uint8
+	Out       [16]uint8
+	_         [8]byte
+}
+
+type netfilterNfAction uint8
+
+const (
+	netfilterNfActionNF_ACTION_DROP   netfilterNfAction = 0
+	netfilterNfActionNF_ACTION_REJECT netfilterNfAction = 1
+)
+
+type netfilterNfBackend uint8
+
+const (
+	netfilterNfBackendNF_BACKEND_NFTABLES  netfilterNfBackend = 0
+	netfilterNfBackendNF_BACKEND_IPTABLES  netfilterNfBackend = 1
+	netfilterNfBackendNF_BACKEND_IP6TABLES netfilterNfBackend = 2
+)
+
+// loadNetfilter returns the embedded CollectionSpec for netfilter.
+func loadNetfilter() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_NetfilterBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load netfilter: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadNetfilterObjects loads netfilter and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*netfilterObjects
+//	*netfilterPrograms
+//	*netfilterMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadNetfilterObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadNetfilter()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// netfilterSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type netfilterSpecs struct {
+	netfilterProgramSpecs
+	netfilterMapSpecs
+}
+
+// netfilterSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type netfilterProgramSpecs struct {
+	IgNfIp6tE  *ebpf.ProgramSpec `ebpf:"ig_nf_ip6t_e"`
+	IgNfIptE   *ebpf.ProgramSpec `ebpf:"ig_nf_ipt_e"`
+	IgNfNftE   *ebpf.ProgramSpec `ebpf:"ig_nf_nft_e"`
+	IgNfReject *ebpf.ProgramSpec `ebpf:"ig_nf_reject"`
+	IgNfTableX *ebpf.ProgramSpec `ebpf:"ig_nf_table_x"`
+}
+
+// netfilterMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type netfilterMapSpecs struct {
+	Events  *ebpf.MapSpec `ebpf:"events"`
+	Sockets *ebpf.MapSpec `ebpf:"sockets"`
+	Stacks  *ebpf.MapSpec `ebpf:"stacks"`
+}
+
+// netfilterObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadNetfilterObjects or ebpf.CollectionSpec.LoadAndAssign.
+type netfilterObjects struct {
+	netfilterPrograms
+	netfilterMaps
+}
+
+func (o *netfilterObjects) Close() error {
+	return _NetfilterClose(
+		&o.netfilterPrograms,
+		&o.netfilterMaps,
+	)
+}
+
+// netfilterMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadNetfilterObjects or ebpf.CollectionSpec.LoadAndAssign.
+type netfilterMaps struct {
+	Events  *ebpf.Map `ebpf:"events"`
+	Sockets *ebpf.Map `ebpf:"sockets"`
+	Stacks  *ebpf.Map `ebpf:"stacks"`
+}
+
+func (m *netfilterMaps) Close() error {
+	return _NetfilterClose(
+		m.Events,
+		m.Sockets,
+		m.Stacks,
+	)
+}
+
+// netfilterPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadNetfilterObjects or ebpf.CollectionSpec.LoadAndAssign.
+type netfilterPrograms struct {
+	IgNfIp6tE  *ebpf.Program `ebpf:"ig_nf_ip6t_e"`
+	IgNfIptE   *ebpf.Program `ebpf:"ig_nf_ipt_e"`
+	IgNfNftE   *ebpf.Program `ebpf:"ig_nf_nft_e"`
+	IgNfReject *ebpf.Program `ebpf:"ig_nf_reject"`
+	IgNfTableX *ebpf.Program `ebpf:"ig_nf_table_x"`
+}
+
+func (p *netfilterPrograms) Close() error {
+	return _NetfilterClose(
+		p.IgNfIp6tE,
+		p.IgNfIptE,
+		p.IgNfNftE,
+		p.IgNfReject,
+		p.IgNfTableX,
+	)
+}
+
+func _NetfilterClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed netfilter_bpfel_x86.o
+var _NetfilterBytes []byte
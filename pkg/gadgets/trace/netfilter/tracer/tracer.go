@@ -0,0 +1,420 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/btf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/networktracer"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/socketenricher"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/netfilter/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -no-global-types -type event -type nf_backend -type nf_action netfilter ./bpf/netfilter.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I../../../internal/socketenricher/bpf
+
+type Tracer struct {
+	socketEnricher *socketenricher.SocketEnricher
+	eventCallback  func(*types.Event)
+
+	objs   netfilterObjects
+	links  []link.Link
+	reader *perf.Reader
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return &Tracer{}, nil
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	go t.run()
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	return nil
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (t *Tracer) close() {
+	for i, l := range t.links {
+		t.links[i] = gadgets.CloseLink(l)
+	}
+
+	if t.reader != nil {
+		t.reader.Close()
+	}
+
+	if t.socketEnricher != nil {
+		t.socketEnricher.Close()
+	}
+
+	t.objs.Close()
+}
+
+// specWithType returns the BTF of the kernel if it has the given type, the
+// one of the module otherwise. It wraps btf.ErrNotFound when the module isn't
+// loaded.
+func specWithType(kernelSpec *btf.Spec, module, name string, typ any) (*btf.Spec, error) {
+	err := kernelSpec.TypeByName(name, typ)
+	if err == nil {
+		return kernelSpec, nil
+	}
+	if !errors.Is(err, btf.ErrNotFound) {
+		return nil, fmt.Errorf("looking up %s: %w", name, err)
+	}
+
+	handle, err := btf.FindHandle(func(info *btf.HandleInfo) bool {
+		return info.IsModule() && info.Name == module
+	})
+	if err != nil {
+		return nil, fmt.Errorf("looking up BTF of module %s: %w", module, err)
+	}
+	defer handle.Close()
+
+	spec, err := handle.Spec()
+	if err != nil {
+		return nil, fmt.Errorf("loading BTF of module %s: %w", module, err)
+	}
+	if err := spec.TypeByName(name, typ); err != nil {
+		return nil, fmt.Errorf("looking up %s: %w", name, err)
+	}
+	return spec, nil
+}
+
+// memberOffset returns the offset in bytes of the field of the struct at the
+// given path
+func memberOffset(s *btf.Struct, path ...string) (uint32, error) {
+	offset := uint32(0)
+	for i, name := range path {
+		var member *btf.Member
+		for j := range s.Members {
+			if s.Members[j].Name == name {
+				member = &s.Members[j]
+				break
+			}
+		}
+		if member == nil {
+			return 0, fmt.Errorf("struct %s has no field %s: %w", s.Name, name, btf.ErrNotFound)
+		}
+		offset += member.Offset.Bytes()
+
+		if i == len(path)-1 {
+			break
+		}
+		next, ok := btf.UnderlyingType(member.Type).(*btf.Struct)
+		if !ok {
+			return 0, fmt.Errorf("field %s of struct %s isn't a struct", name, s.Name)
+		}
+		s = next
+	}
+	return offset, nil
+}
+
+// nftablesOffsets looks up the fields of nf_tables read by the eBPF programs
+func nftablesOffsets(kernelSpec *btf.Spec, consts map[string]any) error {
+	var pktinfo *btf.Struct
+	spec, err := specWithType(kernelSpec, "nf_tables", "nft_pktinfo", &pktinfo)
+	if err != nil {
+		return err
+	}
+
+	fields := []struct {
+		constant string
+		typ      string
+		path     []string
+	}{
+		{"nft_chain_table_off", "nft_chain", []string{"table"}},
+		{"nft_chain_name_off", "nft_chain", []string{"name"}},
+		{"nft_table_name_off", "nft_table", []string{"name"}},
+	}
+	for _, f := range fields {
+		var s *btf.Struct
+		if err := spec.TypeByName(f.typ, &s); err != nil {
+			return fmt.Errorf("looking up %s: %w", f.typ, err)
+		}
+		off, err := memberOffset(s, f.path...)
+		if err != nil {
+			return err
+		}
+		consts[f.constant] = off
+	}
+
+	// Before Linux 5.15, the hook state was in the xt_action_param embedded
+	// in nft_pktinfo
+	off, err := memberOffset(pktinfo, "state")
+	if errors.Is(err, btf.ErrNotFound) {
+		off, err = memberOffset(pktinfo, "xt", "state")
+	}
+	if err != nil {
+		return err
+	}
+	consts["nft_pktinfo_state_off"] = off
+
+	return nil
+}
+
+// xtablesOffsets looks up the fields of x_tables read by the eBPF programs
+func xtablesOffsets(kernelSpec *btf.Spec, consts map[string]any) error {
+	var table *btf.Struct
+	spec, err := specWithType(kernelSpec, "x_tables", "xt_table", &table)
+	if err != nil {
+		return err
+	}
+
+	off, err := memberOffset(table, "name")
+	if err != nil {
+		return err
+	}
+	consts["xt_table_name_off"] = off
+
+	// ipt_do_table() is in ip_tables, not in x_tables: the parameters are
+	// taken from the kernel or the module it's found in
+	var fn *btf.Func
+	if _, err := specWithType(spec, "ip_tables", "ipt_do_table", &fn); err != nil {
+		if _, err := specWithType(spec, "ip6_tables", "ip6t_do_table", &fn); err != nil {
+			return err
+		}
+	}
+	proto, ok := fn.Type.(*btf.FuncProto)
+	if !ok || len(proto.Params) == 0 {
+		return fmt.Errorf("unexpected type of %s", fn.Name)
+	}
+	consts["xt_table_first"] = proto.Params[0].Name == "priv"
+
+	return nil
+}
+
+func (t *Tracer) install() error {
+	kernelSpec, err := btf.LoadKernelSpec()
+	if err != nil {
+		return fmt.Errorf("loading kernel spec: %w", err)
+	}
+
+	// The backends whose modules aren't loaded are skipped
+	consts := map[string]any{}
+	nftables := true
+	if err := nftablesOffsets(kernelSpec, consts); err != nil {
+		if !errors.Is(err, btf.ErrNotFound) {
+			return fmt.Errorf("looking up nf_tables types: %w", err)
+		}
+		nftables = false
+	}
+	xtables := true
+	if err := xtablesOffsets(kernelSpec, consts); err != nil {
+		if !errors.Is(err, btf.ErrNotFound) {
+			return fmt.Errorf("looking up x_tables types: %w", err)
+		}
+		xtables = false
+	}
+	if !nftables && !xtables {
+		return errors.New("neither nf_tables nor ip_tables are loaded")
+	}
+
+	t.socketEnricher, err = socketenricher.NewSocketEnricher()
+	if err != nil {
+		return err
+	}
+
+	spec, err := loadNetfilter()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	if err := spec.RewriteConstants(consts); err != nil {
+		return fmt.Errorf("rewriting constants: %w", err)
+	}
+
+	gadgets.FixBpfKtimeGetBootNs(spec.Programs)
+
+	opts := ebpf.CollectionOptions{}
+
+	mapReplacements := map[string]*ebpf.Map{}
+	mapReplacements[networktracer.SocketsMapName] = t.socketEnricher.SocketsMap()
+	opts.MapReplacements = mapReplacements
+
+	if err := spec.LoadAndAssign(&t.objs, &opts); err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	type table struct {
+		symbol string
+		entry  *ebpf.Program
+	}
+
+	var tables []table
+	if nftables {
+		tables = append(tables, table{"nft_do_chain", t.objs.IgNfNftE})
+	}
+	if xtables {
+		tables = append(tables,
+			table{"ipt_do_table", t.objs.IgNfIptE},
+			table{"ip6t_do_table", t.objs.IgNfIp6tE},
+		)
+	}
+
+	for _, tbl := range tables {
+		l, err := link.Kprobe(tbl.symbol, tbl.entry, nil)
+		if err != nil {
+			// ip_tables is loaded without ip6_tables or the opposite
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("attaching kprobe %s: %w", tbl.symbol, err)
+		}
+		t.links = append(t.links, l)
+
+		l, err = link.Kretprobe(tbl.symbol, t.objs.IgNfTableX, nil)
+		if err != nil {
+			return fmt.Errorf("attaching kretprobe %s: %w", tbl.symbol, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	// The reject modules are only loaded once a rule uses them, the packets
+	// rejected before are reported as dropped
+	for _, symbol := range []string{"nf_send_reset", "nf_send_unreach", "nf_send_reset6", "nf_send_unreach6"} {
+		l, err := link.Kprobe(symbol, t.objs.IgNfReject, nil)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return fmt.Errorf("attaching kprobe %s: %w", symbol, err)
+		}
+		t.links = append(t.links, l)
+	}
+
+	t.reader, err = perf.NewReader(t.objs.netfilterMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	if err != nil {
+		return fmt.Errorf("creating perf ring buffer: %w", err)
+	}
+
+	return nil
+}
+
+var backends = map[netfilterNfBackend]string{
+	netfilterNfBackendNF_BACKEND_NFTABLES:  types.BackendNftables,
+	netfilterNfBackendNF_BACKEND_IPTABLES:  types.BackendIptables,
+	netfilterNfBackendNF_BACKEND_IP6TABLES: types.BackendIp6tables,
+}
+
+var actions = map[netfilterNfAction]string{
+	netfilterNfActionNF_ACTION_DROP:   types.ActionDrop,
+	netfilterNfActionNF_ACTION_REJECT: types.ActionReject,
+}
+
+// The names of the hooks of the IPv4 and IPv6 families, as used by nftables,
+// and of the built-in chains of iptables they correspond to
+var (
+	hooks         = []string{"prerouting", "input", "forward", "output", "postrouting"}
+	builtinChains = []string{"PREROUTING", "INPUT", "FORWARD", "OUTPUT", "POSTROUTING"}
+)
+
+var ipProtocol = map[uint8]string{
+	1:   "ICMP",
+	6:   "TCP",
+	17:  "UDP",
+	58:  "ICMPv6",
+	132: "SCTP",
+}
+
+func protocolToString(protocol uint8) string {
+	protocolString, ok := ipProtocol[protocol]
+	if !ok {
+		protocolString = fmt.Sprintf("%d", protocol)
+	}
+
+	return protocolString
+}
+
+func (t *Tracer) run() {
+	for {
+		record, err := t.reader.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				// nothing to do, we're done
+				return
+			}
+
+			msg := fmt.Sprintf("reading perf ring buffer: %s", err)
+			t.eventCallback(types.Base(eventtypes.Err(msg)))
+			return
+		}
+
+		if record.LostSamples > 0 {
+			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
+			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			continue
+		}
+
+		bpfEvent := (*netfilterEvent)(unsafe.Pointer(&record.RawSample[0]))
+
+		ipversion := gadgets.IPVerFromAF(bpfEvent.Af)
+
+		event := types.Event{
+			Event: eventtypes.Event{
+				Type:      eventtypes.NORMAL,
+				Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+			},
+			WithMountNsID: eventtypes.WithMountNsID{MountNsID: bpfEvent.MntnsId},
+			WithNetNsID:   eventtypes.WithNetNsID{NetNsID: uint64(bpfEvent.Netns)},
+			Pid:           bpfEvent.Pid,
+			Comm:          gadgets.FromCString(bpfEvent.Task[:]),
+			IPVersion:     ipversion,
+			Protocol:      protocolToString(bpfEvent.Proto),
+			Saddr:         gadgets.IPStringFromBytes(bpfEvent.Saddr, ipversion),
+			Daddr:         gadgets.IPStringFromBytes(bpfEvent.Daddr, ipversion),
+			Sport:         gadgets.Htons(bpfEvent.Sport),
+			Dport:         gadgets.Htons(bpfEvent.Dport),
+			Backend:       backends[bpfEvent.Backend],
+			Table:         gadgets.FromCString(bpfEvent.Table[:]),
+			Chain:         gadgets.FromCString(bpfEvent.Chain[:]),
+			Action:        actions[bpfEvent.Action],
+			In:            gadgets.FromCString(bpfEvent.In[:]),
+			Out:           gadgets.FromCString(bpfEvent.Out[:]),
+		}
+
+		if int(bpfEvent.Hook) < len(hooks) {
+			event.Hook = hooks[bpfEvent.Hook]
+			if event.Backend != types.BackendNftables {
+				event.Chain = builtinChains[bpfEvent.Hook]
+			}
+		}
+
+		t.eventCallback(&event)
+	}
+}
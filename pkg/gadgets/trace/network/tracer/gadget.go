@@ -22,6 +22,10 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
 )
 
+const (
+	ParamExcludeSidecarHops = "exclude-sidecar-hops"
+)
+
 type GadgetDesc struct{}
 
 func (g *GadgetDesc) Name() string {
@@ -41,7 +45,15 @@ func (g *GadgetDesc) Description() string {
 }
 
 func (g *GadgetDesc) ParamDescs() params.ParamDescs {
-	return nil
+	return params.ParamDescs{
+		{
+			Key:          ParamExcludeSidecarHops,
+			Title:        "Exclude sidecar hops",
+			DefaultValue: "false",
+			Description:  "Don't show the traffic redirected to service mesh sidecar proxies (Envoy, Linkerd)",
+			TypeHint:     params.TypeBool,
+		},
+	}
 }
 
 func (g *GadgetDesc) Parser() parser.Parser {
@@ -52,6 +64,15 @@ func (g *GadgetDesc) EventPrototype() any {
 	return &types.Event{}
 }
 
+func (g *GadgetDesc) Cost() gadgets.Cost {
+	return gadgets.Cost{
+		Probes:     1,
+		Events:     "every packet, with a socket filter per network namespace",
+		EventCost:  gadgets.CostHigh,
+		BufferSize: gadgets.PerfBufferSize(),
+	}
+}
+
 func init() {
 	gadgetregistry.Register(&GadgetDesc{})
 }
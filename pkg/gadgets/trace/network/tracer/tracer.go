@@ -34,6 +34,7 @@ import (
 	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
 	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/sidecar"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/types"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/rawsock"
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
@@ -75,6 +76,8 @@ type Tracer struct {
 	sync.Mutex
 	cache []*types.Event
 
+	excludeSidecarHops bool
+
 	eventCallback func(ev *types.Event)
 	gadgetCtx     gadgets.GadgetContext
 	ctx           context.Context
@@ -239,6 +242,13 @@ func (t *Tracer) Pop() (events []*types.Event, err error) {
 			WithNetNsID: eventtypes.WithNetNsID{NetNsID: key.ContainerNetns},
 		}
 
+		// The socket owner isn't known here, only the redirection to the
+		// sidecar can be detected.
+		e.Sidecar = sidecar.FromPorts(e.Port)
+		if t.excludeSidecarHops && e.Sidecar != "" {
+			return nil
+		}
+
 		if t.enricher != nil {
 			t.enricher.EnrichByNetNs(&e.CommonData, key.ContainerNetns)
 		} else {
@@ -255,7 +265,9 @@ func (t *Tracer) Pop() (events []*types.Event, err error) {
 		deleteValues := make([]uint64, 256)
 		count, err := graphmap.BatchLookupAndDelete(nil, &nextKey, deleteKeys, deleteValues, nil)
 		for i := 0; i < count; i++ {
-			events = append(events, convertKeyToEvent(deleteKeys[i], deleteValues[i]))
+			if e := convertKeyToEvent(deleteKeys[i], deleteValues[i]); e != nil {
+				events = append(events, e)
+			}
 		}
 		if errors.Is(err, ebpf.ErrKeyNotExist) {
 			return events, nil
@@ -274,7 +286,9 @@ func (t *Tracer) Pop() (events []*types.Event, err error) {
 	entries := graphmap.Iterate()
 
 	for entries.Next(&key, &val) {
-		events = append(events, convertKeyToEvent(key, val))
+		if e := convertKeyToEvent(key, val); e != nil {
+			events = append(events, e)
+		}
 
 		// Deleting an entry during the iteration causes the iteration
 		// to restart from the first key in the hash map. But in this
@@ -369,6 +383,7 @@ func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
 	}
 
 	t.gadgetCtx = gadgetCtx
+	t.excludeSidecarHops = gadgetCtx.GadgetParams().Get(ParamExcludeSidecarHops).AsBool()
 	t.ctx, t.cancel = gadgetcontext.WithTimeoutOrCancel(gadgetCtx.Context(), gadgetCtx.Timeout())
 	return nil
 }
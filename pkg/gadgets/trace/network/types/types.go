@@ -26,10 +26,13 @@ import (
 type Event struct {
 	eventtypes.Event
 	eventtypes.WithNetNsID
+	eventtypes.WithKubeAudit
+	eventtypes.WithJoin
 
 	PktType string `json:"pktType,omitempty" column:"type,maxWidth:9"`
 	Proto   string `json:"proto,omitempty" column:"proto,maxWidth:5"`
 	Port    uint16 `json:"port,omitempty" column:"port,template:ipport"`
+	Sidecar string `json:"sidecar,omitempty" column:"sidecar,maxWidth:7,hide"`
 
 	/* Further information of pod where event occurs */
 	PodHostIP string            `json:"podHostIP,omitempty" column:"podhostip,template:ipaddr,hide"`
@@ -52,6 +52,11 @@ func (g *GadgetDesc) EventPrototype() any {
 	return &types.Event{}
 }
 
+// SeverityRules classifies all the OOM kills as alerts
+func (g *GadgetDesc) SeverityRules() []string {
+	return []string{"alert"}
+}
+
 func init() {
 	gadgetregistry.Register(&GadgetDesc{})
 }
@@ -6,6 +6,7 @@
 #include <bpf/bpf_core_read.h>
 #include "opensnoop.h"
 #include "mntns_filter.h"
+#include "cgroup_filter.h"
 
 #define TASK_RUNNING	0
 
@@ -58,6 +59,9 @@ bool trace_allowed(u32 tgid, u32 pid)
 	if (gadget_should_discard_mntns_id(mntns_id))
 		return false;
 
+	if (gadget_should_discard_cgroup())
+		return false;
+
 	return true;
 }
 
@@ -52,6 +52,15 @@ func (g *GadgetDesc) EventPrototype() any {
 	return &types.Event{}
 }
 
+func (g *GadgetDesc) Cost() gadgets.Cost {
+	return gadgets.Cost{
+		Probes:     4,
+		Events:     "every open() and openat()",
+		EventCost:  gadgets.CostMedium,
+		BufferSize: gadgets.PerfBufferSize(),
+	}
+}
+
 func init() {
 	gadgetregistry.Register(&GadgetDesc{})
 }
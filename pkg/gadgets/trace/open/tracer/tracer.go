@@ -37,6 +37,10 @@ import (
 
 type Config struct {
 	MountnsMap *ebpf.Map
+
+	// CgroupPath, if set, restricts the events to processes running in that
+	// cgroup or in one of its descendants.
+	CgroupPath string
 }
 
 type Tracer struct {
@@ -96,10 +100,20 @@ func (t *Tracer) install() error {
 		return fmt.Errorf("loading ebpf program: %w", err)
 	}
 
-	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+	consts := map[string]interface{}{
+		gadgets.FilterByCgroupName: t.config.CgroupPath != "",
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, consts, &t.objs); err != nil {
 		return fmt.Errorf("loading ebpf spec: %w", err)
 	}
 
+	if t.config.CgroupPath != "" {
+		if err := gadgets.SetCgroupFilter(t.objs.GadgetCgroupFilterMap, t.config.CgroupPath); err != nil {
+			return err
+		}
+	}
+
 	// arm64 does not defined an open() syscall, only openat().
 	if runtime.GOARCH != "arm64" {
 		openEnter, err := link.Tracepoint("syscalls", "sys_enter_open", t.objs.IgOpenE, nil)
@@ -211,6 +225,10 @@ func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
 	t.config.MountnsMap = mountnsMap
 }
 
+func (t *Tracer) SetCgroupFilter(cgroupPath string) {
+	t.config.CgroupPath = cgroupPath
+}
+
 func (t *Tracer) SetEventHandler(handler any) {
 	nh, ok := handler.(func(ev *types.Event))
 	if !ok {
@@ -22,6 +22,8 @@ import (
 type Event struct {
 	eventtypes.Event
 	eventtypes.WithMountNsID
+	eventtypes.WithContainerUid
+	eventtypes.WithSystemdUnit
 
 	Pid  uint32 `json:"pid,omitempty" column:"pid,minWidth:7"`
 	Uid  uint32 `json:"uid,omitempty" column:"uid,minWidth:10,hide"`
@@ -36,6 +38,14 @@ func GetColumns() *columns.Columns[Event] {
 	return columns.MustCreateColumns[Event]()
 }
 
+func (e *Event) GetPid() uint32 {
+	return e.Pid
+}
+
+func (e *Event) GetUid() uint32 {
+	return e.Uid
+}
+
 func Base(ev eventtypes.Event) *Event {
 	return &Event{
 		Event: ev,
@@ -0,0 +1,129 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+
+#include <vmlinux/vmlinux.h>
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include <bpf/bpf_endian.h>
+
+#define GADGET_TYPE_TRACING
+#include <sockets-map.h>
+
+#include "packetdrop.h"
+
+/* Define here, because there are conflicts with include files */
+#define ETH_P_IPV6	0x86DD
+
+const volatile bool include_unspecified = false;
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event *unusedevent __attribute__((unused));
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, sizeof(__u32));
+} events SEC(".maps");
+
+// Source and destination ports are the first fields of both struct tcphdr
+// and struct udphdr.
+struct ports {
+	__be16 source;
+	__be16 dest;
+};
+
+SEC("tracepoint/skb/kfree_skb")
+int ig_packetdrop(struct trace_event_raw_kfree_skb *ctx)
+{
+	struct sk_buff *skb = ctx->skbaddr;
+	int reason = ctx->reason;
+
+	// If bpf_core_enum_value fails, it will return 0 and that will not be a silent failure
+	int reason_not_specified = bpf_core_enum_value(enum skb_drop_reason, SKB_DROP_REASON_NOT_SPECIFIED);
+
+	if (reason < reason_not_specified)
+		return 0;
+	if (reason == reason_not_specified && !include_unspecified)
+		return 0;
+
+	unsigned char *head = BPF_CORE_READ(skb, head);
+	__u16 nhoff = BPF_CORE_READ(skb, network_header);
+	__u16 thoff;
+
+	// The network header was never set, we can't parse the packet
+	if (nhoff == (__u16)~0U)
+		return 0;
+
+	struct event event = {};
+	event.reason = reason;
+
+	// ctx->protocol is already in host byte order
+	switch (ctx->protocol) {
+	case ETH_P_IP: {
+		struct iphdr iph;
+
+		if (bpf_probe_read_kernel(&iph, sizeof(iph), head + nhoff))
+			return 0;
+
+		event.af = AF_INET;
+		event.proto = iph.protocol;
+		event.saddr_v4 = iph.saddr;
+		event.daddr_v4 = iph.daddr;
+		// The transport header isn't always set yet on the receive path
+		thoff = nhoff + iph.ihl * 4;
+		break;
+	}
+	case ETH_P_IPV6: {
+		struct ipv6hdr ip6h;
+
+		if (bpf_probe_read_kernel(&ip6h, sizeof(ip6h), head + nhoff))
+			return 0;
+
+		event.af = AF_INET6;
+		event.proto = ip6h.nexthdr;
+		__builtin_memcpy(event.saddr, &ip6h.saddr, sizeof(event.saddr));
+		__builtin_memcpy(event.daddr, &ip6h.daddr, sizeof(event.daddr));
+		// Extension headers are not followed
+		thoff = nhoff + sizeof(ip6h);
+		break;
+	}
+	default:
+		return 0;
+	}
+
+	if (event.proto == IPPROTO_TCP || event.proto == IPPROTO_UDP) {
+		struct ports ports;
+
+		if (bpf_probe_read_kernel(&ports, sizeof(ports), head + thoff) == 0) {
+			event.sport = ports.source;
+			event.dport = ports.dest;
+		}
+	}
+
+	struct sock *sk = BPF_CORE_READ(skb, sk);
+	if (sk != NULL)
+		BPF_CORE_READ_INTO(&event.netns, sk, __sk_common.skc_net.net, ns.inum);
+	else
+		BPF_CORE_READ_INTO(&event.netns, skb, dev, nd_net.net, ns.inum);
+
+	// Packets don't belong to the task running when they are dropped,
+	// use the process owning the socket when there is one.
+	if (sk != NULL) {
+		struct sockets_value *skb_val = gadget_socket_lookup(sk, event.netns);
+		if (skb_val != NULL) {
+			event.mntns_id = skb_val->mntns;
+			event.pid = skb_val->pid_tgid >> 32;
+			event.tid = (__u32)skb_val->pid_tgid;
+			__builtin_memcpy(&event.task, skb_val->task, sizeof(event.task));
+		}
+	}
+
+	event.timestamp = bpf_ktime_get_boot_ns();
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: GPL-2.0
+
+#ifndef __PACKETDROP_H
+#define __PACKETDROP_H
+
+#define TASK_COMM_LEN 16
+
+struct event {
+	union {
+		__u8 saddr[16];
+		unsigned __int128 saddr_v6;
+		__u32 saddr_v4;
+	};
+	union {
+		__u8 daddr[16];
+		unsigned __int128 daddr_v6;
+		__u32 daddr_v4;
+	};
+	__u64 timestamp;
+	__u64 mntns_id;
+	__u32 pid;
+	__u32 tid;
+	__u32 af; // AF_INET or AF_INET6
+	__u32 reason;
+	__u32 netns;
+	__u16 dport;
+	__u16 sport;
+	__u8 proto;
+	__u8 task[TASK_COMM_LEN];
+};
+
+#endif /* __PACKETDROP_H */
@@ -0,0 +1,139 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type packetdropEvent struct {
+	Saddr     [16]uint8
+	Daddr     [16]uint8
+	Timestamp uint64
+	MntnsId   uint64
+	Pid       uint32
+	Tid       uint32
+	Af        uint32
+	Reason    uint32
+	Netns     uint32
+	Dport     uint16
+	Sport     uint16
+	Proto     uint8
+	Task      [16]uint8
+	_         [7]byte
+}
+
+// loadPacketdrop returns the embedded CollectionSpec for packetdrop.
+func loadPacketdrop() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_PacketdropBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load packetdrop: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadPacketdropObjects loads packetdrop and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*packetdropObjects
+//	*packetdropPrograms
+//	*packetdropMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadPacketdropObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadPacketdrop()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// packetdropSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type packetdropSpecs struct {
+	packetdropProgramSpecs
+	packetdropMapSpecs
+}
+
+// packetdropSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type packetdropProgramSpecs struct {
+	IgPacketdrop *ebpf.ProgramSpec `ebpf:"ig_packetdrop"`
+}
+
+// packetdropMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type packetdropMapSpecs struct {
+	Events  *ebpf.MapSpec `ebpf:"events"`
+	Sockets *ebpf.MapSpec `ebpf:"sockets"`
+}
+
+// packetdropObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadPacketdropObjects or ebpf.CollectionSpec.LoadAndAssign.
+type packetdropObjects struct {
+	packetdropPrograms
+	packetdropMaps
+}
+
+func (o *packetdropObjects) Close() error {
+	return _PacketdropClose(
+		&o.packetdropPrograms,
+		&o.packetdropMaps,
+	)
+}
+
+// packetdropMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadPacketdropObjects or ebpf.CollectionSpec.LoadAndAssign.
+type packetdropMaps struct {
+	Events  *ebpf.Map `ebpf:"events"`
+	Sockets *ebpf.Map `ebpf:"sockets"`
+}
+
+func (m *packetdropMaps) Close() error {
+	return _PacketdropClose(
+		m.Events,
+		m.Sockets,
+	)
+}
+
+// packetdropPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadPacketdropObjects or ebpf.CollectionSpec.LoadAndAssign.
+type packetdropPrograms struct {
+	IgPacketdrop *ebpf.Program `ebpf:"ig_packetdrop"`
+}
+
+func (p *packetdropPrograms) Close() error {
+	return _PacketdropClose(
+		p.IgPacketdrop,
+	)
+}
+
+func _PacketdropClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed packetdrop_bpfel_x86.o
+var _PacketdropBytes []byte
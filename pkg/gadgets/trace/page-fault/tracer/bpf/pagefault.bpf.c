@@ -0,0 +1,53 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include "pagefault.h"
+#include "mntns_filter.h"
+
+#define MAX_STACK_DEPTH	127
+#define STACK_ENTRIES	10240
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event *unusedevent __attribute__((unused));
+
+struct {
+	__uint(type, BPF_MAP_TYPE_STACK_TRACE);
+	__type(key, __u32);
+	__uint(max_entries, STACK_ENTRIES);
+	__uint(value_size, MAX_STACK_DEPTH * sizeof(__u64));
+} stackmap SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, sizeof(__u32));
+} events SEC(".maps");
+
+// Attached to the PERF_COUNT_SW_PAGE_FAULTS_MAJ software event, which is
+// raised in the context of the faulting thread once the fault is handled
+SEC("perf_event")
+int ig_pf_major(struct bpf_perf_event_data *ctx)
+{
+	__u64 pid_tgid = bpf_get_current_pid_tgid();
+	struct event event = {};
+	__u64 mntns_id;
+
+	mntns_id = gadget_get_mntns_id();
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return 0;
+
+	event.mntns_id = mntns_id;
+	event.timestamp = bpf_ktime_get_boot_ns();
+	event.address = ctx->addr;
+	event.pid = pid_tgid >> 32;
+	event.tid = (__u32)pid_tgid;
+	event.user_stack_id = bpf_get_stackid(ctx, &stackmap, BPF_F_USER_STACK);
+	event.kern_stack_id = bpf_get_stackid(ctx, &stackmap, 0);
+	bpf_get_current_comm(&event.task, sizeof(event.task));
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
@@ -0,0 +1,19 @@
+/* SPDX-License-Identifier: GPL-2.0 */
+#ifndef GADGET_PAGEFAULT_H
+#define GADGET_PAGEFAULT_H
+
+#define TASK_COMM_LEN	16
+
+struct event {
+	__u64 mntns_id;
+	__u64 timestamp;
+	/* Faulting address */
+	__u64 address;
+	__s32 user_stack_id;
+	__s32 kern_stack_id;
+	__u32 pid;
+	__u32 tid;
+	__u8 task[TASK_COMM_LEN];
+};
+
+#endif /* GADGET_PAGEFAULT_H */
@@ -0,0 +1,147 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+// mapping is a line of /proc/$PID/maps
+type mapping struct {
+	start  uint64
+	end    uint64
+	offset uint64
+	// path is empty for the anonymous mappings
+	path string
+}
+
+func (m *mapping) fileOffset(address uint64) uint64 {
+	return address - m.start + m.offset
+}
+
+// mappings are the mappings of a process, sorted by address
+type mappings []mapping
+
+func (ms mappings) find(address uint64) *mapping {
+	i := sort.Search(len(ms), func(i int) bool {
+		return ms[i].end > address
+	})
+	if i < len(ms) && ms[i].start <= address {
+		return &ms[i]
+	}
+	return nil
+}
+
+// symbolize returns the file and offset of an instruction pointer, user
+// space symbols aren't supported
+func (ms mappings) symbolize(ip uint64) string {
+	if m := ms.find(ip); m != nil && m.path != "" {
+		return fmt.Sprintf("%s+0x%x", filepath.Base(m.path), m.fileOffset(ip))
+	}
+	return fmt.Sprintf("0x%x", ip)
+}
+
+func readMappings(pid uint32) (mappings, error) {
+	file, err := os.Open(filepath.Join(host.HostProcFs, fmt.Sprint(pid), "maps"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ms := mappings{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// address perms offset dev inode pathname
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 5 {
+			continue
+		}
+		start, end, ok := strings.Cut(fields[0], "-")
+		if !ok {
+			continue
+		}
+		m := mapping{}
+		if m.start, err = strconv.ParseUint(start, 16, 64); err != nil {
+			continue
+		}
+		if m.end, err = strconv.ParseUint(end, 16, 64); err != nil {
+			continue
+		}
+		if m.offset, err = strconv.ParseUint(fields[2], 16, 64); err != nil {
+			continue
+		}
+		// Only keep the files, not the pseudo paths like [heap]
+		if len(fields) > 5 && fields[4] != "0" {
+			m.path = strings.Join(fields[5:], " ")
+		}
+		ms = append(ms, m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return ms, nil
+}
+
+// mappingsCache caches the mappings of the processes to not read them for
+// each fault, they are read again when a fault isn't in a known mapping
+type mappingsCache struct {
+	mu       sync.Mutex
+	mappings map[uint32]mappings
+}
+
+func newMappingsCache() *mappingsCache {
+	return &mappingsCache{
+		mappings: make(map[uint32]mappings),
+	}
+}
+
+func (c *mappingsCache) get(pid uint32, address uint64) mappings {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ms, ok := c.mappings[pid]
+	if ok && ms.find(address) != nil {
+		return ms
+	}
+
+	ms, err := readMappings(pid)
+	if err != nil {
+		// The process is likely gone
+		return nil
+	}
+	c.mappings[pid] = ms
+	return ms
+}
+
+// expire forgets all the mappings, to not keep the ones of the processes that
+// exited or changed their mappings
+func (c *mappingsCache) expire() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.mappings = make(map[uint32]mappings)
+}
@@ -0,0 +1,136 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build arm64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type pagefaultEvent struct {
+	MntnsId     uint64
+	Timestamp   uint64
+	Address     uint64
+	UserStackId int32
+	KernStackId int32
+	Pid         uint32
+	Tid         uint32
+	Task        [16]uint8
+}
+
+// loadPagefault returns the embedded CollectionSpec for pagefault.
+func loadPagefault() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_PagefaultBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load pagefault: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadPagefaultObjects loads pagefault and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*pagefaultObjects
+//	*pagefaultPrograms
+//	*pagefaultMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadPagefaultObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadPagefault()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// pagefaultSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type pagefaultSpecs struct {
+	pagefaultProgramSpecs
+	pagefaultMapSpecs
+}
+
+// pagefaultSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type pagefaultProgramSpecs struct {
+	IgPfMajor *ebpf.ProgramSpec `ebpf:"ig_pf_major"`
+}
+
+// pagefaultMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type pagefaultMapSpecs struct {
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Stackmap             *ebpf.MapSpec `ebpf:"stackmap"`
+}
+
+// pagefaultObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadPagefaultObjects or ebpf.CollectionSpec.LoadAndAssign.
+type pagefaultObjects struct {
+	pagefaultPrograms
+	pagefaultMaps
+}
+
+func (o *pagefaultObjects) Close() error {
+	return _PagefaultClose(
+		&o.pagefaultPrograms,
+		&o.pagefaultMaps,
+	)
+}
+
+// pagefaultMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadPagefaultObjects or ebpf.CollectionSpec.LoadAndAssign.
+type pagefaultMaps struct {
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Stackmap             *ebpf.Map `ebpf:"stackmap"`
+}
+
+func (m *pagefaultMaps) Close() error {
+	return _PagefaultClose(
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.Stackmap,
+	)
+}
+
+// pagefaultPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadPagefaultObjects or ebpf.CollectionSpec.LoadAndAssign.
+type pagefaultPrograms struct {
+	IgPfMajor *ebpf.Program `ebpf:"ig_pf_major"`
+}
+
+func (p *pagefaultPrograms) Close() error {
+	return _PagefaultClose(
+		p.IgPfMajor,
+	)
+}
+
+func _PagefaultClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed pagefault_bpfel_arm64.o
+var _PagefaultBytes []byte
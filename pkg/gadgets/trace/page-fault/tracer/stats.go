@@ -0,0 +1,128 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+// faultStats are the page fault counters of a memory cgroup
+type faultStats struct {
+	// faults includes the major faults
+	faults      uint64
+	majorFaults uint64
+}
+
+type cgroup struct {
+	name string
+	dir  string
+	last faultStats
+}
+
+// getMemoryCgroup returns the directory of the cgroup of the given process in
+// the hierarchy of the memory controller
+func getMemoryCgroup(pid uint32) (string, error) {
+	file, err := os.Open(filepath.Join(host.HostProcFs, fmt.Sprint(pid), "cgroup"))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	pathV2 := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[0] == "0" && fields[1] == "" {
+			pathV2 = fields[2]
+			continue
+		}
+		if fields[1] == "memory" {
+			dir := filepath.Join(cgroupRoot, "memory", fields[2])
+			if _, err := os.Stat(filepath.Join(dir, "memory.stat")); err == nil {
+				return dir, nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	if pathV2 != "" {
+		dir := filepath.Join(cgroupRoot, pathV2)
+		if _, err := os.Stat(filepath.Join(dir, "memory.stat")); err == nil {
+			return dir, nil
+		}
+	}
+
+	return "", errors.New("memory controller not found")
+}
+
+// readFaultStats reads the page fault counters of a cgroup from its
+// memory.stat, which has the same keys with cgroup v1 and v2
+func readFaultStats(dir string) (faultStats, error) {
+	stats := faultStats{}
+
+	file, err := os.Open(filepath.Join(dir, "memory.stat"))
+	if err != nil {
+		return stats, err
+	}
+	defer file.Close()
+
+	found := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), " ")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		// With cgroup v1, the total_ counters, listed after the others,
+		// include the child cgroups like the counters of cgroup v2 do
+		switch key {
+		case "pgfault", "total_pgfault":
+			stats.faults = n
+			found = true
+		case "pgmajfault", "total_pgmajfault":
+			stats.majorFaults = n
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return stats, err
+	}
+	if !found {
+		return stats, errors.New("no page fault counters in memory.stat")
+	}
+
+	return stats, nil
+}
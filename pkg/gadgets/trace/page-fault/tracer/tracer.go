@@ -0,0 +1,355 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/perf"
+	"golang.org/x/sys/unix"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/page-fault/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/kallsyms"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -type event pagefault ./bpf/pagefault.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
+
+const perfMaxStackDepth = 127
+
+type Config struct {
+	MountnsMap  *ebpf.Map
+	Interval    time.Duration
+	MajorFaults bool
+}
+
+type Tracer struct {
+	config        *Config
+	enricher      gadgets.DataEnricherByMntNs
+	eventCallback func(*types.Event)
+
+	mu sync.Mutex
+	// cgroups of the containers indexed by mount namespace
+	cgroups map[uint64]*cgroup
+
+	// The major faults are traced with eBPF, only when asked
+	objs     pagefaultObjects
+	perfFds  []int
+	reader   *perf.Reader
+	kAllSyms *kallsyms.KAllSyms
+	mappings *mappingsCache
+	// stacks caches the instruction pointers of the stacks by ID, a stack
+	// doesn't change once it's in the stack map
+	stacks map[int32][]uint64
+}
+
+func (t *Tracer) close() {
+	if t.reader != nil {
+		t.reader.Close()
+	}
+
+	for _, fd := range t.perfFds {
+		unix.IoctlSetInt(fd, unix.PERF_EVENT_IOC_DISABLE, 0)
+		unix.Close(fd)
+	}
+	t.perfFds = nil
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadPagefault()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	// Sample every major fault on all the CPUs
+	for cpu := 0; cpu < runtime.NumCPU(); cpu++ {
+		fd, err := unix.PerfEventOpen(
+			&unix.PerfEventAttr{
+				Type:        unix.PERF_TYPE_SOFTWARE,
+				Config:      unix.PERF_COUNT_SW_PAGE_FAULTS_MAJ,
+				Sample_type: unix.PERF_SAMPLE_RAW,
+				Sample:      1,
+			},
+			-1,
+			cpu,
+			-1,
+			unix.PERF_FLAG_FD_CLOEXEC,
+		)
+		if err != nil {
+			return fmt.Errorf("creating the perf fd: %w", err)
+		}
+		t.perfFds = append(t.perfFds, fd)
+
+		if err := unix.IoctlSetInt(fd, unix.PERF_EVENT_IOC_SET_BPF, t.objs.IgPfMajor.FD()); err != nil {
+			return fmt.Errorf("attaching eBPF program to perf fd: %w", err)
+		}
+		if err := unix.IoctlSetInt(fd, unix.PERF_EVENT_IOC_ENABLE, 0); err != nil {
+			return fmt.Errorf("enabling perf fd: %w", err)
+		}
+	}
+
+	t.reader, err = perf.NewReader(t.objs.pagefaultMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	if err != nil {
+		return fmt.Errorf("creating perf ring buffer: %w", err)
+	}
+
+	return nil
+}
+
+// getStack returns the instruction pointers of a stack, nil if it couldn't
+// be captured
+func (t *Tracer) getStack(id int32) []uint64 {
+	if id < 0 {
+		return nil
+	}
+	if ips, ok := t.stacks[id]; ok {
+		return ips
+	}
+
+	var raw [perfMaxStackDepth]uint64
+	if err := t.objs.pagefaultMaps.Stackmap.Lookup(id, unsafe.Pointer(&raw)); err != nil {
+		return nil
+	}
+	ips := []uint64{}
+	for _, ip := range raw {
+		if ip == 0 {
+			break
+		}
+		ips = append(ips, ip)
+	}
+	t.stacks[id] = ips
+	return ips
+}
+
+func (t *Tracer) run() {
+	for {
+		record, err := t.reader.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				// nothing to do, we're done
+				return
+			}
+
+			msg := fmt.Sprintf("Error reading perf ring buffer: %s", err)
+			t.eventCallback(types.Base(eventtypes.Err(msg)))
+			return
+		}
+
+		if record.LostSamples > 0 {
+			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
+			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			continue
+		}
+
+		bpfEvent := (*pagefaultEvent)(unsafe.Pointer(&record.RawSample[0]))
+
+		event := types.Event{
+			Event: eventtypes.Event{
+				Type:      eventtypes.NORMAL,
+				Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+			},
+			WithMountNsID: eventtypes.WithMountNsID{MountNsID: bpfEvent.MntnsId},
+			Operation:     types.OperationMajor,
+			Pid:           bpfEvent.Pid,
+			Tid:           bpfEvent.Tid,
+			Comm:          gadgets.FromCString(bpfEvent.Task[:]),
+			Address:       bpfEvent.Address,
+		}
+
+		mappings := t.mappings.get(bpfEvent.Pid, bpfEvent.Address)
+		if m := mappings.find(bpfEvent.Address); m != nil && m.path != "" {
+			event.File = m.path
+			event.Offset = m.fileOffset(bpfEvent.Address)
+		}
+
+		for _, ip := range t.getStack(bpfEvent.UserStackId) {
+			event.UserStack = append(event.UserStack, mappings.symbolize(ip))
+		}
+		// Kernel modules could have been loaded since the last event
+		if kAllSyms, err := kallsyms.Shared(); err == nil {
+			t.kAllSyms = kAllSyms
+		}
+		for _, ip := range t.getStack(bpfEvent.KernStackId) {
+			event.KernelStack = append(event.KernelStack, t.kAllSyms.LookupByInstructionPointer(ip))
+		}
+
+		if t.enricher != nil {
+			t.enricher.EnrichByMntNs(&event.CommonData, event.MountNsID)
+		}
+
+		t.eventCallback(&event)
+	}
+}
+
+// check emits an event with the page faults of each container since the last
+// check
+func (t *Tracer) check(gadgetCtx gadgets.GadgetContext) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for mntns, cg := range t.cgroups {
+		stats, err := readFaultStats(cg.dir)
+		if err != nil {
+			// The container is likely gone, don't try again
+			gadgetCtx.Logger().Debugf("reading memory statistics of container %q: %s", cg.name, err)
+			delete(t.cgroups, mntns)
+			continue
+		}
+
+		last := cg.last
+		cg.last = stats
+
+		// The counters are reset if the cgroup is recreated
+		if stats.faults < last.faults || stats.majorFaults < last.majorFaults {
+			continue
+		}
+		faults := stats.faults - last.faults
+		major := stats.majorFaults - last.majorFaults
+		if faults == 0 {
+			continue
+		}
+		// pgfault counts all the faults, including the major ones
+		minor := uint64(0)
+		if faults > major {
+			minor = faults - major
+		}
+
+		event := &types.Event{
+			Event: eventtypes.Event{
+				Type:      eventtypes.NORMAL,
+				Timestamp: eventtypes.Time(time.Now().UnixNano()),
+			},
+			WithMountNsID: eventtypes.WithMountNsID{MountNsID: mntns},
+			Operation:     types.OperationStats,
+			Minor:         minor,
+			Major:         major,
+		}
+		t.eventCallback(event)
+	}
+}
+
+// ---
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return &Tracer{
+		config:  &Config{},
+		cgroups: make(map[uint64]*cgroup),
+	}, nil
+}
+
+func (t *Tracer) AttachContainer(container *containercollection.Container) error {
+	dir, err := getMemoryCgroup(container.Pid)
+	if err != nil {
+		return fmt.Errorf("getting memory cgroup: %w", err)
+	}
+
+	// The faults that happened before the gadget started aren't reported
+	stats, err := readFaultStats(dir)
+	if err != nil {
+		return fmt.Errorf("reading memory statistics: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.cgroups[container.Mntns] = &cgroup{
+		name: container.Name,
+		dir:  dir,
+		last: stats,
+	}
+	return nil
+}
+
+func (t *Tracer) DetachContainer(container *containercollection.Container) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.cgroups, container.Mntns)
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.Interval = time.Duration(params.Get(gadgets.ParamInterval).AsUint32()) * time.Second
+	if t.config.Interval == 0 {
+		return errors.New("interval must be greater than 0")
+	}
+	t.config.MajorFaults = params.Get(ParamMajorFaults).AsBool()
+
+	if t.config.MajorFaults {
+		defer t.close()
+		if err := t.install(); err != nil {
+			return fmt.Errorf("installing tracer: %w", err)
+		}
+
+		kAllSyms, err := kallsyms.Shared()
+		if err != nil {
+			return fmt.Errorf("loading kernel symbols: %w", err)
+		}
+		t.kAllSyms = kAllSyms
+		t.mappings = newMappingsCache()
+		t.stacks = make(map[int32][]uint64)
+
+		go t.run()
+	}
+
+	ctx, cancel := gadgetcontext.WithTimeoutOrCancel(gadgetCtx.Context(), gadgetCtx.Timeout())
+	defer cancel()
+
+	ticker := time.NewTicker(t.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			t.check(gadgetCtx)
+			if t.mappings != nil {
+				t.mappings.expire()
+			}
+		}
+	}
+}
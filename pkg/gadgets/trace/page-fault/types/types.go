@@ -0,0 +1,104 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"fmt"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperationStats = "stats"
+	OperationMajor = "major"
+)
+
+// Event is either the number of page faults of a container during an
+// interval, or a single major fault
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Operation string `json:"operation,omitempty" column:"op,width:5,fixed" columnDesc:"stats for the page faults of the container during the interval, major for a major fault"`
+	Pid       uint32 `json:"pid,omitempty" column:"pid,template:pid"`
+	Tid       uint32 `json:"tid,omitempty" column:"tid,template:pid,hide"`
+	Comm      string `json:"comm,omitempty" column:"comm,template:comm"`
+
+	// Minor and Major are the number of faults of the container during the
+	// interval, for the stats
+	Minor uint64 `json:"minor,omitempty" column:"minor,width:10,align:right"`
+	Major uint64 `json:"major,omitempty" column:"major,width:10,align:right"`
+
+	// Address is the faulting address, File the file mapped at this address,
+	// if any, and Offset the offset of the address in the file, for the major
+	// faults
+	Address uint64 `json:"address,omitempty" column:"address,width:18,hide"`
+	File    string `json:"file,omitempty" column:"file,width:32"`
+	Offset  uint64 `json:"offset,omitempty" column:"offset,width:12,hide"`
+
+	UserStack   []string `json:"userStack,omitempty"`
+	KernelStack []string `json:"kernelStack,omitempty"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	// The counts are meaningless for the major faults
+	cols.MustSetExtractor("minor", func(event *Event) string {
+		if event.Operation != OperationStats {
+			return ""
+		}
+		return fmt.Sprint(event.Minor)
+	})
+	cols.MustSetExtractor("major", func(event *Event) string {
+		if event.Operation != OperationStats {
+			return ""
+		}
+		return fmt.Sprint(event.Major)
+	})
+	cols.MustSetExtractor("address", func(event *Event) string {
+		if event.Operation != OperationMajor {
+			return ""
+		}
+		return fmt.Sprintf("0x%x", event.Address)
+	})
+	cols.MustSetExtractor("offset", func(event *Event) string {
+		if event.File == "" {
+			return ""
+		}
+		return fmt.Sprintf("0x%x", event.Offset)
+	})
+
+	return cols
+}
+
+// ExtraLines returns the kernel and user stacks of the major faults
+func (e *Event) ExtraLines() []string {
+	var out []string
+	for i := len(e.KernelStack) - 1; i >= 0; i-- {
+		out = append(out, "\t"+e.KernelStack[i])
+	}
+	for i := len(e.UserStack) - 1; i >= 0; i-- {
+		out = append(out, "\t"+e.UserStack[i])
+	}
+	return out
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/pressure/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+const cgroupRoot = "/sys/fs/cgroup"
+
+type Config struct {
+	Interval time.Duration
+	// The thresholds are percentages of the last 10 seconds, 0 when disabled
+	SomeThreshold float64
+	FullThreshold float64
+}
+
+// pressure is the content of a $RESOURCE.pressure file
+type pressure struct {
+	some [3]float64
+	full [3]float64
+}
+
+type cgroup struct {
+	name string
+	dir  string
+	// resources under pressure at the last check
+	underPressure map[types.Resource]bool
+}
+
+type Tracer struct {
+	config        *Config
+	eventCallback func(*types.Event)
+
+	mu sync.Mutex
+	// cgroups of the containers indexed by mount namespace
+	cgroups map[uint64]*cgroup
+}
+
+// getCgroupV2 returns the directory of the cgroup v2 of the given process,
+// the pressure stall information isn't available with cgroup v1
+func getCgroupV2(pid uint32) (string, error) {
+	file, err := os.Open(filepath.Join(host.HostProcFs, fmt.Sprint(pid), "cgroup"))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 || fields[0] != "0" || fields[1] != "" {
+			continue
+		}
+		// The cgroup v2 hierarchy is mounted at "unified" in hybrid mode
+		for _, root := range []string{cgroupRoot, filepath.Join(cgroupRoot, "unified")} {
+			dir := filepath.Join(root, fields[2])
+			if _, err := os.Stat(filepath.Join(dir, "cpu.pressure")); err == nil {
+				return dir, nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	return "", errors.New("pressure stall information not found: it requires cgroup v2 and a kernel with CONFIG_PSI")
+}
+
+// readPressure reads a $RESOURCE.pressure file:
+//
+//	some avg10=0.00 avg60=0.00 avg300=0.00 total=0
+//	full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+func readPressure(path string) (pressure, error) {
+	p := pressure{}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return p, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		var avgs *[3]float64
+		switch fields[0] {
+		case "some":
+			avgs = &p.some
+		case "full":
+			avgs = &p.full
+		default:
+			continue
+		}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			i := -1
+			switch key {
+			case "avg10":
+				i = 0
+			case "avg60":
+				i = 1
+			case "avg300":
+				i = 2
+			}
+			if i < 0 {
+				continue
+			}
+			if avgs[i], err = strconv.ParseFloat(value, 64); err != nil {
+				return p, fmt.Errorf("parsing %q: %w", field, err)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func (t *Tracer) isUnderPressure(p pressure) bool {
+	return (t.config.SomeThreshold > 0 && p.some[0] >= t.config.SomeThreshold) ||
+		(t.config.FullThreshold > 0 && p.full[0] >= t.config.FullThreshold)
+}
+
+// check emits an event for each resource of a container whose pressure
+// crossed the thresholds since the last check
+func (t *Tracer) check(gadgetCtx gadgets.GadgetContext) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for mntns, cg := range t.cgroups {
+		for _, resource := range types.Resources {
+			p, err := readPressure(filepath.Join(cg.dir, string(resource)+".pressure"))
+			if err != nil {
+				// The container is likely gone, don't try again
+				gadgetCtx.Logger().Debugf("reading %s pressure of container %q: %s", resource, cg.name, err)
+				delete(t.cgroups, mntns)
+				break
+			}
+
+			underPressure := t.isUnderPressure(p)
+			if underPressure == cg.underPressure[resource] {
+				continue
+			}
+			cg.underPressure[resource] = underPressure
+
+			state := types.StateRecovered
+			if underPressure {
+				state = types.StatePressure
+			}
+
+			t.eventCallback(&types.Event{
+				Event: eventtypes.Event{
+					Type:      eventtypes.NORMAL,
+					Timestamp: eventtypes.Time(time.Now().UnixNano()),
+				},
+				WithMountNsID: eventtypes.WithMountNsID{MountNsID: mntns},
+				Resource:      resource,
+				State:         state,
+				SomeAvg10:     p.some[0],
+				SomeAvg60:     p.some[1],
+				SomeAvg300:    p.some[2],
+				FullAvg10:     p.full[0],
+				FullAvg60:     p.full[1],
+				FullAvg300:    p.full[2],
+			})
+		}
+	}
+}
+
+// ---
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return &Tracer{
+		config:  &Config{},
+		cgroups: make(map[uint64]*cgroup),
+	}, nil
+}
+
+func (t *Tracer) AttachContainer(container *containercollection.Container) error {
+	dir, err := getCgroupV2(container.Pid)
+	if err != nil {
+		return fmt.Errorf("getting cgroup: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// The containers already under pressure are reported at the first check
+	t.cgroups[container.Mntns] = &cgroup{
+		name:          container.Name,
+		dir:           dir,
+		underPressure: make(map[types.Resource]bool),
+	}
+	return nil
+}
+
+func (t *Tracer) DetachContainer(container *containercollection.Container) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.cgroups, container.Mntns)
+	return nil
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	params := gadgetCtx.GadgetParams()
+	t.config.Interval = time.Duration(params.Get(gadgets.ParamInterval).AsUint32()) * time.Second
+	if t.config.Interval == 0 {
+		return errors.New("interval must be greater than 0")
+	}
+	t.config.SomeThreshold = float64(params.Get(ParamSomeThreshold).AsUint32())
+	t.config.FullThreshold = float64(params.Get(ParamFullThreshold).AsUint32())
+	if t.config.SomeThreshold == 0 && t.config.FullThreshold == 0 {
+		return errors.New("at least one of the thresholds must be greater than 0")
+	}
+
+	ctx, cancel := gadgetcontext.WithTimeoutOrCancel(gadgetCtx.Context(), gadgetCtx.Timeout())
+	defer cancel()
+
+	ticker := time.NewTicker(t.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			t.check(gadgetCtx)
+		}
+	}
+}
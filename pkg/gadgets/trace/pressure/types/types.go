@@ -0,0 +1,69 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// Resource is a resource whose pressure stall information is reported
+type Resource string
+
+const (
+	ResourceCPU    Resource = "cpu"
+	ResourceMemory Resource = "memory"
+	ResourceIO     Resource = "io"
+)
+
+// Resources are the resources checked, in the order of the events
+var Resources = []Resource{ResourceCPU, ResourceMemory, ResourceIO}
+
+type State string
+
+const (
+	// StatePressure is reported when the pressure goes above the threshold
+	StatePressure State = "pressure"
+	// StateRecovered is reported when it goes back below
+	StateRecovered State = "recovered"
+)
+
+// Event is the change of the pressure of a resource of a container. The
+// averages are the percentages of the time in which some tasks, or all of
+// them, were stalled waiting for the resource, over the last 10, 60 and 300
+// seconds.
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Resource   Resource `json:"resource" column:"resource,width:8"`
+	State      State    `json:"state" column:"state,width:9"`
+	SomeAvg10  float64  `json:"someAvg10" column:"some10,width:7,precision:2"`
+	SomeAvg60  float64  `json:"someAvg60" column:"some60,width:7,precision:2"`
+	SomeAvg300 float64  `json:"someAvg300" column:"some300,width:7,precision:2,hide"`
+	FullAvg10  float64  `json:"fullAvg10" column:"full10,width:7,precision:2"`
+	FullAvg60  float64  `json:"fullAvg60" column:"full60,width:7,precision:2"`
+	FullAvg300 float64  `json:"fullAvg300" column:"full300,width:7,precision:2,hide"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	return columns.MustCreateColumns[Event]()
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
@@ -0,0 +1,176 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include <bpf/bpf_endian.h>
+#include "readiness.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+#define MAX_CONTAINERS	10240
+
+#define AF_INET		2
+#define AF_INET6	10
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event *unusedevent __attribute__((unused));
+
+// Sockets being put in the listening state, indexed by thread
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__type(value, struct socket *);
+} sockets SEC(".maps");
+
+struct seen_key {
+	__u64 mntns_id;
+	enum event_type type;
+};
+
+// Containers whose first listen or accept was already reported
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, MAX_CONTAINERS);
+	__type(key, struct seen_key);
+	__type(value, __u8);
+} seen SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, sizeof(__u32));
+} events SEC(".maps");
+
+static __always_inline bool already_seen(__u64 mntns_id, enum event_type type)
+{
+	struct seen_key key = {
+		.mntns_id = mntns_id,
+		.type = type,
+	};
+
+	return bpf_map_lookup_elem(&seen, &key) != NULL;
+}
+
+// mark_seen returns false if another thread already reported the same event
+static __always_inline bool mark_seen(__u64 mntns_id, enum event_type type)
+{
+	struct seen_key key = {
+		.mntns_id = mntns_id,
+		.type = type,
+	};
+	__u8 one = 1;
+
+	return bpf_map_update_elem(&seen, &key, &one, BPF_NOEXIST) == 0;
+}
+
+// The init process of the pid namespace is the main process of the container
+static __always_inline __u64 container_start_time(struct task_struct *task)
+{
+	struct task_struct *reaper;
+
+	reaper = BPF_CORE_READ(task, nsproxy, pid_ns_for_children, child_reaper);
+	if (!reaper)
+		return 0;
+
+	return BPF_CORE_READ(reaper, start_boottime);
+}
+
+static __always_inline void
+submit_event(void *ctx, struct sock *sk, __u64 mntns_id, enum event_type type)
+{
+	struct task_struct *task = (struct task_struct *)bpf_get_current_task();
+	struct event event = {};
+	__u64 start;
+
+	event.type = type;
+	event.mntns_id = mntns_id;
+	event.timestamp = bpf_ktime_get_boot_ns();
+	event.pid = bpf_get_current_pid_tgid() >> 32;
+	event.uid = (__u32)bpf_get_current_uid_gid();
+	bpf_get_current_comm(&event.task, sizeof(event.task));
+
+	start = container_start_time(task);
+	if (start && start < event.timestamp)
+		event.since_start = event.timestamp - start;
+
+	// skc_num is the local port in host byte order
+	event.port = BPF_CORE_READ(sk, __sk_common.skc_num);
+	switch (BPF_CORE_READ(sk, __sk_common.skc_family)) {
+	case AF_INET:
+		event.ver = 4;
+		BPF_CORE_READ_INTO(&event.addr, sk, __sk_common.skc_rcv_saddr);
+		break;
+	case AF_INET6:
+		event.ver = 6;
+		BPF_CORE_READ_INTO(&event.addr, sk, __sk_common.skc_v6_rcv_saddr.in6_u.u6_addr32);
+		break;
+	}
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+}
+
+SEC("kprobe/inet_listen")
+int BPF_KPROBE(ig_ready_listen_e, struct socket *socket)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	__u64 mntns_id = gadget_get_mntns_id();
+
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return 0;
+
+	if (already_seen(mntns_id, READINESS_EVENT_TYPE_LISTEN))
+		return 0;
+
+	bpf_map_update_elem(&sockets, &tid, &socket, BPF_ANY);
+	return 0;
+}
+
+SEC("kretprobe/inet_listen")
+int BPF_KRETPROBE(ig_ready_listen_x, int ret)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	struct socket **socketp;
+	struct sock *sk;
+	__u64 mntns_id;
+
+	socketp = bpf_map_lookup_elem(&sockets, &tid);
+	if (!socketp)
+		return 0;
+
+	if (ret != 0)
+		goto cleanup;
+
+	mntns_id = gadget_get_mntns_id();
+	if (!mark_seen(mntns_id, READINESS_EVENT_TYPE_LISTEN))
+		goto cleanup;
+
+	sk = BPF_CORE_READ(*socketp, sk);
+	submit_event(ctx, sk, mntns_id, READINESS_EVENT_TYPE_LISTEN);
+
+cleanup:
+	bpf_map_delete_elem(&sockets, &tid);
+	return 0;
+}
+
+SEC("kretprobe/inet_csk_accept")
+int BPF_KRETPROBE(ig_ready_accept_x, struct sock *sk)
+{
+	__u64 mntns_id = gadget_get_mntns_id();
+
+	if (!sk)
+		return 0;
+
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return 0;
+
+	if (!mark_seen(mntns_id, READINESS_EVENT_TYPE_ACCEPT))
+		return 0;
+
+	submit_event(ctx, sk, mntns_id, READINESS_EVENT_TYPE_ACCEPT);
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
@@ -0,0 +1,26 @@
+/* SPDX-License-Identifier: GPL-2.0 */
+#ifndef GADGET_READINESS_H
+#define GADGET_READINESS_H
+
+#define TASK_COMM_LEN	16
+
+enum event_type : u8 {
+	READINESS_EVENT_TYPE_LISTEN,
+	READINESS_EVENT_TYPE_ACCEPT,
+};
+
+struct event {
+	__u8 addr[16];
+	__u64 mntns_id;
+	__u64 timestamp;
+	// Time elapsed since the init process of the container started
+	__u64 since_start;
+	__u32 pid;
+	__u32 uid;
+	__u16 port;
+	__u8 ver;
+	enum event_type type;
+	__u8 task[TASK_COMM_LEN];
+};
+
+#endif /* GADGET_READINESS_H */
@@ -0,0 +1,161 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build arm64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type readinessEvent struct {
+	Addr       [16]uint8
+	MntnsId    uint64
+	Timestamp  uint64
+	SinceStart uint64
+	Pid        uint32
+	Uid        uint32
+	Port       uint16
+	Ver        uint8
+	Type       readinessEventType
+	Task       [16]uint8
+	_          [4]byte
+}
+
+type readinessEventType uint8
+
+const (
+	readinessEventTypeREADINESS_EVENT_TYPE_LISTEN readinessEventType = 0
+	readinessEventTypeREADINESS_EVENT_TYPE_ACCEPT readinessEventType = 1
+)
+
+type readinessSeenKey struct {
+	MntnsId uint64
+	Type    readinessEventType
+	_       [7]byte
+}
+
+// loadReadiness returns the embedded CollectionSpec for readiness.
+func loadReadiness() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_ReadinessBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load readiness: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadReadinessObjects loads readiness and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*readinessObjects
+//	*readinessPrograms
+//	*readinessMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadReadinessObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadReadiness()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// readinessSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type readinessSpecs struct {
+	readinessProgramSpecs
+	readinessMapSpecs
+}
+
+// readinessSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type readinessProgramSpecs struct {
+	IgReadyAcceptX *ebpf.ProgramSpec `ebpf:"ig_ready_accept_x"`
+	IgReadyListenE *ebpf.ProgramSpec `ebpf:"ig_ready_listen_e"`
+	IgReadyListenX *ebpf.ProgramSpec `ebpf:"ig_ready_listen_x"`
+}
+
+// readinessMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type readinessMapSpecs struct {
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Seen                 *ebpf.MapSpec `ebpf:"seen"`
+	Sockets              *ebpf.MapSpec `ebpf:"sockets"`
+}
+
+// readinessObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadReadinessObjects or ebpf.CollectionSpec.LoadAndAssign.
+type readinessObjects struct {
+	readinessPrograms
+	readinessMaps
+}
+
+func (o *readinessObjects) Close() error {
+	return _ReadinessClose(
+		&o.readinessPrograms,
+		&o.readinessMaps,
+	)
+}
+
+// readinessMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadReadinessObjects or ebpf.CollectionSpec.LoadAndAssign.
+type readinessMaps struct {
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Seen                 *ebpf.Map `ebpf:"seen"`
+	Sockets              *ebpf.Map `ebpf:"sockets"`
+}
+
+func (m *readinessMaps) Close() error {
+	return _ReadinessClose(
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.Seen,
+		m.Sockets,
+	)
+}
+
+// readinessPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadReadinessObjects or ebpf.CollectionSpec.LoadAndAssign.
+type readinessPrograms struct {
+	IgReadyAcceptX *ebpf.Program `ebpf:"ig_ready_accept_x"`
+	IgReadyListenE *ebpf.Program `ebpf:"ig_ready_listen_e"`
+	IgReadyListenX *ebpf.Program `ebpf:"ig_ready_listen_x"`
+}
+
+func (p *readinessPrograms) Close() error {
+	return _ReadinessClose(
+		p.IgReadyAcceptX,
+		p.IgReadyListenE,
+		p.IgReadyListenX,
+	)
+}
+
+func _ReadinessClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed readiness_bpfel_arm64.o
+var _ReadinessBytes []byte
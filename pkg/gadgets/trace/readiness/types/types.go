@@ -0,0 +1,56 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Pid       uint32        `json:"pid,omitempty" column:"pid,template:pid"`
+	Comm      string        `json:"comm,omitempty" column:"comm,template:comm"`
+	Uid       uint32        `json:"uid,omitempty" column:"uid,template:uid,hide"`
+	Operation string        `json:"operation,omitempty" column:"op,width:6,fixed" columnDesc:"listen for the first listening socket, accept for the first accepted connection"`
+	IPVersion int           `json:"ipversion,omitempty" column:"ip,template:ipversion"`
+	Addr      string        `json:"addr,omitempty" column:"addr,template:ipaddr"`
+	Port      uint16        `json:"port,omitempty" column:"port,template:ipport"`
+	Elapsed   time.Duration `json:"elapsed,omitempty" column:"elapsed,minWidth:10" columnDesc:"Time elapsed since the main process of the container started"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	cols.MustSetExtractor("elapsed", func(event *Event) string {
+		// Not set for processes that don't run in their own pid namespace
+		if event.Elapsed == 0 {
+			return ""
+		}
+		return event.Elapsed.String()
+	})
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
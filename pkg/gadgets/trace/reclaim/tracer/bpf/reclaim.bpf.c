@@ -0,0 +1,164 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "reclaim.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+#define VM_FAULT_MAJOR	0x000004
+
+const volatile __u64 min_latency_ns = 0;
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event *unusedevent __attribute__((unused));
+
+struct start_key {
+	__u32 tid;
+	enum reclaim_op op;
+};
+
+struct start_t {
+	__u64 ts;
+	__u64 swapout;
+};
+
+// A direct reclaim and a memcg reclaim can be in progress at the same time in
+// the same thread, they are indexed by thread and operation
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, struct start_key);
+	__type(value, struct start_t);
+} starts SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, sizeof(__u32));
+} events SEC(".maps");
+
+static __always_inline int trace_entry(enum reclaim_op op)
+{
+	struct start_key key = {};
+	struct start_t start = {};
+
+	if (gadget_should_discard_mntns_id(gadget_get_mntns_id()))
+		return 0;
+
+	key.tid = (__u32)bpf_get_current_pid_tgid();
+	key.op = op;
+	start.ts = bpf_ktime_get_ns();
+	bpf_map_update_elem(&starts, &key, &start, BPF_ANY);
+	return 0;
+}
+
+static __always_inline int trace_exit(void *ctx, enum reclaim_op op, __u64 reclaimed)
+{
+	__u64 pid_tgid = bpf_get_current_pid_tgid();
+	struct start_key key = {};
+	struct event event = {};
+	struct start_t *start;
+	__u64 ts = bpf_ktime_get_ns();
+
+	key.tid = (__u32)pid_tgid;
+	key.op = op;
+	start = bpf_map_lookup_elem(&starts, &key);
+	if (!start)
+		return 0;
+
+	event.latency = ts - start->ts;
+	if (event.latency < min_latency_ns)
+		goto cleanup;
+
+	event.mntns_id = gadget_get_mntns_id();
+	event.timestamp = bpf_ktime_get_boot_ns();
+	event.pid = pid_tgid >> 32;
+	event.tid = (__u32)pid_tgid;
+	event.reclaimed = reclaimed;
+	event.swapout = start->swapout;
+	event.op = op;
+	bpf_get_current_comm(&event.task, sizeof(event.task));
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+
+cleanup:
+	bpf_map_delete_elem(&starts, &key);
+	return 0;
+}
+
+// Reclaim done by the allocator when the memory of the node is low
+SEC("raw_tp/mm_vmscan_direct_reclaim_begin")
+int ig_rc_direct_e(void *ctx)
+{
+	return trace_entry(RECLAIM_OP_DIRECT);
+}
+
+SEC("raw_tp/mm_vmscan_direct_reclaim_end")
+int BPF_PROG(ig_rc_direct_x, unsigned long nr_reclaimed)
+{
+	return trace_exit(ctx, RECLAIM_OP_DIRECT, nr_reclaimed);
+}
+
+// Reclaim done when a cgroup reaches its memory limit
+SEC("raw_tp/mm_vmscan_memcg_reclaim_begin")
+int ig_rc_memcg_e(void *ctx)
+{
+	return trace_entry(RECLAIM_OP_MEMCG);
+}
+
+SEC("raw_tp/mm_vmscan_memcg_reclaim_end")
+int BPF_PROG(ig_rc_memcg_x, unsigned long nr_reclaimed)
+{
+	return trace_exit(ctx, RECLAIM_OP_MEMCG, nr_reclaimed);
+}
+
+// Attached to the function writing pages to swap of the running kernel, its
+// name changed over time
+SEC("kprobe/swap_writepage")
+int BPF_KPROBE(ig_rc_swapout)
+{
+	struct start_key key = {};
+	struct start_t *start;
+
+	key.tid = (__u32)bpf_get_current_pid_tgid();
+	key.op = RECLAIM_OP_MEMCG;
+	start = bpf_map_lookup_elem(&starts, &key);
+	if (!start) {
+		key.op = RECLAIM_OP_DIRECT;
+		start = bpf_map_lookup_elem(&starts, &key);
+		if (!start)
+			return 0;
+	}
+
+	start->swapout++;
+	return 0;
+}
+
+// Page fault on a page that is in swap
+SEC("kprobe/do_swap_page")
+int BPF_KPROBE(ig_rc_swapin_e)
+{
+	return trace_entry(RECLAIM_OP_SWAPIN);
+}
+
+SEC("kretprobe/do_swap_page")
+int BPF_KRETPROBE(ig_rc_swapin_x, unsigned int ret)
+{
+	struct start_key key = {};
+
+	// Only the faults that had to read the page from the swap device are
+	// reported, not the ones finding it in the swap cache
+	if (!(ret & VM_FAULT_MAJOR)) {
+		key.tid = (__u32)bpf_get_current_pid_tgid();
+		key.op = RECLAIM_OP_SWAPIN;
+		bpf_map_delete_elem(&starts, &key);
+		return 0;
+	}
+
+	return trace_exit(ctx, RECLAIM_OP_SWAPIN, 0);
+}
+
+char LICENSE[] SEC("license") = "GPL";
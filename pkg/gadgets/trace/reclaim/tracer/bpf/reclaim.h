@@ -0,0 +1,27 @@
+/* SPDX-License-Identifier: GPL-2.0 */
+#ifndef GADGET_RECLAIM_H
+#define GADGET_RECLAIM_H
+
+#define TASK_COMM_LEN	16
+
+enum reclaim_op : u8 {
+	RECLAIM_OP_DIRECT,
+	RECLAIM_OP_MEMCG,
+	RECLAIM_OP_SWAPIN,
+};
+
+struct event {
+	__u64 mntns_id;
+	__u64 timestamp;
+	__u64 latency;
+	/* Pages reclaimed, for reclaims */
+	__u64 reclaimed;
+	/* Pages or folios written to swap during the reclaim */
+	__u64 swapout;
+	__u32 pid;
+	__u32 tid;
+	enum reclaim_op op;
+	__u8 task[TASK_COMM_LEN];
+};
+
+#endif /* GADGET_RECLAIM_H */
@@ -0,0 +1,175 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build arm64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type reclaimEvent struct {
+	MntnsId   uint64
+	Timestamp uint64
+	Latency   uint64
+	Reclaimed uint64
+	Swapout   uint64
+	Pid       uint32
+	Tid       uint32
+	Op        reclaimReclaimOp
+	Task      [16]uint8
+	_         [7]byte
+}
+
+type reclaimReclaimOp uint8
+
+const (
+	reclaimReclaimOpRECLAIM_OP_DIRECT reclaimReclaimOp = 0
+	reclaimReclaimOpRECLAIM_OP_MEMCG  reclaimReclaimOp = 1
+	reclaimReclaimOpRECLAIM_OP_SWAPIN reclaimReclaimOp = 2
+)
+
+type reclaimStartKey struct {
+	Tid uint32
+	Op  reclaimReclaimOp
+	_   [3]byte
+}
+
+type reclaimStartT struct {
+	Ts      uint64
+	Swapout uint64
+}
+
+// loadReclaim returns the embedded CollectionSpec for reclaim.
+func loadReclaim() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_ReclaimBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load reclaim: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadReclaimObjects loads reclaim and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*reclaimObjects
+//	*reclaimPrograms
+//	*reclaimMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadReclaimObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadReclaim()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// reclaimSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type reclaimSpecs struct {
+	reclaimProgramSpecs
+	reclaimMapSpecs
+}
+
+// reclaimSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type reclaimProgramSpecs struct {
+	IgRcDirectE *ebpf.ProgramSpec `ebpf:"ig_rc_direct_e"`
+	IgRcDirectX *ebpf.ProgramSpec `ebpf:"ig_rc_direct_x"`
+	IgRcMemcgE  *ebpf.ProgramSpec `ebpf:"ig_rc_memcg_e"`
+	IgRcMemcgX  *ebpf.ProgramSpec `ebpf:"ig_rc_memcg_x"`
+	IgRcSwapinE *ebpf.ProgramSpec `ebpf:"ig_rc_swapin_e"`
+	IgRcSwapinX *ebpf.ProgramSpec `ebpf:"ig_rc_swapin_x"`
+	IgRcSwapout *ebpf.ProgramSpec `ebpf:"ig_rc_swapout"`
+}
+
+// reclaimMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type reclaimMapSpecs struct {
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Starts               *ebpf.MapSpec `ebpf:"starts"`
+}
+
+// reclaimObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadReclaimObjects or ebpf.CollectionSpec.LoadAndAssign.
+type reclaimObjects struct {
+	reclaimPrograms
+	reclaimMaps
+}
+
+func (o *reclaimObjects) Close() error {
+	return _ReclaimClose(
+		&o.reclaimPrograms,
+		&o.reclaimMaps,
+	)
+}
+
+// reclaimMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadReclaimObjects or ebpf.CollectionSpec.LoadAndAssign.
+type reclaimMaps struct {
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Starts               *ebpf.Map `ebpf:"starts"`
+}
+
+func (m *reclaimMaps) Close() error {
+	return _ReclaimClose(
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.Starts,
+	)
+}
+
+// reclaimPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadReclaimObjects or ebpf.CollectionSpec.LoadAndAssign.
+type reclaimPrograms struct {
+	IgRcDirectE *ebpf.Program `ebpf:"ig_rc_direct_e"`
+	IgRcDirectX *ebpf.Program `ebpf:"ig_rc_direct_x"`
+	IgRcMemcgE  *ebpf.Program `ebpf:"ig_rc_memcg_e"`
+	IgRcMemcgX  *ebpf.Program `ebpf:"ig_rc_memcg_x"`
+	IgRcSwapinE *ebpf.Program `ebpf:"ig_rc_swapin_e"`
+	IgRcSwapinX *ebpf.Program `ebpf:"ig_rc_swapin_x"`
+	IgRcSwapout *ebpf.Program `ebpf:"ig_rc_swapout"`
+}
+
+func (p *reclaimPrograms) Close() error {
+	return _ReclaimClose(
+		p.IgRcDirectE,
+		p.IgRcDirectX,
+		p.IgRcMemcgE,
+		p.IgRcMemcgX,
+		p.IgRcSwapinE,
+		p.IgRcSwapinX,
+		p.IgRcSwapout,
+	)
+}
+
+func _ReclaimClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed reclaim_bpfel_arm64.o
+var _ReclaimBytes []byte
@@ -0,0 +1,60 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperationDirect = "direct"
+	OperationMemcg  = "memcg"
+	OperationSwapIn = "swapin"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Pid       uint32        `json:"pid,omitempty" column:"pid,template:pid"`
+	Tid       uint32        `json:"tid,omitempty" column:"tid,template:pid,hide"`
+	Comm      string        `json:"comm,omitempty" column:"comm,template:comm"`
+	Operation string        `json:"operation,omitempty" column:"op,width:6,fixed" columnDesc:"direct for a reclaim because the node is low on memory, memcg for a reclaim because the cgroup reached its limit, swapin for a page fault reading a page from swap"`
+	Latency   time.Duration `json:"latency,omitempty" column:"latency,width:12,align:right"`
+	// Reclaimed is the number of pages reclaimed, for reclaims
+	Reclaimed uint64 `json:"reclaimed,omitempty" column:"reclaimed,width:9,align:right"`
+	// SwapOut is the number of pages written to swap during the reclaim,
+	// large folios are counted once
+	SwapOut uint64 `json:"swapout,omitempty" column:"swapout,width:7,align:right"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	cols.MustSetExtractor("latency", func(event *Event) string {
+		return event.Latency.String()
+	})
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
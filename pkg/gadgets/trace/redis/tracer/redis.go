@@ -0,0 +1,513 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/tcpstream"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/redis/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	// Lines longer than that aren't buffered, the connection isn't traced
+	// anymore
+	maxLineLen = 64 << 10
+	// Maximum number of commands waiting for their reply on a connection
+	maxPendingCommands = 1024
+	// Number of arguments of the commands that are kept, enough to find
+	// the first key of the commands
+	maxArgs = 4
+	// Maximum length of the arguments and of the error messages that are
+	// kept
+	maxArgLen = 128
+	// Maximum number of elements of an aggregate
+	maxElements = 1 << 30
+)
+
+var (
+	errInvalid         = errors.New("invalid RESP data")
+	errTooManyCommands = errors.New("too many commands waiting for their reply")
+	// errStreaming is returned once the server started sending messages
+	// that aren't replies
+	errStreaming = errors.New("connection streaming messages")
+)
+
+// Names of the RESP types: https://redis.io/docs/reference/protocol-spec/
+var replyTypes = map[byte]string{
+	'+': "status",
+	'-': "error",
+	':': "integer",
+	'$': "bulk",
+	'*': "array",
+	'_': "null",
+	',': "double",
+	'#': "boolean",
+	'(': "bignum",
+	'!': "error",
+	'=': "verbatim",
+	'%': "map",
+	'~': "set",
+	'|': "attribute",
+	'>': "push",
+}
+
+// Commands whose first argument isn't a key
+var keylessCommands = map[string]struct{}{
+	"ACL": {}, "ASKING": {}, "AUTH": {}, "BGREWRITEAOF": {}, "BGSAVE": {},
+	"CLIENT": {}, "CLUSTER": {}, "COMMAND": {}, "CONFIG": {}, "DBSIZE": {},
+	"DEBUG": {}, "DISCARD": {}, "ECHO": {}, "EXEC": {}, "FAILOVER": {},
+	"FLUSHALL": {}, "FLUSHDB": {}, "FUNCTION": {}, "HELLO": {}, "INFO": {},
+	"KEYS": {}, "LASTSAVE": {}, "LATENCY": {}, "LOLWUT": {}, "MODULE": {},
+	"MONITOR": {}, "MULTI": {}, "PING": {}, "PSUBSCRIBE": {}, "PSYNC": {},
+	"PUBLISH": {}, "PUBSUB": {}, "PUNSUBSCRIBE": {}, "QUIT": {},
+	"RANDOMKEY": {}, "READONLY": {}, "READWRITE": {}, "REPLICAOF": {},
+	"RESET": {}, "ROLE": {}, "SAVE": {}, "SCAN": {}, "SCRIPT": {},
+	"SELECT": {}, "SHUTDOWN": {}, "SLAVEOF": {}, "SLOWLOG": {},
+	"SPUBLISH": {}, "SSUBSCRIBE": {}, "SUBSCRIBE": {}, "SUNSUBSCRIBE": {},
+	"SWAPDB": {}, "SYNC": {}, "TIME": {}, "UNSUBSCRIBE": {}, "UNWATCH": {},
+	"WAIT": {}, "WAITAOF": {},
+}
+
+// Commands having subcommands, the subcommand is reported with the command
+var containerCommands = map[string]struct{}{
+	"ACL": {}, "CLIENT": {}, "CLUSTER": {}, "COMMAND": {}, "CONFIG": {},
+	"FUNCTION": {}, "LATENCY": {}, "MEMORY": {}, "MODULE": {}, "OBJECT": {},
+	"PUBSUB": {}, "SCRIPT": {}, "SLOWLOG": {}, "XGROUP": {}, "XINFO": {},
+}
+
+// Commands after which the server sends messages that aren't replies to
+// commands, at least with RESP2: the connection isn't traced anymore
+var streamingCommands = map[string]struct{}{
+	"MONITOR": {}, "PSUBSCRIBE": {}, "SSUBSCRIBE": {}, "SUBSCRIBE": {},
+}
+
+// value is a RESP value: a command or a reply
+type value struct {
+	typ byte
+	// line is the content of the first line of simple values and errors
+	line string
+	// args are the beginning of the first elements of the commands
+	args  []string
+	count int64
+	size  uint64
+
+	// segment the value started in
+	timestamp eventtypes.Time
+	bootTime  uint64
+	process   tcpstream.Process
+	outgoing  bool
+}
+
+// respReader decodes a stream of RESP values split over several segments
+type respReader struct {
+	// keepArgs tells whether the elements of the arrays are kept, for the
+	// commands sent by the clients
+	keepArgs bool
+
+	// Beginning of a line whose end wasn't received yet
+	line []byte
+	// Bytes of a bulk string that weren't received yet, with its CRLF, and
+	// how many of them are kept in data
+	skip int64
+	keep int64
+	data []byte
+	// Number of elements left in the aggregates being read
+	stack []int64
+
+	started bool
+	current value
+}
+
+// feed decodes the payload and returns the values it completed
+func (r *respReader) feed(seg *tcpstream.Segment, payload []byte) ([]*value, error) {
+	var values []*value
+	for len(payload) > 0 {
+		if r.skip > 0 {
+			n := int64(len(payload))
+			if n > r.skip {
+				n = r.skip
+			}
+			if r.keep > 0 {
+				k := n
+				if k > r.keep {
+					k = r.keep
+				}
+				r.data = append(r.data, payload[:k]...)
+				r.keep -= k
+			}
+			r.skip -= n
+			r.current.size += uint64(n)
+			payload = payload[n:]
+			if r.skip == 0 {
+				if v := r.endBulk(); v != nil {
+					values = append(values, v)
+				}
+			}
+			continue
+		}
+
+		i := bytes.IndexByte(payload, '\n')
+		if i < 0 {
+			if len(r.line)+len(payload) > maxLineLen {
+				return values, fmt.Errorf("line too long")
+			}
+			r.line = append(r.line, payload...)
+			break
+		}
+		line := payload[:i+1]
+		if len(r.line) > 0 {
+			line = append(r.line, line...)
+			r.line = nil
+		}
+		payload = payload[i+1:]
+
+		v, err := r.parseLine(seg, line)
+		if err != nil {
+			return values, err
+		}
+		if v != nil {
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+// lost skips n bytes that weren't captured, it's only possible in the middle
+// of a bulk string. It returns the value completed by the bulk string, if
+// any.
+func (r *respReader) lost(n int64) (*value, bool) {
+	if len(r.line) > 0 || r.skip < n {
+		return nil, false
+	}
+	r.skip -= n
+	r.keep -= n
+	if r.keep < 0 {
+		r.keep = 0
+	}
+	r.current.size += uint64(n)
+	if r.skip == 0 {
+		return r.endBulk(), true
+	}
+	return nil, true
+}
+
+func (r *respReader) parseLine(seg *tcpstream.Segment, line []byte) (*value, error) {
+	size := uint64(len(line))
+	line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+
+	if !r.started {
+		if len(line) == 0 {
+			// Empty inline commands are ignored by the servers
+			return nil, nil
+		}
+		r.started = true
+		r.current = value{
+			typ:       line[0],
+			timestamp: seg.Timestamp,
+			bootTime:  seg.BootTime,
+			process:   seg.Process,
+			outgoing:  seg.Outgoing,
+		}
+	}
+	r.current.size += size
+	topLevel := len(r.stack) == 0
+
+	if len(line) == 0 {
+		return nil, errInvalid
+	}
+
+	switch typ := line[0]; typ {
+	case '+', '-', ':', ',', '(', '#', '_':
+		if topLevel {
+			r.current.line = truncate(string(line[1:]), maxArgLen)
+		}
+		return r.element(), nil
+	case '$', '!', '=':
+		n, err := strconv.ParseInt(string(line[1:]), 10, 64)
+		if err != nil || n < -1 {
+			return nil, errInvalid
+		}
+		if n == -1 {
+			// Null bulk string of RESP2
+			if topLevel {
+				r.current.typ = '_'
+			}
+			return r.element(), nil
+		}
+		r.skip = n + 2
+		r.keep = 0
+		r.data = r.data[:0]
+		if (topLevel && typ == '!') || r.keepArg() {
+			r.keep = n
+			if r.keep > maxArgLen {
+				r.keep = maxArgLen
+			}
+		}
+		return nil, nil
+	case '*', '~', '>', '%', '|':
+		n, err := strconv.ParseInt(string(line[1:]), 10, 64)
+		if err != nil || n < -1 || n > maxElements {
+			return nil, errInvalid
+		}
+		switch typ {
+		case '%':
+			n *= 2
+		case '|':
+			// The attributes are followed by the value they describe
+			n = 2*n + 1
+		}
+		if topLevel {
+			r.current.count = n
+			if n == -1 {
+				// Null array of RESP2
+				r.current.typ = '_'
+			}
+		}
+		if n <= 0 {
+			return r.element(), nil
+		}
+		r.stack = append(r.stack, n)
+		return nil, nil
+	default:
+		// Inline commands, as sent by telnet
+		if !topLevel || !r.keepArgs {
+			return nil, errInvalid
+		}
+		args := strings.Fields(string(line))
+		r.current.typ = 'i'
+		r.current.count = int64(len(args))
+		for i := 0; i < len(args) && i < maxArgs; i++ {
+			r.current.args = append(r.current.args, truncate(args[i], maxArgLen))
+		}
+		return r.element(), nil
+	}
+}
+
+// keepArg tells whether the bulk string starting is one of the first
+// arguments of a command
+func (r *respReader) keepArg() bool {
+	return r.keepArgs && len(r.stack) == 1 && r.current.count-r.stack[0] < maxArgs
+}
+
+func (r *respReader) endBulk() *value {
+	if len(r.stack) == 0 {
+		if r.current.typ == '!' {
+			r.current.line = string(r.data)
+		}
+	} else if r.keepArg() {
+		r.current.args = append(r.current.args, string(r.data))
+	}
+	return r.element()
+}
+
+// element accounts for the end of an element, it returns the value if it's
+// complete
+func (r *respReader) element() *value {
+	for len(r.stack) > 0 {
+		top := len(r.stack) - 1
+		r.stack[top]--
+		if r.stack[top] > 0 {
+			return nil
+		}
+		r.stack = r.stack[:top]
+	}
+
+	v := r.current
+	r.started = false
+	r.current = value{}
+	return &v
+}
+
+// respProtocol decodes the Redis commands of the connections
+type respProtocol struct {
+	keyPrefixLen int
+}
+
+func newRESPProtocol(keyPrefixLen int) *respProtocol {
+	return &respProtocol{keyPrefixLen: keyPrefixLen}
+}
+
+// NewDecoder traces the connections established before the gadget started
+// from the first command that starts at the beginning of a segment, when the
+// replies of the previous commands were received. It's the case of most of
+// the commands, which are small and not pipelined.
+func (p *respProtocol) NewDecoder(seg *tcpstream.Segment) tcpstream.Decoder[types.Event] {
+	if !seg.FromClient || len(seg.Payload) == 0 || seg.Payload[0] != '*' {
+		return nil
+	}
+	c := &conn{key: seg.Key, keyPrefixLen: p.keyPrefixLen}
+	c.readers[0].keepArgs = true
+	return c
+}
+
+func (p *respProtocol) DropNetns(netns uint64) {}
+
+// conn decodes the commands of a connection and their replies
+type conn struct {
+	key          tcpstream.ConnKey
+	keyPrefixLen int
+	// 0: from the client, 1: from the server
+	readers  [2]respReader
+	commands []*value
+}
+
+// Sync reads the replies from the first segment received after a command was
+// sent
+func (c *conn) Sync(seg *tcpstream.Segment) bool {
+	return len(seg.Payload) > 0 && (seg.FromClient || len(c.commands) > 0)
+}
+
+func (c *conn) Decode(seg *tcpstream.Segment, payload []byte, missing uint32) ([]*types.Event, error) {
+	r := &c.readers[0]
+	if !seg.FromClient {
+		r = &c.readers[1]
+	}
+
+	values, err := r.feed(seg, payload)
+	if err == nil && missing > 0 {
+		// The end of the packet wasn't captured: it's fine as long as
+		// it's the content of a bulk string
+		v, ok := r.lost(int64(missing))
+		if !ok {
+			err = errInvalid
+		} else if v != nil {
+			values = append(values, v)
+		}
+	}
+
+	var events []*types.Event
+	for _, v := range values {
+		if seg.FromClient {
+			if len(c.commands) >= maxPendingCommands {
+				return events, errTooManyCommands
+			}
+			c.commands = append(c.commands, v)
+			continue
+		}
+		event, replyErr := c.reply(v, seg)
+		if event != nil {
+			events = append(events, event)
+		}
+		if replyErr != nil {
+			return events, replyErr
+		}
+	}
+	return events, err
+}
+
+func (c *conn) reply(v *value, seg *tcpstream.Segment) (*types.Event, error) {
+	// The push messages of RESP3 aren't replies to commands
+	if v.typ == '>' || len(c.commands) == 0 {
+		return nil, nil
+	}
+	cmd := c.commands[0]
+	c.commands = c.commands[1:]
+
+	event := &types.Event{
+		Event: eventtypes.Event{
+			Type:      eventtypes.NORMAL,
+			Timestamp: cmd.timestamp,
+		},
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: cmd.process.MountNsID},
+		WithNetNsID:   eventtypes.WithNetNsID{NetNsID: c.key.Netns},
+		Pid:           cmd.process.Pid,
+		Tid:           cmd.process.Tid,
+		Comm:          cmd.process.Comm,
+		Role:          types.RoleServer,
+		ClientIP:      c.key.ClientIP,
+		ServerIP:      c.key.ServerIP,
+		ClientPort:    c.key.ClientPort,
+		ServerPort:    c.key.ServerPort,
+		Args:          uint32(cmd.count),
+		Reply:         replyTypes[v.typ],
+		ReplySize:     v.size,
+	}
+	if cmd.outgoing {
+		event.Role = types.RoleClient
+	}
+	if seg.BootTime > cmd.bootTime {
+		event.Latency = time.Duration(seg.BootTime - cmd.bootTime)
+	}
+	if v.typ == '-' || v.typ == '!' {
+		event.Error = v.line
+	}
+
+	var key string
+	event.Command, key = commandAndKey(cmd.args)
+	if c.keyPrefixLen > 0 {
+		event.KeyPrefix = truncate(key, c.keyPrefixLen)
+	}
+
+	if _, ok := streamingCommands[event.Command]; ok {
+		return event, errStreaming
+	}
+
+	return event, nil
+}
+
+// commandAndKey returns the name of a command and its first key, if any
+func commandAndKey(args []string) (string, string) {
+	if len(args) == 0 {
+		return "", ""
+	}
+	command := strings.ToUpper(args[0])
+	keyIndex := 1
+
+	if _, ok := containerCommands[command]; ok && len(args) > 1 {
+		command += " " + strings.ToUpper(args[1])
+		keyIndex = 2
+	}
+
+	switch command {
+	case "EVAL", "EVALSHA", "EVAL_RO", "EVALSHA_RO", "FCALL", "FCALL_RO":
+		// EVAL script numkeys [key...]
+		if len(args) < 4 || args[2] == "0" {
+			return command, ""
+		}
+		keyIndex = 3
+	case "BITOP":
+		keyIndex = 2
+	case "MEMORY USAGE", "OBJECT ENCODING", "OBJECT FREQ", "OBJECT IDLETIME",
+		"OBJECT REFCOUNT", "XINFO STREAM", "XINFO GROUPS", "XINFO CONSUMERS",
+		"XGROUP CREATE", "XGROUP DESTROY", "XGROUP SETID", "XGROUP CREATECONSUMER",
+		"XGROUP DELCONSUMER":
+	default:
+		if _, ok := keylessCommands[strings.Fields(command)[0]]; ok {
+			return command, ""
+		}
+		if _, ok := containerCommands[strings.Fields(command)[0]]; ok {
+			return command, ""
+		}
+	}
+
+	if keyIndex >= len(args) {
+		return command, ""
+	}
+	return command, args[keyIndex]
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
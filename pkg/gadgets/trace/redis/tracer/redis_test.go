@@ -0,0 +1,152 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/tcpstream"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/tcpstream/testutils"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/redis/types"
+)
+
+func command(args ...string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return b.String()
+}
+
+func TestParseRESP(t *testing.T) {
+	p := tcpstream.NewConns[types.Event](newRESPProtocol(8))
+	client, server := testutils.NewPeers(6379, "client")
+
+	// Replies received before the first command are ignored
+	testutils.ExpectEvents(t, p.Process(server.Segment([]byte("+OK\r\n"), 500)), 0)
+
+	testutils.ExpectEvents(t, p.Process(client.Segment([]byte(command("set", "user:1234:profile", "alice")), 1000)), 0)
+	events := p.Process(server.Segment([]byte("+OK\r\n"), 3000))
+	testutils.ExpectEvents(t, events, 1)
+	event := events[0]
+	if event.Command != "SET" || event.KeyPrefix != "user:123" || event.Args != 3 {
+		t.Fatalf("Invalid command %+v", event)
+	}
+	if event.Reply != "status" || event.ReplySize != 5 || event.Latency != 2000*time.Nanosecond {
+		t.Fatalf("Invalid reply %+v", event)
+	}
+	if event.Role != types.RoleClient || event.Pid != 1234 {
+		t.Fatalf("Invalid event %+v", event)
+	}
+
+	// Pipelined commands, with replies split over several segments
+	pipeline := command("GET", "a") + command("HGETALL", "h") + command("INCR", "s") + command("CLIENT", "SETNAME", "app")
+	testutils.ExpectEvents(t, p.Process(client.Segment([]byte(pipeline), 4000)), 0)
+	testutils.ExpectEvents(t, p.Process(server.Segment([]byte("$5\r\nhel"), 5000)), 0)
+	events = p.Process(server.Segment([]byte("lo\r\n*4\r\n$1\r\nk\r\n$1\r\nv"), 6000))
+	testutils.ExpectEvents(t, events, 1)
+	if events[0].Command != "GET" || events[0].Reply != "bulk" || events[0].ReplySize != 11 {
+		t.Fatalf("Invalid event %+v", events[0])
+	}
+	events = p.Process(server.Segment([]byte("\r\n$1\r\nl\r\n$-1\r\n-WRONGTYPE Operation against a key holding the wrong kind of value\r\n+OK\r\n"), 7000))
+	testutils.ExpectEvents(t, events, 3)
+	if events[0].Command != "HGETALL" || events[0].Reply != "array" {
+		t.Fatalf("Invalid event %+v", events[0])
+	}
+	if events[1].Command != "INCR" || events[1].Reply != "error" || !strings.HasPrefix(events[1].Error, "WRONGTYPE") {
+		t.Fatalf("Invalid event %+v", events[1])
+	}
+	if events[2].Command != "CLIENT SETNAME" || events[2].KeyPrefix != "" {
+		t.Fatalf("Invalid event %+v", events[2])
+	}
+
+	// Retransmission of the request, RESP3 reply with a push message
+	seg := client.Segment([]byte(command("EVAL", "return 1", "1", "lock:x")), 8000)
+	testutils.ExpectEvents(t, p.Process(seg), 0)
+	testutils.ExpectEvents(t, p.Process(seg), 0)
+	events = p.Process(server.Segment([]byte(">3\r\n$7\r\nmessage\r\n$2\r\nch\r\n$2\r\nhi\r\n%1\r\n+a\r\n:1\r\n"), 9000))
+	testutils.ExpectEvents(t, events, 1)
+	if events[0].Command != "EVAL" || events[0].KeyPrefix != "lock:x" || events[0].Reply != "map" {
+		t.Fatalf("Invalid event %+v", events[0])
+	}
+
+	// The connection isn't traced anymore once subscribed
+	testutils.ExpectEvents(t, p.Process(client.Segment([]byte(command("SUBSCRIBE", "news")), 10000)), 0)
+	testutils.ExpectEvents(t, p.Process(server.Segment([]byte("*3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n"), 11000)), 1)
+	testutils.ExpectEvents(t, p.Process(server.Segment([]byte("*3\r\n$7\r\nmessage\r\n$4\r\nnews\r\n$2\r\nhi\r\n"), 12000)), 0)
+}
+
+func TestParseRESPLostSegment(t *testing.T) {
+	p := tcpstream.NewConns[types.Event](newRESPProtocol(32))
+	client, server := testutils.NewPeers(6379, "client")
+
+	// The end of a big value wasn't captured
+	value := strings.Repeat("x", 3000)
+	seg := client.Segment([]byte(command("SET", "big", value)), 1000)
+	seg.Payload = seg.Payload[:100]
+	testutils.ExpectEvents(t, p.Process(seg), 0)
+	events := p.Process(server.Segment([]byte("+OK\r\n"), 2000))
+	testutils.ExpectEvents(t, events, 1)
+	if events[0].Command != "SET" || events[0].KeyPrefix != "big" {
+		t.Fatalf("Invalid event %+v", events[0])
+	}
+
+	// Inline commands
+	testutils.ExpectEvents(t, p.Process(client.Segment([]byte("PING\r\n"), 3000)), 0)
+	events = p.Process(server.Segment([]byte("+PONG\r\n"), 3500))
+	testutils.ExpectEvents(t, events, 1)
+	if events[0].Command != "PING" {
+		t.Fatalf("Invalid event %+v", events[0])
+	}
+
+	// A segment was lost: the connection isn't traced anymore
+	client.Segment([]byte(command("GET", "a")), 4000)
+	testutils.ExpectEvents(t, p.Process(client.Segment([]byte(command("GET", "b")), 4000)), 0)
+	testutils.ExpectEvents(t, p.Process(server.Segment([]byte("$1\r\nb\r\n"), 5000)), 0)
+
+	// The connection is traced again after being closed
+	seg = client.Segment([]byte(""), 6000)
+	seg.FIN = true
+	testutils.ExpectEvents(t, p.Process(seg), 0)
+	testutils.ExpectEvents(t, p.Process(client.Segment([]byte(command("GET", "c")), 7000)), 0)
+	testutils.ExpectEvents(t, p.Process(server.Segment([]byte("$1\r\nc\r\n"), 8000)), 1)
+}
+
+func TestCommandAndKey(t *testing.T) {
+	tests := []struct {
+		args    []string
+		command string
+		key     string
+	}{
+		{[]string{"get", "k"}, "GET", "k"},
+		{[]string{"PING"}, "PING", ""},
+		{[]string{"EVALSHA", "abcd", "0"}, "EVALSHA", ""},
+		{[]string{"BITOP", "AND", "dest", "src"}, "BITOP", "dest"},
+		{[]string{"memory", "usage", "k"}, "MEMORY USAGE", "k"},
+		{[]string{"CONFIG", "GET", "maxmemory"}, "CONFIG GET", ""},
+		{[]string{"XINFO", "STREAM", "events"}, "XINFO STREAM", "events"},
+		{[]string{"DEL"}, "DEL", ""},
+	}
+	for _, test := range tests {
+		command, key := commandAndKey(test.args)
+		if command != test.command || key != test.key {
+			t.Errorf("commandAndKey(%q) = %q, %q, expecting %q, %q", test.args, command, key, test.command, test.key)
+		}
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/cilium/ebpf/link"
 	"github.com/cilium/ebpf/perf"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/signal/types"
@@ -207,7 +208,7 @@ func (t *Tracer) run() {
 			},
 			Pid:           bpfEvent.Pid,
 			TargetPid:     bpfEvent.Tpid,
-			Signal:        signalIntToString(int(bpfEvent.Sig)),
+			Signal:        columns.Enum{Name: signalIntToString(int(bpfEvent.Sig)), Value: int64(bpfEvent.Sig)},
 			Retval:        int(bpfEvent.Ret),
 			WithMountNsID: eventtypes.WithMountNsID{MountNsID: bpfEvent.MntnsId},
 			Comm:          gadgets.FromCString(bpfEvent.Comm[:]),
@@ -27,9 +27,9 @@ type Event struct {
 	Comm string `json:"comm,omitempty" column:"comm,template:comm"`
 	// The most common signals are SIGKILL, SIGTERM, SIGINT (6 chars) and the
 	// longest is SIGRTMIN+XX (11 chars).
-	Signal    string `json:"signal,omitempty" column:"signal,minWidth:6,maxWidth:11,ellipsis:start"`
-	TargetPid uint32 `json:"tpid,omitempty" column:"tpid,template:pid"`
-	Retval    int    `json:"ret,omitempty" column:"ret,width:3,fixed"`
+	Signal    columns.Enum `json:"signal" column:"signal,minWidth:6,maxWidth:11,ellipsis:start"`
+	TargetPid uint32       `json:"tpid,omitempty" column:"tpid,template:pid"`
+	Retval    int          `json:"ret,omitempty" column:"ret,width:3,fixed"`
 }
 
 func GetColumns() *columns.Columns[Event] {
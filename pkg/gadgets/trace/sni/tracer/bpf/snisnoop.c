@@ -24,13 +24,22 @@ struct {
 } events SEC(".maps");
 
 
+// ClientHellos waiting for the answer of the server.
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 10240);
+	__type(key, struct flow_t);
+	__type(value, __u8);
+} hellos SEC(".maps");
+
 // parse_sni() from:
 // https://github.com/gardener/connectivity-monitor/blob/4e924f50367c9fa02075b50b0ecd8c821b3a15f1/connectivity-exporter/packet/c/cap.c#L146-L149
 
 // Parses the provided SKB at the given offset for SNI information. If parsing
-// succeeds, the SNI information is written to the out array. Returns the
+// succeeds, the SNI information, the ALPN protocol name list and the TLS
+// versions offered by the client are written to the event. Returns the
 // number of characters in the SNI field or 0 if SNI couldn't be parsed.
-static __always_inline int parse_sni(struct __sk_buff *skb, int data_offset, char *out)
+static __always_inline int parse_sni(struct __sk_buff *skb, int data_offset, struct event_t *event)
 {
   // Verify TLS content type.
   __u8 content_type;
@@ -44,6 +53,12 @@ static __always_inline int parse_sni(struct __sk_buff *skb, int data_offset, cha
   if (handshake_type != TLS_HANDSHAKE_TYPE_CLIENT_HELLO)
     return 0;
 
+  // The legacy version is the only one offered by clients that don't use the
+  // supported_versions extension.
+  __u16 version_be;
+  bpf_skb_load_bytes(skb, data_offset + TLS_HELLO_VERSION_OFF, &version_be, 2);
+  event->version = bpf_ntohs(version_be);
+
   int session_id_len_off = data_offset + TLS_SESSION_ID_LENGTH_OFF;
   __u8 session_id_len;
   bpf_skb_load_bytes(skb, session_id_len_off, &session_id_len, 1);
@@ -65,18 +80,32 @@ static __always_inline int parse_sni(struct __sk_buff *skb, int data_offset, cha
       compression_methods_len_off + TLS_COMPRESSION_METHODS_LENGTH_LEN +
         compression_methods_len;
 
+  __u16 extensions_len_be = 0;
+  bpf_skb_load_bytes(skb, extensions_len_off, &extensions_len_be, 2);
+  __u16 extensions_len = bpf_ntohs(extensions_len_be);
+
   int extensions_off = extensions_len_off + TLS_EXTENSIONS_LENGTH_LEN;
 
-  // TODO: Ensure the cursor doesn't surpass the extensions length value?
   __u16 cur = 0;
   __u16 server_name_ext_off = 0;
+  __u16 alpn_ext_off = 0;
+  __u16 versions_ext_off = 0;
   for (int i = 0; i < TLS_MAX_EXTENSION_COUNT; i++) {
+    if (cur >= extensions_len)
+      break;
+
     __u16 curr_ext_type_be;
     bpf_skb_load_bytes(skb, extensions_off + cur, &curr_ext_type_be, 2);
-    if (bpf_ntohs(curr_ext_type_be) == TLS_EXTENSION_SERVER_NAME)
-    {
+    switch (bpf_ntohs(curr_ext_type_be)) {
+    case TLS_EXTENSION_SERVER_NAME:
       server_name_ext_off = extensions_off + cur;
       break;
+    case TLS_EXTENSION_ALPN:
+      alpn_ext_off = extensions_off + cur;
+      break;
+    case TLS_EXTENSION_SUPPORTED_VERSIONS:
+      versions_ext_off = extensions_off + cur;
+      break;
     }
     // Skip the extension type field to get to the extension length field.
     cur += TLS_EXTENSION_TYPE_LEN;
@@ -104,20 +133,120 @@ static __always_inline int parse_sni(struct __sk_buff *skb, int data_offset, cha
   // Read the server name field.
   int counter = 0;
   for (int i = 0; i < TLS_MAX_SERVER_NAME_LEN; i++) {
-    if (!out)
-      break;
     if (i >= server_name_len)
       break;
     char b;
     bpf_skb_load_bytes(skb, server_name_off + i, &b, 1);
     if (b == '\0')
       break;
-    out[i] = b;
+    event->name[i] = b;
     counter++;
   }
+
+  // Copy the protocol name list, it's decoded in user space.
+  if (alpn_ext_off != 0) {
+    __u16 alpn_len_be;
+    bpf_skb_load_bytes(skb, alpn_ext_off + TLS_ALPN_LIST_OFF - 2, &alpn_len_be, 2);
+    __u16 alpn_len = bpf_ntohs(alpn_len_be);
+    for (int i = 0; i < TLS_MAX_ALPN_LEN; i++) {
+      if (i >= alpn_len)
+        break;
+      if (bpf_skb_load_bytes(skb, alpn_ext_off + TLS_ALPN_LIST_OFF + i, &event->alpn[i], 1))
+        break;
+      event->alpn_len++;
+    }
+  }
+
+  if (versions_ext_off != 0) {
+    __u8 versions_len;
+    bpf_skb_load_bytes(skb, versions_ext_off + TLS_CLIENT_VERSIONS_OFF - 1, &versions_len, 1);
+    for (int i = 0; i < TLS_MAX_VERSIONS; i++) {
+      if (i >= versions_len / 2)
+        break;
+      __u16 v_be;
+      if (bpf_skb_load_bytes(skb, versions_ext_off + TLS_CLIENT_VERSIONS_OFF + i * 2, &v_be, 2))
+        break;
+      event->versions[i] = bpf_ntohs(v_be);
+    }
+  }
+
   return counter;
 }
 
+// Parses the answer of the server to a ClientHello. Returns the kind of
+// response or RESPONSE_NONE if the payload isn't a ServerHello or an alert.
+static __always_inline __u8 parse_response(struct __sk_buff *skb, int data_offset, struct event_t *event)
+{
+  __u8 content_type;
+  if (bpf_skb_load_bytes(skb, data_offset, &content_type, 1))
+    return RESPONSE_NONE;
+
+  if (content_type == TLS_CONTENT_TYPE_ALERT) {
+    bpf_skb_load_bytes(skb, data_offset + TLS_ALERT_DESCRIPTION_OFF, &event->alert, 1);
+    return RESPONSE_ALERT;
+  }
+  if (content_type != TLS_CONTENT_TYPE_HANDSHAKE)
+    return RESPONSE_NONE;
+
+  __u8 handshake_type;
+  bpf_skb_load_bytes(skb, data_offset + TLS_HANDSHAKE_TYPE_OFF, &handshake_type, 1);
+  if (handshake_type != TLS_HANDSHAKE_TYPE_SERVER_HELLO)
+    return RESPONSE_NONE;
+
+  __u16 version_be;
+  bpf_skb_load_bytes(skb, data_offset + TLS_HELLO_VERSION_OFF, &version_be, 2);
+  event->version = bpf_ntohs(version_be);
+
+  // A ServerHello has a single cipher suite and compression method.
+  int session_id_len_off = data_offset + TLS_SESSION_ID_LENGTH_OFF;
+  __u8 session_id_len;
+  bpf_skb_load_bytes(skb, session_id_len_off, &session_id_len, 1);
+
+  int extensions_len_off = session_id_len_off + TLS_SESSION_ID_LENGTH_LEN +
+      session_id_len + 2 + 1;
+
+  __u16 extensions_len_be = 0;
+  bpf_skb_load_bytes(skb, extensions_len_off, &extensions_len_be, 2);
+  __u16 extensions_len = bpf_ntohs(extensions_len_be);
+
+  int extensions_off = extensions_len_off + TLS_EXTENSIONS_LENGTH_LEN;
+
+  // TLS 1.3 servers select the version in the supported_versions extension.
+  __u16 cur = 0;
+  for (int i = 0; i < TLS_MAX_EXTENSION_COUNT; i++) {
+    if (cur >= extensions_len)
+      break;
+
+    __u16 curr_ext_type_be;
+    bpf_skb_load_bytes(skb, extensions_off + cur, &curr_ext_type_be, 2);
+    if (bpf_ntohs(curr_ext_type_be) == TLS_EXTENSION_SUPPORTED_VERSIONS) {
+      if (!bpf_skb_load_bytes(skb, extensions_off + cur + TLS_SERVER_VERSION_OFF, &version_be, 2))
+        event->version = bpf_ntohs(version_be);
+      break;
+    }
+    cur += TLS_EXTENSION_TYPE_LEN;
+
+    __u16 len_be;
+    bpf_skb_load_bytes(skb, extensions_off + cur, &len_be, 2);
+    cur += TLS_EXTENSION_LENGTH_LEN + bpf_ntohs(len_be);
+  }
+
+  return RESPONSE_SERVER_HELLO;
+}
+
+static __always_inline void enrich_event(struct __sk_buff *skb, struct event_t *event)
+{
+	event->timestamp = bpf_ktime_get_boot_ns();
+
+	// Enrich event with process metadata
+	struct sockets_value *skb_val = gadget_socket_lookup(skb);
+	if (skb_val != NULL) {
+		event->mount_ns_id = skb_val->mntns;
+		event->pid = skb_val->pid_tgid >> 32;
+		event->tid = (__u32)skb_val->pid_tgid;
+		__builtin_memcpy(&event->task,  skb_val->task, sizeof(event->task));
+	}
+}
 
 SEC("socket1")
 int ig_trace_sni(struct __sk_buff *skb)
@@ -150,38 +279,54 @@ int ig_trace_sni(struct __sk_buff *skb)
 	if (bpf_skb_load_bytes(skb, tcp_off, &tcph, sizeof tcph))
 		return 0;
 
-	if (!tcph.psh)
-		return 0;
-
 	// The data offset field in the header is specified in 32-bit words. We
 	// have to multiply this value by 4 to get the TCP header length in bytes.
 	__u8 tcp_header_len = tcph.doff * 4;
 	// TLS data starts at this offset.
 	int payload_off = tcp_off + tcp_header_len;
 
+	struct event_t event = {0,};
+
+	// Is the server answering to a ClientHello?
+	struct flow_t reply = {
+		.saddr = iph.daddr,
+		.daddr = iph.saddr,
+		.sport = bpf_ntohs(tcph.dest),
+		.dport = bpf_ntohs(tcph.source),
+	};
+	if (bpf_map_lookup_elem(&hellos, &reply)) {
+		if (tcph.rst)
+			event.response = RESPONSE_RESET;
+		else if (payload_off < skb->len)
+			event.response = parse_response(skb, payload_off, &event);
+		if (event.response == RESPONSE_NONE && tcph.fin)
+			event.response = RESPONSE_CLOSED;
+		if (event.response == RESPONSE_NONE)
+			return 0;
+
+		bpf_map_delete_elem(&hellos, &reply);
+		event.flow = reply;
+		enrich_event(skb, &event);
+		bpf_perf_event_output(skb, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+		return 0;
+	}
+
+	if (!tcph.psh)
+		return 0;
+
 	// Parse SNI.
-	char sni[TLS_MAX_SERVER_NAME_LEN] = {};
-	int read = parse_sni(skb, payload_off, sni);
-	if (read == 0)
+	if (parse_sni(skb, payload_off, &event) == 0)
 		return 0;
 
-	struct event_t event = {0,};
-	for (int i = 0; i < TLS_MAX_SERVER_NAME_LEN; i++) {
-		if (sni[i] == '\0')
-			break;
-		event.name[i] = sni[i];
-	}
-	event.timestamp = bpf_ktime_get_boot_ns();
+	event.flow.saddr = iph.saddr;
+	event.flow.daddr = iph.daddr;
+	event.flow.sport = bpf_ntohs(tcph.source);
+	event.flow.dport = bpf_ntohs(tcph.dest);
 
-	// Enrich event with process metadata
-	struct sockets_value *skb_val = gadget_socket_lookup(skb);
-	if (skb_val != NULL) {
-		event.mount_ns_id = skb_val->mntns;
-		event.pid = skb_val->pid_tgid >> 32;
-		event.tid = (__u32)skb_val->pid_tgid;
-		__builtin_memcpy(&event.task,  skb_val->task, sizeof(event.task));
-	}
+	__u8 zero = 0;
+	bpf_map_update_elem(&hellos, &event.flow, &zero, BPF_ANY);
 
+	enrich_event(skb, &event);
 	bpf_perf_event_output(skb, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
 
 	return 0;
@@ -1,13 +1,21 @@
 #ifndef GADGET_SNISNOOP_H
 #define GADGET_SNISNOOP_H
 
+#define TLS_CONTENT_TYPE_ALERT 0x15
 #define TLS_CONTENT_TYPE_HANDSHAKE 0x16
 #define TLS_HANDSHAKE_TYPE_CLIENT_HELLO 0x1
+#define TLS_HANDSHAKE_TYPE_SERVER_HELLO 0x2
 #define TLS_EXTENSION_SERVER_NAME 0x0
+#define TLS_EXTENSION_ALPN 0x10
+#define TLS_EXTENSION_SUPPORTED_VERSIONS 0x2b
 // TODO: Figure out real max number according to RFC.
 #define TLS_MAX_EXTENSION_COUNT 20
 // TODO: figure out the right value.
 #define TLS_MAX_SERVER_NAME_LEN 128
+// The ALPN protocol name list is copied as is, long lists are truncated.
+#define TLS_MAX_ALPN_LEN 64
+// Maximum number of versions read from the supported_versions extension.
+#define TLS_MAX_VERSIONS 8
 
 // The length of the session ID length field.
 #define TLS_SESSION_ID_LENGTH_LEN 1
@@ -29,13 +37,44 @@
 // extension.
 #define TLS_SERVER_NAME_OFF 9
 
+// The offset of the supported versions list within the supported_versions
+// extension of a ClientHello.
+#define TLS_CLIENT_VERSIONS_OFF 5
+// The offset of the selected version within the supported_versions extension
+// of a ServerHello.
+#define TLS_SERVER_VERSION_OFF 4
+// The offset of the protocol name list within the ALPN extension.
+#define TLS_ALPN_LIST_OFF 6
+
+// The offset of the alert description from the start of the TLS payload.
+#define TLS_ALERT_DESCRIPTION_OFF 6
 // The offset of the handshake type field from the start of the TLS payload.
 #define TLS_HANDSHAKE_TYPE_OFF 5
+// The offset of the (legacy) version field from the start of the TLS payload.
+#define TLS_HELLO_VERSION_OFF 9
 // The offset of the session ID length field from the start of the TLS payload.
 #define TLS_SESSION_ID_LENGTH_OFF 43
 
 #define TASK_COMM_LEN	16
 
+// What the server answered to a ClientHello. RESPONSE_NONE is used by the
+// events reporting the ClientHello itself.
+enum response {
+	RESPONSE_NONE,
+	RESPONSE_SERVER_HELLO,
+	RESPONSE_ALERT,
+	RESPONSE_RESET,
+	RESPONSE_CLOSED,
+};
+
+// A TCP connection, seen from the client.
+struct flow_t {
+	__u32 saddr;
+	__u32 daddr;
+	__u16 sport;
+	__u16 dport;
+};
+
 struct event_t {
 	__u64 mount_ns_id;
 	__u32 pid;
@@ -43,6 +82,14 @@ struct event_t {
 	__u8 task[TASK_COMM_LEN];
 	__u8 name[TLS_MAX_SERVER_NAME_LEN];
 	__u64 timestamp;
+	struct flow_t flow;
+	// Legacy version of the ClientHello or version selected by the server.
+	__u16 version;
+	__u16 versions[TLS_MAX_VERSIONS];
+	__u16 alpn_len;
+	__u8 alpn[TLS_MAX_ALPN_LEN];
+	__u8 response;
+	__u8 alert;
 };
 
 #endif
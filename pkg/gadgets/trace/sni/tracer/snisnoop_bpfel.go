@@ -19,6 +19,21 @@ type snisnoopEventT struct {
 	Task      [16]uint8
 	Name      [128]uint8
 	Timestamp uint64
+	Flow      snisnoopFlowT
+	Version   uint16
+	Versions  [8]uint16
+	AlpnLen   uint16
+	Alpn      [64]uint8
+	Response  uint8
+	Alert     uint8
+	_         [6]byte
+}
+
+type snisnoopFlowT struct {
+	Saddr uint32
+	Daddr uint32
+	Sport uint16
+	Dport uint16
 }
 
 type snisnoopSocketsKey struct {
@@ -86,6 +101,7 @@ type snisnoopProgramSpecs struct {
 // It can be passed ebpf.CollectionSpec.Assign.
 type snisnoopMapSpecs struct {
 	Events  *ebpf.MapSpec `ebpf:"events"`
+	Hellos  *ebpf.MapSpec `ebpf:"hellos"`
 	Sockets *ebpf.MapSpec `ebpf:"sockets"`
 }
 
@@ -109,12 +125,14 @@ func (o *snisnoopObjects) Close() error {
 // It can be passed to loadSnisnoopObjects or ebpf.CollectionSpec.LoadAndAssign.
 type snisnoopMaps struct {
 	Events  *ebpf.Map `ebpf:"events"`
+	Hellos  *ebpf.Map `ebpf:"hellos"`
 	Sockets *ebpf.Map `ebpf:"sockets"`
 }
 
 func (m *snisnoopMaps) Close() error {
 	return _SnisnoopClose(
 		m.Events,
+		m.Hellos,
 		m.Sockets,
 	)
 }
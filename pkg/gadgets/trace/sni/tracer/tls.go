@@ -0,0 +1,228 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/sni/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// How long to wait for the server to answer to a ClientHello before reporting
+// it with the timeout outcome.
+const handshakeTimeout = 10 * time.Second
+
+var tlsVersionNames = map[uint16]string{
+	0x0300: "SSL3.0",
+	0x0301: "TLS1.0",
+	0x0302: "TLS1.1",
+	0x0303: "TLS1.2",
+	0x0304: "TLS1.3",
+}
+
+// Alert descriptions: https://www.rfc-editor.org/rfc/rfc8446#section-6
+var tlsAlertNames = map[uint8]string{
+	0:   "close_notify",
+	10:  "unexpected_message",
+	20:  "bad_record_mac",
+	22:  "record_overflow",
+	40:  "handshake_failure",
+	42:  "bad_certificate",
+	43:  "unsupported_certificate",
+	44:  "certificate_revoked",
+	45:  "certificate_expired",
+	46:  "certificate_unknown",
+	47:  "illegal_parameter",
+	48:  "unknown_ca",
+	49:  "access_denied",
+	50:  "decode_error",
+	51:  "decrypt_error",
+	70:  "protocol_version",
+	71:  "insufficient_security",
+	80:  "internal_error",
+	86:  "inappropriate_fallback",
+	90:  "user_canceled",
+	109: "missing_extension",
+	110: "unsupported_extension",
+	112: "unrecognized_name",
+	113: "bad_certificate_status_response",
+	115: "unknown_psk_identity",
+	116: "certificate_required",
+	120: "no_application_protocol",
+}
+
+// isGREASE tells whether the value is one of the reserved values clients send
+// to make sure servers ignore unknown values, see RFC 8701.
+func isGREASE(v uint16) bool {
+	return v&0x0f0f == 0x0a0a && v>>8 == v&0xff
+}
+
+func tlsVersionName(v uint16) string {
+	if name, ok := tlsVersionNames[v]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%04x", v)
+}
+
+// tlsVersions returns the versions offered by the client: the ones of the
+// supported_versions extension or, without it, the legacy version of the
+// ClientHello.
+func tlsVersions(legacy uint16, supported []uint16) []string {
+	var versions []string
+	for _, v := range supported {
+		if v == 0 {
+			break
+		}
+		if isGREASE(v) {
+			continue
+		}
+		versions = append(versions, tlsVersionName(v))
+	}
+	if len(versions) == 0 && legacy != 0 {
+		versions = append(versions, tlsVersionName(legacy))
+	}
+	return versions
+}
+
+func tlsAlertOutcome(description uint8) string {
+	name, ok := tlsAlertNames[description]
+	if !ok {
+		name = fmt.Sprintf("%d", description)
+	}
+	return types.OutcomeAlert + ":" + name
+}
+
+// parseALPN decodes the protocol name list of the ALPN extension, see
+// https://www.rfc-editor.org/rfc/rfc7301#section-3.1. The list can be
+// truncated, the last incomplete name is ignored.
+func parseALPN(list []byte) []string {
+	var protocols []string
+	for len(list) > 0 {
+		l := int(list[0])
+		if l == 0 || len(list) < 1+l {
+			break
+		}
+		protocols = append(protocols, string(list[1:1+l]))
+		list = list[1+l:]
+	}
+	return protocols
+}
+
+type flowKey struct {
+	netns   uint64
+	srcIP   string
+	dstIP   string
+	srcPort uint16
+	dstPort uint16
+}
+
+func flowKeyFromEvent(event *types.Event) flowKey {
+	return flowKey{
+		netns:   event.NetNsID,
+		srcIP:   event.SrcIP,
+		dstIP:   event.DstIP,
+		srcPort: event.SrcPort,
+		dstPort: event.DstPort,
+	}
+}
+
+type pendingHello struct {
+	event    *types.Event
+	callback func(*types.Event)
+	timer    *time.Timer
+}
+
+// handshakes holds the ClientHellos until the server answers to them, to
+// report each of them once with the outcome of the handshake.
+type handshakes struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	pending map[flowKey]*pendingHello
+}
+
+func newHandshakes(timeout time.Duration) *handshakes {
+	return &handshakes{
+		timeout: timeout,
+		pending: make(map[flowKey]*pendingHello),
+	}
+}
+
+// handle processes the events of the BPF program: ClientHellos, without
+// outcome, are kept until the event with the answer of the server arrives or
+// the timeout expires. Other events are passed to the callback as is.
+func (h *handshakes) handle(event *types.Event, callback func(*types.Event)) {
+	if event.Type != eventtypes.NORMAL {
+		callback(event)
+		return
+	}
+
+	key := flowKeyFromEvent(event)
+
+	h.mu.Lock()
+
+	if event.Outcome == "" {
+		// Retransmissions of a ClientHello are reported once
+		if _, ok := h.pending[key]; !ok {
+			p := &pendingHello{event: event, callback: callback}
+			p.timer = time.AfterFunc(h.timeout, func() { h.expire(key, p) })
+			h.pending[key] = p
+		}
+		h.mu.Unlock()
+		return
+	}
+
+	p, ok := h.pending[key]
+	if ok {
+		p.timer.Stop()
+		delete(h.pending, key)
+	}
+	h.mu.Unlock()
+
+	// The ClientHello could already have timed out
+	if !ok {
+		return
+	}
+
+	p.event.Outcome = event.Outcome
+	p.event.Version = event.Version
+	p.callback(p.event)
+}
+
+func (h *handshakes) expire(key flowKey, p *pendingHello) {
+	h.mu.Lock()
+	if h.pending[key] != p {
+		h.mu.Unlock()
+		return
+	}
+	delete(h.pending, key)
+	h.mu.Unlock()
+
+	p.event.Outcome = types.OutcomeTimeout
+	p.callback(p.event)
+}
+
+// close drops the ClientHellos still waiting for an answer.
+func (h *handshakes) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for key, p := range h.pending {
+		p.timer.Stop()
+		delete(h.pending, key)
+	}
+}
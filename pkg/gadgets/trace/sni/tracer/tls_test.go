@@ -0,0 +1,112 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/sni/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+func TestParseALPN(t *testing.T) {
+	table := []struct {
+		list     []byte
+		expected []string
+	}{
+		{[]byte{2, 'h', '2', 8, 'h', 't', 't', 'p', '/', '1', '.', '1'}, []string{"h2", "http/1.1"}},
+		// Truncated by the BPF program
+		{[]byte{2, 'h', '2', 8, 'h', 't', 't'}, []string{"h2"}},
+		{nil, nil},
+	}
+	for _, entry := range table {
+		protocols := parseALPN(entry.list)
+		if !reflect.DeepEqual(protocols, entry.expected) {
+			t.Fatalf("Invalid protocols %v for %v. Expecting %v", protocols, entry.list, entry.expected)
+		}
+	}
+}
+
+func TestTLSVersions(t *testing.T) {
+	// GREASE values are ignored
+	versions := tlsVersions(0x0303, []uint16{0x7a7a, 0x0304, 0x0303, 0, 0})
+	expected := []string{"TLS1.3", "TLS1.2"}
+	if !reflect.DeepEqual(versions, expected) {
+		t.Fatalf("Invalid versions %v. Expecting %v", versions, expected)
+	}
+
+	// Without supported_versions extension
+	versions = tlsVersions(0x0301, []uint16{0, 0})
+	expected = []string{"TLS1.0"}
+	if !reflect.DeepEqual(versions, expected) {
+		t.Fatalf("Invalid versions %v. Expecting %v", versions, expected)
+	}
+
+	if outcome := tlsAlertOutcome(40); outcome != "alert:handshake_failure" {
+		t.Fatalf("Invalid outcome %q. Expecting %q", outcome, "alert:handshake_failure")
+	}
+}
+
+func newHello(srcPort uint16) *types.Event {
+	return &types.Event{
+		Event:   eventtypes.Event{Type: eventtypes.NORMAL},
+		Name:    "example.com",
+		SrcIP:   "10.0.0.2",
+		DstIP:   "93.184.216.34",
+		SrcPort: srcPort,
+		DstPort: 443,
+	}
+}
+
+func TestHandshakes(t *testing.T) {
+	h := newHandshakes(50 * time.Millisecond)
+	defer h.close()
+
+	events := make(chan *types.Event, 10)
+	callback := func(event *types.Event) { events <- event }
+
+	h.handle(newHello(40000), callback)
+	// Retransmission
+	h.handle(newHello(40000), callback)
+	h.handle(newHello(40001), callback)
+
+	response := newHello(40000)
+	response.Name = ""
+	response.Outcome = types.OutcomeOK
+	response.Version = "TLS1.3"
+	h.handle(response, callback)
+
+	event := <-events
+	if event.Name != "example.com" || event.SrcPort != 40000 || event.Outcome != types.OutcomeOK || event.Version != "TLS1.3" {
+		t.Fatalf("Invalid event %+v", event)
+	}
+
+	// The server never answered to the second connection
+	event = <-events
+	if event.SrcPort != 40001 || event.Outcome != types.OutcomeTimeout {
+		t.Fatalf("Invalid event %+v. Expecting a timeout", event)
+	}
+
+	// Answers arriving after the timeout are ignored
+	response.SrcPort = 40001
+	h.handle(response, callback)
+	select {
+	case event := <-events:
+		t.Fatalf("Unexpected event %+v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
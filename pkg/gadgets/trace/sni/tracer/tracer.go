@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/netip"
 	"unsafe"
 
 	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
@@ -38,15 +39,26 @@ const (
 	TLSMaxServerNameLen = len(snisnoopEventT{}.Name)
 )
 
+// Answers of the server, from enum response in snisnoop.h
+const (
+	responseNone = iota
+	responseServerHello
+	responseAlert
+	responseReset
+	responseClosed
+)
+
 type Tracer struct {
 	*networktracer.Tracer[types.Event]
 
+	handshakes *handshakes
+
 	ctx    context.Context
 	cancel context.CancelFunc
 }
 
 func NewTracer() (*Tracer, error) {
-	t := &Tracer{}
+	t := &Tracer{handshakes: newHandshakes(handshakeTimeout)}
 
 	if err := t.install(); err != nil {
 		t.Close()
@@ -64,11 +76,6 @@ func parseSNIEvent(sample []byte, netns uint64) (*types.Event, error) {
 
 	timestamp := gadgets.WallTimeFromBootTime(bpfEvent.Timestamp)
 
-	name := gadgets.FromCString(bpfEvent.Name[:])
-	if len(name) == 0 {
-		return nil, nil
-	}
-
 	event := types.Event{
 		Event: eventtypes.Event{
 			Type:      eventtypes.NORMAL,
@@ -80,16 +87,62 @@ func parseSNIEvent(sample []byte, netns uint64) (*types.Event, error) {
 		WithNetNsID:   eventtypes.WithNetNsID{NetNsID: netns},
 		Comm:          gadgets.FromCString(bpfEvent.Task[:]),
 
-		Name: name,
+		SrcIP:   netip.AddrFrom4(*(*[4]byte)(unsafe.Pointer(&bpfEvent.Flow.Saddr))).String(),
+		DstIP:   netip.AddrFrom4(*(*[4]byte)(unsafe.Pointer(&bpfEvent.Flow.Daddr))).String(),
+		SrcPort: bpfEvent.Flow.Sport,
+		DstPort: bpfEvent.Flow.Dport,
+	}
+
+	switch bpfEvent.Response {
+	case responseNone:
+		event.Name = gadgets.FromCString(bpfEvent.Name[:])
+		if len(event.Name) == 0 {
+			return nil, nil
+		}
+		alpnLen := int(bpfEvent.AlpnLen)
+		if alpnLen > len(bpfEvent.Alpn) {
+			alpnLen = len(bpfEvent.Alpn)
+		}
+		event.ALPN = parseALPN(bpfEvent.Alpn[:alpnLen])
+		event.Versions = tlsVersions(bpfEvent.Version, bpfEvent.Versions[:])
+	case responseServerHello:
+		event.Outcome = types.OutcomeOK
+		event.Version = tlsVersionName(bpfEvent.Version)
+	case responseAlert:
+		event.Outcome = tlsAlertOutcome(bpfEvent.Alert)
+	case responseReset:
+		event.Outcome = types.OutcomeReset
+	case responseClosed:
+		event.Outcome = types.OutcomeClosed
+	default:
+		return nil, fmt.Errorf("unknown response %d", bpfEvent.Response)
 	}
 
 	return &event, nil
 }
 
+// Attach reports the ClientHellos of the network namespace of the given pid
+// once the server answered to them.
+func (t *Tracer) Attach(pid uint32, eventCallback func(*types.Event)) error {
+	return t.Tracer.Attach(pid, func(event *types.Event) {
+		t.handshakes.handle(event, eventCallback)
+	})
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.Tracer.SetEventHandler(func(event *types.Event) {
+		t.handshakes.handle(event, nh)
+	})
+}
+
 // --- Registry changes
 
 func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
-	return &Tracer{}, nil
+	return &Tracer{handshakes: newHandshakes(handshakeTimeout)}, nil
 }
 
 func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
@@ -134,4 +187,5 @@ func (t *Tracer) Close() {
 	}
 
 	t.Tracer.Close()
+	t.handshakes.close()
 }
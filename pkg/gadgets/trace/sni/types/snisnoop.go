@@ -30,8 +30,32 @@ type Event struct {
 	Comm string `json:"comm,omitempty" column:"comm,template:comm"`
 
 	Name string `json:"name,omitempty" column:"name,width:30"`
+
+	SrcIP   string `json:"srcIP,omitempty" column:"srcip,template:ipaddr,hide"`
+	DstIP   string `json:"dstIP,omitempty" column:"dstip,template:ipaddr,hide"`
+	SrcPort uint16 `json:"srcPort,omitempty" column:"srcport,template:ipport,hide"`
+	DstPort uint16 `json:"dstPort,omitempty" column:"dstport,template:ipport,hide"`
+
+	// ALPN are the application protocols offered by the client
+	ALPN []string `json:"alpn,omitempty" column:"alpn,width:16"`
+	// Versions are the TLS versions offered by the client
+	Versions []string `json:"versions,omitempty" column:"versions,width:24,hide"`
+	// Version is the TLS version selected by the server
+	Version string `json:"version,omitempty" column:"version,width:7"`
+	// Outcome tells how the server answered to the ClientHello: "ok" for a
+	// ServerHello, "alert:<description>" for an alert, "reset" and "closed"
+	// when the connection was closed and "timeout" without answer.
+	Outcome string `json:"outcome,omitempty" column:"outcome,width:16"`
 }
 
+const (
+	OutcomeOK      = "ok"
+	OutcomeAlert   = "alert"
+	OutcomeReset   = "reset"
+	OutcomeClosed  = "closed"
+	OutcomeTimeout = "timeout"
+)
+
 func GetColumns() *columns.Columns[Event] {
 	cols := columns.MustCreateColumns[Event]()
 
@@ -0,0 +1,335 @@
+// SPDX-License-Identifier: GPL-2.0
+// Copyright (c) 2023 The Inspektor Gadget authors
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "sql.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+
+/* Layout of the beginning of struct pg_result (libpq-int.h), which has been
+ * the same since PostgreSQL 8.0 */
+#define PG_RESULT_NTUPS_OFF		0
+#define PG_RESULT_STATUS_OFF		40
+#define PG_RESULT_CMD_STATUS_OFF	44
+
+/* The first field of MYSQL_RES, both in MySQL and MariaDB */
+#define MYSQL_RES_ROW_COUNT_OFF		0
+
+struct conn_key {
+	__u64 conn;
+	__u32 tgid;
+	__u32 pad;
+};
+
+static const struct event empty_event = {};
+
+/* query in flight on each connection: sent, but whose results weren't all
+ * received yet */
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, struct conn_key);
+	__type(value, struct event);
+} queries SEC(".maps");
+
+/* connection used by the threads currently in a library function */
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__type(value, __u64);
+} calls SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(u32));
+	__uint(value_size, sizeof(u32));
+} events SEC(".maps");
+
+static __always_inline void conn_key_init(struct conn_key *key, __u64 conn)
+{
+	key->conn = conn;
+	key->tgid = bpf_get_current_pid_tgid() >> 32;
+	key->pad = 0;
+}
+
+static __always_inline void set_call(__u64 conn)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+
+	bpf_map_update_elem(&calls, &tid, &conn, BPF_ANY);
+}
+
+/* get_call returns the connection passed to the library function the thread
+ * is returning from, 0 if it isn't traced */
+static __always_inline __u64 get_call()
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	__u64 *conn, ret;
+
+	conn = bpf_map_lookup_elem(&calls, &tid);
+	if (!conn)
+		return 0;
+	ret = *conn;
+	bpf_map_delete_elem(&calls, &tid);
+	return ret;
+}
+
+/* start_query keeps track of a query sent on a connection. It returns NULL
+ * if the query isn't traced. */
+static __always_inline struct event *start_query(struct conn_key *key,
+						  __u8 lib)
+{
+	__u64 pid_tgid = bpf_get_current_pid_tgid();
+	struct event *event;
+	__u64 mntns_id;
+
+	mntns_id = gadget_get_mntns_id();
+	if (gadget_should_discard_mntns_id(mntns_id))
+		return NULL;
+
+	if (bpf_map_update_elem(&queries, key, &empty_event, BPF_ANY))
+		return NULL;
+	event = bpf_map_lookup_elem(&queries, key);
+	if (!event)
+		return NULL;
+
+	event->timestamp = bpf_ktime_get_boot_ns();
+	event->mntns_id = mntns_id;
+	event->pid = pid_tgid >> 32;
+	event->tid = (__u32)pid_tgid;
+	event->rows = -1;
+	event->status = SQL_STATUS_NONE;
+	event->lib = lib;
+	bpf_get_current_comm(&event->comm, sizeof(event->comm));
+
+	return event;
+}
+
+static __always_inline void read_query_str(struct event *event,
+					   const char *query)
+{
+	long ret;
+
+	ret = bpf_probe_read_user_str(&event->query, sizeof(event->query),
+				      query);
+	if (ret <= 0)
+		return;
+
+	event->query_len = ret - 1;
+	/* There is no way to tell whether the string had exactly the size of
+	 * the buffer */
+	event->truncated = ret == sizeof(event->query);
+}
+
+static __always_inline void emit(void *ctx, struct conn_key *key,
+				 struct event *event)
+{
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event,
+			      sizeof(*event));
+	bpf_map_delete_elem(&queries, key);
+}
+
+/* flush reports the query still waiting for its results on the connection,
+ * if any */
+static __always_inline void flush(void *ctx, struct conn_key *key)
+{
+	struct event *event;
+
+	event = bpf_map_lookup_elem(&queries, key);
+	if (event)
+		emit(ctx, key, event);
+}
+
+/* libpq: PQexec(), PQexecParams() and PQexecPrepared() are implemented with
+ * the asynchronous functions, i.e. PQsendQuery*() followed by PQgetResult()
+ * until it returns NULL, tracing the latter covers both APIs. */
+
+static __always_inline int pq_send(void *ctx, __u64 conn, const char *query,
+				   bool prepared)
+{
+	struct conn_key key;
+	struct event *event;
+
+	conn_key_init(&key, conn);
+	event = start_query(&key, SQL_LIB_PQ);
+	if (!event)
+		return 0;
+
+	read_query_str(event, query);
+	event->prepared = prepared;
+	set_call(conn);
+	return 0;
+}
+
+SEC("uprobe/PQsendQuery")
+int BPF_KPROBE(ig_sql_pq_send_query, void *conn, const char *query)
+{
+	return pq_send(ctx, (__u64)conn, query, false);
+}
+
+SEC("uprobe/PQsendQueryParams")
+int BPF_KPROBE(ig_sql_pq_send_params, void *conn, const char *command)
+{
+	return pq_send(ctx, (__u64)conn, command, false);
+}
+
+SEC("uprobe/PQsendQueryPrepared")
+int BPF_KPROBE(ig_sql_pq_send_prepared, void *conn, const char *stmt_name)
+{
+	return pq_send(ctx, (__u64)conn, stmt_name, true);
+}
+
+/* The query isn't sent if PQsendQuery*() fails */
+SEC("uretprobe/PQsendQuery")
+int BPF_KRETPROBE(ig_sql_pq_send_x, int ret)
+{
+	struct conn_key key;
+	__u64 conn;
+
+	conn = get_call();
+	if (!conn || ret)
+		return 0;
+
+	conn_key_init(&key, conn);
+	bpf_map_delete_elem(&queries, &key);
+	return 0;
+}
+
+SEC("uprobe/PQgetResult")
+int BPF_KPROBE(ig_sql_pq_get_result_e, void *conn)
+{
+	set_call((__u64)conn);
+	return 0;
+}
+
+SEC("uretprobe/PQgetResult")
+int BPF_KRETPROBE(ig_sql_pq_get_result_x, void *res)
+{
+	struct conn_key key;
+	struct event *event;
+	__s32 ntups = 0;
+	__u64 conn;
+
+	conn = get_call();
+	if (!conn)
+		return 0;
+
+	conn_key_init(&key, conn);
+	event = bpf_map_lookup_elem(&queries, &key);
+	if (!event)
+		return 0;
+
+	/* All the results were received */
+	if (!res) {
+		event->latency_ns = bpf_ktime_get_boot_ns() - event->timestamp;
+		emit(ctx, &key, event);
+		return 0;
+	}
+
+	bpf_probe_read_user(&ntups, sizeof(ntups), res + PG_RESULT_NTUPS_OFF);
+	bpf_probe_read_user(&event->status, sizeof(event->status),
+			    res + PG_RESULT_STATUS_OFF);
+	bpf_probe_read_user(&event->cmd_status, sizeof(event->cmd_status),
+			    res + PG_RESULT_CMD_STATUS_OFF);
+	event->rows = ntups;
+	return 0;
+}
+
+/* libmysqlclient and libmariadb: mysql_query() calls mysql_real_query().
+ * The number of rows is only known if the results are retrieved with
+ * mysql_store_result(), so the query is reported when it's called, when the
+ * next query is sent or when the connection is closed. */
+
+SEC("uprobe/mysql_real_query")
+int BPF_KPROBE(ig_sql_mysql_query_e, void *mysql, const char *query,
+	       unsigned long length)
+{
+	struct conn_key key;
+	struct event *event;
+	__u32 len;
+
+	conn_key_init(&key, (__u64)mysql);
+	flush(ctx, &key);
+
+	event = start_query(&key, SQL_LIB_MYSQL);
+	if (!event)
+		return 0;
+
+	len = length < MAX_QUERY_LEN ? length : MAX_QUERY_LEN - 1;
+	len &= MAX_QUERY_LEN - 1;
+	bpf_probe_read_user(&event->query, len, query);
+	event->query_len = len;
+	event->truncated = length > len;
+	set_call((__u64)mysql);
+	return 0;
+}
+
+SEC("uretprobe/mysql_real_query")
+int BPF_KRETPROBE(ig_sql_mysql_query_x, int ret)
+{
+	struct conn_key key;
+	struct event *event;
+	__u64 conn;
+
+	conn = get_call();
+	if (!conn)
+		return 0;
+
+	conn_key_init(&key, conn);
+	event = bpf_map_lookup_elem(&queries, &key);
+	if (!event)
+		return 0;
+
+	event->latency_ns = bpf_ktime_get_boot_ns() - event->timestamp;
+	event->status = ret;
+	/* There are no results to wait for */
+	if (ret)
+		emit(ctx, &key, event);
+	return 0;
+}
+
+SEC("uprobe/mysql_store_result")
+int BPF_KPROBE(ig_sql_mysql_store_result_e, void *mysql)
+{
+	set_call((__u64)mysql);
+	return 0;
+}
+
+SEC("uretprobe/mysql_store_result")
+int BPF_KRETPROBE(ig_sql_mysql_store_result_x, void *res)
+{
+	struct conn_key key;
+	struct event *event;
+	__u64 conn;
+
+	conn = get_call();
+	if (!conn)
+		return 0;
+
+	conn_key_init(&key, conn);
+	event = bpf_map_lookup_elem(&queries, &key);
+	if (!event || !event->latency_ns)
+		return 0;
+
+	if (res)
+		bpf_probe_read_user(&event->rows, sizeof(event->rows),
+				    res + MYSQL_RES_ROW_COUNT_OFF);
+	emit(ctx, &key, event);
+	return 0;
+}
+
+SEC("uprobe/mysql_close")
+int BPF_KPROBE(ig_sql_mysql_close, void *mysql)
+{
+	struct conn_key key;
+
+	conn_key_init(&key, (__u64)mysql);
+	flush(ctx, &key);
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
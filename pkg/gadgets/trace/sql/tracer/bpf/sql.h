@@ -0,0 +1,42 @@
+/* SPDX-License-Identifier: (LGPL-2.1 OR BSD-2-Clause) */
+#ifndef __SQL_H
+#define __SQL_H
+
+#define TASK_COMM_LEN		16
+#define MAX_QUERY_LEN		256
+/* CMDSTATUS_LEN of libpq */
+#define CMD_STATUS_LEN		64
+
+enum sql_lib {
+	SQL_LIB_PQ = 0,
+	SQL_LIB_MYSQL = 1,
+};
+
+/* status when the library didn't return a result */
+#define SQL_STATUS_NONE		-1
+
+struct event {
+	__u64 timestamp;
+	__u64 mntns_id;
+	__u64 latency_ns;
+	/* number of tuples of the last result of libpq, number of rows
+	 * stored by mysql_store_result(), -1 if unknown */
+	__s64 rows;
+	__u32 pid;
+	__u32 tid;
+	/* number of bytes of query */
+	__u32 query_len;
+	/* ExecStatusType of the last result of libpq, return value of
+	 * mysql_real_query() */
+	__s32 status;
+	__u8 lib;
+	/* the query is the name of a prepared statement */
+	__u8 prepared;
+	/* the query was longer than MAX_QUERY_LEN */
+	__u8 truncated;
+	__u8 comm[TASK_COMM_LEN];
+	__u8 cmd_status[CMD_STATUS_LEN];
+	__u8 query[MAX_QUERY_LEN];
+};
+
+#endif /* __SQL_H */
@@ -0,0 +1,189 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"strconv"
+	"strings"
+)
+
+// redactQuery replaces the literal values of a query, strings and numbers,
+// by '?'. The identifiers, keywords, placeholders and comments are kept.
+// backslashEscapes tells whether backslashes escape quotes in strings, as
+// MySQL does by default. A literal truncated by the BPF program is redacted
+// as well.
+func redactQuery(query string, backslashEscapes bool) string {
+	var b strings.Builder
+	b.Grow(len(query))
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || (c == '"' && backslashEscapes):
+			// With MySQL, double quotes delimit strings, not identifiers
+			i = skipString(query, i, c, backslashEscapes)
+			b.WriteByte('?')
+		case c == '-' && strings.HasPrefix(query[i:], "--"), c == '#' && backslashEscapes:
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				end = len(query) - i
+			}
+			b.WriteString(query[i : i+end])
+			i += end
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				end = len(query) - i
+			} else {
+				end += 4
+			}
+			b.WriteString(query[i : i+end])
+			i += end
+		case c == '$' && !backslashEscapes:
+			// $1 is a placeholder, $tag$ starts a dollar-quoted string
+			j := i + 1
+			for j < len(query) && isIdentChar(query[j]) {
+				j++
+			}
+			if j < len(query) && query[j] == '$' && (j == i+1 || !isDigit(query[i+1])) {
+				tag := query[i : j+1]
+				end := strings.Index(query[j+1:], tag)
+				if end < 0 {
+					i = len(query)
+				} else {
+					i = j + 1 + end + len(tag)
+				}
+				b.WriteByte('?')
+				continue
+			}
+			b.WriteString(query[i:j])
+			i = j
+		case isDigit(c) || (c == '.' && i+1 < len(query) && isDigit(query[i+1])):
+			i = skipNumber(query, i)
+			b.WriteByte('?')
+		case isIdentChar(c):
+			j := i + 1
+			for j < len(query) && isIdentChar(query[j]) {
+				j++
+			}
+			// Prefixed strings: E'', B'', X'' and N''
+			if j == i+1 && j < len(query) && query[j] == '\'' &&
+				strings.ContainsRune("eEbBxXnN", rune(c)) {
+				escapes := backslashEscapes || c == 'e' || c == 'E'
+				i = skipString(query, j, '\'', escapes)
+				b.WriteByte('?')
+				continue
+			}
+			b.WriteString(query[i:j])
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return b.String()
+}
+
+// skipString returns the index following the string starting at i. Quotes
+// are escaped by doubling them or, if backslashEscapes is set, with a
+// backslash.
+func skipString(query string, i int, quote byte, backslashEscapes bool) int {
+	for i++; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if backslashEscapes {
+				i++
+			}
+		case quote:
+			if i+1 < len(query) && query[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+// skipNumber returns the index following the number starting at i: integers,
+// decimals, exponents and hexadecimal numbers.
+func skipNumber(query string, i int) int {
+	for i < len(query) {
+		c := query[i]
+		switch {
+		case isIdentChar(c) || c == '.':
+			i++
+		case (c == '+' || c == '-') && (query[i-1] == 'e' || query[i-1] == 'E'):
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isIdentChar tells whether the byte can be part of an identifier, bytes of
+// multi-byte UTF-8 characters included
+func isIdentChar(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+// pgStatusNames are the names of the ExecStatusType values, as returned by
+// PQresStatus()
+var pgStatusNames = []string{
+	"PGRES_EMPTY_QUERY",
+	"PGRES_COMMAND_OK",
+	"PGRES_TUPLES_OK",
+	"PGRES_COPY_OUT",
+	"PGRES_COPY_IN",
+	"PGRES_BAD_RESPONSE",
+	"PGRES_NONFATAL_ERROR",
+	"PGRES_FATAL_ERROR",
+	"PGRES_COPY_BOTH",
+	"PGRES_SINGLE_TUPLE",
+	"PGRES_PIPELINE_SYNC",
+	"PGRES_PIPELINE_ABORTED",
+}
+
+func pgStatusName(status int32) string {
+	if status < 0 {
+		return "NO_RESULT"
+	}
+	if int(status) < len(pgStatusNames) {
+		return pgStatusNames[status]
+	}
+	return strconv.Itoa(int(status))
+}
+
+// pgRows returns the number of rows of a libpq result: the one of the command
+// status, e.g. "INSERT 0 3" or "SELECT 10", as PQcmdTuples() does, and the
+// number of tuples otherwise.
+func pgRows(cmdStatus string, ntups int64) int64 {
+	fields := strings.Fields(cmdStatus)
+	if len(fields) >= 2 {
+		switch fields[0] {
+		case "SELECT", "INSERT", "UPDATE", "DELETE", "MERGE", "MOVE", "FETCH", "COPY":
+			if rows, err := strconv.ParseInt(fields[len(fields)-1], 10, 64); err == nil {
+				return rows
+			}
+		}
+	}
+	return ntups
+}
@@ -0,0 +1,182 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build arm64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type sqlConnKey struct {
+	Conn uint64
+	Tgid uint32
+	Pad  uint32
+}
+
+type sqlEvent struct {
+	Timestamp uint64
+	MntnsId   uint64
+	LatencyNs uint64
+	Rows      int64
+	Pid       uint32
+	Tid       uint32
+	QueryLen  uint32
+	Status    int32
+	Lib       uint8
+	Prepared  uint8
+	Truncated uint8
+	Comm      [16]uint8
+	CmdStatus [64]uint8
+	Query     [256]uint8
+	_         [5]byte
+}
+
+// loadSql returns the embedded CollectionSpec for sql.
+func loadSql() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_SqlBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load sql: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadSqlObjects loads sql and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*sqlObjects
+//	*sqlPrograms
+//	*sqlMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadSqlObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadSql()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// sqlSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type sqlSpecs struct {
+	sqlProgramSpecs
+	sqlMapSpecs
+}
+
+// sqlSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type sqlProgramSpecs struct {
+	IgSqlMysqlClose        *ebpf.ProgramSpec `ebpf:"ig_sql_mysql_close"`
+	IgSqlMysqlQueryE       *ebpf.ProgramSpec `ebpf:"ig_sql_mysql_query_e"`
+	IgSqlMysqlQueryX       *ebpf.ProgramSpec `ebpf:"ig_sql_mysql_query_x"`
+	IgSqlMysqlStoreResultE *ebpf.ProgramSpec `ebpf:"ig_sql_mysql_store_result_e"`
+	IgSqlMysqlStoreResultX *ebpf.ProgramSpec `ebpf:"ig_sql_mysql_store_result_x"`
+	IgSqlPqGetResultE      *ebpf.ProgramSpec `ebpf:"ig_sql_pq_get_result_e"`
+	IgSqlPqGetResultX      *ebpf.ProgramSpec `ebpf:"ig_sql_pq_get_result_x"`
+	IgSqlPqSendParams      *ebpf.ProgramSpec `ebpf:"ig_sql_pq_send_params"`
+	IgSqlPqSendPrepared    *ebpf.ProgramSpec `ebpf:"ig_sql_pq_send_prepared"`
+	IgSqlPqSendQuery       *ebpf.ProgramSpec `ebpf:"ig_sql_pq_send_query"`
+	IgSqlPqSendX           *ebpf.ProgramSpec `ebpf:"ig_sql_pq_send_x"`
+}
+
+// sqlMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type sqlMapSpecs struct {
+	Calls                *ebpf.MapSpec `ebpf:"calls"`
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Queries              *ebpf.MapSpec `ebpf:"queries"`
+}
+
+// sqlObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadSqlObjects or ebpf.CollectionSpec.LoadAndAssign.
+type sqlObjects struct {
+	sqlPrograms
+	sqlMaps
+}
+
+func (o *sqlObjects) Close() error {
+	return _SqlClose(
+		&o.sqlPrograms,
+		&o.sqlMaps,
+	)
+}
+
+// sqlMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadSqlObjects or ebpf.CollectionSpec.LoadAndAssign.
+type sqlMaps struct {
+	Calls                *ebpf.Map `ebpf:"calls"`
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Queries              *ebpf.Map `ebpf:"queries"`
+}
+
+func (m *sqlMaps) Close() error {
+	return _SqlClose(
+		m.Calls,
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.Queries,
+	)
+}
+
+// sqlPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadSqlObjects or ebpf.CollectionSpec.LoadAndAssign.
+type sqlPrograms struct {
+	IgSqlMysqlClose        *ebpf.Program `ebpf:"ig_sql_mysql_close"`
+	IgSqlMysqlQueryE       *ebpf.Program `ebpf:"ig_sql_mysql_query_e"`
+	IgSqlMysqlQueryX       *ebpf.Program `ebpf:"ig_sql_mysql_query_x"`
+	IgSqlMysqlStoreResultE *ebpf.Program `ebpf:"ig_sql_mysql_store_result_e"`
+	IgSqlMysqlStoreResultX *ebpf.Program `ebpf:"ig_sql_mysql_store_result_x"`
+	IgSqlPqGetResultE      *ebpf.Program `ebpf:"ig_sql_pq_get_result_e"`
+	IgSqlPqGetResultX      *ebpf.Program `ebpf:"ig_sql_pq_get_result_x"`
+	IgSqlPqSendParams      *ebpf.Program `ebpf:"ig_sql_pq_send_params"`
+	IgSqlPqSendPrepared    *ebpf.Program `ebpf:"ig_sql_pq_send_prepared"`
+	IgSqlPqSendQuery       *ebpf.Program `ebpf:"ig_sql_pq_send_query"`
+	IgSqlPqSendX           *ebpf.Program `ebpf:"ig_sql_pq_send_x"`
+}
+
+func (p *sqlPrograms) Close() error {
+	return _SqlClose(
+		p.IgSqlMysqlClose,
+		p.IgSqlMysqlQueryE,
+		p.IgSqlMysqlQueryX,
+		p.IgSqlMysqlStoreResultE,
+		p.IgSqlMysqlStoreResultX,
+		p.IgSqlPqGetResultE,
+		p.IgSqlPqGetResultX,
+		p.IgSqlPqSendParams,
+		p.IgSqlPqSendPrepared,
+		p.IgSqlPqSendQuery,
+		p.IgSqlPqSendX,
+	)
+}
+
+func _SqlClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed sql_bpfel_arm64.o
+var _SqlBytes []byte
@@ -0,0 +1,86 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracer
+
+import (
+	"testing"
+)
+
+func TestRedactQuery(t *testing.T) {
+	table := []struct {
+		query            string
+		backslashEscapes bool
+		expected         string
+	}{
+		{
+			query:    "SELECT * FROM users WHERE email = 'alice@example.com' AND id = 42",
+			expected: "SELECT * FROM users WHERE email = ? AND id = ?",
+		},
+		{
+			// Identifiers with digits, placeholders and doubled quotes
+			query:    `UPDATE t1 SET "col2" = 'it''s', price = 1.5e-3 WHERE id = $1`,
+			expected: `UPDATE t1 SET "col2" = ?, price = ? WHERE id = $1`,
+		},
+		{
+			// Backslashes don't escape quotes in standard PostgreSQL strings
+			query:    `SELECT 'C:\' , E'it\'s' FROM t WHERE x = 'secret'`,
+			expected: `SELECT ? , ? FROM t WHERE x = ?`,
+		},
+		{
+			query:    "SELECT $$it's a secret$$, $tag$x$tag$, 0x1F -- don't\nFROM t /* it's */",
+			expected: "SELECT ?, ?, ? -- don't\nFROM t /* it's */",
+		},
+		{
+			query:            `INSERT INTO users (name, note) VALUES ("bob", 'it\'s secret')`,
+			backslashEscapes: true,
+			expected:         `INSERT INTO users (name, note) VALUES (?, ?)`,
+		},
+		{
+			// Truncated by the BPF program
+			query:    "INSERT INTO tokens VALUES ('abcdef",
+			expected: "INSERT INTO tokens VALUES (?",
+		},
+	}
+	for _, entry := range table {
+		redacted := redactQuery(entry.query, entry.backslashEscapes)
+		if redacted != entry.expected {
+			t.Fatalf("Invalid redacted query %q for %q. Expecting %q", redacted, entry.query, entry.expected)
+		}
+	}
+}
+
+func TestPGRows(t *testing.T) {
+	table := []struct {
+		cmdStatus string
+		ntups     int64
+		expected  int64
+	}{
+		{"SELECT 10", 10, 10},
+		{"INSERT 0 3", 0, 3},
+		{"UPDATE 7", 0, 7},
+		{"CREATE TABLE", 0, 0},
+		{"", 0, 0},
+	}
+	for _, entry := range table {
+		rows := pgRows(entry.cmdStatus, entry.ntups)
+		if rows != entry.expected {
+			t.Fatalf("Invalid rows %d for %q. Expecting %d", rows, entry.cmdStatus, entry.expected)
+		}
+	}
+
+	if name := pgStatusName(7); name != "PGRES_FATAL_ERROR" {
+		t.Fatalf("Invalid status %q. Expecting PGRES_FATAL_ERROR", name)
+	}
+}
@@ -0,0 +1,492 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+	"golang.org/x/sys/unix"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/sql/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -type event sql ./bpf/sql.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
+
+const (
+	sqlLibPQ    = 0
+	sqlLibMySQL = 1
+)
+
+type Config struct {
+	MountnsMap *ebpf.Map
+	ShowValues bool
+}
+
+// library is a client library the uprobes can be attached to
+type library struct {
+	kind int
+	// prefixes of the names of the files, as the process maps them
+	prefixes []string
+	// usual locations of the library, relative to the root of the container
+	paths []string
+}
+
+var libraries = []library{
+	{
+		kind:     sqlLibPQ,
+		prefixes: []string{"libpq.so", "libpq-"},
+		paths: []string{
+			"usr/lib/*-linux-gnu/libpq.so.5*",
+			"usr/lib64/libpq.so.5*",
+			"usr/lib/libpq.so.5*",
+			"usr/local/lib/libpq.so.5*",
+			"usr/local/pgsql/lib/libpq.so.5*",
+		},
+	},
+	{
+		kind:     sqlLibMySQL,
+		prefixes: []string{"libmysqlclient.so", "libmariadb.so"},
+		paths: []string{
+			"usr/lib/*-linux-gnu/libmysqlclient.so.*",
+			"usr/lib/*-linux-gnu/libmariadb.so.*",
+			"usr/lib64/mysql/libmysqlclient.so.*",
+			"usr/lib64/libmariadb.so.*",
+			"usr/lib/libmysqlclient.so.*",
+			"usr/lib/libmariadb.so.*",
+		},
+	},
+}
+
+// libraryProbes are the uprobes attached to a library, shared by all the
+// containers using it
+type libraryProbes struct {
+	links []link.Link
+	refs  int
+}
+
+// fileID identifies a file regardless of the mount namespace it's seen from
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+type Tracer struct {
+	config        *Config
+	enricherFunc  func(ev any) error
+	eventCallback func(*types.Event)
+	sampleParser  gadgets.SampleParser
+
+	objs   sqlObjects
+	reader *perf.Reader
+
+	mu        sync.Mutex
+	installed bool
+	// libraries traced for each container
+	containers map[*containercollection.Container][]fileID
+	libraries  map[fileID]*libraryProbes
+}
+
+func (t *Tracer) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.installed = false
+	for id, probes := range t.libraries {
+		for _, l := range probes.links {
+			gadgets.CloseLink(l)
+		}
+		delete(t.libraries, id)
+	}
+	for container := range t.containers {
+		t.containers[container] = nil
+	}
+
+	if t.reader != nil {
+		t.reader.Close()
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	spec, err := loadSql()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+		return fmt.Errorf("loading ebpf spec: %w", err)
+	}
+
+	reader, err := perf.NewReader(t.objs.sqlMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	if err != nil {
+		return fmt.Errorf("creating perf ring buffer: %w", err)
+	}
+	t.reader = reader
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.installed = true
+	for container := range t.containers {
+		if err := t.attachLibraries(container); err != nil {
+			return fmt.Errorf("tracing libraries of container %q: %w", container.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// findLibrary returns the path, from the host, of the library used by the
+// given process, an empty string if it doesn't use it. The library mapped
+// by the process is preferred, the usual locations are used when the process
+// didn't load it yet.
+func findLibrary(pid uint32, lib *library) (string, error) {
+	root := filepath.Join(host.HostProcFs, fmt.Sprint(pid))
+
+	file, err := os.Open(filepath.Join(root, "maps"))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// address perms offset dev inode pathname
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+
+		path := fields[5]
+		name := filepath.Base(path)
+		for _, prefix := range lib.prefixes {
+			if strings.HasPrefix(name, prefix) {
+				return filepath.Join(root, "root", path), nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+
+	for _, pattern := range lib.paths {
+		matches, _ := filepath.Glob(filepath.Join(root, "root", pattern))
+		if len(matches) > 0 {
+			return matches[0], nil
+		}
+	}
+
+	return "", nil
+}
+
+type uprobe struct {
+	symbol string
+	prog   *ebpf.Program
+	ret    bool
+}
+
+func (t *Tracer) uprobes(kind int) []uprobe {
+	if kind == sqlLibMySQL {
+		return []uprobe{
+			{"mysql_real_query", t.objs.IgSqlMysqlQueryE, false},
+			{"mysql_real_query", t.objs.IgSqlMysqlQueryX, true},
+			{"mysql_store_result", t.objs.IgSqlMysqlStoreResultE, false},
+			{"mysql_store_result", t.objs.IgSqlMysqlStoreResultX, true},
+			{"mysql_close", t.objs.IgSqlMysqlClose, false},
+		}
+	}
+	return []uprobe{
+		{"PQsendQuery", t.objs.IgSqlPqSendQuery, false},
+		{"PQsendQuery", t.objs.IgSqlPqSendX, true},
+		{"PQsendQueryParams", t.objs.IgSqlPqSendParams, false},
+		{"PQsendQueryParams", t.objs.IgSqlPqSendX, true},
+		{"PQsendQueryPrepared", t.objs.IgSqlPqSendPrepared, false},
+		{"PQsendQueryPrepared", t.objs.IgSqlPqSendX, true},
+		{"PQgetResult", t.objs.IgSqlPqGetResultE, false},
+		{"PQgetResult", t.objs.IgSqlPqGetResultX, true},
+	}
+}
+
+// attachLibrary attaches the uprobes to the library, unless they are already
+// attached because another container uses the same file. It must be called
+// with t.mu held.
+func (t *Tracer) attachLibrary(path string, kind int) (*fileID, error) {
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return nil, fmt.Errorf("stat %s: %w", path, err)
+	}
+	id := fileID{dev: st.Dev, ino: st.Ino}
+
+	if probes, ok := t.libraries[id]; ok {
+		probes.refs++
+		return &id, nil
+	}
+
+	ex, err := link.OpenExecutable(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", path, err)
+	}
+
+	probes := &libraryProbes{refs: 1}
+	for _, up := range t.uprobes(kind) {
+		var l link.Link
+		if up.ret {
+			l, err = ex.Uretprobe(up.symbol, up.prog, nil)
+		} else {
+			l, err = ex.Uprobe(up.symbol, up.prog, nil)
+		}
+		if err != nil {
+			for _, l := range probes.links {
+				gadgets.CloseLink(l)
+			}
+			return nil, fmt.Errorf("attaching uprobe to %s in %s: %w", up.symbol, path, err)
+		}
+		probes.links = append(probes.links, l)
+	}
+
+	t.libraries[id] = probes
+	return &id, nil
+}
+
+// attachLibraries attaches the uprobes to the client libraries used by the
+// container. It must be called with t.mu held.
+func (t *Tracer) attachLibraries(container *containercollection.Container) error {
+	for i := range libraries {
+		lib := &libraries[i]
+		path, err := findLibrary(container.Pid, lib)
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			continue
+		}
+
+		id, err := t.attachLibrary(path, lib.kind)
+		if err != nil {
+			return err
+		}
+		t.containers[container] = append(t.containers[container], *id)
+	}
+	return nil
+}
+
+// detachLibraries releases the uprobes used by the container. It must be
+// called with t.mu held.
+func (t *Tracer) detachLibraries(container *containercollection.Container) {
+	for _, id := range t.containers[container] {
+		probes, ok := t.libraries[id]
+		if !ok {
+			continue
+		}
+		probes.refs--
+		if probes.refs > 0 {
+			continue
+		}
+		for _, l := range probes.links {
+			gadgets.CloseLink(l)
+		}
+		delete(t.libraries, id)
+	}
+	t.containers[container] = nil
+}
+
+func (t *Tracer) parseEvent(bpfEvent *sqlEvent) *types.Event {
+	event := &types.Event{
+		Event: eventtypes.Event{
+			Type:      eventtypes.NORMAL,
+			Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+		},
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: bpfEvent.MntnsId},
+		Pid:           bpfEvent.Pid,
+		Tid:           bpfEvent.Tid,
+		Comm:          gadgets.FromCString(bpfEvent.Comm[:]),
+		Truncated:     bpfEvent.Truncated != 0,
+		Prepared:      bpfEvent.Prepared != 0,
+		Rows:          bpfEvent.Rows,
+		Latency:       time.Duration(bpfEvent.LatencyNs),
+	}
+
+	query := string(bpfEvent.Query[:bpfEvent.QueryLen])
+
+	switch bpfEvent.Lib {
+	case sqlLibMySQL:
+		event.Library = types.LibMySQL
+		event.Status = "OK"
+		if bpfEvent.Status != 0 {
+			event.Status = "ERROR"
+		}
+	default:
+		event.Library = types.LibPQ
+		event.Status = pgStatusName(bpfEvent.Status)
+		if bpfEvent.Rows >= 0 {
+			event.Rows = pgRows(gadgets.FromCString(bpfEvent.CmdStatus[:]), bpfEvent.Rows)
+		}
+	}
+
+	// The name of a prepared statement doesn't contain values
+	if !t.config.ShowValues && !event.Prepared {
+		query = redactQuery(query, bpfEvent.Lib == sqlLibMySQL)
+	}
+	event.Query = query
+
+	return event
+}
+
+func (t *Tracer) run() {
+	var sampleParser gadgets.SampleParser = t
+	if t.sampleParser != nil {
+		sampleParser = t.sampleParser
+	}
+
+	for {
+		record, err := t.reader.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				// nothing to do, we're done
+				return
+			}
+
+			msg := fmt.Sprintf("Error reading perf ring buffer: %s", err)
+			t.eventCallback(types.Base(eventtypes.Err(msg)))
+			return
+		}
+
+		if record.LostSamples > 0 {
+			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
+			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			continue
+		}
+
+		events, err := sampleParser.ParseSample(record.RawSample, 0)
+		if err != nil {
+			t.eventCallback(types.Base(eventtypes.Err(err.Error())))
+			continue
+		}
+		for _, ev := range events {
+			event := ev.(*types.Event)
+			if t.enricherFunc != nil {
+				t.enricherFunc(event)
+			}
+
+			t.eventCallback(event)
+		}
+	}
+}
+
+// ParseSample parses the query sent by the eBPF programs, the values are
+// redacted depending on the params given to Init()
+func (t *Tracer) ParseSample(rawSample []byte, netns uint64) ([]any, error) {
+	if len(rawSample) < int(unsafe.Sizeof(sqlEvent{})) {
+		return nil, fmt.Errorf("sample too short: %d bytes", len(rawSample))
+	}
+	return []any{t.parseEvent((*sqlEvent)(unsafe.Pointer(&rawSample[0])))}, nil
+}
+
+// DropNetns does nothing, the queries aren't parsed per network namespace
+func (t *Tracer) DropNetns(netns uint64) {}
+
+// SetSampleParser sets the parser used instead of the tracer itself
+func (t *Tracer) SetSampleParser(parser gadgets.SampleParser) {
+	t.sampleParser = parser
+}
+
+// --- Registry changes
+
+func (t *Tracer) Init(gadgetCtx gadgets.GadgetContext) error {
+	t.config.ShowValues = gadgetCtx.GadgetParams().Get(ParamShowValues).AsBool()
+	return nil
+}
+
+// Close does nothing, the eBPF programs are unloaded when Run() returns
+func (t *Tracer) Close() {}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	go t.run()
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	return nil
+}
+
+// AttachContainer attaches the uprobes to the client libraries used by the
+// container. The libraries of other containers are filtered with the mount
+// namespace map.
+func (t *Tracer) AttachContainer(container *containercollection.Container) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.containers[container] = nil
+	if !t.installed {
+		return nil
+	}
+	return t.attachLibraries(container)
+}
+
+func (t *Tracer) DetachContainer(container *containercollection.Container) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.detachLibraries(container)
+	delete(t.containers, container)
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (t *Tracer) SetEventEnricher(enricher func(ev any) error) {
+	t.enricherFunc = enricher
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	tracer := &Tracer{
+		config:     &Config{},
+		containers: make(map[*containercollection.Container][]fileID),
+		libraries:  make(map[fileID]*libraryProbes),
+	}
+	return tracer, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/environment"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	LibPQ    = "libpq"
+	LibMySQL = "libmysqlclient"
+)
+
+// Event is a query sent by a client library, reported once its results were
+// received
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+
+	Pid  uint32 `json:"pid,omitempty" column:"pid,template:pid"`
+	Tid  uint32 `json:"tid,omitempty" column:"tid,template:pid,hide"`
+	Comm string `json:"comm,omitempty" column:"comm,template:comm"`
+
+	// Library is the client library that sent the query. libmariadb is
+	// reported as libmysqlclient, they have the same API.
+	Library string `json:"library,omitempty" column:"lib,width:14,fixed"`
+
+	// Query is the text of the query, with its literal values replaced by
+	// '?' unless the user asked to show them. It's truncated to 255 bytes.
+	Query     string `json:"query,omitempty" column:"query,minWidth:24,maxWidth:80"`
+	Truncated bool   `json:"truncated,omitempty" column:"truncated,width:9,hide"`
+	// Prepared tells whether Query is the name of a prepared statement
+	Prepared bool `json:"prepared,omitempty" column:"prepared,width:8,hide"`
+
+	// Status is the ExecStatusType of the last result with libpq, OK or
+	// ERROR with libmysqlclient
+	Status string `json:"status,omitempty" column:"status,minWidth:5,maxWidth:20"`
+	// Rows is the number of rows returned or affected by the query, -1
+	// when the library doesn't tell it
+	Rows int64 `json:"rows" column:"rows,minWidth:6,align:right"`
+
+	// Latency is the time between sending the query and receiving its
+	// results
+	Latency time.Duration `json:"latency,omitempty" column:"latency,minWidth:10,align:right"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	cols := columns.MustCreateColumns[Event]()
+
+	// Hide container column for kubernetes environment
+	if environment.Environment == environment.Kubernetes {
+		col, _ := cols.GetColumn("container")
+		col.Visible = false
+	}
+
+	return cols
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
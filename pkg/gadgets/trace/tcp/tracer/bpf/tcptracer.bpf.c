@@ -11,6 +11,7 @@
 #include <bpf/bpf_endian.h>
 #include "tcptracer.h"
 #include "mntns_filter.h"
+#include "cgroup_filter.h"
 
 const volatile uid_t filter_uid = -1;
 const volatile pid_t filter_pid = 0;
@@ -149,6 +150,9 @@ filter_event(struct sock *sk, __u32 uid, __u32 pid, __u64 mntns_id)
 	if (gadget_should_discard_mntns_id(mntns_id))
 		return true;
 
+	if (gadget_should_discard_cgroup())
+		return true;
+
 	if (filter_pid && pid != filter_pid)
 		return true;
 
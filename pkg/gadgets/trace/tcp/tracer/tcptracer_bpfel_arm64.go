@@ -91,10 +91,11 @@ type tcptracerProgramSpecs struct {
 //
 // It can be passed ebpf.CollectionSpec.Assign.
 type tcptracerMapSpecs struct {
-	Events               *ebpf.MapSpec `ebpf:"events"`
-	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
-	Sockets              *ebpf.MapSpec `ebpf:"sockets"`
-	Tuplepid             *ebpf.MapSpec `ebpf:"tuplepid"`
+	Events                *ebpf.MapSpec `ebpf:"events"`
+	GadgetCgroupFilterMap *ebpf.MapSpec `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Sockets               *ebpf.MapSpec `ebpf:"sockets"`
+	Tuplepid              *ebpf.MapSpec `ebpf:"tuplepid"`
 }
 
 // tcptracerObjects contains all objects after they have been loaded into the kernel.
@@ -116,15 +117,17 @@ func (o *tcptracerObjects) Close() error {
 //
 // It can be passed to loadTcptracerObjects or ebpf.CollectionSpec.LoadAndAssign.
 type tcptracerMaps struct {
-	Events               *ebpf.Map `ebpf:"events"`
-	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
-	Sockets              *ebpf.Map `ebpf:"sockets"`
-	Tuplepid             *ebpf.Map `ebpf:"tuplepid"`
+	Events                *ebpf.Map `ebpf:"events"`
+	GadgetCgroupFilterMap *ebpf.Map `ebpf:"gadget_cgroup_filter_map"`
+	GadgetMntnsFilterMap  *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Sockets               *ebpf.Map `ebpf:"sockets"`
+	Tuplepid              *ebpf.Map `ebpf:"tuplepid"`
 }
 
 func (m *tcptracerMaps) Close() error {
 	return _TcptracerClose(
 		m.Events,
+		m.GadgetCgroupFilterMap,
 		m.GadgetMntnsFilterMap,
 		m.Sockets,
 		m.Tuplepid,
@@ -28,6 +28,7 @@ import (
 
 	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/sidecar"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcp/types"
 	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
 )
@@ -35,7 +36,12 @@ import (
 //go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -no-global-types -type event -type event_type tcptracer ./bpf/tcptracer.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I ../../../common/
 
 type Config struct {
-	MountnsMap *ebpf.Map
+	MountnsMap         *ebpf.Map
+	ExcludeSidecarHops bool
+
+	// CgroupPath, if set, restricts the events to processes running in that
+	// cgroup or in one of its descendants.
+	CgroupPath string
 }
 
 type Tracer struct {
@@ -103,10 +109,20 @@ func (t *Tracer) install() error {
 		return fmt.Errorf("loading ebpf program: %w", err)
 	}
 
-	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, nil, &t.objs); err != nil {
+	consts := map[string]interface{}{
+		gadgets.FilterByCgroupName: t.config.CgroupPath != "",
+	}
+
+	if err := gadgets.LoadeBPFSpec(t.config.MountnsMap, spec, consts, &t.objs); err != nil {
 		return fmt.Errorf("loading ebpf spec: %w", err)
 	}
 
+	if t.config.CgroupPath != "" {
+		if err := gadgets.SetCgroupFilter(t.objs.GadgetCgroupFilterMap, t.config.CgroupPath); err != nil {
+			return err
+		}
+	}
+
 	t.tcpv4connectEnterLink, err = link.Kprobe("tcp_v4_connect", t.objs.IgTcpV4CoE, nil)
 	if err != nil {
 		return fmt.Errorf("attaching kprobe: %w", err)
@@ -191,6 +207,16 @@ func (t *Tracer) run() {
 			IPVersion:     ipversion,
 		}
 
+		// Connections redirected to the sidecar and the ones the sidecar
+		// opens on behalf of the application are the same connection.
+		event.Sidecar = sidecar.FromPorts(event.Sport, event.Dport)
+		if event.Sidecar == "" {
+			event.Sidecar = sidecar.FromProcess(event.Comm, bpfEvent.Uid)
+		}
+		if t.config.ExcludeSidecarHops && event.Sidecar != "" {
+			continue
+		}
+
 		switch bpfEvent.Type {
 		case tcptracerEventTypeTCP_EVENT_TYPE_CONNECT:
 			event.Operation = "connect"
@@ -211,6 +237,8 @@ func (t *Tracer) run() {
 // --- Registry changes
 
 func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	t.config.ExcludeSidecarHops = gadgetCtx.GadgetParams().Get(ParamExcludeSidecarHops).AsBool()
+
 	defer t.close()
 	if err := t.install(); err != nil {
 		return fmt.Errorf("installing tracer: %w", err)
@@ -226,6 +254,10 @@ func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
 	t.config.MountnsMap = mountnsMap
 }
 
+func (t *Tracer) SetCgroupFilter(cgroupPath string) {
+	t.config.CgroupPath = cgroupPath
+}
+
 func (t *Tracer) SetEventHandler(handler any) {
 	nh, ok := handler.(func(ev *types.Event))
 	if !ok {
@@ -22,6 +22,9 @@ import (
 type Event struct {
 	eventtypes.Event
 	eventtypes.WithMountNsID
+	eventtypes.WithKubeAudit
+	eventtypes.WithJoin
+	eventtypes.WithSystemdUnit
 
 	Operation string `json:"operation,omitempty" column:"t,width:1,fixed"`
 	Pid       uint32 `json:"pid,omitempty" column:"pid,template:pid"`
@@ -31,6 +34,7 @@ type Event struct {
 	Daddr     string `json:"daddr,omitempty" column:"daddr,template:ipaddr"`
 	Sport     uint16 `json:"sport,omitempty" column:"sport,template:ipport"`
 	Dport     uint16 `json:"dport,omitempty" column:"dport,template:ipport"`
+	Sidecar   string `json:"sidecar,omitempty" column:"sidecar,maxWidth:7,hide"`
 }
 
 func GetColumns() *columns.Columns[Event] {
@@ -54,6 +58,14 @@ func GetColumns() *columns.Columns[Event] {
 	return tcpColumns
 }
 
+func (e *Event) GetPid() uint32 {
+	return e.Pid
+}
+
+func (e *Event) GetRemoteIPs() []string {
+	return []string{e.Daddr}
+}
+
 func Base(ev eventtypes.Event) *Event {
 	return &Event{
 		Event: ev,
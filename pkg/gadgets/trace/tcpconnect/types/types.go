@@ -24,6 +24,7 @@ import (
 type Event struct {
 	eventtypes.Event
 	eventtypes.WithMountNsID
+	eventtypes.WithContainerUid
 
 	Pid       uint32        `json:"pid,omitempty" column:"pid,template:pid"`
 	Uid       uint32        `json:"uid,omitempty" column:"uid,minWidth:6,hide"`
@@ -46,6 +47,10 @@ func GetColumns() *columns.Columns[Event] {
 	return cols
 }
 
+func (e *Event) GetUid() uint32 {
+	return e.Uid
+}
+
 func Base(ev eventtypes.Event) *Event {
 	return &Event{
 		Event: ev,
@@ -28,6 +28,7 @@ import (
 	"github.com/cilium/ebpf/link"
 	"github.com/cilium/ebpf/perf"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/networktracer"
@@ -197,9 +198,9 @@ func (t *Tracer) run() {
 			Daddr:         gadgets.IPStringFromBytes(bpfEvent.Daddr, ipversion),
 			Sport:         gadgets.Htons(bpfEvent.Sport),
 			Dport:         gadgets.Htons(bpfEvent.Dport),
-			State:         tcpbits.TCPState(bpfEvent.State),
+			State:         columns.Enum{Name: tcpbits.TCPState(bpfEvent.State), Value: int64(bpfEvent.State)},
 			Tcpflags:      tcpbits.TCPFlags(bpfEvent.Tcpflags),
-			Reason:        reason,
+			Reason:        columns.Enum{Name: reason, Value: int64(bpfEvent.Reason)},
 			IPVersion:     ipversion,
 		}
 
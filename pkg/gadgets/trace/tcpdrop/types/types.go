@@ -37,9 +37,9 @@ type Event struct {
 	Daddr string `json:"daddr,omitempty" column:"daddr,template:ipaddr,hide,order:3001"`
 	Dport uint16 `json:"dport,omitempty" column:"dport,template:ipport,hide,order:3002"`
 
-	State    string `json:"state,omitempty" column:"state,minWidth:9,maxWidth:12,order:5000"`
-	Tcpflags string `json:"tcpflags,omitempty" column:"tcpflags,minWidth:7,maxWidth:31,order:5001"`
-	Reason   string `json:"reason,omitempty" column:"reason,minWidth:14,maxWidth:23,order:5002"`
+	State    columns.Enum `json:"state" column:"state,minWidth:9,maxWidth:12,order:5000"`
+	Tcpflags string       `json:"tcpflags,omitempty" column:"tcpflags,minWidth:7,maxWidth:31,order:5001"`
+	Reason   columns.Enum `json:"reason" column:"reason,minWidth:14,maxWidth:23,order:5002"`
 
 	/* Source IP resolved by kubeipresolver  */
 	SrcKind      eventtypes.RemoteKind `json:"srcKind,omitempty" column:"srcKind,maxWidth:5,hide,order:2100"`
@@ -26,6 +26,7 @@ import (
 	"github.com/cilium/ebpf/link"
 	"github.com/cilium/ebpf/perf"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
 	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/networktracer"
@@ -161,7 +162,7 @@ func (t *Tracer) run() {
 			Daddr:         gadgets.IPStringFromBytes(bpfEvent.Daddr, ipversion),
 			Dport:         gadgets.Htons(bpfEvent.Dport),
 			Sport:         gadgets.Htons(bpfEvent.Sport),
-			State:         tcpbits.TCPState(bpfEvent.State),
+			State:         columns.Enum{Name: tcpbits.TCPState(bpfEvent.State), Value: int64(bpfEvent.State)},
 			Tcpflags:      tcpbits.TCPFlags(bpfEvent.Tcpflags),
 		}
 
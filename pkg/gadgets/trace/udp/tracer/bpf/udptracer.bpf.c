@@ -0,0 +1,329 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+
+#include <vmlinux/vmlinux.h>
+
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include <bpf/bpf_endian.h>
+
+#define GADGET_TYPE_TRACING
+#include <sockets-map.h>
+
+#include "udptracer.h"
+#include "mntns_filter.h"
+
+/* Define here, because there are conflicts with include files */
+#define ETH_P_IPV6	0x86DD
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event *unusedevent __attribute__((unused));
+const enum event_type unused_eventtype __attribute__((unused));
+
+struct send_args {
+	struct sock *sk;
+	struct msghdr *msg;
+};
+
+struct enqueue_args {
+	struct sock *sk;
+	struct sk_buff *skb;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, u32);
+	__type(value, struct send_args);
+} sends SEC(".maps");
+
+/*
+ * __udp_enqueue_schedule_skb() runs in softirq context, where the current
+ * tid isn't meaningful (all the idle tasks have tid 0). Softirqs don't nest on
+ * a CPU, so a per-CPU entry is enough.
+ */
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, u32);
+	__type(value, struct enqueue_args);
+} enqueues SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(u32));
+	__uint(value_size, sizeof(u32));
+} events SEC(".maps");
+
+static __always_inline void
+fill_current(struct event *event)
+{
+	__u64 pid_tgid = bpf_get_current_pid_tgid();
+	__u64 uid_gid = bpf_get_current_uid_gid();
+
+	event->pid = pid_tgid >> 32;
+	event->uid = (__u32)uid_gid;
+	event->mntns_id = gadget_get_mntns_id();
+	bpf_get_current_comm(&event->task, sizeof(event->task));
+}
+
+/* Fills the addresses and ports of the event from the headers of skb */
+static __always_inline bool
+fill_from_skb(struct event *event, struct sk_buff *skb)
+{
+	unsigned char *head = BPF_CORE_READ(skb, head);
+	__u16 nhoff = BPF_CORE_READ(skb, network_header);
+	__u16 thoff = BPF_CORE_READ(skb, transport_header);
+	struct udphdr udph;
+
+	switch (bpf_ntohs(BPF_CORE_READ(skb, protocol))) {
+	case ETH_P_IP: {
+		struct iphdr iph;
+
+		if (bpf_probe_read_kernel(&iph, sizeof(iph), head + nhoff))
+			return false;
+		event->af = AF_INET;
+		event->saddr_v4 = iph.saddr;
+		event->daddr_v4 = iph.daddr;
+		break;
+	}
+	case ETH_P_IPV6: {
+		struct ipv6hdr ip6h;
+
+		if (bpf_probe_read_kernel(&ip6h, sizeof(ip6h), head + nhoff))
+			return false;
+		event->af = AF_INET6;
+		__builtin_memcpy(event->saddr, &ip6h.saddr, sizeof(event->saddr));
+		__builtin_memcpy(event->daddr, &ip6h.daddr, sizeof(event->daddr));
+		break;
+	}
+	default:
+		return false;
+	}
+
+	if (bpf_probe_read_kernel(&udph, sizeof(udph), head + thoff))
+		return false;
+	event->sport = udph.source;
+	event->dport = udph.dest;
+
+	return true;
+}
+
+static __always_inline int
+enter_udp_sendmsg(struct sock *sk, struct msghdr *msg)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	struct send_args args = {
+		.sk = sk,
+		.msg = msg,
+	};
+
+	if (gadget_should_discard_mntns_id(gadget_get_mntns_id()))
+		return 0;
+
+	bpf_map_update_elem(&sends, &tid, &args, 0);
+	return 0;
+}
+
+SEC("kprobe/udp_sendmsg")
+int BPF_KPROBE(ig_udp_send_e, struct sock *sk, struct msghdr *msg)
+{
+	return enter_udp_sendmsg(sk, msg);
+}
+
+SEC("kprobe/udpv6_sendmsg")
+int BPF_KPROBE(ig_udp6_send_e, struct sock *sk, struct msghdr *msg)
+{
+	return enter_udp_sendmsg(sk, msg);
+}
+
+/*
+ * udpv6_sendmsg() calls udp_sendmsg() for IPv4-mapped destinations. The inner
+ * return sees the stored arguments, sends the event and deletes them, so the
+ * outer one finds nothing to do.
+ */
+static __always_inline int
+exit_udp_sendmsg(struct pt_regs *ctx, int ret)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	struct inet_sock *sockp;
+	struct send_args *args;
+	struct event event = {};
+	struct msghdr *msg;
+	struct sock *sk;
+	void *msg_name;
+
+	args = bpf_map_lookup_elem(&sends, &tid);
+	if (!args)
+		return 0;
+
+	if (ret <= 0)
+		goto end;
+
+	// Don't use args directly in BPF_CORE_READ, it's not a kernel type
+	sk = args->sk;
+	msg = args->msg;
+	sockp = (struct inet_sock *)sk;
+	msg_name = BPF_CORE_READ(msg, msg_name);
+
+	event.af = BPF_CORE_READ(sk, __sk_common.skc_family);
+	switch (event.af) {
+	case AF_INET:
+		BPF_CORE_READ_INTO(&event.saddr_v4, sk, __sk_common.skc_rcv_saddr);
+		if (msg_name) {
+			struct sockaddr_in sin;
+
+			if (bpf_probe_read_kernel(&sin, sizeof(sin), msg_name))
+				goto end;
+			event.daddr_v4 = sin.sin_addr.s_addr;
+			event.dport = sin.sin_port;
+		} else {
+			BPF_CORE_READ_INTO(&event.daddr_v4, sk, __sk_common.skc_daddr);
+			BPF_CORE_READ_INTO(&event.dport, sk, __sk_common.skc_dport);
+		}
+		break;
+	case AF_INET6:
+		BPF_CORE_READ_INTO(&event.saddr_v6, sk,
+				   __sk_common.skc_v6_rcv_saddr.in6_u.u6_addr32);
+		if (msg_name) {
+			struct sockaddr_in6 sin6;
+
+			if (bpf_probe_read_kernel(&sin6, sizeof(sin6), msg_name))
+				goto end;
+			__builtin_memcpy(event.daddr, &sin6.sin6_addr, sizeof(event.daddr));
+			event.dport = sin6.sin6_port;
+		} else {
+			BPF_CORE_READ_INTO(&event.daddr_v6, sk,
+					   __sk_common.skc_v6_daddr.in6_u.u6_addr32);
+			BPF_CORE_READ_INTO(&event.dport, sk, __sk_common.skc_dport);
+		}
+		break;
+	default:
+		goto end;
+	}
+
+	// The socket is bound on the first send, read the port on return
+	BPF_CORE_READ_INTO(&event.sport, sockp, inet_sport);
+
+	fill_current(&event);
+	event.type = UDP_EVENT_TYPE_SEND;
+	event.bytes = ret;
+	BPF_CORE_READ_INTO(&event.netns, sk, __sk_common.skc_net.net, ns.inum);
+	event.timestamp = bpf_ktime_get_boot_ns();
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+
+end:
+	bpf_map_delete_elem(&sends, &tid);
+	return 0;
+}
+
+SEC("kretprobe/udp_sendmsg")
+int BPF_KRETPROBE(ig_udp_send_x, int ret)
+{
+	return exit_udp_sendmsg(ctx, ret);
+}
+
+SEC("kretprobe/udpv6_sendmsg")
+int BPF_KRETPROBE(ig_udp6_send_x, int ret)
+{
+	return exit_udp_sendmsg(ctx, ret);
+}
+
+/*
+ * skb_consume_udp() is called by both udp_recvmsg() and udpv6_recvmsg() once
+ * the datagram was copied to the user, in the context of the receiver.
+ */
+SEC("kprobe/skb_consume_udp")
+int BPF_KPROBE(ig_udp_recv, struct sock *sk, struct sk_buff *skb, int len)
+{
+	struct event event = {};
+
+	if (len < 0)
+		return 0;
+
+	if (gadget_should_discard_mntns_id(gadget_get_mntns_id()))
+		return 0;
+
+	if (!fill_from_skb(&event, skb))
+		return 0;
+
+	fill_current(&event);
+	event.type = UDP_EVENT_TYPE_RECV;
+	event.bytes = len;
+	BPF_CORE_READ_INTO(&event.netns, sk, __sk_common.skc_net.net, ns.inum);
+	event.timestamp = bpf_ktime_get_boot_ns();
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+
+	return 0;
+}
+
+SEC("kprobe/__udp_enqueue_schedule_skb")
+int BPF_KPROBE(ig_udp_enq_e, struct sock *sk, struct sk_buff *skb)
+{
+	__u32 zero = 0;
+	struct enqueue_args args = {
+		.sk = sk,
+		.skb = skb,
+	};
+
+	bpf_map_update_elem(&enqueues, &zero, &args, 0);
+	return 0;
+}
+
+/*
+ * __udp_enqueue_schedule_skb() fails when the receive buffer of the socket is
+ * full (-ENOMEM) or when the memory accounting limits are hit (-ENOBUFS); the
+ * datagram is dropped in both cases.
+ */
+SEC("kretprobe/__udp_enqueue_schedule_skb")
+int BPF_KRETPROBE(ig_udp_enq_x, int ret)
+{
+	struct sockets_value *skb_val;
+	struct enqueue_args *args;
+	struct event event = {};
+	struct sk_buff *skb;
+	struct sock *sk;
+	__u32 zero = 0;
+	__u32 len;
+
+	if (ret >= 0)
+		return 0;
+
+	args = bpf_map_lookup_elem(&enqueues, &zero);
+	if (!args)
+		return 0;
+
+	sk = args->sk;
+	skb = args->skb;
+
+	if (!fill_from_skb(&event, skb))
+		return 0;
+
+	BPF_CORE_READ_INTO(&event.netns, sk, __sk_common.skc_net.net, ns.inum);
+
+	// We aren't in the context of the receiver, use the socket owner
+	skb_val = gadget_socket_lookup(sk, event.netns);
+	if (skb_val != NULL) {
+		event.mntns_id = skb_val->mntns;
+		event.pid = skb_val->pid_tgid >> 32;
+		__builtin_memcpy(&event.task, skb_val->task, sizeof(event.task));
+	}
+
+	if (gadget_should_discard_mntns_id(event.mntns_id))
+		return 0;
+
+	// skb->data points to the UDP header at this point
+	len = BPF_CORE_READ(skb, len);
+	event.bytes = len > sizeof(struct udphdr) ? len - sizeof(struct udphdr) : 0;
+	event.type = UDP_EVENT_TYPE_DROP;
+	event.timestamp = bpf_ktime_get_boot_ns();
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
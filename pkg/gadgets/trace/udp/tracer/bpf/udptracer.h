@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+
+#ifndef __UDPTRACER_H
+#define __UDPTRACER_H
+
+/* The maximum number of items in maps */
+#define MAX_ENTRIES 8192
+
+#define TASK_COMM_LEN 16
+
+enum event_type : u8 {
+	UDP_EVENT_TYPE_SEND,
+	UDP_EVENT_TYPE_RECV,
+	UDP_EVENT_TYPE_DROP,
+};
+
+struct event {
+	union {
+		__u8 saddr[16];
+		unsigned __int128 saddr_v6;
+		__u32 saddr_v4;
+	};
+	union {
+		__u8 daddr[16];
+		unsigned __int128 daddr_v6;
+		__u32 daddr_v4;
+	};
+	__u8 task[TASK_COMM_LEN];
+	__u64 mntns_id;
+	__u64 timestamp;
+	__u32 af; // AF_INET or AF_INET6
+	__u32 pid;
+	__u32 uid;
+	__u32 netns;
+	__u32 bytes;
+	__u16 dport;
+	__u16 sport;
+	enum event_type type;
+};
+
+#endif /* __UDPTRACER_H */
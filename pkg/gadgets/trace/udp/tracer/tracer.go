@@ -0,0 +1,245 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !withoutebpf
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"unsafe"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"github.com/cilium/ebpf/perf"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/networktracer"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/internal/socketenricher"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/udp/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+//go:generate go run github.com/cilium/ebpf/cmd/bpf2go -target $TARGET -cc clang -no-global-types -type event -type event_type udptracer ./bpf/udptracer.bpf.c -- -I./bpf/ -I../../../../${TARGET} -I../../../common/ -I../../../internal/socketenricher/bpf
+
+type Config struct {
+	MountnsMap *ebpf.Map
+}
+
+type Tracer struct {
+	config         *Config
+	socketEnricher *socketenricher.SocketEnricher
+	eventCallback  func(*types.Event)
+
+	objs udptracerObjects
+
+	udpSendmsgEnterLink   link.Link
+	udpSendmsgExitLink    link.Link
+	udpv6SendmsgEnterLink link.Link
+	udpv6SendmsgExitLink  link.Link
+	skbConsumeUDPLink     link.Link
+	udpEnqueueEnterLink   link.Link
+	udpEnqueueExitLink    link.Link
+
+	reader *perf.Reader
+}
+
+func (g *GadgetDesc) NewInstance() (gadgets.Gadget, error) {
+	return &Tracer{
+		config: &Config{},
+	}, nil
+}
+
+func (t *Tracer) Run(gadgetCtx gadgets.GadgetContext) error {
+	defer t.close()
+	if err := t.install(); err != nil {
+		return fmt.Errorf("installing tracer: %w", err)
+	}
+
+	go t.run()
+	gadgetcontext.WaitForTimeoutOrDone(gadgetCtx)
+
+	return nil
+}
+
+func (t *Tracer) SetMountNsMap(mountnsMap *ebpf.Map) {
+	t.config.MountnsMap = mountnsMap
+}
+
+func (t *Tracer) SetEventHandler(handler any) {
+	nh, ok := handler.(func(ev *types.Event))
+	if !ok {
+		panic("event handler invalid")
+	}
+	t.eventCallback = nh
+}
+
+func (t *Tracer) close() {
+	t.udpSendmsgEnterLink = gadgets.CloseLink(t.udpSendmsgEnterLink)
+	t.udpSendmsgExitLink = gadgets.CloseLink(t.udpSendmsgExitLink)
+	t.udpv6SendmsgEnterLink = gadgets.CloseLink(t.udpv6SendmsgEnterLink)
+	t.udpv6SendmsgExitLink = gadgets.CloseLink(t.udpv6SendmsgExitLink)
+	t.skbConsumeUDPLink = gadgets.CloseLink(t.skbConsumeUDPLink)
+	t.udpEnqueueEnterLink = gadgets.CloseLink(t.udpEnqueueEnterLink)
+	t.udpEnqueueExitLink = gadgets.CloseLink(t.udpEnqueueExitLink)
+
+	if t.reader != nil {
+		t.reader.Close()
+	}
+
+	if t.socketEnricher != nil {
+		t.socketEnricher.Close()
+	}
+
+	t.objs.Close()
+}
+
+func (t *Tracer) install() error {
+	var err error
+
+	// Drops happen in softirq context, the socket enricher provides the
+	// process owning the socket
+	t.socketEnricher, err = socketenricher.NewSocketEnricher()
+	if err != nil {
+		return err
+	}
+
+	spec, err := loadUdptracer()
+	if err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	gadgets.FixBpfKtimeGetBootNs(spec.Programs)
+
+	mapReplacements := map[string]*ebpf.Map{}
+	mapReplacements[networktracer.SocketsMapName] = t.socketEnricher.SocketsMap()
+	if t.config.MountnsMap != nil {
+		mapReplacements[gadgets.MntNsFilterMapName] = t.config.MountnsMap
+	}
+
+	consts := map[string]interface{}{
+		gadgets.FilterByMntNsName: t.config.MountnsMap != nil,
+	}
+	if err := spec.RewriteConstants(consts); err != nil {
+		return fmt.Errorf("rewriting constants: %w", err)
+	}
+
+	opts := ebpf.CollectionOptions{
+		MapReplacements: mapReplacements,
+	}
+	if err := spec.LoadAndAssign(&t.objs, &opts); err != nil {
+		return fmt.Errorf("loading ebpf program: %w", err)
+	}
+
+	t.udpSendmsgEnterLink, err = link.Kprobe("udp_sendmsg", t.objs.IgUdpSendE, nil)
+	if err != nil {
+		return fmt.Errorf("attaching kprobe: %w", err)
+	}
+
+	t.udpSendmsgExitLink, err = link.Kretprobe("udp_sendmsg", t.objs.IgUdpSendX, nil)
+	if err != nil {
+		return fmt.Errorf("attaching kretprobe: %w", err)
+	}
+
+	t.udpv6SendmsgEnterLink, err = link.Kprobe("udpv6_sendmsg", t.objs.IgUdp6SendE, nil)
+	if err != nil {
+		return fmt.Errorf("attaching kprobe: %w", err)
+	}
+
+	t.udpv6SendmsgExitLink, err = link.Kretprobe("udpv6_sendmsg", t.objs.IgUdp6SendX, nil)
+	if err != nil {
+		return fmt.Errorf("attaching kretprobe: %w", err)
+	}
+
+	t.skbConsumeUDPLink, err = link.Kprobe("skb_consume_udp", t.objs.IgUdpRecv, nil)
+	if err != nil {
+		return fmt.Errorf("attaching kprobe: %w", err)
+	}
+
+	t.udpEnqueueEnterLink, err = link.Kprobe("__udp_enqueue_schedule_skb", t.objs.IgUdpEnqE, nil)
+	if err != nil {
+		return fmt.Errorf("attaching kprobe: %w", err)
+	}
+
+	t.udpEnqueueExitLink, err = link.Kretprobe("__udp_enqueue_schedule_skb", t.objs.IgUdpEnqX, nil)
+	if err != nil {
+		return fmt.Errorf("attaching kretprobe: %w", err)
+	}
+
+	reader, err := perf.NewReader(t.objs.udptracerMaps.Events, gadgets.PerfBufferPages*os.Getpagesize())
+	if err != nil {
+		return fmt.Errorf("creating perf ring buffer: %w", err)
+	}
+	t.reader = reader
+
+	return nil
+}
+
+func (t *Tracer) run() {
+	for {
+		record, err := t.reader.Read()
+		if err != nil {
+			if errors.Is(err, perf.ErrClosed) {
+				// nothing to do, we're done
+				return
+			}
+
+			msg := fmt.Sprintf("reading perf ring buffer: %s", err)
+			t.eventCallback(types.Base(eventtypes.Err(msg)))
+			return
+		}
+
+		if record.LostSamples > 0 {
+			msg := fmt.Sprintf("lost %d samples", record.LostSamples)
+			t.eventCallback(types.Base(eventtypes.Warn(msg)))
+			continue
+		}
+
+		bpfEvent := (*udptracerEvent)(unsafe.Pointer(&record.RawSample[0]))
+
+		ipversion := gadgets.IPVerFromAF(bpfEvent.Af)
+
+		event := types.Event{
+			Event: eventtypes.Event{
+				Type:      eventtypes.NORMAL,
+				Timestamp: gadgets.WallTimeFromBootTime(bpfEvent.Timestamp),
+			},
+			WithMountNsID: eventtypes.WithMountNsID{MountNsID: bpfEvent.MntnsId},
+			WithNetNsID:   eventtypes.WithNetNsID{NetNsID: uint64(bpfEvent.Netns)},
+			Pid:           bpfEvent.Pid,
+			Comm:          gadgets.FromCString(bpfEvent.Task[:]),
+			Uid:           bpfEvent.Uid,
+			IPVersion:     ipversion,
+			Saddr:         gadgets.IPStringFromBytes(bpfEvent.Saddr, ipversion),
+			Daddr:         gadgets.IPStringFromBytes(bpfEvent.Daddr, ipversion),
+			Sport:         gadgets.Htons(bpfEvent.Sport),
+			Dport:         gadgets.Htons(bpfEvent.Dport),
+			Bytes:         bpfEvent.Bytes,
+		}
+
+		switch bpfEvent.Type {
+		case udptracerEventTypeUDP_EVENT_TYPE_SEND:
+			event.Operation = "send"
+		case udptracerEventTypeUDP_EVENT_TYPE_RECV:
+			event.Operation = "recv"
+		case udptracerEventTypeUDP_EVENT_TYPE_DROP:
+			event.Operation = "drop"
+		}
+
+		t.eventCallback(&event)
+	}
+}
@@ -0,0 +1,174 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type udptracerEvent struct {
+	Saddr     [16]uint8
+	Daddr     [16]uint8
+	Task      [16]uint8
+	MntnsId   uint64
+	Timestamp uint64
+	Af        uint32
+	Pid       uint32
+	Uid       uint32
+	Netns     uint32
+	Bytes     uint32
+	Dport     uint16
+	Sport     uint16
+	Type      udptracerEventType
+	_         [7]byte
+}
+
+type udptracerEventType uint8
+
+const (
+	udptracerEventTypeUDP_EVENT_TYPE_SEND udptracerEventType = 0
+	udptracerEventTypeUDP_EVENT_TYPE_RECV udptracerEventType = 1
+	udptracerEventTypeUDP_EVENT_TYPE_DROP udptracerEventType = 2
+)
+
+// loadUdptracer returns the embedded CollectionSpec for udptracer.
+func loadUdptracer() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_UdptracerBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load udptracer: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadUdptracerObjects loads udptracer and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*udptracerObjects
+//	*udptracerPrograms
+//	*udptracerMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadUdptracerObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadUdptracer()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// udptracerSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type udptracerSpecs struct {
+	udptracerProgramSpecs
+	udptracerMapSpecs
+}
+
+// udptracerSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type udptracerProgramSpecs struct {
+	IgUdp6SendE *ebpf.ProgramSpec `ebpf:"ig_udp6_send_e"`
+	IgUdp6SendX *ebpf.ProgramSpec `ebpf:"ig_udp6_send_x"`
+	IgUdpEnqE   *ebpf.ProgramSpec `ebpf:"ig_udp_enq_e"`
+	IgUdpEnqX   *ebpf.ProgramSpec `ebpf:"ig_udp_enq_x"`
+	IgUdpRecv   *ebpf.ProgramSpec `ebpf:"ig_udp_recv"`
+	IgUdpSendE  *ebpf.ProgramSpec `ebpf:"ig_udp_send_e"`
+	IgUdpSendX  *ebpf.ProgramSpec `ebpf:"ig_udp_send_x"`
+}
+
+// udptracerMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type udptracerMapSpecs struct {
+	Enqueues             *ebpf.MapSpec `ebpf:"enqueues"`
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Sends                *ebpf.MapSpec `ebpf:"sends"`
+	Sockets              *ebpf.MapSpec `ebpf:"sockets"`
+}
+
+// udptracerObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadUdptracerObjects or ebpf.CollectionSpec.LoadAndAssign.
+type udptracerObjects struct {
+	udptracerPrograms
+	udptracerMaps
+}
+
+func (o *udptracerObjects) Close() error {
+	return _UdptracerClose(
+		&o.udptracerPrograms,
+		&o.udptracerMaps,
+	)
+}
+
+// udptracerMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadUdptracerObjects or ebpf.CollectionSpec.LoadAndAssign.
+type udptracerMaps struct {
+	Enqueues             *ebpf.Map `ebpf:"enqueues"`
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Sends                *ebpf.Map `ebpf:"sends"`
+	Sockets              *ebpf.Map `ebpf:"sockets"`
+}
+
+func (m *udptracerMaps) Close() error {
+	return _UdptracerClose(
+		m.Enqueues,
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.Sends,
+		m.Sockets,
+	)
+}
+
+// udptracerPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadUdptracerObjects or ebpf.CollectionSpec.LoadAndAssign.
+type udptracerPrograms struct {
+	IgUdp6SendE *ebpf.Program `ebpf:"ig_udp6_send_e"`
+	IgUdp6SendX *ebpf.Program `ebpf:"ig_udp6_send_x"`
+	IgUdpEnqE   *ebpf.Program `ebpf:"ig_udp_enq_e"`
+	IgUdpEnqX   *ebpf.Program `ebpf:"ig_udp_enq_x"`
+	IgUdpRecv   *ebpf.Program `ebpf:"ig_udp_recv"`
+	IgUdpSendE  *ebpf.Program `ebpf:"ig_udp_send_e"`
+	IgUdpSendX  *ebpf.Program `ebpf:"ig_udp_send_x"`
+}
+
+func (p *udptracerPrograms) Close() error {
+	return _UdptracerClose(
+		p.IgUdp6SendE,
+		p.IgUdp6SendX,
+		p.IgUdpEnqE,
+		p.IgUdpEnqX,
+		p.IgUdpRecv,
+		p.IgUdpSendE,
+		p.IgUdpSendX,
+	)
+}
+
+func _UdptracerClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed udptracer_bpfel_x86.o
+var _UdptracerBytes []byte
@@ -0,0 +1,63 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+	eventtypes.WithNetNsID
+
+	Operation string `json:"operation,omitempty" column:"t,width:1,fixed"`
+	Pid       uint32 `json:"pid,omitempty" column:"pid,template:pid"`
+	Comm      string `json:"comm,omitempty" column:"comm,template:comm"`
+	Uid       uint32 `json:"uid,omitempty" column:"uid,template:uid,hide"`
+	IPVersion int    `json:"ipversion,omitempty" column:"ip,template:ipversion"`
+	Saddr     string `json:"saddr,omitempty" column:"saddr,template:ipaddr"`
+	Daddr     string `json:"daddr,omitempty" column:"daddr,template:ipaddr"`
+	Sport     uint16 `json:"sport,omitempty" column:"sport,template:ipport"`
+	Dport     uint16 `json:"dport,omitempty" column:"dport,template:ipport"`
+	Bytes     uint32 `json:"bytes,omitempty" column:"bytes,minWidth:5,maxWidth:8,align:right"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	udpColumns := columns.MustCreateColumns[Event]()
+
+	udpColumns.MustSetExtractor("t", func(event *Event) (ret string) {
+		operations := map[string]string{
+			"send": "S",
+			"recv": "R",
+			"drop": "D",
+		}
+
+		if op, ok := operations[event.Operation]; ok {
+			return op
+		}
+
+		return "U"
+	})
+
+	return udpColumns
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
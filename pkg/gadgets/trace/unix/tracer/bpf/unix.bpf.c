@@ -0,0 +1,161 @@
+// SPDX-License-Identifier: GPL-2.0
+/* Copyright (c) 2023 The Inspektor Gadget authors */
+#include <vmlinux/vmlinux.h>
+#include <bpf/bpf_helpers.h>
+#include <bpf/bpf_core_read.h>
+#include <bpf/bpf_tracing.h>
+#include "unix.h"
+#include "mntns_filter.h"
+
+#define MAX_ENTRIES	10240
+
+// we need this to make sure the compiler doesn't remove our struct
+const struct event *unusedevent __attribute__((unused));
+
+// The connections in progress, indexed by thread. The event is filled in when
+// the kernel found the listening socket and sent when connect() returns.
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__type(value, struct event);
+} starts SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, MAX_ENTRIES);
+	__type(key, __u32);
+	__type(value, struct socket *);
+} accepts SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERF_EVENT_ARRAY);
+	__uint(key_size, sizeof(__u32));
+	__uint(value_size, sizeof(__u32));
+} events SEC(".maps");
+
+static __always_inline void fill_event(struct event *event, enum unix_op op)
+{
+	__u64 pid_tgid = bpf_get_current_pid_tgid();
+
+	event->mntns_id = gadget_get_mntns_id();
+	event->pid = pid_tgid >> 32;
+	event->tid = (__u32)pid_tgid;
+	event->uid = (__u32)bpf_get_current_uid_gid();
+	event->op = op;
+	bpf_get_current_comm(&event->task, sizeof(event->task));
+}
+
+// The kernel keeps the credentials of the other end in the socket: the ones of
+// the process that called listen() for a listening socket, of the process that
+// called connect() for an accepted one
+static __always_inline void fill_peer(struct event *event, struct sock *sk)
+{
+	event->peer_pid = BPF_CORE_READ(sk, sk_peer_pid, numbers[0].nr);
+	event->peer_uid = BPF_CORE_READ(sk, sk_peer_cred, euid.val);
+}
+
+// The path the listening socket is bound to, the accepted sockets share the
+// address of the listening one
+static __always_inline void fill_path(struct event *event, struct sock *sk)
+{
+	struct unix_address *addr;
+	int len;
+
+	addr = BPF_CORE_READ((struct unix_sock *)sk, addr);
+	if (!addr)
+		return;
+
+	len = BPF_CORE_READ(addr, len) - sizeof(short);
+	if (len <= 0)
+		return;
+	if (len > UNIX_PATH_MAX)
+		len = UNIX_PATH_MAX;
+
+	event->path_len = len;
+	bpf_core_read(&event->path, sizeof(event->path), &addr->name[0].sun_path);
+}
+
+// Called by unix_stream_connect() once it found the listening socket, with the
+// socket connecting and the listening one
+SEC("kprobe/security_unix_stream_connect")
+int BPF_KPROBE(ig_unix_conn_e, struct sock *sock, struct sock *other)
+{
+	struct event event = {};
+
+	if (gadget_should_discard_mntns_id(gadget_get_mntns_id()))
+		return 0;
+
+	fill_event(&event, UNIX_OP_CONNECT);
+	fill_peer(&event, other);
+	fill_path(&event, other);
+	event.sock_type = BPF_CORE_READ(sock, sk_socket, type);
+
+	bpf_map_update_elem(&starts, &event.tid, &event, BPF_ANY);
+	return 0;
+}
+
+SEC("kretprobe/unix_stream_connect")
+int BPF_KRETPROBE(ig_unix_conn_x, int ret)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	struct event *event;
+
+	event = bpf_map_lookup_elem(&starts, &tid);
+	if (!event)
+		return 0;
+
+	event->timestamp = bpf_ktime_get_boot_ns();
+	event->ret = ret;
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, event, sizeof(*event));
+
+	bpf_map_delete_elem(&starts, &tid);
+	return 0;
+}
+
+// The new socket was allocated by the caller, it's only connected when
+// unix_accept() returns
+SEC("kprobe/unix_accept")
+int BPF_KPROBE(ig_unix_acc_e, struct socket *sock, struct socket *newsock)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+
+	if (gadget_should_discard_mntns_id(gadget_get_mntns_id()))
+		return 0;
+
+	bpf_map_update_elem(&accepts, &tid, &newsock, BPF_ANY);
+	return 0;
+}
+
+// Only the accepted connections are reported, failures are mostly
+// non-blocking sockets without pending connection
+SEC("kretprobe/unix_accept")
+int BPF_KRETPROBE(ig_unix_acc_x, int ret)
+{
+	__u32 tid = (__u32)bpf_get_current_pid_tgid();
+	struct event event = {};
+	struct socket **newsock;
+	struct sock *sk;
+
+	newsock = bpf_map_lookup_elem(&accepts, &tid);
+	if (!newsock)
+		return 0;
+	if (ret != 0)
+		goto cleanup;
+
+	sk = BPF_CORE_READ(*newsock, sk);
+
+	fill_event(&event, UNIX_OP_ACCEPT);
+	fill_peer(&event, sk);
+	fill_path(&event, sk);
+	event.sock_type = BPF_CORE_READ(*newsock, type);
+	event.timestamp = bpf_ktime_get_boot_ns();
+
+	bpf_perf_event_output(ctx, &events, BPF_F_CURRENT_CPU, &event, sizeof(event));
+
+cleanup:
+	bpf_map_delete_elem(&accepts, &tid);
+	return 0;
+}
+
+char LICENSE[] SEC("license") = "GPL";
@@ -0,0 +1,35 @@
+/* SPDX-License-Identifier: GPL-2.0 */
+#ifndef GADGET_TRACE_UNIX_H
+#define GADGET_TRACE_UNIX_H
+
+#define TASK_COMM_LEN	16
+#define UNIX_PATH_MAX	108
+
+enum unix_op : u8 {
+	UNIX_OP_CONNECT,
+	UNIX_OP_ACCEPT,
+};
+
+struct event {
+	__u64 mntns_id;
+	__u64 timestamp;
+	__u32 pid;
+	__u32 tid;
+	__u32 uid;
+	// Process at the other end: the one that created the listening socket
+	// for connect, the one that connected for accept. PID in the namespace
+	// of the node.
+	__u32 peer_pid;
+	__u32 peer_uid;
+	// Result of the connection, 0 or -errno
+	__s32 ret;
+	// Length of the path, abstract names start with a null byte
+	__u32 path_len;
+	// SOCK_STREAM or SOCK_SEQPACKET
+	__u16 sock_type;
+	enum unix_op op;
+	__u8 task[TASK_COMM_LEN];
+	__u8 path[UNIX_PATH_MAX];
+};
+
+#endif /* GADGET_TRACE_UNIX_H */
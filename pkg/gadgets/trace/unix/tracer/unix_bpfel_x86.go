@@ -0,0 +1,161 @@
+// Code generated by bpf2go; DO NOT EDIT.
+//go:build 386 || amd64
+
+package tracer
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+
+	"github.com/cilium/ebpf"
+)
+
+type unixEvent struct {
+	MntnsId   uint64
+	Timestamp uint64
+	Pid       uint32
+	Tid       uint32
+	Uid       uint32
+	PeerPid   uint32
+	PeerUid   uint32
+	Ret       int32
+	PathLen   uint32
+	SockType  uint16
+	Op        unixUnixOp
+	Task      [16]uint8
+	Path      [108]uint8
+	_         [5]byte
+}
+
+type unixUnixOp uint8
+
+const (
+	unixUnixOpUNIX_OP_CONNECT unixUnixOp = 0
+	unixUnixOpUNIX_OP_ACCEPT  unixUnixOp = 1
+)
+
+// loadUnix returns the embedded CollectionSpec for unix.
+func loadUnix() (*ebpf.CollectionSpec, error) {
+	reader := bytes.NewReader(_UnixBytes)
+	spec, err := ebpf.LoadCollectionSpecFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("can't load unix: %w", err)
+	}
+
+	return spec, err
+}
+
+// loadUnixObjects loads unix and converts it into a struct.
+//
+// The following types are suitable as obj argument:
+//
+//	*unixObjects
+//	*unixPrograms
+//	*unixMaps
+//
+// See ebpf.CollectionSpec.LoadAndAssign documentation for details.
+func loadUnixObjects(obj interface{}, opts *ebpf.CollectionOptions) error {
+	spec, err := loadUnix()
+	if err != nil {
+		return err
+	}
+
+	return spec.LoadAndAssign(obj, opts)
+}
+
+// unixSpecs contains maps and programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type unixSpecs struct {
+	unixProgramSpecs
+	unixMapSpecs
+}
+
+// unixSpecs contains programs before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type unixProgramSpecs struct {
+	IgUnixAccE  *ebpf.ProgramSpec `ebpf:"ig_unix_acc_e"`
+	IgUnixAccX  *ebpf.ProgramSpec `ebpf:"ig_unix_acc_x"`
+	IgUnixConnE *ebpf.ProgramSpec `ebpf:"ig_unix_conn_e"`
+	IgUnixConnX *ebpf.ProgramSpec `ebpf:"ig_unix_conn_x"`
+}
+
+// unixMapSpecs contains maps before they are loaded into the kernel.
+//
+// It can be passed ebpf.CollectionSpec.Assign.
+type unixMapSpecs struct {
+	Accepts              *ebpf.MapSpec `ebpf:"accepts"`
+	Events               *ebpf.MapSpec `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.MapSpec `ebpf:"gadget_mntns_filter_map"`
+	Starts               *ebpf.MapSpec `ebpf:"starts"`
+}
+
+// unixObjects contains all objects after they have been loaded into the kernel.
+//
+// It can be passed to loadUnixObjects or ebpf.CollectionSpec.LoadAndAssign.
+type unixObjects struct {
+	unixPrograms
+	unixMaps
+}
+
+func (o *unixObjects) Close() error {
+	return _UnixClose(
+		&o.unixPrograms,
+		&o.unixMaps,
+	)
+}
+
+// unixMaps contains all maps after they have been loaded into the kernel.
+//
+// It can be passed to loadUnixObjects or ebpf.CollectionSpec.LoadAndAssign.
+type unixMaps struct {
+	Accepts              *ebpf.Map `ebpf:"accepts"`
+	Events               *ebpf.Map `ebpf:"events"`
+	GadgetMntnsFilterMap *ebpf.Map `ebpf:"gadget_mntns_filter_map"`
+	Starts               *ebpf.Map `ebpf:"starts"`
+}
+
+func (m *unixMaps) Close() error {
+	return _UnixClose(
+		m.Accepts,
+		m.Events,
+		m.GadgetMntnsFilterMap,
+		m.Starts,
+	)
+}
+
+// unixPrograms contains all programs after they have been loaded into the kernel.
+//
+// It can be passed to loadUnixObjects or ebpf.CollectionSpec.LoadAndAssign.
+type unixPrograms struct {
+	IgUnixAccE  *ebpf.Program `ebpf:"ig_unix_acc_e"`
+	IgUnixAccX  *ebpf.Program `ebpf:"ig_unix_acc_x"`
+	IgUnixConnE *ebpf.Program `ebpf:"ig_unix_conn_e"`
+	IgUnixConnX *ebpf.Program `ebpf:"ig_unix_conn_x"`
+}
+
+func (p *unixPrograms) Close() error {
+	return _UnixClose(
+		p.IgUnixAccE,
+		p.IgUnixAccX,
+		p.IgUnixConnE,
+		p.IgUnixConnX,
+	)
+}
+
+func _UnixClose(closers ...io.Closer) error {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Do not access this directly.
+//
+//go:embed unix_bpfel_x86.o
+var _UnixBytes []byte
@@ -0,0 +1,67 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package types
+
+import (
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperationConnect = "connect"
+	OperationAccept  = "accept"
+)
+
+type Event struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+	eventtypes.WithContainerUid
+
+	Pid       uint32 `json:"pid,omitempty" column:"pid,template:pid"`
+	Tid       uint32 `json:"tid,omitempty" column:"tid,template:pid,hide"`
+	Uid       uint32 `json:"uid" column:"uid,template:uid,hide"`
+	Comm      string `json:"comm,omitempty" column:"comm,template:comm"`
+	Operation string `json:"operation,omitempty" column:"op,width:7,fixed"`
+	// SocketType is stream or seqpacket
+	SocketType string `json:"socketType,omitempty" column:"type,width:9,hide"`
+	// Path is the path of the listening socket, abstract names start with
+	// "@"
+	Path string `json:"path,omitempty" column:"path,width:32"`
+
+	// The process at the other end: the one that created the listening
+	// socket for connect, e.g. systemd for the sockets it activates, and the
+	// one that connected for accept
+	PeerPid       uint32 `json:"peerPid,omitempty" column:"peerpid,template:pid"`
+	PeerUid       uint32 `json:"peerUid" column:"peeruid,template:uid,hide"`
+	PeerComm      string `json:"peerComm,omitempty" column:"peercomm,template:comm"`
+	PeerMountNsID uint64 `json:"peerMountnsid,omitempty" column:"peermntns,template:ns"`
+
+	Ret int32  `json:"ret,omitempty" column:"ret,width:4,hide"`
+	Err string `json:"err,omitempty" column:"err,width:12"`
+}
+
+func GetColumns() *columns.Columns[Event] {
+	return columns.MustCreateColumns[Event]()
+}
+
+func (e *Event) GetUid() uint32 {
+	return e.Uid
+}
+
+func Base(ev eventtypes.Event) *Event {
+	return &Event{
+		Event: ev,
+	}
+}
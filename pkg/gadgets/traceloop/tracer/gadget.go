@@ -52,6 +52,15 @@ func (g *GadgetDesc) EventPrototype() any {
 	return &types.Event{}
 }
 
+func (g *GadgetDesc) Cost() gadgets.Cost {
+	return gadgets.Cost{
+		Probes:     2,
+		Events:     "every syscall, recorded in a buffer per container",
+		EventCost:  gadgets.CostHigh,
+		BufferSize: gadgets.PerfBufferSize(),
+	}
+}
+
 func init() {
 	gadgetregistry.Register(&GadgetDesc{})
 }
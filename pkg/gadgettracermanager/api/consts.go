@@ -19,6 +19,9 @@ const (
 	EventTypeGadgetResult  uint32 = 1
 	EventTypeGadgetDone    uint32 = 2
 	EventTypeGadgetJobID   uint32 = 3
+	// EventTypeGadgetClock carries the time of the node, in nanoseconds
+	// since the epoch, for the client to estimate the offset of its clock
+	EventTypeGadgetClock uint32 = 4
 
 	EventLogShift = 16
 )
@@ -63,6 +63,22 @@ func (g *GadgetTracerManager) AddTracer(tracerID string, containerSelector conta
 	return g.tracerCollection.AddTracer(tracerID, containerSelector)
 }
 
+func (g *GadgetTracerManager) UpdateTracer(tracerID string, containerSelector containercollection.ContainerSelector) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.tracerCollection.UpdateTracer(tracerID, containerSelector)
+}
+
+// TracerSelects tells whether the current container selector of a tracer
+// matches the container, see UpdateTracer
+func (g *GadgetTracerManager) TracerSelects(tracerID string, container *containercollection.Container) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.tracerCollection.TracerSelects(tracerID, container)
+}
+
 func (g *GadgetTracerManager) RemoveTracer(tracerID string) error {
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -256,6 +272,8 @@ func NewServer(conf *Conf) (*GadgetTracerManager, error) {
 		opts = append(opts, containercollection.WithCgroupEnrichment())
 		opts = append(opts, containercollection.WithLinuxNamespaceEnrichment())
 		opts = append(opts, containercollection.WithKubernetesEnrichment(g.nodeName, nil))
+		opts = append(opts, containercollection.WithNamespaceLabelsEnrichment(nil))
+		opts = append(opts, containercollection.WithWorkloadEnrichment(nil))
 		opts = append(opts, containercollection.WithTracerCollection(g.tracerCollection))
 	}
 
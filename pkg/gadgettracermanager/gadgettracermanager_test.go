@@ -55,6 +55,26 @@ func TestTracer(t *testing.T) {
 		t.Fatal("Error while trying to add a duplicate tracer: duplicate not detected")
 	}
 
+	// Change the selector of a tracer
+	err = g.UpdateTracer(
+		fmt.Sprintf("my_tracer_id%d", 0),
+		containercollection.ContainerSelector{
+			Namespace: "other-namespace",
+		},
+	)
+	if err != nil {
+		t.Fatalf("Failed to update tracer: %v", err)
+	}
+
+	// Check error on updating non-existent tracer
+	err = g.UpdateTracer(
+		fmt.Sprintf("my_tracer_id%d", 99),
+		containercollection.ContainerSelector{},
+	)
+	if err == nil {
+		t.Fatal("Error while updating non-existent tracer: no error detected")
+	}
+
 	// Remove 1 Tracer
 	err = g.RemoveTracer(fmt.Sprintf("my_tracer_id%d", 1))
 	if err != nil {
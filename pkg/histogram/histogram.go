@@ -28,6 +28,7 @@ type Unit string
 const (
 	UnitMilliseconds Unit = "ms"
 	UnitMicroseconds Unit = "µs"
+	UnitPermille     Unit = "‰"
 )
 
 type Interval struct {
@@ -16,6 +16,7 @@ package igmanager
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/cilium/ebpf"
 	"github.com/cilium/ebpf/rlimit"
@@ -29,6 +30,9 @@ import (
 type IGManager struct {
 	containercollection.ContainerCollection
 
+	// mu protects the tracer of the mount namespace map, changed while the
+	// gadget runs
+	mu               sync.Mutex
 	tracerCollection *tracercollection.TracerCollection
 
 	// containersMap is the global map at /sys/fs/bpf/gadget/containers
@@ -57,6 +61,9 @@ func (l *IGManager) Dump() string {
 const igTracerID = "ig_tracer_id"
 
 func (l *IGManager) CreateMountNsMap(containerSelector containercollection.ContainerSelector) (*ebpf.Map, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	if err := l.tracerCollection.AddTracer(igTracerID, containerSelector); err != nil {
 		return nil, err
 	}
@@ -70,7 +77,28 @@ func (l *IGManager) CreateMountNsMap(containerSelector containercollection.Conta
 	return mountnsmap, nil
 }
 
+// UpdateMountNsMap changes the containers of the map returned by
+// CreateMountNsMap
+func (l *IGManager) UpdateMountNsMap(containerSelector containercollection.ContainerSelector) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.tracerCollection.UpdateTracer(igTracerID, containerSelector)
+}
+
+// MountNsMapSelects tells whether the current container selector of the map
+// returned by CreateMountNsMap matches the container
+func (l *IGManager) MountNsMapSelects(container *containercollection.Container) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.tracerCollection.TracerSelects(igTracerID, container)
+}
+
 func (l *IGManager) RemoveMountNsMap() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	return l.tracerCollection.RemoveTracer(igTracerID)
 }
 
@@ -17,12 +17,14 @@ package kallsyms
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cilium/ebpf"
 )
@@ -130,12 +132,121 @@ func (k *KAllSyms) SymbolExists(symbol string) bool {
 	return ok
 }
 
-var (
-	addrLock sync.Mutex
+// ErrAddressesHidden is returned when /proc/kallsyms only contains zero
+// addresses: reading them requires CAP_SYSLOG and a kernel.kptr_restrict
+// sysctl lower than 2.
+var ErrAddressesHidden = errors.New("kernel symbol addresses are hidden, check CAP_SYSLOG and kernel.kptr_restrict")
 
-	symbolsMap   = map[string]uint64{}
-	triedGetAddr = map[string]error{}
-)
+// How often the list of kernel modules is checked for changes.
+const modulesCheckInterval = time.Second
+
+// cache holds the kernel symbols shared by all the gadgets. /proc/kallsyms is
+// read again when kernel modules are loaded or unloaded as they add or move
+// symbols.
+type cache struct {
+	mu sync.Mutex
+
+	kAllSymsFactory func() (*KAllSyms, error)
+	modulesFactory  func() (string, error)
+
+	kAllSyms     *KAllSyms
+	modules      string
+	modulesRead  time.Time
+	modulesKnown bool
+}
+
+var sharedCache = newCache(NewKAllSyms, readModules)
+
+func newCache(kAllSymsFactory func() (*KAllSyms, error), modulesFactory func() (string, error)) *cache {
+	return &cache{
+		kAllSymsFactory: kAllSymsFactory,
+		modulesFactory:  modulesFactory,
+	}
+}
+
+// readModules returns the names and addresses of the kernel modules in
+// /proc/modules. Other fields, like the reference count, change too often to
+// be used to detect modules being loaded or unloaded.
+func readModules() (string, error) {
+	file, err := os.Open("/proc/modules")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	var modules strings.Builder
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// ip_tables 32768 0 - Live 0xffffffffc0a2e000
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 {
+			continue
+		}
+		modules.WriteString(fields[0] + " " + fields[5] + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return modules.String(), nil
+}
+
+// modulesChanged tells whether kernel modules were loaded or unloaded since
+// the last time the symbols were read. It has to be called with the lock held.
+func (c *cache) modulesChanged() bool {
+	if time.Since(c.modulesRead) < modulesCheckInterval {
+		return false
+	}
+	// Kernels without module support don't have /proc/modules
+	modules, err := c.modulesFactory()
+	c.modulesRead = time.Now()
+	if err != nil {
+		return false
+	}
+	return !c.modulesKnown || modules != c.modules
+}
+
+// refresh reads the symbols again. It has to be called with the lock held.
+func (c *cache) refresh() (*KAllSyms, error) {
+	// Read the modules before the symbols to be sure to refresh again if a
+	// module is loaded in between
+	modules, err := c.modulesFactory()
+	c.modulesRead = time.Now()
+	c.modules, c.modulesKnown = modules, err == nil
+
+	k, err := c.kAllSymsFactory()
+	if err != nil {
+		return nil, err
+	}
+	c.kAllSyms = k
+	return k, nil
+}
+
+func (c *cache) get() (*KAllSyms, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.kAllSyms != nil && !c.modulesChanged() {
+		return c.kAllSyms, nil
+	}
+	return c.refresh()
+}
+
+// Shared returns the kernel symbols shared by all the gadgets. The returned
+// KAllSyms must not be kept for long: call Shared() again each time symbols
+// are needed to get the new ones after a kernel module was loaded or
+// unloaded.
+func Shared() (*KAllSyms, error) {
+	return sharedCache.get()
+}
+
+// Refresh reads the kernel symbols of Shared() again, whether or not the
+// kernel modules changed.
+func Refresh() (*KAllSyms, error) {
+	sharedCache.mu.Lock()
+	defer sharedCache.mu.Unlock()
+
+	return sharedCache.refresh()
+}
 
 // SpecUpdateAddresses updates the addresses of the given symbols in the given
 // collection spec.
@@ -148,58 +259,35 @@ var (
 // Then, SpecUpdateAddresses() can be called in this way:
 //
 //	kallsyms.SpecUpdateAddresses(spec, []string{"socket_file_ops"})
+//
+// The addresses are resolved each time the spec is loaded, so symbols of
+// kernel modules loaded, or reloaded at another address, in the meantime are
+// found.
 func SpecUpdateAddresses(spec *ebpf.CollectionSpec, symbols []string) error {
-	kAllSymsFactory := NewKAllSyms
-	return specUpdateAddresses(kAllSymsFactory, spec, symbols)
+	return sharedCache.specUpdateAddresses(spec, symbols)
 }
 
-func specUpdateAddresses(
-	kAllSymsFactory func() (*KAllSyms, error),
-	spec *ebpf.CollectionSpec,
-	symbols []string,
-) error {
+func (c *cache) specUpdateAddresses(spec *ebpf.CollectionSpec, symbols []string) error {
 	if len(symbols) == 0 {
 		// Nothing to do
 		return nil
 	}
 
-	addrLock.Lock()
-	defer addrLock.Unlock()
-
-	// Are all the requested symbols in the cache?
-	allFoundInCache := true
-	for _, symbol := range symbols {
-		if _, ok := symbolsMap[symbol]; !ok {
-			if err, errFound := triedGetAddr[symbol]; errFound {
-				// We previously tried to find this symbol in the cache and failed.
-				return err
-			}
-			allFoundInCache = false
-			break
-		}
-	}
-
-	// Add the symbols that are not in the cache to the cache
-	if !allFoundInCache {
-		k, err := kAllSymsFactory()
-		if err != nil {
-			triedGetAddr[symbols[0]] = err
-			return err
-		}
-		for _, symbol := range symbols {
-			if _, ok := k.symbolsMap[symbol]; ok {
-				symbolsMap[symbol] = k.symbolsMap[symbol]
-			} else {
-				triedGetAddr[symbol] = os.ErrNotExist
-				return os.ErrNotExist
-			}
-		}
+	k, err := c.get()
+	if err != nil {
+		return err
 	}
 
-	// Rewrite the constants using symbol addresses from the cache
 	consts := map[string]interface{}{}
 	for _, symbol := range symbols {
-		consts[symbol+"_addr"] = symbolsMap[symbol]
+		addr, ok := k.symbolsMap[symbol]
+		if !ok {
+			return fmt.Errorf("looking up %q: %w", symbol, os.ErrNotExist)
+		}
+		if addr == 0 {
+			return fmt.Errorf("looking up %q: %w", symbol, ErrAddressesHidden)
+		}
+		consts[symbol+"_addr"] = addr
 	}
 
 	if err := spec.RewriteConstants(consts); err != nil {
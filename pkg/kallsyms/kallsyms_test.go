@@ -18,6 +18,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/cilium/ebpf"
 	"github.com/cilium/ebpf/btf"
@@ -132,15 +133,15 @@ func TestSpecRewriteConstants(t *testing.T) {
 		},
 	}
 
-	err := specUpdateAddresses(
-		kAllSymsFactory,
+	c := newCache(kAllSymsFactory, func() (string, error) { return "", nil })
+
+	err := c.specUpdateAddresses(
 		spec,
 		[]string{"abcde_bad_name"},
 	)
 	require.ErrorIs(t, err, os.ErrNotExist, "specRewriteConstantsWithSymbolAddresses should have failed")
 
-	err = specUpdateAddresses(
-		kAllSymsFactory,
+	err = c.specUpdateAddresses(
 		spec,
 		[]string{"bpf_prog_fops", "socket_file_ops"},
 	)
@@ -152,3 +153,56 @@ func TestSpecRewriteConstants(t *testing.T) {
 	contents := spec.Maps[".rodata"].Contents[0].Value
 	require.Equal(t, contents, expectedContents, "contents aren't equal")
 }
+
+func TestCacheRefresh(t *testing.T) {
+	kAllSymsStr := strings.Join([]string{
+		"ffffffffb4231f40 D bpf_prog_fops",
+		"ffffffffb43723e0 d socket_file_ops",
+	}, "\n")
+	modules := "ip_tables 0xffffffffc0a2e000\n"
+	reads := 0
+
+	c := newCache(
+		func() (*KAllSyms, error) {
+			reads++
+			return NewKAllSymsFromReader(strings.NewReader(kAllSymsStr))
+		},
+		func() (string, error) { return modules, nil },
+	)
+
+	k, err := c.get()
+	require.Nil(t, err, "get failed: %v", err)
+	require.False(t, k.SymbolExists("nf_conntrack_in"), "nf_conntrack_in shouldn't exist")
+
+	// The symbols are cached
+	_, err = c.get()
+	require.Nil(t, err, "get failed: %v", err)
+	require.Equal(t, 1, reads, "symbols were read again")
+
+	// A module adding symbols is loaded
+	kAllSymsStr += "\nffffffffc0b41230 t nf_conntrack_in\t[nf_conntrack]"
+	modules += "nf_conntrack 0xffffffffc0b30000\n"
+
+	// Modules are only checked from time to time
+	k, err = c.get()
+	require.Nil(t, err, "get failed: %v", err)
+	require.False(t, k.SymbolExists("nf_conntrack_in"), "modules were checked too early")
+
+	c.modulesRead = time.Time{}
+	k, err = c.get()
+	require.Nil(t, err, "get failed: %v", err)
+	require.True(t, k.SymbolExists("nf_conntrack_in"), "nf_conntrack_in should have been found after the module was loaded")
+	require.Equal(t, 2, reads, "symbols weren't read again")
+}
+
+func TestHiddenAddresses(t *testing.T) {
+	c := newCache(
+		func() (*KAllSyms, error) {
+			return NewKAllSymsFromReader(strings.NewReader("0000000000000000 d socket_file_ops"))
+		},
+		func() (string, error) { return "", nil },
+	)
+
+	err := c.specUpdateAddresses(&ebpf.CollectionSpec{}, []string{"socket_file_ops"})
+	require.ErrorIs(t, err, ErrAddressesHidden, "specUpdateAddresses should have failed")
+}
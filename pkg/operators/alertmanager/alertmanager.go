@@ -0,0 +1,138 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package alertmanager provides an operator that sends the events of the
+// gadgets classified as alerts to a Prometheus Alertmanager. It's disabled
+// unless the URL of the Alertmanager is configured.
+package alertmanager
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/routing"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperatorName = "Alertmanager"
+
+	ParamURL            = "alertmanager-url"
+	ParamSeverity       = "alertmanager-severity"
+	ParamResolveTimeout = "alertmanager-resolve-timeout"
+
+	// The environment variables allow to enable the operator on the deployed
+	// gadget pods, where global params can't be set
+	urlEnv      = "INSPEKTOR_GADGET_ALERTMANAGER_URL"
+	severityEnv = "INSPEKTOR_GADGET_ALERTMANAGER_SEVERITY"
+)
+
+type Alertmanager struct {
+	batcher     *cloudsink.Batcher
+	minSeverity eventtypes.Severity
+}
+
+func (a *Alertmanager) Name() string {
+	return OperatorName
+}
+
+func (a *Alertmanager) Description() string {
+	return "Alertmanager sends the alerts to a Prometheus Alertmanager"
+}
+
+func (a *Alertmanager) GlobalParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:         ParamURL,
+			Description: "URL of the Alertmanager to send the alerts to, e.g. http://alertmanager:9093. Empty disables it",
+		},
+		routing.SeverityParamDesc(ParamSeverity, eventtypes.SeverityAlert),
+		{
+			Key:          ParamResolveTimeout,
+			Description:  "Time after which the alerts are resolved if the event doesn't happen again",
+			DefaultValue: "5m",
+			TypeHint:     params.TypeDuration,
+		},
+	}
+}
+
+func (a *Alertmanager) ParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (a *Alertmanager) Dependencies() []string {
+	return nil
+}
+
+func (a *Alertmanager) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	return true
+}
+
+func (a *Alertmanager) IsSink() {}
+
+func (a *Alertmanager) Init(params *params.Params) error {
+	rawURL := params.Get(ParamURL).AsString()
+	if envURL := os.Getenv(urlEnv); envURL != "" && rawURL == "" {
+		rawURL = envURL
+	}
+	if rawURL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid Alertmanager URL %q", rawURL)
+	}
+
+	minSeverity, err := routing.MinSeverity(params.Get(ParamSeverity), severityEnv, eventtypes.SeverityAlert)
+	if err != nil {
+		return err
+	}
+	a.minSeverity = minSeverity
+
+	resolveTimeout := params.Get(ParamResolveTimeout).AsDuration()
+	if resolveTimeout <= 0 {
+		return fmt.Errorf("invalid resolve timeout %s", resolveTimeout)
+	}
+
+	client := newClient(u, resolveTimeout)
+	a.batcher = cloudsink.NewBatcher("Alertmanager", limits, time.Second, client.postAlerts)
+
+	log.Infof("sending alerts to Alertmanager %s", u.Redacted())
+	return nil
+}
+
+func (a *Alertmanager) Close() error {
+	if a.batcher == nil {
+		return nil
+	}
+	a.batcher.Close()
+	return nil
+}
+
+func (a *Alertmanager) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	return cloudsink.NewInstance("AlertmanagerInstance", gadgetCtx, a.batcher, a.minSeverity), nil
+}
+
+func init() {
+	operators.Register(&Alertmanager{})
+}
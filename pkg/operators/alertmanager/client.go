@@ -0,0 +1,115 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alertmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+)
+
+// limits keep the requests small, there shouldn't be many alerts at once
+var limits = cloudsink.Limits{
+	MaxRecords:     100,
+	MaxBytes:       1 << 20,
+	RecordOverhead: 512,
+}
+
+type client struct {
+	endpoint       string
+	node           string
+	resolveTimeout time.Duration
+}
+
+func newClient(u *url.URL, resolveTimeout time.Duration) *client {
+	return &client{
+		endpoint:       u.JoinPath("api", "v2", "alerts").String(),
+		node:           cloudsink.NodeName(),
+		resolveTimeout: resolveTimeout,
+	}
+}
+
+// postableAlert is an alert as accepted by the API v2 of Alertmanager
+type postableAlert struct {
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    string            `json:"startsAt"`
+	EndsAt      string            `json:"endsAt"`
+}
+
+func (c *client) alert(r *cloudsink.Record) postableAlert {
+	// Alertmanager groups and deduplicates the alerts by their labels, they
+	// identify where the event happened and not the event itself
+	labels := map[string]string{
+		"alertname": r.Gadget,
+		"severity":  severity(r.Severity),
+		"node":      c.node,
+	}
+	for _, field := range []string{"namespace", "pod", "container"} {
+		if value := r.String(field); value != "" {
+			labels[field] = value
+		}
+	}
+
+	return postableAlert{
+		Labels: labels,
+		Annotations: map[string]string{
+			"summary":     fmt.Sprintf("%s reported an event on %s", r.Gadget, c.node),
+			"description": string(r.Message),
+			"runID":       r.RunID,
+		},
+		StartsAt: r.Time.UTC().Format(time.RFC3339Nano),
+		EndsAt:   r.Time.Add(c.resolveTimeout).UTC().Format(time.RFC3339Nano),
+	}
+}
+
+func (c *client) postAlerts(ctx context.Context, records []*cloudsink.Record) error {
+	alerts := make([]postableAlert, 0, len(records))
+	for _, r := range records {
+		alerts = append(alerts, c.alert(r))
+	}
+
+	body, err := json.Marshal(alerts)
+	if err != nil {
+		return fmt.Errorf("marshaling alerts: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return cloudsink.Do(req, nil)
+}
+
+// severity returns the value of the severity label, using the names of the
+// Prometheus alerting rules
+func severity(s cloudsink.Severity) string {
+	switch s {
+	case cloudsink.SeverityAlert, cloudsink.SeverityError:
+		return "critical"
+	case cloudsink.SeverityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package azuremonitor provides an operator that sends the events of the
+// gadgets to a Log Analytics workspace of Azure Monitor, with the Logs
+// Ingestion API and a data collection rule. It authenticates with Azure AD
+// Workload Identity or with the managed identity of the node, and is disabled
+// unless an endpoint is configured.
+package azuremonitor
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/routing"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperatorName = "AzureMonitor"
+
+	ParamEndpoint = "azure-monitor-endpoint"
+	ParamRuleID   = "azure-monitor-rule-id"
+	ParamStream   = "azure-monitor-stream"
+	ParamSeverity = "azure-monitor-severity"
+
+	// The environment variables allow to enable the operator on the deployed
+	// gadget pods, where global params can't be set
+	endpointEnv = "INSPEKTOR_GADGET_AZURE_MONITOR_ENDPOINT"
+	ruleIDEnv   = "INSPEKTOR_GADGET_AZURE_MONITOR_RULE_ID"
+	streamEnv   = "INSPEKTOR_GADGET_AZURE_MONITOR_STREAM"
+	severityEnv = "INSPEKTOR_GADGET_AZURE_MONITOR_SEVERITY"
+)
+
+type AzureMonitor struct {
+	batcher     *cloudsink.Batcher
+	minSeverity eventtypes.Severity
+}
+
+func (a *AzureMonitor) Name() string {
+	return OperatorName
+}
+
+func (a *AzureMonitor) Description() string {
+	return "AzureMonitor sends the events to Azure Monitor Logs"
+}
+
+func (a *AzureMonitor) GlobalParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:         ParamEndpoint,
+			Description: "Logs ingestion endpoint of the data collection endpoint or rule to send the events to. Empty disables it",
+		},
+		{
+			Key:         ParamRuleID,
+			Description: "Immutable ID of the data collection rule",
+		},
+		{
+			Key:          ParamStream,
+			Description:  "Name of the stream of the data collection rule",
+			DefaultValue: "Custom-InspektorGadget",
+		},
+		routing.SeverityParamDesc(ParamSeverity, eventtypes.SeverityInfo),
+	}
+}
+
+func (a *AzureMonitor) ParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (a *AzureMonitor) Dependencies() []string {
+	return nil
+}
+
+func (a *AzureMonitor) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	return true
+}
+
+func (a *AzureMonitor) IsSink() {}
+
+func (a *AzureMonitor) Init(params *params.Params) error {
+	endpoint := params.Get(ParamEndpoint).AsString()
+	if envEndpoint := os.Getenv(endpointEnv); envEndpoint != "" && endpoint == "" {
+		endpoint = envEndpoint
+	}
+	if endpoint == "" {
+		return nil
+	}
+
+	minSeverity, err := routing.MinSeverity(params.Get(ParamSeverity), severityEnv, eventtypes.SeverityInfo)
+	if err != nil {
+		return err
+	}
+	a.minSeverity = minSeverity
+
+	ruleID := params.Get(ParamRuleID).AsString()
+	if envRuleID := os.Getenv(ruleIDEnv); envRuleID != "" && ruleID == "" {
+		ruleID = envRuleID
+	}
+	if ruleID == "" {
+		return fmt.Errorf("no data collection rule given for Azure Monitor, use --%s", ParamRuleID)
+	}
+
+	stream := params.Get(ParamStream).AsString()
+	if envStream := os.Getenv(streamEnv); envStream != "" {
+		stream = envStream
+	}
+
+	client := newClient(endpoint, ruleID, stream)
+	a.batcher = cloudsink.NewBatcher("Azure Monitor", limits, cloudsink.DefaultInterval, client.upload)
+
+	log.Infof("sending events to Azure Monitor stream %q of rule %q", stream, ruleID)
+	return nil
+}
+
+func (a *AzureMonitor) Close() error {
+	if a.batcher == nil {
+		return nil
+	}
+	a.batcher.Close()
+	return nil
+}
+
+func (a *AzureMonitor) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	return cloudsink.NewInstance("AzureMonitorInstance", gadgetCtx, a.batcher, a.minSeverity), nil
+}
+
+func init() {
+	operators.Register(&AzureMonitor{})
+}
@@ -0,0 +1,121 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azuremonitor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+)
+
+const apiVersion = "2023-01-01"
+
+// limits keep the requests below the 1MB accepted by the Logs Ingestion API
+var limits = cloudsink.Limits{
+	MaxRecords:     10000,
+	MaxBytes:       900 << 10,
+	RecordOverhead: 256,
+}
+
+type client struct {
+	url    string
+	node   string
+	tokens *cloudsink.TokenSource
+}
+
+func newClient(endpoint, ruleID, stream string) *client {
+	return &client{
+		url: fmt.Sprintf("%s/dataCollectionRules/%s/streams/%s?api-version=%s",
+			strings.TrimSuffix(endpoint, "/"), url.PathEscape(ruleID), url.PathEscape(stream), apiVersion),
+		node:   cloudsink.NodeName(),
+		tokens: cloudsink.NewTokenSource(fetchToken),
+	}
+}
+
+// row is the schema of the stream of the data collection rule. The fields of
+// the events depend on the gadget, they are all in the Event column.
+type row struct {
+	TimeGenerated string         `json:"TimeGenerated"`
+	Severity      string         `json:"Severity"`
+	Gadget        string         `json:"Gadget"`
+	RunID         string         `json:"RunID"`
+	Node          string         `json:"Node"`
+	Namespace     string         `json:"Namespace"`
+	Pod           string         `json:"Pod"`
+	Container     string         `json:"Container"`
+	Event         map[string]any `json:"Event"`
+}
+
+func (c *client) upload(ctx context.Context, records []*cloudsink.Record) error {
+	token, err := c.tokens.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	rows := make([]row, 0, len(records))
+	for _, r := range records {
+		node := r.String("node")
+		if node == "" {
+			node = c.node
+		}
+		rows = append(rows, row{
+			TimeGenerated: r.Time.UTC().Format(time.RFC3339Nano),
+			Severity:      severity(r.Severity),
+			Gadget:        r.Gadget,
+			RunID:         r.RunID,
+			Node:          node,
+			Namespace:     r.String("namespace"),
+			Pod:           r.String("pod"),
+			Container:     r.String("container"),
+			Event:         r.Fields,
+		})
+	}
+
+	body, err := json.Marshal(rows)
+	if err != nil {
+		return fmt.Errorf("marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return cloudsink.Do(req, nil)
+}
+
+func severity(s cloudsink.Severity) string {
+	switch s {
+	case cloudsink.SeverityAlert:
+		return "Critical"
+	case cloudsink.SeverityError:
+		return "Error"
+	case cloudsink.SeverityWarning:
+		return "Warning"
+	case cloudsink.SeverityDebug:
+		return "Debug"
+	default:
+		return "Informational"
+	}
+}
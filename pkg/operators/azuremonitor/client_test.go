@@ -0,0 +1,157 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azuremonitor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+)
+
+// fakeAzure serves the token endpoint of Azure AD and the Logs Ingestion API
+type fakeAzure struct {
+	tokenRequests int
+	uploads       [][]row
+	queries       []string
+}
+
+func (f *fakeAzure) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/tenant-1/oauth2/v2.0/token":
+		f.tokenRequests++
+		if r.PostFormValue("client_id") != "client-1" ||
+			r.PostFormValue("client_assertion") != "service-account-token" ||
+			r.PostFormValue("scope") != "https://monitor.azure.com/.default" ||
+			r.PostFormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"access_token":"aad-token","expires_in":"3599","token_type":"Bearer"}`))
+	case "/dataCollectionRules/dcr-1/streams/Custom-Gadgets":
+		if r.Header.Get("Authorization") != "Bearer aad-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var rows []row
+		if err := json.NewDecoder(r.Body).Decode(&rows); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.uploads = append(f.uploads, rows)
+		f.queries = append(f.queries, r.URL.RawQuery)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestClient(t *testing.T, f *fakeAzure) *client {
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("service-account-token\n"), 0o600))
+	t.Setenv("AZURE_CLIENT_ID", "client-1")
+	t.Setenv("AZURE_TENANT_ID", "tenant-1")
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", tokenFile)
+	t.Setenv("AZURE_AUTHORITY_HOST", server.URL+"/")
+	t.Setenv("NODE_NAME", "node-1")
+
+	return newClient(server.URL+"/", "dcr-1", "Custom-Gadgets")
+}
+
+func testRecord(t *testing.T, fields string, severity cloudsink.Severity) *cloudsink.Record {
+	r, err := cloudsink.NewRecord(json.RawMessage(fields), "trace_exec", "run-1")
+	require.NoError(t, err)
+	r.Severity = severity
+	return r
+}
+
+func TestUpload(t *testing.T) {
+	f := &fakeAzure{}
+	c := newTestClient(t, f)
+
+	records := []*cloudsink.Record{
+		testRecord(t, `{"timestamp":1700000000123456789,"node":"node-2","namespace":"ns","pod":"pod","container":"c","comm":"cat"}`,
+			cloudsink.SeverityAlert),
+		testRecord(t, `{"timestamp":1700000000000000000,"comm":"systemd"}`, cloudsink.SeverityWarning),
+	}
+	require.NoError(t, c.upload(context.Background(), records))
+	require.NoError(t, c.upload(context.Background(), records[1:]))
+
+	require.Equal(t, 1, f.tokenRequests, "token not cached")
+	require.Equal(t, []string{"api-version=2023-01-01", "api-version=2023-01-01"}, f.queries)
+	require.Len(t, f.uploads, 2)
+	require.Len(t, f.uploads[0], 2)
+
+	container := f.uploads[0][0]
+	require.Equal(t, "2023-11-14T22:13:20.123456789Z", container.TimeGenerated)
+	require.Equal(t, "Critical", container.Severity)
+	require.Equal(t, "trace_exec", container.Gadget)
+	require.Equal(t, "run-1", container.RunID)
+	require.Equal(t, "node-2", container.Node)
+	require.Equal(t, "ns", container.Namespace)
+	require.Equal(t, "pod", container.Pod)
+	require.Equal(t, "c", container.Container)
+	require.Equal(t, "cat", container.Event["comm"])
+	require.Equal(t, "run-1", container.Event["runID"])
+
+	// The events without node are the ones of the node of the operator
+	host := f.uploads[0][1]
+	require.Equal(t, "Warning", host.Severity)
+	require.Equal(t, "node-1", host.Node)
+	require.Equal(t, "", host.Namespace)
+}
+
+func TestUploadTokenError(t *testing.T) {
+	f := &fakeAzure{}
+	c := newTestClient(t, f)
+	t.Setenv("AZURE_CLIENT_ID", "client-2")
+
+	err := c.upload(context.Background(), []*cloudsink.Record{
+		testRecord(t, `{}`, cloudsink.SeverityInfo),
+	})
+	var httpErr *cloudsink.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	require.Equal(t, http.StatusBadRequest, httpErr.StatusCode)
+	require.Empty(t, f.uploads)
+}
+
+func TestSeverity(t *testing.T) {
+	t.Parallel()
+
+	table := []struct {
+		severity cloudsink.Severity
+		expected string
+	}{
+		{cloudsink.SeverityInfo, "Informational"},
+		{cloudsink.SeverityDebug, "Debug"},
+		{cloudsink.SeverityWarning, "Warning"},
+		{cloudsink.SeverityError, "Error"},
+		{cloudsink.SeverityAlert, "Critical"},
+	}
+
+	for _, entry := range table {
+		require.Equal(t, entry.expected, severity(entry.severity))
+	}
+}
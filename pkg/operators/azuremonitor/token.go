@@ -0,0 +1,105 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package azuremonitor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+)
+
+const (
+	resource = "https://monitor.azure.com"
+
+	defaultAuthorityHost = "https://login.microsoftonline.com/"
+	imdsTokenURL         = "http://169.254.169.254/metadata/identity/oauth2/token"
+)
+
+// fetchToken gets a token for Azure Monitor with Azure AD Workload Identity
+// when its webhook configured the pod, with the managed identity of the node
+// otherwise
+func fetchToken(ctx context.Context) (*cloudsink.Token, error) {
+	clientID := os.Getenv("AZURE_CLIENT_ID")
+	tenantID := os.Getenv("AZURE_TENANT_ID")
+	tokenFile := os.Getenv("AZURE_FEDERATED_TOKEN_FILE")
+	if clientID != "" && tenantID != "" && tokenFile != "" {
+		return workloadIdentityToken(ctx, clientID, tenantID, tokenFile)
+	}
+	return managedIdentityToken(ctx, clientID)
+}
+
+// workloadIdentityToken exchanges the token of the service account for an
+// Azure AD token of the application it's federated with
+func workloadIdentityToken(ctx context.Context, clientID, tenantID, tokenFile string) (*cloudsink.Token, error) {
+	// The token is renewed by the kubelet, read it again each time
+	assertion, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading federated token: %w", err)
+	}
+
+	authorityHost := os.Getenv("AZURE_AUTHORITY_HOST")
+	if authorityHost == "" {
+		authorityHost = defaultAuthorityHost
+	}
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimSuffix(authorityHost, "/"), url.PathEscape(tenantID))
+
+	form := url.Values{
+		"client_id":             {clientID},
+		"scope":                 {resource + "/.default"},
+		"grant_type":            {"client_credentials"},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {strings.TrimSpace(string(assertion))},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var out cloudsink.OAuth2Response
+	if err := cloudsink.Do(req, &out); err != nil {
+		return nil, fmt.Errorf("getting workload identity token: %w", err)
+	}
+	return out.Token()
+}
+
+// managedIdentityToken gets a token of the managed identity of the node from
+// the instance metadata service. clientID selects the identity when the node
+// has several of them.
+func managedIdentityToken(ctx context.Context, clientID string) (*cloudsink.Token, error) {
+	query := url.Values{
+		"api-version": {"2018-02-01"},
+		"resource":    {resource},
+	}
+	if clientID != "" {
+		query.Set("client_id", clientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imdsTokenURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+
+	var out cloudsink.OAuth2Response
+	if err := cloudsink.Do(req, &out); err != nil {
+		return nil, fmt.Errorf("getting managed identity token: %w", err)
+	}
+	return out.Token()
+}
@@ -0,0 +1,167 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudlogging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+)
+
+const endpoint = "https://logging.googleapis.com/v2/entries:write"
+
+// limits keep the requests well below the 10MB accepted by entries.write
+var limits = cloudsink.Limits{
+	MaxRecords:     1000,
+	MaxBytes:       5 << 20,
+	RecordOverhead: 512,
+}
+
+type client struct {
+	project  string
+	logName  string
+	cluster  *cluster
+	node     string
+	endpoint string
+	tokens   *cloudsink.TokenSource
+}
+
+func newClient(project, logID string, cluster *cluster) *client {
+	return &client{
+		project:  project,
+		logName:  fmt.Sprintf("projects/%s/logs/%s", project, url.PathEscape(logID)),
+		cluster:  cluster,
+		node:     cloudsink.NodeName(),
+		endpoint: endpoint,
+		tokens:   cloudsink.NewTokenSource(fetchToken),
+	}
+}
+
+type monitoredResource struct {
+	Type   string            `json:"type"`
+	Labels map[string]string `json:"labels"`
+}
+
+type logEntry struct {
+	Timestamp   string             `json:"timestamp"`
+	Severity    string             `json:"severity"`
+	Resource    *monitoredResource `json:"resource"`
+	Labels      map[string]string  `json:"labels"`
+	JSONPayload map[string]any     `json:"jsonPayload"`
+}
+
+type writeRequest struct {
+	LogName        string     `json:"logName"`
+	Entries        []logEntry `json:"entries"`
+	PartialSuccess bool       `json:"partialSuccess"`
+}
+
+func (c *client) writeEntries(ctx context.Context, records []*cloudsink.Record) error {
+	token, err := c.tokens.Get(ctx)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]logEntry, 0, len(records))
+	for _, r := range records {
+		entries = append(entries, logEntry{
+			Timestamp: r.Time.UTC().Format(time.RFC3339Nano),
+			Severity:  severity(r.Severity),
+			Resource:  c.resource(r),
+			Labels: map[string]string{
+				"gadget": r.Gadget,
+				"runID":  r.RunID,
+				"node":   c.node,
+			},
+			JSONPayload: r.Fields,
+		})
+	}
+
+	body, err := json.Marshal(writeRequest{
+		LogName: c.logName,
+		Entries: entries,
+		// Write the valid entries even if some are rejected
+		PartialSuccess: true,
+	})
+	if err != nil {
+		return fmt.Errorf("marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return cloudsink.Do(req, nil)
+}
+
+// resource returns the monitored resource of an event: its container or the
+// node on GKE, so the events show up with the logs of the workloads
+func (c *client) resource(r *cloudsink.Record) *monitoredResource {
+	if c.cluster == nil {
+		return &monitoredResource{
+			Type:   "global",
+			Labels: map[string]string{"project_id": c.project},
+		}
+	}
+
+	namespace, pod, container := r.String("namespace"), r.String("pod"), r.String("container")
+	if namespace != "" && pod != "" && container != "" {
+		return &monitoredResource{
+			Type: "k8s_container",
+			Labels: map[string]string{
+				"project_id":     c.project,
+				"location":       c.cluster.location,
+				"cluster_name":   c.cluster.name,
+				"namespace_name": namespace,
+				"pod_name":       pod,
+				"container_name": container,
+			},
+		}
+	}
+
+	return &monitoredResource{
+		Type: "k8s_node",
+		Labels: map[string]string{
+			"project_id":   c.project,
+			"location":     c.cluster.location,
+			"cluster_name": c.cluster.name,
+			"node_name":    c.node,
+		},
+	}
+}
+
+func severity(s cloudsink.Severity) string {
+	switch s {
+	case cloudsink.SeverityAlert:
+		return "ALERT"
+	case cloudsink.SeverityError:
+		return "ERROR"
+	case cloudsink.SeverityWarning:
+		return "WARNING"
+	case cloudsink.SeverityDebug:
+		return "DEBUG"
+	default:
+		return "INFO"
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudlogging
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+)
+
+// fakeGoogle serves the metadata server and entries.write
+type fakeGoogle struct {
+	onGKE         bool
+	tokenRequests int
+	requests      []writeRequest
+}
+
+func (f *fakeGoogle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasPrefix(r.URL.Path, "/computeMetadata/v1/") {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch strings.TrimPrefix(r.URL.Path, "/computeMetadata/v1/") {
+		case "instance/service-accounts/default/token":
+			f.tokenRequests++
+			w.Write([]byte(`{"access_token":"ya29.token","expires_in":3599,"token_type":"Bearer"}`))
+		case "instance/attributes/cluster-name":
+			if !f.onGKE {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write([]byte("cluster-1\n"))
+		case "instance/attributes/cluster-location":
+			w.Write([]byte("europe-west1"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+		return
+	}
+
+	if r.URL.Path != "/v2/entries:write" || r.Header.Get("Authorization") != "Bearer ya29.token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	var req writeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	f.requests = append(f.requests, req)
+	w.Write([]byte("{}"))
+}
+
+func newTestServer(t *testing.T, f *fakeGoogle) *httptest.Server {
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(server.URL, "http://"))
+	t.Setenv("NODE_NAME", "node-1")
+	return server
+}
+
+func testRecord(t *testing.T, fields string, severity cloudsink.Severity) *cloudsink.Record {
+	r, err := cloudsink.NewRecord(json.RawMessage(fields), "trace_exec", "run-1")
+	require.NoError(t, err)
+	r.Severity = severity
+	return r
+}
+
+func TestWriteEntries(t *testing.T) {
+	f := &fakeGoogle{onGKE: true}
+	server := newTestServer(t, f)
+
+	gke := getCluster(context.Background())
+	require.Equal(t, &cluster{name: "cluster-1", location: "europe-west1"}, gke)
+
+	c := newClient("project-1", "inspektor gadget", gke)
+	c.endpoint = server.URL + "/v2/entries:write"
+
+	records := []*cloudsink.Record{
+		testRecord(t, `{"timestamp":1700000000123456789,"namespace":"ns","pod":"pod","container":"c","comm":"cat"}`, cloudsink.SeverityAlert),
+		testRecord(t, `{"timestamp":1700000000000000000,"comm":"systemd"}`, cloudsink.SeverityInfo),
+	}
+	require.NoError(t, c.writeEntries(context.Background(), records))
+	require.NoError(t, c.writeEntries(context.Background(), records[1:]))
+
+	require.Equal(t, 1, f.tokenRequests, "token not cached")
+	require.Len(t, f.requests, 2)
+
+	req := f.requests[0]
+	require.Equal(t, "projects/project-1/logs/inspektor%20gadget", req.LogName)
+	require.True(t, req.PartialSuccess)
+	require.Len(t, req.Entries, 2)
+
+	container := req.Entries[0]
+	require.Equal(t, "2023-11-14T22:13:20.123456789Z", container.Timestamp)
+	require.Equal(t, "ALERT", container.Severity)
+	require.Equal(t, &monitoredResource{
+		Type: "k8s_container",
+		Labels: map[string]string{
+			"project_id":     "project-1",
+			"location":       "europe-west1",
+			"cluster_name":   "cluster-1",
+			"namespace_name": "ns",
+			"pod_name":       "pod",
+			"container_name": "c",
+		},
+	}, container.Resource)
+	require.Equal(t, map[string]string{"gadget": "trace_exec", "runID": "run-1", "node": "node-1"}, container.Labels)
+	require.Equal(t, "cat", container.JSONPayload["comm"])
+	require.Equal(t, "trace_exec", container.JSONPayload["gadget"])
+
+	host := req.Entries[1]
+	require.Equal(t, "INFO", host.Severity)
+	require.Equal(t, &monitoredResource{
+		Type: "k8s_node",
+		Labels: map[string]string{
+			"project_id":   "project-1",
+			"location":     "europe-west1",
+			"cluster_name": "cluster-1",
+			"node_name":    "node-1",
+		},
+	}, host.Resource)
+}
+
+func TestWriteEntriesOutsideGKE(t *testing.T) {
+	f := &fakeGoogle{}
+	server := newTestServer(t, f)
+
+	gke := getCluster(context.Background())
+	require.Nil(t, gke)
+
+	c := newClient("project-1", "gadgets", gke)
+	c.endpoint = server.URL + "/v2/entries:write"
+
+	require.NoError(t, c.writeEntries(context.Background(), []*cloudsink.Record{
+		testRecord(t, `{"namespace":"ns","pod":"pod","container":"c"}`, cloudsink.SeverityInfo),
+	}))
+
+	require.Len(t, f.requests, 1)
+	require.Equal(t, &monitoredResource{
+		Type:   "global",
+		Labels: map[string]string{"project_id": "project-1"},
+	}, f.requests[0].Entries[0].Resource)
+}
+
+func TestWriteEntriesRejected(t *testing.T) {
+	f := &fakeGoogle{}
+	server := newTestServer(t, f)
+
+	c := newClient("project-1", "gadgets", nil)
+	c.endpoint = server.URL + "/v2/entries:write"
+	c.tokens = cloudsink.NewTokenSource(func(ctx context.Context) (*cloudsink.Token, error) {
+		return &cloudsink.Token{Value: "expired", Expiry: time.Now().Add(time.Hour)}, nil
+	})
+
+	err := c.writeEntries(context.Background(), []*cloudsink.Record{
+		testRecord(t, `{}`, cloudsink.SeverityInfo),
+	})
+	var httpErr *cloudsink.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	require.Equal(t, http.StatusUnauthorized, httpErr.StatusCode)
+	require.Empty(t, f.requests)
+}
+
+func TestSeverity(t *testing.T) {
+	t.Parallel()
+
+	table := []struct {
+		severity cloudsink.Severity
+		expected string
+	}{
+		{cloudsink.SeverityInfo, "INFO"},
+		{cloudsink.SeverityDebug, "DEBUG"},
+		{cloudsink.SeverityWarning, "WARNING"},
+		{cloudsink.SeverityError, "ERROR"},
+		{cloudsink.SeverityAlert, "ALERT"},
+	}
+
+	for _, entry := range table {
+		require.Equal(t, entry.expected, severity(entry.severity))
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cloudlogging provides an operator that sends the events of the
+// gadgets to Google Cloud Logging. It gets its access token from the metadata
+// server, i.e. from GKE Workload Identity or from the service account of the
+// node, and is disabled unless a project is configured.
+package cloudlogging
+
+import (
+	"context"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/routing"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperatorName = "CloudLogging"
+
+	ParamProject  = "cloud-logging-project"
+	ParamLog      = "cloud-logging-log"
+	ParamSeverity = "cloud-logging-severity"
+
+	// projectEnv allows to enable the operator on the deployed gadget pods,
+	// where global params can't be set
+	projectEnv  = "INSPEKTOR_GADGET_CLOUD_LOGGING_PROJECT"
+	severityEnv = "INSPEKTOR_GADGET_CLOUD_LOGGING_SEVERITY"
+)
+
+type CloudLogging struct {
+	batcher     *cloudsink.Batcher
+	minSeverity eventtypes.Severity
+}
+
+func (c *CloudLogging) Name() string {
+	return OperatorName
+}
+
+func (c *CloudLogging) Description() string {
+	return "CloudLogging sends the events to Google Cloud Logging"
+}
+
+func (c *CloudLogging) GlobalParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:         ParamProject,
+			Description: "Google Cloud project to send the events to. Empty disables it",
+		},
+		{
+			Key:          ParamLog,
+			Description:  "Name of the log the events are written to",
+			DefaultValue: "inspektor-gadget",
+		},
+		routing.SeverityParamDesc(ParamSeverity, eventtypes.SeverityInfo),
+	}
+}
+
+func (c *CloudLogging) ParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (c *CloudLogging) Dependencies() []string {
+	return nil
+}
+
+func (c *CloudLogging) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	return true
+}
+
+func (c *CloudLogging) IsSink() {}
+
+func (c *CloudLogging) Init(params *params.Params) error {
+	project := params.Get(ParamProject).AsString()
+	if envProject := os.Getenv(projectEnv); envProject != "" && project == "" {
+		project = envProject
+	}
+	if project == "" {
+		return nil
+	}
+
+	minSeverity, err := routing.MinSeverity(params.Get(ParamSeverity), severityEnv, eventtypes.SeverityInfo)
+	if err != nil {
+		return err
+	}
+	c.minSeverity = minSeverity
+
+	ctx, cancel := context.WithTimeout(context.Background(), metadataTimeout)
+	defer cancel()
+
+	client := newClient(project, params.Get(ParamLog).AsString(), getCluster(ctx))
+	c.batcher = cloudsink.NewBatcher("Cloud Logging", limits, cloudsink.DefaultInterval, client.writeEntries)
+
+	log.Infof("sending events to Cloud Logging log %q", client.logName)
+	return nil
+}
+
+func (c *CloudLogging) Close() error {
+	if c.batcher == nil {
+		return nil
+	}
+	c.batcher.Close()
+	return nil
+}
+
+func (c *CloudLogging) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	return cloudsink.NewInstance("CloudLoggingInstance", gadgetCtx, c.batcher, c.minSeverity), nil
+}
+
+func init() {
+	operators.Register(&CloudLogging{})
+}
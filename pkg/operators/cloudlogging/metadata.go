@@ -0,0 +1,113 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudlogging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+)
+
+const (
+	// defaultMetadataHost can be overridden with GCE_METADATA_HOST, like with
+	// the client libraries of Google Cloud
+	defaultMetadataHost = "metadata.google.internal"
+
+	metadataTimeout = 5 * time.Second
+)
+
+func metadataURL(path string) string {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = defaultMetadataHost
+	}
+	return fmt.Sprintf("http://%s/computeMetadata/v1/%s", host, path)
+}
+
+func newMetadataRequest(ctx context.Context, path string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	return req, nil
+}
+
+// fetchToken gets an access token of the service account of the pod, with
+// GKE Workload Identity, or of the node
+func fetchToken(ctx context.Context) (*cloudsink.Token, error) {
+	req, err := newMetadataRequest(ctx, "instance/service-accounts/default/token")
+	if err != nil {
+		return nil, err
+	}
+
+	var out cloudsink.OAuth2Response
+	if err := cloudsink.Do(req, &out); err != nil {
+		return nil, fmt.Errorf("getting token from metadata server: %w", err)
+	}
+	return out.Token()
+}
+
+func getAttribute(ctx context.Context, name string) (string, error) {
+	req, err := newMetadataRequest(ctx, "instance/attributes/"+name)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := cloudsink.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", &cloudsink.HTTPError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// cluster is the GKE cluster the node is part of
+type cluster struct {
+	name     string
+	location string
+}
+
+// getCluster returns the cluster of the node, or nil when not running on GKE.
+// The events are then written to the "global" resource.
+func getCluster(ctx context.Context) *cluster {
+	name, err := getAttribute(ctx, "cluster-name")
+	if err != nil {
+		log.Debugf("getting GKE cluster name: %v", err)
+		return nil
+	}
+	location, err := getAttribute(ctx, "cluster-location")
+	if err != nil {
+		log.Debugf("getting GKE cluster location: %v", err)
+		return nil
+	}
+	return &cluster{name: name, location: location}
+}
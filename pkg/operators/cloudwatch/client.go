@@ -0,0 +1,145 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudwatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/awsauth"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+)
+
+// limits are the ones of PutLogEvents:
+// https://docs.aws.amazon.com/AmazonCloudWatchLogs/latest/APIReference/API_PutLogEvents.html
+var limits = cloudsink.Limits{
+	MaxRecords:     10000,
+	MaxBytes:       1048576,
+	RecordOverhead: 26,
+}
+
+type client struct {
+	endpoint    string
+	logGroup    string
+	logStream   string
+	credentials *awsauth.CredentialsProvider
+	signer      *awsauth.Signer
+
+	// streamCreated is set once the log stream is known to exist
+	streamCreated bool
+}
+
+func newClient(region, logGroup, logStream string, credentials *awsauth.CredentialsProvider) *client {
+	return &client{
+		endpoint:    fmt.Sprintf("https://logs.%s.amazonaws.com/", region),
+		logGroup:    logGroup,
+		logStream:   logStream,
+		credentials: credentials,
+		signer:      &awsauth.Signer{Region: region, Service: "logs"},
+	}
+}
+
+type inputLogEvent struct {
+	Timestamp int64  `json:"timestamp"`
+	Message   string `json:"message"`
+}
+
+// putLogEvents sends a batch of records, creating the log stream the first
+// time
+func (c *client) putLogEvents(ctx context.Context, records []*cloudsink.Record) error {
+	if !c.streamCreated {
+		err := c.call(ctx, "CreateLogStream", map[string]string{
+			"logGroupName":  c.logGroup,
+			"logStreamName": c.logStream,
+		})
+		if err != nil && !isAWSError(err, "ResourceAlreadyExistsException") {
+			return fmt.Errorf("creating log stream: %w", err)
+		}
+		c.streamCreated = true
+	}
+
+	// The events of a batch must be in chronological order
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time.Before(records[j].Time)
+	})
+
+	events := make([]inputLogEvent, 0, len(records))
+	for _, r := range records {
+		events = append(events, inputLogEvent{
+			Timestamp: r.Time.UnixMilli(),
+			Message:   string(r.Message),
+		})
+	}
+
+	err := c.call(ctx, "PutLogEvents", map[string]any{
+		"logGroupName":  c.logGroup,
+		"logStreamName": c.logStream,
+		"logEvents":     events,
+	})
+	if isAWSError(err, "ResourceNotFoundException") {
+		// The stream was deleted, create it again with the next batch
+		c.streamCreated = false
+	}
+	return err
+}
+
+// call calls an action of the JSON API of CloudWatch Logs
+func (c *client) call(ctx context.Context, action string, input any) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("marshaling request: %w", err)
+	}
+
+	credentials, err := c.credentials.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("getting credentials: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "Logs_20140328."+action)
+	c.signer.Sign(req, body, credentials)
+
+	return cloudsink.Do(req, nil)
+}
+
+// awsError is the body of the errors of the JSON APIs of AWS
+type awsError struct {
+	Type    string `json:"__type"`
+	Message string `json:"message"`
+}
+
+func isAWSError(err error, errorType string) bool {
+	var httpErr *cloudsink.HTTPError
+	if !errors.As(err, &httpErr) {
+		return false
+	}
+	var e awsError
+	if json.Unmarshal([]byte(httpErr.Body), &e) != nil {
+		return false
+	}
+	// The type can be prefixed with a namespace, e.g.
+	// "com.amazonaws.logs#ResourceNotFoundException"
+	return e.Type == errorType || strings.HasSuffix(e.Type, "#"+errorType)
+}
@@ -0,0 +1,235 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudwatch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/awsauth"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+)
+
+type putLogEventsInput struct {
+	LogGroupName  string          `json:"logGroupName"`
+	LogStreamName string          `json:"logStreamName"`
+	LogEvents     []inputLogEvent `json:"logEvents"`
+}
+
+// fakeCloudWatch implements CreateLogStream and PutLogEvents, the log stream
+// being deleted when deleteStream is set
+type fakeCloudWatch struct {
+	mu           sync.Mutex
+	actions      []string
+	streams      map[string]bool
+	puts         []putLogEventsInput
+	deleteStream bool
+}
+
+func (f *fakeCloudWatch) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+		r.Header.Get("Content-Type") != "application/x-amz-json-1.1" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	action := strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "Logs_20140328.")
+	f.actions = append(f.actions, action)
+
+	var input putLogEventsInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	stream := input.LogGroupName + "/" + input.LogStreamName
+	if f.deleteStream {
+		delete(f.streams, stream)
+		f.deleteStream = false
+	}
+
+	switch action {
+	case "CreateLogStream":
+		if f.streams[stream] {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"ResourceAlreadyExistsException","message":"The specified log stream already exists"}`))
+			return
+		}
+		f.streams[stream] = true
+	case "PutLogEvents":
+		if !f.streams[stream] {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"__type":"com.amazonaws.logs#ResourceNotFoundException","message":"The specified log stream does not exist."}`))
+			return
+		}
+		f.puts = append(f.puts, input)
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	w.Write([]byte("{}"))
+}
+
+func newTestClient(t *testing.T, f *fakeCloudWatch) *client {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	credentials, err := awsauth.NewCredentialsProvider("us-east-1")
+	require.NoError(t, err)
+
+	c := newClient("us-east-1", "gadgets", "node-1", credentials)
+	c.endpoint = server.URL
+	return c
+}
+
+func testRecord(t time.Time, message string) *cloudsink.Record {
+	return &cloudsink.Record{Time: t, Message: []byte(message)}
+}
+
+func TestPutLogEvents(t *testing.T) {
+	f := &fakeCloudWatch{streams: map[string]bool{}}
+	c := newTestClient(t, f)
+
+	now := time.UnixMilli(1700000000000)
+	err := c.putLogEvents(context.Background(), []*cloudsink.Record{
+		testRecord(now.Add(2*time.Millisecond), `{"comm":"c"}`),
+		testRecord(now, `{"comm":"a"}`),
+		testRecord(now.Add(time.Millisecond), `{"comm":"b"}`),
+	})
+	require.NoError(t, err)
+
+	err = c.putLogEvents(context.Background(), []*cloudsink.Record{
+		testRecord(now.Add(3*time.Millisecond), `{"comm":"d"}`),
+	})
+	require.NoError(t, err)
+
+	// The stream is only created once and the events are sorted
+	require.Equal(t, []string{"CreateLogStream", "PutLogEvents", "PutLogEvents"}, f.actions)
+	require.Equal(t, []putLogEventsInput{
+		{
+			LogGroupName:  "gadgets",
+			LogStreamName: "node-1",
+			LogEvents: []inputLogEvent{
+				{Timestamp: 1700000000000, Message: `{"comm":"a"}`},
+				{Timestamp: 1700000000001, Message: `{"comm":"b"}`},
+				{Timestamp: 1700000000002, Message: `{"comm":"c"}`},
+			},
+		},
+		{
+			LogGroupName:  "gadgets",
+			LogStreamName: "node-1",
+			LogEvents: []inputLogEvent{
+				{Timestamp: 1700000000003, Message: `{"comm":"d"}`},
+			},
+		},
+	}, f.puts)
+}
+
+func TestPutLogEventsExistingStream(t *testing.T) {
+	f := &fakeCloudWatch{streams: map[string]bool{"gadgets/node-1": true}}
+	c := newTestClient(t, f)
+
+	err := c.putLogEvents(context.Background(), []*cloudsink.Record{
+		testRecord(time.Now(), `{}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"CreateLogStream", "PutLogEvents"}, f.actions)
+	require.Len(t, f.puts, 1)
+}
+
+func TestPutLogEventsDeletedStream(t *testing.T) {
+	f := &fakeCloudWatch{streams: map[string]bool{}}
+	c := newTestClient(t, f)
+
+	require.NoError(t, c.putLogEvents(context.Background(), []*cloudsink.Record{
+		testRecord(time.Now(), `{"n":1}`),
+	}))
+
+	// The batch sent while the stream doesn't exist fails and the stream is
+	// created again with the next one
+	f.deleteStream = true
+	require.Error(t, c.putLogEvents(context.Background(), []*cloudsink.Record{
+		testRecord(time.Now(), `{"n":2}`),
+	}))
+	require.NoError(t, c.putLogEvents(context.Background(), []*cloudsink.Record{
+		testRecord(time.Now(), `{"n":3}`),
+	}))
+
+	require.Equal(t, []string{
+		"CreateLogStream", "PutLogEvents",
+		"PutLogEvents",
+		"CreateLogStream", "PutLogEvents",
+	}, f.actions)
+	require.Len(t, f.puts, 2)
+	require.Equal(t, `{"n":3}`, f.puts[1].LogEvents[0].Message)
+}
+
+func TestIsAWSError(t *testing.T) {
+	t.Parallel()
+
+	table := []struct {
+		description string
+		err         error
+		expected    bool
+	}{
+		{
+			description: "type",
+			err:         &cloudsink.HTTPError{StatusCode: 400, Body: `{"__type":"ResourceNotFoundException"}`},
+			expected:    true,
+		},
+		{
+			description: "namespaced_type",
+			err:         &cloudsink.HTTPError{StatusCode: 400, Body: `{"__type":"com.amazonaws.logs#ResourceNotFoundException"}`},
+			expected:    true,
+		},
+		{
+			description: "other_type",
+			err:         &cloudsink.HTTPError{StatusCode: 400, Body: `{"__type":"InvalidParameterException"}`},
+			expected:    false,
+		},
+		{
+			description: "not_json",
+			err:         &cloudsink.HTTPError{StatusCode: 502, Body: "Bad Gateway"},
+			expected:    false,
+		},
+		{
+			description: "nil",
+			err:         nil,
+			expected:    false,
+		},
+	}
+
+	for _, entry := range table {
+		entry := entry
+		t.Run(entry.description, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, entry.expected, isAWSError(entry.err, "ResourceNotFoundException"))
+		})
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cloudwatch provides an operator that sends the events of the gadgets
+// to Amazon CloudWatch Logs, in a log stream per node. It uses the credentials
+// of IAM roles for service accounts or EKS Pod Identity, and is disabled
+// unless a log group is configured.
+package cloudwatch
+
+import (
+	"fmt"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/awsauth"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/routing"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperatorName = "CloudWatch"
+
+	ParamLogGroup  = "cloudwatch-log-group"
+	ParamLogStream = "cloudwatch-log-stream"
+	ParamRegion    = "cloudwatch-region"
+	ParamSeverity  = "cloudwatch-severity"
+
+	// The environment variables allow to enable the operator on the deployed
+	// gadget pods, where global params can't be set
+	logGroupEnv = "INSPEKTOR_GADGET_CLOUDWATCH_LOG_GROUP"
+	regionEnv   = "INSPEKTOR_GADGET_CLOUDWATCH_REGION"
+	severityEnv = "INSPEKTOR_GADGET_CLOUDWATCH_SEVERITY"
+)
+
+type CloudWatch struct {
+	batcher     *cloudsink.Batcher
+	minSeverity eventtypes.Severity
+}
+
+func (c *CloudWatch) Name() string {
+	return OperatorName
+}
+
+func (c *CloudWatch) Description() string {
+	return "CloudWatch sends the events to Amazon CloudWatch Logs"
+}
+
+func (c *CloudWatch) GlobalParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:         ParamLogGroup,
+			Description: "CloudWatch Logs log group to send the events to. It must exist. Empty disables it",
+		},
+		{
+			Key:         ParamLogStream,
+			Description: "Log stream to send the events to, created if needed. Defaults to the name of the node",
+		},
+		{
+			Key:         ParamRegion,
+			Description: "AWS region of the log group. Defaults to the one of the AWS_REGION environment variable",
+		},
+		routing.SeverityParamDesc(ParamSeverity, eventtypes.SeverityInfo),
+	}
+}
+
+func (c *CloudWatch) ParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (c *CloudWatch) Dependencies() []string {
+	return nil
+}
+
+func (c *CloudWatch) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	return true
+}
+
+func (c *CloudWatch) IsSink() {}
+
+func (c *CloudWatch) Init(params *params.Params) error {
+	logGroup := params.Get(ParamLogGroup).AsString()
+	if envLogGroup := os.Getenv(logGroupEnv); envLogGroup != "" && logGroup == "" {
+		logGroup = envLogGroup
+	}
+	if logGroup == "" {
+		return nil
+	}
+
+	minSeverity, err := routing.MinSeverity(params.Get(ParamSeverity), severityEnv, eventtypes.SeverityInfo)
+	if err != nil {
+		return err
+	}
+	c.minSeverity = minSeverity
+
+	region := params.Get(ParamRegion).AsString()
+	for _, env := range []string{regionEnv, "AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region != "" {
+			break
+		}
+		region = os.Getenv(env)
+	}
+	if region == "" {
+		return fmt.Errorf("no AWS region given for CloudWatch Logs, use --%s", ParamRegion)
+	}
+
+	logStream := params.Get(ParamLogStream).AsString()
+	if logStream == "" {
+		logStream = cloudsink.NodeName()
+	}
+
+	credentials, err := awsauth.NewCredentialsProvider(region)
+	if err != nil {
+		return fmt.Errorf("getting AWS credentials: %w", err)
+	}
+
+	client := newClient(region, logGroup, logStream, credentials)
+	c.batcher = cloudsink.NewBatcher("CloudWatch Logs", limits, cloudsink.DefaultInterval, client.putLogEvents)
+
+	log.Infof("sending events to CloudWatch Logs group %q, stream %q", logGroup, logStream)
+	return nil
+}
+
+func (c *CloudWatch) Close() error {
+	if c.batcher == nil {
+		return nil
+	}
+	c.batcher.Close()
+	return nil
+}
+
+func (c *CloudWatch) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	return cloudsink.NewInstance("CloudWatchInstance", gadgetCtx, c.batcher, c.minSeverity), nil
+}
+
+func init() {
+	operators.Register(&CloudWatch{})
+}
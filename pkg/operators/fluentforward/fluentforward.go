@@ -0,0 +1,192 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fluentforward provides an operator that sends the events of the
+// gadgets to Fluentd or Fluent Bit using their forward protocol, so clusters
+// with a log-forwarding DaemonSet can ingest them without tailing files. It's
+// disabled unless an address is configured.
+package fluentforward
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/routing"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperatorName = "FluentForward"
+
+	ParamAddress  = "fluent-forward-address"
+	ParamTag      = "fluent-forward-tag"
+	ParamSeverity = "fluent-forward-severity"
+
+	// addressEnv allows to enable the operator on the deployed gadget pods,
+	// where global params can't be set
+	addressEnv  = "INSPEKTOR_GADGET_FLUENT_FORWARD_ADDRESS"
+	severityEnv = "INSPEKTOR_GADGET_FLUENT_FORWARD_SEVERITY"
+)
+
+type FluentForward struct {
+	forwarder   *forwarder
+	tag         string
+	minSeverity eventtypes.Severity
+}
+
+func (f *FluentForward) Name() string {
+	return OperatorName
+}
+
+func (f *FluentForward) Description() string {
+	return "FluentForward sends the events to Fluentd or Fluent Bit with the forward protocol"
+}
+
+func (f *FluentForward) GlobalParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:         ParamAddress,
+			Description: "Address of the Fluentd or Fluent Bit forward input to send the events to (e.g. localhost:24224). Empty disables it",
+		},
+		{
+			Key:          ParamTag,
+			Description:  "Prefix of the tags of the events, followed by the category and the name of the gadget",
+			DefaultValue: "inspektor-gadget",
+		},
+		routing.SeverityParamDesc(ParamSeverity, eventtypes.SeverityInfo),
+	}
+}
+
+func (f *FluentForward) ParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (f *FluentForward) Dependencies() []string {
+	return nil
+}
+
+func (f *FluentForward) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	return true
+}
+
+func (f *FluentForward) IsSink() {}
+
+func (f *FluentForward) Init(params *params.Params) error {
+	address := params.Get(ParamAddress).AsString()
+	if envAddress := os.Getenv(addressEnv); envAddress != "" && address == "" {
+		address = envAddress
+	}
+	if address == "" {
+		return nil
+	}
+
+	minSeverity, err := routing.MinSeverity(params.Get(ParamSeverity), severityEnv, eventtypes.SeverityInfo)
+	if err != nil {
+		return err
+	}
+	f.minSeverity = minSeverity
+
+	f.tag = params.Get(ParamTag).AsString()
+	f.forwarder = newForwarder(address)
+
+	log.Infof("forwarding events to %q", address)
+	return nil
+}
+
+func (f *FluentForward) Close() error {
+	if f.forwarder == nil {
+		return nil
+	}
+	f.forwarder.close()
+	return nil
+}
+
+func (f *FluentForward) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	desc := gadgetCtx.GadgetDesc()
+	return &FluentForwardInstance{
+		manager: f,
+		tag:     fmt.Sprintf("%s.%s.%s", f.tag, desc.Category(), desc.Name()),
+		gadget:  fmt.Sprintf("%s/%s", desc.Category(), desc.Name()),
+		runID:   gadgetCtx.ID(),
+	}, nil
+}
+
+type FluentForwardInstance struct {
+	manager *FluentForward
+	tag     string
+	gadget  string
+	runID   string
+}
+
+func (m *FluentForwardInstance) Name() string {
+	return "FluentForwardInstance"
+}
+
+func (m *FluentForwardInstance) PreGadgetRun() error {
+	return nil
+}
+
+func (m *FluentForwardInstance) PostGadgetRun() error {
+	return nil
+}
+
+func (m *FluentForwardInstance) EnrichEvent(ev any) error {
+	return nil
+}
+
+func (m *FluentForwardInstance) SinkEvent(ev any) error {
+	// Forwarding is disabled
+	if m.manager.forwarder == nil || !routing.Accepts(ev, m.manager.minSeverity) {
+		return nil
+	}
+
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("marshaling event: %w", err)
+	}
+
+	// Use the JSON encoding of the event as a generic way to get its fields,
+	// keeping the numbers as they are to not lose the precision of the
+	// 64-bit ones
+	var record map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&record); err != nil {
+		return fmt.Errorf("unmarshaling event: %w", err)
+	}
+	record["gadget"] = m.gadget
+	record["runID"] = m.runID
+
+	ts := time.Now()
+	if n, ok := record["timestamp"].(json.Number); ok {
+		if nsec, err := n.Int64(); err == nil && nsec > 0 {
+			ts = time.Unix(0, nsec)
+		}
+	}
+
+	m.manager.forwarder.send(encodeMessage(m.tag, ts, record))
+	return nil
+}
+
+func init() {
+	operators.Register(&FluentForward{})
+}
@@ -0,0 +1,122 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fluentforward
+
+import (
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// bufferSize is the number of messages waiting to be sent, the following
+	// ones are dropped
+	bufferSize = 1024
+
+	dialTimeout  = 5 * time.Second
+	writeTimeout = 5 * time.Second
+
+	// retryInterval is how long the messages are dropped after failing to
+	// connect, before trying again
+	retryInterval = 5 * time.Second
+)
+
+// forwarder sends the messages to the forward input in the background, so a
+// slow or unavailable input doesn't slow down the gadgets
+type forwarder struct {
+	address  string
+	messages chan []byte
+	done     chan struct{}
+	stopped  chan struct{}
+}
+
+func newForwarder(address string) *forwarder {
+	f := &forwarder{
+		address:  address,
+		messages: make(chan []byte, bufferSize),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go f.run()
+	return f
+}
+
+// send queues a message, it's dropped if the buffer is full
+func (f *forwarder) send(message []byte) {
+	select {
+	case f.messages <- message:
+	default:
+	}
+}
+
+func (f *forwarder) close() {
+	close(f.done)
+	<-f.stopped
+}
+
+func (f *forwarder) run() {
+	defer close(f.stopped)
+
+	var conn net.Conn
+	var nextRetry time.Time
+	failing := false
+
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	for {
+		var message []byte
+		select {
+		case message = <-f.messages:
+		case <-f.done:
+			return
+		}
+
+		if conn == nil {
+			if time.Now().Before(nextRetry) {
+				continue
+			}
+
+			var err error
+			conn, err = net.DialTimeout("tcp", f.address, dialTimeout)
+			if err != nil {
+				// Only log the first failure to not flood the logs while
+				// the input is unavailable
+				if !failing {
+					log.Warnf("connecting to fluent forward input %q: %v", f.address, err)
+				}
+				failing = true
+				nextRetry = time.Now().Add(retryInterval)
+				conn = nil
+				continue
+			}
+			if failing {
+				log.Infof("connected to fluent forward input %q", f.address)
+			}
+			failing = false
+		}
+
+		conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+		if _, err := conn.Write(message); err != nil {
+			log.Warnf("sending event to fluent forward input %q: %v", f.address, err)
+			conn.Close()
+			conn = nil
+		}
+	}
+}
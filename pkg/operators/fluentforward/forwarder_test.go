@@ -0,0 +1,157 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fluentforward
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeInput accepts the connections of the forwarder, passing them to the
+// test
+type fakeInput struct {
+	listener net.Listener
+	conns    chan net.Conn
+}
+
+func newFakeInput(t *testing.T) *fakeInput {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	in := &fakeInput{
+		listener: listener,
+		conns:    make(chan net.Conn, 10),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+			in.conns <- conn
+		}
+	}()
+	return in
+}
+
+func (in *fakeInput) accept(t *testing.T) net.Conn {
+	select {
+	case conn := <-in.conns:
+		return conn
+	case <-time.After(5 * time.Second):
+		t.Fatal("forwarder didn't connect")
+		return nil
+	}
+}
+
+func TestForwarder(t *testing.T) {
+	t.Parallel()
+
+	in := newFakeInput(t)
+	f := newForwarder(in.listener.Addr().String())
+
+	messages := [][]byte{
+		encodeMessage("tag", time.Unix(1, 0), map[string]any{"n": "1"}),
+		encodeMessage("tag", time.Unix(2, 0), map[string]any{"n": "2"}),
+		encodeMessage("tag", time.Unix(3, 0), map[string]any{"n": "3"}),
+	}
+	var expected []byte
+	for _, m := range messages {
+		f.send(m)
+		expected = append(expected, m...)
+	}
+
+	conn := in.accept(t)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	received := make([]byte, len(expected))
+	_, err := io.ReadFull(conn, received)
+	require.NoError(t, err)
+	require.Equal(t, expected, received)
+
+	// The connection is closed with the forwarder
+	f.close()
+	n, err := conn.Read(make([]byte, 1))
+	require.Equal(t, 0, n)
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestForwarderReconnect(t *testing.T) {
+	t.Parallel()
+
+	in := newFakeInput(t)
+	f := newForwarder(in.listener.Addr().String())
+	defer f.close()
+
+	message := encodeMessage("tag", time.Unix(1, 0), map[string]any{})
+
+	// The input closes the first connection after a message, the forwarder
+	// connects again once writing to it fails
+	f.send(message)
+	conn := in.accept(t)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err := io.ReadFull(conn, make([]byte, len(message)))
+	require.NoError(t, err)
+	conn.Close()
+
+	deadline := time.After(5 * time.Second)
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case conn := <-in.conns:
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			_, err := io.ReadFull(conn, make([]byte, len(message)))
+			require.NoError(t, err)
+			return
+		case <-ticker.C:
+			f.send(message)
+		case <-deadline:
+			t.Fatal("forwarder didn't reconnect")
+		}
+	}
+}
+
+func TestForwarderUnavailable(t *testing.T) {
+	t.Parallel()
+
+	// Get an address nothing listens on
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+
+	f := newForwarder(address)
+	for i := 0; i < 2*bufferSize; i++ {
+		// The messages are dropped instead of blocking
+		f.send([]byte{0xc0})
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		f.close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("forwarder didn't stop")
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fluentforward
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// eventTime is the decoded EventTime extension type
+type eventTime struct {
+	sec  uint32
+	nsec uint32
+}
+
+// decodeValue decodes the subset of MessagePack written by appendValue, the
+// maps being decoded to map[string]any
+func decodeValue(b []byte) (any, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, fmt.Errorf("unexpected end of data")
+	}
+
+	c := b[0]
+	b = b[1:]
+	switch {
+	case c <= 0x7f:
+		return uint64(c), b, nil
+	case c >= 0xe0:
+		return int64(int8(c)), b, nil
+	case c&0xe0 == 0xa0:
+		return decodeString(b, int(c&0x1f))
+	case c&0xf0 == 0x90:
+		return decodeArray(b, int(c&0x0f))
+	case c&0xf0 == 0x80:
+		return decodeMap(b, int(c&0x0f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, b, nil
+	case 0xc2:
+		return false, b, nil
+	case 0xc3:
+		return true, b, nil
+	case 0xcb:
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), b[8:], nil
+	case 0xcc:
+		return uint64(b[0]), b[1:], nil
+	case 0xcd:
+		return uint64(binary.BigEndian.Uint16(b)), b[2:], nil
+	case 0xce:
+		return uint64(binary.BigEndian.Uint32(b)), b[4:], nil
+	case 0xcf:
+		return binary.BigEndian.Uint64(b), b[8:], nil
+	case 0xd0:
+		return int64(int8(b[0])), b[1:], nil
+	case 0xd1:
+		return int64(int16(binary.BigEndian.Uint16(b))), b[2:], nil
+	case 0xd2:
+		return int64(int32(binary.BigEndian.Uint32(b))), b[4:], nil
+	case 0xd3:
+		return int64(binary.BigEndian.Uint64(b)), b[8:], nil
+	case 0xd7:
+		if b[0] != 0x00 {
+			return nil, nil, fmt.Errorf("unexpected extension type %d", b[0])
+		}
+		return eventTime{binary.BigEndian.Uint32(b[1:]), binary.BigEndian.Uint32(b[5:])}, b[9:], nil
+	case 0xd9:
+		return decodeString(b[1:], int(b[0]))
+	case 0xda:
+		return decodeString(b[2:], int(binary.BigEndian.Uint16(b)))
+	case 0xdb:
+		return decodeString(b[4:], int(binary.BigEndian.Uint32(b)))
+	case 0xdc:
+		return decodeArray(b[2:], int(binary.BigEndian.Uint16(b)))
+	case 0xde:
+		return decodeMap(b[2:], int(binary.BigEndian.Uint16(b)))
+	}
+	return nil, nil, fmt.Errorf("unexpected type 0x%x", c)
+}
+
+func decodeString(b []byte, n int) (any, []byte, error) {
+	if len(b) < n {
+		return nil, nil, fmt.Errorf("unexpected end of string")
+	}
+	return string(b[:n]), b[n:], nil
+}
+
+func decodeArray(b []byte, n int) (any, []byte, error) {
+	a := make([]any, 0, n)
+	for i := 0; i < n; i++ {
+		var v any
+		var err error
+		v, b, err = decodeValue(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		a = append(a, v)
+	}
+	return a, b, nil
+}
+
+func decodeMap(b []byte, n int) (any, []byte, error) {
+	m := make(map[string]any, n)
+	for i := 0; i < n; i++ {
+		var k, v any
+		var err error
+		k, b, err = decodeValue(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, nil, fmt.Errorf("unexpected key %v", k)
+		}
+		v, b, err = decodeValue(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		m[key] = v
+	}
+	return m, b, nil
+}
+
+func TestAppendValue(t *testing.T) {
+	t.Parallel()
+
+	table := []struct {
+		description string
+		value       any
+		expected    []byte
+	}{
+		{"nil", nil, []byte{0xc0}},
+		{"false", false, []byte{0xc2}},
+		{"true", true, []byte{0xc3}},
+		{"positive_fixint", json.Number("127"), []byte{0x7f}},
+		{"uint8", json.Number("128"), []byte{0xcc, 0x80}},
+		{"uint16", json.Number("65535"), []byte{0xcd, 0xff, 0xff}},
+		{"uint32", json.Number("65536"), []byte{0xce, 0x00, 0x01, 0x00, 0x00}},
+		{"uint64", json.Number("18446744073709551615"), []byte{0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{"negative_fixint", json.Number("-32"), []byte{0xe0}},
+		{"int8", json.Number("-33"), []byte{0xd0, 0xdf}},
+		{"int16", json.Number("-129"), []byte{0xd1, 0xff, 0x7f}},
+		{"int32", json.Number("-32769"), []byte{0xd2, 0xff, 0xff, 0x7f, 0xff}},
+		{"int64", json.Number("-2147483649"), []byte{0xd3, 0xff, 0xff, 0xff, 0xff, 0x7f, 0xff, 0xff, 0xff}},
+		{"float", json.Number("1.5"), []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"fixstr", "abc", []byte{0xa3, 'a', 'b', 'c'}},
+		{"fixarray", []any{true, nil}, []byte{0x92, 0xc3, 0xc0}},
+		{"fixmap", map[string]any{"a": json.Number("1")}, []byte{0x81, 0xa1, 'a', 0x01}},
+	}
+
+	for _, entry := range table {
+		entry := entry
+		t.Run(entry.description, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, entry.expected, appendValue(nil, entry.value))
+		})
+	}
+}
+
+func TestAppendValueLengths(t *testing.T) {
+	t.Parallel()
+
+	long := func(n int) string {
+		return strings.Repeat("x", n)
+	}
+	array := func(n int) []any {
+		a := make([]any, n)
+		for i := range a {
+			a[i] = json.Number(fmt.Sprint(i))
+		}
+		return a
+	}
+	toUints := func(a []any) []any {
+		u := make([]any, len(a))
+		for i := range a {
+			u[i] = uint64(i)
+		}
+		return u
+	}
+	object := map[string]any{}
+	for i := 0; i < 20; i++ {
+		object[fmt.Sprintf("key%d", i)] = fmt.Sprintf("value%d", i)
+	}
+
+	table := []struct {
+		description string
+		value       any
+		header      []byte
+		expected    any
+	}{
+		{"str8", long(32), []byte{0xd9, 32}, long(32)},
+		{"str16", long(256), []byte{0xda, 0x01, 0x00}, long(256)},
+		{"str32", long(65536), []byte{0xdb, 0x00, 0x01, 0x00, 0x00}, long(65536)},
+		{"array16", array(16), []byte{0xdc, 0x00, 0x10}, toUints(array(16))},
+		{"map16", object, []byte{0xde, 0x00, 0x14}, object},
+	}
+
+	for _, entry := range table {
+		entry := entry
+		t.Run(entry.description, func(t *testing.T) {
+			t.Parallel()
+
+			b := appendValue(nil, entry.value)
+			require.Equal(t, entry.header, b[:len(entry.header)])
+
+			decoded, rest, err := decodeValue(b)
+			require.NoError(t, err)
+			require.Empty(t, rest)
+			require.Equal(t, entry.expected, decoded)
+		})
+	}
+}
+
+func TestEncodeMessage(t *testing.T) {
+	t.Parallel()
+
+	ts := time.Unix(1700000000, 123456789)
+	record := map[string]any{
+		"comm":    "cat",
+		"pid":     json.Number("1234"),
+		"ok":      true,
+		"args":    []any{"cat", "/etc/passwd"},
+		"latency": json.Number("0.25"),
+		"k8s":     map[string]any{"namespace": "ns"},
+	}
+	b := encodeMessage("inspektor-gadget.trace.exec", ts, record)
+
+	// The entry is [tag, time, record]
+	decoded, rest, err := decodeValue(b)
+	require.NoError(t, err)
+	require.Empty(t, rest)
+	require.Equal(t, []any{
+		"inspektor-gadget.trace.exec",
+		eventTime{sec: 1700000000, nsec: 123456789},
+		map[string]any{
+			"comm":    "cat",
+			"pid":     uint64(1234),
+			"ok":      true,
+			"args":    []any{"cat", "/etc/passwd"},
+			"latency": 0.25,
+			"k8s":     map[string]any{"namespace": "ns"},
+		},
+	}, decoded)
+}
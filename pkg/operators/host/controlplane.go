@@ -0,0 +1,95 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package host
+
+import (
+	"fmt"
+	"strings"
+)
+
+const PresetControlPlane = "control-plane"
+
+// ComponentSetter is implemented by the events that can tell the component of
+// the control plane they come from
+type ComponentSetter interface {
+	SetComponent(component string)
+}
+
+// controlPlaneComms are the command names of the processes of the control
+// plane when they run on the host, truncated to 15 characters like in the
+// events
+var controlPlaneComms = []string{
+	"kubelet",
+	"containerd",
+	"containerd-shim",
+	"dockerd",
+	"cri-dockerd",
+	"crio",
+	"conmon",
+	"kube-apiserver",
+	"kube-controller",
+	"kube-scheduler",
+	"kube-proxy",
+	"etcd",
+}
+
+// controlPlanePods are the components of the control plane running as static
+// pods, whose names are the ones of the components followed by the node name
+var controlPlanePods = []string{
+	"kube-apiserver",
+	"kube-controller-manager",
+	"kube-scheduler",
+	"etcd",
+}
+
+func validatePreset(value string) error {
+	if value != "" && value != PresetControlPlane {
+		return fmt.Errorf("unknown preset %q: valid presets are: %s", value, PresetControlPlane)
+	}
+	return nil
+}
+
+type componentMatcher struct {
+	component string
+	match     func(ev any) bool
+}
+
+// controlPlanePodComponent returns the component of the control plane running
+// in the static pod, or an empty string if it isn't one of them
+func controlPlanePodComponent(namespace, pod string) string {
+	if namespace != "kube-system" {
+		return ""
+	}
+	for _, component := range controlPlanePods {
+		if strings.HasPrefix(pod, component+"-") {
+			return component
+		}
+	}
+	return ""
+}
+
+// component returns the component of the control plane the event comes from,
+// or an empty string if it isn't one of them
+func (i *HostInstance) component(event HostInformation) string {
+	if event.GetContainer() != "" {
+		return controlPlanePodComponent(event.GetNamespace(), event.GetPod())
+	}
+	for _, m := range i.components {
+		if m.match(event) {
+			return m.component
+		}
+	}
+	return ""
+}
@@ -0,0 +1,326 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package host provides an operator that tells the events coming from the
+// processes of the host, i.e. not running in a container, and that allows to
+// trace only them, optionally selected by pid, comm or cgroup. It also tells
+// the events of the components of the control plane, on the host or in static
+// pods, and allows to trace only them with a preset.
+package host
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cilium/ebpf"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/cgroups"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperatorName = "Host"
+
+	ParamHost       = "host"
+	ParamHostPid    = "host-pid"
+	ParamHostComm   = "host-comm"
+	ParamHostCgroup = "host-cgroup"
+	ParamPreset     = "preset"
+)
+
+// managers are the operators enriching the events with their container, one
+// of them is registered in ig and in the gadget pods
+var managers = []string{"LocalManager", "KubeManager"}
+
+// HostInformation is implemented by the events that can tell whether they
+// come from a process of the host
+type HostInformation interface {
+	operators.ContainerInfoFromMountNSID
+	operators.ContainerInfoGetters
+	SetHost(host bool)
+}
+
+type eventTypeGetter interface {
+	GetType() eventtypes.EventType
+}
+
+// CgroupFilterSetter is implemented by the gadgets that can filter the events
+// by cgroup in eBPF
+type CgroupFilterSetter interface {
+	SetCgroupFilter(cgroupPath string)
+}
+
+type MountNsMapSetter interface {
+	SetMountNsMap(*ebpf.Map)
+}
+
+type Host struct{}
+
+func (h *Host) Name() string {
+	return OperatorName
+}
+
+func (h *Host) Description() string {
+	return "Host tells the events coming from processes not running in a container and allows to select them"
+}
+
+func (h *Host) GlobalParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (h *Host) ParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:          ParamHost,
+			Title:        "Host",
+			DefaultValue: "false",
+			Description:  "Show only processes of the host, not running in a container",
+			TypeHint:     params.TypeBool,
+		},
+		{
+			Key:         ParamHostPid,
+			Title:       "Host PIDs",
+			Description: "Show only the host processes with these comma-separated pids, implies --host",
+		},
+		{
+			Key:         ParamHostComm,
+			Title:       "Host Commands",
+			Description: "Show only the host processes with these comma-separated command names, implies --host",
+		},
+		{
+			Key:         ParamHostCgroup,
+			Title:       "Host Cgroup",
+			Description: "Show only the host processes in this cgroup (e.g. /system.slice) or in its descendants, implies --host",
+		},
+		{
+			Key:   ParamPreset,
+			Title: "Preset",
+			Description: "Show only the processes of a preset: \"control-plane\" for the kubelet, the container runtime " +
+				"and the control plane, running on the host or in static pods",
+			Validator: validatePreset,
+		},
+	}
+}
+
+// Dependencies makes the operator run after the one enriching the events with
+// their container and setting the mount namespace map of the gadget, which it
+// removes when selecting the host processes
+func (h *Host) Dependencies() []string {
+	for _, manager := range managers {
+		if operators.GetRaw(manager) != nil {
+			return []string{manager}
+		}
+	}
+	return nil
+}
+
+func (h *Host) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	for _, dependency := range h.Dependencies() {
+		if !operators.GetRaw(dependency).CanOperateOn(gadget) {
+			return false
+		}
+	}
+
+	_, hasHostInf := gadget.EventPrototype().(HostInformation)
+	return hasHostInf && gadget.Parser() != nil
+}
+
+func (h *Host) IsUnprivilegedEnricher() {}
+
+func (h *Host) Init(params *params.Params) error {
+	return nil
+}
+
+func (h *Host) Close() error {
+	return nil
+}
+
+func (h *Host) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	instance := &HostInstance{
+		gadgetCtx:      gadgetCtx,
+		gadgetInstance: gadgetInstance,
+		hostOnly:       params.Get(ParamHost).AsBool(),
+		cgroup:         params.Get(ParamHostCgroup).AsString(),
+		controlPlane:   params.Get(ParamPreset).AsString() == PresetControlPlane,
+	}
+	if instance.cgroup != "" {
+		instance.hostOnly = true
+	}
+
+	// The pids and the commands are matched with the columns of the gadget,
+	// with the syntax of --filter
+	var filters []string
+	for _, pid := range params.Get(ParamHostPid).AsStringSlice() {
+		if _, err := strconv.ParseUint(pid, 10, 32); err != nil {
+			return nil, fmt.Errorf("invalid pid %q in --%s", pid, ParamHostPid)
+		}
+		filters = append(filters, "pid:"+pid)
+	}
+	for _, comm := range params.Get(ParamHostComm).AsStringSlice() {
+		filters = append(filters, "comm:"+comm)
+	}
+	for _, filter := range filters {
+		match, err := gadgetCtx.GadgetDesc().Parser().NewMatcher(filter)
+		if err != nil {
+			return nil, fmt.Errorf("gadget %q can't select host processes by %s: %w",
+				gadgetCtx.GadgetDesc().Name(), strings.SplitN(filter, ":", 2)[0], err)
+		}
+		instance.matchers = append(instance.matchers, match)
+		instance.hostOnly = true
+	}
+
+	// The components of the control plane on the host are told by their
+	// command name, when the gadget has it
+	for _, comm := range controlPlaneComms {
+		match, err := gadgetCtx.GadgetDesc().Parser().NewMatcher("comm:" + comm)
+		if err != nil {
+			gadgetCtx.Logger().Debugf("gadget %q can't tell the components of the control plane on the host: %s",
+				gadgetCtx.GadgetDesc().Name(), err)
+			break
+		}
+		instance.components = append(instance.components, componentMatcher{component: comm, match: match})
+	}
+	if instance.controlPlane && instance.hostOnly {
+		return nil, fmt.Errorf("--%s can't be used with the selection of the host processes", ParamPreset)
+	}
+
+	return instance, nil
+}
+
+type HostInstance struct {
+	gadgetCtx      operators.GadgetContext
+	gadgetInstance any
+	hostOnly       bool
+	cgroup         string
+	matchers       []func(ev any) bool
+	controlPlane   bool
+	components     []componentMatcher
+}
+
+func (i *HostInstance) Name() string {
+	return "HostInstance"
+}
+
+// ConfigureGadget removes the mount namespace map of the gadget and gives it
+// the cgroup to filter on when the host processes are selected, for the
+// instances running in another process than the gadget, see
+// operators.GadgetConfigurer
+func (h *Host) ConfigureGadget(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) error {
+	instance, err := h.Instantiate(gadgetCtx, gadgetInstance, params)
+	if err != nil {
+		return err
+	}
+	return instance.(*HostInstance).configureGadget()
+}
+
+func (i *HostInstance) PreGadgetRun() error {
+	// Without gadget instance, the gadget runs in another process and was
+	// configured there with ConfigureGadget
+	if i.gadgetInstance == nil {
+		return nil
+	}
+	return i.configureGadget()
+}
+
+func (i *HostInstance) configureGadget() error {
+	if i.controlPlane {
+		// The processes on the host and the static pods are selected in
+		// FilterEvent
+		if setter, ok := i.gadgetInstance.(MountNsMapSetter); ok {
+			setter.SetMountNsMap(nil)
+		}
+		return nil
+	}
+	if !i.hostOnly {
+		return nil
+	}
+
+	if i.cgroup != "" {
+		setter, ok := i.gadgetInstance.(CgroupFilterSetter)
+		if !ok {
+			return fmt.Errorf("gadget %q can't filter by cgroup", i.gadgetCtx.GadgetDesc().Name())
+		}
+		cgroupPath, err := cgroups.CgroupPathV2AddMountpoint(i.cgroup)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(cgroupPath); err != nil {
+			return fmt.Errorf("finding cgroup %q: %w", i.cgroup, err)
+		}
+		i.gadgetCtx.Logger().Debugf("filtering on cgroup %q", cgroupPath)
+		setter.SetCgroupFilter(cgroupPath)
+	}
+
+	// The mount namespace map only lets the events of the containers go
+	// through, the ones of the host are selected in FilterEvent
+	if setter, ok := i.gadgetInstance.(MountNsMapSetter); ok {
+		setter.SetMountNsMap(nil)
+	}
+	return nil
+}
+
+func (i *HostInstance) PostGadgetRun() error {
+	return nil
+}
+
+func (i *HostInstance) EnrichEvent(ev any) error {
+	event, ok := ev.(HostInformation)
+	if !ok || event.GetMountNSID() == 0 {
+		return nil
+	}
+	event.SetHost(event.GetContainer() == "")
+	if setter, ok := ev.(ComponentSetter); ok {
+		setter.SetComponent(i.component(event))
+	}
+	return nil
+}
+
+// FilterEvent drops the events of the containers and of the host processes
+// not selected by pid or comm when selecting the host processes, and the ones
+// not coming from the control plane with the control-plane preset
+func (i *HostInstance) FilterEvent(ev any) bool {
+	if !i.hostOnly && !i.controlPlane {
+		return true
+	}
+	if e, ok := ev.(eventTypeGetter); ok && e.GetType() != eventtypes.NORMAL {
+		// Messages of the gadget itself
+		return true
+	}
+	if i.controlPlane {
+		event, ok := ev.(HostInformation)
+		return ok && event.GetMountNSID() != 0 && i.component(event) != ""
+	}
+	event, ok := ev.(HostInformation)
+	if !ok || event.GetMountNSID() == 0 || event.GetContainer() != "" {
+		return false
+	}
+	if len(i.matchers) == 0 {
+		return true
+	}
+	for _, match := range i.matchers {
+		if match(ev) {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	operators.Register(&Host{})
+}
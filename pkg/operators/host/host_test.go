@@ -0,0 +1,146 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package host
+
+import (
+	"testing"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type testEvent struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+	Pid  uint32 `column:"pid"`
+	Comm string `column:"comm"`
+}
+
+func newInstance(t *testing.T, hostOnly bool, filters ...string) *HostInstance {
+	t.Helper()
+	p := parser.NewParser[testEvent](columns.MustCreateColumns[testEvent]())
+	i := &HostInstance{hostOnly: hostOnly}
+	for _, filter := range filters {
+		match, err := p.NewMatcher(filter)
+		if err != nil {
+			t.Fatalf("Creating matcher: %s", err)
+		}
+		i.matchers = append(i.matchers, match)
+	}
+	return i
+}
+
+func newEvent(container string, pid uint32, comm string) *testEvent {
+	ev := &testEvent{Pid: pid, Comm: comm}
+	ev.Type = eventtypes.NORMAL
+	ev.MountNsID = 4026531840
+	ev.Container = container
+	return ev
+}
+
+func TestEnrichEvent(t *testing.T) {
+	i := newInstance(t, false)
+
+	ev := newEvent("", 1, "systemd")
+	i.EnrichEvent(ev)
+	if !ev.Host {
+		t.Fatalf("Event without container not set as coming from the host")
+	}
+
+	ev = newEvent("nginx", 1, "nginx")
+	i.EnrichEvent(ev)
+	if ev.Host {
+		t.Fatalf("Event of container set as coming from the host")
+	}
+
+	// Without mount namespace, the process of the event isn't known
+	ev = newEvent("", 0, "")
+	ev.MountNsID = 0
+	i.EnrichEvent(ev)
+	if ev.Host {
+		t.Fatalf("Event without mount namespace set as coming from the host")
+	}
+}
+
+func TestFilterEvent(t *testing.T) {
+	message := &testEvent{}
+	message.Type = eventtypes.ERR
+
+	table := []struct {
+		description string
+		instance    *HostInstance
+		event       *testEvent
+		expected    bool
+	}{
+		{"all events", newInstance(t, false), newEvent("nginx", 42, "nginx"), true},
+		{"container", newInstance(t, true), newEvent("nginx", 42, "nginx"), false},
+		{"host", newInstance(t, true), newEvent("", 42, "sshd"), true},
+		{"gadget message", newInstance(t, true), message, true},
+		{"host pid", newInstance(t, true, "pid:42", "pid:43"), newEvent("", 43, "sshd"), true},
+		{"other host pid", newInstance(t, true, "pid:42"), newEvent("", 43, "sshd"), false},
+		{"host comm", newInstance(t, true, "comm:kubelet"), newEvent("", 43, "kubelet"), true},
+		{"container comm", newInstance(t, true, "comm:kubelet"), newEvent("kind", 43, "kubelet"), false},
+	}
+	for _, entry := range table {
+		if actual := entry.instance.FilterEvent(entry.event); actual != entry.expected {
+			t.Errorf("%s: expected %v, got %v", entry.description, entry.expected, actual)
+		}
+	}
+}
+
+func TestControlPlane(t *testing.T) {
+	p := parser.NewParser[testEvent](columns.MustCreateColumns[testEvent]())
+	i := &HostInstance{controlPlane: true}
+	for _, comm := range controlPlaneComms {
+		match, err := p.NewMatcher("comm:" + comm)
+		if err != nil {
+			t.Fatalf("Creating matcher: %s", err)
+		}
+		i.components = append(i.components, componentMatcher{component: comm, match: match})
+	}
+
+	staticPod := newEvent("kube-apiserver", 42, "kube-apiserver")
+	staticPod.Namespace = "kube-system"
+	staticPod.Pod = "kube-apiserver-minikube"
+	otherPod := newEvent("coredns", 43, "coredns")
+	otherPod.Namespace = "kube-system"
+	otherPod.Pod = "coredns-5d78c9869d-xq4vb"
+
+	table := []struct {
+		description string
+		event       *testEvent
+		component   string
+	}{
+		{"static pod", staticPod, "kube-apiserver"},
+		{"other pod", otherPod, ""},
+		{"kubelet on the host", newEvent("", 44, "kubelet"), "kubelet"},
+		{"kubelet in a container", newEvent("kind", 45, "kubelet"), ""},
+		{"other host process", newEvent("", 46, "sshd"), ""},
+	}
+	for _, entry := range table {
+		i.EnrichEvent(entry.event)
+		if entry.event.Component != entry.component {
+			t.Errorf("%s: expected component %q, got %q", entry.description, entry.component, entry.event.Component)
+		}
+		if actual := i.FilterEvent(entry.event); actual != (entry.component != "") {
+			t.Errorf("%s: expected %v, got %v", entry.description, entry.component != "", actual)
+		}
+	}
+
+	if err := validatePreset("workers"); err == nil {
+		t.Errorf("Unknown preset accepted")
+	}
+}
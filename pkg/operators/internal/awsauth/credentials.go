@@ -0,0 +1,211 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package awsauth signs the requests to the AWS APIs and gets the credentials
+// to sign them with, without depending on the SDK of AWS.
+package awsauth
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+)
+
+const (
+	// refreshMargin is how long before their expiration the temporary
+	// credentials are renewed
+	refreshMargin = 5 * time.Minute
+
+	maxSessionNameLen = 64
+)
+
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Expiry is zero for static credentials
+	Expiry time.Time
+}
+
+// CredentialsProvider gets the credentials with, in this order:
+//   - The AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables.
+//   - The token of IAM roles for service accounts, exchanged for temporary
+//     credentials with AssumeRoleWithWebIdentity.
+//   - The container credentials endpoint, used by EKS Pod Identity.
+type CredentialsProvider struct {
+	mu          sync.Mutex
+	fetch       func(ctx context.Context) (*Credentials, error)
+	credentials *Credentials
+}
+
+func NewCredentialsProvider(region string) (*CredentialsProvider, error) {
+	p := &CredentialsProvider{}
+
+	switch {
+	case os.Getenv("AWS_ACCESS_KEY_ID") != "" && os.Getenv("AWS_SECRET_ACCESS_KEY") != "":
+		p.credentials = &Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}
+	case os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE") != "" && os.Getenv("AWS_ROLE_ARN") != "":
+		p.fetch = func(ctx context.Context) (*Credentials, error) {
+			return assumeRoleWithWebIdentity(ctx, region,
+				os.Getenv("AWS_ROLE_ARN"), os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"))
+		}
+	case os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI") != "":
+		p.fetch = func(ctx context.Context) (*Credentials, error) {
+			return containerCredentials(ctx,
+				os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"), os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"))
+		}
+	default:
+		return nil, errors.New("no credentials found, configure IAM roles for service accounts or EKS Pod Identity")
+	}
+
+	return p, nil
+}
+
+func (p *CredentialsProvider) Get(ctx context.Context) (*Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.credentials != nil && (p.fetch == nil || time.Now().Add(refreshMargin).Before(p.credentials.Expiry)) {
+		return p.credentials, nil
+	}
+
+	credentials, err := p.fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	p.credentials = credentials
+	return credentials, nil
+}
+
+type assumeRoleWithWebIdentityResponse struct {
+	Credentials struct {
+		AccessKeyID     string    `xml:"AccessKeyId"`
+		SecretAccessKey string    `xml:"SecretAccessKey"`
+		SessionToken    string    `xml:"SessionToken"`
+		Expiration      time.Time `xml:"Expiration"`
+	} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+}
+
+// assumeRoleWithWebIdentity exchanges the token of the service account for
+// temporary credentials of the role. This call of STS doesn't need to be
+// signed.
+func assumeRoleWithWebIdentity(ctx context.Context, region, roleARN, tokenFile string) (*Credentials, error) {
+	// The token is renewed by the kubelet, read it again each time
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading web identity token: %w", err)
+	}
+
+	sessionName := "inspektor-gadget-" + cloudsink.NodeName()
+	if len(sessionName) > maxSessionNameLen {
+		sessionName = sessionName[:maxSessionNameLen]
+	}
+
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {roleARN},
+		"RoleSessionName":  {sessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	endpoint := fmt.Sprintf("https://sts.%s.amazonaws.com/", region)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := cloudsink.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("assuming role %q: %w", roleARN, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("assuming role %q: %w", roleARN, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("assuming role %q: %w", roleARN,
+			&cloudsink.HTTPError{StatusCode: resp.StatusCode, Body: string(body)})
+	}
+
+	var out assumeRoleWithWebIdentityResponse
+	if err := xml.Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("decoding credentials: %w", err)
+	}
+	if out.Credentials.AccessKeyID == "" {
+		return nil, errors.New("no credentials in response of AssumeRoleWithWebIdentity")
+	}
+
+	return &Credentials{
+		AccessKeyID:     out.Credentials.AccessKeyID,
+		SecretAccessKey: out.Credentials.SecretAccessKey,
+		SessionToken:    out.Credentials.SessionToken,
+		Expiry:          out.Credentials.Expiration,
+	}, nil
+}
+
+type containerCredentialsResponse struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	Token           string    `json:"Token"`
+	Expiration      time.Time `json:"Expiration"`
+}
+
+// containerCredentials gets the credentials from the endpoint of the EKS Pod
+// Identity agent running on the node
+func containerCredentials(ctx context.Context, endpoint, tokenFile string) (*Credentials, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if tokenFile != "" {
+		token, err := os.ReadFile(tokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading authorization token: %w", err)
+		}
+		req.Header.Set("Authorization", strings.TrimSpace(string(token)))
+	}
+
+	var out containerCredentialsResponse
+	if err := cloudsink.Do(req, &out); err != nil {
+		return nil, fmt.Errorf("getting container credentials: %w", err)
+	}
+	if out.AccessKeyID == "" {
+		return nil, errors.New("no credentials in response of the container credentials endpoint")
+	}
+
+	return &Credentials{
+		AccessKeyID:     out.AccessKeyID,
+		SecretAccessKey: out.SecretAccessKey,
+		SessionToken:    out.Token,
+		Expiry:          out.Expiration,
+	}, nil
+}
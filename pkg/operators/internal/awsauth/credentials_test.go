@@ -0,0 +1,121 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsauth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func unsetCredentialsEnv(t *testing.T) {
+	for _, name := range []string{
+		"AWS_ACCESS_KEY_ID",
+		"AWS_SECRET_ACCESS_KEY",
+		"AWS_SESSION_TOKEN",
+		"AWS_WEB_IDENTITY_TOKEN_FILE",
+		"AWS_ROLE_ARN",
+		"AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestCredentialsProviderStatic(t *testing.T) {
+	unsetCredentialsEnv(t)
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	// Static credentials take precedence
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", "http://127.0.0.1:1/")
+
+	p, err := NewCredentialsProvider("us-east-1")
+	require.NoError(t, err)
+
+	credentials, err := p.Get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, &Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "secret"}, credentials)
+}
+
+func TestCredentialsProviderNone(t *testing.T) {
+	unsetCredentialsEnv(t)
+
+	_, err := NewCredentialsProvider("us-east-1")
+	require.Error(t, err)
+}
+
+func TestCredentialsProviderContainer(t *testing.T) {
+	unsetCredentialsEnv(t)
+
+	requests := 0
+	expiration := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("Authorization") != "pod-identity-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, `{"AccessKeyId":"AKID%d","SecretAccessKey":"secret","Token":"session","Expiration":%q}`,
+			requests, expiration.Format(time.RFC3339))
+	}))
+	t.Cleanup(server.Close)
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("pod-identity-token\n"), 0o600))
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", server.URL)
+	t.Setenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE", tokenFile)
+
+	p, err := NewCredentialsProvider("us-east-1")
+	require.NoError(t, err)
+
+	expected := &Credentials{
+		AccessKeyID:     "AKID1",
+		SecretAccessKey: "secret",
+		SessionToken:    "session",
+		Expiry:          expiration,
+	}
+	for i := 0; i < 2; i++ {
+		credentials, err := p.Get(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, expected.AccessKeyID, credentials.AccessKeyID)
+		require.Equal(t, expected.SecretAccessKey, credentials.SecretAccessKey)
+		require.Equal(t, expected.SessionToken, credentials.SessionToken)
+		require.True(t, expected.Expiry.Equal(credentials.Expiry))
+	}
+	require.Equal(t, 1, requests, "credentials not cached")
+}
+
+func TestCredentialsProviderContainerUnauthorized(t *testing.T) {
+	unsetCredentialsEnv(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", server.URL)
+
+	p, err := NewCredentialsProvider("us-east-1")
+	require.NoError(t, err)
+
+	_, err = p.Get(context.Background())
+	require.Error(t, err)
+}
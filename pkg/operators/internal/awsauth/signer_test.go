@@ -0,0 +1,64 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package awsauth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestSignerVanilla checks the signature of the get-vanilla case of the test
+// suite of Signature Version 4
+func TestSignerVanilla(t *testing.T) {
+	t.Parallel()
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+
+	signer := &Signer{Region: "us-east-1", Service: "service"}
+	credentials := &Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	signer.signAt(req, nil, credentials, time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	require.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	require.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, "+
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+func TestSignerSessionToken(t *testing.T) {
+	t.Parallel()
+
+	req, err := http.NewRequest(http.MethodPost, "https://logs.eu-west-1.amazonaws.com/", nil)
+	require.NoError(t, err)
+
+	signer := &Signer{Region: "eu-west-1", Service: "logs"}
+	credentials := &Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		SessionToken:    "session",
+	}
+	signer.signAt(req, []byte("{}"), credentials, time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	require.Equal(t, "session", req.Header.Get("X-Amz-Security-Token"))
+	require.Contains(t, req.Header.Get("Authorization"),
+		"Credential=AKIDEXAMPLE/20230102/eu-west-1/logs/aws4_request, SignedHeaders=host;x-amz-date;x-amz-security-token, ")
+}
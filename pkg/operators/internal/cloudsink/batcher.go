@@ -0,0 +1,162 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudsink
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// bufferSize is the number of records waiting to be batched, the
+	// following ones are dropped
+	bufferSize = 8192
+
+	// flushTimeout is how long sending a batch can take, including getting
+	// the credentials
+	flushTimeout = 30 * time.Second
+
+	// DefaultInterval is how often the records are sent if the batches don't
+	// fill up before
+	DefaultInterval = 5 * time.Second
+)
+
+// Limits are the limits of the API of a service for a single request
+type Limits struct {
+	MaxRecords int
+	MaxBytes   int
+
+	// RecordOverhead is added to the size of the message of each record to
+	// compute the size of a batch
+	RecordOverhead int
+}
+
+// FlushFunc sends a batch of records
+type FlushFunc func(ctx context.Context, records []*Record) error
+
+// Batcher collects the records in the background and sends them in batches,
+// so a slow or unavailable service doesn't slow down the gadgets. The batches
+// failing to be sent are dropped.
+type Batcher struct {
+	service  string
+	limits   Limits
+	interval time.Duration
+	flush    FlushFunc
+
+	records chan *Record
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func NewBatcher(service string, limits Limits, interval time.Duration, flush FlushFunc) *Batcher {
+	b := &Batcher{
+		service:  service,
+		limits:   limits,
+		interval: interval,
+		flush:    flush,
+		records:  make(chan *Record, bufferSize),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// Add queues a record, it's dropped if the buffer is full
+func (b *Batcher) Add(r *Record) {
+	select {
+	case b.records <- r:
+	default:
+	}
+}
+
+// Close sends the pending records and stops the batcher
+func (b *Batcher) Close() {
+	close(b.done)
+	<-b.stopped
+}
+
+func (b *Batcher) run() {
+	defer close(b.stopped)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	var batch []*Record
+	size := 0
+	failing := false
+
+	send := func() {
+		if len(batch) == 0 {
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+		err := b.flush(ctx, batch)
+		cancel()
+		if err != nil {
+			// Only log the first failure to not flood the logs while the
+			// service is unavailable
+			if !failing {
+				log.Warnf("sending %d events to %s: %v", len(batch), b.service, err)
+			}
+			failing = true
+		} else {
+			if failing {
+				log.Infof("sending events to %s again", b.service)
+			}
+			failing = false
+		}
+
+		batch = nil
+		size = 0
+	}
+
+	add := func(r *Record) {
+		recordSize := len(r.Message) + b.limits.RecordOverhead
+		if recordSize > b.limits.MaxBytes {
+			// The service would reject the whole batch
+			log.Debugf("dropping event of %d bytes too large for %s", recordSize, b.service)
+			return
+		}
+		if len(batch) >= b.limits.MaxRecords || size+recordSize > b.limits.MaxBytes {
+			send()
+		}
+		batch = append(batch, r)
+		size += recordSize
+	}
+
+	for {
+		select {
+		case r := <-b.records:
+			add(r)
+		case <-ticker.C:
+			send()
+		case <-b.done:
+			// Send what has been queued before closing
+			for {
+				select {
+				case r := <-b.records:
+					add(r)
+				default:
+					send()
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudsink
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeService records the batches it receives, failing the ones whose index
+// is in fail
+type fakeService struct {
+	mu      sync.Mutex
+	batches [][]string
+	fail    map[int]bool
+	flushed chan struct{}
+}
+
+func newFakeService(fail ...int) *fakeService {
+	s := &fakeService{
+		fail:    map[int]bool{},
+		flushed: make(chan struct{}, 100),
+	}
+	for _, i := range fail {
+		s.fail[i] = true
+	}
+	return s
+}
+
+func (s *fakeService) flush(ctx context.Context, records []*Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var messages []string
+	for _, r := range records {
+		messages = append(messages, string(r.Message))
+	}
+	i := len(s.batches)
+	s.batches = append(s.batches, messages)
+	s.flushed <- struct{}{}
+
+	if s.fail[i] {
+		return errors.New("service unavailable")
+	}
+	return nil
+}
+
+func (s *fakeService) received() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.batches
+}
+
+func testRecord(message string) *Record {
+	return &Record{Message: []byte(message)}
+}
+
+func TestBatcher(t *testing.T) {
+	t.Parallel()
+
+	table := []struct {
+		description string
+		limits      Limits
+		records     []string
+		expected    [][]string
+	}{
+		{
+			description: "single_batch",
+			limits:      Limits{MaxRecords: 10, MaxBytes: 100},
+			records:     []string{"a", "b", "c"},
+			expected:    [][]string{{"a", "b", "c"}},
+		},
+		{
+			description: "max_records",
+			limits:      Limits{MaxRecords: 2, MaxBytes: 100},
+			records:     []string{"a", "b", "c", "d", "e"},
+			expected:    [][]string{{"a", "b"}, {"c", "d"}, {"e"}},
+		},
+		{
+			description: "max_bytes",
+			limits:      Limits{MaxRecords: 10, MaxBytes: 10},
+			records:     []string{"aaaa", "bbbb", "cccc", "dd"},
+			expected:    [][]string{{"aaaa", "bbbb"}, {"cccc", "dd"}},
+		},
+		{
+			description: "record_overhead",
+			limits:      Limits{MaxRecords: 10, MaxBytes: 10, RecordOverhead: 3},
+			records:     []string{"aa", "bb", "cc"},
+			expected:    [][]string{{"aa", "bb"}, {"cc"}},
+		},
+		{
+			description: "too_large_record_dropped",
+			limits:      Limits{MaxRecords: 10, MaxBytes: 10, RecordOverhead: 2},
+			records:     []string{"a", strings.Repeat("x", 9), "b"},
+			expected:    [][]string{{"a", "b"}},
+		},
+		{
+			description: "no_records",
+			limits:      Limits{MaxRecords: 10, MaxBytes: 100},
+			expected:    nil,
+		},
+	}
+
+	for _, entry := range table {
+		entry := entry
+		t.Run(entry.description, func(t *testing.T) {
+			t.Parallel()
+
+			service := newFakeService()
+			b := NewBatcher("fake", entry.limits, time.Hour, service.flush)
+			for _, r := range entry.records {
+				b.Add(testRecord(r))
+			}
+			b.Close()
+
+			require.Equal(t, entry.expected, service.received())
+		})
+	}
+}
+
+func TestBatcherInterval(t *testing.T) {
+	t.Parallel()
+
+	service := newFakeService()
+	b := NewBatcher("fake", Limits{MaxRecords: 10, MaxBytes: 100}, 10*time.Millisecond, service.flush)
+	defer b.Close()
+
+	b.Add(testRecord("a"))
+
+	select {
+	case <-service.flushed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch not sent after the interval")
+	}
+	require.Equal(t, [][]string{{"a"}}, service.received())
+}
+
+func TestBatcherFailure(t *testing.T) {
+	t.Parallel()
+
+	// The failing batches aren't sent again, but the following ones are
+	service := newFakeService(0, 1)
+	b := NewBatcher("fake", Limits{MaxRecords: 2, MaxBytes: 100}, time.Hour, service.flush)
+	for _, r := range []string{"a", "b", "c", "d", "e", "f"} {
+		b.Add(testRecord(r))
+	}
+	b.Close()
+
+	require.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e", "f"}}, service.received())
+}
@@ -0,0 +1,76 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudsink
+
+import (
+	"fmt"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/routing"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// Instance is the operator instance of the cloud sinks, it queues the events
+// of a gadget in the batcher of the operator
+type Instance struct {
+	name        string
+	batcher     *Batcher
+	minSeverity eventtypes.Severity
+	gadget      string
+	runID       string
+}
+
+// NewInstance returns the instance of an operator for a gadget, sending the
+// events of at least minSeverity. The batcher is nil when the operator is
+// disabled.
+func NewInstance(name string, gadgetCtx operators.GadgetContext, batcher *Batcher, minSeverity eventtypes.Severity) *Instance {
+	desc := gadgetCtx.GadgetDesc()
+	return &Instance{
+		name:        name,
+		batcher:     batcher,
+		minSeverity: minSeverity,
+		gadget:      fmt.Sprintf("%s/%s", desc.Category(), desc.Name()),
+		runID:       gadgetCtx.ID(),
+	}
+}
+
+func (i *Instance) Name() string {
+	return i.name
+}
+
+func (i *Instance) PreGadgetRun() error {
+	return nil
+}
+
+func (i *Instance) PostGadgetRun() error {
+	return nil
+}
+
+func (i *Instance) EnrichEvent(ev any) error {
+	return nil
+}
+
+func (i *Instance) SinkEvent(ev any) error {
+	if i.batcher == nil || !routing.Accepts(ev, i.minSeverity) {
+		return nil
+	}
+
+	r, err := NewRecord(ev, i.gadget, i.runID)
+	if err != nil {
+		return err
+	}
+	i.batcher.Add(r)
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cloudsink provides the parts shared by the operators sending the
+// events of the gadgets to external services, like the logging services of the
+// cloud providers: the conversion of the events to records, their batching and the caching of the
+// access tokens.
+package cloudsink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityDebug
+	SeverityWarning
+	SeverityError
+	SeverityAlert
+)
+
+// Record is an event ready to be sent
+type Record struct {
+	Time     time.Time
+	Severity Severity
+	Gadget   string
+	RunID    string
+
+	// Fields are the fields of the event, as in its JSON encoding, with the
+	// gadget and the run ID
+	Fields map[string]any
+
+	// Message is what is sent for the record, the JSON encoding of Fields
+	// unless the operator encodes the event differently
+	Message []byte
+}
+
+type eventTypeGetter interface {
+	GetType() eventtypes.EventType
+}
+
+// NewRecord converts an event of a gadget to a record
+func NewRecord(ev any, gadget, runID string) (*Record, error) {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling event: %w", err)
+	}
+
+	// Use the JSON encoding of the event as a generic way to get its fields,
+	// keeping the numbers as they are to not lose the precision of the
+	// 64-bit ones
+	var fields map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("unmarshaling event: %w", err)
+	}
+	fields["gadget"] = gadget
+	fields["runID"] = runID
+
+	message, err := json.Marshal(fields)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling record: %w", err)
+	}
+
+	r := &Record{
+		Time:     time.Now(),
+		Severity: SeverityInfo,
+		Gadget:   gadget,
+		RunID:    runID,
+		Fields:   fields,
+		Message:  message,
+	}
+	if n, ok := fields["timestamp"].(json.Number); ok {
+		if nsec, err := n.Int64(); err == nil && nsec > 0 {
+			r.Time = time.Unix(0, nsec)
+		}
+	}
+	if e, ok := ev.(eventTypeGetter); ok {
+		switch e.GetType() {
+		case eventtypes.ERR:
+			r.Severity = SeverityError
+		case eventtypes.WARN:
+			r.Severity = SeverityWarning
+		case eventtypes.DEBUG:
+			r.Severity = SeverityDebug
+		case eventtypes.NORMAL:
+			switch eventtypes.EventSeverity(ev) {
+			case eventtypes.SeverityAlert:
+				r.Severity = SeverityAlert
+			case eventtypes.SeverityWarn:
+				r.Severity = SeverityWarning
+			}
+		}
+	}
+	return r, nil
+}
+
+// String returns the given field of the record if it's a string, e.g. the
+// Kubernetes information of the event
+func (r *Record) String(field string) string {
+	s, _ := r.Fields[field].(string)
+	return s
+}
+
+// NodeName returns the name of the node the operator is running on: the one
+// of the Kubernetes node on the gadget pods, the hostname otherwise
+func NodeName() string {
+	if name := os.Getenv("NODE_NAME"); name != "" {
+		return name
+	}
+	hostname, _ := os.Hostname()
+	return hostname
+}
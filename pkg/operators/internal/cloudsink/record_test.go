@@ -0,0 +1,141 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudsink
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type testEvent struct {
+	eventtypes.Event
+	Comm  string `json:"comm"`
+	Inode uint64 `json:"inode"`
+}
+
+func TestNewRecord(t *testing.T) {
+	t.Parallel()
+
+	ev := &testEvent{
+		Event: eventtypes.Event{
+			CommonData: eventtypes.CommonData{
+				Namespace: "ns",
+				Pod:       "pod",
+			},
+			Timestamp: eventtypes.Time(1700000000123456789),
+			Type:      eventtypes.NORMAL,
+		},
+		Comm:  "cat",
+		Inode: 18446744073709551615,
+	}
+
+	r, err := NewRecord(ev, "trace_open", "run-1")
+	require.NoError(t, err)
+
+	require.Equal(t, "trace_open", r.Gadget)
+	require.Equal(t, "run-1", r.RunID)
+	require.Equal(t, SeverityInfo, r.Severity)
+	require.Equal(t, time.Unix(0, 1700000000123456789), r.Time)
+	require.Equal(t, "cat", r.String("comm"))
+	require.Equal(t, "ns", r.String("namespace"))
+	require.Equal(t, "pod", r.String("pod"))
+	require.Equal(t, "", r.String("inode"), "inode isn't a string")
+
+	// The message is the event with the gadget and the run ID, keeping the
+	// precision of the 64-bit numbers
+	var message map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(r.Message))
+	decoder.UseNumber()
+	require.NoError(t, decoder.Decode(&message))
+	require.Equal(t, "trace_open", message["gadget"])
+	require.Equal(t, "run-1", message["runID"])
+	require.Equal(t, json.Number("18446744073709551615"), message["inode"])
+	require.Equal(t, "cat", message["comm"])
+}
+
+func TestNewRecordSeverity(t *testing.T) {
+	t.Parallel()
+
+	table := []struct {
+		description string
+		event       eventtypes.Event
+		expected    Severity
+	}{
+		{
+			description: "normal",
+			event:       eventtypes.Event{Type: eventtypes.NORMAL},
+			expected:    SeverityInfo,
+		},
+		{
+			description: "err",
+			event:       eventtypes.Event{Type: eventtypes.ERR},
+			expected:    SeverityError,
+		},
+		{
+			description: "warn",
+			event:       eventtypes.Event{Type: eventtypes.WARN},
+			expected:    SeverityWarning,
+		},
+		{
+			description: "debug",
+			event:       eventtypes.Event{Type: eventtypes.DEBUG},
+			expected:    SeverityDebug,
+		},
+		{
+			description: "classified_alert",
+			event:       eventtypes.Event{Type: eventtypes.NORMAL, Severity: eventtypes.SeverityAlert},
+			expected:    SeverityAlert,
+		},
+		{
+			description: "classified_warn",
+			event:       eventtypes.Event{Type: eventtypes.NORMAL, Severity: eventtypes.SeverityWarn},
+			expected:    SeverityWarning,
+		},
+	}
+
+	for _, entry := range table {
+		entry := entry
+		t.Run(entry.description, func(t *testing.T) {
+			t.Parallel()
+
+			r, err := NewRecord(&testEvent{Event: entry.event}, "gadget", "run")
+			require.NoError(t, err)
+			require.Equal(t, entry.expected, r.Severity)
+		})
+	}
+}
+
+func TestNewRecordWithoutTimestamp(t *testing.T) {
+	t.Parallel()
+
+	before := time.Now()
+	r, err := NewRecord(map[string]any{"comm": "cat"}, "gadget", "run")
+	require.NoError(t, err)
+
+	require.False(t, r.Time.Before(before))
+	require.Equal(t, SeverityInfo, r.Severity)
+	require.JSONEq(t, `{"comm":"cat","gadget":"gadget","runID":"run"}`, string(r.Message))
+}
+
+func TestNodeName(t *testing.T) {
+	t.Setenv("NODE_NAME", "node-1")
+	require.Equal(t, "node-1", NodeName())
+}
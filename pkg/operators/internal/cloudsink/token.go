@@ -0,0 +1,89 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudsink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// refreshMargin is how long before their expiration the tokens are renewed
+const refreshMargin = 5 * time.Minute
+
+// Token is an access token and the time it expires at
+type Token struct {
+	Value  string
+	Expiry time.Time
+}
+
+// TokenSource caches a token until it's about to expire
+type TokenSource struct {
+	mu    sync.Mutex
+	fetch func(ctx context.Context) (*Token, error)
+	token *Token
+}
+
+func NewTokenSource(fetch func(ctx context.Context) (*Token, error)) *TokenSource {
+	return &TokenSource{fetch: fetch}
+}
+
+func (t *TokenSource) Get(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != nil && time.Now().Add(refreshMargin).Before(t.token.Expiry) {
+		return t.token.Value, nil
+	}
+
+	token, err := t.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	t.token = token
+	return token.Value, nil
+}
+
+// OAuth2Response is the answer of the token endpoints of the cloud providers
+type OAuth2Response struct {
+	AccessToken string `json:"access_token"`
+
+	// ExpiresIn is given in seconds, as a number or as a string depending on
+	// the endpoint
+	ExpiresIn json.RawMessage `json:"expires_in"`
+}
+
+func (r *OAuth2Response) Token() (*Token, error) {
+	if r.AccessToken == "" {
+		return nil, fmt.Errorf("no access token in response")
+	}
+
+	s := string(r.ExpiresIn)
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	seconds, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parsing expiration %q: %w", s, err)
+	}
+
+	return &Token{
+		Value:  r.AccessToken,
+		Expiry: time.Now().Add(time.Duration(seconds) * time.Second),
+	}, nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudsink
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenSource(t *testing.T) {
+	t.Parallel()
+
+	table := []struct {
+		description string
+		expiresIn   time.Duration
+		expected    []string
+	}{
+		{
+			description: "cached",
+			expiresIn:   time.Hour,
+			expected:    []string{"token-1", "token-1", "token-1"},
+		},
+		{
+			description: "about_to_expire",
+			expiresIn:   refreshMargin - time.Second,
+			expected:    []string{"token-1", "token-2", "token-3"},
+		},
+	}
+
+	for _, entry := range table {
+		entry := entry
+		t.Run(entry.description, func(t *testing.T) {
+			t.Parallel()
+
+			fetched := 0
+			tokens := NewTokenSource(func(ctx context.Context) (*Token, error) {
+				fetched++
+				return &Token{
+					Value:  fmt.Sprintf("token-%d", fetched),
+					Expiry: time.Now().Add(entry.expiresIn),
+				}, nil
+			})
+
+			var got []string
+			for range entry.expected {
+				token, err := tokens.Get(context.Background())
+				require.NoError(t, err)
+				got = append(got, token)
+			}
+			require.Equal(t, entry.expected, got)
+		})
+	}
+}
+
+func TestTokenSourceError(t *testing.T) {
+	t.Parallel()
+
+	fail := true
+	tokens := NewTokenSource(func(ctx context.Context) (*Token, error) {
+		if fail {
+			return nil, errors.New("unavailable")
+		}
+		return &Token{Value: "token", Expiry: time.Now().Add(time.Hour)}, nil
+	})
+
+	_, err := tokens.Get(context.Background())
+	require.Error(t, err)
+
+	// The errors aren't cached
+	fail = false
+	token, err := tokens.Get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token", token)
+}
+
+func TestOAuth2Response(t *testing.T) {
+	t.Parallel()
+
+	table := []struct {
+		description string
+		response    string
+		expectedErr bool
+	}{
+		{
+			description: "number",
+			response:    `{"access_token":"token","expires_in":3600}`,
+		},
+		{
+			description: "string",
+			response:    `{"access_token":"token","expires_in":"3600"}`,
+		},
+		{
+			description: "no_token",
+			response:    `{"expires_in":3600}`,
+			expectedErr: true,
+		},
+		{
+			description: "invalid_expiration",
+			response:    `{"access_token":"token","expires_in":"soon"}`,
+			expectedErr: true,
+		},
+	}
+
+	for _, entry := range table {
+		entry := entry
+		t.Run(entry.description, func(t *testing.T) {
+			t.Parallel()
+
+			var resp OAuth2Response
+			require.NoError(t, json.Unmarshal([]byte(entry.response), &resp))
+
+			token, err := resp.Token()
+			if entry.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "token", token.Value)
+			require.WithinDuration(t, time.Now().Add(time.Hour), token.Expiry, time.Minute)
+		})
+	}
+}
+
+func TestDo(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte(`{"access_token":"token","expires_in":60}`))
+		case "/throttled":
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte("slow down"))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/ok", nil)
+	require.NoError(t, err)
+	var resp OAuth2Response
+	require.NoError(t, Do(req, &resp))
+	require.Equal(t, "token", resp.AccessToken)
+
+	req, err = http.NewRequest(http.MethodGet, server.URL+"/throttled", nil)
+	require.NoError(t, err)
+	err = Do(req, nil)
+	var httpErr *HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	require.Equal(t, http.StatusTooManyRequests, httpErr.StatusCode)
+	require.Equal(t, "slow down", httpErr.Body)
+}
@@ -0,0 +1,269 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kataguest runs the trace gadgets inside the guest of the Kata
+// Containers sandboxes of the traced containers too. On the host, the gadgets
+// only see the hypervisor of these containers. ig must be installed in the
+// guest image and the debug console of the agent enabled, see pkg/container-utils/kata.
+package kataguest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/kata"
+	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+)
+
+const ParamKataGuest = "kata-guest"
+
+// guestCommand is the ig binary of the guest image
+const guestCommand = "ig"
+
+// ParamDescs are the parameters of the tracing inside the guests, shared by
+// the operators managing the containers
+func ParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key: ParamKataGuest,
+			Description: "Also run trace gadgets inside the guest of the Kata Containers sandboxes of the containers, " +
+				"through the debug console of the Kata agent, to see their processes instead of only their hypervisor",
+			DefaultValue: "false",
+			TypeHint:     params.TypeBool,
+		},
+	}
+}
+
+// Enabled tells whether operatorParams enable the tracing inside the guests
+func Enabled(operatorParams *params.Params) bool {
+	return operatorParams.Get(ParamKataGuest).AsBool()
+}
+
+type execFunc func(sandboxID string, command string) (io.ReadCloser, error)
+
+// The fields of the events which only make sense on the host, the events of
+// the guest would be enriched with the containers of the host having the same
+// namespaces
+var hostFields = []string{"mountnsid", "netnsid"}
+
+type sandbox struct {
+	containers map[string]struct{}
+	output     io.Closer
+}
+
+// Guest runs the gadget inside the guests of the Kata sandboxes of the
+// containers given to Add, as long as one of them is traced, and emits its
+// events as the events of their pod
+type Guest struct {
+	command string
+	logger  logger.Logger
+	emit    func(init func(ev any))
+	exec    execFunc
+
+	mu        sync.Mutex
+	sandboxes map[string]*sandbox
+	stopped   bool
+
+	// readers are the goroutines reading the output of the guests
+	readers sync.WaitGroup
+}
+
+// New returns the tracing inside the guests configured by operatorParams, nil if it's
+// disabled, if the gadget isn't a trace one or if emit is nil
+func New(operatorParams *params.Params, gadgetCtx operators.GadgetContext, emit func(init func(ev any))) *Guest {
+	desc := gadgetCtx.GadgetDesc()
+	if !Enabled(operatorParams) || emit == nil || desc.Type() != gadgets.TypeTrace {
+		return nil
+	}
+
+	command := []string{guestCommand, desc.Category(), desc.Name(), "-o", "json"}
+	if ctx, ok := gadgetCtx.(interface{ GadgetParams() *params.Params }); ok && ctx.GadgetParams() != nil {
+		for _, p := range *ctx.GadgetParams() {
+			if value := p.String(); value != p.DefaultValue {
+				command = append(command, shellQuote(fmt.Sprintf("--%s=%s", p.Key, value)))
+			}
+		}
+	}
+
+	return &Guest{
+		command:   strings.Join(command, " "),
+		logger:    gadgetCtx.Logger(),
+		emit:      emit,
+		exec:      kata.Exec,
+		sandboxes: make(map[string]*sandbox),
+	}
+}
+
+// shellQuote quotes s for the shell of the debug console
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func sandboxID(container *containercollection.Container) string {
+	if id := kata.SandboxID(container.RuntimeAnnotations); id != "" {
+		return id
+	}
+	if container.OciConfig != nil {
+		return kata.SandboxID(container.OciConfig.Annotations)
+	}
+	return ""
+}
+
+// Add starts the gadget inside the guest of the container if it runs in a Kata
+// sandbox and it isn't running there yet
+func (g *Guest) Add(container *containercollection.Container) {
+	if container.Sandbox != runtimeclient.SandboxKata {
+		return
+	}
+	id := sandboxID(container)
+	if id == "" {
+		g.logger.Warnf("tracing inside the guest of container %q: sandbox ID not found", container.Name)
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.stopped {
+		return
+	}
+	if s, ok := g.sandboxes[id]; ok {
+		s.containers[container.ID] = struct{}{}
+		return
+	}
+
+	output, err := g.exec(id, g.command)
+	if err != nil {
+		g.logger.Warnf("tracing inside the guest of pod %q: %s", container.Podname, err)
+		return
+	}
+	g.logger.Debugf("tracing inside the guest of pod %q: %s", container.Podname, g.command)
+	g.sandboxes[id] = &sandbox{
+		containers: map[string]struct{}{container.ID: {}},
+		output:     output,
+	}
+
+	g.readers.Add(1)
+	go func() {
+		defer g.readers.Done()
+		g.read(output, container.Podname, container.Namespace)
+	}()
+}
+
+// Remove stops the gadget inside the guest of the container once the other
+// traced containers of its sandbox are removed too
+func (g *Guest) Remove(container *containercollection.Container) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for id, s := range g.sandboxes {
+		if _, ok := s.containers[container.ID]; !ok {
+			continue
+		}
+		delete(s.containers, container.ID)
+		if len(s.containers) == 0 {
+			s.output.Close()
+			delete(g.sandboxes, id)
+		}
+		return
+	}
+}
+
+// Update makes the traced containers the given ones
+func (g *Guest) Update(containers []*containercollection.Container) {
+	matching := make(map[string]struct{}, len(containers))
+	for _, container := range containers {
+		matching[container.ID] = struct{}{}
+	}
+
+	g.mu.Lock()
+	var removed []*containercollection.Container
+	for _, s := range g.sandboxes {
+		for id := range s.containers {
+			if _, ok := matching[id]; !ok {
+				removed = append(removed, &containercollection.Container{ID: id})
+			}
+		}
+	}
+	g.mu.Unlock()
+
+	for _, container := range removed {
+		g.Remove(container)
+	}
+	for _, container := range containers {
+		g.Add(container)
+	}
+}
+
+// Stop stops the gadget in all the guests and waits for their last events
+func (g *Guest) Stop() {
+	g.mu.Lock()
+	g.stopped = true
+	for id, s := range g.sandboxes {
+		s.output.Close()
+		delete(g.sandboxes, id)
+	}
+	g.mu.Unlock()
+
+	g.readers.Wait()
+}
+
+// read emits the events written by ig in the guest, one JSON object per line.
+// The other lines, like the echo of the command, are ignored.
+func (g *Guest) read(output io.Reader, pod, namespace string) {
+	scanner := bufio.NewScanner(output)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "{") {
+			if line != "" && !strings.Contains(line, g.command) {
+				g.logger.Debugf("inside the guest of pod %q: %s", pod, line)
+			}
+			continue
+		}
+
+		fields := map[string]json.RawMessage{}
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			g.logger.Debugf("decoding event from the guest of pod %q: %s", pod, err)
+			continue
+		}
+		for _, field := range hostFields {
+			delete(fields, field)
+		}
+		data, err := json.Marshal(fields)
+		if err != nil {
+			continue
+		}
+
+		g.emit(func(ev any) {
+			if err := json.Unmarshal(data, ev); err != nil {
+				g.logger.Debugf("decoding event from the guest of pod %q: %s", pod, err)
+			}
+			if setter, ok := ev.(operators.ContainerInfoSetters); ok {
+				setter.SetContainerInfo(pod, namespace, "")
+			}
+			if setter, ok := ev.(operators.SandboxSetter); ok {
+				setter.SetSandbox(runtimeclient.SandboxKata)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kataguest
+
+import (
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	ocispec "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/require"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	runtimeclient "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/runtime-client"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const testCommand = "ig trace exec -o json"
+
+type testEvent struct {
+	eventtypes.Event
+	eventtypes.WithMountNsID
+	eventtypes.WithNetNsID
+
+	Comm string `json:"comm"`
+}
+
+type testGuest struct {
+	*Guest
+	events chan *testEvent
+
+	mu      sync.Mutex
+	outputs map[string]*io.PipeWriter
+	closed  map[string]bool
+}
+
+func newTestGuest() *testGuest {
+	g := &testGuest{
+		events:  make(chan *testEvent, 10),
+		outputs: make(map[string]*io.PipeWriter),
+		closed:  make(map[string]bool),
+	}
+	g.Guest = &Guest{
+		command: testCommand,
+		logger:  logger.DefaultLogger(),
+		emit: func(init func(ev any)) {
+			ev := &testEvent{}
+			init(ev)
+			g.events <- ev
+		},
+		exec:      g.exec,
+		sandboxes: make(map[string]*sandbox),
+	}
+	return g
+}
+
+type testOutput struct {
+	*io.PipeReader
+	g  *testGuest
+	id string
+}
+
+func (o *testOutput) Close() error {
+	o.g.mu.Lock()
+	o.g.closed[o.id] = true
+	o.g.mu.Unlock()
+	return o.PipeReader.Close()
+}
+
+func (g *testGuest) exec(sandboxID, command string) (io.ReadCloser, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r, w := io.Pipe()
+	g.outputs[sandboxID] = w
+	delete(g.closed, sandboxID)
+	return &testOutput{PipeReader: r, g: g, id: sandboxID}, nil
+}
+
+func (g *testGuest) write(t *testing.T, sandboxID, output string) {
+	t.Helper()
+	g.mu.Lock()
+	w, ok := g.outputs[sandboxID]
+	g.mu.Unlock()
+	require.True(t, ok, "gadget not started in sandbox %q", sandboxID)
+	_, err := w.Write([]byte(output))
+	require.NoError(t, err)
+}
+
+func (g *testGuest) started(sandboxID string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	_, ok := g.outputs[sandboxID]
+	return ok && !g.closed[sandboxID]
+}
+
+func (g *testGuest) nextEvent(t *testing.T) *testEvent {
+	t.Helper()
+	select {
+	case ev := <-g.events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event emitted")
+		return nil
+	}
+}
+
+func kataContainer(id, sandboxID string) *containercollection.Container {
+	return &containercollection.Container{
+		ID:                 id,
+		Podname:            "web",
+		Namespace:          "default",
+		Sandbox:            runtimeclient.SandboxKata,
+		RuntimeAnnotations: map[string]string{"io.kubernetes.cri.sandbox-id": sandboxID},
+	}
+}
+
+func TestGuestEvents(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGuest()
+	defer g.Stop()
+
+	g.Add(kataContainer("c1", "sandbox1"))
+	require.True(t, g.started("sandbox1"))
+
+	// The echo of the command and the other lines are ignored
+	g.write(t, "sandbox1", testCommand+"\r\n")
+	g.write(t, "sandbox1", "WARN[0000] no container runtime\r\n")
+	g.write(t, "sandbox1", `{"mountnsid":4026531840,"netnsid":4026531992,"comm":"cat","pid":42}`+"\r\n")
+
+	ev := g.nextEvent(t)
+	require.Equal(t, "cat", ev.Comm)
+	require.Equal(t, "web", ev.Pod)
+	require.Equal(t, "default", ev.Namespace)
+	require.Equal(t, runtimeclient.SandboxKata, ev.Sandbox)
+	// The namespaces of the guest aren't the ones of the host
+	require.Zero(t, ev.MountNsID)
+	require.Zero(t, ev.NetNsID)
+	require.Empty(t, g.events)
+}
+
+func TestGuestSandboxes(t *testing.T) {
+	t.Parallel()
+
+	g := newTestGuest()
+
+	// Not in a Kata sandbox
+	g.Add(&containercollection.Container{ID: "runc", Podname: "web", Namespace: "default"})
+	require.Empty(t, g.outputs)
+
+	// The gadget runs once per sandbox
+	g.Add(kataContainer("c1", "sandbox1"))
+	g.Add(kataContainer("c2", "sandbox1"))
+	g.Add(kataContainer("c3", "sandbox2"))
+	require.Len(t, g.outputs, 2)
+
+	// Until the last of its containers is removed
+	g.Remove(kataContainer("c1", "sandbox1"))
+	require.True(t, g.started("sandbox1"))
+	g.Remove(kataContainer("c2", "sandbox1"))
+	require.False(t, g.started("sandbox1"))
+
+	// The sandbox ID is taken from the OCI config too
+	container := kataContainer("c4", "")
+	container.RuntimeAnnotations = nil
+	container.OciConfig = &ocispec.Spec{Annotations: map[string]string{"io.kubernetes.cri-o.SandboxID": "sandbox3"}}
+	g.Update([]*containercollection.Container{kataContainer("c3", "sandbox2"), container})
+	require.True(t, g.started("sandbox2"))
+	require.True(t, g.started("sandbox3"))
+
+	g.Update([]*containercollection.Container{container})
+	require.False(t, g.started("sandbox2"))
+	require.True(t, g.started("sandbox3"))
+
+	g.Stop()
+	require.False(t, g.started("sandbox3"))
+
+	// Nothing is started once stopped
+	g.Add(kataContainer("c5", "sandbox4"))
+	require.False(t, g.started("sandbox4"))
+}
+
+func TestShellQuote(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, `'--filter=comm:cat'`, shellQuote("--filter=comm:cat"))
+	require.Equal(t, `'--name=it'\''s'`, shellQuote("--name=it's"))
+}
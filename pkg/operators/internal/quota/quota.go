@@ -0,0 +1,304 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package quota excludes temporarily the containers sending too many events,
+// so one misbehaving container doesn't make a capture of the whole cluster
+// unusable.
+package quota
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/cilium/ebpf"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	ParamQuota          = "container-quota"
+	ParamQuotaPeriod    = "container-quota-period"
+	ParamQuotaExclusion = "container-quota-exclusion"
+)
+
+// ParamDescs are the parameters of the quota, shared by the operators
+// managing the containers
+func ParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:          ParamQuota,
+			DefaultValue: "0",
+			Description:  "Maximum number of events per second of a container before excluding it, 0 to disable",
+			TypeHint:     params.TypeUint64,
+		},
+		{
+			Key:          ParamQuotaPeriod,
+			DefaultValue: "10s",
+			Description:  "How long a container has to exceed the quota before being excluded",
+			TypeHint:     params.TypeDuration,
+		},
+		{
+			Key:          ParamQuotaExclusion,
+			DefaultValue: "60s",
+			Description:  "How long a container exceeding the quota is excluded",
+			TypeHint:     params.TypeDuration,
+		},
+	}
+}
+
+// LookupFunc returns the container of a mount namespace, nil if it's gone
+type LookupFunc func(mntns uint64) *containercollection.Container
+
+// SelectsFunc tells whether the current container selector of the gadget
+// matches the container. The selector can be changed while the gadget runs.
+type SelectsFunc func(container *containercollection.Container) bool
+
+// EmitFunc emits an event of the type of the gadget, see
+// operators.EventEmitter
+type EmitFunc func(init func(ev any))
+
+// mountNsMap is the mount namespace map of the gadget, an *ebpf.Map
+type mountNsMap interface {
+	Put(key, value any) error
+	Delete(key any) error
+}
+
+// The events the exclusion notices are written to
+type messageSetter interface {
+	SetMessage(typ eventtypes.EventType, msg string)
+}
+
+type containerInfoSetter interface {
+	SetContainerInfo(pod, namespace, container string)
+}
+
+type counter struct {
+	// second is the second of the events counted in count
+	second int64
+	count  uint64
+	// since is the first second of the consecutive seconds over the quota,
+	// 0 if the last second wasn't
+	since int64
+}
+
+// Quota counts the events of each container and removes the containers
+// exceeding the quota during the period from the mount namespace map of the
+// gadget, until the end of the exclusion. The exclusions and the restores are
+// notified with events of the container, a warning and an info, when the
+// gadget supports it, or with logs otherwise.
+type Quota struct {
+	quota     uint64
+	period    int64
+	exclusion time.Duration
+
+	mountnsmap mountNsMap
+	lookup     LookupFunc
+	selects    SelectsFunc
+	logger     logger.Logger
+	emit       EmitFunc
+
+	mu       sync.Mutex
+	counters map[uint64]*counter
+	// pruned is the last second the counters of the containers without
+	// events during the period were removed
+	pruned   int64
+	excluded map[uint64]*time.Timer
+	stopped  bool
+
+	// notices are the notifications being emitted
+	notices sync.WaitGroup
+}
+
+// New returns the quota configured by params, nil if it's disabled. emit can
+// be nil if the gadget doesn't support it.
+func New(params *params.Params, mountnsmap *ebpf.Map, lookup LookupFunc, selects SelectsFunc, logger logger.Logger, emit EmitFunc) *Quota {
+	quota := params.Get(ParamQuota).AsUint64()
+	if quota == 0 || mountnsmap == nil {
+		return nil
+	}
+
+	period := int64(math.Ceil(params.Get(ParamQuotaPeriod).AsDuration().Seconds()))
+	if period < 1 {
+		period = 1
+	}
+
+	return &Quota{
+		quota:      quota,
+		period:     period,
+		exclusion:  params.Get(ParamQuotaExclusion).AsDuration(),
+		mountnsmap: mountnsmap,
+		lookup:     lookup,
+		selects:    selects,
+		logger:     logger,
+		emit:       emit,
+		counters:   make(map[uint64]*counter),
+		excluded:   make(map[uint64]*time.Timer),
+	}
+}
+
+// Count counts an event of the container of the given mount namespace
+func (q *Quota) Count(mntns uint64) {
+	if mntns == 0 {
+		return
+	}
+	q.count(mntns, time.Now().Unix())
+}
+
+func (q *Quota) count(mntns uint64, now int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// The events already in the buffers when the container was excluded
+	if _, ok := q.excluded[mntns]; ok || q.stopped {
+		return
+	}
+
+	q.prune(now)
+
+	c, ok := q.counters[mntns]
+	if !ok {
+		c = &counter{second: now}
+		q.counters[mntns] = c
+	}
+	if c.second != now {
+		if c.count <= q.quota || c.second != now-1 {
+			c.since = 0
+		}
+		c.second = now
+		c.count = 0
+	}
+
+	c.count++
+	if c.count <= q.quota {
+		return
+	}
+	if c.since == 0 {
+		c.since = now
+	}
+	if now-c.since+1 >= q.period {
+		q.exclude(mntns)
+	}
+}
+
+// prune removes, once per period, the counters of the containers without
+// events during the last period: they would start again from 0 anyway
+func (q *Quota) prune(now int64) {
+	if now-q.pruned < q.period {
+		return
+	}
+	q.pruned = now
+	for mntns, c := range q.counters {
+		if c.second < now-q.period {
+			delete(q.counters, mntns)
+		}
+	}
+}
+
+func (q *Quota) exclude(mntns uint64) {
+	delete(q.counters, mntns)
+
+	if err := q.mountnsmap.Delete(mntns); err != nil {
+		q.logger.Warnf("excluding container %s: %s", q.containerName(mntns), err)
+		return
+	}
+	q.notify(eventtypes.WARN, mntns, fmt.Sprintf("sent more than %d events/s during %ds: excluded for %s",
+		q.quota, q.period, q.exclusion))
+
+	q.excluded[mntns] = time.AfterFunc(q.exclusion, func() {
+		q.restore(mntns)
+	})
+}
+
+func (q *Quota) restore(mntns uint64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.stopped {
+		return
+	}
+	delete(q.excluded, mntns)
+
+	// The container could have been removed while excluded, or not be
+	// selected anymore after the filters of the gadget were changed
+	container := q.lookup(mntns)
+	if container == nil || !q.selects(container) {
+		return
+	}
+
+	if err := q.mountnsmap.Put(mntns, uint32(1)); err != nil {
+		q.logger.Warnf("restoring container %s: %s", q.containerName(mntns), err)
+		return
+	}
+	q.notify(eventtypes.INFO, mntns, "restored after its exclusion")
+}
+
+// notify tells that the container of the given mount namespace was excluded
+// or restored. It's called with mu held, while an event of the gadget is
+// enriched, so the event is emitted by another goroutine.
+func (q *Quota) notify(typ eventtypes.EventType, mntns uint64, msg string) {
+	msg = fmt.Sprintf("container %s %s", q.containerName(mntns), msg)
+	if q.emit == nil {
+		if typ == eventtypes.WARN {
+			q.logger.Warnf("%s", msg)
+		} else {
+			q.logger.Infof("%s", msg)
+		}
+		return
+	}
+
+	container := q.lookup(mntns)
+
+	q.notices.Add(1)
+	go func() {
+		defer q.notices.Done()
+		q.emit(func(ev any) {
+			if setter, ok := ev.(messageSetter); ok {
+				setter.SetMessage(typ, msg)
+			}
+			if setter, ok := ev.(containerInfoSetter); ok && container != nil {
+				setter.SetContainerInfo(container.Podname, container.Namespace, container.Name)
+			}
+		})
+	}()
+}
+
+func (q *Quota) containerName(mntns uint64) string {
+	container := q.lookup(mntns)
+	switch {
+	case container == nil:
+		return fmt.Sprintf("with mount namespace %d", mntns)
+	case container.Podname != "":
+		return fmt.Sprintf("%q of pod %s/%s", container.Name, container.Namespace, container.Podname)
+	default:
+		return fmt.Sprintf("%q", container.Name)
+	}
+}
+
+// Stop cancels the pending restores and waits for the notifications being
+// emitted, to call before removing the mount namespace map
+func (q *Quota) Stop() {
+	q.mu.Lock()
+	q.stopped = true
+	for _, timer := range q.excluded {
+		timer.Stop()
+	}
+	q.mu.Unlock()
+
+	q.notices.Wait()
+}
@@ -0,0 +1,338 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const testMntns = 4026531840
+
+type fakeMountNsMap struct {
+	mu      sync.Mutex
+	entries map[uint64]struct{}
+}
+
+func (m *fakeMountNsMap) Put(key, value any) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries[key.(uint64)] = struct{}{}
+	return nil
+}
+
+func (m *fakeMountNsMap) Delete(key any) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key.(uint64))
+	return nil
+}
+
+func (m *fakeMountNsMap) has(mntns uint64) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.entries[mntns]
+	return ok
+}
+
+type testQuota struct {
+	*Quota
+	mountnsmap *fakeMountNsMap
+	events     chan *eventtypes.Event
+
+	containersLock sync.Mutex
+	containers     map[uint64]*containercollection.Container
+	unselected     map[*containercollection.Container]struct{}
+}
+
+func (q *testQuota) lookup(mntns uint64) *containercollection.Container {
+	q.containersLock.Lock()
+	defer q.containersLock.Unlock()
+	return q.containers[mntns]
+}
+
+func (q *testQuota) selects(container *containercollection.Container) bool {
+	q.containersLock.Lock()
+	defer q.containersLock.Unlock()
+	_, ok := q.unselected[container]
+	return !ok
+}
+
+// unselectContainer changes the selector of the gadget to not match the
+// container anymore
+func (q *testQuota) unselectContainer(mntns uint64) {
+	q.containersLock.Lock()
+	defer q.containersLock.Unlock()
+	q.unselected[q.containers[mntns]] = struct{}{}
+}
+
+func (q *testQuota) removeContainer(mntns uint64) {
+	q.containersLock.Lock()
+	defer q.containersLock.Unlock()
+	delete(q.containers, mntns)
+}
+
+func newTestQuota(quota uint64, period int64, exclusion time.Duration) *testQuota {
+	q := &testQuota{
+		mountnsmap: &fakeMountNsMap{entries: map[uint64]struct{}{testMntns: {}}},
+		events:     make(chan *eventtypes.Event, 10),
+		containers: map[uint64]*containercollection.Container{
+			testMntns: {
+				Name:      "nginx",
+				Podname:   "web",
+				Namespace: "default",
+			},
+		},
+		unselected: make(map[*containercollection.Container]struct{}),
+	}
+	q.Quota = &Quota{
+		quota:      quota,
+		period:     period,
+		exclusion:  exclusion,
+		mountnsmap: q.mountnsmap,
+		lookup:     q.lookup,
+		selects:    q.selects,
+		logger:     logger.DefaultLogger(),
+		emit: func(init func(ev any)) {
+			ev := &eventtypes.Event{}
+			init(ev)
+			q.events <- ev
+		},
+		counters: make(map[uint64]*counter),
+		excluded: make(map[uint64]*time.Timer),
+	}
+	return q
+}
+
+// countEvents counts n events during the given second
+func (q *testQuota) countEvents(n int, now int64) {
+	for i := 0; i < n; i++ {
+		q.count(testMntns, now)
+	}
+}
+
+func (q *testQuota) nextEvent(t *testing.T) *eventtypes.Event {
+	t.Helper()
+	select {
+	case ev := <-q.events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event emitted")
+		return nil
+	}
+}
+
+func TestQuotaThreshold(t *testing.T) {
+	t.Parallel()
+
+	q := newTestQuota(10, 1, time.Hour)
+	defer q.Stop()
+
+	// Up to the quota
+	q.countEvents(10, 100)
+	require.True(t, q.mountnsmap.has(testMntns))
+
+	// The counter starts again each second
+	q.countEvents(10, 101)
+	require.True(t, q.mountnsmap.has(testMntns))
+
+	// Above the quota
+	q.countEvents(11, 102)
+	require.False(t, q.mountnsmap.has(testMntns))
+
+	ev := q.nextEvent(t)
+	require.Equal(t, eventtypes.WARN, ev.Type)
+	require.Equal(t, `container "nginx" of pod default/web sent more than 10 events/s during 1s: excluded for 1h0m0s`, ev.Message)
+	require.Equal(t, "default", ev.Namespace)
+	require.Equal(t, "web", ev.Pod)
+	require.Equal(t, "nginx", ev.Container)
+}
+
+func TestQuotaWindow(t *testing.T) {
+	t.Parallel()
+
+	type testCase struct {
+		// events per second, starting at the second 100
+		events   []int
+		excluded bool
+	}
+
+	testCases := map[string]testCase{
+		"whole_period": {
+			events:   []int{11, 11, 11},
+			excluded: true,
+		},
+		"shorter_than_period": {
+			events:   []int{11, 11},
+			excluded: false,
+		},
+		"under_the_quota_during_the_period": {
+			events:   []int{11, 11, 10, 11, 11},
+			excluded: false,
+		},
+		"restarted_after_a_second_under_the_quota": {
+			events:   []int{11, 10, 11, 11, 11},
+			excluded: true,
+		},
+		"second_without_events": {
+			events:   []int{11, 11, 0, 11, 11},
+			excluded: false,
+		},
+	}
+
+	for name, test := range testCases {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			q := newTestQuota(10, 3, time.Hour)
+			defer q.Stop()
+
+			for i, n := range test.events {
+				q.countEvents(n, int64(100+i))
+			}
+			require.Equal(t, test.excluded, !q.mountnsmap.has(testMntns))
+		})
+	}
+}
+
+func TestQuotaRestore(t *testing.T) {
+	t.Parallel()
+
+	q := newTestQuota(10, 1, 10*time.Millisecond)
+	defer q.Stop()
+
+	q.countEvents(11, 100)
+	require.Equal(t, eventtypes.WARN, q.nextEvent(t).Type)
+
+	ev := q.nextEvent(t)
+	require.Equal(t, eventtypes.INFO, ev.Type)
+	require.Equal(t, `container "nginx" of pod default/web restored after its exclusion`, ev.Message)
+	require.True(t, q.mountnsmap.has(testMntns))
+
+	// Counted again from scratch once restored
+	q.countEvents(10, 101)
+	require.True(t, q.mountnsmap.has(testMntns))
+	q.countEvents(1, 101)
+	require.False(t, q.mountnsmap.has(testMntns))
+}
+
+func TestQuotaExcluded(t *testing.T) {
+	t.Parallel()
+
+	q := newTestQuota(10, 1, time.Hour)
+	defer q.Stop()
+
+	q.countEvents(11, 100)
+	q.nextEvent(t)
+
+	// The events still in the buffers of the gadget aren't counted again
+	q.countEvents(100, 100)
+	q.countEvents(100, 101)
+	select {
+	case ev := <-q.events:
+		t.Fatalf("unexpected event %+v", ev)
+	default:
+	}
+}
+
+func TestQuotaRestoreRemovedContainer(t *testing.T) {
+	t.Parallel()
+
+	q := newTestQuota(10, 1, 10*time.Millisecond)
+	defer q.Stop()
+
+	// The container is removed from the map while excluded
+	q.removeContainer(testMntns)
+	q.countEvents(11, 100)
+	ev := q.nextEvent(t)
+	require.Equal(t, "container with mount namespace 4026531840 sent more than 10 events/s during 1s: excluded for 10ms", ev.Message)
+	require.Empty(t, ev.Container)
+
+	require.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return len(q.excluded) == 0
+	}, 5*time.Second, time.Millisecond)
+	require.False(t, q.mountnsmap.has(testMntns))
+}
+
+func TestQuotaRestoreUnselectedContainer(t *testing.T) {
+	t.Parallel()
+
+	q := newTestQuota(10, 1, 10*time.Millisecond)
+	defer q.Stop()
+
+	// The filters of the gadget are changed while the container is excluded
+	q.countEvents(11, 100)
+	q.nextEvent(t)
+	q.unselectContainer(testMntns)
+
+	require.Eventually(t, func() bool {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return len(q.excluded) == 0
+	}, 5*time.Second, time.Millisecond)
+	require.False(t, q.mountnsmap.has(testMntns))
+	require.Empty(t, q.events)
+}
+
+func TestQuotaPrune(t *testing.T) {
+	t.Parallel()
+
+	q := newTestQuota(10, 3, time.Hour)
+	defer q.Stop()
+
+	const otherMntns = testMntns + 1
+
+	q.countEvents(5, 100)
+	q.count(otherMntns, 102)
+	require.Len(t, q.counters, 2)
+
+	// Still counted during the period
+	q.count(otherMntns, 103)
+	require.Len(t, q.counters, 2)
+
+	// No events during the period
+	q.count(otherMntns, 106)
+	require.Len(t, q.counters, 1)
+	require.Contains(t, q.counters, uint64(otherMntns))
+}
+
+func TestQuotaStop(t *testing.T) {
+	t.Parallel()
+
+	q := newTestQuota(10, 1, 10*time.Millisecond)
+
+	q.countEvents(11, 100)
+	q.nextEvent(t)
+	q.Stop()
+
+	// Not restored once stopped
+	time.Sleep(50 * time.Millisecond)
+	require.False(t, q.mountnsmap.has(testMntns))
+
+	// Not counted anymore
+	q.mountnsmap.Put(uint64(testMntns), uint32(1))
+	q.countEvents(11, 101)
+	require.True(t, q.mountnsmap.has(testMntns))
+}
@@ -0,0 +1,67 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package routing provides the global param of the sink operators selecting
+// the events they forward by their severity, e.g. to only send the alerts to
+// Alertmanager while keeping all the events in the journal.
+package routing
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// SeverityParamDesc returns the description of the param with the minimum
+// severity of the events forwarded by a sink
+func SeverityParamDesc(key string, defaultSeverity eventtypes.Severity) *params.ParamDesc {
+	return &params.ParamDesc{
+		Key: key,
+		Description: fmt.Sprintf("Minimum severity (%s, %s or %s) of the events to send. Defaults to %s",
+			eventtypes.SeverityInfo, eventtypes.SeverityWarn, eventtypes.SeverityAlert, defaultSeverity),
+		Validator: func(value string) error {
+			if value == "" {
+				return nil
+			}
+			_, err := eventtypes.ParseSeverity(value)
+			return err
+		},
+	}
+}
+
+// MinSeverity returns the severity of the param or, if it isn't set, the one
+// of the environment variable, which allows to configure the operator on the
+// deployed gadget pods, where global params can't be set
+func MinSeverity(p *params.Param, env string, defaultSeverity eventtypes.Severity) (eventtypes.Severity, error) {
+	value := p.AsString()
+	if envValue := os.Getenv(env); envValue != "" && value == "" {
+		value = envValue
+	}
+	if value == "" {
+		return defaultSeverity, nil
+	}
+	severity, err := eventtypes.ParseSeverity(value)
+	if err != nil {
+		return "", fmt.Errorf("parsing %s: %w", p.Key, err)
+	}
+	return severity, nil
+}
+
+// Accepts tells whether an event has at least the minimum severity of a sink.
+// The events that weren't classified have the info severity.
+func Accepts(ev any, min eventtypes.Severity) bool {
+	return eventtypes.EventSeverity(ev).AtLeast(min)
+}
@@ -0,0 +1,270 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package join provides an operator that enriches the events of a gadget with
+// the state observed by other gadgets running in the same session, e.g. the
+// name the remote address of a connection was resolved from, as seen by trace
+// dns, or the command line of the process, as seen by trace exec. The other
+// gadgets run on the same node and containers as the joined one, so the
+// correlation happens where the events are generated. It's currently used by
+// the following gadgets:
+// - trace network
+// - trace tcp
+package join
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	gadgetcontext "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-context"
+	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime"
+)
+
+const (
+	OperatorName = "Join"
+
+	ParamJoin   = "join"
+	ParamMaxAge = "join-max-age"
+)
+
+// containerOperators are the operators run with the joined gadgets: the ones
+// selecting and enriching the containers. The other ones, like the sinks,
+// only handle the events of the main gadget.
+var containerOperators = []string{"KubeManager", "LocalManager"}
+
+// Joinable is implemented by events that can be enriched with the state of
+// other gadgets
+type Joinable interface {
+	SetJoined(key, value string)
+}
+
+type RemoteIPsGetter interface {
+	GetRemoteIPs() []string
+}
+
+type PidGetter interface {
+	GetPid() uint32
+}
+
+// runtimeContext gives access to what is needed to run other gadgets in the
+// same session, it's implemented by gadgetcontext.GadgetContext
+type runtimeContext interface {
+	operators.GadgetContext
+	Runtime() runtime.Runtime
+	RuntimeParams() *params.Params
+	Operators() operators.Operators
+	OperatorsParamCollection() params.Collection
+}
+
+type Join struct{}
+
+func (j *Join) Name() string {
+	return OperatorName
+}
+
+func (j *Join) Description() string {
+	return "Join enriches the events with the state observed by other gadgets"
+}
+
+func (j *Join) GlobalParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (j *Join) ParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:         ParamJoin,
+			Description: fmt.Sprintf("Gadgets to join the events with (%s)", strings.Join(sourceNames(), ", ")),
+			Validator:   params.ValidateSlice(validateSource),
+		},
+		{
+			Key:          ParamMaxAge,
+			Description:  "How long the state observed by the joined gadgets is kept",
+			DefaultValue: "10m",
+			TypeHint:     params.TypeDuration,
+		},
+	}
+}
+
+func (j *Join) Dependencies() []string {
+	return nil
+}
+
+func (j *Join) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	prototype := gadget.EventPrototype()
+	if _, ok := prototype.(Joinable); !ok {
+		return false
+	}
+	for _, s := range sources {
+		if s.canJoin(prototype) {
+			return true
+		}
+	}
+	return false
+}
+
+func (j *Join) Init(params *params.Params) error {
+	return nil
+}
+
+func (j *Join) Close() error {
+	return nil
+}
+
+func (j *Join) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	instance := &JoinInstance{
+		gadgetCtx: gadgetCtx,
+	}
+
+	names := params.Get(ParamJoin).AsStringSlice()
+	if len(names) == 0 {
+		return instance, nil
+	}
+
+	rCtx, ok := gadgetCtx.(runtimeContext)
+	if !ok {
+		return nil, errors.New("gadgets can't be joined in this context")
+	}
+	instance.rCtx = rCtx
+
+	maxAge := params.Get(ParamMaxAge).AsDuration()
+	prototype := gadgetCtx.GadgetDesc().EventPrototype()
+	for _, name := range names {
+		s := getSource(name)
+		if !s.canJoin(prototype) {
+			return nil, fmt.Errorf("the events of %s %s can't be joined with %q",
+				gadgetCtx.GadgetDesc().Category(), gadgetCtx.GadgetDesc().Name(), name)
+		}
+		instance.sources = append(instance.sources, s)
+		instance.states = append(instance.states, s.newState(maxAge))
+	}
+	return instance, nil
+}
+
+type JoinInstance struct {
+	gadgetCtx operators.GadgetContext
+	rCtx      runtimeContext
+	sources   []*source
+	states    []state
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func (i *JoinInstance) Name() string {
+	return "JoinInstance"
+}
+
+func (i *JoinInstance) PreGadgetRun() error {
+	if len(i.sources) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(i.rCtx.Context())
+	i.cancel = cancel
+	for idx, s := range i.sources {
+		gadgetCtx, err := i.newGadgetContext(ctx, s, i.states[idx])
+		if err != nil {
+			cancel()
+			i.wg.Wait()
+			return fmt.Errorf("preparing %q: %w", s.name, err)
+		}
+
+		i.wg.Add(1)
+		go func(s *source) {
+			defer i.wg.Done()
+			if _, err := i.rCtx.Runtime().RunGadget(gadgetCtx); err != nil && ctx.Err() == nil {
+				i.gadgetCtx.Logger().Warnf("running %s %s to join its events: %v", s.category, s.gadget, err)
+			}
+		}(s)
+	}
+	return nil
+}
+
+// newGadgetContext prepares the context running the gadget of the source with
+// the same container selection as the joined gadget, and passing its events
+// to the state
+func (i *JoinInstance) newGadgetContext(ctx context.Context, s *source, st state) (*gadgetcontext.GadgetContext, error) {
+	desc := gadgetregistry.Get(s.category, s.gadget)
+	if desc == nil {
+		return nil, fmt.Errorf("gadget %s %s not available", s.category, s.gadget)
+	}
+
+	parser := desc.Parser()
+	if parser == nil {
+		return nil, fmt.Errorf("gadget %s %s has no parser", s.category, s.gadget)
+	}
+	parser.SetEventCallback(st.callback())
+
+	gadgetParamDescs := desc.ParamDescs()
+	gadgetParamDescs.Add(gadgets.GadgetParams(desc, parser)...)
+
+	var ops operators.Operators
+	for _, op := range i.rCtx.Operators() {
+		for _, name := range containerOperators {
+			if op.Name() == name && op.CanOperateOn(desc) {
+				ops = append(ops, op)
+			}
+		}
+	}
+
+	// Select the same containers as the joined gadget
+	values := make(map[string]string)
+	i.rCtx.OperatorsParamCollection().CopyToMap(values, "")
+	operatorsParams := ops.ParamCollection()
+	if err := operatorsParams.CopyFromMap(values, ""); err != nil {
+		return nil, fmt.Errorf("setting operator params: %w", err)
+	}
+
+	gadgetCtx := gadgetcontext.New(
+		ctx,
+		i.rCtx.ID()+"-join-"+s.name,
+		i.rCtx.Runtime(),
+		i.rCtx.RuntimeParams(),
+		desc,
+		gadgetParamDescs.ToParams(),
+		operatorsParams,
+		parser,
+		i.rCtx.Logger(),
+		0,
+	)
+	gadgetCtx.SetOperators(ops)
+	return gadgetCtx, nil
+}
+
+func (i *JoinInstance) PostGadgetRun() error {
+	if i.cancel != nil {
+		i.cancel()
+	}
+	i.wg.Wait()
+	return nil
+}
+
+func (i *JoinInstance) EnrichEvent(ev any) error {
+	for _, st := range i.states {
+		st.join(ev)
+	}
+	return nil
+}
+
+func init() {
+	operators.Register(&Join{})
+}
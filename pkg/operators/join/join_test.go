@@ -0,0 +1,173 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package join
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
+	dnstypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+	exectypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	networktypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/network/types"
+	tcptypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/tcp/types"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+func TestStore(t *testing.T) {
+	now := time.Unix(1000, 0)
+	s := newStore[int, string](time.Minute, 4)
+	s.now = func() time.Time { return now }
+
+	s.put(1, "a")
+	s.put(2, "b")
+	if v, ok := s.get(1); !ok || v != "a" {
+		t.Fatalf("get(1) = %q, %v", v, ok)
+	}
+
+	// Expired entries are removed
+	now = now.Add(2 * time.Minute)
+	if _, ok := s.get(2); ok {
+		t.Fatalf("entry 2 should have expired")
+	}
+
+	// The store doesn't grow beyond its maximum
+	for i := 10; i < 20; i++ {
+		s.put(i, "x")
+	}
+	if len(s.entries) > 4 {
+		t.Fatalf("store has %d entries, expecting at most 4", len(s.entries))
+	}
+	if v, ok := s.get(19); !ok || v != "x" {
+		t.Fatalf("get(19) = %q, %v", v, ok)
+	}
+}
+
+func TestDNSJoin(t *testing.T) {
+	s := getSource("dns").newState(time.Minute).(*dnsState)
+	add := s.callback().(func(*dnstypes.Event))
+
+	answer := &dnstypes.Event{
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: 11},
+		WithNetNsID:   eventtypes.WithNetNsID{NetNsID: 22},
+		Qr:            dnstypes.DNSPktTypeResponse,
+		Rcode:         "NoError",
+		DNSName:       "example.com.",
+		Addresses:     []string{"93.184.216.34", "2606:2800:220:1:248:1893:25c8:1946"},
+	}
+	add(answer)
+	add(&dnstypes.Event{
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: 11},
+		Qr:            dnstypes.DNSPktTypeResponse,
+		Rcode:         "NXDomain",
+		DNSName:       "missing.example.com.",
+		Addresses:     []string{"10.0.0.1"},
+	})
+
+	tcp := &tcptypes.Event{
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: 11},
+		Daddr:         "93.184.216.34",
+	}
+	s.join(tcp)
+	if tcp.Joined["dns.name"] != "example.com" {
+		t.Fatalf("tcp event not joined: %v", tcp.Joined)
+	}
+
+	network := &networktypes.Event{
+		WithNetNsID: eventtypes.WithNetNsID{NetNsID: 22},
+		RemoteAddr:  "2606:2800:220:1:248:1893:25c8:1946",
+	}
+	s.join(network)
+	if network.Joined["dns.name"] != "example.com" {
+		t.Fatalf("network event not joined: %v", network.Joined)
+	}
+
+	// Other containers and failed resolutions don't match
+	for _, ev := range []*tcptypes.Event{
+		{WithMountNsID: eventtypes.WithMountNsID{MountNsID: 12}, Daddr: "93.184.216.34"},
+		{WithMountNsID: eventtypes.WithMountNsID{MountNsID: 11}, Daddr: "10.0.0.1"},
+	} {
+		s.join(ev)
+		if ev.Joined != nil {
+			t.Fatalf("unexpected join: %v", ev.Joined)
+		}
+	}
+}
+
+func TestProcessJoin(t *testing.T) {
+	reads := 0
+	s := getSource("process").newState(time.Minute).(*processState)
+	s.readProc = func(key processKey) processInfo {
+		reads++
+		if key.pid == 42 {
+			return processInfo{found: true, args: "sleep inf", ppid: 1}
+		}
+		return processInfo{}
+	}
+	add := s.callback().(func(*exectypes.Event))
+
+	add(&exectypes.Event{
+		WithMountNsID: eventtypes.WithMountNsID{MountNsID: 11},
+		Pid:           100,
+		Ppid:          42,
+		Uid:           1000,
+		Args:          []string{"/usr/bin/curl", "-s", "example.com"},
+	})
+	tcp := &tcptypes.Event{WithMountNsID: eventtypes.WithMountNsID{MountNsID: 11}, Pid: 100}
+	s.join(tcp)
+	if tcp.Joined["process.args"] != "/usr/bin/curl -s example.com" ||
+		tcp.Joined["process.ppid"] != "42" || tcp.Joined["process.uid"] != "1000" {
+		t.Fatalf("tcp event not joined: %v", tcp.Joined)
+	}
+	if reads != 0 {
+		t.Fatalf("/proc read for an executed process")
+	}
+
+	// Processes started before the gadget are read once
+	for i := 0; i < 2; i++ {
+		tcp = &tcptypes.Event{WithMountNsID: eventtypes.WithMountNsID{MountNsID: 11}, Pid: 42}
+		s.join(tcp)
+		if tcp.Joined["process.args"] != "sleep inf" {
+			t.Fatalf("tcp event not joined: %v", tcp.Joined)
+		}
+		tcp = &tcptypes.Event{WithMountNsID: eventtypes.WithMountNsID{MountNsID: 11}, Pid: 43}
+		s.join(tcp)
+		if tcp.Joined != nil {
+			t.Fatalf("unexpected join: %v", tcp.Joined)
+		}
+	}
+	if reads != 2 {
+		t.Fatalf("/proc read %d times, expecting 2", reads)
+	}
+}
+
+func TestReadProc(t *testing.T) {
+	pid := os.Getpid()
+	mntns, err := containerutils.GetMntNs(pid)
+	if err != nil {
+		t.Skipf("getting mount namespace: %v", err)
+	}
+
+	info := readProc(processKey{mntns: mntns, pid: uint32(pid)})
+	if !info.found || info.args == "" || info.ppid != uint32(os.Getppid()) || info.uid != uint32(os.Getuid()) {
+		t.Fatalf("invalid process %+v", info)
+	}
+
+	// The pid was reused by a process of another container
+	if info := readProc(processKey{mntns: mntns + 1, pid: uint32(pid)}); info.found {
+		t.Fatalf("process found in another mount namespace")
+	}
+}
@@ -0,0 +1,363 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package join
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	containerutils "github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils"
+	dnstypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/dns/types"
+	exectypes "github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets/trace/exec/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+// maxEntries bounds the memory used by the state of each joined gadget
+const maxEntries = 65536
+
+// source is a gadget whose events can be joined with the ones of other
+// gadgets
+type source struct {
+	name     string
+	category string
+	gadget   string
+
+	// canJoin tells whether the given event can be enriched with the state
+	canJoin func(ev any) bool
+
+	newState func(maxAge time.Duration) state
+}
+
+// state keeps what the gadget of a source observed
+type state interface {
+	// callback returns the event callback to set on the parser of the gadget
+	callback() any
+
+	// join enriches the event with the matching state, if any
+	join(ev any)
+}
+
+var sources = []*source{
+	{
+		name:     "dns",
+		category: "trace",
+		gadget:   "dns",
+		canJoin: func(ev any) bool {
+			_, hasRemoteIPs := ev.(RemoteIPsGetter)
+			return hasRemoteIPs && (hasNetNs(ev) || hasMntNs(ev))
+		},
+		newState: func(maxAge time.Duration) state {
+			return &dnsState{names: newStore[dnsKey, string](maxAge, maxEntries)}
+		},
+	},
+	{
+		name:     "process",
+		category: "trace",
+		gadget:   "exec",
+		canJoin: func(ev any) bool {
+			_, hasPid := ev.(PidGetter)
+			return hasPid && hasMntNs(ev)
+		},
+		newState: func(maxAge time.Duration) state {
+			return &processState{
+				processes: newStore[processKey, processInfo](maxAge, maxEntries),
+				readProc:  readProc,
+			}
+		},
+	},
+}
+
+func getSource(name string) *source {
+	for _, s := range sources {
+		if s.name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+func sourceNames() []string {
+	names := make([]string, 0, len(sources))
+	for _, s := range sources {
+		names = append(names, s.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func validateSource(value string) error {
+	if getSource(value) == nil {
+		return fmt.Errorf("%q is not one of %s", value, strings.Join(sourceNames(), ", "))
+	}
+	return nil
+}
+
+type netNsGetter interface {
+	GetNetNSID() uint64
+}
+
+type mntNsGetter interface {
+	GetMountNSID() uint64
+}
+
+func hasNetNs(ev any) bool {
+	_, ok := ev.(netNsGetter)
+	return ok
+}
+
+func hasMntNs(ev any) bool {
+	_, ok := ev.(mntNsGetter)
+	return ok
+}
+
+type storeEntry[V any] struct {
+	value V
+	seen  time.Time
+}
+
+// store is a map whose entries expire after maxAge. It holds at most
+// maxEntries entries, the expired ones and then arbitrary ones are removed if
+// it's full.
+type store[K comparable, V any] struct {
+	mu         sync.Mutex
+	entries    map[K]storeEntry[V]
+	maxAge     time.Duration
+	maxEntries int
+	now        func() time.Time
+}
+
+func newStore[K comparable, V any](maxAge time.Duration, maxEntries int) *store[K, V] {
+	return &store[K, V]{
+		entries:    make(map[K]storeEntry[V]),
+		maxAge:     maxAge,
+		maxEntries: maxEntries,
+		now:        time.Now,
+	}
+}
+
+func (s *store[K, V]) put(key K, value V) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[key]; !ok && len(s.entries) >= s.maxEntries {
+		s.prune()
+	}
+	s.entries[key] = storeEntry[V]{value: value, seen: s.now()}
+}
+
+func (s *store[K, V]) get(key K) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	if s.now().Sub(entry.seen) > s.maxAge {
+		delete(s.entries, key)
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// prune makes room for new entries, it must be called with mu held
+func (s *store[K, V]) prune() {
+	now := s.now()
+	for key, entry := range s.entries {
+		if now.Sub(entry.seen) > s.maxAge {
+			delete(s.entries, key)
+		}
+	}
+	for key := range s.entries {
+		if len(s.entries) <= s.maxEntries*3/4 {
+			break
+		}
+		delete(s.entries, key)
+	}
+}
+
+// dnsKey identifies an address resolved in a mount or network namespace
+type dnsKey struct {
+	mntns uint64
+	netns uint64
+	addr  string
+}
+
+// dnsState keeps the names the addresses were resolved from
+type dnsState struct {
+	names *store[dnsKey, string]
+}
+
+func (s *dnsState) callback() any {
+	return s.add
+}
+
+func (s *dnsState) add(ev *dnstypes.Event) {
+	if ev.Qr != dnstypes.DNSPktTypeResponse || ev.Rcode != "NoError" {
+		return
+	}
+	name := strings.TrimSuffix(ev.DNSName, ".")
+	for _, addr := range ev.Addresses {
+		if ev.MountNsID != 0 {
+			s.names.put(dnsKey{mntns: ev.MountNsID, addr: addr}, name)
+		}
+		if ev.NetNsID != 0 {
+			s.names.put(dnsKey{netns: ev.NetNsID, addr: addr}, name)
+		}
+	}
+}
+
+func (s *dnsState) join(ev any) {
+	joinable, _ := ev.(Joinable)
+	remoteIPs, _ := ev.(RemoteIPsGetter)
+	if joinable == nil || remoteIPs == nil {
+		return
+	}
+
+	var key dnsKey
+	if getter, ok := ev.(netNsGetter); ok {
+		key.netns = getter.GetNetNSID()
+	} else if getter, ok := ev.(mntNsGetter); ok {
+		key.mntns = getter.GetMountNSID()
+	} else {
+		return
+	}
+
+	for _, addr := range remoteIPs.GetRemoteIPs() {
+		key.addr = addr
+		if name, ok := s.names.get(key); ok {
+			joinable.SetJoined("dns.name", name)
+			return
+		}
+	}
+}
+
+type processKey struct {
+	mntns uint64
+	pid   uint32
+}
+
+type processInfo struct {
+	// found is false if the process doesn't exist (anymore)
+	found bool
+	args  string
+	ppid  uint32
+	uid   uint32
+}
+
+// processState keeps the processes executed in the containers. The ones that
+// were started before the gadget are read from /proc when they're first seen.
+type processState struct {
+	processes *store[processKey, processInfo]
+	readProc  func(key processKey) processInfo
+}
+
+func (s *processState) callback() any {
+	return s.add
+}
+
+func (s *processState) add(ev *exectypes.Event) {
+	if ev.Retval != 0 {
+		return
+	}
+	s.processes.put(processKey{mntns: ev.MountNsID, pid: ev.Pid}, processInfo{
+		found: true,
+		args:  strings.Join(ev.Args, " "),
+		ppid:  ev.Ppid,
+		uid:   ev.Uid,
+	})
+}
+
+func (s *processState) join(ev any) {
+	joinable, _ := ev.(Joinable)
+	pid, _ := ev.(PidGetter)
+	mntns, _ := ev.(mntNsGetter)
+	if joinable == nil || pid == nil || mntns == nil {
+		return
+	}
+
+	key := processKey{mntns: mntns.GetMountNSID(), pid: pid.GetPid()}
+	if key.pid == 0 {
+		return
+	}
+	info, ok := s.processes.get(key)
+	if !ok {
+		// Processes that don't exist are kept too, to not look for them
+		// again on each event
+		info = s.readProc(key)
+		s.processes.put(key, info)
+	}
+	if !info.found {
+		return
+	}
+
+	joinable.SetJoined("process.args", info.args)
+	joinable.SetJoined("process.ppid", strconv.FormatUint(uint64(info.ppid), 10))
+	joinable.SetJoined("process.uid", strconv.FormatUint(uint64(info.uid), 10))
+}
+
+// readProc gets the information about the process from /proc, checking it's
+// still in the same mount namespace, i.e. its pid wasn't reused
+func readProc(key processKey) processInfo {
+	mntns, err := containerutils.GetMntNs(int(key.pid))
+	if err != nil || mntns != key.mntns {
+		return processInfo{}
+	}
+
+	info := processInfo{found: true}
+	args := host.GetProcCmdline(int(key.pid))
+	if len(args) > 0 && args[len(args)-1] == "" {
+		args = args[:len(args)-1]
+	}
+	info.args = strings.Join(args, " ")
+
+	f, err := os.Open(filepath.Join(host.HostProcFs, fmt.Sprint(key.pid), "status"))
+	if err != nil {
+		return info
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		field, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		values := strings.Fields(value)
+		if len(values) == 0 {
+			continue
+		}
+		n, err := strconv.ParseUint(values[0], 10, 32)
+		if err != nil {
+			continue
+		}
+		switch field {
+		case "PPid":
+			info.ppid = uint32(n)
+		case "Uid":
+			info.uid = uint32(n)
+		}
+	}
+	return info
+}
@@ -0,0 +1,115 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package journald
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	journalSocket = "/run/systemd/journal/socket"
+
+	maxFieldNameLen = 64
+)
+
+// journal writes entries to journald using its native protocol:
+// https://systemd.io/JOURNAL_NATIVE_PROTOCOL/
+type journal struct {
+	conn *net.UnixConn
+	addr *net.UnixAddr
+}
+
+func newJournal() (*journal, error) {
+	if _, err := os.Stat(journalSocket); err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("creating socket: %w", err)
+	}
+
+	return &journal{
+		conn: conn,
+		addr: &net.UnixAddr{Name: journalSocket, Net: "unixgram"},
+	}, nil
+}
+
+func (j *journal) close() error {
+	return j.conn.Close()
+}
+
+// send writes an entry with the given fields to the journal
+func (j *journal) send(fields map[string]string) error {
+	var buf bytes.Buffer
+	for name, value := range fields {
+		buf.WriteString(name)
+		if !strings.Contains(value, "\n") {
+			buf.WriteByte('=')
+			buf.WriteString(value)
+			buf.WriteByte('\n')
+			continue
+		}
+
+		// Values with new lines are written with their size instead
+		buf.WriteByte('\n')
+		binary.Write(&buf, binary.LittleEndian, uint64(len(value)))
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+	}
+
+	_, _, err := j.conn.WriteMsgUnix(buf.Bytes(), nil, j.addr)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+		return err
+	}
+
+	// The entry is too big for a datagram, pass it in a memfd instead
+	return j.sendFd(buf.Bytes())
+}
+
+func (j *journal) sendFd(entry []byte) error {
+	fd, err := unix.MemfdCreate("journal-entry", unix.MFD_ALLOW_SEALING|unix.MFD_CLOEXEC)
+	if err != nil {
+		return fmt.Errorf("creating memfd: %w", err)
+	}
+	file := os.NewFile(uintptr(fd), "journal-entry")
+	defer file.Close()
+
+	if _, err := file.Write(entry); err != nil {
+		return fmt.Errorf("writing memfd: %w", err)
+	}
+
+	// journald requires the memfd to be sealed
+	_, err = unix.FcntlInt(file.Fd(), unix.F_ADD_SEALS,
+		unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE|unix.F_SEAL_SEAL)
+	if err != nil {
+		return fmt.Errorf("sealing memfd: %w", err)
+	}
+
+	_, _, err = j.conn.WriteMsgUnix(nil, unix.UnixRights(int(file.Fd())), j.addr)
+	return err
+}
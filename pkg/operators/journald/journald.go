@@ -0,0 +1,263 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package journald provides an operator that writes the events of the gadgets
+// to the journal of the node, with the fields of the events as structured
+// fields, so they are kept on hosts without any log shipper and can be queried
+// with journalctl. It's disabled unless enabled with a global param.
+package journald
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/routing"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperatorName = "Journald"
+
+	ParamJournald = "journald"
+	ParamSeverity = "journald-severity"
+
+	// journaldEnv allows to enable the operator on the deployed gadget pods,
+	// where global params can't be set
+	journaldEnv = "INSPEKTOR_GADGET_JOURNALD"
+	severityEnv = "INSPEKTOR_GADGET_JOURNALD_SEVERITY"
+
+	syslogIdentifier = "inspektor-gadget"
+)
+
+// Priorities of the syslog levels, as expected by journald
+const (
+	priorityAlert   = "1"
+	priorityErr     = "3"
+	priorityWarning = "4"
+	priorityInfo    = "6"
+	priorityDebug   = "7"
+)
+
+type eventTypeGetter interface {
+	GetType() eventtypes.EventType
+}
+
+type Journald struct {
+	journal     *journal
+	minSeverity eventtypes.Severity
+}
+
+func (j *Journald) Name() string {
+	return OperatorName
+}
+
+func (j *Journald) Description() string {
+	return "Journald writes the events to the journal of the node"
+}
+
+func (j *Journald) GlobalParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:          ParamJournald,
+			Description:  "Write the events of the gadgets to the journal of the node",
+			DefaultValue: "false",
+			TypeHint:     params.TypeBool,
+		},
+		routing.SeverityParamDesc(ParamSeverity, eventtypes.SeverityInfo),
+	}
+}
+
+func (j *Journald) ParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (j *Journald) Dependencies() []string {
+	return nil
+}
+
+func (j *Journald) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	return true
+}
+
+func (j *Journald) IsSink() {}
+
+func (j *Journald) Init(params *params.Params) error {
+	enabled := params.Get(ParamJournald).AsBool()
+	if env := os.Getenv(journaldEnv); env != "" && !enabled {
+		var err error
+		enabled, err = strconv.ParseBool(env)
+		if err != nil {
+			return fmt.Errorf("parsing %s: %w", journaldEnv, err)
+		}
+	}
+	if !enabled {
+		return nil
+	}
+
+	minSeverity, err := routing.MinSeverity(params.Get(ParamSeverity), severityEnv, eventtypes.SeverityInfo)
+	if err != nil {
+		return err
+	}
+	j.minSeverity = minSeverity
+
+	journal, err := newJournal()
+	if err != nil {
+		return fmt.Errorf("connecting to journald: %w", err)
+	}
+	j.journal = journal
+
+	log.Infof("writing events to journald")
+	return nil
+}
+
+func (j *Journald) Close() error {
+	if j.journal == nil {
+		return nil
+	}
+	return j.journal.close()
+}
+
+func (j *Journald) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	desc := gadgetCtx.GadgetDesc()
+	return &JournaldInstance{
+		manager: j,
+		gadget:  fmt.Sprintf("%s/%s", desc.Category(), desc.Name()),
+		runID:   gadgetCtx.ID(),
+	}, nil
+}
+
+type JournaldInstance struct {
+	manager *Journald
+	gadget  string
+	runID   string
+}
+
+func (m *JournaldInstance) Name() string {
+	return "JournaldInstance"
+}
+
+func (m *JournaldInstance) PreGadgetRun() error {
+	return nil
+}
+
+func (m *JournaldInstance) PostGadgetRun() error {
+	return nil
+}
+
+func (m *JournaldInstance) EnrichEvent(ev any) error {
+	return nil
+}
+
+func (m *JournaldInstance) SinkEvent(ev any) error {
+	// Writing to journald is disabled
+	if m.manager.journal == nil || !routing.Accepts(ev, m.manager.minSeverity) {
+		return nil
+	}
+
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("marshaling event: %w", err)
+	}
+
+	// Use the JSON encoding of the event as a generic way to get its fields
+	var eventFields map[string]any
+	if err := json.Unmarshal(data, &eventFields); err != nil {
+		return fmt.Errorf("unmarshaling event: %w", err)
+	}
+
+	fields := map[string]string{
+		"MESSAGE":           string(data),
+		"PRIORITY":          priority(ev),
+		"SYSLOG_IDENTIFIER": syslogIdentifier,
+		"IG_GADGET":         m.gadget,
+		"IG_RUN_ID":         m.runID,
+	}
+	for key, value := range eventFields {
+		name := fieldName(key)
+		if name == "" {
+			continue
+		}
+		switch v := value.(type) {
+		case string:
+			fields[name] = v
+		default:
+			encoded, _ := json.Marshal(v)
+			fields[name] = string(encoded)
+		}
+	}
+
+	if err := m.manager.journal.send(fields); err != nil {
+		// Don't fail the gadget because the journal isn't available
+		log.Debugf("writing event to journald: %v", err)
+	}
+	return nil
+}
+
+func priority(ev any) string {
+	e, ok := ev.(eventTypeGetter)
+	if !ok {
+		return priorityInfo
+	}
+
+	switch e.GetType() {
+	case eventtypes.ERR:
+		return priorityErr
+	case eventtypes.WARN:
+		return priorityWarning
+	case eventtypes.DEBUG:
+		return priorityDebug
+	case eventtypes.NORMAL:
+		switch eventtypes.EventSeverity(ev) {
+		case eventtypes.SeverityAlert:
+			return priorityAlert
+		case eventtypes.SeverityWarn:
+			return priorityWarning
+		}
+		return priorityInfo
+	default:
+		return priorityInfo
+	}
+}
+
+// fieldName returns the name of the journal field for the given field of an
+// event: the fields of the events are prefixed with IG_ and only contain upper
+// case letters, digits and underscores, as required by journald
+func fieldName(key string) string {
+	name := []byte("IG_")
+	for _, c := range []byte(key) {
+		switch {
+		case c >= 'a' && c <= 'z':
+			name = append(name, c-'a'+'A')
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			name = append(name, c)
+		default:
+			name = append(name, '_')
+		}
+	}
+	if len(name) > maxFieldNameLen {
+		return ""
+	}
+	return string(name)
+}
+
+func init() {
+	operators.Register(&Journald{})
+}
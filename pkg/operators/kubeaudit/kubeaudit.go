@@ -0,0 +1,189 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kubeaudit provides an operator that receives the Kubernetes audit
+// log through a webhook and correlates kubectl exec, attach and port-forward
+// sessions with the events observed in the target pods, telling who did what
+// inside the pod. It's disabled unless a listen address is configured.
+package kubeaudit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/kubemanager"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+)
+
+const (
+	OperatorName = "KubeAudit"
+
+	ParamWebhookAddress = "audit-webhook-address"
+	ParamSessionGrace   = "audit-session-grace"
+
+	// webhookAddressEnv allows to enable the operator on the deployed gadget
+	// pods, where global params can't be set
+	webhookAddressEnv = "INSPEKTOR_GADGET_AUDIT_WEBHOOK_ADDRESS"
+)
+
+// KubeAuditInformation is implemented by events that can be annotated with the
+// Kubernetes session they were observed in
+type KubeAuditInformation interface {
+	SetKubeAuditDetails(user, action string)
+}
+
+type KubeAudit struct {
+	sessions *sessionStore
+	server   *http.Server
+}
+
+func (k *KubeAudit) Name() string {
+	return OperatorName
+}
+
+func (k *KubeAudit) Description() string {
+	return "KubeAudit correlates exec, attach and port-forward audit entries with events"
+}
+
+func (k *KubeAudit) GlobalParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:         ParamWebhookAddress,
+			Description: "Address to receive the audit log webhook on (e.g. :8443). Empty disables the correlation",
+		},
+		{
+			Key:          ParamSessionGrace,
+			Description:  "How long events are still attributed to a session after it ended",
+			DefaultValue: "5s",
+			TypeHint:     params.TypeDuration,
+		},
+	}
+}
+
+func (k *KubeAudit) ParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (k *KubeAudit) Dependencies() []string {
+	return []string{kubemanager.OperatorName}
+}
+
+func (k *KubeAudit) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	km := kubemanager.KubeManager{}
+	if !km.CanOperateOn(gadget) {
+		return false
+	}
+
+	_, hasAuditInf := gadget.EventPrototype().(KubeAuditInformation)
+	return hasAuditInf
+}
+
+func (k *KubeAudit) IsUnprivilegedEnricher() {}
+
+func (k *KubeAudit) Init(params *params.Params) error {
+	address := params.Get(ParamWebhookAddress).AsString()
+	if envAddress := os.Getenv(webhookAddressEnv); envAddress != "" && address == "" {
+		address = envAddress
+	}
+	if address == "" {
+		return nil
+	}
+
+	k.sessions = newSessionStore(params.Get(ParamSessionGrace).AsDuration())
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("listening for audit webhook on %q: %w", address, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", &webhookHandler{sessions: k.sessions})
+	k.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := k.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("serving audit webhook: %v", err)
+		}
+	}()
+
+	log.Infof("receiving audit log webhook on %q", address)
+	return nil
+}
+
+func (k *KubeAudit) Close() error {
+	if k.server == nil {
+		return nil
+	}
+	return k.server.Shutdown(context.Background())
+}
+
+func (k *KubeAudit) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	return &KubeAuditInstance{
+		manager: k,
+	}, nil
+}
+
+type KubeAuditInstance struct {
+	manager *KubeAudit
+}
+
+func (m *KubeAuditInstance) Name() string {
+	return "KubeAuditInstance"
+}
+
+func (m *KubeAuditInstance) PreGadgetRun() error {
+	return nil
+}
+
+func (m *KubeAuditInstance) PostGadgetRun() error {
+	return nil
+}
+
+func (m *KubeAuditInstance) EnrichEvent(ev any) error {
+	// Correlation is disabled
+	if m.manager.sessions == nil {
+		return nil
+	}
+
+	auditInfo, ok := ev.(KubeAuditInformation)
+	if !ok {
+		return nil
+	}
+	containerInfo, ok := ev.(operators.ContainerInfoGetters)
+	if !ok || containerInfo.GetPod() == "" {
+		return nil
+	}
+
+	s := m.manager.sessions.lookup(containerInfo.GetNamespace(), containerInfo.GetPod(),
+		containerInfo.GetContainer(), time.Now())
+	if s != nil {
+		auditInfo.SetKubeAuditDetails(s.user, s.action)
+	}
+	return nil
+}
+
+func init() {
+	operators.Register(&KubeAudit{})
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeaudit
+
+import (
+	"sync"
+	"time"
+)
+
+// session is an exec, attach or port-forward stream opened against a pod
+type session struct {
+	namespace string
+	pod       string
+	// container is empty when the request targets the default container or
+	// the whole pod, like port-forward does
+	container string
+
+	user   string
+	action string
+
+	start time.Time
+	// end is zero while the session is still running
+	end time.Time
+}
+
+func (s *session) matches(namespace, pod, container string, now time.Time, grace time.Duration) bool {
+	if s.namespace != namespace || s.pod != pod {
+		return false
+	}
+	if s.container != "" && s.container != container {
+		return false
+	}
+	if now.Before(s.start) {
+		return false
+	}
+	return s.end.IsZero() || now.Before(s.end.Add(grace))
+}
+
+type sessionStore struct {
+	mu sync.Mutex
+
+	// sessions indexed by audit ID
+	sessions map[string]*session
+	grace    time.Duration
+}
+
+func newSessionStore(grace time.Duration) *sessionStore {
+	return &sessionStore{
+		sessions: make(map[string]*session),
+		grace:    grace,
+	}
+}
+
+// open records a session, or updates it if the audit log already reported it
+// in a previous stage
+func (st *sessionStore) open(id string, s *session) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if existing, ok := st.sessions[id]; ok {
+		if s.start.Before(existing.start) {
+			existing.start = s.start
+		}
+		return
+	}
+	st.sessions[id] = s
+}
+
+func (st *sessionStore) close(id string, end time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if s, ok := st.sessions[id]; ok {
+		s.end = end
+	}
+	st.prune(end)
+}
+
+// prune removes the sessions that can't match any new event. It must be
+// called with the lock held.
+func (st *sessionStore) prune(now time.Time) {
+	for id, s := range st.sessions {
+		if !s.end.IsZero() && now.After(s.end.Add(st.grace)) {
+			delete(st.sessions, id)
+		}
+	}
+}
+
+// lookup returns the most recent session matching the given container at the
+// given time, or nil if there is none
+func (st *sessionStore) lookup(namespace, pod, container string, now time.Time) *session {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	var ret *session
+	for _, s := range st.sessions {
+		if !s.matches(namespace, pod, container, now, st.grace) {
+			continue
+		}
+		if ret == nil || s.start.After(ret.start) {
+			ret = s
+		}
+	}
+	return ret
+}
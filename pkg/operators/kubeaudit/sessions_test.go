@@ -0,0 +1,117 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeaudit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var t0 = time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func TestSessionMatches(t *testing.T) {
+	t.Parallel()
+
+	grace := 5 * time.Second
+	running := &session{namespace: "ns", pod: "pod", container: "nginx", start: t0}
+	ended := &session{namespace: "ns", pod: "pod", container: "nginx", start: t0, end: t0.Add(time.Minute)}
+	wholePod := &session{namespace: "ns", pod: "pod", start: t0}
+
+	table := []struct {
+		description string
+		session     *session
+		namespace   string
+		pod         string
+		container   string
+		now         time.Time
+		expected    bool
+	}{
+		{"running", running, "ns", "pod", "nginx", t0.Add(time.Hour), true},
+		{"before_start", running, "ns", "pod", "nginx", t0.Add(-time.Second), false},
+		{"other_namespace", running, "other", "pod", "nginx", t0, false},
+		{"other_pod", running, "ns", "other", "nginx", t0, false},
+		{"other_container", running, "ns", "pod", "sidecar", t0, false},
+		{"whole_pod", wholePod, "ns", "pod", "sidecar", t0, true},
+		{"ended_within_grace", ended, "ns", "pod", "nginx", t0.Add(time.Minute + grace - time.Second), true},
+		{"ended_after_grace", ended, "ns", "pod", "nginx", t0.Add(time.Minute + grace), false},
+	}
+
+	for _, entry := range table {
+		entry := entry
+		t.Run(entry.description, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, entry.expected,
+				entry.session.matches(entry.namespace, entry.pod, entry.container, entry.now, grace))
+		})
+	}
+}
+
+func TestSessionStoreLookup(t *testing.T) {
+	t.Parallel()
+
+	st := newSessionStore(5 * time.Second)
+	st.open("1", &session{namespace: "ns", pod: "pod", user: "alice", action: "exec", start: t0})
+	st.open("2", &session{namespace: "ns", pod: "pod", user: "bob", action: "attach", start: t0.Add(time.Second)})
+
+	// The most recent session wins
+	s := st.lookup("ns", "pod", "nginx", t0.Add(2*time.Second))
+	require.NotNil(t, s)
+	require.Equal(t, "bob", s.user)
+
+	s = st.lookup("ns", "pod", "nginx", t0)
+	require.NotNil(t, s)
+	require.Equal(t, "alice", s.user)
+
+	require.Nil(t, st.lookup("ns", "other", "nginx", t0.Add(2*time.Second)))
+}
+
+func TestSessionStoreOpenExisting(t *testing.T) {
+	t.Parallel()
+
+	st := newSessionStore(5 * time.Second)
+	st.open("1", &session{namespace: "ns", pod: "pod", user: "alice", start: t0.Add(time.Second)})
+	// A later stage of the same request keeps the earliest start
+	st.open("1", &session{namespace: "ns", pod: "pod", user: "alice", start: t0})
+	st.open("1", &session{namespace: "ns", pod: "pod", user: "alice", start: t0.Add(2 * time.Second)})
+
+	require.Len(t, st.sessions, 1)
+	require.Equal(t, t0, st.sessions["1"].start)
+}
+
+func TestSessionStoreClose(t *testing.T) {
+	t.Parallel()
+
+	st := newSessionStore(5 * time.Second)
+	st.open("1", &session{namespace: "ns", pod: "pod", user: "alice", start: t0})
+	st.open("2", &session{namespace: "ns", pod: "pod", user: "bob", start: t0})
+
+	st.close("1", t0.Add(time.Minute))
+	require.Equal(t, t0.Add(time.Minute), st.sessions["1"].end)
+	s := st.lookup("ns", "pod", "nginx", t0.Add(2*time.Minute))
+	require.NotNil(t, s)
+	require.Equal(t, "bob", s.user, "ended session still matching")
+
+	// Closing another session prunes the ones ended for longer than the
+	// grace period
+	st.close("2", t0.Add(time.Minute+6*time.Second))
+	require.NotContains(t, st.sessions, "1")
+	require.Contains(t, st.sessions, "2")
+
+	// Closing an unknown session is a no-op
+	st.close("3", t0.Add(time.Minute+6*time.Second))
+	require.Len(t, st.sessions, 1)
+}
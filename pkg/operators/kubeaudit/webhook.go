@@ -0,0 +1,122 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeaudit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// The types below are the subset of the audit.k8s.io/v1 API we need. They are
+// defined here to avoid depending on k8s.io/apiserver.
+
+type auditEventList struct {
+	Items []auditEvent `json:"items"`
+}
+
+type auditEvent struct {
+	AuditID    string `json:"auditID"`
+	Stage      string `json:"stage"`
+	RequestURI string `json:"requestURI"`
+	User       struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	ObjectRef *struct {
+		Resource    string `json:"resource"`
+		Namespace   string `json:"namespace"`
+		Name        string `json:"name"`
+		Subresource string `json:"subresource"`
+	} `json:"objectRef"`
+	RequestReceivedTimestamp time.Time `json:"requestReceivedTimestamp"`
+	StageTimestamp           time.Time `json:"stageTimestamp"`
+}
+
+const (
+	stageRequestReceived  = "RequestReceived"
+	stageResponseStarted  = "ResponseStarted"
+	stageResponseComplete = "ResponseComplete"
+	stagePanic            = "Panic"
+)
+
+var sessionSubresources = map[string]struct{}{
+	"exec":        {},
+	"attach":      {},
+	"portforward": {},
+}
+
+type webhookHandler struct {
+	sessions *sessionStore
+}
+
+func (h *webhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var list auditEventList
+	if err := json.NewDecoder(r.Body).Decode(&list); err != nil {
+		log.Debugf("decoding audit events: %v", err)
+		http.Error(w, "invalid audit event list", http.StatusBadRequest)
+		return
+	}
+
+	for i := range list.Items {
+		h.handleEvent(&list.Items[i])
+	}
+}
+
+func (h *webhookHandler) handleEvent(ev *auditEvent) {
+	ref := ev.ObjectRef
+	if ref == nil || ref.Resource != "pods" {
+		return
+	}
+	if _, ok := sessionSubresources[ref.Subresource]; !ok {
+		return
+	}
+
+	s := &session{
+		namespace: ref.Namespace,
+		pod:       ref.Name,
+		container: containerFromRequestURI(ev.RequestURI),
+		user:      ev.User.Username,
+		action:    ref.Subresource,
+		start:     ev.RequestReceivedTimestamp,
+	}
+
+	switch ev.Stage {
+	case stageRequestReceived, stageResponseStarted:
+		h.sessions.open(ev.AuditID, s)
+	case stageResponseComplete, stagePanic:
+		// The policy could log only the last stage, in that case we still
+		// want to attribute the events that happened during the session.
+		h.sessions.open(ev.AuditID, s)
+		h.sessions.close(ev.AuditID, ev.StageTimestamp)
+	}
+}
+
+// containerFromRequestURI returns the container targeted by an exec or attach
+// request, e.g. /api/v1/namespaces/default/pods/mypod/exec?container=nginx&command=sh
+func containerFromRequestURI(requestURI string) string {
+	u, err := url.Parse(requestURI)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("container")
+}
@@ -0,0 +1,217 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubeaudit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// auditEventsJSON is what the API server sends to the webhook backend for an
+// exec session logged at all the stages, a port-forward logged only when
+// complete and requests that aren't sessions
+const auditEventsJSON = `{
+  "kind": "EventList",
+  "apiVersion": "audit.k8s.io/v1",
+  "items": [
+    {
+      "level": "Metadata",
+      "auditID": "exec-1",
+      "stage": "RequestReceived",
+      "requestURI": "/api/v1/namespaces/ns/pods/pod/exec?command=sh&container=nginx&stdin=true&tty=true",
+      "verb": "create",
+      "user": {"username": "alice@example.com", "groups": ["system:authenticated"]},
+      "objectRef": {"resource": "pods", "namespace": "ns", "name": "pod", "apiVersion": "v1", "subresource": "exec"},
+      "requestReceivedTimestamp": "2023-01-02T03:04:05.000000Z",
+      "stageTimestamp": "2023-01-02T03:04:05.000000Z"
+    },
+    {
+      "level": "Metadata",
+      "auditID": "exec-1",
+      "stage": "ResponseStarted",
+      "requestURI": "/api/v1/namespaces/ns/pods/pod/exec?command=sh&container=nginx&stdin=true&tty=true",
+      "verb": "create",
+      "user": {"username": "alice@example.com"},
+      "objectRef": {"resource": "pods", "namespace": "ns", "name": "pod", "apiVersion": "v1", "subresource": "exec"},
+      "responseStatus": {"metadata": {}, "code": 101},
+      "requestReceivedTimestamp": "2023-01-02T03:04:05.000000Z",
+      "stageTimestamp": "2023-01-02T03:04:05.100000Z"
+    },
+    {
+      "level": "Metadata",
+      "auditID": "pf-1",
+      "stage": "ResponseComplete",
+      "requestURI": "/api/v1/namespaces/ns/pods/db/portforward",
+      "verb": "create",
+      "user": {"username": "bob@example.com"},
+      "objectRef": {"resource": "pods", "namespace": "ns", "name": "db", "apiVersion": "v1", "subresource": "portforward"},
+      "requestReceivedTimestamp": "2023-01-02T03:00:00.000000Z",
+      "stageTimestamp": "2023-01-02T03:10:00.000000Z"
+    },
+    {
+      "level": "Metadata",
+      "auditID": "log-1",
+      "stage": "ResponseComplete",
+      "requestURI": "/api/v1/namespaces/ns/pods/pod/log?container=nginx",
+      "verb": "get",
+      "user": {"username": "carol@example.com"},
+      "objectRef": {"resource": "pods", "namespace": "ns", "name": "pod", "apiVersion": "v1", "subresource": "log"},
+      "requestReceivedTimestamp": "2023-01-02T03:04:05.000000Z",
+      "stageTimestamp": "2023-01-02T03:04:05.000000Z"
+    },
+    {
+      "level": "Metadata",
+      "auditID": "list-1",
+      "stage": "ResponseComplete",
+      "requestURI": "/api/v1/namespaces/ns/configmaps",
+      "verb": "list",
+      "user": {"username": "carol@example.com"},
+      "objectRef": {"resource": "configmaps", "namespace": "ns", "apiVersion": "v1"},
+      "requestReceivedTimestamp": "2023-01-02T03:04:05.000000Z",
+      "stageTimestamp": "2023-01-02T03:04:05.000000Z"
+    }
+  ]
+}`
+
+func newTestWebhook(t *testing.T) (*httptest.Server, *sessionStore) {
+	st := newSessionStore(5 * time.Second)
+	server := httptest.NewServer(&webhookHandler{sessions: st})
+	t.Cleanup(server.Close)
+	return server, st
+}
+
+func TestWebhook(t *testing.T) {
+	t.Parallel()
+
+	server, st := newTestWebhook(t)
+
+	resp, err := http.Post(server.URL, "application/json", strings.NewReader(auditEventsJSON))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.Len(t, st.sessions, 2)
+	require.Equal(t, &session{
+		namespace: "ns",
+		pod:       "pod",
+		container: "nginx",
+		user:      "alice@example.com",
+		action:    "exec",
+		start:     time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+	}, st.sessions["exec-1"])
+	require.Equal(t, &session{
+		namespace: "ns",
+		pod:       "db",
+		user:      "bob@example.com",
+		action:    "portforward",
+		start:     time.Date(2023, 1, 2, 3, 0, 0, 0, time.UTC),
+		end:       time.Date(2023, 1, 2, 3, 10, 0, 0, time.UTC),
+	}, st.sessions["pf-1"])
+
+	s := st.lookup("ns", "db", "postgres", time.Date(2023, 1, 2, 3, 5, 0, 0, time.UTC))
+	require.NotNil(t, s)
+	require.Equal(t, "bob@example.com", s.user)
+}
+
+func TestWebhookInvalidRequests(t *testing.T) {
+	t.Parallel()
+
+	server, st := newTestWebhook(t)
+
+	resp, err := http.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+	resp, err = http.Post(server.URL, "application/json", strings.NewReader("{"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	require.Empty(t, st.sessions)
+}
+
+func TestContainerFromRequestURI(t *testing.T) {
+	t.Parallel()
+
+	table := []struct {
+		requestURI string
+		expected   string
+	}{
+		{"/api/v1/namespaces/ns/pods/pod/exec?command=sh&container=nginx", "nginx"},
+		{"/api/v1/namespaces/ns/pods/pod/attach?container=sidecar&stdin=true", "sidecar"},
+		{"/api/v1/namespaces/ns/pods/pod/exec?command=sh", ""},
+		{"/api/v1/namespaces/ns/pods/pod/portforward", ""},
+		{"%zz", ""},
+	}
+
+	for _, entry := range table {
+		require.Equal(t, entry.expected, containerFromRequestURI(entry.requestURI), entry.requestURI)
+	}
+}
+
+type testEvent struct {
+	eventtypes.Event
+	eventtypes.WithKubeAudit
+}
+
+func TestEnrichEvent(t *testing.T) {
+	t.Parallel()
+
+	st := newSessionStore(5 * time.Second)
+	st.open("exec-1", &session{namespace: "ns", pod: "pod", container: "nginx", user: "alice", action: "exec", start: time.Now()})
+	instance := &KubeAuditInstance{manager: &KubeAudit{sessions: st}}
+
+	table := []struct {
+		description string
+		event       *testEvent
+		expected    eventtypes.WithKubeAudit
+	}{
+		{
+			description: "in_session",
+			event:       &testEvent{Event: eventtypes.Event{CommonData: eventtypes.CommonData{Namespace: "ns", Pod: "pod", Container: "nginx"}}},
+			expected:    eventtypes.WithKubeAudit{KubeUser: "alice", KubeAction: "exec"},
+		},
+		{
+			description: "other_container",
+			event:       &testEvent{Event: eventtypes.Event{CommonData: eventtypes.CommonData{Namespace: "ns", Pod: "pod", Container: "sidecar"}}},
+		},
+		{
+			description: "host",
+			event:       &testEvent{},
+		},
+	}
+
+	for _, entry := range table {
+		require.NoError(t, instance.EnrichEvent(entry.event), entry.description)
+		require.Equal(t, entry.expected, entry.event.WithKubeAudit, entry.description)
+	}
+
+	// The events without audit details are left as they are
+	require.NoError(t, instance.EnrichEvent(&eventtypes.Event{CommonData: eventtypes.CommonData{Namespace: "ns", Pod: "pod", Container: "nginx"}}))
+
+	// The correlation is disabled
+	disabled := &KubeAuditInstance{manager: &KubeAudit{}}
+	ev := &testEvent{Event: eventtypes.Event{CommonData: eventtypes.CommonData{Namespace: "ns", Pod: "pod", Container: "nginx"}}}
+	require.NoError(t, disabled.EnrichEvent(ev))
+	require.Empty(t, ev.KubeUser)
+}
@@ -15,7 +15,9 @@
 // Package kubeipresolver provides an operator that enriches events by looking
 // up IP addresses in Kubernetes resources such as pods and services. It is
 // currently used by the following gadgets:
+// - trace nat
 // - trace network
+// - trace packetdrop
 // - trace tcpdrop
 // - trace tcpretrans
 package kubeipresolver
@@ -183,6 +185,8 @@ func (k *KubeIPResolver) CanOperateOn(gadget gadgets.GadgetDesc) bool {
 	return hasNetworkInf
 }
 
+func (k *KubeIPResolver) IsUnprivilegedEnricher() {}
+
 func (k *KubeIPResolver) Init(params *params.Params) error {
 	k8sInventory, err := newCache(1 * time.Second)
 	if err != nil {
@@ -18,6 +18,9 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/cilium/ebpf"
 	"github.com/google/uuid"
@@ -27,6 +30,8 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgettracermanager"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/kataguest"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/quota"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
 )
 
@@ -34,9 +39,14 @@ const (
 	OperatorName       = "KubeManager"
 	ParamContainerName = "containername"
 	ParamSelector      = "selector"
+	ParamNsSelector    = "namespace-selector"
+	ParamLabels        = "labels"
+	ParamAnnotations   = "runtime-annotations"
 	ParamAllNamespaces = "all-namespaces"
 	ParamPodName       = "podname"
 	ParamNamespace     = "namespace"
+
+	ParamLateEnrichment = "late-enrichment"
 )
 
 type MountNsMapSetter interface {
@@ -50,6 +60,7 @@ type Attacher interface {
 
 type KubeManager struct {
 	gadgetTracerManager *gadgettracermanager.GadgetTracerManager
+	remoteEnrichment    bool
 }
 
 func (k *KubeManager) SetGadgetTracerMgr(g *gadgettracermanager.GadgetTracerManager) {
@@ -57,6 +68,14 @@ func (k *KubeManager) SetGadgetTracerMgr(g *gadgettracermanager.GadgetTracerMana
 	k.gadgetTracerManager = g
 }
 
+// SetRemoteEnrichment makes the instances leave the enrichment of the events
+// with their container to the pipeline process, which keeps a copy of the
+// container collection, see pkg/pipelineprocess. They still select, attach and
+// count the containers, and defer their events.
+func (k *KubeManager) SetRemoteEnrichment(remote bool) {
+	k.remoteEnrichment = remote
+}
+
 func (k *KubeManager) Name() string {
 	return OperatorName
 }
@@ -70,7 +89,7 @@ func (k *KubeManager) GlobalParamDescs() params.ParamDescs {
 }
 
 func (k *KubeManager) ParamDescs() params.ParamDescs {
-	return params.ParamDescs{
+	return append(params.ParamDescs{
 		{
 			Key:         ParamContainerName,
 			Alias:       "c",
@@ -82,21 +101,22 @@ func (k *KubeManager) ParamDescs() params.ParamDescs {
 			Alias:       "l",
 			Description: "Labels selector to filter on. Only '=' is supported (e.g. key1=value1,key2=value2).",
 			ValueHint:   gadgets.K8SLabels,
-			Validator: func(value string) error {
-				if value == "" {
-					return nil
-				}
-
-				pairs := strings.Split(value, ",")
-				for _, pair := range pairs {
-					kv := strings.Split(pair, "=")
-					if len(kv) != 2 {
-						return fmt.Errorf("should be a comma-separated list of key-value pairs (key=value[,key=value,...])")
-					}
-				}
-
-				return nil
-			},
+			Validator:   validateSelector,
+		},
+		{
+			Key:         ParamNsSelector,
+			Description: "Labels selector of the namespaces to filter on. Only '=' is supported (e.g. key1=value1,key2=value2).",
+			Validator:   validateSelector,
+		},
+		{
+			Key: ParamAnnotations,
+			Description: "Annotations of the runtime spec of the containers to filter on, like the io.kubernetes.cri-o.* " +
+				"ones of CRI-O. Only '=' is supported (e.g. key1=value1,key2=value2).",
+			Validator: validateSelector,
+		},
+		{
+			Key:         ParamLabels,
+			Description: "Comma-separated keys of the labels of the pods, or of their namespace, to add to the events",
 		},
 		{
 			Key:         ParamPodName,
@@ -117,7 +137,39 @@ func (k *KubeManager) ParamDescs() params.ParamDescs {
 			Description: "Show only data from pods in a given namespace",
 			ValueHint:   gadgets.K8SNamespace,
 		},
+		{
+			Key: ParamLateEnrichment,
+			Description: "Hold the events of containers not known yet for up to this duration, to enrich the events " +
+				"of short-lived containers. 0 to disable",
+			DefaultValue: "100ms",
+			TypeHint:     params.TypeDuration,
+		},
+	}, append(quota.ParamDescs(), kataguest.ParamDescs()...)...)
+}
+
+func validateSelector(value string) error {
+	if value == "" {
+		return nil
+	}
+
+	pairs := strings.Split(value, ",")
+	for _, pair := range pairs {
+		kv := strings.Split(pair, "=")
+		if len(kv) != 2 {
+			return fmt.Errorf("should be a comma-separated list of key-value pairs (key=value[,key=value,...])")
+		}
+	}
+
+	return nil
+}
+
+func parseSelector(selectorSlice []string) map[string]string {
+	labels := make(map[string]string)
+	for _, pair := range selectorSlice {
+		kv := strings.Split(pair, "=")
+		labels[kv[0]] = kv[1]
 	}
+	return labels
 }
 
 func (k *KubeManager) Dependencies() []string {
@@ -162,6 +214,16 @@ func (k *KubeManager) Close() error {
 	return nil
 }
 
+// LookupContainerByMntns returns the container with the given mount
+// namespace, for the operators needing more than the container info of the
+// events
+func (k *KubeManager) LookupContainerByMntns(mntnsid uint64) *containercollection.Container {
+	if k.gadgetTracerManager == nil {
+		return nil
+	}
+	return k.gadgetTracerManager.ContainerCollection.LookupContainerByMntns(mntnsid)
+}
+
 func (k *KubeManager) Instantiate(gadgetContext operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
 	_, canEnrichEventFromMountNs := gadgetContext.GadgetDesc().EventPrototype().(operators.ContainerInfoFromMountNSID)
 	_, canEnrichEventFromNetNs := gadgetContext.GadgetDesc().EventPrototype().(operators.ContainerInfoFromNetNSID)
@@ -170,12 +232,20 @@ func (k *KubeManager) Instantiate(gadgetContext operators.GadgetContext, gadgetI
 	traceInstance := &KubeManagerInstance{
 		id:             uuid.New().String(),
 		manager:        k,
-		enrichEvents:   canEnrichEvent,
+		enrichEvents:   canEnrichEvent && !k.remoteEnrichment,
 		params:         params,
+		labels:         params.Get(ParamLabels).AsStringSlice(),
 		gadgetInstance: gadgetInstance,
 		gadgetCtx:      gadgetContext,
 	}
 
+	// The events of trace gadgets can come before their container is added
+	if delay := params.Get(ParamLateEnrichment).AsDuration(); delay > 0 && canEnrichEventFromMountNs &&
+		gadgetContext.GadgetDesc().Type() == gadgets.TypeTrace && k.gadgetTracerManager != nil {
+		traceInstance.lateEnrichment = k.gadgetTracerManager.ContainerCollection.NewLateEnrichment()
+		traceInstance.lateEnrichmentDelay = delay
+	}
+
 	return traceInstance, nil
 }
 
@@ -184,39 +254,133 @@ type KubeManagerInstance struct {
 	manager      *KubeManager
 	enrichEvents bool
 	mountnsmap   *ebpf.Map
+	quota        atomic.Pointer[quota.Quota]
+	emit         quota.EmitFunc
 	subscribed   bool
 
+	mu                 sync.Mutex
 	attachedContainers map[string]*containercollection.Container
 	attacher           Attacher
+	guest              *kataguest.Guest
 	params             *params.Params
+	labels             []string
 	gadgetInstance     any
 	gadgetCtx          operators.GadgetContext
+
+	lateEnrichment      *containercollection.LateEnrichment
+	lateEnrichmentDelay time.Duration
 }
 
 func (m *KubeManagerInstance) Name() string {
 	return "KubeManagerInstance"
 }
 
-func (m *KubeManagerInstance) PreGadgetRun() error {
+func (m *KubeManagerInstance) containerSelector() containercollection.ContainerSelector {
+	containerSelector := containercollection.ContainerSelector{
+		Namespace:       m.params.Get(ParamNamespace).AsString(),
+		Podname:         m.params.Get(ParamPodName).AsString(),
+		Name:            m.params.Get(ParamContainerName).AsString(),
+		Labels:          parseSelector(m.params.Get(ParamSelector).AsStringSlice()),
+		NamespaceLabels: parseSelector(m.params.Get(ParamNsSelector).AsStringSlice()),
+
+		RuntimeAnnotations: parseSelector(m.params.Get(ParamAnnotations).AsStringSlice()),
+	}
+
+	if m.params.Get(ParamAllNamespaces).AsBool() {
+		containerSelector.Namespace = ""
+	}
+
+	return containerSelector
+}
+
+func (m *KubeManagerInstance) attachContainer(container *containercollection.Container) {
 	log := m.gadgetCtx.Logger()
 
-	labels := make(map[string]string)
-	selectorSlice := m.params.Get(ParamSelector).AsStringSlice()
-	for _, pair := range selectorSlice {
-		kv := strings.Split(pair, "=")
-		labels[kv[0]] = kv[1]
+	log.Debugf("calling gadget.AttachContainer()")
+	err := m.attacher.AttachContainer(container)
+	if err != nil {
+		var ve *ebpf.VerifierError
+		if errors.As(err, &ve) {
+			m.gadgetCtx.Logger().Debugf("start tracing container %q: verifier error: %+v\n", container.Name, ve)
+		}
+
+		log.Warnf("start tracing container %q: %s", container.Name, err)
+		return
 	}
 
-	containerSelector := containercollection.ContainerSelector{
-		Namespace: m.params.Get(ParamNamespace).AsString(),
-		Podname:   m.params.Get(ParamPodName).AsString(),
-		Name:      m.params.Get(ParamContainerName).AsString(),
-		Labels:    labels,
+	m.attachedContainers[container.ID] = container
+
+	log.Debugf("tracer attached: container %q pid %d mntns %d netns %d",
+		container.Name, container.Pid, container.Mntns, container.Netns)
+}
+
+func (m *KubeManagerInstance) detachContainer(container *containercollection.Container) {
+	log := m.gadgetCtx.Logger()
+
+	log.Debugf("calling gadget.Detach()")
+	delete(m.attachedContainers, container.ID)
+
+	err := m.attacher.DetachContainer(container)
+	if err != nil {
+		log.Warnf("stop tracing container %q: %s", container.Name, err)
+		return
 	}
+	log.Debugf("tracer detached: container %q pid %d mntns %d netns %d",
+		container.Name, container.Pid, container.Mntns, container.Netns)
+}
 
-	if m.params.Get(ParamAllNamespaces).AsBool() {
-		containerSelector.Namespace = ""
+// addContainer attaches the gadget to the container and runs it inside its
+// Kata guest, depending on what's enabled
+func (m *KubeManagerInstance) addContainer(container *containercollection.Container) {
+	if m.attacher != nil {
+		m.attachContainer(container)
+	}
+	if m.guest != nil {
+		m.guest.Add(container)
+	}
+}
+
+func (m *KubeManagerInstance) removeContainer(container *containercollection.Container) {
+	if m.attacher != nil {
+		m.detachContainer(container)
+	}
+	if m.guest != nil {
+		m.guest.Remove(container)
+	}
+}
+
+func (m *KubeManagerInstance) handleContainerEvent(event containercollection.PubSubEvent) {
+	m.gadgetCtx.Logger().Debugf("%s: %s", event.Type.String(), event.Container.ID)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch event.Type {
+	case containercollection.EventTypeAddContainer:
+		m.addContainer(event.Container)
+	case containercollection.EventTypeRemoveContainer:
+		m.removeContainer(event.Container)
 	}
+}
+
+func (m *KubeManagerInstance) newQuota() *quota.Quota {
+	selects := func(container *containercollection.Container) bool {
+		return m.manager.gadgetTracerManager.TracerSelects(m.id, container)
+	}
+	return quota.New(m.params, m.mountnsmap, m.manager.gadgetTracerManager.ContainerCollection.LookupContainerByMntns,
+		selects, m.gadgetCtx.Logger(), m.emit)
+}
+
+// SetEventEmitter is used to notify the exclusions of the quota and to emit
+// the events of the Kata guests
+func (m *KubeManagerInstance) SetEventEmitter(emit func(init func(ev any))) {
+	m.emit = emit
+}
+
+func (m *KubeManagerInstance) PreGadgetRun() error {
+	log := m.gadgetCtx.Logger()
+
+	containerSelector := m.containerSelector()
 
 	if setter, ok := m.gadgetInstance.(MountNsMapSetter); ok {
 		err := m.manager.gadgetTracerManager.AddTracer(m.id, containerSelector)
@@ -235,63 +399,86 @@ func (m *KubeManagerInstance) PreGadgetRun() error {
 		setter.SetMountNsMap(mountnsmap)
 
 		m.mountnsmap = mountnsmap
+
+		m.quota.Store(m.newQuota())
 	}
 
 	if attacher, ok := m.gadgetInstance.(Attacher); ok {
 		m.attacher = attacher
 		m.attachedContainers = make(map[string]*containercollection.Container)
+	}
+	m.guest = kataguest.New(m.params, m.gadgetCtx, m.emit)
 
-		attachContainerFunc := func(container *containercollection.Container) {
-			log.Debugf("calling gadget.AttachContainer()")
-			err := attacher.AttachContainer(container)
-			if err != nil {
-				var ve *ebpf.VerifierError
-				if errors.As(err, &ve) {
-					m.gadgetCtx.Logger().Debugf("start tracing container %q: verifier error: %+v\n", container.Name, ve)
-				}
-
-				log.Warnf("start tracing container %q: %s", container.Name, err)
-				return
-			}
+	if m.attacher != nil || m.guest != nil {
+		m.subscribed = true
 
-			m.attachedContainers[container.ID] = container
+		log.Debugf("add subscription")
+		containers := m.manager.gadgetTracerManager.Subscribe(
+			m.id,
+			containerSelector,
+			m.handleContainerEvent,
+		)
 
-			log.Debugf("tracer attached: container %q pid %d mntns %d netns %d",
-				container.Name, container.Pid, container.Mntns, container.Netns)
+		m.mu.Lock()
+		for _, container := range containers {
+			m.addContainer(container)
 		}
+		m.mu.Unlock()
+	}
+
+	return nil
+}
 
-		detachContainerFunc := func(container *containercollection.Container) {
-			log.Debugf("calling gadget.Detach()")
-			delete(m.attachedContainers, container.ID)
+// UpdateParams changes the containers traced by the running gadget, see
+// localmanager
+func (m *KubeManagerInstance) UpdateParams(params *params.Params) error {
+	if kataguest.Enabled(params) != kataguest.Enabled(m.params) {
+		return fmt.Errorf("%q can't be changed while the gadget is running", kataguest.ParamKataGuest)
+	}
 
-			err := attacher.DetachContainer(container)
-			if err != nil {
-				log.Warnf("stop tracing container %q: %s", container.Name, err)
-				return
-			}
-			log.Debugf("tracer detached: container %q pid %d mntns %d netns %d",
-				container.Name, container.Pid, container.Mntns, container.Netns)
+	m.params = params
+	m.labels = params.Get(ParamLabels).AsStringSlice()
+	containerSelector := m.containerSelector()
+
+	if m.mountnsmap != nil {
+		// The containers excluded by the quota are added back to the map, so
+		// start again with a new quota
+		if q := m.quota.Swap(nil); q != nil {
+			q.Stop()
 		}
+		if err := m.manager.gadgetTracerManager.UpdateTracer(m.id, containerSelector); err != nil {
+			return fmt.Errorf("updating tracer: %w", err)
+		}
+		m.quota.Store(m.newQuota())
+	}
 
-		m.subscribed = true
+	if m.subscribed {
+		m.mu.Lock()
+		defer m.mu.Unlock()
 
-		log.Debugf("add subscription")
+		// Subscribing again with the same key replaces the subscription
 		containers := m.manager.gadgetTracerManager.Subscribe(
 			m.id,
 			containerSelector,
-			func(event containercollection.PubSubEvent) {
-				log.Debugf("%s: %s", event.Type.String(), event.Container.ID)
-				switch event.Type {
-				case containercollection.EventTypeAddContainer:
-					attachContainerFunc(event.Container)
-				case containercollection.EventTypeRemoveContainer:
-					detachContainerFunc(event.Container)
-				}
-			},
+			m.handleContainerEvent,
 		)
 
-		for _, container := range containers {
-			attachContainerFunc(container)
+		if m.attacher != nil {
+			matching := make(map[string]struct{}, len(containers))
+			for _, container := range containers {
+				matching[container.ID] = struct{}{}
+				if _, ok := m.attachedContainers[container.ID]; !ok {
+					m.attachContainer(container)
+				}
+			}
+			for id, container := range m.attachedContainers {
+				if _, ok := matching[id]; !ok {
+					m.detachContainer(container)
+				}
+			}
+		}
+		if m.guest != nil {
+			m.guest.Update(containers)
 		}
 	}
 
@@ -299,6 +486,9 @@ func (m *KubeManagerInstance) PreGadgetRun() error {
 }
 
 func (m *KubeManagerInstance) PostGadgetRun() error {
+	if q := m.quota.Load(); q != nil {
+		q.Stop()
+	}
 	if m.mountnsmap != nil {
 		m.gadgetCtx.Logger().Debugf("calling RemoveTracer()")
 		m.manager.gadgetTracerManager.RemoveTracer(m.id)
@@ -307,28 +497,40 @@ func (m *KubeManagerInstance) PostGadgetRun() error {
 		m.gadgetCtx.Logger().Debugf("calling Unsubscribe()")
 		m.manager.gadgetTracerManager.Unsubscribe(m.id)
 
+		if m.guest != nil {
+			m.guest.Stop()
+		}
+
 		// emit detach for all remaining containers
+		m.mu.Lock()
 		for _, container := range m.attachedContainers {
 			m.attacher.DetachContainer(container)
 		}
+		m.mu.Unlock()
 	}
 	return nil
 }
 
-func (m *KubeManagerInstance) enrich(ev any) {
-	if event, canEnrichEventFromMountNs := ev.(operators.ContainerInfoFromMountNSID); canEnrichEventFromMountNs {
-		m.manager.gadgetTracerManager.ContainerCollection.EnrichEventByMntNs(event)
+func (m *KubeManagerInstance) DeferEvent(ev any) time.Duration {
+	if m.lateEnrichment == nil {
+		return 0
 	}
-	if event, canEnrichEventFromNetNs := ev.(operators.ContainerInfoFromNetNSID); canEnrichEventFromNetNs {
-		m.manager.gadgetTracerManager.ContainerCollection.EnrichEventByNetNs(event)
+	if event, ok := ev.(operators.ContainerInfoFromMountNSID); ok && m.lateEnrichment.Defer(event, m.lateEnrichmentDelay) {
+		return m.lateEnrichmentDelay
 	}
+	return 0
 }
 
 func (m *KubeManagerInstance) EnrichEvent(ev any) error {
+	if q := m.quota.Load(); q != nil {
+		if event, ok := ev.(operators.ContainerInfoFromMountNSID); ok {
+			q.Count(event.GetMountNSID())
+		}
+	}
 	if !m.enrichEvents {
 		return nil
 	}
-	m.enrich(ev)
+	m.manager.gadgetTracerManager.ContainerCollection.EnrichContainerInfo(ev, m.labels)
 	return nil
 }
 
@@ -18,6 +18,9 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/cilium/ebpf"
 	"github.com/google/uuid"
@@ -30,6 +33,8 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	igmanager "github.com/inspektor-gadget/inspektor-gadget/pkg/ig-manager"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/kataguest"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/quota"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
 )
 
@@ -40,8 +45,10 @@ const (
 	ContainerName        = "containername"
 	DockerSocketPath     = "docker-socketpath"
 	ContainerdSocketPath = "containerd-socketpath"
+	ContainerdNamespaces = "containerd-namespaces"
 	CrioSocketPath       = "crio-socketpath"
 	PodmanSocketPath     = "podman-socketpath"
+	LateEnrichment       = "late-enrichment"
 )
 
 type MountNsMapSetter interface {
@@ -90,6 +97,11 @@ func (l *LocalManager) GlobalParamDescs() params.ParamDescs {
 			DefaultValue: runtimeclient.ContainerdDefaultSocketPath,
 			Description:  "Containerd CRI Unix socket path",
 		},
+		{
+			Key: ContainerdNamespaces,
+			Description: "Comma-separated containerd namespaces whose containers are also enriched besides the " +
+				"k8s.io one of Kubernetes (e.g. default,buildkit), or * for all of them",
+		},
 		{
 			Key:          CrioSocketPath,
 			DefaultValue: runtimeclient.CrioDefaultSocketPath,
@@ -104,14 +116,21 @@ func (l *LocalManager) GlobalParamDescs() params.ParamDescs {
 }
 
 func (l *LocalManager) ParamDescs() params.ParamDescs {
-	return params.ParamDescs{
+	return append(params.ParamDescs{
 		{
 			Key:         ContainerName,
 			Alias:       "c",
 			Description: "Show only data from containers with that name",
 			ValueHint:   gadgets.LocalContainer,
 		},
-	}
+		{
+			Key: LateEnrichment,
+			Description: "Hold the events of containers not known yet for up to this duration, to enrich the events " +
+				"of short-lived containers. 0 to disable",
+			DefaultValue: "100ms",
+			TypeHint:     params.TypeDuration,
+		},
+	}, append(quota.ParamDescs(), kataguest.ParamDescs()...)...)
 }
 
 func (l *LocalManager) CanOperateOn(gadget gadgets.GadgetDesc) bool {
@@ -175,10 +194,14 @@ partsLoop:
 			}
 		}
 
-		rc = append(rc, &containerutils.RuntimeConfig{
+		runtimeConfig := &containerutils.RuntimeConfig{
 			Name:       runtimeName,
 			SocketPath: socketPath,
-		})
+		}
+		if runtimeName == runtimeclient.ContainerdName {
+			runtimeConfig.Namespaces = operatorParams.Get(ContainerdNamespaces).AsStringSlice()
+		}
+		rc = append(rc, runtimeConfig)
 	}
 
 	l.rc = rc
@@ -196,6 +219,34 @@ func (l *LocalManager) Close() error {
 	return nil
 }
 
+// LookupContainerByMntns returns the container with the given mount
+// namespace, for the operators needing more than the container info of the
+// events
+func (l *LocalManager) LookupContainerByMntns(mntnsid uint64) *containercollection.Container {
+	if l.igManager == nil {
+		return nil
+	}
+	return l.igManager.ContainerCollection.LookupContainerByMntns(mntnsid)
+}
+
+func containerSelector(params *params.Params) containercollection.ContainerSelector {
+	// TODO: Improve filtering, see further details in
+	// https://github.com/inspektor-gadget/inspektor-gadget/issues/644.
+	return containercollection.ContainerSelector{
+		Name: params.Get(ContainerName).AsString(),
+	}
+}
+
+// CountContainers returns the number of the running containers a gadget
+// would trace with the given params
+func (l *LocalManager) CountContainers(params *params.Params) (int, error) {
+	if l.igManager == nil {
+		return 0, errors.New("local manager not initialized")
+	}
+	selector := containerSelector(params)
+	return len(l.igManager.ContainerCollection.GetContainersBySelector(&selector)), nil
+}
+
 func (l *LocalManager) Instantiate(gadgetContext operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
 	_, canEnrichEventFromMountNs := gadgetContext.GadgetDesc().EventPrototype().(operators.ContainerInfoFromMountNSID)
 	_, canEnrichEventFromNetNs := gadgetContext.GadgetDesc().EventPrototype().(operators.ContainerInfoFromNetNSID)
@@ -210,35 +261,130 @@ func (l *LocalManager) Instantiate(gadgetContext operators.GadgetContext, gadget
 		gadgetCtx:          gadgetContext,
 	}
 
+	// The events of trace gadgets can come before their container is added
+	if delay := params.Get(LateEnrichment).AsDuration(); delay > 0 && canEnrichEventFromMountNs &&
+		gadgetContext.GadgetDesc().Type() == gadgets.TypeTrace && l.igManager != nil {
+		traceInstance.lateEnrichment = l.igManager.ContainerCollection.NewLateEnrichment()
+		traceInstance.lateEnrichmentDelay = delay
+	}
+
 	return traceInstance, nil
 }
 
 type localManagerTrace struct {
 	manager         *LocalManager
 	mountnsmap      *ebpf.Map
+	quota           atomic.Pointer[quota.Quota]
+	emit            quota.EmitFunc
 	enrichEvents    bool
 	subscriptionKey string
 
 	// Keep a map to attached containers, so we can clean up properly
+	mu                 sync.Mutex
 	attachedContainers map[*containercollection.Container]struct{}
 	attacher           Attacher
+	guest              *kataguest.Guest
 	params             *params.Params
 	gadgetInstance     any
 	gadgetCtx          operators.GadgetContext
+
+	lateEnrichment      *containercollection.LateEnrichment
+	lateEnrichmentDelay time.Duration
 }
 
 func (l *localManagerTrace) Name() string {
 	return OperatorInstanceName
 }
 
-func (l *localManagerTrace) PreGadgetRun() error {
+func (l *localManagerTrace) containerSelector() containercollection.ContainerSelector {
+	return containerSelector(l.params)
+}
+
+func (l *localManagerTrace) attachContainer(container *containercollection.Container) {
 	log := l.gadgetCtx.Logger()
 
-	// TODO: Improve filtering, see further details in
-	// https://github.com/inspektor-gadget/inspektor-gadget/issues/644.
-	containerSelector := containercollection.ContainerSelector{
-		Name: l.params.Get(ContainerName).AsString(),
+	log.Debugf("calling gadget.AttachContainer()")
+	err := l.attacher.AttachContainer(container)
+	if err != nil {
+		var ve *ebpf.VerifierError
+		if errors.As(err, &ve) {
+			l.gadgetCtx.Logger().Debugf("start tracing container %q: verifier error: %+v\n", container.Name, ve)
+		}
+
+		log.Warnf("start tracing container %q: %s", container.Name, err)
+		return
+	}
+
+	l.attachedContainers[container] = struct{}{}
+
+	log.Debugf("tracer attached: container %q pid %d mntns %d netns %d",
+		container.Name, container.Pid, container.Mntns, container.Netns)
+}
+
+func (l *localManagerTrace) detachContainer(container *containercollection.Container) {
+	log := l.gadgetCtx.Logger()
+
+	log.Debugf("calling gadget.DetachContainer()")
+	delete(l.attachedContainers, container)
+
+	err := l.attacher.DetachContainer(container)
+	if err != nil {
+		log.Warnf("stop tracing container %q: %s", container.Name, err)
+		return
 	}
+	log.Debugf("tracer detached: container %q pid %d mntns %d netns %d",
+		container.Name, container.Pid, container.Mntns, container.Netns)
+}
+
+// addContainer attaches the gadget to the container and runs it inside its
+// Kata guest, depending on what's enabled
+func (l *localManagerTrace) addContainer(container *containercollection.Container) {
+	if l.attacher != nil {
+		l.attachContainer(container)
+	}
+	if l.guest != nil {
+		l.guest.Add(container)
+	}
+}
+
+func (l *localManagerTrace) removeContainer(container *containercollection.Container) {
+	if l.attacher != nil {
+		l.detachContainer(container)
+	}
+	if l.guest != nil {
+		l.guest.Remove(container)
+	}
+}
+
+func (l *localManagerTrace) handleContainerEvent(event containercollection.PubSubEvent) {
+	l.gadgetCtx.Logger().Debugf("%s: %s", event.Type.String(), event.Container.ID)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch event.Type {
+	case containercollection.EventTypeAddContainer:
+		l.addContainer(event.Container)
+	case containercollection.EventTypeRemoveContainer:
+		l.removeContainer(event.Container)
+	}
+}
+
+func (l *localManagerTrace) newQuota() *quota.Quota {
+	return quota.New(l.params, l.mountnsmap, l.manager.igManager.ContainerCollection.LookupContainerByMntns,
+		l.manager.igManager.MountNsMapSelects, l.gadgetCtx.Logger(), l.emit)
+}
+
+// SetEventEmitter is used to notify the exclusions of the quota and to emit
+// the events of the Kata guests
+func (l *localManagerTrace) SetEventEmitter(emit func(init func(ev any))) {
+	l.emit = emit
+}
+
+func (l *localManagerTrace) PreGadgetRun() error {
+	log := l.gadgetCtx.Logger()
+
+	containerSelector := l.containerSelector()
 
 	if setter, ok := l.gadgetInstance.(MountNsMapSetter); ok {
 		// Create mount namespace map to filter by containers
@@ -251,61 +397,87 @@ func (l *localManagerTrace) PreGadgetRun() error {
 		setter.SetMountNsMap(mountnsmap)
 
 		l.mountnsmap = mountnsmap
+
+		l.quota.Store(l.newQuota())
 	}
 
 	if attacher, ok := l.gadgetInstance.(Attacher); ok {
 		l.attacher = attacher
+	}
+	l.guest = kataguest.New(l.params, l.gadgetCtx, l.emit)
 
-		attachContainerFunc := func(container *containercollection.Container) {
-			log.Debugf("calling gadget.AttachContainer()")
-			err := attacher.AttachContainer(container)
-			if err != nil {
-				var ve *ebpf.VerifierError
-				if errors.As(err, &ve) {
-					l.gadgetCtx.Logger().Debugf("start tracing container %q: verifier error: %+v\n", container.Name, ve)
-				}
-
-				log.Warnf("start tracing container %q: %s", container.Name, err)
-				return
-			}
+	if l.attacher != nil || l.guest != nil {
+		id := uuid.New()
+		l.subscriptionKey = id.String()
 
-			l.attachedContainers[container] = struct{}{}
+		log.Debugf("add subscription")
+		containers := l.manager.igManager.Subscribe(
+			l.subscriptionKey,
+			containerSelector,
+			l.handleContainerEvent,
+		)
 
-			log.Debugf("tracer attached: container %q pid %d mntns %d netns %d",
-				container.Name, container.Pid, container.Mntns, container.Netns)
+		l.mu.Lock()
+		for _, container := range containers {
+			l.addContainer(container)
 		}
+		l.mu.Unlock()
+	}
 
-		detachContainerFunc := func(container *containercollection.Container) {
-			log.Debugf("calling gadget.DetachContainer()")
-			err := attacher.DetachContainer(container)
-			if err != nil {
-				log.Warnf("stop tracing container %q: %s", container.Name, err)
-				return
-			}
-			log.Debugf("tracer detached: container %q pid %d mntns %d netns %d",
-				container.Name, container.Pid, container.Mntns, container.Netns)
+	return nil
+}
+
+// UpdateParams changes the containers traced by the running gadget: the mount
+// namespace map given to the gadget is updated in place and the gadget is
+// attached to the new containers and detached from the ones not matching
+// anymore.
+func (l *localManagerTrace) UpdateParams(params *params.Params) error {
+	if kataguest.Enabled(params) != kataguest.Enabled(l.params) {
+		return fmt.Errorf("%q can't be changed while the gadget is running", kataguest.ParamKataGuest)
+	}
+
+	l.params = params
+	containerSelector := l.containerSelector()
+
+	if l.mountnsmap != nil {
+		// The containers excluded by the quota are added back to the map, so
+		// start again with a new quota
+		if q := l.quota.Swap(nil); q != nil {
+			q.Stop()
 		}
+		if err := l.manager.igManager.UpdateMountNsMap(containerSelector); err != nil {
+			return commonutils.WrapInErrManagerCreateMountNsMap(err)
+		}
+		l.quota.Store(l.newQuota())
+	}
 
-		id := uuid.New()
-		l.subscriptionKey = id.String()
+	if l.subscriptionKey != "" {
+		l.mu.Lock()
+		defer l.mu.Unlock()
 
-		log.Debugf("add subscription")
+		// Subscribing again with the same key replaces the subscription
 		containers := l.manager.igManager.Subscribe(
 			l.subscriptionKey,
 			containerSelector,
-			func(event containercollection.PubSubEvent) {
-				log.Debugf("%s: %s", event.Type.String(), event.Container.ID)
-				switch event.Type {
-				case containercollection.EventTypeAddContainer:
-					attachContainerFunc(event.Container)
-				case containercollection.EventTypeRemoveContainer:
-					detachContainerFunc(event.Container)
-				}
-			},
+			l.handleContainerEvent,
 		)
 
-		for _, container := range containers {
-			attachContainerFunc(container)
+		if l.attacher != nil {
+			matching := make(map[*containercollection.Container]struct{}, len(containers))
+			for _, container := range containers {
+				matching[container] = struct{}{}
+				if _, ok := l.attachedContainers[container]; !ok {
+					l.attachContainer(container)
+				}
+			}
+			for container := range l.attachedContainers {
+				if _, ok := matching[container]; !ok {
+					l.detachContainer(container)
+				}
+			}
+		}
+		if l.guest != nil {
+			l.guest.Update(containers)
 		}
 	}
 
@@ -313,6 +485,9 @@ func (l *localManagerTrace) PreGadgetRun() error {
 }
 
 func (l *localManagerTrace) PostGadgetRun() error {
+	if q := l.quota.Load(); q != nil {
+		q.Stop()
+	}
 	if l.mountnsmap != nil {
 		log.Debugf("calling RemoveMountNsMap()")
 		l.manager.igManager.RemoveMountNsMap()
@@ -321,10 +496,16 @@ func (l *localManagerTrace) PostGadgetRun() error {
 		log.Debugf("calling Unsubscribe()")
 		l.manager.igManager.Unsubscribe(l.subscriptionKey)
 
+		if l.guest != nil {
+			l.guest.Stop()
+		}
+
 		// emit detach for all remaining containers
+		l.mu.Lock()
 		for container := range l.attachedContainers {
 			l.attacher.DetachContainer(container)
 		}
+		l.mu.Unlock()
 	}
 	return nil
 }
@@ -338,7 +519,22 @@ func (l *localManagerTrace) enrich(ev any) {
 	}
 }
 
+func (l *localManagerTrace) DeferEvent(ev any) time.Duration {
+	if l.lateEnrichment == nil {
+		return 0
+	}
+	if event, ok := ev.(operators.ContainerInfoFromMountNSID); ok && l.lateEnrichment.Defer(event, l.lateEnrichmentDelay) {
+		return l.lateEnrichmentDelay
+	}
+	return 0
+}
+
 func (l *localManagerTrace) EnrichEvent(ev any) error {
+	if q := l.quota.Load(); q != nil {
+		if event, ok := ev.(operators.ContainerInfoFromMountNSID); ok {
+			q.Count(event.GetMountNSID())
+		}
+	}
 	if !l.enrichEvents {
 		return nil
 	}
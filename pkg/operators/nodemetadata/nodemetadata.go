@@ -0,0 +1,209 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nodemetadata provides an operator adding the zone and region of the
+// node and its kernel version to the events, so the events gathered from many
+// nodes in a central place can be told apart without joining them with the
+// nodes.
+package nodemetadata
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+)
+
+const (
+	OperatorName = "NodeMetadata"
+
+	ParamNodeMetadata = "node-metadata"
+
+	// Well-known labels of the nodes set by the cloud providers, the
+	// deprecated ones are still set by some of them
+	labelZone             = "topology.kubernetes.io/zone"
+	labelRegion           = "topology.kubernetes.io/region"
+	labelDeprecatedZone   = "failure-domain.beta.kubernetes.io/zone"
+	labelDeprecatedRegion = "failure-domain.beta.kubernetes.io/region"
+
+	nodeTimeout = 10 * time.Second
+)
+
+// NodeMetadataSetter is implemented by the events that can be annotated with
+// the metadata of the node they come from
+type NodeMetadataSetter interface {
+	SetNodeMetadata(zone, region, kernelVersion string)
+}
+
+type nodeMetadata struct {
+	zone          string
+	region        string
+	kernelVersion string
+}
+
+type NodeMetadata struct {
+	once     sync.Once
+	metadata nodeMetadata
+}
+
+func (n *NodeMetadata) Name() string {
+	return OperatorName
+}
+
+func (n *NodeMetadata) Description() string {
+	return "NodeMetadata adds the zone and region of the node and its kernel version to the events"
+}
+
+func (n *NodeMetadata) GlobalParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (n *NodeMetadata) ParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:          ParamNodeMetadata,
+			Title:        "Node Metadata",
+			Description:  "Add the zone and region of the node, on Kubernetes, and its kernel version to the events",
+			TypeHint:     params.TypeBool,
+			DefaultValue: "false",
+		},
+	}
+}
+
+func (n *NodeMetadata) Dependencies() []string {
+	return nil
+}
+
+func (n *NodeMetadata) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	_, hasNodeMetadataInf := gadget.EventPrototype().(NodeMetadataSetter)
+	return hasNodeMetadataInf
+}
+
+func (n *NodeMetadata) IsUnprivilegedEnricher() {}
+
+func (n *NodeMetadata) Init(params *params.Params) error {
+	return nil
+}
+
+func (n *NodeMetadata) Close() error {
+	return nil
+}
+
+// load gets the metadata of the node the first time it's needed, the zone and
+// region are the labels of the Kubernetes node of the gadget pods
+func (n *NodeMetadata) load() nodeMetadata {
+	n.once.Do(func() {
+		var uname unix.Utsname
+		if err := unix.Uname(&uname); err == nil {
+			n.metadata.kernelVersion = unix.ByteSliceToString(uname.Release[:])
+		}
+
+		nodeName := os.Getenv("NODE_NAME")
+		if nodeName == "" {
+			return
+		}
+		config, err := rest.InClusterConfig()
+		if err != nil {
+			log.Warnf("node metadata: getting in-cluster config: %s", err)
+			return
+		}
+		labels, err := nodeLabels(config, nodeName)
+		if err != nil {
+			log.Warnf("node metadata: getting labels of node %q: %s", nodeName, err)
+			return
+		}
+		n.metadata.zone, n.metadata.region = zoneAndRegion(labels)
+	})
+	return n.metadata
+}
+
+// zoneAndRegion returns the zone and region of a node given its labels,
+// falling back to the deprecated labels
+func zoneAndRegion(labels map[string]string) (string, string) {
+	zone := labels[labelZone]
+	if zone == "" {
+		zone = labels[labelDeprecatedZone]
+	}
+	region := labels[labelRegion]
+	if region == "" {
+		region = labels[labelDeprecatedRegion]
+	}
+	return zone, region
+}
+
+func nodeLabels(config *rest.Config, nodeName string) (map[string]string, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), nodeTimeout)
+	defer cancel()
+	node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return node.Labels, nil
+}
+
+func (n *NodeMetadata) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	instance := &NodeMetadataInstance{
+		enabled: params.Get(ParamNodeMetadata).AsBool(),
+	}
+	if instance.enabled {
+		instance.metadata = n.load()
+	}
+	return instance, nil
+}
+
+type NodeMetadataInstance struct {
+	enabled  bool
+	metadata nodeMetadata
+}
+
+func (i *NodeMetadataInstance) Name() string {
+	return "NodeMetadataInstance"
+}
+
+func (i *NodeMetadataInstance) PreGadgetRun() error {
+	return nil
+}
+
+func (i *NodeMetadataInstance) PostGadgetRun() error {
+	return nil
+}
+
+func (i *NodeMetadataInstance) EnrichEvent(ev any) error {
+	if !i.enabled {
+		return nil
+	}
+	if event, ok := ev.(NodeMetadataSetter); ok {
+		event.SetNodeMetadata(i.metadata.zone, i.metadata.region, i.metadata.kernelVersion)
+	}
+	return nil
+}
+
+func init() {
+	operators.Register(&NodeMetadata{})
+}
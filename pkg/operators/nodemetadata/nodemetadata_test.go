@@ -0,0 +1,147 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nodemetadata
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/rest"
+
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+func TestZoneAndRegion(t *testing.T) {
+	t.Parallel()
+
+	table := []struct {
+		description string
+		labels      map[string]string
+		zone        string
+		region      string
+	}{
+		{
+			description: "topology",
+			labels: map[string]string{
+				labelZone:             "eu-west-1a",
+				labelRegion:           "eu-west-1",
+				labelDeprecatedZone:   "old-zone",
+				labelDeprecatedRegion: "old-region",
+			},
+			zone:   "eu-west-1a",
+			region: "eu-west-1",
+		},
+		{
+			description: "deprecated",
+			labels: map[string]string{
+				labelDeprecatedZone:   "us-central1-b",
+				labelDeprecatedRegion: "us-central1",
+			},
+			zone:   "us-central1-b",
+			region: "us-central1",
+		},
+		{
+			description: "none",
+			labels:      map[string]string{"kubernetes.io/hostname": "node-1"},
+		},
+		{
+			description: "nil",
+		},
+	}
+
+	for _, entry := range table {
+		entry := entry
+		t.Run(entry.description, func(t *testing.T) {
+			t.Parallel()
+
+			zone, region := zoneAndRegion(entry.labels)
+			require.Equal(t, entry.zone, zone)
+			require.Equal(t, entry.region, region)
+		})
+	}
+}
+
+func TestNodeLabels(t *testing.T) {
+	t.Parallel()
+
+	// fake API server serving a single node
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v1/nodes/node-1" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"kind":"Status","apiVersion":"v1","status":"Failure","reason":"NotFound","code":404}`))
+			return
+		}
+		w.Write([]byte(`{
+  "kind": "Node",
+  "apiVersion": "v1",
+  "metadata": {
+    "name": "node-1",
+    "labels": {
+      "kubernetes.io/hostname": "node-1",
+      "topology.kubernetes.io/zone": "eu-west-1a",
+      "topology.kubernetes.io/region": "eu-west-1"
+    }
+  }
+}`))
+	}))
+	t.Cleanup(server.Close)
+
+	config := &rest.Config{Host: server.URL}
+
+	labels, err := nodeLabels(config, "node-1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"kubernetes.io/hostname":        "node-1",
+		"topology.kubernetes.io/zone":   "eu-west-1a",
+		"topology.kubernetes.io/region": "eu-west-1",
+	}, labels)
+
+	_, err = nodeLabels(config, "node-2")
+	require.Error(t, err)
+}
+
+func TestEnrichEvent(t *testing.T) {
+	t.Parallel()
+
+	metadata := nodeMetadata{zone: "eu-west-1a", region: "eu-west-1", kernelVersion: "6.1.0"}
+
+	ev := &eventtypes.Event{}
+	enabled := &NodeMetadataInstance{enabled: true, metadata: metadata}
+	require.NoError(t, enabled.EnrichEvent(ev))
+	require.Equal(t, "eu-west-1a", ev.NodeZone)
+	require.Equal(t, "eu-west-1", ev.NodeRegion)
+	require.Equal(t, "6.1.0", ev.KernelVersion)
+
+	ev = &eventtypes.Event{}
+	disabled := &NodeMetadataInstance{metadata: metadata}
+	require.NoError(t, disabled.EnrichEvent(ev))
+	require.Equal(t, eventtypes.Event{}, *ev)
+
+	// The events without node metadata are left as they are
+	require.NoError(t, enabled.EnrichEvent(struct{}{}))
+}
+
+func TestLoadWithoutNode(t *testing.T) {
+	t.Setenv("NODE_NAME", "")
+
+	// Outside Kubernetes, only the kernel version is known
+	metadata := (&NodeMetadata{}).load()
+	require.NotEmpty(t, metadata.kernelVersion)
+	require.Empty(t, metadata.zone)
+	require.Empty(t, metadata.region)
+}
@@ -18,12 +18,14 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
 )
 
 type GadgetContext interface {
@@ -84,6 +86,90 @@ type OperatorInstance interface {
 	EnrichEvent(ev any) error
 }
 
+// EventFilter is implemented by operator instances that drop some of the
+// events, FilterEvent returns false for them. Filters are called after all
+// operators enriched the event, so they can use all its fields, and before the
+// classifiers and the sinks.
+type EventFilter interface {
+	FilterEvent(ev any) bool
+}
+
+// EventDeferrer is implemented by operator instances that can't enrich some
+// events yet, e.g. the ones of a container still being added. These events
+// are held for the returned delay before being enriched again, they must not
+// be deferred twice.
+type EventDeferrer interface {
+	DeferEvent(ev any) time.Duration
+}
+
+// EventEmitter is implemented by operator instances that emit events of their
+// own, like warnings about the traced containers. The emit function creates an
+// event of the type of the gadget, gives it to init to fill it, and pushes it
+// downstream like the events of the gadget. It must not be called while
+// enriching an event.
+type EventEmitter interface {
+	SetEventEmitter(emit func(init func(ev any)))
+}
+
+// EventClassifier is implemented by operator instances that compute the
+// severity of the events. Classifiers are called after all operators enriched
+// the event, so they can use all its fields, and before the sinks, which can
+// forward only some severities.
+type EventClassifier interface {
+	ClassifyEvent(ev any)
+}
+
+// EventSink is implemented by operator instances that forward the events
+// somewhere else, e.g. to a log. Sinks are called after all operators enriched
+// and classified the event.
+type EventSink interface {
+	SinkEvent(ev any) error
+}
+
+// ResultSink is implemented by operator instances that forward the results of
+// the gadgets running with a result somewhere else, e.g. to an object storage.
+type ResultSink interface {
+	SinkResult(result []byte) error
+}
+
+// Sink is implemented by the operators whose instances only forward the events
+// or the results of the gadgets somewhere else, without changing them. They
+// can be run in another process than the gadgets, see pkg/pipelineprocess.
+type Sink interface {
+	IsSink()
+}
+
+// UnprivilegedEnricher is implemented by the operators whose instances enrich,
+// filter or classify the events without accessing the node, e.g. with the
+// Kubernetes API. Like the sinks, they can be run in another process than the
+// gadgets, see pkg/pipelineprocess.
+type UnprivilegedEnricher interface {
+	IsUnprivilegedEnricher()
+}
+
+// GadgetConfigurer is implemented by the operators run in another process than
+// the gadgets that still have to configure the gadget instances, e.g. to remove
+// their mount namespace map. ConfigureGadget is called before the gadget runs,
+// in the process running it, with the params of the operator.
+type GadgetConfigurer interface {
+	ConfigureGadget(gadgetCtx GadgetContext, gadgetInstance any, params *params.Params) error
+}
+
+// ParamsUpdater is implemented by operator instances whose params can be
+// changed while the gadget is running. Like for gadgets.ParamsUpdater, the
+// instance gets all its params and must not change anything if it can't apply
+// all of them.
+type ParamsUpdater interface {
+	UpdateParams(params *params.Params) error
+}
+
+// ContainerCounter is implemented by the operators that know the containers
+// a gadget would trace with the given params of the operator, e.g. to show
+// them before running an expensive gadget
+type ContainerCounter interface {
+	CountContainers(params *params.Params) (int, error)
+}
+
 type Operators []Operator
 
 // ContainerInfoFromMountNSID is a typical kubernetes operator interface that adds node, pod, namespace and container
@@ -107,6 +193,43 @@ type NodeSetter interface {
 	SetNode(string)
 }
 
+// SandboxSetter is implemented by the events that can tell the sandbox of the
+// container they come from, e.g. Kata Containers
+type SandboxSetter interface {
+	SetSandbox(string)
+}
+
+// WorkloadSetter is implemented by the events that can tell the workload
+// managing the pod they come from, e.g. a Deployment
+type WorkloadSetter interface {
+	SetWorkload(kind, name string)
+}
+
+// ContainerUidSetter is implemented by the events with the UID of the
+// process, to also give it as seen in the user namespace of the container
+type ContainerUidSetter interface {
+	GetUid() uint32
+	SetContainerUid(uint32)
+}
+
+// ImageInfoSetter is implemented by the events that can tell the registry and
+// the digest of the image of the container they come from
+type ImageInfoSetter interface {
+	SetImageInfo(registry, digest string)
+}
+
+// GenerationSetter is implemented by the events that can tell the restart
+// count and the generation of the container they come from
+type GenerationSetter interface {
+	SetGeneration(restartCount uint32, generation string)
+}
+
+// LabelsSetter is implemented by the events that can tell the labels of the
+// pod they come from
+type LabelsSetter interface {
+	SetLabels(string)
+}
+
 type ContainerInfoGetters interface {
 	GetNode() string
 	GetPod() string
@@ -137,6 +260,15 @@ func GetRaw(name string) Operator {
 	return nil
 }
 
+// Unwrap returns the operator as it was registered, to check which optional
+// interfaces it implements
+func Unwrap(operator Operator) Operator {
+	if wrapper, ok := operator.(*operatorWrapper); ok {
+		return wrapper.Operator
+	}
+	return operator
+}
+
 // Register adds a new operator to the registry
 func Register(operator Operator) {
 	if _, ok := allOperators[operator.Name()]; ok {
@@ -145,6 +277,15 @@ func Register(operator Operator) {
 	allOperators[operator.Name()] = &operatorWrapper{Operator: operator}
 }
 
+// Replace swaps the registered operator having the same name as the given one
+// with it
+func Replace(operator Operator) {
+	if _, ok := allOperators[operator.Name()]; !ok {
+		panic(fmt.Errorf("operator not registered: %q", operator.Name()))
+	}
+	allOperators[operator.Name()] = &operatorWrapper{Operator: operator}
+}
+
 // GlobalParamsCollection returns a collection of params of all registered operators
 func GlobalParamsCollection() params.Collection {
 	pc := make(params.Collection)
@@ -257,14 +398,68 @@ func (oi OperatorInstances) PostGadgetRun() error {
 	return nil
 }
 
-// Enrich an event using all members of the operator collection
+// SetEventEmitter gives the emit function to the operator instances emitting
+// events of their own, see EventEmitter
+func (oi OperatorInstances) SetEventEmitter(emit func(init func(ev any))) {
+	for _, instance := range oi {
+		if emitter, ok := instance.(EventEmitter); ok {
+			emitter.SetEventEmitter(emit)
+		}
+	}
+}
+
+// Enrich an event using all members of the operator collection. It returns
+// parser.ErrDropEvent if one of them filtered the event out, and a
+// parser.DeferEventError if one of them can't enrich it yet.
 func (oi OperatorInstances) Enrich(ev any) error {
+	for _, operator := range oi {
+		if deferrer, ok := operator.(EventDeferrer); ok {
+			if delay := deferrer.DeferEvent(ev); delay > 0 {
+				return &parser.DeferEventError{Delay: delay}
+			}
+		}
+	}
+
 	var err error
 	for _, operator := range oi {
 		if err = operator.EnrichEvent(ev); err != nil {
 			return fmt.Errorf("operator %q failed to enrich event %+v", operator.Name(), ev)
 		}
 	}
+	for _, operator := range oi {
+		if filter, ok := operator.(EventFilter); ok && !filter.FilterEvent(ev) {
+			return parser.ErrDropEvent
+		}
+	}
+	for _, operator := range oi {
+		if classifier, ok := operator.(EventClassifier); ok {
+			classifier.ClassifyEvent(ev)
+		}
+	}
+	for _, operator := range oi {
+		sink, ok := operator.(EventSink)
+		if !ok {
+			continue
+		}
+		if err = sink.SinkEvent(ev); err != nil {
+			return fmt.Errorf("operator %q failed to sink event: %w", operator.Name(), err)
+		}
+	}
+	return nil
+}
+
+// SinkResult passes the result of a gadget to all the sinks of the operator
+// collection
+func (oi OperatorInstances) SinkResult(result []byte) error {
+	for _, operator := range oi {
+		sink, ok := operator.(ResultSink)
+		if !ok {
+			continue
+		}
+		if err := sink.SinkResult(result); err != nil {
+			return fmt.Errorf("operator %q failed to sink result: %w", operator.Name(), err)
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,237 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package otel provides an operator that exports the requests observed by the
+// gadgets, like the DNS queries of trace dns, as OpenTelemetry spans with
+// OTLP/HTTP. The spans join the trace of the request when its traceparent
+// header is known. It's disabled unless an endpoint is configured.
+package otel
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperatorName = "OpenTelemetry"
+
+	ParamEndpoint    = "otel-endpoint"
+	ParamServiceName = "otel-service-name"
+
+	// endpointEnv allows to enable the operator on the deployed gadget pods,
+	// where global params can't be set
+	endpointEnv = "INSPEKTOR_GADGET_OTEL_ENDPOINT"
+
+	// The environment variables of the OpenTelemetry SDKs are supported too:
+	// https://opentelemetry.io/docs/specs/otel/protocol/exporter/
+	otlpEndpointEnv       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	otlpTracesEndpointEnv = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	otlpHeadersEnv        = "OTEL_EXPORTER_OTLP_HEADERS"
+
+	tracesPath = "/v1/traces"
+)
+
+// SpanGetter is implemented by the events of the gadgets observing requests.
+// GetSpan returns nil for the events that aren't a whole request.
+type SpanGetter interface {
+	GetSpan() *eventtypes.Span
+}
+
+type OTel struct {
+	batcher *cloudsink.Batcher
+}
+
+func (o *OTel) Name() string {
+	return OperatorName
+}
+
+func (o *OTel) Description() string {
+	return "OpenTelemetry exports the requests observed by the gadgets as spans"
+}
+
+func (o *OTel) GlobalParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:         ParamEndpoint,
+			Description: "OTLP/HTTP endpoint to export the spans to, e.g. http://otel-collector:4318. Empty disables it",
+		},
+		{
+			Key:          ParamServiceName,
+			Description:  "Name of the service of the exported spans",
+			DefaultValue: "inspektor-gadget",
+		},
+	}
+}
+
+func (o *OTel) ParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (o *OTel) Dependencies() []string {
+	return nil
+}
+
+func (o *OTel) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	_, hasSpans := gadget.EventPrototype().(SpanGetter)
+	return hasSpans
+}
+
+func (o *OTel) IsSink() {}
+
+// getEndpoint returns the URL the spans are posted to
+func getEndpoint(params *params.Params) string {
+	endpoint := params.Get(ParamEndpoint).AsString()
+	if envEndpoint := os.Getenv(endpointEnv); envEndpoint != "" && endpoint == "" {
+		endpoint = envEndpoint
+	}
+	if endpoint == "" {
+		// This one is the full URL already
+		if tracesEndpoint := os.Getenv(otlpTracesEndpointEnv); tracesEndpoint != "" {
+			return tracesEndpoint
+		}
+		endpoint = os.Getenv(otlpEndpointEnv)
+	}
+	if endpoint == "" {
+		return ""
+	}
+	return strings.TrimSuffix(endpoint, "/") + tracesPath
+}
+
+// getHeaders parses the "key1=value1,key2=value2" headers of
+// OTEL_EXPORTER_OTLP_HEADERS, e.g. to authenticate
+func getHeaders() map[string]string {
+	headers := map[string]string{}
+	for _, header := range strings.Split(os.Getenv(otlpHeadersEnv), ",") {
+		key, value, ok := strings.Cut(header, "=")
+		if !ok {
+			continue
+		}
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return headers
+}
+
+func (o *OTel) Init(params *params.Params) error {
+	endpoint := getEndpoint(params)
+	if endpoint == "" {
+		return nil
+	}
+
+	client := newClient(endpoint, getHeaders(), map[string]any{
+		"service.name":  params.Get(ParamServiceName).AsString(),
+		"k8s.node.name": cloudsink.NodeName(),
+	})
+	o.batcher = cloudsink.NewBatcher("OpenTelemetry", limits, cloudsink.DefaultInterval, client.exportSpans)
+
+	log.Infof("exporting spans to %q", endpoint)
+	return nil
+}
+
+func (o *OTel) Close() error {
+	if o.batcher == nil {
+		return nil
+	}
+	o.batcher.Close()
+	return nil
+}
+
+func (o *OTel) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	desc := gadgetCtx.GadgetDesc()
+	return &OTelInstance{
+		batcher: o.batcher,
+		gadget:  fmt.Sprintf("%s/%s", desc.Category(), desc.Name()),
+		runID:   gadgetCtx.ID(),
+	}, nil
+}
+
+// OTelInstance queues the spans of the events of a gadget in the batcher of
+// the operator, which is nil when the operator is disabled
+type OTelInstance struct {
+	batcher *cloudsink.Batcher
+	gadget  string
+	runID   string
+}
+
+func (i *OTelInstance) Name() string {
+	return "OTelInstance"
+}
+
+func (i *OTelInstance) PreGadgetRun() error {
+	return nil
+}
+
+func (i *OTelInstance) PostGadgetRun() error {
+	return nil
+}
+
+func (i *OTelInstance) EnrichEvent(ev any) error {
+	return nil
+}
+
+func (i *OTelInstance) SinkEvent(ev any) error {
+	if i.batcher == nil {
+		return nil
+	}
+	getter, ok := ev.(SpanGetter)
+	if !ok {
+		return nil
+	}
+	s := getter.GetSpan()
+	if s == nil {
+		return nil
+	}
+
+	attributes := map[string]any{
+		"inspektor_gadget.gadget": i.gadget,
+		"inspektor_gadget.run_id": i.runID,
+	}
+	// The span is in the pod the request was observed in
+	if k8s, ok := ev.(operators.ContainerInfoGetters); ok {
+		for key, value := range map[string]string{
+			"k8s.namespace.name": k8s.GetNamespace(),
+			"k8s.pod.name":       k8s.GetPod(),
+			"k8s.container.name": k8s.GetContainer(),
+		} {
+			if value != "" {
+				attributes[key] = value
+			}
+		}
+	}
+
+	data, err := json.Marshal(newSpan(s, attributes))
+	if err != nil {
+		return fmt.Errorf("marshaling span: %w", err)
+	}
+	i.batcher.Add(&cloudsink.Record{
+		Time:    s.End,
+		Gadget:  i.gadget,
+		RunID:   i.runID,
+		Message: data,
+	})
+	return nil
+}
+
+func init() {
+	operators.Register(&OTel{})
+}
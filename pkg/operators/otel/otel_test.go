@@ -0,0 +1,147 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otel
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+func unsetEndpointEnv(t *testing.T) {
+	for _, name := range []string{endpointEnv, otlpEndpointEnv, otlpTracesEndpointEnv, otlpHeadersEnv} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestGetEndpoint(t *testing.T) {
+	table := []struct {
+		description string
+		param       string
+		env         map[string]string
+		expected    string
+	}{
+		{
+			description: "disabled",
+		},
+		{
+			description: "param",
+			param:       "http://collector:4318/",
+			env:         map[string]string{endpointEnv: "http://env:4318"},
+			expected:    "http://collector:4318/v1/traces",
+		},
+		{
+			description: "env",
+			env:         map[string]string{endpointEnv: "http://env:4318", otlpEndpointEnv: "http://otlp:4318"},
+			expected:    "http://env:4318/v1/traces",
+		},
+		{
+			description: "otlp_traces_env",
+			env:         map[string]string{otlpTracesEndpointEnv: "http://otlp:4318/custom", otlpEndpointEnv: "http://otlp:4318"},
+			expected:    "http://otlp:4318/custom",
+		},
+		{
+			description: "otlp_env",
+			env:         map[string]string{otlpEndpointEnv: "http://otlp:4318"},
+			expected:    "http://otlp:4318/v1/traces",
+		},
+	}
+
+	for _, entry := range table {
+		entry := entry
+		t.Run(entry.description, func(t *testing.T) {
+			unsetEndpointEnv(t)
+			for k, v := range entry.env {
+				t.Setenv(k, v)
+			}
+
+			params := (&OTel{}).GlobalParamDescs().ToParams()
+			require.NoError(t, params.Set(ParamEndpoint, entry.param))
+			require.Equal(t, entry.expected, getEndpoint(params))
+		})
+	}
+}
+
+func TestGetHeaders(t *testing.T) {
+	unsetEndpointEnv(t)
+	t.Setenv(otlpHeadersEnv, "Authorization=Bearer secret, X-Scope-OrgID = tenant-1,invalid")
+
+	require.Equal(t, map[string]string{
+		"Authorization": "Bearer secret",
+		"X-Scope-OrgID": "tenant-1",
+	}, getHeaders())
+}
+
+type testEvent struct {
+	eventtypes.Event
+	span *eventtypes.Span
+}
+
+func (e *testEvent) GetSpan() *eventtypes.Span {
+	return e.span
+}
+
+func TestOTelInstance(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeCollector{}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	c := newClient(server.URL+tracesPath, nil, nil)
+	batcher := cloudsink.NewBatcher("OpenTelemetry", limits, time.Hour, c.exportSpans)
+	i := &OTelInstance{
+		batcher: batcher,
+		gadget:  "trace/dns",
+		runID:   "run-1",
+	}
+
+	ev := &testEvent{
+		Event: eventtypes.Event{
+			CommonData: eventtypes.CommonData{
+				Namespace: "ns",
+				Pod:       "pod",
+			},
+		},
+		span: &eventtypes.Span{Name: "A example.com", Kind: eventtypes.SpanKindClient},
+	}
+	require.NoError(t, i.SinkEvent(ev))
+	// Events without span, like the DNS queries without response, are skipped
+	require.NoError(t, i.SinkEvent(&testEvent{}))
+	require.NoError(t, i.SinkEvent(&eventtypes.Event{}))
+	batcher.Close()
+
+	spans := f.spans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "A example.com", spans[0].Name)
+	require.Equal(t, []keyValue{
+		{Key: "inspektor_gadget.gadget", Value: stringValue("trace/dns")},
+		{Key: "inspektor_gadget.run_id", Value: stringValue("run-1")},
+		{Key: "k8s.namespace.name", Value: stringValue("ns")},
+		{Key: "k8s.pod.name", Value: stringValue("pod")},
+	}, spans[0].Attributes)
+}
+
+func TestOTelInstanceDisabled(t *testing.T) {
+	t.Parallel()
+
+	i := &OTelInstance{}
+	require.NoError(t, i.SinkEvent(&testEvent{span: &eventtypes.Span{Name: "A example.com"}}))
+}
@@ -0,0 +1,229 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otel
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// limits keep the requests small enough for the default limits of the
+// OpenTelemetry Collector
+var limits = cloudsink.Limits{
+	MaxRecords:     512,
+	MaxBytes:       4 << 20,
+	RecordOverhead: 1,
+}
+
+const (
+	statusCodeError = 2
+
+	scopeName = "github.com/inspektor-gadget/inspektor-gadget"
+)
+
+// The spans are encoded with the JSON encoding of OTLP:
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	// IntValue is a string as the 64-bit integers of protobuf in JSON
+	IntValue  *string `json:"intValue,omitempty"`
+	BoolValue *bool   `json:"boolValue,omitempty"`
+}
+
+type status struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type span struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            *status    `json:"status,omitempty"`
+}
+
+func toAnyValue(v any) (anyValue, bool) {
+	var s string
+	switch v := v.(type) {
+	case string:
+		return anyValue{StringValue: &v}, true
+	case bool:
+		return anyValue{BoolValue: &v}, true
+	case int:
+		s = strconv.FormatInt(int64(v), 10)
+	case int32:
+		s = strconv.FormatInt(int64(v), 10)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case uint16:
+		s = strconv.FormatUint(uint64(v), 10)
+	case uint32:
+		s = strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		s = strconv.FormatUint(v, 10)
+	default:
+		return anyValue{}, false
+	}
+	return anyValue{IntValue: &s}, true
+}
+
+func toAttributes(attributes map[string]any) []keyValue {
+	kvs := make([]keyValue, 0, len(attributes))
+	for k, v := range attributes {
+		value, ok := toAnyValue(v)
+		if !ok {
+			continue
+		}
+		kvs = append(kvs, keyValue{Key: k, Value: value})
+	}
+	sort.Slice(kvs, func(i, j int) bool { return kvs[i].Key < kvs[j].Key })
+	return kvs
+}
+
+func randomID(n int) string {
+	id := make([]byte, n)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+func isHexID(s string, n int) bool {
+	if len(s) != 2*n || strings.Trim(s, "0") == "" {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTraceParent returns the trace ID and the parent span ID of a W3C
+// traceparent header: https://www.w3.org/TR/trace-context/#traceparent-header
+func parseTraceParent(traceParent string) (string, string, bool) {
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	// Later versions can add fields
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return "", "", false
+	}
+	if !isHexID(parts[1], 16) || !isHexID(parts[2], 8) {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+func newSpan(s *eventtypes.Span, attributes map[string]any) *span {
+	out := &span{
+		SpanID:            randomID(8),
+		Name:              s.Name,
+		Kind:              int(s.Kind),
+		StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+	}
+	if traceID, parentID, ok := parseTraceParent(s.TraceParent); ok {
+		out.TraceID = traceID
+		out.ParentSpanID = parentID
+	} else {
+		out.TraceID = randomID(16)
+	}
+
+	all := make(map[string]any, len(s.Attributes)+len(attributes))
+	for k, v := range s.Attributes {
+		all[k] = v
+	}
+	for k, v := range attributes {
+		all[k] = v
+	}
+	out.Attributes = toAttributes(all)
+
+	if s.Error != "" {
+		out.Status = &status{Code: statusCodeError, Message: s.Error}
+	}
+	return out
+}
+
+type client struct {
+	endpoint string
+	headers  map[string]string
+	resource []keyValue
+}
+
+func newClient(endpoint string, headers map[string]string, resource map[string]any) *client {
+	return &client{
+		endpoint: endpoint,
+		headers:  headers,
+		resource: toAttributes(resource),
+	}
+}
+
+// exportSpans sends the spans, encoded in the messages of the records, with
+// OTLP/HTTP
+func (c *client) exportSpans(ctx context.Context, records []*cloudsink.Record) error {
+	spans := make([]json.RawMessage, 0, len(records))
+	for _, r := range records {
+		spans = append(spans, r.Message)
+	}
+
+	request := map[string]any{
+		"resourceSpans": []any{
+			map[string]any{
+				"resource": map[string]any{"attributes": c.resource},
+				"scopeSpans": []any{
+					map[string]any{
+						"scope": map[string]any{"name": scopeName},
+						"spans": spans,
+					},
+				},
+			},
+		},
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("marshaling request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+
+	return cloudsink.Do(req, nil)
+}
@@ -0,0 +1,278 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otel
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type exportRequest struct {
+	ResourceSpans []struct {
+		Resource struct {
+			Attributes []keyValue `json:"attributes"`
+		} `json:"resource"`
+		ScopeSpans []struct {
+			Scope struct {
+				Name string `json:"name"`
+			} `json:"scope"`
+			Spans []span `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+// fakeCollector implements the traces endpoint of OTLP/HTTP
+type fakeCollector struct {
+	mu       sync.Mutex
+	requests []exportRequest
+	headers  []http.Header
+}
+
+func (f *fakeCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.URL.Path != tracesPath || r.Header.Get("Content-Type") != "application/json" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	var req exportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	f.requests = append(f.requests, req)
+	f.headers = append(f.headers, r.Header)
+	w.Write([]byte("{}"))
+}
+
+func (f *fakeCollector) spans() []span {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var spans []span
+	for _, req := range f.requests {
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}
+	return spans
+}
+
+func stringValue(s string) anyValue {
+	return anyValue{StringValue: &s}
+}
+
+func intValue(s string) anyValue {
+	return anyValue{IntValue: &s}
+}
+
+func boolValue(b bool) anyValue {
+	return anyValue{BoolValue: &b}
+}
+
+func TestParseTraceParent(t *testing.T) {
+	t.Parallel()
+
+	table := []struct {
+		description string
+		traceParent string
+		traceID     string
+		parentID    string
+		ok          bool
+	}{
+		{
+			description: "valid",
+			traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			traceID:     "4bf92f3577b34da6a3ce929d0e0e4736",
+			parentID:    "00f067aa0ba902b7",
+			ok:          true,
+		},
+		{
+			description: "future_version_with_more_fields",
+			traceParent: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+			traceID:     "4bf92f3577b34da6a3ce929d0e0e4736",
+			parentID:    "00f067aa0ba902b7",
+			ok:          true,
+		},
+		{
+			description: "version_00_with_more_fields",
+			traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		},
+		{
+			description: "invalid_version",
+			traceParent: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		},
+		{
+			description: "zero_trace_id",
+			traceParent: "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		},
+		{
+			description: "zero_parent_id",
+			traceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		},
+		{
+			description: "uppercase",
+			traceParent: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00F067AA0BA902B7-01",
+		},
+		{
+			description: "short_trace_id",
+			traceParent: "00-4bf92f3577b34da6-00f067aa0ba902b7-01",
+		},
+		{
+			description: "empty",
+			traceParent: "",
+		},
+	}
+
+	for _, entry := range table {
+		entry := entry
+		t.Run(entry.description, func(t *testing.T) {
+			t.Parallel()
+
+			traceID, parentID, ok := parseTraceParent(entry.traceParent)
+			require.Equal(t, entry.ok, ok)
+			require.Equal(t, entry.traceID, traceID)
+			require.Equal(t, entry.parentID, parentID)
+		})
+	}
+}
+
+func TestToAttributes(t *testing.T) {
+	t.Parallel()
+
+	attributes := toAttributes(map[string]any{
+		"net.peer.name":  "example.com",
+		"net.peer.port":  uint16(53),
+		"dns.rcode":      int32(-1),
+		"dns.truncated":  false,
+		"dns.latency_ns": uint64(18446744073709551615),
+		"ignored":        1.5,
+	})
+	require.Equal(t, []keyValue{
+		{Key: "dns.latency_ns", Value: intValue("18446744073709551615")},
+		{Key: "dns.rcode", Value: intValue("-1")},
+		{Key: "dns.truncated", Value: boolValue(false)},
+		{Key: "net.peer.name", Value: stringValue("example.com")},
+		{Key: "net.peer.port", Value: intValue("53")},
+	}, attributes)
+}
+
+func TestNewSpan(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(1700000000, 0)
+	s := &eventtypes.Span{
+		Name:        "GET /",
+		Kind:        eventtypes.SpanKindServer,
+		Start:       start,
+		End:         start.Add(time.Millisecond),
+		TraceParent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		Attributes:  map[string]any{"http.status_code": 500, "http.method": "GET"},
+		Error:       "Internal Server Error",
+	}
+	out := newSpan(s, map[string]any{"k8s.pod.name": "pod", "http.method": "overridden"})
+
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", out.TraceID)
+	require.Equal(t, "00f067aa0ba902b7", out.ParentSpanID)
+	require.Len(t, out.SpanID, 16)
+	require.Equal(t, "GET /", out.Name)
+	require.Equal(t, 2, out.Kind)
+	require.Equal(t, "1700000000000000000", out.StartTimeUnixNano)
+	require.Equal(t, "1700000000001000000", out.EndTimeUnixNano)
+	require.Equal(t, []keyValue{
+		{Key: "http.method", Value: stringValue("overridden")},
+		{Key: "http.status_code", Value: intValue("500")},
+		{Key: "k8s.pod.name", Value: stringValue("pod")},
+	}, out.Attributes)
+	require.Equal(t, &status{Code: statusCodeError, Message: "Internal Server Error"}, out.Status)
+}
+
+func TestNewSpanWithoutTraceParent(t *testing.T) {
+	t.Parallel()
+
+	out := newSpan(&eventtypes.Span{Name: "A example.com", Kind: eventtypes.SpanKindClient}, nil)
+
+	// The span starts a new trace
+	require.True(t, isHexID(out.TraceID, 16))
+	require.True(t, isHexID(out.SpanID, 8))
+	require.Empty(t, out.ParentSpanID)
+	require.Nil(t, out.Status)
+}
+
+func TestExportSpans(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeCollector{}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	c := newClient(server.URL+tracesPath, map[string]string{"Authorization": "Bearer secret"}, map[string]any{
+		"service.name":  "inspektor-gadget",
+		"k8s.node.name": "node-1",
+	})
+
+	var records []*cloudsink.Record
+	for _, name := range []string{"A example.com", "AAAA example.com"} {
+		data, err := json.Marshal(newSpan(&eventtypes.Span{Name: name, Kind: eventtypes.SpanKindClient}, nil))
+		require.NoError(t, err)
+		records = append(records, &cloudsink.Record{Message: data})
+	}
+	require.NoError(t, c.exportSpans(context.Background(), records))
+
+	require.Len(t, f.requests, 1)
+	require.Equal(t, "Bearer secret", f.headers[0].Get("Authorization"))
+
+	req := f.requests[0]
+	require.Len(t, req.ResourceSpans, 1)
+	require.Equal(t, []keyValue{
+		{Key: "k8s.node.name", Value: stringValue("node-1")},
+		{Key: "service.name", Value: stringValue("inspektor-gadget")},
+	}, req.ResourceSpans[0].Resource.Attributes)
+	require.Len(t, req.ResourceSpans[0].ScopeSpans, 1)
+	require.Equal(t, scopeName, req.ResourceSpans[0].ScopeSpans[0].Scope.Name)
+
+	spans := f.spans()
+	require.Len(t, spans, 2)
+	require.Equal(t, "A example.com", spans[0].Name)
+	require.Equal(t, "AAAA example.com", spans[1].Name)
+}
+
+func TestExportSpansRejected(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeCollector{}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	c := newClient(server.URL+"/wrong", nil, nil)
+	err := c.exportSpans(context.Background(), []*cloudsink.Record{{Message: []byte("{}")}})
+
+	var httpErr *cloudsink.HTTPError
+	require.ErrorAs(t, err, &httpErr)
+	require.Equal(t, http.StatusNotFound, httpErr.StatusCode)
+}
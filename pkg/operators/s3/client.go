@@ -0,0 +1,98 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/awsauth"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+)
+
+type client struct {
+	// endpoint is the URL the keys, prefixed with the bucket with the path
+	// style, are added to
+	endpoint    string
+	credentials *awsauth.CredentialsProvider
+	signer      *awsauth.Signer
+}
+
+// newClient returns a client for a bucket of Amazon S3, addressed with the
+// virtual-hosted style, or, if an endpoint is given, of an S3-compatible
+// object storage, with the path style most of them require
+func newClient(endpoint, bucket, region string, credentials *awsauth.CredentialsProvider) *client {
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region)
+	} else {
+		endpoint = strings.TrimSuffix(endpoint, "/") + "/" + escapeKey(bucket)
+	}
+
+	return &client{
+		endpoint:    endpoint,
+		credentials: credentials,
+		signer:      &awsauth.Signer{Region: region, Service: "s3"},
+	}
+}
+
+// escapeKey encodes all the characters of a key but the unreserved ones and
+// the slashes, as the signature of S3 expects
+func escapeKey(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// putObject uploads an object with PutObject:
+// https://docs.aws.amazon.com/AmazonS3/latest/API/API_PutObject.html
+func (c *client) putObject(ctx context.Context, key, contentType string, data []byte) error {
+	u, err := url.Parse(c.endpoint + "/" + escapeKey(key))
+	if err != nil {
+		return fmt.Errorf("parsing URL of object: %w", err)
+	}
+
+	credentials, err := c.credentials.Get(ctx)
+	if err != nil {
+		return fmt.Errorf("getting credentials: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	payloadHash := sha256.Sum256(data)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	c.signer.Sign(req, data, credentials)
+
+	if err := cloudsink.Do(req, nil); err != nil {
+		return fmt.Errorf("uploading %q: %w", key, err)
+	}
+	return nil
+}
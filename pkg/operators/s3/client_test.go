@@ -0,0 +1,142 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/awsauth"
+)
+
+type object struct {
+	contentType string
+	data        string
+}
+
+// fakeS3 implements PutObject of an S3-compatible object storage addressed
+// with the path style
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string]object
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	auth := r.Header.Get("Authorization")
+	if r.Method != http.MethodPut ||
+		!strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+		!strings.Contains(auth, "/us-east-1/s3/aws4_request") ||
+		!strings.Contains(auth, "x-amz-content-sha256") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	hash := sha256.Sum256(data)
+	if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(hash[:]) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("<Error><Code>XAmzContentSHA256Mismatch</Code></Error>"))
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.EscapedPath(), "/bucket/") {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("<Error><Code>NoSuchBucket</Code></Error>"))
+		return
+	}
+	f.objects[r.URL.EscapedPath()] = object{
+		contentType: r.Header.Get("Content-Type"),
+		data:        string(data),
+	}
+}
+
+func newTestClient(t *testing.T, f *fakeS3, bucket string) *client {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+
+	credentials, err := awsauth.NewCredentialsProvider("us-east-1")
+	require.NoError(t, err)
+
+	return newClient(server.URL+"/", bucket, "us-east-1", credentials)
+}
+
+func TestEscapeKey(t *testing.T) {
+	t.Parallel()
+
+	table := []struct {
+		key      string
+		expected string
+	}{
+		{"default/node-1/profile/cpu/20230102T030405Z.json", "default/node-1/profile/cpu/20230102T030405Z.json"},
+		{"a b+c", "a%20b%2Bc"},
+		{"run:1=é", "run%3A1%3D%C3%A9"},
+		{"~_.-", "~_.-"},
+	}
+
+	for _, entry := range table {
+		require.Equal(t, entry.expected, escapeKey(entry.key))
+	}
+}
+
+func TestNewClientEndpoint(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, "https://bucket.s3.eu-west-1.amazonaws.com",
+		newClient("", "bucket", "eu-west-1", nil).endpoint)
+	require.Equal(t, "http://minio:9000/bucket",
+		newClient("http://minio:9000/", "bucket", "us-east-1", nil).endpoint)
+}
+
+func TestPutObject(t *testing.T) {
+	f := &fakeS3{objects: map[string]object{}}
+	c := newTestClient(t, f, "bucket")
+
+	err := c.putObject(context.Background(), "default/node 1/profile/cpu.folded", "text/plain", []byte("main;foo 1\n"))
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]object{
+		"/bucket/default/node%201/profile/cpu.folded": {contentType: "text/plain", data: "main;foo 1\n"},
+	}, f.objects)
+}
+
+func TestPutObjectError(t *testing.T) {
+	f := &fakeS3{objects: map[string]object{}}
+	c := newTestClient(t, f, "other")
+
+	err := c.putObject(context.Background(), "key.json", "application/json", []byte("{}"))
+	require.ErrorContains(t, err, `uploading "key.json"`)
+	require.ErrorContains(t, err, "NoSuchBucket")
+	require.Empty(t, f.objects)
+}
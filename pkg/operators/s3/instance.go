@@ -0,0 +1,154 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+)
+
+const uploadTimeout = time.Minute
+
+// S3Instance collects the events of a profile gadget, usually a single report,
+// and uploads them once the gadget is done: as JSON, one event per line, and in
+// each output format of the gadget. The results of the gadgets running with a
+// result are uploaded as they are. Its client is nil when the operator is
+// disabled.
+type S3Instance struct {
+	gadgetCtx operators.GadgetContext
+	client    *client
+	key       string
+	formats   gadgets.OutputFormats
+
+	mu          sync.Mutex
+	events      bytes.Buffer
+	transformed map[string]*bytes.Buffer
+}
+
+func (i *S3Instance) Name() string {
+	return "S3Instance"
+}
+
+func (i *S3Instance) PreGadgetRun() error {
+	return nil
+}
+
+func (i *S3Instance) PostGadgetRun() error {
+	if i.client == nil {
+		return nil
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.events.Len() == 0 {
+		return nil
+	}
+
+	i.upload("json", "application/json", i.events.Bytes())
+
+	// Upload the formats in the same order each time
+	names := make([]string, 0, len(i.transformed))
+	for name := range i.transformed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		i.upload(name, "text/plain", i.transformed[name].Bytes())
+	}
+
+	return nil
+}
+
+func (i *S3Instance) EnrichEvent(ev any) error {
+	return nil
+}
+
+func (i *S3Instance) SinkEvent(ev any) error {
+	if i.client == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("marshaling event: %w", err)
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.events.Write(data)
+	i.events.WriteByte('\n')
+
+	for name, format := range i.formats {
+		// The combined results are built by the client
+		if format.RequiresCombinedResult || format.Transform == nil {
+			continue
+		}
+		out, err := format.Transform(ev)
+		if err != nil {
+			return fmt.Errorf("transforming event to %s: %w", name, err)
+		}
+		if i.transformed == nil {
+			i.transformed = make(map[string]*bytes.Buffer)
+		}
+		buf, ok := i.transformed[name]
+		if !ok {
+			buf = &bytes.Buffer{}
+			i.transformed[name] = buf
+		}
+		buf.Write(out)
+		if len(out) > 0 && out[len(out)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+
+	return nil
+}
+
+func (i *S3Instance) SinkResult(result []byte) error {
+	if i.client == nil || len(result) == 0 {
+		return nil
+	}
+
+	if json.Valid(result) {
+		i.upload("json", "application/json", result)
+	} else {
+		i.upload("txt", "text/plain", result)
+	}
+	return nil
+}
+
+// upload uploads an object, the errors are only logged to not lose the result
+// of the gadget for the user
+func (i *S3Instance) upload(extension, contentType string, data []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+	defer cancel()
+
+	key := i.key + "." + extension
+	if err := i.client.putObject(ctx, key, contentType, data); err != nil {
+		i.gadgetCtx.Logger().Warnf("S3: %s", err)
+		return
+	}
+	i.gadgetCtx.Logger().Debugf("S3: uploaded %q", key)
+}
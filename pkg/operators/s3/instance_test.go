@@ -0,0 +1,184 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package s3
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+)
+
+type testGadgetContext struct{}
+
+func (testGadgetContext) ID() string                     { return "run-1" }
+func (testGadgetContext) Context() context.Context       { return context.Background() }
+func (testGadgetContext) GadgetDesc() gadgets.GadgetDesc { return nil }
+func (testGadgetContext) Logger() logger.Logger          { return logger.DefaultLogger() }
+
+type testReport struct {
+	Comm  string `json:"comm"`
+	Count int    `json:"count"`
+}
+
+func newTestInstance(t *testing.T, f *fakeS3) *S3Instance {
+	return &S3Instance{
+		gadgetCtx: testGadgetContext{},
+		client:    newTestClient(t, f, "bucket"),
+		key:       "default/node-1/profile/cpu/20230102T030405Z",
+		formats: gadgets.OutputFormats{
+			"folded": {
+				Transform: func(ev any) ([]byte, error) {
+					r := ev.(*testReport)
+					return []byte(fmt.Sprintf("%s %d", r.Comm, r.Count)), nil
+				},
+			},
+			"report": {
+				Transform: func(ev any) ([]byte, error) {
+					return []byte(ev.(*testReport).Comm + "\n"), nil
+				},
+			},
+			// The combined results are uploaded with SinkResult
+			"svg": {
+				RequiresCombinedResult: true,
+				Transform: func(ev any) ([]byte, error) {
+					return []byte("<svg/>"), nil
+				},
+			},
+		},
+	}
+}
+
+func TestS3InstanceEvents(t *testing.T) {
+	f := &fakeS3{objects: map[string]object{}}
+	i := newTestInstance(t, f)
+
+	require.NoError(t, i.SinkEvent(&testReport{Comm: "cat", Count: 2}))
+	require.NoError(t, i.SinkEvent(&testReport{Comm: "ls", Count: 1}))
+	require.Empty(t, f.objects, "uploaded before the end of the gadget")
+
+	require.NoError(t, i.PostGadgetRun())
+	require.Equal(t, map[string]object{
+		"/bucket/default/node-1/profile/cpu/20230102T030405Z.json": {
+			contentType: "application/json",
+			data:        "{\"comm\":\"cat\",\"count\":2}\n{\"comm\":\"ls\",\"count\":1}\n",
+		},
+		"/bucket/default/node-1/profile/cpu/20230102T030405Z.folded": {
+			contentType: "text/plain",
+			data:        "cat 2\nls 1\n",
+		},
+		"/bucket/default/node-1/profile/cpu/20230102T030405Z.report": {
+			contentType: "text/plain",
+			data:        "cat\nls\n",
+		},
+	}, f.objects)
+}
+
+func TestS3InstanceNoEvents(t *testing.T) {
+	f := &fakeS3{objects: map[string]object{}}
+	i := newTestInstance(t, f)
+
+	require.NoError(t, i.PostGadgetRun())
+	require.Empty(t, f.objects)
+}
+
+func TestS3InstanceResult(t *testing.T) {
+	table := []struct {
+		description string
+		result      string
+		expected    map[string]object
+	}{
+		{
+			description: "json",
+			result:      `[{"comm":"cat"}]`,
+			expected: map[string]object{
+				"/bucket/default/node-1/profile/cpu/20230102T030405Z.json": {contentType: "application/json", data: `[{"comm":"cat"}]`},
+			},
+		},
+		{
+			description: "text",
+			result:      "COMM  COUNT\ncat   2\n",
+			expected: map[string]object{
+				"/bucket/default/node-1/profile/cpu/20230102T030405Z.txt": {contentType: "text/plain", data: "COMM  COUNT\ncat   2\n"},
+			},
+		},
+		{
+			description: "empty",
+			result:      "",
+			expected:    map[string]object{},
+		},
+	}
+
+	for _, entry := range table {
+		entry := entry
+		t.Run(entry.description, func(t *testing.T) {
+			f := &fakeS3{objects: map[string]object{}}
+			i := newTestInstance(t, f)
+
+			require.NoError(t, i.SinkResult([]byte(entry.result)))
+			require.Equal(t, entry.expected, f.objects)
+		})
+	}
+}
+
+func TestS3InstanceUploadError(t *testing.T) {
+	f := &fakeS3{objects: map[string]object{}}
+	i := newTestInstance(t, f)
+	i.client = newTestClient(t, f, "other")
+
+	// The errors are only logged to not fail the gadget
+	require.NoError(t, i.SinkEvent(&testReport{Comm: "cat"}))
+	require.NoError(t, i.PostGadgetRun())
+	require.Empty(t, f.objects)
+}
+
+func TestS3InstanceDisabled(t *testing.T) {
+	t.Parallel()
+
+	i := &S3Instance{}
+	require.NoError(t, i.SinkEvent(&testReport{Comm: "cat"}))
+	require.NoError(t, i.SinkResult([]byte("{}")))
+	require.NoError(t, i.PostGadgetRun())
+}
+
+func TestKey(t *testing.T) {
+	t.Parallel()
+
+	keyTmpl, err := template.New("key").Option("missingkey=error").
+		Parse("{{.Cluster}}/{{.Node}}/{{.Category}}/{{.Gadget}}/{{.Timestamp}}")
+	require.NoError(t, err)
+	s := &S3{keyTmpl: keyTmpl}
+
+	key, err := s.key(&keyData{
+		Cluster:   "prod",
+		Node:      "node-1",
+		Category:  "profile",
+		Gadget:    "cpu",
+		RunID:     "run-1",
+		Timestamp: "20230102T030405Z",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "prod/node-1/profile/cpu/20230102T030405Z", key)
+
+	s.keyTmpl, err = template.New("key").Option("missingkey=error").Parse("{{.Unknown}}")
+	require.NoError(t, err)
+	_, err = s.key(&keyData{})
+	require.Error(t, err)
+}
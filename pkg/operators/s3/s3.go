@@ -0,0 +1,224 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package s3 provides an operator that uploads the results of the profile
+// gadgets, like the flame graphs of profile off-cpu, to a bucket of Amazon S3
+// or of an S3-compatible object storage, to keep them after an investigation.
+// It's disabled unless a bucket is configured.
+package s3
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/awsauth"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/internal/cloudsink"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+)
+
+const (
+	OperatorName = "S3"
+
+	ParamBucket   = "s3-bucket"
+	ParamEndpoint = "s3-endpoint"
+	ParamRegion   = "s3-region"
+	ParamKey      = "s3-key"
+	ParamCluster  = "s3-cluster"
+
+	// The environment variables allow to enable the operator on the deployed
+	// gadget pods, where global params can't be set
+	bucketEnv   = "INSPEKTOR_GADGET_S3_BUCKET"
+	endpointEnv = "INSPEKTOR_GADGET_S3_ENDPOINT"
+	regionEnv   = "INSPEKTOR_GADGET_S3_REGION"
+	clusterEnv  = "INSPEKTOR_GADGET_S3_CLUSTER"
+
+	// defaultRegion is used by the S3-compatible object storages without
+	// regions
+	defaultRegion = "us-east-1"
+
+	defaultCluster = "default"
+
+	timestampFormat = "20060102T150405Z"
+)
+
+// keyData are the fields the template of the keys can use
+type keyData struct {
+	Cluster   string
+	Node      string
+	Category  string
+	Gadget    string
+	RunID     string
+	Timestamp string
+}
+
+type S3 struct {
+	client  *client
+	keyTmpl *template.Template
+	cluster string
+}
+
+func (s *S3) Name() string {
+	return OperatorName
+}
+
+func (s *S3) Description() string {
+	return "S3 uploads the results of the profile gadgets to an S3-compatible object storage"
+}
+
+func (s *S3) GlobalParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:         ParamBucket,
+			Description: "Bucket to upload the results of the profile gadgets to. Empty disables it",
+		},
+		{
+			Key:         ParamEndpoint,
+			Description: "URL of an S3-compatible object storage, the bucket is then addressed with the path style. Defaults to Amazon S3",
+		},
+		{
+			Key:         ParamRegion,
+			Description: "Region of the bucket. Defaults to the one of the AWS_REGION environment variable, or to " + defaultRegion,
+		},
+		{
+			Key:          ParamKey,
+			Description:  "Template of the keys of the objects, with the fields .Cluster, .Node, .Category, .Gadget, .RunID and .Timestamp. The extension of the format of the result is added",
+			DefaultValue: "{{.Cluster}}/{{.Node}}/{{.Category}}/{{.Gadget}}/{{.Timestamp}}",
+		},
+		{
+			Key:         ParamCluster,
+			Description: "Name of the cluster used in the keys. Defaults to \"" + defaultCluster + "\"",
+		},
+	}
+}
+
+func (s *S3) ParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (s *S3) Dependencies() []string {
+	return nil
+}
+
+func (s *S3) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	return gadget.Type() == gadgets.TypeProfile
+}
+
+func (s *S3) IsSink() {}
+
+// getParam returns the value of a param, or of its environment variable if
+// the param isn't set
+func getParam(params *params.Params, key, env string) string {
+	value := params.Get(key).AsString()
+	if envValue := os.Getenv(env); envValue != "" && value == "" {
+		value = envValue
+	}
+	return value
+}
+
+func (s *S3) Init(params *params.Params) error {
+	bucket := getParam(params, ParamBucket, bucketEnv)
+	if bucket == "" {
+		return nil
+	}
+
+	keyTmpl, err := template.New("key").Option("missingkey=error").Parse(params.Get(ParamKey).AsString())
+	if err != nil {
+		return fmt.Errorf("parsing template of the keys: %w", err)
+	}
+	s.keyTmpl = keyTmpl
+
+	s.cluster = getParam(params, ParamCluster, clusterEnv)
+	if s.cluster == "" {
+		s.cluster = defaultCluster
+	}
+	// Check that the template works before a gadget runs
+	if _, err := s.key(&keyData{}); err != nil {
+		return err
+	}
+
+	endpoint := getParam(params, ParamEndpoint, endpointEnv)
+	region := params.Get(ParamRegion).AsString()
+	for _, env := range []string{regionEnv, "AWS_REGION", "AWS_DEFAULT_REGION"} {
+		if region != "" {
+			break
+		}
+		region = os.Getenv(env)
+	}
+	if region == "" {
+		region = defaultRegion
+	}
+
+	credentials, err := awsauth.NewCredentialsProvider(region)
+	if err != nil {
+		return fmt.Errorf("getting AWS credentials: %w", err)
+	}
+
+	s.client = newClient(endpoint, bucket, region, credentials)
+
+	log.Infof("uploading the results of the profile gadgets to bucket %q", bucket)
+	return nil
+}
+
+// key returns the key of the objects of a gadget run, without extension
+func (s *S3) key(data *keyData) (string, error) {
+	var key strings.Builder
+	if err := s.keyTmpl.Execute(&key, data); err != nil {
+		return "", fmt.Errorf("executing template of the keys: %w", err)
+	}
+	return key.String(), nil
+}
+
+func (s *S3) Close() error {
+	return nil
+}
+
+func (s *S3) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	if s.client == nil {
+		return &S3Instance{}, nil
+	}
+
+	desc := gadgetCtx.GadgetDesc()
+	key, err := s.key(&keyData{
+		Cluster:   s.cluster,
+		Node:      cloudsink.NodeName(),
+		Category:  desc.Category(),
+		Gadget:    desc.Name(),
+		RunID:     gadgetCtx.ID(),
+		Timestamp: time.Now().UTC().Format(timestampFormat),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	instance := &S3Instance{
+		gadgetCtx: gadgetCtx,
+		client:    s.client,
+		key:       key,
+	}
+	if outputFormats, ok := desc.(gadgets.GadgetOutputFormats); ok {
+		instance.formats, _ = outputFormats.OutputFormats()
+	}
+	return instance, nil
+}
+
+func init() {
+	operators.Register(&S3{})
+}
@@ -0,0 +1,187 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"encoding/json"
+	"errors"
+	"path"
+	"strings"
+)
+
+type Package struct {
+	Name    string
+	Version string
+}
+
+// document holds the packages of an SBOM, indexed by the files they contain
+// and by their names
+type document struct {
+	files map[string]Package
+	names map[string]Package
+}
+
+func newDocument() *document {
+	return &document{
+		files: make(map[string]Package),
+		names: make(map[string]Package),
+	}
+}
+
+func (d *document) addPackage(pkg Package, files ...string) {
+	if pkg.Name == "" {
+		return
+	}
+	if _, ok := d.names[pkg.Name]; !ok {
+		d.names[pkg.Name] = pkg
+	}
+	for _, file := range files {
+		if file == "" {
+			continue
+		}
+		d.files[path.Clean("/"+file)] = pkg
+	}
+}
+
+// lookup returns the package the binary at the given path belongs to. The
+// SBOMs don't always list the files of the packages: binaries not found are
+// looked up by name, as standalone binaries are usually named after their
+// package.
+func (d *document) lookup(binary string) (Package, bool) {
+	if pkg, ok := d.files[path.Clean("/"+binary)]; ok {
+		return pkg, true
+	}
+	pkg, ok := d.names[path.Base(binary)]
+	return pkg, ok
+}
+
+type spdxDocument struct {
+	Packages []struct {
+		SPDXID      string   `json:"SPDXID"`
+		Name        string   `json:"name"`
+		VersionInfo string   `json:"versionInfo"`
+		HasFiles    []string `json:"hasFiles"`
+	} `json:"packages"`
+	Files []struct {
+		SPDXID   string `json:"SPDXID"`
+		FileName string `json:"fileName"`
+	} `json:"files"`
+	Relationships []struct {
+		SPDXElementID      string `json:"spdxElementId"`
+		RelationshipType   string `json:"relationshipType"`
+		RelatedSPDXElement string `json:"relatedSpdxElement"`
+	} `json:"relationships"`
+}
+
+type cycloneDXComponent struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Properties []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	} `json:"properties"`
+	Evidence struct {
+		Occurrences []struct {
+			Location string `json:"location"`
+		} `json:"occurrences"`
+	} `json:"evidence"`
+	Components []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXDocument struct {
+	Components []cycloneDXComponent `json:"components"`
+}
+
+// parseDocument parses an SBOM in the SPDX or CycloneDX JSON format
+func parseDocument(data []byte) (*document, error) {
+	var format struct {
+		SPDXVersion string `json:"spdxVersion"`
+		BOMFormat   string `json:"bomFormat"`
+	}
+	if err := json.Unmarshal(data, &format); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case format.SPDXVersion != "":
+		return parseSPDX(data)
+	case format.BOMFormat == "CycloneDX":
+		return parseCycloneDX(data)
+	default:
+		return nil, errors.New("unknown SBOM format, only SPDX and CycloneDX JSON are supported")
+	}
+}
+
+func parseSPDX(data []byte) (*document, error) {
+	var spdx spdxDocument
+	if err := json.Unmarshal(data, &spdx); err != nil {
+		return nil, err
+	}
+
+	fileNames := make(map[string]string, len(spdx.Files))
+	for _, file := range spdx.Files {
+		fileNames[file.SPDXID] = file.FileName
+	}
+
+	// The files of a package are given by hasFiles or by CONTAINS
+	// relationships
+	packageFiles := make(map[string][]string)
+	for _, pkg := range spdx.Packages {
+		for _, id := range pkg.HasFiles {
+			packageFiles[pkg.SPDXID] = append(packageFiles[pkg.SPDXID], fileNames[id])
+		}
+	}
+	for _, rel := range spdx.Relationships {
+		fileName, ok := fileNames[rel.RelatedSPDXElement]
+		if !ok || rel.RelationshipType != "CONTAINS" {
+			continue
+		}
+		packageFiles[rel.SPDXElementID] = append(packageFiles[rel.SPDXElementID], fileName)
+	}
+
+	doc := newDocument()
+	for _, pkg := range spdx.Packages {
+		doc.addPackage(Package{Name: pkg.Name, Version: pkg.VersionInfo}, packageFiles[pkg.SPDXID]...)
+	}
+	return doc, nil
+}
+
+func parseCycloneDX(data []byte) (*document, error) {
+	var cdx cycloneDXDocument
+	if err := json.Unmarshal(data, &cdx); err != nil {
+		return nil, err
+	}
+
+	doc := newDocument()
+	var add func(components []cycloneDXComponent)
+	add = func(components []cycloneDXComponent) {
+		for _, component := range components {
+			var files []string
+			for _, occurrence := range component.Evidence.Occurrences {
+				files = append(files, occurrence.Location)
+			}
+			// Locations given by syft, e.g. the path of a Go binary
+			for _, property := range component.Properties {
+				if strings.HasPrefix(property.Name, "syft:location:") && strings.HasSuffix(property.Name, ":path") {
+					files = append(files, property.Value)
+				}
+			}
+			doc.addPackage(Package{Name: component.Name, Version: component.Version}, files...)
+			add(component.Components)
+		}
+	}
+	add(cdx.Components)
+	return doc, nil
+}
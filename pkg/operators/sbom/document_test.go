@@ -0,0 +1,84 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const spdxSbom = `{
+  "spdxVersion": "SPDX-2.3",
+  "packages": [
+    {"SPDXID": "SPDXRef-Package-busybox", "name": "busybox", "versionInfo": "1.36.1-r2"},
+    {"SPDXID": "SPDXRef-Package-curl", "name": "curl", "versionInfo": "8.4.0-r0", "hasFiles": ["SPDXRef-File-curl"]}
+  ],
+  "files": [
+    {"SPDXID": "SPDXRef-File-busybox", "fileName": "bin/busybox"},
+    {"SPDXID": "SPDXRef-File-curl", "fileName": "/usr/bin/curl"}
+  ],
+  "relationships": [
+    {"spdxElementId": "SPDXRef-Package-busybox", "relationshipType": "CONTAINS", "relatedSpdxElement": "SPDXRef-File-busybox"}
+  ]
+}`
+
+const cycloneDXSbom = `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "components": [
+    {
+      "name": "app", "version": "v1.2.3",
+      "properties": [{"name": "syft:location:0:path", "value": "/usr/local/bin/server"}],
+      "components": [
+        {"name": "helper", "version": "0.1.0", "evidence": {"occurrences": [{"location": "/opt/helper"}]}}
+      ]
+    }
+  ]
+}`
+
+func TestParseDocument(t *testing.T) {
+	table := []struct {
+		description string
+		sbom        string
+		binary      string
+		expected    *Package
+	}{
+		{"SPDX relationship", spdxSbom, "/bin/busybox", &Package{"busybox", "1.36.1-r2"}},
+		{"SPDX hasFiles", spdxSbom, "/usr/bin/curl", &Package{"curl", "8.4.0-r0"}},
+		{"SPDX package name", spdxSbom, "/usr/local/bin/curl", &Package{"curl", "8.4.0-r0"}},
+		{"SPDX unlisted", spdxSbom, "/tmp/miner", nil},
+		{"CycloneDX syft location", cycloneDXSbom, "/usr/local/bin/server", &Package{"app", "v1.2.3"}},
+		{"CycloneDX nested occurrence", cycloneDXSbom, "/opt/helper", &Package{"helper", "0.1.0"}},
+		{"CycloneDX unlisted", cycloneDXSbom, "/bin/sh", nil},
+	}
+	for _, entry := range table {
+		doc, err := parseDocument([]byte(entry.sbom))
+		require.NoError(t, err, entry.description)
+
+		pkg, ok := doc.lookup(entry.binary)
+		if entry.expected == nil {
+			require.False(t, ok, entry.description)
+			continue
+		}
+		require.True(t, ok, entry.description)
+		require.Equal(t, *entry.expected, pkg, entry.description)
+	}
+}
+
+func TestParseDocumentUnknownFormat(t *testing.T) {
+	_, err := parseDocument([]byte(`{"name": "not an SBOM"}`))
+	require.Error(t, err)
+}
@@ -0,0 +1,343 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	dockerHubRegistry = "registry-1.docker.io"
+
+	mediaTypeImageIndex    = "application/vnd.oci.image.index.v1+json"
+	mediaTypeImageManifest = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeDockerList    = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeDockerImage   = "application/vnd.docker.distribution.manifest.v2+json"
+
+	maxManifestSize = 4 << 20
+	maxSbomSize     = 64 << 20
+)
+
+// sbomArtifactTypes are the artifact types of the SBOMs attached to the
+// images, e.g. by `oras attach` or by the SBOM generators
+var sbomArtifactTypes = []string{
+	"application/spdx+json",
+	"application/vnd.cyclonedx+json",
+}
+
+// imageReference is a parsed image reference, like
+// docker.io/library/nginx:latest
+type imageReference struct {
+	registry   string
+	repository string
+	// reference is a tag or a digest
+	reference string
+}
+
+func parseImageReference(image string) (*imageReference, error) {
+	if image == "" {
+		return nil, errors.New("empty image reference")
+	}
+
+	ref := &imageReference{registry: dockerHubRegistry}
+	name := image
+	if domain, rest, ok := strings.Cut(image, "/"); ok &&
+		(strings.ContainsAny(domain, ".:") || domain == "localhost") {
+		ref.registry = domain
+		name = rest
+	}
+	if ref.registry == "docker.io" || ref.registry == "index.docker.io" {
+		ref.registry = dockerHubRegistry
+	}
+
+	if repository, digest, ok := strings.Cut(name, "@"); ok {
+		name, ref.reference = repository, digest
+	} else if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, ref.reference = name[:i], name[i+1:]
+	} else {
+		ref.reference = "latest"
+	}
+	if ref.registry == dockerHubRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	if name == "" || ref.reference == "" {
+		return nil, fmt.Errorf("invalid image reference %q", image)
+	}
+	ref.repository = name
+	return ref, nil
+}
+
+type descriptor struct {
+	MediaType    string `json:"mediaType"`
+	ArtifactType string `json:"artifactType"`
+	Digest       string `json:"digest"`
+	Size         int64  `json:"size"`
+}
+
+type manifest struct {
+	MediaType    string       `json:"mediaType"`
+	ArtifactType string       `json:"artifactType"`
+	Config       descriptor   `json:"config"`
+	Layers       []descriptor `json:"layers"`
+	Manifests    []descriptor `json:"manifests"`
+}
+
+// registryClient fetches the SBOMs attached to the images with the referrers
+// API of the OCI distribution spec. Only anonymous pulls are supported.
+type registryClient struct {
+	client *http.Client
+}
+
+// fetchSbom returns the first SBOM attached to the image
+func (c *registryClient) fetchSbom(ctx context.Context, image string) ([]byte, error) {
+	ref, err := parseImageReference(image)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := ref.reference
+	if !strings.Contains(digest, ":") {
+		digest, err = c.resolve(ctx, ref)
+		if err != nil {
+			return nil, fmt.Errorf("resolving %q: %w", image, err)
+		}
+	}
+
+	referrers, err := c.referrers(ctx, ref, digest)
+	if err != nil {
+		return nil, fmt.Errorf("getting referrers of %q: %w", image, err)
+	}
+	for _, referrer := range referrers.Manifests {
+		if !isSbomArtifactType(referrer.ArtifactType) {
+			continue
+		}
+		var artifact manifest
+		if err := c.getJSON(ctx, ref, "manifests/"+referrer.Digest, referrer.MediaType, &artifact); err != nil {
+			return nil, fmt.Errorf("getting SBOM manifest: %w", err)
+		}
+		if len(artifact.Layers) == 0 {
+			continue
+		}
+		return c.getBlob(ctx, ref, artifact.Layers[0])
+	}
+	return nil, fmt.Errorf("no SBOM attached to %q", image)
+}
+
+func isSbomArtifactType(artifactType string) bool {
+	for _, t := range sbomArtifactTypes {
+		if artifactType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the digest of the manifest the tag of ref points to
+func (c *registryClient) resolve(ctx context.Context, ref *imageReference) (string, error) {
+	req, err := c.newRequest(ctx, http.MethodHead, ref, "manifests/"+ref.reference)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", strings.Join([]string{
+		mediaTypeImageIndex, mediaTypeImageManifest, mediaTypeDockerList, mediaTypeDockerImage,
+	}, ", "))
+	resp, err := c.do(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", errors.New("no digest given by the registry")
+	}
+	return digest, nil
+}
+
+// referrers returns the index of the artifacts referring to the manifest
+// with the given digest. Registries not supporting the referrers API keep the
+// index in a tag named after the digest.
+func (c *registryClient) referrers(ctx context.Context, ref *imageReference, digest string) (*manifest, error) {
+	var index manifest
+	err := c.getJSON(ctx, ref, "referrers/"+digest, mediaTypeImageIndex, &index)
+	if err == nil {
+		return &index, nil
+	}
+	var statusErr *statusError
+	if !errors.As(err, &statusErr) || statusErr.code != http.StatusNotFound {
+		return nil, err
+	}
+	tag := strings.Replace(digest, ":", "-", 1)
+	if err := c.getJSON(ctx, ref, "manifests/"+tag, mediaTypeImageIndex, &index); err != nil {
+		return nil, err
+	}
+	return &index, nil
+}
+
+func (c *registryClient) getJSON(ctx context.Context, ref *imageReference, path, mediaType string, v any) error {
+	req, err := c.newRequest(ctx, http.MethodGet, ref, path)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", mediaType)
+	resp, err := c.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(v)
+}
+
+func (c *registryClient) getBlob(ctx context.Context, ref *imageReference, desc descriptor) ([]byte, error) {
+	algorithm, expected, _ := strings.Cut(desc.Digest, ":")
+	if algorithm != "sha256" {
+		return nil, fmt.Errorf("unsupported digest %q", desc.Digest)
+	}
+	if desc.Size > maxSbomSize {
+		return nil, fmt.Errorf("SBOM too big: %d bytes", desc.Size)
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, ref, "blobs/"+desc.Digest)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSbomSize))
+	if err != nil {
+		return nil, fmt.Errorf("reading SBOM: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != expected {
+		return nil, fmt.Errorf("digest of SBOM doesn't match %q", desc.Digest)
+	}
+	return data, nil
+}
+
+func (c *registryClient) newRequest(ctx context.Context, method string, ref *imageReference, path string) (*http.Request, error) {
+	u := url.URL{
+		Scheme: "https",
+		Host:   ref.registry,
+		Path:   fmt.Sprintf("/v2/%s/%s", ref.repository, path),
+	}
+	return http.NewRequestWithContext(ctx, method, u.String(), nil)
+}
+
+type statusError struct {
+	code int
+	url  string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s: %s", e.url, http.StatusText(e.code))
+}
+
+// do sends the request, getting an anonymous token first if the registry
+// asks for one
+func (c *registryClient) do(req *http.Request) (*http.Response, error) {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		token, err := c.token(req.Context(), resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return nil, fmt.Errorf("getting token: %w", err)
+		}
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err = c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, &statusError{code: resp.StatusCode, url: req.URL.String()}
+	}
+	return resp, nil
+}
+
+// token gets an anonymous token from the authorization service given by
+// challenge, e.g. Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="..."
+func (c *registryClient) token(ctx context.Context, challenge string) (string, error) {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	if !strings.EqualFold(scheme, "Bearer") {
+		return "", fmt.Errorf("unsupported authentication %q", challenge)
+	}
+
+	values := url.Values{}
+	realm := ""
+	for _, param := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+		value = strings.Trim(value, `"`)
+		if key == "realm" {
+			realm = value
+		} else {
+			values.Set(key, value)
+		}
+	}
+	if realm == "" {
+		return "", fmt.Errorf("no realm in %q", challenge)
+	}
+
+	u, err := url.Parse(realm)
+	if err != nil {
+		return "", err
+	}
+	u.RawQuery = values.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", &statusError{code: resp.StatusCode, url: u.String()}
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&token); err != nil {
+		return "", err
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	if token.AccessToken != "" {
+		return token.AccessToken, nil
+	}
+	return "", errors.New("no token given")
+}
@@ -0,0 +1,127 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sbom
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseImageReference(t *testing.T) {
+	table := []struct {
+		image    string
+		expected imageReference
+	}{
+		{"nginx", imageReference{dockerHubRegistry, "library/nginx", "latest"}},
+		{"docker.io/library/nginx:1.25", imageReference{dockerHubRegistry, "library/nginx", "1.25"}},
+		{"ghcr.io/inspektor-gadget/ig:v0.21.0", imageReference{"ghcr.io", "inspektor-gadget/ig", "v0.21.0"}},
+		{"localhost:5000/app@sha256:abcd", imageReference{"localhost:5000", "app", "sha256:abcd"}},
+		{"user/app", imageReference{dockerHubRegistry, "user/app", "latest"}},
+	}
+	for _, entry := range table {
+		ref, err := parseImageReference(entry.image)
+		require.NoError(t, err, entry.image)
+		require.Equal(t, entry.expected, *ref, entry.image)
+	}
+}
+
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// newRegistry returns a registry serving an image with an SBOM attached,
+// asking for a token if withAuth is true and supporting the referrers API if
+// withReferrers is true
+func newRegistry(t *testing.T, sbom []byte, withAuth, withReferrers bool) *httptest.Server {
+	imageDigest := "sha256:" + strings.Repeat("1", 64)
+
+	artifact, err := json.Marshal(manifest{
+		MediaType:    mediaTypeImageManifest,
+		ArtifactType: "application/spdx+json",
+		Layers:       []descriptor{{MediaType: "application/spdx+json", Digest: sha256Digest(sbom), Size: int64(len(sbom))}},
+	})
+	require.NoError(t, err)
+	index, err := json.Marshal(manifest{
+		MediaType: mediaTypeImageIndex,
+		Manifests: []descriptor{
+			{MediaType: mediaTypeImageManifest, ArtifactType: "application/vnd.dev.sigstore.bundle+json", Digest: "sha256:other"},
+			{MediaType: mediaTypeImageManifest, ArtifactType: "application/spdx+json", Digest: sha256Digest(artifact)},
+		},
+	})
+	require.NoError(t, err)
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			require.Equal(t, "repository:app:pull", r.URL.Query().Get("scope"))
+			w.Write([]byte(`{"token": "secret"}`))
+			return
+		}
+		if withAuth && r.Header.Get("Authorization") != "Bearer secret" {
+			w.Header().Set("WWW-Authenticate",
+				fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:app:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v2/app/manifests/v1":
+			w.Header().Set("Docker-Content-Digest", imageDigest)
+		case "/v2/app/referrers/" + imageDigest:
+			if !withReferrers {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(index)
+		case "/v2/app/manifests/" + strings.Replace(imageDigest, ":", "-", 1):
+			w.Write(index)
+		case "/v2/app/manifests/" + sha256Digest(artifact):
+			w.Write(artifact)
+		case "/v2/app/blobs/" + sha256Digest(sbom):
+			w.Write(sbom)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchSbom(t *testing.T) {
+	for _, withAuth := range []bool{false, true} {
+		for _, withReferrers := range []bool{false, true} {
+			server := newRegistry(t, []byte(spdxSbom), withAuth, withReferrers)
+			client := &registryClient{client: server.Client()}
+			host := strings.TrimPrefix(server.URL, "https://")
+
+			data, err := client.fetchSbom(context.Background(), host+"/app:v1")
+			require.NoError(t, err)
+			require.Equal(t, spdxSbom, string(data))
+
+			_, err = client.fetchSbom(context.Background(), host+"/other:v1")
+			require.Error(t, err)
+		}
+	}
+}
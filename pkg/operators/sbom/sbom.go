@@ -0,0 +1,335 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sbom provides an operator that tells whether the binaries executed
+// in the containers are listed in the SBOM of their image, and the package
+// they belong to. The SBOM is given by an annotation of the container or is
+// the one attached to the image in the registry.
+package sbom
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/utils/host"
+)
+
+const (
+	OperatorName = "SBOM"
+
+	ParamSbom = "sbom"
+
+	// SbomAnnotation gives the SBOM of a container, as an http(s) URL or as
+	// a path in the file system of the container. It must be passed by the
+	// runtime to the OCI config of the container.
+	SbomAnnotation = "inspektor-gadget.io/sbom"
+
+	fetchTimeout = time.Minute
+	// SBOMs that couldn't be loaded are tried again after retryDelay
+	retryDelay = 5 * time.Minute
+)
+
+// managers are the operators knowing the containers, one of them is
+// registered in ig and in the gadget pods
+var managers = []string{"LocalManager", "KubeManager"}
+
+// SbomInformation is implemented by the events that can be annotated with the
+// package of the SBOM the executed binary belongs to
+type SbomInformation interface {
+	GetMountNSID() uint64
+	GetPid() uint32
+	SetSbomPackage(status, name, version string)
+}
+
+// argsGetter is implemented by the events giving the arguments of the
+// process, the first one is used when its executable isn't known anymore
+type argsGetter interface {
+	GetArgs() []string
+}
+
+type containerLookuper interface {
+	LookupContainerByMntns(mntnsid uint64) *containercollection.Container
+}
+
+// sbomEntry is an SBOM being loaded or loaded
+type sbomEntry struct {
+	loaded chan struct{}
+	doc    *document
+	err    error
+	time   time.Time
+}
+
+type SBOM struct {
+	registry *registryClient
+
+	mu    sync.Mutex
+	sboms map[string]*sbomEntry
+}
+
+func (s *SBOM) Name() string {
+	return OperatorName
+}
+
+func (s *SBOM) Description() string {
+	return "SBOM tells the packages of the SBOMs of the images the executed binaries belong to"
+}
+
+func (s *SBOM) GlobalParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (s *SBOM) ParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:          ParamSbom,
+			Title:        "SBOM",
+			DefaultValue: "false",
+			Description: "Tell whether the executed binaries are listed in the SBOM of the image of the container, " +
+				"given by the " + SbomAnnotation + " annotation or attached to the image in the registry",
+			TypeHint: params.TypeBool,
+		},
+	}
+}
+
+func (s *SBOM) Dependencies() []string {
+	for _, manager := range managers {
+		if operators.GetRaw(manager) != nil {
+			return []string{manager}
+		}
+	}
+	return nil
+}
+
+func (s *SBOM) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	for _, dependency := range s.Dependencies() {
+		if !operators.GetRaw(dependency).CanOperateOn(gadget) {
+			return false
+		}
+	}
+
+	_, hasSbomInf := gadget.EventPrototype().(SbomInformation)
+	return hasSbomInf
+}
+
+func (s *SBOM) Init(params *params.Params) error {
+	s.registry = &registryClient{client: &http.Client{Timeout: fetchTimeout}}
+	s.sboms = make(map[string]*sbomEntry)
+	return nil
+}
+
+func (s *SBOM) Close() error {
+	return nil
+}
+
+func (s *SBOM) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	instance := &SBOMInstance{
+		operator:  s,
+		gadgetCtx: gadgetCtx,
+		enabled:   params.Get(ParamSbom).AsBool(),
+		// The events of the gadgets only fetching results are all
+		// emitted at once, waiting for the SBOMs is better than missing
+		// them
+		wait: gadgetCtx.GadgetDesc().Type() == gadgets.TypeOneShot,
+	}
+	for _, dependency := range s.Dependencies() {
+		if lookuper, ok := operators.GetRaw(dependency).(containerLookuper); ok {
+			instance.lookuper = lookuper
+		}
+	}
+	if instance.enabled && instance.lookuper == nil {
+		return nil, fmt.Errorf("no operator knowing the containers to get their SBOM")
+	}
+	return instance, nil
+}
+
+// source returns where the SBOM of the container is found, the value of the
+// annotation or the image
+func source(container *containercollection.Container) string {
+	if container.OciConfig != nil {
+		if sbom := container.OciConfig.Annotations[SbomAnnotation]; sbom != "" {
+			return sbom
+		}
+	}
+	return container.Image
+}
+
+// document returns the SBOM of the container, nil if it can't be loaded or,
+// unless wait is true, while it's being loaded
+func (s *SBOM) document(container *containercollection.Container, wait bool) *document {
+	location := source(container)
+	if location == "" {
+		return nil
+	}
+	key := location
+	if strings.HasPrefix(location, "/") {
+		// Paths are specific to the file system of each container
+		key = container.ID + ":" + location
+	}
+
+	s.mu.Lock()
+	entry, ok := s.sboms[key]
+	if ok {
+		select {
+		case <-entry.loaded:
+			if entry.err != nil && time.Since(entry.time) > retryDelay {
+				ok = false
+			}
+		default:
+		}
+	}
+	if !ok {
+		entry = &sbomEntry{loaded: make(chan struct{})}
+		s.sboms[key] = entry
+		go s.load(entry, location, container.Pid)
+	}
+	s.mu.Unlock()
+
+	if wait {
+		<-entry.loaded
+	}
+	select {
+	case <-entry.loaded:
+		return entry.doc
+	default:
+		return nil
+	}
+}
+
+func (s *SBOM) load(entry *sbomEntry, location string, pid uint32) {
+	defer close(entry.loaded)
+	defer func() { entry.time = time.Now() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	var data []byte
+	var err error
+	switch {
+	case strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://"):
+		data, err = s.fetchURL(ctx, location)
+	case strings.HasPrefix(location, "/"):
+		// A path in the file system of the container
+		data, err = os.ReadFile(filepath.Join(host.HostProcFs, fmt.Sprint(pid), "root", location))
+	default:
+		data, err = s.registry.fetchSbom(ctx, location)
+	}
+	if err == nil {
+		entry.doc, err = parseDocument(data)
+	}
+	if err != nil {
+		entry.err = err
+		log.Warnf("SBOM: loading SBOM %q: %v", location, err)
+		return
+	}
+	log.Debugf("SBOM: loaded SBOM %q", location)
+}
+
+func (s *SBOM) fetchURL(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.registry.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{code: resp.StatusCode, url: url}
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxSbomSize))
+}
+
+type SBOMInstance struct {
+	operator  *SBOM
+	gadgetCtx operators.GadgetContext
+	lookuper  containerLookuper
+	enabled   bool
+	wait      bool
+}
+
+func (i *SBOMInstance) Name() string {
+	return "SBOMInstance"
+}
+
+func (i *SBOMInstance) PreGadgetRun() error {
+	return nil
+}
+
+func (i *SBOMInstance) PostGadgetRun() error {
+	return nil
+}
+
+// executable returns the path of the binary executed by the process of the
+// event, in the file system of its container
+func executable(event SbomInformation) string {
+	exe, err := os.Readlink(filepath.Join(host.HostProcFs, fmt.Sprint(event.GetPid()), "exe"))
+	if err == nil {
+		return exe
+	}
+	// The process already exited, fall back to the first argument
+	if e, ok := event.(argsGetter); ok {
+		if args := e.GetArgs(); len(args) > 0 && strings.HasPrefix(args[0], "/") {
+			return args[0]
+		}
+	}
+	return ""
+}
+
+func (i *SBOMInstance) EnrichEvent(ev any) error {
+	if !i.enabled {
+		return nil
+	}
+	event, ok := ev.(SbomInformation)
+	if !ok {
+		return nil
+	}
+	container := i.lookuper.LookupContainerByMntns(event.GetMountNSID())
+	if container == nil {
+		return nil
+	}
+	doc := i.operator.document(container, i.wait)
+	if doc == nil {
+		return nil
+	}
+	exe := executable(event)
+	if exe == "" {
+		return nil
+	}
+
+	if pkg, ok := doc.lookup(exe); ok {
+		event.SetSbomPackage(eventtypes.SbomListed, pkg.Name, pkg.Version)
+	} else {
+		event.SetSbomPackage(eventtypes.SbomUnlisted, "", "")
+	}
+	return nil
+}
+
+func init() {
+	operators.Register(&SBOM{})
+}
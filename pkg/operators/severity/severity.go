@@ -0,0 +1,194 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package severity provides an operator classifying the events of the gadgets
+// as info, warn or alert with rules matching their columns. The severity is
+// used to color the events in the output and by the sinks to only forward
+// some of them, e.g. only the alerts to Alertmanager.
+package severity
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+const (
+	OperatorName = "Severity"
+
+	ParamRules = "severity-rules"
+)
+
+type eventTypeGetter interface {
+	GetType() eventtypes.EventType
+}
+
+// rule gives its severity to the events it matches
+type rule struct {
+	severity eventtypes.Severity
+	// match is nil for the rules matching all the events
+	match func(ev any) bool
+}
+
+// parseRules parses rules written as <severity>[:<column>:<filter>], the
+// filter having the syntax of the --filter flag
+func parseRules(p parser.Parser, specs []string) ([]rule, error) {
+	rules := make([]rule, 0, len(specs))
+	for _, spec := range specs {
+		name, filter, _ := strings.Cut(spec, ":")
+		severity, err := eventtypes.ParseSeverity(name)
+		if err != nil {
+			return nil, fmt.Errorf("parsing severity rule %q: %w", spec, err)
+		}
+
+		r := rule{severity: severity}
+		if filter != "" {
+			r.match, err = p.NewMatcher(filter)
+			if err != nil {
+				return nil, fmt.Errorf("parsing severity rule %q: %w", spec, err)
+			}
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// classify returns the severity of the first rule matching the event, an
+// empty one if none of them does
+func classify(rules []rule, ev any) eventtypes.Severity {
+	for _, r := range rules {
+		if r.match == nil || r.match(ev) {
+			return r.severity
+		}
+	}
+	return ""
+}
+
+type Severity struct{}
+
+func (s *Severity) Name() string {
+	return OperatorName
+}
+
+func (s *Severity) Description() string {
+	return "Severity classifies the events as info, warn or alert"
+}
+
+func (s *Severity) GlobalParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (s *Severity) ParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key: ParamRules,
+			Description: "Rules classifying the events as <severity>[:<column>:<filter>], with the filter syntax of --filter, " +
+				"e.g. alert:comm:nc or warn:status:!OK. They are evaluated in order before the default ones of the gadget, " +
+				"the first one matching gives its severity (info, warn or alert) to the event",
+		},
+	}
+}
+
+func (s *Severity) Dependencies() []string {
+	return nil
+}
+
+func (s *Severity) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	return gadget.Parser() != nil
+}
+
+func (s *Severity) IsUnprivilegedEnricher() {}
+
+func (s *Severity) Init(params *params.Params) error {
+	return nil
+}
+
+func (s *Severity) Close() error {
+	return nil
+}
+
+func (s *Severity) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	instance := &SeverityInstance{
+		parser: gadgetCtx.GadgetDesc().Parser(),
+	}
+	if g, ok := gadgetCtx.GadgetDesc().(gadgets.GadgetDescSeverityRules); ok {
+		instance.defaults = g.SeverityRules()
+	}
+	if err := instance.setRules(params.Get(ParamRules).AsStringSlice()); err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
+
+type SeverityInstance struct {
+	parser   parser.Parser
+	defaults []string
+	rules    atomic.Pointer[[]rule]
+}
+
+func (i *SeverityInstance) setRules(specs []string) error {
+	rules, err := parseRules(i.parser, append(specs, i.defaults...))
+	if err != nil {
+		return err
+	}
+	i.rules.Store(&rules)
+	return nil
+}
+
+func (i *SeverityInstance) Name() string {
+	return "SeverityInstance"
+}
+
+func (i *SeverityInstance) PreGadgetRun() error {
+	return nil
+}
+
+func (i *SeverityInstance) PostGadgetRun() error {
+	return nil
+}
+
+func (i *SeverityInstance) EnrichEvent(ev any) error {
+	return nil
+}
+
+// ClassifyEvent sets the severity of the events of the gadget, the messages
+// of the gadget itself (errors, warnings...) aren't classified
+func (i *SeverityInstance) ClassifyEvent(ev any) {
+	if e, ok := ev.(eventTypeGetter); ok && e.GetType() != eventtypes.NORMAL {
+		return
+	}
+	setter, ok := ev.(eventtypes.SeveritySetter)
+	if !ok {
+		return
+	}
+	if severity := classify(*i.rules.Load(), ev); severity != "" {
+		setter.SetSeverity(severity)
+	}
+}
+
+// UpdateParams replaces the rules given by the user while the gadget is
+// running
+func (i *SeverityInstance) UpdateParams(params *params.Params) error {
+	return i.setRules(params.Get(ParamRules).AsStringSlice())
+}
+
+func init() {
+	operators.Register(&Severity{})
+}
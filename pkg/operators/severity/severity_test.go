@@ -0,0 +1,89 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package severity
+
+import (
+	"testing"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type testEvent struct {
+	eventtypes.Event
+	Comm   string `column:"comm"`
+	Status string `column:"status"`
+	Rows   int64  `column:"rows"`
+}
+
+func newInstance(t *testing.T, rules []string, defaults []string) *SeverityInstance {
+	t.Helper()
+	i := &SeverityInstance{
+		parser:   parser.NewParser[testEvent](columns.MustCreateColumns[testEvent]()),
+		defaults: defaults,
+	}
+	if err := i.setRules(rules); err != nil {
+		t.Fatalf("Setting rules: %s", err)
+	}
+	return i
+}
+
+func TestClassifyEvent(t *testing.T) {
+	i := newInstance(t, []string{"info:comm:cron", "alert:rows:>1000"}, []string{"warn:status:!OK"})
+
+	table := []struct {
+		event    testEvent
+		expected eventtypes.Severity
+	}{
+		{testEvent{Comm: "app", Status: "OK", Rows: 10}, ""},
+		{testEvent{Comm: "app", Status: "ERROR"}, eventtypes.SeverityWarn},
+		{testEvent{Comm: "app", Status: "ERROR", Rows: 5000}, eventtypes.SeverityAlert},
+		// The rules of the user are evaluated before the default ones
+		{testEvent{Comm: "cron", Status: "ERROR"}, eventtypes.SeverityInfo},
+	}
+	for _, entry := range table {
+		ev := entry.event
+		ev.Type = eventtypes.NORMAL
+		i.ClassifyEvent(&ev)
+		if ev.Severity != entry.expected {
+			t.Fatalf("Invalid severity %q for %+v. Expecting %q", ev.Severity, entry.event, entry.expected)
+		}
+	}
+
+	// Messages of the gadget aren't classified
+	msg := testEvent{Event: eventtypes.Warn("lost samples")}
+	i.ClassifyEvent(&msg)
+	if msg.Severity != "" {
+		t.Fatalf("Unexpected severity %q for a message", msg.Severity)
+	}
+
+	// Rules without filter match all the events
+	i = newInstance(t, nil, []string{"alert"})
+	ev := testEvent{Event: eventtypes.Event{Type: eventtypes.NORMAL}}
+	i.ClassifyEvent(&ev)
+	if ev.Severity != eventtypes.SeverityAlert {
+		t.Fatalf("Invalid severity %q. Expecting %q", ev.Severity, eventtypes.SeverityAlert)
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	p := parser.NewParser[testEvent](columns.MustCreateColumns[testEvent]())
+	for _, spec := range []string{"critical:comm:nc", "warn:unknown:1", "warn:rows:abc"} {
+		if _, err := parseRules(p, []string{spec}); err == nil {
+			t.Fatalf("Expected an error for rule %q", spec)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package systemd provides an operator that sets the systemd unit of the
+// processes the events come from, given by their cgroup, and that allows to
+// trace the processes of a systemd unit, like the daemons of the nodes that
+// don't run in containers.
+package systemd
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cilium/ebpf"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/container-utils/cgroups"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+)
+
+const (
+	OperatorName = "Systemd"
+
+	ParamSystemdUnit       = "systemd-unit"
+	ParamSystemdUnitColumn = "systemd-unit-column"
+
+	// The units of the processes are cached for a short time, processes
+	// rarely change of cgroup but pids are reused
+	cacheTTL  = time.Second
+	cacheSize = 4096
+)
+
+// managers are the operators setting the mount namespace map of the gadgets,
+// one of them is registered in ig and in the gadget pods
+var managers = []string{"LocalManager", "KubeManager"}
+
+// SystemdUnitInformation is implemented by the events that can be annotated
+// with the systemd unit of the process they come from
+type SystemdUnitInformation interface {
+	GetPid() uint32
+	SetSystemdUnit(unit string)
+}
+
+// CgroupFilterSetter is implemented by the gadgets that can filter the events
+// by cgroup in eBPF
+type CgroupFilterSetter interface {
+	SetCgroupFilter(cgroupPath string)
+}
+
+type MountNsMapSetter interface {
+	SetMountNsMap(*ebpf.Map)
+}
+
+type Systemd struct {
+	mu    sync.Mutex
+	cache map[uint32]cacheEntry
+}
+
+type cacheEntry struct {
+	unit    string
+	expires time.Time
+}
+
+func (s *Systemd) Name() string {
+	return OperatorName
+}
+
+func (s *Systemd) Description() string {
+	return "Systemd sets the systemd unit of the processes and filters the events by unit"
+}
+
+func (s *Systemd) GlobalParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (s *Systemd) ParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:         ParamSystemdUnit,
+			Title:       "Systemd Unit",
+			Description: "Show only processes running in the cgroup of this systemd unit (e.g. kubelet.service), instead of the containers",
+		},
+		{
+			// Set by the frontends when the unit column is shown or
+			// filtered, reading the cgroup of each process is costly
+			Key:          ParamSystemdUnitColumn,
+			Title:        "Systemd Unit Column",
+			DefaultValue: "false",
+			Description:  "Set the systemd unit of the processes, shown in the unit column",
+			TypeHint:     params.TypeBool,
+		},
+	}
+}
+
+// Dependencies makes the operator run after the one setting the mount
+// namespace map of the gadget, which it removes when filtering by unit
+func (s *Systemd) Dependencies() []string {
+	for _, manager := range managers {
+		if operators.GetRaw(manager) != nil {
+			return []string{manager}
+		}
+	}
+	return nil
+}
+
+func (s *Systemd) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	for _, dependency := range s.Dependencies() {
+		if !operators.GetRaw(dependency).CanOperateOn(gadget) {
+			return false
+		}
+	}
+
+	_, hasSystemdUnitInf := gadget.EventPrototype().(SystemdUnitInformation)
+	return hasSystemdUnitInf
+}
+
+func (s *Systemd) Init(params *params.Params) error {
+	s.cache = make(map[uint32]cacheEntry)
+	return nil
+}
+
+func (s *Systemd) Close() error {
+	return nil
+}
+
+func (s *Systemd) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	unit := params.Get(ParamSystemdUnit).AsString()
+	return &SystemdInstance{
+		operator:       s,
+		gadgetCtx:      gadgetCtx,
+		gadgetInstance: gadgetInstance,
+		unit:           unit,
+		enrich:         unit != "" || params.Get(ParamSystemdUnitColumn).AsBool(),
+	}, nil
+}
+
+// lookup returns the systemd unit of the process pid
+func (s *Systemd) lookup(pid uint32) string {
+	now := time.Now()
+
+	s.mu.Lock()
+	entry, ok := s.cache[pid]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.unit
+	}
+
+	// The cgroup is read without holding the lock, the events of other
+	// processes don't wait for it. Concurrent lookups of the same pid
+	// read it several times, they find the same unit.
+	unit := ""
+	cgroupPathV1, cgroupPathV2, err := cgroups.GetCgroupPaths(int(pid))
+	if err == nil {
+		unit = cgroups.SystemdUnitFromCgroupPath(cgroupPathV2)
+		if unit == "" {
+			unit = cgroups.SystemdUnitFromCgroupPath(cgroupPathV1)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.cache) >= cacheSize {
+		for pid, entry := range s.cache {
+			if !now.Before(entry.expires) {
+				delete(s.cache, pid)
+			}
+		}
+		// All the entries are recent, start again
+		if len(s.cache) >= cacheSize {
+			s.cache = make(map[uint32]cacheEntry)
+		}
+	}
+	s.cache[pid] = cacheEntry{unit: unit, expires: now.Add(cacheTTL)}
+
+	return unit
+}
+
+type SystemdInstance struct {
+	operator       *Systemd
+	gadgetCtx      operators.GadgetContext
+	gadgetInstance any
+	unit           string
+	// enrich is set when the unit of the processes is needed, to filter
+	// the events by unit or to show it
+	enrich bool
+}
+
+func (i *SystemdInstance) Name() string {
+	return "SystemdInstance"
+}
+
+func (i *SystemdInstance) PreGadgetRun() error {
+	if i.unit == "" {
+		return nil
+	}
+
+	setter, ok := i.gadgetInstance.(CgroupFilterSetter)
+	if !ok {
+		return fmt.Errorf("gadget %q can't filter by systemd unit", i.gadgetCtx.GadgetDesc().Name())
+	}
+	cgroupPath, err := cgroups.GetSystemdUnitCgroupPath(i.unit)
+	if err != nil {
+		return err
+	}
+	i.gadgetCtx.Logger().Debugf("filtering on cgroup %q", cgroupPath)
+	setter.SetCgroupFilter(cgroupPath)
+
+	// The processes of the unit are usually not in a container, stop
+	// filtering by mount namespace
+	if setter, ok := i.gadgetInstance.(MountNsMapSetter); ok {
+		setter.SetMountNsMap(nil)
+	}
+	return nil
+}
+
+func (i *SystemdInstance) PostGadgetRun() error {
+	return nil
+}
+
+func (i *SystemdInstance) EnrichEvent(ev any) error {
+	if !i.enrich {
+		return nil
+	}
+	event, ok := ev.(SystemdUnitInformation)
+	if !ok || event.GetPid() == 0 {
+		return nil
+	}
+	event.SetSystemdUnit(i.operator.lookup(event.GetPid()))
+	return nil
+}
+
+func init() {
+	operators.Register(&Systemd{})
+}
@@ -0,0 +1,140 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulnerabilities
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+type vulnerability struct {
+	id       string
+	severity string
+}
+
+// severityRanks orders the severities given by the scanners, unknown ones
+// rank last
+var severityRanks = map[string]int{
+	"critical":   5,
+	"high":       4,
+	"medium":     3,
+	"low":        2,
+	"negligible": 1,
+}
+
+// database holds the vulnerabilities of the packages, by name and version
+type database map[string][]vulnerability
+
+func packageKey(name, version string) string {
+	return name + "@" + version
+}
+
+func (db database) add(name, version, id, severity string) {
+	if name == "" || id == "" {
+		return
+	}
+	key := packageKey(name, version)
+	for _, vuln := range db[key] {
+		if vuln.id == id {
+			return
+		}
+	}
+	db[key] = append(db[key], vulnerability{id: id, severity: strings.ToLower(severity)})
+}
+
+// sort orders the vulnerabilities of each package, the most severe first
+func (db database) sort() {
+	for _, vulns := range db {
+		sort.Slice(vulns, func(i, j int) bool {
+			ri, rj := severityRanks[vulns[i].severity], severityRanks[vulns[j].severity]
+			if ri != rj {
+				return ri > rj
+			}
+			return vulns[i].id < vulns[j].id
+		})
+	}
+}
+
+// lookup returns the comma-separated vulnerabilities of the package and the
+// most severe severity
+func (db database) lookup(name, version string) (string, string) {
+	vulns := db[packageKey(name, version)]
+	if len(vulns) == 0 {
+		return "", ""
+	}
+	ids := make([]string, len(vulns))
+	for i, vuln := range vulns {
+		ids[i] = vuln.id
+	}
+	return strings.Join(ids, ","), vulns[0].severity
+}
+
+type grypeReport struct {
+	Matches []struct {
+		Vulnerability struct {
+			ID       string `json:"id"`
+			Severity string `json:"severity"`
+		} `json:"vulnerability"`
+		Artifact struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"artifact"`
+	} `json:"matches"`
+}
+
+type trivyReport struct {
+	Results []struct {
+		Vulnerabilities []struct {
+			VulnerabilityID  string `json:"VulnerabilityID"`
+			PkgName          string `json:"PkgName"`
+			InstalledVersion string `json:"InstalledVersion"`
+			Severity         string `json:"Severity"`
+		} `json:"Vulnerabilities"`
+	} `json:"Results"`
+}
+
+// addReport adds the vulnerabilities of a report of Grype or Trivy in JSON
+func (db database) addReport(data []byte) error {
+	var format map[string]json.RawMessage
+	if err := json.Unmarshal(data, &format); err != nil {
+		return err
+	}
+
+	switch {
+	case format["matches"] != nil:
+		var report grypeReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return err
+		}
+		for _, match := range report.Matches {
+			db.add(match.Artifact.Name, match.Artifact.Version, match.Vulnerability.ID, match.Vulnerability.Severity)
+		}
+	case format["Results"] != nil:
+		var report trivyReport
+		if err := json.Unmarshal(data, &report); err != nil {
+			return err
+		}
+		for _, result := range report.Results {
+			for _, vuln := range result.Vulnerabilities {
+				db.add(vuln.PkgName, vuln.InstalledVersion, vuln.VulnerabilityID, vuln.Severity)
+			}
+		}
+	default:
+		return errors.New("unknown report format, only Grype and Trivy JSON are supported")
+	}
+	return nil
+}
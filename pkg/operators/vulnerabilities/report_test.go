@@ -0,0 +1,64 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vulnerabilities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const grypeReportJSON = `{
+  "matches": [
+    {"vulnerability": {"id": "CVE-2023-38546", "severity": "Low"}, "artifact": {"name": "curl", "version": "8.3.0-r0"}},
+    {"vulnerability": {"id": "CVE-2023-38545", "severity": "Critical"}, "artifact": {"name": "curl", "version": "8.3.0-r0"}},
+    {"vulnerability": {"id": "CVE-2023-5363", "severity": "High"}, "artifact": {"name": "libssl3", "version": "3.1.3-r0"}}
+  ]
+}`
+
+const trivyReportJSON = `{
+  "Results": [
+    {"Vulnerabilities": [
+      {"VulnerabilityID": "CVE-2023-5363", "PkgName": "libssl3", "InstalledVersion": "3.1.3-r0", "Severity": "HIGH"},
+      {"VulnerabilityID": "CVE-2023-4807", "PkgName": "libssl3", "InstalledVersion": "3.1.3-r0", "Severity": "UNKNOWN"}
+    ]}
+  ]
+}`
+
+func TestDatabase(t *testing.T) {
+	db := make(database)
+	require.NoError(t, db.addReport([]byte(grypeReportJSON)))
+	require.NoError(t, db.addReport([]byte(trivyReportJSON)))
+	require.Error(t, db.addReport([]byte(`{"packages": []}`)))
+	db.sort()
+
+	table := []struct {
+		name, version    string
+		expectedVulns    string
+		expectedSeverity string
+	}{
+		{name: "curl", version: "8.3.0-r0", expectedVulns: "CVE-2023-38545,CVE-2023-38546", expectedSeverity: "critical"},
+		// The same vulnerability found by both scanners is only given once
+		{name: "libssl3", version: "3.1.3-r0", expectedVulns: "CVE-2023-5363,CVE-2023-4807", expectedSeverity: "high"},
+		// Other versions of the package aren't vulnerable
+		{name: "curl", version: "8.4.0-r0"},
+		{name: "busybox", version: "1.36.1-r2"},
+	}
+	for _, entry := range table {
+		vulns, severity := db.lookup(entry.name, entry.version)
+		require.Equal(t, entry.expectedVulns, vulns, entry.name)
+		require.Equal(t, entry.expectedSeverity, severity, entry.name)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vulnerabilities provides an operator annotating the events with the
+// known vulnerabilities of the package the executed binary belongs to, as
+// found by a vulnerability scanner, to prioritize the workloads running
+// vulnerable code.
+package vulnerabilities
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+)
+
+const (
+	OperatorName = "Vulnerabilities"
+
+	ParamReports = "vulnerability-reports"
+
+	// The packages of the binaries are given by the SBOM operator
+	sbomOperatorName = "SBOM"
+
+	fetchTimeout  = time.Minute
+	maxReportSize = 256 << 20
+)
+
+// VulnerabilityInformation is implemented by the events that can be annotated
+// with the vulnerabilities of the package of the SBOM they come from
+type VulnerabilityInformation interface {
+	GetSbomPackage() (name, version string)
+	SetVulnerabilities(vulnerabilities, severity string)
+}
+
+type Vulnerabilities struct{}
+
+func (v *Vulnerabilities) Name() string {
+	return OperatorName
+}
+
+func (v *Vulnerabilities) Description() string {
+	return "Vulnerabilities annotates the events with the known vulnerabilities of the packages of the executed binaries"
+}
+
+func (v *Vulnerabilities) GlobalParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (v *Vulnerabilities) ParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key:   ParamReports,
+			Title: "Vulnerability Reports",
+			Description: "Comma-separated paths or http(s) URLs of vulnerability reports of Grype or Trivy in JSON, " +
+				"the vulnerabilities of the packages given by --sbom are added to the events",
+		},
+	}
+}
+
+func (v *Vulnerabilities) Dependencies() []string {
+	if operators.GetRaw(sbomOperatorName) != nil {
+		return []string{sbomOperatorName}
+	}
+	return nil
+}
+
+func (v *Vulnerabilities) CanOperateOn(gadget gadgets.GadgetDesc) bool {
+	deps := v.Dependencies()
+	if len(deps) == 0 || !operators.GetRaw(deps[0]).CanOperateOn(gadget) {
+		return false
+	}
+
+	_, hasVulnerabilityInf := gadget.EventPrototype().(VulnerabilityInformation)
+	return hasVulnerabilityInf
+}
+
+func (v *Vulnerabilities) Init(params *params.Params) error {
+	return nil
+}
+
+func (v *Vulnerabilities) Close() error {
+	return nil
+}
+
+func (v *Vulnerabilities) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	instance := &VulnerabilitiesInstance{}
+
+	reports := params.Get(ParamReports).AsStringSlice()
+	if len(reports) == 0 {
+		return instance, nil
+	}
+
+	db := make(database)
+	for _, report := range reports {
+		data, err := readReport(gadgetCtx.Context(), report)
+		if err != nil {
+			return nil, fmt.Errorf("reading vulnerability report %q: %w", report, err)
+		}
+		if err := db.addReport(data); err != nil {
+			return nil, fmt.Errorf("parsing vulnerability report %q: %w", report, err)
+		}
+	}
+	db.sort()
+	instance.db = db
+	return instance, nil
+}
+
+func readReport(ctx context.Context, location string) ([]byte, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		return os.ReadFile(location)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("getting report: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxReportSize))
+}
+
+type VulnerabilitiesInstance struct {
+	db database
+}
+
+func (i *VulnerabilitiesInstance) Name() string {
+	return "VulnerabilitiesInstance"
+}
+
+func (i *VulnerabilitiesInstance) PreGadgetRun() error {
+	return nil
+}
+
+func (i *VulnerabilitiesInstance) PostGadgetRun() error {
+	return nil
+}
+
+func (i *VulnerabilitiesInstance) EnrichEvent(ev any) error {
+	if i.db == nil {
+		return nil
+	}
+	event, ok := ev.(VulnerabilityInformation)
+	if !ok {
+		return nil
+	}
+	name, version := event.GetSbomPackage()
+	if name == "" {
+		return nil
+	}
+	event.SetVulnerabilities(i.db.lookup(name, version))
+	return nil
+}
+
+func init() {
+	operators.Register(&Vulnerabilities{})
+}
@@ -219,6 +219,19 @@ func (p *Params) Set(key, val string) error {
 	return ErrNotFound
 }
 
+// Copy returns a copy of the params; setting values on the copy doesn't change
+// the original params
+func (p *Params) Copy() *Params {
+	params := make(Params, 0, len(*p))
+	for _, param := range *p {
+		params = append(params, &Param{
+			ParamDesc: param.ParamDesc,
+			value:     param.value,
+		})
+	}
+	return &params
+}
+
 func (p *Params) ParamMap() (res map[string]string) {
 	res = make(map[string]string)
 	for _, v := range *p {
@@ -315,6 +328,17 @@ func (p Collection) Set(entry, key, val string) error {
 	return p[entry].Set(key, val)
 }
 
+// Copy returns a copy of the collection, see Params.Copy
+func (p Collection) Copy() Collection {
+	coll := make(Collection, len(p))
+	for key, params := range p {
+		if params != nil {
+			coll[key] = params.Copy()
+		}
+	}
+	return coll
+}
+
 func (p Collection) CopyToMap(target map[string]string, prefix string) {
 	for collectionKey, params := range p {
 		params.CopyToMap(target, prefix+collectionKey+".")
@@ -405,3 +405,22 @@ func TestBytesHandling(t *testing.T) {
 	params.CopyFromMap(testMap, "")
 	require.Equal(t, testString, string(params[0].AsBytes()), "decompression + B64 decoding failed")
 }
+
+func TestParamsCopy(t *testing.T) {
+	params := ParamDescs{
+		{
+			Key:          "interval",
+			DefaultValue: "1",
+			TypeHint:     TypeUint32,
+		},
+	}.ToParams()
+
+	cp := params.Copy()
+	require.Equal(t, "1", cp.Get("interval").String())
+
+	require.NoError(t, cp.Set("interval", "5"))
+	require.Equal(t, "5", cp.Get("interval").String())
+	require.Equal(t, "1", params.Get("interval").String(), "setting the copy changed the original")
+
+	require.Error(t, cp.Set("interval", "-1"), "copy lost the validation")
+}
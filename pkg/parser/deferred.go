@@ -0,0 +1,177 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+type deferredEvent[T any] struct {
+	event   *T
+	handler func(*T)
+	release time.Time
+	seq     uint64
+}
+
+// deferredHeap sorts the deferred events by release time, then by arrival
+type deferredHeap[T any] []*deferredEvent[T]
+
+func (h deferredHeap[T]) Len() int { return len(h) }
+
+func (h deferredHeap[T]) Less(i, j int) bool {
+	if !h[i].release.Equal(h[j].release) {
+		return h[i].release.Before(h[j].release)
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h deferredHeap[T]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *deferredHeap[T]) Push(x any) { *h = append(*h, x.(*deferredEvent[T])) }
+
+func (h *deferredHeap[T]) Pop() any {
+	old := *h
+	n := len(old)
+	ev := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return ev
+}
+
+// deferQueue holds the events deferred by the enrichers and gives them back
+// to their handler once their delay expired. They are handed back one at a
+// time by a single goroutine, started with the first deferred event, and the
+// ones still held when the queue is stopped are handed back right away.
+type deferQueue[T any] struct {
+	max int
+
+	mu      sync.Mutex
+	events  deferredHeap[T]
+	seq     uint64
+	running bool
+	closed  bool
+
+	wake    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newDeferQueue[T any](max int) *deferQueue[T] {
+	return &deferQueue[T]{
+		max:     max,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+// push holds the event for the given delay. It returns false when the event
+// can't be held, because the queue is full or stopped.
+func (q *deferQueue[T]) push(ev *T, delay time.Duration, handler func(*T)) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed || len(q.events) >= q.max {
+		return false
+	}
+
+	q.seq++
+	held := &deferredEvent[T]{
+		event:   ev,
+		handler: handler,
+		release: time.Now().Add(delay),
+		seq:     q.seq,
+	}
+	heap.Push(&q.events, held)
+
+	if !q.running {
+		q.running = true
+		go q.run()
+	} else if q.events[0] == held {
+		// Released before the one the goroutine is waiting for
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+// releaseUntil hands back the events to release before the given time, or
+// all of them if now is zero. It returns when the next one has to be
+// released, zero if there is none.
+func (q *deferQueue[T]) releaseUntil(now time.Time) time.Time {
+	for {
+		q.mu.Lock()
+		if len(q.events) == 0 {
+			q.mu.Unlock()
+			return time.Time{}
+		}
+		if !now.IsZero() && q.events[0].release.After(now) {
+			next := q.events[0].release
+			q.mu.Unlock()
+			return next
+		}
+		held := heap.Pop(&q.events).(*deferredEvent[T])
+		q.mu.Unlock()
+
+		held.handler(held.event)
+	}
+}
+
+func (q *deferQueue[T]) run() {
+	defer close(q.stopped)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-q.done:
+			return
+		case <-q.wake:
+		case <-timer.C:
+		}
+
+		next := q.releaseUntil(time.Now())
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		if !next.IsZero() {
+			timer.Reset(time.Until(next))
+		}
+	}
+}
+
+// stop hands back the events still held right away and makes push fail from
+// now on
+func (q *deferQueue[T]) stop() {
+	q.mu.Lock()
+	q.closed = true
+	running := q.running
+	q.mu.Unlock()
+
+	if running {
+		close(q.done)
+		<-q.stopped
+	}
+
+	q.releaseUntil(time.Time{})
+}
@@ -31,6 +31,10 @@ type TextColumnsFormatter interface {
 	EventHandlerFuncArray(...func()) any
 	SetEventCallback(eventCallback func(string))
 	SetEnableExtraLines(bool)
+
+	// SetEnableColors highlights the lines of the events classified as
+	// warnings or alerts
+	SetEnableColors(bool)
 }
 
 type ExtraLines interface {
@@ -48,10 +52,31 @@ type outputHelper[T any] struct {
 	*textcolumns.TextColumnsFormatter[T]
 	eventCallback    func(string)
 	enableExtraLines bool
+	enableColors     bool
+}
+
+const (
+	colorYellow = "\033[33m"
+	colorRed    = "\033[31m"
+	colorReset  = "\033[0m"
+)
+
+// colorize highlights the line of an event according to its severity
+func (oh *outputHelper[T]) colorize(ev *T, line string) string {
+	if !oh.enableColors {
+		return line
+	}
+	switch types.EventSeverity(ev) {
+	case types.SeverityAlert:
+		return colorRed + line + colorReset
+	case types.SeverityWarn:
+		return colorYellow + line + colorReset
+	}
+	return line
 }
 
 func (oh *outputHelper[T]) forwardEvent(ev *T) {
-	oh.eventCallback(oh.TextColumnsFormatter.FormatEntry(ev))
+	oh.eventCallback(oh.colorize(ev, oh.TextColumnsFormatter.FormatEntry(ev)))
 	if !oh.enableExtraLines {
 		return
 	}
@@ -118,7 +143,7 @@ func (oh *outputHelper[T]) TransformEvent(line string) (string, error) {
 		return "", nil
 	}
 
-	return oh.FormatEntry(ev), nil
+	return oh.colorize(ev, oh.FormatEntry(ev)), nil
 }
 
 func (oh *outputHelper[T]) SetShowColumns(cols []string) error {
@@ -132,3 +157,7 @@ func (oh *outputHelper[T]) SetEnableExtraLines(newVal bool) {
 	}
 	oh.enableExtraLines = newVal
 }
+
+func (oh *outputHelper[T]) SetEnableColors(newVal bool) {
+	oh.enableColors = newVal
+}
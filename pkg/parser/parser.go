@@ -23,6 +23,7 @@ package parser
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -37,6 +38,31 @@ import (
 
 type LogCallback func(severity logger.Level, fmt string, params ...any)
 
+// ErrDropEvent is returned by the enrichers given to the event handlers to
+// drop the event instead of pushing it downstream
+var ErrDropEvent = errors.New("event dropped")
+
+// ErrDeferEvent is matched by the DeferEventError returned by the enrichers
+var ErrDeferEvent = errors.New("event deferred")
+
+// maxDeferredEvents is the number of events that can be held at once, the
+// ones deferred above it are handled again right away
+const maxDeferredEvents = 16384
+
+// DeferEventError is returned by the enrichers given to the event handlers to
+// hold the event for the given delay, before handling it again
+type DeferEventError struct {
+	Delay time.Duration
+}
+
+func (e *DeferEventError) Error() string {
+	return fmt.Sprintf("event deferred for %s", e.Delay)
+}
+
+func (e *DeferEventError) Is(target error) bool {
+	return target == ErrDeferEvent
+}
+
 // Parser is the (untyped) interface used for parser
 type Parser interface {
 	// GetTextColumnsFormatter returns the default formatter for this columns instance
@@ -68,6 +94,10 @@ type Parser interface {
 	// SetFilters sets which filter to apply before emitting events downstream
 	SetFilters([]string) error
 
+	// NewMatcher returns a function telling whether an event of type *T matches the given filter, which uses
+	// the syntax of SetFilters. Events of other types never match.
+	NewMatcher(filter string) (func(ev any) bool, error)
+
 	// EventHandlerFunc returns a function that accepts an instance of type *T and pushes it downstream after applying
 	// enrichers and filters
 	EventHandlerFunc(enrichers ...func(any) error) any
@@ -92,8 +122,18 @@ type Parser interface {
 	// Events are released by calling Flush().
 	EnableCombiner()
 
-	// Flush sends the events downstream that were collected after EnableCombiner() was called.
+	// EnableReorder initializes the reorder buffer, which holds the events for the given delay to emit them in
+	// timestamp order, even if they come interleaved from several sources; used for trace gadgets. Flush() has to
+	// be called to release the events still held.
+	EnableReorder(delay time.Duration)
+
+	// Flush sends the events downstream that were collected after EnableCombiner() was called, or held by the
+	// reorder buffer.
 	Flush()
+
+	// Stop handles the events deferred by the enrichers right away, without waiting for their delay, and stops
+	// deferring events. It has to be called once the gadget is done, before Flush().
+	Stop()
 }
 
 type parser[T any] struct {
@@ -112,11 +152,19 @@ type parser[T any] struct {
 	eventCombinerEnabled bool
 	combinedEvents       []*T
 	mu                   sync.Mutex
+
+	reorderBuffer *reorderBuffer[T]
+
+	// handlerMu serializes the handling of the events of the tracer and of
+	// the deferred ones
+	handlerMu sync.Mutex
+	deferred  *deferQueue[T]
 }
 
 func NewParser[T any](columns *columns.Columns[T]) Parser {
 	p := &parser[T]{
-		columns: columns,
+		columns:  columns,
+		deferred: newDeferQueue[T](maxDeferredEvents),
 	}
 	return p
 }
@@ -149,8 +197,29 @@ func (p *parser[T]) EnableCombiner() {
 	p.combinedEvents = []*T{}
 }
 
+func (p *parser[T]) EnableReorder(delay time.Duration) {
+	if p.eventCallback == nil {
+		panic("eventCallback has to be set before using EnableReorder()")
+	}
+
+	p.reorderBuffer = newReorderBuffer[T](delay, p.eventCallback)
+	go p.reorderBuffer.run()
+}
+
 func (p *parser[T]) Flush() {
-	p.eventCallbackArray(p.combinedEvents)
+	if p.reorderBuffer != nil {
+		if late := p.reorderBuffer.stop(); late > 0 {
+			p.writeLogMessage(logger.WarnLevel, "%d events arrived too late to be emitted in order, consider a longer delay", late)
+		}
+		p.reorderBuffer = nil
+	}
+	if p.eventCombinerEnabled {
+		p.eventCallbackArray(p.combinedEvents)
+	}
+}
+
+func (p *parser[T]) Stop() {
+	p.deferred.stop()
 }
 
 func (p *parser[T]) SetColumnFilters(filters ...columns.ColumnFilter) {
@@ -186,15 +255,38 @@ func (p *parser[T]) eventHandler(cb func(*T), enrichers ...func(any) error) func
 	if cb == nil {
 		panic("cb can't be nil in eventHandler from parser")
 	}
-	return func(ev *T) {
+	var handler, handle func(ev *T)
+	// handle is called with handlerMu held
+	handle = func(ev *T) {
 		for _, enricher := range enrichers {
-			enricher(ev)
+			err := enricher(ev)
+			if errors.Is(err, ErrDropEvent) {
+				return
+			}
+			var deferErr *DeferEventError
+			if errors.As(err, &deferErr) {
+				// The enrichers don't defer an event twice, the ones that
+				// can't be held are handled again right away
+				if !p.deferred.push(ev, deferErr.Delay, handler) {
+					handle(ev)
+				}
+				return
+			}
 		}
 		if p.filterSpecs != nil && !p.filterSpecs.MatchAll(ev) {
 			return
 		}
 		cb(ev)
 	}
+	// The deferred events are handled by the goroutine of the deferQueue: the
+	// events are handled one at a time, so the enrichers and cb are never
+	// called concurrently
+	handler = func(ev *T) {
+		p.handlerMu.Lock()
+		defer p.handlerMu.Unlock()
+		handle(ev)
+	}
+	return handler
 }
 
 func (p *parser[T]) eventHandlerArray(cb func([]*T), enrichers ...func(any) error) func([]*T) {
@@ -202,10 +294,18 @@ func (p *parser[T]) eventHandlerArray(cb func([]*T), enrichers ...func(any) erro
 		panic("cb can't be nil in eventHandlerArray from parser")
 	}
 	return func(events []*T) {
-		for _, enricher := range enrichers {
+		if len(enrichers) > 0 {
+			enrichedEvents := make([]*T, 0, len(events))
+		eventLoop:
 			for _, ev := range events {
-				enricher(ev)
+				for _, enricher := range enrichers {
+					if errors.Is(enricher(ev), ErrDropEvent) {
+						continue eventLoop
+					}
+				}
+				enrichedEvents = append(enrichedEvents, ev)
 			}
+			events = enrichedEvents
 		}
 		if p.filterSpecs != nil {
 			filteredEvents := make([]*T, 0, len(events))
@@ -249,6 +349,8 @@ func (p *parser[T]) JSONHandlerFunc(enrichers ...func(any) error) func([]byte) {
 	cb := p.eventCallback
 	if p.eventCombinerEnabled {
 		cb = p.combineEventsCallback
+	} else if p.reorderBuffer != nil {
+		cb = p.reorderBuffer.push
 	}
 
 	handler := p.eventHandler(cb, enrichers...)
@@ -287,7 +389,11 @@ func (p *parser[T]) JSONHandlerFuncArray(key string, enrichers ...func(any) erro
 }
 
 func (p *parser[T]) EventHandlerFunc(enrichers ...func(any) error) any {
-	return p.eventHandler(p.eventCallback, enrichers...)
+	cb := p.eventCallback
+	if p.reorderBuffer != nil {
+		cb = p.reorderBuffer.push
+	}
+	return p.eventHandler(cb, enrichers...)
 }
 
 func (p *parser[T]) EventHandlerFuncArray(enrichers ...func(any) error) any {
@@ -344,6 +450,18 @@ func (p *parser[T]) SetSorting(sortBy []string) error {
 	return nil
 }
 
+func (p *parser[T]) NewMatcher(f string) (func(ev any) bool, error) {
+	filterSpec, err := filter.GetFilterFromString(p.columns.ColumnMap, f)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ev any) bool {
+		entry, ok := ev.(*T)
+		return ok && filterSpec.Match(entry)
+	}, nil
+}
+
 func (p *parser[T]) SetFilters(filters []string) error {
 	if len(filters) == 0 {
 		return nil
@@ -0,0 +1,132 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
+)
+
+func TestDeferEvent(t *testing.T) {
+	p := NewParser[testEvent](columns.MustCreateColumns[testEvent]())
+
+	emitted := make(chan int, 2)
+	p.SetEventCallback(func(ev *testEvent) {
+		emitted <- ev.ID
+	})
+
+	deferred := map[int]bool{}
+	handler := p.EventHandlerFunc(func(ev any) error {
+		id := ev.(*testEvent).ID
+		if id == 1 && !deferred[id] {
+			deferred[id] = true
+			return &DeferEventError{Delay: 50 * time.Millisecond}
+		}
+		return nil
+	}).(func(*testEvent))
+
+	handler(&testEvent{ID: 1})
+	handler(&testEvent{ID: 2})
+
+	// The deferred event comes after the next one
+	require.Equal(t, 2, <-emitted)
+	select {
+	case id := <-emitted:
+		require.Equal(t, 1, id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("deferred event not emitted")
+	}
+}
+
+func TestDeferEventStop(t *testing.T) {
+	p := NewParser[testEvent](columns.MustCreateColumns[testEvent]())
+
+	var emitted []int
+	p.SetEventCallback(func(ev *testEvent) {
+		emitted = append(emitted, ev.ID)
+	})
+
+	deferred := map[int]bool{}
+	handler := p.EventHandlerFunc(func(ev any) error {
+		id := ev.(*testEvent).ID
+		if !deferred[id] {
+			deferred[id] = true
+			return &DeferEventError{Delay: time.Hour}
+		}
+		return nil
+	}).(func(*testEvent))
+
+	handler(&testEvent{ID: 1})
+	handler(&testEvent{ID: 2})
+	require.Empty(t, emitted)
+
+	// The deferred events are handled when the parser stops, in order
+	p.Stop()
+	require.Equal(t, []int{1, 2}, emitted)
+
+	// The events aren't deferred anymore once the parser is stopped
+	handler(&testEvent{ID: 3})
+	require.Equal(t, []int{1, 2, 3}, emitted)
+}
+
+func TestDeferEventConcurrent(t *testing.T) {
+	p := NewParser[testEvent](columns.MustCreateColumns[testEvent]())
+
+	const events = 1000
+
+	var running, concurrent atomic.Int32
+	done := make(chan struct{})
+	count := 0
+	p.SetEventCallback(func(ev *testEvent) {
+		if running.Add(1) > 1 {
+			concurrent.Add(1)
+		}
+		time.Sleep(time.Microsecond)
+		count++
+		if count == events {
+			close(done)
+		}
+		running.Add(-1)
+	})
+
+	// The enricher isn't safe for concurrent use either
+	deferred := map[int]bool{}
+	handler := p.EventHandlerFunc(func(ev any) error {
+		id := ev.(*testEvent).ID
+		if id%2 == 0 && !deferred[id] {
+			deferred[id] = true
+			return &DeferEventError{Delay: time.Duration(id%10) * time.Millisecond}
+		}
+		return nil
+	}).(func(*testEvent))
+
+	for i := 0; i < events; i++ {
+		handler(&testEvent{ID: i})
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deferred events not emitted")
+	}
+	p.Stop()
+
+	require.Equal(t, int32(0), concurrent.Load(), "callback called concurrently")
+}
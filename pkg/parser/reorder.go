@@ -0,0 +1,178 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+// timestamped is implemented by the events that can be reordered
+type timestamped interface {
+	GetTimestamp() eventtypes.Time
+}
+
+type heldEvent[T any] struct {
+	event     *T
+	timestamp eventtypes.Time
+	seq       uint64
+	release   time.Time
+}
+
+// eventHeap sorts the held events by timestamp, then by arrival
+type eventHeap[T any] []*heldEvent[T]
+
+func (h eventHeap[T]) Len() int { return len(h) }
+
+func (h eventHeap[T]) Less(i, j int) bool {
+	if h[i].timestamp != h[j].timestamp {
+		return h[i].timestamp < h[j].timestamp
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h eventHeap[T]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *eventHeap[T]) Push(x any) { *h = append(*h, x.(*heldEvent[T])) }
+
+func (h *eventHeap[T]) Pop() any {
+	old := *h
+	n := len(old)
+	ev := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return ev
+}
+
+// reorderBuffer holds each event for a given delay after it arrived and
+// releases them in timestamp order, so that the events of different sources
+// (nodes, CPUs) arriving interleaved are emitted in order, as long as they
+// arrive within the delay. Events without timestamp aren't held.
+type reorderBuffer[T any] struct {
+	delay time.Duration
+	cb    func(*T)
+
+	mu            sync.Mutex
+	events        eventHeap[T]
+	seq           uint64
+	lastTimestamp eventtypes.Time
+	late          uint64
+	closed        bool
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newReorderBuffer[T any](delay time.Duration, cb func(*T)) *reorderBuffer[T] {
+	return &reorderBuffer[T]{
+		delay:   delay,
+		cb:      cb,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+func (b *reorderBuffer[T]) push(ev *T) {
+	var timestamp eventtypes.Time
+	if t, ok := any(ev).(timestamped); ok {
+		timestamp = t.GetTimestamp()
+	}
+	if timestamp == 0 {
+		b.cb(ev)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Events coming after the gadget is done aren't held anymore
+	if b.closed {
+		b.cb(ev)
+		return
+	}
+
+	// Events older than the ones already released can't be ordered anymore
+	if timestamp < b.lastTimestamp {
+		b.late++
+	}
+	b.seq++
+	heap.Push(&b.events, &heldEvent[T]{
+		event:     ev,
+		timestamp: timestamp,
+		seq:       b.seq,
+		release:   time.Now().Add(b.delay),
+	})
+}
+
+// releaseUntil emits the events in timestamp order until the first one to
+// be held after the given time, or all of them if now is zero
+func (b *reorderBuffer[T]) releaseUntil(now time.Time) {
+	b.mu.Lock()
+	var ready []*T
+	for len(b.events) > 0 && (now.IsZero() || !b.events[0].release.After(now)) {
+		held := heap.Pop(&b.events).(*heldEvent[T])
+		if held.timestamp > b.lastTimestamp {
+			b.lastTimestamp = held.timestamp
+		}
+		ready = append(ready, held.event)
+	}
+	b.mu.Unlock()
+
+	for _, ev := range ready {
+		b.cb(ev)
+	}
+}
+
+func (b *reorderBuffer[T]) run() {
+	defer close(b.stopped)
+
+	interval := b.delay / 4
+	if interval < time.Millisecond {
+		interval = time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			b.releaseUntil(now)
+		case <-b.done:
+			b.releaseUntil(time.Time{})
+			return
+		}
+	}
+}
+
+// stop releases the events still held and returns how many events arrived
+// too late to be emitted in order
+func (b *reorderBuffer[T]) stop() uint64 {
+	close(b.done)
+	<-b.stopped
+
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	// Events pushed while the last ones were released
+	b.releaseUntil(time.Time{})
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.late
+}
@@ -0,0 +1,64 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type testEvent struct {
+	eventtypes.Event
+	ID int
+}
+
+func TestReorderBuffer(t *testing.T) {
+	var emitted []int
+	b := newReorderBuffer[testEvent](time.Hour, func(ev *testEvent) {
+		emitted = append(emitted, ev.ID)
+	})
+
+	push := func(id int, timestamp eventtypes.Time) {
+		ev := &testEvent{ID: id}
+		ev.Timestamp = timestamp
+		b.push(ev)
+	}
+
+	push(1, 300)
+	push(2, 100)
+	// Events without timestamp are emitted right away
+	push(3, 0)
+	push(4, 200)
+	push(5, 100)
+	require.Equal(t, []int{3}, emitted)
+
+	// Nothing is released before the delay
+	b.releaseUntil(time.Now())
+	require.Equal(t, []int{3}, emitted)
+
+	// Same timestamp: order of arrival
+	b.releaseUntil(time.Now().Add(2 * time.Hour))
+	require.Equal(t, []int{3, 2, 5, 4, 1}, emitted)
+
+	// Arrived after a later event was released
+	push(6, 250)
+	b.releaseUntil(time.Time{})
+	require.Equal(t, []int{3, 2, 5, 4, 1, 6}, emitted)
+	require.Equal(t, uint64(1), b.late)
+}
@@ -0,0 +1,328 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipelineprocess
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/kubemanager"
+)
+
+const (
+	// OperatorName is the name of the operator forwarding the events of the
+	// runs to the pipeline process
+	OperatorName = "PipelineProcess"
+
+	subscriptionKey = "pipelineprocess"
+)
+
+// The delay before starting the pipeline process again doubles each time it
+// exits shortly after being started
+const (
+	minRestartDelay = time.Second
+	maxRestartDelay = time.Minute
+)
+
+// errNotRunning is returned to the runs started while the pipeline process is
+// being started again
+var errNotRunning = errors.New("pipeline process not running")
+
+// Start executes the running binary again with the given arguments, which must
+// make it call Serve(), as nobody and without capabilities. It keeps a copy of
+// the containers of cc in it and starts it again when it exits. Then it
+// replaces the sink and unprivileged enricher operators with proxies to it and
+// registers the PipelineProcess operator. It must be called before the
+// operators are initialized.
+func Start(cc *containercollection.ContainerCollection, args ...string) error {
+	registerEventTypes()
+
+	s := &supervisor{
+		cc: cc,
+		start: func() (*client, func() error, error) {
+			return startChild(args)
+		},
+	}
+	// The containers added before the pipeline process is started are given
+	// to it with the ones already there
+	cc.Subscribe(subscriptionKey, containercollection.ContainerSelector{}, s.handleContainerEvent)
+	c, wait, err := s.start()
+	if err != nil {
+		cc.Unsubscribe(subscriptionKey)
+		return err
+	}
+	s.use(c)
+	go s.watch(c, wait)
+
+	s.replaceOperators()
+	return nil
+}
+
+// startChild starts the pipeline process and returns the client talking to it
+// and the function waiting for it to exit
+func startChild(args []string) (*client, func() error, error) {
+	fds, err := unix.Socketpair(unix.AF_UNIX, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating socket pair: %w", err)
+	}
+	local := os.NewFile(uintptr(fds[0]), "pipeline-process")
+	remote := os.NewFile(uintptr(fds[1]), "pipeline-process")
+	defer remote.Close()
+
+	executable, err := os.Executable()
+	if err != nil {
+		local.Close()
+		return nil, nil, fmt.Errorf("getting executable: %w", err)
+	}
+
+	cmd := exec.Command(executable, args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{remote}
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		// Changing the user from root drops the capabilities on exec
+		Credential: &syscall.Credential{Uid: childUID, Gid: childGID},
+		Pdeathsig:  syscall.SIGKILL,
+	}
+	if err := cmd.Start(); err != nil {
+		local.Close()
+		return nil, nil, fmt.Errorf("starting pipeline process: %w", err)
+	}
+
+	log.Infof("running the event pipeline in process %d", cmd.Process.Pid)
+	return newClient(local), cmd.Wait, nil
+}
+
+// supervisor keeps the pipeline process running
+type supervisor struct {
+	cc    *containercollection.ContainerCollection
+	start func() (*client, func() error, error)
+
+	mu     sync.Mutex
+	client *client
+}
+
+// current returns the client of the running pipeline process, nil while it's
+// started again
+func (s *supervisor) current() *client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client
+}
+
+// use makes the runs started from now on use c, and gives it the containers
+func (s *supervisor) use(c *client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.client = c
+	if c == nil {
+		return
+	}
+	// The events of the containers wait for the lock, so the ones removed
+	// meanwhile are removed after being added
+	s.cc.ContainerRange(func(container *containercollection.Container) {
+		s.sendContainer(c, requestAddContainer, container)
+	})
+}
+
+// watch waits for the pipeline process to exit, which fails the runs using it,
+// and starts it again
+func (s *supervisor) watch(c *client, wait func() error) {
+	delay := minRestartDelay
+	for {
+		started := time.Now()
+		err := wait()
+		c.close(fmt.Errorf("pipeline process exited: %v", err))
+		log.Errorf("pipeline process exited: %v", err)
+		s.use(nil)
+
+		if time.Since(started) > maxRestartDelay {
+			delay = minRestartDelay
+		}
+		for {
+			time.Sleep(delay)
+			if delay *= 2; delay > maxRestartDelay {
+				delay = maxRestartDelay
+			}
+
+			c, wait, err = s.start()
+			if err == nil {
+				break
+			}
+			log.Errorf("starting the pipeline process again: %v", err)
+		}
+		s.use(c)
+	}
+}
+
+func (s *supervisor) handleContainerEvent(event containercollection.PubSubEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.client == nil {
+		return
+	}
+	switch event.Type {
+	case containercollection.EventTypeAddContainer:
+		s.sendContainer(s.client, requestAddContainer, event.Container)
+	case containercollection.EventTypeRemoveContainer:
+		s.sendContainer(s.client, requestRemoveContainer, event.Container)
+	}
+}
+
+func (s *supervisor) sendContainer(c *client, t requestType, container *containercollection.Container) {
+	// The OCI config is only used to select the containers, in the agent
+	copied := *container
+	copied.OciConfig = nil
+	if err := c.send(&request{Type: t, Container: &copied}); err != nil {
+		log.Debugf("sending container %q to the pipeline process: %v", container.ID, err)
+	}
+}
+
+// replaceOperators replaces the operators run by the pipeline process with
+// proxies, makes the KubeManager operator leave the enrichment of the events to
+// it and registers the PipelineProcess operator
+func (s *supervisor) replaceOperators() {
+	pipeline := &PipelineProcess{supervisor: s}
+	for _, op := range operators.GetAll() {
+		raw := operators.GetRaw(op.Name())
+		if !isPipelineOperator(raw) {
+			continue
+		}
+		operators.Replace(&proxy{Operator: raw, pipeline: pipeline})
+	}
+	if km, ok := operators.GetRaw(kubemanager.OperatorName).(*kubemanager.KubeManager); ok {
+		km.SetRemoteEnrichment(true)
+	}
+	operators.Register(pipeline)
+}
+
+// client sends the requests to the pipeline process and gets their replies
+type client struct {
+	conn io.Closer
+
+	writeMu sync.Mutex
+	enc     *gob.Encoder
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan *reply
+	err     error
+	done    chan struct{}
+}
+
+func newClient(conn io.ReadWriteCloser) *client {
+	c := &client{
+		conn:    conn,
+		enc:     gob.NewEncoder(conn),
+		pending: make(map[uint64]chan *reply),
+		done:    make(chan struct{}),
+	}
+	go c.read(conn)
+	return c
+}
+
+func (c *client) read(r io.Reader) {
+	dec := gob.NewDecoder(r)
+	for {
+		var rep reply
+		if err := dec.Decode(&rep); err != nil {
+			c.close(fmt.Errorf("reading reply: %w", err))
+			return
+		}
+
+		c.mu.Lock()
+		ch, ok := c.pending[rep.ID]
+		delete(c.pending, rep.ID)
+		c.mu.Unlock()
+		if ok {
+			ch <- &rep
+		}
+	}
+}
+
+// close makes the pending and the next requests fail with err
+func (c *client) close(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return
+	}
+	c.err = err
+	close(c.done)
+	c.conn.Close()
+}
+
+func (c *client) closed() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// send sends a request without waiting for a reply
+func (c *client) send(req *request) error {
+	if err := c.closed(); err != nil {
+		return err
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.enc.Encode(req); err != nil {
+		c.close(fmt.Errorf("writing request: %w", err))
+		return err
+	}
+	return nil
+}
+
+// call sends a request and waits for its reply. The error is only set when
+// the pipeline process can't be reached, the errors of the request are in the
+// reply.
+func (c *client) call(req *request) (*reply, error) {
+	ch := make(chan *reply, 1)
+
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.nextID++
+	req.ID = c.nextID
+	c.pending[req.ID] = ch
+	c.mu.Unlock()
+
+	if err := c.send(req); err != nil {
+		return nil, err
+	}
+
+	select {
+	case rep := <-ch:
+		return rep, nil
+	case <-c.done:
+		return nil, c.closed()
+	}
+}
@@ -0,0 +1,435 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipelineprocess
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators/kubemanager"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
+)
+
+// runContext gives access to the params of the run, it's implemented by
+// gadgetcontext.GadgetContext
+type runContext interface {
+	operators.GadgetContext
+	Operators() operators.Operators
+	OperatorsParamCollection() params.Collection
+	GadgetParams() *params.Params
+	Timeout() time.Duration
+}
+
+// PipelineProcess forwards the events of the runs to the pipeline process, once
+// the operators of the agent handled them, and gives back the enriched events.
+// It also makes the gadgets parse their raw samples there.
+type PipelineProcess struct {
+	supervisor *supervisor
+
+	mu   sync.Mutex
+	runs map[string]*PipelineProcessInstance
+}
+
+func (p *PipelineProcess) Name() string {
+	return OperatorName
+}
+
+func (p *PipelineProcess) Description() string {
+	return "PipelineProcess parses, enriches and exports the events in a process without privileges"
+}
+
+func (p *PipelineProcess) GlobalParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (p *PipelineProcess) ParamDescs() params.ParamDescs {
+	return nil
+}
+
+func (p *PipelineProcess) Dependencies() []string {
+	return nil
+}
+
+func (p *PipelineProcess) CanOperateOn(gadgets.GadgetDesc) bool {
+	return true
+}
+
+func (p *PipelineProcess) Init(*params.Params) error {
+	return nil
+}
+
+func (p *PipelineProcess) Close() error {
+	return nil
+}
+
+func (p *PipelineProcess) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, _ *params.Params) (operators.OperatorInstance, error) {
+	instance := &PipelineProcessInstance{
+		operator:  p,
+		gadgetCtx: gadgetCtx,
+		started:   make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	runCtx, ok := gadgetCtx.(runContext)
+	if !ok {
+		return instance, nil
+	}
+
+	desc := gadgetCtx.GadgetDesc()
+	start := &startRequest{
+		Category:     desc.Category(),
+		Gadget:       desc.Name(),
+		GadgetParams: make(map[string]string),
+		Timeout:      runCtx.Timeout(),
+	}
+	runCtx.GadgetParams().CopyToMap(start.GadgetParams, "")
+
+	for _, op := range runCtx.Operators() {
+		opParams := runCtx.OperatorsParamCollection()[op.Name()]
+		if op.Name() == kubemanager.OperatorName {
+			_, fromMntns := desc.EventPrototype().(operators.ContainerInfoFromMountNSID)
+			_, fromNetns := desc.EventPrototype().(operators.ContainerInfoFromNetNSID)
+			start.Containers = fromMntns || fromNetns
+			if opParams != nil {
+				start.Labels = opParams.Get(kubemanager.ParamLabels).AsStringSlice()
+			}
+		}
+		if !isPipelineOperator(op) {
+			continue
+		}
+		operator := operatorParams{Name: op.Name(), Params: make(map[string]string)}
+		if opParams != nil {
+			opParams.CopyToMap(operator.Params, "")
+		}
+		start.Operators = append(start.Operators, operator)
+	}
+
+	if setter, ok := gadgetInstance.(gadgets.SampleParserSetter); ok {
+		start.ParseSamples = true
+		setter.SetSampleParser(&remoteParser{instance: instance})
+	}
+
+	if len(start.Operators) > 0 || start.Containers || start.ParseSamples {
+		instance.start = start
+	}
+	return instance, nil
+}
+
+func (p *PipelineProcess) addRun(instance *PipelineProcessInstance) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.runs == nil {
+		p.runs = make(map[string]*PipelineProcessInstance)
+	}
+	p.runs[instance.gadgetCtx.ID()] = instance
+}
+
+func (p *PipelineProcess) removeRun(instance *PipelineProcessInstance) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.runs, instance.gadgetCtx.ID())
+}
+
+func (p *PipelineProcess) run(id string) *PipelineProcessInstance {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.runs[id]
+}
+
+type PipelineProcessInstance struct {
+	operator  *PipelineProcess
+	gadgetCtx operators.GadgetContext
+
+	// start is nil when the run doesn't need the pipeline process
+	start *startRequest
+
+	// client is set before started is closed, done is closed when the run
+	// is stopped or couldn't be started
+	client   *client
+	started  chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	failOnce sync.Once
+}
+
+func (i *PipelineProcessInstance) Name() string {
+	return "PipelineProcessInstance"
+}
+
+func (i *PipelineProcessInstance) PreGadgetRun() error {
+	if i.start == nil {
+		return nil
+	}
+
+	c := i.operator.supervisor.current()
+	if c == nil {
+		i.stop()
+		return errNotRunning
+	}
+	rep, err := c.call(&request{Type: requestStart, RunID: i.gadgetCtx.ID(), Start: i.start})
+	if err == nil && rep.Error != "" {
+		err = errors.New(rep.Error)
+	}
+	if err != nil {
+		i.stop()
+		return fmt.Errorf("starting the run in the pipeline process: %w", err)
+	}
+
+	i.operator.addRun(i)
+	i.client = c
+	close(i.started)
+	return nil
+}
+
+func (i *PipelineProcessInstance) PostGadgetRun() error {
+	if i.start == nil {
+		return nil
+	}
+
+	select {
+	case <-i.started:
+		i.operator.removeRun(i)
+		i.client.send(&request{Type: requestStop, RunID: i.gadgetCtx.ID()})
+	default:
+	}
+	i.stop()
+	return nil
+}
+
+func (i *PipelineProcessInstance) stop() {
+	i.stopOnce.Do(func() {
+		close(i.done)
+	})
+}
+
+// wait waits for the run to be started in the pipeline process and returns the
+// client to use, nil if the run is stopped
+func (i *PipelineProcessInstance) wait() *client {
+	select {
+	case <-i.started:
+	case <-i.done:
+		return nil
+	case <-i.gadgetCtx.Context().Done():
+		return nil
+	}
+	select {
+	case <-i.done:
+		return nil
+	default:
+		return i.client
+	}
+}
+
+// fail stops the gadget when the pipeline process can't be reached anymore, the
+// next runs use it once it's started again
+func (i *PipelineProcessInstance) fail(err error) {
+	i.failOnce.Do(func() {
+		i.gadgetCtx.Logger().Errorf("stopping the gadget: %v", err)
+		if canceler, ok := i.gadgetCtx.(interface{ Cancel() }); ok {
+			canceler.Cancel()
+		}
+	})
+}
+
+func (i *PipelineProcessInstance) EnrichEvent(ev any) error {
+	return nil
+}
+
+// SinkEvent forwards the event to the pipeline process, after the enrichers,
+// the filters and the classifiers of the agent, and replaces it with the
+// enriched one
+func (i *PipelineProcessInstance) SinkEvent(ev any) error {
+	if i.start == nil {
+		return nil
+	}
+
+	c := i.wait()
+	if c == nil {
+		return parser.ErrDropEvent
+	}
+	rep, err := c.call(&request{Type: requestEvent, RunID: i.gadgetCtx.ID(), Event: ev})
+	if err == nil && rep.Failed {
+		err = errors.New(rep.Error)
+	}
+	if err != nil {
+		i.fail(err)
+		return parser.ErrDropEvent
+	}
+	if rep.Error != "" {
+		return errors.New(rep.Error)
+	}
+	if rep.Dropped {
+		return parser.ErrDropEvent
+	}
+
+	dst := reflect.ValueOf(ev)
+	src := reflect.ValueOf(rep.Event)
+	if dst.Kind() != reflect.Pointer || src.Type() != dst.Type() {
+		return fmt.Errorf("unexpected event %T from the pipeline process", rep.Event)
+	}
+	dst.Elem().Set(src.Elem())
+	return nil
+}
+
+func (i *PipelineProcessInstance) SinkResult(result []byte) error {
+	if i.start == nil {
+		return nil
+	}
+
+	c := i.wait()
+	if c == nil {
+		return errNotRunning
+	}
+	return c.send(&request{Type: requestResult, RunID: i.gadgetCtx.ID(), Result: result})
+}
+
+// update changes the params of an operator of the run in the pipeline process
+func (i *PipelineProcessInstance) update(operator string, params *params.Params) error {
+	c := i.wait()
+	if c == nil {
+		return errNotRunning
+	}
+	req := &request{
+		Type:     requestUpdate,
+		RunID:    i.gadgetCtx.ID(),
+		Operator: operator,
+		Params:   make(map[string]string),
+	}
+	params.CopyToMap(req.Params, "")
+	rep, err := c.call(req)
+	if err == nil && rep.Failed {
+		err = errors.New(rep.Error)
+	}
+	if err != nil {
+		i.fail(err)
+		return err
+	}
+	if rep.Error != "" {
+		return errors.New(rep.Error)
+	}
+	return nil
+}
+
+// remoteParser parses the raw samples of a gadget in the pipeline process
+type remoteParser struct {
+	instance *PipelineProcessInstance
+}
+
+func (p *remoteParser) ParseSample(rawSample []byte, netns uint64) ([]any, error) {
+	c := p.instance.wait()
+	if c == nil {
+		return nil, nil
+	}
+	rep, err := c.call(&request{
+		Type:   requestSample,
+		RunID:  p.instance.gadgetCtx.ID(),
+		Sample: rawSample,
+		Netns:  netns,
+	})
+	if err == nil && rep.Failed {
+		err = errors.New(rep.Error)
+	}
+	if err != nil {
+		p.instance.fail(err)
+		return nil, nil
+	}
+	if rep.Error != "" {
+		return nil, errors.New(rep.Error)
+	}
+	return rep.Events, nil
+}
+
+func (p *remoteParser) DropNetns(netns uint64) {
+	select {
+	case <-p.instance.started:
+	default:
+		return
+	}
+	select {
+	case <-p.instance.done:
+		return
+	default:
+	}
+	p.instance.client.send(&request{Type: requestDropNetns, RunID: p.instance.gadgetCtx.ID(), Netns: netns})
+}
+
+// proxy replaces an operator run by the pipeline process in the agent. It's
+// initialized by the pipeline process, with the configuration it gets from the
+// environment.
+type proxy struct {
+	operators.Operator
+	pipeline *PipelineProcess
+}
+
+func (p *proxy) Init(params *params.Params) error {
+	return nil
+}
+
+func (p *proxy) Close() error {
+	return nil
+}
+
+func (p *proxy) Instantiate(gadgetCtx operators.GadgetContext, gadgetInstance any, params *params.Params) (operators.OperatorInstance, error) {
+	return &proxyInstance{
+		proxy:          p,
+		gadgetCtx:      gadgetCtx,
+		gadgetInstance: gadgetInstance,
+		params:         params,
+	}, nil
+}
+
+type proxyInstance struct {
+	proxy          *proxy
+	gadgetCtx      operators.GadgetContext
+	gadgetInstance any
+	params         *params.Params
+}
+
+func (i *proxyInstance) Name() string {
+	return "PipelineProxyInstance"
+}
+
+// PreGadgetRun lets the operators configure the gadget, which runs in the
+// agent, see operators.GadgetConfigurer
+func (i *proxyInstance) PreGadgetRun() error {
+	configurer, ok := i.proxy.Operator.(operators.GadgetConfigurer)
+	if !ok || i.gadgetInstance == nil {
+		return nil
+	}
+	return configurer.ConfigureGadget(i.gadgetCtx, i.gadgetInstance, i.params)
+}
+
+func (i *proxyInstance) PostGadgetRun() error {
+	return nil
+}
+
+func (i *proxyInstance) EnrichEvent(ev any) error {
+	return nil
+}
+
+// UpdateParams changes the params of the instance in the pipeline process,
+// which fails if the operator doesn't support it
+func (i *proxyInstance) UpdateParams(params *params.Params) error {
+	run := i.proxy.pipeline.run(i.gadgetCtx.ID())
+	if run == nil {
+		return fmt.Errorf("the params of operator %q can't be changed while the gadget is running", i.proxy.Name())
+	}
+	return run.update(i.proxy.Name(), params)
+}
@@ -0,0 +1,214 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pipelineprocess splits the agent into a privileged loader and a
+// process without privileges handling the events. It's opt-in, the agent only
+// starts this process when it's deployed with --pipeline-process.
+//
+// The agent keeps what needs the capabilities: loading and attaching the eBPF
+// programs, reading their records, tracking the containers and the operators
+// selecting them or reading the node, like the SBOM or systemd ones. The
+// pipeline process, running as nobody without capabilities, gets over a socket
+// pair:
+//   - the raw samples of the gadgets parsing them in user space, like the DNS,
+//     Kafka, Redis, SQL or gRPC ones (see gadgets.SampleParserSetter), and
+//     gives back the decoded events;
+//   - the events to enrich with their container, from a copy of the container
+//     collection of the agent, and with the operators marked with
+//     operators.UnprivilegedEnricher, e.g. the Kubernetes IP resolver;
+//   - the events and the results to give to the sink operators, the ones
+//     marked with operators.Sink.
+//
+// The records of the other gadgets have a fixed layout and are still copied
+// into their events in the agent. A bug in the protocol parsers, the enrichers
+// using the Kubernetes API or the sinks doesn't give access to the node anymore.
+//
+// In the agent, these operators are replaced by proxies with the same name and
+// params, so the gadgets still get them, and the PipelineProcess operator
+// forwards the events of each run to the pipeline process once the operators
+// of the agent handled them. The pipeline process initializes the real
+// operators from the environment and instantiates them for each run.
+//
+// The messages are encoded with encoding/gob: unlike JSON, it keeps the fields
+// of the events tagged with `json:"-"`, but not their unexported fields, which
+// are only used by the gadgets while decoding them.
+//
+// If the pipeline process exits, the runs using it are stopped with an error
+// and it's started again, with a growing delay, for the next runs.
+package pipelineprocess
+
+import (
+	"encoding/gob"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+)
+
+// The socket pair is the first file given to the pipeline process
+const childFD = 3
+
+// The user and group the pipeline process runs as, nobody
+const (
+	childUID = 65534
+	childGID = 65534
+)
+
+// removeDelay is how long the containers removed from the agent are kept in
+// the pipeline process, to enrich their last events, like the container
+// collection of the agent does
+const removeDelay = 2 * time.Second
+
+type requestType int
+
+const (
+	// requestAddContainer adds a container to the copy of the collection
+	requestAddContainer requestType = iota
+	// requestRemoveContainer removes a container from the copy of the
+	// collection
+	requestRemoveContainer
+	// requestStart instantiates the operators of a run, and the gadget if
+	// its samples are parsed
+	requestStart
+	// requestSample is a raw sample of a run, the reply has its events
+	requestSample
+	// requestDropNetns drops the state kept to parse the samples of a
+	// network namespace
+	requestDropNetns
+	// requestEvent is an event of a run, the reply has it enriched
+	requestEvent
+	// requestResult is the result of a run, given to its sinks
+	requestResult
+	// requestUpdate changes the params of an operator of a run
+	requestUpdate
+	// requestStop stops a run
+	requestStop
+)
+
+// request is sent by the agent to the pipeline process. The ones with an ID
+// get a reply with the same ID.
+type request struct {
+	Type  requestType
+	ID    uint64
+	RunID string
+
+	// Set for requestAddContainer and requestRemoveContainer
+	Container *containercollection.Container
+
+	// Set for requestStart
+	Start *startRequest
+
+	// Set for requestSample and requestDropNetns
+	Sample []byte
+	Netns  uint64
+
+	// Set for requestEvent
+	Event any
+
+	// Set for requestResult
+	Result []byte
+
+	// Set for requestUpdate
+	Operator string
+	Params   map[string]string
+}
+
+// startRequest describes a run of a gadget
+type startRequest struct {
+	Category     string
+	Gadget       string
+	GadgetParams map[string]string
+	Timeout      time.Duration
+
+	// Operators are the operators run by the pipeline process, in the
+	// order of the agent
+	Operators []operatorParams
+
+	// Containers is set when the events are enriched with their container,
+	// Labels are the labels of the pods to add to them
+	Containers bool
+	Labels     []string
+
+	// ParseSamples is set when the raw samples of the gadget are parsed by
+	// the pipeline process
+	ParseSamples bool
+}
+
+type operatorParams struct {
+	Name   string
+	Params map[string]string
+}
+
+type reply struct {
+	ID    uint64
+	Error string
+
+	// Failed is set when the run isn't running in the pipeline process
+	// anymore, e.g. after a panic of one of its operators
+	Failed bool
+
+	// Set for requestSample
+	Events []any
+
+	// Set for requestEvent, Dropped is set when one of the operators
+	// filtered it out
+	Event   any
+	Dropped bool
+}
+
+var registerOnce sync.Once
+
+// registerEventTypes registers the events of all the gadgets, to send them in
+// the interface fields of the messages
+func registerEventTypes() {
+	registerOnce.Do(func() {
+		descs := gadgetregistry.GetAll()
+		sort.Slice(descs, func(i, j int) bool {
+			if descs[i].Category() != descs[j].Category() {
+				return descs[i].Category() < descs[j].Category()
+			}
+			return descs[i].Name() < descs[j].Name()
+		})
+
+		registered := make(map[reflect.Type]struct{})
+		for _, desc := range descs {
+			prototype := desc.EventPrototype()
+			if prototype == nil {
+				continue
+			}
+			// Several gadgets can share the same events
+			if _, ok := registered[reflect.TypeOf(prototype)]; ok {
+				continue
+			}
+			registered[reflect.TypeOf(prototype)] = struct{}{}
+			gob.RegisterName(fmt.Sprintf("%s/%s", desc.Category(), desc.Name()), prototype)
+		}
+	})
+}
+
+// isPipelineOperator tells whether the operator is run by the pipeline process
+func isPipelineOperator(operator operators.Operator) bool {
+	operator = operators.Unwrap(operator)
+	if p, ok := operator.(*proxy); ok {
+		operator = p.Operator
+	}
+	_, isSink := operator.(operators.Sink)
+	_, isEnricher := operator.(operators.UnprivilegedEnricher)
+	return isSink || isEnricher
+}
@@ -0,0 +1,535 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipelineprocess
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
+	eventtypes "github.com/inspektor-gadget/inspektor-gadget/pkg/types"
+)
+
+type testEvent struct {
+	Comm string `json:"comm"`
+
+	// Enriched is set by the enricher, in the pipeline process
+	Enriched string `json:"-"`
+}
+
+type testGadget struct{}
+
+func (g *testGadget) Name() string                  { return "test" }
+func (g *testGadget) Description() string           { return "" }
+func (g *testGadget) Category() string              { return "trace" }
+func (g *testGadget) Type() gadgets.GadgetType      { return gadgets.TypeTrace }
+func (g *testGadget) ParamDescs() params.ParamDescs { return nil }
+func (g *testGadget) Parser() parser.Parser         { return nil }
+func (g *testGadget) EventPrototype() any           { return &testEvent{} }
+
+func (g *testGadget) NewInstance() (gadgets.Gadget, error) {
+	return &testTracer{}, nil
+}
+
+// testTracer parses the samples as the comm of an event, two events for
+// "double"
+type testTracer struct {
+	sampleParser gadgets.SampleParser
+	initialized  bool
+	dropped      []uint64
+}
+
+func (t *testTracer) Init(gadgets.GadgetContext) error {
+	t.initialized = true
+	return nil
+}
+
+func (t *testTracer) Close() {}
+
+func (t *testTracer) ParseSample(rawSample []byte, netns uint64) ([]any, error) {
+	if !t.initialized {
+		return nil, errors.New("not initialized")
+	}
+	switch comm := string(rawSample); comm {
+	case "panic":
+		panic("parsing sample")
+	case "double":
+		return []any{&testEvent{Comm: comm}, &testEvent{Comm: comm}}, nil
+	default:
+		return []any{&testEvent{Comm: comm}}, nil
+	}
+}
+
+func (t *testTracer) DropNetns(netns uint64) {
+	t.dropped = append(t.dropped, netns)
+}
+
+func (t *testTracer) SetSampleParser(parser gadgets.SampleParser) {
+	t.sampleParser = parser
+}
+
+type containerEvent struct {
+	eventtypes.CommonData
+	eventtypes.WithMountNsID
+}
+
+type containerGadget struct {
+	testGadget
+}
+
+func (g *containerGadget) Name() string        { return "test-containers" }
+func (g *containerGadget) EventPrototype() any { return &containerEvent{} }
+
+// testOperator is both a sink and an enricher, it records what its instances
+// get in the pipeline process
+type testOperator struct {
+	name string
+
+	mu      sync.Mutex
+	events  []string
+	results []string
+	stopped int
+}
+
+func (o *testOperator) Name() string                         { return o.name }
+func (o *testOperator) Description() string                  { return "" }
+func (o *testOperator) GlobalParamDescs() params.ParamDescs  { return nil }
+func (o *testOperator) Dependencies() []string               { return nil }
+func (o *testOperator) CanOperateOn(gadgets.GadgetDesc) bool { return true }
+func (o *testOperator) IsSink()                              {}
+func (o *testOperator) IsUnprivilegedEnricher()              {}
+func (o *testOperator) Init(*params.Params) error            { return nil }
+func (o *testOperator) Close() error                         { return nil }
+func (o *testOperator) PreGadgetRun() error                  { return nil }
+
+func (o *testOperator) ParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{Key: "value", DefaultValue: "enriched"},
+	}
+}
+
+func (o *testOperator) Instantiate(_ operators.GadgetContext, _ any, params *params.Params) (operators.OperatorInstance, error) {
+	return &testOperatorInstance{operator: o, value: params.Get("value").AsString()}, nil
+}
+
+type testOperatorInstance struct {
+	operator *testOperator
+	value    string
+}
+
+func (i *testOperatorInstance) Name() string        { return i.operator.name }
+func (i *testOperatorInstance) PreGadgetRun() error { return nil }
+
+func (i *testOperatorInstance) PostGadgetRun() error {
+	i.operator.mu.Lock()
+	defer i.operator.mu.Unlock()
+	i.operator.stopped++
+	return nil
+}
+
+func (i *testOperatorInstance) EnrichEvent(ev any) error {
+	if event, ok := ev.(*testEvent); ok {
+		event.Enriched = i.value
+	}
+	return nil
+}
+
+func (i *testOperatorInstance) FilterEvent(ev any) bool {
+	event, ok := ev.(*testEvent)
+	return !ok || event.Comm != "drop"
+}
+
+func (i *testOperatorInstance) SinkEvent(ev any) error {
+	if event, ok := ev.(*testEvent); ok {
+		i.operator.mu.Lock()
+		defer i.operator.mu.Unlock()
+		i.operator.events = append(i.operator.events, event.Comm)
+	}
+	return nil
+}
+
+func (i *testOperatorInstance) SinkResult(result []byte) error {
+	i.operator.mu.Lock()
+	defer i.operator.mu.Unlock()
+	i.operator.results = append(i.operator.results, string(result))
+	return nil
+}
+
+var testOp = &testOperator{name: "TestOperator"}
+
+func init() {
+	gadgetregistry.Register(&testGadget{})
+	gadgetregistry.Register(&containerGadget{})
+	operators.Register(testOp)
+}
+
+// testAgent is the agent side of the tests, the pipeline processes are served
+// by goroutines of the test
+type testAgent struct {
+	cc         *containercollection.ContainerCollection
+	supervisor *supervisor
+	operators  map[string]operators.Operator
+
+	mu      sync.Mutex
+	servers []*server
+	conns   []net.Conn
+}
+
+var (
+	agentOnce sync.Once
+	agent     *testAgent
+	agentErr  error
+)
+
+// getAgent sets up the agent once: the operators are replaced globally
+func getAgent(t *testing.T) *testAgent {
+	agentOnce.Do(func() {
+		registerEventTypes()
+
+		// Get the real operators before they are replaced
+		var s *server
+		s, agentErr = newServer("node")
+		if agentErr != nil {
+			return
+		}
+
+		agent = &testAgent{
+			cc:        &containercollection.ContainerCollection{},
+			operators: s.operators,
+		}
+		if agentErr = agent.cc.Initialize(containercollection.WithPubSub()); agentErr != nil {
+			return
+		}
+		agent.cc.AddContainer(&containercollection.Container{ID: "before", Mntns: 1})
+
+		agent.supervisor = &supervisor{cc: agent.cc, start: agent.start}
+		agent.cc.Subscribe(subscriptionKey, containercollection.ContainerSelector{}, agent.supervisor.handleContainerEvent)
+		c, wait, err := agent.start()
+		if agentErr = err; err != nil {
+			return
+		}
+		agent.supervisor.use(c)
+		go agent.supervisor.watch(c, wait)
+		agent.supervisor.replaceOperators()
+	})
+	require.NoError(t, agentErr)
+
+	// A previous test may have made the pipeline process exit
+	require.Eventually(t, func() bool {
+		return agent.supervisor.current() != nil
+	}, 5*maxRestartDelay, 10*time.Millisecond)
+	return agent
+}
+
+func (a *testAgent) start() (*client, func() error, error) {
+	s := &server{
+		operators: a.operators,
+		runs:      make(map[string]*run),
+	}
+	if err := s.cc.Initialize(containercollection.WithNodeName("node")); err != nil {
+		return nil, nil, err
+	}
+
+	local, remote := net.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.serve(remote)
+		remote.Close()
+	}()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.servers = append(a.servers, s)
+	a.conns = append(a.conns, remote)
+	return newClient(local), func() error { return <-done }, nil
+}
+
+// exit makes the running pipeline process exit
+func (a *testAgent) exit() *server {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.conns[len(a.conns)-1].Close()
+	return a.servers[len(a.servers)-1]
+}
+
+func (a *testAgent) lastServer() *server {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.servers[len(a.servers)-1]
+}
+
+// testRunContext is the context of a run in the agent
+type testRunContext struct {
+	*gadgetContext
+	ops      operators.Operators
+	opParams params.Collection
+}
+
+func (c *testRunContext) Operators() operators.Operators {
+	return c.ops
+}
+
+func (c *testRunContext) OperatorsParamCollection() params.Collection {
+	return c.opParams
+}
+
+func (c *testRunContext) Cancel() {
+	c.cancel()
+}
+
+type testRun struct {
+	ctx       *testRunContext
+	tracer    *testTracer
+	instances operators.OperatorInstances
+}
+
+func (a *testAgent) run(t *testing.T, id string, value string) *testRun {
+	desc := &testGadget{}
+	ops := operators.GetOperatorsForGadget(desc)
+	opParams := ops.ParamCollection()
+	require.NoError(t, opParams[testOp.name].Set("value", value))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	r := &testRun{
+		ctx: &testRunContext{
+			gadgetContext: &gadgetContext{
+				id:           id,
+				ctx:          ctx,
+				cancel:       cancel,
+				desc:         desc,
+				logger:       logger.DefaultLogger(),
+				gadgetParams: desc.ParamDescs().ToParams(),
+			},
+			ops:      ops,
+			opParams: opParams,
+		},
+		tracer: &testTracer{},
+	}
+
+	var err error
+	r.instances, err = ops.Instantiate(r.ctx, r.tracer, opParams)
+	require.NoError(t, err)
+	require.NoError(t, r.instances.PreGadgetRun())
+	return r
+}
+
+// events parses a sample and enriches its events like the gadget and the
+// parser do in the agent
+func (r *testRun) events(t *testing.T, sample string) []*testEvent {
+	require.NotNil(t, r.tracer.sampleParser)
+	parsed, err := r.tracer.sampleParser.ParseSample([]byte(sample), 0)
+	require.NoError(t, err)
+
+	events := []*testEvent{}
+	for _, ev := range parsed {
+		err := r.instances.Enrich(ev)
+		if errors.Is(err, parser.ErrDropEvent) {
+			continue
+		}
+		require.NoError(t, err)
+		events = append(events, ev.(*testEvent))
+	}
+	return events
+}
+
+func TestPipelineProcess(t *testing.T) {
+	a := getAgent(t)
+	require.IsType(t, &proxy{}, operators.GetRaw(testOp.name))
+	require.IsType(t, &PipelineProcess{}, operators.GetRaw(OperatorName))
+
+	testOp.mu.Lock()
+	testOp.events, testOp.results, testOp.stopped = nil, nil, 0
+	testOp.mu.Unlock()
+
+	r := a.run(t, "run", "enriched")
+	require.Equal(t, []*testEvent{{Comm: "cat", Enriched: "enriched"}}, r.events(t, "cat"))
+	require.Equal(t, []*testEvent{
+		{Comm: "double", Enriched: "enriched"},
+		{Comm: "double", Enriched: "enriched"},
+	}, r.events(t, "double"))
+	require.Empty(t, r.events(t, "drop"))
+	require.NoError(t, r.instances.SinkResult([]byte("result")))
+
+	// The params of the operator can't be changed while it's running
+	for _, instance := range r.instances {
+		if proxy, ok := instance.(*proxyInstance); ok {
+			require.Error(t, proxy.UpdateParams(testOp.ParamDescs().ToParams()))
+		}
+	}
+
+	r.tracer.sampleParser.DropNetns(42)
+	require.NoError(t, r.instances.PostGadgetRun())
+
+	// The stop request is handled after the previous ones
+	s := a.lastServer()
+	require.Eventually(t, func() bool {
+		testOp.mu.Lock()
+		defer testOp.mu.Unlock()
+		return testOp.stopped == 1
+	}, time.Second, 10*time.Millisecond)
+
+	testOp.mu.Lock()
+	defer testOp.mu.Unlock()
+	require.Equal(t, []string{"cat", "double", "double"}, testOp.events)
+	require.Equal(t, []string{"result"}, testOp.results)
+	require.Empty(t, s.runs)
+	require.NoError(t, r.ctx.ctx.Err())
+}
+
+func TestPipelineProcessPanic(t *testing.T) {
+	a := getAgent(t)
+
+	failed := a.run(t, "failed", "enriched")
+	other := a.run(t, "other", "other")
+
+	// The panic stops the run in the pipeline process and the gadget
+	parsed, err := failed.tracer.sampleParser.ParseSample([]byte("panic"), 0)
+	require.NoError(t, err)
+	require.Empty(t, parsed)
+	require.Error(t, failed.ctx.ctx.Err())
+
+	parsed, err = failed.tracer.sampleParser.ParseSample([]byte("cat"), 0)
+	require.NoError(t, err)
+	require.Empty(t, parsed)
+
+	// The other runs aren't affected
+	require.Equal(t, []*testEvent{{Comm: "cat", Enriched: "other"}}, other.events(t, "cat"))
+	require.NoError(t, other.ctx.ctx.Err())
+
+	require.NoError(t, failed.instances.PostGadgetRun())
+	require.NoError(t, other.instances.PostGadgetRun())
+}
+
+func TestPipelineProcessRestart(t *testing.T) {
+	a := getAgent(t)
+
+	r := a.run(t, "restarted", "enriched")
+	exited := a.exit()
+
+	// The runs using the pipeline process are stopped
+	parsed, err := r.tracer.sampleParser.ParseSample([]byte("cat"), 0)
+	require.NoError(t, err)
+	require.Empty(t, parsed)
+	require.Error(t, r.ctx.ctx.Err())
+	require.NoError(t, r.instances.PostGadgetRun())
+
+	// The container added meanwhile is given to the next one
+	a.cc.AddContainer(&containercollection.Container{ID: "meanwhile", Mntns: 2})
+	t.Cleanup(func() {
+		a.cc.RemoveContainer("meanwhile")
+	})
+
+	require.Eventually(t, func() bool {
+		return a.lastServer() != exited && a.supervisor.current() != nil
+	}, 5*maxRestartDelay, 10*time.Millisecond)
+
+	next := a.run(t, "next", "enriched")
+	require.Equal(t, []*testEvent{{Comm: "cat", Enriched: "enriched"}}, next.events(t, "cat"))
+	require.NoError(t, next.instances.PostGadgetRun())
+
+	s := a.lastServer()
+	for _, mntns := range []uint64{1, 2} {
+		require.NotNil(t, s.cc.LookupContainerByMntns(mntns), "mntns %d", mntns)
+	}
+}
+
+func TestPipelineProcessContainers(t *testing.T) {
+	getAgent(t)
+
+	s := &server{runs: make(map[string]*run)}
+	require.NoError(t, s.cc.Initialize(containercollection.WithNodeName("node")))
+
+	container := &containercollection.Container{
+		ID:        "container",
+		Mntns:     42,
+		Namespace: "default",
+		Podname:   "pod",
+		Name:      "name",
+	}
+	rep := s.handle(&request{Type: requestAddContainer, Container: container})
+	require.Empty(t, rep.Error)
+
+	rep = s.handle(&request{
+		Type:  requestStart,
+		RunID: "run",
+		Start: &startRequest{
+			Category:   "trace",
+			Gadget:     "test-containers",
+			Containers: true,
+		},
+	})
+	require.Empty(t, rep.Error)
+
+	table := []struct {
+		description string
+		mntns       uint64
+		expected    *containerEvent
+	}{
+		{
+			description: "container",
+			mntns:       42,
+			expected: &containerEvent{
+				CommonData: eventtypes.CommonData{
+					Node:       "node",
+					Namespace:  "default",
+					Pod:        "pod",
+					Container:  "name",
+					Generation: "container-0",
+				},
+				WithMountNsID: eventtypes.WithMountNsID{MountNsID: 42},
+			},
+		},
+		{
+			description: "host",
+			mntns:       1,
+			expected: &containerEvent{
+				CommonData:    eventtypes.CommonData{Node: "node"},
+				WithMountNsID: eventtypes.WithMountNsID{MountNsID: 1},
+			},
+		},
+	}
+
+	for _, entry := range table {
+		entry := entry
+		t.Run(entry.description, func(t *testing.T) {
+			rep := s.handle(&request{
+				Type:  requestEvent,
+				RunID: "run",
+				Event: &containerEvent{WithMountNsID: eventtypes.WithMountNsID{MountNsID: entry.mntns}},
+			})
+			require.Empty(t, rep.Error)
+			require.Equal(t, entry.expected, rep.Event)
+		})
+	}
+
+	rep = s.handle(&request{Type: requestStop, RunID: "run"})
+	require.Empty(t, rep.Error)
+
+	rep = s.handle(&request{Type: requestEvent, RunID: "run", Event: &containerEvent{}})
+	require.True(t, rep.Failed)
+}
@@ -0,0 +1,432 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipelineprocess
+
+import (
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	containercollection "github.com/inspektor-gadget/inspektor-gadget/pkg/container-collection"
+	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/logger"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
+)
+
+// Serve runs the event pipeline for the agent that started the process, until
+// the agent exits. nodeName is the node the events are enriched with.
+func Serve(nodeName string) error {
+	registerEventTypes()
+
+	f := os.NewFile(childFD, "pipeline-process")
+	if f == nil {
+		return errors.New("pipeline process socket not given")
+	}
+	defer f.Close()
+
+	s, err := newServer(nodeName)
+	if err != nil {
+		return err
+	}
+	defer s.close()
+
+	return s.serve(f)
+}
+
+// gadgetContext is the context the operators and the gadgets parsing the
+// samples are instantiated with: the one of the agent can't be used in this
+// process
+type gadgetContext struct {
+	id           string
+	ctx          context.Context
+	cancel       context.CancelFunc
+	desc         gadgets.GadgetDesc
+	logger       logger.Logger
+	gadgetParams *params.Params
+	timeout      time.Duration
+}
+
+func (c *gadgetContext) ID() string {
+	return c.id
+}
+
+func (c *gadgetContext) Context() context.Context {
+	return c.ctx
+}
+
+func (c *gadgetContext) GadgetDesc() gadgets.GadgetDesc {
+	return c.desc
+}
+
+func (c *gadgetContext) Logger() logger.Logger {
+	return c.logger
+}
+
+func (c *gadgetContext) GadgetParams() *params.Params {
+	return c.gadgetParams
+}
+
+func (c *gadgetContext) Timeout() time.Duration {
+	return c.timeout
+}
+
+type run struct {
+	gadgetCtx  *gadgetContext
+	instances  operators.OperatorInstances
+	byName     map[string]operators.OperatorInstance
+	containers bool
+	labels     []string
+
+	// sampleParser is the instance of the gadget parsing the samples
+	sampleParser gadgets.SampleParser
+	closeGadget  func()
+}
+
+// errRunFailed is wrapped by the errors of the runs not running anymore, the
+// agent then stops their gadget
+var errRunFailed = errors.New("run failed in the pipeline process")
+
+type server struct {
+	operators map[string]operators.Operator
+	runs      map[string]*run
+
+	// cc is a copy of the container collection of the agent
+	cc containercollection.ContainerCollection
+}
+
+// newServer initializes the operators run by the pipeline process, the sinks
+// are disabled unless they are configured with environment variables
+func newServer(nodeName string) (*server, error) {
+	s := &server{
+		operators: make(map[string]operators.Operator),
+		runs:      make(map[string]*run),
+	}
+	if err := s.cc.Initialize(containercollection.WithNodeName(nodeName)); err != nil {
+		return nil, fmt.Errorf("initializing container collection: %w", err)
+	}
+
+	ops := operators.Operators{}
+	for _, op := range operators.GetAll() {
+		if !isPipelineOperator(op) {
+			continue
+		}
+		ops = append(ops, op)
+		s.operators[op.Name()] = op
+	}
+	if err := ops.Init(operators.GlobalParamsCollection()); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *server) close() {
+	for id := range s.runs {
+		s.stop(id)
+	}
+	for _, op := range s.operators {
+		if err := op.Close(); err != nil {
+			log.Warnf("closing operator %q: %v", op.Name(), err)
+		}
+	}
+}
+
+func (s *server) serve(conn io.ReadWriter) error {
+	dec := gob.NewDecoder(conn)
+	enc := gob.NewEncoder(conn)
+	for {
+		var req request
+		if err := dec.Decode(&req); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("reading request: %w", err)
+		}
+
+		rep := s.handle(&req)
+		if req.ID == 0 {
+			if rep.Error != "" {
+				log.Warnf("pipeline process: %s", rep.Error)
+			}
+			continue
+		}
+		rep.ID = req.ID
+		if err := enc.Encode(rep); err != nil {
+			return fmt.Errorf("writing reply: %w", err)
+		}
+	}
+}
+
+// handle handles a request. A panic only stops the run it belongs to.
+func (s *server) handle(req *request) (rep *reply) {
+	rep = &reply{}
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("pipeline process: panic in run %q: %v\n%s", req.RunID, r, debug.Stack())
+			s.stop(req.RunID)
+			rep = &reply{Error: fmt.Sprintf("%s: panic: %v", errRunFailed, r), Failed: true}
+		}
+	}()
+
+	var err error
+	switch req.Type {
+	case requestAddContainer, requestRemoveContainer:
+		err = s.container(req)
+	case requestStart:
+		err = s.start(req.RunID, req.Start)
+	case requestSample:
+		rep.Events, err = s.sample(req)
+	case requestDropNetns:
+		err = s.dropNetns(req)
+	case requestEvent:
+		rep.Event, rep.Dropped, err = s.event(req)
+	case requestResult:
+		err = s.result(req)
+	case requestUpdate:
+		err = s.update(req)
+	case requestStop:
+		s.stop(req.RunID)
+	default:
+		err = fmt.Errorf("unknown request type %d", req.Type)
+	}
+	if err != nil {
+		rep.Error = err.Error()
+		rep.Failed = errors.Is(err, errRunFailed)
+	}
+	return rep
+}
+
+func (s *server) container(req *request) error {
+	if req.Container == nil {
+		return errors.New("container not given")
+	}
+	if req.Type == requestAddContainer {
+		s.cc.AddContainer(req.Container)
+		return nil
+	}
+	id := req.Container.ID
+	time.AfterFunc(removeDelay, func() {
+		s.cc.RemoveContainer(id)
+	})
+	return nil
+}
+
+func (s *server) run(id string) (*run, error) {
+	r, ok := s.runs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: run %q not found", errRunFailed, id)
+	}
+	return r, nil
+}
+
+func (s *server) start(id string, start *startRequest) error {
+	if start == nil {
+		return errors.New("run not described")
+	}
+	if _, ok := s.runs[id]; ok {
+		return fmt.Errorf("run %q already started", id)
+	}
+
+	desc := gadgetregistry.Get(start.Category, start.Gadget)
+	if desc == nil {
+		return fmt.Errorf("unknown gadget %s/%s", start.Category, start.Gadget)
+	}
+	gadgetParams := desc.ParamDescs().ToParams()
+	if err := gadgetParams.CopyFromMap(start.GadgetParams, ""); err != nil {
+		return fmt.Errorf("setting params of gadget: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &run{
+		gadgetCtx: &gadgetContext{
+			id:           id,
+			ctx:          ctx,
+			cancel:       cancel,
+			desc:         desc,
+			logger:       logger.DefaultLogger(),
+			gadgetParams: gadgetParams,
+			timeout:      start.Timeout,
+		},
+		byName:     make(map[string]operators.OperatorInstance),
+		containers: start.Containers,
+		labels:     start.Labels,
+	}
+
+	for _, op := range start.Operators {
+		operator, ok := s.operators[op.Name]
+		if !ok {
+			cancel()
+			return fmt.Errorf("unknown operator %q", op.Name)
+		}
+		params := operator.ParamDescs().ToParams()
+		if err := params.CopyFromMap(op.Params, ""); err != nil {
+			cancel()
+			return fmt.Errorf("setting params of %q: %w", op.Name, err)
+		}
+		instance, err := operator.Instantiate(r.gadgetCtx, nil, params)
+		if err != nil {
+			cancel()
+			return fmt.Errorf("instantiating %q: %w", op.Name, err)
+		}
+		r.instances = append(r.instances, instance)
+		r.byName[op.Name] = instance
+	}
+	if err := r.instances.PreGadgetRun(); err != nil {
+		cancel()
+		return err
+	}
+
+	if start.ParseSamples {
+		if err := r.newSampleParser(); err != nil {
+			r.instances.PostGadgetRun()
+			cancel()
+			return err
+		}
+	}
+
+	s.runs[id] = r
+	return nil
+}
+
+// newSampleParser creates and initializes an instance of the gadget, without
+// running it, to parse the samples of the run
+func (r *run) newSampleParser() error {
+	gadget, ok := r.gadgetCtx.desc.(gadgets.GadgetInstantiate)
+	if !ok {
+		return errors.New("gadget not instantiable")
+	}
+	instance, err := gadget.NewInstance()
+	if err != nil {
+		return fmt.Errorf("instantiating gadget: %w", err)
+	}
+	sampleParser, ok := instance.(gadgets.SampleParser)
+	if !ok {
+		return fmt.Errorf("gadget %q can't parse samples", r.gadgetCtx.desc.Name())
+	}
+	if initClose, ok := instance.(gadgets.InitCloseGadget); ok {
+		if err := initClose.Init(r.gadgetCtx); err != nil {
+			return fmt.Errorf("initializing gadget: %w", err)
+		}
+		r.closeGadget = initClose.Close
+	}
+	r.sampleParser = sampleParser
+	return nil
+}
+
+func (s *server) sample(req *request) ([]any, error) {
+	r, err := s.run(req.RunID)
+	if err != nil {
+		return nil, err
+	}
+	if r.sampleParser == nil {
+		return nil, fmt.Errorf("%w: samples of run %q not parsed", errRunFailed, req.RunID)
+	}
+	return r.sampleParser.ParseSample(req.Sample, req.Netns)
+}
+
+func (s *server) dropNetns(req *request) error {
+	r, err := s.run(req.RunID)
+	if err != nil {
+		return err
+	}
+	if r.sampleParser != nil {
+		r.sampleParser.DropNetns(req.Netns)
+	}
+	return nil
+}
+
+// event enriches the event with its container and gives it to the operators
+// of the run. It tells whether one of them dropped it.
+func (s *server) event(req *request) (any, bool, error) {
+	r, err := s.run(req.RunID)
+	if err != nil {
+		return nil, false, err
+	}
+	if req.Event == nil {
+		return nil, false, errors.New("event not given")
+	}
+
+	ev := req.Event
+	if r.containers {
+		s.cc.EnrichContainerInfo(ev, r.labels)
+	}
+	err = r.instances.Enrich(ev)
+	if errors.Is(err, parser.ErrDropEvent) {
+		return nil, true, nil
+	}
+	if err != nil {
+		// Like in the agent, the event isn't dropped
+		log.Warnf("pipeline process: %v", err)
+	}
+	return ev, false, nil
+}
+
+func (s *server) result(req *request) error {
+	r, err := s.run(req.RunID)
+	if err != nil {
+		return err
+	}
+	return r.instances.SinkResult(req.Result)
+}
+
+func (s *server) update(req *request) error {
+	r, err := s.run(req.RunID)
+	if err != nil {
+		return err
+	}
+	instance, ok := r.byName[req.Operator]
+	if !ok {
+		return fmt.Errorf("operator %q not running", req.Operator)
+	}
+	updater, ok := instance.(operators.ParamsUpdater)
+	if !ok {
+		return fmt.Errorf("the params of operator %q can't be changed while the gadget is running", req.Operator)
+	}
+	params := s.operators[req.Operator].ParamDescs().ToParams()
+	if err := params.CopyFromMap(req.Params, ""); err != nil {
+		return err
+	}
+	return updater.UpdateParams(params)
+}
+
+// stop stops the operators of a run and the gadget parsing its samples
+func (s *server) stop(id string) {
+	r, ok := s.runs[id]
+	if !ok {
+		return
+	}
+	delete(s.runs, id)
+	defer r.gadgetCtx.cancel()
+
+	// The run may be stopped after one of them panicked
+	defer func() {
+		if p := recover(); p != nil {
+			log.Errorf("pipeline process: panic stopping run %q: %v", id, p)
+		}
+	}()
+	r.instances.PostGadgetRun()
+	if r.closeGadget != nil {
+		r.closeGadget()
+	}
+}
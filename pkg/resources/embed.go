@@ -21,6 +21,9 @@ import (
 //go:embed crd/bases/gadget.kinvolk.io_traces.yaml
 var TracesCustomResource string
 
+//go:embed crd/bases/gadget.kinvolk.io_workloadprofiles.yaml
+var WorkloadProfilesCustomResource string
+
 //go:embed rbac/role.yaml
 var RbacRole string
 
@@ -0,0 +1,39 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcruntime
+
+import (
+	"time"
+)
+
+// maxClockSkew is the offset of the clock of a node above which the events
+// of different nodes can't be ordered anymore without compensating it
+const maxClockSkew = time.Second
+
+// timestampAdjuster is implemented by the events whose timestamp can be moved
+// to the clock of the client
+type timestampAdjuster interface {
+	AdjustTimestamp(offset time.Duration)
+}
+
+// estimateClockOffset estimates how far the clock of a node is ahead of the
+// local one from the local times a request was sent and its answer received,
+// and from the time of the node given in the answer. The node is assumed to
+// answer in the middle of the round trip, so the estimate can be wrong by up
+// to half of it, returned as the uncertainty.
+func estimateClockOffset(sent, received time.Time, nodeTime int64) (offset, uncertainty time.Duration) {
+	halfRTT := received.Sub(sent) / 2
+	return time.Unix(0, nodeTime).Sub(sent.Add(halfRTT)), halfRTT
+}
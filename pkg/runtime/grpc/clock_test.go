@@ -0,0 +1,38 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcruntime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateClockOffset(t *testing.T) {
+	sent := time.Unix(1700000000, 0)
+	received := sent.Add(40 * time.Millisecond)
+
+	// Node answering at the middle of the round trip with a clock 2s ahead
+	nodeTime := sent.Add(20*time.Millisecond + 2*time.Second).UnixNano()
+	offset, uncertainty := estimateClockOffset(sent, received, nodeTime)
+	require.Equal(t, 2*time.Second, offset)
+	require.Equal(t, 20*time.Millisecond, uncertainty)
+
+	// Node behind
+	nodeTime = sent.Add(20*time.Millisecond - 500*time.Millisecond).UnixNano()
+	offset, _ = estimateClockOffset(sent, received, nodeTime)
+	require.Equal(t, -500*time.Millisecond, offset)
+}
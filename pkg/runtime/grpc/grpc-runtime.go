@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -41,7 +42,8 @@ import (
 )
 
 const (
-	ParamNode = "node"
+	ParamNode             = "node"
+	ParamAdjustTimestamps = "adjust-timestamps"
 
 	// ConnectTimeout is the time in seconds we wait for a connection to the pod to
 	// succeed
@@ -50,6 +52,10 @@ const (
 	// ResultTimeout is the time in seconds we wait for a result to return from the gadget
 	// after sending a Stop command
 	ResultTimeout = 30
+
+	// updateQueueSize is the number of param updates waiting to be sent to a
+	// node
+	updateQueueSize = 16
 )
 
 type Runtime struct {
@@ -109,7 +115,7 @@ func (r *Runtime) Close() error {
 }
 
 func (r *Runtime) ParamDescs() params.ParamDescs {
-	return params.ParamDescs{
+	p := params.ParamDescs{
 		{
 			Key:         ParamNode,
 			Description: "Comma-separated list of nodes to run the gadget on",
@@ -125,7 +131,16 @@ func (r *Runtime) ParamDescs() params.ParamDescs {
 				return nil
 			},
 		},
+		{
+			Key: ParamAdjustTimestamps,
+			Description: "Move the timestamps of the events to the local clock, to compensate the differences " +
+				"between the clocks of the nodes",
+			DefaultValue: "false",
+			TypeHint:     params.TypeBool,
+		},
 	}
+	p.Add(runtime.ReorderParamDescs()...)
+	return p
 }
 
 func (r *Runtime) GlobalParamDescs() params.ParamDescs {
@@ -137,7 +152,7 @@ type gadgetPod struct {
 	node string
 }
 
-func getGadgetPods(ctx context.Context, nodes []string) ([]gadgetPod, error) {
+func newClientset() (*kubernetes.Clientset, error) {
 	config, err := utils.KubernetesConfigFlags.ToRESTConfig()
 	if err != nil {
 		return nil, fmt.Errorf("creating RESTConfig: %w", err)
@@ -147,6 +162,14 @@ func getGadgetPods(ctx context.Context, nodes []string) ([]gadgetPod, error) {
 	if err != nil {
 		return nil, fmt.Errorf("setting up trace client: %w", err)
 	}
+	return client, nil
+}
+
+func getGadgetPods(ctx context.Context, nodes []string) ([]gadgetPod, error) {
+	client, err := newClientset()
+	if err != nil {
+		return nil, err
+	}
 
 	opts := metav1.ListOptions{LabelSelector: "k8s-app=gadget"}
 	pods, err := client.CoreV1().Pods("gadget").List(ctx, opts)
@@ -207,6 +230,14 @@ func (r *Runtime) RunGadget(gadgetCtx runtime.GadgetContext) (runtime.CombinedGa
 		defer gadgetCtx.Parser().Flush()
 	}
 
+	// Events of different nodes come interleaved
+	defer runtime.EnableReorder(gadgetCtx)()
+
+	// Release the events deferred by the enrichers before the reorder buffer
+	if parser := gadgetCtx.Parser(); parser != nil {
+		defer parser.Stop()
+	}
+
 	results := make(runtime.CombinedGadgetResult)
 	var resultsLock sync.Mutex
 
@@ -219,12 +250,44 @@ func (r *Runtime) RunGadget(gadgetCtx runtime.GadgetContext) (runtime.CombinedGa
 		gadgetCtx.Logger().Debugf("- %s: %q", k, v)
 	}
 
+	// Params changed while the gadget is running are forwarded to all the
+	// nodes, each of them reports the errors with its logs. The nodes are
+	// removed once the gadget is done on them, nothing reads their updates
+	// anymore.
+	queues := make([]chan map[string]string, len(pods))
+	updates := make(map[string]chan map[string]string, len(pods))
+	var updatesLock sync.Mutex
+	for i, pod := range pods {
+		queues[i] = make(chan map[string]string, updateQueueSize)
+		updates[pod.node] = queues[i]
+	}
+	gadgetCtx.SetParamsUpdater(func(params map[string]string) error {
+		updatesLock.Lock()
+		defer updatesLock.Unlock()
+
+		// Check all the nodes first to send the update to all of them or
+		// none. The queues can only get shorter until it's sent.
+		for node, ch := range updates {
+			if len(ch) == cap(ch) {
+				return fmt.Errorf("too many pending updates on node %q", node)
+			}
+		}
+		for _, ch := range updates {
+			ch <- params
+		}
+		return nil
+	})
+	defer gadgetCtx.SetParamsUpdater(nil)
+
 	wg := sync.WaitGroup{}
-	for _, pod := range pods {
+	for i, pod := range pods {
 		wg.Add(1)
-		go func(pod gadgetPod) {
+		go func(pod gadgetPod, nodeUpdates <-chan map[string]string) {
 			gadgetCtx.Logger().Debugf("running gadget on node %q", pod.node)
-			res, err := r.runGadget(gadgetCtx, pod, allParams)
+			res, err := r.runGadget(gadgetCtx, pod, allParams, nodeUpdates)
+			updatesLock.Lock()
+			delete(updates, pod.node)
+			updatesLock.Unlock()
 			resultsLock.Lock()
 			results[pod.node] = &runtime.GadgetResult{
 				Payload: res,
@@ -232,14 +295,14 @@ func (r *Runtime) RunGadget(gadgetCtx runtime.GadgetContext) (runtime.CombinedGa
 			}
 			resultsLock.Unlock()
 			wg.Done()
-		}(pod)
+		}(pod, queues[i])
 	}
 
 	wg.Wait()
 	return results, results.Err()
 }
 
-func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, pod gadgetPod, allParams map[string]string) ([]byte, error) {
+func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, pod gadgetPod, allParams map[string]string, updates <-chan map[string]string) ([]byte, error) {
 	// Notice that we cannot use gadgetCtx.Context() here, as that would - when cancelled by the user - also cancel the
 	// underlying gRPC connection. That would then lead to results not being received anymore (mostly for profile
 	// gadgets.)
@@ -276,6 +339,7 @@ func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, pod gadgetPod, allP
 	}
 
 	controlRequest := &pb.GadgetControlRequest{Event: &pb.GadgetControlRequest_RunRequest{RunRequest: runRequest}}
+	sent := time.Now()
 	err = runClient.Send(controlRequest)
 	if err != nil {
 		return nil, err
@@ -283,6 +347,11 @@ func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, pod gadgetPod, allP
 
 	parser := gadgetCtx.Parser()
 
+	// The clock of the node is received before any event, in the same
+	// goroutine as them
+	var clockOffset time.Duration
+	adjustTimestamps := gadgetCtx.RuntimeParams().Get(ParamAdjustTimestamps).AsBool()
+
 	jsonHandler := func([]byte) {}
 	jsonArrayHandler := func([]byte) {}
 
@@ -295,6 +364,12 @@ func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, pod gadgetPod, allP
 				return nil
 			})
 		}
+		if _, ok := ev.(timestampAdjuster); ok && adjustTimestamps {
+			enrichers = append(enrichers, func(ev any) error {
+				ev.(timestampAdjuster).AdjustTimestamp(-clockOffset)
+				return nil
+			})
+		}
 
 		jsonHandler = parser.JSONHandlerFunc(enrichers...)
 		jsonArrayHandler = parser.JSONHandlerFuncArray(pod.node, enrichers...)
@@ -331,6 +406,18 @@ func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, pod gadgetPod, allP
 			case pb.EventTypeGadgetResult:
 				gadgetCtx.Logger().Debugf("%-20s | got result from server", pod.node)
 				result = ev.Payload
+			case pb.EventTypeGadgetClock:
+				nodeTime, err := strconv.ParseInt(string(ev.Payload), 10, 64)
+				if err != nil {
+					gadgetCtx.Logger().Warnf("%-20s | invalid node time %q", pod.node, ev.Payload)
+					continue
+				}
+				var uncertainty time.Duration
+				clockOffset, uncertainty = estimateClockOffset(sent, time.Now(), nodeTime)
+				gadgetCtx.Logger().Debugf("%-20s | clock offset %s (±%s)", pod.node, clockOffset, uncertainty)
+				if !adjustTimestamps && (clockOffset-uncertainty > maxClockSkew || clockOffset+uncertainty < -maxClockSkew) {
+					gadgetCtx.Logger().Warnf("%-20s | clock is off by %s, use --%s to compensate it", pod.node, clockOffset, ParamAdjustTimestamps)
+				}
 			case pb.EventTypeGadgetJobID: // not needed right now
 			default:
 				if ev.Type >= 1<<pb.EventLogShift {
@@ -343,26 +430,35 @@ func (r *Runtime) runGadget(gadgetCtx runtime.GadgetContext, pod gadgetPod, allP
 	}()
 
 	var runErr error
-	select {
-	case doneErr := <-doneChan:
-		gadgetCtx.Logger().Debugf("%-20s | done from server side (%v)", pod.node, doneErr)
-		runErr = doneErr
-	case <-gadgetCtx.Context().Done():
-		// Send stop request
-		gadgetCtx.Logger().Debugf("%-20s | sending stop request", pod.node)
-		controlRequest := &pb.GadgetControlRequest{Event: &pb.GadgetControlRequest_StopRequest{StopRequest: &pb.GadgetStopRequest{}}}
-		runClient.Send(controlRequest)
-
-		// Wait for done or timeout
+	for {
 		select {
 		case doneErr := <-doneChan:
-			gadgetCtx.Logger().Debugf("%-20s | done after cancel request (%v)", pod.node, doneErr)
+			gadgetCtx.Logger().Debugf("%-20s | done from server side (%v)", pod.node, doneErr)
 			runErr = doneErr
-		case <-time.After(ResultTimeout * time.Second):
-			return nil, fmt.Errorf("timed out while getting result")
+		case params := <-updates:
+			gadgetCtx.Logger().Debugf("%-20s | sending update request", pod.node)
+			controlRequest := &pb.GadgetControlRequest{Event: &pb.GadgetControlRequest_UpdateRequest{UpdateRequest: &pb.GadgetUpdateRequest{Params: params}}}
+			if err := runClient.Send(controlRequest); err != nil {
+				gadgetCtx.Logger().Warnf("%-20s | sending update request: %v", pod.node, err)
+			}
+			continue
+		case <-gadgetCtx.Context().Done():
+			// Send stop request
+			gadgetCtx.Logger().Debugf("%-20s | sending stop request", pod.node)
+			controlRequest := &pb.GadgetControlRequest{Event: &pb.GadgetControlRequest_StopRequest{StopRequest: &pb.GadgetStopRequest{}}}
+			runClient.Send(controlRequest)
+
+			// Wait for done or timeout
+			select {
+			case doneErr := <-doneChan:
+				gadgetCtx.Logger().Debugf("%-20s | done after cancel request (%v)", pod.node, doneErr)
+				runErr = doneErr
+			case <-time.After(ResultTimeout * time.Second):
+				return nil, fmt.Errorf("timed out while getting result")
+			}
 		}
+		return result, runErr
 	}
-	return result, runErr
 }
 
 func (r *Runtime) GetCatalog() (*runtime.Catalog, error) {
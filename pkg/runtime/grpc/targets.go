@@ -0,0 +1,130 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcruntime
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/runtime"
+)
+
+// Operator and params selecting the containers on the nodes, see the
+// kubemanager package; it's not imported to keep it out of the client
+const (
+	kubeManagerName         = "KubeManager"
+	kubeManagerContainer    = "containername"
+	kubeManagerSelector     = "selector"
+	kubeManagerNsSelector   = "namespace-selector"
+	kubeManagerPodName      = "podname"
+	kubeManagerAllNamespace = "all-namespaces"
+	kubeManagerNamespace    = "namespace"
+)
+
+// CountTargets returns the number of nodes the gadget would run on and of the
+// running containers it would trace there
+func (r *Runtime) CountTargets(gadgetCtx runtime.GadgetContext) (int, int, error) {
+	pods, err := getGadgetPods(gadgetCtx.Context(), gadgetCtx.RuntimeParams().Get(ParamNode).AsStringSlice())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	client, err := newClientset()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	nodes := make(map[string]struct{}, len(pods))
+	for _, pod := range pods {
+		nodes[pod.node] = struct{}{}
+	}
+
+	containers, err := countContainers(gadgetCtx.Context(), client, nodes,
+		gadgetCtx.OperatorsParamCollection()[kubeManagerName])
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(nodes), containers, nil
+}
+
+// countContainers returns the number of running containers on the given nodes
+// that are selected by the params of the KubeManager operator. Without these
+// params, e.g. for the gadgets not tracing containers, all of them are counted.
+func countContainers(ctx context.Context, client kubernetes.Interface, nodes map[string]struct{}, kubeManagerParams *params.Params) (int, error) {
+	value := func(key string) string {
+		if kubeManagerParams == nil {
+			return ""
+		}
+		if p := kubeManagerParams.Get(key); p != nil {
+			return p.AsString()
+		}
+		return ""
+	}
+
+	namespace := value(kubeManagerNamespace)
+	if value(kubeManagerAllNamespace) == "true" {
+		namespace = ""
+	}
+
+	// The selectors are lists of key=value, like the label selectors
+	var namespaces map[string]struct{}
+	if nsSelector := value(kubeManagerNsSelector); nsSelector != "" {
+		nsList, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: nsSelector})
+		if err != nil {
+			return 0, fmt.Errorf("listing namespaces: %w", err)
+		}
+		namespaces = make(map[string]struct{}, len(nsList.Items))
+		for _, ns := range nsList.Items {
+			namespaces[ns.Name] = struct{}{}
+		}
+	}
+
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: value(kubeManagerSelector)})
+	if err != nil {
+		return 0, fmt.Errorf("listing pods: %w", err)
+	}
+
+	podName := value(kubeManagerPodName)
+	containerName := value(kubeManagerContainer)
+
+	count := 0
+	for _, pod := range pods.Items {
+		if _, ok := nodes[pod.Spec.NodeName]; !ok {
+			continue
+		}
+		if namespaces != nil {
+			if _, ok := namespaces[pod.Namespace]; !ok {
+				continue
+			}
+		}
+		if podName != "" && pod.Name != podName {
+			continue
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Running == nil {
+				continue
+			}
+			if containerName != "" && status.Name != containerName {
+				continue
+			}
+			count++
+		}
+	}
+	return count, nil
+}
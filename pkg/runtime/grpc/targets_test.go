@@ -0,0 +1,136 @@
+// Copyright 2023 The Inspektor Gadget authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpcruntime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/params"
+)
+
+func testPod(namespace, name, node string, labels map[string]string, containers ...string) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+		Spec:       v1.PodSpec{NodeName: node},
+	}
+	for _, container := range containers {
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, v1.ContainerStatus{
+			Name:  container,
+			State: v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+		})
+	}
+	return pod
+}
+
+func TestCountContainers(t *testing.T) {
+	t.Parallel()
+
+	terminated := testPod("default", "job", "node-1", nil, "main")
+	terminated.Status.ContainerStatuses[0].State = v1.ContainerState{Terminated: &v1.ContainerStateTerminated{}}
+
+	objects := []runtime.Object{
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default", Labels: map[string]string{"team": "a"}}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "prod", Labels: map[string]string{"team": "b"}}},
+		testPod("default", "nginx", "node-1", map[string]string{"app": "nginx"}, "nginx", "istio-proxy"),
+		testPod("default", "redis", "node-2", map[string]string{"app": "redis"}, "redis"),
+		testPod("prod", "nginx", "node-1", map[string]string{"app": "nginx"}, "nginx"),
+		testPod("prod", "db", "node-3", map[string]string{"app": "db"}, "db"),
+		terminated,
+	}
+	allNodes := map[string]struct{}{"node-1": {}, "node-2": {}, "node-3": {}}
+
+	table := []struct {
+		description string
+		nodes       map[string]struct{}
+		params      map[string]string
+		expected    int
+	}{
+		{
+			description: "no_params",
+			nodes:       allNodes,
+			expected:    5,
+		},
+		{
+			description: "namespace",
+			nodes:       allNodes,
+			params:      map[string]string{kubeManagerNamespace: "default"},
+			expected:    3,
+		},
+		{
+			description: "all_namespaces",
+			nodes:       allNodes,
+			params:      map[string]string{kubeManagerNamespace: "default", kubeManagerAllNamespace: "true"},
+			expected:    5,
+		},
+		{
+			description: "node",
+			nodes:       map[string]struct{}{"node-1": {}},
+			params:      map[string]string{kubeManagerAllNamespace: "true"},
+			expected:    3,
+		},
+		{
+			description: "selector",
+			nodes:       allNodes,
+			params:      map[string]string{kubeManagerAllNamespace: "true", kubeManagerSelector: "app=nginx"},
+			expected:    3,
+		},
+		{
+			description: "namespace_selector",
+			nodes:       allNodes,
+			params:      map[string]string{kubeManagerAllNamespace: "true", kubeManagerNsSelector: "team=b"},
+			expected:    2,
+		},
+		{
+			description: "pod_and_container",
+			nodes:       allNodes,
+			params:      map[string]string{kubeManagerNamespace: "default", kubeManagerPodName: "nginx", kubeManagerContainer: "nginx"},
+			expected:    1,
+		},
+	}
+
+	for _, entry := range table {
+		entry := entry
+		t.Run(entry.description, func(t *testing.T) {
+			t.Parallel()
+
+			var kubeManagerParams *params.Params
+			if entry.params != nil {
+				kubeManagerParams = params.ParamDescs{
+					{Key: kubeManagerNamespace},
+					{Key: kubeManagerAllNamespace, TypeHint: params.TypeBool},
+					{Key: kubeManagerSelector},
+					{Key: kubeManagerNsSelector},
+					{Key: kubeManagerPodName},
+					{Key: kubeManagerContainer},
+				}.ToParams()
+				for key, value := range entry.params {
+					require.NoError(t, kubeManagerParams.Set(key, value))
+				}
+			}
+
+			client := fake.NewSimpleClientset(objects...)
+			count, err := countContainers(context.Background(), client, entry.nodes, kubeManagerParams)
+			require.NoError(t, err)
+			require.Equal(t, entry.expected, count)
+		})
+	}
+}
@@ -19,9 +19,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 
 	"github.com/cilium/ebpf"
 
+	"github.com/inspektor-gadget/inspektor-gadget/pkg/bpfconflicts"
 	gadgetregistry "github.com/inspektor-gadget/inspektor-gadget/pkg/gadget-registry"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/gadgets"
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/operators"
@@ -71,7 +74,7 @@ func (r *Runtime) GlobalParamDescs() params.ParamDescs {
 }
 
 func (r *Runtime) ParamDescs() params.ParamDescs {
-	return nil
+	return runtime.ReorderParamDescs()
 }
 
 func (r *Runtime) RunGadget(gadgetCtx runtime.GadgetContext) (runtime.CombinedGadgetResult, error) {
@@ -92,6 +95,12 @@ func (r *Runtime) RunGadget(gadgetCtx runtime.GadgetContext) (runtime.CombinedGa
 		return nil, fmt.Errorf("instantiating gadget: %w", err)
 	}
 
+	// Other agents using eBPF can make the gadget fail or miss events, warn
+	// the user before it's initialized
+	for _, conflict := range bpfconflicts.Detect() {
+		log.Warnf("possible conflict with another agent: %s", conflict)
+	}
+
 	// Initialize gadgets, if needed
 	if initClose, ok := gadgetInstance.(gadgets.InitCloseGadget); ok {
 		log.Debugf("calling gadget.Init()")
@@ -112,10 +121,23 @@ func (r *Runtime) RunGadget(gadgetCtx runtime.GadgetContext) (runtime.CombinedGa
 	}
 	log.Debugf("found %d operators", len(gadgetCtx.Operators()))
 
+	// Events of different CPUs can come interleaved
+	defer runtime.EnableReorder(gadgetCtx)()
+
+	// Release the events deferred by the enrichers before the reorder buffer
+	if parser := gadgetCtx.Parser(); parser != nil {
+		defer parser.Stop()
+	}
+
 	// Set event handler
 	if setter, ok := gadgetInstance.(gadgets.EventHandlerSetter); ok {
 		log.Debugf("set event handler")
 		setter.SetEventHandler(gadgetCtx.Parser().EventHandlerFunc(operatorInstances.Enrich))
+
+		// The events of the operators aren't enriched, they are about the
+		// gadget itself or already carry their container
+		operatorInstances.SetEventEmitter(newEventEmitter(gadgetCtx.GadgetDesc().EventPrototype(),
+			gadgetCtx.Parser().EventHandlerFunc()))
 	}
 
 	// Set event handler for array results
@@ -140,6 +162,11 @@ func (r *Runtime) RunGadget(gadgetCtx runtime.GadgetContext) (runtime.CombinedGa
 		operatorInstances.PostGadgetRun()
 	}()
 
+	gadgetCtx.SetParamsUpdater(func(params map[string]string) error {
+		return updateParams(gadgetCtx, gadgetInstance, operatorInstances, params)
+	})
+	defer gadgetCtx.SetParamsUpdater(nil)
+
 	if run, ok := gadgetInstance.(gadgets.RunGadget); ok {
 		log.Debugf("calling gadget.Run()")
 		err := run.Run(gadgetCtx)
@@ -158,11 +185,150 @@ func (r *Runtime) RunGadget(gadgetCtx runtime.GadgetContext) (runtime.CombinedGa
 		if err != nil {
 			return nil, fmt.Errorf("running (with result) gadget: %w", err)
 		}
+		if err := operatorInstances.SinkResult(out); err != nil {
+			log.Warnf("%s", err)
+		}
 		return runtime.CombinedGadgetResult{"": &runtime.GadgetResult{Payload: out}}, nil
 	}
 	return nil, errors.New("gadget not runnable")
 }
 
+// newEventEmitter returns the function given to the operators to emit events of
+// the type of prototype with handler, see operators.EventEmitter
+func newEventEmitter(prototype any, handler any) func(init func(ev any)) {
+	eventType := reflect.TypeOf(prototype)
+	if eventType == nil || eventType.Kind() != reflect.Pointer {
+		return nil
+	}
+	eventType = eventType.Elem()
+	handlerValue := reflect.ValueOf(handler)
+	return func(init func(ev any)) {
+		ev := reflect.New(eventType)
+		init(ev.Interface())
+		handlerValue.Call([]reflect.Value{ev})
+	}
+}
+
+// paramsUpdate is a gadget or an operator whose params are being changed
+type paramsUpdate struct {
+	name    string
+	updater interface{ UpdateParams(*params.Params) error }
+	current *params.Params
+	updated *params.Params
+}
+
+// updateParams changes the params of the running gadget and of its operators.
+// The gadget and the operators with changed params must support it, this is
+// checked before changing anything. If one of them fails to apply its params,
+// the ones already updated are restored to their previous params.
+func updateParams(
+	gadgetCtx runtime.GadgetContext,
+	gadgetInstance any,
+	operatorInstances operators.OperatorInstances,
+	update map[string]string,
+) error {
+	gadgetParams := gadgetCtx.GadgetParams().Copy()
+	operatorsParams := gadgetCtx.OperatorsParamCollection().Copy()
+
+	for key := range update {
+		if !strings.HasPrefix(key, "operator.") {
+			if gadgetParams.Get(key) == nil {
+				return fmt.Errorf("unknown param %q", key)
+			}
+			continue
+		}
+		operatorName, paramKey, _ := strings.Cut(strings.TrimPrefix(key, "operator."), ".")
+		if params, ok := operatorsParams[operatorName]; !ok || params.Get(paramKey) == nil {
+			return fmt.Errorf("unknown param %q", key)
+		}
+	}
+
+	if err := gadgetParams.CopyFromMap(update, ""); err != nil {
+		return fmt.Errorf("setting gadget params: %w", err)
+	}
+	if err := operatorsParams.CopyFromMap(update, "operator."); err != nil {
+		return fmt.Errorf("setting operator params: %w", err)
+	}
+
+	var updates []paramsUpdate
+	if paramsChanged(gadgetCtx.GadgetParams(), gadgetParams) {
+		updater, ok := gadgetInstance.(gadgets.ParamsUpdater)
+		if !ok {
+			return errors.New("the params of the gadget can't be changed while it's running")
+		}
+		updates = append(updates, paramsUpdate{
+			name:    "gadget",
+			updater: updater,
+			current: gadgetCtx.GadgetParams().Copy(),
+			updated: gadgetParams,
+		})
+	}
+
+	for i, operator := range gadgetCtx.Operators() {
+		name := operator.Name()
+		current := gadgetCtx.OperatorsParamCollection()[name]
+		if !paramsChanged(current, operatorsParams[name]) {
+			continue
+		}
+		updater, ok := operatorInstances[i].(operators.ParamsUpdater)
+		if !ok {
+			return fmt.Errorf("the params of operator %q can't be changed while the gadget is running", name)
+		}
+		updates = append(updates, paramsUpdate{
+			name:    fmt.Sprintf("operator %q", name),
+			updater: updater,
+			current: current.Copy(),
+			updated: operatorsParams[name],
+		})
+	}
+
+	for i, u := range updates {
+		if err := u.updater.UpdateParams(u.updated); err != nil {
+			// The failed one didn't change anything, restore the previous ones
+			for j := i - 1; j >= 0; j-- {
+				if rerr := updates[j].updater.UpdateParams(updates[j].current); rerr != nil {
+					gadgetCtx.Logger().Warnf("restoring the params of %s: %v", updates[j].name, rerr)
+				}
+			}
+			return fmt.Errorf("updating %s: %w", u.name, err)
+		}
+	}
+
+	// Keep the current values in the gadget context
+	gadgetCtx.GadgetParams().CopyFromMap(update, "")
+	gadgetCtx.OperatorsParamCollection().CopyFromMap(update, "operator.")
+	return nil
+}
+
+func paramsChanged(current, updated *params.Params) bool {
+	if current == nil || updated == nil {
+		return false
+	}
+	for _, param := range *updated {
+		if current.Get(param.Key).String() != param.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// CountTargets returns the local node and the number of containers the
+// gadget would trace, as counted by its operators
+func (r *Runtime) CountTargets(gadgetCtx runtime.GadgetContext) (int, int, error) {
+	for _, operator := range gadgetCtx.Operators() {
+		counter, ok := operators.Unwrap(operator).(operators.ContainerCounter)
+		if !ok {
+			continue
+		}
+		containers, err := counter.CountContainers(gadgetCtx.OperatorsParamCollection()[operator.Name()])
+		if err != nil {
+			return 0, 0, fmt.Errorf("counting containers: %w", err)
+		}
+		return 1, containers, nil
+	}
+	return 0, 0, errors.New("the gadget doesn't trace containers")
+}
+
 func (r *Runtime) GetCatalog() (*runtime.Catalog, error) {
 	return r.catalog, nil
 }
@@ -25,6 +25,35 @@ import (
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/parser"
 )
 
+// ParamReorderDelay is the param of the runtimes emitting the events of trace
+// gadgets in timestamp order
+const ParamReorderDelay = "reorder-delay"
+
+func ReorderParamDescs() params.ParamDescs {
+	return params.ParamDescs{
+		{
+			Key: ParamReorderDelay,
+			Description: "Hold the events for the given duration, e.g. 500ms, to emit them in timestamp order " +
+				"when they come interleaved from several nodes or CPUs",
+			DefaultValue: "0",
+			TypeHint:     params.TypeDuration,
+		},
+	}
+}
+
+// EnableReorder enables the reorder buffer of the parser of trace gadgets
+// when requested with ParamReorderDelay. The returned function releases the
+// events still held, it has to be called once the gadget is done.
+func EnableReorder(gadgetCtx GadgetContext) func() {
+	param := gadgetCtx.RuntimeParams().Get(ParamReorderDelay)
+	if param == nil || param.AsDuration() <= 0 || gadgetCtx.Parser() == nil ||
+		gadgetCtx.GadgetDesc().Type() != gadgets.TypeTrace {
+		return func() {}
+	}
+	gadgetCtx.Parser().EnableReorder(param.AsDuration())
+	return gadgetCtx.Parser().Flush
+}
+
 type GadgetContext interface {
 	ID() string
 	Parser() parser.Parser
@@ -36,6 +65,10 @@ type GadgetContext interface {
 	GadgetParams() *params.Params
 	OperatorsParamCollection() params.Collection
 	Timeout() time.Duration
+
+	// SetParamsUpdater registers the function changing the params of the
+	// running gadget, see gadgetcontext.GadgetContext.UpdateParams
+	SetParamsUpdater(func(map[string]string) error)
 }
 
 // GadgetResult contains the (optional) payload and error of a gadget run for a node
@@ -90,3 +123,10 @@ type Runtime interface {
 	SetDefaultValue(params.ValueHint, string)
 	GetDefaultValue(params.ValueHint) (string, bool)
 }
+
+// TargetCounter is implemented by the runtimes that can tell how many nodes
+// and containers a gadget would trace according to its params, without
+// running it. The operators of the gadget context must be initialized.
+type TargetCounter interface {
+	CountTargets(gadgetCtx GadgetContext) (nodes int, containers int, err error)
+}
@@ -133,6 +133,46 @@ func (tc *TracerCollection) AddTracer(id string, containerSelector containercoll
 	return nil
 }
 
+// UpdateTracer changes the container selector of a tracer. The mount namespace
+// map of the tracer is kept and updated in place, so the gadgets using it don't
+// need to be restarted.
+func (tc *TracerCollection) UpdateTracer(id string, containerSelector containercollection.ContainerSelector) error {
+	t, ok := tc.tracers[id]
+	if !ok {
+		return fmt.Errorf("unknown tracer %q", id)
+	}
+	t.containerSelector = containerSelector
+	tc.tracers[id] = t
+
+	if tc.testOnly {
+		return nil
+	}
+
+	tc.containerCollection.ContainerRange(func(c *containercollection.Container) {
+		mntnsC := uint64(c.Mntns)
+		if mntnsC == 0 {
+			return
+		}
+		if containercollection.ContainerSelectorMatches(&containerSelector, c) {
+			one := uint32(1)
+			t.mntnsSetMap.Put(mntnsC, one)
+		} else {
+			t.mntnsSetMap.Delete(mntnsC)
+		}
+	})
+	return nil
+}
+
+// TracerSelects tells whether the current container selector of a tracer
+// matches the container
+func (tc *TracerCollection) TracerSelects(id string, c *containercollection.Container) bool {
+	t, ok := tc.tracers[id]
+	if !ok {
+		return false
+	}
+	return containercollection.ContainerSelectorMatches(&t.containerSelector, c)
+}
+
 func (tc *TracerCollection) RemoveTracer(id string) error {
 	if id == "" {
 		return fmt.Errorf("container id not set")
@@ -17,6 +17,8 @@ package types
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/inspektor-gadget/inspektor-gadget/pkg/columns"
@@ -77,6 +79,12 @@ type CommonData struct {
 	// Node where the event comes from
 	Node string `json:"node,omitempty" column:"node,template:node" columnTags:"kubernetes"`
 
+	// Zone and region of the node and its kernel version, added with
+	// --node-metadata
+	NodeZone      string `json:"nodeZone,omitempty" column:"zone,width:16,hide" columnTags:"kubernetes"`
+	NodeRegion    string `json:"nodeRegion,omitempty" column:"region,width:16,hide" columnTags:"kubernetes"`
+	KernelVersion string `json:"kernelVersion,omitempty" column:"kernel,width:20,hide"`
+
 	// Pod namespace where the event comes from, or empty for host-level
 	// event
 	Namespace string `json:"namespace,omitempty" column:"namespace,template:namespace" columnTags:"kubernetes"`
@@ -87,12 +95,50 @@ type CommonData struct {
 	// Container where the event comes from, or empty for host-level or
 	// pod-level event
 	Container string `json:"container,omitempty" column:"container,template:container" columnTags:"kubernetes,runtime"`
+
+	// Workload managing the pod where the event comes from, like a
+	// Deployment, to group the events of its pods
+	WorkloadKind string `json:"workloadKind,omitempty" column:"workloadkind,width:12,hide" columnTags:"kubernetes"`
+	Workload     string `json:"workload,omitempty" column:"workload,width:30,hide" columnTags:"kubernetes"`
+
+	// Registry and digest of the image of the container where the event
+	// comes from, to tell where the running code was pulled from
+	ImageRegistry string `json:"imageRegistry,omitempty" column:"registry,width:20,hide" columnTags:"kubernetes,runtime"`
+	ImageDigest   string `json:"imageDigest,omitempty" column:"imagedigest,width:20,hide" columnTags:"kubernetes,runtime"`
+
+	// Labels of the pod where the event comes from, or of its namespace,
+	// selected with --labels as comma-separated key=value pairs
+	Labels string `json:"labels,omitempty" column:"labels,width:30,hide" columnTags:"kubernetes"`
+
+	// Sandbox the container runs in, like "kata" or "gvisor", or empty when
+	// it runs on the kernel of the host. The events of sandboxed containers
+	// are the ones of the sandbox on the host, e.g. the hypervisor.
+	Sandbox string `json:"sandbox,omitempty" column:"sandbox,width:8,hide" columnTags:"kubernetes,runtime"`
+
+	// Host is true when the event comes from a process of the host, i.e. not
+	// running in a container
+	Host bool `json:"host,omitempty" column:"host,width:4,hide" columnTags:"kubernetes,runtime"`
+
+	// Component of the control plane where the event comes from, like
+	// "kubelet" or "kube-apiserver", running on the host or in a static pod
+	Component string `json:"component,omitempty" column:"component,width:16,hide" columnTags:"kubernetes,runtime"`
+
+	// Restart count and generation of the container where the event comes
+	// from, to tell apart the events of its different runs
+	RestartCount uint32 `json:"restartCount,omitempty" column:"restarts,width:8,hide" columnTags:"kubernetes,runtime"`
+	Generation   string `json:"generation,omitempty" column:"generation,width:16,hide" columnTags:"kubernetes,runtime"`
 }
 
 func (c *CommonData) SetNode(node string) {
 	c.Node = node
 }
 
+func (c *CommonData) SetNodeMetadata(zone, region, kernelVersion string) {
+	c.NodeZone = zone
+	c.NodeRegion = region
+	c.KernelVersion = kernelVersion
+}
+
 func (c *CommonData) SetContainerInfo(pod, namespace, container string) {
 	c.Pod = pod
 	c.Namespace = namespace
@@ -104,6 +150,37 @@ func (c *CommonData) SetContainerInfo(pod, namespace, container string) {
 	}
 }
 
+func (c *CommonData) SetWorkload(kind, name string) {
+	c.WorkloadKind = kind
+	c.Workload = name
+}
+
+func (c *CommonData) SetImageInfo(registry, digest string) {
+	c.ImageRegistry = registry
+	c.ImageDigest = digest
+}
+
+func (c *CommonData) SetLabels(labels string) {
+	c.Labels = labels
+}
+
+func (c *CommonData) SetSandbox(sandbox string) {
+	c.Sandbox = sandbox
+}
+
+func (c *CommonData) SetHost(host bool) {
+	c.Host = host
+}
+
+func (c *CommonData) SetComponent(component string) {
+	c.Component = component
+}
+
+func (c *CommonData) SetGeneration(restartCount uint32, generation string) {
+	c.RestartCount = restartCount
+	c.Generation = generation
+}
+
 func (c *CommonData) GetNode() string {
 	return c.Node
 }
@@ -160,6 +237,10 @@ type Event struct {
 
 	// Message when Type is ERR, WARN, DEBUG or INFO
 	Message string `json:"message,omitempty"`
+
+	// Severity is the severity of a NORMAL event given by the classifiers,
+	// empty when it wasn't classified
+	Severity Severity `json:"severity,omitempty" column:"severity,width:8,hide"`
 }
 
 // GetBaseEvent is needed to implement commonutils.BaseElement and
@@ -176,6 +257,84 @@ func (e *Event) GetMessage() string {
 	return e.Message
 }
 
+func (e *Event) GetTimestamp() Time {
+	return e.Timestamp
+}
+
+// AdjustTimestamp moves the timestamp of the event by the given offset, e.g.
+// to compensate the difference between the clock of the node it comes from
+// and the one of the client. Events without timestamp are left as is.
+func (e *Event) AdjustTimestamp(offset time.Duration) {
+	if e.Timestamp != 0 {
+		e.Timestamp += Time(offset)
+	}
+}
+
+func (e *Event) GetSeverity() Severity {
+	return e.Severity
+}
+
+func (e *Event) SetSeverity(severity Severity) {
+	e.Severity = severity
+}
+
+// SetMessage turns the event into a message of the given type, like the events
+// returned by Warn() or Info()
+func (e *Event) SetMessage(typ EventType, msg string) {
+	e.Node = node
+	e.Type = typ
+	e.Message = msg
+}
+
+// Severity tells how much attention an event deserves. It's used by the
+// output, to color the events, and by the sinks, to only forward the events
+// above a given severity.
+type Severity string
+
+const (
+	SeverityInfo  Severity = "info"
+	SeverityWarn  Severity = "warn"
+	SeverityAlert Severity = "alert"
+)
+
+var severityLevels = map[Severity]int{
+	SeverityInfo:  0,
+	SeverityWarn:  1,
+	SeverityAlert: 2,
+}
+
+func ParseSeverity(s string) (Severity, error) {
+	severity := Severity(s)
+	if _, ok := severityLevels[severity]; !ok {
+		return "", fmt.Errorf("invalid severity %q: expected %s, %s or %s",
+			s, SeverityInfo, SeverityWarn, SeverityAlert)
+	}
+	return severity, nil
+}
+
+// AtLeast tells whether the severity is the given one or a higher one. An
+// empty severity is the same as SeverityInfo.
+func (s Severity) AtLeast(min Severity) bool {
+	return severityLevels[s] >= severityLevels[min]
+}
+
+type SeverityGetter interface {
+	GetSeverity() Severity
+}
+
+type SeveritySetter interface {
+	SetSeverity(Severity)
+}
+
+// EventSeverity returns the severity of an event, SeverityInfo if it wasn't
+// classified
+func EventSeverity(ev any) Severity {
+	if getter, ok := ev.(SeverityGetter); ok && getter.GetSeverity() != "" {
+		return getter.GetSeverity()
+	}
+	return SeverityInfo
+}
+
 func Err(msg string) Event {
 	return Event{
 		CommonData: CommonData{
@@ -232,6 +391,17 @@ func (e *WithMountNsID) GetMountNSID() uint64 {
 	return e.MountNsID
 }
 
+// WithContainerUid is embedded by the events with the UID of the process,
+// which is the one of the host, to also give it as seen in the user namespace
+// of the container
+type WithContainerUid struct {
+	ContainerUid uint32 `json:"containerUid" column:"containeruid,template:uid,hide"`
+}
+
+func (e *WithContainerUid) SetContainerUid(uid uint32) {
+	e.ContainerUid = uid
+}
+
 type WithNetNsID struct {
 	NetNsID uint64 `json:"netnsid,omitempty" column:"netns,template:ns"`
 }
@@ -239,3 +409,129 @@ type WithNetNsID struct {
 func (e *WithNetNsID) GetNetNSID() uint64 {
 	return e.NetNsID
 }
+
+// WithKubeAudit holds the details of the Kubernetes session (exec, attach or
+// port-forward) an event was observed in, as reported by the audit log.
+type WithKubeAudit struct {
+	KubeUser   string `json:"kubeUser,omitempty" column:"kubeuser,hide"`
+	KubeAction string `json:"kubeAction,omitempty" column:"kubeaction,maxWidth:11,hide"`
+}
+
+func (e *WithKubeAudit) SetKubeAuditDetails(user, action string) {
+	e.KubeUser = user
+	e.KubeAction = action
+}
+
+// WithSystemdUnit holds the systemd unit of the process an event comes from,
+// e.g. kubelet.service, as given by its cgroup. It's only set when the unit
+// column is requested or the events are filtered by unit.
+type WithSystemdUnit struct {
+	SystemdUnit string `json:"systemdUnit,omitempty" column:"unit,width:24,hide" columnTags:"param:systemd-unit-column"`
+}
+
+func (e *WithSystemdUnit) SetSystemdUnit(unit string) {
+	e.SystemdUnit = unit
+}
+
+// WithSbomPackage holds the package of the SBOM of the container image an
+// executed binary belongs to.
+type WithSbomPackage struct {
+	// SbomStatus is SbomListed or SbomUnlisted, or empty when the SBOM of
+	// the image isn't known
+	SbomStatus     string `json:"sbomStatus,omitempty" column:"sbom,width:8,hide"`
+	Package        string `json:"package,omitempty" column:"package,width:20,hide"`
+	PackageVersion string `json:"packageVersion,omitempty" column:"pkgversion,width:12,hide"`
+}
+
+const (
+	// SbomListed is the SbomStatus of the binaries found in the SBOM
+	SbomListed = "listed"
+	// SbomUnlisted is the SbomStatus of the binaries not found in the SBOM
+	SbomUnlisted = "unlisted"
+)
+
+func (e *WithSbomPackage) SetSbomPackage(status, name, version string) {
+	e.SbomStatus = status
+	e.Package = name
+	e.PackageVersion = version
+}
+
+func (e *WithSbomPackage) GetSbomPackage() (name, version string) {
+	return e.Package, e.PackageVersion
+}
+
+// WithVulnerabilities holds the known vulnerabilities of the package of the
+// SBOM an executed binary belongs to.
+type WithVulnerabilities struct {
+	// Vulnerabilities is the comma-separated list of the vulnerabilities,
+	// the most severe first
+	Vulnerabilities string `json:"vulnerabilities,omitempty" column:"vulnerabilities,width:30,hide"`
+	// VulnerabilitySeverity is the severity of the most severe one, e.g.
+	// critical
+	VulnerabilitySeverity string `json:"vulnerabilitySeverity,omitempty" column:"vulnseverity,width:8,hide"`
+}
+
+func (e *WithVulnerabilities) SetVulnerabilities(vulnerabilities, severity string) {
+	e.Vulnerabilities = vulnerabilities
+	e.VulnerabilitySeverity = severity
+}
+
+// Joined holds the fields of the events of other gadgets joined to an event,
+// like the DNS name of its remote address. The keys are prefixed by the name
+// of the source they come from, e.g. "dns.name".
+type Joined map[string]string
+
+func (j Joined) String() string {
+	keys := make([]string, 0, len(j))
+	for key := range j {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	fields := make([]string, 0, len(keys))
+	for _, key := range keys {
+		fields = append(fields, key+"="+j[key])
+	}
+	return strings.Join(fields, ",")
+}
+
+// WithJoin holds the fields joined to an event by the Join operator
+type WithJoin struct {
+	Joined Joined `json:"joined,omitempty" column:"joined,width:40,hide,stringer"`
+}
+
+func (e *WithJoin) SetJoined(key, value string) {
+	if e.Joined == nil {
+		e.Joined = make(Joined)
+	}
+	e.Joined[key] = value
+}
+
+type SpanKind int
+
+// The kinds of spans have the values of OpenTelemetry
+const (
+	SpanKindServer SpanKind = 2
+	SpanKindClient SpanKind = 3
+)
+
+// Span is a request observed by a gadget, like a DNS query and its response.
+// The events of these gadgets implement GetSpan() to be exported as
+// OpenTelemetry spans.
+type Span struct {
+	Name  string
+	Kind  SpanKind
+	Start time.Time
+	End   time.Time
+
+	// TraceParent is the W3C traceparent header of the request, when the
+	// protocol carries it, to add the span to the trace of the caller
+	TraceParent string
+
+	// Attributes are strings, integers or booleans, named after the semantic
+	// conventions of OpenTelemetry
+	Attributes map[string]any
+
+	// Error is the reason the request failed, empty if it succeeded
+	Error string
+}